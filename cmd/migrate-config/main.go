@@ -0,0 +1,97 @@
+// Command migrate-config upgrades configs/models.json and configs/credentials.json from older
+// layouts to the current schema. With -dry-run it prints the changes as a diff instead of
+// writing them; otherwise each changed file is saved next to a .bak copy of the original
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/migrate"
+)
+
+func main() {
+	modelsPath := flag.String("models", "configs/models.json", "models.json to migrate")
+	credentialsPath := flag.String("credentials", "configs/credentials.json", "credentials.json to migrate (skipped when missing)")
+	dryRun := flag.Bool("dry-run", false, "Print the changes as a diff without writing them")
+	list := flag.Bool("list", false, "List the migrations and exit")
+	flag.Parse()
+
+	if *list {
+		for _, file := range []migrate.File{migrate.Models, migrate.Credentials} {
+			for _, migration := range file.Migrations {
+				fmt.Printf("%-18s %-20s %s\n", file.Name, migration.Name, migration.Description)
+			}
+		}
+		return
+	}
+
+	failed := false
+	for _, target := range []struct {
+		path     string
+		file     migrate.File
+		optional bool
+	}{
+		{*modelsPath, migrate.Models, false},
+		{*credentialsPath, migrate.Credentials, true},
+	} {
+		if err := migrateFile(target.path, target.file, target.optional, *dryRun); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// migrateFile migrates one file, printing what was or would be changed
+func migrateFile(path string, file migrate.File, optional, dryRun bool) error {
+	original, err := os.ReadFile(filepath.Clean(path))
+	if optional && errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "%s: not found, skipped\n", path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	result, err := file.Migrate(original)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if !result.Changed() {
+		fmt.Fprintf(os.Stderr, "%s: up to date\n", path)
+		return nil
+	}
+	applied := strings.Join(result.Applied, ", ")
+	if dryRun {
+		fmt.Print(result.Diff(path, original))
+		fmt.Fprintf(os.Stderr, "%s: would apply %s\n", path, applied)
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if err := os.WriteFile(path+".bak", original, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	// Writing to a temporary file and renaming it leaves the original intact if writing fails
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, result.Output, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	fmt.Fprintf(os.Stderr, "%s: applied %s (original saved to %s.bak)\n", path, applied, path)
+	return nil
+}
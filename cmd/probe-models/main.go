@@ -0,0 +1,62 @@
+// Command probe-models queries each configured vendor for its available models and
+// writes a starter models.json with detected capabilities and context windows
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/probe"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+func main() {
+	modelsPath := flag.String("models", "configs/models.json", "Existing models.json to read vendor base URLs from (optional)")
+	outputPath := flag.String("output", "", "File to write the generated models.json to (default stdout)")
+	active := flag.Bool("active", false, "Send minimal tool-calling and streaming requests to confirm capabilities (incurs small vendor costs)")
+	timeout := flag.Duration("timeout", 30*time.Second, "Timeout for each vendor request")
+	flag.Parse()
+
+	// Credentials are loaded the same way as the server: environment first, then configs/credentials.json
+	_ = utils.LoadEnvFile()
+	creds, err := config.LoadCredentialsSecurely()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load credentials: %v\n", err)
+		os.Exit(1)
+	}
+
+	vendors := map[string]string{}
+	if existing, err := config.LoadModelsConfig(*modelsPath); err == nil {
+		vendors = existing.Vendors
+	}
+
+	prober := probe.NewProber(*timeout, *active)
+	generated, errs := prober.Generate(context.Background(), creds, vendors)
+	for _, probeErr := range errs {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", probeErr)
+	}
+
+	output, err := json.MarshalIndent(generated, "", "    ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode models config: %v\n", err)
+		os.Exit(1)
+	}
+	output = append(output, '\n')
+
+	if *outputPath == "" {
+		os.Stdout.Write(output)
+	} else if err := os.WriteFile(*outputPath, output, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write %s: %v\n", *outputPath, err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "probed %d vendor(s), found %d chat model(s)\n", len(generated.Vendors), len(generated.Models))
+	if len(generated.Models) == 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,89 @@
+// Command route-scenarios evaluates a YAML file of routing scenarios against a models.json,
+// client ACL and tools file, reporting every scenario whose routing outcome differs from
+// the one it expects
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/scenario"
+	"github.com/aashari/go-generative-api-router/internal/tools"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+func main() {
+	_ = utils.LoadEnvFile()
+	scenariosPath := flag.String("scenarios", "configs/scenarios.yaml", "Scenario file to evaluate")
+	modelsPath := flag.String("models", "configs/models.json", "models.json to route with")
+	aclPath := flag.String("acl", os.Getenv("CLIENT_ACL_FILE"), "Client ACL file (optional)")
+	toolsPath := flag.String("tools", os.Getenv("TOOLS_FILE"), "Server-side tools file (optional)")
+	verbose := flag.Bool("v", false, "Print the pool and applied policies of every scenario")
+	flag.Parse()
+
+	scenarios, err := scenario.Load(*scenariosPath)
+	if err != nil {
+		fail("%v", err)
+	}
+
+	modelsConfig, err := config.LoadModelsConfig(*modelsPath)
+	if err != nil {
+		fail("failed to load %s: %v", *modelsPath, err)
+	}
+	if validationErr := config.ValidateVendorModels(modelsConfig.Models); validationErr != nil {
+		fail("invalid %s: %s", *modelsPath, validationErr.Message)
+	}
+
+	env := scenario.Environment{Models: modelsConfig.Models}
+	if *aclPath != "" {
+		if env.ACL, err = access.LoadACL(*aclPath); err != nil {
+			fail("%v", err)
+		}
+	}
+	// Server-side tools are offered to chat requests and so affect capability filtering
+	if *toolsPath != "" {
+		registry, err := tools.LoadRegistry(*toolsPath)
+		if err != nil {
+			fail("%v", err)
+		}
+		tools.SetDefault(registry)
+	}
+
+	failed := 0
+	for _, result := range scenario.Run(scenarios, env) {
+		if result.Passed() {
+			fmt.Printf("PASS  %s\n", result.Name)
+		} else {
+			failed++
+			fmt.Printf("FAIL  %s\n", result.Name)
+			for _, failure := range result.Failures {
+				fmt.Printf("      %s\n", failure)
+			}
+		}
+		if *verbose && result.Plan != nil {
+			if result.Plan.Status != 0 {
+				fmt.Printf("      rejected: %d %s\n", result.Plan.Status, result.Plan.Error)
+			} else {
+				fmt.Printf("      pool: %s\n", strings.Join(result.Plan.Pool, ", "))
+			}
+			for _, step := range result.Plan.Steps {
+				fmt.Printf("      %s removed: %s\n", step.Policy, strings.Join(step.Removed, ", "))
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "%d scenario(s), %d failed\n", len(scenarios), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// fail prints the error and exits
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/aashari/go-generative-api-router/internal/app"
+	"github.com/aashari/go-generative-api-router/internal/listener"
 	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/router"
 	"github.com/aashari/go-generative-api-router/internal/utils"
 )
 
@@ -47,26 +50,50 @@ func main() {
 
 	// Initialize logger
 	logger.InitFromEnv()
+	defer logger.Close()
 
 	// Create a new application instance
 	appInstance, err := app.NewApp()
 	if err != nil {
 		logger.Error(context.Background(), "Failed to initialize application", err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	// Setup router
 	r := appInstance.SetupRoutes()
 
-	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8082"
+	// Start server on TCP, a Unix domain socket, or both
+	listenerConfig, err := listener.ConfigFromEnv()
+	if err != nil {
+		logger.Error(context.Background(), "Invalid listener configuration", err)
+		exit(1)
+	}
+	listeners, err := listener.Open(listenerConfig)
+	if err != nil {
+		logger.Error(context.Background(), "Failed to start server", err)
+		exit(1)
 	}
 
-	logger.Info(context.Background(), "Starting server", "port", port)
-	if err := http.ListenAndServe(":"+port, r); err != nil {
+	// Serve Prometheus metrics on their own port when METRICS_PORT is set
+	if port := router.MetricsPort(); port != 0 {
+		metricsAddr := ":" + strconv.Itoa(port)
+		go func() {
+			if err := http.ListenAndServe(metricsAddr, appInstance.SetupMetricsRoutes()); err != nil {
+				logger.Error(context.Background(), "Metrics server stopped", err, "address", metricsAddr)
+			}
+		}()
+		logger.Info(context.Background(), "Starting metrics server", "address", metricsAddr)
+	}
+
+	logger.Info(context.Background(), "Starting server", "listeners", listener.Addresses(listeners))
+	if err := listener.Serve(&http.Server{Handler: r}, listeners); err != nil {
 		logger.Error(context.Background(), "Failed to start server", err)
-		os.Exit(1)
+		exit(1)
 	}
 }
+
+// exit flushes the log sinks, which deferred calls would not get to, and exits
+func exit(code int) {
+	logger.Close()
+	os.Exit(code)
+}
@@ -0,0 +1,205 @@
+// Package access holds the routing permissions granted to client API keys
+package access
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// hashPrefix marks ACL keys given as the SHA-256 hex digest of the client key
+const hashPrefix = "sha256:"
+
+// Policy is the routing access granted to a client key
+// Empty allow-lists permit every vendor or model
+type Policy struct {
+	// AllowedVendors limits routing to these vendors
+	AllowedVendors []string `json:"allowed_vendors,omitempty"`
+	// AllowedModels limits routing to these models, given as "model" or "vendor/model"
+	AllowedModels []string `json:"allowed_models,omitempty"`
+	// DenyExclusions rejects requests that set router.exclude_vendors or router.exclude_models
+	DenyExclusions bool `json:"deny_exclusions,omitempty"`
+	// DenyVendorQuery rejects requests that force routing to a vendor with the ?vendor= query parameter
+	DenyVendorQuery bool `json:"deny_vendor_query,omitempty"`
+	// DenyRoutingHeaders rejects requests that pin or prefer a vendor, model or credential
+	// with the X-Force-* and X-Preferred-* headers
+	DenyRoutingHeaders bool `json:"deny_routing_headers,omitempty"`
+	// Extensions lists the response extensions emitted for this key, overriding RESPONSE_EXTENSIONS
+	// Unset uses the deployment setting; an empty list emits none
+	Extensions []string `json:"extensions,omitempty"`
+	// Reasoning sets how reasoning models' chain of thought is returned to this key (field,
+	// strip, extensions or inline), overriding REASONING_POLICY
+	Reasoning string `json:"reasoning,omitempty"`
+	// RouterHeaders lists the X-Router-* routing metadata headers sent to this key (vendor,
+	// model, attempts, cache, queue_wait), overriding ROUTER_HEADERS
+	// Unset uses the deployment setting; an empty list sends none
+	RouterHeaders []string `json:"router_headers,omitempty"`
+	// RequestsPerMinute caps the completion requests this key sends per minute; 0 is unlimited
+	RequestsPerMinute int `json:"requests_per_minute,omitempty"`
+	// UserRequestsPerMinute caps the completion requests each end user of this key, named by
+	// the OpenAI "user" field, sends per minute, within RequestsPerMinute; 0 is unlimited
+	UserRequestsPerMinute int `json:"user_requests_per_minute,omitempty"`
+}
+
+// ACL maps client keys to policies, falling back to a default policy for unknown keys
+type ACL struct {
+	defaultPolicy Policy
+	keys          map[string]Policy // SHA-256 hex digest of the client key -> policy
+}
+
+// aclFile is the on-disk ACL format
+type aclFile struct {
+	Default Policy            `json:"default"`
+	Keys    map[string]Policy `json:"keys"`
+}
+
+var (
+	defaultACL   = NewACL(Policy{}, nil)
+	defaultACLMu sync.RWMutex
+)
+
+// NewACL creates an ACL; keys are raw client keys or "sha256:" followed by their hex digest
+func NewACL(defaultPolicy Policy, keys map[string]Policy) *ACL {
+	acl := &ACL{defaultPolicy: defaultPolicy, keys: make(map[string]Policy, len(keys))}
+	for key, policy := range keys {
+		if digest, ok := strings.CutPrefix(key, hashPrefix); ok {
+			acl.keys[strings.ToLower(digest)] = policy
+			continue
+		}
+		acl.keys[hashKey(key)] = policy
+	}
+	return acl
+}
+
+// LoadACL reads an ACL from a JSON file with "default" and "keys" policies
+func LoadACL(path string) (*ACL, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ACL file: %w", err)
+	}
+	var file aclFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid ACL file: %w", err)
+	}
+	return NewACL(file.Default, file.Keys), nil
+}
+
+// LoadACLFromEnv loads the ACL named by CLIENT_ACL_FILE, or returns an unrestricted ACL when unset
+func LoadACLFromEnv() (*ACL, error) {
+	path := utils.GetEnvString("CLIENT_ACL_FILE", "")
+	if path == "" {
+		return NewACL(Policy{}, nil), nil
+	}
+	return LoadACL(path)
+}
+
+// Default returns the process-wide ACL, unrestricted until SetDefault is called
+func Default() *ACL {
+	defaultACLMu.RLock()
+	defer defaultACLMu.RUnlock()
+	return defaultACL
+}
+
+// SetDefault replaces the process-wide ACL
+func SetDefault(acl *ACL) {
+	defaultACLMu.Lock()
+	defer defaultACLMu.Unlock()
+	defaultACL = acl
+}
+
+// Policy returns the policy for a client key
+func (a *ACL) Policy(clientKey string) Policy {
+	if policy, ok := a.keys[hashKey(clientKey)]; ok && clientKey != "" {
+		return policy
+	}
+	return a.defaultPolicy
+}
+
+// PolicyFor returns the policy for the request's bearer token, or the policy of its
+// authenticated client identity when that sets one
+func (a *ACL) PolicyFor(r *http.Request) Policy {
+	if identity := IdentityFromContext(r.Context()); identity != nil && identity.Policy != nil {
+		return *identity.Policy
+	}
+	return a.Policy(ClientKey(r))
+}
+
+// Permits reports whether the policy allows routing to the vendor/model pair
+func (p Policy) Permits(vendor, model string) bool {
+	if len(p.AllowedVendors) > 0 && !containsFold(p.AllowedVendors, vendor) {
+		return false
+	}
+	if len(p.AllowedModels) == 0 {
+		return true
+	}
+	for _, entry := range p.AllowedModels {
+		if MatchesModel(entry, vendor, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesModel reports whether a model entry ("model" or "vendor/model") names the vendor/model pair
+func MatchesModel(entry, vendor, model string) bool {
+	if entryVendor, entryModel, ok := strings.Cut(entry, "/"); ok && strings.EqualFold(entryVendor, vendor) {
+		return entryModel == model
+	}
+	return entry == model
+}
+
+// ClientKey returns the bearer token sent by the client, or "" when there is none
+func ClientKey(r *http.Request) string {
+	header := r.Header.Get(utils.HeaderAuthorization)
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// KeyHint returns a non-reversible hint of the client's bearer token (last 4 characters)
+func KeyHint(r *http.Request) string {
+	token := ClientKey(r)
+	if token == "" {
+		return ""
+	}
+	if len(token) <= 4 {
+		return "****"
+	}
+	return "..." + token[len(token)-4:]
+}
+
+// KeyID returns the SHA-256 hex digest identifying the client's bearer token, or "" when
+// there is none
+func KeyID(r *http.Request) string {
+	token := ClientKey(r)
+	if token == "" {
+		return ""
+	}
+	return hashKey(token)
+}
+
+// hashKey returns the SHA-256 hex digest used to look up a client key
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package access
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACL_Policy(t *testing.T) {
+	eu := Policy{AllowedVendors: []string{"mistral"}}
+	acl := NewACL(Policy{DenyExclusions: true}, map[string]Policy{
+		"sk-client-eu":                          eu,
+		"sha256:" + hashKey("sk-client-hashed"): {AllowedModels: []string{"gpt-4o"}},
+	})
+
+	req := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	req.Header.Set("Authorization", "Bearer sk-client-eu")
+	assert.Equal(t, eu, acl.PolicyFor(req))
+
+	assert.Equal(t, []string{"gpt-4o"}, acl.Policy("sk-client-hashed").AllowedModels)
+	assert.True(t, acl.Policy("sk-unknown").DenyExclusions, "unknown keys get the default policy")
+	assert.True(t, acl.Policy("").DenyExclusions)
+}
+
+func TestPolicy_Permits(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		vendor   string
+		model    string
+		expected bool
+	}{
+		{"unrestricted", Policy{}, "openai", "gpt-4o", true},
+		{"allowed vendor", Policy{AllowedVendors: []string{"OpenAI"}}, "openai", "gpt-4o", true},
+		{"disallowed vendor", Policy{AllowedVendors: []string{"gemini"}}, "openai", "gpt-4o", false},
+		{"allowed model any vendor", Policy{AllowedModels: []string{"gpt-4o"}}, "openai", "gpt-4o", true},
+		{"allowed vendor/model", Policy{AllowedModels: []string{"openai/gpt-4o"}}, "openai", "gpt-4o", true},
+		{"other vendor's model", Policy{AllowedModels: []string{"azure/gpt-4o"}}, "openai", "gpt-4o", false},
+		{"model with slash", Policy{AllowedModels: []string{"meta-llama/llama-3"}}, "openrouter", "meta-llama/llama-3", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.Permits(tt.vendor, tt.model))
+		})
+	}
+}
+
+func TestLoadACL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acl.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"default": {"allowed_vendors": ["openai"]},
+		"keys": {"sk-client-1": {"deny_exclusions": true}}
+	}`), 0o600))
+
+	acl, err := LoadACL(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"openai"}, acl.Policy("anyone").AllowedVendors)
+	assert.True(t, acl.Policy("sk-client-1").DenyExclusions)
+
+	_, err = LoadACL(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestKeyHint(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"no key", "", ""},
+		{"not a bearer token", "Basic abc", ""},
+		{"short key", "Bearer abc", "****"},
+		{"key", "Bearer sk-test-1234", "...1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/models", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			assert.Equal(t, tt.expected, KeyHint(r))
+		})
+	}
+}
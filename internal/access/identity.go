@@ -0,0 +1,60 @@
+package access
+
+import (
+	"context"
+	"net/http"
+)
+
+// identityKey is the context key of the authenticated client identity
+type identityKey struct{}
+
+// adminKey is the context key marking requests authenticated with an admin credential
+type adminKey struct{}
+
+// Identity is the client a key store knows an inbound API key as
+type Identity struct {
+	// ID names the client in logs, rate limits and routing decisions; keys sharing an ID share
+	// its rate limits
+	ID string `json:"id"`
+	// Name is a human-readable label for the client
+	Name string `json:"name,omitempty"`
+	// Policy replaces the ACL policy of the client's keys when set
+	Policy *Policy `json:"policy,omitempty"`
+	// Disabled rejects the key without removing it from the store
+	Disabled bool `json:"disabled,omitempty"`
+	// Admin lets the client's keys call the /admin/ endpoints
+	Admin bool `json:"admin,omitempty"`
+}
+
+// WithIdentity returns a context carrying the authenticated client identity
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the authenticated client identity, or nil when the request
+// was not authenticated against a key store
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
+
+// WithAdmin returns a context marking the request as authenticated with an admin credential
+func WithAdmin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, adminKey{}, true)
+}
+
+// IsAdmin reports whether the request was authenticated with an admin credential, either
+// ADMIN_API_KEY or the key of an identity with admin set
+func IsAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminKey{}).(bool)
+	return admin
+}
+
+// QuotaKey returns what the request's rate limits are counted under: the authenticated
+// client identity when there is one, otherwise the bearer token
+func QuotaKey(r *http.Request) string {
+	if identity := IdentityFromContext(r.Context()); identity != nil && identity.ID != "" {
+		return "identity:" + identity.ID
+	}
+	return ClientKey(r)
+}
@@ -0,0 +1,157 @@
+package access
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aashari/go-generative-api-router/internal/shared"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// redisKeyPrefix is the prefix, after REDIS_KEY_PREFIX, of the Redis keys holding client
+// identities, followed by the SHA-256 hex digest of the client key
+const redisKeyPrefix = "client_keys:"
+
+// KeyStore validates inbound client API keys
+type KeyStore interface {
+	// Lookup returns the identity of a client key, or nil when the store does not know it
+	Lookup(ctx context.Context, key string) (*Identity, error)
+}
+
+var (
+	defaultKeyStore   KeyStore
+	defaultKeyStoreMu sync.RWMutex
+)
+
+// DefaultKeyStore returns the process-wide key store, or nil when any key is accepted
+func DefaultKeyStore() KeyStore {
+	defaultKeyStoreMu.RLock()
+	defer defaultKeyStoreMu.RUnlock()
+	return defaultKeyStore
+}
+
+// SetDefaultKeyStore replaces the process-wide key store; nil accepts any key
+func SetDefaultKeyStore(store KeyStore) {
+	defaultKeyStoreMu.Lock()
+	defer defaultKeyStoreMu.Unlock()
+	defaultKeyStore = store
+}
+
+// NewKeyStoreFromEnv creates the key store selected by CLIENT_AUTH_STORE: file, reading
+// CLIENT_AUTH_FILE, or redis, reading REDIS_URL. It returns nil when CLIENT_AUTH_STORE is
+// unset, which accepts any key
+func NewKeyStoreFromEnv() (KeyStore, error) {
+	switch kind := utils.GetEnvString("CLIENT_AUTH_STORE", ""); strings.ToLower(kind) {
+	case "":
+		return nil, nil
+	case "file":
+		path := utils.GetEnvString("CLIENT_AUTH_FILE", "")
+		if path == "" {
+			return nil, fmt.Errorf("CLIENT_AUTH_STORE=file requires CLIENT_AUTH_FILE")
+		}
+		return LoadFileKeyStore(path)
+	case "redis":
+		store, err := shared.NewFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		if store == nil {
+			return nil, fmt.Errorf("CLIENT_AUTH_STORE=redis requires REDIS_URL")
+		}
+		return &RedisKeyStore{Store: store}, nil
+	default:
+		return nil, fmt.Errorf("unknown CLIENT_AUTH_STORE %q: must be file or redis", kind)
+	}
+}
+
+// FileKeyStore holds the client keys of a JSON file, read once at startup
+type FileKeyStore struct {
+	keys map[string]*Identity // SHA-256 hex digest of the client key -> identity
+}
+
+// keyFile is the on-disk key store format
+type keyFile struct {
+	Keys map[string]Identity `json:"keys"`
+}
+
+// NewFileKeyStore creates a key store from identities keyed by raw client keys or
+// "sha256:" followed by their hex digest
+func NewFileKeyStore(keys map[string]Identity) *FileKeyStore {
+	store := &FileKeyStore{keys: make(map[string]*Identity, len(keys))}
+	for key, identity := range keys {
+		if digest, ok := strings.CutPrefix(key, hashPrefix); ok {
+			store.keys[strings.ToLower(digest)] = &identity
+			continue
+		}
+		store.keys[hashKey(key)] = &identity
+	}
+	return store
+}
+
+// LoadFileKeyStore reads a key store from a JSON file with a "keys" object
+func LoadFileKeyStore(path string) (*FileKeyStore, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client key file: %w", err)
+	}
+	var file keyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid client key file: %w", err)
+	}
+	for key, identity := range file.Keys {
+		if identity.ID == "" {
+			return nil, fmt.Errorf("invalid client key file: key %s has no id", keyLabel(key))
+		}
+	}
+	return NewFileKeyStore(file.Keys), nil
+}
+
+// Lookup returns the identity of a client key
+func (s *FileKeyStore) Lookup(_ context.Context, key string) (*Identity, error) {
+	if key == "" {
+		return nil, nil
+	}
+	return s.keys[hashKey(key)], nil
+}
+
+// RedisKeyStore looks client keys up in a shared store, as JSON identities under
+// "client_keys:" followed by the SHA-256 hex digest of the key, so that keys can be issued
+// and revoked without restarting any replica
+type RedisKeyStore struct {
+	Store shared.Store
+}
+
+// Lookup returns the identity of a client key
+func (s *RedisKeyStore) Lookup(ctx context.Context, key string) (*Identity, error) {
+	if key == "" {
+		return nil, nil
+	}
+	value, ok, err := s.Store.Get(ctx, redisKeyPrefix+hashKey(key))
+	if err != nil || !ok {
+		return nil, err
+	}
+	var identity Identity
+	if err := json.Unmarshal([]byte(value), &identity); err != nil {
+		return nil, fmt.Errorf("invalid identity for client key %s: %w", keyLabel(key), err)
+	}
+	if identity.ID == "" {
+		return nil, fmt.Errorf("invalid identity for client key %s: no id", keyLabel(key))
+	}
+	return &identity, nil
+}
+
+// keyLabel names a client key in errors without revealing it
+func keyLabel(key string) string {
+	if strings.HasPrefix(key, hashPrefix) {
+		return key
+	}
+	if len(key) <= 4 {
+		return "****"
+	}
+	return "..." + key[len(key)-4:]
+}
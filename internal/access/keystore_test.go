@@ -0,0 +1,114 @@
+package access
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFileKeyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"keys": {
+			"sk-team-a": {"id": "team-a", "name": "Team A"},
+			"sha256:`+hashKey("sk-team-b")+`": {"id": "team-b", "policy": {"allowed_vendors": ["gemini"]}},
+			"sk-retired": {"id": "team-c", "disabled": true}
+		}
+	}`), 0o600))
+
+	store, err := LoadFileKeyStore(path)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	identity, err := store.Lookup(ctx, "sk-team-a")
+	require.NoError(t, err)
+	require.NotNil(t, identity)
+	assert.Equal(t, "team-a", identity.ID)
+	assert.Equal(t, "Team A", identity.Name)
+
+	identity, err = store.Lookup(ctx, "sk-team-b")
+	require.NoError(t, err)
+	require.NotNil(t, identity)
+	assert.Equal(t, []string{"gemini"}, identity.Policy.AllowedVendors)
+
+	identity, err = store.Lookup(ctx, "sk-retired")
+	require.NoError(t, err)
+	assert.True(t, identity.Disabled)
+
+	identity, err = store.Lookup(ctx, "sk-unknown")
+	require.NoError(t, err)
+	assert.Nil(t, identity)
+}
+
+func TestLoadFileKeyStore_RequiresIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys": {"sk-secret-key": {"name": "no id"}}}`), 0o600))
+
+	_, err := LoadFileKeyStore(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "...-key")
+	assert.NotContains(t, err.Error(), "sk-secret-key", "keys are not revealed")
+}
+
+func TestRedisKeyStore(t *testing.T) {
+	ctx := context.Background()
+	memory := shared.NewMemory()
+	require.NoError(t, memory.Set(ctx, "client_keys:"+hashKey("sk-team-a"), `{"id":"team-a"}`, time.Hour))
+	require.NoError(t, memory.Set(ctx, "client_keys:"+hashKey("sk-broken"), `{"name":"no id"}`, time.Hour))
+	store := &RedisKeyStore{Store: memory}
+
+	identity, err := store.Lookup(ctx, "sk-team-a")
+	require.NoError(t, err)
+	require.NotNil(t, identity)
+	assert.Equal(t, "team-a", identity.ID)
+
+	identity, err = store.Lookup(ctx, "sk-unknown")
+	require.NoError(t, err)
+	assert.Nil(t, identity)
+
+	_, err = store.Lookup(ctx, "sk-broken")
+	assert.Error(t, err)
+}
+
+func TestNewKeyStoreFromEnv(t *testing.T) {
+	t.Setenv("REDIS_URL", "")
+
+	store, err := NewKeyStoreFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, store, "unset CLIENT_AUTH_STORE accepts any key")
+
+	t.Setenv("CLIENT_AUTH_STORE", "file")
+	_, err = NewKeyStoreFromEnv()
+	assert.ErrorContains(t, err, "CLIENT_AUTH_FILE")
+
+	t.Setenv("CLIENT_AUTH_STORE", "redis")
+	_, err = NewKeyStoreFromEnv()
+	assert.ErrorContains(t, err, "REDIS_URL")
+
+	t.Setenv("CLIENT_AUTH_STORE", "ldap")
+	_, err = NewKeyStoreFromEnv()
+	assert.ErrorContains(t, err, "must be file or redis")
+}
+
+func TestIdentity_PolicyAndQuotaKey(t *testing.T) {
+	acl := NewACL(Policy{}, map[string]Policy{"sk-team-a": {AllowedVendors: []string{"openai"}}})
+	req := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	req.Header.Set("Authorization", "Bearer sk-team-a")
+
+	assert.Equal(t, []string{"openai"}, acl.PolicyFor(req).AllowedVendors)
+	assert.Equal(t, "sk-team-a", QuotaKey(req))
+
+	withoutPolicy := req.WithContext(WithIdentity(req.Context(), &Identity{ID: "team-a"}))
+	assert.Equal(t, []string{"openai"}, acl.PolicyFor(withoutPolicy).AllowedVendors, "identities without a policy keep the ACL's")
+	assert.Equal(t, "identity:team-a", QuotaKey(withoutPolicy))
+
+	withPolicy := req.WithContext(WithIdentity(req.Context(), &Identity{ID: "team-a", Policy: &Policy{AllowedVendors: []string{"gemini"}}}))
+	assert.Equal(t, []string{"gemini"}, acl.PolicyFor(withPolicy).AllowedVendors)
+}
@@ -0,0 +1,121 @@
+package access
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// rateWindow is the window request rates are counted over
+const rateWindow = time.Minute
+
+// Errors returned when a request exceeds its client key's rate limits
+var (
+	ErrKeyRateLimited  = errors.New("rate limit exceeded for this client key")
+	ErrUserRateLimited = errors.New("rate limit exceeded for this user")
+)
+
+// RateLimitError reports which limit rejected a request and when it may be retried
+type RateLimitError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// Limiter enforces the per-key and per-user request rates of client key policies. Each
+// key and each user of a key counts its requests in fixed one-minute windows; a request
+// counts against both only when both have room, so users throttled by their own limit do
+// not use up the key's
+type Limiter struct {
+	mu      sync.Mutex
+	windows map[string]*window // key digest, or key digest + user digest -> window
+	// swept is when ended windows were last dropped
+	swept time.Time
+	now   func() time.Time
+}
+
+// window is the request count of the minute starting at start
+type window struct {
+	start time.Time
+	count int
+}
+
+var (
+	defaultLimiter     *Limiter
+	defaultLimiterOnce sync.Once
+)
+
+// NewLimiter creates a limiter with no requests counted
+func NewLimiter() *Limiter {
+	return &Limiter{windows: make(map[string]*window), now: time.Now}
+}
+
+// DefaultLimiter returns the process-wide limiter
+func DefaultLimiter() *Limiter {
+	defaultLimiterOnce.Do(func() {
+		defaultLimiter = NewLimiter()
+	})
+	return defaultLimiter
+}
+
+// Allow counts a request from clientKey on behalf of user ("" when the request names none)
+// against the policy's limits, returning a *RateLimitError when either is exhausted
+func (l *Limiter) Allow(policy Policy, clientKey, user string) error {
+	if policy.RequestsPerMinute <= 0 && (policy.UserRequestsPerMinute <= 0 || user == "") {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	keyDigest := hashKey(clientKey)
+	var keyWindow, userWindow *window
+	if policy.RequestsPerMinute > 0 {
+		keyWindow = l.windowFor(keyDigest, now)
+		if keyWindow.count >= policy.RequestsPerMinute {
+			return &RateLimitError{Err: ErrKeyRateLimited, RetryAfter: keyWindow.start.Add(rateWindow).Sub(now)}
+		}
+	}
+	if policy.UserRequestsPerMinute > 0 && user != "" {
+		userWindow = l.windowFor(keyDigest+":"+hashKey(user), now)
+		if userWindow.count >= policy.UserRequestsPerMinute {
+			return &RateLimitError{Err: ErrUserRateLimited, RetryAfter: userWindow.start.Add(rateWindow).Sub(now)}
+		}
+	}
+
+	if keyWindow != nil {
+		keyWindow.count++
+	}
+	if userWindow != nil {
+		userWindow.count++
+	}
+	return nil
+}
+
+// windowFor returns the current window of a counter, starting a new one when the last has
+// ended. Once a minute ended windows are dropped so idle users do not accumulate; callers hold mu
+func (l *Limiter) windowFor(key string, now time.Time) *window {
+	w, ok := l.windows[key]
+	if ok && now.Sub(w.start) < rateWindow {
+		return w
+	}
+	if now.Sub(l.swept) >= rateWindow {
+		for other, ended := range l.windows {
+			if now.Sub(ended.start) >= rateWindow {
+				delete(l.windows, other)
+			}
+		}
+		l.swept = now
+	}
+	w = &window{start: now}
+	l.windows[key] = w
+	return w
+}
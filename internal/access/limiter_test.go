@@ -0,0 +1,58 @@
+package access
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter()
+	limiter.now = func() time.Time { return now }
+	policy := Policy{RequestsPerMinute: 3, UserRequestsPerMinute: 2}
+
+	require.NoError(t, limiter.Allow(policy, "sk-client", "alice"))
+	require.NoError(t, limiter.Allow(policy, "sk-client", "alice"))
+
+	err := limiter.Allow(policy, "sk-client", "alice")
+	var rateErr *RateLimitError
+	require.ErrorAs(t, err, &rateErr)
+	assert.ErrorIs(t, err, ErrUserRateLimited)
+	assert.Equal(t, time.Minute, rateErr.RetryAfter)
+
+	// The rejected request did not count against the key, which has room for one more
+	require.NoError(t, limiter.Allow(policy, "sk-client", "bob"))
+	now = now.Add(20 * time.Second)
+	err = limiter.Allow(policy, "sk-client", "")
+	require.ErrorAs(t, err, &rateErr)
+	assert.ErrorIs(t, err, ErrKeyRateLimited)
+	assert.Equal(t, 40*time.Second, rateErr.RetryAfter)
+
+	assert.NoError(t, limiter.Allow(policy, "sk-other", "alice"), "limits are counted per client key")
+
+	now = now.Add(time.Minute)
+	assert.NoError(t, limiter.Allow(policy, "sk-client", "alice"), "windows refill after a minute")
+}
+
+func TestLimiter_Unlimited(t *testing.T) {
+	limiter := NewLimiter()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, limiter.Allow(Policy{}, "sk-client", "alice"))
+		require.NoError(t, limiter.Allow(Policy{UserRequestsPerMinute: 1}, "sk-client", ""))
+	}
+	assert.Empty(t, limiter.windows)
+}
+
+func TestUserHash(t *testing.T) {
+	assert.Equal(t, "", UserHash(""))
+	assert.Len(t, UserHash("user-1234"), 16)
+	assert.Equal(t, UserHash("user-1234"), UserHash("user-1234"))
+	assert.NotEqual(t, UserHash("user-1234"), UserHash("user-5678"))
+
+	assert.NoError(t, ValidateUser("user-1234"))
+	assert.Error(t, ValidateUser(" "))
+	assert.Error(t, ValidateUser(string(make([]byte, MaxUserLength+1))))
+}
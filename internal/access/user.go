@@ -0,0 +1,29 @@
+package access
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxUserLength is the longest end-user identifier accepted in the OpenAI "user" field
+const MaxUserLength = 256
+
+// ValidateUser checks an end-user identifier sent in the OpenAI "user" field
+func ValidateUser(user string) error {
+	if strings.TrimSpace(user) == "" {
+		return fmt.Errorf("invalid 'user' field: must not be empty")
+	}
+	if len(user) > MaxUserLength {
+		return fmt.Errorf("invalid 'user' field: must be at most %d characters", MaxUserLength)
+	}
+	return nil
+}
+
+// UserHash returns a non-reversible identifier of an end user for logs and metrics, the
+// first 16 hex characters of the SHA-256 digest of the "user" field, or "" when there is none
+func UserHash(user string) string {
+	if user == "" {
+		return ""
+	}
+	return hashKey(user)[:16]
+}
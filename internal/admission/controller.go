@@ -0,0 +1,295 @@
+// Package admission guards the process against overload: requests are admitted while the
+// in-flight count and their estimated memory footprint stay under the configured ceilings,
+// and otherwise wait in a bounded queue or are rejected. Chat completions are further capped
+// in how many are sent to vendors at once, overall and per vendor, and new requests are shed
+// outright while the process's heap, goroutines or in-flight requests are over their thresholds
+package admission
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// DefaultQueueTimeout is how long a queued request waits for admission when
+// ADMISSION_QUEUE_TIMEOUT is unset
+const DefaultQueueTimeout = 10 * time.Second
+
+var (
+	// ErrOverloaded is returned when a request is rejected because the ceilings are reached
+	// and the queue is full or its wait timed out
+	ErrOverloaded = errors.New("the service is at capacity")
+	// ErrTooLarge is returned for a request whose estimated footprint alone exceeds the
+	// memory ceiling, so it could never be admitted
+	ErrTooLarge = errors.New("the request exceeds the memory ceiling")
+)
+
+// Limits are the ceilings enforced by a controller; zero disables a ceiling
+type Limits struct {
+	// MaxInFlight is the most requests served at once
+	MaxInFlight int `json:"max_in_flight"`
+	// MaxMemoryBytes is the most estimated memory, request bodies plus downloaded media,
+	// held by the requests served at once
+	MaxMemoryBytes int64 `json:"max_memory_bytes"`
+	// MaxQueue is the most requests waiting for admission; beyond it they are rejected
+	MaxQueue int `json:"max_queue"`
+	// QueueTimeout is how long a request waits for admission before it is rejected
+	QueueTimeout time.Duration `json:"-"`
+}
+
+// Stats is a snapshot of a controller's load
+type Stats struct {
+	Limits
+	InFlight    int   `json:"in_flight"`
+	MemoryBytes int64 `json:"memory_bytes"`
+	Queued      int   `json:"queued"`
+	Rejected    int64 `json:"rejected"`
+}
+
+// Controller admits requests under its limits
+type Controller struct {
+	mu       sync.Mutex
+	limits   Limits
+	inFlight int
+	memory   int64
+	// queue holds the waiting requests in arrival order; they are admitted first come,
+	// first served so large requests are not starved by smaller ones
+	queue    []*waiter
+	rejected int64
+}
+
+// waiter is a request waiting for admission
+type waiter struct {
+	estimate int64
+	admitted chan struct{}
+}
+
+// Ticket is an admitted request's share of the controller; Release must be called once the
+// request is served
+type Ticket struct {
+	controller *Controller
+	mu         sync.Mutex
+	memory     int64
+	released   bool
+	// waited is how long the request spent in the queue before it was admitted
+	waited time.Duration
+}
+
+var (
+	defaultController     *Controller
+	defaultControllerOnce sync.Once
+)
+
+// NewController creates a controller enforcing limits
+func NewController(limits Limits) *Controller {
+	c := &Controller{}
+	c.Configure(limits)
+	return c
+}
+
+// Default returns the process-wide controller, configured by ADMISSION_MAX_IN_FLIGHT,
+// ADMISSION_MAX_MEMORY_BYTES, ADMISSION_MAX_QUEUE and ADMISSION_QUEUE_TIMEOUT (seconds);
+// every ceiling is disabled by default
+func Default() *Controller {
+	defaultControllerOnce.Do(func() {
+		defaultController = NewController(Limits{
+			MaxInFlight:    utils.GetEnvInt("ADMISSION_MAX_IN_FLIGHT", 0),
+			MaxMemoryBytes: int64(utils.GetEnvInt("ADMISSION_MAX_MEMORY_BYTES", 0)),
+			MaxQueue:       utils.GetEnvInt("ADMISSION_MAX_QUEUE", 0),
+			QueueTimeout:   utils.GetEnvDuration("ADMISSION_QUEUE_TIMEOUT", DefaultQueueTimeout),
+		})
+	})
+	return defaultController
+}
+
+// Configure replaces the ceilings; waiting requests that fit the new ones are admitted
+func (c *Controller) Configure(limits Limits) {
+	if limits.QueueTimeout <= 0 {
+		limits.QueueTimeout = DefaultQueueTimeout
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limits = limits
+	c.admitQueued()
+}
+
+// Enabled reports whether any ceiling is configured
+func (c *Controller) Enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limits.MaxInFlight > 0 || c.limits.MaxMemoryBytes > 0
+}
+
+// Admit admits a request estimated to hold estimate bytes, waiting in the queue while the
+// ceilings are reached. It returns ErrOverloaded when the queue is full or the wait times
+// out, ErrTooLarge when the request could never fit, or the context's error
+func (c *Controller) Admit(ctx context.Context, estimate int64) (*Ticket, error) {
+	c.mu.Lock()
+	if c.limits.MaxMemoryBytes > 0 && estimate > c.limits.MaxMemoryBytes {
+		c.rejected++
+		c.mu.Unlock()
+		return nil, ErrTooLarge
+	}
+	if len(c.queue) == 0 && c.fits(estimate) {
+		c.take(estimate)
+		c.mu.Unlock()
+		return c.ticket(estimate), nil
+	}
+	if len(c.queue) >= c.limits.MaxQueue {
+		c.rejected++
+		c.mu.Unlock()
+		return nil, ErrOverloaded
+	}
+	w := &waiter{estimate: estimate, admitted: make(chan struct{})}
+	c.queue = append(c.queue, w)
+	queueTimeout := c.limits.QueueTimeout
+	c.mu.Unlock()
+
+	queued := time.Now()
+	timer := time.NewTimer(queueTimeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-w.admitted:
+		ticket := c.ticket(estimate)
+		ticket.waited = time.Since(queued)
+		return ticket, nil
+	case <-timer.C:
+		err = ErrOverloaded
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-w.admitted:
+		// Admitted while giving up; hand the share back to the next in line
+		c.give(estimate)
+	default:
+		c.remove(w)
+	}
+	if err == ErrOverloaded {
+		c.rejected++
+	}
+	c.admitQueued()
+	return nil, err
+}
+
+// Stats returns a snapshot of the controller's load
+func (c *Controller) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Limits:      c.limits,
+		InFlight:    c.inFlight,
+		MemoryBytes: c.memory,
+		Queued:      len(c.queue),
+		Rejected:    c.rejected,
+	}
+}
+
+// fits reports whether a request of estimate bytes can be admitted now
+func (c *Controller) fits(estimate int64) bool {
+	if c.limits.MaxInFlight > 0 && c.inFlight >= c.limits.MaxInFlight {
+		return false
+	}
+	return c.limits.MaxMemoryBytes <= 0 || c.memory+estimate <= c.limits.MaxMemoryBytes
+}
+
+func (c *Controller) take(estimate int64) {
+	c.inFlight++
+	c.memory += estimate
+}
+
+func (c *Controller) give(memory int64) {
+	c.inFlight--
+	c.memory -= memory
+}
+
+// admitQueued admits waiting requests in order while they fit
+func (c *Controller) admitQueued() {
+	for len(c.queue) > 0 && c.fits(c.queue[0].estimate) {
+		w := c.queue[0]
+		c.queue = c.queue[1:]
+		c.take(w.estimate)
+		close(w.admitted)
+	}
+}
+
+// remove drops a waiter that gave up from the queue
+func (c *Controller) remove(w *waiter) {
+	for i, queued := range c.queue {
+		if queued == w {
+			c.queue = append(c.queue[:i], c.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *Controller) ticket(memory int64) *Ticket {
+	return &Ticket{controller: c, memory: memory}
+}
+
+// Grow adds bytes the request came to hold after admission, such as downloaded media, to
+// its footprint. Requests already admitted are never rejected for growing; the memory
+// counts against the requests that arrive while it is held
+func (t *Ticket) Grow(bytes int64) {
+	if t == nil || bytes <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.released {
+		return
+	}
+	t.memory += bytes
+	t.controller.mu.Lock()
+	t.controller.memory += bytes
+	t.controller.mu.Unlock()
+}
+
+// Release returns the request's share to the controller and admits waiting requests that
+// now fit; calls after the first do nothing
+func (t *Ticket) Release() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.released {
+		return
+	}
+	t.released = true
+	c := t.controller
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.give(t.memory)
+	c.admitQueued()
+}
+
+type ticketKey struct{}
+
+// WithTicket returns a context carrying the request's ticket
+func WithTicket(ctx context.Context, ticket *Ticket) context.Context {
+	return context.WithValue(ctx, ticketKey{}, ticket)
+}
+
+// QueueWait returns how long the request whose ticket ctx carries waited for admission, or
+// 0 when it carries none
+func QueueWait(ctx context.Context) time.Duration {
+	ticket, _ := ctx.Value(ticketKey{}).(*Ticket)
+	if ticket == nil {
+		return 0
+	}
+	return ticket.waited
+}
+
+// Grow adds bytes to the footprint of the request whose ticket ctx carries, if any
+func Grow(ctx context.Context, bytes int64) {
+	ticket, _ := ctx.Value(ticketKey{}).(*Ticket)
+	ticket.Grow(bytes)
+}
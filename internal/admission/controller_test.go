@@ -0,0 +1,115 @@
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestController_InFlightCeiling(t *testing.T) {
+	c := NewController(Limits{MaxInFlight: 2})
+
+	first, err := c.Admit(context.Background(), 0)
+	require.NoError(t, err)
+	second, err := c.Admit(context.Background(), 0)
+	require.NoError(t, err)
+
+	_, err = c.Admit(context.Background(), 0)
+	assert.ErrorIs(t, err, ErrOverloaded, "no queue: rejected right away")
+
+	first.Release()
+	first.Release()
+	third, err := c.Admit(context.Background(), 0)
+	require.NoError(t, err, "a released share is reused")
+
+	second.Release()
+	third.Release()
+	stats := c.Stats()
+	assert.Equal(t, 0, stats.InFlight)
+	assert.EqualValues(t, 1, stats.Rejected)
+}
+
+func TestController_MemoryCeiling(t *testing.T) {
+	c := NewController(Limits{MaxMemoryBytes: 100})
+
+	_, err := c.Admit(context.Background(), 101)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	ticket, err := c.Admit(context.Background(), 60)
+	require.NoError(t, err)
+	_, err = c.Admit(context.Background(), 50)
+	assert.ErrorIs(t, err, ErrOverloaded)
+
+	// Media downloaded while serving counts against later requests
+	ctx := WithTicket(context.Background(), ticket)
+	Grow(ctx, 30)
+	assert.EqualValues(t, 90, c.Stats().MemoryBytes)
+	_, err = c.Admit(context.Background(), 20)
+	assert.ErrorIs(t, err, ErrOverloaded)
+
+	ticket.Release()
+	Grow(ctx, 30)
+	assert.EqualValues(t, 0, c.Stats().MemoryBytes, "released tickets no longer grow")
+	Grow(context.Background(), 30)
+}
+
+func TestController_Queue(t *testing.T) {
+	c := NewController(Limits{MaxInFlight: 1, MaxQueue: 1, QueueTimeout: 5 * time.Second})
+	held, err := c.Admit(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Zero(t, QueueWait(WithTicket(context.Background(), held)), "admitted without queueing")
+
+	admitted := make(chan *Ticket)
+	go func() {
+		ticket, err := c.Admit(context.Background(), 0)
+		assert.NoError(t, err)
+		admitted <- ticket
+	}()
+	require.Eventually(t, func() bool { return c.Stats().Queued == 1 }, time.Second, time.Millisecond)
+
+	_, err = c.Admit(context.Background(), 0)
+	assert.ErrorIs(t, err, ErrOverloaded, "the queue is full")
+
+	time.Sleep(10 * time.Millisecond)
+	held.Release()
+	select {
+	case ticket := <-admitted:
+		assert.Equal(t, 1, c.Stats().InFlight)
+		assert.GreaterOrEqual(t, QueueWait(WithTicket(context.Background(), ticket)), 10*time.Millisecond)
+		ticket.Release()
+	case <-time.After(time.Second):
+		t.Fatal("the queued request was not admitted")
+	}
+}
+
+func TestController_QueueGivesUp(t *testing.T) {
+	c := NewController(Limits{MaxInFlight: 1, MaxQueue: 2, QueueTimeout: 20 * time.Millisecond})
+	held, err := c.Admit(context.Background(), 0)
+	require.NoError(t, err)
+	defer held.Release()
+
+	_, err = c.Admit(context.Background(), 0)
+	assert.ErrorIs(t, err, ErrOverloaded, "the wait timed out")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.Admit(ctx, 0)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	stats := c.Stats()
+	assert.Equal(t, 0, stats.Queued)
+	assert.Equal(t, 1, stats.InFlight)
+	assert.EqualValues(t, 1, stats.Rejected, "cancelled requests are not rejections")
+}
+
+func TestController_Disabled(t *testing.T) {
+	c := NewController(Limits{})
+	assert.False(t, c.Enabled())
+	for i := 0; i < 10; i++ {
+		_, err := c.Admit(context.Background(), 1<<30)
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,302 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// ErrConcurrencyLimited is returned when a chat completion finds its vendor, or the router,
+// at its concurrency limit and the queue is full or its wait timed out
+var ErrConcurrencyLimited = errors.New("too many concurrent requests")
+
+// LimiterConfig are the concurrency limits enforced by a limiter; zero disables a limit
+type LimiterConfig struct {
+	// MaxConcurrent is the most chat completions sent to vendors at once
+	MaxConcurrent int `json:"max_concurrent"`
+	// VendorLimits is the most chat completions sent to each vendor at once
+	VendorLimits map[string]int `json:"vendor_limits,omitempty"`
+	// MaxQueue is the most requests waiting for a slot; beyond it they are rejected
+	MaxQueue int `json:"max_queue"`
+	// QueueTimeout is how long a request waits for a slot before it is rejected
+	QueueTimeout time.Duration `json:"-"`
+}
+
+// LimiterStats is a snapshot of a limiter's load
+type LimiterStats struct {
+	LimiterConfig
+	InFlight       int            `json:"in_flight"`
+	VendorInFlight map[string]int `json:"vendor_in_flight"`
+	Queued         int            `json:"queued"`
+	Rejected       int64          `json:"rejected"`
+}
+
+// Limiter caps the chat completions in flight, overall and per vendor. Requests beyond the
+// caps wait in a bounded queue, where each is let through as soon as both its vendor and
+// the router have room, in arrival order among those that fit
+type Limiter struct {
+	mu       sync.Mutex
+	config   LimiterConfig
+	inFlight int
+	vendors  map[string]int
+	queue    []*slotWaiter
+	rejected int64
+}
+
+// slotWaiter is a request waiting for a slot on its vendor
+type slotWaiter struct {
+	vendor   string
+	admitted chan struct{}
+}
+
+var (
+	defaultLimiter     *Limiter
+	defaultLimiterOnce sync.Once
+)
+
+// NewLimiter creates a limiter enforcing config
+func NewLimiter(config LimiterConfig) *Limiter {
+	l := &Limiter{vendors: make(map[string]int)}
+	l.Configure(config)
+	return l
+}
+
+// DefaultLimiter returns the process-wide limiter, configured by CONCURRENCY_MAX,
+// CONCURRENCY_VENDOR_LIMITS (vendor=limit pairs), CONCURRENCY_MAX_QUEUE and
+// CONCURRENCY_QUEUE_TIMEOUT (seconds); every limit is disabled by default
+func DefaultLimiter() *Limiter {
+	defaultLimiterOnce.Do(func() {
+		vendorLimits, err := ParseVendorLimits(utils.GetEnvString("CONCURRENCY_VENDOR_LIMITS", ""))
+		if err != nil {
+			logger.Warn(context.Background(), "Ignoring invalid CONCURRENCY_VENDOR_LIMITS", "error", err.Error())
+		}
+		defaultLimiter = NewLimiter(LimiterConfig{
+			MaxConcurrent: utils.GetEnvInt("CONCURRENCY_MAX", 0),
+			VendorLimits:  vendorLimits,
+			MaxQueue:      utils.GetEnvInt("CONCURRENCY_MAX_QUEUE", 0),
+			QueueTimeout:  utils.GetEnvDuration("CONCURRENCY_QUEUE_TIMEOUT", DefaultQueueTimeout),
+		})
+	})
+	return defaultLimiter
+}
+
+// ParseVendorLimits parses comma-separated vendor=limit pairs, such as "openai=20,gemini=10"
+func ParseVendorLimits(value string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		vendor, rawLimit, ok := strings.Cut(entry, "=")
+		limit, err := strconv.Atoi(strings.TrimSpace(rawLimit))
+		if !ok || strings.TrimSpace(vendor) == "" || err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid vendor limit %q, expected vendor=limit", entry)
+		}
+		limits[strings.TrimSpace(vendor)] = limit
+	}
+	return limits, nil
+}
+
+// Configure replaces the limits; waiting requests that fit the new ones are let through
+func (l *Limiter) Configure(config LimiterConfig) {
+	if config.QueueTimeout <= 0 {
+		config.QueueTimeout = DefaultQueueTimeout
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = config
+	l.admitQueued()
+}
+
+// Enabled reports whether any limit is configured
+func (l *Limiter) Enabled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.config.MaxConcurrent > 0 {
+		return true
+	}
+	for _, limit := range l.config.VendorLimits {
+		if limit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Acquire takes a slot on vendor, waiting in the queue while the vendor or the router is
+// at its limit. It returns ErrConcurrencyLimited when the queue is full or the wait times
+// out, or the context's error. The returned release is idempotent
+func (l *Limiter) Acquire(ctx context.Context, vendor string) (release func(), err error) {
+	l.mu.Lock()
+	// Waiters are let through whenever they fit, so one that fits now overtakes nobody
+	if l.fits(vendor) {
+		l.take(vendor)
+		l.mu.Unlock()
+		return l.release(vendor), nil
+	}
+	if len(l.queue) >= l.config.MaxQueue {
+		l.rejected++
+		l.mu.Unlock()
+		return nil, ErrConcurrencyLimited
+	}
+	w := &slotWaiter{vendor: vendor, admitted: make(chan struct{})}
+	l.queue = append(l.queue, w)
+	queueTimeout := l.config.QueueTimeout
+	l.mu.Unlock()
+
+	timer := time.NewTimer(queueTimeout)
+	defer timer.Stop()
+	select {
+	case <-w.admitted:
+		return l.release(vendor), nil
+	case <-timer.C:
+		err = ErrConcurrencyLimited
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-w.admitted:
+		// Let through while giving up; hand the slot back to the next in line
+		l.give(vendor)
+	default:
+		l.remove(w)
+	}
+	if err == ErrConcurrencyLimited {
+		l.rejected++
+	}
+	l.admitQueued()
+	return nil, err
+}
+
+// RetryAfter is how long a rejected request is told to wait before retrying
+func (l *Limiter) RetryAfter() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.config.QueueTimeout
+}
+
+// Filter removes the models of vendors at their concurrency limit, and their credentials,
+// from a routing pool, so requests go to vendors with room. When it would leave nothing to
+// route to, the pool is returned unchanged and the request waits for its vendor
+func (l *Limiter) Filter(creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var keptModels []config.VendorModel
+	for _, model := range models {
+		if !l.vendorFull(model.Vendor) {
+			keptModels = append(keptModels, model)
+		}
+	}
+	if len(keptModels) == len(models) || len(keptModels) == 0 {
+		return creds, models
+	}
+
+	var keptCreds []config.Credential
+	for _, cred := range creds {
+		if !l.vendorFull(cred.Platform) {
+			keptCreds = append(keptCreds, cred)
+		}
+	}
+	if len(keptCreds) == 0 {
+		return creds, models
+	}
+	return keptCreds, keptModels
+}
+
+// Stats returns a snapshot of the limiter's load
+func (l *Limiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	vendors := make(map[string]int, len(l.vendors))
+	for vendor, count := range l.vendors {
+		vendors[vendor] = count
+	}
+	return LimiterStats{
+		LimiterConfig:  l.config,
+		InFlight:       l.inFlight,
+		VendorInFlight: vendors,
+		Queued:         len(l.queue),
+		Rejected:       l.rejected,
+	}
+}
+
+// fits reports whether a request for vendor can take a slot now
+func (l *Limiter) fits(vendor string) bool {
+	if l.config.MaxConcurrent > 0 && l.inFlight >= l.config.MaxConcurrent {
+		return false
+	}
+	return !l.vendorFull(vendor)
+}
+
+// vendorFull reports whether vendor is at its limit
+func (l *Limiter) vendorFull(vendor string) bool {
+	limit := l.config.VendorLimits[vendor]
+	return limit > 0 && l.vendors[vendor] >= limit
+}
+
+func (l *Limiter) take(vendor string) {
+	l.inFlight++
+	l.vendors[vendor]++
+}
+
+func (l *Limiter) give(vendor string) {
+	l.inFlight--
+	if l.vendors[vendor]--; l.vendors[vendor] <= 0 {
+		delete(l.vendors, vendor)
+	}
+}
+
+// release returns a release func giving vendor's slot back once
+func (l *Limiter) release(vendor string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.give(vendor)
+			l.admitQueued()
+		})
+	}
+}
+
+// admitQueued lets waiting requests through in order while they fit, skipping those whose
+// vendor is still at its limit
+func (l *Limiter) admitQueued() {
+	for i := 0; i < len(l.queue); {
+		w := l.queue[i]
+		if !l.fits(w.vendor) {
+			if l.config.MaxConcurrent > 0 && l.inFlight >= l.config.MaxConcurrent {
+				return
+			}
+			i++
+			continue
+		}
+		l.queue = append(l.queue[:i], l.queue[i+1:]...)
+		l.take(w.vendor)
+		close(w.admitted)
+	}
+}
+
+// remove drops a waiter that gave up from the queue
+func (l *Limiter) remove(w *slotWaiter) {
+	for i, queued := range l.queue {
+		if queued == w {
+			l.queue = append(l.queue[:i], l.queue[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVendorLimits(t *testing.T) {
+	limits, err := ParseVendorLimits(" openai=20, gemini = 10 ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"openai": 20, "gemini": 10}, limits)
+
+	for _, value := range []string{"openai", "openai=many", "=5", "openai=-1"} {
+		_, err := ParseVendorLimits(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestLimiter_Limits(t *testing.T) {
+	l := NewLimiter(LimiterConfig{MaxConcurrent: 3, VendorLimits: map[string]int{"openai": 1}})
+	assert.True(t, l.Enabled())
+
+	releaseOpenAI, err := l.Acquire(context.Background(), "openai")
+	require.NoError(t, err)
+	_, err = l.Acquire(context.Background(), "openai")
+	assert.ErrorIs(t, err, ErrConcurrencyLimited, "the vendor is at its limit")
+
+	releaseGemini, err := l.Acquire(context.Background(), "gemini")
+	require.NoError(t, err)
+	_, err = l.Acquire(context.Background(), "gemini")
+	require.NoError(t, err)
+	_, err = l.Acquire(context.Background(), "mistral")
+	assert.ErrorIs(t, err, ErrConcurrencyLimited, "the router is at its limit")
+
+	releaseOpenAI()
+	releaseOpenAI()
+	releaseGemini()
+	stats := l.Stats()
+	assert.Equal(t, 1, stats.InFlight)
+	assert.Equal(t, map[string]int{"gemini": 1}, stats.VendorInFlight)
+	assert.EqualValues(t, 2, stats.Rejected)
+
+	assert.False(t, NewLimiter(LimiterConfig{}).Enabled())
+}
+
+func TestLimiter_Queue(t *testing.T) {
+	l := NewLimiter(LimiterConfig{VendorLimits: map[string]int{"openai": 1}, MaxQueue: 1, QueueTimeout: 5 * time.Second})
+	held, err := l.Acquire(context.Background(), "openai")
+	require.NoError(t, err)
+
+	admitted := make(chan func())
+	go func() {
+		release, err := l.Acquire(context.Background(), "openai")
+		assert.NoError(t, err)
+		admitted <- release
+	}()
+	require.Eventually(t, func() bool { return l.Stats().Queued == 1 }, time.Second, time.Millisecond)
+
+	_, err = l.Acquire(context.Background(), "openai")
+	assert.ErrorIs(t, err, ErrConcurrencyLimited, "the queue is full")
+	release, err := l.Acquire(context.Background(), "gemini")
+	require.NoError(t, err, "other vendors are not held up by the queue")
+	release()
+
+	held()
+	select {
+	case release := <-admitted:
+		assert.Equal(t, 1, l.Stats().VendorInFlight["openai"])
+		release()
+	case <-time.After(time.Second):
+		t.Fatal("the queued request was not let through")
+	}
+}
+
+func TestLimiter_QueueTimeout(t *testing.T) {
+	l := NewLimiter(LimiterConfig{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: 20 * time.Millisecond})
+	held, err := l.Acquire(context.Background(), "openai")
+	require.NoError(t, err)
+	defer held()
+
+	_, err = l.Acquire(context.Background(), "gemini")
+	assert.ErrorIs(t, err, ErrConcurrencyLimited)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = l.Acquire(ctx, "gemini")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	stats := l.Stats()
+	assert.Equal(t, 0, stats.Queued)
+	assert.EqualValues(t, 1, stats.Rejected)
+}
+
+func TestLimiter_Filter(t *testing.T) {
+	creds := []config.Credential{{Platform: "openai"}, {Platform: "gemini"}}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-2.0-flash"}}
+	l := NewLimiter(LimiterConfig{VendorLimits: map[string]int{"openai": 1, "gemini": 1}})
+
+	keptCreds, keptModels := l.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds)
+	assert.Equal(t, models, keptModels)
+
+	releaseOpenAI, err := l.Acquire(context.Background(), "openai")
+	require.NoError(t, err)
+	keptCreds, keptModels = l.Filter(creds, models)
+	assert.Equal(t, creds[1:], keptCreds)
+	assert.Equal(t, models[1:], keptModels)
+
+	releaseGemini, err := l.Acquire(context.Background(), "gemini")
+	require.NoError(t, err)
+	keptCreds, keptModels = l.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds, "every vendor is full: the pool is unchanged")
+	assert.Equal(t, models, keptModels)
+
+	releaseOpenAI()
+	releaseGemini()
+}
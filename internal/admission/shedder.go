@@ -0,0 +1,221 @@
+package admission
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Resources a shedder watches, as reported in ShedError and ShedderStats
+const (
+	ShedReasonMemory     = "memory"
+	ShedReasonGoroutines = "goroutines"
+	ShedReasonInFlight   = "in_flight"
+)
+
+// DefaultShedRetryAfter is the Retry-After of shed requests when LOAD_SHED_RETRY_AFTER is unset
+const DefaultShedRetryAfter = 5 * time.Second
+
+// heapSampleInterval is how long a heap reading is reused, so busy servers do not read the
+// runtime metrics on every request
+const heapSampleInterval = 100 * time.Millisecond
+
+// heapMetric is the runtime metric of the memory held by live and not yet swept heap objects
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// ShedError reports a request shed because a resource was over its threshold
+type ShedError struct {
+	Reason    string
+	Value     int64
+	Threshold int64
+}
+
+// Error implements the error interface
+func (e *ShedError) Error() string {
+	return fmt.Sprintf("the service is under load: %s at %d exceeds %d", e.Reason, e.Value, e.Threshold)
+}
+
+// ShedderConfig are the thresholds beyond which a shedder rejects new requests; zero
+// disables a threshold
+type ShedderConfig struct {
+	// MaxHeapBytes is the most heap memory in use before new requests are shed
+	MaxHeapBytes int64 `json:"max_heap_bytes"`
+	// MaxGoroutines is the most goroutines running before new requests are shed
+	MaxGoroutines int `json:"max_goroutines"`
+	// MaxInFlight is the most requests served at once before new ones are shed
+	MaxInFlight int `json:"max_in_flight"`
+	// RetryAfter is how long shed requests are told to wait before retrying
+	RetryAfter time.Duration `json:"-"`
+}
+
+// ShedderStats is a snapshot of a shedder's readings and of the requests it shed
+type ShedderStats struct {
+	ShedderConfig
+	HeapBytes  int64 `json:"heap_bytes"`
+	Goroutines int   `json:"goroutines"`
+	InFlight   int   `json:"in_flight"`
+	// Shed counts the requests shed per resource
+	Shed       map[string]int64 `json:"shed"`
+	TotalShed  int64            `json:"total_shed"`
+	LastShedAt *time.Time       `json:"last_shed_at,omitempty"`
+}
+
+// Shedder rejects new requests outright while memory, goroutines or in-flight requests are
+// over their thresholds, so an overloaded process turns some requests away instead of
+// slowing down every stream it serves
+type Shedder struct {
+	mu         sync.Mutex
+	config     ShedderConfig
+	inFlight   int
+	heapBytes  int64
+	heapReadAt time.Time
+	shed       map[string]int64
+	lastShedAt time.Time
+
+	readHeap   func() int64
+	goroutines func() int
+}
+
+var (
+	defaultShedder     *Shedder
+	defaultShedderOnce sync.Once
+)
+
+// NewShedder creates a shedder enforcing config against the process's own resources
+func NewShedder(config ShedderConfig) *Shedder {
+	s := &Shedder{
+		shed:       make(map[string]int64),
+		readHeap:   readHeapBytes,
+		goroutines: runtime.NumGoroutine,
+	}
+	s.Configure(config)
+	return s
+}
+
+// DefaultShedder returns the process-wide shedder, configured by LOAD_SHED_MAX_HEAP_BYTES,
+// LOAD_SHED_MAX_GOROUTINES, LOAD_SHED_MAX_IN_FLIGHT and LOAD_SHED_RETRY_AFTER (seconds);
+// every threshold is disabled by default
+func DefaultShedder() *Shedder {
+	defaultShedderOnce.Do(func() {
+		defaultShedder = NewShedder(ShedderConfig{
+			MaxHeapBytes:  int64(utils.GetEnvInt("LOAD_SHED_MAX_HEAP_BYTES", 0)),
+			MaxGoroutines: utils.GetEnvInt("LOAD_SHED_MAX_GOROUTINES", 0),
+			MaxInFlight:   utils.GetEnvInt("LOAD_SHED_MAX_IN_FLIGHT", 0),
+			RetryAfter:    utils.GetEnvDuration("LOAD_SHED_RETRY_AFTER", DefaultShedRetryAfter),
+		})
+	})
+	return defaultShedder
+}
+
+// Configure replaces the thresholds; requests already admitted are unaffected
+func (s *Shedder) Configure(config ShedderConfig) {
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = DefaultShedRetryAfter
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = config
+}
+
+// Enabled reports whether any threshold is configured
+func (s *Shedder) Enabled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config.MaxHeapBytes > 0 || s.config.MaxGoroutines > 0 || s.config.MaxInFlight > 0
+}
+
+// RetryAfter is how long shed requests are told to wait before retrying
+func (s *Shedder) RetryAfter() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config.RetryAfter
+}
+
+// Admit lets a request in unless a resource is over its threshold, in which case it returns
+// a ShedError naming it. The returned release must be called once the request is served
+// and is idempotent
+func (s *Shedder) Admit() (release func(), err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if shedErr := s.overloaded(); shedErr != nil {
+		s.shed[shedErr.Reason]++
+		s.lastShedAt = time.Now().UTC()
+		return nil, shedErr
+	}
+	s.inFlight++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.inFlight--
+		})
+	}, nil
+}
+
+// Stats returns a snapshot of the shedder's readings and counters
+func (s *Shedder) Stats() ShedderStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := ShedderStats{
+		ShedderConfig: s.config,
+		HeapBytes:     s.heap(),
+		Goroutines:    s.goroutines(),
+		InFlight:      s.inFlight,
+		Shed:          make(map[string]int64, len(s.shed)),
+	}
+	for reason, count := range s.shed {
+		stats.Shed[reason] = count
+		stats.TotalShed += count
+	}
+	if !s.lastShedAt.IsZero() {
+		lastShedAt := s.lastShedAt
+		stats.LastShedAt = &lastShedAt
+	}
+	return stats
+}
+
+// overloaded returns the first resource over its threshold, or nil
+func (s *Shedder) overloaded() *ShedError {
+	if limit := s.config.MaxInFlight; limit > 0 && s.inFlight >= limit {
+		return &ShedError{Reason: ShedReasonInFlight, Value: int64(s.inFlight), Threshold: int64(limit)}
+	}
+	if limit := s.config.MaxGoroutines; limit > 0 {
+		if count := s.goroutines(); count >= limit {
+			return &ShedError{Reason: ShedReasonGoroutines, Value: int64(count), Threshold: int64(limit)}
+		}
+	}
+	if limit := s.config.MaxHeapBytes; limit > 0 {
+		if heap := s.heap(); heap >= limit {
+			return &ShedError{Reason: ShedReasonMemory, Value: heap, Threshold: limit}
+		}
+	}
+	return nil
+}
+
+// heap returns the heap in use, read at most once per heapSampleInterval
+func (s *Shedder) heap() int64 {
+	if now := time.Now(); now.Sub(s.heapReadAt) >= heapSampleInterval {
+		s.heapBytes = s.readHeap()
+		s.heapReadAt = now
+	}
+	return s.heapBytes
+}
+
+// readHeapBytes reads the heap in use from the runtime metrics, which unlike
+// runtime.ReadMemStats does not stop the world
+func readHeapBytes() int64 {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return int64(sample[0].Value.Uint64())
+}
@@ -0,0 +1,54 @@
+package admission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShedder_Admit(t *testing.T) {
+	s := NewShedder(ShedderConfig{MaxHeapBytes: 1000, MaxGoroutines: 50, MaxInFlight: 2})
+	heap, goroutines := int64(100), 10
+	s.readHeap = func() int64 { return heap }
+	s.goroutines = func() int { return goroutines }
+	assert.True(t, s.Enabled())
+
+	first, err := s.Admit()
+	require.NoError(t, err)
+	second, err := s.Admit()
+	require.NoError(t, err)
+	_, err = s.Admit()
+	var shedErr *ShedError
+	require.ErrorAs(t, err, &shedErr)
+	assert.Equal(t, ShedReasonInFlight, shedErr.Reason)
+
+	first()
+	first()
+	second()
+	goroutines = 50
+	_, err = s.Admit()
+	require.ErrorAs(t, err, &shedErr)
+	assert.Equal(t, ShedReasonGoroutines, shedErr.Reason)
+
+	goroutines = 10
+	heap = 2000
+	s.heapReadAt = s.heapReadAt.Add(-heapSampleInterval)
+	_, err = s.Admit()
+	require.ErrorAs(t, err, &shedErr)
+	assert.Equal(t, ShedReasonMemory, shedErr.Reason)
+	assert.EqualValues(t, 2000, shedErr.Value)
+
+	stats := s.Stats()
+	assert.Equal(t, 0, stats.InFlight)
+	assert.Equal(t, map[string]int64{ShedReasonInFlight: 1, ShedReasonGoroutines: 1, ShedReasonMemory: 1}, stats.Shed)
+	assert.EqualValues(t, 3, stats.TotalShed)
+	assert.NotNil(t, stats.LastShedAt)
+
+	assert.False(t, NewShedder(ShedderConfig{}).Enabled())
+	assert.Equal(t, DefaultShedRetryAfter, NewShedder(ShedderConfig{}).RetryAfter())
+}
+
+func TestReadHeapBytes(t *testing.T) {
+	assert.Positive(t, readHeapBytes())
+}
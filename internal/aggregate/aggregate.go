@@ -0,0 +1,469 @@
+// Package aggregate runs map-reduce workloads: a prompt template is applied to every document
+// of a request in concurrent chat completions (map), then one completion combines their
+// results (reduce)
+package aggregate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Template placeholders
+const (
+	PlaceholderDocument = "{{document}}"
+	PlaceholderID       = "{{id}}"
+	PlaceholderIndex    = "{{index}}"
+	PlaceholderResults  = "{{results}}"
+)
+
+// Statuses of an aggregation and of its steps
+const (
+	StatusCompleted = "completed"
+	StatusPartial   = "partial"
+	StatusFailed    = "failed"
+	StatusSucceeded = "succeeded"
+)
+
+// Progress events sent while an aggregation runs
+const (
+	EventStarted       = "aggregate.started"
+	EventMapCompleted  = "map.completed"
+	EventReduceStarted = "reduce.started"
+	EventCompleted     = "aggregate.completed"
+	EventFailed        = "aggregate.failed"
+)
+
+const (
+	// chatCompletionsPath is where map and reduce completions are sent
+	chatCompletionsPath = "/v1/chat/completions"
+	// DefaultConcurrency is how many documents of one request are mapped at once when it sets no concurrency
+	DefaultConcurrency = 4
+	// DefaultMaxConcurrency is how many map completions run at once across all requests when AGGREGATE_MAX_CONCURRENCY is unset
+	DefaultMaxConcurrency = 16
+	// DefaultMaxDocuments caps the documents of one request when AGGREGATE_MAX_DOCUMENTS is unset
+	DefaultMaxDocuments = 100
+	// DefaultReducePrompt combines the map results when a request sets no reduce_prompt
+	DefaultReducePrompt = "Combine the following results, one per document, into a single coherent answer.\n\n" + PlaceholderResults
+)
+
+// ErrInvalidRequest is returned for requests that cannot be aggregated
+var ErrInvalidRequest = errors.New("invalid aggregate request")
+
+// Document is one input of the map step
+type Document struct {
+	ID   string `json:"id,omitempty" example:"doc-1"`
+	Text string `json:"text" example:"Quarterly revenue grew 12%..."`
+}
+
+// Request represents a request to the aggregate endpoint
+type Request struct {
+	Model     string     `json:"model" example:"gpt-4o"`
+	Documents []Document `json:"documents"`
+	// Prompt is the map template; {{document}} is replaced by each document's text, {{id}} and {{index}} by its ID and position
+	Prompt string `json:"prompt" example:"Summarize this report in three bullet points:\n\n{{document}}"`
+	// ReducePrompt is the reduce template; {{results}} is replaced by the map outputs
+	ReducePrompt string   `json:"reduce_prompt,omitempty" example:"Merge these summaries into one executive summary:\n\n{{results}}"`
+	System       string   `json:"system,omitempty" example:"You are a financial analyst."`
+	Concurrency  int      `json:"concurrency,omitempty" example:"4"`
+	MaxTokens    int      `json:"max_tokens,omitempty" example:"512"`
+	Temperature  *float64 `json:"temperature,omitempty" example:"0.2"`
+	Stream       bool     `json:"stream,omitempty" example:"false"`
+}
+
+// Usage is the token usage of a completion or the sum over an aggregation
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens" example:"120"`
+	CompletionTokens int `json:"completion_tokens" example:"40"`
+	TotalTokens      int `json:"total_tokens" example:"160"`
+}
+
+// StepError describes why a completion failed
+type StepError struct {
+	StatusCode int    `json:"status_code,omitempty" example:"503"`
+	Message    string `json:"message" example:"no vendor available"`
+}
+
+// MapResult is the outcome of the map completion of one document
+type MapResult struct {
+	Index  int        `json:"index" example:"0"`
+	ID     string     `json:"id" example:"doc-1"`
+	Status string     `json:"status" example:"succeeded"`
+	Output string     `json:"output,omitempty"`
+	Usage  *Usage     `json:"usage,omitempty"`
+	Error  *StepError `json:"error,omitempty"`
+}
+
+// ReduceResult is the outcome of the reduce completion
+type ReduceResult struct {
+	Status string     `json:"status" example:"succeeded"`
+	Inputs int        `json:"inputs" example:"3"`
+	Output string     `json:"output,omitempty"`
+	Usage  *Usage     `json:"usage,omitempty"`
+	Error  *StepError `json:"error,omitempty"`
+}
+
+// Counts counts the documents by map outcome
+type Counts struct {
+	Total     int `json:"total" example:"3"`
+	Succeeded int `json:"succeeded" example:"2"`
+	Failed    int `json:"failed" example:"1"`
+}
+
+// Response is the result of an aggregation
+// Status is completed when every step succeeded, partial when some documents failed but the
+// reduce step succeeded, and failed when no document succeeded or the reduce step failed
+type Response struct {
+	ID      string        `json:"id" example:"agg_abc123"`
+	Object  string        `json:"object" example:"aggregate"`
+	Created int64         `json:"created" example:"1735689600"`
+	Model   string        `json:"model" example:"gpt-4o"`
+	Status  string        `json:"status" example:"partial"`
+	Output  string        `json:"output"`
+	Counts  Counts        `json:"counts"`
+	Map     []MapResult   `json:"map"`
+	Reduce  *ReduceResult `json:"reduce"`
+	Usage   Usage         `json:"usage"`
+}
+
+// Event is a progress event of an aggregation
+type Event struct {
+	Type string
+	Data interface{}
+}
+
+// Runner runs aggregations through a chat completions handler
+type Runner struct {
+	// Handler serves the chat completions of aggregations
+	Handler      http.Handler
+	MaxDocuments int
+	// slots bounds how many map completions run at once across all aggregations
+	slots chan struct{}
+	now   func() time.Time
+}
+
+var (
+	defaultRunner   *Runner
+	defaultRunnerMu sync.RWMutex
+)
+
+// NewRunner creates a runner running at most maxConcurrency map completions at once
+func NewRunner(handler http.Handler, maxConcurrency, maxDocuments int) *Runner {
+	if maxConcurrency <= 0 {
+		maxConcurrency = DefaultMaxConcurrency
+	}
+	if maxDocuments <= 0 {
+		maxDocuments = DefaultMaxDocuments
+	}
+	return &Runner{
+		Handler:      handler,
+		MaxDocuments: maxDocuments,
+		slots:        make(chan struct{}, maxConcurrency),
+		now:          time.Now,
+	}
+}
+
+// NewRunnerFromEnv creates a runner limited by AGGREGATE_MAX_CONCURRENCY and AGGREGATE_MAX_DOCUMENTS
+func NewRunnerFromEnv(handler http.Handler) *Runner {
+	return NewRunner(handler,
+		utils.GetEnvInt("AGGREGATE_MAX_CONCURRENCY", DefaultMaxConcurrency),
+		utils.GetEnvInt("AGGREGATE_MAX_DOCUMENTS", DefaultMaxDocuments),
+	)
+}
+
+// Default returns the process-wide runner, nil until SetDefault is called
+func Default() *Runner {
+	defaultRunnerMu.RLock()
+	defer defaultRunnerMu.RUnlock()
+	return defaultRunner
+}
+
+// SetDefault replaces the process-wide runner
+func SetDefault(runner *Runner) {
+	defaultRunnerMu.Lock()
+	defer defaultRunnerMu.Unlock()
+	defaultRunner = runner
+}
+
+// Validate checks a request and fills in its defaults
+func (ru *Runner) Validate(req *Request) error {
+	switch {
+	case len(req.Documents) == 0:
+		return fmt.Errorf("%w: documents must not be empty", ErrInvalidRequest)
+	case len(req.Documents) > ru.MaxDocuments:
+		return fmt.Errorf("%w: at most %d documents are allowed", ErrInvalidRequest, ru.MaxDocuments)
+	case !strings.Contains(req.Prompt, PlaceholderDocument):
+		return fmt.Errorf("%w: prompt must contain %s", ErrInvalidRequest, PlaceholderDocument)
+	case req.ReducePrompt != "" && !strings.Contains(req.ReducePrompt, PlaceholderResults):
+		return fmt.Errorf("%w: reduce_prompt must contain %s", ErrInvalidRequest, PlaceholderResults)
+	case req.Concurrency < 0:
+		return fmt.Errorf("%w: concurrency must not be negative", ErrInvalidRequest)
+	}
+	seen := make(map[string]bool, len(req.Documents))
+	for i := range req.Documents {
+		if req.Documents[i].ID == "" {
+			req.Documents[i].ID = strconv.Itoa(i)
+		}
+		if seen[req.Documents[i].ID] {
+			return fmt.Errorf("%w: document id %q is used more than once", ErrInvalidRequest, req.Documents[i].ID)
+		}
+		seen[req.Documents[i].ID] = true
+	}
+	if req.ReducePrompt == "" {
+		req.ReducePrompt = DefaultReducePrompt
+	}
+	if req.Concurrency == 0 {
+		req.Concurrency = DefaultConcurrency
+	}
+	if req.Concurrency > cap(ru.slots) {
+		req.Concurrency = cap(ru.slots)
+	}
+	return nil
+}
+
+// Run maps every document of a validated request, then reduces the successful results
+// Completions are sent like the parent request: same API key and query parameters.
+// progress, when set, receives an event as each step finishes
+func (ru *Runner) Run(ctx context.Context, parent *http.Request, req *Request, progress func(Event)) *Response {
+	ctx = logger.WithComponent(ctx, "AggregateRunner")
+	ctx = logger.WithStage(ctx, "Run")
+	if progress == nil {
+		progress = func(Event) {}
+	}
+
+	response := &Response{
+		ID:      "agg_" + utils.GenerateShortID() + utils.GenerateShortID(),
+		Object:  "aggregate",
+		Created: ru.now().Unix(),
+		Model:   req.Model,
+		Counts:  Counts{Total: len(req.Documents)},
+		Map:     make([]MapResult, len(req.Documents)),
+	}
+	progress(Event{Type: EventStarted, Data: map[string]interface{}{"id": response.ID, "total": len(req.Documents)}})
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	requestSlots := make(chan struct{}, req.Concurrency)
+	for i, document := range req.Documents {
+		result := MapResult{Index: i, ID: document.ID}
+		if !ru.acquire(ctx, requestSlots) {
+			result.Status = StatusFailed
+			result.Error = &StepError{Message: "The request was cancelled before this document was mapped."}
+			ru.record(&mu, response, result, progress)
+			continue
+		}
+		wg.Add(1)
+		go func(result MapResult, document Document) {
+			defer wg.Done()
+			defer func() { <-ru.slots; <-requestSlots }()
+			prompt := strings.NewReplacer(
+				PlaceholderDocument, document.Text,
+				PlaceholderID, document.ID,
+				PlaceholderIndex, strconv.Itoa(result.Index),
+			).Replace(req.Prompt)
+			result.Output, result.Usage, result.Error = ru.complete(ctx, parent, req, prompt)
+			result.Status = StatusSucceeded
+			if result.Error != nil {
+				result.Status = StatusFailed
+			}
+			ru.record(&mu, response, result, progress)
+		}(result, document)
+	}
+	wg.Wait()
+
+	var results []string
+	for _, result := range response.Map {
+		if result.Status == StatusSucceeded {
+			results = append(results, fmt.Sprintf("Document %s:\n%s", result.ID, result.Output))
+		}
+	}
+	if len(results) == 0 {
+		response.Status = StatusFailed
+		logger.Warn(ctx, "Aggregation failed: no document was mapped",
+			"aggregate_id", response.ID,
+			"documents", response.Counts.Total,
+		)
+		progress(Event{Type: EventFailed, Data: response})
+		return response
+	}
+
+	progress(Event{Type: EventReduceStarted, Data: map[string]interface{}{"id": response.ID, "inputs": len(results)}})
+	reduce := &ReduceResult{Inputs: len(results), Status: StatusSucceeded}
+	prompt := strings.ReplaceAll(req.ReducePrompt, PlaceholderResults, strings.Join(results, "\n\n"))
+	reduce.Output, reduce.Usage, reduce.Error = ru.complete(ctx, parent, req, prompt)
+	response.Reduce = reduce
+	addUsage(&response.Usage, reduce.Usage)
+
+	switch {
+	case reduce.Error != nil:
+		reduce.Status = StatusFailed
+		response.Status = StatusFailed
+	case response.Counts.Failed > 0:
+		response.Status = StatusPartial
+	default:
+		response.Status = StatusCompleted
+	}
+	response.Output = reduce.Output
+	logger.Info(ctx, "Aggregation finished",
+		"aggregate_id", response.ID,
+		"status", response.Status,
+		"documents", response.Counts.Total,
+		"failed_documents", response.Counts.Failed,
+		"total_tokens", response.Usage.TotalTokens,
+	)
+	if response.Status == StatusFailed {
+		progress(Event{Type: EventFailed, Data: response})
+	} else {
+		progress(Event{Type: EventCompleted, Data: response})
+	}
+	return response
+}
+
+// acquire waits for a free slot of the request and of the runner
+// It returns false when the request was cancelled first
+func (ru *Runner) acquire(ctx context.Context, requestSlots chan struct{}) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case requestSlots <- struct{}{}:
+	}
+	select {
+	case <-ctx.Done():
+		<-requestSlots
+		return false
+	case ru.slots <- struct{}{}:
+		if ctx.Err() != nil {
+			<-ru.slots
+			<-requestSlots
+			return false
+		}
+		return true
+	}
+}
+
+// record stores the map result of a document and reports it
+func (ru *Runner) record(mu *sync.Mutex, response *Response, result MapResult, progress func(Event)) {
+	mu.Lock()
+	defer mu.Unlock()
+	response.Map[result.Index] = result
+	if result.Status == StatusSucceeded {
+		response.Counts.Succeeded++
+	} else {
+		response.Counts.Failed++
+	}
+	addUsage(&response.Usage, result.Usage)
+	progress(Event{Type: EventMapCompleted, Data: map[string]interface{}{
+		"id":     response.ID,
+		"result": result,
+		"counts": response.Counts,
+	}})
+}
+
+// complete sends one chat completion through the handler and returns its text
+func (ru *Runner) complete(ctx context.Context, parent *http.Request, req *Request, prompt string) (string, *Usage, *StepError) {
+	var messages []interface{}
+	if req.System != "" {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": req.System})
+	}
+	messages = append(messages, map[string]interface{}{"role": "user", "content": prompt})
+	payload := map[string]interface{}{"model": req.Model, "messages": messages}
+	if req.MaxTokens > 0 {
+		payload["max_tokens"] = req.MaxTokens
+	}
+	if req.Temperature != nil {
+		payload["temperature"] = *req.Temperature
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", nil, &StepError{Message: err.Error()}
+	}
+
+	target := chatCompletionsPath
+	if parent.URL.RawQuery != "" {
+		target += "?" + parent.URL.RawQuery
+	}
+	completionReq, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return "", nil, &StepError{Message: err.Error()}
+	}
+	completionReq.Header.Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	completionReq.Header.Set(utils.HeaderRequestID, utils.GenerateRequestID())
+	if auth := parent.Header.Get(utils.HeaderAuthorization); auth != "" {
+		completionReq.Header.Set(utils.HeaderAuthorization, auth)
+	}
+
+	recorder := newResponseRecorder()
+	ru.Handler.ServeHTTP(recorder, completionReq)
+	if recorder.status != http.StatusOK {
+		return "", nil, &StepError{StatusCode: recorder.status, Message: errorMessage(recorder.body.Bytes())}
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage *Usage `json:"usage"`
+	}
+	if err := json.Unmarshal(recorder.body.Bytes(), &completion); err != nil || len(completion.Choices) == 0 {
+		return "", nil, &StepError{StatusCode: http.StatusBadGateway, Message: "The vendor returned an invalid chat completion."}
+	}
+	return completion.Choices[0].Message.Content, completion.Usage, nil
+}
+
+// errorMessage extracts the message of an error response, which may be plain text
+func errorMessage(body []byte) string {
+	var decoded struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &decoded); err == nil && decoded.Error.Message != "" {
+		return decoded.Error.Message
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// addUsage adds the usage of one completion to a total
+func addUsage(total, usage *Usage) {
+	if usage == nil {
+		return
+	}
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+}
+
+// responseRecorder captures the response of a completion
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	return r.body.Write(p)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
@@ -0,0 +1,182 @@
+package aggregate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCompletions answers with the upper-cased prompt, failing prompts containing "FAIL"
+type fakeCompletions struct {
+	mu       sync.Mutex
+	prompts  []string
+	queries  []string
+	auths    []string
+	inFlight int32
+	peak     int32
+	delay    time.Duration
+}
+
+func (f *fakeCompletions) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	current := atomic.AddInt32(&f.inFlight, 1)
+	defer atomic.AddInt32(&f.inFlight, -1)
+	for {
+		peak := atomic.LoadInt32(&f.peak)
+		if current <= peak || atomic.CompareAndSwapInt32(&f.peak, peak, current) {
+			break
+		}
+	}
+	time.Sleep(f.delay)
+
+	var body struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	prompt := body.Messages[len(body.Messages)-1].Content
+	f.mu.Lock()
+	f.prompts = append(f.prompts, prompt)
+	f.queries = append(f.queries, r.URL.RawQuery)
+	f.auths = append(f.auths, r.Header.Get("Authorization"))
+	f.mu.Unlock()
+
+	if strings.Contains(prompt, "FAIL") {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":{"message":"no vendor available"}}`))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"choices": []interface{}{map[string]interface{}{"message": map[string]interface{}{"role": "assistant", "content": strings.ToUpper(prompt)}}},
+		"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15},
+	})
+}
+
+func parentRequest() *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/v1/aggregate?vendor=openai", nil)
+	r.Header.Set("Authorization", "Bearer sk-client")
+	return r
+}
+
+func TestValidate(t *testing.T) {
+	runner := NewRunner(http.NotFoundHandler(), 2, 3)
+	docs := []Document{{Text: "a"}, {Text: "b"}}
+
+	tests := []struct {
+		name string
+		req  Request
+	}{
+		{"no documents", Request{Prompt: "{{document}}"}},
+		{"too many documents", Request{Prompt: "{{document}}", Documents: []Document{{Text: "a"}, {Text: "b"}, {Text: "c"}, {Text: "d"}}}},
+		{"prompt without placeholder", Request{Prompt: "Summarize", Documents: docs}},
+		{"reduce prompt without placeholder", Request{Prompt: "{{document}}", ReducePrompt: "Combine", Documents: docs}},
+		{"negative concurrency", Request{Prompt: "{{document}}", Concurrency: -1, Documents: docs}},
+		{"duplicate IDs", Request{Prompt: "{{document}}", Documents: []Document{{ID: "x", Text: "a"}, {ID: "x", Text: "b"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, runner.Validate(&tt.req), ErrInvalidRequest)
+		})
+	}
+
+	req := Request{Prompt: "{{document}}", Concurrency: 10, Documents: []Document{{Text: "a"}, {ID: "b", Text: "b"}}}
+	require.NoError(t, runner.Validate(&req))
+	assert.Equal(t, "0", req.Documents[0].ID)
+	assert.Equal(t, "b", req.Documents[1].ID)
+	assert.Equal(t, DefaultReducePrompt, req.ReducePrompt)
+	assert.Equal(t, 2, req.Concurrency, "concurrency is capped by the runner")
+}
+
+func TestRun_PartialFailure(t *testing.T) {
+	completions := &fakeCompletions{delay: 20 * time.Millisecond}
+	runner := NewRunner(completions, 8, 10)
+	req := &Request{
+		Model:        "gpt-4o",
+		Prompt:       "summarize {{id}}#{{index}}: {{document}}",
+		ReducePrompt: "combine:\n{{results}}",
+		Concurrency:  2,
+		Documents:    []Document{{ID: "a", Text: "one"}, {ID: "b", Text: "FAIL"}, {ID: "c", Text: "three"}, {ID: "d", Text: "four"}},
+	}
+	require.NoError(t, runner.Validate(req))
+
+	var events []Event
+	response := runner.Run(context.Background(), parentRequest(), req, func(event Event) {
+		events = append(events, event)
+	})
+
+	assert.Equal(t, StatusPartial, response.Status)
+	assert.Equal(t, Counts{Total: 4, Succeeded: 3, Failed: 1}, response.Counts)
+	assert.LessOrEqual(t, atomic.LoadInt32(&completions.peak), int32(2))
+
+	require.Len(t, response.Map, 4)
+	assert.Equal(t, "SUMMARIZE A#0: ONE", response.Map[0].Output)
+	assert.Equal(t, StatusFailed, response.Map[1].Status)
+	assert.Equal(t, &StepError{StatusCode: http.StatusServiceUnavailable, Message: "no vendor available"}, response.Map[1].Error)
+
+	require.NotNil(t, response.Reduce)
+	assert.Equal(t, 3, response.Reduce.Inputs)
+	assert.Equal(t, response.Reduce.Output, response.Output)
+	assert.Contains(t, response.Output, "DOCUMENT A:\nSUMMARIZE A#0: ONE")
+	assert.NotContains(t, response.Output, "DOCUMENT B")
+	assert.Equal(t, Usage{PromptTokens: 40, CompletionTokens: 20, TotalTokens: 60}, response.Usage)
+
+	for i := range completions.queries {
+		assert.Equal(t, "vendor=openai", completions.queries[i])
+		assert.Equal(t, "Bearer sk-client", completions.auths[i])
+	}
+
+	require.Len(t, events, 7)
+	assert.Equal(t, EventStarted, events[0].Type)
+	for _, event := range events[1:5] {
+		assert.Equal(t, EventMapCompleted, event.Type)
+	}
+	assert.Equal(t, EventReduceStarted, events[5].Type)
+	assert.Equal(t, EventCompleted, events[6].Type)
+}
+
+func TestRun_Failures(t *testing.T) {
+	tests := []struct {
+		name         string
+		documents    []Document
+		reducePrompt string
+		reduced      bool
+	}{
+		{"every document fails", []Document{{Text: "FAIL"}, {Text: "FAIL too"}}, "{{results}}", false},
+		{"reduce fails", []Document{{Text: "one"}}, "FAIL {{results}}", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := NewRunner(&fakeCompletions{}, 4, 10)
+			req := &Request{Prompt: "{{document}}", ReducePrompt: tt.reducePrompt, Documents: tt.documents}
+			require.NoError(t, runner.Validate(req))
+
+			var last Event
+			response := runner.Run(context.Background(), parentRequest(), req, func(event Event) { last = event })
+			assert.Equal(t, StatusFailed, response.Status)
+			assert.Equal(t, EventFailed, last.Type)
+			assert.Equal(t, tt.reduced, response.Reduce != nil)
+		})
+	}
+}
+
+func TestRun_Cancelled(t *testing.T) {
+	runner := NewRunner(&fakeCompletions{}, 4, 10)
+	req := &Request{Prompt: "{{document}}", Documents: []Document{{Text: "one"}, {Text: "two"}}}
+	require.NoError(t, runner.Validate(req))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	response := runner.Run(ctx, parentRequest(), req, nil)
+	assert.Equal(t, StatusFailed, response.Status)
+	assert.Equal(t, Counts{Total: 2, Failed: 2}, response.Counts)
+}
@@ -0,0 +1,128 @@
+// Package alias resolves model aliases: virtual model names, such as "smart" or "fast", that
+// requests may name to be routed within a pool of configured models
+package alias
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+)
+
+// Pools holds the configured aliases, each mapping a name to the models it routes to
+type Pools struct {
+	mu    sync.RWMutex
+	pools map[string][]string
+}
+
+var (
+	defaultPools     *Pools
+	defaultPoolsOnce sync.Once
+)
+
+// NewPools creates an alias set with no aliases
+func NewPools() *Pools {
+	return &Pools{pools: make(map[string][]string)}
+}
+
+// Default returns the process-wide alias set
+func Default() *Pools {
+	defaultPoolsOnce.Do(func() {
+		defaultPools = NewPools()
+	})
+	return defaultPools
+}
+
+// Configure replaces the aliases. Members are given as "model" (any vendor) or
+// "vendor/model" and must name configured models; an alias may not share the name of a
+// configured model. When any alias is invalid the error names it and the previous aliases
+// are kept
+func (p *Pools) Configure(aliases map[string][]string, models []config.VendorModel) error {
+	pools := make(map[string][]string, len(aliases))
+	for name, members := range aliases {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("alias names must not be empty")
+		}
+		if len(members) == 0 {
+			return fmt.Errorf("alias %s: names no models", name)
+		}
+		for _, model := range models {
+			if model.Model == name {
+				return fmt.Errorf("alias %s: shadows the configured model %s/%s", name, model.Vendor, model.Model)
+			}
+		}
+		for _, member := range members {
+			if len(matching(member, models)) == 0 {
+				return fmt.Errorf("alias %s: %s is not a configured model", name, member)
+			}
+		}
+		pools[name] = append([]string(nil), members...)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pools = pools
+	return nil
+}
+
+// Names returns the configured alias names, sorted
+func (p *Pools) Names() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	names := make([]string, 0, len(p.pools))
+	for name := range p.pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Members returns the models an alias routes to, and false when name is not an alias
+func (p *Pools) Members(name string) ([]string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	members, ok := p.pools[name]
+	return append([]string(nil), members...), ok
+}
+
+// Filter restricts a routing pool to the models of the alias a request names, removing
+// credentials left without models, and reports whether model is an alias. The pool is
+// returned unchanged for other model names; it is empty when no model of the alias is in it
+func (p *Pools) Filter(model string, creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel, bool) {
+	members, ok := p.Members(model)
+	if !ok {
+		return creds, models, false
+	}
+
+	var keptModels []config.VendorModel
+	for _, candidate := range models {
+		for _, member := range members {
+			if matches(member, candidate) {
+				keptModels = append(keptModels, candidate)
+				break
+			}
+		}
+	}
+	return filter.CredentialsForModels(creds, keptModels), keptModels, true
+}
+
+// matching returns the models a member names
+func matching(member string, models []config.VendorModel) []config.VendorModel {
+	var matched []config.VendorModel
+	for _, model := range models {
+		if matches(member, model) {
+			matched = append(matched, model)
+		}
+	}
+	return matched
+}
+
+// matches reports whether member, "model" or "vendor/model", names model
+func matches(member string, model config.VendorModel) bool {
+	return member == model.Model || member == model.Vendor+"/"+model.Model
+}
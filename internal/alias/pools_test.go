@@ -0,0 +1,66 @@
+package alias
+
+import (
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testModels = []config.VendorModel{
+	{Vendor: "openai", Model: "gpt-4o"},
+	{Vendor: "anthropic", Model: "claude-sonnet"},
+	{Vendor: "gemini", Model: "gemini-flash"},
+	{Vendor: "groq", Model: "llama-3"},
+	{Vendor: "together", Model: "llama-3"},
+}
+
+func TestPools_Configure(t *testing.T) {
+	pools := NewPools()
+	require.NoError(t, pools.Configure(map[string][]string{"smart": {"gpt-4o", "anthropic/claude-sonnet"}}, testModels))
+
+	tests := []struct {
+		name        string
+		aliases     map[string][]string
+		expectedErr string
+	}{
+		{"empty name", map[string][]string{" ": {"gpt-4o"}}, "alias names must not be empty"},
+		{"no members", map[string][]string{"fast": nil}, "alias fast: names no models"},
+		{"unknown member", map[string][]string{"fast": {"gemini/llama-3"}}, "alias fast: gemini/llama-3 is not a configured model"},
+		{"shadows a model", map[string][]string{"gpt-4o": {"claude-sonnet"}}, "shadows the configured model openai/gpt-4o"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := pools.Configure(tt.aliases, testModels)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+	assert.Equal(t, []string{"smart"}, pools.Names(), "invalid aliases keep the previous ones")
+}
+
+func TestPools_Filter(t *testing.T) {
+	pools := NewPools()
+	require.NoError(t, pools.Configure(map[string][]string{
+		"smart": {"gpt-4o", "anthropic/claude-sonnet"},
+		"fast":  {"gemini-flash", "groq/llama-3"},
+	}, testModels))
+	assert.Equal(t, []string{"fast", "smart"}, pools.Names())
+
+	creds := []config.Credential{{Platform: "openai"}, {Platform: "gemini"}, {Platform: "groq"}, {Platform: "together"}}
+
+	keptCreds, keptModels, ok := pools.Filter("fast", creds, testModels)
+	require.True(t, ok)
+	assert.Equal(t, []config.VendorModel{testModels[2], testModels[3]}, keptModels)
+	assert.Equal(t, []config.Credential{{Platform: "gemini"}, {Platform: "groq"}}, keptCreds)
+
+	_, keptModels, ok = pools.Filter("smart", creds, testModels[2:])
+	assert.True(t, ok)
+	assert.Empty(t, keptModels, "an alias whose models left the pool has nothing to route to")
+
+	keptCreds, keptModels, ok = pools.Filter("gpt-4o", creds, testModels)
+	assert.False(t, ok)
+	assert.Equal(t, creds, keptCreds)
+	assert.Equal(t, testModels, keptModels)
+}
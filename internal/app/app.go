@@ -6,18 +6,38 @@ import (
 	"net/http"
 
 	_ "github.com/aashari/go-generative-api-router/docs/api" // This is necessary for Swagger documentation
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/aggregate"
+	"github.com/aashari/go-generative-api-router/internal/alias"
+	"github.com/aashari/go-generative-api-router/internal/batch"
+	"github.com/aashari/go-generative-api-router/internal/budget"
+	"github.com/aashari/go-generative-api-router/internal/cache"
+	"github.com/aashari/go-generative-api-router/internal/canary"
 	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/events"
+	"github.com/aashari/go-generative-api-router/internal/files"
 	"github.com/aashari/go-generative-api-router/internal/handlers"
 	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
 	"github.com/aashari/go-generative-api-router/internal/proxy"
+	"github.com/aashari/go-generative-api-router/internal/quarantine"
+	"github.com/aashari/go-generative-api-router/internal/ratelimit"
+	"github.com/aashari/go-generative-api-router/internal/rollout"
 	"github.com/aashari/go-generative-api-router/internal/router"
+	"github.com/aashari/go-generative-api-router/internal/schedule"
 	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/shared"
+	"github.com/aashari/go-generative-api-router/internal/state"
+	"github.com/aashari/go-generative-api-router/internal/tokenizer"
+	"github.com/aashari/go-generative-api-router/internal/tools"
+	"github.com/aashari/go-generative-api-router/internal/usage"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+	"github.com/aashari/go-generative-api-router/internal/vendorhealth"
 )
 
 // App centralizes the application's dependencies and configuration
 type App struct {
-	Credentials   []config.Credential
-	VendorModels  []config.VendorModel
+	Config        *config.Store
 	APIClient     *proxy.APIClient
 	ModelSelector selector.Selector
 	APIHandlers   *handlers.APIHandlers
@@ -38,11 +58,27 @@ func NewApp() (*App, error) {
 	}
 	models := modelsConfig.Models
 
+	// Self-hosted vendors such as Ollama need no API key
+	creds = config.WithKeylessCredentials(creds, models)
+
 	// Validate configuration
 	if validationErr := config.ValidateConfiguration(creds, models); validationErr != nil {
 		return nil, fmt.Errorf("configuration validation failed: %s", validationErr.Error())
 	}
 
+	if validationErr := config.ValidateBudgets(modelsConfig.Budgets, models); validationErr != nil {
+		return nil, fmt.Errorf("budget validation failed: %s", validationErr.Error())
+	}
+	budget.Default().Configure(modelsConfig.Budgets, models)
+
+	if err := schedule.Default().Configure(modelsConfig.Schedules); err != nil {
+		return nil, fmt.Errorf("schedule validation failed: %w", err)
+	}
+
+	if err := alias.Default().Configure(modelsConfig.Aliases, models); err != nil {
+		return nil, fmt.Errorf("alias validation failed: %w", err)
+	}
+
 	logger.Info(context.Background(), "Configuration loaded and validated",
 		"credentials_count", len(creds),
 		"vendor_model_pairs", len(models),
@@ -50,12 +86,168 @@ func NewApp() (*App, error) {
 		"stage", "ConfigurationLoaded",
 	)
 
+	// Load per-client-key routing permissions (unrestricted unless CLIENT_ACL_FILE is set)
+	acl, err := access.LoadACLFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client ACL: %w", err)
+	}
+	access.SetDefault(acl)
+
+	// Load the key store inbound client keys are checked against (any key is accepted unless CLIENT_AUTH_STORE is set)
+	keyStore, err := access.NewKeyStoreFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client key store: %w", err)
+	}
+	access.SetDefaultKeyStore(keyStore)
+
+	// Load the server-side tools models may call (none unless TOOLS_FILE is set)
+	toolRegistry, err := tools.LoadRegistryFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server-side tools: %w", err)
+	}
+	tools.SetDefault(toolRegistry)
+
+	// Load the semantic response cache (disabled unless SEMANTIC_CACHE_FILE is set)
+	semanticCache, err := cache.LoadFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load semantic cache: %w", err)
+	}
+	cache.SetDefault(semanticCache)
+
+	// Load the tokenizers counting prompts for /v1/tokenize and /v1/count_tokens (TOKENIZER_DIR overrides the embedded ones)
+	tokenizers, err := tokenizer.NewRegistryFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizers: %w", err)
+	}
+	tokenizer.SetDefault(tokenizers)
+
 	// Database logging functionality has been removed
 
+	// Publish the validated configuration as the initial immutable snapshot
+	store := config.NewStore(config.NewSnapshot(config.Data{
+		Credentials: creds,
+		Models:      models,
+		Vendors:     modelsConfig.Vendors,
+	}))
+
+	// Subscribe cross-cutting features to request lifecycle events
+	subscribeLifecycleEvents(events.Default())
+
 	// Initialize components
-	apiClient := proxy.NewAPIClient(modelsConfig.Vendors)
-	modelSelector := selector.NewContextAwareSelector()
-	apiHandlers := handlers.NewAPIHandlers(creds, models, apiClient, modelSelector)
+	apiClient := proxy.NewAPIClientForStore(store)
+
+	// Share conversation affinity, credential rotation and rate-limit cooldowns across replicas when REDIS_URL is set
+	sharedStore, err := shared.NewFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load shared state: %w", err)
+	}
+	if sharedStore != nil {
+		shared.SetDefault(sharedStore)
+		if err := sharedStore.Ping(context.Background()); err != nil {
+			logger.Warn(context.Background(), "Shared state store unreachable, routing on local state until it answers",
+				"error", err.Error(),
+				"component", "App",
+				"stage", "SharedStatePing",
+			)
+		}
+		syncInterval := utils.GetEnvDuration("SHARED_STATE_SYNC_INTERVAL", shared.DefaultSyncInterval)
+		ratelimit.Default().Share(context.Background(), sharedStore, syncInterval)
+		logger.Info(context.Background(), "Shared routing state enabled",
+			"sync_interval", syncInterval,
+			"component", "App",
+			"stage", "SharedStateStarted",
+		)
+	}
+
+	modelSelector, err := selector.NewFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	apiHandlers := handlers.NewAPIHandlers(store, apiClient, modelSelector)
+
+	// Store files API uploads in the backend selected by FILES_STORAGE (memory, disk or s3)
+	fileStore, err := files.NewStoreFromEnv(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load files: %w", err)
+	}
+	files.SetDefault(fileStore)
+
+	// Account token usage per client key, vendor and model in the store selected by USAGE_STORE (memory or redis)
+	usageStore, err := usage.NewStoreFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load usage store: %w", err)
+	}
+	usage.SetDefault(usageStore)
+
+	// Run Batch API jobs through the chat completions handler, persisting them when BATCH_DIR is set
+	batchManager, err := batch.NewManagerFromEnv(fileStore, http.HandlerFunc(apiHandlers.ChatCompletionsHandler))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load batches: %w", err)
+	}
+	batch.SetDefault(batchManager)
+	batchManager.Resume(context.Background())
+
+	// Run /v1/aggregate map-reduce completions through the chat completions handler
+	aggregate.SetDefault(aggregate.NewRunnerFromEnv(http.HandlerFunc(apiHandlers.ChatCompletionsHandler)))
+
+	// Restore rate-limit cooldowns, canary quarantine and vendor spend saved before a restart when STATE_FILE is set
+	// Canary results are only restored when the canary job runs to replace them
+	canaryJob := canary.NewJobFromEnv(store, apiClient)
+	var canaryStatus *canary.Status
+	if canaryJob != nil {
+		canaryStatus = canaryJob.Status
+	}
+	if persister := state.NewPersisterFromEnv(ratelimit.Default(), canaryStatus, budget.Default()); persister != nil {
+		if err := persister.Restore(); err != nil {
+			logger.Warn(context.Background(), "Starting without saved routing state",
+				"error", err.Error(),
+				"path", persister.Path,
+				"component", "App",
+				"stage", "StateRestore",
+			)
+		}
+		persister.Start(context.Background())
+		logger.Info(context.Background(), "Routing state persistence started",
+			"path", persister.Path,
+			"save_interval", persister.Interval,
+			"max_age", persister.MaxAge,
+			"component", "App",
+			"stage", "StatePersisterStarted",
+		)
+	}
+
+	// Start the canary job when CANARY_INTERVAL is set
+	if canaryJob != nil {
+		canaryJob.Start(context.Background())
+		logger.Info(context.Background(), "Canary job started",
+			"interval", canaryJob.Interval,
+			"max_latency", canaryJob.MaxLatency,
+			"component", "App",
+			"stage", "CanaryStarted",
+		)
+	}
+
+	// Probe every vendor when VENDOR_HEALTH_INTERVAL is set
+	if prober := vendorhealth.NewProberFromEnv(store, apiClient); prober != nil {
+		prober.Start(context.Background())
+		logger.Info(context.Background(), "Vendor health prober started",
+			"interval", prober.Interval,
+			"timeout", prober.Timeout,
+			"component", "App",
+			"stage", "VendorHealthStarted",
+		)
+	}
+
+	// Probe credentials quarantined after repeated 401/403/429 responses unless QUARANTINE_THRESHOLD is 0
+	if prober := quarantine.NewProberFromEnv(store, apiClient); prober != nil {
+		prober.Start(context.Background())
+		logger.Info(context.Background(), "Credential quarantine prober started",
+			"threshold", prober.Quarantine.Threshold(),
+			"interval", prober.Interval,
+			"component", "App",
+			"stage", "QuarantineProberStarted",
+		)
+	}
 
 	// Log configuration loaded with complete data
 	logger.Info(context.Background(), "Configuration loaded with complete data",
@@ -72,8 +264,7 @@ func NewApp() (*App, error) {
 	)
 
 	return &App{
-		Credentials:   creds,
-		VendorModels:  models,
+		Config:        store,
 		APIClient:     apiClient,
 		ModelSelector: modelSelector,
 		APIHandlers:   apiHandlers,
@@ -85,7 +276,91 @@ func (a *App) SetupRoutes() http.Handler {
 	return router.SetupRoutes(a.APIHandlers)
 }
 
+// SetupMetricsRoutes configures the routes of the separate metrics listener
+func (a *App) SetupMetricsRoutes() http.Handler {
+	return router.SetupMetricsRoutes(a.APIHandlers)
+}
+
 // Helper functions for comprehensive logging
+// subscribeLifecycleEvents registers the built-in request lifecycle subscribers
+func subscribeLifecycleEvents(bus *events.Bus) {
+	// Keep the final routing decision of every request for the admin decisions endpoint
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		if event.Decision != nil {
+			monitoring.DefaultDecisionLog().Record(*event.Decision)
+		}
+	}, events.RequestCompleted, events.RequestFailed)
+
+	// Ramp models on a rollout trial, or roll them back, from the outcome of every vendor attempt
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		failed := event.Error != "" || event.StatusCode >= http.StatusInternalServerError
+		rollout.Default().Observe(event.Vendor, event.Model, failed, event.Duration)
+	}, events.VendorResponded)
+
+	// Keep rolling latency and error-rate stats per model for the latency-aware selector
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		failed := event.Error != "" || event.StatusCode >= http.StatusInternalServerError
+		monitoring.DefaultLatencyTracker().Observe(event.Vendor, event.Model, failed, event.Duration)
+	}, events.VendorResponded)
+
+	// Charge vendors with a budget for the tokens of every completed request
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		decision := event.Decision
+		if decision == nil {
+			return
+		}
+		vendor, model := decision.Vendor, decision.Model
+		if decision.FallbackVendor != "" {
+			vendor, model = decision.FallbackVendor, decision.FallbackModel
+		}
+		budget.Default().Observe(vendor, model, decision.PromptTokens, decision.CompletionTokens)
+	}, events.RequestCompleted)
+
+	// Account the tokens of every request served, interrupted streams included
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		decision := event.Decision
+		if decision == nil || (event.Type == events.RequestFailed && decision.PromptTokens+decision.CompletionTokens == 0) {
+			return
+		}
+		vendor, model := decision.Vendor, decision.Model
+		if decision.FallbackVendor != "" {
+			vendor, model = decision.FallbackVendor, decision.FallbackModel
+		}
+		usage.Account(usage.Record{
+			ClientKey:        decision.ClientKeyID,
+			Vendor:           vendor,
+			Model:            model,
+			PromptTokens:     decision.PromptTokens,
+			CompletionTokens: decision.CompletionTokens,
+			Estimated:        decision.UsageEstimated,
+		})
+	}, events.RequestCompleted, events.RequestFailed)
+
+	// Count vendor attempts and requests for the Prometheus /metrics endpoint
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		monitoring.DefaultPrometheusMetrics().ObserveVendorResponse(event.Vendor, event.Model, event.StatusCode, event.Error != "", event.Duration)
+	}, events.VendorResponded)
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		if event.Decision != nil {
+			monitoring.DefaultPrometheusMetrics().ObserveRequest(*event.Decision, event.Duration)
+		}
+	}, events.RequestCompleted, events.RequestFailed)
+
+	// Trace every lifecycle step at debug level
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		logger.Debug(ctx, "Request lifecycle event",
+			"event_type", string(event.Type),
+			"vendor", event.Vendor,
+			"model", event.Model,
+			"status_code", event.StatusCode,
+			"duration_ms", event.Duration.Milliseconds(),
+			"error", event.Error,
+			"component", "EventBus",
+			"stage", "Lifecycle",
+		)
+	})
+}
+
 func getUniqueVendors(models []config.VendorModel) []string {
 	vendorMap := make(map[string]bool)
 	for _, model := range models {
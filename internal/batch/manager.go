@@ -0,0 +1,648 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/files"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/maintenance"
+	"github.com/aashari/go-generative-api-router/internal/middleware"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Batch statuses, as in the OpenAI Batch API
+const (
+	StatusValidating = "validating"
+	StatusFailed     = "failed"
+	StatusInProgress = "in_progress"
+	StatusFinalizing = "finalizing"
+	StatusCompleted  = "completed"
+	StatusExpired    = "expired"
+	StatusCancelling = "cancelling"
+	StatusCancelled  = "cancelled"
+)
+
+const (
+	// Endpoint is the only endpoint batches can target
+	Endpoint = "/v1/chat/completions"
+	// CompletionWindow is the only completion window batches accept
+	CompletionWindow = "24h"
+	// DefaultConcurrency is how many batch requests run at once when BATCH_CONCURRENCY is unset
+	DefaultConcurrency = 4
+	// MaxRequests caps the requests in one batch
+	MaxRequests = 50000
+	// maintenancePoll is how often a batch paused by maintenance mode checks whether it ended
+	maintenancePoll = time.Second
+	// checkpointEvery is how many results a running batch collects between writes to disk
+	checkpointEvery = 50
+)
+
+// Errors returned when creating or cancelling a batch
+var (
+	ErrInvalidBatch    = errors.New("invalid batch")
+	ErrAlreadyFinished = errors.New("batch has already finished")
+)
+
+// RequestCounts counts the requests of a batch by outcome
+type RequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// LineError describes why an input line was rejected or a request could not run
+type LineError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Line    *int   `json:"line,omitempty"`
+}
+
+// Errors is the list of validation errors of a failed batch
+type Errors struct {
+	Object string      `json:"object"`
+	Data   []LineError `json:"data"`
+}
+
+// Batch is an asynchronous job running the requests of an input file
+type Batch struct {
+	ID               string            `json:"id"`
+	Object           string            `json:"object"`
+	Endpoint         string            `json:"endpoint"`
+	Errors           *Errors           `json:"errors"`
+	InputFileID      string            `json:"input_file_id"`
+	CompletionWindow string            `json:"completion_window"`
+	Status           string            `json:"status"`
+	OutputFileID     *string           `json:"output_file_id"`
+	ErrorFileID      *string           `json:"error_file_id"`
+	CreatedAt        int64             `json:"created_at"`
+	InProgressAt     *int64            `json:"in_progress_at"`
+	ExpiresAt        int64             `json:"expires_at"`
+	FinalizingAt     *int64            `json:"finalizing_at"`
+	CompletedAt      *int64            `json:"completed_at"`
+	FailedAt         *int64            `json:"failed_at"`
+	ExpiredAt        *int64            `json:"expired_at"`
+	CancellingAt     *int64            `json:"cancelling_at"`
+	CancelledAt      *int64            `json:"cancelled_at"`
+	RequestCounts    RequestCounts     `json:"request_counts"`
+	Metadata         map[string]string `json:"metadata"`
+	// Owner is the SHA-256 hex digest of the client key that created the batch
+	Owner string `json:"owner,omitempty"`
+	// Results holds the output line of every finished request by custom_id, so a batch
+	// interrupted by a restart keeps the results it already has
+	Results map[string]json.RawMessage `json:"results,omitempty"`
+}
+
+// CreateRequest is the body of POST /v1/batches
+type CreateRequest struct {
+	InputFileID      string            `json:"input_file_id"`
+	Endpoint         string            `json:"endpoint"`
+	CompletionWindow string            `json:"completion_window"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+}
+
+// inputLine is one request of a batch input file
+type inputLine struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// Manager creates batches and runs their requests through a chat completions handler
+type Manager struct {
+	Store *Store
+	// Files holds the input, output and error files of batches
+	Files *files.Store
+	// Handler serves the chat completion requests of batches, once they are authenticated
+	// against the process-wide key store with the key that created the batch
+	Handler http.Handler
+	// slots bounds how many batch requests run at once across all batches
+	slots   chan struct{}
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+	now     func() time.Time
+}
+
+var (
+	defaultManager   *Manager
+	defaultManagerMu sync.RWMutex
+)
+
+// NewManager creates a manager running at most concurrency requests at once
+func NewManager(store *Store, fileStore *files.Store, handler http.Handler, concurrency int) *Manager {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	return &Manager{
+		Store:   store,
+		Files:   fileStore,
+		Handler: handler,
+		slots:   make(chan struct{}, concurrency),
+		cancels: make(map[string]context.CancelFunc),
+		now:     time.Now,
+	}
+}
+
+// NewManagerFromEnv creates a manager persisting batches to BATCH_DIR (in memory when unset)
+// and running BATCH_CONCURRENCY requests at once
+func NewManagerFromEnv(fileStore *files.Store, handler http.Handler) (*Manager, error) {
+	store, err := NewStore(utils.GetEnvString("BATCH_DIR", ""))
+	if err != nil {
+		return nil, err
+	}
+	return NewManager(store, fileStore, handler, utils.GetEnvInt("BATCH_CONCURRENCY", DefaultConcurrency)), nil
+}
+
+// Default returns the process-wide manager, nil until SetDefault is called
+func Default() *Manager {
+	defaultManagerMu.RLock()
+	defer defaultManagerMu.RUnlock()
+	return defaultManager
+}
+
+// SetDefault replaces the process-wide manager
+func SetDefault(manager *Manager) {
+	defaultManagerMu.Lock()
+	defer defaultManagerMu.Unlock()
+	defaultManager = manager
+}
+
+// Create validates the input file of a batch and starts running it for the client key
+// A batch whose input has invalid lines is returned with status failed and the line errors
+func (m *Manager) Create(clientKey string, request CreateRequest) (*Batch, error) {
+	if request.Endpoint != Endpoint {
+		return nil, fmt.Errorf("%w: endpoint must be %s", ErrInvalidBatch, Endpoint)
+	}
+	if request.CompletionWindow != CompletionWindow {
+		return nil, fmt.Errorf("%w: completion_window must be %s", ErrInvalidBatch, CompletionWindow)
+	}
+	ctx := context.Background()
+	input, err := m.Files.File(ctx, files.Owner(clientKey), request.InputFileID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: input file %s not found", ErrInvalidBatch, request.InputFileID)
+	}
+	if input.Purpose != files.PurposeBatch {
+		return nil, fmt.Errorf("%w: input file %s was not uploaded with purpose %q", ErrInvalidBatch, input.ID, files.PurposeBatch)
+	}
+	content, err := m.Files.Content(ctx, files.Owner(clientKey), input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	batch := &Batch{
+		ID:               newID("batch_"),
+		Object:           "batch",
+		Endpoint:         request.Endpoint,
+		InputFileID:      input.ID,
+		CompletionWindow: request.CompletionWindow,
+		Status:           StatusValidating,
+		CreatedAt:        now.Unix(),
+		ExpiresAt:        now.Add(24 * time.Hour).Unix(),
+		Metadata:         request.Metadata,
+		Owner:            files.Owner(clientKey),
+	}
+
+	lines, lineErrors := parseInput(content)
+	if len(lineErrors) > 0 {
+		batch.Status = StatusFailed
+		batch.FailedAt = unix(now)
+		batch.Errors = &Errors{Object: "list", Data: lineErrors}
+		return batch, m.Store.SaveBatch(batch)
+	}
+
+	batch.Status = StatusInProgress
+	batch.InProgressAt = unix(now)
+	batch.RequestCounts.Total = len(lines)
+	if err := m.Store.SaveBatch(batch); err != nil {
+		return nil, err
+	}
+	m.start(batch, lines, clientKey)
+	return batch, nil
+}
+
+// Cancel stops a batch; requests already running finish and their results are kept
+func (m *Manager) Cancel(clientKey, id string) (*Batch, error) {
+	finished := false
+	batch, err := m.Store.updateBatch(files.Owner(clientKey), id, true, func(batch *Batch) {
+		if finished = batch.finished(); !finished {
+			batch.Status = StatusCancelling
+			batch.CancellingAt = unix(m.now())
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if finished {
+		return nil, ErrAlreadyFinished
+	}
+
+	m.mu.Lock()
+	cancel, running := m.cancels[id]
+	m.mu.Unlock()
+	if running {
+		cancel()
+		return batch, nil
+	}
+	// A batch that is not running has nothing in flight and is finalized right away
+	lines, _ := parseInput(m.inputContent(batch))
+	return batch, m.finalize(batch, lines, StatusCancelled)
+}
+
+// Resume finalizes batches interrupted by a restart: client keys are never persisted, so
+// the remaining requests cannot be sent with the key that created them. The results the
+// batch already has are kept and the other requests are reported as expired
+func (m *Manager) Resume(ctx context.Context) {
+	for _, batch := range m.Store.unfinished() {
+		status := StatusExpired
+		if batch.Status == StatusCancelling {
+			status = StatusCancelled
+		}
+		lines, _ := parseInput(m.inputContent(batch))
+		if err := m.finalize(batch, lines, status); err != nil {
+			logger.Error(ctx, "Failed to finalize interrupted batch", err,
+				"batch_id", batch.ID,
+				"component", "BatchManager",
+				"stage", "Resume",
+			)
+			continue
+		}
+		logger.Warn(ctx, "Batch interrupted by restart was finalized",
+			"batch_id", batch.ID,
+			"status", status,
+			"completed_requests", batch.RequestCounts.Completed,
+			"failed_requests", batch.RequestCounts.Failed,
+			"component", "BatchManager",
+			"stage", "Resume",
+		)
+	}
+}
+
+// inputContent returns the input file of a batch, or nil when it is gone
+func (m *Manager) inputContent(batch *Batch) []byte {
+	content, err := m.Files.Content(context.Background(), batch.Owner, batch.InputFileID)
+	if err != nil {
+		return nil
+	}
+	return content
+}
+
+// start runs the requests of a batch in the background
+func (m *Manager) start(batch *Batch, lines []inputLine, clientKey string) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Unix(batch.ExpiresAt, 0))
+	m.mu.Lock()
+	m.cancels[batch.ID] = cancel
+	m.mu.Unlock()
+
+	go func() {
+		defer func() {
+			cancel()
+			m.mu.Lock()
+			delete(m.cancels, batch.ID)
+			m.mu.Unlock()
+		}()
+		m.run(ctx, batch.ID, lines, clientKey)
+	}()
+}
+
+// run sends every request of a batch, then writes its output and error files
+func (m *Manager) run(ctx context.Context, id string, lines []inputLine, clientKey string) {
+	ctx = logger.WithComponent(ctx, "BatchManager")
+	ctx = logger.WithStage(ctx, "Run")
+	ownerKey := files.Owner(clientKey)
+
+	var wg sync.WaitGroup
+	for _, line := range lines {
+		if !m.acquire(ctx) {
+			break
+		}
+		wg.Add(1)
+		go func(line inputLine) {
+			defer wg.Done()
+			defer func() { <-m.slots }()
+			m.record(ctx, ownerKey, id, line.CustomID, m.send(ctx, line, clientKey))
+		}(line)
+	}
+	wg.Wait()
+
+	batch, err := m.Store.Batch(ownerKey, id)
+	if err != nil {
+		logger.Error(ctx, "Batch disappeared while running", err, "batch_id", id)
+		return
+	}
+
+	status := StatusCompleted
+	switch {
+	case batch.Status == StatusCancelling:
+		status = StatusCancelled
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		status = StatusExpired
+	}
+	if err := m.finalize(batch, lines, status); err != nil {
+		logger.Error(ctx, "Failed to finalize batch", err, "batch_id", id)
+		return
+	}
+	logger.Info(ctx, "Batch finished",
+		"batch_id", id,
+		"status", status,
+		"completed_requests", batch.RequestCounts.Completed,
+		"failed_requests", batch.RequestCounts.Failed,
+	)
+}
+
+// record adds the result of one request to a running batch and updates its request counts
+func (m *Manager) record(ctx context.Context, ownerKey, id, customID string, result json.RawMessage) {
+	update := func(batch *Batch) {
+		if batch.Results == nil {
+			batch.Results = make(map[string]json.RawMessage)
+		}
+		batch.Results[customID] = result
+		if succeeded(result) {
+			batch.RequestCounts.Completed++
+		} else {
+			batch.RequestCounts.Failed++
+		}
+	}
+	batch, err := m.Store.updateBatch(ownerKey, id, false, update)
+	if err == nil && len(batch.Results)%checkpointEvery == 0 {
+		_, err = m.Store.updateBatch(ownerKey, id, true, func(*Batch) {})
+	}
+	if err != nil {
+		logger.Error(ctx, "Failed to record batch result", err,
+			"batch_id", id,
+			"custom_id", customID,
+		)
+	}
+}
+
+// acquire waits for a free request slot, pausing while maintenance mode is enabled
+// It returns false when the batch was cancelled or expired first
+func (m *Manager) acquire(ctx context.Context) bool {
+	for maintenance.Default().Status().Enabled {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(maintenancePoll):
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case m.slots <- struct{}{}:
+		if ctx.Err() != nil {
+			<-m.slots
+			return false
+		}
+		return true
+	}
+}
+
+// send runs one batch request through the handler and returns its output line
+func (m *Manager) send(ctx context.Context, line inputLine, clientKey string) json.RawMessage {
+	requestID := utils.GenerateRequestID()
+	request, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodPost, Endpoint, bytes.NewReader(line.Body))
+	if err != nil {
+		return outputLine(line.CustomID, requestID, 0, nil, &LineError{Code: "invalid_request", Message: err.Error()})
+	}
+	request.Header.Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	request.Header.Set(utils.HeaderRequestID, requestID)
+	if clientKey != "" {
+		request.Header.Set(utils.HeaderAuthorization, "Bearer "+clientKey)
+	}
+
+	// Each request is authenticated like an API request, so the key store identity's policy
+	// applies and a key disabled since the batch was created stops spending
+	recorder := newResponseRecorder()
+	middleware.AuthMiddleware(m.Handler).ServeHTTP(recorder, request)
+	body := bytes.TrimSpace(recorder.body.Bytes())
+	if !json.Valid(body) {
+		// Routing errors are written as plain text; wrap them as an OpenAI error
+		body, _ = json.Marshal(map[string]interface{}{
+			"error": map[string]string{"message": string(body), "type": "api_error"},
+		})
+	}
+	return outputLine(line.CustomID, requestID, recorder.status, body, nil)
+}
+
+// finalize writes the output and error files of a batch and records its final status
+// lines are the batch's requests; those without a result are reported in the error file
+func (m *Manager) finalize(batch *Batch, lines []inputLine, status string) error {
+	now := m.now()
+	batch.FinalizingAt = unix(now)
+
+	var output, errorLines bytes.Buffer
+	counts := RequestCounts{Total: batch.RequestCounts.Total}
+	for _, line := range lines {
+		result, ok := batch.Results[line.CustomID]
+		if !ok {
+			code, message := "batch_expired", "This request could not be executed before the completion window expired."
+			if status == StatusCancelled {
+				code, message = "batch_cancelled", "This request was not executed because the batch was cancelled."
+			}
+			result = outputLine(line.CustomID, "", 0, nil, &LineError{Code: code, Message: message})
+		}
+		if succeeded(result) {
+			counts.Completed++
+			output.Write(result)
+			output.WriteByte('\n')
+		} else {
+			counts.Failed++
+			errorLines.Write(result)
+			errorLines.WriteByte('\n')
+		}
+	}
+	batch.RequestCounts = counts
+
+	for _, file := range []struct {
+		content *bytes.Buffer
+		suffix  string
+		id      **string
+	}{
+		{&output, "output", &batch.OutputFileID},
+		{&errorLines, "errors", &batch.ErrorFileID},
+	} {
+		if file.content.Len() == 0 {
+			continue
+		}
+		stored, err := m.Files.Add(context.Background(), batch.Owner, fmt.Sprintf("%s_%s.jsonl", batch.ID, file.suffix), files.PurposeBatchOutput, file.content.Bytes())
+		if err != nil {
+			return err
+		}
+		*file.id = &stored.ID
+	}
+
+	batch.Status = status
+	switch status {
+	case StatusCompleted:
+		batch.CompletedAt = unix(now)
+	case StatusExpired:
+		batch.ExpiredAt = unix(now)
+	case StatusCancelled:
+		batch.CancelledAt = unix(now)
+	}
+	// Results are in the output and error files now
+	batch.Results = nil
+	return m.Store.SaveBatch(batch)
+}
+
+// parseInput parses a JSONL batch input file, returning every line that is not valid
+func parseInput(content []byte) ([]inputLine, []LineError) {
+	var lines []inputLine
+	var lineErrors []LineError
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		lineNumber := number
+		invalid := func(code, message string) {
+			lineErrors = append(lineErrors, LineError{Code: code, Message: message, Line: &lineNumber})
+		}
+
+		var line inputLine
+		if err := json.Unmarshal([]byte(text), &line); err != nil {
+			invalid("invalid_json_line", "This line is not parseable as valid JSON.")
+			continue
+		}
+		var body map[string]interface{}
+		switch {
+		case line.CustomID == "":
+			invalid("missing_required_parameter", "custom_id is required.")
+		case seen[line.CustomID]:
+			invalid("duplicate_custom_id", fmt.Sprintf("The custom_id %q is used more than once.", line.CustomID))
+		case strings.ToUpper(line.Method) != http.MethodPost:
+			invalid("invalid_method", "method must be POST.")
+		case line.URL != Endpoint:
+			invalid("invalid_url", fmt.Sprintf("url must be %s.", Endpoint))
+		case json.Unmarshal(line.Body, &body) != nil:
+			invalid("invalid_request", "body must be a JSON object.")
+		default:
+			seen[line.CustomID] = true
+			// Results are collected whole, so batch requests are never streamed
+			delete(body, "stream")
+			delete(body, "stream_options")
+			line.Body, _ = json.Marshal(body)
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		lineErrors = append(lineErrors, LineError{Code: "invalid_file", Message: err.Error()})
+	}
+	if len(lines) == 0 && len(lineErrors) == 0 {
+		lineErrors = append(lineErrors, LineError{Code: "empty_file", Message: "The input file contains no requests."})
+	}
+	if len(lines) > MaxRequests {
+		lineErrors = append(lineErrors, LineError{Code: "too_many_requests", Message: fmt.Sprintf("A batch can contain at most %d requests.", MaxRequests)})
+	}
+	return lines, lineErrors
+}
+
+// outputLine encodes the result of one request in the OpenAI batch output format
+func outputLine(customID, requestID string, status int, body json.RawMessage, lineErr *LineError) json.RawMessage {
+	result := map[string]interface{}{
+		"id":        newID("batch_req_"),
+		"custom_id": customID,
+		"response":  nil,
+		"error":     lineErr,
+	}
+	if lineErr == nil {
+		result["response"] = map[string]interface{}{
+			"status_code": status,
+			"request_id":  requestID,
+			"body":        body,
+		}
+	}
+	encoded, _ := json.Marshal(result)
+	return encoded
+}
+
+// succeeded reports whether an output line holds a 2xx response
+func succeeded(result json.RawMessage) bool {
+	var decoded struct {
+		Response *struct {
+			StatusCode int `json:"status_code"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(result, &decoded); err != nil || decoded.Response == nil {
+		return false
+	}
+	return decoded.Response.StatusCode >= 200 && decoded.Response.StatusCode < 300
+}
+
+// finished reports whether the batch reached a final status
+func (b *Batch) finished() bool {
+	switch b.Status {
+	case StatusFailed, StatusCompleted, StatusExpired, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// clone returns a deep copy of the batch
+func (b *Batch) clone() *Batch {
+	copied := *b
+	if b.Results != nil {
+		copied.Results = make(map[string]json.RawMessage, len(b.Results))
+		for customID, result := range b.Results {
+			copied.Results[customID] = result
+		}
+	}
+	if b.Metadata != nil {
+		copied.Metadata = make(map[string]string, len(b.Metadata))
+		for key, value := range b.Metadata {
+			copied.Metadata[key] = value
+		}
+	}
+	return &copied
+}
+
+// Response returns a copy of the batch as clients see it, without its owner and results
+func (b *Batch) Response() *Batch {
+	copied := b.clone()
+	copied.Owner = ""
+	copied.Results = nil
+	return copied
+}
+
+// unix returns a pointer to the Unix time of t
+func unix(t time.Time) *int64 {
+	seconds := t.Unix()
+	return &seconds
+}
+
+// responseRecorder captures the response of a batch request
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	return r.body.Write(p)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
@@ -0,0 +1,362 @@
+package batch
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoHandler answers every chat completion with the model it was asked for, failing for "bad"
+func echoHandler(calls *int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if _, streamed := body["stream"]; streamed {
+			http.Error(w, "stream must be stripped", http.StatusBadRequest)
+			return
+		}
+		if body["model"] == "bad" {
+			http.Error(w, "no vendor available", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"object": "chat.completion",
+			"model":  body["model"],
+			"auth":   r.Header.Get("Authorization"),
+		})
+	})
+}
+
+func inputFile(models ...string) []byte {
+	var lines []string
+	for i, model := range models {
+		line, _ := json.Marshal(map[string]interface{}{
+			"custom_id": "req-" + string(rune('a'+i)),
+			"method":    "POST",
+			"url":       Endpoint,
+			"body":      map[string]interface{}{"model": model, "stream": true},
+		})
+		lines = append(lines, string(line))
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+func newTestManager(t *testing.T, handler http.Handler, concurrency int) *Manager {
+	t.Helper()
+	store, err := NewStore("")
+	require.NoError(t, err)
+	fileStore, err := files.NewStore(t.Context(), files.NewMemoryBackend(), 0)
+	require.NoError(t, err)
+	return NewManager(store, fileStore, handler, concurrency)
+}
+
+func waitFinished(t *testing.T, m *Manager, clientKey, id string) *Batch {
+	t.Helper()
+	var batch *Batch
+	require.Eventually(t, func() bool {
+		var err error
+		batch, err = m.Store.Batch(files.Owner(clientKey), id)
+		return err == nil && batch.finished()
+	}, 5*time.Second, 10*time.Millisecond)
+	return batch
+}
+
+func readLines(t *testing.T, m *Manager, clientKey string, id *string) []map[string]interface{} {
+	t.Helper()
+	require.NotNil(t, id)
+	content, err := m.Files.Content(t.Context(), files.Owner(clientKey), *id)
+	require.NoError(t, err)
+	var lines []map[string]interface{}
+	for _, text := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(text), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestParseInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		requests int
+		codes    []string
+	}{
+		{
+			name:     "valid lines skipping blanks",
+			content:  string(inputFile("gpt-4o", "gpt-4o-mini")) + "\n\n",
+			requests: 2,
+		},
+		{
+			name:    "invalid json",
+			content: "{not json",
+			codes:   []string{"invalid_json_line"},
+		},
+		{
+			name: "invalid fields",
+			content: strings.Join([]string{
+				`{"method":"POST","url":"/v1/chat/completions","body":{}}`,
+				`{"custom_id":"a","method":"GET","url":"/v1/chat/completions","body":{}}`,
+				`{"custom_id":"b","method":"POST","url":"/v1/embeddings","body":{}}`,
+				`{"custom_id":"c","method":"POST","url":"/v1/chat/completions","body":[]}`,
+			}, "\n"),
+			codes: []string{"missing_required_parameter", "invalid_method", "invalid_url", "invalid_request"},
+		},
+		{
+			name: "duplicate custom_id",
+			content: strings.Join([]string{
+				`{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{}}`,
+				`{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{}}`,
+			}, "\n"),
+			requests: 1,
+			codes:    []string{"duplicate_custom_id"},
+		},
+		{
+			name:    "empty file",
+			content: "\n \n",
+			codes:   []string{"empty_file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, lineErrors := parseInput([]byte(tt.content))
+			assert.Len(t, lines, tt.requests)
+			var codes []string
+			for _, lineErr := range lineErrors {
+				codes = append(codes, lineErr.Code)
+			}
+			assert.Equal(t, tt.codes, codes)
+			for _, line := range lines {
+				assert.NotContains(t, string(line.Body), "stream")
+			}
+		})
+	}
+}
+
+func TestManagerRunsBatch(t *testing.T) {
+	var calls int32
+	m := newTestManager(t, echoHandler(&calls), 2)
+
+	file, err := m.Files.Upload(t.Context(), files.Owner("sk-client"), "input.jsonl", files.PurposeBatch, inputFile("gpt-4o", "bad", "gpt-4o-mini"))
+	require.NoError(t, err)
+	assert.Equal(t, "file", file.Object)
+	assert.True(t, strings.HasPrefix(file.ID, "file-"))
+
+	created, err := m.Create("sk-client", CreateRequest{InputFileID: file.ID, Endpoint: Endpoint, CompletionWindow: CompletionWindow, Metadata: map[string]string{"job": "nightly"}})
+	require.NoError(t, err)
+	assert.Equal(t, StatusInProgress, created.Status)
+	assert.Equal(t, 3, created.RequestCounts.Total)
+
+	batch := waitFinished(t, m, "sk-client", created.ID)
+	assert.Equal(t, StatusCompleted, batch.Status)
+	assert.Equal(t, RequestCounts{Total: 3, Completed: 2, Failed: 1}, batch.RequestCounts)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+	assert.NotNil(t, batch.CompletedAt)
+	assert.Nil(t, batch.Results)
+
+	output := readLines(t, m, "sk-client", batch.OutputFileID)
+	require.Len(t, output, 2)
+	for _, line := range output {
+		response := line["response"].(map[string]interface{})
+		assert.EqualValues(t, http.StatusOK, response["status_code"])
+		assert.Equal(t, "Bearer sk-client", response["body"].(map[string]interface{})["auth"])
+	}
+
+	errorLines := readLines(t, m, "sk-client", batch.ErrorFileID)
+	require.Len(t, errorLines, 1)
+	assert.Equal(t, "req-b", errorLines[0]["custom_id"])
+	response := errorLines[0]["response"].(map[string]interface{})
+	assert.EqualValues(t, http.StatusServiceUnavailable, response["status_code"])
+	assert.Equal(t, "no vendor available", response["body"].(map[string]interface{})["error"].(map[string]interface{})["message"])
+
+	// Other client keys cannot see the batch or its files
+	_, err = m.Store.Batch(files.Owner("sk-other"), created.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = m.Files.Content(t.Context(), files.Owner("sk-other"), *batch.OutputFileID)
+	assert.ErrorIs(t, err, files.ErrNotFound)
+	assert.Empty(t, m.Store.Batches(files.Owner("sk-other")))
+}
+
+func TestManagerCreateValidation(t *testing.T) {
+	m := newTestManager(t, echoHandler(new(int32)), 1)
+
+	valid, err := m.Files.Upload(t.Context(), files.Owner("sk-client"), "input.jsonl", files.PurposeBatch, inputFile("gpt-4o"))
+	require.NoError(t, err)
+	invalid, err := m.Files.Upload(t.Context(), files.Owner("sk-client"), "invalid.jsonl", files.PurposeBatch, []byte("{not json"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		clientKey string
+		request   CreateRequest
+	}{
+		{"unsupported endpoint", "sk-client", CreateRequest{InputFileID: valid.ID, Endpoint: "/v1/embeddings", CompletionWindow: CompletionWindow}},
+		{"unsupported completion window", "sk-client", CreateRequest{InputFileID: valid.ID, Endpoint: Endpoint, CompletionWindow: "1h"}},
+		{"unknown input file", "sk-client", CreateRequest{InputFileID: "file-missing", Endpoint: Endpoint, CompletionWindow: CompletionWindow}},
+		{"input file of another client key", "sk-other", CreateRequest{InputFileID: valid.ID, Endpoint: Endpoint, CompletionWindow: CompletionWindow}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := m.Create(tt.clientKey, tt.request)
+			assert.ErrorIs(t, err, ErrInvalidBatch)
+		})
+	}
+
+	failed, err := m.Create("sk-client", CreateRequest{InputFileID: invalid.ID, Endpoint: Endpoint, CompletionWindow: CompletionWindow})
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, failed.Status)
+	require.NotNil(t, failed.Errors)
+	require.Len(t, failed.Errors.Data, 1)
+	assert.Equal(t, "invalid_json_line", failed.Errors.Data[0].Code)
+	assert.Equal(t, 1, *failed.Errors.Data[0].Line)
+}
+
+func TestManagerCancel(t *testing.T) {
+	release := make(chan struct{})
+	var calls int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) > 1 {
+			<-release
+		}
+		_, _ = io.WriteString(w, `{"object":"chat.completion"}`)
+	})
+	m := newTestManager(t, handler, 1)
+
+	file, err := m.Files.Upload(t.Context(), files.Owner("sk-client"), "input.jsonl", files.PurposeBatch, inputFile("a", "b", "c", "d"))
+	require.NoError(t, err)
+	created, err := m.Create("sk-client", CreateRequest{InputFileID: file.ID, Endpoint: Endpoint, CompletionWindow: CompletionWindow})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	cancelling, err := m.Cancel("sk-client", created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCancelling, cancelling.Status)
+	close(release)
+
+	batch := waitFinished(t, m, "sk-client", created.ID)
+	assert.Equal(t, StatusCancelled, batch.Status)
+	assert.Equal(t, RequestCounts{Total: 4, Completed: 2, Failed: 2}, batch.RequestCounts)
+	errorLines := readLines(t, m, "sk-client", batch.ErrorFileID)
+	require.Len(t, errorLines, 2)
+	assert.Equal(t, "batch_cancelled", errorLines[0]["error"].(map[string]interface{})["code"])
+
+	_, err = m.Cancel("sk-client", created.ID)
+	assert.ErrorIs(t, err, ErrAlreadyFinished)
+	_, err = m.Cancel("sk-other", created.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestManagerResume(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	require.NoError(t, err)
+	backend, err := files.NewDiskBackend(t.TempDir())
+	require.NoError(t, err)
+	fileStore, err := files.NewStore(t.Context(), backend, 0)
+	require.NoError(t, err)
+	m := NewManager(store, fileStore, echoHandler(new(int32)), 1)
+
+	file, err := m.Files.Upload(t.Context(), files.Owner("sk-client"), "input.jsonl", files.PurposeBatch, inputFile("a", "b"))
+	require.NoError(t, err)
+	// Save a batch as a restart would leave it: one request done, one never sent
+	done := outputLine("req-a", "req_1", http.StatusOK, json.RawMessage(`{"object":"chat.completion"}`), nil)
+	require.NoError(t, store.SaveBatch(&Batch{
+		ID:               "batch_interrupted",
+		Object:           "batch",
+		Endpoint:         Endpoint,
+		InputFileID:      file.ID,
+		CompletionWindow: CompletionWindow,
+		Status:           StatusInProgress,
+		RequestCounts:    RequestCounts{Total: 2, Completed: 1},
+		Owner:            files.Owner("sk-client"),
+		Results:          map[string]json.RawMessage{"req-a": done},
+	}))
+
+	reloaded, err := NewStore(dir)
+	require.NoError(t, err)
+	reloadedFiles, err := files.NewStore(t.Context(), backend, 0)
+	require.NoError(t, err)
+	resumed := NewManager(reloaded, reloadedFiles, echoHandler(new(int32)), 1)
+	resumed.Resume(t.Context())
+
+	batch, err := reloaded.Batch(files.Owner("sk-client"), "batch_interrupted")
+	require.NoError(t, err)
+	assert.Equal(t, StatusExpired, batch.Status)
+	assert.Equal(t, RequestCounts{Total: 2, Completed: 1, Failed: 1}, batch.RequestCounts)
+	assert.Len(t, readLines(t, resumed, "sk-client", batch.OutputFileID), 1)
+	errorLines := readLines(t, resumed, "sk-client", batch.ErrorFileID)
+	require.Len(t, errorLines, 1)
+	assert.Equal(t, "batch_expired", errorLines[0]["error"].(map[string]interface{})["code"])
+
+	// The finalized batch and its files survive another restart
+	again, err := NewStore(dir)
+	require.NoError(t, err)
+	persisted, err := again.Batch(files.Owner("sk-client"), "batch_interrupted")
+	require.NoError(t, err)
+	assert.Equal(t, StatusExpired, persisted.Status)
+	againFiles, err := files.NewStore(t.Context(), backend, 0)
+	require.NoError(t, err)
+	_, err = againFiles.Content(t.Context(), files.Owner("sk-client"), *persisted.OutputFileID)
+	assert.NoError(t, err)
+}
+
+// policyHandler answers like the chat completions handler does for models the request's
+// ACL policy does not permit
+func policyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		model, _ := body["model"].(string)
+		if !access.Default().PolicyFor(r).Permits("openai", model) {
+			http.Error(w, "no permitted route", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"object": "chat.completion", "model": model})
+	})
+}
+
+func TestManagerAuthenticatesRequests(t *testing.T) {
+	access.SetDefaultKeyStore(access.NewFileKeyStore(map[string]access.Identity{
+		"sk-restricted": {ID: "team-a", Policy: &access.Policy{AllowedModels: []string{"gpt-4o-mini"}}},
+		"sk-retired":    {ID: "team-b", Disabled: true},
+	}))
+	t.Cleanup(func() { access.SetDefaultKeyStore(nil) })
+	m := newTestManager(t, policyHandler(), 1)
+
+	statuses := func(clientKey string, models ...string) map[string]float64 {
+		file, err := m.Files.Upload(t.Context(), files.Owner(clientKey), "input.jsonl", files.PurposeBatch, inputFile(models...))
+		require.NoError(t, err)
+		created, err := m.Create(clientKey, CreateRequest{InputFileID: file.ID, Endpoint: Endpoint, CompletionWindow: CompletionWindow})
+		require.NoError(t, err)
+		batch := waitFinished(t, m, clientKey, created.ID)
+
+		result := make(map[string]float64)
+		for _, id := range []*string{batch.OutputFileID, batch.ErrorFileID} {
+			if id == nil {
+				continue
+			}
+			for _, line := range readLines(t, m, clientKey, id) {
+				result[line["custom_id"].(string)] = line["response"].(map[string]interface{})["status_code"].(float64)
+			}
+		}
+		return result
+	}
+
+	assert.Equal(t, map[string]float64{"req-a": http.StatusOK, "req-b": http.StatusForbidden}, statuses("sk-restricted", "gpt-4o-mini", "gpt-4o"),
+		"the identity's policy applies to batch requests")
+	assert.Equal(t, map[string]float64{"req-a": http.StatusUnauthorized}, statuses("sk-retired", "gpt-4o-mini"),
+		"a disabled key stops spending")
+}
@@ -0,0 +1,167 @@
+// Package batch emulates the OpenAI Batch API: clients upload a JSONL file of chat
+// completion requests, the router runs them asynchronously against the routed vendors
+// and the results are downloaded as JSONL output and error files
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// ErrNotFound is returned for batches that do not exist or belong to another client key
+var ErrNotFound = errors.New("not found")
+
+// Store keeps batches in memory and, when it has a directory, on disk so they survive
+// restarts; their input, output and error files live in the files store
+type Store struct {
+	mu      sync.RWMutex
+	dir     string
+	batches map[string]*Batch
+}
+
+// NewStore creates a store persisting to dir, or an in-memory store when dir is ""
+// Batches already in dir are loaded
+func NewStore(dir string) (*Store, error) {
+	store := &Store{
+		dir:     dir,
+		batches: make(map[string]*Batch),
+	}
+	if dir == "" {
+		return store, nil
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "batches"), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create batch directory: %w", err)
+	}
+	if err := store.load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// load reads the persisted batches
+func (s *Store) load() error {
+	batches, err := filepath.Glob(filepath.Join(s.dir, "batches", "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range batches {
+		batch := &Batch{}
+		if err := readJSON(path, batch); err != nil {
+			return err
+		}
+		s.batches[batch.ID] = batch
+	}
+	return nil
+}
+
+// SaveBatch stores a copy of a batch
+func (s *Store) SaveBatch(batch *Batch) error {
+	copied := batch.clone()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dir != "" {
+		if err := writeJSON(filepath.Join(s.dir, "batches", batch.ID+".json"), copied); err != nil {
+			return err
+		}
+	}
+	s.batches[batch.ID] = copied
+	return nil
+}
+
+// updateBatch applies update to a batch owned by owner and returns a copy of the result
+// The batch is written to disk only when persist is set, so frequent updates stay cheap
+func (s *Store) updateBatch(owner, id string, persist bool, update func(*Batch)) (*Batch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	batch, ok := s.batches[id]
+	if !ok || batch.Owner != owner {
+		return nil, ErrNotFound
+	}
+	update(batch)
+	if persist && s.dir != "" {
+		if err := writeJSON(filepath.Join(s.dir, "batches", id+".json"), batch); err != nil {
+			return nil, err
+		}
+	}
+	return batch.clone(), nil
+}
+
+// Batch returns a copy of a batch owned by owner
+func (s *Store) Batch(owner, id string) (*Batch, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	batch, ok := s.batches[id]
+	if !ok || batch.Owner != owner {
+		return nil, ErrNotFound
+	}
+	return batch.clone(), nil
+}
+
+// Batches returns copies of the batches owned by owner, newest first
+func (s *Store) Batches(owner string) []*Batch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var batches []*Batch
+	for _, batch := range s.batches {
+		if batch.Owner == owner {
+			batches = append(batches, batch.clone())
+		}
+	}
+	sort.Slice(batches, func(i, j int) bool {
+		if batches[i].CreatedAt != batches[j].CreatedAt {
+			return batches[i].CreatedAt > batches[j].CreatedAt
+		}
+		return batches[i].ID > batches[j].ID
+	})
+	return batches
+}
+
+// unfinished returns copies of every batch that has not reached a final status
+func (s *Store) unfinished() []*Batch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var batches []*Batch
+	for _, batch := range s.batches {
+		if !batch.finished() {
+			batches = append(batches, batch.clone())
+		}
+	}
+	return batches
+}
+
+// newID returns a random identifier with the given prefix
+func newID(prefix string) string {
+	return prefix + utils.GenerateShortID() + utils.GenerateShortID()
+}
+
+// writeJSON atomically replaces path with the JSON encoding of v
+func writeJSON(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// readJSON decodes the JSON file at path into v
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
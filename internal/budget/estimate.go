@@ -0,0 +1,175 @@
+package budget
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/tokenizer"
+)
+
+// AnyModel prices a request on every model it could be routed to
+const AnyModel = "any"
+
+// ErrNoModels is returned when no configured model matches the model to price
+var ErrNoModels = errors.New("no matching model")
+
+// EstimateRequest is the body of /v1/cost_estimate
+type EstimateRequest struct {
+	// Model is the model to price, as "model" or "vendor/model", or "any"; defaults to the request's model
+	Model   string              `json:"model,omitempty" example:"any"`
+	Request EstimateChatRequest `json:"request"`
+}
+
+// EstimateChatRequest holds the fields of a chat completion request that decide its cost
+type EstimateChatRequest struct {
+	Model               string                   `json:"model,omitempty" example:"gpt-4o"`
+	Messages            []map[string]interface{} `json:"messages"`
+	Tools               []interface{}            `json:"tools,omitempty"`
+	MaxTokens           int                      `json:"max_tokens,omitempty" example:"1024"`
+	MaxCompletionTokens int                      `json:"max_completion_tokens,omitempty"`
+	N                   int                      `json:"n,omitempty"`
+}
+
+// CostEstimate is what a request would cost if served by one model
+type CostEstimate struct {
+	Vendor    string `json:"vendor" example:"openai"`
+	Model     string `json:"model" example:"gpt-4o"`
+	Tokenizer string `json:"tokenizer" example:"o200k_base"`
+	// Exact is false when the prompt token count is an approximation
+	Exact         bool    `json:"exact" example:"true"`
+	PromptTokens  int     `json:"prompt_tokens" example:"12"`
+	PromptCostUSD float64 `json:"prompt_cost_usd" example:"0.00003"`
+	// MaxCompletionTokens is the most the request may generate over all its choices; null when
+	// the request sets no limit and the model has no configured context window
+	MaxCompletionTokens  *int     `json:"max_completion_tokens" example:"1024"`
+	MaxCompletionCostUSD *float64 `json:"max_completion_cost_usd" example:"0.01024"`
+	MaxTotalCostUSD      *float64 `json:"max_total_cost_usd" example:"0.01027"`
+}
+
+// EstimateResponse is the response of /v1/cost_estimate
+type EstimateResponse struct {
+	Object    string         `json:"object" example:"cost_estimate"`
+	Model     string         `json:"model" example:"any"`
+	Estimates []CostEstimate `json:"estimates"`
+	// MinPromptCostUSD is the cheapest prompt cost among the estimates
+	MinPromptCostUSD *float64 `json:"min_prompt_cost_usd,omitempty" example:"0.00003"`
+	// MaxTotalCostUSD is the worst case over the estimates, absent when a completion is unbounded
+	MaxTotalCostUSD *float64 `json:"max_total_cost_usd,omitempty" example:"0.01027"`
+	// Unpriced lists the matching models, as vendor/model, that have no pricing configured
+	Unpriced []string `json:"unpriced,omitempty"`
+}
+
+// Estimate prices a chat completion request on the models it names, or on every model when
+// it names "any", from the models' configured pricing. Prompt tokens are counted with each
+// model's tokenizer; the completion is priced at the most tokens the request allows
+func Estimate(tokenizers *tokenizer.Registry, models []config.VendorModel, req EstimateRequest) (EstimateResponse, error) {
+	target := req.Model
+	if target == "" {
+		target = req.Request.Model
+	}
+	if target == "" {
+		target = AnyModel
+	}
+
+	response := EstimateResponse{Object: "cost_estimate", Model: target, Estimates: []CostEstimate{}}
+	seen := make(map[string]bool)
+	matched := false
+	for _, model := range models {
+		if target != AnyModel && !access.MatchesModel(target, model.Vendor, model.Model) {
+			continue
+		}
+		matched = true
+		key := model.Vendor + "/" + model.Model
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if model.Pricing == nil {
+			response.Unpriced = append(response.Unpriced, key)
+			continue
+		}
+		estimate, err := estimateModel(tokenizers, model, req.Request)
+		if err != nil {
+			return EstimateResponse{}, err
+		}
+		response.Estimates = append(response.Estimates, estimate)
+	}
+	if !matched {
+		return EstimateResponse{}, fmt.Errorf("%w: no available model matches %q", ErrNoModels, target)
+	}
+
+	bounded := true
+	var minPrompt, maxTotal float64
+	for i, estimate := range response.Estimates {
+		if i == 0 || estimate.PromptCostUSD < minPrompt {
+			minPrompt = estimate.PromptCostUSD
+		}
+		if estimate.MaxTotalCostUSD == nil {
+			bounded = false
+		} else if *estimate.MaxTotalCostUSD > maxTotal {
+			maxTotal = *estimate.MaxTotalCostUSD
+		}
+	}
+	if len(response.Estimates) > 0 {
+		response.MinPromptCostUSD = &minPrompt
+		if bounded {
+			response.MaxTotalCostUSD = &maxTotal
+		}
+	}
+	return response, nil
+}
+
+// estimateModel prices a request served by one priced model
+func estimateModel(tokenizers *tokenizer.Registry, model config.VendorModel, req EstimateChatRequest) (CostEstimate, error) {
+	count, err := tokenizer.CountTokens(tokenizers.ForModel(model.Vendor, model.Model), tokenizer.CountTokensRequest{
+		Model:    model.Model,
+		Messages: req.Messages,
+		Tools:    req.Tools,
+	})
+	if err != nil {
+		return CostEstimate{}, err
+	}
+
+	estimate := CostEstimate{
+		Vendor:        model.Vendor,
+		Model:         model.Model,
+		Tokenizer:     count.Tokenizer,
+		Exact:         count.Exact,
+		PromptTokens:  count.InputTokens,
+		PromptCostUSD: perMillion(count.InputTokens, model.Pricing.InputPerMillion),
+	}
+	if completion, ok := maxCompletionTokens(model, req, count.InputTokens); ok {
+		completionCost := perMillion(completion, model.Pricing.OutputPerMillion)
+		totalCost := estimate.PromptCostUSD + completionCost
+		estimate.MaxCompletionTokens = &completion
+		estimate.MaxCompletionCostUSD = &completionCost
+		estimate.MaxTotalCostUSD = &totalCost
+	}
+	return estimate, nil
+}
+
+// maxCompletionTokens returns the most tokens a request may generate over all its choices:
+// its token limit, capped by what the model's context window leaves after the prompt. It
+// reports false when neither bounds the completion
+func maxCompletionTokens(model config.VendorModel, req EstimateChatRequest, promptTokens int) (int, bool) {
+	limit := req.MaxCompletionTokens
+	if limit <= 0 {
+		limit = req.MaxTokens
+	}
+	if model.Config != nil && model.Config.ContextWindow > 0 {
+		remaining := max(model.Config.ContextWindow-promptTokens, 0)
+		if limit <= 0 || limit > remaining {
+			limit = remaining
+		}
+	} else if limit <= 0 {
+		return 0, false
+	}
+	return limit * max(req.N, 1), true
+}
+
+// perMillion prices tokens at a rate per million
+func perMillion(tokens int, rate float64) float64 {
+	return float64(tokens) * rate / 1e6
+}
@@ -0,0 +1,116 @@
+package budget
+
+import (
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/tokenizer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimate(t *testing.T) {
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o", Pricing: &config.ModelPricing{InputPerMillion: 2.5, OutputPerMillion: 10}},
+		{Vendor: "gemini", Model: "gemini-pro", Pricing: &config.ModelPricing{InputPerMillion: 1, OutputPerMillion: 4},
+			Config: &config.ModelConfig{ContextWindow: 1000}},
+		{Vendor: "openai", Model: "gpt-4o-mini"},
+	}
+	messages := []map[string]interface{}{{"role": "user", "content": "Hello world"}}
+
+	tests := []struct {
+		name         string
+		req          EstimateRequest
+		wantModels   []string
+		wantUnpriced []string
+		wantBounded  bool
+		wantErr      error
+	}{
+		{
+			name:         "any model prices every model",
+			req:          EstimateRequest{Model: "any", Request: EstimateChatRequest{Messages: messages, MaxTokens: 100}},
+			wantModels:   []string{"gpt-4o", "gemini-pro"},
+			wantUnpriced: []string{"openai/gpt-4o-mini"},
+			wantBounded:  true,
+		},
+		{
+			name:        "model named by the request",
+			req:         EstimateRequest{Request: EstimateChatRequest{Model: "gemini/gemini-pro", Messages: messages}},
+			wantModels:  []string{"gemini-pro"},
+			wantBounded: true,
+		},
+		{
+			name:       "no limit and no context window",
+			req:        EstimateRequest{Model: "gpt-4o", Request: EstimateChatRequest{Messages: messages}},
+			wantModels: []string{"gpt-4o"},
+		},
+		{
+			name:    "unknown model",
+			req:     EstimateRequest{Model: "gpt-5", Request: EstimateChatRequest{Messages: messages}},
+			wantErr: ErrNoModels,
+		},
+		{
+			name:    "no messages",
+			req:     EstimateRequest{Model: "any"},
+			wantErr: tokenizer.ErrInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := Estimate(tokenizer.Default(), models, tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var names []string
+			for _, estimate := range response.Estimates {
+				names = append(names, estimate.Model)
+				assert.Greater(t, estimate.PromptTokens, 0)
+			}
+			assert.Equal(t, tt.wantModels, names)
+			assert.Equal(t, tt.wantUnpriced, response.Unpriced)
+			assert.NotNil(t, response.MinPromptCostUSD)
+			assert.Equal(t, tt.wantBounded, response.MaxTotalCostUSD != nil)
+		})
+	}
+}
+
+func TestEstimate_Costs(t *testing.T) {
+	models := []config.VendorModel{
+		{Vendor: "gemini", Model: "gemini-pro", Pricing: &config.ModelPricing{InputPerMillion: 1, OutputPerMillion: 4},
+			Config: &config.ModelConfig{ContextWindow: 1000}},
+	}
+	req := EstimateChatRequest{Messages: []map[string]interface{}{{"role": "user", "content": "Hello world"}}}
+
+	tests := []struct {
+		name     string
+		limit    int
+		n        int
+		expected func(prompt int) int
+	}{
+		{name: "token limit", limit: 100, expected: func(int) int { return 100 }},
+		{name: "token limit per choice", limit: 100, n: 3, expected: func(int) int { return 300 }},
+		{name: "context window caps the limit", limit: 5000, expected: func(prompt int) int { return 1000 - prompt }},
+		{name: "context window without a limit", expected: func(prompt int) int { return 1000 - prompt }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req.MaxCompletionTokens, req.N = tt.limit, tt.n
+			response, err := Estimate(tokenizer.Default(), models, EstimateRequest{Model: "any", Request: req})
+			require.NoError(t, err)
+			require.Len(t, response.Estimates, 1)
+
+			estimate := response.Estimates[0]
+			completion := tt.expected(estimate.PromptTokens)
+			require.NotNil(t, estimate.MaxCompletionTokens)
+			assert.Equal(t, completion, *estimate.MaxCompletionTokens)
+			assert.InDelta(t, float64(estimate.PromptTokens)/1e6, estimate.PromptCostUSD, 1e-12)
+			assert.InDelta(t, float64(completion)*4/1e6, *estimate.MaxCompletionCostUSD, 1e-12)
+			assert.InDelta(t, estimate.PromptCostUSD+*estimate.MaxCompletionCostUSD, *estimate.MaxTotalCostUSD, 1e-12)
+		})
+	}
+}
@@ -0,0 +1,269 @@
+// Package budget caps what each vendor may cost per day and per month. Every vendor with
+// a budget has a daily and a monthly bucket holding its ceiling in US dollars; completed
+// requests drain them by their cost, priced from the model's pricing config, and a vendor
+// whose bucket runs dry leaves the routing pool until the bucket refills at the start of
+// the next UTC day or month
+package budget
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+)
+
+// Budget windows
+const (
+	WindowDaily   = "daily"
+	WindowMonthly = "monthly"
+)
+
+// Spend is what a vendor has cost in the current day and month
+type Spend struct {
+	Vendor     string    `json:"vendor"`
+	Day        time.Time `json:"day"`
+	DailyUSD   float64   `json:"daily_usd"`
+	Month      time.Time `json:"month"`
+	MonthlyUSD float64   `json:"monthly_usd"`
+}
+
+// State reports a vendor's spend against its budget
+type State struct {
+	Spend
+	DailyLimitUSD   float64 `json:"daily_limit_usd,omitempty"`
+	MonthlyLimitUSD float64 `json:"monthly_limit_usd,omitempty"`
+	// Exhausted names the window whose ceiling was reached, if any
+	Exhausted string `json:"exhausted,omitempty"`
+	// ResetsAt is when the exhausted window refills
+	ResetsAt *time.Time `json:"resets_at,omitempty"`
+}
+
+// Limiter tracks vendor spend and removes vendors over budget from the routing pool
+type Limiter struct {
+	mu      sync.Mutex
+	budgets map[string]config.VendorBudget
+	pricing map[string]config.ModelPricing // vendor:model -> pricing
+	spend   map[string]*Spend
+	now     func() time.Time
+}
+
+var (
+	defaultLimiter     *Limiter
+	defaultLimiterOnce sync.Once
+)
+
+// NewLimiter creates a limiter with no budgets
+func NewLimiter() *Limiter {
+	return &Limiter{
+		budgets: make(map[string]config.VendorBudget),
+		pricing: make(map[string]config.ModelPricing),
+		spend:   make(map[string]*Spend),
+		now:     time.Now,
+	}
+}
+
+// Default returns the process-wide limiter
+func Default() *Limiter {
+	defaultLimiterOnce.Do(func() {
+		defaultLimiter = NewLimiter()
+	})
+	return defaultLimiter
+}
+
+// Configure sets the vendor budgets and the model prices requests are charged at
+// Spend recorded so far is kept
+func (l *Limiter) Configure(budgets map[string]config.VendorBudget, models []config.VendorModel) {
+	pricing := make(map[string]config.ModelPricing)
+	for _, model := range models {
+		if model.Pricing != nil {
+			pricing[modelKey(model.Vendor, model.Model)] = *model.Pricing
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.budgets = make(map[string]config.VendorBudget, len(budgets))
+	for vendor, budget := range budgets {
+		l.budgets[vendor] = budget
+	}
+	l.pricing = pricing
+}
+
+// Observe charges the vendor for a request served by model; vendors without a budget and
+// models without pricing are ignored. Reaching a ceiling logs an alert
+func (l *Limiter) Observe(vendor, model string, promptTokens, completionTokens int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	budget, ok := l.budgets[vendor]
+	if !ok {
+		return
+	}
+	cost, ok := l.cost(vendor, model, promptTokens, completionTokens)
+	if !ok || cost <= 0 {
+		return
+	}
+
+	spend := l.spendFor(vendor)
+	wasExhausted := exhausted(spend, budget)
+	spend.DailyUSD += cost
+	spend.MonthlyUSD += cost
+
+	if window := exhausted(spend, budget); window != "" && wasExhausted == "" {
+		ctx := logger.WithComponent(context.Background(), "BudgetLimiter")
+		logger.Error(logger.WithStage(ctx, "CostCeilingReached"), "Vendor cost ceiling reached, removing vendor from routing", nil,
+			"vendor", vendor,
+			"window", window,
+			"daily_spend_usd", spend.DailyUSD,
+			"daily_limit_usd", budget.DailyUSD,
+			"monthly_spend_usd", spend.MonthlyUSD,
+			"monthly_limit_usd", budget.MonthlyUSD,
+			"resets_at", resetTime(spend, window),
+			"alert", true,
+		)
+	}
+}
+
+// Cost estimates what a request served by model cost in US dollars, reporting false when
+// the model has no pricing
+func (l *Limiter) Cost(vendor, model string, promptTokens, completionTokens int) (float64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cost(vendor, model, promptTokens, completionTokens)
+}
+
+// cost prices a request at the model's rates; l.mu must be held
+func (l *Limiter) cost(vendor, model string, promptTokens, completionTokens int) (float64, bool) {
+	pricing, ok := l.pricing[modelKey(vendor, model)]
+	if !ok {
+		return 0, false
+	}
+	return (float64(promptTokens)*pricing.InputPerMillion + float64(completionTokens)*pricing.OutputPerMillion) / 1e6, true
+}
+
+// Filter removes vendors that reached a cost ceiling, with their credentials and models
+// Unlike health filters it does not fail open: when every vendor is over budget, nothing is left
+func (l *Limiter) Filter(creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	blocked := make(map[string]bool)
+	for vendor, budget := range l.budgets {
+		if exhausted(l.spendFor(vendor), budget) != "" {
+			blocked[vendor] = true
+		}
+	}
+	if len(blocked) == 0 {
+		return creds, models
+	}
+
+	var keptCreds []config.Credential
+	for _, cred := range creds {
+		if !blocked[cred.Platform] {
+			keptCreds = append(keptCreds, cred)
+		}
+	}
+	var keptModels []config.VendorModel
+	for _, model := range models {
+		if !blocked[model.Vendor] {
+			keptModels = append(keptModels, model)
+		}
+	}
+	return keptCreds, keptModels
+}
+
+// States returns the spend of every vendor with a budget, sorted by vendor
+func (l *Limiter) States() []State {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	states := make([]State, 0, len(l.budgets))
+	for vendor, budget := range l.budgets {
+		spend := l.spendFor(vendor)
+		state := State{
+			Spend:           *spend,
+			DailyLimitUSD:   budget.DailyUSD,
+			MonthlyLimitUSD: budget.MonthlyUSD,
+			Exhausted:       exhausted(spend, budget),
+		}
+		if state.Exhausted != "" {
+			resetsAt := resetTime(spend, state.Exhausted)
+			state.ResetsAt = &resetsAt
+		}
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Vendor < states[j].Vendor })
+	return states
+}
+
+// Spend returns the spend recorded for every vendor, for persisting across restarts
+func (l *Limiter) Spend() []Spend {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	spend := make([]Spend, 0, len(l.spend))
+	for vendor := range l.spend {
+		spend = append(spend, *l.spendFor(vendor))
+	}
+	sort.Slice(spend, func(i, j int) bool { return spend[i].Vendor < spend[j].Vendor })
+	return spend
+}
+
+// Restore replaces the recorded spend with saved spend; windows that have since ended are dropped
+func (l *Limiter) Restore(saved []Spend) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.spend = make(map[string]*Spend, len(saved))
+	for _, spend := range saved {
+		restored := spend
+		l.spend[spend.Vendor] = &restored
+		l.spendFor(spend.Vendor)
+	}
+}
+
+// spendFor returns the vendor's spend, emptying the buckets whose window has ended; callers hold mu
+func (l *Limiter) spendFor(vendor string) *Spend {
+	now := l.now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	spend, ok := l.spend[vendor]
+	if !ok {
+		spend = &Spend{Vendor: vendor}
+		l.spend[vendor] = spend
+	}
+	if !spend.Day.Equal(day) {
+		spend.Day, spend.DailyUSD = day, 0
+	}
+	if !spend.Month.Equal(month) {
+		spend.Month, spend.MonthlyUSD = month, 0
+	}
+	return spend
+}
+
+// exhausted returns the window whose ceiling the spend has reached, or "" when there is budget left
+func exhausted(spend *Spend, budget config.VendorBudget) string {
+	switch {
+	case budget.MonthlyUSD > 0 && spend.MonthlyUSD >= budget.MonthlyUSD:
+		return WindowMonthly
+	case budget.DailyUSD > 0 && spend.DailyUSD >= budget.DailyUSD:
+		return WindowDaily
+	}
+	return ""
+}
+
+// resetTime returns when a window of the spend refills
+func resetTime(spend *Spend, window string) time.Time {
+	if window == WindowMonthly {
+		return spend.Month.AddDate(0, 1, 0)
+	}
+	return spend.Day.AddDate(0, 0, 1)
+}
+
+func modelKey(vendor, model string) string {
+	return vendor + ":" + model
+}
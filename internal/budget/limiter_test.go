@@ -0,0 +1,118 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(now *time.Time) *Limiter {
+	limiter := NewLimiter()
+	limiter.now = func() time.Time { return *now }
+	limiter.Configure(
+		map[string]config.VendorBudget{
+			"openai": {DailyUSD: 1, MonthlyUSD: 2},
+			"gemini": {MonthlyUSD: 100},
+		},
+		[]config.VendorModel{
+			{Vendor: "openai", Model: "gpt-4o", Pricing: &config.ModelPricing{InputPerMillion: 2.5, OutputPerMillion: 10}},
+			{Vendor: "gemini", Model: "gemini-2.5-pro", Pricing: &config.ModelPricing{InputPerMillion: 1.25, OutputPerMillion: 10}},
+		},
+	)
+	return limiter
+}
+
+func TestLimiter_Filter(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	creds := []config.Credential{{Platform: "openai", Value: "sk-1"}, {Platform: "gemini", Value: "g-1"}}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-2.5-pro"}}
+
+	// 200k prompt + 40k completion tokens cost $0.50 + $0.40
+	limiter.Observe("openai", "gpt-4o", 200_000, 40_000)
+	keptCreds, keptModels := limiter.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds, "vendors under budget stay in the pool")
+	assert.Equal(t, models, keptModels)
+
+	limiter.Observe("openai", "gpt-4o", 40_000, 0)
+	keptCreds, keptModels = limiter.Filter(creds, models)
+	assert.Equal(t, creds[1:], keptCreds, "the daily ceiling removes the vendor")
+	assert.Equal(t, models[1:], keptModels)
+
+	states := limiter.States()
+	require.Len(t, states, 2)
+	assert.Equal(t, "gemini", states[0].Vendor)
+	openai := states[1]
+	assert.InDelta(t, 1.0, openai.DailyUSD, 1e-9)
+	assert.Equal(t, WindowDaily, openai.Exhausted)
+	require.NotNil(t, openai.ResetsAt)
+	assert.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), *openai.ResetsAt)
+
+	// The daily bucket refills the next day, but the month keeps its spend
+	now = now.Add(24 * time.Hour)
+	keptCreds, _ = limiter.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds)
+	limiter.Observe("openai", "gpt-4o", 0, 100_000)
+	_, keptModels = limiter.Filter(creds, models)
+	assert.Equal(t, models[1:], keptModels)
+	assert.Equal(t, WindowMonthly, limiter.States()[1].Exhausted)
+
+	// Every vendor over budget empties the pool
+	limiter.Observe("gemini", "gemini-2.5-pro", 0, 10_000_000)
+	keptCreds, keptModels = limiter.Filter(creds, models)
+	assert.Empty(t, keptCreds)
+	assert.Empty(t, keptModels)
+
+	now = time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	keptCreds, keptModels = limiter.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds, "a new month refills every bucket")
+	assert.Equal(t, models, keptModels)
+}
+
+func TestLimiter_ObserveIgnoresUnbudgetedAndUnpriced(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	limiter.Observe("anthropic", "claude-sonnet-4", 1_000_000, 1_000_000)
+	limiter.Observe("openai", "gpt-4o-mini", 1_000_000, 1_000_000)
+
+	for _, state := range limiter.States() {
+		assert.Zero(t, state.DailyUSD, state.Vendor)
+	}
+	assert.Len(t, limiter.Spend(), 2, "only budgeted vendors are tracked")
+}
+
+func TestLimiter_Cost(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	cost, ok := limiter.Cost("openai", "gpt-4o", 200_000, 40_000)
+	require.True(t, ok)
+	assert.InDelta(t, 0.9, cost, 1e-9)
+
+	_, ok = limiter.Cost("openai", "gpt-4o-mini", 1_000, 1_000)
+	assert.False(t, ok, "unpriced models have no estimate")
+	for _, state := range limiter.States() {
+		assert.Zero(t, state.DailyUSD, "estimating does not charge the budget")
+	}
+}
+
+func TestLimiter_Restore(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+
+	limiter.Restore([]Spend{
+		{Vendor: "openai", Day: time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), DailyUSD: 0.9, Month: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), MonthlyUSD: 1.5},
+		{Vendor: "gemini", Day: time.Date(2026, 9, 30, 0, 0, 0, 0, time.UTC), DailyUSD: 5, Month: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), MonthlyUSD: 99},
+	})
+
+	spend := limiter.Spend()
+	require.Len(t, spend, 2)
+	assert.Equal(t, Spend{Vendor: "gemini", Day: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), Month: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)}, spend[0], "ended windows are dropped")
+	assert.Zero(t, spend[1].DailyUSD, "yesterday's spend is dropped")
+	assert.Equal(t, 1.5, spend[1].MonthlyUSD, "this month's spend is kept")
+}
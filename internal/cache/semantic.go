@@ -0,0 +1,299 @@
+// Package cache serves repeated chat completions from memory. Requests are keyed by a hash
+// of their normalized form, so prompts generated from the same template with small variable
+// changes can share a cached response
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+const (
+	// DefaultTTL is how long a response is served from the cache when the file sets no ttl_seconds
+	DefaultTTL = 10 * time.Minute
+	// DefaultMaxEntries bounds the cache when the file sets no max_entries
+	DefaultMaxEntries = 1000
+)
+
+// whitespace matches the runs of whitespace collapsed before hashing
+var whitespace = regexp.MustCompile(`\s+`)
+
+// Pattern rewrites the variable parts of templated prompts to a placeholder before hashing
+type Pattern struct {
+	// Name identifies the pattern in the cache metrics
+	Name string `json:"name"`
+	// Pattern is a regular expression matched against message text
+	Pattern string `json:"pattern"`
+	// Replacement is substituted for each match, "" by default; $1 style references are expanded
+	Replacement string `json:"replacement"`
+}
+
+// Config is the on-disk semantic cache format
+type Config struct {
+	TTLSeconds int       `json:"ttl_seconds,omitempty"`
+	MaxEntries int       `json:"max_entries,omitempty"`
+	Patterns   []Pattern `json:"patterns"`
+}
+
+// Stats are the cache counters used to evaluate how effective the patterns are
+type Stats struct {
+	Entries   int     `json:"entries"`
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	HitRate   float64 `json:"hit_rate"`
+	Stores    uint64  `json:"stores"`
+	Evictions uint64  `json:"evictions"`
+	// Normalized counts, per pattern name, the cache lookups whose messages the pattern rewrote
+	Normalized map[string]uint64 `json:"normalized"`
+}
+
+// compiledPattern is a validated Pattern
+type compiledPattern struct {
+	Pattern
+	re *regexp.Regexp
+}
+
+// entry is a cached response
+type entry struct {
+	key      string
+	response []byte
+	expires  time.Time
+}
+
+// Cache is a size-bounded, least-recently-used response cache with a fixed time to live
+type Cache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	patterns   []compiledPattern
+	entries    map[string]*list.Element
+	order      *list.List // most recently used at the front
+	now        func() time.Time
+
+	hits, misses, stores, evictions uint64
+	normalized                      map[string]uint64
+}
+
+var (
+	defaultCache   *Cache
+	defaultCacheMu sync.RWMutex
+)
+
+// New creates a cache from a configuration
+func New(cfg Config) (*Cache, error) {
+	if cfg.TTLSeconds < 0 || cfg.MaxEntries < 0 {
+		return nil, fmt.Errorf("ttl_seconds and max_entries must not be negative")
+	}
+	ttl := time.Duration(cfg.TTLSeconds) * time.Second
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	maxEntries := cfg.MaxEntries
+	if maxEntries == 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	patterns := make([]compiledPattern, 0, len(cfg.Patterns))
+	names := make(map[string]bool, len(cfg.Patterns))
+	for i, pattern := range cfg.Patterns {
+		if pattern.Name == "" {
+			return nil, fmt.Errorf("pattern %d: name is required", i)
+		}
+		if names[pattern.Name] {
+			return nil, fmt.Errorf("pattern %s is declared twice", pattern.Name)
+		}
+		names[pattern.Name] = true
+		re, err := regexp.Compile(pattern.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %s is not a valid regular expression: %w", pattern.Name, err)
+		}
+		patterns = append(patterns, compiledPattern{Pattern: pattern, re: re})
+	}
+
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		patterns:   patterns,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		now:        time.Now,
+		normalized: make(map[string]uint64, len(patterns)),
+	}, nil
+}
+
+// Load reads a semantic cache file: "patterns" and optional "ttl_seconds" and "max_entries"
+func Load(path string) (*Cache, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read semantic cache file: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid semantic cache file: %w", err)
+	}
+	return New(cfg)
+}
+
+// LoadFromEnv loads the file named by SEMANTIC_CACHE_FILE, or returns nil when unset
+func LoadFromEnv() (*Cache, error) {
+	path := utils.GetEnvString("SEMANTIC_CACHE_FILE", "")
+	if path == "" {
+		return nil, nil
+	}
+	return Load(path)
+}
+
+// Default returns the process-wide cache, nil (disabled) until SetDefault is called
+func Default() *Cache {
+	defaultCacheMu.RLock()
+	defer defaultCacheMu.RUnlock()
+	return defaultCache
+}
+
+// SetDefault replaces the process-wide cache; nil disables caching
+func SetDefault(cache *Cache) {
+	defaultCacheMu.Lock()
+	defer defaultCacheMu.Unlock()
+	defaultCache = cache
+}
+
+// Key returns the cache key of a chat completion request sent with the given scope, such
+// as the client key, so cached responses are never shared across scopes. Message text is
+// whitespace-collapsed and rewritten by the patterns before hashing. Streaming and
+// unparseable requests are not cacheable
+func (c *Cache) Key(scope string, body []byte) (string, bool) {
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return "", false
+	}
+	if stream, _ := request["stream"].(bool); stream {
+		return "", false
+	}
+	messages, ok := request["messages"].([]interface{})
+	if !ok {
+		return "", false
+	}
+
+	applied := make(map[string]bool)
+	for _, message := range messages {
+		fields, ok := message.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch content := fields["content"].(type) {
+		case string:
+			fields["content"] = c.normalize(content, applied)
+		case []interface{}:
+			for _, part := range content {
+				if partFields, ok := part.(map[string]interface{}); ok {
+					if text, ok := partFields["text"].(string); ok {
+						partFields["text"] = c.normalize(text, applied)
+					}
+				}
+			}
+		}
+	}
+	delete(request, "stream")
+
+	canonical, err := json.Marshal(request)
+	if err != nil {
+		return "", false
+	}
+	c.mu.Lock()
+	for name := range applied {
+		c.normalized[name]++
+	}
+	c.mu.Unlock()
+
+	sum := sha256.Sum256(append([]byte(scope+"\n"), canonical...))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// normalize collapses whitespace and applies the patterns, noting the ones that matched
+func (c *Cache) normalize(text string, applied map[string]bool) string {
+	text = strings.TrimSpace(whitespace.ReplaceAllString(text, " "))
+	for _, pattern := range c.patterns {
+		if pattern.re.MatchString(text) {
+			text = pattern.re.ReplaceAllString(text, pattern.Replacement)
+			applied[pattern.Name] = true
+		}
+	}
+	return text
+}
+
+// Get returns the response cached under key, counting the lookup as a hit or a miss
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if ok && c.now().After(element.Value.(*entry).expires) {
+		c.remove(element)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(element)
+	return element.Value.(*entry).response, true
+}
+
+// Put caches a response under key, evicting the least recently used entries beyond the limit
+func (c *Cache) Put(key string, response []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stores++
+	cached := &entry{key: key, response: append([]byte(nil), response...), expires: c.now().Add(c.ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = cached
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(cached)
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+// remove drops an entry; callers must hold c.mu
+func (c *Cache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry).key)
+}
+
+// Stats returns the cache counters
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := Stats{
+		Entries:    len(c.entries),
+		Hits:       c.hits,
+		Misses:     c.misses,
+		Stores:     c.stores,
+		Evictions:  c.evictions,
+		Normalized: make(map[string]uint64, len(c.patterns)),
+	}
+	if lookups := c.hits + c.misses; lookups > 0 {
+		stats.HitRate = float64(c.hits) / float64(lookups)
+	}
+	for _, pattern := range c.patterns {
+		stats.Normalized[pattern.Name] = c.normalized[pattern.Name]
+	}
+	return stats
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCache(t *testing.T, cfg Config) *Cache {
+	t.Helper()
+	cache, err := New(cfg)
+	require.NoError(t, err)
+	return cache
+}
+
+func TestCache_Key(t *testing.T) {
+	cache := newTestCache(t, Config{Patterns: []Pattern{
+		{Name: "date", Pattern: `\d{4}-\d{2}-\d{2}`, Replacement: "<date>"},
+		{Name: "order", Pattern: `order #\d+`, Replacement: "order #<id>"},
+	}})
+	request := func(content string) []byte {
+		return []byte(fmt.Sprintf(`{"model":"gpt-4o","messages":[{"role":"user","content":%q}]}`, content))
+	}
+
+	base, ok := cache.Key("sk-a", request("Summarize order #123 placed on 2026-10-01."))
+	require.True(t, ok)
+
+	tests := []struct {
+		name  string
+		scope string
+		body  []byte
+		same  bool
+	}{
+		{name: "template variables", scope: "sk-a", body: request("Summarize order #98765 placed on 2025-01-31."), same: true},
+		{name: "whitespace", scope: "sk-a", body: request("  Summarize   order #1\nplaced on 2026-10-01. "), same: true},
+		{name: "text parts", scope: "sk-a", body: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":[{"type":"text","text":"Summarize order #5 placed on 2026-10-02."}]}]}`)},
+		{name: "different wording", scope: "sk-a", body: request("Cancel order #123 placed on 2026-10-01.")},
+		{name: "different scope", scope: "sk-b", body: request("Summarize order #123 placed on 2026-10-01.")},
+		{name: "different parameters", scope: "sk-a", body: []byte(`{"model":"gpt-4o","temperature":0.2,"messages":[{"role":"user","content":"Summarize order #123 placed on 2026-10-01."}]}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := cache.Key(tt.scope, tt.body)
+			require.True(t, ok)
+			assert.Equal(t, tt.same, key == base)
+		})
+	}
+
+	_, ok = cache.Key("sk-a", []byte(`{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+	assert.False(t, ok, "streaming requests are not cached")
+	_, ok = cache.Key("sk-a", []byte(`not json`))
+	assert.False(t, ok)
+
+	stats := cache.Stats()
+	assert.Equal(t, uint64(7), stats.Normalized["date"])
+	assert.Equal(t, uint64(7), stats.Normalized["order"])
+}
+
+func TestCache_GetPut(t *testing.T) {
+	cache := newTestCache(t, Config{TTLSeconds: 60, MaxEntries: 2})
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	_, hit := cache.Get("a")
+	assert.False(t, hit)
+	cache.Put("a", []byte("response a"))
+	response, hit := cache.Get("a")
+	require.True(t, hit)
+	assert.Equal(t, "response a", string(response))
+
+	cache.Put("b", []byte("response b"))
+	cache.Get("a")
+	cache.Put("c", []byte("response c"))
+	_, hit = cache.Get("b")
+	assert.False(t, hit, "the least recently used entry is evicted")
+	_, hit = cache.Get("a")
+	assert.True(t, hit)
+
+	now = now.Add(61 * time.Second)
+	_, hit = cache.Get("a")
+	assert.False(t, hit, "entries expire after the TTL")
+
+	stats := cache.Stats()
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(3), stats.Misses)
+	assert.Equal(t, 0.5, stats.HitRate)
+	assert.Equal(t, uint64(3), stats.Stores)
+	assert.Equal(t, uint64(1), stats.Evictions)
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           Config
+		expectedError string
+	}{
+		{name: "negative ttl", cfg: Config{TTLSeconds: -1}, expectedError: "must not be negative"},
+		{name: "unnamed pattern", cfg: Config{Patterns: []Pattern{{Pattern: `\d+`}}}, expectedError: "name is required"},
+		{name: "duplicate pattern", cfg: Config{Patterns: []Pattern{{Name: "n", Pattern: `\d+`}, {Name: "n", Pattern: `x`}}}, expectedError: "declared twice"},
+		{name: "invalid pattern", cfg: Config{Patterns: []Pattern{{Name: "n", Pattern: `(`}}}, expectedError: "not a valid regular expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			assert.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+
+	cache := newTestCache(t, Config{})
+	assert.Equal(t, DefaultTTL, cache.ttl)
+	assert.Equal(t, DefaultMaxEntries, cache.maxEntries)
+}
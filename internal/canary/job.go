@@ -0,0 +1,236 @@
+package canary
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/proxy"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Default canary settings, overridable with CANARY_PROMPT, CANARY_EXPECT and CANARY_MAX_LATENCY
+const (
+	DefaultPrompt     = "Reply with exactly one word: pong"
+	DefaultExpect     = "pong"
+	DefaultMaxLatency = 30 * time.Second
+)
+
+// Job sends a fixed prompt to every configured vendor/model pair and records whether
+// each answered with non-empty content containing the expected text, finished with
+// finish_reason "stop" and responded within the latency bound
+type Job struct {
+	Config     *config.Store
+	Client     proxy.APIClientInterface
+	Status     *Status
+	Prompt     string
+	Expect     string
+	MaxLatency time.Duration
+	Interval   time.Duration
+}
+
+// NewJobFromEnv creates a job reporting into Default(), or returns nil when CANARY_INTERVAL
+// is unset or zero, which keeps canary checks disabled
+func NewJobFromEnv(store *config.Store, client proxy.APIClientInterface) *Job {
+	interval := utils.GetEnvDuration("CANARY_INTERVAL", 0)
+	if interval <= 0 {
+		return nil
+	}
+	return &Job{
+		Config:     store,
+		Client:     client,
+		Status:     Default(),
+		Prompt:     utils.GetEnvString("CANARY_PROMPT", DefaultPrompt),
+		Expect:     utils.GetEnvString("CANARY_EXPECT", DefaultExpect),
+		MaxLatency: utils.GetEnvDuration("CANARY_MAX_LATENCY", DefaultMaxLatency),
+		Interval:   interval,
+	}
+}
+
+// Start runs the job immediately and then every Interval until ctx is cancelled
+func (j *Job) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(j.Interval)
+		defer ticker.Stop()
+
+		for {
+			j.RunOnce(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce checks every configured model once, publishes the results and returns them
+func (j *Job) RunOnce(ctx context.Context) []Result {
+	ctx = logger.WithComponent(ctx, "CanaryJob")
+	ctx = logger.WithStage(ctx, "Check")
+
+	snapshot := j.Config.Snapshot()
+	creds := snapshot.Credentials()
+
+	var results []Result
+	for _, model := range filter.ModelsByType(snapshot.Models(), config.ModelTypeChat) {
+		result := j.check(ctx, model, creds)
+		if !result.Healthy {
+			logger.Warn(ctx, "Canary check failed",
+				"vendor", result.Vendor,
+				"model", result.Model,
+				"reason", result.Reason,
+				"latency_ms", result.LatencyMs,
+			)
+		}
+		results = append(results, result)
+	}
+
+	j.Status.Replace(results)
+	return j.Status.Results()
+}
+
+// check sends the canary prompt to one model using the first credential for its vendor
+func (j *Job) check(ctx context.Context, model config.VendorModel, creds []config.Credential) Result {
+	result := Result{Vendor: model.Vendor, Model: model.Model, CheckedAt: time.Now().UTC()}
+
+	var selection *selector.VendorSelection
+	for _, cred := range creds {
+		if cred.Platform == model.Vendor {
+			selection = &selector.VendorSelection{
+				Vendor:     model.Vendor,
+				Model:      model.Model,
+				Credential: cred,
+				BaseURL:    model.BaseURL,
+				AuthHeader: model.AuthHeader,
+			}
+			break
+		}
+	}
+	if selection == nil {
+		result.Reason = "no credential configured for vendor"
+		return result
+	}
+
+	body, err := codec.Marshal(map[string]interface{}{
+		"model":    model.Model,
+		"messages": []map[string]string{{"role": "user", "content": j.Prompt}},
+	})
+	if err != nil {
+		result.Reason = err.Error()
+		return result
+	}
+
+	response, latency, err := j.send(ctx, selection, body)
+	result.LatencyMs = latency.Milliseconds()
+	if err != nil {
+		result.Reason = err.Error()
+		return result
+	}
+	if reason := j.verify(response, latency); reason != "" {
+		result.Reason = reason
+		return result
+	}
+	result.Healthy = true
+	return result
+}
+
+// send runs the request through the API client, giving up once the latency bound passes
+func (j *Job) send(ctx context.Context, selection *selector.VendorSelection, body []byte) (*responseRecorder, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set(utils.HeaderContentType, "application/json")
+
+	recorder := newResponseRecorder()
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		done <- j.Client.SendRequest(recorder, req, selection, body, selection.Model)
+	}()
+
+	timer := time.NewTimer(j.MaxLatency)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		latency := time.Since(start)
+		if err != nil {
+			return nil, latency, err
+		}
+		return recorder, latency, nil
+	case <-timer.C:
+		return nil, time.Since(start), fmt.Errorf("no response within %s", j.MaxLatency)
+	case <-ctx.Done():
+		return nil, time.Since(start), ctx.Err()
+	}
+}
+
+// verify returns why a completed canary response fails its expectations, or "" when it passes
+func (j *Job) verify(response *responseRecorder, latency time.Duration) string {
+	if response.status >= http.StatusBadRequest {
+		return fmt.Sprintf("status %d", response.status)
+	}
+	if latency > j.MaxLatency {
+		return fmt.Sprintf("latency %s exceeds %s", latency.Round(time.Millisecond), j.MaxLatency)
+	}
+
+	var completion struct {
+		Choices []struct {
+			FinishReason string `json:"finish_reason"`
+			Message      struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := codec.Unmarshal(response.body.Bytes(), &completion); err != nil {
+		return "response is not a chat completion"
+	}
+	if len(completion.Choices) == 0 {
+		return "response has no choices"
+	}
+
+	choice := completion.Choices[0]
+	content := strings.TrimSpace(choice.Message.Content)
+	switch {
+	case content == "":
+		return "empty content"
+	case choice.FinishReason != "stop":
+		return fmt.Sprintf("finish_reason %q, expected \"stop\"", choice.FinishReason)
+	case j.Expect != "" && !strings.Contains(strings.ToLower(content), strings.ToLower(j.Expect)):
+		return fmt.Sprintf("content does not contain %q", j.Expect)
+	}
+	return ""
+}
+
+// responseRecorder captures the response the API client writes for a canary request
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	return r.body.Write(p)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
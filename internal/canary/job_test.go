@@ -0,0 +1,99 @@
+package canary
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient answers canary requests with a canned response per model
+type fakeClient struct {
+	responses map[string]string
+	delay     time.Duration
+}
+
+func (f *fakeClient) SendRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, body []byte, originalModel string) error {
+	time.Sleep(f.delay)
+	response, ok := f.responses[selection.Model]
+	if !ok {
+		return fmt.Errorf("vendor unavailable")
+	}
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write([]byte(response))
+	return err
+}
+
+func completion(content, finishReason string) string {
+	return fmt.Sprintf(`{"choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":%q}]}`, content, finishReason)
+}
+
+func TestJob_RunOnce(t *testing.T) {
+	store := config.NewStore(config.NewSnapshot(config.Data{
+		Credentials: []config.Credential{{Platform: "openai", Type: "api-key", Value: "sk-test"}},
+		Models: []config.VendorModel{
+			{Vendor: "openai", Model: "healthy"},
+			{Vendor: "openai", Model: "empty"},
+			{Vendor: "openai", Model: "truncated"},
+			{Vendor: "openai", Model: "offtopic"},
+			{Vendor: "openai", Model: "down"},
+			{Vendor: "gemini", Model: "gemini-2.5-pro"},
+		},
+	}))
+	client := &fakeClient{responses: map[string]string{
+		"healthy":   completion("Pong.", "stop"),
+		"empty":     completion("  ", "stop"),
+		"truncated": completion("pong", "length"),
+		"offtopic":  completion("Hello there", "stop"),
+	}}
+	job := &Job{Config: store, Client: client, Status: NewStatus(false), Prompt: DefaultPrompt, Expect: DefaultExpect, MaxLatency: time.Second}
+
+	reasons := make(map[string]string)
+	for _, result := range job.RunOnce(context.Background()) {
+		assert.Equal(t, result.Reason == "", result.Healthy, result.Model)
+		reasons[result.Model] = result.Reason
+	}
+
+	assert.Equal(t, map[string]string{
+		"healthy":        "",
+		"empty":          "empty content",
+		"truncated":      `finish_reason "length", expected "stop"`,
+		"offtopic":       `content does not contain "pong"`,
+		"down":           "vendor unavailable",
+		"gemini-2.5-pro": "no credential configured for vendor",
+	}, reasons)
+	assert.Len(t, job.Status.Degraded(), 5)
+}
+
+func TestJob_LatencyBound(t *testing.T) {
+	store := config.NewStore(config.NewSnapshot(config.Data{
+		Credentials: []config.Credential{{Platform: "openai", Type: "api-key", Value: "sk-test"}},
+		Models:      []config.VendorModel{{Vendor: "openai", Model: "slow"}},
+	}))
+	client := &fakeClient{responses: map[string]string{"slow": completion("pong", "stop")}, delay: 200 * time.Millisecond}
+	job := &Job{Config: store, Client: client, Status: NewStatus(false), Expect: DefaultExpect, MaxLatency: 20 * time.Millisecond}
+
+	results := job.RunOnce(context.Background())
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Healthy)
+	assert.Equal(t, "no response within 20ms", results[0].Reason)
+}
+
+func TestNewJobFromEnv(t *testing.T) {
+	t.Setenv("CANARY_INTERVAL", "")
+	assert.Nil(t, NewJobFromEnv(config.NewStore(nil), &fakeClient{}))
+
+	t.Setenv("CANARY_INTERVAL", "300")
+	t.Setenv("CANARY_MAX_LATENCY", "10")
+	job := NewJobFromEnv(config.NewStore(nil), &fakeClient{})
+	require.NotNil(t, job)
+	assert.Equal(t, 5*time.Minute, job.Interval)
+	assert.Equal(t, 10*time.Second, job.MaxLatency)
+	assert.Equal(t, DefaultPrompt, job.Prompt)
+}
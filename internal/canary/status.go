@@ -0,0 +1,166 @@
+// Package canary periodically sends a fixed prompt to every configured model and
+// tracks which models fail the check so they can be reported and quarantined
+package canary
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Result is the outcome of the latest canary check for one vendor/model pair
+type Result struct {
+	Vendor              string    `json:"vendor"`
+	Model               string    `json:"model"`
+	Healthy             bool      `json:"healthy"`
+	Reason              string    `json:"reason,omitempty"`
+	LatencyMs           int64     `json:"latency_ms"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	CheckedAt           time.Time `json:"checked_at"`
+}
+
+// Status holds the latest canary results and decides which models are quarantined
+type Status struct {
+	mu         sync.RWMutex
+	enabled    bool
+	quarantine bool
+	results    map[string]Result
+}
+
+var (
+	defaultStatus     *Status
+	defaultStatusOnce sync.Once
+)
+
+// NewStatus creates an empty status; quarantine removes failing models from routing pools
+func NewStatus(quarantine bool) *Status {
+	return &Status{
+		quarantine: quarantine,
+		results:    make(map[string]Result),
+	}
+}
+
+// Default returns the process-wide canary status, with quarantine set from CANARY_QUARANTINE
+func Default() *Status {
+	defaultStatusOnce.Do(func() {
+		defaultStatus = NewStatus(utils.GetEnvBool("CANARY_QUARANTINE", false))
+	})
+	return defaultStatus
+}
+
+// Enabled reports whether a canary job has reported results into this status
+func (s *Status) Enabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.enabled
+}
+
+// Replace publishes the results of a complete canary run
+// Consecutive failure counts carry over from the previous run and models no longer
+// configured are dropped
+func (s *Status) Replace(results []Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := make(map[string]Result, len(results))
+	for _, result := range results {
+		key := modelKey(result.Vendor, result.Model)
+		if result.Healthy {
+			result.ConsecutiveFailures = 0
+		} else {
+			result.ConsecutiveFailures = s.results[key].ConsecutiveFailures + 1
+		}
+		next[key] = result
+	}
+	s.results = next
+	s.enabled = true
+}
+
+// Restore publishes results saved by an earlier process so quarantine holds until the
+// first canary run completes; models already checked by this process are left as they are
+func (s *Status) Restore(results []Result) {
+	if len(results) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, result := range results {
+		key := modelKey(result.Vendor, result.Model)
+		if _, ok := s.results[key]; !ok {
+			s.results[key] = result
+		}
+	}
+	s.enabled = true
+}
+
+// Results returns the latest result of every checked model sorted by vendor and model
+func (s *Status) Results() []Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]Result, 0, len(s.results))
+	for _, result := range s.results {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Vendor != results[j].Vendor {
+			return results[i].Vendor < results[j].Vendor
+		}
+		return results[i].Model < results[j].Model
+	})
+	return results
+}
+
+// Degraded returns the results of models that failed their latest check
+func (s *Status) Degraded() []Result {
+	var degraded []Result
+	for _, result := range s.Results() {
+		if !result.Healthy {
+			degraded = append(degraded, result)
+		}
+	}
+	return degraded
+}
+
+// Filter removes quarantined models, and credentials left without models, from a routing pool
+// When quarantine is off, or it would leave nothing to route to, the pool is returned unchanged
+func (s *Status) Filter(creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.quarantine || len(s.results) == 0 {
+		return creds, models
+	}
+
+	var keptModels []config.VendorModel
+	vendors := make(map[string]bool)
+	for _, model := range models {
+		if result, ok := s.results[modelKey(model.Vendor, model.Model)]; ok && !result.Healthy {
+			continue
+		}
+		keptModels = append(keptModels, model)
+		vendors[model.Vendor] = true
+	}
+
+	var keptCreds []config.Credential
+	for _, cred := range creds {
+		if vendors[cred.Platform] {
+			keptCreds = append(keptCreds, cred)
+		}
+	}
+
+	if len(keptModels) == 0 || len(keptCreds) == 0 {
+		return creds, models
+	}
+	return keptCreds, keptModels
+}
+
+// modelKey identifies a vendor/model pair
+func modelKey(vendor, model string) string {
+	return vendor + "/" + model
+}
@@ -0,0 +1,56 @@
+package canary
+
+import (
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus_Replace(t *testing.T) {
+	status := NewStatus(false)
+	assert.False(t, status.Enabled())
+
+	status.Replace([]Result{{Vendor: "openai", Model: "a"}, {Vendor: "openai", Model: "b", Healthy: true}})
+	status.Replace([]Result{{Vendor: "openai", Model: "a"}, {Vendor: "openai", Model: "b"}})
+
+	assert.True(t, status.Enabled())
+	results := status.Results()
+	assert.Equal(t, 2, results[0].ConsecutiveFailures)
+	assert.Equal(t, 1, results[1].ConsecutiveFailures)
+
+	status.Replace([]Result{{Vendor: "openai", Model: "a", Healthy: true}})
+	assert.Equal(t, []Result{{Vendor: "openai", Model: "a", Healthy: true}}, status.Results(), "removed models are dropped")
+	assert.Empty(t, status.Degraded())
+}
+
+func TestStatus_Filter(t *testing.T) {
+	creds := []config.Credential{{Platform: "openai"}, {Platform: "gemini"}}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o"},
+		{Vendor: "openai", Model: "gpt-4o-mini"},
+		{Vendor: "gemini", Model: "gemini-2.5-pro"},
+	}
+	results := []Result{
+		{Vendor: "openai", Model: "gpt-4o", Healthy: true},
+		{Vendor: "openai", Model: "gpt-4o-mini"},
+		{Vendor: "gemini", Model: "gemini-2.5-pro"},
+	}
+
+	observing := NewStatus(false)
+	observing.Replace(results)
+	keptCreds, keptModels := observing.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds, "without quarantine failing models stay routable")
+	assert.Equal(t, models, keptModels)
+
+	quarantining := NewStatus(true)
+	quarantining.Replace(results)
+	keptCreds, keptModels = quarantining.Filter(creds, models)
+	assert.Equal(t, []config.Credential{{Platform: "openai"}}, keptCreds)
+	assert.Equal(t, []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}}, keptModels)
+
+	quarantining.Replace([]Result{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "openai", Model: "gpt-4o-mini"}, {Vendor: "gemini", Model: "gemini-2.5-pro"}})
+	keptCreds, keptModels = quarantining.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds, "quarantining every model fails open")
+	assert.Equal(t, models, keptModels)
+}
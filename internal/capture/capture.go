@@ -0,0 +1,192 @@
+// Package capture keeps sanitized copies of the most recent request/response pairs so that
+// response-processing bugs can be inspected and replayed
+package capture
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Default limits of the process-wide store
+const (
+	DefaultSize         = 50
+	DefaultMaxBodyBytes = 256 << 10
+)
+
+// Message is the sanitized headers and body of a captured request or response
+type Message struct {
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+	// BodyEncoding is "base64" when the body is not UTF-8 text, e.g. an audio upload
+	BodyEncoding string `json:"body_encoding,omitempty"`
+	// Truncated is set when the body was longer than the store keeps
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// NewMessage captures headers, without credentials, and at most maxBody bytes of body
+func NewMessage(headers http.Header, body []byte, maxBody int) Message {
+	message := Message{Headers: sanitizeHeaders(headers)}
+	if maxBody > 0 && len(body) > maxBody {
+		body = body[:maxBody]
+		message.Truncated = true
+	}
+	if utf8.Valid(body) {
+		message.Body = string(body)
+	} else {
+		message.Body = base64.StdEncoding.EncodeToString(body)
+		message.BodyEncoding = "base64"
+	}
+	return message
+}
+
+// BodyBytes returns the captured body as sent
+func (m Message) BodyBytes() ([]byte, error) {
+	if m.BodyEncoding == "base64" {
+		return base64.StdEncoding.DecodeString(m.Body)
+	}
+	return []byte(m.Body), nil
+}
+
+// Exchange is one captured request and the response the router sent for it
+type Exchange struct {
+	// ID is the request ID the router assigned, which the routing decision log and the
+	// access log use too
+	ID         string    `json:"id"`
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Query      string    `json:"query,omitempty"`
+	Status     int       `json:"status"`
+	DurationMs int64     `json:"duration_ms"`
+	Request    Message   `json:"request"`
+	Response   Message   `json:"response"`
+}
+
+// Store is a fixed-size ring buffer of the most recent exchanges
+type Store struct {
+	// MaxBodyBytes caps how much of each request and response body is kept
+	MaxBodyBytes int
+
+	mu        sync.RWMutex
+	exchanges []Exchange
+	next      int
+	full      bool
+}
+
+var (
+	defaultStore     *Store
+	defaultStoreOnce sync.Once
+)
+
+// NewStore creates a store holding at most size exchanges, keeping up to maxBodyBytes of
+// each body
+func NewStore(size, maxBodyBytes int) *Store {
+	if size <= 0 {
+		size = 1
+	}
+	return &Store{MaxBodyBytes: maxBodyBytes, exchanges: make([]Exchange, size)}
+}
+
+// Default returns the process-wide store, or nil unless REQUEST_CAPTURE_ENABLED is set
+// Its limits are read once from REQUEST_CAPTURE_SIZE and REQUEST_CAPTURE_MAX_BODY_BYTES
+func Default() *Store {
+	defaultStoreOnce.Do(func() {
+		if utils.GetEnvBool("REQUEST_CAPTURE_ENABLED", false) {
+			defaultStore = NewStore(
+				utils.GetEnvInt("REQUEST_CAPTURE_SIZE", DefaultSize),
+				utils.GetEnvInt("REQUEST_CAPTURE_MAX_BODY_BYTES", DefaultMaxBodyBytes),
+			)
+		}
+	})
+	return defaultStore
+}
+
+// Record appends an exchange, overwriting the oldest one when the store is full
+func (s *Store) Record(exchange Exchange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.exchanges[s.next] = exchange
+	s.next = (s.next + 1) % len(s.exchanges)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// List returns up to limit exchanges, newest first; a non-positive limit returns them all
+func (s *Store) List(limit int) []Exchange {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := s.next
+	if s.full {
+		count = len(s.exchanges)
+	}
+	if limit > 0 && limit < count {
+		count = limit
+	}
+	results := make([]Exchange, 0, count)
+	for i := 0; i < count; i++ {
+		results = append(results, s.exchanges[(s.next-1-i+len(s.exchanges))%len(s.exchanges)])
+	}
+	return results
+}
+
+// Get returns the most recent exchange with the given ID
+func (s *Store) Get(id string) (Exchange, bool) {
+	for _, exchange := range s.List(0) {
+		if exchange.ID == id {
+			return exchange, true
+		}
+	}
+	return Exchange{}, false
+}
+
+// sanitizeHeaders copies headers without the ones carrying credentials, which are dropped
+// rather than redacted so that a replay never sends a placeholder as a key
+func sanitizeHeaders(headers http.Header) http.Header {
+	if len(headers) == 0 {
+		return nil
+	}
+	sanitized := make(http.Header, len(headers))
+	for key, values := range headers {
+		if isCredentialHeader(key) {
+			continue
+		}
+		sanitized[key] = append([]string(nil), values...)
+	}
+	return sanitized
+}
+
+// isCredentialHeader reports whether a header may carry a client's or vendor's credentials
+func isCredentialHeader(key string) bool {
+	lower := strings.ToLower(key)
+	switch lower {
+	case "authorization", "proxy-authorization", "cookie", "set-cookie":
+		return true
+	}
+	return strings.Contains(lower, "api-key") || strings.Contains(lower, "token") || strings.Contains(lower, "secret")
+}
+
+// SanitizeQuery removes the key parameter Google's SDKs may send their API key in
+func SanitizeQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return ""
+	}
+	if !query.Has("key") {
+		return rawQuery
+	}
+	query.Del("key")
+	return query.Encode()
+}
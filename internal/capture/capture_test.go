@@ -0,0 +1,95 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_RingBuffer(t *testing.T) {
+	store := NewStore(3, 1024)
+	for i := 1; i <= 5; i++ {
+		store.Record(Exchange{ID: fmt.Sprintf("req-%d", i)})
+	}
+
+	exchanges := store.List(0)
+	require.Len(t, exchanges, 3)
+	assert.Equal(t, "req-5", exchanges[0].ID)
+	assert.Equal(t, "req-3", exchanges[2].ID)
+	assert.Len(t, store.List(2), 2)
+
+	_, ok := store.Get("req-1")
+	assert.False(t, ok, "overwritten exchanges are gone")
+	exchange, ok := store.Get("req-4")
+	require.True(t, ok)
+	assert.Equal(t, "req-4", exchange.ID)
+}
+
+func TestNewMessage_StripsCredentials(t *testing.T) {
+	headers := http.Header{
+		"Authorization":  {"Bearer sk-secret"},
+		"X-Api-Key":      {"sk-ant"},
+		"X-Goog-Api-Key": {"gm-key"},
+		"Cookie":         {"session=1"},
+		"Content-Type":   {"application/json"},
+	}
+	message := NewMessage(headers, []byte(`{"model":"gpt-4o"}`), 1024)
+
+	assert.Equal(t, http.Header{"Content-Type": {"application/json"}}, message.Headers)
+	assert.Equal(t, `{"model":"gpt-4o"}`, message.Body)
+	assert.Empty(t, message.BodyEncoding)
+	assert.False(t, message.Truncated)
+
+	assert.Equal(t, "alt=sse", SanitizeQuery("alt=sse&key=gm-key"))
+	assert.Equal(t, "vendor=openai", SanitizeQuery("vendor=openai"))
+}
+
+func TestNewMessage_BinaryAndTruncatedBodies(t *testing.T) {
+	binary := []byte{0xff, 0xfe, 0x00, 0x01}
+	message := NewMessage(nil, binary, 1024)
+	assert.Equal(t, "base64", message.BodyEncoding)
+	body, err := message.BodyBytes()
+	require.NoError(t, err)
+	assert.Equal(t, binary, body)
+
+	message = NewMessage(nil, []byte("0123456789"), 4)
+	assert.Equal(t, "0123", message.Body)
+	assert.True(t, message.Truncated)
+}
+
+func TestSend(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "Bearer admin-key", r.Header.Get("Authorization"))
+		assert.Empty(t, r.Header.Get("X-Request-ID"), "the replay gets a request ID of its own")
+		assert.Equal(t, "vendor=openai", r.URL.RawQuery)
+		w.Header().Set("X-Request-ID", "req-replay")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(append([]byte("echo:"), body...))
+	})
+	exchange := Exchange{
+		ID:     "req-1",
+		Method: http.MethodPost,
+		Path:   "/v1/chat/completions",
+		Query:  "vendor=openai",
+		Request: Message{
+			Headers: http.Header{"X-Request-Id": {"req-1"}, "Content-Type": {"application/json"}},
+			Body:    `{"model":"gpt-4o"}`,
+		},
+	}
+
+	replay, err := Send(context.Background(), handler, exchange, "Bearer admin-key", 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "req-replay", replay.RequestID)
+	assert.Equal(t, http.StatusCreated, replay.Status)
+	assert.Equal(t, `echo:{"model":"gpt-4o"}`, replay.Response.Body)
+
+	exchange.Request.Truncated = true
+	_, err = Send(context.Background(), handler, exchange, "", 1024)
+	assert.ErrorIs(t, err, ErrTruncated)
+}
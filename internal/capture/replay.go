@@ -0,0 +1,93 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// ErrTruncated is returned when replaying an exchange whose request body was not kept whole
+var ErrTruncated = errors.New("the captured request body was truncated, so it cannot be replayed")
+
+// Replay is the response the router sent when a captured request was re-sent
+type Replay struct {
+	RequestID  string  `json:"request_id,omitempty"`
+	Status     int     `json:"status"`
+	DurationMs int64   `json:"duration_ms"`
+	Response   Message `json:"response"`
+}
+
+// Send re-sends the captured request through handler and returns the response it got
+// Captured requests carry no credentials, so the replay is sent with authorization, the
+// Authorization header of whoever asked for it, and with ctx, which carries their access
+func Send(ctx context.Context, handler http.Handler, exchange Exchange, authorization string, maxBodyBytes int) (Replay, error) {
+	if exchange.Request.Truncated {
+		return Replay{}, ErrTruncated
+	}
+	body, err := exchange.Request.BodyBytes()
+	if err != nil {
+		return Replay{}, err
+	}
+
+	target := exchange.Path
+	if exchange.Query != "" {
+		target += "?" + exchange.Query
+	}
+	req, err := http.NewRequestWithContext(ctx, exchange.Method, target, bytes.NewReader(body))
+	if err != nil {
+		return Replay{}, err
+	}
+	for key, values := range exchange.Request.Headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	// The request ID of the original must not be reused, so the replay can be told apart
+	req.Header.Del(utils.HeaderRequestID)
+	if authorization != "" {
+		req.Header.Set(utils.HeaderAuthorization, authorization)
+	}
+
+	start := time.Now()
+	rec := &recorder{header: make(http.Header)}
+	handler.ServeHTTP(rec, req)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return Replay{
+		RequestID:  rec.header.Get(utils.HeaderRequestID),
+		Status:     rec.status,
+		DurationMs: time.Since(start).Milliseconds(),
+		Response:   NewMessage(rec.header, rec.body.Bytes(), maxBodyBytes),
+	}, nil
+}
+
+// recorder collects the response of a replayed request
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(statusCode int) {
+	if r.status == 0 {
+		r.status = statusCode
+	}
+}
+
+func (r *recorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(data)
+}
+
+// Flush implements http.Flusher so that streamed responses can be replayed; the whole
+// stream is kept until it ends
+func (r *recorder) Flush() {}
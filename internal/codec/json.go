@@ -0,0 +1,44 @@
+// Package codec provides the JSON codec used on the request/response hot path.
+//
+// The default build uses encoding/json. Building with the "jsoniter" tag swaps in
+// json-iterator configured for standard library compatibility:
+//
+//	go build -tags jsoniter ./...
+package codec
+
+import "io"
+
+// Decoder reads and decodes JSON values from an input stream
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+// Encoder writes JSON values to an output stream
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+// Marshal returns the JSON encoding of v
+func Marshal(v interface{}) ([]byte, error) {
+	return impl.Marshal(v)
+}
+
+// Unmarshal parses JSON-encoded data and stores the result in v
+func Unmarshal(data []byte, v interface{}) error {
+	return impl.Unmarshal(data, v)
+}
+
+// NewDecoder returns a new decoder that reads from r
+func NewDecoder(r io.Reader) Decoder {
+	return impl.NewDecoder(r)
+}
+
+// NewEncoder returns a new encoder that writes to w
+func NewEncoder(w io.Writer) Encoder {
+	return impl.NewEncoder(w)
+}
+
+// Name returns the name of the JSON implementation compiled into the binary
+func Name() string {
+	return implName
+}
@@ -0,0 +1,10 @@
+//go:build jsoniter
+
+package codec
+
+import jsoniter "github.com/json-iterator/go"
+
+const implName = "json-iterator"
+
+// impl mirrors encoding/json behaviour (sorted map keys, HTML escaping) so outputs stay byte-compatible
+var impl = jsoniter.ConfigCompatibleWithStandardLibrary
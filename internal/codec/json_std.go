@@ -0,0 +1,20 @@
+//go:build !jsoniter
+
+package codec
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const implName = "encoding/json"
+
+// stdCodec adapts encoding/json to the codec functions
+type stdCodec struct{}
+
+var impl = stdCodec{}
+
+func (stdCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (stdCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (stdCodec) NewDecoder(r io.Reader) Decoder             { return json.NewDecoder(r) }
+func (stdCodec) NewEncoder(w io.Writer) Encoder             { return json.NewEncoder(w) }
@@ -0,0 +1,133 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Representative payloads for the normalized outputs produced on the hot path
+var compatibilityFixtures = map[string]string{
+	"chat_completion": `{
+		"id": "chatcmpl-abc123",
+		"object": "chat.completion",
+		"created": 1677652288,
+		"model": "gpt-4o",
+		"system_fingerprint": "fp_abc123",
+		"service_tier": "default",
+		"choices": [{
+			"index": 0,
+			"message": {"role": "assistant", "content": "Hello <b>world</b> & \"friends\"  ", "refusal": null, "annotations": []},
+			"logprobs": null,
+			"finish_reason": "stop"
+		}],
+		"usage": {
+			"prompt_tokens": 10,
+			"completion_tokens": 20,
+			"total_tokens": 30,
+			"prompt_tokens_details": {"cached_tokens": 0, "audio_tokens": 0},
+			"completion_tokens_details": {"reasoning_tokens": 0, "audio_tokens": 0}
+		}
+	}`,
+	"stream_chunk": `{
+		"id": "chatcmpl-abc123",
+		"object": "chat.completion.chunk",
+		"created": 1677652288,
+		"model": "gpt-4o",
+		"choices": [{"index": 0, "delta": {"content": "Hi 👋"}, "finish_reason": null}]
+	}`,
+	"tool_calls": `{
+		"choices": [{
+			"index": 0,
+			"message": {
+				"role": "assistant",
+				"content": null,
+				"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"location\":\"Boston\"}"}}]
+			},
+			"finish_reason": "tool_calls"
+		}]
+	}`,
+	"numbers": `{"a": 0.1, "b": 1e21, "c": -0, "d": 123456789012, "e": 3.14159, "f": 1.0}`,
+}
+
+func TestMarshal_CompatibleWithStandardLibrary(t *testing.T) {
+	for name, fixture := range compatibilityFixtures {
+		t.Run(name, func(t *testing.T) {
+			var expectedData, actualData interface{}
+			require.NoError(t, json.Unmarshal([]byte(fixture), &expectedData))
+			require.NoError(t, Unmarshal([]byte(fixture), &actualData))
+			assert.Equal(t, expectedData, actualData)
+
+			expected, err := json.Marshal(expectedData)
+			require.NoError(t, err)
+			actual, err := Marshal(actualData)
+			require.NoError(t, err)
+
+			assert.Equal(t, string(expected), string(actual), "codec %s output differs from encoding/json", Name())
+		})
+	}
+}
+
+func TestDecoderEncoder_RoundTrip(t *testing.T) {
+	fixture := compatibilityFixtures["chat_completion"]
+
+	var data map[string]interface{}
+	require.NoError(t, NewDecoder(strings.NewReader(fixture)).Decode(&data))
+
+	var buf bytes.Buffer
+	require.NoError(t, NewEncoder(&buf).Encode(data))
+
+	expected, err := json.Marshal(data)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected)+"\n", buf.String())
+}
+
+func TestUnmarshal_InvalidJSON(t *testing.T) {
+	var data map[string]interface{}
+	assert.Error(t, Unmarshal([]byte(`{"invalid": json`), &data))
+}
+
+// largeBody builds a chat completion response with many choices to mimic large payloads
+func largeBody() []byte {
+	var sb strings.Builder
+	sb.WriteString(`{"id":"chatcmpl-bench","object":"chat.completion","created":1677652288,"model":"gpt-4o","choices":[`)
+	for i := 0; i < 200; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(`{"index":0,"message":{"role":"assistant","content":"`)
+		sb.WriteString(strings.Repeat("lorem ipsum dolor sit amet ", 20))
+		sb.WriteString(`"},"finish_reason":"stop"}`)
+	}
+	sb.WriteString(`],"usage":{"prompt_tokens":10,"completion_tokens":20,"total_tokens":30}}`)
+	return []byte(sb.String())
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	body := largeBody()
+	b.SetBytes(int64(len(body)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var data map[string]interface{}
+		if err := Unmarshal(body, &data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(largeBody(), &data); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
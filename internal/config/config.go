@@ -2,32 +2,189 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 type Credential struct {
+	// ID names the credential for X-Force-Credential pinning; optional
+	ID       string `json:"id,omitempty"`
 	Platform string `json:"platform"`
 	Type     string `json:"type"`
 	Value    string `json:"value"`
+	// Weight is the credential's relative share of its vendor's traffic under weighted
+	// selection and round-robin rotation; unset counts as 1
+	Weight float64 `json:"weight,omitempty"`
+	// MaxConcurrent caps the vendor requests in flight on the credential; while it is at the
+	// cap the credential is left out of the routing pool. 0 is unlimited
+	MaxConcurrent int `json:"max_concurrent,omitempty"`
+}
+
+// CredentialTypeNone marks a credential for a vendor that needs no API key
+const CredentialTypeNone = "none"
+
+// KeylessVendors are self-hosted vendors that are reached without an API key
+var KeylessVendors = map[string]bool{
+	"ollama": true,
+}
+
+// RerankOnlyVendors serve no chat API, so their models must be rerank models
+var RerankOnlyVendors = map[string]bool{
+	"voyage": true,
+	"jina":   true,
+}
+
+// WithKeylessCredentials adds a keyless credential for every keyless vendor, or vendor of a
+// model with its own base URL, that has models but no credential, so those models can be
+// routed to without a credentials entry
+func WithKeylessCredentials(creds []Credential, models []VendorModel) []Credential {
+	hasCredential := make(map[string]bool)
+	for _, cred := range creds {
+		hasCredential[cred.Platform] = true
+	}
+	for _, model := range models {
+		if (KeylessVendors[model.Vendor] || model.BaseURL != "") && !hasCredential[model.Vendor] {
+			creds = append(creds, Credential{Platform: model.Vendor, Type: CredentialTypeNone})
+			hasCredential[model.Vendor] = true
+		}
+	}
+	return creds
 }
 
 type ModelConfig struct {
 	SupportImage     bool `json:"support_image"`
 	SupportVideo     bool `json:"support_video"`
+	SupportAudio     bool `json:"support_audio"`
 	SupportTools     bool `json:"support_tools"`
 	SupportStreaming bool `json:"support_streaming"`
+	ContextWindow    int  `json:"context_window,omitempty"`
 }
 
+// Model types; models without a type are chat models
+const (
+	ModelTypeChat          = "chat"
+	ModelTypeEmbedding     = "embedding"
+	ModelTypeTranscription = "transcription"
+	ModelTypeSpeech        = "speech"
+	ModelTypeModeration    = "moderation"
+	ModelTypeRerank        = "rerank"
+)
+
 type VendorModel struct {
 	Vendor string       `json:"vendor"`
 	Model  string       `json:"model"`
 	Config *ModelConfig `json:"config,omitempty"`
+	// Type is "chat" (the default), "embedding", "transcription", "speech", "moderation" or
+	// "rerank"; each API routes to models of its own type
+	Type string `json:"type,omitempty"`
+	// Voices maps the voice names clients ask a speech model for to the vendor's voices;
+	// voices without an entry are sent as-is
+	Voices map[string]string `json:"voices,omitempty"`
+	// BaseURL overrides the vendor's base URL for this model, so any OpenAI-compatible
+	// server (vLLM, LM Studio, TGI, ...) can be routed to under a vendor name of its own
+	BaseURL string `json:"base_url,omitempty"`
+	// AuthHeader is the header carrying the credential, with {key} standing for its value,
+	// e.g. "api-key: {key}"; it defaults to "Authorization: Bearer {key}"
+	AuthHeader string `json:"auth_header,omitempty"`
+	// Rollout puts a newly added model on trial with a ramped share of traffic
+	Rollout *RolloutConfig `json:"rollout,omitempty"`
+	// Pricing is what the model costs, used to hold vendors to their budgets
+	Pricing *ModelPricing `json:"pricing,omitempty"`
+	// Timeouts overrides the CLIENT_* timeouts of requests to the model
+	Timeouts *ModelTimeouts `json:"timeouts,omitempty"`
+	// Weight is the model's relative share of traffic under weighted selection; unset counts as 1
+	Weight float64 `json:"weight,omitempty"`
+	// Priority places the model in a fallback chain: requests go to the lowest-numbered
+	// priority that can serve them, and fall to the next when it fails. Unset is 0, the first
+	Priority int `json:"priority,omitempty"`
+}
+
+// ModelPricing is a model's price in US dollars per million tokens
+type ModelPricing struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million,omitempty"`
+}
+
+// ModelTimeouts bounds the phases of requests to a model, in seconds; unset phases keep the
+// router's defaults
+type ModelTimeouts struct {
+	// ConnectSeconds bounds connecting to the vendor
+	ConnectSeconds int `json:"connect_seconds,omitempty"`
+	// FirstByteSeconds bounds waiting for the response headers once the request is sent
+	FirstByteSeconds int `json:"first_byte_seconds,omitempty"`
+	// TotalSeconds bounds the whole request, including reading or streaming the response
+	TotalSeconds int `json:"total_seconds,omitempty"`
+}
+
+// VendorBudget caps what a vendor may cost in US dollars per UTC day and calendar month
+// A zero ceiling leaves that window unlimited
+type VendorBudget struct {
+	DailyUSD   float64 `json:"daily_usd,omitempty"`
+	MonthlyUSD float64 `json:"monthly_usd,omitempty"`
+}
+
+// ScheduleRule restricts the routing pool to some vendors or models while its window is open,
+// e.g. a batch-priced vendor at night or self-hosted models at weekends
+type ScheduleRule struct {
+	Name string `json:"name"`
+	// Window is when the rule applies: days and an optional time range such as "mon-fri
+	// 00:00-06:00", "sat,sun" or "* 22:00-02:00"
+	Window string `json:"window"`
+	// Timezone is the IANA time zone the window is read in; it defaults to UTC
+	Timezone string `json:"timezone,omitempty"`
+	// Vendors and Models (as "model" or "vendor/model") are what requests may be routed to
+	// while the rule applies; a model matching either is kept
+	Vendors []string `json:"vendors,omitempty"`
+	Models  []string `json:"models,omitempty"`
+}
+
+// RolloutConfig ramps a newly added model up from a small share of requests while it stays
+// healthy and rolls it back when it breaches its error rate or latency limit
+// Zero values take the defaults of the rollout package
+type RolloutConfig struct {
+	// InitialPercent is the share of requests the model is eligible for at first
+	InitialPercent float64 `json:"initial_percent"`
+	// StepPercent is added to the share after every healthy step
+	StepPercent float64 `json:"step_percent,omitempty"`
+	// StepSeconds is how long each step lasts before the model is promoted
+	StepSeconds int `json:"step_seconds,omitempty"`
+	// MinRequests is how many requests a step needs before it is judged
+	MinRequests int `json:"min_requests,omitempty"`
+	// MaxErrorRate is the highest share of failed requests, from 0 to 1, a step may have
+	MaxErrorRate float64 `json:"max_error_rate,omitempty"`
+	// MaxP95LatencyMs is the highest 95th percentile latency a step may have; 0 disables the check
+	MaxP95LatencyMs int64 `json:"max_p95_latency_ms,omitempty"`
+}
+
+// AuthHeaderKeyPlaceholder stands for the credential value in a model's AuthHeader
+const AuthHeaderKeyPlaceholder = "{key}"
+
+// ParseAuthHeader splits an auth header template such as "api-key: {key}" into the
+// header name and value template
+func ParseAuthHeader(template string) (string, string, error) {
+	name, value, found := strings.Cut(template, ":")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if !found || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("auth header %q must look like \"Header-Name: value\"", template)
+	}
+	if !strings.Contains(value, AuthHeaderKeyPlaceholder) {
+		return "", "", fmt.Errorf("auth header %q must contain %s", template, AuthHeaderKeyPlaceholder)
+	}
+	return name, value, nil
 }
 
 type ModelsConfig struct {
 	Vendors map[string]string `json:"vendors"`
 	Models  []VendorModel     `json:"models"`
+	// Budgets holds the cost ceilings of vendors, keyed by vendor name
+	Budgets map[string]VendorBudget `json:"budgets,omitempty"`
+	// Schedules restrict routing to some vendors or models during time windows
+	Schedules []ScheduleRule `json:"schedules,omitempty"`
+	// Aliases map virtual model names to the models, "model" or "vendor/model", that
+	// requests naming them are routed within
+	Aliases map[string][]string `json:"aliases,omitempty"`
 }
 
 func LoadCredentials(filePath string) ([]Credential, error) {
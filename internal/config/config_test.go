@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLoadCredentials(t *testing.T) {
@@ -317,3 +318,88 @@ func TestFilepathSecurity(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestWithKeylessCredentials(t *testing.T) {
+	creds := []Credential{{Platform: "openai", Type: "api-key", Value: "sk-test"}}
+	models := []VendorModel{
+		{Vendor: "openai", Model: "gpt-4o"},
+		{Vendor: "ollama", Model: "llama3.1:8b"},
+		{Vendor: "ollama", Model: "qwen2.5:7b"},
+	}
+
+	withKeyless := WithKeylessCredentials(creds, models)
+	assert.Equal(t, []Credential{creds[0], {Platform: "ollama", Type: CredentialTypeNone}}, withKeyless)
+	assert.Nil(t, ValidateConfiguration(withKeyless, models))
+
+	proxied := []Credential{{Platform: "ollama", Type: "api-key", Value: "proxy-token"}}
+	assert.Equal(t, proxied, WithKeylessCredentials(proxied, models[1:]), "an explicit credential is kept")
+}
+
+func TestModelEndpointOverrides(t *testing.T) {
+	tests := []struct {
+		name        string
+		model       VendorModel
+		expectedErr string
+	}{
+		{name: "custom vendor", model: VendorModel{Vendor: "vllm", Model: "Qwen/Qwen2.5-7B-Instruct", BaseURL: "http://vllm.internal:8000/v1"}},
+		{name: "custom auth header", model: VendorModel{Vendor: "tgi", Model: "tgi", BaseURL: "https://tgi.example.com/v1", AuthHeader: "X-Api-Key: {key}"}},
+		{name: "invalid base URL", model: VendorModel{Vendor: "vllm", Model: "m", BaseURL: "vllm.internal:8000"}, expectedErr: "invalid base_url"},
+		{name: "auth header without a name", model: VendorModel{Vendor: "tgi", Model: "m", BaseURL: "https://tgi.example.com/v1", AuthHeader: "Bearer {key}"}, expectedErr: "must look like"},
+		{name: "auth header without placeholder", model: VendorModel{Vendor: "tgi", Model: "m", BaseURL: "https://tgi.example.com/v1", AuthHeader: "X-Api-Key: secret"}, expectedErr: "must contain {key}"},
+		{name: "embedding model", model: VendorModel{Vendor: "tei", Model: "bge-m3", BaseURL: "https://tei.example.com/v1", Type: ModelTypeEmbedding}},
+		{name: "transcription model", model: VendorModel{Vendor: "whisper", Model: "large-v3", BaseURL: "https://whisper.example.com/v1", Type: ModelTypeTranscription}},
+		{name: "speech model", model: VendorModel{Vendor: "kokoro", Model: "kokoro-82m", BaseURL: "https://tts.example.com/v1", Type: ModelTypeSpeech, Voices: map[string]string{"alloy": "af_heart"}}},
+		{name: "moderation model", model: VendorModel{Vendor: "guard", Model: "llama-guard-3", BaseURL: "https://guard.example.com/v1", Type: ModelTypeModeration}},
+		{name: "rerank model", model: VendorModel{Vendor: "jina", Model: "jina-reranker-v2-base-multilingual", BaseURL: "https://reranker.example.com/v1", Type: ModelTypeRerank}},
+		{name: "chat model of a rerank vendor", model: VendorModel{Vendor: "voyage", Model: "rerank-2", BaseURL: "https://voyage.example.com/v1"}, expectedErr: "only serves rerank models"},
+		{name: "empty voice mapping", model: VendorModel{Vendor: "kokoro", Model: "kokoro-82m", BaseURL: "https://tts.example.com/v1", Type: ModelTypeSpeech, Voices: map[string]string{"alloy": ""}}, expectedErr: "voice names must not be empty"},
+		{name: "unknown model type", model: VendorModel{Vendor: "tei", Model: "m", BaseURL: "https://tei.example.com/v1", Type: "image"}, expectedErr: "invalid type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			models := []VendorModel{tt.model}
+			creds := WithKeylessCredentials(nil, models)
+			require.Len(t, creds, 1, "models with a base URL need no credentials entry")
+
+			err := ValidateConfiguration(creds, models)
+			if tt.expectedErr == "" {
+				assert.Nil(t, err)
+				assert.Nil(t, ValidateVendorModels(models))
+				return
+			}
+			require.NotNil(t, err)
+			assert.Contains(t, err.Message, tt.expectedErr)
+		})
+	}
+}
+
+func TestValidateBudgets(t *testing.T) {
+	priced := VendorModel{Vendor: "openai", Model: "gpt-4o", Pricing: &ModelPricing{InputPerMillion: 2.5, OutputPerMillion: 10}}
+	unpriced := VendorModel{Vendor: "openai", Model: "gpt-4o-mini"}
+
+	tests := []struct {
+		name        string
+		budgets     map[string]VendorBudget
+		models      []VendorModel
+		expectedErr string
+	}{
+		{name: "no budgets", models: []VendorModel{unpriced}},
+		{name: "priced models", budgets: map[string]VendorBudget{"openai": {DailyUSD: 50, MonthlyUSD: 1000}}, models: []VendorModel{priced}},
+		{name: "model without pricing", budgets: map[string]VendorBudget{"openai": {MonthlyUSD: 1000}}, models: []VendorModel{priced, unpriced}, expectedErr: "gpt-4o-mini needs pricing"},
+		{name: "negative ceiling", budgets: map[string]VendorBudget{"openai": {DailyUSD: -1}}, models: []VendorModel{priced}, expectedErr: "negative ceiling"},
+		{name: "vendor without models", budgets: map[string]VendorBudget{"gemini": {DailyUSD: 5}}, models: []VendorModel{priced}, expectedErr: "has no models"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBudgets(tt.budgets, tt.models)
+			if tt.expectedErr == "" {
+				assert.Nil(t, err)
+				return
+			}
+			require.NotNil(t, err)
+			assert.Contains(t, err.Message, tt.expectedErr)
+		})
+	}
+}
@@ -88,6 +88,103 @@ func LoadCredentialsFromEnv() ([]Credential, error) {
 		})
 	}
 
+	// Check for Mistral credentials
+	if mistralKey := os.Getenv("MISTRAL_API_KEY"); mistralKey != "" {
+		credentials = append(credentials, Credential{
+			Platform: "mistral",
+			Type:     "api-key",
+			Value:    mistralKey,
+		})
+	}
+
+	// Check for Cohere credentials
+	if cohereKey := os.Getenv("COHERE_API_KEY"); cohereKey != "" {
+		credentials = append(credentials, Credential{
+			Platform: "cohere",
+			Type:     "api-key",
+			Value:    cohereKey,
+		})
+	}
+
+	// Check for Groq credentials
+	if groqKey := os.Getenv("GROQ_API_KEY"); groqKey != "" {
+		credentials = append(credentials, Credential{
+			Platform: "groq",
+			Type:     "api-key",
+			Value:    groqKey,
+		})
+	}
+
+	// Check for Together AI credentials
+	if togetherKey := os.Getenv("TOGETHER_API_KEY"); togetherKey != "" {
+		credentials = append(credentials, Credential{
+			Platform: "together",
+			Type:     "api-key",
+			Value:    togetherKey,
+		})
+	}
+
+	// Check for DeepSeek credentials
+	if deepSeekKey := os.Getenv("DEEPSEEK_API_KEY"); deepSeekKey != "" {
+		credentials = append(credentials, Credential{
+			Platform: "deepseek",
+			Type:     "api-key",
+			Value:    deepSeekKey,
+		})
+	}
+
+	// Check for HuggingFace credentials
+	if huggingFaceToken := os.Getenv("HUGGINGFACE_API_KEY"); huggingFaceToken != "" {
+		credentials = append(credentials, Credential{
+			Platform: "huggingface",
+			Type:     "api-key",
+			Value:    huggingFaceToken,
+		})
+	}
+
+	// Check for an Ollama key, only needed for instances behind an authenticating proxy
+	if ollamaKey := os.Getenv("OLLAMA_API_KEY"); ollamaKey != "" {
+		credentials = append(credentials, Credential{
+			Platform: "ollama",
+			Type:     "api-key",
+			Value:    ollamaKey,
+		})
+	}
+
+	// Check for Voyage AI credentials
+	if voyageKey := os.Getenv("VOYAGE_API_KEY"); voyageKey != "" {
+		credentials = append(credentials, Credential{
+			Platform: "voyage",
+			Type:     "api-key",
+			Value:    voyageKey,
+		})
+	}
+
+	// Check for Jina AI credentials
+	if jinaKey := os.Getenv("JINA_API_KEY"); jinaKey != "" {
+		credentials = append(credentials, Credential{
+			Platform: "jina",
+			Type:     "api-key",
+			Value:    jinaKey,
+		})
+	}
+
+	// Check for Vertex AI credentials: a service account key (JSON or base64 JSON) or an OAuth access token
+	if vertexKey := os.Getenv("VERTEX_SERVICE_ACCOUNT_KEY"); vertexKey != "" {
+		credentials = append(credentials, Credential{
+			Platform: "vertex",
+			Type:     "service-account",
+			Value:    vertexKey,
+		})
+	}
+	if vertexToken := os.Getenv("VERTEX_ACCESS_TOKEN"); vertexToken != "" {
+		credentials = append(credentials, Credential{
+			Platform: "vertex",
+			Type:     "oauth",
+			Value:    vertexToken,
+		})
+	}
+
 	// Check for multiple credentials with numbered suffixes
 	for i := 1; i <= 20; i++ {
 		if openaiKey := os.Getenv(fmt.Sprintf("OPENAI_API_KEY_%d", i)); openaiKey != "" {
@@ -104,6 +201,41 @@ func LoadCredentialsFromEnv() ([]Credential, error) {
 				Value:    geminiKey,
 			})
 		}
+		if mistralKey := os.Getenv(fmt.Sprintf("MISTRAL_API_KEY_%d", i)); mistralKey != "" {
+			credentials = append(credentials, Credential{
+				Platform: "mistral",
+				Type:     "api-key",
+				Value:    mistralKey,
+			})
+		}
+		if groqKey := os.Getenv(fmt.Sprintf("GROQ_API_KEY_%d", i)); groqKey != "" {
+			credentials = append(credentials, Credential{
+				Platform: "groq",
+				Type:     "api-key",
+				Value:    groqKey,
+			})
+		}
+		if togetherKey := os.Getenv(fmt.Sprintf("TOGETHER_API_KEY_%d", i)); togetherKey != "" {
+			credentials = append(credentials, Credential{
+				Platform: "together",
+				Type:     "api-key",
+				Value:    togetherKey,
+			})
+		}
+		if deepSeekKey := os.Getenv(fmt.Sprintf("DEEPSEEK_API_KEY_%d", i)); deepSeekKey != "" {
+			credentials = append(credentials, Credential{
+				Platform: "deepseek",
+				Type:     "api-key",
+				Value:    deepSeekKey,
+			})
+		}
+		if huggingFaceToken := os.Getenv(fmt.Sprintf("HUGGINGFACE_API_KEY_%d", i)); huggingFaceToken != "" {
+			credentials = append(credentials, Credential{
+				Platform: "huggingface",
+				Type:     "api-key",
+				Value:    huggingFaceToken,
+			})
+		}
 	}
 
 	if len(credentials) == 0 {
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Data is a mutable copy of the router configuration used to build snapshots
+type Data struct {
+	Credentials []Credential
+	Models      []VendorModel
+	Vendors     map[string]string
+}
+
+// Snapshot is an immutable view of the router configuration
+// Accessors return copies, so callers may freely modify what they receive
+type Snapshot struct {
+	credentials []Credential
+	models      []VendorModel
+	vendors     map[string]string
+	version     uint64
+	loadedAt    time.Time
+}
+
+// NewSnapshot creates a snapshot holding a deep copy of data
+func NewSnapshot(data Data) *Snapshot {
+	copied := data.clone()
+	return &Snapshot{
+		credentials: copied.Credentials,
+		models:      copied.Models,
+		vendors:     copied.Vendors,
+		loadedAt:    time.Now().UTC(),
+	}
+}
+
+// Credentials returns the configured credentials
+func (s *Snapshot) Credentials() []Credential {
+	return append([]Credential(nil), s.credentials...)
+}
+
+// Models returns the configured vendor/model pairs
+// The returned ModelConfig pointers are shared with the snapshot and must be treated as read-only
+func (s *Snapshot) Models() []VendorModel {
+	return append([]VendorModel(nil), s.models...)
+}
+
+// Vendors returns the configured vendor base URLs
+func (s *Snapshot) Vendors() map[string]string {
+	return cloneVendors(s.vendors)
+}
+
+// BaseURL returns the configured base URL of vendor without copying the vendor map
+func (s *Snapshot) BaseURL(vendor string) (string, bool) {
+	baseURL, ok := s.vendors[vendor]
+	return baseURL, ok
+}
+
+// CredentialCount returns the number of credentials without copying them
+func (s *Snapshot) CredentialCount() int {
+	return len(s.credentials)
+}
+
+// ModelCount returns the number of vendor/model pairs without copying them
+func (s *Snapshot) ModelCount() int {
+	return len(s.models)
+}
+
+// Version is incremented every time a store publishes a new snapshot
+func (s *Snapshot) Version() uint64 {
+	return s.version
+}
+
+// LoadedAt returns when the snapshot was created
+func (s *Snapshot) LoadedAt() time.Time {
+	return s.loadedAt
+}
+
+// Data returns a deep, mutable copy of the snapshot's configuration
+func (s *Snapshot) Data() Data {
+	return Data{
+		Credentials: s.credentials,
+		Models:      s.models,
+		Vendors:     s.vendors,
+	}.clone()
+}
+
+// Store publishes configuration snapshots with copy-on-write semantics
+// Readers load the current snapshot without locking; writers build a new
+// snapshot and swap it in atomically, so in-flight requests keep a consistent view
+type Store struct {
+	mu      sync.Mutex
+	current atomic.Pointer[Snapshot]
+}
+
+// NewStore creates a store publishing initial as version 1
+func NewStore(initial *Snapshot) *Store {
+	if initial == nil {
+		initial = NewSnapshot(Data{})
+	}
+	store := &Store{}
+	store.publish(initial, 0)
+	return store
+}
+
+// Snapshot returns the current configuration snapshot
+func (st *Store) Snapshot() *Snapshot {
+	return st.current.Load()
+}
+
+// Swap publishes next as the current snapshot and returns the previous one
+func (st *Store) Swap(next *Snapshot) *Snapshot {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	previous := st.current.Load()
+	st.publish(next, previous.version)
+	return previous
+}
+
+// Update applies mutate to a copy of the current configuration, validates the
+// result and publishes it. The current snapshot is left untouched if mutate or
+// validation fails
+func (st *Store) Update(mutate func(*Data) error) (*Snapshot, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	previous := st.current.Load()
+	data := previous.Data()
+	if err := mutate(&data); err != nil {
+		return nil, err
+	}
+	data.Credentials = WithKeylessCredentials(data.Credentials, data.Models)
+	if validationErr := ValidateConfiguration(data.Credentials, data.Models); validationErr != nil {
+		return nil, validationErr
+	}
+
+	return st.publish(NewSnapshot(data), previous.version), nil
+}
+
+// publish makes a copy of next, stamped with the version following previousVersion, current
+// Callers other than NewStore must hold st.mu
+func (st *Store) publish(next *Snapshot, previousVersion uint64) *Snapshot {
+	published := *next
+	published.version = previousVersion + 1
+	st.current.Store(&published)
+	return &published
+}
+
+// clone returns a deep copy of the configuration data
+func (d Data) clone() Data {
+	copied := Data{
+		Credentials: append([]Credential(nil), d.Credentials...),
+		Models:      make([]VendorModel, len(d.Models)),
+		Vendors:     cloneVendors(d.Vendors),
+	}
+	for i, model := range d.Models {
+		if model.Config != nil {
+			modelConfig := *model.Config
+			model.Config = &modelConfig
+		}
+		copied.Models[i] = model
+	}
+	return copied
+}
+
+// cloneVendors returns a copy of a vendor base URL map
+func cloneVendors(vendors map[string]string) map[string]string {
+	copied := make(map[string]string, len(vendors))
+	for vendor, baseURL := range vendors {
+		copied[vendor] = baseURL
+	}
+	return copied
+}
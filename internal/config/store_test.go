@@ -0,0 +1,105 @@
+package config
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testData() Data {
+	return Data{
+		Credentials: []Credential{{Platform: "openai", Type: "api-key", Value: "sk-test"}},
+		Models: []VendorModel{
+			{Vendor: "openai", Model: "gpt-4o", Config: &ModelConfig{SupportTools: true}},
+		},
+		Vendors: map[string]string{"openai": "https://api.openai.com/v1"},
+	}
+}
+
+func TestSnapshot_IsolatedFromCallers(t *testing.T) {
+	data := testData()
+	snapshot := NewSnapshot(data)
+
+	// Mutating the source data does not leak into the snapshot
+	data.Credentials[0].Value = "changed"
+	data.Models[0].Config.SupportTools = false
+	data.Vendors["openai"] = "changed"
+
+	assert.Equal(t, "sk-test", snapshot.Credentials()[0].Value)
+	assert.True(t, snapshot.Models()[0].Config.SupportTools)
+	assert.Equal(t, "https://api.openai.com/v1", snapshot.Vendors()["openai"])
+
+	// Mutating accessor results does not leak either
+	snapshot.Credentials()[0].Value = "changed"
+	snapshot.Vendors()["gemini"] = "added"
+	assert.Equal(t, "sk-test", snapshot.Credentials()[0].Value)
+	assert.NotContains(t, snapshot.Vendors(), "gemini")
+
+	copied := snapshot.Data()
+	copied.Models[0].Config.SupportTools = false
+	assert.True(t, snapshot.Models()[0].Config.SupportTools)
+}
+
+func TestStore_Update(t *testing.T) {
+	store := NewStore(NewSnapshot(testData()))
+	initial := store.Snapshot()
+	assert.Equal(t, uint64(1), initial.Version())
+
+	next, err := store.Update(func(data *Data) error {
+		data.Models = append(data.Models, VendorModel{Vendor: "openai", Model: "gpt-4o-mini"})
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), next.Version())
+	assert.Equal(t, 2, store.Snapshot().ModelCount())
+	assert.Equal(t, 1, initial.ModelCount(), "previous snapshots are never modified")
+
+	_, err = store.Update(func(data *Data) error {
+		data.Models = nil
+		return nil
+	})
+	assert.Error(t, err, "invalid configurations are not published")
+
+	_, err = store.Update(func(data *Data) error {
+		return errors.New("aborted")
+	})
+	assert.EqualError(t, err, "aborted")
+	assert.Equal(t, uint64(2), store.Snapshot().Version())
+}
+
+func TestStore_Swap(t *testing.T) {
+	store := NewStore(nil)
+	assert.Equal(t, 0, store.Snapshot().ModelCount())
+
+	previous := store.Swap(NewSnapshot(testData()))
+	assert.Equal(t, uint64(1), previous.Version())
+	assert.Equal(t, uint64(2), store.Snapshot().Version())
+	assert.Equal(t, 1, store.Snapshot().ModelCount())
+}
+
+func TestStore_ConcurrentAccess(t *testing.T) {
+	store := NewStore(NewSnapshot(testData()))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				snapshot := store.Snapshot()
+				assert.Equal(t, len(snapshot.Models()), snapshot.ModelCount())
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				store.Swap(NewSnapshot(testData()))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, uint64(81), store.Snapshot().Version())
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/aashari/go-generative-api-router/internal/errors"
@@ -16,15 +17,24 @@ type AppConfig struct {
 
 // Credential validation tags
 type ValidatedCredential struct {
-	Platform string `validate:"required,oneof=openai gemini anthropic"`
-	Type     string `validate:"required,oneof=api-key oauth"`
-	Value    string `validate:"required,min=1"`
+	Platform string `validate:"required,oneof=openai gemini anthropic vertex mistral cohere groq together deepseek huggingface ollama voyage jina"`
+	Type     string `validate:"required,oneof=api-key oauth service-account none"`
+	Value    string `validate:"required_unless=Type none"`
 }
 
 // VendorModel validation tags
 type ValidatedVendorModel struct {
-	Vendor string `validate:"required,oneof=openai gemini anthropic"`
+	Vendor string `validate:"required,oneof=openai gemini anthropic vertex mistral cohere groq together deepseek huggingface ollama voyage jina"`
 	Model  string `validate:"required,min=1"`
+	Type   string `validate:"omitempty,oneof=chat embedding transcription speech moderation rerank"`
+}
+
+// ValidatedCustomVendorModel validates models with their own base URL, which may use any vendor name
+type ValidatedCustomVendorModel struct {
+	Vendor  string `validate:"required"`
+	Model   string `validate:"required,min=1"`
+	BaseURL string `validate:"required,url"`
+	Type    string `validate:"omitempty,oneof=chat embedding transcription speech moderation rerank"`
 }
 
 var validate *validator.Validate
@@ -106,9 +116,18 @@ func validateCredential(cred Credential, index int) *errors.APIError {
 
 // validateVendorModel validates a single vendor model
 func validateVendorModel(model VendorModel, index int) *errors.APIError {
-	validatedModel := ValidatedVendorModel{
+	var validatedModel interface{} = ValidatedVendorModel{
 		Vendor: model.Vendor,
 		Model:  model.Model,
+		Type:   model.Type,
+	}
+	if model.BaseURL != "" {
+		validatedModel = ValidatedCustomVendorModel{
+			Vendor:  model.Vendor,
+			Model:   model.Model,
+			BaseURL: model.BaseURL,
+			Type:    model.Type,
+		}
 	}
 
 	if err := validate.Struct(validatedModel); err != nil {
@@ -136,6 +155,22 @@ func validateAPIKeyFormat(platform, apiKey string) error {
 		if !strings.HasPrefix(apiKey, "sk-ant-") {
 			return fmt.Errorf("Anthropic API key must start with 'sk-ant-'")
 		}
+	case "mistral":
+		if len(apiKey) < 20 {
+			return fmt.Errorf("Mistral API key appears to be too short")
+		}
+	case "deepseek":
+		if !strings.HasPrefix(apiKey, "sk-") {
+			return fmt.Errorf("DeepSeek API key must start with 'sk-'")
+		}
+	case "huggingface":
+		if !strings.HasPrefix(apiKey, "hf_") {
+			return fmt.Errorf("HuggingFace token must start with 'hf_'")
+		}
+	case "groq":
+		if !strings.HasPrefix(apiKey, "gsk_") {
+			return fmt.Errorf("Groq API key must start with 'gsk_'")
+		}
 	}
 	return nil
 }
@@ -159,6 +194,59 @@ func validateBusinessRules(creds []Credential, models []VendorModel) *errors.API
 		return errors.NewConfigurationError(fmt.Sprintf("Missing credentials for vendors: %s", strings.Join(missingCreds, ", ")))
 	}
 
+	for i, cred := range creds {
+		if cred.Weight < 0 {
+			return errors.NewConfigurationError(fmt.Sprintf("Credential %d has a negative weight", i))
+		}
+		if cred.MaxConcurrent < 0 {
+			return errors.NewConfigurationError(fmt.Sprintf("Credential %d has a negative max_concurrent", i))
+		}
+	}
+
+	// Check per-model endpoint overrides
+	for _, model := range models {
+		if model.BaseURL != "" {
+			if parsed, err := url.Parse(model.BaseURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return errors.NewConfigurationError(fmt.Sprintf("Model %s:%s has an invalid base_url: %s", model.Vendor, model.Model, model.BaseURL))
+			}
+		}
+		if model.AuthHeader != "" {
+			if _, _, err := ParseAuthHeader(model.AuthHeader); err != nil {
+				return errors.NewConfigurationError(fmt.Sprintf("Model %s:%s: %s", model.Vendor, model.Model, err.Error()))
+			}
+		}
+		if RerankOnlyVendors[model.Vendor] && model.Type != ModelTypeRerank {
+			return errors.NewConfigurationError(fmt.Sprintf("Model %s:%s must have type %q, %s only serves rerank models", model.Vendor, model.Model, ModelTypeRerank, model.Vendor))
+		}
+		switch model.Type {
+		case "", ModelTypeChat, ModelTypeEmbedding, ModelTypeTranscription, ModelTypeSpeech, ModelTypeModeration, ModelTypeRerank:
+		default:
+			return errors.NewConfigurationError(fmt.Sprintf("Model %s:%s has an invalid type %q, expected %q, %q, %q, %q, %q or %q", model.Vendor, model.Model, model.Type, ModelTypeChat, ModelTypeEmbedding, ModelTypeTranscription, ModelTypeSpeech, ModelTypeModeration, ModelTypeRerank))
+		}
+		for voice, vendorVoice := range model.Voices {
+			if voice == "" || vendorVoice == "" {
+				return errors.NewConfigurationError(fmt.Sprintf("Model %s:%s maps voice %q to %q; voice names must not be empty", model.Vendor, model.Model, voice, vendorVoice))
+			}
+		}
+		if model.Rollout != nil {
+			if err := validateRollout(model.Rollout); err != nil {
+				return errors.NewConfigurationError(fmt.Sprintf("Model %s:%s has an invalid rollout: %s", model.Vendor, model.Model, err.Error()))
+			}
+		}
+		if model.Pricing != nil && (model.Pricing.InputPerMillion < 0 || model.Pricing.OutputPerMillion < 0) {
+			return errors.NewConfigurationError(fmt.Sprintf("Model %s:%s has a negative price", model.Vendor, model.Model))
+		}
+		if timeouts := model.Timeouts; timeouts != nil && (timeouts.ConnectSeconds < 0 || timeouts.FirstByteSeconds < 0 || timeouts.TotalSeconds < 0) {
+			return errors.NewConfigurationError(fmt.Sprintf("Model %s:%s has a negative timeout", model.Vendor, model.Model))
+		}
+		if model.Weight < 0 {
+			return errors.NewConfigurationError(fmt.Sprintf("Model %s:%s has a negative weight", model.Vendor, model.Model))
+		}
+		if model.Priority < 0 {
+			return errors.NewConfigurationError(fmt.Sprintf("Model %s:%s has a negative priority", model.Vendor, model.Model))
+		}
+	}
+
 	// Check for duplicate models
 	modelKeys := make(map[string]bool)
 	for _, model := range models {
@@ -172,6 +260,30 @@ func validateBusinessRules(creds []Credential, models []VendorModel) *errors.API
 	return nil
 }
 
+// ValidateBudgets checks vendor budgets against the models they cover
+// Every model of a vendor with a budget needs pricing, or its requests would be free
+func ValidateBudgets(budgets map[string]VendorBudget, models []VendorModel) *errors.APIError {
+	for vendor, budget := range budgets {
+		if budget.DailyUSD < 0 || budget.MonthlyUSD < 0 {
+			return errors.NewConfigurationError(fmt.Sprintf("Budget for vendor %s has a negative ceiling", vendor))
+		}
+		covered := false
+		for _, model := range models {
+			if model.Vendor != vendor {
+				continue
+			}
+			covered = true
+			if model.Pricing == nil {
+				return errors.NewConfigurationError(fmt.Sprintf("Model %s:%s needs pricing because vendor %s has a budget", model.Vendor, model.Model, vendor))
+			}
+		}
+		if !covered {
+			return errors.NewConfigurationError(fmt.Sprintf("Budget set for vendor %s, which has no models", vendor))
+		}
+	}
+	return nil
+}
+
 // formatValidationError formats validator errors into APIError
 func formatValidationError(err error) *errors.APIError {
 	if validationErrors, ok := err.(validator.ValidationErrors); ok {
@@ -221,3 +333,18 @@ func formatFieldError(e validator.FieldError) string {
 		return fmt.Sprintf("field '%s' failed validation: %s", e.Field(), e.Tag())
 	}
 }
+
+// validateRollout checks a model's rollout settings are within range
+func validateRollout(rollout *RolloutConfig) error {
+	switch {
+	case rollout.InitialPercent <= 0 || rollout.InitialPercent > 100:
+		return fmt.Errorf("initial_percent must be above 0 and at most 100")
+	case rollout.StepPercent < 0 || rollout.StepPercent > 100:
+		return fmt.Errorf("step_percent must be between 0 and 100")
+	case rollout.StepSeconds < 0 || rollout.MinRequests < 0 || rollout.MaxP95LatencyMs < 0:
+		return fmt.Errorf("step_seconds, min_requests and max_p95_latency_ms must not be negative")
+	case rollout.MaxErrorRate < 0 || rollout.MaxErrorRate > 1:
+		return fmt.Errorf("max_error_rate must be between 0 and 1")
+	}
+	return nil
+}
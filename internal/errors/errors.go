@@ -21,6 +21,8 @@ const (
 	ErrorTypeInternal       ErrorType = "internal_error"
 	ErrorTypeExternal       ErrorType = "external_error"
 	ErrorTypeConfiguration  ErrorType = "configuration_error"
+	ErrorTypeInvalidRequest ErrorType = "invalid_request_error"
+	ErrorTypeUnavailable    ErrorType = "service_unavailable_error"
 )
 
 // APIError represents a structured API error
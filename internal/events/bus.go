@@ -0,0 +1,150 @@
+// Package events publishes request lifecycle events in-process, so metrics, audit
+// logging, alerting and plugins can observe requests without living in the proxy code
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+)
+
+// Type identifies a point in a request's lifecycle
+type Type string
+
+// Request lifecycle events, in the order a successful request publishes them
+// VendorResponded is published once per vendor attempt, including retries and fallbacks
+const (
+	RequestReceived  Type = "request.received"
+	VendorSelected   Type = "vendor.selected"
+	RequestValidated Type = "request.validated"
+	VendorResponded  Type = "vendor.responded"
+	RequestCompleted Type = "request.completed"
+	RequestFailed    Type = "request.failed"
+)
+
+// Event describes one lifecycle step of a request; fields that do not apply are left empty
+type Event struct {
+	Type          Type
+	RequestID     string
+	Timestamp     time.Time
+	OriginalModel string
+	Vendor        string
+	Model         string
+	// StatusCode is the vendor's HTTP status, set on VendorResponded
+	StatusCode int
+	// Duration is the vendor latency on VendorResponded, and the total request time on
+	// RequestCompleted and RequestFailed
+	Duration time.Duration
+	// Error is set on RequestFailed, and on VendorResponded when the vendor could not be reached
+	Error string
+	// Decision is the final routing decision, set on RequestCompleted and RequestFailed
+	Decision *monitoring.RoutingDecision
+}
+
+// Handler receives published events
+// Handlers run synchronously on the request path and must hand slow work off to a goroutine
+type Handler func(ctx context.Context, event Event)
+
+// subscription is a handler and the event types it receives (all types when empty)
+type subscription struct {
+	handler Handler
+	types   map[Type]bool
+}
+
+// Bus delivers events to subscribers in the order they subscribed
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions map[int]subscription
+	order         []int
+	nextID        int
+}
+
+var (
+	defaultBus     *Bus
+	defaultBusOnce sync.Once
+)
+
+// NewBus creates a bus with no subscribers
+func NewBus() *Bus {
+	return &Bus{subscriptions: make(map[int]subscription)}
+}
+
+// Default returns the process-wide bus
+func Default() *Bus {
+	defaultBusOnce.Do(func() {
+		defaultBus = NewBus()
+	})
+	return defaultBus
+}
+
+// Subscribe registers handler for the given event types, or every type when none are given,
+// and returns a function that removes the subscription
+func (b *Bus) Subscribe(handler Handler, types ...Type) func() {
+	sub := subscription{handler: handler}
+	if len(types) > 0 {
+		sub.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	b.subscriptions[id] = sub
+	b.order = append(b.order, id)
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscriptions, id)
+		for i, existing := range b.order {
+			if existing == id {
+				b.order = append(b.order[:i:i], b.order[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Publish delivers event to its subscribers, stamping it with the current time if unset
+// A panicking handler is logged and does not stop delivery to the others
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.order))
+	for _, id := range b.order {
+		sub := b.subscriptions[id]
+		if sub.types == nil || sub.types[event.Type] {
+			handlers = append(handlers, sub.handler)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		deliver(ctx, handler, event)
+	}
+}
+
+// deliver runs one handler, recovering from panics
+func deliver(ctx context.Context, handler Handler, event Event) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logger.Error(ctx, "Event handler panicked", fmt.Errorf("%v", recovered),
+				"event_type", string(event.Type),
+				"request_id", event.RequestID,
+				"component", "EventBus",
+				"stage", "Delivery",
+			)
+		}
+	}()
+	handler(ctx, event)
+}
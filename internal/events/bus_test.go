@@ -0,0 +1,50 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus_SubscribeAndPublish(t *testing.T) {
+	bus := NewBus()
+
+	var all, outcomes []Type
+	bus.Subscribe(func(ctx context.Context, event Event) {
+		all = append(all, event.Type)
+	})
+	unsubscribe := bus.Subscribe(func(ctx context.Context, event Event) {
+		outcomes = append(outcomes, event.Type)
+		assert.False(t, event.Timestamp.IsZero(), "events are timestamped")
+	}, RequestCompleted, RequestFailed)
+
+	for _, eventType := range []Type{RequestReceived, VendorSelected, RequestCompleted} {
+		bus.Publish(context.Background(), Event{Type: eventType})
+	}
+	assert.Equal(t, []Type{RequestReceived, VendorSelected, RequestCompleted}, all)
+	assert.Equal(t, []Type{RequestCompleted}, outcomes)
+
+	unsubscribe()
+	bus.Publish(context.Background(), Event{Type: RequestFailed})
+	assert.Equal(t, []Type{RequestCompleted}, outcomes, "unsubscribed handlers receive nothing")
+	assert.Len(t, all, 4)
+}
+
+func TestBus_PanickingHandler(t *testing.T) {
+	bus := NewBus()
+
+	var order []string
+	bus.Subscribe(func(ctx context.Context, event Event) {
+		order = append(order, "first")
+		panic("broken plugin")
+	})
+	bus.Subscribe(func(ctx context.Context, event Event) {
+		order = append(order, "second")
+	})
+
+	assert.NotPanics(t, func() {
+		bus.Publish(context.Background(), Event{Type: RequestReceived, RequestID: "req-1"})
+	})
+	assert.Equal(t, []string{"first", "second"}, order, "delivery continues after a panic, in subscription order")
+}
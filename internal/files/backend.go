@@ -0,0 +1,151 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Backend stores objects by key; keys are slash-separated and never contain ".."
+type Backend interface {
+	// Put creates or replaces an object
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns an object, or ErrNotFound when it does not exist
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes an object; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// List returns the keys starting with prefix
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// MemoryBackend keeps objects in memory; they are lost on restart
+type MemoryBackend struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+}
+
+// NewMemoryBackend creates an empty in-memory backend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{objects: make(map[string][]byte)}
+}
+
+// Put creates or replaces an object
+func (b *MemoryBackend) Put(_ context.Context, key string, data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[key] = append([]byte(nil), data...)
+	return nil
+}
+
+// Get returns an object
+func (b *MemoryBackend) Get(_ context.Context, key string) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	data, ok := b.objects[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+// Delete removes an object
+func (b *MemoryBackend) Delete(_ context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.objects, key)
+	return nil
+}
+
+// List returns the keys starting with prefix
+func (b *MemoryBackend) List(_ context.Context, prefix string) ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var keys []string
+	for key := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// DiskBackend keeps objects as files under a directory
+type DiskBackend struct {
+	dir string
+}
+
+// NewDiskBackend creates a backend storing objects under dir
+func NewDiskBackend(dir string) (*DiskBackend, error) {
+	if dir == "" {
+		return nil, errors.New("FILES_DIR must be set for disk file storage")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create files directory: %w", err)
+	}
+	return &DiskBackend{dir: dir}, nil
+}
+
+// Put atomically creates or replaces an object
+func (b *DiskBackend) Put(_ context.Context, key string, data []byte) error {
+	path := b.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Get returns an object
+func (b *DiskBackend) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(b.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Delete removes an object
+func (b *DiskBackend) Delete(_ context.Context, key string) error {
+	err := os.Remove(b.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// List returns the keys starting with prefix
+func (b *DiskBackend) List(_ context.Context, prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(b.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(b.dir, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// path returns the file holding the object with key
+func (b *DiskBackend) path(key string) string {
+	return filepath.Join(b.dir, filepath.FromSlash(key))
+}
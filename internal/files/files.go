@@ -0,0 +1,336 @@
+// Package files stores the uploads of the files API. Content lives in a pluggable storage
+// backend (memory, local disk or S3), every file belongs to the client key that uploaded it,
+// and chat requests reference files with file://{id} handles
+package files
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// File purposes
+const (
+	PurposeAssistants  = "assistants"
+	PurposeBatch       = "batch"
+	PurposeBatchOutput = "batch_output"
+	PurposeEvals       = "evals"
+	PurposeFineTune    = "fine-tune"
+	PurposeUserData    = "user_data"
+	PurposeVision      = "vision"
+)
+
+const (
+	// HandleScheme prefixes file IDs referenced from file_url content parts
+	HandleScheme = "file://"
+	// DefaultMaxBytes caps the size of an upload when FILES_MAX_BYTES is unset
+	DefaultMaxBytes = 100 << 20
+	// metaPrefix and contentPrefix are the backend key prefixes of file metadata and content
+	metaPrefix    = "meta/"
+	contentPrefix = "content/"
+)
+
+// Error types for file operations
+var (
+	// ErrNotFound is returned for files that do not exist or belong to another client key
+	ErrNotFound = errors.New("file not found")
+	// ErrInvalidFile is returned for uploads that cannot be stored
+	ErrInvalidFile = errors.New("invalid file")
+)
+
+// uploadPurposes are the purposes clients may upload with; batch_output files are only
+// written by the router
+var uploadPurposes = []string{PurposeAssistants, PurposeBatch, PurposeEvals, PurposeFineTune, PurposeUserData, PurposeVision}
+
+// idPattern restricts file IDs to backend-key-safe characters
+var idPattern = regexp.MustCompile(`^file-[A-Za-z0-9]{1,64}$`)
+
+// File is the metadata of a stored file
+type File struct {
+	ID        string `json:"id" example:"file-abc123"`
+	Object    string `json:"object" example:"file"`
+	Bytes     int    `json:"bytes" example:"1024"`
+	CreatedAt int64  `json:"created_at" example:"1735689600"`
+	Filename  string `json:"filename" example:"input.jsonl"`
+	Purpose   string `json:"purpose" example:"batch"`
+	// Owner is the SHA-256 hex digest of the client key that uploaded the file
+	Owner string `json:"-"`
+}
+
+// storedFile is the persisted form of a file's metadata, which keeps its owner
+type storedFile struct {
+	File
+	Owner string `json:"owner"`
+}
+
+// Store keeps file metadata in memory, backed by a storage backend holding metadata and content
+type Store struct {
+	backend  Backend
+	maxBytes int
+	mu       sync.RWMutex
+	files    map[string]File
+	now      func() time.Time
+}
+
+var (
+	defaultStore   *Store
+	defaultStoreMu sync.RWMutex
+)
+
+// NewStore creates a store on backend, loading the metadata of the files it already holds
+func NewStore(ctx context.Context, backend Backend, maxBytes int) (*Store, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	store := &Store{
+		backend:  backend,
+		maxBytes: maxBytes,
+		files:    make(map[string]File),
+		now:      time.Now,
+	}
+	keys, err := backend.List(ctx, metaPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored files: %w", err)
+	}
+	for _, key := range keys {
+		id := strings.TrimSuffix(strings.TrimPrefix(key, metaPrefix), ".json")
+		file, err := store.load(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		store.files[id] = file
+	}
+	return store, nil
+}
+
+// NewStoreFromEnv creates a store on the backend selected by FILES_STORAGE: "memory",
+// "disk" (FILES_DIR) or "s3" (FILES_S3_*). Without FILES_STORAGE, files are kept on disk
+// when FILES_DIR is set and in memory otherwise. Uploads are capped at FILES_MAX_BYTES
+func NewStoreFromEnv(ctx context.Context) (*Store, error) {
+	dir := utils.GetEnvString("FILES_DIR", "")
+	kind := utils.GetEnvString("FILES_STORAGE", "")
+	if kind == "" {
+		kind = "memory"
+		if dir != "" {
+			kind = "disk"
+		}
+	}
+
+	var backend Backend
+	var err error
+	switch strings.ToLower(kind) {
+	case "memory":
+		backend = NewMemoryBackend()
+	case "disk":
+		backend, err = NewDiskBackend(dir)
+	case "s3":
+		backend, err = NewS3Backend(S3ConfigFromEnv())
+	default:
+		return nil, fmt.Errorf("unknown FILES_STORAGE %q: must be memory, disk or s3", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(ctx, backend, utils.GetEnvInt("FILES_MAX_BYTES", DefaultMaxBytes))
+}
+
+// Default returns the process-wide store, nil until SetDefault is called
+func Default() *Store {
+	defaultStoreMu.RLock()
+	defer defaultStoreMu.RUnlock()
+	return defaultStore
+}
+
+// SetDefault replaces the process-wide store
+func SetDefault(store *Store) {
+	defaultStoreMu.Lock()
+	defer defaultStoreMu.Unlock()
+	defaultStore = store
+}
+
+// MaxBytes returns the size limit of uploads
+func (s *Store) MaxBytes() int {
+	return s.maxBytes
+}
+
+// Upload validates and stores a file uploaded by owner
+func (s *Store) Upload(ctx context.Context, owner, filename, purpose string, content []byte) (File, error) {
+	if !ValidPurpose(purpose) {
+		return File{}, fmt.Errorf("%w: purpose must be one of %s", ErrInvalidFile, strings.Join(uploadPurposes, ", "))
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return File{}, fmt.Errorf("%w: the file is empty", ErrInvalidFile)
+	}
+	if len(content) > s.maxBytes {
+		return File{}, fmt.Errorf("%w: the file exceeds the %d byte limit", ErrInvalidFile, s.maxBytes)
+	}
+	return s.Add(ctx, owner, filename, purpose, content)
+}
+
+// Add stores a file for owner without validating it, for files the router generates
+func (s *Store) Add(ctx context.Context, owner, filename, purpose string, content []byte) (File, error) {
+	file := File{
+		ID:        "file-" + utils.GenerateShortID() + utils.GenerateShortID(),
+		Object:    "file",
+		Bytes:     len(content),
+		CreatedAt: s.now().Unix(),
+		Filename:  filename,
+		Purpose:   purpose,
+		Owner:     owner,
+	}
+	meta, err := json.Marshal(storedFile{File: file, Owner: owner})
+	if err != nil {
+		return File{}, err
+	}
+	// Content goes first so metadata never points at a missing object
+	if err := s.backend.Put(ctx, contentPrefix+file.ID, content); err != nil {
+		return File{}, fmt.Errorf("failed to store file content: %w", err)
+	}
+	if err := s.backend.Put(ctx, metaPrefix+file.ID+".json", meta); err != nil {
+		return File{}, fmt.Errorf("failed to store file metadata: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[file.ID] = file
+	return file, nil
+}
+
+// File returns a file owned by owner
+// Files unknown to this instance are looked up in the backend, so instances sharing a
+// bucket see each other's uploads
+func (s *Store) File(ctx context.Context, owner, id string) (File, error) {
+	if !idPattern.MatchString(id) {
+		return File{}, ErrNotFound
+	}
+	s.mu.RLock()
+	file, ok := s.files[id]
+	s.mu.RUnlock()
+	if !ok {
+		loaded, err := s.load(ctx, id)
+		if err != nil {
+			return File{}, ErrNotFound
+		}
+		s.mu.Lock()
+		s.files[id] = loaded
+		s.mu.Unlock()
+		file = loaded
+	}
+	if file.Owner != owner {
+		return File{}, ErrNotFound
+	}
+	return file, nil
+}
+
+// Content returns the content of a file owned by owner
+func (s *Store) Content(ctx context.Context, owner, id string) ([]byte, error) {
+	if _, err := s.File(ctx, owner, id); err != nil {
+		return nil, err
+	}
+	content, err := s.backend.Get(ctx, contentPrefix+id)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read file content: %w", err)
+	}
+	return content, nil
+}
+
+// List returns the files owned by owner, newest first, optionally only those with purpose
+func (s *Store) List(owner, purpose string) []File {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var files []File
+	for _, file := range s.files {
+		if file.Owner == owner && (purpose == "" || file.Purpose == purpose) {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].CreatedAt != files[j].CreatedAt {
+			return files[i].CreatedAt > files[j].CreatedAt
+		}
+		return files[i].ID > files[j].ID
+	})
+	return files
+}
+
+// Delete removes a file owned by owner
+func (s *Store) Delete(ctx context.Context, owner, id string) error {
+	if _, err := s.File(ctx, owner, id); err != nil {
+		return err
+	}
+	// Metadata goes first so a half-deleted file is no longer listed
+	if err := s.backend.Delete(ctx, metaPrefix+id+".json"); err != nil {
+		return fmt.Errorf("failed to delete file metadata: %w", err)
+	}
+	s.mu.Lock()
+	delete(s.files, id)
+	s.mu.Unlock()
+	if err := s.backend.Delete(ctx, contentPrefix+id); err != nil {
+		return fmt.Errorf("failed to delete file content: %w", err)
+	}
+	return nil
+}
+
+// load reads the metadata of a file from the backend
+func (s *Store) load(ctx context.Context, id string) (File, error) {
+	data, err := s.backend.Get(ctx, metaPrefix+id+".json")
+	if err != nil {
+		return File{}, err
+	}
+	var stored storedFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return File{}, fmt.Errorf("invalid metadata of file %s: %w", id, err)
+	}
+	stored.File.Owner = stored.Owner
+	return stored.File, nil
+}
+
+// ValidPurpose reports whether clients may upload files with purpose
+func ValidPurpose(purpose string) bool {
+	for _, allowed := range uploadPurposes {
+		if purpose == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Owner returns the SHA-256 hex digest identifying a client key
+func Owner(clientKey string) string {
+	sum := sha256.Sum256([]byte(clientKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseHandle returns the file ID of a file://{id} handle
+func ParseHandle(rawURL string) (string, bool) {
+	id, ok := strings.CutPrefix(rawURL, HandleScheme)
+	return id, ok
+}
+
+// ownerKey is the context key of the owner resolving file handles
+type ownerKey struct{}
+
+// WithOwner returns a context resolving file handles for owner
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// OwnerFromContext returns the owner set by WithOwner, "" when none is set
+func OwnerFromContext(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerKey{}).(string)
+	return owner
+}
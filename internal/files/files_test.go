@@ -0,0 +1,112 @@
+package files
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	disk, err := NewDiskBackend(t.TempDir())
+	require.NoError(t, err)
+	backends := map[string]Backend{"memory": NewMemoryBackend(), "disk": disk}
+
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store, err := NewStore(ctx, backend, 16)
+			require.NoError(t, err)
+			owner := Owner("sk-client")
+
+			_, err = store.Upload(ctx, owner, "a.txt", PurposeBatchOutput, []byte("data"))
+			assert.ErrorIs(t, err, ErrInvalidFile, "batch_output files are only written by the router")
+			_, err = store.Upload(ctx, owner, "a.txt", PurposeUserData, []byte(" \n"))
+			assert.ErrorIs(t, err, ErrInvalidFile)
+			_, err = store.Upload(ctx, owner, "a.txt", PurposeUserData, []byte("more than sixteen bytes"))
+			assert.ErrorIs(t, err, ErrInvalidFile)
+
+			file, err := store.Upload(ctx, owner, "a.txt", PurposeUserData, []byte("hello"))
+			require.NoError(t, err)
+			assert.Regexp(t, idPattern, file.ID)
+			assert.Equal(t, 5, file.Bytes)
+			_, err = store.Add(ctx, owner, "out.jsonl", PurposeBatchOutput, []byte("{}"))
+			require.NoError(t, err)
+
+			content, err := store.Content(ctx, owner, file.ID)
+			require.NoError(t, err)
+			assert.Equal(t, "hello", string(content))
+			_, err = store.Content(ctx, Owner("sk-other"), file.ID)
+			assert.ErrorIs(t, err, ErrNotFound)
+			_, err = store.File(ctx, owner, "file-../../etc")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			assert.Len(t, store.List(owner, ""), 2)
+			assert.Len(t, store.List(owner, PurposeUserData), 1)
+			assert.Empty(t, store.List(Owner("sk-other"), ""))
+
+			// A store on the same backend sees the files, including their owner
+			reloaded, err := NewStore(ctx, backend, 16)
+			require.NoError(t, err)
+			assert.Len(t, reloaded.List(owner, ""), 2)
+
+			assert.ErrorIs(t, store.Delete(ctx, Owner("sk-other"), file.ID), ErrNotFound)
+			require.NoError(t, store.Delete(ctx, owner, file.ID))
+			_, err = store.File(ctx, owner, file.ID)
+			assert.ErrorIs(t, err, ErrNotFound)
+			_, err = backend.Get(ctx, contentPrefix+file.ID)
+			assert.ErrorIs(t, err, ErrNotFound)
+		})
+	}
+}
+
+func TestStore_SeesFilesOfOtherInstances(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend()
+	first, err := NewStore(ctx, backend, 0)
+	require.NoError(t, err)
+	second, err := NewStore(ctx, backend, 0)
+	require.NoError(t, err)
+
+	file, err := first.Upload(ctx, Owner("sk-client"), "a.txt", PurposeAssistants, []byte("hello"))
+	require.NoError(t, err)
+	content, err := second.Content(ctx, Owner("sk-client"), file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+}
+
+func TestNewStoreFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{"memory by default", map[string]string{}, false},
+		{"disk when FILES_DIR is set", map[string]string{"FILES_DIR": t.TempDir()}, false},
+		{"disk without FILES_DIR", map[string]string{"FILES_STORAGE": "disk"}, true},
+		{"s3 without bucket", map[string]string{"FILES_STORAGE": "s3"}, true},
+		{"unknown storage", map[string]string{"FILES_STORAGE": "ftp"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"FILES_DIR", "FILES_STORAGE", "FILES_S3_BUCKET"} {
+				t.Setenv(key, tt.env[key])
+			}
+			_, err := NewStoreFromEnv(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseHandle(t *testing.T) {
+	id, ok := ParseHandle("file://file-abc")
+	assert.True(t, ok)
+	assert.Equal(t, "file-abc", id)
+	_, ok = ParseHandle("https://example.com/a.pdf")
+	assert.False(t, ok)
+}
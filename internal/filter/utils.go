@@ -23,3 +23,122 @@ func ModelsByVendor(models []config.VendorModel, vendor string) []config.VendorM
 	}
 	return result
 }
+
+// ModelsByType filters models by type; models without a type are chat models
+func ModelsByType(models []config.VendorModel, modelType string) []config.VendorModel {
+	var result []config.VendorModel
+	for _, m := range models {
+		mType := m.Type
+		if mType == "" {
+			mType = config.ModelTypeChat
+		}
+		if mType == modelType {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// CredentialsForModels keeps the credentials of vendors that have at least one of models
+func CredentialsForModels(creds []config.Credential, models []config.VendorModel) []config.Credential {
+	vendors := make(map[string]bool, len(models))
+	for _, m := range models {
+		vendors[m.Vendor] = true
+	}
+	var result []config.Credential
+	for _, c := range creds {
+		if vendors[c.Platform] {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// ModelsForCredentials keeps the models of vendors that have at least one of creds
+func ModelsForCredentials(models []config.VendorModel, creds []config.Credential) []config.VendorModel {
+	vendors := make(map[string]bool, len(creds))
+	for _, c := range creds {
+		vendors[c.Platform] = true
+	}
+	var result []config.VendorModel
+	for _, m := range models {
+		if vendors[m.Vendor] {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// Model capabilities accepted by ModelCriteria
+const (
+	CapabilityVision    = "vision"
+	CapabilityVideo     = "video"
+	CapabilityAudio     = "audio"
+	CapabilityTools     = "tools"
+	CapabilityStreaming = "streaming"
+)
+
+// ModelCriteria describes the capabilities a model must have to be listed
+// Nil pointers and zero values mean "no constraint"
+type ModelCriteria struct {
+	Capabilities      []string
+	SupportsTools     *bool
+	SupportsStreaming *bool
+	MinContext        int
+}
+
+// IsEmpty reports whether the criteria impose no constraint
+func (c ModelCriteria) IsEmpty() bool {
+	return len(c.Capabilities) == 0 && c.SupportsTools == nil && c.SupportsStreaming == nil && c.MinContext == 0
+}
+
+// ModelsByCriteria filters models by the capabilities declared in their ModelConfig
+// Models without a config are assumed to support every capability, as in context-aware
+// selection, but their context window is unknown so they never satisfy MinContext
+func ModelsByCriteria(models []config.VendorModel, criteria ModelCriteria) []config.VendorModel {
+	var result []config.VendorModel
+	for _, m := range models {
+		if matchesCriteria(m.Config, criteria) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// matchesCriteria checks a single model config against the criteria
+func matchesCriteria(cfg *config.ModelConfig, criteria ModelCriteria) bool {
+	if cfg == nil {
+		cfg = &config.ModelConfig{SupportImage: true, SupportVideo: true, SupportAudio: true, SupportTools: true, SupportStreaming: true}
+	}
+
+	for _, capability := range criteria.Capabilities {
+		if !hasCapability(cfg, capability) {
+			return false
+		}
+	}
+	if criteria.SupportsTools != nil && cfg.SupportTools != *criteria.SupportsTools {
+		return false
+	}
+	if criteria.SupportsStreaming != nil && cfg.SupportStreaming != *criteria.SupportsStreaming {
+		return false
+	}
+	return cfg.ContextWindow >= criteria.MinContext
+}
+
+// hasCapability reports whether cfg declares support for a named capability
+func hasCapability(cfg *config.ModelConfig, capability string) bool {
+	switch capability {
+	case CapabilityVision:
+		return cfg.SupportImage
+	case CapabilityVideo:
+		return cfg.SupportVideo
+	case CapabilityAudio:
+		return cfg.SupportAudio
+	case CapabilityTools:
+		return cfg.SupportTools
+	case CapabilityStreaming:
+		return cfg.SupportStreaming
+	default:
+		return false
+	}
+}
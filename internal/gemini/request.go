@@ -0,0 +1,389 @@
+// Package gemini serves Google's native generateContent and streamGenerateContent methods on
+// top of the chat completions pipeline: requests are translated into chat completion requests
+// and the chat completion, or its stream of chunks, is translated back into
+// GenerateContentResponse objects, so applications built on Google's SDKs can use the router
+// unchanged
+package gemini
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Methods served on models/{model}
+const (
+	MethodGenerateContent       = "generateContent"
+	MethodStreamGenerateContent = "streamGenerateContent"
+)
+
+// ErrInvalidRequest is returned for requests that cannot be translated into a chat completion
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Request represents a generateContent request
+type Request struct {
+	Contents          []Content         `json:"contents"`
+	SystemInstruction *Content          `json:"systemInstruction,omitempty"`
+	Tools             []Tool            `json:"tools,omitempty"`
+	ToolConfig        *ToolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *GenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// Content is one turn of the conversation
+type Content struct {
+	Role  string `json:"role,omitempty" example:"user"`
+	Parts []Part `json:"parts"`
+}
+
+// Part is one part of a turn: text, inline or referenced media, a function call or a function
+// response
+type Part struct {
+	Text             string            `json:"text,omitempty"`
+	InlineData       *Blob             `json:"inlineData,omitempty"`
+	FileData         *FileData         `json:"fileData,omitempty"`
+	FunctionCall     *FunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *FunctionResponse `json:"functionResponse,omitempty"`
+	// Thought marks the model's thinking, which is not sent back to the model
+	Thought bool `json:"thought,omitempty"`
+}
+
+// Blob is inline media
+type Blob struct {
+	MimeType string `json:"mimeType" example:"image/png"`
+	Data     string `json:"data"`
+}
+
+// FileData is media referenced by URI
+type FileData struct {
+	MimeType string `json:"mimeType,omitempty" example:"application/pdf"`
+	FileURI  string `json:"fileUri" example:"https://example.com/report.pdf"`
+}
+
+// FunctionCall is a function call made by the model
+type FunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name" example:"get_weather"`
+	Args json.RawMessage `json:"args,omitempty" swaggertype:"object"`
+}
+
+// FunctionResponse is the result of a function call
+type FunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name" example:"get_weather"`
+	Response json.RawMessage `json:"response" swaggertype:"object"`
+}
+
+// Tool declares functions the model may call
+type Tool struct {
+	FunctionDeclarations []FunctionDeclaration `json:"functionDeclarations,omitempty"`
+}
+
+// FunctionDeclaration describes a function
+type FunctionDeclaration struct {
+	Name        string                 `json:"name" example:"get_weather"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// ToolConfig configures function calling
+type ToolConfig struct {
+	FunctionCallingConfig *FunctionCallingConfig `json:"functionCallingConfig,omitempty"`
+}
+
+// FunctionCallingConfig sets the function calling mode
+type FunctionCallingConfig struct {
+	Mode                 string   `json:"mode,omitempty" example:"AUTO"`
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+// GenerationConfig configures generation
+type GenerationConfig struct {
+	Temperature      *float64               `json:"temperature,omitempty" example:"0.7"`
+	TopP             *float64               `json:"topP,omitempty" example:"1"`
+	MaxOutputTokens  *int                   `json:"maxOutputTokens,omitempty" example:"1024"`
+	StopSequences    []string               `json:"stopSequences,omitempty"`
+	CandidateCount   *int                   `json:"candidateCount,omitempty"`
+	PresencePenalty  *float64               `json:"presencePenalty,omitempty"`
+	FrequencyPenalty *float64               `json:"frequencyPenalty,omitempty"`
+	Seed             *int                   `json:"seed,omitempty"`
+	ResponseMimeType string                 `json:"responseMimeType,omitempty" example:"application/json"`
+	ResponseSchema   map[string]interface{} `json:"responseSchema,omitempty"`
+	// TopK is accepted and ignored; chat completions have no equivalent
+	TopK *int `json:"topK,omitempty"`
+}
+
+// ParseTarget splits the {model}:{method} path segment of models/{model}:{method} at its last
+// colon; the "models/" prefix some clients repeat in the model name is dropped
+func ParseTarget(target string) (model, method string) {
+	separator := strings.LastIndex(target, ":")
+	if separator < 0 {
+		return strings.TrimPrefix(target, "models/"), ""
+	}
+	return strings.TrimPrefix(target[:separator], "models/"), target[separator+1:]
+}
+
+// ChatRequest translates a generateContent request for model into a chat completion request
+// body, streamed when stream is set
+func ChatRequest(model string, req *Request, stream bool) (map[string]interface{}, error) {
+	if len(req.Contents) == 0 {
+		return nil, fmt.Errorf("%w: contents must not be empty", ErrInvalidRequest)
+	}
+
+	var messages []interface{}
+	if req.SystemInstruction != nil {
+		var texts []string
+		for _, part := range req.SystemInstruction.Parts {
+			if part.Text == "" {
+				return nil, fmt.Errorf("%w: systemInstruction only supports text parts", ErrInvalidRequest)
+			}
+			texts = append(texts, part.Text)
+		}
+		if len(texts) > 0 {
+			messages = append(messages, map[string]interface{}{"role": "system", "content": strings.Join(texts, "\n")})
+		}
+	}
+	calls := newCallIDs()
+	for i, content := range req.Contents {
+		translated, err := chatMessages(content, calls)
+		if err != nil {
+			return nil, fmt.Errorf("%w (contents[%d])", err, i)
+		}
+		messages = append(messages, translated...)
+	}
+
+	payload := map[string]interface{}{
+		"model":    model,
+		"messages": messages,
+	}
+	if stream {
+		payload["stream"] = true
+		payload["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
+	if config := req.GenerationConfig; config != nil {
+		if config.Temperature != nil {
+			payload["temperature"] = *config.Temperature
+		}
+		if config.TopP != nil {
+			payload["top_p"] = *config.TopP
+		}
+		if config.MaxOutputTokens != nil {
+			payload["max_tokens"] = *config.MaxOutputTokens
+		}
+		if len(config.StopSequences) > 0 {
+			payload["stop"] = config.StopSequences
+		}
+		if config.CandidateCount != nil && *config.CandidateCount > 1 {
+			payload["n"] = *config.CandidateCount
+		}
+		if config.PresencePenalty != nil {
+			payload["presence_penalty"] = *config.PresencePenalty
+		}
+		if config.FrequencyPenalty != nil {
+			payload["frequency_penalty"] = *config.FrequencyPenalty
+		}
+		if config.Seed != nil {
+			payload["seed"] = *config.Seed
+		}
+		switch config.ResponseMimeType {
+		case "", "text/plain":
+		case "application/json":
+			if config.ResponseSchema != nil {
+				payload["response_format"] = map[string]interface{}{
+					"type":        "json_schema",
+					"json_schema": map[string]interface{}{"name": "response", "schema": config.ResponseSchema},
+				}
+			} else {
+				payload["response_format"] = map[string]interface{}{"type": "json_object"}
+			}
+		default:
+			return nil, fmt.Errorf("%w: responseMimeType %q is not supported", ErrInvalidRequest, config.ResponseMimeType)
+		}
+	}
+
+	var tools []interface{}
+	for _, tool := range req.Tools {
+		if len(tool.FunctionDeclarations) == 0 {
+			return nil, fmt.Errorf("%w: only tools with functionDeclarations are supported", ErrInvalidRequest)
+		}
+		for _, declaration := range tool.FunctionDeclarations {
+			function := map[string]interface{}{"name": declaration.Name}
+			if declaration.Description != "" {
+				function["description"] = declaration.Description
+			}
+			if declaration.Parameters != nil {
+				function["parameters"] = declaration.Parameters
+			}
+			tools = append(tools, map[string]interface{}{"type": "function", "function": function})
+		}
+	}
+	if len(tools) > 0 {
+		payload["tools"] = tools
+	}
+	if req.ToolConfig != nil && req.ToolConfig.FunctionCallingConfig != nil {
+		toolChoice, err := chatToolChoice(req.ToolConfig.FunctionCallingConfig)
+		if err != nil {
+			return nil, err
+		}
+		if toolChoice != nil {
+			payload["tool_choice"] = toolChoice
+		}
+	}
+	return payload, nil
+}
+
+// callIDs assigns chat tool call IDs to function calls, which Gemini only identifies by name,
+// and matches each function response to the oldest unanswered call of its function
+type callIDs struct {
+	next    int
+	pending map[string][]string
+}
+
+func newCallIDs() *callIDs {
+	return &callIDs{pending: make(map[string][]string)}
+}
+
+// call returns the ID of a function call
+func (c *callIDs) call(call *FunctionCall) string {
+	id := call.ID
+	if id == "" {
+		c.next++
+		id = fmt.Sprintf("call_%d", c.next)
+	}
+	c.pending[call.Name] = append(c.pending[call.Name], id)
+	return id
+}
+
+// response returns the ID of the call a function response answers
+func (c *callIDs) response(response *FunctionResponse) string {
+	if response.ID != "" {
+		return response.ID
+	}
+	pending := c.pending[response.Name]
+	if len(pending) == 0 {
+		c.next++
+		return fmt.Sprintf("call_%d", c.next)
+	}
+	c.pending[response.Name] = pending[1:]
+	return pending[0]
+}
+
+// chatMessages translates a turn into chat messages: function responses become tool messages
+// ahead of the rest of the user's parts, function calls become the assistant's tool calls
+func chatMessages(content Content, calls *callIDs) ([]interface{}, error) {
+	switch content.Role {
+	case "", "user", "function":
+		return userMessages(content.Parts, calls)
+	case "model":
+		return modelMessage(content.Parts, calls)
+	default:
+		return nil, fmt.Errorf("%w: role %q is not supported, use user or model", ErrInvalidRequest, content.Role)
+	}
+}
+
+// userMessages translates the parts of a user turn
+func userMessages(parts []Part, calls *callIDs) ([]interface{}, error) {
+	var messages []interface{}
+	chatParts := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		switch {
+		case part.FunctionResponse != nil:
+			response := string(part.FunctionResponse.Response)
+			if response == "" {
+				response = "{}"
+			}
+			messages = append(messages, map[string]interface{}{
+				"role":         "tool",
+				"tool_call_id": calls.response(part.FunctionResponse),
+				"content":      response,
+			})
+		case part.InlineData != nil:
+			chatParts = append(chatParts, inlinePart(part.InlineData))
+		case part.FileData != nil:
+			if part.FileData.FileURI == "" {
+				return nil, fmt.Errorf("%w: fileData requires a fileUri", ErrInvalidRequest)
+			}
+			if strings.HasPrefix(part.FileData.MimeType, "image/") {
+				chatParts = append(chatParts, map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": part.FileData.FileURI}})
+			} else {
+				chatParts = append(chatParts, map[string]interface{}{"type": "file_url", "file_url": map[string]interface{}{"url": part.FileData.FileURI}})
+			}
+		case part.FunctionCall != nil:
+			return nil, fmt.Errorf("%w: functionCall parts belong to model turns", ErrInvalidRequest)
+		case part.Thought:
+		default:
+			chatParts = append(chatParts, map[string]interface{}{"type": "text", "text": part.Text})
+		}
+	}
+	if len(chatParts) > 0 {
+		messages = append(messages, map[string]interface{}{"role": "user", "content": chatParts})
+	}
+	return messages, nil
+}
+
+// modelMessage translates the parts of a model turn into one assistant message
+func modelMessage(parts []Part, calls *callIDs) ([]interface{}, error) {
+	var texts []string
+	var toolCalls []interface{}
+	for _, part := range parts {
+		switch {
+		case part.FunctionCall != nil:
+			arguments := string(part.FunctionCall.Args)
+			if arguments == "" {
+				arguments = "{}"
+			}
+			toolCalls = append(toolCalls, map[string]interface{}{
+				"id":       calls.call(part.FunctionCall),
+				"type":     "function",
+				"function": map[string]interface{}{"name": part.FunctionCall.Name, "arguments": arguments},
+			})
+		case part.InlineData != nil, part.FileData != nil, part.FunctionResponse != nil:
+			return nil, fmt.Errorf("%w: model turns only support text and functionCall parts", ErrInvalidRequest)
+		case part.Thought:
+		default:
+			texts = append(texts, part.Text)
+		}
+	}
+
+	message := map[string]interface{}{"role": "assistant", "content": strings.Join(texts, "")}
+	if len(toolCalls) > 0 {
+		message["tool_calls"] = toolCalls
+		if len(texts) == 0 {
+			message["content"] = nil
+		}
+	}
+	return []interface{}{message}, nil
+}
+
+// inlinePart translates inline media: images and audio become image_url and input_audio
+// parts, anything else a file part
+func inlinePart(blob *Blob) map[string]interface{} {
+	dataURL := "data:" + blob.MimeType + ";base64," + blob.Data
+	switch {
+	case strings.HasPrefix(blob.MimeType, "image/"):
+		return map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": dataURL}}
+	case blob.MimeType == "audio/wav" || blob.MimeType == "audio/x-wav":
+		return map[string]interface{}{"type": "input_audio", "input_audio": map[string]interface{}{"data": blob.Data, "format": "wav"}}
+	case blob.MimeType == "audio/mp3" || blob.MimeType == "audio/mpeg":
+		return map[string]interface{}{"type": "input_audio", "input_audio": map[string]interface{}{"data": blob.Data, "format": "mp3"}}
+	default:
+		return map[string]interface{}{"type": "file", "file": map[string]interface{}{"file_data": dataURL}}
+	}
+}
+
+// chatToolChoice translates a function calling mode into a tool_choice
+func chatToolChoice(config *FunctionCallingConfig) (interface{}, error) {
+	switch strings.ToUpper(config.Mode) {
+	case "", "MODE_UNSPECIFIED", "AUTO", "VALIDATED":
+		return nil, nil
+	case "NONE":
+		return "none", nil
+	case "ANY":
+		if len(config.AllowedFunctionNames) == 1 {
+			return map[string]interface{}{"type": "function", "function": map[string]interface{}{"name": config.AllowedFunctionNames[0]}}, nil
+		}
+		return "required", nil
+	default:
+		return nil, fmt.Errorf("%w: function calling mode %q is not supported", ErrInvalidRequest, config.Mode)
+	}
+}
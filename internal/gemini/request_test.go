@@ -0,0 +1,158 @@
+package gemini
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		model  string
+		method string
+	}{
+		{"gemini-2.5-flash:generateContent", "gemini-2.5-flash", MethodGenerateContent},
+		{"models/gemini-2.5-flash:streamGenerateContent", "gemini-2.5-flash", MethodStreamGenerateContent},
+		{"vendor:model:generateContent", "vendor:model", MethodGenerateContent},
+		{"gemini-2.5-flash", "gemini-2.5-flash", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			model, method := ParseTarget(tt.target)
+			assert.Equal(t, tt.model, model)
+			assert.Equal(t, tt.method, method)
+		})
+	}
+}
+
+func TestChatRequest(t *testing.T) {
+	body := `{
+		"systemInstruction": {"parts": [{"text": "Be brief."}, {"text": "Answer in French."}]},
+		"contents": [
+			{"role": "user", "parts": [
+				{"text": "What is this?"},
+				{"inlineData": {"mimeType": "image/png", "data": "iVBOR"}},
+				{"inlineData": {"mimeType": "audio/wav", "data": "UklGR"}},
+				{"fileData": {"mimeType": "application/pdf", "fileUri": "https://example.com/a.pdf"}}
+			]},
+			{"role": "model", "parts": [
+				{"text": "Let me look.", "thought": true},
+				{"text": "Looking."},
+				{"functionCall": {"name": "lookup", "args": {"q": "png"}}},
+				{"functionCall": {"name": "lookup", "args": {"q": "pdf"}}}
+			]},
+			{"role": "user", "parts": [
+				{"functionResponse": {"name": "lookup", "response": {"result": "an image"}}},
+				{"functionResponse": {"name": "lookup", "response": {"result": "a report"}}},
+				{"text": "And now?"}
+			]}
+		],
+		"tools": [{"functionDeclarations": [{"name": "lookup", "description": "Look up", "parameters": {"type": "object"}}]}],
+		"toolConfig": {"functionCallingConfig": {"mode": "ANY", "allowedFunctionNames": ["lookup"]}},
+		"generationConfig": {
+			"temperature": 0.2,
+			"topK": 40,
+			"maxOutputTokens": 256,
+			"stopSequences": ["END"],
+			"candidateCount": 2,
+			"responseMimeType": "application/json",
+			"responseSchema": {"type": "object"}
+		}
+	}`
+	var req Request
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+
+	payload, err := ChatRequest("gemini-2.5-flash", &req, true)
+	require.NoError(t, err)
+	translated, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	expected := `{
+		"model": "gemini-2.5-flash",
+		"stream": true,
+		"stream_options": {"include_usage": true},
+		"temperature": 0.2,
+		"max_tokens": 256,
+		"stop": ["END"],
+		"n": 2,
+		"response_format": {"type": "json_schema", "json_schema": {"name": "response", "schema": {"type": "object"}}},
+		"messages": [
+			{"role": "system", "content": "Be brief.\nAnswer in French."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is this?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBOR"}},
+				{"type": "input_audio", "input_audio": {"data": "UklGR", "format": "wav"}},
+				{"type": "file_url", "file_url": {"url": "https://example.com/a.pdf"}}
+			]},
+			{"role": "assistant", "content": "Looking.", "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\": \"png\"}"}},
+				{"id": "call_2", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\": \"pdf\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "{\"result\": \"an image\"}"},
+			{"role": "tool", "tool_call_id": "call_2", "content": "{\"result\": \"a report\"}"},
+			{"role": "user", "content": [{"type": "text", "text": "And now?"}]}
+		],
+		"tools": [{"type": "function", "function": {"name": "lookup", "description": "Look up", "parameters": {"type": "object"}}}],
+		"tool_choice": {"type": "function", "function": {"name": "lookup"}}
+	}`
+	assert.JSONEq(t, expected, string(translated))
+}
+
+func TestChatRequest_FunctionCallIDs(t *testing.T) {
+	body := `{
+		"contents": [
+			{"role": "model", "parts": [{"functionCall": {"id": "call_abc", "name": "lookup"}}]},
+			{"role": "user", "parts": [{"functionResponse": {"id": "call_abc", "name": "lookup", "response": {}}}]}
+		],
+		"toolConfig": {"functionCallingConfig": {"mode": "NONE"}},
+		"generationConfig": {"responseMimeType": "application/json"}
+	}`
+	var req Request
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+
+	payload, err := ChatRequest("gemini-2.5-flash", &req, false)
+	require.NoError(t, err)
+	translated, err := json.Marshal(payload)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"model": "gemini-2.5-flash",
+		"response_format": {"type": "json_object"},
+		"messages": [
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_abc", "type": "function", "function": {"name": "lookup", "arguments": "{}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_abc", "content": "{}"}
+		],
+		"tool_choice": "none"
+	}`, string(translated))
+}
+
+func TestChatRequest_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no contents", `{"contents": []}`},
+		{"unknown role", `{"contents": [{"role": "system", "parts": [{"text": "Hi"}]}]}`},
+		{"media in system instruction", `{"systemInstruction": {"parts": [{"inlineData": {"mimeType": "image/png", "data": "x"}}]}, "contents": [{"parts": [{"text": "Hi"}]}]}`},
+		{"function call in user turn", `{"contents": [{"role": "user", "parts": [{"functionCall": {"name": "f"}}]}]}`},
+		{"media in model turn", `{"contents": [{"role": "model", "parts": [{"inlineData": {"mimeType": "image/png", "data": "x"}}]}]}`},
+		{"file without uri", `{"contents": [{"parts": [{"fileData": {"mimeType": "image/png"}}]}]}`},
+		{"built-in tool", `{"contents": [{"parts": [{"text": "Hi"}]}], "tools": [{"googleSearch": {}}]}`},
+		{"unknown mode", `{"contents": [{"parts": [{"text": "Hi"}]}], "toolConfig": {"functionCallingConfig": {"mode": "SOMETIMES"}}}`},
+		{"unsupported mime type", `{"contents": [{"parts": [{"text": "Hi"}]}], "generationConfig": {"responseMimeType": "text/x.enum"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req Request
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &req))
+			_, err := ChatRequest("gemini-2.5-flash", &req, false)
+			assert.ErrorIs(t, err, ErrInvalidRequest)
+		})
+	}
+}
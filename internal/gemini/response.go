@@ -0,0 +1,243 @@
+package gemini
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Finish reasons of a candidate
+const (
+	FinishReasonStop      = "STOP"
+	FinishReasonMaxTokens = "MAX_TOKENS"
+	FinishReasonSafety    = "SAFETY"
+)
+
+// finishReasons maps chat completion finish reasons to Gemini finish reasons
+var finishReasons = map[string]string{
+	"stop":           FinishReasonStop,
+	"tool_calls":     FinishReasonStop,
+	"function_call":  FinishReasonStop,
+	"length":         FinishReasonMaxTokens,
+	"content_filter": FinishReasonSafety,
+}
+
+// Response is a GenerateContentResponse
+type Response struct {
+	Candidates    []Candidate    `json:"candidates"`
+	UsageMetadata *UsageMetadata `json:"usageMetadata,omitempty"`
+	ModelVersion  string         `json:"modelVersion,omitempty" example:"gemini-2.5-flash"`
+	ResponseID    string         `json:"responseId,omitempty" example:"abc123"`
+}
+
+// Candidate is one generated answer
+type Candidate struct {
+	Content      Content `json:"content"`
+	FinishReason string  `json:"finishReason,omitempty" example:"STOP"`
+	Index        int     `json:"index"`
+}
+
+// UsageMetadata represents token usage of a response
+type UsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount" example:"10"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount" example:"20"`
+	TotalTokenCount         int `json:"totalTokenCount" example:"30"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty" example:"0"`
+}
+
+// ErrorResponse is an error in the Gemini API error shape
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes an error
+type ErrorDetail struct {
+	Code    int    `json:"code" example:"400"`
+	Message string `json:"message"`
+	Status  string `json:"status" example:"INVALID_ARGUMENT"`
+}
+
+// chatCompletion is the part of a chat completion translated into a response
+type chatCompletion struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index   int `json:"index"`
+		Message struct {
+			Content   *string        `json:"content"`
+			Refusal   *string        `json:"refusal"`
+			ToolCalls []chatToolCall `json:"tool_calls"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+}
+
+// chatToolCall is a tool call of a chat completion or, with Index, of a chunk
+type chatToolCall struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// chatUsage is the token usage of a chat completion
+type chatUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+// chatError is the body of a chat completion error response
+type chatError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// FromChatCompletion translates a chat completion into a GenerateContentResponse, one
+// candidate per choice
+func FromChatCompletion(model string, body []byte) (*Response, error) {
+	var completion chatCompletion
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return nil, fmt.Errorf("invalid chat completion: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("invalid chat completion: no choices")
+	}
+
+	response := newResponse(model, completion.ID, completion.Model)
+	for _, choice := range completion.Choices {
+		text := ""
+		if choice.Message.Content != nil {
+			text = *choice.Message.Content
+		} else if choice.Message.Refusal != nil {
+			text = *choice.Message.Refusal
+		}
+		parts := []Part{}
+		if text != "" {
+			parts = append(parts, Part{Text: text})
+		}
+		for _, call := range choice.Message.ToolCalls {
+			part, err := functionCallPart(call)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+		}
+		response.Candidates = append(response.Candidates, Candidate{
+			Content:      Content{Role: "model", Parts: parts},
+			FinishReason: finishReason(choice.FinishReason),
+			Index:        choice.Index,
+		})
+	}
+	response.UsageMetadata = usageMetadata(completion.Usage)
+	return response, nil
+}
+
+// newResponse returns a response without candidates
+func newResponse(model, chatID, chatModel string) *Response {
+	if chatModel == "" {
+		chatModel = model
+	}
+	if chatID == "" {
+		chatID = utils.GenerateChatCompletionID()
+	}
+	return &Response{
+		Candidates:   []Candidate{},
+		ModelVersion: chatModel,
+		ResponseID:   strings.TrimPrefix(chatID, "chatcmpl-"),
+	}
+}
+
+// functionCallPart translates a chat tool call into a functionCall part, keeping the call ID
+// so clients can echo it back in the functionResponse
+func functionCallPart(call chatToolCall) (Part, error) {
+	args := json.RawMessage(call.Function.Arguments)
+	if strings.TrimSpace(call.Function.Arguments) == "" {
+		args = json.RawMessage("{}")
+	} else if !json.Valid(args) {
+		return Part{}, fmt.Errorf("invalid chat completion: arguments of tool call %s are not JSON", call.ID)
+	}
+	return Part{FunctionCall: &FunctionCall{ID: call.ID, Name: call.Function.Name, Args: args}}, nil
+}
+
+// finishReason translates a chat finish_reason, treating unknown reasons as a stop
+func finishReason(chatReason string) string {
+	if reason, ok := finishReasons[chatReason]; ok {
+		return reason
+	}
+	return FinishReasonStop
+}
+
+// usageMetadata translates chat completion usage
+func usageMetadata(chat *chatUsage) *UsageMetadata {
+	if chat == nil {
+		return nil
+	}
+	total := chat.TotalTokens
+	if total == 0 {
+		total = chat.PromptTokens + chat.CompletionTokens
+	}
+	return &UsageMetadata{
+		PromptTokenCount:        chat.PromptTokens,
+		CandidatesTokenCount:    chat.CompletionTokens,
+		TotalTokenCount:         total,
+		CachedContentTokenCount: chat.PromptTokensDetails.CachedTokens,
+	}
+}
+
+// errorStatus returns the Google RPC status of an HTTP status
+func errorStatus(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case status == http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case status == http.StatusNotFound:
+		return "NOT_FOUND"
+	case status == http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case status == http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case status == http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	case status >= 500:
+		return "INTERNAL"
+	default:
+		return "INVALID_ARGUMENT"
+	}
+}
+
+// WriteError writes an error in the Gemini API error shape
+func WriteError(w http.ResponseWriter, status int, message string) {
+	body, _ := json.Marshal(ErrorResponse{
+		Error: ErrorDetail{Code: status, Message: message, Status: errorStatus(status)},
+	})
+	w.Header().Del(utils.HeaderContentEncoding)
+	w.Header().Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	w.Header().Set(utils.HeaderContentLength, strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// errorMessage returns the message of a chat completion error response
+func errorMessage(status int, body []byte) string {
+	var chat chatError
+	if err := json.Unmarshal(body, &chat); err == nil && chat.Error.Message != "" {
+		return chat.Error.Message
+	}
+	if text := strings.TrimSpace(string(body)); text != "" {
+		return text
+	}
+	return http.StatusText(status)
+}
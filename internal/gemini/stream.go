@@ -0,0 +1,230 @@
+package gemini
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// stream translates a stream of chat completion chunks into GenerateContentResponse chunks
+// Text is streamed as it arrives; function calls are sent whole, with the finish reasons and
+// usage, in the last chunk since Gemini does not stream partial arguments. With sse the
+// chunks are server-sent events, otherwise the elements of a JSON array
+type stream struct {
+	w     io.Writer
+	model string
+	sse   bool
+	// chatID and chatModel identify the chat completion being translated
+	chatID    string
+	chatModel string
+	// started is set once the first chunk, or the opening bracket of the array, is written
+	started bool
+	// choices accumulates the tool calls and finish reason of each choice
+	choices map[int]*streamChoice
+	usage   *chatUsage
+	done    bool
+}
+
+// streamChoice is what a choice leaves for the last chunk
+type streamChoice struct {
+	toolCalls    map[int]*chatToolCall
+	finishReason string
+}
+
+// chatChunk is the part of a chat completion chunk translated into response chunks
+type chatChunk struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Content   *string        `json:"content"`
+			Refusal   *string        `json:"refusal"`
+			ToolCalls []chatToolCall `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func newStream(w io.Writer, model string, sse bool) *stream {
+	return &stream{
+		w:       w,
+		model:   model,
+		sse:     sse,
+		choices: make(map[int]*streamChoice),
+	}
+}
+
+// data handles the data of one chat completion stream event
+func (s *stream) data(data []byte) error {
+	if s.done {
+		return nil
+	}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("[DONE]")) {
+		return s.complete()
+	}
+
+	var chunk chatChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		// Comments and keep-alives carry no chunk
+		return nil
+	}
+	if chunk.Error != nil {
+		return s.fail(chunk.Error.Message)
+	}
+	if s.chatID == "" {
+		s.chatID = chunk.ID
+	}
+	if s.chatModel == "" {
+		s.chatModel = chunk.Model
+	}
+
+	var candidates []Candidate
+	for _, delta := range chunk.Choices {
+		choice := s.choice(delta.Index)
+		text := ""
+		if delta.Delta.Content != nil {
+			text = *delta.Delta.Content
+		} else if delta.Delta.Refusal != nil {
+			text = *delta.Delta.Refusal
+		}
+		if text != "" {
+			candidates = append(candidates, Candidate{
+				Content: Content{Role: "model", Parts: []Part{{Text: text}}},
+				Index:   delta.Index,
+			})
+		}
+		for _, call := range delta.Delta.ToolCalls {
+			accumulated, ok := choice.toolCalls[call.Index]
+			if !ok {
+				accumulated = &chatToolCall{Index: call.Index}
+				choice.toolCalls[call.Index] = accumulated
+			}
+			if call.ID != "" {
+				accumulated.ID = call.ID
+			}
+			accumulated.Function.Name += call.Function.Name
+			accumulated.Function.Arguments += call.Function.Arguments
+		}
+		if delta.FinishReason != nil && *delta.FinishReason != "" {
+			choice.finishReason = *delta.FinishReason
+		}
+	}
+	if chunk.Usage != nil {
+		s.usage = chunk.Usage
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	response := newResponse(s.model, s.chatID, s.chatModel)
+	response.Candidates = candidates
+	return s.emit(response)
+}
+
+// choice returns the state of the choice at index
+func (s *stream) choice(index int) *streamChoice {
+	choice, ok := s.choices[index]
+	if !ok {
+		choice = &streamChoice{toolCalls: make(map[int]*chatToolCall)}
+		s.choices[index] = choice
+	}
+	return choice
+}
+
+// complete emits the last chunk: the function calls, finish reason of every choice and usage
+func (s *stream) complete() error {
+	s.done = true
+	if len(s.choices) == 0 {
+		s.choice(0)
+	}
+	indexes := make([]int, 0, len(s.choices))
+	for index := range s.choices {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	response := newResponse(s.model, s.chatID, s.chatModel)
+	for _, index := range indexes {
+		choice := s.choices[index]
+		callIndexes := make([]int, 0, len(choice.toolCalls))
+		for callIndex := range choice.toolCalls {
+			callIndexes = append(callIndexes, callIndex)
+		}
+		sort.Ints(callIndexes)
+		parts := []Part{}
+		for _, callIndex := range callIndexes {
+			part, err := functionCallPart(*choice.toolCalls[callIndex])
+			if err != nil {
+				return s.fail(err.Error())
+			}
+			parts = append(parts, part)
+		}
+		response.Candidates = append(response.Candidates, Candidate{
+			Content:      Content{Role: "model", Parts: parts},
+			FinishReason: finishReason(choice.finishReason),
+			Index:        index,
+		})
+	}
+	response.UsageMetadata = usageMetadata(s.usage)
+	if err := s.emit(response); err != nil {
+		return err
+	}
+	return s.close()
+}
+
+// fail ends the stream with an error
+func (s *stream) fail(message string) error {
+	s.done = true
+	if err := s.emit(ErrorResponse{Error: ErrorDetail{
+		Code:    http.StatusInternalServerError,
+		Message: message,
+		Status:  errorStatus(http.StatusInternalServerError),
+	}}); err != nil {
+		return err
+	}
+	return s.close()
+}
+
+// emit writes one chunk
+func (s *stream) emit(chunk interface{}) error {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	switch {
+	case s.sse:
+		out.WriteString("data: ")
+	case s.started:
+		out.WriteString(",\r\n")
+	default:
+		out.WriteString("[")
+	}
+	s.started = true
+	out.Write(data)
+	if s.sse {
+		out.WriteString("\r\n\r\n")
+	}
+	_, err = s.w.Write(out.Bytes())
+	return err
+}
+
+// close ends the JSON array
+func (s *stream) close() error {
+	if s.sse {
+		return nil
+	}
+	closing := "]"
+	if !s.started {
+		closing = "[]"
+	}
+	s.started = true
+	_, err := io.WriteString(s.w, closing)
+	return err
+}
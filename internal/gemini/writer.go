@@ -0,0 +1,40 @@
+package gemini
+
+import (
+	"net/http"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/translate"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// NewWriter returns the writer given to the chat completions handler: it translates the
+// chat completion generated by model into a GenerateContentResponse, or a chat completion
+// stream into response chunks as the chat chunks arrive, written as server-sent events when
+// sse is set and as a JSON array otherwise. Error responses are translated into the Gemini
+// API error shape
+func NewWriter(w http.ResponseWriter, model string, sse bool) *translate.Writer {
+	stream := newStream(w, model, sse)
+	translators := translate.Translators{
+		Completion: func(body []byte) ([]byte, error) {
+			response, err := FromChatCompletion(model, body)
+			if err != nil {
+				return nil, err
+			}
+			return codec.Marshal(response)
+		},
+		Chunk: stream.data,
+		End: func() {
+			if !stream.done {
+				_ = stream.fail("The stream ended before the response completed")
+			}
+		},
+		Error: func(w http.ResponseWriter, status int, body []byte) {
+			WriteError(w, status, errorMessage(status, body))
+		},
+	}
+	if !sse {
+		translators.StreamContentType = utils.ContentTypeJSON
+	}
+	return translate.NewWriter(w, translators)
+}
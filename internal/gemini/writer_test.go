@@ -0,0 +1,240 @@
+package gemini
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamChunks are chat chunks answering with text and a function call split across deltas
+var streamChunks = []string{
+	`data: {"id":"chatcmpl-abc","model":"gemini-2.5-flash","choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}` + "\n\n",
+	`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"content":"Hel"}}]}` + "\n\n" +
+		`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+	"\n\n",
+	`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{\"q\":"}}]}}]}` + "\n\n",
+	`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"1}"}}]}}]}` + "\n\n",
+	`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}` + "\n\n",
+	`data: {"id":"chatcmpl-abc","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":4,"total_tokens":7}}` + "\n\n",
+	"data: [DONE]\n\n",
+}
+
+// expectedChunks are the response chunks streamChunks translate into
+var expectedChunks = []string{
+	`{"candidates": [{"content": {"role": "model", "parts": [{"text": "Hel"}]}, "index": 0}], "modelVersion": "gemini-2.5-flash", "responseId": "abc"}`,
+	`{"candidates": [{"content": {"role": "model", "parts": [{"text": "lo"}]}, "index": 0}], "modelVersion": "gemini-2.5-flash", "responseId": "abc"}`,
+	`{
+		"candidates": [{
+			"content": {"role": "model", "parts": [{"functionCall": {"id": "call_1", "name": "lookup", "args": {"q": 1}}}]},
+			"finishReason": "STOP",
+			"index": 0
+		}],
+		"usageMetadata": {"promptTokenCount": 3, "candidatesTokenCount": 4, "totalTokenCount": 7},
+		"modelVersion": "gemini-2.5-flash",
+		"responseId": "abc"
+	}`,
+}
+
+func TestWriter_Completion(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writer := NewWriter(rec, "gemini-2.5-flash", false)
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Content-Length", "999")
+	writer.WriteHeader(http.StatusOK)
+	_, err := writer.Write([]byte(`{
+		"id": "chatcmpl-abc",
+		"object": "chat.completion",
+		"model": "gemini-2.5-flash-001",
+		"choices": [
+			{"index": 0, "finish_reason": "tool_calls", "message": {
+				"role": "assistant",
+				"content": "Checking.",
+				"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":1}"}}]
+			}},
+			{"index": 1, "finish_reason": "length", "message": {"role": "assistant", "content": "Che"}}
+		],
+		"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15, "prompt_tokens_details": {"cached_tokens": 4}}
+	}`))
+	require.NoError(t, err)
+	assert.Empty(t, rec.Body.String(), "the completion is only written once translated")
+	require.NoError(t, writer.Finish())
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, strconv.Itoa(rec.Body.Len()), rec.Header().Get("Content-Length"))
+	assert.JSONEq(t, `{
+		"candidates": [
+			{
+				"content": {"role": "model", "parts": [
+					{"text": "Checking."},
+					{"functionCall": {"id": "call_1", "name": "lookup", "args": {"q": 1}}}
+				]},
+				"finishReason": "STOP",
+				"index": 0
+			},
+			{"content": {"role": "model", "parts": [{"text": "Che"}]}, "finishReason": "MAX_TOKENS", "index": 1}
+		],
+		"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 5, "totalTokenCount": 15, "cachedContentTokenCount": 4},
+		"modelVersion": "gemini-2.5-flash-001",
+		"responseId": "abc"
+	}`, rec.Body.String())
+}
+
+func TestWriter_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		code    string
+		message string
+	}{
+		{"rate limited", http.StatusTooManyRequests, `{"error":{"type":"rate_limit_error","message":"slow down"}}`, "RESOURCE_EXHAUSTED", "slow down"},
+		{"invalid request", http.StatusBadRequest, `{"error":{"message":"contents must not be empty"}}`, "INVALID_ARGUMENT", "contents must not be empty"},
+		{"unauthenticated", http.StatusUnauthorized, `{"error":{"message":"missing key"}}`, "UNAUTHENTICATED", "missing key"},
+		{"unavailable", http.StatusServiceUnavailable, `maintenance`, "UNAVAILABLE", "maintenance"},
+		{"vendor failure", http.StatusBadGateway, ``, "INTERNAL", "Bad Gateway"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writer := NewWriter(rec, "gemini-2.5-flash", false)
+			writer.Header().Set("Content-Type", "application/json")
+			writer.Header().Set("Retry-After", "5")
+			writer.WriteHeader(tt.status)
+			_, err := writer.Write([]byte(tt.body))
+			require.NoError(t, err)
+			require.NoError(t, writer.Finish())
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, "5", rec.Header().Get("Retry-After"))
+			var response ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, ErrorResponse{Error: ErrorDetail{Code: tt.status, Message: tt.message, Status: tt.code}}, response)
+		})
+	}
+}
+
+func TestWriter_UntranslatableCompletion(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no choices", `{"choices": []}`},
+		{"invalid arguments", `{"choices": [{"message": {"tool_calls": [{"id": "t", "function": {"name": "f", "arguments": "{\"q\":"}}]}}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writer := NewWriter(rec, "gemini-2.5-flash", false)
+			_, err := writer.Write([]byte(tt.body))
+			require.NoError(t, err)
+			assert.Error(t, writer.Finish())
+			assert.Empty(t, rec.Body.String())
+		})
+	}
+}
+
+func TestWriter_Stream(t *testing.T) {
+	tests := []struct {
+		name        string
+		sse         bool
+		contentType string
+		chunks      func(t *testing.T, body string) []string
+	}{
+		{
+			name:        "json array",
+			contentType: "application/json",
+			chunks: func(t *testing.T, body string) []string {
+				var chunks []json.RawMessage
+				require.NoError(t, json.Unmarshal([]byte(body), &chunks))
+				var out []string
+				for _, chunk := range chunks {
+					out = append(out, string(chunk))
+				}
+				return out
+			},
+		},
+		{
+			name:        "server-sent events",
+			sse:         true,
+			contentType: "text/event-stream; charset=utf-8",
+			chunks: func(t *testing.T, body string) []string {
+				var out []string
+				for _, event := range strings.Split(strings.TrimSpace(body), "\r\n\r\n") {
+					data, ok := strings.CutPrefix(event, "data: ")
+					require.True(t, ok, event)
+					out = append(out, data)
+				}
+				return out
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writer := NewWriter(rec, "gemini-2.5-flash", tt.sse)
+			writer.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+			writer.WriteHeader(http.StatusOK)
+			for _, chunk := range streamChunks {
+				_, err := writer.Write([]byte(chunk))
+				require.NoError(t, err)
+				writer.Flush()
+			}
+			require.NoError(t, writer.Finish())
+			assert.True(t, rec.Flushed)
+			assert.Equal(t, tt.contentType, rec.Header().Get("Content-Type"))
+
+			chunks := tt.chunks(t, rec.Body.String())
+			require.Len(t, chunks, len(expectedChunks))
+			for i, expected := range expectedChunks {
+				assert.JSONEq(t, expected, chunks[i])
+			}
+		})
+	}
+}
+
+func TestWriter_StreamCutOff(t *testing.T) {
+	tests := []struct {
+		name    string
+		chunks  string
+		message string
+	}{
+		{
+			name:    "stream ends early",
+			chunks:  `data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"content":"Hi"}}]}` + "\n\n",
+			message: "The stream ended before the response completed",
+		},
+		{
+			name:    "error chunk",
+			chunks:  `data: {"error":{"message":"upstream failed"}}` + "\n\n",
+			message: "upstream failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writer := NewWriter(rec, "gemini-2.5-flash", false)
+			writer.Header().Set("Content-Type", "text/event-stream")
+			writer.WriteHeader(http.StatusOK)
+			_, err := writer.Write([]byte(tt.chunks))
+			require.NoError(t, err)
+			require.NoError(t, writer.Finish())
+
+			var chunks []map[string]interface{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &chunks), "the array is closed")
+			last := chunks[len(chunks)-1]
+			assert.Equal(t, map[string]interface{}{"code": float64(500), "message": tt.message, "status": "INTERNAL"}, last["error"])
+		})
+	}
+}
@@ -0,0 +1,546 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/admission"
+	"github.com/aashari/go-generative-api-router/internal/budget"
+	"github.com/aashari/go-generative-api-router/internal/cache"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/maintenance"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/rollout"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// defaultDecisionQueryLimit caps the number of decisions returned when no limit is given
+const defaultDecisionQueryLimit = 100
+
+// RoutingDecisionsResponse represents the response of the routing decisions endpoint
+type RoutingDecisionsResponse struct {
+	Object   string                       `json:"object"`
+	Total    int                          `json:"total"`
+	Returned int                          `json:"returned"`
+	Data     []monitoring.RoutingDecision `json:"data"`
+}
+
+// RoutingDecisionsHandler returns recent routing decisions from the in-memory ring buffer
+// @Summary      Recent routing decisions
+// @Description  Returns the most recent routing decisions, newest first, optionally filtered
+// @Tags         admin
+// @Produce      json
+// @Param        request_id  query  string  false  "Only decisions for this request ID"
+// @Param        client_key  query  string  false  "Only decisions for this client key hint (e.g. '...abcd')"
+// @Param        user        query  string  false  "Only decisions for this hashed end user, as logged"
+// @Param        vendor      query  string  false  "Only decisions routed (or falling back) to this vendor"
+// @Param        model       query  string  false  "Only decisions routed (or falling back) to this model"
+// @Param        credential  query  string  false  "Only decisions sent with this credential label (e.g. 'openai:...abcd')"
+// @Param        outcome     query  string  false  "Only decisions with this outcome (success, fallback_success, error, selection_failed)"
+// @Param        since       query  string  false  "Only decisions at or after this RFC3339 timestamp"
+// @Param        limit       query  int     false  "Maximum number of decisions to return (default 100)"
+// @Security     BearerAuth
+// @Success      200  {object}  handlers.RoutingDecisionsResponse  "Matching routing decisions"
+// @Failure      400  {object}  types.ErrorResponse                "Bad request error"
+// @Router       /admin/routing/decisions [get]
+func (h *APIHandlers) RoutingDecisionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "RoutingDecisionsHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := monitoring.DecisionFilter{
+		RequestID:  query.Get("request_id"),
+		ClientKey:  query.Get("client_key"),
+		User:       query.Get("user"),
+		Vendor:     query.Get("vendor"),
+		Model:      query.Get("model"),
+		Credential: query.Get("credential"),
+		Outcome:    query.Get("outcome"),
+		Limit:      defaultDecisionQueryLimit,
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			errors.HandleError(w, errors.NewValidationError("limit must be a positive integer"), http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			errors.HandleError(w, errors.NewValidationError("since must be an RFC3339 timestamp"), http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+
+	decisionLog := monitoring.DefaultDecisionLog()
+	decisions := decisionLog.Query(filter)
+
+	response := RoutingDecisionsResponse{
+		Object:   "list",
+		Total:    decisionLog.Len(),
+		Returned: len(decisions),
+		Data:     decisions,
+	}
+
+	jsonResp, err := json.Marshal(response)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal routing decisions response", err,
+			"returned", len(decisions),
+		)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate routing decisions"), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
+		logger.Error(ctx, "Failed to write routing decisions response", err,
+			"response_size", len(jsonResp),
+		)
+	}
+}
+
+// PayloadSizesResponse represents the response of the payload size metrics endpoint
+type PayloadSizesResponse struct {
+	Object string                          `json:"object"`
+	Data   []monitoring.VendorPayloadStats `json:"data"`
+}
+
+// PayloadSizesHandler returns per-vendor request/response size and compressibility metrics
+// @Summary      Vendor payload size metrics
+// @Description  Returns outbound body sizes, inbound wire/uncompressed sizes and compression ratios per vendor
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  handlers.PayloadSizesResponse  "Per-vendor payload size metrics"
+// @Router       /admin/metrics/payload-sizes [get]
+func (h *APIHandlers) PayloadSizesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "PayloadSizesHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	response := PayloadSizesResponse{
+		Object: "list",
+		Data:   monitoring.DefaultPayloadSizeMetrics().Snapshot(),
+	}
+
+	jsonResp, err := json.Marshal(response)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal payload size metrics response", err,
+			"vendors", len(response.Data),
+		)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate payload size metrics"), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
+		logger.Error(ctx, "Failed to write payload size metrics response", err,
+			"response_size", len(jsonResp),
+		)
+	}
+}
+
+// ResponseAnomaliesResponse represents the response of the response anomaly metrics endpoint
+type ResponseAnomaliesResponse struct {
+	Object  string                          `json:"object"`
+	Enabled bool                            `json:"enabled"`
+	Data    []monitoring.VendorAnomalyStats `json:"data"`
+}
+
+// ResponseAnomaliesHandler returns per-vendor counts of responses flagged by the anomaly detector
+// @Summary      Vendor response anomalies
+// @Description  Returns per-vendor counts of byte-identical responses returned for distinct prompts
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  handlers.ResponseAnomaliesResponse  "Per-vendor anomaly counts"
+// @Router       /admin/metrics/response-anomalies [get]
+func (h *APIHandlers) ResponseAnomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "ResponseAnomaliesHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	response := ResponseAnomaliesResponse{
+		Object: "list",
+		Data:   []monitoring.VendorAnomalyStats{},
+	}
+	if detector := monitoring.DefaultResponseAnomalyDetector(); detector != nil {
+		response.Enabled = true
+		if reporter, ok := detector.(monitoring.AnomalyReporter); ok {
+			response.Data = reporter.Snapshot()
+		}
+	}
+
+	jsonResp, err := json.Marshal(response)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal response anomalies response", err,
+			"vendors", len(response.Data),
+		)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate response anomaly metrics"), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
+		logger.Error(ctx, "Failed to write response anomalies response", err,
+			"response_size", len(jsonResp),
+		)
+	}
+}
+
+// SlowClientsResponse represents the response of the slow-client metrics endpoint
+type SlowClientsResponse struct {
+	Object string                             `json:"object"`
+	Data   []monitoring.VendorSlowClientStats `json:"data"`
+}
+
+// SlowClientsHandler returns per-vendor counts of streams held back or dropped by slow clients
+// @Summary      Slow streaming client metrics
+// @Description  Returns per-vendor counts of streaming write timeouts, buffer-full disconnects and upstream pauses
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  handlers.SlowClientsResponse  "Per-vendor slow-client counts"
+// @Router       /admin/metrics/slow-clients [get]
+func (h *APIHandlers) SlowClientsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "SlowClientsHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	response := SlowClientsResponse{
+		Object: "list",
+		Data:   monitoring.DefaultSlowClientMetrics().Snapshot(),
+	}
+
+	jsonResp, err := json.Marshal(response)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal slow-client metrics response", err,
+			"vendors", len(response.Data),
+		)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate slow-client metrics"), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
+		logger.Error(ctx, "Failed to write slow-client metrics response", err,
+			"response_size", len(jsonResp),
+		)
+	}
+}
+
+// LoadSheddingHandler returns the load shedder's thresholds, current readings and counts of
+// shed requests
+// @Summary      Load shedding metrics
+// @Description  Returns the heap, goroutine and in-flight thresholds, their current readings and the number of requests shed per resource
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  admission.ShedderStats  "Load shedding readings and counters"
+// @Router       /admin/metrics/load-shedding [get]
+func (h *APIHandlers) LoadSheddingHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "LoadSheddingHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	stats := admission.DefaultShedder().Stats()
+	jsonResp, err := json.Marshal(stats)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal load shedding metrics response", err,
+			"total_shed", stats.TotalShed,
+		)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate load shedding metrics"), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
+		logger.Error(ctx, "Failed to write load shedding metrics response", err,
+			"response_size", len(jsonResp),
+		)
+	}
+}
+
+// MetricsHandler exposes request, vendor, token and streaming metrics for Prometheus to scrape
+// @Summary      Prometheus metrics
+// @Description  Returns request counts, latency histograms, token usage, streaming time to first byte, retries and failed attempts, labelled by vendor, model and status, in the Prometheus text exposition format
+// @Tags         admin
+// @Produce      plain
+// @Success      200  {string}  string  "Metrics in the Prometheus text exposition format"
+// @Router       /metrics [get]
+func (h *APIHandlers) MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "MetricsHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	w.Header().Set(utils.HeaderContentType, monitoring.PrometheusContentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := monitoring.DefaultPrometheusMetrics().WriteTo(w); err != nil {
+		logger.Error(ctx, "Failed to write metrics response", err)
+	}
+}
+
+// LatencyStatsResponse represents the response of the rolling latency metrics endpoint
+type LatencyStatsResponse struct {
+	Object string                         `json:"object"`
+	Data   []monitoring.ModelLatencyStats `json:"data"`
+}
+
+// LatencyStatsHandler returns the rolling latency and error rate of every vendor model
+// @Summary      Rolling vendor latency metrics
+// @Description  Returns the exponentially weighted moving average latency and error rate per vendor model, as used by the latency-aware selector
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  handlers.LatencyStatsResponse  "Per-model rolling latency stats"
+// @Router       /admin/metrics/latency [get]
+func (h *APIHandlers) LatencyStatsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "LatencyStatsHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	response := LatencyStatsResponse{
+		Object: "list",
+		Data:   monitoring.DefaultLatencyTracker().Snapshot(),
+	}
+
+	jsonResp, err := json.Marshal(response)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal latency metrics response", err,
+			"models", len(response.Data),
+		)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate latency metrics"), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
+		logger.Error(ctx, "Failed to write latency metrics response", err,
+			"response_size", len(jsonResp),
+		)
+	}
+}
+
+// SemanticCacheResponse represents the response of the semantic cache metrics endpoint
+type SemanticCacheResponse struct {
+	Object  string       `json:"object"`
+	Enabled bool         `json:"enabled"`
+	Data    *cache.Stats `json:"data,omitempty"`
+}
+
+// SemanticCacheHandler returns the hit rate of the semantic response cache
+// @Summary      Semantic cache metrics
+// @Description  Returns semantic cache entries, hits, misses, hit rate, stores, evictions and how often each normalization pattern rewrote a request
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  handlers.SemanticCacheResponse  "Semantic cache counters"
+// @Router       /admin/metrics/semantic-cache [get]
+func (h *APIHandlers) SemanticCacheHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "SemanticCacheHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	response := SemanticCacheResponse{Object: "semantic_cache"}
+	if semanticCache := cache.Default(); semanticCache != nil {
+		stats := semanticCache.Stats()
+		response.Enabled = true
+		response.Data = &stats
+	}
+
+	jsonResp, err := json.Marshal(response)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal semantic cache metrics response", err)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate semantic cache metrics"), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
+		logger.Error(ctx, "Failed to write semantic cache metrics response", err,
+			"response_size", len(jsonResp),
+		)
+	}
+}
+
+// RolloutsResponse represents the response of the rollouts endpoint
+type RolloutsResponse struct {
+	Object string          `json:"object"`
+	Data   []rollout.State `json:"data"`
+}
+
+// RolloutsHandler returns the rollout progress of every model on trial
+// @Summary      Model rollouts
+// @Description  Returns the phase, traffic share and current step outcomes of every model with a rollout config
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  handlers.RolloutsResponse  "Rollout state per model"
+// @Router       /admin/rollouts [get]
+func (h *APIHandlers) RolloutsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "RolloutsHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	response := RolloutsResponse{
+		Object: "list",
+		Data:   rollout.Default().States(),
+	}
+
+	jsonResp, err := json.Marshal(response)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal rollouts response", err,
+			"models", len(response.Data),
+		)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate rollout states"), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
+		logger.Error(ctx, "Failed to write rollouts response", err,
+			"response_size", len(jsonResp),
+		)
+	}
+}
+
+// BudgetsResponse represents the response of the budgets endpoint
+type BudgetsResponse struct {
+	Object string         `json:"object"`
+	Data   []budget.State `json:"data"`
+}
+
+// BudgetsHandler returns the spend of every vendor with a cost ceiling
+// @Summary      Vendor budgets
+// @Description  Returns the daily and monthly spend of every vendor with a budget, against its ceilings
+// @Tags         admin
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  handlers.BudgetsResponse  "Spend per vendor"
+// @Router       /admin/budgets [get]
+func (h *APIHandlers) BudgetsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "BudgetsHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	response := BudgetsResponse{
+		Object: "list",
+		Data:   budget.Default().States(),
+	}
+
+	jsonResp, err := json.Marshal(response)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal budgets response", err,
+			"vendors", len(response.Data),
+		)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate budget states"), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
+		logger.Error(ctx, "Failed to write budgets response", err,
+			"response_size", len(jsonResp),
+		)
+	}
+}
+
+// MaintenanceRequest toggles maintenance mode
+type MaintenanceRequest struct {
+	Enabled    bool   `json:"enabled"`
+	Message    string `json:"message,omitempty"`
+	RetryAfter int    `json:"retry_after_seconds,omitempty"`
+}
+
+// MaintenanceHandler reports or toggles the read-only maintenance mode
+// @Summary      Maintenance mode
+// @Description  GET returns the current maintenance state; PUT enables or disables it. While enabled, completion endpoints return 503 with Retry-After
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body  handlers.MaintenanceRequest  false  "Desired maintenance state (PUT only)"
+// @Security     BearerAuth
+// @Success      200  {object}  maintenance.Status    "Current maintenance state"
+// @Failure      400  {object}  types.ErrorResponse   "Bad request error"
+// @Failure      401  {object}  types.ErrorResponse   "PUT without an admin API key"
+// @Router       /admin/maintenance [get]
+// @Router       /admin/maintenance [put]
+func (h *APIHandlers) MaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "MaintenanceHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet, http.MethodPut) {
+		return
+	}
+
+	mode := maintenance.Default()
+	if r.Method == http.MethodPut {
+		if !requireAdmin(w, r) {
+			return
+		}
+		var req MaintenanceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			errors.HandleError(w, errors.NewValidationError("invalid request format"), http.StatusBadRequest)
+			return
+		}
+		if req.RetryAfter < 0 {
+			errors.HandleError(w, errors.NewValidationError("retry_after_seconds must not be negative"), http.StatusBadRequest)
+			return
+		}
+
+		if req.Enabled {
+			mode.Enable(req.Message, req.RetryAfter)
+		} else {
+			mode.Disable()
+		}
+		logger.Warn(ctx, "Maintenance mode changed",
+			"enabled", req.Enabled,
+			"message", req.Message,
+			"retry_after", req.RetryAfter,
+			"remote_addr", r.RemoteAddr,
+		)
+	}
+
+	jsonResp, err := json.Marshal(mode.Status())
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal maintenance status", err)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate maintenance status"), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
+		logger.Error(ctx, "Failed to write maintenance status response", err,
+			"response_size", len(jsonResp),
+		)
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/maintenance"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceHandler_WriteRequiresAdmin(t *testing.T) {
+	h := newTestHandlers()
+	mode := maintenance.Default()
+	t.Cleanup(mode.Disable)
+
+	put := func(r *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.MaintenanceHandler(rec, r)
+		return rec
+	}
+	body := `{"enabled": true, "message": "Incident", "retry_after_seconds": 60}`
+
+	rec := put(httptest.NewRequest(http.MethodPut, "/admin/maintenance", strings.NewReader(body)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.False(t, mode.Status().Enabled, "unauthenticated writes change nothing")
+
+	get := httptest.NewRecorder()
+	h.MaintenanceHandler(get, httptest.NewRequest(http.MethodGet, "/admin/maintenance", nil))
+	assert.Equal(t, http.StatusOK, get.Code, "reads need no admin credential")
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/maintenance", strings.NewReader(body))
+	rec = put(req.WithContext(access.WithAdmin(req.Context())))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, mode.Status().Enabled)
+}
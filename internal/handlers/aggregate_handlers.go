@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+
+	"github.com/aashari/go-generative-api-router/internal/aggregate"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// AggregateHandler runs a map-reduce aggregation over a list of documents
+// @Summary      Map-reduce aggregation
+// @Description  Applies a prompt template to every document in concurrent chat completions (map), then combines the successful results in one final completion (reduce). Completions are routed like /v1/chat/completions. With stream set, progress is sent as server-sent events (aggregate.started, map.completed, reduce.started, aggregate.completed or aggregate.failed)
+// @Tags         chat
+// @Accept       json
+// @Produce      json
+// @Produce      text/event-stream
+// @Param        vendor  query     string               false  "Optional vendor to target (e.g., 'openai', 'gemini')"
+// @Param        request body      aggregate.Request    true   "Documents, map prompt template and optional reduce prompt template"
+// @Security     BearerAuth
+// @Success      200     {object}  aggregate.Response   "Aggregation completed, possibly with failed documents (status partial)"
+// @Failure      400     {object}  types.ErrorResponse  "Bad request error"
+// @Failure      502     {object}  aggregate.Response   "No document could be mapped or the reduce step failed"
+// @Failure      503     {object}  types.ErrorResponse  "Aggregation is not available"
+// @Router       /v1/aggregate [post]
+func (h *APIHandlers) AggregateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "AggregateHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	runner := aggregate.Default()
+	if runner == nil {
+		errors.HandleError(w, errors.NewAPIError(errors.ErrorTypeUnavailable, "Aggregation is not available"), http.StatusServiceUnavailable)
+		return
+	}
+
+	var req aggregate.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode request", err)
+		errors.HandleError(w, errors.NewValidationError("invalid request format"), http.StatusBadRequest)
+		return
+	}
+	if err := runner.Validate(&req); err != nil {
+		if stderrors.Is(err, aggregate.ErrInvalidRequest) {
+			errors.HandleError(w, errors.NewValidationError(err.Error()), http.StatusBadRequest)
+			return
+		}
+		errors.HandleError(w, errors.NewInternalError("Failed to validate request"), http.StatusInternalServerError)
+		return
+	}
+	logger.Info(ctx, "Aggregate request received",
+		"model", req.Model,
+		"documents", len(req.Documents),
+		"concurrency", req.Concurrency,
+		"stream", req.Stream,
+	)
+
+	if !req.Stream {
+		response := runner.Run(r.Context(), r, &req, nil)
+		jsonResp, err := json.Marshal(response)
+		if err != nil {
+			logger.Error(ctx, "Failed to marshal aggregate response", err)
+			errors.HandleError(w, errors.NewInternalError("Failed to generate response"), http.StatusInternalServerError)
+			return
+		}
+		status := http.StatusOK
+		if response.Status == aggregate.StatusFailed {
+			status = http.StatusBadGateway
+		}
+		if err := writeJSONResponse(w, r, status, jsonResp); err != nil {
+			logger.Error(ctx, "Failed to write aggregate response", err,
+				"response_size", len(jsonResp),
+			)
+		}
+		return
+	}
+
+	// Progress events are written as each step finishes
+	w.Header().Set(utils.HeaderContentType, utils.ContentTypeEventStreamUTF8)
+	w.Header().Set(utils.HeaderCacheControl, utils.CacheControlNoCache)
+	w.Header().Set(utils.HeaderXAccelBuffering, utils.XAccelBufferingNo)
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	runner.Run(r.Context(), r, &req, func(event aggregate.Event) {
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			logger.Error(ctx, "Failed to marshal aggregate event", err, "event", event.Type)
+			return
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/aggregate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAggregateHandlers(t *testing.T) *APIHandlers {
+	completions := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "FAIL") {
+			http.Error(w, `{"error":{"message":"no vendor available"}}`, http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"done"}}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+	})
+	aggregate.SetDefault(aggregate.NewRunner(completions, 4, 10))
+	t.Cleanup(func() { aggregate.SetDefault(nil) })
+	return newTestHandlers()
+}
+
+func TestAggregateHandler(t *testing.T) {
+	h := newAggregateHandlers(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		result string
+	}{
+		{"completed", `{"model":"gpt-4o","prompt":"Summarize {{document}}","documents":[{"text":"a"},{"text":"b"}]}`, http.StatusOK, aggregate.StatusCompleted},
+		{"partial", `{"model":"gpt-4o","prompt":"Summarize {{document}}","documents":[{"text":"a"},{"text":"FAIL"}]}`, http.StatusOK, aggregate.StatusPartial},
+		{"failed", `{"model":"gpt-4o","prompt":"Summarize {{document}}","documents":[{"text":"FAIL"}]}`, http.StatusBadGateway, aggregate.StatusFailed},
+		{"invalid", `{"model":"gpt-4o","prompt":"Summarize","documents":[{"text":"a"}]}`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.AggregateHandler(rec, httptest.NewRequest(http.MethodPost, "/v1/aggregate", strings.NewReader(tt.body)))
+			require.Equal(t, tt.status, rec.Code)
+			if tt.result == "" {
+				return
+			}
+			var response aggregate.Response
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, tt.result, response.Status)
+		})
+	}
+}
+
+func TestAggregateHandler_Stream(t *testing.T) {
+	h := newAggregateHandlers(t)
+
+	rec := httptest.NewRecorder()
+	body := `{"model":"gpt-4o","stream":true,"prompt":"Summarize {{document}}","documents":[{"id":"a","text":"a"},{"id":"b","text":"b"}]}`
+	h.AggregateHandler(rec, httptest.NewRequest(http.MethodPost, "/v1/aggregate", strings.NewReader(body)))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream; charset=utf-8", rec.Header().Get("Content-Type"))
+	var names []string
+	for _, block := range strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n") {
+		names = append(names, strings.TrimPrefix(strings.SplitN(block, "\n", 2)[0], "event: "))
+	}
+	assert.Equal(t, []string{
+		aggregate.EventStarted,
+		aggregate.EventMapCompleted,
+		aggregate.EventMapCompleted,
+		aggregate.EventReduceStarted,
+		aggregate.EventCompleted,
+	}, names)
+}
+
+func TestAggregateHandler_Unavailable(t *testing.T) {
+	aggregate.SetDefault(nil)
+	rec := httptest.NewRecorder()
+	newTestHandlers().AggregateHandler(rec, httptest.NewRequest(http.MethodPost, "/v1/aggregate", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
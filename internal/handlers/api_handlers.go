@@ -2,22 +2,43 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/admission"
+	"github.com/aashari/go-generative-api-router/internal/alias"
+	"github.com/aashari/go-generative-api-router/internal/budget"
+	"github.com/aashari/go-generative-api-router/internal/canary"
+	"github.com/aashari/go-generative-api-router/internal/capture"
 	"github.com/aashari/go-generative-api-router/internal/config"
 	"github.com/aashari/go-generative-api-router/internal/database"
 	"github.com/aashari/go-generative-api-router/internal/errors"
 	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/gemini"
 	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/maintenance"
+	"github.com/aashari/go-generative-api-router/internal/messages"
 	"github.com/aashari/go-generative-api-router/internal/proxy"
+	"github.com/aashari/go-generative-api-router/internal/quarantine"
+	"github.com/aashari/go-generative-api-router/internal/ratelimit"
+	"github.com/aashari/go-generative-api-router/internal/reliability"
+	"github.com/aashari/go-generative-api-router/internal/responses"
+	"github.com/aashari/go-generative-api-router/internal/rollout"
+	"github.com/aashari/go-generative-api-router/internal/schedule"
 	"github.com/aashari/go-generative-api-router/internal/selector"
 	"github.com/aashari/go-generative-api-router/internal/types"
 	"github.com/aashari/go-generative-api-router/internal/utils"
+	"github.com/aashari/go-generative-api-router/internal/vendorhealth"
 )
 
 // startTime tracks when the application started
@@ -29,23 +50,32 @@ type HealthResponse struct {
 	Timestamp string                 `json:"timestamp"`
 	Services  map[string]string      `json:"services"`
 	Details   map[string]interface{} `json:"details"`
+	// Dependencies is the per-dependency breakdown of a deep health check
+	Dependencies map[string]DependencyGroup `json:"dependencies,omitempty"`
 }
 
 // APIHandlers contains the dependencies needed for API handlers
 type APIHandlers struct {
-	Credentials   []config.Credential
-	VendorModels  []config.VendorModel
+	Config        *config.Store
 	APIClient     *proxy.APIClient
 	ModelSelector selector.Selector
+	// CaptureStore holds the captured requests, nil unless REQUEST_CAPTURE_ENABLED is set
+	CaptureStore *capture.Store
+	// Pipeline is the full router handler captured requests are replayed through, set by
+	// router.SetupRoutes once its middleware stack is built
+	Pipeline http.Handler
+
+	// deepHealthCache keeps the latest deep health check results
+	deepHealthCache deepHealthCache
 }
 
 // NewAPIHandlers creates a new APIHandlers instance
-func NewAPIHandlers(creds []config.Credential, models []config.VendorModel, client *proxy.APIClient, selector selector.Selector) *APIHandlers {
+func NewAPIHandlers(store *config.Store, client *proxy.APIClient, selector selector.Selector) *APIHandlers {
 	return &APIHandlers{
-		Credentials:   creds,
-		VendorModels:  models,
+		Config:        store,
 		APIClient:     client,
 		ModelSelector: selector,
+		CaptureStore:  capture.Default(),
 	}
 }
 
@@ -55,9 +85,15 @@ func NewAPIHandlers(creds []config.Credential, models []config.VendorModel, clie
 // @Tags         health
 // @Accept       json
 // @Produce      json
+// @Param        deep  query  bool  false  "Also check vendors, credentials, media tools and configuration live (needs HEALTH_DEEP_CHECKS=true)"
 // @Success      200  {object}  handlers.HealthResponse  "Structured health response"
 // @Router       /health [get]
+// @Router       /health [head]
 func (h *APIHandlers) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+
 	// Skip logging for health checks to reduce log noise
 	// Only errors will be logged if health check fails
 
@@ -82,8 +118,11 @@ func (h *APIHandlers) HealthHandler(w http.ResponseWriter, r *http.Request) {
 		overallStatus = "unhealthy"
 	}
 
+	// Read the configuration once so every check sees the same snapshot
+	snapshot := h.Config.Snapshot()
+
 	// Check credentials availability
-	if len(h.Credentials) > 0 {
+	if snapshot.CredentialCount() > 0 {
 		services["credentials"] = "up"
 	} else {
 		services["credentials"] = "down"
@@ -91,7 +130,7 @@ func (h *APIHandlers) HealthHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check models availability
-	if len(h.VendorModels) > 0 {
+	if snapshot.ModelCount() > 0 {
 		services["models"] = "up"
 	} else {
 		services["models"] = "down"
@@ -122,20 +161,83 @@ func (h *APIHandlers) HealthHandler(w http.ResponseWriter, r *http.Request) {
 		services["database"] = "up"
 	}
 
+	details := map[string]interface{}{
+		"version":     version,
+		"uptime":      uptime,
+		"maintenance": maintenance.Default().Status().Enabled,
+	}
+	if controller := admission.Default(); controller.Enabled() {
+		details["admission"] = controller.Stats()
+	}
+	if limiter := admission.DefaultLimiter(); limiter.Enabled() {
+		details["concurrency"] = limiter.Stats()
+	}
+	if shedder := admission.DefaultShedder(); shedder.Enabled() {
+		details["load_shedding"] = shedder.Stats()
+	}
+	if budget := reliability.DefaultRetryBudget(); budget.Enabled() {
+		details["retry_budget"] = budget.Stats()
+	}
+
+	// Check canary results; models failing their latest check degrade the service
+	if canaryStatus := canary.Default(); canaryStatus.Enabled() {
+		if degraded := canaryStatus.Degraded(); len(degraded) > 0 {
+			services["canary"] = "degraded"
+			details["degraded_models"] = degraded
+			if overallStatus == "healthy" {
+				overallStatus = "degraded"
+			}
+		} else {
+			services["canary"] = "up"
+		}
+	}
+
+	// Vendors failing their health probes degrade the service
+	if vendorHealth := vendorhealth.Default(); vendorHealth.Enabled() {
+		details["vendor_health"] = vendorHealth.Results()
+		if len(vendorHealth.Degraded()) > 0 {
+			services["vendor_health"] = "degraded"
+			if overallStatus == "healthy" {
+				overallStatus = "degraded"
+			}
+		} else {
+			services["vendor_health"] = "up"
+		}
+	}
+
+	// Credentials quarantined after repeated 401/403/429 responses degrade the service
+	if q := quarantine.Default(); q.Enabled() {
+		if entries := q.Entries(snapshot.Credentials()); len(entries) > 0 {
+			services["credential_quarantine"] = "degraded"
+			details["quarantined_credentials"] = entries
+			if overallStatus == "healthy" {
+				overallStatus = "degraded"
+			}
+		} else {
+			services["credential_quarantine"] = "up"
+		}
+	}
+
+	// Deep checks call every vendor, so they run only on request and when enabled
+	var dependencies map[string]DependencyGroup
+	if r.URL.Query().Get("deep") == "true" && utils.GetEnvBool("HEALTH_DEEP_CHECKS", false) {
+		dependencies = h.deepHealth(r.Context(), snapshot)
+		for _, group := range dependencies {
+			if group.Status != dependencyUp && group.Status != dependencyUnknown && overallStatus == "healthy" {
+				overallStatus = "degraded"
+			}
+		}
+	}
+
 	// Create structured health response
 	healthResponse := HealthResponse{
-		Status:    overallStatus,
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		Services:  services,
-		Details: map[string]interface{}{
-			"version": version,
-			"uptime":  uptime,
-		},
+		Status:       overallStatus,
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Services:     services,
+		Details:      details,
+		Dependencies: dependencies,
 	}
 
-	// Set content type to JSON
-	w.Header().Set(utils.HeaderContentType, utils.ContentTypeJSON)
-
 	// Determine HTTP status code based on overall health
 	statusCode := http.StatusOK
 	if overallStatus == "unhealthy" {
@@ -144,9 +246,7 @@ func (h *APIHandlers) HealthHandler(w http.ResponseWriter, r *http.Request) {
 		statusCode = http.StatusOK // Still return 200 for degraded but functional service
 	}
 
-	w.WriteHeader(statusCode)
-
-	// Marshal and send JSON response
+	// Marshal before writing headers so Content-Length is accurate (also for HEAD probes)
 	jsonResponse, err := json.Marshal(healthResponse)
 	if err != nil {
 		ctx := logger.WithComponent(r.Context(), "HealthHandler")
@@ -160,7 +260,7 @@ func (h *APIHandlers) HealthHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err := w.Write(jsonResponse); err != nil {
+	if err := writeJSONResponse(w, r, statusCode, jsonResponse); err != nil {
 		ctx := logger.WithComponent(r.Context(), "HealthHandler")
 		ctx = logger.WithStage(ctx, "ResponseWrite")
 		logger.Error(ctx, "Failed to write health response", err,
@@ -179,8 +279,8 @@ func (h *APIHandlers) HealthHandler(w http.ResponseWriter, r *http.Request) {
 			"services_status", services,
 			"version", version,
 			"uptime_seconds", uptime,
-			"credentials_count", len(h.Credentials),
-			"models_count", len(h.VendorModels),
+			"credentials_count", snapshot.CredentialCount(),
+			"models_count", snapshot.ModelCount(),
 		)
 	}
 }
@@ -203,61 +303,245 @@ func (h *APIHandlers) ChatCompletionsHandler(w http.ResponseWriter, r *http.Requ
 	ctx := logger.WithComponent(r.Context(), "ChatCompletionsHandler")
 	ctx = logger.WithStage(ctx, "Request")
 
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+
+	snapshot := h.Config.Snapshot()
+
 	// Log complete chat completions request data
 	logger.Info(ctx, "Chat completions request received",
-		"credentials_available", len(h.Credentials),
-		"models_available", len(h.VendorModels),
+		"credentials_available", snapshot.CredentialCount(),
+		"models_available", snapshot.ModelCount(),
 		"method", r.Method,
 		"path", r.URL.Path,
 		"query_params", r.URL.Query(),
 	)
 
-	// Optional vendor filter via query parameter
-	vendorFilter := r.URL.Query().Get("vendor")
+	// The pool narrowed by ?vendor=, routing headers and rollout trials
+	creds, models, ok := buildRoutingPool(ctx, w, r, snapshot, config.ModelTypeChat)
+	if !ok {
+		return
+	}
 
-	// Filter credentials and models if vendor is specified
-	creds := h.Credentials
-	models := h.VendorModels
-	if vendorFilter != "" {
-		// Log complete filtering operation
-		logger.Debug(ctx, "Filtering by vendor",
-			"vendor_filter", vendorFilter,
-			"original_credentials_count", len(creds),
-			"original_models_count", len(models),
+	proxy.ProxyRequest(w, r, creds, models, h.APIClient, h.ModelSelector)
+}
+
+// ResponsesHandler handles the Responses API endpoint
+// @Summary      Responses API
+// @Description  Translates OpenAI Responses API requests into chat completions routed like /v1/chat/completions, and the result back into a response or, with stream set, Responses stream events
+// @Tags         chat
+// @Accept       json
+// @Produce      json
+// @Produce      text/event-stream
+// @Param        vendor  query     string              false  "Optional vendor to target (e.g., 'openai', 'gemini')"
+// @Param        request body      responses.Request   true   "Request in OpenAI Responses API format"
+// @Security     BearerAuth
+// @Success      200     {object}  responses.Response  "OpenAI-compatible response"
+// @Failure      400     {object}  types.ErrorResponse "Bad request error"
+// @Failure      500     {object}  types.ErrorResponse "Internal server error"
+// @Failure      502     {object}  types.ErrorResponse "Untranslatable vendor response"
+// @Router       /v1/responses [post]
+func (h *APIHandlers) ResponsesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "ResponsesHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+
+	var req responses.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode request", err)
+		validationErr := errors.NewValidationError("invalid request format")
+		errors.HandleError(w, validationErr, http.StatusBadRequest)
+		return
+	}
+
+	payload, err := responses.ChatRequest(&req)
+	if err != nil {
+		errors.HandleError(w, errors.NewValidationError(err.Error()), http.StatusBadRequest)
+		return
+	}
+	bodyBytes, err := json.Marshal(payload)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal payload", err)
+		apiErr := errors.NewInternalError("failed to build request")
+		errors.HandleError(w, apiErr, http.StatusInternalServerError)
+		return
+	}
+
+	// Route the translated request exactly like a chat completion, uncompressed so it can be translated back
+	newReq := r.Clone(r.Context())
+	newReq.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	newReq.ContentLength = int64(len(bodyBytes))
+	newReq.Header.Del(utils.HeaderAcceptEncoding)
+
+	writer := responses.NewWriter(w, &req)
+	h.ChatCompletionsHandler(writer, newReq)
+	if err := writer.Finish(); err != nil {
+		logger.Error(ctx, "Failed to translate chat completion into a response", err,
+			"model", req.Model,
+			"stream", req.Stream,
+		)
+		apiErr := errors.NewExternalError("failed to translate the vendor response")
+		errors.HandleError(w, apiErr, http.StatusBadGateway)
+	}
+}
+
+// MessagesHandler handles the Anthropic-compatible Messages API endpoint
+// @Summary      Messages API
+// @Description  Translates Anthropic Messages API requests into chat completions routed like /v1/chat/completions, and the result back into a message or, with stream set, Messages stream events. Clients may authenticate with x-api-key instead of a Bearer token; the anthropic-version header is accepted and ignored
+// @Tags         chat
+// @Accept       json
+// @Produce      json
+// @Produce      text/event-stream
+// @Param        vendor             query     string                  false  "Optional vendor to target (e.g., 'openai', 'gemini')"
+// @Param        anthropic-version  header    string                  false  "Anthropic API version, accepted for compatibility"
+// @Param        request            body      messages.Request        true   "Request in Anthropic Messages API format"
+// @Security     BearerAuth
+// @Success      200                {object}  messages.Response       "Anthropic-compatible message"
+// @Failure      400                {object}  messages.ErrorResponse  "Bad request error"
+// @Failure      500                {object}  messages.ErrorResponse  "Internal server error"
+// @Failure      502                {object}  messages.ErrorResponse  "Untranslatable vendor response"
+// @Router       /v1/messages [post]
+func (h *APIHandlers) MessagesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "MessagesHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	// Errors are written through the writer too, so clients get them in the Messages API shape
+	var req messages.Request
+	writer := messages.NewWriter(w, &req)
+	h.serveMessages(ctx, writer, r, &req)
+	if err := writer.Finish(); err != nil {
+		logger.Error(ctx, "Failed to translate chat completion into a message", err,
+			"model", req.Model,
+			"stream", req.Stream,
 		)
+		messages.WriteError(w, http.StatusBadGateway, "failed to translate the vendor response")
+	}
+}
 
-		creds = filter.CredentialsByVendor(creds, vendorFilter)
-		models = filter.ModelsByVendor(models, vendorFilter)
+// serveMessages translates a Messages API request and serves it as a chat completion
+func (h *APIHandlers) serveMessages(ctx context.Context, w http.ResponseWriter, r *http.Request, req *messages.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		logger.Error(ctx, "Failed to decode request", err)
+		validationErr := errors.NewValidationError("invalid request format")
+		errors.HandleError(w, validationErr, http.StatusBadRequest)
+		return
+	}
 
-		// Log complete filtering results
-		logger.Debug(ctx, "Vendor filtering completed",
-			"vendor_filter", vendorFilter,
-			"filtered_credentials_count", len(creds),
-			"filtered_models_count", len(models),
+	payload, err := messages.ChatRequest(req)
+	if err != nil {
+		errors.HandleError(w, errors.NewValidationError(err.Error()), http.StatusBadRequest)
+		return
+	}
+	bodyBytes, err := json.Marshal(payload)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal payload", err)
+		apiErr := errors.NewInternalError("failed to build request")
+		errors.HandleError(w, apiErr, http.StatusInternalServerError)
+		return
+	}
+
+	// Route the translated request exactly like a chat completion, uncompressed so it can be
+	// translated back, without the Anthropic headers meant for this router
+	newReq := r.Clone(r.Context())
+	newReq.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	newReq.ContentLength = int64(len(bodyBytes))
+	newReq.Header.Del(utils.HeaderAcceptEncoding)
+	newReq.Header.Del("Anthropic-Version")
+	newReq.Header.Del("Anthropic-Beta")
+	h.ChatCompletionsHandler(w, newReq)
+}
+
+// GenerateContentHandler handles Google's native generateContent and streamGenerateContent methods
+// @Summary      Gemini generateContent
+// @Description  Translates Gemini API generateContent requests into chat completions routed like /v1/chat/completions, and the result back into a GenerateContentResponse. streamGenerateContent streams response chunks as a JSON array or, with alt=sse, as server-sent events. Clients may authenticate with x-goog-api-key or the key query parameter instead of a Bearer token
+// @Tags         chat
+// @Accept       json
+// @Produce      json
+// @Produce      text/event-stream
+// @Param        target   path      string                true   "Model and method, e.g. gemini-2.5-flash:generateContent or gemini-2.5-flash:streamGenerateContent"
+// @Param        alt      query     string                false  "sse to stream server-sent events instead of a JSON array"
+// @Param        key      query     string                false  "API key, when not sent as a Bearer token or x-goog-api-key"
+// @Param        vendor   query     string                false  "Optional vendor to target (e.g., 'openai', 'gemini')"
+// @Param        request  body      gemini.Request        true   "Request in Gemini API generateContent format"
+// @Security     BearerAuth
+// @Success      200      {object}  gemini.Response       "Gemini-compatible response"
+// @Failure      400      {object}  gemini.ErrorResponse  "Bad request error"
+// @Failure      404      {object}  gemini.ErrorResponse  "Unknown method"
+// @Failure      500      {object}  gemini.ErrorResponse  "Internal server error"
+// @Failure      502      {object}  gemini.ErrorResponse  "Untranslatable vendor response"
+// @Router       /v1beta/models/{target} [post]
+// @Router       /v1/models/{target} [post]
+func (h *APIHandlers) GenerateContentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "GenerateContentHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	model, method := gemini.ParseTarget(r.PathValue("target"))
+	if method != gemini.MethodGenerateContent && method != gemini.MethodStreamGenerateContent {
+		gemini.WriteError(w, http.StatusNotFound, fmt.Sprintf("method %q is not supported, use generateContent or streamGenerateContent", method))
+		return
+	}
+	stream := method == gemini.MethodStreamGenerateContent
+
+	// Errors are written through the writer too, so clients get them in the Gemini API shape
+	writer := gemini.NewWriter(w, model, r.URL.Query().Get("alt") == "sse")
+	h.serveGenerateContent(ctx, writer, r, model, stream)
+	if err := writer.Finish(); err != nil {
+		logger.Error(ctx, "Failed to translate chat completion into a Gemini response", err,
+			"model", model,
+			"stream", stream,
 		)
+		gemini.WriteError(w, http.StatusBadGateway, "failed to translate the vendor response")
+	}
+}
 
-		// Check if we have credentials and models for this vendor
-		if len(creds) == 0 {
-			err := fmt.Errorf("no credentials available for vendor: %s", vendorFilter)
-			logger.Error(ctx, "Vendor filtering failed", err,
-				"vendor_filter", vendorFilter,
-			)
-			validationErr := errors.NewValidationError(err.Error())
-			errors.HandleError(w, validationErr, http.StatusBadRequest)
-			return
-		}
-		if len(models) == 0 {
-			err := fmt.Errorf("no models available for vendor: %s", vendorFilter)
-			logger.Error(ctx, "Vendor filtering failed", err,
-				"vendor_filter", vendorFilter,
-			)
-			validationErr := errors.NewValidationError(err.Error())
-			errors.HandleError(w, validationErr, http.StatusBadRequest)
-			return
-		}
+// serveGenerateContent translates a generateContent request and serves it as a chat completion
+func (h *APIHandlers) serveGenerateContent(ctx context.Context, w http.ResponseWriter, r *http.Request, model string, stream bool) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	var req gemini.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error(ctx, "Failed to decode request", err)
+		validationErr := errors.NewValidationError("invalid request format")
+		errors.HandleError(w, validationErr, http.StatusBadRequest)
+		return
 	}
 
-	proxy.ProxyRequest(w, r, creds, models, h.APIClient, h.ModelSelector)
+	payload, err := gemini.ChatRequest(model, &req, stream)
+	if err != nil {
+		errors.HandleError(w, errors.NewValidationError(err.Error()), http.StatusBadRequest)
+		return
+	}
+	bodyBytes, err := json.Marshal(payload)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal payload", err)
+		apiErr := errors.NewInternalError("failed to build request")
+		errors.HandleError(w, apiErr, http.StatusInternalServerError)
+		return
+	}
+
+	// Route the translated request exactly like a chat completion, uncompressed so it can be
+	// translated back. Google's REST clients may pass their key as the key query parameter,
+	// which authenticates like a Bearer token
+	newReq := r.Clone(r.Context())
+	newReq.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+	newReq.ContentLength = int64(len(bodyBytes))
+	newReq.Header.Del(utils.HeaderAcceptEncoding)
+	query := newReq.URL.Query()
+	if key := strings.TrimSpace(query.Get("key")); key != "" && newReq.Header.Get(utils.HeaderAuthorization) == "" {
+		newReq.Header.Set(utils.HeaderAuthorization, "Bearer "+key)
+	}
+	query.Del("key")
+	query.Del("alt")
+	newReq.URL.RawQuery = query.Encode()
+	h.ChatCompletionsHandler(w, newReq)
 }
 
 // ModelsHandler handles the models endpoint
@@ -266,20 +550,28 @@ func (h *APIHandlers) ChatCompletionsHandler(w http.ResponseWriter, r *http.Requ
 // @Tags         models
 // @Accept       json
 // @Produce      json
-// @Param        vendor  query     string         false  "Optional vendor to filter models (e.g., 'openai', 'gemini')"
-// @Success      200     {object}  types.ModelsResponse "List of available models"
+// @Param        vendor              query     string         false  "Optional vendor to filter models (e.g., 'openai', 'gemini')"
+// @Param        capability          query     string         false  "Required capabilities, comma-separated or repeated (vision, video, tools, streaming)"
+// @Param        supports_tools      query     bool           false  "Only models whose tool support matches"
+// @Param        supports_streaming  query     bool           false  "Only models whose streaming support matches"
+// @Param        min_context         query     int            false  "Only models with at least this many context tokens"
+// @Success      200                 {object}  types.ModelsResponse "List of available models"
+// @Failure      400                 {object}  types.ErrorResponse  "Invalid filter"
 // @Router       /v1/models [get]
+// @Router       /v1/models [head]
 func (h *APIHandlers) ModelsHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := logger.WithComponent(r.Context(), "ModelsHandler")
 	ctx = logger.WithStage(ctx, "Request")
 
-	w.Header().Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	if !allowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
 
 	var response types.ModelsResponse
 
 	// Optional vendor filter via query parameter
 	vendorFilter := r.URL.Query().Get("vendor")
-	models := h.VendorModels
+	models := h.Config.Snapshot().Models()
 	if vendorFilter != "" {
 		// Log complete models filtering operation
 		logger.Debug(ctx, "Filtering models by vendor",
@@ -289,9 +581,24 @@ func (h *APIHandlers) ModelsHandler(w http.ResponseWriter, r *http.Request) {
 		models = filter.ModelsByVendor(models, vendorFilter)
 	}
 
+	criteria, err := parseModelCriteria(r.URL.Query())
+	if err != nil {
+		errors.HandleError(w, errors.NewAPIError(errors.ErrorTypeInvalidRequest, err.Error()), http.StatusBadRequest)
+		return
+	}
+	if !criteria.IsEmpty() {
+		logger.Debug(ctx, "Filtering models by capability",
+			"capabilities", criteria.Capabilities,
+			"min_context", criteria.MinContext,
+			"original_models_count", len(models),
+		)
+		models = filter.ModelsByCriteria(models, criteria)
+	}
+
 	response.Object = "list"
 	timestamp := time.Now().Unix() // or a fixed timestamp if preferred
 
+	response.Data = make([]types.Model, 0, len(models))
 	for _, vm := range models {
 		model := types.Model{
 			ID:      vm.Model,
@@ -302,6 +609,20 @@ func (h *APIHandlers) ModelsHandler(w http.ResponseWriter, r *http.Request) {
 		response.Data = append(response.Data, model)
 	}
 
+	// Aliases are listed when a model they route to is
+	for _, name := range alias.Default().Names() {
+		if _, pool, _ := alias.Default().Filter(name, nil, models); len(pool) > 0 {
+			members, _ := alias.Default().Members(name)
+			response.Data = append(response.Data, types.Model{
+				ID:      name,
+				Object:  "model",
+				Created: timestamp,
+				OwnedBy: "alias",
+				Models:  members,
+			})
+		}
+	}
+
 	// Log complete models response generation
 	logger.Debug(ctx, "Models list generated",
 		"vendor_filter", vendorFilter,
@@ -320,14 +641,137 @@ func (h *APIHandlers) ModelsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write(jsonResp); err != nil {
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
 		logger.Error(ctx, "Failed to write models response", err,
 			"response_size", len(jsonResp),
 		)
 	}
 }
 
+// ModelHandler handles the model detail endpoint
+// @Summary      Retrieve a model
+// @Description  Returns a configured model with its type, capabilities, context window and pricing, so clients can introspect routing targets. The ID may be given as "vendor/model" to pick one of several vendors serving a model
+// @Tags         models
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string             true   "Model ID, or vendor/model"
+// @Param        vendor  query     string             false  "Vendor serving the model, when several do"
+// @Success      200     {object}  types.ModelDetail  "Model details"
+// @Failure      404     {object}  types.ErrorResponse "Model not found"
+// @Router       /v1/models/{id} [get]
+// @Router       /v1/models/{id} [head]
+func (h *APIHandlers) ModelHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "ModelHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet, http.MethodHead) {
+		return
+	}
+
+	id := r.PathValue("id")
+	vendorFilter := r.URL.Query().Get("vendor")
+	var matches []config.VendorModel
+	for _, model := range h.Config.Snapshot().Models() {
+		if access.MatchesModel(id, model.Vendor, model.Model) && (vendorFilter == "" || model.Vendor == vendorFilter) {
+			matches = append(matches, model)
+		}
+	}
+	if len(matches) == 0 {
+		apiErr := errors.NewAPIErrorWithCode(errors.ErrorTypeNotFound, fmt.Sprintf("The model '%s' does not exist", id), "model_not_found")
+		errors.HandleError(w, apiErr, http.StatusNotFound)
+		return
+	}
+
+	writeResourceJSON(ctx, w, r, modelDetail(matches, time.Now().Unix()))
+}
+
+// modelDetail describes the first of the configured models sharing an ID, listing the
+// vendors of all of them
+func modelDetail(models []config.VendorModel, created int64) types.ModelDetail {
+	model := models[0]
+	detail := types.ModelDetail{
+		Model: types.Model{
+			ID:      model.Model,
+			Object:  "model",
+			Created: created,
+			OwnedBy: model.Vendor,
+		},
+		Type: model.Type,
+	}
+	if detail.Type == "" {
+		detail.Type = config.ModelTypeChat
+	}
+	// Models without a capability config are routed as if they supported everything
+	detail.Capabilities = types.ModelCapabilities{Vision: true, Video: true, Audio: true, Tools: true, Streaming: true}
+	if model.Config != nil {
+		detail.Capabilities = types.ModelCapabilities{
+			Vision:    model.Config.SupportImage,
+			Video:     model.Config.SupportVideo,
+			Audio:     model.Config.SupportAudio,
+			Tools:     model.Config.SupportTools,
+			Streaming: model.Config.SupportStreaming,
+			Declared:  true,
+		}
+		detail.ContextWindow = model.Config.ContextWindow
+	}
+	if model.Pricing != nil {
+		detail.Pricing = &types.ModelPricing{
+			InputPerMillion:  model.Pricing.InputPerMillion,
+			OutputPerMillion: model.Pricing.OutputPerMillion,
+		}
+	}
+	for _, vm := range models {
+		if !slices.Contains(detail.Vendors, vm.Vendor) {
+			detail.Vendors = append(detail.Vendors, vm.Vendor)
+		}
+	}
+	return detail
+}
+
+// parseModelCriteria reads the capability filters of the models endpoint from the query string
+func parseModelCriteria(query url.Values) (filter.ModelCriteria, error) {
+	var criteria filter.ModelCriteria
+
+	for _, value := range query["capability"] {
+		for _, capability := range strings.Split(value, ",") {
+			capability = strings.ToLower(strings.TrimSpace(capability))
+			switch capability {
+			case "":
+				continue
+			case "image", "images":
+				capability = filter.CapabilityVision
+			case filter.CapabilityVision, filter.CapabilityVideo, filter.CapabilityAudio, filter.CapabilityTools, filter.CapabilityStreaming:
+			default:
+				return criteria, fmt.Errorf("unknown capability %q, expected one of vision, video, audio, tools, streaming", capability)
+			}
+			criteria.Capabilities = append(criteria.Capabilities, capability)
+		}
+	}
+
+	for name, target := range map[string]**bool{
+		"supports_tools":     &criteria.SupportsTools,
+		"supports_streaming": &criteria.SupportsStreaming,
+	} {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return criteria, fmt.Errorf("%s must be true or false", name)
+			}
+			*target = &parsed
+		}
+	}
+
+	if value := query.Get("min_context"); value != "" {
+		minContext, err := strconv.Atoi(value)
+		if err != nil || minContext < 0 {
+			return criteria, fmt.Errorf("min_context must be a non-negative integer")
+		}
+		criteria.MinContext = minContext
+	}
+
+	return criteria, nil
+}
+
 // ImageToTextHandler handles the image description endpoint
 // @Summary      Describe image
 // @Description  Generates a detailed text description of a single image
@@ -345,8 +789,7 @@ func (h *APIHandlers) ImageToTextHandler(w http.ResponseWriter, r *http.Request)
 	ctx := logger.WithComponent(r.Context(), "ImageToTextHandler")
 	ctx = logger.WithStage(ctx, "Request")
 
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if !allowMethods(w, r, http.MethodPost) {
 		return
 	}
 
@@ -399,19 +842,258 @@ func (h *APIHandlers) ImageToTextHandler(w http.ResponseWriter, r *http.Request)
 	newReq.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 	newReq.ContentLength = int64(len(bodyBytes))
 
-	vendorFilter := r.URL.Query().Get("vendor")
-	creds := h.Credentials
-	models := h.VendorModels
+	creds, models, ok := buildRoutingPool(ctx, w, r, h.Config.Snapshot(), config.ModelTypeChat)
+	if !ok {
+		return
+	}
+
+	proxy.ProxyRequest(w, newReq, creds, models, h.APIClient, h.ModelSelector)
+}
+
+// EmbeddingsHandler handles the embeddings endpoint
+// @Summary      Embeddings API
+// @Description  Routes embeddings requests to the configured embedding models of providers with an embeddings API
+// @Tags         embeddings
+// @Accept       json
+// @Produce      json
+// @Param        vendor  query     string                    false  "Optional vendor to target (e.g., 'openai', 'gemini')"
+// @Param        request body      types.EmbeddingsRequest   true   "Embeddings request in OpenAI-compatible format"
+// @Security     BearerAuth
+// @Success      200  {object}  types.EmbeddingsResponse "OpenAI-compatible embeddings response"
+// @Failure      400  {object}  types.ErrorResponse      "Bad request error"
+// @Failure      500  {object}  types.ErrorResponse      "Internal server error"
+// @Router       /v1/embeddings [post]
+func (h *APIHandlers) EmbeddingsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+
+	creds, models, ok := buildRoutingPool(r.Context(), w, r, h.Config.Snapshot(), config.ModelTypeEmbedding)
+	if !ok {
+		return
+	}
+
+	proxy.ProxyEmbeddingsRequest(w, r, creds, models, h.APIClient, h.ModelSelector)
+}
+
+// TranscriptionsHandler handles the audio transcriptions endpoint
+// @Summary      Audio transcriptions API
+// @Description  Routes Whisper-compatible transcription requests to the configured transcription models of providers with a speech-to-text API
+// @Description  The audio is uploaded as the file part or fetched from file_url; with stream=true partial transcripts are relayed as server-sent events when the vendor streams them
+// @Tags         audio
+// @Accept       multipart/form-data
+// @Produce      json
+// @Produce      plain
+// @Produce      text/event-stream
+// @Param        vendor           query     string  false  "Optional vendor to target (e.g., 'openai', 'groq')"
+// @Param        file             formData  file    false  "Audio file to transcribe (flac, mp3, mp4, mpeg, mpga, m4a, ogg, wav or webm)"
+// @Param        file_url         formData  string  false  "URL to download the audio from when no file is uploaded"
+// @Param        model            formData  string  false  "Model to use"
+// @Param        language         formData  string  false  "Language of the audio in ISO-639-1 format"
+// @Param        prompt           formData  string  false  "Text to guide the model's style"
+// @Param        response_format  formData  string  false  "json, text, srt, verbose_json or vtt"
+// @Param        temperature      formData  number  false  "Sampling temperature between 0 and 1"
+// @Param        stream           formData  boolean false  "Stream the transcript as server-sent events"
+// @Security     BearerAuth
+// @Success      200  {object}  types.TranscriptionResponse "OpenAI-compatible transcription response"
+// @Failure      400  {object}  types.ErrorResponse         "Bad request error"
+// @Failure      413  {object}  types.ErrorResponse         "Audio too large"
+// @Failure      500  {object}  types.ErrorResponse         "Internal server error"
+// @Router       /v1/audio/transcriptions [post]
+func (h *APIHandlers) TranscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+
+	creds, models, ok := buildRoutingPool(r.Context(), w, r, h.Config.Snapshot(), config.ModelTypeTranscription)
+	if !ok {
+		return
+	}
+
+	proxy.ProxyTranscriptionRequest(w, r, creds, models, h.APIClient, h.ModelSelector)
+}
+
+// SpeechHandler handles the text-to-speech endpoint
+// @Summary      Text-to-speech API
+// @Description  Routes speech requests to the configured speech models of providers with a text-to-speech API and streams the audio back
+// @Description  Voices are translated through the selected model's voice map and formats to the vendor's name for them; models whose vendor cannot produce the requested format are skipped
+// @Tags         audio
+// @Accept       json
+// @Produce      audio/mpeg
+// @Produce      audio/ogg
+// @Produce      audio/aac
+// @Produce      audio/flac
+// @Produce      audio/wav
+// @Produce      audio/pcm
+// @Param        vendor  query     string               false  "Optional vendor to target (e.g., 'openai', 'groq')"
+// @Param        request body      types.SpeechRequest  true   "Speech request in OpenAI-compatible format"
+// @Security     BearerAuth
+// @Success      200  {file}    binary               "Audio in the requested format"
+// @Failure      400  {object}  types.ErrorResponse  "Bad request error"
+// @Failure      500  {object}  types.ErrorResponse  "Internal server error"
+// @Router       /v1/audio/speech [post]
+func (h *APIHandlers) SpeechHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+
+	creds, models, ok := buildRoutingPool(r.Context(), w, r, h.Config.Snapshot(), config.ModelTypeSpeech)
+	if !ok {
+		return
+	}
+
+	proxy.ProxySpeechRequest(w, r, creds, models, h.APIClient, h.ModelSelector)
+}
+
+// ModerationsHandler handles the moderations endpoint
+// @Summary      Moderations API
+// @Description  Routes moderation requests to the configured moderation models of providers with a moderation API and normalizes the categories to OpenAI's
+// @Description  When the selected vendor fails for any reason other than rejecting the input, the request falls back to a moderation model of another vendor
+// @Tags         moderations
+// @Accept       json
+// @Produce      json
+// @Param        vendor  query     string                    false  "Optional vendor to target (e.g., 'openai', 'mistral')"
+// @Param        request body      types.ModerationRequest   true   "Moderation request in OpenAI-compatible format"
+// @Security     BearerAuth
+// @Success      200  {object}  types.ModerationResponse "OpenAI-compatible moderation response"
+// @Failure      400  {object}  types.ErrorResponse      "Bad request error"
+// @Failure      500  {object}  types.ErrorResponse      "Internal server error"
+// @Router       /v1/moderations [post]
+func (h *APIHandlers) ModerationsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+
+	creds, models, ok := buildRoutingPool(r.Context(), w, r, h.Config.Snapshot(), config.ModelTypeModeration)
+	if !ok {
+		return
+	}
+
+	proxy.ProxyModerationRequest(w, r, creds, models, h.APIClient, h.ModelSelector)
+}
+
+// RerankHandler handles the rerank endpoint
+// @Summary      Rerank API
+// @Description  Routes rerank requests to the configured rerank models of providers with a rerank API (Cohere, Voyage AI, Jina AI) and normalizes the results to one shape, sorted by relevance
+// @Description  When the selected vendor fails for any reason other than rejecting the input, the request falls back to a rerank model of another vendor
+// @Tags         rerank
+// @Accept       json
+// @Produce      json
+// @Param        vendor  query     string               false  "Optional vendor to target (e.g., 'cohere', 'voyage', 'jina')"
+// @Param        request body      types.RerankRequest  true   "Rerank request"
+// @Security     BearerAuth
+// @Success      200  {object}  types.RerankResponse "Documents ranked by relevance to the query"
+// @Failure      400  {object}  types.ErrorResponse  "Bad request error"
+// @Failure      500  {object}  types.ErrorResponse  "Internal server error"
+// @Router       /v1/rerank [post]
+func (h *APIHandlers) RerankHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+
+	creds, models, ok := buildRoutingPool(r.Context(), w, r, h.Config.Snapshot(), config.ModelTypeRerank)
+	if !ok {
+		return
+	}
+
+	proxy.ProxyRerankRequest(w, r, creds, models, h.APIClient, h.ModelSelector)
+}
+
+// buildRoutingPool returns the credentials and models a request for modelType may be routed
+// to: the routing pool narrowed to the vendor forced with ?vendor=, to what the routing
+// headers pin, and to the current share of models on a rollout trial. It answers the request
+// and returns false when the client key may not force the routing or nothing matches
+func buildRoutingPool(ctx context.Context, w http.ResponseWriter, r *http.Request, snapshot *config.Snapshot, modelType string) ([]config.Credential, []config.VendorModel, bool) {
+	vendorFilter, ok := forcedVendor(ctx, w, r)
+	if !ok {
+		return nil, nil, false
+	}
+
+	creds, models := routingPool(snapshot, modelType)
 	if vendorFilter != "" {
 		creds = filter.CredentialsByVendor(creds, vendorFilter)
 		models = filter.ModelsByVendor(models, vendorFilter)
+		logger.Debug(ctx, "Vendor filtering completed",
+			"vendor_filter", vendorFilter,
+			"model_type", modelType,
+			"filtered_credentials_count", len(creds),
+			"filtered_models_count", len(models),
+		)
 
 		if len(creds) == 0 || len(models) == 0 {
-			validationErr := errors.NewValidationError("no credentials or models for vendor")
-			errors.HandleError(w, validationErr, http.StatusBadRequest)
-			return
+			kind := "models"
+			if modelType != config.ModelTypeChat {
+				kind = modelType + " models"
+			}
+			err := fmt.Errorf("no credentials or %s available for vendor: %s", kind, vendorFilter)
+			logger.Info(ctx, "Vendor filtering left nothing to route to",
+				"vendor_filter", vendorFilter,
+				"model_type", modelType,
+			)
+			errors.HandleError(w, errors.NewValidationError(err.Error()), http.StatusBadRequest)
+			return nil, nil, false
 		}
 	}
 
-	proxy.ProxyRequest(w, newReq, creds, models, h.APIClient, h.ModelSelector)
+	// Routing headers pin or prefer a vendor, model or credential for this request
+	creds, models, ok = pinnedPool(ctx, w, r, creds, models)
+	if !ok {
+		return nil, nil, false
+	}
+
+	// Models on a rollout trial only take their current share of requests
+	creds, models = rollout.Default().Filter(creds, models)
+	return creds, models, true
+}
+
+// forcedVendor returns the vendor a request forces routing to with ?vendor=, or "", answering
+// 403 when the client key may not force routing to it
+func forcedVendor(ctx context.Context, w http.ResponseWriter, r *http.Request) (string, bool) {
+	vendor, err := proxy.AuthorizeVendorQuery(ctx, r)
+	if err != nil {
+		errors.HandleError(w, errors.NewAuthorizationError(err.Error()), http.StatusForbidden)
+		return "", false
+	}
+	return vendor, true
+}
+
+// pinnedPool applies a request's routing headers to the pool, answering 403 when the client
+// key may not send them and 400 when no available route matches what they force
+func pinnedPool(ctx context.Context, w http.ResponseWriter, r *http.Request, creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel, bool) {
+	pins, err := proxy.AuthorizeRoutingPins(ctx, r)
+	if err != nil {
+		errors.HandleError(w, errors.NewAuthorizationError(err.Error()), http.StatusForbidden)
+		return nil, nil, false
+	}
+	creds, models, err = pins.Apply(access.Default().PolicyFor(r), creds, models)
+	if err != nil {
+		errors.HandleError(w, errors.NewValidationError(err.Error()), http.StatusBadRequest)
+		return nil, nil, false
+	}
+	return creds, models, true
+}
+
+// routingPool returns the credentials and models of one model type that requests can be
+// routed to right now
+func routingPool(snapshot *config.Snapshot, modelType string) ([]config.Credential, []config.VendorModel) {
+	models := filter.ModelsByType(snapshot.Models(), modelType)
+	creds := filter.CredentialsForModels(snapshot.Credentials(), models)
+	// Schedule rules whose time window is open restrict the pool to the vendors and models they name
+	creds, models = schedule.Default().Filter(creds, models)
+	// Models quarantined by the canary job are left out of the pool when quarantine is enabled
+	creds, models = canary.Default().Filter(creds, models)
+	// Vendors failing their health probes are left out until a probe succeeds
+	creds, models = vendorhealth.Default().Filter(creds, models)
+	// Credentials quarantined after repeated 401/403/429 responses are left out until a probe succeeds
+	creds, models = quarantine.Default().Filter(creds, models)
+	// Credentials a vendor reported as rate limited are avoided until their limits reset
+	creds, models = ratelimit.Default().Filter(creds, models)
+	// Credentials at their concurrency cap are avoided until a request on them finishes
+	creds, models = ratelimit.DefaultInFlight().Filter(creds, models)
+	// Chat completions avoid vendors at their concurrency limit while others have room
+	if modelType == config.ModelTypeChat {
+		creds, models = admission.DefaultLimiter().Filter(creds, models)
+	}
+	// Vendors that reached a cost ceiling are left out until their budget window resets
+	return budget.Default().Filter(creds, models)
 }
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/alias"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHandlers() *APIHandlers {
+	creds := []config.Credential{{Platform: "openai", Type: "api-key", Value: "sk-test"}}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o"},
+		{Vendor: "gemini", Model: "gemini-pro"},
+	}
+	store := config.NewStore(config.NewSnapshot(config.Data{Credentials: creds, Models: models}))
+	return NewAPIHandlers(store, nil, nil)
+}
+
+func TestModelsHandler_ContentLengthAndHead(t *testing.T) {
+	h := newTestHandlers()
+
+	getRec := httptest.NewRecorder()
+	h.ModelsHandler(getRec, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	require.Equal(t, http.StatusOK, getRec.Code)
+	assert.Equal(t, strconv.Itoa(getRec.Body.Len()), getRec.Header().Get("Content-Length"))
+
+	headRec := httptest.NewRecorder()
+	h.ModelsHandler(headRec, httptest.NewRequest(http.MethodHead, "/v1/models", nil))
+	assert.Equal(t, http.StatusOK, headRec.Code)
+	assert.Equal(t, 0, headRec.Body.Len())
+	assert.NotEmpty(t, headRec.Header().Get("Content-Length"))
+}
+
+func TestModelsHandler_CapabilityFilters(t *testing.T) {
+	h := newTestHandlers()
+	h.Config.Swap(config.NewSnapshot(config.Data{Models: []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o", Config: &config.ModelConfig{SupportImage: true, SupportTools: true, SupportStreaming: true, ContextWindow: 128000}},
+		{Vendor: "openai", Model: "gpt-3.5-turbo", Config: &config.ModelConfig{SupportTools: true, SupportStreaming: true, ContextWindow: 16000}},
+		{Vendor: "gemini", Model: "gemini-pro", Config: &config.ModelConfig{SupportImage: true, SupportVideo: true, SupportStreaming: true, ContextWindow: 1000000}},
+		{Vendor: "gemini", Model: "gemini-legacy"},
+	}}))
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"no filters", "", []string{"gpt-4o", "gpt-3.5-turbo", "gemini-pro", "gemini-legacy"}},
+		{"vision", "?capability=vision", []string{"gpt-4o", "gemini-pro", "gemini-legacy"}},
+		{"multiple capabilities", "?capability=vision,video", []string{"gemini-pro", "gemini-legacy"}},
+		{"supports tools false", "?supports_tools=false", []string{"gemini-pro"}},
+		{"min context excludes unknown", "?min_context=100000", []string{"gpt-4o", "gemini-pro"}},
+		{"combined with vendor", "?vendor=openai&capability=image&min_context=100000", []string{"gpt-4o"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ModelsHandler(rec, httptest.NewRequest(http.MethodGet, "/v1/models"+tt.query, nil))
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var response types.ModelsResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			ids := make([]string, 0, len(response.Data))
+			for _, model := range response.Data {
+				ids = append(ids, model.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestModelsHandler_Aliases(t *testing.T) {
+	h := newTestHandlers()
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o"},
+		{Vendor: "gemini", Model: "gemini-flash"},
+	}
+	h.Config.Swap(config.NewSnapshot(config.Data{Models: models}))
+	require.NoError(t, alias.Default().Configure(map[string][]string{"smart": {"openai/gpt-4o"}, "fast": {"gemini-flash"}}, models))
+	t.Cleanup(func() { require.NoError(t, alias.Default().Configure(nil, nil)) })
+
+	rec := httptest.NewRecorder()
+	h.ModelsHandler(rec, httptest.NewRequest(http.MethodGet, "/v1/models?vendor=openai", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response types.ModelsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(t, response.Data, 2, "aliases without a listed model are left out")
+	assert.Equal(t, "gpt-4o", response.Data[0].ID)
+	assert.Equal(t, "smart", response.Data[1].ID)
+	assert.Equal(t, "alias", response.Data[1].OwnedBy)
+	assert.Equal(t, []string{"openai/gpt-4o"}, response.Data[1].Models)
+}
+
+func TestModelHandler(t *testing.T) {
+	h := newTestHandlers()
+	h.Config.Swap(config.NewSnapshot(config.Data{Models: []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o", Config: &config.ModelConfig{SupportImage: true, SupportTools: true, SupportStreaming: true, ContextWindow: 128000},
+			Pricing: &config.ModelPricing{InputPerMillion: 2.5, OutputPerMillion: 10}},
+		{Vendor: "azure", Model: "gpt-4o", BaseURL: "https://example.openai.azure.com/v1"},
+		{Vendor: "openrouter", Model: "meta-llama/llama-3", BaseURL: "https://openrouter.ai/api/v1"},
+		{Vendor: "openai", Model: "text-embedding-3-small", Type: config.ModelTypeEmbedding},
+	}}))
+
+	tests := []struct {
+		name     string
+		target   string
+		status   int
+		expected types.ModelDetail
+	}{
+		{
+			name:   "model served by several vendors",
+			target: "/v1/models/gpt-4o",
+			status: http.StatusOK,
+			expected: types.ModelDetail{
+				Model:         types.Model{ID: "gpt-4o", Object: "model", OwnedBy: "openai"},
+				Type:          config.ModelTypeChat,
+				Capabilities:  types.ModelCapabilities{Vision: true, Tools: true, Streaming: true, Declared: true},
+				ContextWindow: 128000,
+				Pricing:       &types.ModelPricing{InputPerMillion: 2.5, OutputPerMillion: 10},
+				Vendors:       []string{"openai", "azure"},
+			},
+		},
+		{
+			name:   "vendor/model",
+			target: "/v1/models/azure/gpt-4o",
+			status: http.StatusOK,
+			expected: types.ModelDetail{
+				Model:        types.Model{ID: "gpt-4o", Object: "model", OwnedBy: "azure"},
+				Type:         config.ModelTypeChat,
+				Capabilities: types.ModelCapabilities{Vision: true, Video: true, Audio: true, Tools: true, Streaming: true},
+				Vendors:      []string{"azure"},
+			},
+		},
+		{
+			name:   "model ID with a slash",
+			target: "/v1/models/meta-llama/llama-3",
+			status: http.StatusOK,
+			expected: types.ModelDetail{
+				Model:        types.Model{ID: "meta-llama/llama-3", Object: "model", OwnedBy: "openrouter"},
+				Type:         config.ModelTypeChat,
+				Capabilities: types.ModelCapabilities{Vision: true, Video: true, Audio: true, Tools: true, Streaming: true},
+				Vendors:      []string{"openrouter"},
+			},
+		},
+		{
+			name:   "embedding model",
+			target: "/v1/models/text-embedding-3-small?vendor=openai",
+			status: http.StatusOK,
+			expected: types.ModelDetail{
+				Model:        types.Model{ID: "text-embedding-3-small", Object: "model", OwnedBy: "openai"},
+				Type:         config.ModelTypeEmbedding,
+				Capabilities: types.ModelCapabilities{Vision: true, Video: true, Audio: true, Tools: true, Streaming: true},
+				Vendors:      []string{"openai"},
+			},
+		},
+		{name: "unknown model", target: "/v1/models/gpt-5", status: http.StatusNotFound},
+		{name: "vendor not serving the model", target: "/v1/models/gpt-4o?vendor=gemini", status: http.StatusNotFound},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/models/{id...}", h.ModelHandler)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			require.Equal(t, tt.status, rec.Code, rec.Body.String())
+			if tt.status != http.StatusOK {
+				assert.Contains(t, rec.Body.String(), "model_not_found")
+				return
+			}
+
+			var detail types.ModelDetail
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &detail))
+			assert.NotZero(t, detail.Created)
+			detail.Created = 0
+			assert.Equal(t, tt.expected, detail)
+		})
+	}
+}
+
+func TestModelsHandler_InvalidFilters(t *testing.T) {
+	h := newTestHandlers()
+
+	for _, query := range []string{"?capability=telepathy", "?supports_tools=maybe", "?min_context=-1", "?min_context=lots"} {
+		t.Run(query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ModelsHandler(rec, httptest.NewRequest(http.MethodGet, "/v1/models"+query, nil))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestModelsHandler_RejectsPost(t *testing.T) {
+	h := newTestHandlers()
+
+	rec := httptest.NewRecorder()
+	h.ModelsHandler(rec, httptest.NewRequest(http.MethodPost, "/v1/models", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+}
+
+func TestChatCompletionsHandler_RejectsHead(t *testing.T) {
+	h := newTestHandlers()
+
+	rec := httptest.NewRecorder()
+	h.ChatCompletionsHandler(rec, httptest.NewRequest(http.MethodHead, "/v1/chat/completions", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, "POST", rec.Header().Get("Allow"))
+	assert.Equal(t, 0, rec.Body.Len())
+}
+
+func TestChatCompletionsHandler_VendorQueryNeedsPermission(t *testing.T) {
+	h := newTestHandlers()
+	access.SetDefault(access.NewACL(access.Policy{DenyVendorQuery: true}, nil))
+	t.Cleanup(func() { access.SetDefault(access.NewACL(access.Policy{}, nil)) })
+
+	rec := httptest.NewRecorder()
+	h.ChatCompletionsHandler(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions?vendor=openai",
+		strings.NewReader(`{"messages":[{"role":"user","content":"Hi"}]}`)))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "vendor query parameter is not permitted")
+}
+
+func TestResponsesHandler_RejectsUntranslatableRequests(t *testing.T) {
+	h := newTestHandlers()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed JSON", `{"model":`},
+		{"built-in tool", `{"model": "gpt-4o", "input": "Hi", "tools": [{"type": "web_search"}]}`},
+		{"previous response", `{"model": "gpt-4o", "input": "Hi", "previous_response_id": "resp_1"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ResponsesHandler(rec, httptest.NewRequest(http.MethodPost, "/v1/responses", strings.NewReader(tt.body)))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestMessagesHandler_RejectsUntranslatableRequests(t *testing.T) {
+	h := newTestHandlers()
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{"malformed JSON", http.MethodPost, `{"model":`, http.StatusBadRequest},
+		{"missing max_tokens", http.MethodPost, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "Hi"}]}`, http.StatusBadRequest},
+		{"server tool", http.MethodPost, `{"model": "gpt-4o", "max_tokens": 8, "messages": [{"role": "user", "content": "Hi"}], "tools": [{"type": "bash_20250124", "name": "bash"}]}`, http.StatusBadRequest},
+		{"wrong method", http.MethodGet, ``, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.MessagesHandler(rec, httptest.NewRequest(tt.method, "/v1/messages", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.status, rec.Code)
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, "error", body["type"], "errors use the Messages API shape")
+		})
+	}
+}
+
+func TestGenerateContentHandler_RejectsUntranslatableRequests(t *testing.T) {
+	h := newTestHandlers()
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{"malformed JSON", http.MethodPost, "gemini-2.5-flash:generateContent", `{"contents":`, http.StatusBadRequest},
+		{"no contents", http.MethodPost, "gemini-2.5-flash:streamGenerateContent", `{"contents": []}`, http.StatusBadRequest},
+		{"built-in tool", http.MethodPost, "gemini-2.5-flash:generateContent", `{"contents": [{"parts": [{"text": "Hi"}]}], "tools": [{"codeExecution": {}}]}`, http.StatusBadRequest},
+		{"unknown method", http.MethodPost, "gemini-2.5-flash:countTokens", `{}`, http.StatusNotFound},
+		{"wrong method", http.MethodGet, "gemini-2.5-flash:generateContent", ``, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, "/v1beta/models/"+tt.target, strings.NewReader(tt.body))
+			req.SetPathValue("target", tt.target)
+			h.GenerateContentHandler(rec, req)
+			assert.Equal(t, tt.status, rec.Code)
+			var body struct {
+				Error struct {
+					Code   int    `json:"code"`
+					Status string `json:"status"`
+				} `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tt.status, body.Error.Code, "errors use the Gemini API shape")
+			assert.NotEmpty(t, body.Error.Status)
+		})
+	}
+}
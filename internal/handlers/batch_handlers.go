@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/batch"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+)
+
+// defaultBatchListLimit is the number of batches listed when no limit is given
+const defaultBatchListLimit = 20
+
+// BatchListResponse represents the response of the list batches endpoint
+type BatchListResponse struct {
+	Object  string         `json:"object"`
+	Data    []*batch.Batch `json:"data"`
+	FirstID *string        `json:"first_id"`
+	LastID  *string        `json:"last_id"`
+	HasMore bool           `json:"has_more"`
+}
+
+// batchManager returns the batch manager, answering 503 when batches are not set up
+func batchManager(w http.ResponseWriter) (*batch.Manager, bool) {
+	manager := batch.Default()
+	if manager == nil {
+		errors.HandleError(w, errors.NewAPIError(errors.ErrorTypeUnavailable, "Batches are not available"), http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return manager, true
+}
+
+// BatchesHandler creates or lists batches
+// @Summary      Create or list batches
+// @Description  POST creates a batch running the requests of an uploaded input file against the routed vendors; GET lists the batches of the client key, newest first
+// @Tags         batches
+// @Accept       json
+// @Produce      json
+// @Param        request  body   batch.CreateRequest  false  "Batch to create (POST only); endpoint must be /v1/chat/completions and completion_window 24h"
+// @Param        limit    query  int                  false  "Maximum number of batches to list (default 20)"
+// @Param        after    query  string               false  "List batches created before this batch ID"
+// @Security     BearerAuth
+// @Success      200  {object}  batch.Batch          "Created batch (POST); GET returns a handlers.BatchListResponse"
+// @Failure      400  {object}  types.ErrorResponse  "Bad request error"
+// @Router       /v1/batches [post]
+// @Router       /v1/batches [get]
+func (h *APIHandlers) BatchesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "BatchesHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+	manager, ok := batchManager(w)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		response, err := listBatches(manager, r)
+		if err != nil {
+			errors.HandleError(w, errors.NewValidationError(err.Error()), http.StatusBadRequest)
+			return
+		}
+		writeResourceJSON(ctx, w, r, response)
+		return
+	}
+
+	var request batch.CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errors.HandleError(w, errors.NewValidationError("invalid request format"), http.StatusBadRequest)
+		return
+	}
+	created, err := manager.Create(access.ClientKey(r), request)
+	if err != nil {
+		if stderrors.Is(err, batch.ErrInvalidBatch) {
+			errors.HandleError(w, errors.NewValidationError(err.Error()), http.StatusBadRequest)
+			return
+		}
+		logger.Error(ctx, "Failed to create batch", err,
+			"input_file_id", request.InputFileID,
+		)
+		errors.HandleError(w, errors.NewInternalError("Failed to create batch"), http.StatusInternalServerError)
+		return
+	}
+	logger.Info(ctx, "Batch created",
+		"batch_id", created.ID,
+		"status", created.Status,
+		"total_requests", created.RequestCounts.Total,
+	)
+	writeResourceJSON(ctx, w, r, created.Response())
+}
+
+// BatchHandler returns a batch
+// @Summary      Retrieve a batch
+// @Description  Returns the status, request counts and output and error file IDs of a batch
+// @Tags         batches
+// @Produce      json
+// @Param        id  path  string  true  "Batch ID"
+// @Security     BearerAuth
+// @Success      200  {object}  batch.Batch          "Batch"
+// @Failure      404  {object}  types.ErrorResponse  "Batch not found"
+// @Router       /v1/batches/{id} [get]
+func (h *APIHandlers) BatchHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "BatchHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	manager, ok := batchManager(w)
+	if !ok {
+		return
+	}
+	found, err := manager.Store.Batch(clientOwner(r), r.PathValue("id"))
+	if err != nil {
+		errors.HandleError(w, errors.NewNotFoundError("No such batch: "+r.PathValue("id")), http.StatusNotFound)
+		return
+	}
+	writeResourceJSON(ctx, w, r, found.Response())
+}
+
+// CancelBatchHandler cancels a batch
+// @Summary      Cancel a batch
+// @Description  Stops a running batch; requests already sent finish and the results so far are written to its output and error files
+// @Tags         batches
+// @Produce      json
+// @Param        id  path  string  true  "Batch ID"
+// @Security     BearerAuth
+// @Success      200  {object}  batch.Batch          "Batch being cancelled"
+// @Failure      404  {object}  types.ErrorResponse  "Batch not found"
+// @Failure      409  {object}  types.ErrorResponse  "Batch already finished"
+// @Router       /v1/batches/{id}/cancel [post]
+func (h *APIHandlers) CancelBatchHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "CancelBatchHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	manager, ok := batchManager(w)
+	if !ok {
+		return
+	}
+	cancelled, err := manager.Cancel(access.ClientKey(r), r.PathValue("id"))
+	switch {
+	case stderrors.Is(err, batch.ErrNotFound):
+		errors.HandleError(w, errors.NewNotFoundError("No such batch: "+r.PathValue("id")), http.StatusNotFound)
+		return
+	case stderrors.Is(err, batch.ErrAlreadyFinished):
+		errors.HandleError(w, errors.NewAPIError(errors.ErrorTypeInvalidRequest, err.Error()), http.StatusConflict)
+		return
+	case err != nil:
+		logger.Error(ctx, "Failed to cancel batch", err,
+			"batch_id", r.PathValue("id"),
+		)
+		errors.HandleError(w, errors.NewInternalError("Failed to cancel batch"), http.StatusInternalServerError)
+		return
+	}
+	logger.Info(ctx, "Batch cancelled",
+		"batch_id", cancelled.ID,
+	)
+	writeResourceJSON(ctx, w, r, cancelled.Response())
+}
+
+// listBatches returns a page of the client key's batches
+func listBatches(manager *batch.Manager, r *http.Request) (BatchListResponse, error) {
+	limit := defaultBatchListLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 || parsed > 100 {
+			return BatchListResponse{}, stderrors.New("limit must be an integer between 1 and 100")
+		}
+		limit = parsed
+	}
+
+	batches := manager.Store.Batches(clientOwner(r))
+	if after := r.URL.Query().Get("after"); after != "" {
+		for i, listed := range batches {
+			if listed.ID == after {
+				batches = batches[i+1:]
+				break
+			}
+		}
+	}
+
+	response := BatchListResponse{Object: "list", Data: []*batch.Batch{}}
+	for _, listed := range batches {
+		if len(response.Data) == limit {
+			response.HasMore = true
+			break
+		}
+		response.Data = append(response.Data, listed.Response())
+	}
+	if len(response.Data) > 0 {
+		response.FirstID = &response.Data[0].ID
+		response.LastID = &response.Data[len(response.Data)-1].ID
+	}
+	return response, nil
+}
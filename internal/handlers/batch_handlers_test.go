@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/batch"
+	"github.com/aashari/go-generative-api-router/internal/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBatchMux(t *testing.T) *http.ServeMux {
+	store, err := batch.NewStore("")
+	require.NoError(t, err)
+	fileStore, err := files.NewStore(t.Context(), files.NewMemoryBackend(), 0)
+	require.NoError(t, err)
+	completions := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"object":"chat.completion"}`)
+	})
+	files.SetDefault(fileStore)
+	batch.SetDefault(batch.NewManager(store, fileStore, completions, 1))
+	t.Cleanup(func() {
+		files.SetDefault(nil)
+		batch.SetDefault(nil)
+	})
+
+	h := newTestHandlers()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/files", h.FilesHandler)
+	mux.HandleFunc("/v1/files/{id}", h.FileHandler)
+	mux.HandleFunc("/v1/files/{id}/content", h.FileContentHandler)
+	mux.HandleFunc("/v1/batches", h.BatchesHandler)
+	mux.HandleFunc("/v1/batches/{id}", h.BatchHandler)
+	mux.HandleFunc("/v1/batches/{id}/cancel", h.CancelBatchHandler)
+	return mux
+}
+
+func serveBatch(mux http.Handler, method, path, clientKey string, body io.Reader, contentType string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, body)
+	req.Header.Set("Authorization", "Bearer "+clientKey)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func uploadBatchFile(t *testing.T, mux http.Handler, clientKey, purpose, content string) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	require.NoError(t, form.WriteField("purpose", purpose))
+	part, err := form.CreateFormFile("file", "input.jsonl")
+	require.NoError(t, err)
+	_, err = io.WriteString(part, content)
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+	return serveBatch(mux, http.MethodPost, "/v1/files", clientKey, &buf, form.FormDataContentType())
+}
+
+func TestBatchHandlers(t *testing.T) {
+	mux := newBatchMux(t)
+	input := `{"custom_id":"req-1","method":"POST","url":"/v1/chat/completions","body":{"model":"gpt-4o","messages":[]}}`
+
+	assert.Equal(t, http.StatusBadRequest, uploadBatchFile(t, mux, "sk-a", "batch_output", input).Code)
+	rec := uploadBatchFile(t, mux, "sk-a", "batch", input)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var file files.File
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &file))
+	assert.Equal(t, "input.jsonl", file.Filename)
+	assert.NotContains(t, rec.Body.String(), "owner")
+
+	assert.Equal(t, http.StatusOK, serveBatch(mux, http.MethodGet, "/v1/files/"+file.ID, "sk-a", nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, serveBatch(mux, http.MethodGet, "/v1/files/"+file.ID, "sk-b", nil, "").Code)
+
+	rec = serveBatch(mux, http.MethodPost, "/v1/batches", "sk-a", bytes.NewBufferString(`{"input_file_id":"`+file.ID+`","endpoint":"/v1/embeddings","completion_window":"24h"}`), "application/json")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveBatch(mux, http.MethodPost, "/v1/batches", "sk-a", bytes.NewBufferString(`{"input_file_id":"`+file.ID+`","endpoint":"/v1/chat/completions","completion_window":"24h"}`), "application/json")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var created batch.Batch
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "batch", created.Object)
+
+	var finished batch.Batch
+	require.Eventually(t, func() bool {
+		rec := serveBatch(mux, http.MethodGet, "/v1/batches/"+created.ID, "sk-a", nil, "")
+		return rec.Code == http.StatusOK && json.Unmarshal(rec.Body.Bytes(), &finished) == nil && finished.Status == batch.StatusCompleted
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NotNil(t, finished.OutputFileID)
+	assert.Empty(t, finished.Owner)
+	assert.Nil(t, finished.Results)
+
+	rec = serveBatch(mux, http.MethodGet, "/v1/files/"+*finished.OutputFileID+"/content", "sk-a", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/jsonl", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"custom_id":"req-1"`)
+
+	rec = serveBatch(mux, http.MethodGet, "/v1/batches?limit=1", "sk-a", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list BatchListResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, created.ID, *list.FirstID)
+	assert.False(t, list.HasMore)
+
+	rec = serveBatch(mux, http.MethodGet, "/v1/batches", "sk-b", nil, "")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Empty(t, list.Data)
+	assert.Equal(t, http.StatusBadRequest, serveBatch(mux, http.MethodGet, "/v1/batches?limit=0", "sk-a", nil, "").Code)
+
+	assert.Equal(t, http.StatusConflict, serveBatch(mux, http.MethodPost, "/v1/batches/"+created.ID+"/cancel", "sk-a", nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, serveBatch(mux, http.MethodPost, "/v1/batches/"+created.ID+"/cancel", "sk-b", nil, "").Code)
+}
+
+func TestBatchHandlersUnavailable(t *testing.T) {
+	batch.SetDefault(nil)
+	rec := httptest.NewRecorder()
+	newTestHandlers().BatchesHandler(rec, httptest.NewRequest(http.MethodGet, "/v1/batches", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/capture"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// defaultCaptureListLimit is how many captured exchanges are listed when no limit is given
+const defaultCaptureListLimit = 20
+
+// CapturesResponse represents the response of the captured requests endpoint
+type CapturesResponse struct {
+	Object string             `json:"object"`
+	Data   []capture.Exchange `json:"data"`
+}
+
+// CaptureReplayResponse pairs a captured exchange with the response its replay got
+type CaptureReplayResponse struct {
+	Original capture.Exchange `json:"original"`
+	Replay   capture.Replay   `json:"replay"`
+}
+
+// CapturesHandler lists the most recently captured requests and responses
+// @Summary      Captured requests
+// @Description  Returns the most recent sanitized request/response pairs, newest first. Requires REQUEST_CAPTURE_ENABLED=true
+// @Tags         admin
+// @Produce      json
+// @Param        limit  query  int  false  "Maximum number of exchanges to return (default 20)"
+// @Security     BearerAuth
+// @Success      200  {object}  handlers.CapturesResponse  "Captured exchanges"
+// @Failure      400  {object}  types.ErrorResponse        "Bad request error"
+// @Failure      401  {object}  types.ErrorResponse        "No admin API key"
+// @Failure      404  {object}  types.ErrorResponse        "Request capture is disabled"
+// @Router       /admin/captures [get]
+func (h *APIHandlers) CapturesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "CapturesHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	if !h.captureEnabled(w) {
+		return
+	}
+
+	limit := defaultCaptureListLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 {
+			errors.HandleError(w, errors.NewValidationError("limit must be a positive integer"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	h.writeCaptureJSON(ctx, w, r, CapturesResponse{Object: "list", Data: h.CaptureStore.List(limit)})
+}
+
+// CaptureHandler returns one captured request and response
+// @Summary      Captured request
+// @Description  Returns the sanitized request/response pair captured for a request ID
+// @Tags         admin
+// @Produce      json
+// @Param        id  path  string  true  "Request ID"
+// @Security     BearerAuth
+// @Success      200  {object}  capture.Exchange     "Captured exchange"
+// @Failure      401  {object}  types.ErrorResponse  "No admin API key"
+// @Failure      404  {object}  types.ErrorResponse  "Not captured, or request capture is disabled"
+// @Router       /admin/captures/{id} [get]
+func (h *APIHandlers) CaptureHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "CaptureHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	exchange, ok := h.capturedExchange(w, r)
+	if !ok {
+		return
+	}
+	h.writeCaptureJSON(ctx, w, r, exchange)
+}
+
+// CaptureReplayHandler re-sends a captured request through the router
+// @Summary      Replay captured request
+// @Description  Re-sends a captured request through the full middleware and proxy pipeline and returns the new response next to the captured one. Captured requests carry no credentials, so the replay is sent with the Authorization header of this request and is trusted as this admin's request by client authentication
+// @Tags         admin
+// @Produce      json
+// @Param        id  path  string  true  "Request ID"
+// @Security     BearerAuth
+// @Success      200  {object}  handlers.CaptureReplayResponse  "Captured exchange and replayed response"
+// @Failure      401  {object}  types.ErrorResponse             "No admin API key"
+// @Failure      404  {object}  types.ErrorResponse             "Not captured, or request capture is disabled"
+// @Failure      422  {object}  types.ErrorResponse             "The captured request body was truncated"
+// @Router       /admin/captures/{id}/replay [post]
+func (h *APIHandlers) CaptureReplayHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "CaptureReplayHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	exchange, ok := h.capturedExchange(w, r)
+	if !ok {
+		return
+	}
+
+	// The replay is made on the admin's behalf, so client authentication lets it through
+	// even when the admin credential is ADMIN_API_KEY rather than a client key
+	replay, err := capture.Send(access.WithAdmin(r.Context()), h.Pipeline, exchange, r.Header.Get(utils.HeaderAuthorization), h.CaptureStore.MaxBodyBytes)
+	if err != nil {
+		if stderrors.Is(err, capture.ErrTruncated) {
+			errors.HandleError(w, errors.NewValidationError(err.Error()), http.StatusUnprocessableEntity)
+			return
+		}
+		logger.Error(ctx, "Failed to replay captured request", err, "capture_id", exchange.ID)
+		errors.HandleError(w, errors.NewInternalError("Failed to replay captured request"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info(ctx, "Captured request replayed",
+		"capture_id", exchange.ID,
+		"replay_request_id", replay.RequestID,
+		"original_status", exchange.Status,
+		"replay_status", replay.Status,
+	)
+	h.writeCaptureJSON(ctx, w, r, CaptureReplayResponse{Original: exchange, Replay: replay})
+}
+
+// captureEnabled answers 404 when request capture is off; returns true when it is on
+func (h *APIHandlers) captureEnabled(w http.ResponseWriter) bool {
+	if h.CaptureStore == nil {
+		errors.HandleError(w, errors.NewNotFoundError("Request capture is disabled, set REQUEST_CAPTURE_ENABLED=true"), http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// capturedExchange looks up the exchange named by the request's id path value, answering
+// 404 when there is none
+func (h *APIHandlers) capturedExchange(w http.ResponseWriter, r *http.Request) (capture.Exchange, bool) {
+	if !h.captureEnabled(w) {
+		return capture.Exchange{}, false
+	}
+	exchange, ok := h.CaptureStore.Get(r.PathValue("id"))
+	if !ok {
+		errors.HandleError(w, errors.NewNotFoundError("No captured request with ID "+r.PathValue("id")), http.StatusNotFound)
+		return capture.Exchange{}, false
+	}
+	return exchange, true
+}
+
+// writeCaptureJSON writes a capture endpoint's response
+func (h *APIHandlers) writeCaptureJSON(ctx context.Context, w http.ResponseWriter, r *http.Request, response any) {
+	jsonResp, err := json.Marshal(response)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal capture response", err)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate capture response"), http.StatusInternalServerError)
+		return
+	}
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
+		logger.Error(ctx, "Failed to write capture response", err,
+			"response_size", len(jsonResp),
+		)
+	}
+}
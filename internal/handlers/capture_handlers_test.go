@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/capture"
+	"github.com/aashari/go-generative-api-router/internal/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// adminRequest returns a request authenticated with an admin credential
+func adminRequest(method, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	return req.WithContext(access.WithAdmin(req.Context()))
+}
+
+func TestCaptureHandlers_Disabled(t *testing.T) {
+	h := newTestHandlers()
+	h.CaptureStore = nil
+
+	rec := httptest.NewRecorder()
+	h.CapturesHandler(rec, adminRequest(http.MethodGet, "/admin/captures"))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCaptureHandlers_RequireAdmin(t *testing.T) {
+	h := newTestHandlers()
+	h.CaptureStore = capture.NewStore(10, 1024)
+	h.CaptureStore.Record(capture.Exchange{ID: "req-1", Method: http.MethodPost, Path: "/v1/chat/completions"})
+	h.Pipeline = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("unauthenticated replays must not reach the pipeline")
+	})
+
+	rec := httptest.NewRecorder()
+	h.CapturesHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/captures", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "req-1")
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/captures/req-1", nil)
+	req.SetPathValue("id", "req-1")
+	rec = httptest.NewRecorder()
+	h.CaptureHandler(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/captures/req-1/replay", nil)
+	req.SetPathValue("id", "req-1")
+	rec = httptest.NewRecorder()
+	h.CaptureReplayHandler(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestCaptureReplayHandler(t *testing.T) {
+	h := newTestHandlers()
+	h.CaptureStore = capture.NewStore(10, 1024)
+	h.CaptureStore.Record(capture.Exchange{
+		ID:       "req-1",
+		Method:   http.MethodPost,
+		Path:     "/v1/chat/completions",
+		Status:   http.StatusOK,
+		Request:  capture.Message{Body: `{"model":"gpt-4o"}`},
+		Response: capture.Message{Body: `{"choices":[]}`},
+	})
+	h.Pipeline = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"model":"gpt-4o"}`, string(body))
+		assert.Equal(t, "Bearer admin-key", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	rec := httptest.NewRecorder()
+	h.CapturesHandler(rec, adminRequest(http.MethodGet, "/admin/captures?limit=5"))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var list CapturesResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+
+	req := adminRequest(http.MethodPost, "/admin/captures/req-1/replay")
+	req.SetPathValue("id", "req-1")
+	req.Header.Set("Authorization", "Bearer admin-key")
+	rec = httptest.NewRecorder()
+	h.CaptureReplayHandler(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response CaptureReplayResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "req-1", response.Original.ID)
+	assert.Equal(t, http.StatusBadGateway, response.Replay.Status)
+
+	req = adminRequest(http.MethodGet, "/admin/captures/req-2")
+	req.SetPathValue("id", "req-2")
+	rec = httptest.NewRecorder()
+	h.CaptureHandler(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCaptureReplayHandler_AdminKeyWithKeyStore(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "admin-key")
+	access.SetDefaultKeyStore(access.NewFileKeyStore(map[string]access.Identity{"sk-team-a": {ID: "team-a"}}))
+	t.Cleanup(func() { access.SetDefaultKeyStore(nil) })
+
+	h := newTestHandlers()
+	h.CaptureStore = capture.NewStore(10, 1024)
+	h.CaptureStore.Record(capture.Exchange{
+		ID:      "req-1",
+		Method:  http.MethodPost,
+		Path:    "/v1/chat/completions",
+		Status:  http.StatusOK,
+		Request: capture.Message{Body: `{"model":"gpt-4o"}`},
+	})
+	h.Pipeline = middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// ADMIN_API_KEY is not a client key, yet the replay it asked for is let through
+	handler := middleware.AdminAuthMiddleware(http.HandlerFunc(h.CaptureReplayHandler))
+	req := httptest.NewRequest(http.MethodPost, "/admin/captures/req-1/replay", nil)
+	req.SetPathValue("id", "req-1")
+	req.Header.Set("Authorization", "Bearer admin-key")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response CaptureReplayResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, http.StatusOK, response.Replay.Status)
+
+	// The same key sent to the API directly is still rejected
+	direct := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	direct.Header.Set("Authorization", "Bearer admin-key")
+	rec = httptest.NewRecorder()
+	h.Pipeline.ServeHTTP(rec, direct)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/budget"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/tokenizer"
+)
+
+// CostEstimateHandler prices a chat completion request before it is sent
+// @Summary      Estimate request cost
+// @Description  Returns what a chat completion request would cost on the models it could be routed to, from the pricing configured in models.json, without calling a vendor. Set model to "any" to price every available chat model, or to a model name ("model" or "vendor/model") to price that model
+// @Description  Prompt cost is counted with each model's tokenizer; the maximum completion cost assumes the request generates max_completion_tokens (or max_tokens) for each of its n choices, capped by the model's context window. Models without pricing are listed in unpriced
+// @Tags         tokenizer
+// @Accept       json
+// @Produce      json
+// @Param        vendor  query     string                   false  "Only price models of this vendor"
+// @Param        request body      budget.EstimateRequest   true   "Model to price and the chat completion request"
+// @Security     BearerAuth
+// @Success      200     {object}  budget.EstimateResponse  "Cost estimates"
+// @Failure      400     {object}  types.ErrorResponse      "Bad request error"
+// @Router       /v1/cost_estimate [post]
+func (h *APIHandlers) CostEstimateHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "CostEstimateHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	var req budget.EstimateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.HandleError(w, errors.NewValidationError("invalid request format"), http.StatusBadRequest)
+		return
+	}
+
+	// Price the models the request could be routed to: available chat models the key may use
+	_, models := routingPool(h.Config.Snapshot(), config.ModelTypeChat)
+	if vendor := r.URL.Query().Get("vendor"); vendor != "" {
+		models = filter.ModelsByVendor(models, vendor)
+	}
+	policy := access.Default().PolicyFor(r)
+	var permitted []config.VendorModel
+	for _, model := range models {
+		if policy.Permits(model.Vendor, model.Model) {
+			permitted = append(permitted, model)
+		}
+	}
+
+	response, err := budget.Estimate(tokenizer.Default(), permitted, req)
+	switch {
+	case stderrors.Is(err, tokenizer.ErrInvalidRequest), stderrors.Is(err, budget.ErrNoModels):
+		errors.HandleError(w, errors.NewValidationError(err.Error()), http.StatusBadRequest)
+		return
+	case err != nil:
+		errors.HandleError(w, errors.NewInternalError("Failed to estimate cost"), http.StatusInternalServerError)
+		return
+	}
+	writeResourceJSON(ctx, w, r, response)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/budget"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCostEstimateHandler(t *testing.T) {
+	h := newTestHandlers()
+	h.Config.Swap(config.NewSnapshot(config.Data{
+		Credentials: []config.Credential{
+			{Platform: "openai", Type: "api-key", Value: "sk-test"},
+			{Platform: "gemini", Type: "api-key", Value: "gm-test"},
+		},
+		Models: []config.VendorModel{
+			{Vendor: "openai", Model: "gpt-4o", Pricing: &config.ModelPricing{InputPerMillion: 2.5, OutputPerMillion: 10}},
+			{Vendor: "gemini", Model: "gemini-pro", Pricing: &config.ModelPricing{InputPerMillion: 1, OutputPerMillion: 4}},
+		},
+	}))
+	access.SetDefault(access.NewACL(access.Policy{}, map[string]access.Policy{
+		"sk-gemini": {AllowedVendors: []string{"gemini"}},
+	}))
+	t.Cleanup(func() { access.SetDefault(access.NewACL(access.Policy{}, nil)) })
+
+	request := `"request":{"messages":[{"role":"user","content":"Hello world"}],"max_tokens":100}`
+	tests := []struct {
+		name       string
+		target     string
+		key        string
+		body       string
+		status     int
+		wantModels []string
+	}{
+		{"any model", "/v1/cost_estimate", "", `{"model":"any",` + request + `}`, http.StatusOK, []string{"gpt-4o", "gemini-pro"}},
+		{"named model", "/v1/cost_estimate", "", `{"model":"gpt-4o",` + request + `}`, http.StatusOK, []string{"gpt-4o"}},
+		{"vendor query", "/v1/cost_estimate?vendor=gemini", "", `{"model":"any",` + request + `}`, http.StatusOK, []string{"gemini-pro"}},
+		{"key restricted to a vendor", "/v1/cost_estimate", "sk-gemini", `{"model":"any",` + request + `}`, http.StatusOK, []string{"gemini-pro"}},
+		{"model the key may not use", "/v1/cost_estimate", "sk-gemini", `{"model":"gpt-4o",` + request + `}`, http.StatusBadRequest, nil},
+		{"no messages", "/v1/cost_estimate", "", `{"model":"any","request":{}}`, http.StatusBadRequest, nil},
+		{"invalid body", "/v1/cost_estimate", "", `{`, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			if tt.key != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			rec := httptest.NewRecorder()
+			h.CostEstimateHandler(rec, r)
+			require.Equal(t, tt.status, rec.Code, rec.Body.String())
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var response budget.EstimateResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			var models []string
+			for _, estimate := range response.Estimates {
+				models = append(models, estimate.Model)
+			}
+			assert.Equal(t, tt.wantModels, models)
+			require.NotNil(t, response.MaxTotalCostUSD)
+			assert.Greater(t, *response.MaxTotalCostUSD, 0.0)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/budget"
+	"github.com/aashari/go-generative-api-router/internal/canary"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/maintenance"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/proxy"
+	"github.com/aashari/go-generative-api-router/internal/ratelimit"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// defaultDashboardWindow is how far back routing decisions are summarized when no window is given
+const defaultDashboardWindow = 15 * time.Minute
+
+//go:embed dashboard.html
+var dashboardPage []byte
+
+// DashboardVendor summarizes the recent traffic and health of one vendor
+type DashboardVendor struct {
+	Vendor        string  `json:"vendor"`
+	Requests      int     `json:"requests"`
+	Errors        int     `json:"errors"`
+	ErrorRate     float64 `json:"error_rate"`
+	Share         float64 `json:"share"`
+	ActiveStreams int64   `json:"active_streams"`
+	// DegradedModels lists the vendor's models that failed their latest canary check
+	DegradedModels []string `json:"degraded_models,omitempty"`
+}
+
+// DashboardSelection counts how often a vendor/model pair was selected
+type DashboardSelection struct {
+	Vendor   string  `json:"vendor"`
+	Model    string  `json:"model"`
+	Requests int     `json:"requests"`
+	Share    float64 `json:"share"`
+}
+
+// DashboardResponse represents the response of the dashboard data endpoint
+type DashboardResponse struct {
+	Object        string    `json:"object" example:"dashboard"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	WindowSeconds int       `json:"window_seconds"`
+	Requests      int       `json:"requests"`
+	Errors        int       `json:"errors"`
+	ErrorRate     float64   `json:"error_rate"`
+	// SelectionFailures counts requests for which no vendor could be selected
+	SelectionFailures      int                  `json:"selection_failures"`
+	ActiveStreams          int64                `json:"active_streams"`
+	RateLimitedCredentials int                  `json:"rate_limited_credentials"`
+	CanaryEnabled          bool                 `json:"canary_enabled"`
+	Vendors                []DashboardVendor    `json:"vendors"`
+	Selections             []DashboardSelection `json:"selections"`
+	Budgets                []budget.State       `json:"budgets"`
+	Maintenance            maintenance.Status   `json:"maintenance"`
+}
+
+// DashboardHandler serves the embedded read-only dashboard page
+// @Summary      Admin dashboard
+// @Description  Serves a single self-contained HTML page that polls /admin/dashboard/data to show vendor health, error rates, selection distribution, active streams and budget usage
+// @Tags         admin
+// @Produce      html
+// @Success      200  {string}  string  "Dashboard page"
+// @Router       /admin/dashboard [get]
+func (h *APIHandlers) DashboardHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "DashboardHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	w.Header().Set(utils.HeaderContentType, "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(dashboardPage); err != nil {
+		logger.Error(ctx, "Failed to write dashboard page", err,
+			"response_size", len(dashboardPage),
+		)
+	}
+}
+
+// DashboardDataHandler returns the live state shown by the dashboard
+// @Summary      Admin dashboard data
+// @Description  Summarizes the routing decisions of the last window per vendor and vendor/model, alongside canary health, rate-limited credentials, active streams, budgets and maintenance state
+// @Tags         admin
+// @Produce      json
+// @Param        window  query  int  false  "Seconds of routing decisions to summarize (default 900)"
+// @Security     BearerAuth
+// @Success      200  {object}  handlers.DashboardResponse  "Dashboard state"
+// @Failure      400  {object}  types.ErrorResponse         "Bad request error"
+// @Router       /admin/dashboard/data [get]
+func (h *APIHandlers) DashboardDataHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "DashboardDataHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	window := defaultDashboardWindow
+	if windowStr := r.URL.Query().Get("window"); windowStr != "" {
+		seconds, err := strconv.Atoi(windowStr)
+		if err != nil || seconds <= 0 {
+			errors.HandleError(w, errors.NewValidationError("window must be a positive number of seconds"), http.StatusBadRequest)
+			return
+		}
+		window = time.Duration(seconds) * time.Second
+	}
+
+	response := buildDashboard(time.Now().UTC(), window)
+
+	jsonResp, err := json.Marshal(response)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal dashboard response", err,
+			"vendors", len(response.Vendors),
+		)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate dashboard data"), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
+		logger.Error(ctx, "Failed to write dashboard response", err,
+			"response_size", len(jsonResp),
+		)
+	}
+}
+
+// buildDashboard gathers the dashboard state from the process-wide metrics and state holders
+func buildDashboard(now time.Time, window time.Duration) DashboardResponse {
+	response := DashboardResponse{
+		Object:        "dashboard",
+		GeneratedAt:   now,
+		WindowSeconds: int(window.Seconds()),
+		Vendors:       []DashboardVendor{},
+		Selections:    []DashboardSelection{},
+		Budgets:       budget.Default().States(),
+		Maintenance:   maintenance.Default().Status(),
+	}
+	if response.Budgets == nil {
+		response.Budgets = []budget.State{}
+	}
+
+	vendors := make(map[string]*DashboardVendor)
+	vendor := func(name string) *DashboardVendor {
+		stats, ok := vendors[name]
+		if !ok {
+			stats = &DashboardVendor{Vendor: name}
+			vendors[name] = stats
+		}
+		return stats
+	}
+	selections := make(map[string]*DashboardSelection)
+
+	decisions := monitoring.DefaultDecisionLog().Query(monitoring.DecisionFilter{Since: now.Add(-window)})
+	for _, decision := range decisions {
+		response.Requests++
+		if decision.Outcome == proxy.DecisionOutcomeSelectionFailed {
+			response.Errors++
+			response.SelectionFailures++
+			continue
+		}
+		stats := vendor(decision.Vendor)
+		stats.Requests++
+		if decision.Outcome == proxy.DecisionOutcomeError {
+			response.Errors++
+			stats.Errors++
+		}
+		key := decision.Vendor + "/" + decision.Model
+		selection, ok := selections[key]
+		if !ok {
+			selection = &DashboardSelection{Vendor: decision.Vendor, Model: decision.Model}
+			selections[key] = selection
+		}
+		selection.Requests++
+	}
+
+	for _, streams := range monitoring.DefaultActiveStreams().Snapshot() {
+		vendor(streams.Vendor).ActiveStreams = streams.Active
+		response.ActiveStreams += streams.Active
+	}
+	if status := canary.Default(); status.Enabled() {
+		response.CanaryEnabled = true
+		for _, result := range status.Results() {
+			stats := vendor(result.Vendor)
+			if !result.Healthy {
+				stats.DegradedModels = append(stats.DegradedModels, result.Model)
+			}
+		}
+	}
+	response.RateLimitedCredentials = len(ratelimit.Default().Cooldowns())
+
+	response.ErrorRate = ratio(response.Errors, response.Requests)
+	routed := response.Requests - response.SelectionFailures
+	for _, stats := range vendors {
+		stats.ErrorRate = ratio(stats.Errors, stats.Requests)
+		stats.Share = ratio(stats.Requests, routed)
+		response.Vendors = append(response.Vendors, *stats)
+	}
+	sort.Slice(response.Vendors, func(i, j int) bool {
+		return response.Vendors[i].Vendor < response.Vendors[j].Vendor
+	})
+	for _, selection := range selections {
+		selection.Share = ratio(selection.Requests, routed)
+		response.Selections = append(response.Selections, *selection)
+	}
+	sort.Slice(response.Selections, func(i, j int) bool {
+		if response.Selections[i].Requests != response.Selections[j].Requests {
+			return response.Selections[i].Requests > response.Selections[j].Requests
+		}
+		if response.Selections[i].Vendor != response.Selections[j].Vendor {
+			return response.Selections[i].Vendor < response.Selections[j].Vendor
+		}
+		return response.Selections[i].Model < response.Selections[j].Model
+	})
+	return response
+}
+
+// ratio returns part/total, or 0 when total is 0
+func ratio(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/proxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestHandlers().DashboardHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/dashboard", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "/admin/dashboard/data")
+}
+
+func TestDashboardDataHandler(t *testing.T) {
+	log := monitoring.DefaultDecisionLog()
+	log.Record(monitoring.RoutingDecision{Vendor: "dash-a", Model: "m1", Outcome: proxy.DecisionOutcomeSuccess})
+	log.Record(monitoring.RoutingDecision{Vendor: "dash-a", Model: "m1", Outcome: proxy.DecisionOutcomeError})
+	log.Record(monitoring.RoutingDecision{Vendor: "dash-a", Model: "m2", Outcome: proxy.DecisionOutcomeFallbackSuccess})
+	log.Record(monitoring.RoutingDecision{Vendor: "dash-b", Model: "m3", Outcome: proxy.DecisionOutcomeSuccess, Timestamp: time.Now().Add(-time.Hour)})
+	done := monitoring.DefaultActiveStreams().Start("dash-b")
+	defer done()
+
+	rec := httptest.NewRecorder()
+	newTestHandlers().DashboardDataHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/dashboard/data?window=600", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var response DashboardResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "dashboard", response.Object)
+	assert.Equal(t, 600, response.WindowSeconds)
+	assert.GreaterOrEqual(t, response.ActiveStreams, int64(1))
+
+	vendors := make(map[string]DashboardVendor)
+	for _, vendor := range response.Vendors {
+		vendors[vendor.Vendor] = vendor
+	}
+	assert.Equal(t, 3, vendors["dash-a"].Requests)
+	assert.Equal(t, 1, vendors["dash-a"].Errors)
+	assert.InDelta(t, 1.0/3, vendors["dash-a"].ErrorRate, 1e-9)
+	assert.Zero(t, vendors["dash-b"].Requests, "decisions outside the window are not counted")
+	assert.Equal(t, int64(1), vendors["dash-b"].ActiveStreams)
+
+	var m1 *DashboardSelection
+	for i, selection := range response.Selections {
+		if selection.Vendor == "dash-a" && selection.Model == "m1" {
+			m1 = &response.Selections[i]
+		}
+	}
+	require.NotNil(t, m1)
+	assert.Equal(t, 2, m1.Requests)
+
+	rec = httptest.NewRecorder()
+	newTestHandlers().DashboardDataHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/dashboard/data?window=soon", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
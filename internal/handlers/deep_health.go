@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/quarantine"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+	"github.com/aashari/go-generative-api-router/internal/vendorhealth"
+)
+
+// Statuses of a deep health check
+const (
+	dependencyUp       = "up"
+	dependencyDegraded = "degraded"
+	dependencyDown     = "down"
+	dependencyUnknown  = "unknown"
+)
+
+// DefaultDeepHealthCacheTTL is how long deep health results are reused when
+// HEALTH_DEEP_CACHE_TTL is unset, so that frequent probes do not call every vendor
+const DefaultDeepHealthCacheTTL = 30 * time.Second
+
+// healthConfigFiles are the configuration files checked for changes the running
+// configuration has not picked up
+var healthConfigFiles = []string{"configs/models.json", "configs/credentials.json"}
+
+// healthMediaTools are the external tools media processing shells out to
+var healthMediaTools = []string{"markitdown", "ffmpeg"}
+
+// DependencyCheck is the status of one vendor, credential, tool or file
+type DependencyCheck struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+}
+
+// DependencyGroup is the status of one kind of dependency, worked out from its checks:
+// "down" when every check is down, "degraded" when some are not up
+type DependencyGroup struct {
+	Status string            `json:"status"`
+	Checks []DependencyCheck `json:"checks"`
+}
+
+// newDependencyGroup works out the status of a group from its checks
+func newDependencyGroup(checks []DependencyCheck) DependencyGroup {
+	group := DependencyGroup{Status: dependencyUp, Checks: checks}
+	if len(checks) == 0 {
+		group.Status = dependencyUnknown
+		group.Checks = []DependencyCheck{}
+		return group
+	}
+	down, unknown := 0, 0
+	for _, check := range checks {
+		switch check.Status {
+		case dependencyUp:
+		case dependencyDown:
+			down++
+			group.Status = dependencyDegraded
+		case dependencyUnknown:
+			unknown++
+		default:
+			group.Status = dependencyDegraded
+		}
+	}
+	switch {
+	case down == len(checks):
+		group.Status = dependencyDown
+	case unknown == len(checks):
+		group.Status = dependencyUnknown
+	}
+	return group
+}
+
+// deepHealthCache keeps the latest deep health results for HEALTH_DEEP_CACHE_TTL
+type deepHealthCache struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	groups    map[string]DependencyGroup
+}
+
+// deepHealth returns the per-dependency breakdown of a deep health check, reusing the
+// previous one while it is younger than HEALTH_DEEP_CACHE_TTL
+func (h *APIHandlers) deepHealth(ctx context.Context, snapshot *config.Snapshot) map[string]DependencyGroup {
+	ttl := utils.GetEnvDuration("HEALTH_DEEP_CACHE_TTL", DefaultDeepHealthCacheTTL)
+
+	h.deepHealthCache.mu.Lock()
+	defer h.deepHealthCache.mu.Unlock()
+	if h.deepHealthCache.groups != nil && time.Since(h.deepHealthCache.checkedAt) < ttl {
+		return h.deepHealthCache.groups
+	}
+
+	groups := map[string]DependencyGroup{
+		"vendors":     newDependencyGroup(h.vendorChecks(ctx)),
+		"credentials": newDependencyGroup(credentialChecks(snapshot)),
+		"media_tools": newDependencyGroup(mediaToolChecks()),
+		"config":      newDependencyGroup(configChecks(snapshot)),
+	}
+	// A check cut short by the client going away says nothing about the dependencies
+	if ctx.Err() == nil {
+		h.deepHealthCache.checkedAt = time.Now()
+		h.deepHealthCache.groups = groups
+	}
+	return groups
+}
+
+// vendorChecks sends every vendor a live one-token completion, the way the vendor health
+// prober does, without publishing the results to its routing status
+func (h *APIHandlers) vendorChecks(ctx context.Context) []DependencyCheck {
+	if h.APIClient == nil {
+		return []DependencyCheck{{Name: "api_client", Status: dependencyUnknown, Detail: "no API client to reach vendors with"}}
+	}
+	prober := &vendorhealth.Prober{
+		Config:  h.Config,
+		Client:  h.APIClient,
+		Status:  vendorhealth.NewStatus(1),
+		Timeout: utils.GetEnvDuration("VENDOR_HEALTH_TIMEOUT", vendorhealth.DefaultTimeout),
+	}
+	return vendorResultChecks(prober.RunOnce(ctx))
+}
+
+// vendorResultChecks turns vendor probe results into checks
+func vendorResultChecks(results []vendorhealth.Result) []DependencyCheck {
+	checks := make([]DependencyCheck, 0, len(results))
+	for _, result := range results {
+		check := DependencyCheck{Name: result.Vendor, Status: dependencyUp, LatencyMs: result.LatencyMs}
+		if !result.Healthy {
+			check.Status = dependencyDown
+			check.Detail = result.Reason
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// credentialChecks reports every credential, by its index in the credentials file, as down
+// while it is quarantined. Without the quarantine their validity is unknown
+func credentialChecks(snapshot *config.Snapshot) []DependencyCheck {
+	creds := snapshot.Credentials()
+	q := quarantine.Default()
+	quarantined := make(map[int]quarantine.Entry)
+	if q.Enabled() {
+		for _, entry := range q.Entries(creds) {
+			quarantined[entry.Index] = entry
+		}
+	}
+
+	checks := make([]DependencyCheck, 0, len(creds))
+	for i, cred := range creds {
+		check := DependencyCheck{Name: strconv.Itoa(i) + ":" + cred.Platform + "/" + cred.Type, Status: dependencyUp}
+		if entry, ok := quarantined[i]; ok {
+			check.Status = dependencyDown
+			check.Detail = fmt.Sprintf("quarantined since %s after %d refusals (last status %d)",
+				entry.Since.UTC().Format(time.RFC3339), entry.Failures, entry.LastStatus)
+		} else if !q.Enabled() {
+			check.Status = dependencyUnknown
+			check.Detail = "credential quarantine disabled"
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// mediaToolChecks reports whether the tools media processing needs are on the PATH;
+// without them documents or audio in requests cannot be converted
+func mediaToolChecks() []DependencyCheck {
+	checks := make([]DependencyCheck, 0, len(healthMediaTools))
+	for _, tool := range healthMediaTools {
+		check := DependencyCheck{Name: tool, Status: dependencyUp}
+		if path, err := exec.LookPath(tool); err != nil {
+			check.Status = dependencyDown
+			check.Detail = "not found in PATH"
+		} else {
+			check.Detail = path
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// configChecks reports the running configuration and every configuration file changed
+// since it was loaded, which a restart would pick up
+func configChecks(snapshot *config.Snapshot) []DependencyCheck {
+	loadedAt := snapshot.LoadedAt()
+	checks := []DependencyCheck{{
+		Name:   "snapshot",
+		Status: dependencyUp,
+		Detail: fmt.Sprintf("version %d loaded at %s (%s ago)", snapshot.Version(),
+			loadedAt.UTC().Format(time.RFC3339), time.Since(loadedAt).Round(time.Second)),
+	}}
+	for _, path := range healthConfigFiles {
+		info, err := os.Stat(path)
+		if err != nil {
+			// Credentials may come from the environment instead
+			continue
+		}
+		check := DependencyCheck{Name: path, Status: dependencyUp}
+		if info.ModTime().After(loadedAt) {
+			check.Status = "stale"
+			check.Detail = "modified at " + info.ModTime().UTC().Format(time.RFC3339) + ", after the running configuration was loaded"
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
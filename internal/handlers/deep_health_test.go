@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/vendorhealth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDependencyGroup(t *testing.T) {
+	up := DependencyCheck{Name: "a", Status: dependencyUp}
+	down := DependencyCheck{Name: "b", Status: dependencyDown}
+	unknown := DependencyCheck{Name: "c", Status: dependencyUnknown}
+	stale := DependencyCheck{Name: "d", Status: "stale"}
+
+	assert.Equal(t, dependencyUp, newDependencyGroup([]DependencyCheck{up, unknown}).Status)
+	assert.Equal(t, dependencyDegraded, newDependencyGroup([]DependencyCheck{up, down}).Status)
+	assert.Equal(t, dependencyDegraded, newDependencyGroup([]DependencyCheck{up, stale}).Status)
+	assert.Equal(t, dependencyDown, newDependencyGroup([]DependencyCheck{down, down}).Status)
+	assert.Equal(t, dependencyUnknown, newDependencyGroup([]DependencyCheck{unknown}).Status)
+
+	empty := newDependencyGroup(nil)
+	assert.Equal(t, dependencyUnknown, empty.Status)
+	assert.NotNil(t, empty.Checks)
+}
+
+func TestVendorResultChecks(t *testing.T) {
+	checks := vendorResultChecks([]vendorhealth.Result{
+		{Vendor: "openai", Healthy: true, LatencyMs: 120},
+		{Vendor: "gemini", Reason: "status 503", LatencyMs: 40},
+	})
+
+	require.Len(t, checks, 2)
+	assert.Equal(t, DependencyCheck{Name: "openai", Status: dependencyUp, LatencyMs: 120}, checks[0])
+	assert.Equal(t, DependencyCheck{Name: "gemini", Status: dependencyDown, Detail: "status 503", LatencyMs: 40}, checks[1])
+}
+
+func TestMediaToolChecks(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte("#!/bin/sh\n"), 0o755))
+	t.Setenv("PATH", dir)
+
+	checks := mediaToolChecks()
+
+	require.Len(t, checks, 2)
+	assert.Equal(t, "markitdown", checks[0].Name)
+	assert.Equal(t, dependencyDown, checks[0].Status)
+	assert.Equal(t, "ffmpeg", checks[1].Name)
+	assert.Equal(t, dependencyUp, checks[1].Status)
+	assert.Equal(t, filepath.Join(dir, "ffmpeg"), checks[1].Detail)
+}
+
+func TestConfigChecks_StaleFile(t *testing.T) {
+	dir := t.TempDir()
+	models := filepath.Join(dir, "models.json")
+	require.NoError(t, os.WriteFile(models, []byte("{}"), 0o644))
+	previous := healthConfigFiles
+	healthConfigFiles = []string{models, filepath.Join(dir, "credentials.json")}
+	t.Cleanup(func() { healthConfigFiles = previous })
+
+	snapshot := config.NewSnapshot(config.Data{})
+	checks := configChecks(snapshot)
+	require.Len(t, checks, 2, "missing files are skipped")
+	assert.Equal(t, "snapshot", checks[0].Name)
+	assert.Equal(t, dependencyUp, checks[1].Status)
+
+	later := snapshot.LoadedAt().Add(time.Minute)
+	require.NoError(t, os.Chtimes(models, later, later))
+	checks = configChecks(snapshot)
+	assert.Equal(t, "stale", checks[1].Status)
+	assert.Equal(t, dependencyDegraded, newDependencyGroup(checks).Status)
+}
+
+func TestDeepHealth(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	h := newTestHandlers()
+
+	groups := h.deepHealth(context.Background(), h.Config.Snapshot())
+
+	assert.Equal(t, dependencyUnknown, groups["vendors"].Status, "no API client to probe with")
+	require.NotEmpty(t, groups["credentials"].Checks)
+	assert.Equal(t, "0:openai/api-key", groups["credentials"].Checks[0].Name)
+	assert.Equal(t, dependencyDown, groups["media_tools"].Status)
+	assert.Contains(t, groups, "config")
+
+	// Results are reused until HEALTH_DEEP_CACHE_TTL passes
+	h.deepHealthCache.groups["media_tools"] = DependencyGroup{Status: dependencyUp}
+	assert.Equal(t, dependencyUp, h.deepHealth(context.Background(), h.Config.Snapshot())["media_tools"].Status)
+
+	h.deepHealthCache.checkedAt = time.Now().Add(-DefaultDeepHealthCacheTTL)
+	assert.Equal(t, dependencyDown, h.deepHealth(context.Background(), h.Config.Snapshot())["media_tools"].Status)
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/files"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+const (
+	// multipartOverhead is the room left above the file size limit for the rest of an upload form
+	multipartOverhead = 1 << 20
+	// maxFileListLimit caps and defaults the number of files listed
+	maxFileListLimit = 10000
+)
+
+// FileListResponse represents the response of the list files endpoint
+type FileListResponse struct {
+	Object  string       `json:"object" example:"list"`
+	Data    []files.File `json:"data"`
+	FirstID *string      `json:"first_id"`
+	LastID  *string      `json:"last_id"`
+	HasMore bool         `json:"has_more"`
+}
+
+// FileDeletedResponse represents the response of the delete file endpoint
+type FileDeletedResponse struct {
+	ID      string `json:"id" example:"file-abc123"`
+	Object  string `json:"object" example:"file"`
+	Deleted bool   `json:"deleted" example:"true"`
+}
+
+// fileStore returns the files store, answering 503 when files are not set up
+func fileStore(w http.ResponseWriter) (*files.Store, bool) {
+	store := files.Default()
+	if store == nil {
+		errors.HandleError(w, errors.NewAPIError(errors.ErrorTypeUnavailable, "Files are not available"), http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return store, true
+}
+
+// FilesHandler uploads or lists files
+// @Summary      Upload or list files
+// @Description  POST uploads a file for batches (purpose "batch") or for file_url content parts referencing it as file://{id}; GET lists the files of the client key, newest first unless order is asc
+// @Tags         files
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file     formData  file    false  "File to upload (POST only)"
+// @Param        purpose  formData  string  false  "One of assistants, batch, evals, fine-tune, user_data or vision (POST only)"
+// @Param        purpose  query     string  false  "Only list files with this purpose"
+// @Param        limit    query     int     false  "Maximum number of files to list (default and maximum 10000)"
+// @Param        order    query     string  false  "asc or desc (default) by creation time"
+// @Param        after    query     string  false  "List files after this file ID"
+// @Security     BearerAuth
+// @Success      200  {object}  files.File           "Uploaded file (POST); GET returns a handlers.FileListResponse"
+// @Failure      400  {object}  types.ErrorResponse  "Bad request error"
+// @Failure      413  {object}  types.ErrorResponse  "File too large"
+// @Failure      503  {object}  types.ErrorResponse  "Files are not available"
+// @Router       /v1/files [post]
+// @Router       /v1/files [get]
+func (h *APIHandlers) FilesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "FilesHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+	store, ok := fileStore(w)
+	if !ok {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		response, err := listFiles(store, r)
+		if err != nil {
+			errors.HandleError(w, errors.NewValidationError(err.Error()), http.StatusBadRequest)
+			return
+		}
+		writeResourceJSON(ctx, w, r, response)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, int64(store.MaxBytes())+multipartOverhead)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if stderrors.As(err, &tooLarge) {
+			errors.HandleError(w, errors.NewValidationError(fmt.Sprintf("file exceeds the %d byte limit", store.MaxBytes())), http.StatusRequestEntityTooLarge)
+			return
+		}
+		errors.HandleError(w, errors.NewValidationError("request must be multipart/form-data with a file and a purpose"), http.StatusBadRequest)
+		return
+	}
+	upload, header, err := r.FormFile("file")
+	if err != nil {
+		errors.HandleError(w, errors.NewValidationError("missing 'file' field"), http.StatusBadRequest)
+		return
+	}
+	defer upload.Close()
+	content, err := io.ReadAll(upload)
+	if err != nil {
+		errors.HandleError(w, errors.NewValidationError("failed to read the uploaded file"), http.StatusBadRequest)
+		return
+	}
+
+	file, err := store.Upload(ctx, clientOwner(r), header.Filename, r.FormValue("purpose"), content)
+	if err != nil {
+		if stderrors.Is(err, files.ErrInvalidFile) {
+			errors.HandleError(w, errors.NewValidationError(err.Error()), http.StatusBadRequest)
+			return
+		}
+		logger.Error(ctx, "Failed to store uploaded file", err,
+			"file_size", len(content),
+		)
+		errors.HandleError(w, errors.NewInternalError("Failed to store file"), http.StatusInternalServerError)
+		return
+	}
+	logger.Info(ctx, "File uploaded",
+		"file_id", file.ID,
+		"file_size", file.Bytes,
+		"purpose", file.Purpose,
+	)
+	writeResourceJSON(ctx, w, r, file)
+}
+
+// FileHandler returns or deletes a file
+// @Summary      Retrieve or delete a file
+// @Description  GET returns the metadata of a file; DELETE removes the file and its content
+// @Tags         files
+// @Produce      json
+// @Param        id  path  string  true  "File ID"
+// @Security     BearerAuth
+// @Success      200  {object}  files.File           "File metadata (GET); DELETE returns a handlers.FileDeletedResponse"
+// @Failure      404  {object}  types.ErrorResponse  "File not found"
+// @Router       /v1/files/{id} [get]
+// @Router       /v1/files/{id} [delete]
+func (h *APIHandlers) FileHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "FileHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet, http.MethodDelete) {
+		return
+	}
+	store, ok := fileStore(w)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+
+	if r.Method == http.MethodDelete {
+		err := store.Delete(ctx, clientOwner(r), id)
+		switch {
+		case stderrors.Is(err, files.ErrNotFound):
+			errors.HandleError(w, errors.NewNotFoundError("No such file: "+id), http.StatusNotFound)
+			return
+		case err != nil:
+			logger.Error(ctx, "Failed to delete file", err,
+				"file_id", id,
+			)
+			errors.HandleError(w, errors.NewInternalError("Failed to delete file"), http.StatusInternalServerError)
+			return
+		}
+		logger.Info(ctx, "File deleted",
+			"file_id", id,
+		)
+		writeResourceJSON(ctx, w, r, FileDeletedResponse{ID: id, Object: "file", Deleted: true})
+		return
+	}
+
+	file, err := store.File(ctx, clientOwner(r), id)
+	if err != nil {
+		errors.HandleError(w, errors.NewNotFoundError("No such file: "+id), http.StatusNotFound)
+		return
+	}
+	writeResourceJSON(ctx, w, r, file)
+}
+
+// FileContentHandler returns a file's content
+// @Summary      Retrieve file content
+// @Description  Returns the content of a file; batch input, output and error files are served as JSONL
+// @Tags         files
+// @Produce      application/jsonl
+// @Produce      application/octet-stream
+// @Param        id  path  string  true  "File ID"
+// @Security     BearerAuth
+// @Success      200  {file}    binary               "File content"
+// @Failure      404  {object}  types.ErrorResponse  "File not found"
+// @Router       /v1/files/{id}/content [get]
+func (h *APIHandlers) FileContentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "FileContentHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	store, ok := fileStore(w)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	file, err := store.File(ctx, clientOwner(r), id)
+	if err != nil {
+		errors.HandleError(w, errors.NewNotFoundError("No such file: "+id), http.StatusNotFound)
+		return
+	}
+	content, err := store.Content(ctx, clientOwner(r), id)
+	if err != nil {
+		if stderrors.Is(err, files.ErrNotFound) {
+			errors.HandleError(w, errors.NewNotFoundError("No such file: "+id), http.StatusNotFound)
+			return
+		}
+		logger.Error(ctx, "Failed to read file content", err,
+			"file_id", id,
+		)
+		errors.HandleError(w, errors.NewInternalError("Failed to read file"), http.StatusInternalServerError)
+		return
+	}
+
+	contentType := http.DetectContentType(content)
+	if file.Purpose == files.PurposeBatch || file.Purpose == files.PurposeBatchOutput {
+		contentType = "application/jsonl"
+	}
+	w.Header().Set(utils.HeaderContentType, contentType)
+	w.Header().Set(utils.HeaderContentLength, strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(content); err != nil {
+		logger.Error(ctx, "Failed to write file content", err,
+			"response_size", len(content),
+		)
+	}
+}
+
+// listFiles returns a page of the client key's files
+func listFiles(store *files.Store, r *http.Request) (FileListResponse, error) {
+	query := r.URL.Query()
+	limit := maxFileListLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 || parsed > maxFileListLimit {
+			return FileListResponse{}, fmt.Errorf("limit must be an integer between 1 and %d", maxFileListLimit)
+		}
+		limit = parsed
+	}
+
+	listed := store.List(clientOwner(r), query.Get("purpose"))
+	switch query.Get("order") {
+	case "", "desc":
+	case "asc":
+		for i, j := 0, len(listed)-1; i < j; i, j = i+1, j-1 {
+			listed[i], listed[j] = listed[j], listed[i]
+		}
+	default:
+		return FileListResponse{}, stderrors.New("order must be asc or desc")
+	}
+	if after := query.Get("after"); after != "" {
+		for i, file := range listed {
+			if file.ID == after {
+				listed = listed[i+1:]
+				break
+			}
+		}
+	}
+
+	response := FileListResponse{Object: "list", Data: []files.File{}}
+	for _, file := range listed {
+		if len(response.Data) == limit {
+			response.HasMore = true
+			break
+		}
+		response.Data = append(response.Data, file)
+	}
+	if len(response.Data) > 0 {
+		response.FirstID = &response.Data[0].ID
+		response.LastID = &response.Data[len(response.Data)-1].ID
+	}
+	return response, nil
+}
+
+// clientOwner returns the owner identifier of the request's client key
+func clientOwner(r *http.Request) string {
+	return files.Owner(access.ClientKey(r))
+}
+
+// writeResourceJSON writes a files or batch API response
+func writeResourceJSON(ctx context.Context, w http.ResponseWriter, r *http.Request, response interface{}) {
+	jsonResp, err := json.Marshal(response)
+	if err != nil {
+		logger.Error(ctx, "Failed to marshal response", err)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate response"), http.StatusInternalServerError)
+		return
+	}
+	if err := writeJSONResponse(w, r, http.StatusOK, jsonResp); err != nil {
+		logger.Error(ctx, "Failed to write response", err,
+			"response_size", len(jsonResp),
+		)
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilesHandlers(t *testing.T) {
+	mux := newBatchMux(t)
+
+	var uploaded []files.File
+	for _, purpose := range []string{"user_data", "assistants"} {
+		rec := uploadBatchFile(t, mux, "sk-a", purpose, "plain text notes")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var file files.File
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &file))
+		uploaded = append(uploaded, file)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		clientKey string
+		ids       int
+		hasMore   bool
+	}{
+		{"all files", "", "sk-a", 2, false},
+		{"by purpose", "?purpose=user_data", "sk-a", 1, false},
+		{"paged", "?limit=1&order=asc", "sk-a", 1, true},
+		{"other client key", "", "sk-b", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveBatch(mux, http.MethodGet, "/v1/files"+tt.query, tt.clientKey, nil, "")
+			require.Equal(t, http.StatusOK, rec.Code)
+			var list FileListResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+			assert.Equal(t, "list", list.Object)
+			assert.Len(t, list.Data, tt.ids)
+			assert.Equal(t, tt.hasMore, list.HasMore)
+		})
+	}
+	assert.Equal(t, http.StatusBadRequest, serveBatch(mux, http.MethodGet, "/v1/files?order=random", "sk-a", nil, "").Code)
+
+	rec := serveBatch(mux, http.MethodGet, "/v1/files/"+uploaded[0].ID+"/content", "sk-a", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "plain text notes", rec.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, serveBatch(mux, http.MethodDelete, "/v1/files/"+uploaded[0].ID, "sk-b", nil, "").Code)
+	rec = serveBatch(mux, http.MethodDelete, "/v1/files/"+uploaded[0].ID, "sk-a", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id":"`+uploaded[0].ID+`","object":"file","deleted":true}`, rec.Body.String())
+	assert.Equal(t, http.StatusNotFound, serveBatch(mux, http.MethodGet, "/v1/files/"+uploaded[0].ID+"/content", "sk-a", nil, "").Code)
+}
+
+func TestFilesHandlersUnavailable(t *testing.T) {
+	files.SetDefault(nil)
+	rec := httptest.NewRecorder()
+	newTestHandlers().FilesHandler(rec, httptest.NewRequest(http.MethodGet, "/v1/files", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
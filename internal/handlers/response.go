@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// allowMethods rejects requests whose method is not in the allowed list with a 405 and an Allow header
+// Returns true when the request may proceed
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	if r.Method == http.MethodHead {
+		// HEAD responses must not carry a body
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return false
+	}
+
+	apiErr := errors.NewAPIError(errors.ErrorTypeInvalidRequest,
+		fmt.Sprintf("Method %s not allowed, use %s", r.Method, strings.Join(methods, " or ")))
+	errors.HandleError(w, apiErr, http.StatusMethodNotAllowed)
+	return false
+}
+
+// requireAdmin rejects requests not authenticated with an admin credential by
+// middleware.AdminAuthMiddleware with a 401, so that admin handlers changing router state
+// stay protected wherever they are mounted. Returns true when the request may proceed
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if access.IsAdmin(r.Context()) {
+		return true
+	}
+	errors.HandleError(w, errors.NewAuthenticationError("this admin endpoint needs an admin API key (ADMIN_API_KEY or a key store identity with admin set)"), http.StatusUnauthorized)
+	return false
+}
+
+// writeJSONResponse writes a JSON body with an accurate Content-Length, omitting the body for HEAD requests
+func writeJSONResponse(w http.ResponseWriter, r *http.Request, statusCode int, body []byte) error {
+	w.Header().Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	w.Header().Set(utils.HeaderContentLength, strconv.Itoa(len(body)))
+	w.WriteHeader(statusCode)
+
+	if r.Method == http.MethodHead {
+		return nil
+	}
+	_, err := w.Write(body)
+	return err
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/admission"
+	"github.com/aashari/go-generative-api-router/internal/budget"
+	"github.com/aashari/go-generative-api-router/internal/cache"
+	"github.com/aashari/go-generative-api-router/internal/canary"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/maintenance"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/proxy"
+	"github.com/aashari/go-generative-api-router/internal/quarantine"
+	"github.com/aashari/go-generative-api-router/internal/ratelimit"
+	"github.com/aashari/go-generative-api-router/internal/rollout"
+	"github.com/aashari/go-generative-api-router/internal/schedule"
+	"github.com/aashari/go-generative-api-router/internal/vendorhealth"
+)
+
+const (
+	// supportBundleDecisionLimit caps the routing decisions included in a support bundle
+	supportBundleDecisionLimit = 200
+	// supportBundleErrorLimit caps the failed decisions of each outcome included in a support bundle
+	supportBundleErrorLimit = 50
+	// redacted replaces secrets in a support bundle
+	redacted = "[REDACTED]"
+)
+
+// supportBundleSettings are the prefixes of environment variables included in a support bundle
+var supportBundleSettings = []string{
+	"ENVIRONMENT", "SERVICE_NAME", "PORT", "VERSION", "LOG_", "MONGODB_", "MEDIA_", "INLINE_IMAGE_",
+	"PAYLOAD_", "SERVER_TIMING_", "RESPONSE_", "CANARY_", "STATE_", "CLIENT_", "TOOLS_FILE",
+	"ROUTING_", "SEMANTIC_CACHE_", "WATERMARK_", "FILES_", "BATCH_", "AGGREGATE_", "MERGE_SAME_ROLE_", "STREAM_", "MAINTENANCE_",
+	"UNIX_SOCKET", "ANTHROPIC_", "OPENAI_", "GEMINI_", "MISTRAL_", "COHERE_", "GROQ_", "TOGETHER_",
+	"DEEPSEEK_", "HUGGINGFACE_", "OLLAMA_", "VERTEX_",
+}
+
+// sensitiveSettingMarkers mark environment variables whose values are always redacted
+var sensitiveSettingMarkers = []string{"KEY", "TOKEN", "SECRET", "PASSWORD"}
+
+// SupportBundleHandler returns a zip archive describing the router's configuration and state
+// @Summary      Support bundle
+// @Description  Returns a zip archive with the redacted effective configuration, version info, recent routing decisions, recent errors, canary/rate-limit/budget/rollout/maintenance state and a metrics snapshot
+// @Tags         admin
+// @Produce      application/zip
+// @Security     BearerAuth
+// @Success      200  {file}  binary  "Support bundle archive"
+// @Failure      401  {object}  types.ErrorResponse  "No admin API key"
+// @Router       /admin/support-bundle [get]
+func (h *APIHandlers) SupportBundleHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "SupportBundleHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	// The bundle holds the configuration and recent traffic, so it is never served read-only
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	now := time.Now().UTC()
+	bundle, err := h.buildSupportBundle(now)
+	if err != nil {
+		logger.Error(ctx, "Failed to build support bundle", err)
+		errors.HandleError(w, errors.NewInternalError("Failed to generate support bundle"), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info(ctx, "Support bundle generated",
+		"bundle_size", len(bundle),
+		"remote_addr", r.RemoteAddr,
+	)
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"support-bundle-%s.zip\"", now.Format("20060102T150405Z")))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(bundle); err != nil {
+		logger.Error(ctx, "Failed to write support bundle", err,
+			"bundle_size", len(bundle),
+		)
+	}
+}
+
+// buildSupportBundle writes each section of the support bundle as a JSON file of a zip archive
+func (h *APIHandlers) buildSupportBundle(now time.Time) ([]byte, error) {
+	snapshot := h.Config.Snapshot()
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"version.json", supportBundleVersion(snapshot, now)},
+		{"config.json", supportBundleConfig(snapshot)},
+		{"routing-decisions.json", monitoring.DefaultDecisionLog().Query(monitoring.DecisionFilter{Limit: supportBundleDecisionLimit})},
+		{"errors.json", supportBundleErrors()},
+		{"state.json", supportBundleState(snapshot)},
+		{"metrics.json", supportBundleMetrics()},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.content, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", file.name, err)
+		}
+		writer, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s: %w", file.name, err)
+		}
+		if _, err := writer.Write(content); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish support bundle: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// supportBundleVersion describes the running build and configuration snapshot
+func supportBundleVersion(snapshot *config.Snapshot, now time.Time) map[string]interface{} {
+	version := map[string]interface{}{
+		"version":          os.Getenv("VERSION"),
+		"go_version":       runtime.Version(),
+		"platform":         runtime.GOOS + "/" + runtime.GOARCH,
+		"generated_at":     now,
+		"started_at":       startTime.UTC(),
+		"uptime_seconds":   int64(now.Sub(startTime).Seconds()),
+		"config_version":   snapshot.Version(),
+		"config_loaded_at": snapshot.LoadedAt(),
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision", "vcs.time", "vcs.modified":
+				version[strings.ReplaceAll(setting.Key, ".", "_")] = setting.Value
+			}
+		}
+	}
+	return version
+}
+
+// supportBundleConfig returns the effective configuration with credentials and URL passwords redacted
+func supportBundleConfig(snapshot *config.Snapshot) map[string]interface{} {
+	credentials := snapshot.Credentials()
+	for i := range credentials {
+		credentials[i].Value = redacted
+	}
+	models := snapshot.Models()
+	for i := range models {
+		models[i].BaseURL = redactURL(models[i].BaseURL)
+	}
+	vendors := snapshot.Vendors()
+	for vendor, baseURL := range vendors {
+		vendors[vendor] = redactURL(baseURL)
+	}
+	return map[string]interface{}{
+		"credentials": credentials,
+		"models":      models,
+		"vendors":     vendors,
+		"settings":    supportBundleEnvironment(os.Environ()),
+	}
+}
+
+// supportBundleEnvironment returns the router's settings from environ, redacting secrets
+func supportBundleEnvironment(environ []string) map[string]string {
+	settings := make(map[string]string)
+	for _, entry := range environ {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || !isRouterSetting(name) {
+			continue
+		}
+		upper := strings.ToUpper(name)
+		sensitive := false
+		for _, marker := range sensitiveSettingMarkers {
+			if strings.Contains(upper, marker) {
+				sensitive = true
+				break
+			}
+		}
+		switch {
+		case sensitive && value != "":
+			settings[name] = redacted
+		case strings.Contains(value, "://"):
+			settings[name] = redactURL(value)
+		default:
+			settings[name] = value
+		}
+	}
+	return settings
+}
+
+// isRouterSetting reports whether an environment variable configures the router
+func isRouterSetting(name string) bool {
+	for _, prefix := range supportBundleSettings {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL replaces the password of a URL and its query string, which may carry keys
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return raw
+	}
+	if parsed.User != nil {
+		parsed.User = url.UserPassword(parsed.User.Username(), "REDACTED")
+	}
+	if parsed.RawQuery != "" {
+		parsed.RawQuery = "REDACTED"
+	}
+	return parsed.String()
+}
+
+// supportBundleErrors returns the most recent failed routing decisions, newest first
+func supportBundleErrors() []monitoring.RoutingDecision {
+	decisionLog := monitoring.DefaultDecisionLog()
+	var failed []monitoring.RoutingDecision
+	for _, outcome := range []string{proxy.DecisionOutcomeError, proxy.DecisionOutcomeSelectionFailed} {
+		failed = append(failed, decisionLog.Query(monitoring.DecisionFilter{Outcome: outcome, Limit: supportBundleErrorLimit})...)
+	}
+	sort.SliceStable(failed, func(i, j int) bool {
+		return failed[i].Timestamp.After(failed[j].Timestamp)
+	})
+	return failed
+}
+
+// rateLimitedCredential is a credential a vendor has throttled, identified by its position in the configuration
+type rateLimitedCredential struct {
+	Index    int       `json:"index"`
+	Platform string    `json:"platform"`
+	Type     string    `json:"type"`
+	Until    time.Time `json:"until"`
+}
+
+// credentialQuota is the rate-limit quota a vendor last reported for a credential, identified by its position in the configuration
+type credentialQuota struct {
+	Index    int             `json:"index"`
+	Platform string          `json:"platform"`
+	Type     string          `json:"type"`
+	Quota    ratelimit.Quota `json:"quota"`
+}
+
+// supportBundleState returns the runtime state that removes models or credentials from routing
+func supportBundleState(snapshot *config.Snapshot) map[string]interface{} {
+	rateLimited := []rateLimitedCredential{}
+	quotas := []credentialQuota{}
+	for i, cred := range snapshot.Credentials() {
+		if until := ratelimit.Default().Until(cred); !until.IsZero() {
+			rateLimited = append(rateLimited, rateLimitedCredential{Index: i, Platform: cred.Platform, Type: cred.Type, Until: until})
+		}
+		if quota, ok := ratelimit.Default().Quota(cred); ok {
+			quotas = append(quotas, credentialQuota{Index: i, Platform: cred.Platform, Type: cred.Type, Quota: quota})
+		}
+	}
+	return map[string]interface{}{
+		"canary": map[string]interface{}{
+			"enabled": canary.Default().Enabled(),
+			"results": canary.Default().Results(),
+		},
+		"vendor_health":            vendorhealth.Default().Results(),
+		"rate_limited_credentials": rateLimited,
+		"credential_quotas":        quotas,
+		"quarantined_credentials":  quarantine.Default().Entries(snapshot.Credentials()),
+		"budgets":                  budget.Default().States(),
+		"rollouts":                 rollout.Default().States(),
+		"maintenance":              maintenance.Default().Status(),
+		"active_schedules":         schedule.Default().Active(),
+	}
+}
+
+// supportBundleMetrics returns a snapshot of the in-memory metrics and the Go runtime
+func supportBundleMetrics() map[string]interface{} {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	anomalies := []monitoring.VendorAnomalyStats{}
+	if reporter, ok := monitoring.DefaultResponseAnomalyDetector().(monitoring.AnomalyReporter); ok {
+		anomalies = reporter.Snapshot()
+	}
+	var semanticCache *cache.Stats
+	if c := cache.Default(); c != nil {
+		stats := c.Stats()
+		semanticCache = &stats
+	}
+	return map[string]interface{}{
+		"payload_sizes":      monitoring.DefaultPayloadSizeMetrics().Snapshot(),
+		"response_anomalies": anomalies,
+		"slow_clients":       monitoring.DefaultSlowClientMetrics().Snapshot(),
+		"load_shedding":      admission.DefaultShedder().Stats(),
+		"semantic_cache":     semanticCache,
+		"runtime": map[string]interface{}{
+			"goroutines":       runtime.NumGoroutine(),
+			"heap_alloc_bytes": memStats.HeapAlloc,
+			"sys_bytes":        memStats.Sys,
+			"num_gc":           memStats.NumGC,
+		},
+	}
+}
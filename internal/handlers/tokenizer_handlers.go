@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/tokenizer"
+)
+
+// TokenizeHandler counts the tokens of a text for a model
+// @Summary      Tokenize text
+// @Description  Returns the token count of a text for a model without calling a vendor, and the token IDs when the model's tokenizer is embedded (OpenAI models with the cl100k_base or o200k_base rank files). Other models, such as Claude and Gemini, get an approximate count and exact set to false
+// @Tags         tokenizer
+// @Accept       json
+// @Produce      json
+// @Param        vendor  query     string                      false  "Vendor serving the model, when the model name does not identify its tokenizer"
+// @Param        request body      tokenizer.TokenizeRequest   true   "Model and text"
+// @Security     BearerAuth
+// @Success      200     {object}  tokenizer.TokenizeResponse  "Token count"
+// @Failure      400     {object}  types.ErrorResponse         "Bad request error"
+// @Router       /v1/tokenize [post]
+func (h *APIHandlers) TokenizeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "TokenizeHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	var req tokenizer.TokenizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.HandleError(w, errors.NewValidationError("invalid request format"), http.StatusBadRequest)
+		return
+	}
+	response, err := tokenizer.Tokenize(h.tokenizerFor(r, req.Model), req)
+	if err != nil {
+		writeTokenizerError(w, err)
+		return
+	}
+	writeResourceJSON(ctx, w, r, response)
+}
+
+// CountTokensHandler counts the prompt tokens of a chat completion request
+// @Summary      Count prompt tokens
+// @Description  Returns the prompt tokens a chat completion request would use, counted with the model's tokenizer and the chat format overhead of OpenAI models, so clients can budget prompts without calling a vendor
+// @Description  Images are counted at 85 tokens for low detail and 765 otherwise; images, audio, files, tool calls and tool definitions make the count an estimate (exact false)
+// @Tags         tokenizer
+// @Accept       json
+// @Produce      json
+// @Param        vendor  query     string                         false  "Vendor serving the model, when the model name does not identify its tokenizer"
+// @Param        request body      tokenizer.CountTokensRequest   true   "Model, messages and optional tools"
+// @Security     BearerAuth
+// @Success      200     {object}  tokenizer.CountTokensResponse  "Prompt token count"
+// @Failure      400     {object}  types.ErrorResponse            "Bad request error"
+// @Router       /v1/count_tokens [post]
+func (h *APIHandlers) CountTokensHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "CountTokensHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	var req tokenizer.CountTokensRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.HandleError(w, errors.NewValidationError("invalid request format"), http.StatusBadRequest)
+		return
+	}
+	response, err := tokenizer.CountTokens(h.tokenizerFor(r, req.Model), req)
+	if err != nil {
+		writeTokenizerError(w, err)
+		return
+	}
+	writeResourceJSON(ctx, w, r, response)
+}
+
+// tokenizerFor returns the tokenizer of a model, identified by its name or, for names that do
+// not identify a tokenizer, by the vendor serving it in the ?vendor= query or the configuration
+func (h *APIHandlers) tokenizerFor(r *http.Request, model string) tokenizer.Tokenizer {
+	vendor := r.URL.Query().Get("vendor")
+	if vendor == "" {
+		vendor = configuredVendor(h.Config.Snapshot().Models(), model)
+	}
+	return tokenizer.Default().ForModel(vendor, model)
+}
+
+// configuredVendor returns the vendor of the first configured model named model, or ""
+func configuredVendor(models []config.VendorModel, model string) string {
+	for _, configured := range models {
+		if configured.Model == model {
+			return configured.Vendor
+		}
+	}
+	return ""
+}
+
+// writeTokenizerError answers invalid tokenize and count requests with 400
+func writeTokenizerError(w http.ResponseWriter, err error) {
+	if stderrors.Is(err, tokenizer.ErrInvalidRequest) {
+		errors.HandleError(w, errors.NewValidationError(err.Error()), http.StatusBadRequest)
+		return
+	}
+	errors.HandleError(w, errors.NewInternalError("Failed to count tokens"), http.StatusInternalServerError)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/tokenizer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenizeHandler(t *testing.T) {
+	h := newTestHandlers()
+
+	tests := []struct {
+		name      string
+		target    string
+		body      string
+		status    int
+		tokenizer string
+	}{
+		{"model name", "/v1/tokenize", `{"model":"claude-sonnet-4","input":"Hello world"}`, http.StatusOK, tokenizer.ApproximationClaude},
+		{"configured vendor", "/v1/tokenize", `{"model":"gemini-pro","input":"Hello world"}`, http.StatusOK, tokenizer.ApproximationGemini},
+		{"vendor query", "/v1/tokenize?vendor=anthropic", `{"model":"my-model","input":"Hello world"}`, http.StatusOK, tokenizer.ApproximationClaude},
+		{"missing model", "/v1/tokenize", `{"input":"Hello world"}`, http.StatusBadRequest, ""},
+		{"invalid body", "/v1/tokenize", `{`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.TokenizeHandler(rec, httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body)))
+			require.Equal(t, tt.status, rec.Code, rec.Body.String())
+			if tt.status != http.StatusOK {
+				return
+			}
+			var response tokenizer.TokenizeResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, tt.tokenizer, response.Tokenizer)
+			assert.False(t, response.Exact)
+			assert.Equal(t, 2, response.Count)
+		})
+	}
+}
+
+func TestCountTokensHandler(t *testing.T) {
+	h := newTestHandlers()
+
+	rec := httptest.NewRecorder()
+	h.CountTokensHandler(rec, httptest.NewRequest(http.MethodPost, "/v1/count_tokens",
+		strings.NewReader(`{"model":"gpt-4o","messages":[{"role":"system","content":"Be brief."},{"role":"user","content":"Hello world"}]}`)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response tokenizer.CountTokensResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "gpt-4o", response.Model)
+	assert.Equal(t, tokenizer.EncodingO200K, response.Tokenizer)
+	assert.Greater(t, response.InputTokens, 3+3+3)
+
+	rec = httptest.NewRecorder()
+	h.CountTokensHandler(rec, httptest.NewRequest(http.MethodPost, "/v1/count_tokens", strings.NewReader(`{"model":"gpt-4o","messages":[]}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.CountTokensHandler(rec, httptest.NewRequest(http.MethodGet, "/v1/count_tokens", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
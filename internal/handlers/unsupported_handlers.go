@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+)
+
+// SupportedEndpoints lists the OpenAI-compatible endpoints served by the router
+var SupportedEndpoints = []string{
+	"POST /v1/chat/completions",
+	"POST /v1/responses",
+	"POST /v1/messages",
+	"POST /v1/aggregate",
+	"GET /v1/models",
+	"GET /v1/models/{id}",
+	"POST /v1/models/{model}:generateContent",
+	"POST /v1/models/{model}:streamGenerateContent",
+	"POST /v1beta/models/{model}:generateContent",
+	"POST /v1beta/models/{model}:streamGenerateContent",
+	"POST /v1/images/text",
+	"POST /v1/embeddings",
+	"POST /v1/audio/transcriptions",
+	"POST /v1/audio/speech",
+	"POST /v1/moderations",
+	"POST /v1/rerank",
+	"POST /v1/tokenize",
+	"POST /v1/count_tokens",
+	"POST /v1/cost_estimate",
+	"POST /v1/files",
+	"GET /v1/files",
+	"GET /v1/files/{id}",
+	"DELETE /v1/files/{id}",
+	"GET /v1/files/{id}/content",
+	"POST /v1/batches",
+	"GET /v1/batches",
+	"GET /v1/batches/{id}",
+	"POST /v1/batches/{id}/cancel",
+}
+
+// endpointSuggestions maps well-known unsupported OpenAI paths to the closest supported endpoint
+var endpointSuggestions = map[string]string{
+	"/v1/completions": "/v1/chat/completions",
+	"/v1/engines":     "/v1/models",
+	"/v1/chat":        "/v1/chat/completions",
+}
+
+// UnsupportedEndpointHandler answers requests to unimplemented /v1/ paths with an OpenAI-style error
+// @Summary      Unsupported endpoint
+// @Description  Catch-all for /v1/ paths the router does not implement; lists the supported endpoints
+// @Tags         errors
+// @Produce      json
+// @Failure      404  {object}  types.ErrorResponse  "Endpoint not supported"
+// @Router       /v1/{path} [get]
+func (h *APIHandlers) UnsupportedEndpointHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := logger.WithComponent(r.Context(), "UnsupportedEndpointHandler")
+	ctx = logger.WithStage(ctx, "Request")
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+
+	message := fmt.Sprintf("Invalid URL (%s %s): this endpoint is not supported by the router.", r.Method, r.URL.Path)
+	if suggestion, ok := suggestEndpoint(path); ok {
+		message += fmt.Sprintf(" Did you mean %s?", suggestion)
+	}
+
+	logger.Warn(ctx, "Unsupported endpoint requested",
+		"method", r.Method,
+		"path", r.URL.Path,
+	)
+
+	apiErr := errors.NewAPIErrorWithCode(errors.ErrorTypeInvalidRequest, message, "unsupported_endpoint")
+	apiErr.Details = "Supported endpoints: " + strings.Join(SupportedEndpoints, ", ")
+	errors.HandleError(w, apiErr, http.StatusNotFound)
+}
+
+// suggestEndpoint returns the closest supported endpoint for a path, if any
+func suggestEndpoint(path string) (string, bool) {
+	if suggestion, ok := endpointSuggestions[path]; ok {
+		return suggestion, true
+	}
+	for prefix, suggestion := range endpointSuggestions {
+		if strings.HasPrefix(path, prefix+"/") {
+			return suggestion, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnsupportedEndpointHandler(t *testing.T) {
+	h := newTestHandlers()
+
+	tests := []struct {
+		name               string
+		method             string
+		target             string
+		expectedMessage    string
+		expectedSuggestion string
+	}{
+		{
+			name:            "unknown path",
+			method:          http.MethodPost,
+			target:          "/v1/fine_tuning/jobs",
+			expectedMessage: "Invalid URL (POST /v1/fine_tuning/jobs): this endpoint is not supported by the router.",
+		},
+		{
+			name:               "near miss",
+			method:             http.MethodPost,
+			target:             "/v1/chat/completion",
+			expectedMessage:    "Invalid URL (POST /v1/chat/completion): this endpoint is not supported by the router. Did you mean /v1/chat/completions?",
+			expectedSuggestion: "/v1/chat/completions",
+		},
+		{
+			name:               "legacy endpoint with a trailing slash",
+			method:             http.MethodPost,
+			target:             "/v1/completions/",
+			expectedMessage:    "Invalid URL (POST /v1/completions/): this endpoint is not supported by the router. Did you mean /v1/chat/completions?",
+			expectedSuggestion: "/v1/chat/completions",
+		},
+		{
+			name:               "legacy engine path",
+			method:             http.MethodGet,
+			target:             "/v1/engines/davinci",
+			expectedMessage:    "Invalid URL (GET /v1/engines/davinci): this endpoint is not supported by the router. Did you mean /v1/models?",
+			expectedSuggestion: "/v1/models",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.UnsupportedEndpointHandler(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var response errors.ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, errors.ErrorTypeInvalidRequest, response.Error.Type)
+			assert.Equal(t, "unsupported_endpoint", response.Error.Code)
+			assert.Equal(t, tt.expectedMessage, response.Error.Message)
+			if tt.expectedSuggestion != "" {
+				assert.True(t, strings.HasSuffix(response.Error.Message, "Did you mean "+tt.expectedSuggestion+"?"))
+			} else {
+				assert.NotContains(t, response.Error.Message, "Did you mean")
+			}
+			assert.Equal(t, "Supported endpoints: "+strings.Join(SupportedEndpoints, ", "), response.Error.Details)
+		})
+	}
+}
+
+func TestSuggestEndpoint(t *testing.T) {
+	tests := []struct {
+		path       string
+		suggestion string
+		ok         bool
+	}{
+		{"/v1/completions", "/v1/chat/completions", true},
+		{"/v1/chat", "/v1/chat/completions", true},
+		{"/v1/chat/completion", "/v1/chat/completions", true},
+		{"/v1/engines", "/v1/models", true},
+		{"/v1/engines/davinci/completions", "/v1/models", true},
+		{"/v1/chatbots", "", false},
+		{"/v1/assistants", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			suggestion, ok := suggestEndpoint(tt.path)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.suggestion, suggestion)
+		})
+	}
+}
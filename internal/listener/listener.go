@@ -0,0 +1,173 @@
+// Package listener opens the network listeners the API is served on: TCP, a Unix domain
+// socket for sidecar deployments next to an application container, or both
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Default listener settings, overridable with PORT and UNIX_SOCKET_MODE
+const (
+	DefaultPort       = 8082
+	DefaultSocketMode = os.FileMode(0660)
+)
+
+// Config selects the listeners to open; an empty TCPAddr or SocketPath disables that transport
+type Config struct {
+	TCPAddr    string
+	SocketPath string
+	// SocketMode is applied to the socket file after it is created
+	SocketMode os.FileMode
+	// SocketGroup, a group name or numeric ID, owns the socket file when set
+	SocketGroup string
+}
+
+// ConfigFromEnv reads the listener config: TCP on PORT, plus a Unix socket at
+// UNIX_SOCKET when set. UNIX_SOCKET_ONLY=true disables TCP when a socket is configured
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		TCPAddr:     ":" + strconv.Itoa(utils.GetEnvPort("PORT", DefaultPort)),
+		SocketPath:  utils.GetEnvString("UNIX_SOCKET", ""),
+		SocketMode:  DefaultSocketMode,
+		SocketGroup: utils.GetEnvString("UNIX_SOCKET_GROUP", ""),
+	}
+
+	if mode := utils.GetEnvString("UNIX_SOCKET_MODE", ""); mode != "" {
+		parsed, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil || parsed > 0777 {
+			return Config{}, fmt.Errorf("invalid UNIX_SOCKET_MODE %q: expected octal permissions such as 0660", mode)
+		}
+		cfg.SocketMode = os.FileMode(parsed)
+	}
+
+	if cfg.SocketPath != "" && utils.GetEnvBool("UNIX_SOCKET_ONLY", false) {
+		cfg.TCPAddr = ""
+	}
+	return cfg, nil
+}
+
+// Open opens every listener the config enables. If any fails, those already opened are closed
+func Open(cfg Config) ([]net.Listener, error) {
+	if cfg.TCPAddr == "" && cfg.SocketPath == "" {
+		return nil, errors.New("no listener configured: set PORT or UNIX_SOCKET")
+	}
+
+	var listeners []net.Listener
+	if cfg.TCPAddr != "" {
+		tcp, err := net.Listen("tcp", cfg.TCPAddr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", cfg.TCPAddr, err)
+		}
+		listeners = append(listeners, tcp)
+	}
+
+	if cfg.SocketPath != "" {
+		unix, err := listenUnix(cfg)
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, unix)
+	}
+	return listeners, nil
+}
+
+// listenUnix creates the socket file, replacing a stale socket left by a previous run,
+// and applies the configured permissions. The file is removed when the listener closes
+func listenUnix(cfg Config) (net.Listener, error) {
+	if err := removeStaleSocket(cfg.SocketPath); err != nil {
+		return nil, err
+	}
+
+	l, err := net.Listen("unix", cfg.SocketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on unix socket %s: %w", cfg.SocketPath, err)
+	}
+
+	if err := os.Chmod(cfg.SocketPath, cfg.SocketMode); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("failed to set permissions of unix socket %s: %w", cfg.SocketPath, err)
+	}
+	if cfg.SocketGroup != "" {
+		gid, err := lookupGroup(cfg.SocketGroup)
+		if err == nil {
+			err = os.Lchown(cfg.SocketPath, -1, gid)
+		}
+		if err != nil {
+			_ = l.Close()
+			return nil, fmt.Errorf("failed to set group of unix socket %s: %w", cfg.SocketPath, err)
+		}
+	}
+	return l, nil
+}
+
+// removeStaleSocket removes a socket file nothing is listening on. Sockets still in use
+// and files that are not sockets are left alone and reported as errors
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to inspect unix socket path %s: %w", path, err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("unix socket path %s exists and is not a socket", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("unix socket %s is already in use", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale unix socket %s: %w", path, err)
+	}
+	return nil
+}
+
+// lookupGroup resolves a group name or numeric ID to a group ID
+func lookupGroup(group string) (int, error) {
+	if gid, err := strconv.Atoi(group); err == nil {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(g.Gid)
+}
+
+// Serve serves server on every listener until one of them fails or the server is shut
+// down, then closes the rest and returns the first error
+func Serve(server *http.Server, listeners []net.Listener) error {
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			errs <- server.Serve(l)
+		}(l)
+	}
+
+	err := <-errs
+	_ = server.Close()
+	return err
+}
+
+// Addresses describes the listeners for logging, e.g. "tcp [::]:8082"
+func Addresses(listeners []net.Listener) []string {
+	addresses := make([]string, 0, len(listeners))
+	for _, l := range listeners {
+		addresses = append(addresses, l.Addr().Network()+" "+l.Addr().String())
+	}
+	return addresses
+}
@@ -0,0 +1,134 @@
+package listener
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServe_TCPAndUnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "router.sock")
+	listeners, err := Open(Config{TCPAddr: "127.0.0.1:0", SocketPath: socketPath, SocketMode: 0600})
+	require.NoError(t, err)
+	require.Len(t, listeners, 2)
+
+	info, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})}
+	served := make(chan error, 1)
+	go func() { served <- Serve(server, listeners) }()
+
+	clients := map[string]*http.Client{
+		"tcp": http.DefaultClient,
+		"unix": {Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		}}},
+	}
+	for transport, client := range clients {
+		resp, err := client.Get("http://" + listeners[0].Addr().String() + "/health")
+		require.NoError(t, err, transport)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "ok", string(body), transport)
+	}
+
+	require.NoError(t, server.Shutdown(context.Background()))
+	assert.ErrorIs(t, <-served, http.ErrServerClosed)
+	_, err = os.Stat(socketPath)
+	assert.True(t, os.IsNotExist(err), "the socket file is removed on shutdown")
+}
+
+func TestOpen_ExistingSocketPath(t *testing.T) {
+	t.Run("stale socket is replaced", func(t *testing.T) {
+		socketPath := filepath.Join(t.TempDir(), "router.sock")
+		stale, err := net.Listen("unix", socketPath)
+		require.NoError(t, err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, stale.Close())
+
+		listeners, err := Open(Config{SocketPath: socketPath, SocketMode: DefaultSocketMode})
+		require.NoError(t, err)
+		defer listeners[0].Close()
+	})
+
+	t.Run("socket in use is kept", func(t *testing.T) {
+		socketPath := filepath.Join(t.TempDir(), "router.sock")
+		active, err := net.Listen("unix", socketPath)
+		require.NoError(t, err)
+		defer active.Close()
+
+		_, err = Open(Config{SocketPath: socketPath, SocketMode: DefaultSocketMode})
+		assert.ErrorContains(t, err, "already in use")
+	})
+
+	t.Run("regular file is kept", func(t *testing.T) {
+		socketPath := filepath.Join(t.TempDir(), "router.sock")
+		require.NoError(t, os.WriteFile(socketPath, []byte("data"), 0600))
+
+		_, err := Open(Config{TCPAddr: "127.0.0.1:0", SocketPath: socketPath, SocketMode: DefaultSocketMode})
+		assert.ErrorContains(t, err, "is not a socket")
+		data, _ := os.ReadFile(socketPath)
+		assert.Equal(t, "data", string(data))
+	})
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected Config
+		err      string
+	}{
+		{
+			name:     "TCP only by default",
+			expected: Config{TCPAddr: ":8082", SocketMode: DefaultSocketMode},
+		},
+		{
+			name:     "TCP and socket",
+			env:      map[string]string{"PORT": "9000", "UNIX_SOCKET": "/run/router.sock", "UNIX_SOCKET_MODE": "0666", "UNIX_SOCKET_GROUP": "app"},
+			expected: Config{TCPAddr: ":9000", SocketPath: "/run/router.sock", SocketMode: 0666, SocketGroup: "app"},
+		},
+		{
+			name:     "socket only",
+			env:      map[string]string{"UNIX_SOCKET": "/run/router.sock", "UNIX_SOCKET_ONLY": "true"},
+			expected: Config{SocketPath: "/run/router.sock", SocketMode: DefaultSocketMode},
+		},
+		{
+			name:     "socket only without a socket keeps TCP",
+			env:      map[string]string{"UNIX_SOCKET_ONLY": "true"},
+			expected: Config{TCPAddr: ":8082", SocketMode: DefaultSocketMode},
+		},
+		{
+			name: "invalid mode",
+			env:  map[string]string{"UNIX_SOCKET": "/run/router.sock", "UNIX_SOCKET_MODE": "rw-rw----"},
+			err:  "invalid UNIX_SOCKET_MODE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"PORT", "UNIX_SOCKET", "UNIX_SOCKET_MODE", "UNIX_SOCKET_GROUP", "UNIX_SOCKET_ONLY"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			cfg, err := ConfigFromEnv()
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Request body logging modes, selected with LOG_REQUEST_BODY
+const (
+	// BodyLogSummary logs a structured summary of the body and none of its content (default)
+	BodyLogSummary = "summary"
+	// BodyLogFull logs the body itself, with base64 payloads and long strings truncated
+	BodyLogFull = "full"
+)
+
+// BodySummary describes a chat completion request body without any of its content
+type BodySummary struct {
+	Bytes               int            `json:"bytes"`
+	Model               string         `json:"model,omitempty"`
+	Stream              bool           `json:"stream,omitempty"`
+	MessageCount        int            `json:"message_count"`
+	Roles               map[string]int `json:"roles,omitempty"`
+	ContentTypes        map[string]int `json:"content_types,omitempty"`
+	ContentBytes        int            `json:"content_bytes"`
+	LargestMessageBytes int            `json:"largest_message_bytes"`
+	ToolCount           int            `json:"tool_count,omitempty"`
+	Invalid             bool           `json:"invalid_json,omitempty"`
+}
+
+// SummarizeRequestBody reads a chat completion request body and summarizes it
+// Messages are decoded one at a time, so the body never has to be held in memory whole
+// Malformed bodies are reported as Invalid with whatever was read before the error
+func SummarizeRequestBody(body io.Reader) BodySummary {
+	counter := &countingReader{r: body}
+	summary := BodySummary{}
+	if err := summarizeObject(json.NewDecoder(counter), &summary); err != nil {
+		summary.Invalid = true
+	}
+	_, _ = io.Copy(io.Discard, counter)
+	summary.Bytes = counter.n
+	return summary
+}
+
+// summarizeObject walks the top-level request object, skipping fields it does not summarize
+func summarizeObject(dec *json.Decoder, summary *BodySummary) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch key, _ := token.(string); key {
+		case "model":
+			err = dec.Decode(&summary.Model)
+		case "stream":
+			err = dec.Decode(&summary.Stream)
+		case "messages":
+			err = summarizeMessages(dec, summary)
+		case "tools":
+			var tools []json.RawMessage
+			err = dec.Decode(&tools)
+			summary.ToolCount = len(tools)
+		default:
+			var skipped json.RawMessage
+			err = dec.Decode(&skipped)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// summarizeMessages counts the messages array one message at a time
+func summarizeMessages(dec *json.Decoder, summary *BodySummary) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	summary.Roles = make(map[string]int)
+	summary.ContentTypes = make(map[string]int)
+
+	for dec.More() {
+		var message struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		}
+		if err := dec.Decode(&message); err != nil {
+			return err
+		}
+		summary.MessageCount++
+		summary.Roles[message.Role]++
+
+		size := summarizeContent(message.Content, summary.ContentTypes)
+		summary.ContentBytes += size
+		if size > summary.LargestMessageBytes {
+			summary.LargestMessageBytes = size
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// summarizeContent counts the content parts of one message by type and returns the
+// size of its content in bytes; string content counts as a single "text" part
+func summarizeContent(content json.RawMessage, types map[string]int) int {
+	content = bytes.TrimSpace(content)
+	if len(content) == 0 || string(content) == "null" {
+		return 0
+	}
+
+	var text string
+	if json.Unmarshal(content, &text) == nil {
+		types["text"]++
+		return len(text)
+	}
+
+	var parts []struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(content, &parts) == nil {
+		for _, part := range parts {
+			types[part.Type]++
+		}
+	}
+	return len(content)
+}
+
+// expectDelim reads the next token and checks it opens the expected JSON value
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %s, got %v", delim, token)
+	}
+	return nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+// Read implements io.Reader
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+// requestBodyValue defers describing a request body until a log record is written,
+// so bodies of records below the log level are never parsed
+type requestBodyValue struct {
+	body []byte
+}
+
+// RequestBody returns a log attribute value describing a request body according to
+// LOG_REQUEST_BODY: a BodySummary by default, or the truncated body in "full" mode
+func RequestBody(body []byte) slog.LogValuer {
+	return requestBodyValue{body: body}
+}
+
+// LogValue implements slog.LogValuer; StructuredJSONHandler describes the body with the
+// record's context instead, so that body sampling applies
+func (v requestBodyValue) LogValue() slog.Value {
+	return slog.AnyValue(DescribeRequestBody(context.Background(), v.body))
+}
+
+// resolveValue resolves a log attribute value, describing deferred request bodies with ctx
+func resolveValue(ctx context.Context, value slog.Value) interface{} {
+	if value.Kind() == slog.KindLogValuer {
+		if body, ok := value.LogValuer().(requestBodyValue); ok {
+			return DescribeRequestBody(ctx, body.body)
+		}
+	}
+	return value.Resolve().Any()
+}
+
+// DescribeRequestBody describes a request body according to LOG_REQUEST_BODY right away,
+// for callers that place the description inside a larger log object
+func DescribeRequestBody(ctx context.Context, body []byte) interface{} {
+	return DescribeRequestBodyReader(ctx, bytes.NewReader(body))
+}
+
+// DescribeRequestBodyReader is DescribeRequestBody for a body read from a stream; in
+// summary mode the body is consumed as it is summarized rather than buffered first
+// Full mode only logs the bodies of sampled requests (see WithBodySampling) up to
+// LOG_MAX_BODY_BYTES; other bodies are summarized
+func DescribeRequestBodyReader(ctx context.Context, body io.Reader) interface{} {
+	if requestBodyLogMode() != BodyLogFull || !BodySampled(ctx) {
+		return SummarizeRequestBody(body)
+	}
+
+	limited := body
+	limit := maxLogBodyBytes()
+	if limit > 0 {
+		limited = io.LimitReader(body, int64(limit)+1)
+	}
+	data, err := io.ReadAll(limited)
+	if err != nil {
+		return nil
+	}
+	if limit > 0 && len(data) > limit {
+		return SummarizeRequestBody(io.MultiReader(bytes.NewReader(data), body))
+	}
+	var parsed interface{}
+	if json.Unmarshal(data, &parsed) == nil {
+		return utils.TruncateBase64InData(parsed)
+	}
+	return string(data)
+}
+
+// requestBodyLogMode returns the LOG_REQUEST_BODY mode, defaulting to summary
+func requestBodyLogMode() string {
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("LOG_REQUEST_BODY")), BodyLogFull) {
+		return BodyLogFull
+	}
+	return BodyLogSummary
+}
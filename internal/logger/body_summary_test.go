@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeRequestBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected BodySummary
+	}{
+		{
+			name: "text and multimodal messages",
+			body: `{"model":"gpt-4o","stream":true,"temperature":0.2,"messages":[
+				{"role":"system","content":"Be brief."},
+				{"role":"user","content":[{"type":"text","text":"What is this?"},{"type":"image_url","image_url":{"url":"data:image/png;base64,iVBORw0KGgo="}}]},
+				{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{}"}}]},
+				{"role":"tool","tool_call_id":"call_1","content":"a cat"}
+			],"tools":[{"type":"function","function":{"name":"lookup"}}]}`,
+			expected: BodySummary{
+				Model:               "gpt-4o",
+				Stream:              true,
+				MessageCount:        4,
+				Roles:               map[string]int{"system": 1, "user": 1, "assistant": 1, "tool": 1},
+				ContentTypes:        map[string]int{"text": 3, "image_url": 1},
+				ContentBytes:        9 + 118 + 5,
+				LargestMessageBytes: 118,
+				ToolCount:           1,
+			},
+		},
+		{
+			name:     "malformed body keeps what was read",
+			body:     `{"model":"gemini-2.5-pro","messages":[{"role":"user","content":"hi"},`,
+			expected: BodySummary{Model: "gemini-2.5-pro", MessageCount: 1, Roles: map[string]int{"user": 1}, ContentTypes: map[string]int{"text": 1}, ContentBytes: 2, LargestMessageBytes: 2, Invalid: true},
+		},
+		{
+			name:     "not an object",
+			body:     `[1,2,3]`,
+			expected: BodySummary{Invalid: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.expected.Bytes = len(tt.body)
+			assert.Equal(t, tt.expected, SummarizeRequestBody(strings.NewReader(tt.body)))
+		})
+	}
+}
+
+func TestDescribeRequestBody(t *testing.T) {
+	body := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"my secret prompt"}]}`)
+
+	t.Setenv("LOG_REQUEST_BODY", "")
+	summary, ok := DescribeRequestBody(context.Background(), body).(BodySummary)
+	require.True(t, ok, "summary is the default")
+	assert.Equal(t, 1, summary.MessageCount)
+
+	t.Setenv("LOG_REQUEST_BODY", "FULL")
+	full, ok := DescribeRequestBody(context.Background(), body).(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "gpt-4o", full["model"])
+}
+
+func TestRequestBody_DeferredUntilWritten(t *testing.T) {
+	t.Setenv("LOG_REQUEST_BODY", "")
+	var out bytes.Buffer
+	log := slog.New(NewStructuredJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	body := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"my secret prompt"}]}`)
+
+	log.DebugContext(context.Background(), "below the level", "request_body", RequestBody(body))
+	assert.Zero(t, out.Len())
+
+	log.InfoContext(context.Background(), "Sending", "request_body", RequestBody(body))
+	assert.NotContains(t, out.String(), "my secret prompt")
+
+	var entry struct {
+		Attributes map[string]BodySummary `json:"attributes"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "gpt-4o", entry.Attributes["request_body"].Model)
+	assert.Equal(t, 16, entry.Attributes["request_body"].ContentBytes)
+}
@@ -23,6 +23,10 @@ const (
 	CorrelationIDKey ContextKey = "correlation_id"
 	ComponentKey     ContextKey = "component"
 	StageKey         ContextKey = "stage"
+	// UserKey holds the hashed end-user identifier of the request, from the OpenAI "user" field
+	UserKey ContextKey = "user"
+	// ClientIDKey holds the client identity the request's API key authenticated as
+	ClientIDKey ContextKey = "client_id"
 )
 
 var (
@@ -50,6 +54,7 @@ func Init(writer io.Writer, level slog.Level, appVersion, appServiceName, appEnv
 		handler := NewStructuredJSONHandler(writer, &slog.HandlerOptions{
 			Level: level,
 		})
+		handler.componentLevels = componentLevels
 		globalLogger = slog.New(handler)
 	})
 }
@@ -59,6 +64,9 @@ func Log(ctx context.Context, level slog.Level, msg string, attrs ...any) {
 	if globalLogger == nil {
 		Init(os.Stdout, slog.LevelInfo, os.Getenv("VERSION"), os.Getenv("SERVICE_NAME"), os.Getenv("ENVIRONMENT"))
 	}
+	if hold(ctx, level, msg, attrs) {
+		return
+	}
 	globalLogger.Log(ctx, level, msg, attrs...)
 }
 
@@ -66,6 +74,8 @@ func Log(ctx context.Context, level slog.Level, msg string, attrs ...any) {
 type StructuredJSONHandler struct {
 	handler slog.Handler
 	writer  io.Writer
+	// componentLevels overrides the minimum level for the components it names
+	componentLevels map[string]slog.Level
 }
 
 // NewStructuredJSONHandler creates a new handler.
@@ -77,18 +87,26 @@ func NewStructuredJSONHandler(w io.Writer, opts *slog.HandlerOptions) *Structure
 }
 
 // Enabled reports whether the handler handles records at the given level.
+// A level override for the component logging takes precedence over the handler's level.
 func (h *StructuredJSONHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if len(h.componentLevels) > 0 && ctx != nil {
+		if component, ok := ctx.Value(ComponentKey).(string); ok {
+			if minLevel, ok := h.componentLevels[strings.ToLower(component)]; ok {
+				return level >= minLevel
+			}
+		}
+	}
 	return h.handler.Enabled(ctx, level)
 }
 
 // WithAttrs returns a new handler with the given attributes.
 func (h *StructuredJSONHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &StructuredJSONHandler{handler: h.handler.WithAttrs(attrs), writer: h.writer}
+	return &StructuredJSONHandler{handler: h.handler.WithAttrs(attrs), writer: h.writer, componentLevels: h.componentLevels}
 }
 
 // WithGroup returns a new handler with the given group name.
 func (h *StructuredJSONHandler) WithGroup(name string) slog.Handler {
-	return &StructuredJSONHandler{handler: h.handler.WithGroup(name), writer: h.writer}
+	return &StructuredJSONHandler{handler: h.handler.WithGroup(name), writer: h.writer, componentLevels: h.componentLevels}
 }
 
 // Handle processes the given log record and writes it to the output.
@@ -133,11 +151,18 @@ func (h *StructuredJSONHandler) Handle(ctx context.Context, r slog.Record) error
 
 	// Process attributes and categorize them
 	attributes := make(map[string]interface{})
+	if user, ok := ctx.Value(UserKey).(string); ok && user != "" {
+		attributes["user"] = user
+	}
+	if clientID, ok := ctx.Value(ClientIDKey).(string); ok && clientID != "" {
+		attributes["client_id"] = clientID
+	}
 	var requestData, responseData map[string]interface{}
 	var errorData error
 
 	r.Attrs(func(a slog.Attr) bool {
-		val := a.Value.Any()
+		// Resolve deferred values such as RequestBody now that the record is being written
+		val := resolveValue(ctx, a.Value)
 
 		switch a.Key {
 		case "error":
@@ -235,27 +260,46 @@ func WithStage(ctx context.Context, stage string) context.Context {
 	return context.WithValue(ctx, StageKey, stage)
 }
 
+// WithUser returns a new context whose log entries carry the hashed end-user identifier.
+func WithUser(ctx context.Context, userHash string) context.Context {
+	return context.WithValue(ctx, UserKey, userHash)
+}
+
+// WithClientID returns a new context whose log entries carry the authenticated client identity.
+func WithClientID(ctx context.Context, clientID string) context.Context {
+	return context.WithValue(ctx, ClientIDKey, clientID)
+}
+
 // InitFromEnv initializes the logger from environment variables.
 func InitFromEnv() {
-	logLevel := slog.LevelInfo
-	if levelStr := os.Getenv("LOG_LEVEL"); levelStr != "" {
-		switch strings.ToUpper(levelStr) {
-		case "DEBUG":
-			logLevel = slog.LevelDebug
-		case "WARN":
-			logLevel = slog.LevelWarn
-		case "ERROR":
-			logLevel = slog.LevelError
-		}
-	}
+	logLevel, _ := parseLevel(os.Getenv("LOG_LEVEL"))
+	componentLevels = ParseComponentLevels(os.Getenv("LOG_LEVEL_OVERRIDES"))
 
-	output := os.Stdout
+	var output io.Writer = os.Stdout
+	var sinks []Sink
 	if logFile := os.Getenv("LOG_OUTPUT"); logFile != "" && logFile != "stdout" {
-		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		f, err := OpenRotatingFile(logFile,
+			int64(utils.GetEnvInt("LOG_FILE_MAX_BYTES", 0)),
+			utils.GetEnvInt("LOG_FILE_MAX_BACKUPS", DefaultFileBackups),
+		)
 		if err == nil {
 			output = f
+			sinks = append(sinks, f)
+		}
+	}
+
+	// Export sinks receive every entry in addition to LOG_OUTPUT
+	if exporters := exportSinksFromEnv(); len(exporters) > 0 {
+		writers := fanout{output}
+		for _, exporter := range exporters {
+			writers = append(writers, exporter)
 		}
+		output = writers
+		sinks = append(sinks, exporters...)
 	}
+	sinksMu.Lock()
+	openSinks = append(openSinks, sinks...)
+	sinksMu.Unlock()
 
 	Init(
 		output,
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// LokiExporter pushes log lines to Grafana Loki's push API. Lines are grouped into one
+// stream per level, labelled with the service, environment and level plus Labels
+type LokiExporter struct {
+	// URL is the push endpoint, e.g. http://loki:3100/loki/api/v1/push
+	URL    string
+	Labels map[string]string
+	// TenantID is sent as X-Scope-OrgID to multi-tenant Loki deployments
+	TenantID string
+	Client   *http.Client
+}
+
+// lokiStream is one labelled stream of a Loki push request
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// Push sends one batch of log lines
+func (e *LokiExporter) Push(lines [][]byte) error {
+	streams := make(map[LogLevel]*lokiStream)
+	var order []LogLevel
+	for _, line := range lines {
+		var entry exportedEntry
+		_ = json.Unmarshal(line, &entry)
+		stream, ok := streams[entry.Level]
+		if !ok {
+			stream = &lokiStream{Stream: e.streamLabels(entry.Level)}
+			streams[entry.Level] = stream
+			order = append(order, entry.Level)
+		}
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(entry.timeUnixNano(), 10), string(line)})
+	}
+
+	payload := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, level := range order {
+		payload.Streams = append(payload.Streams, streams[level])
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	var headers map[string]string
+	if e.TenantID != "" {
+		headers = map[string]string{"X-Scope-OrgID": e.TenantID}
+	}
+	return postJSON(e.Client, e.URL, headers, body)
+}
+
+// streamLabels returns the labels of the stream holding entries of level
+func (e *LokiExporter) streamLabels(level LogLevel) map[string]string {
+	labels := map[string]string{
+		"service":     serviceName,
+		"environment": environment,
+		"level":       strings.ToLower(string(level)),
+	}
+	for key, value := range e.Labels {
+		labels[key] = value
+	}
+	return labels
+}
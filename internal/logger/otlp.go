@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// OTLPExporter pushes log lines to an OpenTelemetry collector's OTLP/HTTP logs endpoint,
+// using the JSON encoding. Each line becomes a log record whose body is the line itself,
+// with the entry's time, severity, component and request ID set on the record
+type OTLPExporter struct {
+	// Endpoint is the full logs URL, e.g. http://collector:4318/v1/logs
+	Endpoint string
+	Headers  map[string]string
+	Client   *http.Client
+}
+
+// otlpValue is an OTLP AnyValue holding a string
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// otlpAttribute is an OTLP KeyValue
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpLogRecord is an OTLP LogRecord; 64-bit integers are strings in OTLP's JSON encoding
+type otlpLogRecord struct {
+	TimeUnixNano   string          `json:"timeUnixNano"`
+	SeverityNumber int             `json:"severityNumber"`
+	SeverityText   string          `json:"severityText"`
+	Body           otlpValue       `json:"body"`
+	Attributes     []otlpAttribute `json:"attributes,omitempty"`
+}
+
+// otlpSeverity maps log levels to OTLP severity numbers
+var otlpSeverity = map[LogLevel]int{
+	LevelDEBUG: 5,
+	LevelINFO:  9,
+	LevelWARN:  13,
+	LevelERROR: 17,
+}
+
+// Push sends one batch of log lines as an ExportLogsServiceRequest
+func (e *OTLPExporter) Push(lines [][]byte) error {
+	records := make([]otlpLogRecord, 0, len(lines))
+	for _, line := range lines {
+		var entry exportedEntry
+		_ = json.Unmarshal(line, &entry)
+		record := otlpLogRecord{
+			TimeUnixNano:   strconv.FormatInt(entry.timeUnixNano(), 10),
+			SeverityNumber: otlpSeverity[entry.Level],
+			SeverityText:   string(entry.Level),
+			Body:           otlpValue{StringValue: string(line)},
+		}
+		if entry.Component != "" {
+			record.Attributes = append(record.Attributes, otlpAttribute{Key: "component", Value: otlpValue{entry.Component}})
+		}
+		if entry.Request != nil && entry.Request.RequestID != "" {
+			record.Attributes = append(record.Attributes, otlpAttribute{Key: "request_id", Value: otlpValue{entry.Request.RequestID}})
+		}
+		records = append(records, record)
+	}
+
+	payload := map[string]interface{}{
+		"resourceLogs": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []otlpAttribute{
+					{Key: "service.name", Value: otlpValue{serviceName}},
+					{Key: "service.version", Value: otlpValue{version}},
+					{Key: "deployment.environment", Value: otlpValue{environment}},
+				},
+			},
+			"scopeLogs": []interface{}{map[string]interface{}{
+				"scope":      map[string]string{"name": serviceName},
+				"logRecords": records,
+			}},
+		}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return postJSON(e.Client, e.Endpoint, e.Headers, body)
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "router.log")
+	require.NoError(t, os.WriteFile(path, []byte("old\n"), 0666))
+
+	f, err := OpenRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+
+	read := func(name string) string {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "fourth\n", read(path))
+	assert.Equal(t, "third\n", read(path+".1"))
+	assert.Equal(t, "second\n", read(path+".2"))
+	assert.NoFileExists(t, path+".3", "only two backups are kept")
+}
+
+func TestRotatingFileWithoutLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "router.log")
+	f, err := OpenRotatingFile(path, 0, DefaultFileBackups)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		_, err := f.Write([]byte("a log line\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+	assert.NoFileExists(t, path+".1")
+}
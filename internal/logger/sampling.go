@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// bodySampledKey is the context key marking whether a request's bodies are logged in full
+const bodySampledKey ContextKey = "body_sampled"
+
+// bodySampleCounter counts the requests WithBodySampling has seen, to pick every Nth one
+var bodySampleCounter atomic.Uint64
+
+// componentLevels holds the LOG_LEVEL_OVERRIDES minimum levels by lower-cased component,
+// read by InitFromEnv before the global handler is built
+var componentLevels map[string]slog.Level
+
+// WithBodySampling decides whether the request served with ctx is one of the 1 in
+// LOG_BODY_SAMPLE_RATE requests whose bodies are logged in full; the others only get
+// summaries. A rate of 1, the default, samples every request
+func WithBodySampling(ctx context.Context) context.Context {
+	rate := uint64(utils.GetEnvInt("LOG_BODY_SAMPLE_RATE", 1))
+	if rate <= 1 {
+		return context.WithValue(ctx, bodySampledKey, true)
+	}
+	return context.WithValue(ctx, bodySampledKey, bodySampleCounter.Add(1)%rate == 1)
+}
+
+// BodySampled reports whether bodies logged with ctx may be logged in full; contexts
+// outside of a sampled request, e.g. startup, always may
+func BodySampled(ctx context.Context) bool {
+	if ctx == nil {
+		return true
+	}
+	sampled, ok := ctx.Value(bodySampledKey).(bool)
+	return !ok || sampled
+}
+
+// maxLogBodyBytes returns LOG_MAX_BODY_BYTES, the size above which bodies are not logged
+// in full, or 0 for no limit
+func maxLogBodyBytes() int {
+	return utils.GetEnvInt("LOG_MAX_BODY_BYTES", 0)
+}
+
+// DescribeResponseBody describes a response body for a log entry: the parsed body with
+// base64 payloads truncated for sampled requests, a note of its size when it is larger
+// than LOG_MAX_BODY_BYTES, and nil for requests that were not sampled
+func DescribeResponseBody(ctx context.Context, body []byte) interface{} {
+	if len(body) == 0 || !BodySampled(ctx) {
+		return nil
+	}
+	if limit := maxLogBodyBytes(); limit > 0 && len(body) > limit {
+		return fmt.Sprintf("[%d bytes, larger than LOG_MAX_BODY_BYTES]", len(body))
+	}
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil
+	}
+	return utils.TruncateBase64InData(parsed)
+}
+
+// ParseComponentLevels parses LOG_LEVEL_OVERRIDES, a comma-separated list of
+// component=level pairs such as "proxy=warn,StreamProcessor=debug". Component names are
+// matched case-insensitively; malformed pairs are skipped
+func ParseComponentLevels(value string) map[string]slog.Level {
+	levels := make(map[string]slog.Level)
+	for _, pair := range strings.Split(value, ",") {
+		component, levelStr, ok := strings.Cut(pair, "=")
+		component = strings.ToLower(strings.TrimSpace(component))
+		if !ok || component == "" {
+			continue
+		}
+		if level, ok := parseLevel(levelStr); ok {
+			levels[component] = level
+		}
+	}
+	return levels
+}
+
+// parseLevel parses a LOG_LEVEL value
+func parseLevel(value string) (slog.Level, bool) {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "DEBUG":
+		return slog.LevelDebug, true
+	case "INFO":
+		return slog.LevelInfo, true
+	case "WARN":
+		return slog.LevelWarn, true
+	case "ERROR":
+		return slog.LevelError, true
+	}
+	return slog.LevelInfo, false
+}
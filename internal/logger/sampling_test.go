@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBodySampling(t *testing.T) {
+	assert.True(t, BodySampled(context.Background()), "contexts outside a request are not sampled out")
+
+	t.Setenv("LOG_BODY_SAMPLE_RATE", "")
+	assert.True(t, BodySampled(WithBodySampling(context.Background())))
+
+	t.Setenv("LOG_BODY_SAMPLE_RATE", "3")
+	sampled := 0
+	for i := 0; i < 9; i++ {
+		if BodySampled(WithBodySampling(context.Background())) {
+			sampled++
+		}
+	}
+	assert.Equal(t, 3, sampled)
+}
+
+func TestDescribeRequestBodySamplingAndLimit(t *testing.T) {
+	body := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"my secret prompt"}]}`)
+	t.Setenv("LOG_REQUEST_BODY", "full")
+	t.Setenv("LOG_BODY_SAMPLE_RATE", "1000000")
+	t.Setenv("LOG_MAX_BODY_BYTES", "")
+
+	sampledOut := context.WithValue(context.Background(), bodySampledKey, false)
+	_, ok := DescribeRequestBody(sampledOut, body).(BodySummary)
+	assert.True(t, ok, "requests that were not sampled get a summary")
+
+	_, ok = DescribeRequestBody(context.Background(), body).(map[string]interface{})
+	assert.True(t, ok)
+
+	t.Setenv("LOG_MAX_BODY_BYTES", "20")
+	summary, ok := DescribeRequestBody(context.Background(), body).(BodySummary)
+	require.True(t, ok, "bodies over the limit get a summary")
+	assert.Equal(t, len(body), summary.Bytes)
+	assert.Equal(t, 1, summary.MessageCount)
+}
+
+func TestDescribeResponseBody(t *testing.T) {
+	body := []byte(`{"id":"chatcmpl-1","object":"chat.completion"}`)
+	t.Setenv("LOG_MAX_BODY_BYTES", "")
+
+	described, ok := DescribeResponseBody(context.Background(), body).(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "chatcmpl-1", described["id"])
+
+	assert.Nil(t, DescribeResponseBody(context.WithValue(context.Background(), bodySampledKey, false), body))
+	assert.Nil(t, DescribeResponseBody(context.Background(), []byte("not json")))
+
+	t.Setenv("LOG_MAX_BODY_BYTES", "10")
+	assert.Equal(t, "[46 bytes, larger than LOG_MAX_BODY_BYTES]", DescribeResponseBody(context.Background(), body))
+}
+
+func TestParseComponentLevels(t *testing.T) {
+	levels := ParseComponentLevels("proxy=warn, StreamProcessor=DEBUG,broken,cache=loud,=info")
+	assert.Equal(t, map[string]slog.Level{
+		"proxy":           slog.LevelWarn,
+		"streamprocessor": slog.LevelDebug,
+	}, levels)
+}
+
+func TestComponentLevelOverrides(t *testing.T) {
+	var out bytes.Buffer
+	handler := NewStructuredJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo})
+	handler.componentLevels = ParseComponentLevels("proxy=warn,StreamProcessor=debug")
+	log := slog.New(handler)
+
+	log.InfoContext(WithComponent(context.Background(), "Proxy"), "quieted")
+	log.DebugContext(WithComponent(context.Background(), "StreamProcessor"), "raised")
+	log.DebugContext(WithComponent(context.Background(), "Middleware"), "below the level")
+	log.InfoContext(context.Background(), "at the level")
+
+	assert.NotContains(t, out.String(), "quieted")
+	assert.Contains(t, out.String(), "raised")
+	assert.NotContains(t, out.String(), "below the level")
+	assert.Contains(t, out.String(), "at the level")
+}
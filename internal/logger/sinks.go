@@ -0,0 +1,259 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Defaults of the batching export sinks
+const (
+	DefaultExportBatchSize = 100
+	DefaultExportInterval  = time.Second
+	// exportQueueBatches is how many batches a sink queues while its endpoint is slow or
+	// down; lines beyond that are dropped
+	exportQueueBatches = 10
+)
+
+// Sink receives every log entry as one JSON line per Write
+type Sink interface {
+	io.Writer
+	// Close writes out whatever the sink still holds
+	Close() error
+}
+
+var (
+	sinksMu   sync.Mutex
+	openSinks []Sink
+)
+
+// Close flushes and closes the sinks InitFromEnv opened, so that exported entries are not
+// lost when the process exits
+func Close() {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	for _, sink := range openSinks {
+		if err := sink.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to close log sink: %v\n", err)
+		}
+	}
+	openSinks = nil
+}
+
+// exportSinksFromEnv opens the sinks LOG_EXPORT lists: otlp and loki
+func exportSinksFromEnv() []Sink {
+	batchSize := utils.GetEnvInt("LOG_EXPORT_BATCH_SIZE", DefaultExportBatchSize)
+	interval := time.Duration(utils.GetEnvInt("LOG_EXPORT_INTERVAL_MS", int(DefaultExportInterval/time.Millisecond))) * time.Millisecond
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	var sinks []Sink
+	for _, name := range utils.GetEnvStringSlice("LOG_EXPORT", nil) {
+		switch strings.ToLower(name) {
+		case "otlp":
+			exporter := &OTLPExporter{
+				Endpoint: utils.GetEnvString("LOG_OTLP_ENDPOINT", "http://localhost:4318/v1/logs"),
+				Headers:  parseKeyValues(os.Getenv("LOG_OTLP_HEADERS")),
+				Client:   client,
+			}
+			sinks = append(sinks, NewBatchSink(exporter.Push, batchSize, interval))
+		case "loki":
+			exporter := &LokiExporter{
+				URL:      utils.GetEnvString("LOG_LOKI_URL", "http://localhost:3100/loki/api/v1/push"),
+				Labels:   parseKeyValues(os.Getenv("LOG_LOKI_LABELS")),
+				TenantID: os.Getenv("LOG_LOKI_TENANT_ID"),
+				Client:   client,
+			}
+			sinks = append(sinks, NewBatchSink(exporter.Push, batchSize, interval))
+		default:
+			fmt.Fprintf(os.Stderr, "unknown LOG_EXPORT sink %q, expected otlp or loki\n", name)
+		}
+	}
+	return sinks
+}
+
+// parseKeyValues parses a comma-separated list of key=value pairs
+func parseKeyValues(value string) map[string]string {
+	pairs := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); ok && key != "" {
+			pairs[key] = strings.TrimSpace(val)
+		}
+	}
+	return pairs
+}
+
+// fanout writes every entry to all of its writers; one failing writer does not keep the
+// entry from the others
+type fanout []io.Writer
+
+// Write implements io.Writer
+func (f fanout) Write(p []byte) (int, error) {
+	var firstErr error
+	for _, w := range f {
+		if _, err := w.Write(p); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return len(p), firstErr
+}
+
+// BatchSink collects log lines and pushes them in batches from a background goroutine,
+// every interval or as soon as a batch is full, so that logging never waits on the network
+type BatchSink struct {
+	push      func(lines [][]byte) error
+	batchSize int
+
+	mu      sync.Mutex
+	lines   [][]byte
+	dropped int
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewBatchSink starts a sink handing batches of at most batchSize lines to push
+func NewBatchSink(push func(lines [][]byte) error, batchSize int, interval time.Duration) *BatchSink {
+	if batchSize <= 0 {
+		batchSize = DefaultExportBatchSize
+	}
+	if interval <= 0 {
+		interval = DefaultExportInterval
+	}
+	s := &BatchSink{
+		push:      push,
+		batchSize: batchSize,
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go s.run(interval)
+	return s
+}
+
+// Write queues one log line
+func (s *BatchSink) Write(p []byte) (int, error) {
+	line := bytes.TrimRight(p, "\n")
+	if len(line) == 0 {
+		return len(p), nil
+	}
+
+	s.mu.Lock()
+	if len(s.lines) >= s.batchSize*exportQueueBatches {
+		s.dropped++
+		s.mu.Unlock()
+		return len(p), nil
+	}
+	s.lines = append(s.lines, append([]byte(nil), line...))
+	full := len(s.lines) >= s.batchSize
+	s.mu.Unlock()
+
+	if full {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Close pushes the queued lines and stops the background goroutine
+func (s *BatchSink) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	return nil
+}
+
+// run pushes batches until the sink is closed, then pushes what is left
+func (s *BatchSink) run(interval time.Duration) {
+	defer close(s.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-s.stop:
+			s.flush()
+			return
+		}
+		s.flush()
+	}
+}
+
+// flush pushes every queued line, a batch at a time. Push failures are reported on stderr,
+// since logging them would queue more lines for the failing sink
+func (s *BatchSink) flush() {
+	for {
+		s.mu.Lock()
+		n := min(len(s.lines), s.batchSize)
+		batch := s.lines[:n:n]
+		s.lines = s.lines[n:]
+		dropped := s.dropped
+		s.dropped = 0
+		s.mu.Unlock()
+
+		if dropped > 0 {
+			fmt.Fprintf(os.Stderr, "log export queue full, dropped %d entries\n", dropped)
+		}
+		if n == 0 {
+			return
+		}
+		if err := s.push(batch); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to export %d log entries: %v\n", n, err)
+		}
+	}
+}
+
+// postJSON sends a JSON export request and fails on any non-2xx answer
+func postJSON(client *http.Client, url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s answered %d: %s", url, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// exportedEntry holds the fields of a log line the export sinks need
+type exportedEntry struct {
+	Timestamp string   `json:"timestamp"`
+	Level     LogLevel `json:"level"`
+	Message   string   `json:"message"`
+	Component string   `json:"component"`
+	Request   *struct {
+		RequestID string `json:"request_id"`
+	} `json:"request"`
+}
+
+// timeUnixNano returns the entry's timestamp in nanoseconds, or now when it has none
+func (e exportedEntry) timeUnixNano() int64 {
+	if t, err := time.Parse(time.RFC3339Nano, e.Timestamp); err == nil {
+		return t.UnixNano()
+	}
+	return time.Now().UnixNano()
+}
@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	infoLine  = `{"environment":"test","level":"INFO","message":"Processing request","timestamp":"2026-10-17T15:04:05.000000001Z","service":"router","component":"proxy","request":{"request_id":"req-1"}}`
+	errorLine = `{"environment":"test","level":"ERROR","message":"Vendor failed","timestamp":"2026-10-17T15:04:06Z","service":"router"}`
+)
+
+// captureServer records the headers and JSON bodies of the requests it receives
+func captureServer(t *testing.T, status int) (*httptest.Server, func() ([]http.Header, []map[string]interface{})) {
+	var mu sync.Mutex
+	var headers []http.Header
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &body))
+		mu.Lock()
+		headers = append(headers, r.Header.Clone())
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() ([]http.Header, []map[string]interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		return headers, bodies
+	}
+}
+
+func TestOTLPExporterPush(t *testing.T) {
+	server, received := captureServer(t, http.StatusOK)
+	exporter := &OTLPExporter{Endpoint: server.URL + "/v1/logs", Headers: map[string]string{"Authorization": "Bearer token"}}
+
+	require.NoError(t, exporter.Push([][]byte{[]byte(infoLine), []byte(errorLine)}))
+
+	headers, bodies := received()
+	require.Len(t, bodies, 1)
+	assert.Equal(t, "Bearer token", headers[0].Get("Authorization"))
+	assert.Equal(t, "application/json", headers[0].Get("Content-Type"))
+
+	resourceLogs := bodies[0]["resourceLogs"].([]interface{})[0].(map[string]interface{})
+	scopeLogs := resourceLogs["scopeLogs"].([]interface{})[0].(map[string]interface{})
+	records := scopeLogs["logRecords"].([]interface{})
+	require.Len(t, records, 2)
+
+	first := records[0].(map[string]interface{})
+	assert.Equal(t, "1792249445000000001", first["timeUnixNano"])
+	assert.Equal(t, float64(9), first["severityNumber"])
+	assert.Equal(t, "INFO", first["severityText"])
+	assert.Equal(t, infoLine, first["body"].(map[string]interface{})["stringValue"])
+	assert.Len(t, first["attributes"], 2)
+	assert.Equal(t, float64(17), records[1].(map[string]interface{})["severityNumber"])
+}
+
+func TestLokiExporterPush(t *testing.T) {
+	server, received := captureServer(t, http.StatusNoContent)
+	exporter := &LokiExporter{URL: server.URL, Labels: map[string]string{"cluster": "eu-1"}, TenantID: "team-a"}
+
+	require.NoError(t, exporter.Push([][]byte{[]byte(infoLine), []byte(errorLine), []byte(infoLine)}))
+
+	headers, bodies := received()
+	require.Len(t, bodies, 1)
+	assert.Equal(t, "team-a", headers[0].Get("X-Scope-OrgID"))
+
+	streams := bodies[0]["streams"].([]interface{})
+	require.Len(t, streams, 2, "one stream per level")
+	info := streams[0].(map[string]interface{})
+	assert.Equal(t, "info", info["stream"].(map[string]interface{})["level"])
+	assert.Equal(t, "eu-1", info["stream"].(map[string]interface{})["cluster"])
+	values := info["values"].([]interface{})
+	require.Len(t, values, 2)
+	assert.Equal(t, []interface{}{"1792249445000000001", infoLine}, values[0])
+}
+
+func TestExporterPushFailsOnErrorStatus(t *testing.T) {
+	server, _ := captureServer(t, http.StatusBadRequest)
+	err := (&LokiExporter{URL: server.URL}).Push([][]byte{[]byte(infoLine)})
+	assert.ErrorContains(t, err, "answered 400")
+}
+
+func TestBatchSink(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][][]byte
+	push := func(lines [][]byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, lines)
+		return nil
+	}
+	sink := NewBatchSink(push, 2, time.Hour)
+
+	for _, line := range []string{"a\n", "b\n"} {
+		_, err := sink.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(batches) == 1
+	}, time.Second, 5*time.Millisecond, "a full batch is pushed right away")
+	_, err := sink.Write([]byte("c\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Close())
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, batches, 2, "close pushes what is left")
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("b")}, batches[0])
+	assert.Equal(t, [][]byte{[]byte("c")}, batches[1])
+}
+
+func TestBatchSinkDropsWhenQueueIsFull(t *testing.T) {
+	release := make(chan struct{})
+	var pushed int
+	push := func(lines [][]byte) error {
+		<-release
+		pushed += len(lines)
+		return errors.New("unreachable")
+	}
+	sink := NewBatchSink(push, 1, time.Hour)
+
+	for i := 0; i < 5*exportQueueBatches; i++ {
+		_, err := sink.Write([]byte("line"))
+		require.NoError(t, err)
+	}
+	close(release)
+	require.NoError(t, sink.Close())
+	assert.Less(t, pushed, 5*exportQueueBatches)
+}
+
+func TestFanoutKeepsWritingPastFailures(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	sink := NewBatchSink(func(lines [][]byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, line := range lines {
+			got = append(got, string(line))
+		}
+		return nil
+	}, 10, time.Hour)
+
+	closed, err := OpenRotatingFile(t.TempDir()+"/closed.log", 0, 0)
+	require.NoError(t, err)
+	require.NoError(t, closed.Close())
+
+	_, err = fanout{closed, sink}.Write([]byte("entry\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+	require.NoError(t, sink.Close())
+	assert.Equal(t, []string{"entry"}, got)
+}
+
+func TestParseKeyValues(t *testing.T) {
+	assert.Equal(t, map[string]string{"Authorization": "Bearer x=y", "team": "ai"},
+		parseKeyValues(" Authorization = Bearer x=y,team=ai,broken,=empty"))
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// MaxTraceEntries caps how many log entries a request trace keeps; later ones are counted
+// as dropped, so a long stream cannot grow the buffer without bound
+const MaxTraceEntries = 500
+
+// traceKey is the context key of the request trace
+const traceKey ContextKey = "trace"
+
+// TraceEntry is one log entry held back by a request trace
+type TraceEntry struct {
+	Time       string                 `json:"time"`
+	Level      string                 `json:"level"`
+	Message    string                 `json:"message"`
+	Component  string                 `json:"component,omitempty"`
+	Stage      string                 `json:"stage,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Trace holds back the Info and Debug entries logged while serving one request, so that
+// they are written only if the request turns out to be slow. Warnings and errors are never
+// held back
+type Trace struct {
+	// verbose keeps Debug entries even when LOG_LEVEL would discard them
+	verbose bool
+
+	mu      sync.Mutex
+	entries []TraceEntry
+	dropped int
+}
+
+// WithTrace returns a context whose Info and Debug entries are held by a new trace instead
+// of being written. With verbose, entries below LOG_LEVEL are kept as well
+func WithTrace(ctx context.Context, verbose bool) (context.Context, *Trace) {
+	trace := &Trace{verbose: verbose}
+	return context.WithValue(ctx, traceKey, trace), trace
+}
+
+// TraceFromContext returns the request trace, or nil when entries are written as logged
+func TraceFromContext(ctx context.Context) *Trace {
+	if ctx == nil {
+		return nil
+	}
+	trace, _ := ctx.Value(traceKey).(*Trace)
+	return trace
+}
+
+// Entries returns the held entries in the order they were logged and how many were dropped
+// once the trace was full
+func (t *Trace) Entries() ([]TraceEntry, int) {
+	if t == nil {
+		return nil, 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TraceEntry(nil), t.entries...), t.dropped
+}
+
+// hold keeps an entry the request's trace should hold instead of it being written; it
+// reports false when the entry is to be written as usual
+func hold(ctx context.Context, level slog.Level, msg string, attrs []any) bool {
+	if level >= slog.LevelWarn {
+		return false
+	}
+	trace := TraceFromContext(ctx)
+	if trace == nil {
+		return false
+	}
+	if !trace.verbose && !globalLogger.Enabled(ctx, level) {
+		return true
+	}
+
+	entry := TraceEntry{
+		Time:    time.Now().UTC().Format(time.RFC3339Nano),
+		Level:   level.String(),
+		Message: msg,
+	}
+	entry.Component, _ = ctx.Value(ComponentKey).(string)
+	entry.Stage, _ = ctx.Value(StageKey).(string)
+
+	record := slog.NewRecord(time.Time{}, level, msg, 0)
+	record.Add(attrs...)
+	record.Attrs(func(a slog.Attr) bool {
+		if entry.Attributes == nil {
+			entry.Attributes = make(map[string]interface{})
+		}
+		val := resolveValue(ctx, a.Value)
+		if err, ok := val.(error); ok {
+			val = err.Error()
+		}
+		entry.Attributes[a.Key] = SerializeValue(val)
+		return true
+	})
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	if len(trace.entries) >= MaxTraceEntries {
+		trace.dropped++
+		return true
+	}
+	trace.entries = append(trace.entries, entry)
+	return true
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceHoldsInfoAndDebugEntries(t *testing.T) {
+	ctx, trace := WithTrace(WithStage(context.Background(), "Request"), true)
+
+	Info(ctx, "Processing request", "model", "my-model")
+	Debug(ctx, "Vendor selected", "vendor", "openai")
+	Warn(ctx, "Vendor is slow")
+	Error(ctx, "Vendor failed", errors.New("boom"))
+
+	entries, dropped := trace.Entries()
+	require.Len(t, entries, 2)
+	assert.Zero(t, dropped)
+	assert.Equal(t, "Processing request", entries[0].Message)
+	assert.Equal(t, "INFO", entries[0].Level)
+	assert.Equal(t, "Request", entries[0].Stage)
+	assert.Equal(t, "my-model", entries[0].Attributes["model"])
+	assert.Equal(t, "DEBUG", entries[1].Level)
+}
+
+func TestTraceDropsEntriesOnceFull(t *testing.T) {
+	ctx, trace := WithTrace(context.Background(), true)
+	for i := 0; i < MaxTraceEntries+3; i++ {
+		Info(ctx, "Stream chunk")
+	}
+
+	entries, dropped := trace.Entries()
+	assert.Len(t, entries, MaxTraceEntries)
+	assert.Equal(t, 3, dropped)
+}
+
+func TestTraceFromContextWithoutTrace(t *testing.T) {
+	assert.Nil(t, TraceFromContext(context.Background()))
+	entries, dropped := TraceFromContext(context.Background()).Entries()
+	assert.Nil(t, entries)
+	assert.Zero(t, dropped)
+}
@@ -0,0 +1,80 @@
+// Package maintenance holds the process-wide read-only/maintenance mode toggle
+package maintenance
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// DefaultMessage is returned to clients when no custom maintenance message is set
+const DefaultMessage = "The service is temporarily in maintenance mode, please retry later"
+
+// Status describes the current maintenance state
+type Status struct {
+	Enabled    bool       `json:"enabled"`
+	Message    string     `json:"message,omitempty"`
+	RetryAfter int        `json:"retry_after_seconds,omitempty"`
+	Since      *time.Time `json:"since,omitempty"`
+}
+
+// Mode is a concurrency-safe maintenance mode toggle
+type Mode struct {
+	mu     sync.RWMutex
+	status Status
+}
+
+var (
+	defaultMode     *Mode
+	defaultModeOnce sync.Once
+)
+
+// NewMode creates a disabled maintenance mode
+func NewMode() *Mode {
+	return &Mode{}
+}
+
+// Default returns the process-wide maintenance mode, initialised once from
+// MAINTENANCE_MODE, MAINTENANCE_MESSAGE and MAINTENANCE_RETRY_AFTER (seconds, default 300)
+func Default() *Mode {
+	defaultModeOnce.Do(func() {
+		defaultMode = NewMode()
+		if utils.GetEnvBool("MAINTENANCE_MODE", false) {
+			defaultMode.Enable(
+				utils.GetEnvString("MAINTENANCE_MESSAGE", ""),
+				int(utils.GetEnvDuration("MAINTENANCE_RETRY_AFTER", 300*time.Second).Seconds()),
+			)
+		}
+	})
+	return defaultMode
+}
+
+// Enable turns maintenance mode on; an empty message uses DefaultMessage
+func (m *Mode) Enable(message string, retryAfter int) {
+	if message == "" {
+		message = DefaultMessage
+	}
+	if retryAfter <= 0 {
+		retryAfter = 300
+	}
+	now := time.Now().UTC()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status = Status{Enabled: true, Message: message, RetryAfter: retryAfter, Since: &now}
+}
+
+// Disable turns maintenance mode off
+func (m *Mode) Disable() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status = Status{}
+}
+
+// Status returns a snapshot of the current state
+func (m *Mode) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
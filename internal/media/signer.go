@@ -0,0 +1,133 @@
+package media
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// ReferenceScheme is the URL scheme for media pre-registered in the router's object storage
+const ReferenceScheme = "router-media://"
+
+// Error types for media reference resolution
+var (
+	ErrStorageNotConfigured = errors.New("media storage is not configured")
+	ErrInvalidReference     = errors.New("invalid media reference")
+	ErrDirectURLsDisabled   = errors.New("direct media URLs are disabled, use a router-media:// reference")
+	ErrSignatureExpired     = errors.New("signed media URL has expired")
+	ErrSignatureMismatch    = errors.New("signed media URL signature mismatch")
+)
+
+// referenceIDPattern restricts IDs to object-key-safe characters and forbids path traversal
+var referenceIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._\-/]{0,511}$`)
+
+// Signer resolves router-media:// references to signed, expiring URLs on the internal object storage
+type Signer struct {
+	baseURL           string
+	signingKey        []byte
+	ttl               time.Duration
+	requireReferences bool
+	now               func() time.Time
+}
+
+// NewSigner creates a signer for the given storage base URL and HMAC signing key
+func NewSigner(baseURL string, signingKey []byte, ttl time.Duration, requireReferences bool) *Signer {
+	return &Signer{
+		baseURL:           strings.TrimSuffix(baseURL, "/"),
+		signingKey:        signingKey,
+		ttl:               ttl,
+		requireReferences: requireReferences,
+		now:               time.Now,
+	}
+}
+
+// NewSignerFromEnv creates a signer configured from environment variables:
+// MEDIA_STORAGE_URL, MEDIA_SIGNING_KEY, MEDIA_SIGNED_URL_TTL (seconds, default 300)
+// and MEDIA_REQUIRE_REFERENCES (reject direct http(s) media URLs, default false)
+func NewSignerFromEnv() *Signer {
+	return NewSigner(
+		utils.GetEnvString("MEDIA_STORAGE_URL", ""),
+		[]byte(utils.GetEnvString("MEDIA_SIGNING_KEY", "")),
+		utils.GetEnvDuration("MEDIA_SIGNED_URL_TTL", 300*time.Second),
+		utils.GetEnvBool("MEDIA_REQUIRE_REFERENCES", false),
+	)
+}
+
+// IsReference reports whether rawURL is a router-media:// reference
+func IsReference(rawURL string) bool {
+	return strings.HasPrefix(rawURL, ReferenceScheme)
+}
+
+// Configured reports whether a storage URL and signing key are available
+func (s *Signer) Configured() bool {
+	return s.baseURL != "" && len(s.signingKey) > 0
+}
+
+// RequireReferences reports whether direct http(s) media URLs must be rejected
+func (s *Signer) RequireReferences() bool {
+	return s.requireReferences
+}
+
+// Resolve turns a client-supplied media URL into the URL the router should fetch
+// References are replaced by a signed URL and client headers are dropped so no
+// third-party credentials are forwarded; other URLs are returned unchanged
+func (s *Signer) Resolve(rawURL string, headers map[string]string) (string, map[string]string, error) {
+	if !IsReference(rawURL) {
+		if s.requireReferences && (strings.HasPrefix(rawURL, "http://") || strings.HasPrefix(rawURL, "https://")) {
+			return "", nil, ErrDirectURLsDisabled
+		}
+		return rawURL, headers, nil
+	}
+
+	signedURL, err := s.SignReference(rawURL)
+	if err != nil {
+		return "", nil, err
+	}
+	return signedURL, nil, nil
+}
+
+// SignReference returns a signed, expiring storage URL for a router-media:// reference
+func (s *Signer) SignReference(reference string) (string, error) {
+	if !s.Configured() {
+		return "", ErrStorageNotConfigured
+	}
+
+	id := strings.TrimPrefix(reference, ReferenceScheme)
+	if !referenceIDPattern.MatchString(id) || strings.Contains(id, "..") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidReference, reference)
+	}
+
+	expires := s.now().Add(s.ttl).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", s.sign(id, expires))
+
+	return fmt.Sprintf("%s/%s?%s", s.baseURL, id, query.Encode()), nil
+}
+
+// Verify checks a signature produced by SignReference; storage gateways can use it to validate requests
+func (s *Signer) Verify(id string, expires int64, signature string) error {
+	if s.now().Unix() > expires {
+		return ErrSignatureExpired
+	}
+	if !hmac.Equal([]byte(s.sign(id, expires)), []byte(signature)) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// sign computes the hex HMAC-SHA256 of "id:expires"
+func (s *Signer) sign(id string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(id + ":" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,87 @@
+package media
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSigner(requireReferences bool) *Signer {
+	signer := NewSigner("https://storage.internal/media/", []byte("test-signing-key"), 5*time.Minute, requireReferences)
+	signer.now = func() time.Time { return time.Unix(1700000000, 0) }
+	return signer
+}
+
+func TestSigner_SignReference(t *testing.T) {
+	signer := newTestSigner(false)
+
+	signed, err := signer.SignReference("router-media://uploads/abc-123.png")
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "storage.internal", parsed.Host)
+	assert.Equal(t, "/media/uploads/abc-123.png", parsed.Path)
+
+	expires, err := strconv.ParseInt(parsed.Query().Get("expires"), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000300), expires)
+	assert.NoError(t, signer.Verify("uploads/abc-123.png", expires, parsed.Query().Get("signature")))
+}
+
+func TestSigner_InvalidReferences(t *testing.T) {
+	signer := newTestSigner(false)
+
+	for _, ref := range []string{"router-media://", "router-media://../secret", "router-media:///etc/passwd", "router-media://a b"} {
+		t.Run(ref, func(t *testing.T) {
+			_, err := signer.SignReference(ref)
+			assert.ErrorIs(t, err, ErrInvalidReference)
+		})
+	}
+}
+
+func TestSigner_NotConfigured(t *testing.T) {
+	signer := NewSigner("", nil, time.Minute, false)
+
+	_, _, err := signer.Resolve("router-media://abc", nil)
+	assert.ErrorIs(t, err, ErrStorageNotConfigured)
+}
+
+func TestSigner_Resolve(t *testing.T) {
+	headers := map[string]string{"Authorization": "Bearer third-party"}
+
+	t.Run("reference drops client headers", func(t *testing.T) {
+		resolved, resolvedHeaders, err := newTestSigner(false).Resolve("router-media://abc", headers)
+		require.NoError(t, err)
+		assert.Contains(t, resolved, "https://storage.internal/media/abc?")
+		assert.Nil(t, resolvedHeaders)
+	})
+
+	t.Run("direct URL passes through", func(t *testing.T) {
+		resolved, resolvedHeaders, err := newTestSigner(false).Resolve("https://example.com/a.png", headers)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/a.png", resolved)
+		assert.Equal(t, headers, resolvedHeaders)
+	})
+
+	t.Run("direct URL rejected when references are required", func(t *testing.T) {
+		_, _, err := newTestSigner(true).Resolve("https://example.com/a.png", headers)
+		assert.ErrorIs(t, err, ErrDirectURLsDisabled)
+	})
+}
+
+func TestSigner_VerifyRejectsTamperingAndExpiry(t *testing.T) {
+	signer := newTestSigner(false)
+	expires := int64(1700000300)
+	signature := signer.sign("abc", expires)
+
+	assert.ErrorIs(t, signer.Verify("abd", expires, signature), ErrSignatureMismatch)
+	assert.ErrorIs(t, signer.Verify("abc", expires+1, signature), ErrSignatureMismatch)
+
+	signer.now = func() time.Time { return time.Unix(expires+1, 0) }
+	assert.ErrorIs(t, signer.Verify("abc", expires, signature), ErrSignatureExpired)
+}
@@ -0,0 +1,87 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// uploadPrefix is the object key prefix for media the router stores on its own behalf
+const uploadPrefix = "inline/"
+
+// Uploader stores media in the router's object storage so vendors can fetch it by signed URL
+type Uploader struct {
+	signer     *Signer
+	httpClient *http.Client
+}
+
+// NewUploader creates an uploader writing to the signer's storage
+func NewUploader(signer *Signer, httpClient *http.Client) *Uploader {
+	return &Uploader{signer: signer, httpClient: httpClient}
+}
+
+// Configured reports whether media storage is available for uploads
+func (u *Uploader) Configured() bool {
+	return u != nil && u.signer.Configured()
+}
+
+// Upload stores data under a new ID and returns its router-media:// reference
+// The PUT is authorized with a signature over "upload:<id>" so a signed download
+// URL cannot be replayed to overwrite the object
+func (u *Uploader) Upload(ctx context.Context, data []byte, contentType string) (string, error) {
+	if !u.Configured() {
+		return "", ErrStorageNotConfigured
+	}
+
+	id := uploadPrefix + uuid.NewString() + extensionFor(contentType)
+	expires := u.signer.now().Add(u.signer.ttl).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", u.signer.sign("upload:"+id, expires))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/%s?%s", u.signer.baseURL, id, query.Encode()), bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to create media upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload media: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("media storage rejected upload with status %d", resp.StatusCode)
+	}
+	return ReferenceScheme + id, nil
+}
+
+// VerifyUpload checks an upload signature produced by Upload; storage gateways can use it to validate PUTs
+func (s *Signer) VerifyUpload(id string, expires int64, signature string) error {
+	return s.Verify("upload:"+id, expires, signature)
+}
+
+// extensionFor returns a file extension for common image content types
+func extensionFor(contentType string) string {
+	switch strings.ToLower(contentType) {
+	case "image/jpeg", "image/jpg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,48 @@
+package media
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploader_Upload(t *testing.T) {
+	signer := newTestSigner(false)
+	var gotID string
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = strings.TrimPrefix(r.URL.Path, "/")
+		expires, _ := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+		signature := r.URL.Query().Get("signature")
+		assert.NoError(t, signer.VerifyUpload(gotID, expires, signature))
+		assert.Error(t, signer.Verify(gotID, expires, signature), "upload signatures are not download signatures")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer storage.Close()
+	signer.baseURL = storage.URL
+
+	reference, err := NewUploader(signer, storage.Client()).Upload(context.Background(), []byte("png"), "image/png")
+	require.NoError(t, err)
+	assert.Equal(t, ReferenceScheme+gotID, reference)
+	assert.True(t, strings.HasPrefix(gotID, "inline/") && strings.HasSuffix(gotID, ".png"))
+}
+
+func TestUploader_Errors(t *testing.T) {
+	_, err := NewUploader(NewSigner("", nil, 0, false), http.DefaultClient).Upload(context.Background(), nil, "image/png")
+	assert.ErrorIs(t, err, ErrStorageNotConfigured)
+
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer storage.Close()
+	signer := newTestSigner(false)
+	signer.baseURL = storage.URL
+
+	_, err = NewUploader(signer, storage.Client()).Upload(context.Background(), []byte("png"), "image/png")
+	assert.ErrorContains(t, err, "403")
+}
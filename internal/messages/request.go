@@ -0,0 +1,301 @@
+// Package messages serves Anthropic's Messages API on top of the chat completions pipeline:
+// requests are translated into chat completion requests and the chat completion, or its
+// stream of chunks, is translated back into a message or Messages stream events, so clients
+// of Anthropic's SDKs can use the router unchanged
+package messages
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidRequest is returned for requests that cannot be translated into a chat completion
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Request represents a request to the Messages API
+type Request struct {
+	Model     string    `json:"model" example:"claude-sonnet-4-5"`
+	Messages  []Message `json:"messages"`
+	MaxTokens int       `json:"max_tokens" example:"1024"`
+	// System is a string or an array of text blocks
+	System        json.RawMessage `json:"system,omitempty" swaggertype:"string" example:"You are a helpful assistant."`
+	StopSequences []string        `json:"stop_sequences,omitempty"`
+	Stream        bool            `json:"stream,omitempty" example:"false"`
+	Temperature   *float64        `json:"temperature,omitempty" example:"0.7"`
+	TopP          *float64        `json:"top_p,omitempty" example:"1"`
+	// TopK is accepted and ignored; chat completions have no equivalent
+	TopK       *int        `json:"top_k,omitempty"`
+	Tools      []Tool      `json:"tools,omitempty"`
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+	Metadata   *Metadata   `json:"metadata,omitempty"`
+}
+
+// Message is one turn of the conversation
+type Message struct {
+	Role string `json:"role" example:"user"`
+	// Content is a string or an array of content blocks
+	Content json.RawMessage `json:"content" swaggertype:"string" example:"Tell me a joke"`
+}
+
+// Tool represents a tool the model may use
+type Tool struct {
+	Type        string                 `json:"type,omitempty" example:"custom"`
+	Name        string                 `json:"name" example:"get_weather"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// ToolChoice controls how the model uses tools
+type ToolChoice struct {
+	Type                   string `json:"type" example:"auto"`
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse *bool  `json:"disable_parallel_tool_use,omitempty"`
+}
+
+// Metadata describes the request
+type Metadata struct {
+	UserID string `json:"user_id,omitempty" example:"user-123"`
+}
+
+// contentBlock is one block of a message's content
+type contentBlock struct {
+	Type   string       `json:"type"`
+	Text   string       `json:"text"`
+	Source *imageSource `json:"source"`
+	// ID, Name and Input describe a tool_use block
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+	// ToolUseID, Content and IsError describe a tool_result block
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+	IsError   bool            `json:"is_error"`
+}
+
+// imageSource is the source of an image block
+type imageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+	URL       string `json:"url"`
+}
+
+// ChatRequest translates a Messages API request into a chat completion request body
+func ChatRequest(req *Request) (map[string]interface{}, error) {
+	if req.MaxTokens <= 0 {
+		return nil, fmt.Errorf("%w: max_tokens is required and must be positive", ErrInvalidRequest)
+	}
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("%w: messages must not be empty", ErrInvalidRequest)
+	}
+
+	var messages []interface{}
+	if len(req.System) > 0 {
+		system, err := textContent(req.System)
+		if err != nil {
+			return nil, fmt.Errorf("%w: system must be a string or an array of text blocks", ErrInvalidRequest)
+		}
+		if system != "" {
+			messages = append(messages, map[string]interface{}{"role": "system", "content": system})
+		}
+	}
+	for i, message := range req.Messages {
+		translated, err := chatMessages(message)
+		if err != nil {
+			return nil, fmt.Errorf("%w (messages[%d])", err, i)
+		}
+		messages = append(messages, translated...)
+	}
+
+	payload := map[string]interface{}{
+		"model":      req.Model,
+		"messages":   messages,
+		"max_tokens": req.MaxTokens,
+	}
+	if req.Stream {
+		payload["stream"] = true
+		payload["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
+	if len(req.StopSequences) > 0 {
+		payload["stop"] = req.StopSequences
+	}
+	if req.Temperature != nil {
+		payload["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		payload["top_p"] = *req.TopP
+	}
+	if req.Metadata != nil && req.Metadata.UserID != "" {
+		payload["user"] = req.Metadata.UserID
+	}
+
+	if len(req.Tools) > 0 {
+		tools := make([]interface{}, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			if tool.Type != "" && tool.Type != "custom" {
+				return nil, fmt.Errorf("%w: tools of type %q are not supported, only custom tools", ErrInvalidRequest, tool.Type)
+			}
+			function := map[string]interface{}{"name": tool.Name}
+			if tool.Description != "" {
+				function["description"] = tool.Description
+			}
+			if tool.InputSchema != nil {
+				function["parameters"] = tool.InputSchema
+			}
+			tools = append(tools, map[string]interface{}{"type": "function", "function": function})
+		}
+		payload["tools"] = tools
+	}
+	if req.ToolChoice != nil {
+		switch choice := req.ToolChoice; choice.Type {
+		case "auto", "none":
+			payload["tool_choice"] = choice.Type
+		case "any":
+			payload["tool_choice"] = "required"
+		case "tool":
+			if choice.Name == "" {
+				return nil, fmt.Errorf("%w: tool_choice of type tool requires a name", ErrInvalidRequest)
+			}
+			payload["tool_choice"] = map[string]interface{}{"type": "function", "function": map[string]interface{}{"name": choice.Name}}
+		default:
+			return nil, fmt.Errorf("%w: tool_choice must be auto, any, tool or none", ErrInvalidRequest)
+		}
+		if choice := req.ToolChoice; choice.DisableParallelToolUse != nil && len(req.Tools) > 0 {
+			payload["parallel_tool_calls"] = !*choice.DisableParallelToolUse
+		}
+	}
+	return payload, nil
+}
+
+// chatMessages translates a message into chat messages: tool results become tool messages
+// ahead of the rest of the user's content, tool uses become the assistant's tool calls
+func chatMessages(message Message) ([]interface{}, error) {
+	if message.Role != "user" && message.Role != "assistant" {
+		return nil, fmt.Errorf("%w: message role %q is not supported, use user or assistant", ErrInvalidRequest, message.Role)
+	}
+
+	var text string
+	if err := json.Unmarshal(message.Content, &text); err == nil {
+		return []interface{}{map[string]interface{}{"role": message.Role, "content": text}}, nil
+	}
+	var blocks []contentBlock
+	if err := json.Unmarshal(message.Content, &blocks); err != nil {
+		return nil, fmt.Errorf("%w: message content must be a string or an array of content blocks", ErrInvalidRequest)
+	}
+	if message.Role == "assistant" {
+		return assistantMessage(blocks)
+	}
+
+	var messages []interface{}
+	content := make([]interface{}, 0, len(blocks))
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			content = append(content, map[string]interface{}{"type": "text", "text": block.Text})
+		case "image":
+			url, err := imageURL(block.Source)
+			if err != nil {
+				return nil, err
+			}
+			content = append(content, map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": url}})
+		case "tool_result":
+			result, err := toolResult(block)
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, map[string]interface{}{"role": "tool", "tool_call_id": block.ToolUseID, "content": result})
+		default:
+			return nil, fmt.Errorf("%w: user content blocks of type %q are not supported", ErrInvalidRequest, block.Type)
+		}
+	}
+	if len(content) > 0 {
+		messages = append(messages, map[string]interface{}{"role": "user", "content": content})
+	}
+	return messages, nil
+}
+
+// assistantMessage translates the content blocks of an assistant turn into one chat message
+// Thinking blocks echoed back from earlier turns are dropped
+func assistantMessage(blocks []contentBlock) ([]interface{}, error) {
+	var texts []string
+	var toolCalls []interface{}
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			texts = append(texts, block.Text)
+		case "tool_use":
+			arguments := "{}"
+			if len(block.Input) > 0 {
+				arguments = string(block.Input)
+			}
+			toolCalls = append(toolCalls, map[string]interface{}{
+				"id":       block.ID,
+				"type":     "function",
+				"function": map[string]interface{}{"name": block.Name, "arguments": arguments},
+			})
+		case "thinking", "redacted_thinking":
+		default:
+			return nil, fmt.Errorf("%w: assistant content blocks of type %q are not supported", ErrInvalidRequest, block.Type)
+		}
+	}
+
+	message := map[string]interface{}{"role": "assistant", "content": strings.Join(texts, "")}
+	if len(toolCalls) > 0 {
+		message["tool_calls"] = toolCalls
+		if len(texts) == 0 {
+			message["content"] = nil
+		}
+	}
+	return []interface{}{message}, nil
+}
+
+// imageURL returns the URL of an image block, inlining base64 images as data URLs
+func imageURL(source *imageSource) (string, error) {
+	switch {
+	case source == nil:
+		return "", fmt.Errorf("%w: image blocks require a source", ErrInvalidRequest)
+	case source.Type == "base64" && source.MediaType != "" && source.Data != "":
+		return "data:" + source.MediaType + ";base64," + source.Data, nil
+	case source.Type == "url" && source.URL != "":
+		return source.URL, nil
+	default:
+		return "", fmt.Errorf("%w: image source must be base64 data with a media_type or a url", ErrInvalidRequest)
+	}
+}
+
+// toolResult returns the content of a tool_result block as text, marking errors
+func toolResult(block contentBlock) (string, error) {
+	result := ""
+	if len(block.Content) > 0 {
+		var err error
+		if result, err = textContent(block.Content); err != nil {
+			return "", fmt.Errorf("%w: tool_result content must be a string or an array of text blocks", ErrInvalidRequest)
+		}
+	}
+	if block.IsError {
+		result = "Error: " + result
+	}
+	return result, nil
+}
+
+// textContent returns a string, or the text of an array of text blocks, as one string
+func textContent(raw json.RawMessage) (string, error) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+	var blocks []contentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return "", err
+	}
+	texts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Type != "text" {
+			return "", fmt.Errorf("unsupported block type %q", block.Type)
+		}
+		texts = append(texts, block.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
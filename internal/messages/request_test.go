@@ -0,0 +1,137 @@
+package messages
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatRequest(t *testing.T) {
+	body := `{
+		"model": "claude-sonnet-4-5",
+		"max_tokens": 256,
+		"system": [{"type": "text", "text": "Be brief."}, {"type": "text", "text": "Answer in French."}],
+		"stream": true,
+		"temperature": 0.2,
+		"top_k": 40,
+		"stop_sequences": ["END"],
+		"metadata": {"user_id": "user-1"},
+		"messages": [
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is this?"},
+				{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "iVBOR"}},
+				{"type": "image", "source": {"type": "url", "url": "https://example.com/a.png"}}
+			]},
+			{"role": "assistant", "content": [
+				{"type": "thinking", "thinking": "Let me look.", "signature": "sig"},
+				{"type": "text", "text": "Looking."},
+				{"type": "tool_use", "id": "toolu_1", "name": "lookup", "input": {"q": "png"}}
+			]},
+			{"role": "user", "content": [
+				{"type": "tool_result", "tool_use_id": "toolu_1", "content": [{"type": "text", "text": "an image"}]},
+				{"type": "text", "text": "And now?"}
+			]},
+			{"role": "assistant", "content": "Une image."}
+		],
+		"tools": [{"name": "lookup", "description": "Look up", "input_schema": {"type": "object"}}],
+		"tool_choice": {"type": "tool", "name": "lookup", "disable_parallel_tool_use": true}
+	}`
+	var req Request
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+
+	payload, err := ChatRequest(&req)
+	require.NoError(t, err)
+	translated, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	expected := `{
+		"model": "claude-sonnet-4-5",
+		"max_tokens": 256,
+		"stream": true,
+		"stream_options": {"include_usage": true},
+		"temperature": 0.2,
+		"stop": ["END"],
+		"user": "user-1",
+		"messages": [
+			{"role": "system", "content": "Be brief.\nAnswer in French."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is this?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBOR"}},
+				{"type": "image_url", "image_url": {"url": "https://example.com/a.png"}}
+			]},
+			{"role": "assistant", "content": "Looking.", "tool_calls": [
+				{"id": "toolu_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\": \"png\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "toolu_1", "content": "an image"},
+			{"role": "user", "content": [{"type": "text", "text": "And now?"}]},
+			{"role": "assistant", "content": "Une image."}
+		],
+		"tools": [{"type": "function", "function": {"name": "lookup", "description": "Look up", "parameters": {"type": "object"}}}],
+		"tool_choice": {"type": "function", "function": {"name": "lookup"}},
+		"parallel_tool_calls": false
+	}`
+	assert.JSONEq(t, expected, string(translated))
+}
+
+func TestChatRequest_ToolResults(t *testing.T) {
+	body := `{
+		"model": "claude-sonnet-4-5",
+		"max_tokens": 64,
+		"system": "Be brief.",
+		"messages": [
+			{"role": "assistant", "content": [{"type": "tool_use", "id": "toolu_1", "name": "lookup", "input": {}}]},
+			{"role": "user", "content": [{"type": "tool_result", "tool_use_id": "toolu_1", "content": "not found", "is_error": true}]}
+		],
+		"tool_choice": {"type": "any"}
+	}`
+	var req Request
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+
+	payload, err := ChatRequest(&req)
+	require.NoError(t, err)
+	translated, err := json.Marshal(payload)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"model": "claude-sonnet-4-5",
+		"max_tokens": 64,
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "toolu_1", "type": "function", "function": {"name": "lookup", "arguments": "{}"}}
+			]},
+			{"role": "tool", "tool_call_id": "toolu_1", "content": "Error: not found"}
+		],
+		"tool_choice": "required"
+	}`, string(translated))
+}
+
+func TestChatRequest_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing max_tokens", `{"model": "m", "messages": [{"role": "user", "content": "Hi"}]}`},
+		{"no messages", `{"model": "m", "max_tokens": 8, "messages": []}`},
+		{"system role", `{"model": "m", "max_tokens": 8, "messages": [{"role": "system", "content": "Hi"}]}`},
+		{"invalid system", `{"model": "m", "max_tokens": 8, "system": 1, "messages": [{"role": "user", "content": "Hi"}]}`},
+		{"invalid content", `{"model": "m", "max_tokens": 8, "messages": [{"role": "user", "content": 1}]}`},
+		{"document block", `{"model": "m", "max_tokens": 8, "messages": [{"role": "user", "content": [{"type": "document", "source": {}}]}]}`},
+		{"image without source", `{"model": "m", "max_tokens": 8, "messages": [{"role": "user", "content": [{"type": "image"}]}]}`},
+		{"image by file", `{"model": "m", "max_tokens": 8, "messages": [{"role": "user", "content": [{"type": "image", "source": {"type": "file", "file_id": "f"}}]}]}`},
+		{"image in assistant turn", `{"model": "m", "max_tokens": 8, "messages": [{"role": "assistant", "content": [{"type": "image", "source": {"type": "url", "url": "u"}}]}]}`},
+		{"server tool", `{"model": "m", "max_tokens": 8, "messages": [{"role": "user", "content": "Hi"}], "tools": [{"type": "web_search_20250305", "name": "web_search"}]}`},
+		{"tool choice without name", `{"model": "m", "max_tokens": 8, "messages": [{"role": "user", "content": "Hi"}], "tool_choice": {"type": "tool"}}`},
+		{"unknown tool choice", `{"model": "m", "max_tokens": 8, "messages": [{"role": "user", "content": "Hi"}], "tool_choice": {"type": "some"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req Request
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &req))
+			_, err := ChatRequest(&req)
+			assert.ErrorIs(t, err, ErrInvalidRequest)
+		})
+	}
+}
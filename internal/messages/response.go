@@ -0,0 +1,250 @@
+package messages
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Stop reasons of a message
+const (
+	StopReasonEndTurn   = "end_turn"
+	StopReasonMaxTokens = "max_tokens"
+	StopReasonToolUse   = "tool_use"
+	StopReasonRefusal   = "refusal"
+)
+
+// stopReasons maps chat completion finish reasons to Messages API stop reasons
+var stopReasons = map[string]string{
+	"stop":           StopReasonEndTurn,
+	"length":         StopReasonMaxTokens,
+	"tool_calls":     StopReasonToolUse,
+	"function_call":  StopReasonToolUse,
+	"content_filter": StopReasonRefusal,
+}
+
+// Response is a message of the Messages API
+type Response struct {
+	ID   string `json:"id" example:"msg_abc123"`
+	Type string `json:"type" example:"message"`
+	Role string `json:"role" example:"assistant"`
+	// Content holds TextBlock and ToolUseBlock values
+	Content      []interface{} `json:"content" swaggertype:"array,object"`
+	Model        string        `json:"model" example:"claude-sonnet-4-5"`
+	StopReason   *string       `json:"stop_reason" example:"end_turn"`
+	StopSequence *string       `json:"stop_sequence"`
+	Usage        Usage         `json:"usage"`
+}
+
+// TextBlock is text generated by the model
+type TextBlock struct {
+	Type string `json:"type" example:"text"`
+	Text string `json:"text"`
+}
+
+// ToolUseBlock is a tool use by the model
+type ToolUseBlock struct {
+	Type  string          `json:"type" example:"tool_use"`
+	ID    string          `json:"id" example:"toolu_abc123"`
+	Name  string          `json:"name" example:"get_weather"`
+	Input json.RawMessage `json:"input" swaggertype:"object"`
+}
+
+// Usage represents token usage of a message
+type Usage struct {
+	InputTokens          int `json:"input_tokens" example:"10"`
+	OutputTokens         int `json:"output_tokens" example:"20"`
+	CacheReadInputTokens int `json:"cache_read_input_tokens" example:"0"`
+}
+
+// ErrorResponse is an error in the Messages API error shape
+type ErrorResponse struct {
+	Type  string      `json:"type" example:"error"`
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes an error
+type ErrorDetail struct {
+	Type    string `json:"type" example:"invalid_request_error"`
+	Message string `json:"message"`
+}
+
+// chatCompletion is the part of a chat completion translated into a message
+type chatCompletion struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content   *string        `json:"content"`
+			Refusal   *string        `json:"refusal"`
+			ToolCalls []chatToolCall `json:"tool_calls"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+}
+
+// chatToolCall is a tool call of a chat completion or, with Index, of a chunk
+type chatToolCall struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// chatUsage is the token usage of a chat completion
+type chatUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+// chatError is the body of a chat completion error response
+type chatError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// FromChatCompletion translates a chat completion answering req into a message
+func FromChatCompletion(req *Request, body []byte) (*Response, error) {
+	var completion chatCompletion
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return nil, fmt.Errorf("invalid chat completion: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("invalid chat completion: no choices")
+	}
+
+	if completion.ID == "" {
+		completion.ID = utils.GenerateChatCompletionID()
+	}
+	response := newResponse(req, completion.ID, completion.Model)
+	choice := completion.Choices[0]
+	stopReason := stopReason(choice.FinishReason)
+	response.StopReason = &stopReason
+
+	text := ""
+	if choice.Message.Content != nil {
+		text = *choice.Message.Content
+	} else if choice.Message.Refusal != nil {
+		text = *choice.Message.Refusal
+	}
+	if text != "" {
+		response.Content = append(response.Content, &TextBlock{Type: "text", Text: text})
+	}
+	for _, call := range choice.Message.ToolCalls {
+		input := json.RawMessage(call.Function.Arguments)
+		if strings.TrimSpace(call.Function.Arguments) == "" {
+			input = json.RawMessage("{}")
+		} else if !json.Valid(input) {
+			return nil, fmt.Errorf("invalid chat completion: arguments of tool call %s are not JSON", call.ID)
+		}
+		response.Content = append(response.Content, &ToolUseBlock{
+			Type:  "tool_use",
+			ID:    toolUseID(call.ID),
+			Name:  call.Function.Name,
+			Input: input,
+		})
+	}
+	response.Usage = usage(completion.Usage)
+	return response, nil
+}
+
+// newResponse returns an empty message answering req
+func newResponse(req *Request, chatID, model string) *Response {
+	if model == "" {
+		model = req.Model
+	}
+	return &Response{
+		ID:      "msg_" + strings.TrimPrefix(chatID, "chatcmpl-"),
+		Type:    "message",
+		Role:    "assistant",
+		Content: []interface{}{},
+		Model:   model,
+	}
+}
+
+// stopReason translates a chat finish_reason, treating unknown reasons as the end of the turn
+func stopReason(finishReason string) string {
+	if reason, ok := stopReasons[finishReason]; ok {
+		return reason
+	}
+	return StopReasonEndTurn
+}
+
+// usage translates chat completion usage; input tokens exclude those read from the cache
+func usage(chat *chatUsage) Usage {
+	if chat == nil {
+		return Usage{}
+	}
+	cached := chat.PromptTokensDetails.CachedTokens
+	return Usage{
+		InputTokens:          chat.PromptTokens - cached,
+		OutputTokens:         chat.CompletionTokens,
+		CacheReadInputTokens: cached,
+	}
+}
+
+// toolUseID keeps the chat tool call ID, which clients echo back in tool_result blocks
+func toolUseID(callID string) string {
+	if callID == "" {
+		return "toolu_" + strings.TrimPrefix(utils.GenerateChatCompletionID(), "chatcmpl-")
+	}
+	return callID
+}
+
+// errorType returns the Messages API error type of an HTTP status
+func errorType(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return "authentication_error"
+	case status == http.StatusForbidden:
+		return "permission_error"
+	case status == http.StatusNotFound:
+		return "not_found_error"
+	case status == http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case status == http.StatusServiceUnavailable || status == 529:
+		return "overloaded_error"
+	case status >= 500:
+		return "api_error"
+	default:
+		return "invalid_request_error"
+	}
+}
+
+// WriteError writes an error in the Messages API error shape
+func WriteError(w http.ResponseWriter, status int, message string) {
+	body, _ := json.Marshal(ErrorResponse{
+		Type:  "error",
+		Error: ErrorDetail{Type: errorType(status), Message: message},
+	})
+	w.Header().Del(utils.HeaderContentEncoding)
+	w.Header().Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	w.Header().Set(utils.HeaderContentLength, strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+// errorMessage returns the message of a chat completion error response
+func errorMessage(status int, body []byte) string {
+	var chat chatError
+	if err := json.Unmarshal(body, &chat); err == nil && chat.Error.Message != "" {
+		return chat.Error.Message
+	}
+	if text := strings.TrimSpace(string(body)); text != "" {
+		return text
+	}
+	return http.StatusText(status)
+}
@@ -0,0 +1,213 @@
+package messages
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// stream translates a stream of chat completion chunks into Messages stream events
+// Content blocks are streamed one at a time: a block stops when the next one starts
+type stream struct {
+	w        io.Writer
+	request  *Request
+	response *Response
+	// block is the index of the open content block, -1 when none is open
+	block int
+	// textBlock is set while the open block is a text block
+	textBlock bool
+	// toolBlocks maps the index of a chat tool call to its content block
+	toolBlocks   map[int]int
+	finishReason string
+	usage        Usage
+	done         bool
+}
+
+// chatChunk is the part of a chat completion chunk translated into stream events
+type chatChunk struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content   *string        `json:"content"`
+			Refusal   *string        `json:"refusal"`
+			ToolCalls []chatToolCall `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func newStream(w io.Writer, req *Request) *stream {
+	return &stream{
+		w:          w,
+		request:    req,
+		block:      -1,
+		toolBlocks: make(map[int]int),
+	}
+}
+
+// data handles the data of one chat completion stream event
+func (s *stream) data(data []byte) error {
+	if s.done {
+		return nil
+	}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("[DONE]")) {
+		return s.complete()
+	}
+
+	var chunk chatChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		// Comments and keep-alives carry no chunk
+		return nil
+	}
+	if err := s.start(chunk.ID, chunk.Model); err != nil {
+		return err
+	}
+	if chunk.Error != nil {
+		return s.fail(chunk.Error.Message)
+	}
+
+	for _, choice := range chunk.Choices {
+		text := ""
+		if choice.Delta.Content != nil {
+			text = *choice.Delta.Content
+		} else if choice.Delta.Refusal != nil {
+			text = *choice.Delta.Refusal
+		}
+		if text != "" {
+			if err := s.textDelta(text); err != nil {
+				return err
+			}
+		}
+		for _, call := range choice.Delta.ToolCalls {
+			if err := s.toolCallDelta(call); err != nil {
+				return err
+			}
+		}
+		if choice.FinishReason != nil && *choice.FinishReason != "" {
+			s.finishReason = *choice.FinishReason
+		}
+	}
+	if chunk.Usage != nil {
+		s.usage = usage(chunk.Usage)
+	}
+	return nil
+}
+
+// start emits the message_start event before the first chunk
+func (s *stream) start(chatID, model string) error {
+	if s.response != nil {
+		return nil
+	}
+	if chatID == "" {
+		chatID = utils.GenerateChatCompletionID()
+	}
+	s.response = newResponse(s.request, chatID, model)
+	return s.emit("message_start", map[string]interface{}{"message": s.response})
+}
+
+// textDelta streams text into a text block, starting one unless it is open
+func (s *stream) textDelta(text string) error {
+	if !s.textBlock {
+		if err := s.startBlock(&TextBlock{Type: "text", Text: ""}); err != nil {
+			return err
+		}
+		s.textBlock = true
+	}
+	return s.emit("content_block_delta", map[string]interface{}{
+		"index": s.block,
+		"delta": map[string]interface{}{"type": "text_delta", "text": text},
+	})
+}
+
+// toolCallDelta streams a tool call into a tool_use block, starting it on its first delta
+func (s *stream) toolCallDelta(delta chatToolCall) error {
+	block, ok := s.toolBlocks[delta.Index]
+	if !ok {
+		if err := s.startBlock(&ToolUseBlock{
+			Type:  "tool_use",
+			ID:    toolUseID(delta.ID),
+			Name:  delta.Function.Name,
+			Input: json.RawMessage("{}"),
+		}); err != nil {
+			return err
+		}
+		block = s.block
+		s.toolBlocks[delta.Index] = block
+	}
+	if delta.Function.Arguments == "" {
+		return nil
+	}
+	return s.emit("content_block_delta", map[string]interface{}{
+		"index": block,
+		"delta": map[string]interface{}{"type": "input_json_delta", "partial_json": delta.Function.Arguments},
+	})
+}
+
+// startBlock stops the open content block and starts the next one
+func (s *stream) startBlock(block interface{}) error {
+	if err := s.stopBlock(); err != nil {
+		return err
+	}
+	s.block = len(s.response.Content)
+	s.response.Content = append(s.response.Content, block)
+	return s.emit("content_block_start", map[string]interface{}{
+		"index":         s.block,
+		"content_block": block,
+	})
+}
+
+// stopBlock stops the open content block, if any
+func (s *stream) stopBlock() error {
+	if s.block < 0 {
+		return nil
+	}
+	index := s.block
+	s.block = -1
+	s.textBlock = false
+	return s.emit("content_block_stop", map[string]interface{}{"index": index})
+}
+
+// complete stops the open block and emits the stop reason, usage and message_stop
+func (s *stream) complete() error {
+	if err := s.start("", ""); err != nil {
+		return err
+	}
+	s.done = true
+	if err := s.stopBlock(); err != nil {
+		return err
+	}
+	if err := s.emit("message_delta", map[string]interface{}{
+		"delta": map[string]interface{}{"stop_reason": stopReason(s.finishReason), "stop_sequence": nil},
+		"usage": s.usage,
+	}); err != nil {
+		return err
+	}
+	return s.emit("message_stop", map[string]interface{}{})
+}
+
+// fail ends the stream with an error event
+func (s *stream) fail(message string) error {
+	s.done = true
+	return s.emit("error", map[string]interface{}{
+		"error": ErrorDetail{Type: "api_error", Message: message},
+	})
+}
+
+// emit writes one Messages stream event
+func (s *stream) emit(eventType string, fields map[string]interface{}) error {
+	fields["type"] = eventType
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", eventType, data)
+	return err
+}
@@ -0,0 +1,34 @@
+package messages
+
+import (
+	"net/http"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/translate"
+)
+
+// NewWriter returns the writer given to the chat completions handler: it translates the
+// chat completion answering req into a message, or a chat completion stream into Messages
+// stream events as the chunks arrive. Error responses are translated into the Messages API
+// error shape
+func NewWriter(w http.ResponseWriter, req *Request) *translate.Writer {
+	stream := newStream(w, req)
+	return translate.NewWriter(w, translate.Translators{
+		Completion: func(body []byte) ([]byte, error) {
+			response, err := FromChatCompletion(req, body)
+			if err != nil {
+				return nil, err
+			}
+			return codec.Marshal(response)
+		},
+		Chunk: stream.data,
+		End: func() {
+			if !stream.done {
+				_ = stream.fail("The stream ended before the message completed")
+			}
+		},
+		Error: func(w http.ResponseWriter, status int, body []byte) {
+			WriteError(w, status, errorMessage(status, body))
+		},
+	})
+}
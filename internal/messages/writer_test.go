@@ -0,0 +1,226 @@
+package messages
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamEvent is one decoded Messages stream event
+type streamEvent struct {
+	name string
+	data map[string]interface{}
+}
+
+func parseEvents(t *testing.T, body string) []streamEvent {
+	t.Helper()
+	var events []streamEvent
+	for _, block := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		lines := strings.SplitN(block, "\n", 2)
+		require.Len(t, lines, 2, block)
+		event := streamEvent{name: strings.TrimPrefix(lines[0], "event: ")}
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &event.data))
+		assert.Equal(t, event.name, event.data["type"])
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestWriter_Completion(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writer := NewWriter(rec, &Request{Model: "claude-sonnet-4-5", MaxTokens: 64})
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Content-Length", "999")
+	writer.WriteHeader(http.StatusOK)
+	_, err := writer.Write([]byte(`{
+		"id": "chatcmpl-abc",
+		"object": "chat.completion",
+		"created": 1735689600,
+		"model": "claude-sonnet-4-5",
+		"choices": [{"index": 0, "finish_reason": "tool_calls", "message": {
+			"role": "assistant",
+			"content": "Checking.",
+			"tool_calls": [
+				{"id": "toolu_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":1}"}},
+				{"id": "toolu_2", "type": "function", "function": {"name": "list", "arguments": ""}}
+			]
+		}}],
+		"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15, "prompt_tokens_details": {"cached_tokens": 4}}
+	}`))
+	require.NoError(t, err)
+	assert.Empty(t, rec.Body.String(), "the completion is only written once translated")
+	require.NoError(t, writer.Finish())
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, strconv.Itoa(rec.Body.Len()), rec.Header().Get("Content-Length"))
+	assert.JSONEq(t, `{
+		"id": "msg_abc",
+		"type": "message",
+		"role": "assistant",
+		"content": [
+			{"type": "text", "text": "Checking."},
+			{"type": "tool_use", "id": "toolu_1", "name": "lookup", "input": {"q": 1}},
+			{"type": "tool_use", "id": "toolu_2", "name": "list", "input": {}}
+		],
+		"model": "claude-sonnet-4-5",
+		"stop_reason": "tool_use",
+		"stop_sequence": null,
+		"usage": {"input_tokens": 6, "output_tokens": 5, "cache_read_input_tokens": 4}
+	}`, rec.Body.String())
+}
+
+func TestWriter_Error(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		errorType string
+		message   string
+	}{
+		{"rate limited", http.StatusTooManyRequests, `{"error":{"type":"rate_limit_error","message":"slow down"}}`, "rate_limit_error", "slow down"},
+		{"invalid request", http.StatusBadRequest, `{"error":{"message":"max_tokens is required"}}`, "invalid_request_error", "max_tokens is required"},
+		{"forbidden", http.StatusForbidden, `{"error":{"message":"model not permitted"}}`, "permission_error", "model not permitted"},
+		{"unavailable", http.StatusServiceUnavailable, `maintenance`, "overloaded_error", "maintenance"},
+		{"vendor failure", http.StatusBadGateway, ``, "api_error", "Bad Gateway"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writer := NewWriter(rec, &Request{Model: "claude-sonnet-4-5"})
+			writer.Header().Set("Content-Type", "application/json")
+			writer.Header().Set("Retry-After", "5")
+			writer.WriteHeader(tt.status)
+			_, err := writer.Write([]byte(tt.body))
+			require.NoError(t, err)
+			require.NoError(t, writer.Finish())
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, "5", rec.Header().Get("Retry-After"))
+			var response ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, ErrorResponse{Type: "error", Error: ErrorDetail{Type: tt.errorType, Message: tt.message}}, response)
+		})
+	}
+}
+
+func TestWriter_UntranslatableCompletion(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"no choices", `{"choices": []}`},
+		{"invalid arguments", `{"choices": [{"message": {"tool_calls": [{"id": "t", "function": {"name": "f", "arguments": "{\"q\":"}}]}}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writer := NewWriter(rec, &Request{Model: "claude-sonnet-4-5"})
+			_, err := writer.Write([]byte(tt.body))
+			require.NoError(t, err)
+			assert.Error(t, writer.Finish())
+			assert.Empty(t, rec.Body.String())
+		})
+	}
+}
+
+func TestWriter_Stream(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writer := NewWriter(rec, &Request{Model: "claude-sonnet-4-5", Stream: true})
+	writer.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	writer.WriteHeader(http.StatusOK)
+
+	chunks := []string{
+		`data: {"id":"chatcmpl-abc","model":"claude-sonnet-4-5","choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}` + "\n\n",
+		`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"content":"Hel"}}]}` + "\n\n" +
+			`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+		"\n\n",
+		`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"toolu_1","type":"function","function":{"name":"lookup","arguments":""}}]}}]}` + "\n\n",
+		`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"q\":"}}]}}]}` + "\n\n",
+		`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"1}"}}]}}]}` + "\n\n",
+		`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}` + "\n\n",
+		`data: {"id":"chatcmpl-abc","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":4,"total_tokens":7}}` + "\n\n",
+		"data: [DONE]\n\n",
+	}
+	for _, chunk := range chunks {
+		_, err := writer.Write([]byte(chunk))
+		require.NoError(t, err)
+		writer.Flush()
+	}
+	require.NoError(t, writer.Finish())
+	assert.True(t, rec.Flushed)
+
+	events := parseEvents(t, rec.Body.String())
+	var names []string
+	for _, event := range events {
+		names = append(names, event.name)
+	}
+	assert.Equal(t, []string{
+		"message_start",
+		"content_block_start",
+		"content_block_delta",
+		"content_block_delta",
+		"content_block_stop",
+		"content_block_start",
+		"content_block_delta",
+		"content_block_delta",
+		"content_block_stop",
+		"message_delta",
+		"message_stop",
+	}, names)
+
+	message := events[0].data["message"].(map[string]interface{})
+	assert.Equal(t, "msg_abc", message["id"])
+	assert.Equal(t, "claude-sonnet-4-5", message["model"])
+	assert.Equal(t, map[string]interface{}{"type": "text_delta", "text": "lo"}, events[3].data["delta"])
+	assert.EqualValues(t, 1, events[5].data["index"])
+	assert.Equal(t, map[string]interface{}{"type": "tool_use", "id": "toolu_1", "name": "lookup", "input": map[string]interface{}{}}, events[5].data["content_block"])
+	assert.Equal(t, map[string]interface{}{"type": "input_json_delta", "partial_json": "1}"}, events[7].data["delta"])
+	assert.Equal(t, "tool_use", events[9].data["delta"].(map[string]interface{})["stop_reason"])
+	assert.EqualValues(t, 4, events[9].data["usage"].(map[string]interface{})["output_tokens"])
+}
+
+func TestWriter_StreamCutOff(t *testing.T) {
+	tests := []struct {
+		name    string
+		chunks  string
+		message string
+	}{
+		{
+			name:    "stream ends early",
+			chunks:  `data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"content":"Hi"}}]}` + "\n\n",
+			message: "The stream ended before the message completed",
+		},
+		{
+			name:    "error chunk",
+			chunks:  `data: {"error":{"message":"upstream failed"}}` + "\n\n",
+			message: "upstream failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writer := NewWriter(rec, &Request{Model: "claude-sonnet-4-5", Stream: true})
+			writer.Header().Set("Content-Type", "text/event-stream")
+			writer.WriteHeader(http.StatusOK)
+			_, err := writer.Write([]byte(tt.chunks))
+			require.NoError(t, err)
+			require.NoError(t, writer.Finish())
+
+			events := parseEvents(t, rec.Body.String())
+			last := events[len(events)-1]
+			assert.Equal(t, "error", last.name)
+			assert.Equal(t, map[string]interface{}{"type": "api_error", "message": tt.message}, last.data["error"])
+		})
+	}
+}
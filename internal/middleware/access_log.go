@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// AccessLogEntry is the single line written to the access log when a request completes
+// Its fields are flat and stable so the log can be ingested into analytics as is
+type AccessLogEntry struct {
+	Time             time.Time `json:"time"`
+	Type             string    `json:"type"`
+	RequestID        string    `json:"request_id,omitempty"`
+	Method           string    `json:"method"`
+	Path             string    `json:"path"`
+	Status           int       `json:"status"`
+	ResponseBytes    int64     `json:"response_bytes"`
+	KeyID            string    `json:"key_id,omitempty"`
+	OriginalModel    string    `json:"original_model,omitempty"`
+	Vendor           string    `json:"vendor,omitempty"`
+	Model            string    `json:"model,omitempty"`
+	Attempts         int       `json:"attempts,omitempty"`
+	Outcome          string    `json:"outcome,omitempty"`
+	PromptTokens     int       `json:"prompt_tokens,omitempty"`
+	CompletionTokens int       `json:"completion_tokens,omitempty"`
+	UsageEstimated   bool      `json:"usage_estimated,omitempty"`
+	CostUSD          *float64  `json:"cost_usd,omitempty"`
+	ValidationMs     float64   `json:"validation_ms"`
+	MediaMs          float64   `json:"media_ms"`
+	VendorTTFBMs     float64   `json:"vendor_ttfb_ms"`
+	TotalMs          float64   `json:"total_ms"`
+	// TTFTMs and TokensPerSecond are set for streamed completions that carried content
+	TTFTMs          int64   `json:"ttft_ms,omitempty"`
+	TokensPerSecond float64 `json:"tokens_per_second,omitempty"`
+}
+
+// AccessLogMiddleware writes one AccessLogEntry per request, separate from the debug logs, to
+// ACCESS_LOG_OUTPUT: stdout (the default), a file path, or off. Health checks are not logged
+func AccessLogMiddleware(next http.Handler) http.Handler {
+	out := accessLogOutput()
+	if out == nil {
+		return next
+	}
+	var mu sync.Mutex
+	encoder := json.NewEncoder(out)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		ctx := r.Context()
+		timing := monitoring.ServerTimingFromContext(ctx)
+		if timing == nil {
+			ctx, timing = monitoring.WithServerTiming(ctx)
+		}
+		ctx, record := monitoring.WithAccessRecord(ctx)
+		aw := &accessLogWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(aw, r.WithContext(ctx))
+
+		entry := AccessLogEntry{
+			Time:          start.UTC(),
+			Type:          "access",
+			RequestID:     w.Header().Get(RequestIDHeader),
+			Method:        r.Method,
+			Path:          r.URL.Path,
+			Status:        aw.status,
+			ResponseBytes: aw.bytes,
+			KeyID:         access.KeyID(r),
+			ValidationMs:  milliseconds(timing.Duration(monitoring.TimingValidation)),
+			MediaMs:       milliseconds(timing.Duration(monitoring.TimingMedia)),
+			VendorTTFBMs:  milliseconds(timing.Duration(monitoring.TimingVendor)),
+			TotalMs:       milliseconds(time.Since(start)),
+		}
+		if decision, ok := record.Decision(); ok {
+			entry.Vendor, entry.Model = decision.ServedBy()
+			entry.OriginalModel = decision.OriginalModel
+			entry.Attempts = decision.Attempts
+			entry.Outcome = decision.Outcome
+			entry.PromptTokens = decision.PromptTokens
+			entry.CompletionTokens = decision.CompletionTokens
+			entry.UsageEstimated = decision.UsageEstimated
+			entry.CostUSD = decision.CostUSD
+			entry.TTFTMs = decision.TTFTMs
+			entry.TokensPerSecond = decision.TokensPerSecond
+			if decision.ClientKeyID != "" {
+				entry.KeyID = decision.ClientKeyID
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err := encoder.Encode(entry); err != nil {
+			logger.Error(r.Context(), "Failed to write access log entry", err)
+		}
+	})
+}
+
+// accessLogOutput opens the writer ACCESS_LOG_OUTPUT names, or returns nil when it is off
+// A file that cannot be opened falls back to stdout, as LOG_OUTPUT does
+func accessLogOutput() io.Writer {
+	target := strings.TrimSpace(utils.GetEnvString("ACCESS_LOG_OUTPUT", "stdout"))
+	switch strings.ToLower(target) {
+	case "off", "false", "none":
+		return nil
+	case "", "stdout":
+		return os.Stdout
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		logger.Warn(context.Background(), "Cannot open access log output, writing to stdout", "output", target, "error", err)
+		return os.Stdout
+	}
+	return f
+}
+
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// accessLogWriter records the status and size of the response for the access log
+type accessLogWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (w *accessLogWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *accessLogWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(data)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher interface for streaming support
+func (w *accessLogWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to set write deadlines
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAccessLog returns the entries written to the access log file at path
+func readAccessLog(t *testing.T, path string) []AccessLogEntry {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var entries []AccessLogEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry AccessLogEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	t.Setenv("ACCESS_LOG_OUTPUT", path)
+	cost := 0.0032
+
+	handler := AccessLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timing := monitoring.ServerTimingFromContext(r.Context())
+		timing.Add(monitoring.TimingValidation, 2*time.Millisecond)
+		timing.Add(monitoring.TimingMedia, 30*time.Millisecond)
+		timing.Add(monitoring.TimingVendor, 250*time.Millisecond)
+		monitoring.AccessRecordFromContext(r.Context()).SetDecision(monitoring.RoutingDecision{
+			OriginalModel: "my-model", Vendor: "openai", Model: "gpt-4o",
+			FallbackVendor: "gemini", FallbackModel: "gemini-2.0-flash",
+			Attempts: 2, Outcome: "success", PromptTokens: 1500, CompletionTokens: 20,
+			CostUSD: &cost, ClientKeyID: "abc123", TTFTMs: 420, TokensPerSecond: 61.5,
+		})
+		w.Header().Set(RequestIDHeader, "req-1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+
+	entries := readAccessLog(t, path)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "access", entry.Type)
+	assert.Equal(t, "req-1", entry.RequestID)
+	assert.Equal(t, http.MethodPost, entry.Method)
+	assert.Equal(t, "/v1/chat/completions", entry.Path)
+	assert.Equal(t, http.StatusCreated, entry.Status)
+	assert.Equal(t, int64(2), entry.ResponseBytes)
+	assert.Equal(t, "abc123", entry.KeyID)
+	assert.Equal(t, "gemini", entry.Vendor, "the fallback served the request")
+	assert.Equal(t, "gemini-2.0-flash", entry.Model)
+	assert.Equal(t, 2, entry.Attempts)
+	assert.Equal(t, 1500, entry.PromptTokens)
+	assert.Equal(t, 20, entry.CompletionTokens)
+	require.NotNil(t, entry.CostUSD)
+	assert.Equal(t, cost, *entry.CostUSD)
+	assert.Equal(t, 2.0, entry.ValidationMs)
+	assert.Equal(t, 30.0, entry.MediaMs)
+	assert.Equal(t, 250.0, entry.VendorTTFBMs)
+	assert.GreaterOrEqual(t, entry.TotalMs, 0.0)
+	assert.Equal(t, int64(420), entry.TTFTMs)
+	assert.Equal(t, 61.5, entry.TokensPerSecond)
+}
+
+func TestAccessLogMiddleware_UnroutedAndHealthRequests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	t.Setenv("ACCESS_LOG_OUTPUT", path)
+
+	handler := AccessLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := readAccessLog(t, path)
+	require.Len(t, entries, 1, "health checks are not logged")
+	assert.Equal(t, http.StatusUnauthorized, entries[0].Status)
+	assert.Len(t, entries[0].KeyID, 64, "the key is identified by its digest")
+	assert.Empty(t, entries[0].Vendor)
+}
+
+func TestAccessLogMiddleware_Off(t *testing.T) {
+	t.Setenv("ACCESS_LOG_OUTPUT", "off")
+
+	handler := AccessLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, monitoring.AccessRecordFromContext(r.Context()))
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	stderrors "errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/aashari/go-generative-api-router/internal/admission"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+)
+
+// AdmissionMiddleware admits completion requests through the process-wide admission
+// controller, holding their share until they are served. Requests beyond the configured
+// ceilings wait in its queue or are rejected with a 503 and Retry-After. The estimated
+// footprint is the request body, grown by the media downloaded while serving it
+func AdmissionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		controller := admission.Default()
+		if !controller.Enabled() || !maintenanceBlocked(r.URL.Path) || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		estimate := max(r.ContentLength, 0)
+		ticket, err := controller.Admit(r.Context(), estimate)
+		if err != nil {
+			if r.Context().Err() != nil {
+				// The client is gone; there is nobody left to answer
+				return
+			}
+			stats := controller.Stats()
+			ctx := logger.WithComponent(r.Context(), "AdmissionMiddleware")
+			ctx = logger.WithStage(ctx, "RequestBlocked")
+			logger.Warn(ctx, "Request rejected by admission control",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"estimated_bytes", estimate,
+				"in_flight", stats.InFlight,
+				"memory_bytes", stats.MemoryBytes,
+				"queued", stats.Queued,
+				"reason", err.Error(),
+			)
+
+			code := "overloaded"
+			if stderrors.Is(err, admission.ErrTooLarge) {
+				code = "request_too_large"
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(max(int(stats.QueueTimeout.Seconds()), 1)))
+			apiErr := errors.NewAPIErrorWithCode(errors.ErrorTypeUnavailable, err.Error(), code)
+			errors.HandleError(w, apiErr, http.StatusServiceUnavailable)
+			return
+		}
+		defer ticket.Release()
+
+		r = r.WithContext(admission.WithTicket(r.Context(), ticket))
+		if r.ContentLength < 0 && r.Body != nil {
+			// Bodies of unknown length count as they are read
+			r.Body = &countingBody{ReadCloser: r.Body, ticket: ticket}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// countingBody grows a ticket by the bytes read from a request body
+type countingBody struct {
+	io.ReadCloser
+	ticket *admission.Ticket
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.ticket.Grow(int64(n))
+	return n, err
+}
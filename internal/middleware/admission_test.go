@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/admission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmissionMiddleware(t *testing.T) {
+	release := make(chan struct{})
+	handler := AdmissionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("hold") != "" {
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	controller := admission.Default()
+	controller.Configure(admission.Limits{MaxInFlight: 1, MaxMemoryBytes: 1 << 10, QueueTimeout: time.Second})
+	t.Cleanup(func() { controller.Configure(admission.Limits{}) })
+
+	held := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions?hold=1", strings.NewReader("{}")))
+		held <- rec.Code
+	}()
+	require.Eventually(t, func() bool { return controller.Stats().InFlight == 1 }, time.Second, time.Millisecond)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"completion rejected at the in-flight ceiling", http.MethodPost, "/v1/chat/completions", "{}", http.StatusServiceUnavailable, "overloaded"},
+		{"gemini rejected at the in-flight ceiling", http.MethodPost, "/v1beta/models/gemini-2.5-flash:generateContent", "{}", http.StatusServiceUnavailable, "overloaded"},
+		{"body over the memory ceiling", http.MethodPost, "/v1/chat/completions", strings.Repeat("x", 2<<10), http.StatusServiceUnavailable, "request_too_large"},
+		{"preflight allowed", http.MethodOptions, "/v1/chat/completions", "", http.StatusOK, ""},
+		{"models allowed", http.MethodGet, "/v1/models", "", http.StatusOK, ""},
+		{"health allowed", http.MethodGet, "/health", "", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusServiceUnavailable {
+				assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+				assert.Contains(t, rec.Body.String(), `"code":"`+tt.expectedCode+`"`)
+			}
+		})
+	}
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-held)
+	assert.Equal(t, 0, controller.Stats().InFlight)
+	assert.EqualValues(t, 0, controller.Stats().MemoryBytes)
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// apiKeyHeaders are the headers SDKs other than OpenAI's send their key in: x-api-key for
+// Anthropic's, x-goog-api-key for Google's
+var apiKeyHeaders = []string{utils.HeaderXAPIKey, utils.HeaderXGoogAPIKey}
+
+// APIKeyHeaderMiddleware lets clients of Anthropic's and Google's SDKs, which send their key
+// in the x-api-key and x-goog-api-key headers, authenticate like every other client: when a
+// request has no Authorization header, its key becomes a Bearer token. The headers are always
+// removed so they are never forwarded to vendors
+func APIKeyHeaderMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := ""
+		for _, header := range apiKeyHeaders {
+			if key = strings.TrimSpace(r.Header.Get(header)); key != "" {
+				break
+			}
+		}
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		r = r.Clone(r.Context())
+		for _, header := range apiKeyHeaders {
+			r.Header.Del(header)
+		}
+		if r.Header.Get(utils.HeaderAuthorization) == "" {
+			r.Header.Set(utils.HeaderAuthorization, "Bearer "+key)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyHeaderMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		apiKey        string
+		googAPIKey    string
+		authorization string
+		expectedAuth  string
+	}{
+		{"x-api-key becomes a bearer token", "sk-client", "", "", "Bearer sk-client"},
+		{"x-goog-api-key becomes a bearer token", "", "sk-client", "", "Bearer sk-client"},
+		{"authorization header wins", "sk-client", "sk-client", "Bearer sk-other", "Bearer sk-other"},
+		{"no key", "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen *http.Request
+			handler := APIKeyHeaderMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = r
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+			if tt.apiKey != "" {
+				req.Header.Set("x-api-key", tt.apiKey)
+			}
+			if tt.googAPIKey != "" {
+				req.Header.Set("x-goog-api-key", tt.googAPIKey)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.expectedAuth, seen.Header.Get("Authorization"))
+			assert.Empty(t, seen.Header.Get("x-api-key"), "the key is never forwarded")
+			assert.Empty(t, seen.Header.Get("x-goog-api-key"), "the key is never forwarded")
+		})
+	}
+}
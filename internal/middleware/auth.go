@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// authenticatedPrefixes are the API paths that need a known client key while a key store
+// is configured. Health checks, metrics and docs are left alone; admin endpoints have their
+// own check in AdminAuthMiddleware
+var authenticatedPrefixes = []string{"/v1/", "/v1beta/"}
+
+// adminPrefix is the path prefix of the admin endpoints
+const adminPrefix = "/admin/"
+
+// adminPublicPaths are admin pages served without a credential; they hold no data and ask
+// for the admin key before loading any
+var adminPublicPaths = map[string]bool{
+	"/admin/dashboard": true,
+}
+
+// AuthMiddleware rejects API requests whose bearer token the process-wide key store does
+// not know, with a 401, and attaches the identity of the others to the request context for
+// logging, rate limits and routing policies. Without a key store every key is accepted, as
+// vendors are called with the router's own credentials. Requests the router makes on an
+// admin's behalf, marked with access.WithAdmin, such as capture replays, are trusted as they are
+func AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		store := access.DefaultKeyStore()
+		if store == nil || !authenticated(r.URL.Path) || r.Method == http.MethodOptions || access.IsAdmin(r.Context()) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := logger.WithComponent(r.Context(), "AuthMiddleware")
+		ctx = logger.WithStage(ctx, "Authentication")
+
+		key := access.ClientKey(r)
+		if key == "" {
+			errors.HandleError(w, errors.NewAuthenticationError("missing API key: send it as a Bearer token in the Authorization header"), http.StatusUnauthorized)
+			return
+		}
+		identity, err := store.Lookup(r.Context(), key)
+		if err != nil {
+			logger.Error(ctx, "Client key store lookup failed", err,
+				"client_key", access.KeyHint(r),
+			)
+			errors.HandleError(w, errors.NewAPIError(errors.ErrorTypeUnavailable, "API key could not be verified, try again later"), http.StatusServiceUnavailable)
+			return
+		}
+		if identity == nil || identity.Disabled {
+			logger.Info(ctx, "Request rejected with unknown or disabled API key",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"client_key", access.KeyHint(r),
+			)
+			errors.HandleError(w, errors.NewAuthenticationError("invalid API key"), http.StatusUnauthorized)
+			return
+		}
+
+		ctx = access.WithIdentity(r.Context(), identity)
+		ctx = logger.WithClientID(ctx, identity.ID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticated reports whether path needs a known client key
+func authenticated(path string) bool {
+	for _, prefix := range authenticatedPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminAuthMiddleware requires an admin credential on the /admin/ endpoints: the
+// ADMIN_API_KEY bearer token, or the key of a key store identity with admin set. Requests
+// presenting one are marked with access.WithAdmin. Without ADMIN_API_KEY or a key store the
+// admin endpoints are read-only: GET and HEAD pass unmarked and every change is rejected
+func AdminAuthMiddleware(next http.Handler) http.Handler {
+	adminKey := utils.GetEnvString("ADMIN_API_KEY", "")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, adminPrefix) || adminPublicPaths[r.URL.Path] || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := logger.WithComponent(r.Context(), "AdminAuthMiddleware")
+		ctx = logger.WithStage(ctx, "Authentication")
+
+		store := access.DefaultKeyStore()
+		key := access.ClientKey(r)
+		if key != "" && adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) == 1 {
+			next.ServeHTTP(w, r.WithContext(access.WithAdmin(r.Context())))
+			return
+		}
+		if key != "" && store != nil {
+			identity, err := store.Lookup(r.Context(), key)
+			if err != nil {
+				logger.Error(ctx, "Client key store lookup failed", err,
+					"client_key", access.KeyHint(r),
+				)
+				errors.HandleError(w, errors.NewAPIError(errors.ErrorTypeUnavailable, "API key could not be verified, try again later"), http.StatusServiceUnavailable)
+				return
+			}
+			if identity != nil && !identity.Disabled && identity.Admin {
+				adminCtx := access.WithAdmin(access.WithIdentity(r.Context(), identity))
+				next.ServeHTTP(w, r.WithContext(logger.WithClientID(adminCtx, identity.ID)))
+				return
+			}
+		}
+
+		if adminKey == "" && store == nil && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		logger.Warn(ctx, "Admin request rejected without an admin credential",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"client_key", access.KeyHint(r),
+			"remote_addr", r.RemoteAddr,
+		)
+		message := "admin endpoints need an admin API key"
+		if adminKey == "" && store == nil {
+			message = "admin endpoints are read-only until ADMIN_API_KEY or CLIENT_AUTH_STORE is set"
+		}
+		errors.HandleError(w, errors.NewAuthenticationError(message), http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingKeyStore is a key store whose backend is unreachable
+type failingKeyStore struct{}
+
+func (failingKeyStore) Lookup(context.Context, string) (*access.Identity, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	var seen *http.Request
+	handler := AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	access.SetDefaultKeyStore(access.NewFileKeyStore(map[string]access.Identity{
+		"sk-team-a":  {ID: "team-a"},
+		"sk-retired": {ID: "team-b", Disabled: true},
+	}))
+	t.Cleanup(func() { access.SetDefaultKeyStore(nil) })
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		authorization  string
+		expectedStatus int
+		expectedID     string
+	}{
+		{"known key", http.MethodPost, "/v1/chat/completions", "Bearer sk-team-a", http.StatusOK, "team-a"},
+		{"gemini path", http.MethodPost, "/v1beta/models/gemini-2.5-flash:generateContent", "Bearer sk-team-a", http.StatusOK, "team-a"},
+		{"unknown key", http.MethodPost, "/v1/chat/completions", "Bearer sk-unknown", http.StatusUnauthorized, ""},
+		{"disabled key", http.MethodPost, "/v1/chat/completions", "Bearer sk-retired", http.StatusUnauthorized, ""},
+		{"missing key", http.MethodGet, "/v1/models", "", http.StatusUnauthorized, ""},
+		{"health unauthenticated", http.MethodGet, "/health", "", http.StatusOK, ""},
+		{"admin unauthenticated", http.MethodGet, "/admin/budgets", "", http.StatusOK, ""},
+		{"preflight unauthenticated", http.MethodOptions, "/v1/chat/completions", "", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				assert.Nil(t, seen)
+				assert.Contains(t, rec.Body.String(), "authentication_error")
+				return
+			}
+			identity := access.IdentityFromContext(seen.Context())
+			if tt.expectedID == "" {
+				assert.Nil(t, identity)
+				return
+			}
+			if assert.NotNil(t, identity) {
+				assert.Equal(t, tt.expectedID, identity.ID)
+			}
+			assert.Equal(t, tt.expectedID, seen.Context().Value(logger.ClientIDKey))
+		})
+	}
+}
+
+func TestAuthMiddleware_StoreUnavailable(t *testing.T) {
+	handler := AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("request must not reach the handler")
+	}))
+	access.SetDefaultKeyStore(failingKeyStore{})
+	t.Cleanup(func() { access.SetDefaultKeyStore(nil) })
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req.Header.Set("Authorization", "Bearer sk-team-a")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestAuthMiddleware_NoKeyStore(t *testing.T) {
+	reached := false
+	handler := AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+	assert.True(t, reached, "any key is accepted without a key store")
+}
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	access.SetDefaultKeyStore(access.NewFileKeyStore(map[string]access.Identity{
+		"sk-ops":    {ID: "ops", Admin: true},
+		"sk-team-a": {ID: "team-a"},
+	}))
+	t.Cleanup(func() { access.SetDefaultKeyStore(nil) })
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+
+	var seen *http.Request
+	handler := AdminAuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		authorization  string
+		expectedStatus int
+	}{
+		{"unauthenticated maintenance toggle", http.MethodPut, "/admin/maintenance", "", http.StatusUnauthorized},
+		{"unauthenticated read", http.MethodGet, "/admin/captures", "", http.StatusUnauthorized},
+		{"client key without admin", http.MethodPut, "/admin/maintenance", "Bearer sk-team-a", http.StatusUnauthorized},
+		{"admin API key", http.MethodPut, "/admin/maintenance", "Bearer admin-secret", http.StatusOK},
+		{"admin identity", http.MethodPost, "/admin/captures/req_1/replay", "Bearer sk-ops", http.StatusOK},
+		{"dashboard page", http.MethodGet, "/admin/dashboard", "", http.StatusOK},
+		{"API paths untouched", http.MethodPost, "/v1/chat/completions", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = nil
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				assert.Nil(t, seen)
+				return
+			}
+			if tt.authorization != "" {
+				assert.True(t, access.IsAdmin(seen.Context()))
+			}
+		})
+	}
+}
+
+func TestAdminAuthMiddleware_ReadOnlyWithoutCredentials(t *testing.T) {
+	handler := AdminAuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.False(t, access.IsAdmin(r.Context()))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	get := httptest.NewRecorder()
+	handler.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/admin/maintenance", nil))
+	assert.Equal(t, http.StatusOK, get.Code)
+
+	put := httptest.NewRecorder()
+	handler.ServeHTTP(put, httptest.NewRequest(http.MethodPut, "/admin/maintenance", nil))
+	assert.Equal(t, http.StatusUnauthorized, put.Code)
+	assert.Contains(t, put.Body.String(), "ADMIN_API_KEY")
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/capture"
+)
+
+// CaptureMiddleware keeps a sanitized copy of every API request and its response in the
+// capture store, for inspection and replay from the admin endpoints. It is opt-in via
+// REQUEST_CAPTURE_ENABLED=true; credentials are stripped before anything is stored
+func CaptureMiddleware(next http.Handler) http.Handler {
+	store := capture.Default()
+	if store == nil {
+		return next
+	}
+	return captureWith(store, next)
+}
+
+// captureWith records the API requests served by next in store
+func captureWith(store *capture.Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v1/") && !strings.HasPrefix(r.URL.Path, "/v1beta/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Only the part of the body the store keeps is read ahead; one byte more tells
+		// NewMessage it was truncated. The handler reads the rest as it streams in
+		var body []byte
+		if r.Body != nil {
+			var reader io.Reader = r.Body
+			if store.MaxBodyBytes > 0 {
+				reader = io.LimitReader(r.Body, int64(store.MaxBodyBytes)+1)
+			}
+			var err error
+			if body, err = io.ReadAll(reader); err != nil {
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = prefixedBody{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+		}
+
+		start := time.Now()
+		cw := &captureWriter{ResponseWriter: w, status: http.StatusOK, limit: store.MaxBodyBytes}
+		next.ServeHTTP(cw, r)
+
+		response := capture.NewMessage(w.Header(), cw.body.Bytes(), store.MaxBodyBytes)
+		response.Truncated = response.Truncated || cw.truncated
+		store.Record(capture.Exchange{
+			ID:         w.Header().Get(RequestIDHeader),
+			Time:       start.UTC(),
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Query:      capture.SanitizeQuery(r.URL.RawQuery),
+			Status:     cw.status,
+			DurationMs: time.Since(start).Milliseconds(),
+			Request:    capture.NewMessage(r.Header, body, store.MaxBodyBytes),
+			Response:   response,
+		})
+	})
+}
+
+// prefixedBody is a request body whose first bytes were already read, put back in front
+// of the rest
+type prefixedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// captureWriter keeps the status and up to limit bytes of the response for the capture store
+type captureWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	limit       int
+	truncated   bool
+}
+
+func (w *captureWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *captureWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	kept := data
+	if w.limit > 0 && w.body.Len()+len(kept) > w.limit {
+		kept = kept[:w.limit-w.body.Len()]
+		w.truncated = true
+	}
+	w.body.Write(kept)
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush implements http.Flusher interface for streaming support
+func (w *captureWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to set write deadlines
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
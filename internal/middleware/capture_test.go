@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/capture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureMiddleware(t *testing.T) {
+	store := capture.NewStore(10, 8)
+	handler := captureWith(store, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, `{"model":"gpt-4o"}`, string(body), "the handler still reads the whole body")
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("0123456789"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions?key=gm-key", strings.NewReader(`{"model":"gpt-4o"}`))
+	req.Header.Set("Authorization", "Bearer sk-secret")
+	rec := httptest.NewRecorder()
+	rec.Header().Set(RequestIDHeader, "req-1")
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "0123456789", rec.Body.String())
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/admin/captures", nil))
+
+	exchanges := store.List(0)
+	require.Len(t, exchanges, 1, "only API requests are captured")
+	exchange := exchanges[0]
+	assert.Equal(t, "req-1", exchange.ID)
+	assert.Equal(t, http.StatusAccepted, exchange.Status)
+	assert.Empty(t, exchange.Query)
+	assert.Empty(t, exchange.Request.Headers.Get("Authorization"))
+	assert.True(t, exchange.Request.Truncated)
+	assert.Equal(t, "01234567", exchange.Response.Body)
+	assert.True(t, exchange.Response.Truncated)
+}
+
+// countingReader counts the bytes read from it
+type countingReader struct {
+	io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.read += n
+	return n, err
+}
+
+func TestCaptureMiddleware_ReadsOnlyTheKeptPrefix(t *testing.T) {
+	store := capture.NewStore(10, 8)
+	source := &countingReader{Reader: strings.NewReader(strings.Repeat("a", 1<<20))}
+	var readAhead int
+	handler := captureWith(store, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		readAhead = source.read
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Len(t, body, 1<<20, "the handler still reads the whole body")
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/audio/transcriptions", nil)
+	req.Body = io.NopCloser(source)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Less(t, readAhead, 1<<20, "the body is not buffered before the handler runs")
+	exchanges := store.List(0)
+	require.Len(t, exchanges, 1)
+	assert.Equal(t, "aaaaaaaa", exchanges[0].Request.Body)
+	assert.True(t, exchanges[0].Request.Truncated)
+}
@@ -50,8 +50,9 @@ func RequestCorrelationMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// General request handling with structured logging
-		handleGeneralRequest(ctx, w, r, next)
+		// General request handling with structured logging; 1 in LOG_BODY_SAMPLE_RATE
+		// requests get their bodies logged in full
+		handleGeneralRequest(logger.WithBodySampling(ctx), w, r, next)
 	})
 }
 
@@ -218,14 +219,9 @@ func logStructuredRequest(ctx context.Context, r *http.Request, body []byte) {
 		"headers":    utils.SanitizeHeaders(r.Header),
 	}
 
-	// Add body if present
+	// Add a body summary (or the body itself with LOG_REQUEST_BODY=full) if present
 	if len(body) > 0 {
-		var bodyData interface{}
-		if err := json.Unmarshal(body, &bodyData); err == nil {
-			requestData["body"] = utils.TruncateBase64InData(bodyData)
-		} else {
-			requestData["body"] = "Non-JSON body omitted"
-		}
+		requestData["body"] = logger.DescribeRequestBody(ctx, body)
 	}
 
 	logger.Info(
@@ -244,11 +240,10 @@ func logStructuredResponse(ctx context.Context, w *responseWriterWrapper, durati
 		"headers":        utils.SanitizeHeaders(w.Header()),
 	}
 
-	// Add response body if available
+	// Add response body if available and the request was sampled for full bodies
 	if w.body.Len() > 0 && !w.isStreaming {
-		var bodyData interface{}
-		if err := json.Unmarshal(w.body.Bytes(), &bodyData); err == nil {
-			responseData["body"] = utils.TruncateBase64InData(bodyData)
+		if body := logger.DescribeResponseBody(ctx, w.body.Bytes()); body != nil {
+			responseData["body"] = body
 		}
 	} else if w.isStreaming {
 		responseData["body"] = "[streaming response]"
@@ -324,6 +319,11 @@ func (w *responseWriterWrapper) Flush() {
 	}
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to set write deadlines
+func (w *responseWriterWrapper) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // Header constants
 const (
 	RequestIDHeader     = utils.HeaderRequestID
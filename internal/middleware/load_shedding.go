@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strconv"
+
+	"github.com/aashari/go-generative-api-router/internal/admission"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+)
+
+// LoadSheddingMiddleware rejects completion requests with a 503 and Retry-After, before
+// they queue for admission, while the process-wide shedder finds memory, goroutines or
+// in-flight requests over their thresholds
+func LoadSheddingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		shedder := admission.DefaultShedder()
+		if !shedder.Enabled() || !maintenanceBlocked(r.URL.Path) || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		release, err := shedder.Admit()
+		if err != nil {
+			var shedErr *admission.ShedError
+			reason := ""
+			if stderrors.As(err, &shedErr) {
+				reason = shedErr.Reason
+			}
+			ctx := logger.WithComponent(r.Context(), "LoadSheddingMiddleware")
+			ctx = logger.WithStage(ctx, "RequestShed")
+			logger.Warn(ctx, "Request shed under load",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"resource", reason,
+				"reason", err.Error(),
+			)
+
+			w.Header().Set("Retry-After", strconv.Itoa(max(int(shedder.RetryAfter().Seconds()), 1)))
+			apiErr := errors.NewAPIErrorWithCode(errors.ErrorTypeUnavailable, err.Error(), "load_shed")
+			errors.HandleError(w, apiErr, http.StatusServiceUnavailable)
+			return
+		}
+		defer release()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/admission"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSheddingMiddleware(t *testing.T) {
+	release := make(chan struct{})
+	handler := LoadSheddingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("hold") != "" {
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	shedder := admission.DefaultShedder()
+	shedder.Configure(admission.ShedderConfig{MaxInFlight: 1, RetryAfter: 3 * time.Second})
+	t.Cleanup(func() { shedder.Configure(admission.ShedderConfig{}) })
+
+	held := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions?hold=1", strings.NewReader("{}")))
+		held <- rec.Code
+	}()
+	require.Eventually(t, func() bool { return shedder.Stats().InFlight == 1 }, time.Second, time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader("{}")))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "3", rec.Header().Get("Retry-After"))
+	assert.Contains(t, rec.Body.String(), `"code":"load_shed"`)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "health checks are never shed")
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-held)
+	stats := shedder.Stats()
+	assert.Equal(t, 0, stats.InFlight)
+	assert.EqualValues(t, 1, stats.Shed[admission.ShedReasonInFlight])
+}
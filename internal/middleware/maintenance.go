@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/maintenance"
+)
+
+// maintenanceBlockedPaths are the endpoints paused while maintenance mode is enabled
+// Model listing, health checks and admin endpoints keep working
+var maintenanceBlockedPaths = map[string]bool{
+	"/v1/chat/completions":     true,
+	"/v1/responses":            true,
+	"/v1/messages":             true,
+	"/v1/aggregate":            true,
+	"/v1/images/text":          true,
+	"/v1/embeddings":           true,
+	"/v1/audio/transcriptions": true,
+	"/v1/audio/speech":         true,
+	"/v1/moderations":          true,
+	"/v1/rerank":               true,
+}
+
+// maintenanceBlockedSuffixes are the Gemini methods paused with them, served on any model path
+var maintenanceBlockedSuffixes = []string{":generateContent", ":streamGenerateContent"}
+
+// maintenanceBlocked reports whether path is paused while maintenance mode is enabled
+func maintenanceBlocked(path string) bool {
+	if maintenanceBlockedPaths[path] {
+		return true
+	}
+	for _, suffix := range maintenanceBlockedSuffixes {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaintenanceMiddleware rejects completion requests with a 503 and Retry-After while
+// the process-wide maintenance mode is enabled
+func MaintenanceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !maintenanceBlocked(r.URL.Path) || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		status := maintenance.Default().Status()
+		if !status.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := logger.WithComponent(r.Context(), "MaintenanceMiddleware")
+		ctx = logger.WithStage(ctx, "RequestBlocked")
+		logger.Info(ctx, "Request rejected during maintenance mode",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"retry_after", status.RetryAfter,
+		)
+
+		w.Header().Set("Retry-After", strconv.Itoa(status.RetryAfter))
+		err := errors.NewAPIErrorWithCode(errors.ErrorTypeUnavailable, status.Message, "maintenance_mode")
+		errors.HandleError(w, err, http.StatusServiceUnavailable)
+	})
+}
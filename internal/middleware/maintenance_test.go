@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/maintenance"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	handler := MaintenanceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	mode := maintenance.Default()
+	mode.Enable("Incident in progress", 120)
+	t.Cleanup(mode.Disable)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{"chat completions blocked", http.MethodPost, "/v1/chat/completions", http.StatusServiceUnavailable},
+		{"image description blocked", http.MethodPost, "/v1/images/text", http.StatusServiceUnavailable},
+		{"gemini generateContent blocked", http.MethodPost, "/v1beta/models/gemini-2.5-flash:generateContent", http.StatusServiceUnavailable},
+		{"gemini streamGenerateContent blocked", http.MethodPost, "/v1/models/gemini-2.5-flash:streamGenerateContent", http.StatusServiceUnavailable},
+		{"preflight allowed", http.MethodOptions, "/v1/chat/completions", http.StatusOK},
+		{"models allowed", http.MethodGet, "/v1/models", http.StatusOK},
+		{"model allowed", http.MethodGet, "/v1/models/gemini-2.5-flash", http.StatusOK},
+		{"health allowed", http.MethodGet, "/health", http.StatusOK},
+		{"admin allowed", http.MethodGet, "/admin/maintenance", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusServiceUnavailable {
+				assert.Equal(t, "120", rec.Header().Get("Retry-After"))
+				assert.Contains(t, rec.Body.String(), `"code":"maintenance_mode"`)
+				assert.Contains(t, rec.Body.String(), "Incident in progress")
+			}
+		})
+	}
+
+	mode.Disable()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
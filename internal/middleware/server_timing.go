@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// ServerTimingMiddleware adds a Server-Timing header showing how long the vendor took,
+// how long the router spent processing and the total, so latency can be inspected in
+// browser devtools. It is opt-in via SERVER_TIMING_ENABLED=true
+func ServerTimingMiddleware(next http.Handler) http.Handler {
+	if !utils.GetEnvBool("SERVER_TIMING_ENABLED", false) {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, timing := monitoring.WithServerTiming(r.Context())
+		tw := &serverTimingWriter{ResponseWriter: w, timing: timing, start: time.Now()}
+		next.ServeHTTP(tw, r.WithContext(ctx))
+	})
+}
+
+// serverTimingWriter sets the Server-Timing header just before the response headers are sent
+// For streaming responses the durations therefore cover the time until the first byte
+type serverTimingWriter struct {
+	http.ResponseWriter
+	timing      *monitoring.ServerTiming
+	start       time.Time
+	wroteHeader bool
+}
+
+func (w *serverTimingWriter) WriteHeader(statusCode int) {
+	w.setHeader()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *serverTimingWriter) Write(data []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush implements http.Flusher interface for streaming support
+func (w *serverTimingWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to set write deadlines
+func (w *serverTimingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *serverTimingWriter) setHeader() {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.Header().Set(utils.HeaderServerTiming, w.timing.Header(time.Since(w.start)))
+	w.Header().Set(utils.HeaderTimingAllowOrigin, utils.CORSAllowOriginAll)
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerTimingMiddleware(t *testing.T) {
+	t.Setenv("SERVER_TIMING_ENABLED", "true")
+
+	handler := ServerTimingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		monitoring.ServerTimingFromContext(r.Context()).Add(monitoring.TimingVendor, 250*time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+
+	header := rec.Header().Get("Server-Timing")
+	assert.Regexp(t, regexp.MustCompile(`^vendor;dur=250\.0, processing;dur=\d+\.\d, total;dur=\d+\.\d$`), header)
+	assert.Equal(t, "*", rec.Header().Get("Timing-Allow-Origin"))
+}
+
+func TestServerTimingMiddleware_Disabled(t *testing.T) {
+	t.Setenv("SERVER_TIMING_ENABLED", "false")
+
+	handler := ServerTimingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, monitoring.ServerTimingFromContext(r.Context()))
+		// Recording without timing enabled is a no-op
+		monitoring.ServerTimingFromContext(r.Context()).Add(monitoring.TimingVendor, time.Second)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	assert.Empty(t, rec.Header().Get("Server-Timing"))
+}
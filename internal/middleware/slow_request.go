@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// SlowRequestReport is the diagnostic entry logged for a request that took longer than the
+// slow-request threshold
+type SlowRequestReport struct {
+	RequestID   string `json:"request_id,omitempty"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Status      int    `json:"status"`
+	DurationMs  int64  `json:"duration_ms"`
+	ThresholdMs int64  `json:"threshold_ms"`
+	// StagesMs holds the Server-Timing stages the request went through
+	StagesMs map[string]float64          `json:"stages_ms,omitempty"`
+	Decision *monitoring.RoutingDecision `json:"decision,omitempty"`
+	// Trace holds the Info and Debug entries logged while the request was served
+	Trace        []logger.TraceEntry `json:"trace,omitempty"`
+	TraceDropped int                 `json:"trace_dropped,omitempty"`
+}
+
+// slowRequestStages are the Server-Timing stages reported for slow requests
+var slowRequestStages = []string{
+	monitoring.TimingValidation,
+	monitoring.TimingMedia,
+	monitoring.TimingVendor,
+	monitoring.TimingProcessing,
+}
+
+// SlowRequestMiddleware replaces per-request logging with slow-request detection when
+// SLOW_REQUEST_THRESHOLD_MS is set: the Info and Debug entries of each request are held back,
+// dropped when the request is fast, and written in one "Slow request" diagnostic entry when
+// it is not. SLOW_REQUEST_TRACE=true keeps Debug entries in that trace whatever LOG_LEVEL is
+func SlowRequestMiddleware(next http.Handler) http.Handler {
+	threshold := time.Duration(utils.GetEnvInt("SLOW_REQUEST_THRESHOLD_MS", 0)) * time.Millisecond
+	if threshold <= 0 {
+		return next
+	}
+	return slowRequestWith(threshold, utils.GetEnvBool("SLOW_REQUEST_TRACE", false), logSlowRequest, next)
+}
+
+// slowRequestWith passes a report to emit for each request served by next that takes longer
+// than threshold
+func slowRequestWith(threshold time.Duration, verbose bool, emit func(context.Context, SlowRequestReport), next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		ctx := r.Context()
+		timing := monitoring.ServerTimingFromContext(ctx)
+		if timing == nil {
+			ctx, timing = monitoring.WithServerTiming(ctx)
+		}
+		record := monitoring.AccessRecordFromContext(ctx)
+		if record == nil {
+			ctx, record = monitoring.WithAccessRecord(ctx)
+		}
+		ctx, trace := logger.WithTrace(ctx, verbose)
+		sw := &accessLogWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		duration := time.Since(start)
+		if duration < threshold {
+			return
+		}
+
+		report := SlowRequestReport{
+			RequestID:   w.Header().Get(RequestIDHeader),
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			Status:      sw.status,
+			DurationMs:  duration.Milliseconds(),
+			ThresholdMs: threshold.Milliseconds(),
+		}
+		for _, stage := range slowRequestStages {
+			if d := timing.Duration(stage); d > 0 {
+				if report.StagesMs == nil {
+					report.StagesMs = make(map[string]float64)
+				}
+				report.StagesMs[stage] = milliseconds(d)
+			}
+		}
+		if decision, ok := record.Decision(); ok {
+			report.Decision = &decision
+		}
+		report.Trace, report.TraceDropped = trace.Entries()
+		emit(r.Context(), report)
+	})
+}
+
+// logSlowRequest writes the diagnostic entry of a slow request
+func logSlowRequest(ctx context.Context, report SlowRequestReport) {
+	ctx = logger.WithComponent(ctx, "SlowRequest")
+	if report.RequestID != "" {
+		ctx = context.WithValue(ctx, logger.RequestIDKey, report.RequestID)
+	}
+	logger.Warn(ctx, "Slow request", "slow_request", report)
+}
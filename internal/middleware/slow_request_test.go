@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowRequestReportsSlowRequests(t *testing.T) {
+	var reports []SlowRequestReport
+	emit := func(_ context.Context, report SlowRequestReport) { reports = append(reports, report) }
+
+	handler := slowRequestWith(20*time.Millisecond, false, emit, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Info(r.Context(), "Processing request", "model", "my-model")
+		logger.Debug(r.Context(), "Below the log level")
+		monitoring.ServerTimingFromContext(r.Context()).Add(monitoring.TimingVendor, 25*time.Millisecond)
+		monitoring.AccessRecordFromContext(r.Context()).SetDecision(monitoring.RoutingDecision{
+			OriginalModel: "my-model", Vendor: "openai", Model: "gpt-4o", Outcome: "success",
+		})
+		time.Sleep(25 * time.Millisecond)
+		w.Header().Set(RequestIDHeader, "req-1")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+
+	require.Len(t, reports, 1)
+	report := reports[0]
+	assert.Equal(t, "req-1", report.RequestID)
+	assert.Equal(t, http.MethodPost, report.Method)
+	assert.Equal(t, "/v1/chat/completions", report.Path)
+	assert.Equal(t, http.StatusAccepted, report.Status)
+	assert.GreaterOrEqual(t, report.DurationMs, int64(20))
+	assert.Equal(t, int64(20), report.ThresholdMs)
+	assert.InDelta(t, 25, report.StagesMs[monitoring.TimingVendor], 0.001)
+	require.NotNil(t, report.Decision)
+	assert.Equal(t, "gpt-4o", report.Decision.Model)
+	require.Len(t, report.Trace, 1)
+	assert.Equal(t, "Processing request", report.Trace[0].Message)
+	assert.Equal(t, "my-model", report.Trace[0].Attributes["model"])
+}
+
+func TestSlowRequestVerboseTraceKeepsDebugEntries(t *testing.T) {
+	var reports []SlowRequestReport
+	emit := func(_ context.Context, report SlowRequestReport) { reports = append(reports, report) }
+
+	handler := slowRequestWith(time.Nanosecond, true, emit, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Debug(logger.WithComponent(r.Context(), "Proxy"), "Below the log level")
+		time.Sleep(time.Millisecond)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+
+	require.Len(t, reports, 1)
+	require.Len(t, reports[0].Trace, 1)
+	assert.Equal(t, "DEBUG", reports[0].Trace[0].Level)
+	assert.Equal(t, "Proxy", reports[0].Trace[0].Component)
+}
+
+func TestSlowRequestIgnoresFastRequests(t *testing.T) {
+	var reports []SlowRequestReport
+	emit := func(_ context.Context, report SlowRequestReport) { reports = append(reports, report) }
+
+	handler := slowRequestWith(time.Hour, true, emit, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NotNil(t, logger.TraceFromContext(r.Context()))
+		logger.Info(r.Context(), "Processing request")
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+	assert.Empty(t, reports)
+}
+
+func TestSlowRequestMiddlewareDisabledByDefault(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, logger.TraceFromContext(r.Context()))
+	})
+
+	SlowRequestMiddleware(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+}
@@ -0,0 +1,271 @@
+// Package migrate upgrades configs/models.json and configs/credentials.json from older
+// layouts to the schema the router loads. Every migration recognises its layout and leaves
+// files already in the current schema untouched, so migrating twice is a no-op
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/probe"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Migration upgrades one older layout of a config file
+type Migration struct {
+	Name        string
+	Description string
+	// Apply rewrites the decoded file, reporting whether it found the layout it upgrades
+	Apply func(doc interface{}) (interface{}, bool)
+}
+
+// File is a config file with the migrations that upgrade it, applied in order
+type File struct {
+	Name       string
+	Migrations []Migration
+	// decode strictly decodes the migrated file into its schema
+	decode func(data []byte) (interface{}, error)
+	// secrets returns the values that must not appear in diffs
+	secrets func(decoded interface{}) []string
+}
+
+// Result is the outcome of migrating a file
+type Result struct {
+	// Applied names the migrations that changed the file; empty when it is up to date
+	Applied []string
+	// Output is the migrated file, identical to the input when nothing was applied
+	Output  []byte
+	secrets []string
+}
+
+// Changed reports whether any migration applied
+func (r Result) Changed() bool {
+	return len(r.Applied) > 0
+}
+
+// Models is configs/models.json
+var Models = File{
+	Name: "models.json",
+	Migrations: []Migration{
+		{
+			Name:        "models-array",
+			Description: "wrap a bare array of models in {\"vendors\": ..., \"models\": [...]}",
+			Apply:       wrapModelsArray,
+		},
+		{
+			Name:        "vendor-urls",
+			Description: "add the default base URL of vendors that have models but no entry in \"vendors\"",
+			Apply:       addVendorURLs,
+		},
+	},
+	decode: func(data []byte) (interface{}, error) {
+		var models config.ModelsConfig
+		if err := decodeStrict(data, &models); err != nil {
+			return nil, err
+		}
+		return &models, nil
+	},
+	secrets: func(interface{}) []string { return nil },
+}
+
+// Credentials is configs/credentials.json
+var Credentials = File{
+	Name: "credentials.json",
+	Migrations: []Migration{
+		{
+			Name:        "credentials-object",
+			Description: "turn a {\"platform\": \"key\"} object into a list of credentials",
+			Apply:       credentialsObjectToList,
+		},
+		{
+			Name:        "credential-types",
+			Description: "set the type of credentials without one: \"none\" for keyless vendors, else \"api-key\"",
+			Apply:       addCredentialTypes,
+		},
+	},
+	decode: func(data []byte) (interface{}, error) {
+		var creds []config.Credential
+		if err := decodeStrict(data, &creds); err != nil {
+			return nil, err
+		}
+		return creds, nil
+	},
+	secrets: func(decoded interface{}) []string {
+		var values []string
+		for _, cred := range decoded.([]config.Credential) {
+			if cred.Value != "" {
+				values = append(values, cred.Value)
+			}
+		}
+		return values
+	},
+}
+
+// Migrate applies the file's migrations to data and checks the result against the schema
+// Fields the schema does not know are an error rather than silently dropped
+func (f File) Migrate(data []byte) (Result, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return Result{}, fmt.Errorf("failed to parse %s: %w", f.Name, err)
+	}
+
+	result := Result{Output: data}
+	for _, migration := range f.Migrations {
+		var applied bool
+		if doc, applied = migration.Apply(doc); applied {
+			result.Applied = append(result.Applied, migration.Name)
+		}
+	}
+	if result.Changed() {
+		migrated, err := json.Marshal(doc)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to encode %s: %w", f.Name, err)
+		}
+		data = migrated
+	}
+
+	decoded, err := f.decode(data)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s does not match the current schema: %w", f.Name, err)
+	}
+	result.secrets = f.secrets(decoded)
+	if result.Changed() {
+		// Re-encoding the decoded schema keeps the field order of the structs
+		output, err := json.MarshalIndent(decoded, "", "    ")
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to encode %s: %w", f.Name, err)
+		}
+		result.Output = append(output, '\n')
+	}
+	return result, nil
+}
+
+// Diff returns a unified diff from the original file to the migrated one, with credential
+// values redacted
+func (r Result) Diff(name string, original []byte) string {
+	before, after := string(original), string(r.Output)
+	for _, secret := range r.secrets {
+		before = strings.ReplaceAll(before, secret, "[REDACTED]")
+		after = strings.ReplaceAll(after, secret, "[REDACTED]")
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(strings.TrimSuffix(before, "\n")),
+		B:        difflib.SplitLines(strings.TrimSuffix(after, "\n")),
+		FromFile: name,
+		ToFile:   name + " (migrated)",
+		Context:  3,
+	})
+	return diff
+}
+
+// decodeStrict decodes data, rejecting fields target does not declare
+func decodeStrict(data []byte, target interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(target)
+}
+
+// wrapModelsArray upgrades the first models.json layout, a bare array of models
+func wrapModelsArray(doc interface{}) (interface{}, bool) {
+	models, ok := doc.([]interface{})
+	if !ok {
+		return doc, false
+	}
+	return map[string]interface{}{"vendors": map[string]interface{}{}, "models": models}, true
+}
+
+// addVendorURLs adds the default base URL of every vendor whose models need one
+// Models with their own base_url and vendors without a known default are left alone
+func addVendorURLs(doc interface{}) (interface{}, bool) {
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return doc, false
+	}
+	vendors, ok := root["vendors"].(map[string]interface{})
+	if !ok {
+		if root["vendors"] != nil {
+			return doc, false
+		}
+		vendors = map[string]interface{}{}
+	}
+	models, _ := root["models"].([]interface{})
+
+	applied := false
+	for _, entry := range models {
+		model, _ := entry.(map[string]interface{})
+		vendor, _ := model["vendor"].(string)
+		if baseURL, _ := model["base_url"].(string); baseURL != "" {
+			continue
+		}
+		if _, configured := vendors[vendor]; configured {
+			continue
+		}
+		if url, known := probe.DefaultVendorURLs[vendor]; known {
+			vendors[vendor] = url
+			applied = true
+		}
+	}
+	if applied {
+		root["vendors"] = vendors
+	}
+	return doc, applied
+}
+
+// credentialsObjectToList upgrades credentials keyed by platform, each a key or a list of
+// keys, to a list of api-key credentials ordered by platform
+func credentialsObjectToList(doc interface{}) (interface{}, bool) {
+	object, ok := doc.(map[string]interface{})
+	if !ok {
+		return doc, false
+	}
+	platforms := make([]string, 0, len(object))
+	for platform := range object {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+
+	creds := []interface{}{}
+	for _, platform := range platforms {
+		keys, isList := object[platform].([]interface{})
+		if !isList {
+			keys = []interface{}{object[platform]}
+		}
+		for _, key := range keys {
+			creds = append(creds, map[string]interface{}{"platform": platform, "type": "api-key", "value": key})
+		}
+	}
+	return creds, true
+}
+
+// addCredentialTypes sets the type of credentials written before the type was required
+func addCredentialTypes(doc interface{}) (interface{}, bool) {
+	creds, ok := doc.([]interface{})
+	if !ok {
+		return doc, false
+	}
+	applied := false
+	for _, entry := range creds {
+		cred, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if credType, _ := cred["type"].(string); credType != "" {
+			continue
+		}
+		platform, _ := cred["platform"].(string)
+		value, _ := cred["value"].(string)
+		if config.KeylessVendors[platform] && value == "" {
+			cred["type"] = config.CredentialTypeNone
+		} else {
+			cred["type"] = "api-key"
+		}
+		applied = true
+	}
+	return doc, applied
+}
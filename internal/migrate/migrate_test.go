@@ -0,0 +1,120 @@
+package migrate
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModels_Migrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		applied  []string
+		expected string
+	}{
+		{
+			name:    "bare array of models",
+			input:   `[{"vendor":"openai","model":"gpt-4o"},{"vendor":"custom","model":"llama","base_url":"http://localhost:8000/v1"}]`,
+			applied: []string{"models-array", "vendor-urls"},
+			expected: `{"vendors":{"openai":"https://api.openai.com/v1"},"models":[` +
+				`{"vendor":"openai","model":"gpt-4o"},{"vendor":"custom","model":"llama","base_url":"http://localhost:8000/v1"}]}`,
+		},
+		{
+			name:     "vendor without a base URL",
+			input:    `{"vendors":{"openai":"https://api.openai.com/v1"},"models":[{"vendor":"gemini","model":"gemini-pro"}]}`,
+			applied:  []string{"vendor-urls"},
+			expected: `{"vendors":{"gemini":"https://generativelanguage.googleapis.com/v1beta/openai","openai":"https://api.openai.com/v1"},"models":[{"vendor":"gemini","model":"gemini-pro"}]}`,
+		},
+		{
+			name:     "current schema",
+			input:    `{"vendors":{"openai":"http://proxy/v1"},"models":[{"vendor":"openai","model":"gpt-4o","pricing":{"input_per_million":2.5}}]}`,
+			expected: `{"vendors":{"openai":"http://proxy/v1"},"models":[{"vendor":"openai","model":"gpt-4o","pricing":{"input_per_million":2.5}}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Models.Migrate([]byte(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.applied, result.Applied)
+			assert.JSONEq(t, tt.expected, string(result.Output))
+
+			// Migrating the result again changes nothing
+			again, err := Models.Migrate(result.Output)
+			require.NoError(t, err)
+			assert.False(t, again.Changed())
+			assert.Equal(t, result.Output, again.Output)
+		})
+	}
+}
+
+func TestCredentials_Migrate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		applied  []string
+		expected string
+	}{
+		{
+			name:    "object keyed by platform",
+			input:   `{"openai":"sk-1","gemini":["g-1","g-2"]}`,
+			applied: []string{"credentials-object"},
+			expected: `[{"platform":"gemini","type":"api-key","value":"g-1"},{"platform":"gemini","type":"api-key","value":"g-2"},` +
+				`{"platform":"openai","type":"api-key","value":"sk-1"}]`,
+		},
+		{
+			name:     "credentials without a type",
+			input:    `[{"platform":"openai","value":"sk-1"},{"platform":"ollama"}]`,
+			applied:  []string{"credential-types"},
+			expected: `[{"platform":"openai","type":"api-key","value":"sk-1"},{"platform":"ollama","type":"none","value":""}]`,
+		},
+		{
+			name:     "current schema",
+			input:    `[{"platform":"openai","type":"api-key","value":"sk-1"}]`,
+			expected: `[{"platform":"openai","type":"api-key","value":"sk-1"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Credentials.Migrate([]byte(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.applied, result.Applied)
+			assert.JSONEq(t, tt.expected, string(result.Output))
+
+			again, err := Credentials.Migrate(result.Output)
+			require.NoError(t, err)
+			assert.False(t, again.Changed())
+		})
+	}
+}
+
+func TestMigrate_RejectsUnknownFields(t *testing.T) {
+	_, err := Models.Migrate([]byte(`[{"vendor":"openai","model":"gpt-4o","capabilities":{}}]`))
+	assert.ErrorContains(t, err, "does not match the current schema")
+
+	_, err = Credentials.Migrate([]byte(`{"openai":`))
+	assert.ErrorContains(t, err, "failed to parse")
+}
+
+func TestResult_DiffRedactsCredentials(t *testing.T) {
+	original := []byte(`{"openai":"sk-secret"}`)
+	result, err := Credentials.Migrate(original)
+	require.NoError(t, err)
+
+	diff := result.Diff("credentials.json", original)
+	assert.Contains(t, diff, "--- credentials.json")
+	assert.Contains(t, diff, `+        "value": "[REDACTED]"`)
+	assert.NotContains(t, diff, "sk-secret")
+}
+
+func TestModels_MigrateShippedConfig(t *testing.T) {
+	data, err := os.ReadFile("../../configs/models.json")
+	require.NoError(t, err)
+	result, err := Models.Migrate(data)
+	require.NoError(t, err)
+	assert.False(t, result.Changed())
+}
@@ -0,0 +1,51 @@
+package monitoring
+
+import (
+	"context"
+	"sync"
+)
+
+// AccessRecord carries the routing decision of a request out to the access log, which is
+// written by middleware that never sees the proxy's own context
+// A nil *AccessRecord ignores all calls, so callers need not check whether access logging is enabled
+type AccessRecord struct {
+	mu       sync.Mutex
+	decision *RoutingDecision
+}
+
+type accessRecordKey struct{}
+
+// WithAccessRecord attaches a new AccessRecord to the context
+func WithAccessRecord(ctx context.Context) (context.Context, *AccessRecord) {
+	record := &AccessRecord{}
+	return context.WithValue(ctx, accessRecordKey{}, record), record
+}
+
+// AccessRecordFromContext returns the request's AccessRecord, or nil when access logging is disabled
+func AccessRecordFromContext(ctx context.Context) *AccessRecord {
+	record, _ := ctx.Value(accessRecordKey{}).(*AccessRecord)
+	return record
+}
+
+// SetDecision records the final routing decision of the request
+func (a *AccessRecord) SetDecision(decision RoutingDecision) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.decision = &decision
+}
+
+// Decision returns the recorded routing decision, if the request was routed to a vendor
+func (a *AccessRecord) Decision() (RoutingDecision, bool) {
+	if a == nil {
+		return RoutingDecision{}, false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.decision == nil {
+		return RoutingDecision{}, false
+	}
+	return *a.decision, true
+}
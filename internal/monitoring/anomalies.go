@@ -0,0 +1,154 @@
+package monitoring
+
+import (
+	"crypto/sha256"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// ResponseAnomalyDetector inspects vendor responses for signs of upstream misbehaviour
+// Observe returns true when the response is considered anomalous
+type ResponseAnomalyDetector interface {
+	Observe(vendor string, prompt, response []byte) bool
+}
+
+// VendorAnomalyStats summarizes anomalies detected for one vendor
+type VendorAnomalyStats struct {
+	Vendor        string     `json:"vendor"`
+	Observed      int64      `json:"observed"`
+	Anomalies     int64      `json:"anomalies"`
+	LastAnomalyAt *time.Time `json:"last_anomaly_at,omitempty"`
+}
+
+// AnomalyReporter is implemented by detectors that keep per-vendor counts
+type AnomalyReporter interface {
+	Snapshot() []VendorAnomalyStats
+}
+
+// DuplicateResponseDetector flags byte-identical responses returned for distinct prompts,
+// a symptom of upstream routing or caching bugs. It remembers the last window responses per vendor
+type DuplicateResponseDetector struct {
+	mu       sync.Mutex
+	window   int
+	minBytes int
+	vendors  map[string]*duplicateWindow
+}
+
+// duplicateWindow maps recent response hashes to the prompt hash that produced them
+type duplicateWindow struct {
+	prompts map[[sha256.Size]byte][sha256.Size]byte
+	order   [][sha256.Size]byte
+	stats   VendorAnomalyStats
+}
+
+var (
+	defaultAnomalyDetector     ResponseAnomalyDetector
+	defaultAnomalyDetectorOnce sync.Once
+	anomalyDetectorMu          sync.RWMutex
+)
+
+// NewDuplicateResponseDetector creates a detector remembering window responses per vendor
+// Responses shorter than minBytes are ignored since short answers legitimately repeat
+func NewDuplicateResponseDetector(window, minBytes int) *DuplicateResponseDetector {
+	if window <= 0 {
+		window = 1
+	}
+	return &DuplicateResponseDetector{
+		window:   window,
+		minBytes: minBytes,
+		vendors:  make(map[string]*duplicateWindow),
+	}
+}
+
+// DefaultResponseAnomalyDetector returns the process-wide detector, or nil when disabled
+// It is enabled with RESPONSE_DUPLICATE_DETECTION=true and sized by RESPONSE_DUPLICATE_WINDOW
+// (default 1000) and RESPONSE_DUPLICATE_MIN_BYTES (default 64)
+func DefaultResponseAnomalyDetector() ResponseAnomalyDetector {
+	defaultAnomalyDetectorOnce.Do(func() {
+		if !utils.GetEnvBool("RESPONSE_DUPLICATE_DETECTION", false) {
+			return
+		}
+		anomalyDetectorMu.Lock()
+		defer anomalyDetectorMu.Unlock()
+		if defaultAnomalyDetector == nil {
+			defaultAnomalyDetector = NewDuplicateResponseDetector(
+				utils.GetEnvInt("RESPONSE_DUPLICATE_WINDOW", 1000),
+				utils.GetEnvInt("RESPONSE_DUPLICATE_MIN_BYTES", 64),
+			)
+		}
+	})
+
+	anomalyDetectorMu.RLock()
+	defer anomalyDetectorMu.RUnlock()
+	return defaultAnomalyDetector
+}
+
+// SetResponseAnomalyDetector replaces the process-wide detector; nil disables detection
+func SetResponseAnomalyDetector(detector ResponseAnomalyDetector) {
+	defaultAnomalyDetectorOnce.Do(func() {})
+
+	anomalyDetectorMu.Lock()
+	defer anomalyDetectorMu.Unlock()
+	defaultAnomalyDetector = detector
+}
+
+// Observe records a response and reports whether the same response was recently
+// returned by this vendor for a different prompt
+func (d *DuplicateResponseDetector) Observe(vendor string, prompt, response []byte) bool {
+	if len(response) < d.minBytes {
+		return false
+	}
+	promptHash := sha256.Sum256(prompt)
+	responseHash := sha256.Sum256(response)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	w, ok := d.vendors[vendor]
+	if !ok {
+		w = &duplicateWindow{
+			prompts: make(map[[sha256.Size]byte][sha256.Size]byte),
+			stats:   VendorAnomalyStats{Vendor: vendor},
+		}
+		d.vendors[vendor] = w
+	}
+	w.stats.Observed++
+
+	if previousPrompt, seen := w.prompts[responseHash]; seen {
+		if previousPrompt == promptHash {
+			// Identical prompt, identical answer: deterministic, not an anomaly
+			return false
+		}
+		w.stats.Anomalies++
+		now := time.Now().UTC()
+		w.stats.LastAnomalyAt = &now
+		w.prompts[responseHash] = promptHash
+		return true
+	}
+
+	w.prompts[responseHash] = promptHash
+	w.order = append(w.order, responseHash)
+	if len(w.order) > d.window {
+		delete(w.prompts, w.order[0])
+		w.order = w.order[1:]
+	}
+	return false
+}
+
+// Snapshot returns per-vendor counts, sorted by vendor name
+func (d *DuplicateResponseDetector) Snapshot() []VendorAnomalyStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	snapshot := make([]VendorAnomalyStats, 0, len(d.vendors))
+	for _, w := range d.vendors {
+		snapshot = append(snapshot, w.stats)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Vendor < snapshot[j].Vendor
+	})
+	return snapshot
+}
@@ -0,0 +1,50 @@
+package monitoring
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicateResponseDetector_Observe(t *testing.T) {
+	detector := NewDuplicateResponseDetector(10, 16)
+	response := []byte(strings.Repeat("cached answer ", 4))
+
+	assert.False(t, detector.Observe("openai", []byte("prompt A"), response))
+	assert.False(t, detector.Observe("openai", []byte("prompt A"), response), "same prompt may repeat its answer")
+	assert.True(t, detector.Observe("openai", []byte("prompt B"), response), "distinct prompt, identical answer")
+	assert.False(t, detector.Observe("gemini", []byte("prompt C"), response), "windows are per vendor")
+	assert.False(t, detector.Observe("openai", []byte("prompt D"), []byte("OK")), "short responses are ignored")
+
+	snapshot := detector.Snapshot()
+	require.Len(t, snapshot, 2)
+	assert.Equal(t, "gemini", snapshot[0].Vendor)
+	assert.Equal(t, int64(0), snapshot[0].Anomalies)
+	assert.Nil(t, snapshot[0].LastAnomalyAt)
+
+	assert.Equal(t, "openai", snapshot[1].Vendor)
+	assert.Equal(t, int64(3), snapshot[1].Observed)
+	assert.Equal(t, int64(1), snapshot[1].Anomalies)
+	assert.NotNil(t, snapshot[1].LastAnomalyAt)
+}
+
+func TestDuplicateResponseDetector_WindowEviction(t *testing.T) {
+	detector := NewDuplicateResponseDetector(2, 0)
+
+	detector.Observe("openai", []byte("p1"), []byte("r1"))
+	detector.Observe("openai", []byte("p2"), []byte("r2"))
+	detector.Observe("openai", []byte("p3"), []byte("r3"))
+
+	assert.False(t, detector.Observe("openai", []byte("p4"), []byte("r1")), "r1 was evicted from the window")
+	assert.True(t, detector.Observe("openai", []byte("p5"), []byte("r3")))
+}
+
+func TestSetResponseAnomalyDetector(t *testing.T) {
+	detector := NewDuplicateResponseDetector(1, 0)
+	SetResponseAnomalyDetector(detector)
+	t.Cleanup(func() { SetResponseAnomalyDetector(nil) })
+
+	assert.Same(t, detector, DefaultResponseAnomalyDetector())
+}
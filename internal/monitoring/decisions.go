@@ -0,0 +1,214 @@
+package monitoring
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// RoutingDecision captures why a single request was routed to a vendor/model
+type RoutingDecision struct {
+	RequestID       string          `json:"request_id"`
+	ClientKey       string          `json:"client_key,omitempty"`
+	User            string          `json:"user,omitempty"`
+	Timestamp       time.Time       `json:"timestamp"`
+	OriginalModel   string          `json:"original_model"`
+	Vendor          string          `json:"vendor"`
+	Model           string          `json:"model"`
+	VendorFilter    string          `json:"vendor_filter,omitempty"`
+	ExcludedVendors []string        `json:"excluded_vendors,omitempty"`
+	ExcludedModels  []string        `json:"excluded_models,omitempty"`
+	Filters         map[string]bool `json:"capability_filters,omitempty"`
+	CandidateCount  int             `json:"candidate_count"`
+	Candidates      []string        `json:"candidates,omitempty"`
+	Credential      string          `json:"credential,omitempty"`
+	Reason          string          `json:"reason,omitempty"`
+	Attempts        int             `json:"attempts"`
+	// QueueWaitMs is how long the request waited for admission and for a slot on its vendor
+	QueueWaitMs       int64           `json:"queue_wait_ms,omitempty"`
+	FallbackVendor    string          `json:"fallback_vendor,omitempty"`
+	FallbackModel     string          `json:"fallback_model,omitempty"`
+	FailedAttempts    []FailedAttempt `json:"failed_attempts,omitempty"`
+	Outcome           string          `json:"outcome"`
+	Error             string          `json:"error,omitempty"`
+	Seed              *int64          `json:"seed,omitempty"`
+	SystemFingerprint string          `json:"system_fingerprint,omitempty"`
+	// PromptTokens and CompletionTokens are the usage of the response served, as reported
+	// by the vendor or estimated when a stream carries no usage
+	PromptTokens     int `json:"prompt_tokens,omitempty"`
+	CompletionTokens int `json:"completion_tokens,omitempty"`
+	// UsageEstimated is set when the router counted the tokens because the vendor reported none
+	UsageEstimated bool `json:"usage_estimated,omitempty"`
+	// CostUSD is what the response served cost at its model's pricing, omitted when the
+	// model has none
+	CostUSD *float64 `json:"cost_usd,omitempty"`
+	// TTFTMs is how long after routing began a streamed response's first content arrived,
+	// and TokensPerSecond the rate its completion tokens were streamed at from then on
+	TTFTMs          int64   `json:"ttft_ms,omitempty"`
+	TokensPerSecond float64 `json:"tokens_per_second,omitempty"`
+	// ClientKeyID is the SHA-256 hex digest of the client's bearer token, which usage is
+	// accounted under; unlike ClientKey it is never exposed
+	ClientKeyID string `json:"-"`
+}
+
+// ServedBy returns the vendor and model serving the request: the fallback once the request
+// failed over, the selection otherwise
+func (d RoutingDecision) ServedBy() (string, string) {
+	if d.FallbackVendor != "" {
+		return d.FallbackVendor, d.FallbackModel
+	}
+	return d.Vendor, d.Model
+}
+
+// FailedAttempt is a vendor attempt a request failed over from
+type FailedAttempt struct {
+	Vendor     string `json:"vendor"`
+	Model      string `json:"model"`
+	Credential string `json:"credential,omitempty"`
+	// Reason is the vendor's HTTP status, or "invalid_response" when its response failed
+	// validation
+	Reason string `json:"reason"`
+}
+
+// DecisionFilter narrows down the decisions returned by Query
+type DecisionFilter struct {
+	RequestID string
+	ClientKey string
+	User      string
+	Vendor    string
+	Model     string
+	// Credential matches the label of the credential any attempt of the request used
+	Credential string
+	Outcome    string
+	Since      time.Time
+	Limit      int
+}
+
+// DecisionLog is a fixed-size ring buffer of the most recent routing decisions
+type DecisionLog struct {
+	mu      sync.RWMutex
+	entries []RoutingDecision
+	next    int
+	full    bool
+}
+
+var (
+	defaultDecisionLog     *DecisionLog
+	defaultDecisionLogOnce sync.Once
+)
+
+// NewDecisionLog creates a decision log holding at most size entries
+func NewDecisionLog(size int) *DecisionLog {
+	if size <= 0 {
+		size = 1
+	}
+	return &DecisionLog{
+		entries: make([]RoutingDecision, size),
+	}
+}
+
+// DefaultDecisionLog returns the process-wide decision log
+// Its capacity is read once from ROUTING_DECISION_LOG_SIZE (default 1000)
+func DefaultDecisionLog() *DecisionLog {
+	defaultDecisionLogOnce.Do(func() {
+		defaultDecisionLog = NewDecisionLog(utils.GetEnvInt("ROUTING_DECISION_LOG_SIZE", 1000))
+	})
+	return defaultDecisionLog
+}
+
+// Record appends a decision, overwriting the oldest entry when the buffer is full
+func (l *DecisionLog) Record(decision RoutingDecision) {
+	if decision.Timestamp.IsZero() {
+		decision.Timestamp = time.Now().UTC()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next] = decision
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Len returns the number of decisions currently held
+func (l *DecisionLog) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.full {
+		return len(l.entries)
+	}
+	return l.next
+}
+
+// Query returns decisions matching the filter, newest first
+func (l *DecisionLog) Query(filter DecisionFilter) []RoutingDecision {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.entries)
+	}
+
+	results := make([]RoutingDecision, 0)
+	for i := 0; i < count; i++ {
+		// Walk backwards from the most recently written slot
+		idx := (l.next - 1 - i + len(l.entries)) % len(l.entries)
+		decision := l.entries[idx]
+		if !filter.matches(decision) {
+			continue
+		}
+		results = append(results, decision)
+		if filter.Limit > 0 && len(results) >= filter.Limit {
+			break
+		}
+	}
+	return results
+}
+
+// matches reports whether a decision satisfies every non-empty filter field
+func (f DecisionFilter) matches(d RoutingDecision) bool {
+	if f.RequestID != "" && d.RequestID != f.RequestID {
+		return false
+	}
+	if f.ClientKey != "" && d.ClientKey != f.ClientKey {
+		return false
+	}
+	if f.User != "" && d.User != f.User {
+		return false
+	}
+	if f.Vendor != "" && d.Vendor != f.Vendor && d.FallbackVendor != f.Vendor {
+		return false
+	}
+	if f.Model != "" && d.Model != f.Model && d.FallbackModel != f.Model {
+		return false
+	}
+	if f.Credential != "" && !d.usedCredential(f.Credential) {
+		return false
+	}
+	if f.Outcome != "" && d.Outcome != f.Outcome {
+		return false
+	}
+	if !f.Since.IsZero() && d.Timestamp.Before(f.Since) {
+		return false
+	}
+	return true
+}
+
+// usedCredential reports whether the request was sent with the labelled credential, by its
+// selection or by an attempt it failed over from
+func (d RoutingDecision) usedCredential(credential string) bool {
+	if d.Credential == credential {
+		return true
+	}
+	for _, attempt := range d.FailedAttempts {
+		if attempt.Credential == credential {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package monitoring
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecisionLog_RingBuffer(t *testing.T) {
+	log := NewDecisionLog(3)
+
+	for i := 1; i <= 5; i++ {
+		log.Record(RoutingDecision{RequestID: fmt.Sprintf("req-%d", i), Vendor: "openai"})
+	}
+
+	assert.Equal(t, 3, log.Len())
+
+	decisions := log.Query(DecisionFilter{})
+	require.Len(t, decisions, 3)
+	assert.Equal(t, "req-5", decisions[0].RequestID)
+	assert.Equal(t, "req-4", decisions[1].RequestID)
+	assert.Equal(t, "req-3", decisions[2].RequestID)
+}
+
+func TestDecisionLog_QueryFilters(t *testing.T) {
+	log := NewDecisionLog(10)
+	base := time.Now().UTC()
+
+	log.Record(RoutingDecision{RequestID: "a", Vendor: "openai", Model: "gpt-4o", Outcome: "success", Timestamp: base.Add(-time.Hour)})
+	log.Record(RoutingDecision{RequestID: "b", Vendor: "gemini", Model: "gemini-pro", User: "5d41402abc4b2a76", Outcome: "error", Timestamp: base})
+	log.Record(RoutingDecision{RequestID: "c", Vendor: "gemini", Model: "gemini-pro", FallbackVendor: "openai", FallbackModel: "gpt-4o", Outcome: "fallback_success", Timestamp: base,
+		Credential: "openai:...abcd", FailedAttempts: []FailedAttempt{{Vendor: "gemini", Model: "gemini-pro", Credential: "gemini-primary", Reason: "503"}}})
+
+	tests := []struct {
+		name     string
+		filter   DecisionFilter
+		expected []string
+	}{
+		{name: "by request id", filter: DecisionFilter{RequestID: "b"}, expected: []string{"b"}},
+		{name: "by user", filter: DecisionFilter{User: "5d41402abc4b2a76"}, expected: []string{"b"}},
+		{name: "by vendor includes fallback", filter: DecisionFilter{Vendor: "openai"}, expected: []string{"c", "a"}},
+		{name: "by model", filter: DecisionFilter{Model: "gemini-pro"}, expected: []string{"c", "b"}},
+		{name: "by credential", filter: DecisionFilter{Credential: "openai:...abcd"}, expected: []string{"c"}},
+		{name: "by failed attempt credential", filter: DecisionFilter{Credential: "gemini-primary"}, expected: []string{"c"}},
+		{name: "by outcome", filter: DecisionFilter{Outcome: "error"}, expected: []string{"b"}},
+		{name: "since", filter: DecisionFilter{Since: base.Add(-time.Minute)}, expected: []string{"c", "b"}},
+		{name: "limit", filter: DecisionFilter{Limit: 1}, expected: []string{"c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []string
+			for _, d := range log.Query(tt.filter) {
+				ids = append(ids, d.RequestID)
+			}
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
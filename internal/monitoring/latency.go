@@ -0,0 +1,150 @@
+package monitoring
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// DefaultLatencyDecayWindow is how long it takes an observation's influence on the rolling
+// latency stats to fall to about a third (1/e)
+const DefaultLatencyDecayWindow = 5 * time.Minute
+
+// ModelLatencyStats reports the rolling latency and error rate of a vendor model
+type ModelLatencyStats struct {
+	Vendor string `json:"vendor"`
+	Model  string `json:"model"`
+
+	// LatencyMs is the exponentially weighted moving average latency of successful requests
+	LatencyMs float64 `json:"latency_ms"`
+	// ErrorRate is the exponentially weighted moving average share of failed requests
+	ErrorRate float64 `json:"error_rate"`
+	// Samples is the decayed number of observations behind the averages: recent requests
+	// count as one each, older ones progressively less
+	Samples        float64   `json:"samples"`
+	LastObservedAt time.Time `json:"last_observed_at"`
+}
+
+// latencyState is a model's averages and the decayed observation counts they were built from
+type latencyState struct {
+	stats         ModelLatencyStats
+	weight        float64
+	latencyWeight float64
+}
+
+// LatencyTracker keeps time-decayed moving averages of latency and error rate per vendor model,
+// fed from every vendor attempt
+type LatencyTracker struct {
+	mu     sync.Mutex
+	window time.Duration
+	models map[string]*latencyState
+	now    func() time.Time
+}
+
+var (
+	defaultLatencyTracker     *LatencyTracker
+	defaultLatencyTrackerOnce sync.Once
+)
+
+// NewLatencyTracker creates a tracker whose observations decay over window; a non-positive
+// window uses DefaultLatencyDecayWindow
+func NewLatencyTracker(window time.Duration) *LatencyTracker {
+	if window <= 0 {
+		window = DefaultLatencyDecayWindow
+	}
+	return &LatencyTracker{
+		window: window,
+		models: make(map[string]*latencyState),
+		now:    time.Now,
+	}
+}
+
+// DefaultLatencyTracker returns the process-wide tracker, with its decay window from
+// LATENCY_DECAY_WINDOW (seconds)
+func DefaultLatencyTracker() *LatencyTracker {
+	defaultLatencyTrackerOnce.Do(func() {
+		defaultLatencyTracker = NewLatencyTracker(utils.GetEnvDuration("LATENCY_DECAY_WINDOW", DefaultLatencyDecayWindow))
+	})
+	return defaultLatencyTracker
+}
+
+// Observe records a vendor attempt; failed attempts count toward the error rate but not
+// the latency average
+func (t *LatencyTracker) Observe(vendor, model string, failed bool, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := vendor + "/" + model
+	state, ok := t.models[key]
+	now := t.now()
+	if !ok {
+		state = &latencyState{stats: ModelLatencyStats{Vendor: vendor, Model: model}}
+		t.models[key] = state
+	}
+
+	// Older observations fade with the time since the last one, so that every observation
+	// counts fully when it is made, however many arrive at once
+	decay := t.decay(state.stats.LastObservedAt, now)
+	state.weight = state.weight*decay + 1
+	state.latencyWeight *= decay
+
+	outcome := 0.0
+	if failed {
+		outcome = 1
+	} else {
+		state.latencyWeight++
+		ms := float64(latency) / float64(time.Millisecond)
+		state.stats.LatencyMs += (ms - state.stats.LatencyMs) / state.latencyWeight
+	}
+	state.stats.ErrorRate += (outcome - state.stats.ErrorRate) / state.weight
+	state.stats.LastObservedAt = now
+}
+
+// Stats returns the rolling stats of vendor/model, and false when it has not been observed
+func (t *LatencyTracker) Stats(vendor, model string) (ModelLatencyStats, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.models[vendor+"/"+model]
+	if !ok {
+		return ModelLatencyStats{}, false
+	}
+	return t.snapshot(state, t.now()), true
+}
+
+// Snapshot returns the rolling stats of every observed model, sorted by vendor and model
+func (t *LatencyTracker) Snapshot() []ModelLatencyStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	snapshot := make([]ModelLatencyStats, 0, len(t.models))
+	for _, state := range t.models {
+		snapshot = append(snapshot, t.snapshot(state, now))
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Vendor != snapshot[j].Vendor {
+			return snapshot[i].Vendor < snapshot[j].Vendor
+		}
+		return snapshot[i].Model < snapshot[j].Model
+	})
+	return snapshot
+}
+
+// snapshot copies a model's stats with its observation count decayed to now; callers hold mu
+func (t *LatencyTracker) snapshot(state *latencyState, now time.Time) ModelLatencyStats {
+	stats := state.stats
+	stats.Samples = state.weight * t.decay(stats.LastObservedAt, now)
+	return stats
+}
+
+// decay is the factor observations made at since have faded by at now
+func (t *LatencyTracker) decay(since, now time.Time) float64 {
+	if since.IsZero() || !now.After(since) {
+		return 1
+	}
+	return math.Exp(-float64(now.Sub(since)) / float64(t.window))
+}
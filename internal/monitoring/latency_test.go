@@ -0,0 +1,67 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLatencyTracker(window time.Duration) (*LatencyTracker, func(time.Duration)) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	tracker := NewLatencyTracker(window)
+	tracker.now = func() time.Time { return now }
+	return tracker, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestLatencyTracker_Observe(t *testing.T) {
+	tracker, _ := newTestLatencyTracker(time.Minute)
+
+	_, ok := tracker.Stats("openai", "gpt-4o")
+	assert.False(t, ok)
+
+	tracker.Observe("openai", "gpt-4o", false, 100*time.Millisecond)
+	tracker.Observe("openai", "gpt-4o", false, 300*time.Millisecond)
+	tracker.Observe("openai", "gpt-4o", true, 5*time.Second)
+	tracker.Observe("openai", "gpt-4o", false, 200*time.Millisecond)
+
+	stats, ok := tracker.Stats("openai", "gpt-4o")
+	require.True(t, ok)
+	assert.InDelta(t, 200, stats.LatencyMs, 0.001, "simultaneous observations count equally and failures are left out")
+	assert.InDelta(t, 0.25, stats.ErrorRate, 0.001)
+	assert.InDelta(t, 4, stats.Samples, 0.001)
+}
+
+func TestLatencyTracker_Decay(t *testing.T) {
+	tracker, advance := newTestLatencyTracker(time.Minute)
+
+	for i := 0; i < 10; i++ {
+		tracker.Observe("gemini", "gemini-2.5-flash", true, 0)
+	}
+	advance(5 * time.Minute)
+
+	stats, _ := tracker.Stats("gemini", "gemini-2.5-flash")
+	assert.Less(t, stats.Samples, 0.1, "old observations fade")
+	assert.InDelta(t, 1, stats.ErrorRate, 0.001, "averages are kept until new observations arrive")
+
+	tracker.Observe("gemini", "gemini-2.5-flash", false, 400*time.Millisecond)
+	stats, _ = tracker.Stats("gemini", "gemini-2.5-flash")
+	assert.Less(t, stats.ErrorRate, 0.1, "a recent success outweighs faded failures")
+	assert.InDelta(t, 400, stats.LatencyMs, 0.001)
+}
+
+func TestLatencyTracker_Snapshot(t *testing.T) {
+	tracker, _ := newTestLatencyTracker(0)
+	assert.Equal(t, DefaultLatencyDecayWindow, tracker.window)
+
+	tracker.Observe("openai", "gpt-4o-mini", false, time.Second)
+	tracker.Observe("gemini", "gemini-2.5-pro", false, time.Second)
+	tracker.Observe("openai", "gpt-4o", false, time.Second)
+
+	snapshot := tracker.Snapshot()
+	require.Len(t, snapshot, 3)
+	assert.Equal(t, "gemini-2.5-pro", snapshot[0].Model)
+	assert.Equal(t, "gpt-4o", snapshot[1].Model)
+	assert.Equal(t, "gpt-4o-mini", snapshot[2].Model)
+}
@@ -0,0 +1,183 @@
+package monitoring
+
+import (
+	"compress/gzip"
+	"sort"
+	"sync"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// VendorPayloadStats summarizes request and response body sizes exchanged with one vendor
+// Ratios are compressed size divided by uncompressed size, so lower means more compressible
+type VendorPayloadStats struct {
+	Vendor string `json:"vendor"`
+
+	Requests            int64   `json:"requests"`
+	RequestBytes        int64   `json:"request_bytes"`
+	MaxRequestBytes     int64   `json:"max_request_bytes"`
+	AverageRequestBytes float64 `json:"average_request_bytes"`
+
+	// Outbound bodies are sent uncompressed; a sample is gzipped to estimate what compression would save
+	SampledRequests           int64   `json:"sampled_requests"`
+	SampledRequestBytes       int64   `json:"sampled_request_bytes"`
+	SampledRequestGzipBytes   int64   `json:"sampled_request_gzip_bytes"`
+	EstimatedRequestGzipRatio float64 `json:"estimated_request_gzip_ratio"`
+
+	Responses            int64   `json:"responses"`
+	ResponseWireBytes    int64   `json:"response_wire_bytes"`
+	ResponseBytes        int64   `json:"response_uncompressed_bytes"`
+	MaxResponseBytes     int64   `json:"max_response_uncompressed_bytes"`
+	AverageResponseBytes float64 `json:"average_response_bytes"`
+
+	CompressedResponses        int64   `json:"compressed_responses"`
+	CompressedResponseWire     int64   `json:"compressed_response_wire_bytes"`
+	CompressedResponseBytes    int64   `json:"compressed_response_uncompressed_bytes"`
+	ResponseCompressionRatio   float64 `json:"response_compression_ratio"`
+	ResponseCompressedFraction float64 `json:"response_compressed_fraction"`
+}
+
+// PayloadSizeMetrics aggregates per-vendor payload sizes and compressibility
+type PayloadSizeMetrics struct {
+	mu          sync.Mutex
+	vendors     map[string]*VendorPayloadStats
+	sampleEvery int64
+}
+
+var (
+	defaultPayloadSizeMetrics     *PayloadSizeMetrics
+	defaultPayloadSizeMetricsOnce sync.Once
+)
+
+// NewPayloadSizeMetrics creates an aggregator that gzips every sampleEvery-th request body
+// per vendor to estimate compressibility; zero or less disables the estimate
+func NewPayloadSizeMetrics(sampleEvery int) *PayloadSizeMetrics {
+	return &PayloadSizeMetrics{
+		vendors:     make(map[string]*VendorPayloadStats),
+		sampleEvery: int64(sampleEvery),
+	}
+}
+
+// DefaultPayloadSizeMetrics returns the process-wide payload size aggregator
+// The sampling interval is read once from PAYLOAD_COMPRESSIBILITY_SAMPLE_EVERY (default 10)
+func DefaultPayloadSizeMetrics() *PayloadSizeMetrics {
+	defaultPayloadSizeMetricsOnce.Do(func() {
+		defaultPayloadSizeMetrics = NewPayloadSizeMetrics(utils.GetEnvInt("PAYLOAD_COMPRESSIBILITY_SAMPLE_EVERY", 10))
+	})
+	return defaultPayloadSizeMetrics
+}
+
+// RecordRequest records an outbound request body sent to vendor
+func (m *PayloadSizeMetrics) RecordRequest(vendor string, body []byte) {
+	size := int64(len(body))
+
+	m.mu.Lock()
+	stats := m.statsFor(vendor)
+	stats.Requests++
+	stats.RequestBytes += size
+	if size > stats.MaxRequestBytes {
+		stats.MaxRequestBytes = size
+	}
+	sample := m.sampleEvery > 0 && (stats.Requests-1)%m.sampleEvery == 0
+	m.mu.Unlock()
+
+	if !sample || size == 0 {
+		return
+	}
+
+	// Compress outside the lock so concurrent requests are not serialized on gzip
+	gzipSize, err := gzipSize(body)
+	if err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	stats.SampledRequests++
+	stats.SampledRequestBytes += size
+	stats.SampledRequestGzipBytes += gzipSize
+	m.mu.Unlock()
+}
+
+// RecordResponse records an inbound response body received from vendor
+// wireBytes is the size as received and uncompressedBytes the size after decoding
+func (m *PayloadSizeMetrics) RecordResponse(vendor string, wireBytes, uncompressedBytes int64, compressed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.statsFor(vendor)
+	stats.Responses++
+	stats.ResponseWireBytes += wireBytes
+	stats.ResponseBytes += uncompressedBytes
+	if uncompressedBytes > stats.MaxResponseBytes {
+		stats.MaxResponseBytes = uncompressedBytes
+	}
+	if compressed {
+		stats.CompressedResponses++
+		stats.CompressedResponseWire += wireBytes
+		stats.CompressedResponseBytes += uncompressedBytes
+	}
+}
+
+// Snapshot returns the current stats for every vendor, sorted by vendor name
+func (m *PayloadSizeMetrics) Snapshot() []VendorPayloadStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make([]VendorPayloadStats, 0, len(m.vendors))
+	for _, stats := range m.vendors {
+		s := *stats
+		s.AverageRequestBytes = ratio(s.RequestBytes, s.Requests)
+		s.AverageResponseBytes = ratio(s.ResponseBytes, s.Responses)
+		s.EstimatedRequestGzipRatio = ratio(s.SampledRequestGzipBytes, s.SampledRequestBytes)
+		s.ResponseCompressionRatio = ratio(s.CompressedResponseWire, s.CompressedResponseBytes)
+		s.ResponseCompressedFraction = ratio(s.CompressedResponses, s.Responses)
+		snapshot = append(snapshot, s)
+	}
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Vendor < snapshot[j].Vendor
+	})
+	return snapshot
+}
+
+// statsFor returns the stats entry for vendor, creating it if needed; callers must hold m.mu
+func (m *PayloadSizeMetrics) statsFor(vendor string) *VendorPayloadStats {
+	stats, ok := m.vendors[vendor]
+	if !ok {
+		stats = &VendorPayloadStats{Vendor: vendor}
+		m.vendors[vendor] = stats
+	}
+	return stats
+}
+
+// ratio divides two counters, returning 0 when the denominator is 0
+func ratio(numerator, denominator int64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
+}
+
+// byteCounter is an io.Writer that only counts what is written to it
+type byteCounter int64
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
+
+// gzipSize returns the size of body compressed with gzip at the fastest level
+func gzipSize(body []byte) (int64, error) {
+	var counter byteCounter
+	gzipWriter, err := gzip.NewWriterLevel(&counter, gzip.BestSpeed)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := gzipWriter.Write(body); err != nil {
+		return 0, err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return 0, err
+	}
+	return int64(counter), nil
+}
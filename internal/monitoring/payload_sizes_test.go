@@ -0,0 +1,57 @@
+package monitoring
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadSizeMetrics_Requests(t *testing.T) {
+	metrics := NewPayloadSizeMetrics(2)
+	body := []byte(strings.Repeat(`{"role":"user","content":"hello"}`, 100))
+
+	for i := 0; i < 3; i++ {
+		metrics.RecordRequest("openai", body)
+	}
+
+	snapshot := metrics.Snapshot()
+	require.Len(t, snapshot, 1)
+	stats := snapshot[0]
+	assert.Equal(t, int64(3), stats.Requests)
+	assert.Equal(t, int64(3*len(body)), stats.RequestBytes)
+	assert.Equal(t, int64(len(body)), stats.MaxRequestBytes)
+	assert.Equal(t, int64(2), stats.SampledRequests, "requests 1 and 3 are sampled")
+	assert.Greater(t, stats.EstimatedRequestGzipRatio, 0.0)
+	assert.Less(t, stats.EstimatedRequestGzipRatio, 0.1, "repetitive JSON compresses well")
+}
+
+func TestPayloadSizeMetrics_SamplingDisabled(t *testing.T) {
+	metrics := NewPayloadSizeMetrics(0)
+	metrics.RecordRequest("openai", []byte(`{"model":"gpt-4o"}`))
+
+	stats := metrics.Snapshot()[0]
+	assert.Equal(t, int64(0), stats.SampledRequests)
+	assert.Equal(t, 0.0, stats.EstimatedRequestGzipRatio)
+}
+
+func TestPayloadSizeMetrics_Responses(t *testing.T) {
+	metrics := NewPayloadSizeMetrics(0)
+	metrics.RecordResponse("gemini", 250, 1000, true)
+	metrics.RecordResponse("gemini", 500, 500, false)
+	metrics.RecordResponse("anthropic", 10, 10, false)
+
+	snapshot := metrics.Snapshot()
+	require.Len(t, snapshot, 2)
+	assert.Equal(t, "anthropic", snapshot[0].Vendor)
+
+	stats := snapshot[1]
+	assert.Equal(t, int64(2), stats.Responses)
+	assert.Equal(t, int64(750), stats.ResponseWireBytes)
+	assert.Equal(t, int64(1500), stats.ResponseBytes)
+	assert.Equal(t, int64(1000), stats.MaxResponseBytes)
+	assert.Equal(t, 0.25, stats.ResponseCompressionRatio)
+	assert.Equal(t, 0.5, stats.ResponseCompressedFraction)
+	assert.Equal(t, 750.0, stats.AverageResponseBytes)
+}
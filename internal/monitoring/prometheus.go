@@ -0,0 +1,331 @@
+package monitoring
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency histograms
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// DefaultThroughputBuckets are the upper bounds, in tokens per second, of the streaming
+// throughput histogram
+var DefaultThroughputBuckets = []float64{5, 10, 25, 50, 75, 100, 150, 250, 500, 1000}
+
+// counterSeries is one labelled series of a counter
+type counterSeries struct {
+	labels []string
+	value  float64
+}
+
+// counterVec is a counter partitioned by label values
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+	series map[string]*counterSeries
+}
+
+// histogramSeries is one labelled series of a histogram; counts are per bucket, not cumulative
+type histogramSeries struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// histogramVec is a histogram partitioned by label values
+type histogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	series  map[string]*histogramSeries
+}
+
+// PrometheusMetrics keeps the request, vendor, token and streaming metrics scraped from
+// /metrics, labelled by vendor, model and status
+type PrometheusMetrics struct {
+	mu sync.Mutex
+
+	requests        *counterVec
+	requestDuration *histogramVec
+	vendorRequests  *counterVec
+	vendorLatency   *histogramVec
+	tokens          *counterVec
+	retries         *counterVec
+	failedAttempts  *counterVec
+	streamTTFB      *histogramVec
+	streamTTFT      *histogramVec
+	streamTPS       *histogramVec
+}
+
+var (
+	defaultPrometheusMetrics     *PrometheusMetrics
+	defaultPrometheusMetricsOnce sync.Once
+)
+
+// NewPrometheusMetrics creates an empty metrics registry
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		requests: newCounterVec("router_requests_total",
+			"Requests routed, by the vendor and model that served them and their outcome",
+			"vendor", "model", "outcome"),
+		requestDuration: newHistogramVec("router_request_duration_seconds",
+			"Total time spent routing and serving a request, retries and fallbacks included",
+			"vendor", "model", "outcome"),
+		vendorRequests: newCounterVec("router_vendor_requests_total",
+			"Requests sent to vendors, by HTTP status; status is \"error\" when the vendor could not be reached",
+			"vendor", "model", "status"),
+		vendorLatency: newHistogramVec("router_vendor_latency_seconds",
+			"Time until a vendor responded",
+			"vendor", "model", "status"),
+		tokens: newCounterVec("router_tokens_total",
+			"Tokens used by completed requests, by type (prompt or completion)",
+			"vendor", "model", "type"),
+		retries: newCounterVec("router_retries_total",
+			"Vendor requests made after the first for the same client request, fallbacks included",
+			"vendor", "model"),
+		failedAttempts: newCounterVec("router_failed_attempts_total",
+			"Vendor attempts that failed and were retried or failed over, by the vendor and model attempted",
+			"vendor", "model", "reason"),
+		streamTTFB: newHistogramVec("router_stream_ttfb_seconds",
+			"Time from routing a streamed request until its first chunk was written to the client",
+			"vendor", "model"),
+		streamTTFT: newHistogramVec("router_stream_ttft_seconds",
+			"Time from routing a streamed request until the vendor sent its first content, reasoning or tool call delta",
+			"vendor", "model"),
+		streamTPS: newHistogramVec("router_stream_tokens_per_second",
+			"Rate completion tokens were streamed at, from the first content delta to the last",
+			"vendor", "model").withBuckets(DefaultThroughputBuckets),
+	}
+}
+
+// DefaultPrometheusMetrics returns the process-wide metrics registry
+func DefaultPrometheusMetrics() *PrometheusMetrics {
+	defaultPrometheusMetricsOnce.Do(func() {
+		defaultPrometheusMetrics = NewPrometheusMetrics()
+	})
+	return defaultPrometheusMetrics
+}
+
+// ObserveVendorResponse records a vendor attempt; status 0 with errored set means the vendor
+// could not be reached
+func (m *PrometheusMetrics) ObserveVendorResponse(vendor, model string, status int, errored bool, latency time.Duration) {
+	statusLabel := strconv.Itoa(status)
+	if errored && status == 0 {
+		statusLabel = "error"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vendorRequests.add(1, vendor, model, statusLabel)
+	m.vendorLatency.observe(latency.Seconds(), vendor, model, statusLabel)
+}
+
+// ObserveRequest records the final routing decision of a request that took duration; the
+// request is attributed to the fallback model when one served it
+func (m *PrometheusMetrics) ObserveRequest(decision RoutingDecision, duration time.Duration) {
+	vendor, model := decision.Vendor, decision.Model
+	if decision.FallbackVendor != "" {
+		vendor, model = decision.FallbackVendor, decision.FallbackModel
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests.add(1, vendor, model, decision.Outcome)
+	m.requestDuration.observe(duration.Seconds(), vendor, model, decision.Outcome)
+	if decision.PromptTokens > 0 {
+		m.tokens.add(float64(decision.PromptTokens), vendor, model, "prompt")
+	}
+	if decision.CompletionTokens > 0 {
+		m.tokens.add(float64(decision.CompletionTokens), vendor, model, "completion")
+	}
+	if decision.Attempts > 1 {
+		m.retries.add(float64(decision.Attempts-1), vendor, model)
+	}
+	for _, attempt := range decision.FailedAttempts {
+		m.failedAttempts.add(1, attempt.Vendor, attempt.Model, attempt.Reason)
+	}
+}
+
+// ObserveStreamTTFB records how long a streamed request waited for its first chunk
+func (m *PrometheusMetrics) ObserveStreamTTFB(vendor, model string, ttfb time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.streamTTFB.observe(ttfb.Seconds(), vendor, model)
+}
+
+// ObserveStreamTTFT records how long a streamed request waited for its first content delta
+func (m *PrometheusMetrics) ObserveStreamTTFT(vendor, model string, ttft time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.streamTTFT.observe(ttft.Seconds(), vendor, model)
+}
+
+// ObserveStreamThroughput records the rate a stream's completion tokens were sent at
+func (m *PrometheusMetrics) ObserveStreamThroughput(vendor, model string, tokensPerSecond float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.streamTPS.observe(tokensPerSecond, vendor, model)
+}
+
+// WriteTo writes every metric in the Prometheus text exposition format, followed by the
+// active stream gauge of DefaultActiveStreams
+func (m *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	buf := bufio.NewWriter(w)
+	out := &countingWriter{w: buf}
+
+	m.mu.Lock()
+	m.requests.write(out)
+	m.requestDuration.write(out)
+	m.vendorRequests.write(out)
+	m.vendorLatency.write(out)
+	m.tokens.write(out)
+	m.retries.write(out)
+	m.failedAttempts.write(out)
+	m.streamTTFB.write(out)
+	m.streamTTFT.write(out)
+	m.streamTPS.write(out)
+	m.mu.Unlock()
+
+	fmt.Fprintf(out, "# HELP router_active_streams Streaming responses currently being proxied\n")
+	fmt.Fprintf(out, "# TYPE router_active_streams gauge\n")
+	for _, stream := range DefaultActiveStreams().Snapshot() {
+		fmt.Fprintf(out, "router_active_streams%s %d\n", labelPairs([]string{"vendor"}, []string{stream.Vendor}), stream.Active)
+	}
+
+	if out.err != nil {
+		return out.n, out.err
+	}
+	return out.n, buf.Flush()
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, series: make(map[string]*counterSeries)}
+}
+
+func (c *counterVec) add(value float64, labels ...string) {
+	key := strings.Join(labels, "\x00")
+	series, ok := c.series[key]
+	if !ok {
+		series = &counterSeries{labels: labels}
+		c.series[key] = series
+	}
+	series.value += value
+}
+
+func (c *counterVec) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", c.name, c.help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", c.name)
+	for _, key := range sortedKeys(c.series) {
+		series := c.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, labelPairs(c.labels, series.labels), formatValue(series.value))
+	}
+}
+
+func newHistogramVec(name, help string, labels ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labels: labels, buckets: DefaultLatencyBuckets, series: make(map[string]*histogramSeries)}
+}
+
+// withBuckets replaces the histogram's latency buckets, before anything was observed
+func (h *histogramVec) withBuckets(buckets []float64) *histogramVec {
+	h.buckets = buckets
+	return h
+}
+
+func (h *histogramVec) observe(value float64, labels ...string) {
+	key := strings.Join(labels, "\x00")
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogramSeries{labels: labels, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		series.counts[i]++
+	}
+	series.count++
+	series.sum += value
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", h.name, h.help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", h.name)
+	bucketLabels := append(append([]string{}, h.labels...), "le")
+	for _, key := range sortedKeys(h.series) {
+		series := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += series.counts[i]
+			values := append(append([]string{}, series.labels...), formatValue(bound))
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelPairs(bucketLabels, values), cumulative)
+		}
+		values := append(append([]string{}, series.labels...), "+Inf")
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelPairs(bucketLabels, values), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labelPairs(h.labels, series.labels), formatValue(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labelPairs(h.labels, series.labels), series.count)
+	}
+}
+
+// labelPairs renders names and values as a {name="value",...} label set
+func labelPairs(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// labelEscaper escapes label values as the exposition format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func sortedKeys[V any](series map[string]V) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// countingWriter counts the bytes written and keeps the first error, so exposition code can
+// write without checking every call
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
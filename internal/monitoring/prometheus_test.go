@@ -0,0 +1,83 @@
+package monitoring
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusMetrics_WriteTo(t *testing.T) {
+	metrics := NewPrometheusMetrics()
+
+	metrics.ObserveVendorResponse("openai", "gpt-4o", 500, false, 300*time.Millisecond)
+	metrics.ObserveVendorResponse("gemini", "gemini-2.0-flash", 200, false, 2*time.Second)
+	metrics.ObserveVendorResponse("openai", "gpt-4o", 0, true, 40*time.Millisecond)
+	metrics.ObserveRequest(RoutingDecision{
+		Vendor:           "openai",
+		Model:            "gpt-4o",
+		FallbackVendor:   "gemini",
+		FallbackModel:    "gemini-2.0-flash",
+		Attempts:         2,
+		FailedAttempts:   []FailedAttempt{{Vendor: "openai", Model: "gpt-4o", Reason: "status 500"}},
+		Outcome:          "fallback_success",
+		PromptTokens:     12,
+		CompletionTokens: 30,
+	}, 2500*time.Millisecond)
+	metrics.ObserveStreamTTFB("gemini", "gemini-2.0-flash", 700*time.Millisecond)
+	metrics.ObserveStreamTTFT("gemini", "gemini-2.0-flash", 900*time.Millisecond)
+	metrics.ObserveStreamThroughput("gemini", "gemini-2.0-flash", 80)
+
+	var out strings.Builder
+	n, err := metrics.WriteTo(&out)
+	require.NoError(t, err)
+	assert.Equal(t, int64(out.Len()), n)
+	text := out.String()
+
+	assert.Contains(t, text, "# TYPE router_requests_total counter\n")
+	assert.Contains(t, text, `router_requests_total{vendor="gemini",model="gemini-2.0-flash",outcome="fallback_success"} 1`)
+	assert.Contains(t, text, `router_vendor_requests_total{vendor="openai",model="gpt-4o",status="500"} 1`)
+	assert.Contains(t, text, `router_vendor_requests_total{vendor="openai",model="gpt-4o",status="error"} 1`)
+	assert.Contains(t, text, `router_tokens_total{vendor="gemini",model="gemini-2.0-flash",type="prompt"} 12`)
+	assert.Contains(t, text, `router_tokens_total{vendor="gemini",model="gemini-2.0-flash",type="completion"} 30`)
+	assert.Contains(t, text, `router_retries_total{vendor="gemini",model="gemini-2.0-flash"} 1`)
+	assert.Contains(t, text, `router_failed_attempts_total{vendor="openai",model="gpt-4o",reason="status 500"} 1`)
+
+	assert.Contains(t, text, "# TYPE router_request_duration_seconds histogram\n")
+	assert.Contains(t, text, `router_request_duration_seconds_bucket{vendor="gemini",model="gemini-2.0-flash",outcome="fallback_success",le="2.5"} 1`)
+	assert.Contains(t, text, `router_request_duration_seconds_bucket{vendor="gemini",model="gemini-2.0-flash",outcome="fallback_success",le="1"} 0`)
+	assert.Contains(t, text, `router_request_duration_seconds_bucket{vendor="gemini",model="gemini-2.0-flash",outcome="fallback_success",le="+Inf"} 1`)
+	assert.Contains(t, text, `router_request_duration_seconds_sum{vendor="gemini",model="gemini-2.0-flash",outcome="fallback_success"} 2.5`)
+	assert.Contains(t, text, `router_request_duration_seconds_count{vendor="gemini",model="gemini-2.0-flash",outcome="fallback_success"} 1`)
+	assert.Contains(t, text, `router_stream_ttfb_seconds_bucket{vendor="gemini",model="gemini-2.0-flash",le="0.5"} 0`)
+	assert.Contains(t, text, `router_stream_ttfb_seconds_bucket{vendor="gemini",model="gemini-2.0-flash",le="1"} 1`)
+	assert.Contains(t, text, `router_stream_ttft_seconds_bucket{vendor="gemini",model="gemini-2.0-flash",le="1"} 1`)
+	assert.Contains(t, text, `router_stream_tokens_per_second_bucket{vendor="gemini",model="gemini-2.0-flash",le="75"} 0`)
+	assert.Contains(t, text, `router_stream_tokens_per_second_bucket{vendor="gemini",model="gemini-2.0-flash",le="100"} 1`)
+	assert.Contains(t, text, "# TYPE router_active_streams gauge\n")
+}
+
+func TestPrometheusMetrics_HistogramBucketsAreCumulative(t *testing.T) {
+	metrics := NewPrometheusMetrics()
+	for _, latency := range []time.Duration{20 * time.Millisecond, 200 * time.Millisecond, 3 * time.Minute} {
+		metrics.ObserveVendorResponse("openai", "gpt-4o", 200, false, latency)
+	}
+
+	var out strings.Builder
+	_, err := metrics.WriteTo(&out)
+	require.NoError(t, err)
+	text := out.String()
+
+	prefix := `router_vendor_latency_seconds_bucket{vendor="openai",model="gpt-4o",status="200",le=`
+	assert.Contains(t, text, prefix+`"0.05"} 1`)
+	assert.Contains(t, text, prefix+`"0.25"} 2`)
+	assert.Contains(t, text, prefix+`"120"} 2`, "observations above the last bound only count toward +Inf")
+	assert.Contains(t, text, prefix+`"+Inf"} 3`)
+}
+
+func TestLabelPairs_EscapesValues(t *testing.T) {
+	assert.Equal(t, `{reason="bad \"quote\"\nline\\"}`, labelPairs([]string{"reason"}, []string{"bad \"quote\"\nline\\"}))
+	assert.Equal(t, "", labelPairs(nil, nil))
+}
@@ -0,0 +1,92 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server-Timing metric names
+const (
+	TimingVendor     = "vendor"
+	TimingValidation = "validation"
+	TimingMedia      = "media"
+	TimingProcessing = "processing"
+	TimingTotal      = "total"
+)
+
+// ServerTiming collects named durations spent while serving one request
+// A nil *ServerTiming ignores all calls, so callers need not check whether timing is enabled
+type ServerTiming struct {
+	mu        sync.Mutex
+	durations map[string]time.Duration
+	order     []string
+}
+
+type serverTimingKey struct{}
+
+// WithServerTiming attaches a new ServerTiming to the context
+func WithServerTiming(ctx context.Context) (context.Context, *ServerTiming) {
+	timing := &ServerTiming{durations: make(map[string]time.Duration)}
+	return context.WithValue(ctx, serverTimingKey{}, timing), timing
+}
+
+// ServerTimingFromContext returns the request's ServerTiming, or nil when timing is disabled
+func ServerTimingFromContext(ctx context.Context) *ServerTiming {
+	timing, _ := ctx.Value(serverTimingKey{}).(*ServerTiming)
+	return timing
+}
+
+// Add accumulates d under name; repeated calls (e.g. retried vendor calls) are summed
+func (t *ServerTiming) Add(name string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.durations[name]; !ok {
+		t.order = append(t.order, name)
+	}
+	t.durations[name] += d
+}
+
+// Duration returns the time accumulated under name
+func (t *ServerTiming) Duration(name string) time.Duration {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.durations[name]
+}
+
+// Header formats the recorded durations for the Server-Timing header, followed by
+// processing (total minus everything recorded) and total
+func (t *ServerTiming) Header(total time.Duration) string {
+	if t == nil {
+		return ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	metrics := make([]string, 0, len(t.order)+2)
+	var recorded time.Duration
+	for _, name := range t.order {
+		recorded += t.durations[name]
+		metrics = append(metrics, formatTiming(name, t.durations[name]))
+	}
+	processing := total - recorded
+	if processing < 0 {
+		processing = 0
+	}
+	metrics = append(metrics, formatTiming(TimingProcessing, processing), formatTiming(TimingTotal, total))
+	return strings.Join(metrics, ", ")
+}
+
+// formatTiming renders one metric with its duration in milliseconds
+func formatTiming(name string, d time.Duration) string {
+	return fmt.Sprintf("%s;dur=%.1f", name, float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,95 @@
+package monitoring
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Slow-client events recorded while streaming responses
+const (
+	SlowClientWriteTimeout = "write_timeout"
+	SlowClientBufferFull   = "buffer_full"
+	SlowClientPaused       = "paused"
+)
+
+// VendorSlowClientStats counts streams whose clients read slower than the vendor produced
+type VendorSlowClientStats struct {
+	Vendor string `json:"vendor"`
+
+	// WriteTimeouts counts streams dropped because a chunk could not be written within the deadline
+	WriteTimeouts int64 `json:"write_timeouts"`
+	// BufferFullDisconnects counts streams dropped because the output buffer filled up
+	BufferFullDisconnects int64 `json:"buffer_full_disconnects"`
+	// Pauses counts times upstream reads were paused until the client caught up
+	Pauses      int64      `json:"pauses"`
+	PausedMs    int64      `json:"paused_ms"`
+	LastEventAt *time.Time `json:"last_event_at,omitempty"`
+	LastEvent   string     `json:"last_event,omitempty"`
+}
+
+// SlowClientMetrics aggregates slow-client events per vendor
+type SlowClientMetrics struct {
+	mu      sync.Mutex
+	vendors map[string]*VendorSlowClientStats
+}
+
+var (
+	defaultSlowClientMetrics     *SlowClientMetrics
+	defaultSlowClientMetricsOnce sync.Once
+)
+
+// NewSlowClientMetrics creates an empty slow-client aggregator
+func NewSlowClientMetrics() *SlowClientMetrics {
+	return &SlowClientMetrics{vendors: make(map[string]*VendorSlowClientStats)}
+}
+
+// DefaultSlowClientMetrics returns the process-wide slow-client aggregator
+func DefaultSlowClientMetrics() *SlowClientMetrics {
+	defaultSlowClientMetricsOnce.Do(func() {
+		defaultSlowClientMetrics = NewSlowClientMetrics()
+	})
+	return defaultSlowClientMetrics
+}
+
+// Record counts a slow-client event for vendor; paused is how long upstream reads were held
+// and is only meaningful for SlowClientPaused
+func (m *SlowClientMetrics) Record(vendor, event string, paused time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.vendors[vendor]
+	if !ok {
+		stats = &VendorSlowClientStats{Vendor: vendor}
+		m.vendors[vendor] = stats
+	}
+	switch event {
+	case SlowClientWriteTimeout:
+		stats.WriteTimeouts++
+	case SlowClientBufferFull:
+		stats.BufferFullDisconnects++
+	case SlowClientPaused:
+		stats.Pauses++
+		stats.PausedMs += paused.Milliseconds()
+	default:
+		return
+	}
+	now := time.Now().UTC()
+	stats.LastEventAt = &now
+	stats.LastEvent = event
+}
+
+// Snapshot returns per-vendor counts, sorted by vendor name
+func (m *SlowClientMetrics) Snapshot() []VendorSlowClientStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make([]VendorSlowClientStats, 0, len(m.vendors))
+	for _, stats := range m.vendors {
+		snapshot = append(snapshot, *stats)
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Vendor < snapshot[j].Vendor
+	})
+	return snapshot
+}
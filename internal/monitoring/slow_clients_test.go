@@ -0,0 +1,33 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowClientMetrics_Record(t *testing.T) {
+	metrics := NewSlowClientMetrics()
+
+	metrics.Record("openai", SlowClientPaused, 1500*time.Millisecond)
+	metrics.Record("openai", SlowClientPaused, 500*time.Millisecond)
+	metrics.Record("openai", SlowClientWriteTimeout, 0)
+	metrics.Record("gemini", SlowClientBufferFull, 0)
+	metrics.Record("gemini", "unknown", 0)
+
+	snapshot := metrics.Snapshot()
+	require.Len(t, snapshot, 2)
+
+	assert.Equal(t, "gemini", snapshot[0].Vendor)
+	assert.Equal(t, int64(1), snapshot[0].BufferFullDisconnects)
+	assert.Equal(t, SlowClientBufferFull, snapshot[0].LastEvent, "unknown events are ignored")
+
+	assert.Equal(t, "openai", snapshot[1].Vendor)
+	assert.Equal(t, int64(2), snapshot[1].Pauses)
+	assert.Equal(t, int64(2000), snapshot[1].PausedMs)
+	assert.Equal(t, int64(1), snapshot[1].WriteTimeouts)
+	assert.Equal(t, SlowClientWriteTimeout, snapshot[1].LastEvent)
+	assert.NotNil(t, snapshot[1].LastEventAt)
+}
@@ -0,0 +1,82 @@
+package monitoring
+
+import (
+	"sort"
+	"sync"
+)
+
+// VendorActiveStreams is the number of streaming responses currently open to a vendor
+type VendorActiveStreams struct {
+	Vendor string `json:"vendor"`
+	Active int64  `json:"active"`
+}
+
+// ActiveStreams tracks the streaming responses currently being proxied, per vendor
+type ActiveStreams struct {
+	mu      sync.Mutex
+	vendors map[string]int64
+}
+
+var (
+	defaultActiveStreams     *ActiveStreams
+	defaultActiveStreamsOnce sync.Once
+)
+
+// NewActiveStreams creates an empty active-stream gauge
+func NewActiveStreams() *ActiveStreams {
+	return &ActiveStreams{vendors: make(map[string]int64)}
+}
+
+// DefaultActiveStreams returns the process-wide active-stream gauge
+func DefaultActiveStreams() *ActiveStreams {
+	defaultActiveStreamsOnce.Do(func() {
+		defaultActiveStreams = NewActiveStreams()
+	})
+	return defaultActiveStreams
+}
+
+// Start counts a stream to vendor as open; the returned function closes it and must be
+// called exactly once
+func (s *ActiveStreams) Start(vendor string) func() {
+	s.mu.Lock()
+	s.vendors[vendor]++
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.vendors[vendor]--
+			if s.vendors[vendor] <= 0 {
+				delete(s.vendors, vendor)
+			}
+		})
+	}
+}
+
+// Total returns the number of open streams across vendors
+func (s *ActiveStreams) Total() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var total int64
+	for _, active := range s.vendors {
+		total += active
+	}
+	return total
+}
+
+// Snapshot returns the open streams per vendor, sorted by vendor name
+func (s *ActiveStreams) Snapshot() []VendorActiveStreams {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make([]VendorActiveStreams, 0, len(s.vendors))
+	for vendor, active := range s.vendors {
+		snapshot = append(snapshot, VendorActiveStreams{Vendor: vendor, Active: active})
+	}
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Vendor < snapshot[j].Vendor
+	})
+	return snapshot
+}
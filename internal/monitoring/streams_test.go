@@ -0,0 +1,26 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActiveStreams(t *testing.T) {
+	streams := NewActiveStreams()
+	doneA := streams.Start("openai")
+	doneB := streams.Start("openai")
+	doneC := streams.Start("gemini")
+
+	assert.Equal(t, int64(3), streams.Total())
+	assert.Equal(t, []VendorActiveStreams{{Vendor: "gemini", Active: 1}, {Vendor: "openai", Active: 2}}, streams.Snapshot())
+
+	doneA()
+	doneA()
+	doneC()
+	assert.Equal(t, int64(1), streams.Total())
+	assert.Equal(t, []VendorActiveStreams{{Vendor: "openai", Active: 1}}, streams.Snapshot())
+
+	doneB()
+	assert.Empty(t, streams.Snapshot())
+}
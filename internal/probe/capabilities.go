@@ -0,0 +1,144 @@
+package probe
+
+import (
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+)
+
+// nonChatMarkers identify model IDs that cannot serve chat completions
+var nonChatMarkers = []string{
+	"embedding", "tts", "whisper", "dall-e", "realtime", "audio", "transcribe",
+	"moderation", "search", "image", "imagen", "aqa", "live", "instruct",
+}
+
+// openAIContextWindows maps OpenAI model ID prefixes to their context windows
+// The longest matching prefix wins, so dated snapshots inherit their family's window
+var openAIContextWindows = map[string]int{
+	"gpt-3.5-turbo": 16385,
+	"gpt-4":         8192,
+	"gpt-4-turbo":   128000,
+	"gpt-4o":        128000,
+	"gpt-4.1":       1047576,
+	"gpt-5":         400000,
+	"chatgpt-4o":    128000,
+	"o1":            200000,
+	"o1-mini":       128000,
+	"o3":            200000,
+	"o3-mini":       200000,
+	"o4-mini":       200000,
+}
+
+// IsChatModel reports whether a listed model ID looks like a chat completion model
+func IsChatModel(vendor, model string) bool {
+	id := strings.ToLower(model)
+	if vendor == "ollama" {
+		// Ollama tags often name quantized "-instruct" builds, so only embedding models are skipped
+		return !strings.Contains(id, "embed")
+	}
+	if vendor == "together" {
+		// Together's listing is already filtered to chat models by type, and many are "-Instruct"
+		return true
+	}
+	if vendor == "groq" {
+		// Groq's chat models include "-instruct" variants, so only speech and guard models are skipped
+		return !strings.Contains(id, "whisper") && !strings.Contains(id, "tts") && !strings.Contains(id, "guard")
+	}
+	for _, marker := range nonChatMarkers {
+		if strings.Contains(id, marker) {
+			return false
+		}
+	}
+
+	switch vendor {
+	case "openai":
+		for _, prefix := range []string{"gpt-", "chatgpt-", "o1", "o3", "o4"} {
+			if strings.HasPrefix(id, prefix) {
+				return true
+			}
+		}
+		return false
+	case "gemini":
+		return strings.HasPrefix(id, "gemini-")
+	case "mistral":
+		return !strings.Contains(id, "embed") && !strings.Contains(id, "ocr")
+	default:
+		return true
+	}
+}
+
+// InferCapabilities returns a best-effort ModelConfig from the vendor and model ID
+// Values inferred here are starting points and should be reviewed before deploying
+func InferCapabilities(vendor, model string) config.ModelConfig {
+	id := strings.ToLower(model)
+
+	switch vendor {
+	case "gemini":
+		return config.ModelConfig{
+			SupportImage:     true,
+			SupportVideo:     !strings.HasPrefix(id, "gemini-1.0"),
+			SupportAudio:     !strings.HasPrefix(id, "gemini-1.0"),
+			SupportTools:     true,
+			SupportStreaming: true,
+		}
+	case "openai":
+		legacy := strings.HasPrefix(id, "gpt-3.5") || id == "gpt-4" || strings.HasPrefix(id, "gpt-4-0")
+		reasoningPreview := strings.HasPrefix(id, "o1-mini") || strings.HasPrefix(id, "o1-preview")
+		return config.ModelConfig{
+			SupportImage:     !legacy && !reasoningPreview && !strings.HasPrefix(id, "o3-mini"),
+			SupportAudio:     strings.Contains(id, "audio"),
+			SupportTools:     !reasoningPreview,
+			SupportStreaming: true,
+			ContextWindow:    longestPrefixValue(openAIContextWindows, id),
+		}
+	case "mistral":
+		return config.ModelConfig{
+			SupportImage:     strings.HasPrefix(id, "pixtral") || strings.HasPrefix(id, "mistral-medium") || strings.HasPrefix(id, "mistral-small"),
+			SupportTools:     true,
+			SupportStreaming: true,
+		}
+	case "groq":
+		return config.ModelConfig{
+			SupportImage:     strings.Contains(id, "llama-4") || strings.Contains(id, "vision"),
+			SupportTools:     true,
+			SupportStreaming: true,
+		}
+	case "deepseek":
+		return config.ModelConfig{
+			SupportTools:     true,
+			SupportStreaming: true,
+			ContextWindow:    128000,
+		}
+	case "huggingface":
+		return config.ModelConfig{
+			SupportImage:     strings.Contains(id, "vision") || strings.Contains(id, "-vl") || strings.Contains(id, "llava") || strings.Contains(id, "idefics"),
+			SupportTools:     true,
+			SupportStreaming: true,
+		}
+	case "together":
+		return config.ModelConfig{
+			SupportImage:     strings.Contains(id, "vision") || strings.Contains(id, "-vl") || strings.Contains(id, "llama-4"),
+			SupportTools:     true,
+			SupportStreaming: true,
+		}
+	case "ollama":
+		return config.ModelConfig{
+			SupportImage:     strings.Contains(id, "llava") || strings.Contains(id, "vision") || strings.Contains(id, "gemma3") || strings.Contains(id, "vl"),
+			SupportTools:     !strings.Contains(id, "llava"),
+			SupportStreaming: true,
+		}
+	default:
+		return config.ModelConfig{SupportStreaming: true}
+	}
+}
+
+// longestPrefixValue returns the value of the longest key that prefixes id, or 0
+func longestPrefixValue(values map[string]int, id string) int {
+	best, value := -1, 0
+	for prefix, v := range values {
+		if strings.HasPrefix(id, prefix) && len(prefix) > best {
+			best, value = len(prefix), v
+		}
+	}
+	return value
+}
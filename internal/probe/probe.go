@@ -0,0 +1,274 @@
+// Package probe discovers the models offered by each vendor and their capabilities
+// so a starter configs/models.json can be generated instead of written by hand
+package probe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+)
+
+// DefaultVendorURLs are the OpenAI-compatible base URLs used when no models.json provides one
+var DefaultVendorURLs = map[string]string{
+	"openai":      "https://api.openai.com/v1",
+	"gemini":      "https://generativelanguage.googleapis.com/v1beta/openai",
+	"mistral":     "https://api.mistral.ai/v1",
+	"groq":        "https://api.groq.com/openai/v1",
+	"together":    "https://api.together.xyz/v1",
+	"deepseek":    "https://api.deepseek.com",
+	"huggingface": "https://router.huggingface.co/v1",
+	"ollama":      "http://localhost:11434",
+}
+
+// Prober queries vendor APIs for their model catalogues
+type Prober struct {
+	httpClient *http.Client
+	active     bool
+}
+
+// NewProber creates a prober; when active is true each chat model also receives
+// minimal tool-calling and streaming requests to confirm those capabilities
+func NewProber(timeout time.Duration, active bool) *Prober {
+	return &Prober{
+		httpClient: &http.Client{Timeout: timeout},
+		active:     active,
+	}
+}
+
+// Generate probes every vendor that has both a credential and a base URL and returns a models config
+// Per-vendor failures are returned alongside the partial result so one bad key does not stop the rest
+func (p *Prober) Generate(ctx context.Context, creds []config.Credential, vendors map[string]string) (*config.ModelsConfig, []error) {
+	result := &config.ModelsConfig{
+		Vendors: make(map[string]string),
+		Models:  []config.VendorModel{},
+	}
+	var errs []error
+
+	// Self-hosted vendors are probed without a credential when a base URL is configured
+	for vendor := range vendors {
+		if config.KeylessVendors[vendor] {
+			creds = append(creds, config.Credential{Platform: vendor, Type: config.CredentialTypeNone})
+		}
+	}
+
+	probed := make(map[string]bool)
+	for _, cred := range creds {
+		vendor := cred.Platform
+		if probed[vendor] {
+			continue
+		}
+		probed[vendor] = true
+
+		baseURL := vendors[vendor]
+		if baseURL == "" {
+			baseURL = DefaultVendorURLs[vendor]
+		}
+		if baseURL == "" {
+			errs = append(errs, fmt.Errorf("%s: no base URL known, add it to the vendors map", vendor))
+			continue
+		}
+
+		models, err := p.ProbeVendor(ctx, vendor, baseURL, cred.Value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", vendor, err))
+			continue
+		}
+		result.Vendors[vendor] = baseURL
+		result.Models = append(result.Models, models...)
+	}
+
+	sort.Slice(result.Models, func(i, j int) bool {
+		if result.Models[i].Vendor != result.Models[j].Vendor {
+			return result.Models[i].Vendor < result.Models[j].Vendor
+		}
+		return result.Models[i].Model < result.Models[j].Model
+	})
+	return result, errs
+}
+
+// ProbeVendor lists the chat models of one vendor and detects their capabilities
+func (p *Prober) ProbeVendor(ctx context.Context, vendor, baseURL, apiKey string) ([]config.VendorModel, error) {
+	// Ollama serves its OpenAI-compatible API under /v1 of the native base URL
+	if vendor == "ollama" {
+		baseURL = strings.TrimSuffix(baseURL, "/") + "/v1"
+	}
+
+	listed, err := p.listModels(ctx, baseURL, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Gemini's native API exposes context windows that its OpenAI-compatible listing omits
+	var contextWindows map[string]int
+	if vendor == "gemini" {
+		contextWindows, _ = p.geminiContextWindows(ctx, baseURL, apiKey)
+	}
+
+	var models []config.VendorModel
+	for _, model := range listed {
+		id := model.ID
+		// Together types its models, so only those typed "chat" are kept
+		if vendor == "together" && model.Type != "chat" {
+			continue
+		}
+		if !IsChatModel(vendor, id) {
+			continue
+		}
+
+		modelConfig := InferCapabilities(vendor, id)
+		if window, ok := contextWindows[id]; ok {
+			modelConfig.ContextWindow = window
+		} else if model.ContextLength > 0 {
+			modelConfig.ContextWindow = model.ContextLength
+		}
+		if p.active {
+			modelConfig.SupportTools = p.acceptsRequest(ctx, baseURL, apiKey, toolsProbeRequest(id))
+			modelConfig.SupportStreaming = p.acceptsRequest(ctx, baseURL, apiKey, streamingProbeRequest(id))
+		}
+
+		models = append(models, config.VendorModel{Vendor: vendor, Model: id, Config: &modelConfig})
+	}
+	return models, nil
+}
+
+// listedModel is one entry of a GET /models listing
+type listedModel struct {
+	ID string `json:"id"`
+	// Type and ContextLength are only reported by Together
+	Type          string `json:"type"`
+	ContextLength int    `json:"context_length"`
+}
+
+// listModels returns the models from an OpenAI-compatible GET /models endpoint
+// Listings are either wrapped in a "data" array or, as Together returns them, a bare array
+func (p *Prober) listModels(ctx context.Context, baseURL, apiKey string) ([]listedModel, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/models", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+
+	body, err := p.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+
+	var listing struct {
+		Data []listedModel `json:"data"`
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		err = codec.Unmarshal(body, &listing.Data)
+	} else {
+		err = codec.Unmarshal(body, &listing)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid models listing: %w", err)
+	}
+
+	for i := range listing.Data {
+		// Gemini's OpenAI-compatible listing prefixes IDs with "models/"
+		listing.Data[i].ID = strings.TrimPrefix(listing.Data[i].ID, "models/")
+	}
+	return listing.Data, nil
+}
+
+// geminiContextWindows reads inputTokenLimit for each model from Gemini's native models API
+func (p *Prober) geminiContextWindows(ctx context.Context, baseURL, apiKey string) (map[string]int, error) {
+	nativeURL := strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/openai") + "/models?pageSize=1000&key=" + url.QueryEscape(apiKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, nativeURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var listing struct {
+		Models []struct {
+			Name            string `json:"name"`
+			InputTokenLimit int    `json:"inputTokenLimit"`
+		} `json:"models"`
+	}
+	if err := codec.Unmarshal(body, &listing); err != nil {
+		return nil, err
+	}
+
+	windows := make(map[string]int, len(listing.Models))
+	for _, model := range listing.Models {
+		windows[strings.TrimPrefix(model.Name, "models/")] = model.InputTokenLimit
+	}
+	return windows, nil
+}
+
+// acceptsRequest reports whether the vendor answers a chat completion request with a 2xx status
+func (p *Prober) acceptsRequest(ctx context.Context, baseURL, apiKey string, payload map[string]interface{}) bool {
+	data, err := codec.Marshal(payload)
+	if err != nil {
+		return false
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(baseURL, "/")+"/chat/completions", bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = p.do(req)
+	return err == nil
+}
+
+// do executes a request and returns the body, treating non-2xx statuses as errors
+func (p *Prober) do(req *http.Request) ([]byte, error) {
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("vendor returned status %d", resp.StatusCode)
+	}
+	return body, nil
+}
+
+// toolsProbeRequest is the smallest request that exercises tool calling
+func toolsProbeRequest(model string) map[string]interface{} {
+	return map[string]interface{}{
+		"model":      model,
+		"max_tokens": 1,
+		"messages":   []interface{}{map[string]interface{}{"role": "user", "content": "ping"}},
+		"tools": []interface{}{map[string]interface{}{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":       "ping",
+				"parameters": map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+			},
+		}},
+	}
+}
+
+// streamingProbeRequest is the smallest request that exercises streaming
+func streamingProbeRequest(model string) map[string]interface{} {
+	return map[string]interface{}{
+		"model":      model,
+		"max_tokens": 1,
+		"stream":     true,
+		"messages":   []interface{}{map[string]interface{}{"role": "user", "content": "ping"}},
+	}
+}
@@ -0,0 +1,138 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVendorServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/openai/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer sk-openai", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o-2024-08-06"},{"id":"text-embedding-3-small"},{"id":"o1-mini"},{"id":"whisper-1"}]}`))
+	})
+	mux.HandleFunc("/gemini/v1beta/openai/models", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"object":"list","data":[{"id":"models/gemini-2.0-flash"},{"id":"models/text-embedding-004"}]}`))
+	})
+	mux.HandleFunc("/gemini/v1beta/models", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gm-key", r.URL.Query().Get("key"))
+		w.Write([]byte(`{"models":[{"name":"models/gemini-2.0-flash","inputTokenLimit":1048576}]}`))
+	})
+	mux.HandleFunc("/broken/models", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProber_Generate(t *testing.T) {
+	server := newVendorServer(t)
+	creds := []config.Credential{
+		{Platform: "openai", Type: "api-key", Value: "sk-openai"},
+		{Platform: "gemini", Type: "api-key", Value: "gm-key"},
+		{Platform: "broken", Type: "api-key", Value: "x"},
+		{Platform: "unknown", Type: "api-key", Value: "x"},
+	}
+	vendors := map[string]string{
+		"openai": server.URL + "/openai/v1",
+		"gemini": server.URL + "/gemini/v1beta/openai",
+		"broken": server.URL + "/broken",
+	}
+
+	generated, errs := NewProber(5*time.Second, false).Generate(context.Background(), creds, vendors)
+	assert.Len(t, errs, 2, "broken vendor and vendor without base URL are reported")
+
+	require.Len(t, generated.Models, 3)
+	assert.Equal(t, map[string]string{"openai": vendors["openai"], "gemini": vendors["gemini"]}, generated.Vendors)
+
+	gemini := generated.Models[0]
+	assert.Equal(t, "gemini-2.0-flash", gemini.Model)
+	assert.Equal(t, 1048576, gemini.Config.ContextWindow)
+	assert.True(t, gemini.Config.SupportVideo)
+
+	assert.Equal(t, "gpt-4o-2024-08-06", generated.Models[1].Model)
+	assert.Equal(t, 128000, generated.Models[1].Config.ContextWindow)
+	assert.True(t, generated.Models[1].Config.SupportImage)
+
+	assert.Equal(t, "o1-mini", generated.Models[2].Model)
+	assert.False(t, generated.Models[2].Config.SupportTools)
+}
+
+func TestProber_GenerateKeylessVendor(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"object":"list","data":[{"id":"llama3.2-vision:11b"},{"id":"nomic-embed-text:latest"}]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	generated, errs := NewProber(5*time.Second, false).Generate(context.Background(), nil, map[string]string{"ollama": server.URL})
+	assert.Empty(t, errs)
+	assert.Equal(t, map[string]string{"ollama": server.URL}, generated.Vendors, "the native base URL is kept for routing")
+	require.Len(t, generated.Models, 1)
+	assert.Equal(t, "llama3.2-vision:11b", generated.Models[0].Model)
+	assert.True(t, generated.Models[0].Config.SupportImage)
+}
+
+func TestProber_ProbeVendorTogether(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/models", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"id":"meta-llama/Llama-3.3-70B-Instruct-Turbo","object":"model","type":"chat","context_length":131072},
+			{"id":"meta-llama/Llama-Vision-Free","object":"model","type":"chat","context_length":131072},
+			{"id":"BAAI/bge-large-en-v1.5","object":"model","type":"embedding","context_length":512},
+			{"id":"black-forest-labs/FLUX.1-schnell","object":"model","type":"image"}
+		]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	models, err := NewProber(5*time.Second, false).ProbeVendor(context.Background(), "together", server.URL+"/v1", "key")
+	require.NoError(t, err)
+	require.Len(t, models, 2, "only models typed chat are kept")
+	assert.Equal(t, "meta-llama/Llama-3.3-70B-Instruct-Turbo", models[0].Model)
+	assert.Equal(t, 131072, models[0].Config.ContextWindow)
+	assert.False(t, models[0].Config.SupportImage)
+	assert.True(t, models[1].Config.SupportImage)
+}
+
+func TestIsChatModel(t *testing.T) {
+	tests := []struct {
+		vendor   string
+		model    string
+		expected bool
+	}{
+		{"openai", "gpt-4.1-mini", true},
+		{"openai", "o3", true},
+		{"openai", "gpt-4o-realtime-preview", false},
+		{"openai", "gpt-3.5-turbo-instruct", false},
+		{"openai", "dall-e-3", false},
+		{"gemini", "gemini-2.5-pro", true},
+		{"gemini", "gemini-embedding-001", false},
+		{"gemini", "imagen-3.0-generate-002", false},
+		{"mistral", "mistral-large-latest", true},
+		{"mistral", "mistral-embed", false},
+		{"mistral", "mistral-ocr-latest", false},
+		{"groq", "meta-llama/llama-4-scout-17b-16e-instruct", true},
+		{"groq", "llama-3.3-70b-versatile", true},
+		{"groq", "whisper-large-v3", false},
+		{"groq", "meta-llama/llama-guard-4-12b", false},
+		{"ollama", "llama3.1:8b-instruct-q4_K_M", true},
+		{"ollama", "nomic-embed-text:latest", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsChatModel(tt.vendor, tt.model))
+		})
+	}
+}
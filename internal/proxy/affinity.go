@@ -0,0 +1,27 @@
+package proxy
+
+import (
+	"net/http"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// DefaultAffinityHeader is the request header that names a conversation for sticky routing
+const DefaultAffinityHeader = "X-Conversation-ID"
+
+// conversationKey identifies the conversation a request belongs to for sticky routing: the
+// value of the AFFINITY_HEADER header or, with AFFINITY_USE_USER, the end user named by the
+// "user" field. Keys are scoped to the client key, so clients cannot steer each other's
+// conversations; "" means the request names no conversation
+func conversationKey(r *http.Request) string {
+	id := r.Header.Get(utils.GetEnvString("AFFINITY_HEADER", DefaultAffinityHeader))
+	if id != "" {
+		id = "header:" + id
+	} else if user := userHashFromContext(r); user != "" && utils.GetEnvBool("AFFINITY_USE_USER", false) {
+		id = "user:" + user
+	} else {
+		return ""
+	}
+	return access.UserHash(access.ClientKey(r) + "\n" + id)
+}
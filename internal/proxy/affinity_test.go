@@ -0,0 +1,37 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConversationKey(t *testing.T) {
+	request := func(clientKey, conversation, userHash string) string {
+		r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+		r.Header.Set("Authorization", "Bearer "+clientKey)
+		if conversation != "" {
+			r.Header.Set(DefaultAffinityHeader, conversation)
+		}
+		if userHash != "" {
+			r = r.WithContext(logger.WithUser(r.Context(), userHash))
+		}
+		return conversationKey(r)
+	}
+
+	key := request("sk-client-a", "conv-1", "")
+	assert.NotEmpty(t, key)
+	assert.Equal(t, key, request("sk-client-a", "conv-1", "user-hash"), "the header names the conversation")
+	assert.NotEqual(t, key, request("sk-client-b", "conv-1", ""), "keys are scoped to the client key")
+	assert.NotEqual(t, key, request("sk-client-a", "conv-2", ""))
+
+	assert.Empty(t, request("sk-client-a", "", "user-hash"), "the user is only used when enabled")
+	t.Setenv("AFFINITY_USE_USER", "true")
+	assert.NotEmpty(t, request("sk-client-a", "", "user-hash"))
+	assert.Empty(t, request("sk-client-a", "", ""))
+
+	t.Setenv("AFFINITY_HEADER", "X-Session-ID")
+	assert.NotEqual(t, key, request("sk-client-a", "conv-1", "user-hash"), "only the configured header is read")
+}
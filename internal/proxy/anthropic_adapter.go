@@ -0,0 +1,485 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// anthropicVersion is the Messages API version the adapter is written against
+const anthropicVersion = "2023-06-01"
+
+// anthropicStopReasons maps Anthropic stop reasons to OpenAI finish reasons
+var anthropicStopReasons = map[string]string{
+	"end_turn":      "stop",
+	"stop_sequence": "stop",
+	"max_tokens":    "length",
+	"tool_use":      "tool_calls",
+	"refusal":       "content_filter",
+}
+
+// AnthropicAdapter translates OpenAI chat completions to and from Anthropic's Messages API
+type AnthropicAdapter struct {
+	maxTokensOnce    sync.Once
+	defaultMaxTokens int
+}
+
+func init() {
+	RegisterVendorAdapter("anthropic", func() VendorAdapter { return NewAnthropicAdapter() })
+}
+
+// NewAnthropicAdapter creates an Anthropic adapter
+func NewAnthropicAdapter() *AnthropicAdapter {
+	return &AnthropicAdapter{}
+}
+
+// maxTokens returns the max_tokens sent when the request has none, which the Messages API
+// requires: ANTHROPIC_MAX_TOKENS (default 4096), read on first use since adapters are
+// registered before the .env file is loaded
+func (a *AnthropicAdapter) maxTokens() int {
+	a.maxTokensOnce.Do(func() {
+		if a.defaultMaxTokens == 0 {
+			a.defaultMaxTokens = utils.GetEnvInt("ANTHROPIC_MAX_TOKENS", 4096)
+		}
+	})
+	return a.defaultMaxTokens
+}
+
+// KeptRequestFields passes the client's output limit through validation, since the Messages
+// API requires one
+func (a *AnthropicAdapter) KeptRequestFields() []string {
+	return []string{"max_tokens", "max_completion_tokens"}
+}
+
+// Endpoint returns the Messages API URL
+func (a *AnthropicAdapter) Endpoint(baseURL, model string, streaming bool) string {
+	return baseURL + "/messages"
+}
+
+// Authorize sets the x-api-key and anthropic-version headers instead of a Bearer token
+func (a *AnthropicAdapter) Authorize(req *http.Request, credential config.Credential) error {
+	req.Header.Del(utils.HeaderAuthorization)
+	req.Header.Set("x-api-key", credential.Value)
+	req.Header.Set("anthropic-version", anthropicVersion)
+	return nil
+}
+
+// TranslateRequest converts an OpenAI chat completion request into a Messages API request
+// System messages become the top-level system prompt, tool calls and results become
+// tool_use/tool_result blocks, and consecutive same-role turns are merged
+func (a *AnthropicAdapter) TranslateRequest(body []byte) ([]byte, error) {
+	var request map[string]interface{}
+	if err := codec.Unmarshal(body, &request); err != nil {
+		return nil, fmt.Errorf("invalid request format: %w", err)
+	}
+
+	messages, _ := request["messages"].([]interface{})
+	var systemParts []string
+	anthropicMessages := make([]map[string]interface{}, 0, len(messages))
+
+	for _, raw := range messages {
+		message, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		role, _ := message["role"].(string)
+		var blocks []interface{}
+		switch role {
+		case "system", "developer":
+			if text := contentText(message["content"]); text != "" {
+				systemParts = append(systemParts, text)
+			}
+			continue
+		case "tool":
+			role = "user"
+			toolCallID, _ := message["tool_call_id"].(string)
+			blocks = []interface{}{map[string]interface{}{
+				"type":        "tool_result",
+				"tool_use_id": toolCallID,
+				"content":     contentText(message["content"]),
+			}}
+		case "assistant":
+			blocks = anthropicContentBlocks(message["content"])
+			blocks = append(blocks, anthropicToolUseBlocks(message["tool_calls"])...)
+		default:
+			role = "user"
+			blocks = anthropicContentBlocks(message["content"])
+		}
+
+		if len(blocks) == 0 {
+			continue
+		}
+
+		// The Messages API expects alternating turns, so fold same-role messages together
+		if n := len(anthropicMessages); n > 0 && anthropicMessages[n-1]["role"] == role {
+			previous := anthropicMessages[n-1]["content"].([]interface{})
+			anthropicMessages[n-1]["content"] = append(previous, blocks...)
+			continue
+		}
+		anthropicMessages = append(anthropicMessages, map[string]interface{}{
+			"role":    role,
+			"content": blocks,
+		})
+	}
+
+	translated := map[string]interface{}{
+		"model":      request["model"],
+		"messages":   anthropicMessages,
+		"max_tokens": a.maxTokens(),
+	}
+	// max_completion_tokens, OpenAI's newer name for the limit, wins over max_tokens
+	for _, field := range []string{"max_tokens", "max_completion_tokens"} {
+		if maxTokens, ok := request[field].(float64); ok && maxTokens > 0 {
+			translated["max_tokens"] = int(maxTokens)
+		}
+	}
+	if len(systemParts) > 0 {
+		translated["system"] = strings.Join(systemParts, "\n\n")
+	}
+	if stream, ok := request["stream"].(bool); ok && stream {
+		translated["stream"] = true
+	}
+	if user, ok := request["user"].(string); ok && user != "" {
+		translated["metadata"] = map[string]interface{}{"user_id": user}
+	}
+	if tools := anthropicTools(request["tools"]); len(tools) > 0 {
+		translated["tools"] = tools
+		if toolChoice := anthropicToolChoice(request["tool_choice"]); toolChoice != nil {
+			translated["tool_choice"] = toolChoice
+		}
+	}
+
+	return codec.Marshal(translated)
+}
+
+// TranslateResponse converts a Messages API response into an OpenAI chat completion
+func (a *AnthropicAdapter) TranslateResponse(body []byte) ([]byte, error) {
+	var response struct {
+		ID         string `json:"id"`
+		Model      string `json:"model"`
+		StopReason string `json:"stop_reason"`
+		Content    []struct {
+			Type  string      `json:"type"`
+			Text  string      `json:"text"`
+			ID    string      `json:"id"`
+			Name  string      `json:"name"`
+			Input interface{} `json:"input"`
+		} `json:"content"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	var text strings.Builder
+	var toolCalls []interface{}
+	for _, block := range response.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			arguments, err := codec.Marshal(block.Input)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid tool_use input: %v", ErrInvalidResponse, err)
+			}
+			toolCalls = append(toolCalls, map[string]interface{}{
+				"id":   block.ID,
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":      block.Name,
+					"arguments": string(arguments),
+				},
+			})
+		}
+	}
+
+	message := map[string]interface{}{
+		"role":    "assistant",
+		"content": text.String(),
+	}
+	if len(toolCalls) > 0 {
+		message["tool_calls"] = toolCalls
+		if text.Len() == 0 {
+			message["content"] = nil
+		}
+	}
+
+	return codec.Marshal(map[string]interface{}{
+		"id":      response.ID,
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   response.Model,
+		"choices": []interface{}{map[string]interface{}{
+			"index":         0,
+			"message":       message,
+			"finish_reason": anthropicFinishReason(response.StopReason),
+		}},
+		"usage": map[string]interface{}{
+			"prompt_tokens":     response.Usage.InputTokens,
+			"completion_tokens": response.Usage.OutputTokens,
+			"total_tokens":      response.Usage.InputTokens + response.Usage.OutputTokens,
+		},
+	})
+}
+
+// TranslateStream converts Messages API server-sent events into OpenAI chunk events
+func (a *AnthropicAdapter) TranslateStream(r io.Reader) io.Reader {
+	s := &anthropicStream{toolIndex: make(map[int]int)}
+	s.chunkReader = newChunkReader(r, "", s.translateEvent, nil)
+	return s
+}
+
+// anthropicStream produces OpenAI SSE chunks from Anthropic SSE events; the stream is
+// complete at message_stop
+type anthropicStream struct {
+	*chunkReader
+	inputTokens int
+	toolIndex   map[int]int // Anthropic content block index -> OpenAI tool call index
+	nextToolID  int
+}
+
+// translateEvent converts a single Anthropic event payload
+func (s *anthropicStream) translateEvent(data []byte) {
+	var event struct {
+		Type    string `json:"type"`
+		Index   int    `json:"index"`
+		Message struct {
+			ID    string `json:"id"`
+			Model string `json:"model"`
+			Usage struct {
+				InputTokens int `json:"input_tokens"`
+			} `json:"usage"`
+		} `json:"message"`
+		ContentBlock struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"content_block"`
+		Delta struct {
+			Type        string `json:"type"`
+			Text        string `json:"text"`
+			PartialJSON string `json:"partial_json"`
+			StopReason  string `json:"stop_reason"`
+		} `json:"delta"`
+		Usage struct {
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+		Error map[string]interface{} `json:"error"`
+	}
+	if err := codec.Unmarshal(data, &event); err != nil {
+		return
+	}
+
+	switch event.Type {
+	case "message_start":
+		s.id = event.Message.ID
+		s.model = event.Message.Model
+		s.inputTokens = event.Message.Usage.InputTokens
+		s.writeChunk(map[string]interface{}{"role": "assistant", "content": ""}, nil, nil)
+	case "content_block_start":
+		if event.ContentBlock.Type == "tool_use" {
+			toolIndex := s.nextToolID
+			s.toolIndex[event.Index] = toolIndex
+			s.nextToolID++
+			s.writeChunk(map[string]interface{}{"tool_calls": []interface{}{map[string]interface{}{
+				"index":    toolIndex,
+				"id":       event.ContentBlock.ID,
+				"type":     "function",
+				"function": map[string]interface{}{"name": event.ContentBlock.Name, "arguments": ""},
+			}}}, nil, nil)
+		}
+	case "content_block_delta":
+		switch event.Delta.Type {
+		case "text_delta":
+			s.writeChunk(map[string]interface{}{"content": event.Delta.Text}, nil, nil)
+		case "input_json_delta":
+			s.writeChunk(map[string]interface{}{"tool_calls": []interface{}{map[string]interface{}{
+				"index":    s.toolIndex[event.Index],
+				"function": map[string]interface{}{"arguments": event.Delta.PartialJSON},
+			}}}, nil, nil)
+		}
+	case "message_delta":
+		finishReason := anthropicFinishReason(event.Delta.StopReason)
+		usage := map[string]interface{}{
+			"prompt_tokens":     s.inputTokens,
+			"completion_tokens": event.Usage.OutputTokens,
+			"total_tokens":      s.inputTokens + event.Usage.OutputTokens,
+		}
+		s.writeChunk(map[string]interface{}{}, finishReason, usage)
+	case "message_stop":
+		s.writeDone()
+	case "error":
+		s.writeError(event.Error)
+	}
+}
+
+// anthropicFinishReason maps a stop reason, defaulting to "stop" for unknown values
+func anthropicFinishReason(stopReason string) string {
+	if reason, ok := anthropicStopReasons[stopReason]; ok {
+		return reason
+	}
+	return "stop"
+}
+
+// contentText flattens message content (string or content parts) into plain text
+func contentText(content interface{}) string {
+	switch c := content.(type) {
+	case string:
+		return c
+	case []interface{}:
+		var parts []string
+		for _, part := range c {
+			if partMap, ok := part.(map[string]interface{}); ok {
+				if text, ok := partMap["text"].(string); ok {
+					parts = append(parts, text)
+				}
+			}
+		}
+		return strings.Join(parts, "\n")
+	default:
+		return ""
+	}
+}
+
+// anthropicContentBlocks converts OpenAI message content into Anthropic content blocks
+func anthropicContentBlocks(content interface{}) []interface{} {
+	switch c := content.(type) {
+	case string:
+		if c == "" {
+			return nil
+		}
+		return []interface{}{map[string]interface{}{"type": "text", "text": c}}
+	case []interface{}:
+		blocks := make([]interface{}, 0, len(c))
+		for _, part := range c {
+			partMap, ok := part.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch partMap["type"] {
+			case "text":
+				blocks = append(blocks, map[string]interface{}{"type": "text", "text": partMap["text"]})
+			case "image_url":
+				imageURL, _ := partMap["image_url"].(map[string]interface{})
+				url, _ := imageURL["url"].(string)
+				if block := anthropicImageBlock(url); block != nil {
+					blocks = append(blocks, block)
+				}
+			}
+		}
+		return blocks
+	default:
+		return nil
+	}
+}
+
+// anthropicImageBlock converts a data: or http(s) image URL into an image block
+func anthropicImageBlock(url string) map[string]interface{} {
+	if dataURL, ok := strings.CutPrefix(url, "data:"); ok {
+		mediaType, data, ok := strings.Cut(dataURL, ";base64,")
+		if !ok {
+			return nil
+		}
+		return map[string]interface{}{
+			"type":   "image",
+			"source": map[string]interface{}{"type": "base64", "media_type": mediaType, "data": data},
+		}
+	}
+	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		return map[string]interface{}{
+			"type":   "image",
+			"source": map[string]interface{}{"type": "url", "url": url},
+		}
+	}
+	return nil
+}
+
+// anthropicToolUseBlocks converts assistant tool_calls into tool_use blocks
+func anthropicToolUseBlocks(toolCalls interface{}) []interface{} {
+	calls, _ := toolCalls.([]interface{})
+	blocks := make([]interface{}, 0, len(calls))
+	for _, call := range calls {
+		callMap, ok := call.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		function, _ := callMap["function"].(map[string]interface{})
+		arguments, _ := function["arguments"].(string)
+
+		var input interface{} = map[string]interface{}{}
+		if arguments != "" {
+			if err := codec.Unmarshal([]byte(arguments), &input); err != nil {
+				input = map[string]interface{}{}
+			}
+		}
+
+		blocks = append(blocks, map[string]interface{}{
+			"type":  "tool_use",
+			"id":    callMap["id"],
+			"name":  function["name"],
+			"input": input,
+		})
+	}
+	return blocks
+}
+
+// anthropicTools converts OpenAI function tools into Anthropic tool definitions
+func anthropicTools(tools interface{}) []interface{} {
+	list, _ := tools.([]interface{})
+	result := make([]interface{}, 0, len(list))
+	for _, tool := range list {
+		toolMap, ok := tool.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		function, ok := toolMap["function"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		schema := function["parameters"]
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		definition := map[string]interface{}{
+			"name":         function["name"],
+			"input_schema": schema,
+		}
+		if description, ok := function["description"].(string); ok && description != "" {
+			definition["description"] = description
+		}
+		result = append(result, definition)
+	}
+	return result
+}
+
+// anthropicToolChoice converts an OpenAI tool_choice into Anthropic's form
+func anthropicToolChoice(toolChoice interface{}) map[string]interface{} {
+	switch choice := toolChoice.(type) {
+	case string:
+		switch choice {
+		case "auto":
+			return map[string]interface{}{"type": "auto"}
+		case "none":
+			return map[string]interface{}{"type": "none"}
+		case "required":
+			return map[string]interface{}{"type": "any"}
+		}
+	case map[string]interface{}:
+		if function, ok := choice["function"].(map[string]interface{}); ok {
+			return map[string]interface{}{"type": "tool", "name": function["name"]}
+		}
+	}
+	return nil
+}
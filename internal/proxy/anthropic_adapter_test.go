@@ -0,0 +1,210 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnthropicAdapter_TranslateRequest(t *testing.T) {
+	adapter := &AnthropicAdapter{defaultMaxTokens: 1024}
+	body := `{
+		"model": "claude-sonnet-4",
+		"stream": true,
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is this?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBOR"}}
+			]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":\"png\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "an image"},
+			{"role": "user", "content": "Thanks"}
+		],
+		"tools": [{"type": "function", "function": {"name": "lookup", "description": "Look up", "parameters": {"type": "object"}}}],
+		"tool_choice": "required",
+		"user": "user-1234"
+	}`
+
+	translated, err := adapter.TranslateRequest([]byte(body))
+	require.NoError(t, err)
+
+	expected := `{
+		"model": "claude-sonnet-4",
+		"max_tokens": 1024,
+		"stream": true,
+		"system": "Be brief.",
+		"messages": [
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is this?"},
+				{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "iVBOR"}}
+			]},
+			{"role": "assistant", "content": [
+				{"type": "tool_use", "id": "call_1", "name": "lookup", "input": {"q": "png"}}
+			]},
+			{"role": "user", "content": [
+				{"type": "tool_result", "tool_use_id": "call_1", "content": "an image"},
+				{"type": "text", "text": "Thanks"}
+			]}
+		],
+		"tools": [{"name": "lookup", "description": "Look up", "input_schema": {"type": "object"}}],
+		"tool_choice": {"type": "any"},
+		"metadata": {"user_id": "user-1234"}
+	}`
+	assert.JSONEq(t, expected, string(translated))
+}
+
+func TestAnthropicAdapter_DefaultMaxTokensReadOnFirstUse(t *testing.T) {
+	// Adapters are created at package init, before the .env file is loaded
+	adapter := NewAnthropicAdapter()
+	t.Setenv("ANTHROPIC_MAX_TOKENS", "2048")
+
+	translated, err := adapter.TranslateRequest([]byte(`{"model":"claude-sonnet-4","messages":[{"role":"user","content":"Hi"}]}`))
+	require.NoError(t, err)
+
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(translated, &request))
+	assert.Equal(t, float64(2048), request["max_tokens"])
+}
+
+func TestAnthropicAdapter_TranslateResponse(t *testing.T) {
+	adapter := &AnthropicAdapter{}
+	body := `{
+		"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-sonnet-4",
+		"content": [
+			{"type": "text", "text": "Checking."},
+			{"type": "tool_use", "id": "toolu_1", "name": "lookup", "input": {"q": "x"}}
+		],
+		"stop_reason": "tool_use",
+		"usage": {"input_tokens": 10, "output_tokens": 5}
+	}`
+
+	translated, err := adapter.TranslateResponse([]byte(body))
+	require.NoError(t, err)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(translated, &response))
+	assert.Equal(t, "chat.completion", response["object"])
+
+	choice := response["choices"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "tool_calls", choice["finish_reason"])
+	message := choice["message"].(map[string]interface{})
+	assert.Equal(t, "Checking.", message["content"])
+	toolCall := message["tool_calls"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "toolu_1", toolCall["id"])
+	assert.JSONEq(t, `{"name":"lookup","arguments":"{\"q\":\"x\"}"}`, mustJSON(t, toolCall["function"]))
+
+	assert.Equal(t, map[string]interface{}{"prompt_tokens": float64(10), "completion_tokens": float64(5), "total_tokens": float64(15)}, response["usage"])
+
+	// A successfully translated response passes the standard vendor validation
+	assert.NoError(t, NewResponseStandardizer().validateVendorResponse(translated, "anthropic"))
+}
+
+func TestAnthropicAdapter_TranslateStream(t *testing.T) {
+	events := strings.Join([]string{
+		"event: message_start",
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4","usage":{"input_tokens":7}}}`,
+		"",
+		"event: ping",
+		`data: {"type":"ping"}`,
+		"",
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		"",
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`,
+		"",
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup","input":{}}}`,
+		"",
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"q\":"}}`,
+		"",
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":3}}`,
+		"",
+		`data: {"type":"message_stop"}`,
+		"",
+	}, "\n")
+
+	output, err := io.ReadAll((&AnthropicAdapter{}).TranslateStream(strings.NewReader(events)))
+	require.NoError(t, err)
+
+	chunks := strings.Split(strings.TrimSpace(string(output)), "\n\n")
+	require.Len(t, chunks, 6)
+	assert.Contains(t, chunks[0], `"role":"assistant"`)
+	assert.Contains(t, chunks[1], `"content":"Hi"`)
+	assert.Contains(t, chunks[2], `"id":"toolu_1"`)
+	assert.Contains(t, chunks[3], `"arguments":"{\"q\":"`)
+	assert.Contains(t, chunks[4], `"finish_reason":"tool_calls"`)
+	assert.Contains(t, chunks[4], `"total_tokens":10`)
+	assert.Equal(t, "data: [DONE]", chunks[5])
+}
+
+// TestAnthropicAdapter_MaxTokensThroughProxy checks that the client's max_tokens survives
+// request validation and reaches the Messages API, rather than the adapter's default
+func TestAnthropicAdapter_MaxTokensThroughProxy(t *testing.T) {
+	t.Setenv("FAILOVER_MAX_HOPS", "0")
+
+	var received map[string]interface{}
+	vendorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/messages", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-sonnet-4",
+			"content": [{"type": "text", "text": "Hi"}],
+			"stop_reason": "end_turn",
+			"usage": {"input_tokens": 3, "output_tokens": 1}
+		}`))
+	}))
+	defer vendorServer.Close()
+
+	credentials := []config.Credential{{Platform: "anthropic", Type: "api-key", Value: "sk-ant"}}
+	models := []config.VendorModel{{Vendor: "anthropic", Model: "claude-sonnet-4"}}
+	mockSelector := &MockSelector{}
+	mockSelector.On("Select", credentials, models).Return(&selector.VendorSelection{
+		Vendor:     "anthropic",
+		Model:      "claude-sonnet-4",
+		Credential: credentials[0],
+	}, nil)
+	proxyHandler := NewProxyHandler(credentials, models, NewAPIClient(map[string]string{"anthropic": vendorServer.URL}), mockSelector)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(
+		`{"model":"claude","messages":[{"role":"user","content":"Hello"}],"max_tokens":50}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	proxyHandler.HandleChatCompletions(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NotNil(t, received)
+	assert.Equal(t, float64(50), received["max_tokens"])
+}
+
+func TestAdapterFor(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer client-key")
+
+	anthropic := adapterFor("anthropic")
+	assert.Equal(t, "https://api.anthropic.com/v1/messages", anthropic.Endpoint("https://api.anthropic.com/v1", "claude-sonnet-4", true))
+	require.NoError(t, anthropic.Authorize(req, config.Credential{Platform: "anthropic", Type: "api-key", Value: "sk-ant"}))
+	assert.Empty(t, req.Header.Get("Authorization"))
+	assert.Equal(t, "sk-ant", req.Header.Get("x-api-key"))
+	assert.Equal(t, anthropicVersion, req.Header.Get("anthropic-version"))
+
+	openai := adapterFor("openai")
+	assert.Equal(t, "https://api.openai.com/v1/chat/completions", openai.Endpoint("https://api.openai.com/v1", "gpt-4o", false))
+}
+
+func mustJSON(t *testing.T, value interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	return string(data)
+}
@@ -5,30 +5,27 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"strings"
 	"time"
 
+	"github.com/aashari/go-generative-api-router/internal/admission"
 	"github.com/aashari/go-generative-api-router/internal/logger"
 	"github.com/aashari/go-generative-api-router/internal/utils"
 )
 
 // AudioProcessor handles audio URL processing and conversion
 type AudioProcessor struct {
-	httpClient *http.Client
+	downloader *utils.Downloader
 	maxSize    int64
 }
 
 // NewAudioProcessor creates a new audio processor with default settings
 func NewAudioProcessor() *AudioProcessor {
 	return &AudioProcessor{
-		httpClient: &http.Client{
-			Timeout: 180 * time.Second, // Longer timeout for audio files
-		},
-		maxSize: 25 * 1024 * 1024, // 25MB limit for audio files
+		downloader: utils.NewDownloaderFromEnv(180 * time.Second), // Longer timeout for audio files
+		maxSize:    audioMaxBytes(),
 	}
 }
 
@@ -82,52 +79,15 @@ func (p *AudioProcessor) ProcessAudioURL(ctx context.Context, audioURL string, h
 	}, nil
 }
 
-// downloadAudio downloads audio from a URL with custom headers
+// downloadAudio downloads audio from a URL with custom headers, counting it against the
+// request's admission share
 func (p *AudioProcessor) downloadAudio(ctx context.Context, audioURL string, headers map[string]string) ([]byte, string, error) {
-	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, audioURL, nil)
+	data, contentType, err := p.downloader.Download(ctx, audioURL, headers, p.maxSize)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to create request: %w", err)
+		return nil, "", err
 	}
-
-	// Set user agent
-	req.Header.Set(utils.HeaderUserAgent, utils.ServiceName)
-
-	// Add custom headers if provided
-	if headers != nil {
-		for key, value := range headers {
-			req.Header.Set(key, value)
-		}
-	}
-
-	// Download the audio
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to download audio: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return nil, "", fmt.Errorf("failed to download audio: status %d", resp.StatusCode)
-	}
-
-	// Get content type
-	contentType := resp.Header.Get(utils.HeaderContentType)
-
-	// Read with size limit
-	limitedReader := io.LimitReader(resp.Body, p.maxSize)
-	audioData, err := io.ReadAll(limitedReader)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to read audio data: %w", err)
-	}
-
-	// Check if we hit the size limit
-	if int64(len(audioData)) >= p.maxSize {
-		return nil, "", fmt.Errorf("audio size exceeds limit of %d bytes", p.maxSize)
-	}
-
-	return audioData, contentType, nil
+	admission.Grow(ctx, int64(len(data)))
+	return data, contentType, nil
 }
 
 // determineOutputFormat determines the best output format based on input
@@ -295,7 +255,7 @@ func (p *AudioProcessor) generateAudioFailureMessage(err error, audioPosition, t
 	} else if strings.Contains(errorMsg, "status 404") {
 		baseMessage = "Respond naturally that the audio URL appears to be broken or the file has been moved/deleted (404 Not Found). Ask them to provide a valid audio URL."
 	} else if strings.Contains(errorMsg, "size exceeds limit") {
-		baseMessage = "Respond naturally that the audio file is too large to process (exceeds 25MB limit). Ask them to provide a smaller audio file or compress it before sharing."
+		baseMessage = fmt.Sprintf("Respond naturally that the audio file is too large to process (exceeds %s limit). Ask them to provide a smaller audio file or compress it before sharing.", formatSizeLimit(p.maxSize))
 	} else if strings.Contains(errorMsg, "timeout") || strings.Contains(errorMsg, "deadline exceeded") {
 		baseMessage = "Respond naturally that the audio file took too long to download. Suggest they try again later or provide an alternative audio file."
 	} else if strings.Contains(errorMsg, "ffmpeg") {
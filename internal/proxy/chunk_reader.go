@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+)
+
+// chunkReader is an io.Reader producing OpenAI SSE chunks from a vendor's own stream format
+// The source is read a line at a time, as server-sent events or newline-delimited JSON, and
+// the payload of each line is handed to translateEvent, which queues chunks with writeChunk
+// and calls writeDone once the vendor reports the stream complete
+type chunkReader struct {
+	source *bufio.Reader
+	// translateEvent converts the payload of one line, without any "data:" prefix
+	translateEvent func(data []byte)
+	// atEOF, when set, completes the stream of a vendor that ends it by closing the source;
+	// otherwise a source ending before writeDone reads as a stream cut short
+	atEOF func()
+	// id and model are sent on every chunk; model is left out when the vendor names none
+	id           string
+	model        string
+	pending      bytes.Buffer
+	done         bool
+	sourceFailed error
+}
+
+// newChunkReader translates the stream r with translateEvent into chunks carrying id
+func newChunkReader(r io.Reader, id string, translateEvent func(data []byte), atEOF func()) *chunkReader {
+	return &chunkReader{source: bufio.NewReader(r), id: id, translateEvent: translateEvent, atEOF: atEOF}
+}
+
+func (s *chunkReader) Read(p []byte) (int, error) {
+	for s.pending.Len() == 0 {
+		if s.done {
+			return 0, io.EOF
+		}
+		if s.sourceFailed != nil {
+			return 0, s.sourceFailed
+		}
+		s.readEvent()
+	}
+	return s.pending.Read(p)
+}
+
+// readEvent reads one line from the source and queues any translated output
+func (s *chunkReader) readEvent() {
+	line, err := s.source.ReadString('\n')
+	data := strings.TrimSpace(line)
+	if trimmed, ok := strings.CutPrefix(data, "data:"); ok {
+		data = strings.TrimSpace(trimmed)
+	}
+	if data != "" {
+		s.translateEvent([]byte(data))
+	}
+	switch {
+	case err == io.EOF && !s.done && s.atEOF != nil:
+		s.atEOF()
+	case err != nil:
+		s.sourceFailed = err
+	}
+}
+
+// writeChunk queues an OpenAI chat.completion.chunk event
+func (s *chunkReader) writeChunk(delta map[string]interface{}, finishReason interface{}, usage map[string]interface{}) {
+	chunk := map[string]interface{}{
+		"id":      s.id,
+		"object":  "chat.completion.chunk",
+		"created": time.Now().Unix(),
+		"choices": []interface{}{map[string]interface{}{
+			"index":         0,
+			"delta":         delta,
+			"finish_reason": finishReason,
+		}},
+	}
+	if s.model != "" {
+		chunk["model"] = s.model
+	}
+	if usage != nil {
+		chunk["usage"] = usage
+	}
+
+	payload, err := codec.Marshal(chunk)
+	if err != nil {
+		return
+	}
+	s.pending.WriteString("data: " + string(payload) + "\n\n")
+}
+
+// writeError queues an OpenAI error event carrying the vendor's error
+func (s *chunkReader) writeError(vendorError interface{}) {
+	if payload, err := codec.Marshal(map[string]interface{}{"error": vendorError}); err == nil {
+		s.pending.WriteString("data: " + string(payload) + "\n\n")
+	}
+}
+
+// writeDone queues [DONE] and ends the stream
+func (s *chunkReader) writeDone() {
+	s.pending.WriteString("data: [DONE]\n\n")
+	s.done = true
+}
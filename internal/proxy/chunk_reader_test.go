@@ -0,0 +1,123 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingReader returns its data, then err
+type failingReader struct {
+	data io.Reader
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+	if err == io.EOF {
+		return n, r.err
+	}
+	return n, err
+}
+
+func TestChunkReader(t *testing.T) {
+	tests := []struct {
+		name          string
+		source        io.Reader
+		completeAtEOF bool
+		expectedData  []string
+		expectedError error
+	}{
+		{
+			name:         "server-sent events completed by the vendor",
+			source:       strings.NewReader("data: one\n\ndata:two\n\ndata: stop\n\ndata: ignored\n"),
+			expectedData: []string{"one", "two", "stop", "[DONE]"},
+		},
+		{
+			name:         "newline-delimited JSON completed by the vendor",
+			source:       strings.NewReader("one\n\ntwo\nstop\n"),
+			expectedData: []string{"one", "two", "stop", "[DONE]"},
+		},
+		{
+			name:          "cut short",
+			source:        strings.NewReader("data: one\n\ndata: two"),
+			expectedData:  []string{"one", "two"},
+			expectedError: io.EOF,
+		},
+		{
+			name:          "completed when the source ends",
+			source:        strings.NewReader("data: one\n\ndata: two"),
+			completeAtEOF: true,
+			expectedData:  []string{"one", "two", "[DONE]"},
+		},
+		{
+			name:          "source failure",
+			source:        &failingReader{data: strings.NewReader("data: one\n"), err: io.ErrUnexpectedEOF},
+			completeAtEOF: true,
+			expectedData:  []string{"one"},
+			expectedError: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s *chunkReader
+			var atEOF func()
+			if tt.completeAtEOF {
+				atEOF = func() { s.writeDone() }
+			}
+			s = newChunkReader(tt.source, "chatcmpl-test", func(data []byte) {
+				// Each payload is echoed as the content of a chunk; "stop" completes the stream
+				if string(data) == "stop" {
+					s.writeChunk(map[string]interface{}{}, "stop", nil)
+					s.writeDone()
+					return
+				}
+				s.writeChunk(map[string]interface{}{"content": string(data)}, nil, nil)
+			}, atEOF)
+
+			output, err := io.ReadAll(s)
+			if tt.expectedError != nil && tt.expectedError != io.EOF {
+				require.True(t, errors.Is(err, tt.expectedError), "got %v", err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			var data []string
+			for _, event := range strings.Split(strings.TrimSpace(string(output)), "\n\n") {
+				payload := strings.TrimPrefix(event, "data: ")
+				switch chunk := parseChunk(t, payload); {
+				case chunk == nil:
+					data = append(data, payload)
+				case chunk["choices"].([]interface{})[0].(map[string]interface{})["finish_reason"] == "stop":
+					data = append(data, "stop")
+				default:
+					assert.Equal(t, "chatcmpl-test", chunk["id"])
+					assert.NotContains(t, chunk, "model", "no model is sent when the vendor names none")
+					delta := chunk["choices"].([]interface{})[0].(map[string]interface{})["delta"].(map[string]interface{})
+					data = append(data, delta["content"].(string))
+				}
+			}
+			assert.Equal(t, tt.expectedData, data)
+
+			// A stream cut short does not end with [DONE], so it reads as interrupted
+			assert.Equal(t, tt.expectedError == nil, strings.HasSuffix(string(output), "data: [DONE]\n\n"))
+		})
+	}
+}
+
+// parseChunk decodes a chunk payload, or returns nil for [DONE]
+func parseChunk(t *testing.T, payload string) map[string]interface{} {
+	t.Helper()
+	if payload == "[DONE]" {
+		return nil
+	}
+	var chunk map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(payload), &chunk))
+	return chunk
+}
@@ -0,0 +1,72 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+)
+
+// chunkRewriter is an io.Reader passing OpenAI-style SSE lines through, rewriting the
+// chunks that may need it. Lines that are not data lines are left untouched
+type chunkRewriter struct {
+	source       *bufio.Reader
+	needsRewrite func(data []byte) bool
+	rewrite      func(chunk map[string]interface{})
+	pending      bytes.Buffer
+	sourceFailed error
+}
+
+// newChunkRewriter rewrites the chunks of r for which needsRewrite, a cheap check on the
+// raw JSON, returns true
+func newChunkRewriter(r io.Reader, needsRewrite func(data []byte) bool, rewrite func(chunk map[string]interface{})) *chunkRewriter {
+	return &chunkRewriter{source: bufio.NewReader(r), needsRewrite: needsRewrite, rewrite: rewrite}
+}
+
+func (s *chunkRewriter) Read(p []byte) (int, error) {
+	for s.pending.Len() == 0 {
+		if s.sourceFailed != nil {
+			return 0, s.sourceFailed
+		}
+		s.readLine()
+	}
+	return s.pending.Read(p)
+}
+
+// readLine reads one line from the source and queues it, rewritten if needed
+func (s *chunkRewriter) readLine() {
+	line, err := s.source.ReadString('\n')
+	if err != nil {
+		s.sourceFailed = err
+	}
+	if line == "" {
+		return
+	}
+
+	data, ok := strings.CutPrefix(strings.TrimSpace(line), "data:")
+	if !ok || !s.needsRewrite([]byte(data)) {
+		// Some servers omit the space after "data:", which the stream processor expects
+		if ok && !strings.HasPrefix(line, "data: ") {
+			line = "data: " + strings.TrimSpace(data) + "\n"
+		}
+		s.pending.WriteString(line)
+		return
+	}
+
+	var chunk map[string]interface{}
+	if codec.Unmarshal([]byte(strings.TrimSpace(data)), &chunk) != nil {
+		s.pending.WriteString(line)
+		return
+	}
+	s.rewrite(chunk)
+	translated, marshalErr := codec.Marshal(chunk)
+	if marshalErr != nil {
+		s.pending.WriteString(line)
+		return
+	}
+	s.pending.WriteString("data: ")
+	s.pending.Write(translated)
+	s.pending.WriteString("\n")
+}
@@ -5,7 +5,6 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -14,9 +13,15 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aashari/go-generative-api-router/internal/codec"
 	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/events"
 	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/quarantine"
+	"github.com/aashari/go-generative-api-router/internal/ratelimit"
 	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/tokenizer"
 	"github.com/aashari/go-generative-api-router/internal/utils"
 )
 
@@ -50,24 +55,37 @@ func NewResponseStandardizer() *ResponseStandardizer {
 
 // APIClient handles communication with vendor APIs
 type APIClient struct {
-	BaseURLs     map[string]string
+	// config holds the vendor base URLs; requests use those of its current snapshot
+	config       *config.Store
 	httpClient   *http.Client
 	standardizer *ResponseStandardizer
+	streamLimits StreamLimits
+	backpressure StreamBackpressure
+	timeouts     Timeouts
 }
 
-// NewAPIClient creates a new API client with configured base URLs
+// NewAPIClient creates a new API client sending requests to fixed vendor base URLs
 func NewAPIClient(vendors map[string]string) *APIClient {
-	// Configure client timeout from environment variable
-	// Default to 1200 seconds (20 minutes) to allow for longer AI model responses
-	// This prevents 120-second timeouts that can occur with complex requests
-	clientTimeout := utils.GetEnvDuration("CLIENT_TIMEOUT", 1200*time.Second)
+	return NewAPIClientForStore(config.NewStore(config.NewSnapshot(config.Data{Vendors: vendors})))
+}
+
+// NewAPIClientForStore creates a new API client sending requests to the vendor base URLs of
+// the store's current snapshot, so a published configuration applies to the next request
+func NewAPIClientForStore(store *config.Store) *APIClient {
+	// Vendor requests are bounded by connect, first byte and total timeouts, which models
+	// and requests may override; the total defaults to 1200 seconds (20 minutes) to allow
+	// for longer AI model responses
+	timeouts := TimeoutsFromEnv()
 
 	httpClient := &http.Client{
-		Timeout: clientTimeout,
+		Transport: newTimeoutTransport(timeouts),
 	}
 
+	vendors := store.Snapshot().Vendors()
 	logger.Info(context.Background(), "API client initialized",
-		"client_timeout", clientTimeout,
+		"client_connect_timeout", timeouts.Connect,
+		"client_first_byte_timeout", timeouts.FirstByte,
+		"client_timeout", timeouts.Total,
 		"openai_base_url", vendors["openai"],
 		"gemini_base_url", vendors["gemini"],
 		"component", "APIClient",
@@ -75,9 +93,12 @@ func NewAPIClient(vendors map[string]string) *APIClient {
 	)
 
 	return &APIClient{
-		BaseURLs:     vendors,
+		config:       store,
 		httpClient:   httpClient,
 		standardizer: NewResponseStandardizer(),
+		streamLimits: StreamLimitsFromEnv(),
+		backpressure: StreamBackpressureFromEnv(),
+		timeouts:     timeouts,
 	}
 }
 
@@ -90,11 +111,6 @@ func (c *APIClient) SendRequest(w http.ResponseWriter, r *http.Request, selectio
 	}
 
 	// Log complete vendor request data before sending - including full credential and model objects
-	var vendorBodyForLog interface{}
-	if err := json.Unmarshal(modifiedBody, &vendorBodyForLog); err != nil {
-		vendorBodyForLog = string(modifiedBody)
-	}
-
 	// Get complete model object from context if available
 	var completeModelObject interface{}
 	if vendorModels := r.Context().Value("vendor_models"); vendorModels != nil {
@@ -125,7 +141,7 @@ func (c *APIClient) SendRequest(w http.ResponseWriter, r *http.Request, selectio
 		"vendor_method", req.Method,
 		"vendor_url", req.URL.String(),
 		"vendor_headers", map[string][]string(req.Header),
-		"vendor_body", vendorBodyForLog,
+		"vendor_body", logger.RequestBody(modifiedBody),
 		"client_method", r.Method,
 		"client_path", r.URL.Path,
 		"client_headers", map[string][]string(r.Header),
@@ -135,25 +151,58 @@ func (c *APIClient) SendRequest(w http.ResponseWriter, r *http.Request, selectio
 	)
 
 	// 2. Send request to vendor
+	monitoring.DefaultPayloadSizeMetrics().RecordRequest(selection.Vendor, modifiedBody)
 	startTime := time.Now()
-	resp, err := c.httpClient.Do(req)
+	// The request counts against the credential's concurrency cap until its response is handled
+	defer ratelimit.DefaultInFlight().Acquire(selection.Credential)()
+	resp, err := c.httpClientFor(selection.Vendor).Do(req)
 	duration := time.Since(startTime)
+	monitoring.ServerTimingFromContext(r.Context()).Add(monitoring.TimingVendor, duration)
 
+	responded := events.Event{Type: events.VendorResponded, OriginalModel: originalModel, Vendor: selection.Vendor, Model: selection.Model, Duration: duration}
 	if err != nil {
+		responded.Error = err.Error()
+	} else {
+		responded.StatusCode = resp.StatusCode
+	}
+	publishEvent(r, responded)
+
+	if err != nil {
+		// The client went away or its deadline passed: the vendor call was aborted with it
+		if ctxErr := requestContextError(r.Context()); ctxErr != nil {
+			return ctxErr
+		}
 		logger.Error(r.Context(), "vendor communication failed", err,
 			"vendor", selection.Vendor,
 			"url", req.URL.String(),
-			"request_body", string(modifiedBody),
+			"request_body", logger.RequestBody(modifiedBody),
 			"request_headers", map[string][]string(req.Header),
 			"complete_credential_object", selection.Credential, // Full credential object in error logs too
 			"complete_model_object", completeModelObject, // Full model object in error logs too
 			"component", "APIClient",
 			"stage", "VendorCommunication",
 		)
+		var timeoutErr *TimeoutError
+		if errors.As(err, &timeoutErr) {
+			return &VendorAPIError{
+				Vendor:     selection.Vendor,
+				StatusCode: http.StatusGatewayTimeout,
+				ErrorType:  "timeout",
+				Message:    timeoutErr.Error(),
+				Retriable:  true,
+			}
+		}
 		return fmt.Errorf("failed to send request to vendor: %v", err)
 	}
 	defer resp.Body.Close()
 
+	observeRateLimit(r, selection, resp)
+	quarantine.Default().Observe(selection.Credential, resp.StatusCode)
+
+	// Measure response sizes on the wire and after decompression for payload metrics
+	sizes := trackResponseSize(selection.Vendor, &resp.Body, resp.Header.Get(utils.HeaderContentEncoding) == utils.AcceptEncodingGzip)
+	defer sizes.record()
+
 	// Check for HTTP error status codes and parse vendor errors
 	if resp.StatusCode >= 400 {
 		// Read response body for error parsing
@@ -170,6 +219,7 @@ func (c *APIClient) SendRequest(w http.ResponseWriter, r *http.Request, selectio
 			// Create a generic error if we can't read the response
 			return ParseVendorError(selection.Vendor, resp.StatusCode, nil)
 		}
+		sizes.uncompressed = int64(len(errorBody))
 
 		// Database logging removed - no longer logging vendor requests
 
@@ -212,37 +262,88 @@ func (c *APIClient) SendRequest(w http.ResponseWriter, r *http.Request, selectio
 
 	// 3. Handle response based on streaming mode
 	if isStreaming {
-		// Setup headers for streaming and handle streaming response
-		c.setupResponseHeadersWithVendor(w, resp, isStreaming, selection.Vendor)
-		return c.handleStreaming(w, r, resp, selection, originalModel, duration, modifiedBody)
+		// Setup headers for streaming and handle streaming response; a stream restarted on
+		// this vendor continues one whose headers the client already has
+		if state := streamStateFromContext(r.Context()); state == nil || !state.headersSent {
+			c.setupResponseHeadersWithVendor(w, resp, isStreaming, selection.Vendor)
+			setRouterHeaders(r, w.Header())
+			if state != nil {
+				state.headersSent = true
+			}
+		}
+		return c.handleStreaming(w, r, resp, selection, originalModel, duration, modifiedBody, sizes)
 	} else {
 		// For non-streaming, we need to process the response first to determine compression
-		return c.handleNonStreamingWithHeaders(w, r, resp, selection, originalModel, duration, modifiedBody)
+		return c.handleNonStreamingWithHeaders(w, r, resp, selection, originalModel, duration, modifiedBody, sizes)
+	}
+}
+
+// observeRateLimit records the rate-limit quota a vendor reported for the credential and
+// remembers credentials that are throttled or nearly exhausted, so later requests are routed
+// around them. Vendors whose adapters do not read rate limits themselves are read as
+// sending OpenAI-style x-ratelimit-* and retry-after headers
+func observeRateLimit(r *http.Request, selection *selector.VendorSelection, resp *http.Response) {
+	now := time.Now()
+	var until time.Time
+	if reporter, ok := adapterFor(selection.Vendor).(RateLimitReporter); ok {
+		until = reporter.RateLimitedUntil(resp.StatusCode, resp.Header, now)
+	} else {
+		until = ratelimit.ThrottledUntil(resp.StatusCode, resp.Header, now)
+	}
+	quota, reported := ratelimit.ParseQuota(resp.Header, now)
+	if reported {
+		ratelimit.Default().ObserveQuota(selection.Credential, quota)
+	}
+	ratelimit.Default().Observe(selection.Credential, until)
+	if !until.IsZero() {
+		logger.Warn(r.Context(), "Vendor credential rate limited",
+			"vendor", selection.Vendor,
+			"status_code", resp.StatusCode,
+			"throttled_until", until,
+			"quota", quota,
+			"component", "APIClient",
+			"stage", "RateLimitTracking",
+		)
 	}
 }
 
+// httpClientFor returns the vendor adapter's own HTTP client, or the shared client
+func (c *APIClient) httpClientFor(vendor string) *http.Client {
+	if provider, ok := adapterFor(vendor).(HTTPClientProvider); ok {
+		return provider.HTTPClient()
+	}
+	return c.httpClient
+}
+
 // setupRequest prepares the HTTP request for the vendor API
 func (c *APIClient) setupRequest(r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) (*http.Request, bool, error) {
-	baseURL, ok := c.BaseURLs[selection.Vendor]
-	if !ok {
-		return nil, false, fmt.Errorf("%w: %s", ErrUnknownVendor, selection.Vendor)
+	baseURL, err := c.baseURLFor(selection)
+	if err != nil {
+		return nil, false, err
 	}
 
 	// Check if this is a streaming request
 	isStreaming := false
 	var requestData map[string]interface{}
-	if err := json.Unmarshal(modifiedBody, &requestData); err == nil {
+	if err := codec.Unmarshal(modifiedBody, &requestData); err == nil {
 		if stream, ok := requestData["stream"].(bool); ok && stream {
 			isStreaming = true
 			// Note: Streaming initiation is logged by the proxy layer with request context
 		}
 	}
 
-	// All vendors use the same OpenAI-compatible endpoint
-	fullURL := baseURL + "/chat/completions"
+	// Vendors without an OpenAI-compatible endpoint are reached through an adapter
+	adapter := adapterFor(selection.Vendor)
+	vendorBody, err := adapter.TranslateRequest(modifiedBody)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to translate request for %s: %w", selection.Vendor, err)
+	}
+	fullURL := adapter.Endpoint(baseURL, selection.Model, isStreaming)
 
-	// Create the proxied request
-	req, err := http.NewRequest(r.Method, fullURL, bytes.NewReader(modifiedBody))
+	// Create the proxied request, bounded by the model's timeouts and the request's own, and
+	// derived from the client's request so it is aborted when the client disconnects
+	ctx := withTimeouts(r.Context(), c.timeoutsFor(r, selection))
+	req, err := http.NewRequestWithContext(ctx, r.Method, fullURL, bytes.NewReader(vendorBody))
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -254,15 +355,61 @@ func (c *APIClient) setupRequest(r *http.Request, selection *selector.VendorSele
 		}
 	}
 
+	req.Header.Del(HeaderRequestTimeout)
+
 	// Enable gzip compression for vendor requests to reduce bandwidth and improve performance
 	req.Header.Set(utils.HeaderAcceptEncoding, utils.AcceptEncodingGzip)
 
-	// Set authorization header using Bearer token for all vendors
-	req.Header.Set(utils.HeaderAuthorization, "Bearer "+selection.Credential.Value)
+	if err := authorizeRequest(req, adapter, selection); err != nil {
+		return nil, false, err
+	}
 
 	return req, isStreaming, nil
 }
 
+// timeoutsFor returns the timeouts of a vendor request: the defaults, overridden by those
+// of the selected model, and shortened by the request's X-Request-Timeout
+func (c *APIClient) timeoutsFor(r *http.Request, selection *selector.VendorSelection) Timeouts {
+	timeouts := c.timeouts
+	if models, ok := r.Context().Value("vendor_models").([]config.VendorModel); ok {
+		for i := range models {
+			if models[i].Vendor == selection.Vendor && models[i].Model == selection.Model {
+				timeouts = timeouts.ForModel(&models[i])
+				break
+			}
+		}
+	}
+	return timeouts.Shorten(requestTimeoutFromContext(r.Context()))
+}
+
+// baseURLFor returns the base URL requests for the selection are sent to
+// Models with their own base URL override the vendor's
+func (c *APIClient) baseURLFor(selection *selector.VendorSelection) (string, error) {
+	if baseURL := strings.TrimRight(selection.BaseURL, "/"); baseURL != "" {
+		return baseURL, nil
+	}
+	baseURL, ok := c.config.Snapshot().BaseURL(selection.Vendor)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownVendor, selection.Vendor)
+	}
+	return baseURL, nil
+}
+
+// authorizeRequest sets the vendor's authentication headers (Bearer token for
+// OpenAI-compatible vendors), or the model's own auth header when it configures one
+func authorizeRequest(req *http.Request, adapter VendorAdapter, selection *selector.VendorSelection) error {
+	authorize := adapter.Authorize
+	if selection.AuthHeader != "" {
+		authorize = func(req *http.Request, credential config.Credential) error {
+			return applyAuthHeader(req, selection.AuthHeader, credential)
+		}
+	}
+	if err := authorize(req, selection.Credential); err != nil {
+		return fmt.Errorf("failed to authorize request for %s: %w", selection.Vendor, err)
+	}
+	return nil
+}
+
 // setupResponseHeadersWithVendor sets up response headers with vendor awareness
 func (c *APIClient) setupResponseHeadersWithVendor(w http.ResponseWriter, resp *http.Response, isStreaming bool, vendor string) {
 	// Set base compliant headers (content-length=0 for streaming to prevent it being set)
@@ -289,6 +436,8 @@ func (c *APIClient) setupResponseHeadersWithVendor(w http.ResponseWriter, resp *
 		w.Header().Set(utils.HeaderTransferEncoding, utils.TransferEncodingChunked)
 		// Set X-Accel-Buffering to no to prevent nginx from buffering
 		w.Header().Set(utils.HeaderXAccelBuffering, utils.XAccelBufferingNo)
+		// The cost of a stream is only known once it ends
+		announceCostTrailer(w)
 		// Log complete streaming headers setup
 		logger.Info(context.Background(), "Set streaming headers with complete data",
 			"vendor", vendor,
@@ -316,7 +465,7 @@ func (c *APIClient) setupResponseHeadersWithVendor(w http.ResponseWriter, resp *
 }
 
 // handleStreaming processes streaming responses
-func (c *APIClient) handleStreaming(w http.ResponseWriter, r *http.Request, resp *http.Response, selection *selector.VendorSelection, originalModel string, duration time.Duration, modifiedBody []byte) error {
+func (c *APIClient) handleStreaming(w http.ResponseWriter, r *http.Request, resp *http.Response, selection *selector.VendorSelection, originalModel string, duration time.Duration, modifiedBody []byte, sizes *responseSizeTracker) error {
 	// Get complete model object from context if available
 	var completeModelObject interface{}
 	if vendorModels := r.Context().Value("vendor_models"); vendorModels != nil {
@@ -346,10 +495,9 @@ func (c *APIClient) handleStreaming(w http.ResponseWriter, r *http.Request, resp
 		"stage", "StreamingProcessingStart",
 	)
 
-	// Generate consistent conversation-level values for streaming responses
-	conversationID := utils.GenerateChatCompletionID()
-	timestamp := time.Now().Unix()
-	systemFingerprint := utils.GenerateSystemFingerprint()
+	// Generate consistent conversation-level values for streaming responses, kept when the
+	// stream is restarted on another vendor
+	conversationID, timestamp, systemFingerprint := streamIdentity(r.Context())
 	// Log complete streaming values generation
 	logger.Info(r.Context(), "Generated streaming values with complete data",
 		"conversation_id", conversationID,
@@ -364,8 +512,35 @@ func (c *APIClient) handleStreaming(w http.ResponseWriter, r *http.Request, resp
 		"stage", "StreamingValuesGeneration",
 	)
 
+	defer monitoring.DefaultActiveStreams().Start(selection.Vendor)()
+
 	// Create stream processor
 	streamProcessor := NewStreamProcessor(conversationID, timestamp, systemFingerprint, selection.Vendor, originalModel)
+	streamProcessor.Tokenizer = tokenizer.Default().ForModel(selection.Vendor, selection.Model)
+	info := newReproducibility(modifiedBody, selection.Vendor, selection.Model)
+	if info != nil {
+		streamProcessor.Reproducibility = info
+		defer recordFingerprint(r.Context(), info)
+	}
+	streamProcessor.Extensions = responseExtensions(r, selection.Vendor, selection.Model, info, func() *Watermark {
+		return &Watermark{ResponseID: conversationID, Timestamp: timestamp}
+	})
+	streamProcessor.ReasoningPolicy = reasoningPolicy(r)
+	streamProcessor.Summary = newStreamSummary(r, selection, conversationID, originalModel, duration, modifiedBody)
+	started := requestStarted(r, duration)
+	streamProcessor.OnFirstWrite = func() {
+		monitoring.DefaultPrometheusMetrics().ObserveStreamTTFB(selection.Vendor, selection.Model, time.Since(started))
+	}
+	defer func() {
+		promptTokens, completionTokens, estimated := streamProcessor.Usage(modifiedBody)
+		recordUsage(r.Context(), promptTokens, completionTokens)
+		if estimated {
+			markUsageEstimated(r.Context())
+		}
+		recordStreamMetrics(r.Context(), streamProcessor, selection, started, completionTokens)
+		// Sent as the trailer announced with the headers
+		setCostHeader(r.Context(), w.Header())
+	}()
 
 	// Get content encoding for gzip handling
 	contentEncoding := resp.Header.Get(utils.HeaderContentEncoding)
@@ -385,9 +560,14 @@ func (c *APIClient) handleStreaming(w http.ResponseWriter, r *http.Request, resp
 			return fmt.Errorf("failed to decompress streaming response: %v", err)
 		}
 		defer gzipReader.Close()
-		reader = gzipReader
+		decoded := &countingReader{Reader: gzipReader}
+		defer func() { sizes.uncompressed = decoded.n }()
+		reader = decoded
 	}
 
+	// Convert native vendor events into OpenAI chunks
+	reader = adapterFor(selection.Vendor).TranslateStream(reader)
+
 	// Create buffered reader for line-by-line processing
 	bufReader := bufio.NewReader(reader)
 
@@ -404,8 +584,25 @@ func (c *APIClient) handleStreaming(w http.ResponseWriter, r *http.Request, resp
 		return fmt.Errorf("streaming not supported")
 	}
 
+	// Enforce stream guardrails, if configured
+	var guard *streamGuard
+	if c.streamLimits.Enabled() {
+		guard = newStreamGuard(c.streamLimits, streamProcessor, modifiedBody, resp.Body)
+		defer guard.stop()
+	}
+
+	// Write through a bounded buffer with per-chunk deadlines, if configured
+	if c.backpressure.Enabled() {
+		sw := newStreamWriter(r.Context(), w, flusher, c.backpressure, selection.Vendor)
+		err := c.processStreamingResponse(sw, bufReader, streamProcessor, sw, guard)
+		if closeErr := sw.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("error writing chunk: %w", closeErr)
+		}
+		return err
+	}
+
 	// Process the streaming response
-	return c.processStreamingResponse(w, bufReader, streamProcessor, flusher)
+	return c.processStreamingResponse(w, bufReader, streamProcessor, flusher, guard)
 }
 
 // validateVendorResponse validates JSON responses from vendors
@@ -444,7 +641,7 @@ func (s *ResponseStandardizer) validateVendorResponse(body []byte, vendor string
 	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
 		// Handle array response (common for error responses from some vendors)
 		var arrayResponse []interface{}
-		if err := json.Unmarshal(body, &arrayResponse); err != nil {
+		if err := codec.Unmarshal(body, &arrayResponse); err != nil {
 			// Log complete JSON parsing error for array
 			logger.Error(context.Background(), "JSON parsing error for array response", err,
 				"vendor", vendor,
@@ -490,7 +687,7 @@ func (s *ResponseStandardizer) validateVendorResponse(body []byte, vendor string
 	}
 
 	// Handle object response (normal case)
-	if err = json.Unmarshal(body, &responseData); err != nil {
+	if err = codec.Unmarshal(body, &responseData); err != nil {
 		// Log complete JSON parsing error for object
 		logger.Error(context.Background(), "JSON parsing error for object response", err,
 			"vendor", vendor,
@@ -748,19 +945,26 @@ func (s *ResponseStandardizer) compressResponseMandatory(body []byte) ([]byte, e
 }
 
 // processStreamingResponse handles streaming SSE responses
-func (c *APIClient) processStreamingResponse(w http.ResponseWriter, reader *bufio.Reader, streamProcessor *StreamProcessor, flusher http.Flusher) error {
+func (c *APIClient) processStreamingResponse(w http.ResponseWriter, reader *bufio.Reader, streamProcessor *StreamProcessor, flusher http.Flusher, guard *streamGuard) error {
 	for {
 		// Read the "data: " line
 		line, err := reader.ReadString('\n')
 		if err != nil {
+			// A read failing because the duration guardrail closed the upstream is a cutoff, not an error
+			if reason := guard.cutoffReason(streamProcessor); reason != "" {
+				return c.cutStream(w, streamProcessor, flusher, guard, reason)
+			}
+			// The vendor stream ended or failed before [DONE]
 			if err == io.EOF {
-				return nil
+				err = io.ErrUnexpectedEOF
 			}
 			logger.Error(context.Background(), "Error reading stream", err,
+				"vendor", streamProcessor.Vendor,
+				"content_sent", streamProcessor.ContentSent(),
 				"component", "APIClient",
 				"stage", "StreamReading",
 			)
-			return fmt.Errorf("error reading stream: %w", err)
+			return &StreamInterruptedError{Vendor: streamProcessor.Vendor, ContentSent: streamProcessor.ContentSent(), Err: err}
 		}
 
 		// Check for [DONE] message
@@ -770,7 +974,10 @@ func (c *APIClient) processStreamingResponse(w http.ResponseWriter, reader *bufi
 			if flusher != nil {
 				flusher.Flush()
 			}
-			return err
+			if err != nil {
+				return err
+			}
+			return writeStreamSummary(w, streamProcessor, flusher)
 		}
 
 		// Process the chunk
@@ -810,6 +1017,11 @@ func (c *APIClient) processStreamingResponse(w http.ResponseWriter, reader *bufi
 		if flusher != nil {
 			flusher.Flush()
 		}
+		streamProcessor.chunkWritten()
+
+		if reason := guard.cutoffReason(streamProcessor); reason != "" {
+			return c.cutStream(w, streamProcessor, flusher, guard, reason)
+		}
 
 		// Some SSE implementations have an extra newline after data
 		if !strings.HasSuffix(line, "\n\n") {
@@ -827,7 +1039,7 @@ func (c *APIClient) processStreamingResponse(w http.ResponseWriter, reader *bufi
 // Database logging functionality has been removed
 
 // handleNonStreamingWithHeaders processes non-streaming responses
-func (c *APIClient) handleNonStreamingWithHeaders(w http.ResponseWriter, r *http.Request, resp *http.Response, selection *selector.VendorSelection, originalModel string, duration time.Duration, modifiedBody []byte) error {
+func (c *APIClient) handleNonStreamingWithHeaders(w http.ResponseWriter, r *http.Request, resp *http.Response, selection *selector.VendorSelection, originalModel string, duration time.Duration, modifiedBody []byte, sizes *responseSizeTracker) error {
 	logger.Info(r.Context(), "Processing non-streaming request",
 		"vendor", selection.Vendor,
 		"component", "APIClient",
@@ -859,10 +1071,22 @@ func (c *APIClient) handleNonStreamingWithHeaders(w http.ResponseWriter, r *http
 		)
 		return err
 	}
+	sizes.uncompressed = int64(len(responseBody))
+
+	// Convert native vendor responses into an OpenAI chat completion
+	responseBody, err = adapterFor(selection.Vendor).TranslateResponse(responseBody)
+	if err != nil {
+		logger.Error(r.Context(), "Error translating vendor response", err,
+			"vendor", selection.Vendor,
+			"component", "APIClient",
+			"stage", "ResponseTranslation",
+		)
+		return &VendorValidationError{Vendor: selection.Vendor, OriginalErr: err}
+	}
 
 	// Log complete vendor response body immediately after processing
 	var vendorResponseBodyForLog interface{}
-	if err := json.Unmarshal(responseBody, &vendorResponseBodyForLog); err != nil {
+	if err := codec.Unmarshal(responseBody, &vendorResponseBodyForLog); err != nil {
 		vendorResponseBodyForLog = string(responseBody)
 	}
 
@@ -923,6 +1147,18 @@ func (c *APIClient) handleNonStreamingWithHeaders(w http.ResponseWriter, r *http
 		}
 	}
 
+	if detector := monitoring.DefaultResponseAnomalyDetector(); detector != nil {
+		if detector.Observe(selection.Vendor, modifiedBody, responseBody) {
+			logger.Warn(r.Context(), "Vendor returned an identical response for a different prompt",
+				"vendor", selection.Vendor,
+				"model", selection.Model,
+				"response_size_bytes", len(responseBody),
+				"component", "APIClient",
+				"stage", "ResponseAnomalyDetection",
+			)
+		}
+	}
+
 	// 3. Process response (replace model, format, etc.)
 	modifiedResponse, err := ProcessResponse(responseBody, selection.Vendor, resp.Header.Get(utils.HeaderContentEncoding), originalModel)
 	if err != nil {
@@ -936,6 +1172,40 @@ func (c *APIClient) handleNonStreamingWithHeaders(w http.ResponseWriter, r *http
 		return err
 	}
 
+	modifiedResponse, err = ApplyReasoningPolicy(modifiedResponse, reasoningPolicy(r))
+	if err != nil {
+		logger.Error(r.Context(), "Error applying reasoning policy", err,
+			"vendor", selection.Vendor,
+			"component", "APIClient",
+			"stage", "ReasoningPolicy",
+		)
+		return err
+	}
+
+	promptTokens, completionTokens := responseUsage(modifiedResponse)
+	recordUsage(r.Context(), promptTokens, completionTokens)
+	setCostHeader(r.Context(), w.Header())
+
+	// Seeded requests record the vendor fingerprint so the generation can be re-run
+	info := newReproducibility(modifiedBody, selection.Vendor, selection.Model)
+	if info != nil {
+		info.SystemFingerprint = vendorFingerprint(vendorResponseBodyForLog)
+		recordFingerprint(r.Context(), info)
+	}
+	watermark := func() *Watermark { return responseWatermark(modifiedResponse) }
+	if extensions := responseExtensions(r, selection.Vendor, selection.Model, info, watermark); extensions != nil {
+		withExtensions, err := addExtensions(modifiedResponse, extensions)
+		if err != nil {
+			logger.Error(r.Context(), "Error adding response extensions", err,
+				"vendor", selection.Vendor,
+				"component", "APIClient",
+				"stage", "ResponseExtensions",
+			)
+		} else {
+			modifiedResponse = withExtensions
+		}
+	}
+
 	// 4. Determine compression
 	shouldCompress := c.standardizer.shouldCompress(r)
 	var finalResponse []byte
@@ -964,6 +1234,7 @@ func (c *APIClient) handleNonStreamingWithHeaders(w http.ResponseWriter, r *http
 
 	// 5. Set headers
 	c.standardizer.setCompliantHeaders(w, selection.Vendor, len(finalResponse), shouldCompress)
+	setRouterHeaders(r, w.Header())
 
 	// 6. Write the response
 	_, err = w.Write(finalResponse)
@@ -980,7 +1251,7 @@ func (c *APIClient) handleNonStreamingWithHeaders(w http.ResponseWriter, r *http
 
 	// Log complete final response sent to client
 	var finalResponseForLog interface{}
-	if err := json.Unmarshal(finalResponse, &finalResponseForLog); err != nil {
+	if err := codec.Unmarshal(finalResponse, &finalResponseForLog); err != nil {
 		finalResponseForLog = string(finalResponse)
 	}
 
@@ -1008,3 +1279,18 @@ func (c *APIClient) handleNonStreamingWithHeaders(w http.ResponseWriter, r *http
 
 	return nil
 }
+
+// cutStream ends a stream cut by a guardrail with a reconciled usage chunk
+func (c *APIClient) cutStream(w http.ResponseWriter, streamProcessor *StreamProcessor, flusher http.Flusher, guard *streamGuard, reason string) error {
+	logger.Warn(context.Background(), "Streaming response cut by guardrail",
+		"vendor", streamProcessor.Vendor,
+		"model", streamProcessor.OriginalModel,
+		"conversation_id", streamProcessor.ConversationID,
+		"cutoff_reason", reason,
+		"prompt_tokens_estimate", guard.promptTokens,
+		"completion_tokens_estimate", streamProcessor.CompletionTokens(),
+		"component", "APIClient",
+		"stage", "StreamCutoff",
+	)
+	return guard.writeCutoff(w, streamProcessor, flusher)
+}
@@ -0,0 +1,510 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// cohereFinishReasons maps Cohere finish reasons to OpenAI finish reasons
+var cohereFinishReasons = map[string]string{
+	"COMPLETE":      "stop",
+	"STOP_SEQUENCE": "stop",
+	"MAX_TOKENS":    "length",
+	"ERROR_LIMIT":   "length",
+	"ERROR_TOXIC":   "content_filter",
+	"ERROR":         "stop",
+	"USER_CANCEL":   "stop",
+}
+
+// cohereParameterTypes maps JSON schema types to Cohere parameter definition types
+var cohereParameterTypes = map[string]string{
+	"string":  "str",
+	"integer": "int",
+	"number":  "float",
+	"boolean": "bool",
+	"array":   "list",
+	"object":  "dict",
+}
+
+// CohereAdapter translates OpenAI chat completions to and from Cohere's Chat API
+type CohereAdapter struct{}
+
+func init() {
+	RegisterVendorAdapter("cohere", func() VendorAdapter { return NewCohereAdapter() })
+}
+
+// NewCohereAdapter creates a Cohere adapter
+func NewCohereAdapter() *CohereAdapter {
+	return &CohereAdapter{}
+}
+
+// Endpoint returns the Chat API URL
+func (a *CohereAdapter) Endpoint(baseURL, model string, streaming bool) string {
+	return baseURL + "/chat"
+}
+
+// Authorize sets the Bearer token
+func (a *CohereAdapter) Authorize(req *http.Request, credential config.Credential) error {
+	req.Header.Set(utils.HeaderAuthorization, "Bearer "+credential.Value)
+	return nil
+}
+
+// TranslateRequest converts an OpenAI chat completion request into a Chat API request
+// System messages become the preamble, the final user turn becomes message (or the
+// final tool results become tool_results) and earlier turns become chat_history
+func (a *CohereAdapter) TranslateRequest(body []byte) ([]byte, error) {
+	var request map[string]interface{}
+	if err := codec.Unmarshal(body, &request); err != nil {
+		return nil, fmt.Errorf("invalid request format: %w", err)
+	}
+
+	messages, _ := request["messages"].([]interface{})
+	var preamble []string
+	var history []map[string]interface{}
+	calls := make(map[string]map[string]interface{}) // tool call ID -> Cohere call
+
+	for _, raw := range messages {
+		message, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		switch message["role"] {
+		case "system", "developer":
+			if text := contentText(message["content"]); text != "" {
+				preamble = append(preamble, text)
+			}
+		case "assistant":
+			entry := map[string]interface{}{"role": "CHATBOT", "message": contentText(message["content"])}
+			if toolCalls := cohereToolCalls(message["tool_calls"], calls); len(toolCalls) > 0 {
+				entry["tool_calls"] = toolCalls
+			}
+			history = append(history, entry)
+		case "tool":
+			toolCallID, _ := message["tool_call_id"].(string)
+			result := map[string]interface{}{
+				"call":    calls[toolCallID],
+				"outputs": []interface{}{cohereToolOutput(message["content"])},
+			}
+			// Results answering the same assistant turn share one TOOL entry
+			if n := len(history); n > 0 && history[n-1]["role"] == "TOOL" {
+				history[n-1]["tool_results"] = append(history[n-1]["tool_results"].([]interface{}), result)
+				continue
+			}
+			history = append(history, map[string]interface{}{"role": "TOOL", "tool_results": []interface{}{result}})
+		default:
+			history = append(history, map[string]interface{}{"role": "USER", "message": contentText(message["content"])})
+		}
+	}
+
+	translated := map[string]interface{}{
+		"model":   request["model"],
+		"message": "",
+	}
+	if n := len(history); n > 0 {
+		switch last := history[n-1]; last["role"] {
+		case "USER":
+			translated["message"] = last["message"]
+			history = history[:n-1]
+		case "TOOL":
+			translated["tool_results"] = last["tool_results"]
+			history = history[:n-1]
+		}
+	}
+	if len(history) > 0 {
+		translated["chat_history"] = history
+	}
+	if len(preamble) > 0 {
+		translated["preamble"] = strings.Join(preamble, "\n\n")
+	}
+
+	for openAIName, cohereName := range map[string]string{
+		"temperature":       "temperature",
+		"max_tokens":        "max_tokens",
+		"top_p":             "p",
+		"seed":              "seed",
+		"frequency_penalty": "frequency_penalty",
+		"presence_penalty":  "presence_penalty",
+	} {
+		if value, ok := request[openAIName].(float64); ok {
+			translated[cohereName] = value
+		}
+	}
+	switch stop := request["stop"].(type) {
+	case string:
+		translated["stop_sequences"] = []interface{}{stop}
+	case []interface{}:
+		translated["stop_sequences"] = stop
+	}
+	if stream, ok := request["stream"].(bool); ok && stream {
+		translated["stream"] = true
+	}
+	if tools := cohereTools(request["tools"]); len(tools) > 0 {
+		translated["tools"] = tools
+		switch request["tool_choice"] {
+		case "required":
+			translated["tool_choice"] = "REQUIRED"
+		case "none":
+			translated["tool_choice"] = "NONE"
+		}
+	}
+
+	return codec.Marshal(translated)
+}
+
+// cohereResponse is the subset of a Chat API response (or stream-end response) the adapter reads
+type cohereResponse struct {
+	GenerationID string                   `json:"generation_id"`
+	Text         string                   `json:"text"`
+	FinishReason string                   `json:"finish_reason"`
+	ToolCalls    []cohereToolCall         `json:"tool_calls"`
+	Citations    []cohereCitation         `json:"citations"`
+	Documents    []map[string]interface{} `json:"documents"`
+	Meta         struct {
+		BilledUnits cohereTokens `json:"billed_units"`
+		Tokens      cohereTokens `json:"tokens"`
+	} `json:"meta"`
+}
+
+// cohereToolCall is a function call requested by the model
+type cohereToolCall struct {
+	Name       string      `json:"name"`
+	Parameters interface{} `json:"parameters"`
+}
+
+// cohereCitation marks the span of the response text supported by documents
+type cohereCitation struct {
+	Start       int      `json:"start"`
+	End         int      `json:"end"`
+	Text        string   `json:"text"`
+	DocumentIDs []string `json:"document_ids"`
+}
+
+// cohereTokens counts input and output tokens
+type cohereTokens struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// usage converts token counts to OpenAI usage, preferring the full counts over billed units
+func (r *cohereResponse) usage() map[string]interface{} {
+	tokens := r.Meta.Tokens
+	if tokens.InputTokens == 0 && tokens.OutputTokens == 0 {
+		tokens = r.Meta.BilledUnits
+	}
+	return map[string]interface{}{
+		"prompt_tokens":     tokens.InputTokens,
+		"completion_tokens": tokens.OutputTokens,
+		"total_tokens":      tokens.InputTokens + tokens.OutputTokens,
+	}
+}
+
+// TranslateResponse converts a Chat API response into an OpenAI chat completion
+// Citations are attached to the message with their documents' URL and title and are
+// turned into annotations by the response processor
+func (a *CohereAdapter) TranslateResponse(body []byte) ([]byte, error) {
+	var response cohereResponse
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	toolCalls, err := openAIToolCallsFromCohere(response.ToolCalls)
+	if err != nil {
+		return nil, err
+	}
+
+	message := map[string]interface{}{
+		"role":    "assistant",
+		"content": response.Text,
+	}
+	if len(toolCalls) > 0 {
+		message["tool_calls"] = toolCalls
+		if response.Text == "" {
+			message["content"] = nil
+		}
+	}
+	if citations := cohereCitations(response.Citations, response.Documents); len(citations) > 0 {
+		message["citations"] = citations
+	}
+
+	id := response.GenerationID
+	if id == "" {
+		id = utils.GenerateChatCompletionID()
+	}
+	return codec.Marshal(map[string]interface{}{
+		"id":      id,
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"choices": []interface{}{map[string]interface{}{
+			"index":         0,
+			"message":       message,
+			"finish_reason": cohereFinishReason(response.FinishReason, len(toolCalls) > 0),
+		}},
+		"usage": response.usage(),
+	})
+}
+
+// TranslateStream converts Chat API stream events into OpenAI chunk events
+func (a *CohereAdapter) TranslateStream(r io.Reader) io.Reader {
+	s := &cohereStream{}
+	s.chunkReader = newChunkReader(r, utils.GenerateChatCompletionID(), s.translateEvent, func() { s.writeDone() })
+	return s
+}
+
+// RerankEndpoint returns the Rerank API URL
+func (a *CohereAdapter) RerankEndpoint(baseURL string) string {
+	return baseURL + "/rerank"
+}
+
+// TranslateRerankRequest sends the request unchanged; the Rerank API takes the same fields
+func (a *CohereAdapter) TranslateRerankRequest(body []byte) ([]byte, error) {
+	return body, nil
+}
+
+// TranslateRerankResponse reports the billed search units as usage
+func (a *CohereAdapter) TranslateRerankResponse(body []byte) ([]byte, error) {
+	var response map[string]interface{}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	meta, _ := response["meta"].(map[string]interface{})
+	billed, _ := meta["billed_units"].(map[string]interface{})
+	if searchUnits, ok := billed["search_units"]; ok {
+		response["usage"] = map[string]interface{}{"search_units": searchUnits}
+	}
+	delete(response, "meta")
+	return codec.Marshal(response)
+}
+
+// cohereStream produces OpenAI SSE chunks from Cohere's newline-delimited stream events
+// Citations are collected and sent, resolved against the response documents, just before
+// the final chunk. The stream is complete at stream-end or when the source ends
+type cohereStream struct {
+	*chunkReader
+	nextToolID int
+	citations  []cohereCitation
+	documents  []map[string]interface{}
+}
+
+// translateEvent converts a single Cohere stream event
+func (s *cohereStream) translateEvent(data []byte) {
+	var event struct {
+		EventType    string                   `json:"event_type"`
+		GenerationID string                   `json:"generation_id"`
+		Text         string                   `json:"text"`
+		ToolCalls    []cohereToolCall         `json:"tool_calls"`
+		Citations    []cohereCitation         `json:"citations"`
+		Documents    []map[string]interface{} `json:"documents"`
+		FinishReason string                   `json:"finish_reason"`
+		Response     cohereResponse           `json:"response"`
+	}
+	if err := codec.Unmarshal(data, &event); err != nil {
+		return
+	}
+
+	switch event.EventType {
+	case "stream-start":
+		if event.GenerationID != "" {
+			s.id = event.GenerationID
+		}
+		s.writeChunk(map[string]interface{}{"role": "assistant", "content": ""}, nil, nil)
+	case "text-generation":
+		s.writeChunk(map[string]interface{}{"content": event.Text}, nil, nil)
+	case "tool-calls-generation":
+		toolCalls, err := openAIToolCallsFromCohere(event.ToolCalls)
+		if err != nil {
+			return
+		}
+		for _, call := range toolCalls {
+			call.(map[string]interface{})["index"] = s.nextToolID
+			s.nextToolID++
+			s.writeChunk(map[string]interface{}{"tool_calls": []interface{}{call}}, nil, nil)
+		}
+	case "citation-generation":
+		s.citations = append(s.citations, event.Citations...)
+	case "search-results":
+		s.documents = append(s.documents, event.Documents...)
+	case "stream-end":
+		documents := append(s.documents, event.Response.Documents...)
+		if citations := cohereCitations(s.citations, documents); len(citations) > 0 {
+			s.writeChunk(map[string]interface{}{"citations": citations}, nil, nil)
+		}
+		s.writeChunk(map[string]interface{}{}, cohereFinishReason(event.FinishReason, s.nextToolID > 0), event.Response.usage())
+		s.writeDone()
+	}
+}
+
+// cohereFinishReason maps a finish reason, reporting "tool_calls" when the model called tools
+func cohereFinishReason(finishReason string, calledTools bool) string {
+	reason, ok := cohereFinishReasons[finishReason]
+	if !ok {
+		reason = "stop"
+	}
+	if calledTools && reason == "stop" {
+		return "tool_calls"
+	}
+	return reason
+}
+
+// cohereToolCalls converts assistant tool_calls into Cohere calls, remembering each
+// call by ID so the matching tool result can name it
+func cohereToolCalls(toolCalls interface{}, calls map[string]map[string]interface{}) []interface{} {
+	list, _ := toolCalls.([]interface{})
+	result := make([]interface{}, 0, len(list))
+	for _, call := range list {
+		callMap, ok := call.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		function, _ := callMap["function"].(map[string]interface{})
+		arguments, _ := function["arguments"].(string)
+
+		var parameters interface{} = map[string]interface{}{}
+		if arguments != "" {
+			if err := codec.Unmarshal([]byte(arguments), &parameters); err != nil {
+				parameters = map[string]interface{}{}
+			}
+		}
+
+		cohereCall := map[string]interface{}{"name": function["name"], "parameters": parameters}
+		if id, ok := callMap["id"].(string); ok {
+			calls[id] = cohereCall
+		}
+		result = append(result, cohereCall)
+	}
+	return result
+}
+
+// cohereToolOutput wraps tool output as the object Cohere expects,
+// passing JSON objects through and wrapping anything else under "result"
+func cohereToolOutput(content interface{}) map[string]interface{} {
+	text := contentText(content)
+	var object map[string]interface{}
+	if err := codec.Unmarshal([]byte(text), &object); err == nil && object != nil {
+		return object
+	}
+	return map[string]interface{}{"result": text}
+}
+
+// cohereTools converts OpenAI function tools into Cohere tool definitions
+func cohereTools(tools interface{}) []interface{} {
+	list, _ := tools.([]interface{})
+	result := make([]interface{}, 0, len(list))
+	for _, tool := range list {
+		toolMap, ok := tool.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		function, ok := toolMap["function"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		description, _ := function["description"].(string)
+		definition := map[string]interface{}{
+			"name":        function["name"],
+			"description": description,
+		}
+		if parameters := cohereParameterDefinitions(function["parameters"]); len(parameters) > 0 {
+			definition["parameter_definitions"] = parameters
+		}
+		result = append(result, definition)
+	}
+	return result
+}
+
+// cohereParameterDefinitions flattens a JSON schema's top-level properties into Cohere parameter definitions
+func cohereParameterDefinitions(schema interface{}) map[string]interface{} {
+	schemaMap, _ := schema.(map[string]interface{})
+	properties, _ := schemaMap["properties"].(map[string]interface{})
+
+	required := make(map[string]bool)
+	if list, ok := schemaMap["required"].([]interface{}); ok {
+		for _, name := range list {
+			if nameStr, ok := name.(string); ok {
+				required[nameStr] = true
+			}
+		}
+	}
+
+	definitions := make(map[string]interface{}, len(properties))
+	for name, property := range properties {
+		propertyMap, _ := property.(map[string]interface{})
+		schemaType, _ := propertyMap["type"].(string)
+		cohereType, ok := cohereParameterTypes[schemaType]
+		if !ok {
+			cohereType = "str"
+		}
+
+		definition := map[string]interface{}{"type": cohereType, "required": required[name]}
+		if description, ok := propertyMap["description"].(string); ok && description != "" {
+			definition["description"] = description
+		}
+		definitions[name] = definition
+	}
+	return definitions
+}
+
+// openAIToolCallsFromCohere converts Cohere tool calls into OpenAI tool calls with generated IDs
+func openAIToolCallsFromCohere(toolCalls []cohereToolCall) ([]interface{}, error) {
+	result := make([]interface{}, 0, len(toolCalls))
+	for _, call := range toolCalls {
+		parameters := call.Parameters
+		if parameters == nil {
+			parameters = map[string]interface{}{}
+		}
+		arguments, err := codec.Marshal(parameters)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid tool call parameters: %v", ErrInvalidResponse, err)
+		}
+		result = append(result, map[string]interface{}{
+			"id":   utils.GenerateToolCallID(),
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":      call.Name,
+				"arguments": string(arguments),
+			},
+		})
+	}
+	return result, nil
+}
+
+// cohereCitations resolves citations against their documents, producing the vendor-neutral
+// citations normalizeCitations turns into OpenAI annotations
+func cohereCitations(citations []cohereCitation, documents []map[string]interface{}) []interface{} {
+	byID := make(map[string]map[string]interface{}, len(documents))
+	for _, document := range documents {
+		if id, ok := document["id"].(string); ok {
+			byID[id] = document
+		}
+	}
+
+	result := make([]interface{}, 0, len(citations))
+	for _, citation := range citations {
+		entry := map[string]interface{}{
+			"start": citation.Start,
+			"end":   citation.End,
+			"text":  citation.Text,
+		}
+		for _, id := range citation.DocumentIDs {
+			document := byID[id]
+			url, _ := document["url"].(string)
+			title, _ := document["title"].(string)
+			if url == "" && title == "" {
+				continue
+			}
+			entry["url"] = url
+			entry["title"] = title
+			break
+		}
+		result = append(result, entry)
+	}
+	return result
+}
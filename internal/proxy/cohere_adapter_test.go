@@ -0,0 +1,166 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCohereAdapter_TranslateRequest(t *testing.T) {
+	body := `{
+		"model": "command-r-plus",
+		"temperature": 0.3,
+		"top_p": 0.9,
+		"stop": "END",
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": "Weather in Paris and Rome?"},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}},
+				{"id": "call_2", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Rome\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "{\"temp\":21}"},
+			{"role": "tool", "tool_call_id": "call_2", "content": "sunny"}
+		],
+		"tools": [{"type": "function", "function": {"name": "get_weather", "description": "Current weather", "parameters": {
+			"type": "object",
+			"properties": {"city": {"type": "string", "description": "City name"}, "days": {"type": "integer"}},
+			"required": ["city"]
+		}}}],
+		"tool_choice": "required"
+	}`
+
+	translated, err := NewCohereAdapter().TranslateRequest([]byte(body))
+	require.NoError(t, err)
+
+	expected := `{
+		"model": "command-r-plus",
+		"message": "",
+		"preamble": "Be brief.",
+		"temperature": 0.3,
+		"p": 0.9,
+		"stop_sequences": ["END"],
+		"chat_history": [
+			{"role": "USER", "message": "Weather in Paris and Rome?"},
+			{"role": "CHATBOT", "message": "", "tool_calls": [
+				{"name": "get_weather", "parameters": {"city": "Paris"}},
+				{"name": "get_weather", "parameters": {"city": "Rome"}}
+			]}
+		],
+		"tool_results": [
+			{"call": {"name": "get_weather", "parameters": {"city": "Paris"}}, "outputs": [{"temp": 21}]},
+			{"call": {"name": "get_weather", "parameters": {"city": "Rome"}}, "outputs": [{"result": "sunny"}]}
+		],
+		"tools": [{"name": "get_weather", "description": "Current weather", "parameter_definitions": {
+			"city": {"type": "str", "description": "City name", "required": true},
+			"days": {"type": "int", "required": false}
+		}}],
+		"tool_choice": "REQUIRED"
+	}`
+	assert.JSONEq(t, expected, string(translated))
+}
+
+func TestCohereAdapter_TranslateRequest_FinalUserMessage(t *testing.T) {
+	body := `{"model": "command-r", "messages": [
+		{"role": "user", "content": "Hi"},
+		{"role": "assistant", "content": "Hello!"},
+		{"role": "user", "content": [{"type": "text", "text": "How are you?"}]}
+	]}`
+
+	translated, err := NewCohereAdapter().TranslateRequest([]byte(body))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"model": "command-r",
+		"message": "How are you?",
+		"chat_history": [{"role": "USER", "message": "Hi"}, {"role": "CHATBOT", "message": "Hello!"}]
+	}`, string(translated))
+}
+
+func TestCohereAdapter_TranslateResponse(t *testing.T) {
+	body := `{
+		"response_id": "resp-1",
+		"generation_id": "gen-1",
+		"text": "Paris is sunny.",
+		"finish_reason": "COMPLETE",
+		"citations": [{"start": 0, "end": 5, "text": "Paris", "document_ids": ["doc_0"]}],
+		"documents": [{"id": "doc_0", "url": "https://example.com/paris", "title": "Paris weather"}],
+		"meta": {"billed_units": {"input_tokens": 10, "output_tokens": 4}, "tokens": {"input_tokens": 40, "output_tokens": 4}}
+	}`
+
+	translated, err := NewCohereAdapter().TranslateResponse([]byte(body))
+	require.NoError(t, err)
+
+	processed, err := ProcessResponse(translated, "cohere", "", "command-r-plus")
+	require.NoError(t, err)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(processed, &response))
+	assert.Equal(t, "gen-1", response["id"])
+	assert.Equal(t, float64(44), response["usage"].(map[string]interface{})["total_tokens"])
+
+	choice := response["choices"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "stop", choice["finish_reason"])
+	message := choice["message"].(map[string]interface{})
+	assert.Equal(t, "Paris is sunny.", message["content"])
+	assert.NotContains(t, message, "citations")
+	assert.JSONEq(t, `[{"type":"url_citation","url_citation":{"start_index":0,"end_index":5,"url":"https://example.com/paris","title":"Paris weather"}}]`, mustJSON(t, message["annotations"]))
+}
+
+func TestCohereAdapter_TranslateResponse_ToolCalls(t *testing.T) {
+	translated, err := NewCohereAdapter().TranslateResponse([]byte(`{
+		"text": "",
+		"finish_reason": "COMPLETE",
+		"tool_calls": [{"name": "get_weather", "parameters": {"city": "Paris"}}]
+	}`))
+	require.NoError(t, err)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(translated, &response))
+	choice := response["choices"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "tool_calls", choice["finish_reason"])
+	message := choice["message"].(map[string]interface{})
+	assert.Nil(t, message["content"])
+	toolCall := message["tool_calls"].([]interface{})[0].(map[string]interface{})
+	assert.NotEmpty(t, toolCall["id"])
+	assert.JSONEq(t, `{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}`, mustJSON(t, toolCall["function"]))
+}
+
+func TestCohereAdapter_TranslateStream(t *testing.T) {
+	events := strings.Join([]string{
+		`{"is_finished":false,"event_type":"stream-start","generation_id":"gen-1"}`,
+		`{"is_finished":false,"event_type":"text-generation","text":"Paris"}`,
+		`{"is_finished":false,"event_type":"text-generation","text":" is sunny."}`,
+		`{"is_finished":false,"event_type":"citation-generation","citations":[{"start":0,"end":5,"text":"Paris","document_ids":["doc_0"]}]}`,
+		`{"is_finished":true,"event_type":"stream-end","finish_reason":"MAX_TOKENS","response":{"documents":[{"id":"doc_0","url":"https://example.com/paris","title":"Paris weather"}],"meta":{"tokens":{"input_tokens":7,"output_tokens":3}}}}`,
+		"",
+	}, "\n")
+
+	output, err := io.ReadAll(NewCohereAdapter().TranslateStream(strings.NewReader(events)))
+	require.NoError(t, err)
+
+	chunks := strings.Split(strings.TrimSpace(string(output)), "\n\n")
+	require.Len(t, chunks, 6)
+	assert.Contains(t, chunks[0], `"role":"assistant"`)
+	assert.Contains(t, chunks[0], `"id":"gen-1"`)
+	assert.Contains(t, chunks[1], `"content":"Paris"`)
+	assert.Contains(t, chunks[2], `"content":" is sunny."`)
+	assert.Contains(t, chunks[3], `"url":"https://example.com/paris"`)
+	assert.Contains(t, chunks[4], `"finish_reason":"length"`)
+	assert.Contains(t, chunks[4], `"total_tokens":10`)
+	assert.Equal(t, "data: [DONE]", chunks[5])
+
+	processor := NewStreamProcessor("conv", 0, "fp", "cohere", "command-r-plus")
+	processed := string(processor.ProcessChunk([]byte(chunks[3] + "\n\n")))
+	assert.Contains(t, processed, `"type":"url_citation"`)
+	assert.NotContains(t, processed, `"citations"`)
+}
+
+func TestCohereAdapter_TranslateRerankResponse(t *testing.T) {
+	translated, err := NewCohereAdapter().TranslateRerankResponse([]byte(`{"id":"r1","results":[{"index":0,"relevance_score":0.9}],"meta":{"api_version":{"version":"1"},"billed_units":{"search_units":1}}}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"r1","results":[{"index":0,"relevance_score":0.9}],"usage":{"search_units":1}}`, string(translated))
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/admission"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+)
+
+// vendorSlot is the concurrency slot a chat completion holds on the vendor it is being sent
+// to. Failover moves it to each fallback vendor, so every hop counts against the cap of the
+// vendor it is sent to, and no vendor keeps a slot for a request it no longer serves
+type vendorSlot struct {
+	vendor  string
+	release func()
+	waited  time.Duration
+}
+
+// vendorSlotKey is the context key of the request's vendor slot
+type vendorSlotKey struct{}
+
+// withVendorSlot returns a context carrying the request's vendor slot
+func withVendorSlot(ctx context.Context, slot *vendorSlot) context.Context {
+	return context.WithValue(ctx, vendorSlotKey{}, slot)
+}
+
+// vendorSlotFromContext returns the request's vendor slot, or nil outside chat completions
+func vendorSlotFromContext(ctx context.Context) *vendorSlot {
+	slot, _ := ctx.Value(vendorSlotKey{}).(*vendorSlot)
+	return slot
+}
+
+// moveTo takes a slot on vendor, first giving back the one held on another vendor; a slot
+// already held on vendor is kept. As acquireConcurrencySlot, it answers the request with a
+// 429 and returns the error when no slot is free in time
+func (s *vendorSlot) moveTo(w http.ResponseWriter, r *http.Request, vendor string) error {
+	if s.release != nil && s.vendor == vendor {
+		return nil
+	}
+	s.Release()
+
+	started := time.Now()
+	release, err := acquireConcurrencySlot(w, r, vendor)
+	s.waited += time.Since(started)
+	if decision := routingDecisionFromContext(r.Context()); decision != nil {
+		decision.QueueWaitMs = (admission.QueueWait(r.Context()) + s.waited).Milliseconds()
+	}
+	if err != nil {
+		return err
+	}
+	s.vendor, s.release = vendor, release
+	return nil
+}
+
+// Release gives the slot back, if one is held
+func (s *vendorSlot) Release() {
+	if s.release != nil {
+		s.release()
+		s.release = nil
+	}
+}
+
+// acquireConcurrencySlot takes a slot on vendor from the process-wide concurrency limiter,
+// waiting in its queue while the vendor or the router is at its limit. When none is free
+// in time, it answers the request with a 429 and Retry-After and returns the error
+func acquireConcurrencySlot(w http.ResponseWriter, r *http.Request, vendor string) (release func(), err error) {
+	limiter := admission.DefaultLimiter()
+	release, err = limiter.Acquire(r.Context(), vendor)
+	if err == nil {
+		return release, nil
+	}
+	if r.Context().Err() != nil {
+		// The client is gone; there is nobody left to answer
+		return nil, err
+	}
+
+	stats := limiter.Stats()
+	ctx := logger.WithComponent(r.Context(), "proxy")
+	ctx = logger.WithStage(ctx, "concurrency_limiting")
+	logger.Warn(ctx, "Concurrency limit reached",
+		"vendor", vendor,
+		"in_flight", stats.InFlight,
+		"vendor_in_flight", stats.VendorInFlight[vendor],
+		"queued", stats.Queued,
+		"error", err.Error(),
+	)
+	w.Header().Set("Retry-After", strconv.Itoa(max(int(math.Ceil(limiter.RetryAfter().Seconds())), 1)))
+	http.Error(w, err.Error(), http.StatusTooManyRequests)
+	return nil, err
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/admission"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// slotRecordingClient fails requests for one vendor with an invalid response and records the
+// vendor slots in use whenever a request is sent
+type slotRecordingClient struct {
+	failVendor string
+	attempts   []string
+	inFlight   []map[string]int
+}
+
+func (c *slotRecordingClient) SendRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error {
+	c.attempts = append(c.attempts, selection.Vendor)
+	c.inFlight = append(c.inFlight, admission.DefaultLimiter().Stats().VendorInFlight)
+	if selection.Vendor == c.failVendor {
+		return &VendorValidationError{Vendor: selection.Vendor, MissingField: "choices"}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write([]byte(finalAnswer))
+	return err
+}
+
+func TestProxyRequest_FailoverMovesConcurrencySlot(t *testing.T) {
+	limiter := admission.DefaultLimiter()
+	limiter.Configure(admission.LimiterConfig{VendorLimits: map[string]int{"openai": 1, "gemini": 1}, QueueTimeout: 50 * time.Millisecond})
+	t.Cleanup(func() { limiter.Configure(admission.LimiterConfig{}) })
+
+	creds := []config.Credential{{Platform: "openai", Value: "sk-1"}, {Platform: "gemini", Value: "gm-1"}}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-2.5-flash"}}
+	send := func(client APIClientInterface) *httptest.ResponseRecorder {
+		mockSelector := &MockSelector{}
+		mockSelector.On("Select", creds, models).Return(&selector.VendorSelection{Vendor: "openai", Model: "gpt-4o", Credential: creds[0]}, nil)
+		mockSelector.On("Select", mock.Anything, mock.Anything).Return(&selector.VendorSelection{Vendor: "gemini", Model: "gemini-2.5-flash", Credential: creds[1]}, nil)
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"any","messages":[{"role":"user","content":"Hi"}]}`))
+		rr := httptest.NewRecorder()
+		ProxyRequest(rr, req, creds, models, client, mockSelector)
+		return rr
+	}
+
+	t.Run("the fallback takes a slot on its own vendor", func(t *testing.T) {
+		client := &slotRecordingClient{failVendor: "openai"}
+		rr := send(client)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "gemini", client.attempts[len(client.attempts)-1])
+		assert.Equal(t, map[string]int{"gemini": 1}, client.inFlight[len(client.inFlight)-1], "the failed vendor's slot is given back")
+		assert.Zero(t, limiter.Stats().InFlight, "every slot is released once the request is served")
+	})
+
+	t.Run("the fallback vendor's cap is enforced", func(t *testing.T) {
+		release, err := limiter.Acquire(t.Context(), "gemini")
+		require.NoError(t, err)
+		defer release()
+
+		client := &slotRecordingClient{failVendor: "openai"}
+		rr := send(client)
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.NotContains(t, client.attempts, "gemini", "nothing is sent to a vendor at its cap")
+		assert.Equal(t, 1, limiter.Stats().InFlight, "only the slot held outside the request remains")
+	})
+}
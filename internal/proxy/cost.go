@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// costHeaderEnabled reports whether the price of each request is sent in the X-Router-Cost
+// header, which COST_HEADER_ENABLED turns on
+func costHeaderEnabled() bool {
+	return utils.GetEnvBool("COST_HEADER_ENABLED", false)
+}
+
+// setCostHeader sets X-Router-Cost in header to the price in US dollars of the response the
+// request was served, when the header is enabled and the serving model has pricing
+func setCostHeader(ctx context.Context, header http.Header) {
+	if !costHeaderEnabled() {
+		return
+	}
+	decision := routingDecisionFromContext(ctx)
+	if decision == nil || decision.CostUSD == nil {
+		return
+	}
+	header.Set(utils.HeaderXRouterCost, formatCost(*decision.CostUSD))
+}
+
+// announceCostTrailer declares X-Router-Cost as a trailer of a streamed response, whose
+// usage is only known once the stream ends; it must be called before the headers are sent
+func announceCostTrailer(w http.ResponseWriter) {
+	if costHeaderEnabled() {
+		w.Header().Add(utils.HeaderTrailer, utils.HeaderXRouterCost)
+	}
+}
+
+// formatCost renders a cost in US dollars without exponent notation
+func formatCost(cost float64) string {
+	return strconv.FormatFloat(cost, 'f', -1, 64)
+}
@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/budget"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withTestPricing prices priced-model at $2 per million input and $10 per million output tokens
+func withTestPricing(t *testing.T) {
+	budget.Default().Configure(nil, []config.VendorModel{{
+		Vendor:  "pricedvendor",
+		Model:   "priced-model",
+		Pricing: &config.ModelPricing{InputPerMillion: 2, OutputPerMillion: 10},
+	}})
+	t.Cleanup(func() { budget.Default().Configure(nil, nil) })
+}
+
+func TestRecordUsage_PricesTheServingModel(t *testing.T) {
+	withTestPricing(t)
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+	decision := newRoutingDecision(r, &selector.VendorSelection{Vendor: "openai", Model: "gpt-4o"}, "my-model", nil, nil)
+	ctx := withRoutingDecision(r.Context(), decision)
+	recordUsage(ctx, 1000, 100)
+	assert.Nil(t, decision.CostUSD, "unpriced models have no cost")
+
+	decision.FallbackVendor, decision.FallbackModel = "pricedvendor", "priced-model"
+	recordUsage(ctx, 1000, 100)
+	require.NotNil(t, decision.CostUSD)
+	assert.InDelta(t, 0.003, *decision.CostUSD, 1e-12)
+}
+
+func TestMarkUsageEstimated(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	decision := newRoutingDecision(r, &selector.VendorSelection{Vendor: "openai", Model: "gpt-4o"}, "my-model", nil, nil)
+	ctx := withRoutingDecision(r.Context(), decision)
+
+	recordUsage(ctx, 10, 6)
+	markUsageEstimated(ctx)
+	assert.True(t, decision.UsageEstimated)
+
+	recordUsage(ctx, 12, 8)
+	assert.False(t, decision.UsageEstimated, "usage recorded again is the vendor's own")
+}
+
+func TestSetCostHeader(t *testing.T) {
+	withTestPricing(t)
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	decision := newRoutingDecision(r, &selector.VendorSelection{Vendor: "pricedvendor", Model: "priced-model"}, "my-model", nil, nil)
+	ctx := withRoutingDecision(r.Context(), decision)
+	recordUsage(ctx, 1500, 20)
+
+	header := http.Header{}
+	setCostHeader(ctx, header)
+	assert.Empty(t, header.Get(utils.HeaderXRouterCost), "the header is opt-in")
+
+	t.Setenv("COST_HEADER_ENABLED", "true")
+	setCostHeader(ctx, header)
+	assert.Equal(t, "0.0032", header.Get(utils.HeaderXRouterCost))
+}
+
+func TestCostTrailer_SentAfterTheStream(t *testing.T) {
+	withTestPricing(t)
+	t.Setenv("COST_HEADER_ENABLED", "true")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decision := newRoutingDecision(r, &selector.VendorSelection{Vendor: "pricedvendor", Model: "priced-model"}, "my-model", nil, nil)
+		ctx := withRoutingDecision(r.Context(), decision)
+
+		announceCostTrailer(w)
+		w.WriteHeader(http.StatusOK)
+		_, _ = io.WriteString(w, "data: [DONE]\n\n")
+		recordUsage(ctx, 0, 1000)
+		setCostHeader(ctx, w.Header())
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Empty(t, resp.Header.Get(utils.HeaderXRouterCost))
+
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "0.01", resp.Trailer.Get(utils.HeaderXRouterCost))
+}
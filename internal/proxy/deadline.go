@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrClientDisconnected reports a vendor call abandoned because the client went away
+var ErrClientDisconnected = errors.New("client disconnected")
+
+// RequestDeadlineError reports a request that ran out of the time its client gave it, across
+// all of its vendor attempts
+type RequestDeadlineError struct {
+	// Limit is the request's X-Request-Timeout, or zero when the deadline came from elsewhere
+	Limit time.Duration
+}
+
+// Error implements the error interface
+func (e *RequestDeadlineError) Error() string {
+	if e.Limit > 0 {
+		return fmt.Sprintf("request timeout of %s exceeded", e.Limit)
+	}
+	return "request deadline exceeded"
+}
+
+// Timeout marks the error as a timeout for net.Error checks
+func (e *RequestDeadlineError) Timeout() bool {
+	return true
+}
+
+// withRequestDeadline returns ctx carrying the request's X-Request-Timeout and bounded by it,
+// so its vendor attempts, retries and failover hops all share one deadline
+func withRequestDeadline(ctx context.Context, limit time.Duration) (context.Context, context.CancelFunc) {
+	if limit <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(withRequestTimeout(ctx, limit), limit)
+}
+
+// requestContextError returns why a request whose context is done can no longer be served:
+// ErrClientDisconnected when the client went away, or a RequestDeadlineError when its deadline
+// passed. It returns nil while the context is live. Neither error is retried or failed over
+func requestContextError(ctx context.Context) error {
+	switch err := ctx.Err(); {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded):
+		return &RequestDeadlineError{Limit: requestTimeoutFromContext(ctx)}
+	default:
+		return ErrClientDisconnected
+	}
+}
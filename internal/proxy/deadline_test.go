@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendRequest_ClientDisconnect(t *testing.T) {
+	aborted := make(chan struct{})
+	vendor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+			close(aborted)
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer vendor.Close()
+
+	body := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"Hi"}]}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(string(body))).WithContext(ctx)
+	selection := &selector.VendorSelection{Vendor: "openai", Model: "gpt-4o", Credential: config.Credential{Platform: "openai", Value: "sk-test"}}
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := NewAPIClient(map[string]string{"openai": vendor.URL}).SendRequest(httptest.NewRecorder(), r, selection, body, "gpt-4o")
+	assert.ErrorIs(t, err, ErrClientDisconnected)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.False(t, shouldFailOver(err))
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Fatal("the vendor request was not aborted")
+	}
+}
+
+func TestRequestDeadline(t *testing.T) {
+	ctx, cancel := withRequestDeadline(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, requestContextError(ctx))
+	assert.Equal(t, 20*time.Millisecond, requestTimeoutFromContext(ctx))
+
+	<-ctx.Done()
+	err := requestContextError(ctx)
+	var deadlineErr *RequestDeadlineError
+	require.ErrorAs(t, err, &deadlineErr)
+	assert.Equal(t, "request timeout of 20ms exceeded", err.Error())
+	assert.False(t, shouldFailOver(err))
+
+	w := httptest.NewRecorder()
+	writeUpstreamError(ctx, w, err, "openai")
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	canceled, cancelClient := context.WithCancel(context.Background())
+	cancelClient()
+	w = httptest.NewRecorder()
+	writeUpstreamError(canceled, w, requestContextError(canceled), "openai")
+	assert.Empty(t, w.Body.String(), "a client that went away gets no answer")
+
+	unbounded, cancelUnbounded := withRequestDeadline(context.Background(), 0)
+	defer cancelUnbounded()
+	_, hasDeadline := unbounded.Deadline()
+	assert.False(t, hasDeadline)
+}
@@ -0,0 +1,183 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/admission"
+	"github.com/aashari/go-generative-api-router/internal/budget"
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/types"
+)
+
+// Routing decision outcomes
+const (
+	DecisionOutcomeSuccess         = "success"
+	DecisionOutcomeFallbackSuccess = "fallback_success"
+	DecisionOutcomeError           = "error"
+	DecisionOutcomeSelectionFailed = "selection_failed"
+)
+
+// routingDecision accumulates a monitoring.RoutingDecision while a request is proxied
+type routingDecision struct {
+	monitoring.RoutingDecision
+	// started is when routing began, for latencies reported while the request is proxied
+	started time.Time
+}
+
+// newRoutingDecision starts a decision record for the given selection among candidates
+func newRoutingDecision(r *http.Request, selection *selector.VendorSelection, originalModel string, payloadContext *types.PayloadContext, candidates []config.VendorModel) *routingDecision {
+	decision := &routingDecision{
+		RoutingDecision: monitoring.RoutingDecision{
+			RequestID:      requestIDFromContext(r),
+			ClientKey:      access.KeyHint(r),
+			ClientKeyID:    access.KeyID(r),
+			User:           userHashFromContext(r),
+			OriginalModel:  originalModel,
+			VendorFilter:   r.URL.Query().Get("vendor"),
+			Filters:        capabilityFilters(payloadContext),
+			CandidateCount: len(candidates),
+			Candidates:     candidateNames(candidates),
+			QueueWaitMs:    admission.QueueWait(r.Context()).Milliseconds(),
+		},
+		started: time.Now(),
+	}
+	if exclusions := routingExclusionsFromContext(r.Context()); exclusions != nil {
+		decision.ExcludedVendors = exclusions.Vendors
+		decision.ExcludedModels = exclusions.Models
+	}
+	if selection != nil {
+		decision.Vendor = selection.Vendor
+		decision.Model = selection.Model
+		decision.Credential = credentialLabel(selection.Credential)
+		decision.Reason = selection.Reason
+	}
+	return decision
+}
+
+// recordStreamTiming sets the time to first token and the streaming throughput of the
+// response served on the in-flight routing decision
+func recordStreamTiming(ctx context.Context, ttft time.Duration, tokensPerSecond float64) {
+	decision := routingDecisionFromContext(ctx)
+	if decision == nil {
+		return
+	}
+	decision.TTFTMs = ttft.Milliseconds()
+	decision.TokensPerSecond = tokensPerSecond
+}
+
+// candidateNames lists the vendor/model names of the candidates a request was routed among
+func candidateNames(candidates []config.VendorModel) []string {
+	if len(candidates) == 0 {
+		return nil
+	}
+	names := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		names[i] = candidate.Vendor + "/" + candidate.Model
+	}
+	return names
+}
+
+// credentialLabel names a credential in the routing decision log without exposing it: its
+// ID when it has one, otherwise its vendor and the last 4 characters of its key
+func credentialLabel(cred config.Credential) string {
+	if cred.ID != "" {
+		return cred.ID
+	}
+	if cred.Value == "" || cred.Type == config.CredentialTypeNone {
+		return cred.Platform
+	}
+	if len(cred.Value) <= 4 {
+		return cred.Platform + ":..."
+	}
+	return cred.Platform + ":..." + cred.Value[len(cred.Value)-4:]
+}
+
+// Complete sets the final outcome of the decision based on the proxy result
+func (d *routingDecision) Complete(err error) {
+	switch {
+	case err != nil:
+		d.Outcome = DecisionOutcomeError
+		d.Error = err.Error()
+	case d.FallbackVendor != "":
+		d.Outcome = DecisionOutcomeFallbackSuccess
+	default:
+		d.Outcome = DecisionOutcomeSuccess
+	}
+}
+
+// recordUsage sets the token usage of the response served on the in-flight routing decision,
+// and its cost when the serving model has pricing
+func recordUsage(ctx context.Context, promptTokens, completionTokens int) {
+	decision := routingDecisionFromContext(ctx)
+	if decision == nil {
+		return
+	}
+	decision.PromptTokens = promptTokens
+	decision.CompletionTokens = completionTokens
+	decision.UsageEstimated = false
+	decision.CostUSD = nil
+	vendor, model := decision.ServedBy()
+	if cost, ok := budget.Default().Cost(vendor, model, promptTokens, completionTokens); ok {
+		decision.CostUSD = &cost
+	}
+}
+
+// markUsageEstimated flags the usage recorded on the in-flight routing decision as counted
+// by the router rather than reported by the vendor
+func markUsageEstimated(ctx context.Context) {
+	if decision := routingDecisionFromContext(ctx); decision != nil {
+		decision.UsageEstimated = true
+	}
+}
+
+// responseUsage returns the prompt and completion tokens of a chat completion or embeddings response
+func responseUsage(body []byte) (int, int) {
+	var response struct {
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if codec.Unmarshal(body, &response) != nil {
+		return 0, 0
+	}
+	return response.Usage.PromptTokens, response.Usage.CompletionTokens
+}
+
+// recordSelectionFailure publishes the decision of a request that never reached a vendor
+func recordSelectionFailure(r *http.Request, originalModel string, payloadContext *types.PayloadContext, candidateCount int, start time.Time, err error) {
+	decision := newRoutingDecision(r, nil, originalModel, payloadContext, nil)
+	decision.CandidateCount = candidateCount
+	decision.Outcome = DecisionOutcomeSelectionFailed
+	decision.Error = err.Error()
+	publishOutcome(r, decision, start, err)
+}
+
+// capabilityFilters lists the capability requirements applied during selection
+func capabilityFilters(payloadContext *types.PayloadContext) map[string]bool {
+	if payloadContext == nil {
+		return nil
+	}
+	return map[string]bool{
+		"images": payloadContext.HasImages,
+		"videos": payloadContext.HasVideos,
+		"audio":  payloadContext.HasAudio,
+		"tools":  payloadContext.HasTools,
+		"stream": payloadContext.HasStream,
+	}
+}
+
+// requestIDFromContext returns the request ID set by the correlation middleware
+func requestIDFromContext(r *http.Request) string {
+	if requestID, ok := r.Context().Value(logger.RequestIDKey).(string); ok {
+		return requestID
+	}
+	return ""
+}
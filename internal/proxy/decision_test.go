@@ -0,0 +1,35 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRoutingDecision_RecordsPoolCredentialAndReason(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	selection := &selector.VendorSelection{
+		Vendor:     "gemini",
+		Model:      "gemini-2.5-flash",
+		Credential: config.Credential{Platform: "gemini", Type: "api-key", Value: "secret-gemini-key-wxyz"},
+		Reason:     "weighted pick among 2 combinations",
+	}
+	candidates := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-2.5-flash"}}
+
+	decision := newRoutingDecision(r, selection, "my-model", nil, candidates)
+	assert.Equal(t, 2, decision.CandidateCount)
+	assert.Equal(t, []string{"openai/gpt-4o", "gemini/gemini-2.5-flash"}, decision.Candidates)
+	assert.Equal(t, "gemini:...wxyz", decision.Credential, "the key itself is never recorded")
+	assert.Equal(t, "weighted pick among 2 combinations", decision.Reason)
+}
+
+func TestCredentialLabel(t *testing.T) {
+	assert.Equal(t, "openai-primary", credentialLabel(config.Credential{ID: "openai-primary", Platform: "openai", Value: "sk-123456"}))
+	assert.Equal(t, "openai:...3456", credentialLabel(config.Credential{Platform: "openai", Value: "sk-123456"}))
+	assert.Equal(t, "openai:...", credentialLabel(config.Credential{Platform: "openai", Value: "sk"}))
+	assert.Equal(t, "ollama", credentialLabel(config.Credential{Platform: "ollama", Type: config.CredentialTypeNone}))
+}
@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+)
+
+// deepSeekFinishReasons maps DeepSeek-specific finish reasons to OpenAI finish reasons
+// DeepSeek cuts generation short with insufficient_system_resource when it is overloaded,
+// which clients should treat like a truncated completion
+var deepSeekFinishReasons = map[string]string{
+	"insufficient_system_resource": "length",
+}
+
+// DeepSeekAdapter handles DeepSeek's OpenAI-compatible API
+// deepseek-reasoner returns its chain of thought in reasoning_content, which the response
+// processor reports like every other vendor's reasoning. DeepSeek rejects requests that
+// send reasoning_content back in earlier assistant turns, so it is stripped from requests
+type DeepSeekAdapter struct {
+	openAICompatibleAdapter
+}
+
+func init() {
+	RegisterVendorAdapter("deepseek", func() VendorAdapter { return NewDeepSeekAdapter() })
+}
+
+// NewDeepSeekAdapter creates a DeepSeek adapter
+func NewDeepSeekAdapter() *DeepSeekAdapter {
+	return &DeepSeekAdapter{}
+}
+
+// TranslateRequest removes reasoning from earlier assistant turns
+func (a *DeepSeekAdapter) TranslateRequest(body []byte) ([]byte, error) {
+	if !bytes.Contains(body, []byte(`"reasoning`)) && !bytes.Contains(body, []byte(`"thinking"`)) {
+		return body, nil
+	}
+
+	var request map[string]interface{}
+	if err := codec.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+	messages, _ := request["messages"].([]interface{})
+	for _, item := range messages {
+		if message, ok := item.(map[string]interface{}); ok {
+			delete(message, "reasoning_content")
+			for _, alias := range reasoningAliases {
+				delete(message, alias)
+			}
+		}
+	}
+	return codec.Marshal(request)
+}
+
+// TranslateResponse maps DeepSeek-specific finish reasons
+func (a *DeepSeekAdapter) TranslateResponse(body []byte) ([]byte, error) {
+	if !hasDeepSeekFinishReason(body) {
+		return body, nil
+	}
+
+	var response map[string]interface{}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	mapDeepSeekFinishReasons(response)
+	return codec.Marshal(response)
+}
+
+// TranslateStream maps DeepSeek-specific finish reasons in streamed chunks
+func (a *DeepSeekAdapter) TranslateStream(r io.Reader) io.Reader {
+	return newChunkRewriter(r, hasDeepSeekFinishReason, mapDeepSeekFinishReasons)
+}
+
+// hasDeepSeekFinishReason cheaply checks whether a payload may need finish reason mapping
+func hasDeepSeekFinishReason(body []byte) bool {
+	for reason := range deepSeekFinishReasons {
+		if bytes.Contains(body, []byte(`"`+reason+`"`)) {
+			return true
+		}
+	}
+	return false
+}
+
+// mapDeepSeekFinishReasons rewrites the finish_reason of every choice in a response or chunk
+func mapDeepSeekFinishReasons(response map[string]interface{}) {
+	choices, _ := response["choices"].([]interface{})
+	for _, item := range choices {
+		choice, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if reason, ok := choice["finish_reason"].(string); ok {
+			if mapped, ok := deepSeekFinishReasons[reason]; ok {
+				choice["finish_reason"] = mapped
+			}
+		}
+	}
+}
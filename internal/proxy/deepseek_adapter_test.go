@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeepSeekAdapter_TranslateRequest(t *testing.T) {
+	body := `{
+		"model": "deepseek-reasoner",
+		"reasoning_effort": "high",
+		"messages": [
+			{"role": "user", "content": "Which is larger, 9.11 or 9.8?"},
+			{"role": "assistant", "content": "9.8", "reasoning_content": "Compare the tenths."},
+			{"role": "user", "content": "Why?"}
+		]
+	}`
+
+	translated, err := NewDeepSeekAdapter().TranslateRequest([]byte(body))
+	require.NoError(t, err)
+
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(translated, &request))
+	assert.Equal(t, "high", request["reasoning_effort"])
+	assert.Equal(t, map[string]interface{}{"role": "assistant", "content": "9.8"}, request["messages"].([]interface{})[1])
+}
+
+func TestDeepSeekAdapter_TranslateResponse(t *testing.T) {
+	body := `{"choices":[{"index":0,"message":{"role":"assistant","content":"partial"},"finish_reason":"insufficient_system_resource"}]}`
+
+	translated, err := NewDeepSeekAdapter().TranslateResponse([]byte(body))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"choices":[{"index":0,"message":{"role":"assistant","content":"partial"},"finish_reason":"length"}]}`, string(translated))
+}
+
+func TestDeepSeekAdapter_TranslateStream(t *testing.T) {
+	stream := "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":null,\"reasoning_content\":\"Compare\"},\"finish_reason\":null}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"\"},\"finish_reason\":\"insufficient_system_resource\"}]}\n\n" +
+		"data: [DONE]\n\n"
+
+	translated, err := io.ReadAll(NewDeepSeekAdapter().TranslateStream(strings.NewReader(stream)))
+	require.NoError(t, err)
+	assert.Contains(t, string(translated), `"reasoning_content":"Compare"`, "reasoning chunks pass through unchanged")
+	assert.Contains(t, string(translated), `"finish_reason":"length"`)
+	assert.NotContains(t, string(translated), "insufficient_system_resource")
+}
+
+func TestStreamProcessor_Reasoning(t *testing.T) {
+	sp := NewStreamProcessor("chatcmpl-1", 1700000000, "fp_generated", "groq", "any-model")
+
+	chunk := sp.ProcessChunk([]byte(`data: {"choices":[{"index":0,"delta":{"content":null,"reasoning":"Let me think"}}]}` + "\n"))
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(string(chunk)), "data: ")), &data))
+	delta := data["choices"].([]interface{})[0].(map[string]interface{})["delta"].(map[string]interface{})
+	assert.Equal(t, "Let me think", delta["reasoning_content"])
+	assert.NotContains(t, delta, "reasoning")
+	assert.Equal(t, 3, sp.CompletionTokens(), "reasoning counts toward completion tokens")
+}
@@ -0,0 +1,289 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/events"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/reliability"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+	"github.com/aashari/go-generative-api-router/internal/validator"
+)
+
+// ErrEmbeddingsUnsupported is returned when the selected vendor does not serve embeddings
+var ErrEmbeddingsUnsupported = errors.New("vendor does not support embeddings")
+
+// EmbeddingsClientInterface defines the interface for clients sending embeddings requests
+type EmbeddingsClientInterface interface {
+	SendEmbeddingsRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error
+}
+
+// ProxyEmbeddingsRequest validates an embeddings request, routes it to a vendor serving one
+// of the embedding models and forwards the normalized response
+func ProxyEmbeddingsRequest(w http.ResponseWriter, r *http.Request, creds []config.Credential, models []config.VendorModel, apiClient EmbeddingsClientInterface, modelSelector selector.Selector) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	start := time.Now()
+	ctx := logger.WithComponent(r.Context(), "proxy")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := r.Body.Close(); err != nil {
+		logger.Warn(logger.WithStage(ctx, "request_handling"), "Failed to close request body", "error", err)
+	}
+
+	request, originalModel, err := validator.ValidateEmbeddingsRequest(body)
+	if err != nil {
+		logger.Warn(logger.WithStage(ctx, "request_validation"), "Embeddings request validation failed", "error", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	publishEvent(r, events.Event{Type: events.RequestReceived, OriginalModel: originalModel})
+
+	// Restrict the pool to what the client key's ACL permits, minus any requested exclusions
+	r, creds, models, ok := restrictEndpointPool(ctx, w, r, body, originalModel, start, creds, models)
+	if !ok {
+		return
+	}
+
+	// Only vendors with an embeddings API can take the request
+	models = embeddingsModels(models)
+	creds = filter.CredentialsForModels(creds, models)
+
+	r, selection, decision, ok := selectEndpointVendor(ctx, w, r, creds, models, modelSelector, originalModel, start)
+	if !ok {
+		return
+	}
+
+	request["model"] = selection.Model
+	modifiedBody, err := codec.Marshal(request)
+	if err != nil {
+		logger.Error(logger.WithStage(ctx, "request_validation"), "Failed to encode embeddings request", err)
+		http.Error(w, "Failed to encode request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	publishEvent(r, events.Event{Type: events.RequestValidated, OriginalModel: originalModel, Vendor: selection.Vendor, Model: selection.Model})
+
+	ctx = context.WithValue(ctx, "vendor", selection.Vendor)
+	ctx = context.WithValue(ctx, "model", selection.Model)
+	logger.Info(logger.WithStage(ctx, "RequestProcessing"), "Proxying embeddings request",
+		"original_model", originalModel,
+		"vendor", selection.Vendor,
+		"model", selection.Model,
+		"request_body", logger.RequestBody(modifiedBody),
+	)
+
+	err = reliability.NewRetryExecutor(nil).ExecuteWithRetry(ctx, func() error {
+		decision.Attempts++
+		return apiClient.SendEmbeddingsRequest(w, r, selection, modifiedBody, originalModel)
+	})
+	if err != nil {
+		writeUpstreamError(ctx, w, err, selection.Vendor)
+	}
+	decision.Complete(err)
+	publishOutcome(r, decision, start, err)
+}
+
+// embeddingsModels returns the models whose vendor serves embeddings
+func embeddingsModels(models []config.VendorModel) []config.VendorModel {
+	var result []config.VendorModel
+	for _, model := range models {
+		if SupportsEmbeddings(model.Vendor) {
+			result = append(result, model)
+		}
+	}
+	return result
+}
+
+// SendEmbeddingsRequest sends an embeddings request to the vendor API and writes the
+// normalized response back
+func (c *APIClient) SendEmbeddingsRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error {
+	adapter := adapterFor(selection.Vendor)
+	provider, ok := adapter.(EmbeddingsProvider)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrEmbeddingsUnsupported, selection.Vendor)
+	}
+	baseURL, err := c.baseURLFor(selection)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, provider.EmbeddingsEndpoint(baseURL), bytes.NewReader(modifiedBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	req.Header.Set(utils.HeaderAcceptEncoding, utils.AcceptEncodingGzip)
+	if err := authorizeRequest(req, adapter, selection); err != nil {
+		return err
+	}
+
+	resp, release, err := c.doVendorRequest(r, req, selection, modifiedBody, originalModel)
+	if err != nil {
+		return err
+	}
+	// The request counts against the credential's concurrency cap until its response is handled
+	defer release()
+	defer resp.Body.Close()
+
+	observeRateLimit(r, selection, resp)
+
+	sizes := trackResponseSize(selection.Vendor, &resp.Body, resp.Header.Get(utils.HeaderContentEncoding) == utils.AcceptEncodingGzip)
+	defer sizes.record()
+
+	responseBody, err := c.standardizer.processResponseBody(resp.Body, resp.Header.Get(utils.HeaderContentEncoding), selection.Vendor)
+	if resp.StatusCode >= 400 {
+		if err != nil {
+			return ParseVendorError(selection.Vendor, resp.StatusCode, nil)
+		}
+		vendorErr := ParseVendorError(selection.Vendor, resp.StatusCode, responseBody)
+		logger.Warn(r.Context(), "Vendor API error detected",
+			"vendor", selection.Vendor,
+			"status_code", resp.StatusCode,
+			"retriable", IsRetriableAPIError(vendorErr),
+			"response_body", string(responseBody),
+			"component", "APIClient",
+			"stage", "VendorAPIError",
+		)
+		return vendorErr
+	}
+	if err != nil {
+		logger.Error(r.Context(), "Error processing response body", err,
+			"vendor", selection.Vendor,
+			"component", "APIClient",
+			"stage", "ResponseBodyProcessing",
+		)
+		return err
+	}
+	sizes.uncompressed = int64(len(responseBody))
+
+	modifiedResponse, err := ProcessEmbeddingsResponse(responseBody, originalModel)
+	if err != nil {
+		logger.Error(r.Context(), "Error processing embeddings response", err,
+			"vendor", selection.Vendor,
+			"response_size_bytes", len(responseBody),
+			"component", "APIClient",
+			"stage", "ResponseProcessing",
+		)
+		return err
+	}
+
+	promptTokens, _ := responseUsage(modifiedResponse)
+	recordUsage(r.Context(), promptTokens, 0)
+	setCostHeader(r.Context(), w.Header())
+
+	shouldCompress := c.standardizer.shouldCompress(r)
+	finalResponse := modifiedResponse
+	if shouldCompress {
+		compressed, err := c.standardizer.compressResponseMandatory(modifiedResponse)
+		if err != nil {
+			logger.Error(r.Context(), "Error compressing response", err,
+				"vendor", selection.Vendor,
+				"component", "APIClient",
+				"stage", "ResponseCompression",
+			)
+			// Fall back to uncompressed if compression fails
+			shouldCompress = false
+		} else {
+			finalResponse = compressed
+			w.Header().Set(utils.HeaderContentEncoding, utils.AcceptEncodingGzip)
+		}
+	}
+
+	c.standardizer.setCompliantHeaders(w, selection.Vendor, len(finalResponse), shouldCompress)
+	setRouterHeaders(r, w.Header())
+	if _, err := w.Write(finalResponse); err != nil {
+		logger.Error(r.Context(), "Error writing response", err,
+			"vendor", selection.Vendor,
+			"component", "APIClient",
+			"stage", "ResponseWriting",
+		)
+		return err
+	}
+
+	logger.Info(r.Context(), "Embeddings response sent to client",
+		"vendor", selection.Vendor,
+		"model", selection.Model,
+		"original_model", originalModel,
+		"original_response_size", len(responseBody),
+		"final_response_size", len(finalResponse),
+		"compressed", shouldCompress,
+		"component", "APIClient",
+		"stage", "FinalResponseSent",
+	)
+	return nil
+}
+
+// ProcessEmbeddingsResponse normalizes a vendor embeddings response into the OpenAI shape:
+// a "list" of "embedding" objects with their input index, the model the client asked for
+// and usage with prompt and total token counts
+func ProcessEmbeddingsResponse(body []byte, originalModel string) ([]byte, error) {
+	var response map[string]interface{}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	items, ok := response["data"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: missing required field 'data'", ErrInvalidResponse)
+	}
+	data := make([]interface{}, 0, len(items))
+	for i, raw := range items {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: data item %d is not an object", ErrInvalidResponse, i)
+		}
+		embedding, exists := item["embedding"]
+		if !exists {
+			return nil, fmt.Errorf("%w: data item %d is missing 'embedding'", ErrInvalidResponse, i)
+		}
+		index, ok := item["index"].(float64)
+		if !ok {
+			index = float64(i)
+		}
+		data = append(data, map[string]interface{}{
+			"object":    "embedding",
+			"index":     int(index),
+			"embedding": embedding,
+		})
+	}
+
+	usage, _ := response["usage"].(map[string]interface{})
+	promptTokens := tokenCount(usage, "prompt_tokens")
+	totalTokens := tokenCount(usage, "total_tokens")
+	if totalTokens == 0 {
+		totalTokens = promptTokens
+	}
+
+	return codec.Marshal(map[string]interface{}{
+		"object": "list",
+		"data":   data,
+		"model":  originalModel,
+		"usage": map[string]interface{}{
+			"prompt_tokens": promptTokens,
+			"total_tokens":  totalTokens,
+		},
+	})
+}
+
+// tokenCount returns a usage count, or zero when it is missing
+func tokenCount(usage map[string]interface{}, field string) int {
+	count, _ := usage[field].(float64)
+	return int(count)
+}
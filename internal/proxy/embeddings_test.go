@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessEmbeddingsResponse(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "OpenAI response",
+			body:     `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2]}],"model":"text-embedding-3-small","usage":{"prompt_tokens":5,"total_tokens":5}}`,
+			expected: `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2]}],"model":"my-model","usage":{"prompt_tokens":5,"total_tokens":5}}`,
+		},
+		{
+			name:     "vendor extras are dropped and usage completed",
+			body:     `{"id":"emb-1","object":"list","data":[{"embedding":[0.1]},{"embedding":"AAAA"}],"model":"mistral-embed","usage":{"prompt_tokens":7,"completion_tokens":0}}`,
+			expected: `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1]},{"object":"embedding","index":1,"embedding":"AAAA"}],"model":"my-model","usage":{"prompt_tokens":7,"total_tokens":7}}`,
+		},
+		{
+			name:     "missing usage",
+			body:     `{"data":[{"object":"embedding","index":0,"embedding":[1]}]}`,
+			expected: `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1]}],"model":"my-model","usage":{"prompt_tokens":0,"total_tokens":0}}`,
+		},
+		{name: "missing data", body: `{"object":"list"}`, expectedError: "missing required field 'data'"},
+		{name: "missing embedding", body: `{"data":[{"index":0}]}`, expectedError: "data item 0 is missing 'embedding'"},
+		{name: "invalid JSON", body: `not json`, expectedError: ErrInvalidResponse.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processed, err := ProcessEmbeddingsResponse([]byte(tt.body), "my-model")
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				assert.True(t, errors.Is(err, ErrInvalidResponse))
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(processed))
+		})
+	}
+}
+
+func TestProxyEmbeddingsRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		vendorStatus   int
+		vendorResponse string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "routes to the selected model",
+			body:           `{"model":"my-embeddings","input":["first","second"],"dimensions":256}`,
+			vendorStatus:   http.StatusOK,
+			vendorResponse: `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1]},{"object":"embedding","index":1,"embedding":[0.2]}],"model":"text-embedding-3-small","usage":{"prompt_tokens":2,"total_tokens":2}}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1]},{"object":"embedding","index":1,"embedding":[0.2]}],"model":"my-embeddings","usage":{"prompt_tokens":2,"total_tokens":2}}`,
+		},
+		{
+			name:           "invalid request",
+			body:           `{"model":"my-embeddings","input":[]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "vendor error",
+			body:           `{"input":"hello"}`,
+			vendorStatus:   http.StatusBadRequest,
+			vendorResponse: `{"error":{"message":"invalid dimensions","type":"invalid_request_error"}}`,
+			expectedStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vendorRequest map[string]interface{}
+			vendorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/embeddings", r.URL.Path)
+				assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+				body, _ := io.ReadAll(r.Body)
+				require.NoError(t, json.Unmarshal(body, &vendorRequest))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.vendorStatus)
+				_, _ = w.Write([]byte(tt.vendorResponse))
+			}))
+			defer vendorServer.Close()
+
+			creds := []config.Credential{
+				{Platform: "openai", Type: "api-key", Value: "sk-test"},
+				{Platform: "anthropic", Type: "api-key", Value: "sk-ant-test"},
+			}
+			models := []config.VendorModel{
+				{Vendor: "openai", Model: "text-embedding-3-small", Type: config.ModelTypeEmbedding},
+				{Vendor: "anthropic", Model: "claude-embed", Type: config.ModelTypeEmbedding},
+			}
+			mockSelector := &MockSelector{}
+			mockSelector.On("Select", creds[:1], models[:1]).Return(&selector.VendorSelection{Vendor: "openai", Model: "text-embedding-3-small", Credential: creds[0]}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/embeddings", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			ProxyEmbeddingsRequest(rr, req, creds, models, NewAPIClient(map[string]string{"openai": vendorServer.URL}), mockSelector)
+
+			require.Equal(t, tt.expectedStatus, rr.Code, rr.Body.String())
+			if tt.expectedStatus == http.StatusBadRequest {
+				assert.Nil(t, vendorRequest, "invalid requests never reach a vendor")
+				return
+			}
+			mockSelector.AssertExpectations(t)
+			assert.Equal(t, "text-embedding-3-small", vendorRequest["model"])
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+				assert.Equal(t, float64(256), vendorRequest["dimensions"])
+			}
+		})
+	}
+}
+
+func TestSupportsEmbeddings(t *testing.T) {
+	for _, vendor := range []string{"openai", "gemini", "mistral", "together", "huggingface", "ollama"} {
+		assert.True(t, SupportsEmbeddings(vendor), vendor)
+	}
+	for _, vendor := range []string{"anthropic", "cohere", "vertex"} {
+		assert.False(t, SupportsEmbeddings(vendor), vendor)
+	}
+	assert.Equal(t, "https://xyz.endpoints.huggingface.cloud/v1/embeddings",
+		NewHuggingFaceAdapter().EmbeddingsEndpoint("https://xyz.endpoints.huggingface.cloud"))
+	assert.Equal(t, "http://localhost:11434/v1/embeddings", NewOllamaAdapter().EmbeddingsEndpoint("http://localhost:11434"))
+}
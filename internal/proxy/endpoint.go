@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/events"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/ratelimit"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+)
+
+// Steps shared by the embeddings, transcriptions, speech, moderations and rerank endpoints,
+// which route one request to one vendor model without the chat pipeline's payload analysis
+
+// restrictEndpointPool restricts the pool to what the client key's ACL permits, minus the
+// exclusions of a JSON body; multipart requests pass a nil body and cannot exclude routes.
+// It answers the request and returns false when it is rejected: 403 when the key may not
+// exclude routes or use any of the pool, 400 for invalid or unsatisfiable exclusions
+func restrictEndpointPool(ctx context.Context, w http.ResponseWriter, r *http.Request, body []byte, originalModel string, start time.Time,
+	creds []config.Credential, models []config.VendorModel) (*http.Request, []config.Credential, []config.VendorModel, bool) {
+	var exclusions *routingExclusions
+	if body != nil {
+		var err error
+		exclusions, err = parseRoutingExclusions(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return r, nil, nil, false
+		}
+		r = r.WithContext(withRoutingExclusions(r.Context(), exclusions))
+	}
+
+	candidateCount := len(models)
+	creds, models, err := restrictRoutingPool(access.Default().PolicyFor(r), exclusions, creds, models)
+	if err != nil {
+		logger.Warn(logger.WithStage(ctx, "routing_access"), "Routing pool restriction rejected request", "error", err.Error())
+		recordSelectionFailure(r, originalModel, nil, candidateCount, start, err)
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrExclusionsDenied) || errors.Is(err, ErrNoPermittedRoute) {
+			status = http.StatusForbidden
+		}
+		http.Error(w, err.Error(), status)
+		return r, nil, nil, false
+	}
+	return r, creds, models, true
+}
+
+// selectEndpointVendor selects the vendor model a request is sent to and starts tracking its
+// routing decision, so it can be queried later via the admin endpoint. It answers the
+// request with a 500 and returns false when nothing can be selected
+func selectEndpointVendor(ctx context.Context, w http.ResponseWriter, r *http.Request, creds []config.Credential, models []config.VendorModel,
+	modelSelector selector.Selector, originalModel string, start time.Time) (*http.Request, *selector.VendorSelection, *routingDecision, bool) {
+	selection, err := modelSelector.Select(creds, models)
+	if err != nil {
+		logger.Error(logger.WithStage(ctx, "vendor_selection"), "Vendor selection failed", err)
+		recordSelectionFailure(r, originalModel, nil, len(models), start, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return r, nil, nil, false
+	}
+
+	decision := newRoutingDecision(r, selection, originalModel, nil, models)
+	r = r.WithContext(withRoutingDecision(r.Context(), decision))
+	publishEvent(r, events.Event{Type: events.VendorSelected, OriginalModel: originalModel, Vendor: selection.Vendor, Model: selection.Model})
+	return r, selection, decision, true
+}
+
+// doVendorRequest sends an endpoint's request to the vendor, recording its payload size,
+// its duration and the vendor's response. The request counts against the credential's
+// concurrency cap until the returned release is called, once its response is handled;
+// release is nil when the vendor could not be reached
+func (c *APIClient) doVendorRequest(r, req *http.Request, selection *selector.VendorSelection, body []byte, originalModel string) (*http.Response, func(), error) {
+	monitoring.DefaultPayloadSizeMetrics().RecordRequest(selection.Vendor, body)
+	startTime := time.Now()
+	release := ratelimit.DefaultInFlight().Acquire(selection.Credential)
+	resp, err := c.httpClientFor(selection.Vendor).Do(req)
+	duration := time.Since(startTime)
+	monitoring.ServerTimingFromContext(r.Context()).Add(monitoring.TimingVendor, duration)
+
+	responded := events.Event{Type: events.VendorResponded, OriginalModel: originalModel, Vendor: selection.Vendor, Model: selection.Model, Duration: duration}
+	if err != nil {
+		responded.Error = err.Error()
+	} else {
+		responded.StatusCode = resp.StatusCode
+	}
+	publishEvent(r, responded)
+
+	if err != nil {
+		release()
+		logger.Error(r.Context(), "vendor communication failed", err,
+			"vendor", selection.Vendor,
+			"url", req.URL.String(),
+			"component", "APIClient",
+			"stage", "VendorCommunication",
+		)
+		return nil, nil, fmt.Errorf("failed to send request to vendor: %v", err)
+	}
+	return resp, release, nil
+}
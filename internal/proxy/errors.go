@@ -46,14 +46,11 @@ func (e *VendorAPIError) IsRetriable() bool {
 	return e.Retriable
 }
 
-// IsRetriableValidationError checks if the error is retriable (missing choices from Gemini)
+// IsRetriableValidationError checks if the error is a vendor response that failed
+// validation, such as one missing its choices, which another vendor may answer properly
 func IsRetriableValidationError(err error) bool {
 	var vendorErr *VendorValidationError
-	if errors.As(err, &vendorErr) {
-		// Only retry if it's Gemini with missing 'choices' field
-		return vendorErr.Vendor == "gemini" && vendorErr.MissingField == "choices"
-	}
-	return false
+	return errors.As(err, &vendorErr)
 }
 
 // IsRetriableAPIError checks if the API error is retriable with backoff
@@ -83,6 +80,16 @@ func ParseVendorError(vendor string, statusCode int, responseBody []byte) error
 		return nil
 	}
 
+	apiErr := classifyVendorError(vendor, statusCode, responseBody)
+	if parser, ok := adapterFor(vendor).(ErrorParser); ok {
+		parser.ParseError(apiErr, responseBody)
+	}
+	return apiErr
+}
+
+// classifyVendorError builds a VendorAPIError from common error patterns and the HTTP status
+func classifyVendorError(vendor string, statusCode int, responseBody []byte) *VendorAPIError {
+
 	// Try to parse JSON error response
 	if len(responseBody) > 0 {
 		// Simple JSON parsing without importing json package
@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+)
+
+// Errors returned when the routing pool cannot be restricted for a request
+var (
+	ErrExclusionsDenied  = errors.New("routing exclusions are not permitted for this client key")
+	ErrNoPermittedRoute  = errors.New("no vendor or model is permitted for this client key")
+	ErrAllRoutesExcluded = errors.New("routing exclusions leave no vendor or model to route to")
+	ErrInvalidExclusions = errors.New("router.exclude_vendors and router.exclude_models must be arrays of strings")
+)
+
+// routingExclusionsKey is the context key holding a request's routing exclusions
+type routingExclusionsKey struct{}
+
+// routingExclusions are the vendors and models a client asked not to be routed to,
+// sent in the request body as router.exclude_vendors and router.exclude_models
+// Models are given as "model" (any vendor) or "vendor/model"
+type routingExclusions struct {
+	Vendors []string `json:"exclude_vendors"`
+	Models  []string `json:"exclude_models"`
+}
+
+// parseRoutingExclusions reads the router object of a request body, returning nil when
+// the request excludes nothing. Malformed bodies are left for request validation to report
+func parseRoutingExclusions(body []byte) (*routingExclusions, error) {
+	var request struct {
+		Router interface{} `json:"router"`
+	}
+	if err := codec.Unmarshal(body, &request); err != nil || request.Router == nil {
+		return nil, nil
+	}
+
+	router, ok := request.Router.(map[string]interface{})
+	if !ok {
+		return nil, ErrInvalidExclusions
+	}
+	raw, err := codec.Marshal(router)
+	if err != nil {
+		return nil, ErrInvalidExclusions
+	}
+	var exclusions routingExclusions
+	if err := codec.Unmarshal(raw, &exclusions); err != nil {
+		return nil, ErrInvalidExclusions
+	}
+	if len(exclusions.Vendors) == 0 && len(exclusions.Models) == 0 {
+		return nil, nil
+	}
+	return &exclusions, nil
+}
+
+// excludes reports whether the vendor/model pair was excluded
+func (e *routingExclusions) excludes(vendor, model string) bool {
+	for _, excluded := range e.Vendors {
+		if strings.EqualFold(excluded, vendor) {
+			return true
+		}
+	}
+	for _, entry := range e.Models {
+		if access.MatchesModel(entry, vendor, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictRoutingPool removes the vendor/model pairs the client key's policy does not permit,
+// then those the request excluded, along with credentials left without models
+func restrictRoutingPool(policy access.Policy, exclusions *routingExclusions, creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel, error) {
+	if exclusions != nil && policy.DenyExclusions {
+		return nil, nil, ErrExclusionsDenied
+	}
+
+	permitted := models[:0:0]
+	for _, model := range models {
+		if policy.Permits(model.Vendor, model.Model) {
+			permitted = append(permitted, model)
+		}
+	}
+	if len(permitted) == 0 && len(models) > 0 {
+		return nil, nil, ErrNoPermittedRoute
+	}
+
+	if exclusions != nil {
+		remaining := permitted[:0:0]
+		for _, model := range permitted {
+			if !exclusions.excludes(model.Vendor, model.Model) {
+				remaining = append(remaining, model)
+			}
+		}
+		if len(remaining) == 0 {
+			return nil, nil, ErrAllRoutesExcluded
+		}
+		permitted = remaining
+	}
+
+	vendors := make(map[string]bool)
+	for _, model := range permitted {
+		vendors[model.Vendor] = true
+	}
+	remainingCreds := creds[:0:0]
+	for _, cred := range creds {
+		if vendors[cred.Platform] {
+			remainingCreds = append(remainingCreds, cred)
+		}
+	}
+	return remainingCreds, permitted, nil
+}
+
+// withRoutingExclusions attaches the request's exclusions so routing decisions can record them
+func withRoutingExclusions(ctx context.Context, exclusions *routingExclusions) context.Context {
+	return context.WithValue(ctx, routingExclusionsKey{}, exclusions)
+}
+
+// routingExclusionsFromContext returns the request's exclusions, or nil when there are none
+func routingExclusionsFromContext(ctx context.Context) *routingExclusions {
+	exclusions, _ := ctx.Value(routingExclusionsKey{}).(*routingExclusions)
+	return exclusions
+}
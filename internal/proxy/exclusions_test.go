@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRoutingExclusions(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected *routingExclusions
+		wantErr  bool
+	}{
+		{"no router field", `{"model":"gpt-4o"}`, nil, false},
+		{"empty router", `{"router":{}}`, nil, false},
+		{"vendors and models", `{"router":{"exclude_vendors":["openai"],"exclude_models":["gemini/gemini-2.0-flash"]}}`,
+			&routingExclusions{Vendors: []string{"openai"}, Models: []string{"gemini/gemini-2.0-flash"}}, false},
+		{"router not an object", `{"router":"openai"}`, nil, true},
+		{"vendors not an array", `{"router":{"exclude_vendors":"openai"}}`, nil, true},
+		{"malformed body left to validation", `{"router":`, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exclusions, err := parseRoutingExclusions([]byte(tt.body))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidExclusions)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, exclusions)
+		})
+	}
+}
+
+func TestRestrictRoutingPool(t *testing.T) {
+	creds := []config.Credential{{Platform: "openai"}, {Platform: "gemini"}, {Platform: "mistral"}}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o"},
+		{Vendor: "gemini", Model: "gemini-2.0-flash"},
+		{Vendor: "mistral", Model: "mistral-large-latest"},
+	}
+
+	t.Run("unrestricted without exclusions", func(t *testing.T) {
+		keptCreds, keptModels, err := restrictRoutingPool(access.Policy{}, nil, creds, models)
+		require.NoError(t, err)
+		assert.Equal(t, creds, keptCreds)
+		assert.Equal(t, models, keptModels)
+	})
+
+	t.Run("exclusions narrow the pool", func(t *testing.T) {
+		exclusions := &routingExclusions{Vendors: []string{"openai"}, Models: []string{"gemini-2.0-flash"}}
+		keptCreds, keptModels, err := restrictRoutingPool(access.Policy{}, exclusions, creds, models)
+		require.NoError(t, err)
+		assert.Equal(t, []config.Credential{{Platform: "mistral"}}, keptCreds)
+		assert.Equal(t, []config.VendorModel{{Vendor: "mistral", Model: "mistral-large-latest"}}, keptModels)
+	})
+
+	t.Run("policy applies before exclusions", func(t *testing.T) {
+		policy := access.Policy{AllowedVendors: []string{"openai", "mistral"}}
+		_, _, err := restrictRoutingPool(policy, &routingExclusions{Vendors: []string{"openai", "mistral"}}, creds, models)
+		assert.ErrorIs(t, err, ErrAllRoutesExcluded)
+
+		keptCreds, _, err := restrictRoutingPool(policy, nil, creds, models)
+		require.NoError(t, err)
+		assert.Equal(t, []config.Credential{{Platform: "openai"}, {Platform: "mistral"}}, keptCreds)
+	})
+
+	t.Run("policy permits nothing", func(t *testing.T) {
+		_, _, err := restrictRoutingPool(access.Policy{AllowedVendors: []string{"cohere"}}, nil, creds, models)
+		assert.ErrorIs(t, err, ErrNoPermittedRoute)
+	})
+
+	t.Run("exclusions denied", func(t *testing.T) {
+		_, _, err := restrictRoutingPool(access.Policy{DenyExclusions: true}, &routingExclusions{Vendors: []string{"openai"}}, creds, models)
+		assert.ErrorIs(t, err, ErrExclusionsDenied)
+	})
+}
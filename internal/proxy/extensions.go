@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Response extensions; each is a field of the "extensions" object added to responses and chunks,
+// except for the stream summary
+const (
+	// ExtensionReproducibility reports the seed, vendor, model and vendor fingerprint of seeded requests
+	ExtensionReproducibility = "reproducibility"
+	// ExtensionRoute reports the vendor and model that served the request
+	ExtensionRoute = "route"
+	// ExtensionAttempts reports how many vendor requests were made, including retries and fallbacks
+	ExtensionAttempts = "attempts"
+	// ExtensionWatermark reports signed metadata proving which model produced the response; it
+	// is only emitted when WATERMARK_KEY is set
+	ExtensionWatermark = "watermark"
+	// ExtensionSummary ends streams with a router.summary event after [DONE] instead of adding
+	// an "extensions" field
+	ExtensionSummary = "summary"
+)
+
+// Route names the vendor and model that served a request
+type Route struct {
+	Vendor string `json:"vendor"`
+	Model  string `json:"model"`
+}
+
+// enabledExtensions returns the extensions to emit for a request: those of the client key's
+// ACL policy when it sets any, otherwise RESPONSE_EXTENSIONS. None are emitted by default,
+// so responses stay identical to OpenAI's unless a deployment or key opts in
+func enabledExtensions(r *http.Request) map[string]bool {
+	names := access.Default().PolicyFor(r).Extensions
+	if names == nil {
+		names = utils.GetEnvStringSlice("RESPONSE_EXTENSIONS", nil)
+	}
+	enabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		enabled[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	return enabled
+}
+
+// responseExtensions collects the enabled extension values for a response served by vendor/model
+// info is the reproducibility record of a seeded request, or nil. watermark returns the unsigned
+// watermark of the response and is only called when it is emitted. Returns nil when nothing applies
+func responseExtensions(r *http.Request, vendor, model string, info *Reproducibility, watermark func() *Watermark) map[string]interface{} {
+	enabled := enabledExtensions(r)
+	if len(enabled) == 0 {
+		return nil
+	}
+
+	extensions := make(map[string]interface{})
+	if enabled[ExtensionReproducibility] && info != nil {
+		extensions[ExtensionReproducibility] = info
+	}
+	if enabled[ExtensionRoute] {
+		extensions[ExtensionRoute] = Route{Vendor: vendor, Model: model}
+	}
+	if decision := routingDecisionFromContext(r.Context()); enabled[ExtensionAttempts] && decision != nil {
+		extensions[ExtensionAttempts] = decision.Attempts
+	}
+	if key := watermarkKey(); enabled[ExtensionWatermark] && watermark != nil && key != nil {
+		if unsigned := watermark(); unsigned != nil {
+			extensions[ExtensionWatermark] = signWatermark(key, *unsigned, vendor, model)
+		}
+	}
+	if len(extensions) == 0 {
+		return nil
+	}
+	return extensions
+}
+
+// addExtensions attaches extension values to a response body as an "extensions" object
+func addExtensions(body []byte, extensions map[string]interface{}) ([]byte, error) {
+	var response map[string]interface{}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response for extensions: %w", err)
+	}
+	response["extensions"] = extensions
+	return codec.Marshal(response)
+}
@@ -0,0 +1,82 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseExtensions(t *testing.T) {
+	access.SetDefault(access.NewACL(access.Policy{}, map[string]access.Policy{
+		"sk-verbose": {Extensions: []string{"route", "attempts"}},
+		"sk-quiet":   {Extensions: []string{}},
+	}))
+	t.Cleanup(func() { access.SetDefault(access.NewACL(access.Policy{}, nil)) })
+
+	info := &Reproducibility{Seed: 7, Vendor: "openai", Model: "gpt-4o"}
+	tests := []struct {
+		name     string
+		env      string
+		key      string
+		seeded   bool
+		expected map[string]interface{}
+	}{
+		{name: "nothing enabled by default", seeded: true, expected: nil},
+		{name: "deployment setting", env: "reproducibility, route", seeded: true, expected: map[string]interface{}{
+			"reproducibility": info,
+			"route":           Route{Vendor: "openai", Model: "gpt-4o"},
+		}},
+		{name: "reproducibility only for seeded requests", env: "reproducibility", expected: nil},
+		{name: "key policy overrides deployment", env: "reproducibility", key: "sk-verbose", seeded: true, expected: map[string]interface{}{
+			"route":    Route{Vendor: "openai", Model: "gpt-4o"},
+			"attempts": 2,
+		}},
+		{name: "key policy disables extensions", env: "route", key: "sk-quiet", expected: nil},
+		{name: "unknown key uses deployment setting", env: "route", key: "sk-other", expected: map[string]interface{}{
+			"route": Route{Vendor: "openai", Model: "gpt-4o"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RESPONSE_EXTENSIONS", tt.env)
+			r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+			if tt.key != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			decision := &routingDecision{}
+			decision.Attempts = 2
+			r = r.WithContext(withRoutingDecision(r.Context(), decision))
+
+			var seeded *Reproducibility
+			if tt.seeded {
+				seeded = info
+			}
+			assert.Equal(t, tt.expected, responseExtensions(r, "openai", "gpt-4o", seeded, nil))
+		})
+	}
+}
+
+func TestAddExtensions(t *testing.T) {
+	info := &Reproducibility{Seed: 7, Vendor: "openai", Model: "gpt-4o", SystemFingerprint: "fp_abc"}
+
+	body, err := addExtensions([]byte(`{"id":"chatcmpl-1","choices":[]}`), map[string]interface{}{
+		ExtensionReproducibility: info,
+		ExtensionAttempts:        1,
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "chatcmpl-1",
+		"choices": [],
+		"extensions": {
+			"reproducibility": {"seed": 7, "vendor": "openai", "model": "gpt-4o", "system_fingerprint": "fp_abc"},
+			"attempts": 1
+		}
+	}`, string(body))
+
+	_, err = addExtensions([]byte(`not json`), nil)
+	assert.Error(t, err)
+}
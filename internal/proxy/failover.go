@@ -0,0 +1,193 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/admission"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/reliability"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// DefaultFailoverMaxHops is how many other vendor models or credentials a failed request is
+// replayed on, overridable with FAILOVER_MAX_HOPS
+const DefaultFailoverMaxHops = 3
+
+// Failed attempt reasons of errors without an HTTP status
+const (
+	// reasonInvalidResponse is a response that failed validation
+	reasonInvalidResponse = "invalid_response"
+	// reasonStreamInterrupted is a stream that failed before any content reached the client
+	reasonStreamInterrupted = "stream_interrupted"
+)
+
+// shouldFailOver reports whether another vendor or credential may succeed where one failed:
+// a rate limit, quota or server error that outlasted the retries, a response that failed
+// validation, or a stream interrupted before any content reached the client
+func shouldFailOver(err error) bool {
+	return IsRetriableAPIError(err) || IsRetriableValidationError(err) || isRestartableStreamError(err)
+}
+
+// failOver replays a request whose vendor failed with an error shouldFailOver accepts on
+// other vendor models and credentials, one attempt each and at most FAILOVER_MAX_HOPS of
+// them. The selector picks among the models not yet tried, so with a priority chain the rest
+// of the failed model's tier comes first and then the lower tiers; once every model has been
+// tried, the credentials that have not failed get a turn. It stops at the first success, at
+// an error another vendor would not avoid, or when the hops or the pool run out, answering
+// with the last vendor's error. Every failed attempt is recorded in the routing decision and,
+// when the client opted in, the X-Router-Failed-Attempts header
+func failOver(ctx context.Context, w http.ResponseWriter, r *http.Request, err error, selection *selector.VendorSelection, body, processedBody []byte,
+	creds []config.Credential, models []config.VendorModel, apiClient APIClientInterface, modelSelector selector.Selector, originalModel string, decision *routingDecision) error {
+
+	ctx = logger.WithStage(ctx, "vendor_failover")
+	payloadContext, _ := AnalyzePayload(body)
+	if payloadContext != nil {
+		payloadContext.ConversationKey = conversationKey(r)
+	}
+
+	maxHops := utils.GetEnvInt("FAILOVER_MAX_HOPS", DefaultFailoverMaxHops)
+	triedModels := make(map[string]bool)
+	failedCreds := make(map[string]bool)
+	failed := selection
+	for hop := 1; shouldFailOver(err); hop++ {
+		triedModels[failed.Vendor+"/"+failed.Model] = true
+		failedCreds[credentialID(failed.Credential)] = true
+		recordFailedAttempt(w, r, decision, failed, err)
+
+		if hop > maxHops {
+			logger.Warn(ctx, "Failover hops exhausted", "max_hops", maxHops, "last_vendor", failed.Vendor, "last_model", failed.Model)
+			break
+		}
+		remainingCreds, remaining := failoverPool(creds, models, triedModels, failedCreds)
+		remainingCreds, remaining = admission.DefaultLimiter().Filter(remainingCreds, remaining)
+		if len(remaining) == 0 {
+			logger.Warn(ctx, "No vendor left to fail over to", "last_vendor", failed.Vendor, "last_model", failed.Model)
+			break
+		}
+
+		var next *selector.VendorSelection
+		var selectErr error
+		if contextSelector, ok := modelSelector.(selector.ContextSelector); ok && payloadContext != nil {
+			next, selectErr = contextSelector.SelectWithContext(remainingCreds, remaining, payloadContext)
+		} else {
+			next, selectErr = modelSelector.Select(remainingCreds, remaining)
+		}
+		if selectErr != nil {
+			logger.Warn(ctx, "No vendor left to fail over to", "error", selectErr.Error())
+			break
+		}
+
+		// Each hop spends a retry of the client key's budget; once it is spent the vendor's
+		// error is returned as it is
+		if !reliability.DefaultRetryBudget().AllowRetry(access.ClientKey(r)) {
+			logger.Warn(ctx, "Retry budget exhausted, not failing over", "last_vendor", failed.Vendor, "last_model", failed.Model)
+			break
+		}
+
+		logger.Warn(ctx, "Vendor failed, failing over",
+			"hop", hop,
+			"failed_vendor", failed.Vendor,
+			"failed_model", failed.Model,
+			"fallback_vendor", next.Vendor,
+			"fallback_model", next.Model,
+			"error", err.Error())
+
+		retryReq, modifiedBody, validationErr := prepareFallbackRequest(r, next, processedBody)
+		if validationErr != nil {
+			http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
+			return validationErr
+		}
+
+		// The hop takes a slot on its own vendor, giving back the failed vendor's
+		if slot := vendorSlotFromContext(r.Context()); slot != nil {
+			if slotErr := slot.moveTo(w, r, next.Vendor); slotErr != nil {
+				return slotErr
+			}
+		}
+
+		decision.Attempts++
+		decision.FallbackVendor = next.Vendor
+		decision.FallbackModel = next.Model
+		decision.Credential = credentialLabel(next.Credential)
+		err = apiClient.SendRequest(w, retryReq, next, modifiedBody, originalModel)
+		if err == nil {
+			logger.Info(ctx, "Request served after failing over",
+				"vendor", next.Vendor,
+				"model", next.Model,
+				"failed_attempts", decision.FailedAttempts)
+			return nil
+		}
+		failed = next
+	}
+
+	writeUpstreamError(ctx, w, err, failed.Vendor)
+	return err
+}
+
+// failoverPool returns the part of the pool a request may fail over to: the models not yet
+// tried on credentials that have not failed, else on any credential, else the tried models
+// on the credentials that have not failed. Returns empty slices when nothing is left
+func failoverPool(creds []config.Credential, models []config.VendorModel, triedModels, failedCreds map[string]bool) ([]config.Credential, []config.VendorModel) {
+	var freshCreds []config.Credential
+	for _, cred := range creds {
+		if !failedCreds[credentialID(cred)] {
+			freshCreds = append(freshCreds, cred)
+		}
+	}
+	var untried []config.VendorModel
+	for _, model := range models {
+		if !triedModels[model.Vendor+"/"+model.Model] {
+			untried = append(untried, model)
+		}
+	}
+
+	for _, candidate := range []struct {
+		creds  []config.Credential
+		models []config.VendorModel
+	}{{freshCreds, untried}, {creds, untried}, {freshCreds, models}} {
+		poolModels := filter.ModelsForCredentials(candidate.models, candidate.creds)
+		if len(poolModels) > 0 {
+			return filter.CredentialsForModels(candidate.creds, poolModels), poolModels
+		}
+	}
+	return nil, nil
+}
+
+// recordFailedAttempt adds a failed vendor attempt to the routing decision and, when the
+// request's routing metadata headers include it, to the X-Router-Failed-Attempts header of
+// the response, as "vendor/model=reason" entries
+func recordFailedAttempt(w http.ResponseWriter, r *http.Request, decision *routingDecision, failed *selector.VendorSelection, err error) {
+	reason := "error"
+	var apiErr *VendorAPIError
+	if errors.As(err, &apiErr) {
+		reason = strconv.Itoa(apiErr.StatusCode)
+	} else if IsRetriableValidationError(err) {
+		reason = reasonInvalidResponse
+	} else if isRestartableStreamError(err) {
+		reason = reasonStreamInterrupted
+	}
+	decision.FailedAttempts = append(decision.FailedAttempts, monitoring.FailedAttempt{Vendor: failed.Vendor, Model: failed.Model, Credential: credentialLabel(failed.Credential), Reason: reason})
+
+	if !enabledRouterHeaders(r)[RouterHeaderFailedAttempts] {
+		return
+	}
+	entries := make([]string, len(decision.FailedAttempts))
+	for i, attempt := range decision.FailedAttempts {
+		entries[i] = attempt.Vendor + "/" + attempt.Model + "=" + attempt.Reason
+	}
+	w.Header().Set(utils.HeaderXRouterFailedAttempts, strings.Join(entries, ", "))
+}
+
+// credentialID identifies a credential among the pool's
+func credentialID(cred config.Credential) string {
+	return cred.Platform + "\x00" + cred.Value
+}
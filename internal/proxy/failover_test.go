@@ -0,0 +1,162 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingVendorClient fails requests to the listed vendors with a retriable server error and
+// records the models it was asked for
+type failingVendorClient struct {
+	failing map[string]bool
+	models  []string
+}
+
+func (c *failingVendorClient) SendRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error {
+	c.models = append(c.models, selection.Model)
+	if c.failing[selection.Vendor] {
+		return &VendorAPIError{Vendor: selection.Vendor, StatusCode: http.StatusServiceUnavailable, ErrorType: "server_error", Message: "overloaded", Retriable: true}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[]}`))
+	return err
+}
+
+func priorityChainPool() ([]config.Credential, []config.VendorModel) {
+	creds := []config.Credential{
+		{Platform: "openai", Type: "api-key", Value: "sk-openai"},
+		{Platform: "gemini", Type: "api-key", Value: "sk-gemini"},
+		{Platform: "ollama", Type: "api-key", Value: "none"},
+	}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o", Priority: 1},
+		{Vendor: "gemini", Model: "gemini-2.5-pro", Priority: 2},
+		{Vendor: "ollama", Model: "llama3", Priority: 3},
+	}
+	return creds, models
+}
+
+func TestFailOver_PriorityChain(t *testing.T) {
+	body := []byte(`{"model":"any","messages":[{"role":"user","content":"Hi"}]}`)
+	creds, models := priorityChainPool()
+	primary := &selector.VendorSelection{Vendor: "openai", Model: "gpt-4o", Credential: creds[0]}
+	initialErr := &VendorAPIError{Vendor: "openai", StatusCode: http.StatusServiceUnavailable, Retriable: true}
+
+	t.Run("falls to the next healthy tier", func(t *testing.T) {
+		client := &failingVendorClient{failing: map[string]bool{"gemini": true}}
+		decision := &routingDecision{}
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(string(body)))
+
+		err := failOver(context.Background(), w, r, initialErr, primary, body, body, creds, models, client, selector.NewPrioritySelector(selector.NewContextAwareSelector()), "any", decision)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"gemini-2.5-pro", "llama3"}, client.models, "tiers are tried in priority order")
+		assert.Equal(t, "llama3", decision.FallbackModel)
+		assert.Equal(t, 2, decision.Attempts)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("answers with the last error when the chain runs out", func(t *testing.T) {
+		client := &failingVendorClient{failing: map[string]bool{"gemini": true, "ollama": true}}
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(string(body)))
+
+		err := failOver(context.Background(), w, r, initialErr, primary, body, body, creds, models, client, selector.NewPrioritySelector(selector.NewContextAwareSelector()), "any", &routingDecision{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "ollama")
+		assert.Equal(t, []string{"gemini-2.5-pro", "llama3"}, client.models)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+// credentialErrorClient fails requests sent with the listed credential values with their
+// error and records the vendor/model and credential of every request
+type credentialErrorClient struct {
+	errs     map[string]error
+	attempts []string
+}
+
+func (c *credentialErrorClient) SendRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error {
+	c.attempts = append(c.attempts, selection.Vendor+"/"+selection.Model+"@"+selection.Credential.Value)
+	if err := c.errs[selection.Credential.Value]; err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[]}`))
+	return err
+}
+
+func TestFailOver(t *testing.T) {
+	t.Setenv("ROUTER_HEADERS", "failed_attempts")
+	body := []byte(`{"model":"any","messages":[{"role":"user","content":"Hi"}]}`)
+	rateLimited := &VendorAPIError{Vendor: "openai", StatusCode: http.StatusTooManyRequests, ErrorType: "rate_limit_exceeded", Retriable: true}
+	invalid := &VendorValidationError{Vendor: "gemini", MissingField: "choices"}
+	creds := []config.Credential{
+		{Platform: "openai", Value: "sk-1"},
+		{Platform: "openai", Value: "sk-2"},
+		{Platform: "gemini", Value: "gm-1"},
+	}
+	primary := &selector.VendorSelection{Vendor: "openai", Model: "gpt-4o", Credential: creds[0]}
+
+	run := func(t *testing.T, client *credentialErrorClient, models []config.VendorModel) (*httptest.ResponseRecorder, *routingDecision, error) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(string(body)))
+		decision := &routingDecision{}
+		err := failOver(context.Background(), w, r, rateLimited, primary, body, body, creds, models, client, selector.NewContextAwareSelector(), "any", decision)
+		return w, decision, err
+	}
+
+	t.Run("moves to another vendor and records the failed attempts", func(t *testing.T) {
+		client := &credentialErrorClient{errs: map[string]error{"sk-2": rateLimited}}
+		models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-2.5-flash"}}
+
+		w, decision, err := run(t, client, models)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"gemini/gemini-2.5-flash@gm-1"}, client.attempts, "the failed model is left for untried ones")
+		assert.Equal(t, "openai/gpt-4o=429", w.Header().Get("X-Router-Failed-Attempts"))
+		assert.Equal(t, "gemini", decision.FallbackVendor)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("fails over on invalid responses", func(t *testing.T) {
+		client := &credentialErrorClient{errs: map[string]error{"gm-1": invalid}}
+		models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-2.5-flash"}}
+
+		w, decision, err := run(t, client, models)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"gemini/gemini-2.5-flash@gm-1", "openai/gpt-4o@sk-2"}, client.attempts, "the failed credential is left out once every model was tried")
+		assert.Equal(t, "openai/gpt-4o=429, gemini/gemini-2.5-flash=invalid_response", w.Header().Get("X-Router-Failed-Attempts"))
+		require.Len(t, decision.FailedAttempts, 2)
+		assert.Equal(t, "invalid_response", decision.FailedAttempts[1].Reason)
+	})
+
+	t.Run("stops after FAILOVER_MAX_HOPS", func(t *testing.T) {
+		t.Setenv("FAILOVER_MAX_HOPS", "1")
+		client := &credentialErrorClient{errs: map[string]error{"gm-1": rateLimited, "sk-2": rateLimited}}
+		models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-2.5-flash"}}
+
+		w, _, err := run(t, client, models)
+		require.Error(t, err)
+		assert.Len(t, client.attempts, 1)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "openai/gpt-4o=429, gemini/gemini-2.5-flash=429", w.Header().Get("X-Router-Failed-Attempts"))
+	})
+
+	t.Run("does not fail over errors another vendor would repeat", func(t *testing.T) {
+		client := &credentialErrorClient{errs: map[string]error{"gm-1": &VendorAPIError{Vendor: "gemini", StatusCode: http.StatusBadRequest, ErrorType: "invalid_request"}}}
+		models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-2.5-flash"}}
+
+		w, _, err := run(t, client, models)
+		require.Error(t, err)
+		assert.Len(t, client.attempts, 1)
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+	})
+}
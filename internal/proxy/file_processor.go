@@ -2,16 +2,43 @@ package proxy
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/aashari/go-generative-api-router/internal/files"
 	"github.com/aashari/go-generative-api-router/internal/logger"
 	"github.com/aashari/go-generative-api-router/internal/utils"
 )
 
+// Default size limits of downloaded media, overridden per deployment with MEDIA_MAX_BYTES
+// (images and files) and AUDIO_MAX_BYTES
+const (
+	DefaultMediaMaxBytes = 20 << 20
+	DefaultAudioMaxBytes = 25 << 20
+)
+
+// mediaMaxBytes returns the size limit of downloaded images and files
+func mediaMaxBytes() int64 {
+	return int64(utils.GetEnvInt("MEDIA_MAX_BYTES", DefaultMediaMaxBytes))
+}
+
+// audioMaxBytes returns the size limit of downloaded audio
+func audioMaxBytes() int64 {
+	return int64(utils.GetEnvInt("AUDIO_MAX_BYTES", DefaultAudioMaxBytes))
+}
+
+// formatSizeLimit formats a size limit for messages, e.g. "20MB"
+func formatSizeLimit(bytes int64) string {
+	if bytes%(1<<20) == 0 {
+		return fmt.Sprintf("%dMB", bytes>>20)
+	}
+	return fmt.Sprintf("%.1fMB", float64(bytes)/(1<<20))
+}
+
 // FileProcessor handles file processing operations with intelligent routing
 type FileProcessor struct {
 	imageProcessor *ImageProcessor
@@ -28,7 +55,7 @@ func NewFileProcessor() *FileProcessor {
 		httpClient: &http.Client{
 			Timeout: 120 * time.Second, // Increased timeout for file downloads
 		},
-		maxSize: 20 * 1024 * 1024, // 20MB limit
+		maxSize: mediaMaxBytes(),
 	}
 }
 
@@ -162,6 +189,62 @@ func (f *FileProcessor) ProcessFileURLIntelligent(ctx context.Context, fileURL *
 	}
 }
 
+// ProcessStoredFile processes a file of the files API, referenced by a file://{id} handle, and
+// returns the appropriate ContentPart type; the file must belong to the request's client key
+func (f *FileProcessor) ProcessStoredFile(ctx context.Context, id string) (ContentPart, error) {
+	store := files.Default()
+	if store == nil {
+		return ContentPart{}, fmt.Errorf("file storage is not available")
+	}
+	owner := files.OwnerFromContext(ctx)
+	file, err := store.File(ctx, owner, id)
+	if err != nil {
+		return ContentPart{}, fmt.Errorf("failed to fetch file %s: %w", id, err)
+	}
+	if int64(file.Bytes) > f.maxSize {
+		return ContentPart{}, fmt.Errorf("file size exceeds limit of %d bytes", f.maxSize)
+	}
+	data, err := store.Content(ctx, owner, id)
+	if err != nil {
+		return ContentPart{}, fmt.Errorf("failed to fetch file %s: %w", id, err)
+	}
+
+	ctx = logger.WithComponent(ctx, "file_processor")
+	ctx = logger.WithStage(ctx, "stored_file_processing")
+	logger.Info(ctx, "Processing stored file", "file_id", id, "filename", file.Filename, "size_bytes", len(data))
+
+	if contentType := f.detectImageFormat(data); contentType != "" {
+		return ContentPart{
+			Type: "image_url",
+			ImageURL: &ImageURL{
+				URL: fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(data)),
+			},
+		}, nil
+	}
+	if contentType := f.detectAudioFormat(data); contentType != "" {
+		outputFormat := f.audioProcessor.determineOutputFormat(contentType)
+		converted, err := f.audioProcessor.convertAudio(ctx, data, contentType, outputFormat)
+		if err != nil {
+			return ContentPart{}, fmt.Errorf("failed to convert audio: %w", err)
+		}
+		return ContentPart{
+			Type: "input_audio",
+			InputAudio: &InputAudio{
+				Data:   base64.StdEncoding.EncodeToString(converted),
+				Format: outputFormat,
+			},
+		}, nil
+	}
+	content, err := f.imageProcessor.convertFileDataToText(ctx, data, files.HandleScheme+id)
+	if err != nil {
+		return ContentPart{}, err
+	}
+	return ContentPart{
+		Type: "text",
+		Text: content,
+	}, nil
+}
+
 // detectFileType downloads the beginning of the file to determine its type
 func (f *FileProcessor) detectFileType(ctx context.Context, fileURL string, headers map[string]string) (string, error) {
 	// Create request with context
@@ -179,6 +262,8 @@ func (f *FileProcessor) detectFileType(ctx context.Context, fileURL string, head
 			req.Header.Set(key, value)
 		}
 	}
+	// Only the first bytes are needed; hosts without range support send the whole file
+	req.Header.Set("Range", "bytes=0-511")
 
 	// Make the request
 	resp, err := f.httpClient.Do(req)
@@ -188,7 +273,7 @@ func (f *FileProcessor) detectFileType(ctx context.Context, fileURL string, head
 	defer resp.Body.Close()
 
 	// Check response status
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
 		return "", fmt.Errorf("failed to fetch file: status %d", resp.StatusCode)
 	}
 
@@ -389,7 +474,7 @@ func (f *FileProcessor) generateFileErrorMessage(err error, fileURL string) stri
 	} else if strings.Contains(errorMsg, "status 404") {
 		baseMessage = fmt.Sprintf("Respond naturally that the file URL %s appears to be broken or the file has been moved/deleted (404 Not Found). Ask them to provide a valid file URL.", fileURL)
 	} else if strings.Contains(errorMsg, "size exceeds limit") {
-		baseMessage = fmt.Sprintf("Respond naturally that the file at %s is too large to process (exceeds 20MB limit). Ask them to provide a smaller file or compress it before sharing.", fileURL)
+		baseMessage = fmt.Sprintf("Respond naturally that the file at %s is too large to process (exceeds %s limit). Ask them to provide a smaller file or compress it before sharing.", fileURL, formatSizeLimit(f.maxSize))
 	} else if strings.Contains(errorMsg, "timeout") || strings.Contains(errorMsg, "deadline exceeded") {
 		baseMessage = fmt.Sprintf("Respond naturally that the file at %s took too long to download due to slow response from the file server. Suggest they try again later or provide an alternative file.", fileURL)
 	} else if strings.Contains(errorMsg, "markitdown failed") {
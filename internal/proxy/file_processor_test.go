@@ -0,0 +1,35 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/files"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProcessor_ProcessStoredFile(t *testing.T) {
+	store, err := files.NewStore(context.Background(), files.NewMemoryBackend(), 0)
+	require.NoError(t, err)
+	files.SetDefault(store)
+	t.Cleanup(func() { files.SetDefault(nil) })
+
+	png := []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A, 0, 0, 0, 0x0D}
+	file, err := store.Upload(context.Background(), files.Owner("sk-client"), "chart.png", files.PurposeVision, png)
+	require.NoError(t, err)
+	processor := NewImageProcessor().fileProcessor
+
+	ctx := files.WithOwner(context.Background(), files.Owner("sk-client"))
+	part, err := processor.ProcessStoredFile(ctx, file.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "image_url", part.Type)
+	assert.Equal(t, "data:image/png;base64,iVBORw0KGgoAAAAN", part.ImageURL.URL)
+
+	_, err = processor.ProcessStoredFile(files.WithOwner(context.Background(), files.Owner("sk-other")), file.ID)
+	assert.ErrorIs(t, err, files.ErrNotFound, "files of other client keys cannot be referenced")
+
+	files.SetDefault(nil)
+	_, err = processor.ProcessStoredFile(ctx, file.ID)
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/ratelimit"
+)
+
+// groqUnsupportedFields are request fields Groq rejects with a 400
+var groqUnsupportedFields = []string{"logprobs", "top_logprobs", "logit_bias"}
+
+// GroqAdapter handles Groq's OpenAI-compatible API
+// Groq rejects a few OpenAI fields, which are dropped, and reports its rate limits in
+// x-ratelimit-* and retry-after headers, which are used to route around throttled keys
+type GroqAdapter struct {
+	openAICompatibleAdapter
+}
+
+func init() {
+	RegisterVendorAdapter("groq", func() VendorAdapter { return NewGroqAdapter() })
+}
+
+// NewGroqAdapter creates a Groq adapter
+func NewGroqAdapter() *GroqAdapter {
+	return &GroqAdapter{}
+}
+
+// TranslateRequest drops fields and message names Groq does not accept
+func (a *GroqAdapter) TranslateRequest(body []byte) ([]byte, error) {
+	var request map[string]interface{}
+	if err := codec.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+
+	for _, field := range groqUnsupportedFields {
+		delete(request, field)
+	}
+	messages, _ := request["messages"].([]interface{})
+	for _, item := range messages {
+		if message, ok := item.(map[string]interface{}); ok {
+			delete(message, "name")
+		}
+	}
+
+	return codec.Marshal(request)
+}
+
+// SpeechFormat maps OpenAI speech formats to Groq's; Groq encodes Opus as Ogg and has no AAC or raw PCM
+func (a *GroqAdapter) SpeechFormat(format string) (string, bool) {
+	switch format {
+	case "mp3", "flac", "wav":
+		return format, true
+	case "opus":
+		return "ogg", true
+	}
+	return "", false
+}
+
+// RateLimitedUntil reads Groq's rate-limit headers
+func (a *GroqAdapter) RateLimitedUntil(statusCode int, header http.Header, now time.Time) time.Time {
+	return ratelimit.ThrottledUntil(statusCode, header, now)
+}
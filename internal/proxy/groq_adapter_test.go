@@ -0,0 +1,46 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroqAdapter_TranslateRequest(t *testing.T) {
+	body := `{
+		"model": "llama-3.3-70b-versatile",
+		"messages": [{"role": "user", "name": "alice", "content": "Hi"}],
+		"logprobs": true,
+		"top_logprobs": 2,
+		"logit_bias": {"50256": -100},
+		"temperature": 0.2
+	}`
+
+	translated, err := NewGroqAdapter().TranslateRequest([]byte(body))
+	require.NoError(t, err)
+
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(translated, &request))
+	assert.NotContains(t, request, "logprobs")
+	assert.NotContains(t, request, "top_logprobs")
+	assert.NotContains(t, request, "logit_bias")
+	assert.Equal(t, 0.2, request["temperature"])
+	assert.NotContains(t, request["messages"].([]interface{})[0], "name")
+}
+
+func TestGroqAdapter_ReportsRateLimits(t *testing.T) {
+	reporter, ok := adapterFor("groq").(RateLimitReporter)
+	require.True(t, ok)
+
+	now := time.Now()
+	header := http.Header{}
+	header.Set("Retry-After", "5")
+	assert.Equal(t, now.Add(5*time.Second), reporter.RateLimitedUntil(http.StatusTooManyRequests, header, now))
+
+	_, ok = adapterFor("openai").(RateLimitReporter)
+	assert.False(t, ok, "other vendors are not tracked")
+}
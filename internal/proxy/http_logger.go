@@ -38,18 +38,8 @@ func (h *HTTPLogger) LogRequest(ctx context.Context, req *http.Request) {
 	}
 
 	// Add body if present
-	if req.Body != nil {
-		body, err := io.ReadAll(req.Body)
-		if err == nil {
-			req.Body = io.NopCloser(bytes.NewBuffer(body))
-
-			var bodyData interface{}
-			if json.Unmarshal(body, &bodyData) == nil {
-				requestData["body"] = utils.TruncateBase64InData(bodyData)
-			} else {
-				requestData["body"] = string(body)
-			}
-		}
+	if body := describeOutgoingBody(req); body != nil {
+		requestData["body"] = body
 	}
 
 	logger.Info(ctx, "Sending vendor request", "request", utils.TruncateStringsInData(requestData))
@@ -131,18 +121,8 @@ func (h *HTTPLogger) LogRequestWithTiming(ctx context.Context, req *http.Request
 	}
 
 	// Add body if present
-	if req.Body != nil {
-		body, err := io.ReadAll(req.Body)
-		if err == nil {
-			req.Body = io.NopCloser(bytes.NewBuffer(body))
-
-			var bodyData interface{}
-			if json.Unmarshal(body, &bodyData) == nil {
-				requestData["body"] = utils.TruncateBase64InData(bodyData)
-			} else {
-				requestData["body"] = string(body)
-			}
-		}
+	if body := describeOutgoingBody(req); body != nil {
+		requestData["body"] = body
 	}
 
 	logger.Info(ctx, "Sending vendor request with tracking", "request", utils.TruncateStringsInData(requestData))
@@ -194,6 +174,23 @@ func (h *HTTPLogger) LogResponseWithTiming(ctx context.Context, resp *http.Respo
 	logger.Info(ctx, message, "response", utils.TruncateStringsInData(responseData))
 }
 
+// describeOutgoingBody describes an outgoing request body without consuming req.Body:
+// replayable bodies are read from a fresh copy via GetBody, others are only measured
+func describeOutgoingBody(req *http.Request) interface{} {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	if req.GetBody == nil {
+		return map[string]interface{}{"bytes": req.ContentLength, "streamed": true}
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	return logger.DescribeRequestBodyReader(req.Context(), body)
+}
+
 // extractSafeHeaders extracts headers while filtering sensitive ones
 func extractSafeHeaders(headers http.Header) map[string]string {
 	result := make(map[string]string)
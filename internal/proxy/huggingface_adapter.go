@@ -0,0 +1,272 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// huggingFaceFinishReasons maps TGI finish reasons to OpenAI finish reasons
+var huggingFaceFinishReasons = map[string]string{
+	"eos_token":     "stop",
+	"stop_sequence": "stop",
+}
+
+// Warm-up polling bounds; a loading model is re-checked at most every
+// maxHuggingFaceWarmupPoll even when it estimates a longer load
+const (
+	minHuggingFaceWarmupPoll = time.Second
+	maxHuggingFaceWarmupPoll = 10 * time.Second
+)
+
+// HuggingFaceAdapter handles HuggingFace Inference Endpoints and the serverless router,
+// both served by Text Generation Inference (TGI) through its OpenAI-compatible Messages API
+// Requests are authorized with the HuggingFace token as a Bearer token. TGI streams
+// "data:" lines without a space, reports finish reasons such as "eos_token", may send
+// tool call arguments as objects and reports errors as bare strings. Endpoints scaled to
+// zero answer 503 with an estimated_time while the model loads; those requests are held
+// and retried until the model is ready instead of failing the client request
+type HuggingFaceAdapter struct {
+	openAICompatibleAdapter
+	clientOnce sync.Once
+	client     *http.Client
+}
+
+func init() {
+	RegisterVendorAdapter("huggingface", func() VendorAdapter { return NewHuggingFaceAdapter() })
+}
+
+// NewHuggingFaceAdapter creates a HuggingFace adapter
+func NewHuggingFaceAdapter() *HuggingFaceAdapter {
+	return &HuggingFaceAdapter{}
+}
+
+// Endpoint returns the Messages API URL, adding the /v1 prefix when the base URL is an
+// endpoint's root URL
+func (a *HuggingFaceAdapter) Endpoint(baseURL, model string, streaming bool) string {
+	if !strings.HasSuffix(baseURL, "/v1") {
+		baseURL += "/v1"
+	}
+	return baseURL + "/chat/completions"
+}
+
+// EmbeddingsEndpoint returns the embeddings URL of Text Embeddings Inference (TEI)
+// endpoints, adding the /v1 prefix like Endpoint
+func (a *HuggingFaceAdapter) EmbeddingsEndpoint(baseURL string) string {
+	if !strings.HasSuffix(baseURL, "/v1") {
+		baseURL += "/v1"
+	}
+	return baseURL + "/embeddings"
+}
+
+// HTTPClient returns the client used for HuggingFace requests, which waits for models that
+// are still loading for up to HUGGINGFACE_WARMUP_TIMEOUT (seconds, default 300)
+func (a *HuggingFaceAdapter) HTTPClient() *http.Client {
+	a.clientOnce.Do(func() {
+		a.client = &http.Client{
+			Timeout: utils.GetEnvDuration("CLIENT_TIMEOUT", 1200*time.Second),
+			Transport: &huggingFaceWarmupTransport{
+				base:    http.DefaultTransport,
+				maxWait: utils.GetEnvDuration("HUGGINGFACE_WARMUP_TIMEOUT", 300*time.Second),
+			},
+		}
+	})
+	return a.client
+}
+
+// TranslateRequest passes requests through unchanged
+func (a *HuggingFaceAdapter) TranslateRequest(body []byte) ([]byte, error) {
+	return body, nil
+}
+
+// TranslateResponse maps TGI finish reasons and tool call arguments
+func (a *HuggingFaceAdapter) TranslateResponse(body []byte) ([]byte, error) {
+	var response map[string]interface{}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	cleanHuggingFacePayload(response)
+	return codec.Marshal(response)
+}
+
+// TranslateStream maps TGI chunks and mid-stream errors into the OpenAI shape
+func (a *HuggingFaceAdapter) TranslateStream(r io.Reader) io.Reader {
+	return newChunkRewriter(r, isTogetherChunk, cleanHuggingFacePayload)
+}
+
+// cleanHuggingFacePayload rewrites a TGI response or chunk into the OpenAI shape
+func cleanHuggingFacePayload(payload map[string]interface{}) {
+	// TGI reports errors, including those cut into a stream, as {"error": "...", "error_type": "..."}
+	if message, ok := payload["error"].(string); ok {
+		errorType, _ := payload["error_type"].(string)
+		delete(payload, "error_type")
+		payload["error"] = map[string]interface{}{
+			"message": message,
+			"type":    errorType,
+		}
+		return
+	}
+
+	choices, _ := payload["choices"].([]interface{})
+	for _, item := range choices {
+		choice, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if reason, _ := choice["finish_reason"].(string); huggingFaceFinishReasons[reason] != "" {
+			choice["finish_reason"] = huggingFaceFinishReasons[reason]
+		}
+		for _, key := range []string{"delta", "message"} {
+			message, ok := choice[key].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			toolCalls, _ := message["tool_calls"].([]interface{})
+			for _, raw := range toolCalls {
+				call, _ := raw.(map[string]interface{})
+				function, _ := call["function"].(map[string]interface{})
+				if arguments, ok := function["arguments"].(map[string]interface{}); ok {
+					encoded, _ := codec.Marshal(arguments)
+					function["arguments"] = string(encoded)
+				}
+			}
+		}
+	}
+}
+
+// ParseError replaces the generic message with the one from TGI's error body and maps
+// its error types. A 503 still carrying an estimated_time means the model did not finish
+// loading within HUGGINGFACE_WARMUP_TIMEOUT, which retrying at once will not fix
+func (a *HuggingFaceAdapter) ParseError(apiErr *VendorAPIError, responseBody []byte) {
+	var body struct {
+		Error         interface{} `json:"error"`
+		ErrorType     string      `json:"error_type"`
+		EstimatedTime float64     `json:"estimated_time"`
+	}
+	if codec.Unmarshal(responseBody, &body) != nil {
+		return
+	}
+	switch e := body.Error.(type) {
+	case string:
+		apiErr.Message = e
+	case map[string]interface{}:
+		if message, _ := e["message"].(string); message != "" {
+			apiErr.Message = message
+		}
+	}
+
+	switch {
+	case apiErr.StatusCode == http.StatusServiceUnavailable && body.EstimatedTime > 0:
+		apiErr.ErrorType = "model_loading"
+		apiErr.Retriable = false
+	case body.ErrorType == "validation":
+		apiErr.ErrorType = "invalid_request_error"
+		apiErr.Retriable = false
+	case body.ErrorType == "overloaded":
+		apiErr.ErrorType = "rate_limit_exceeded"
+		apiErr.Retriable = true
+	}
+}
+
+// huggingFaceWarmupTransport retries requests answered with a model loading 503 until the
+// model is ready or maxWait has passed, then returns the last response as is
+type huggingFaceWarmupTransport struct {
+	base    http.RoundTripper
+	maxWait time.Duration
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *huggingFaceWarmupTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	deadline := time.Now().Add(t.maxWait)
+	attempt := req
+	for waits := 0; ; waits++ {
+		resp, err := t.base.RoundTrip(attempt)
+		if err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+			return resp, err
+		}
+
+		estimated := huggingFaceEstimatedLoadTime(resp)
+		if estimated <= 0 || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+		wait := estimated
+		if wait < minHuggingFaceWarmupPoll {
+			wait = minHuggingFaceWarmupPoll
+		} else if wait > maxHuggingFaceWarmupPoll {
+			wait = maxHuggingFaceWarmupPoll
+		}
+		if time.Now().Add(wait).After(deadline) {
+			return resp, nil
+		}
+
+		ctx := logger.WithComponent(req.Context(), "HuggingFaceAdapter")
+		logger.Info(logger.WithStage(ctx, "ModelWarmup"), "Model is loading, waiting before retrying",
+			"url", req.URL.String(),
+			"estimated_seconds", estimated.Seconds(),
+			"wait_seconds", wait.Seconds(),
+			"waits", waits+1,
+		)
+		_ = resp.Body.Close()
+		if err := sleepContext(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		attempt = req.Clone(req.Context())
+		if req.GetBody != nil {
+			if attempt.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// huggingFaceEstimatedLoadTime returns the estimated_time of a model loading response, or
+// zero for any other 503. The body is read and replaced so it can still be parsed later
+func huggingFaceEstimatedLoadTime(resp *http.Response) time.Duration {
+	data, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		return 0
+	}
+
+	body := data
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return 0
+		}
+		if body, err = io.ReadAll(reader); err != nil {
+			return 0
+		}
+	}
+
+	var loading struct {
+		EstimatedTime float64 `json:"estimated_time"`
+	}
+	if codec.Unmarshal(body, &loading) != nil {
+		return 0
+	}
+	return time.Duration(loading.EstimatedTime * float64(time.Second))
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
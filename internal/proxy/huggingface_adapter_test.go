@@ -0,0 +1,193 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHuggingFaceAdapter_Endpoint(t *testing.T) {
+	adapter := NewHuggingFaceAdapter()
+	assert.Equal(t, "https://xyz.endpoints.huggingface.cloud/v1/chat/completions",
+		adapter.Endpoint("https://xyz.endpoints.huggingface.cloud", "tgi", false))
+	assert.Equal(t, "https://router.huggingface.co/v1/chat/completions",
+		adapter.Endpoint("https://router.huggingface.co/v1", "meta-llama/Llama-3.1-8B-Instruct", true))
+}
+
+func TestHuggingFaceAdapter_TranslateResponse(t *testing.T) {
+	body := `{
+		"object": "chat.completion", "id": "", "created": 1700000000, "model": "tgi",
+		"choices": [{
+			"index": 0, "logprobs": null, "finish_reason": "eos_token",
+			"message": {"role": "assistant", "tool_calls": [{"id": "0", "type": "function", "function": {"name": "get_weather", "arguments": {"city": "Paris"}}}]}
+		}]
+	}`
+
+	translated, err := NewHuggingFaceAdapter().TranslateResponse([]byte(body))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"object": "chat.completion", "id": "", "created": 1700000000, "model": "tgi",
+		"choices": [{
+			"index": 0, "logprobs": null, "finish_reason": "stop",
+			"message": {"role": "assistant", "tool_calls": [{"id": "0", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}]}
+		}]
+	}`, string(translated))
+}
+
+func TestHuggingFaceAdapter_TranslateStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`data:{"object":"chat.completion.chunk","id":"","created":1700000000,"model":"tgi","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"},"logprobs":null,"finish_reason":null}]}`,
+		``,
+		`data:{"object":"chat.completion.chunk","id":"","created":1700000000,"model":"tgi","choices":[{"index":0,"delta":{"role":"assistant","content":""},"logprobs":null,"finish_reason":"stop_sequence"}]}`,
+		``,
+		`data:{"error":"Request failed during generation: Server error: CUDA out of memory","error_type":"generation"}`,
+		``,
+		`data:[DONE]`,
+		``,
+	}, "\n")
+
+	translated, err := io.ReadAll(NewHuggingFaceAdapter().TranslateStream(strings.NewReader(stream)))
+	require.NoError(t, err)
+
+	events := strings.Split(strings.TrimSpace(string(translated)), "\n\n")
+	require.Len(t, events, 4)
+	for _, event := range events {
+		assert.True(t, strings.HasPrefix(event, "data: "), "data lines are normalized: %s", event)
+	}
+
+	var last, failure map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(events[1], "data: ")), &last))
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(events[2], "data: ")), &failure))
+	assert.Equal(t, "stop", last["choices"].([]interface{})[0].(map[string]interface{})["finish_reason"])
+	assert.Equal(t, map[string]interface{}{
+		"error": map[string]interface{}{"message": "Request failed during generation: Server error: CUDA out of memory", "type": "generation"},
+	}, failure)
+	assert.Equal(t, "data: [DONE]", events[3])
+}
+
+func TestParseVendorError_HuggingFace(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		errorType string
+		message   string
+		retriable bool
+	}{
+		{
+			name:      "validation error",
+			status:    http.StatusUnprocessableEntity,
+			body:      `{"error":"Input validation error: inputs tokens + max_new_tokens must be <= 4096","error_type":"validation"}`,
+			errorType: "invalid_request_error",
+			message:   "Input validation error: inputs tokens + max_new_tokens must be <= 4096",
+		},
+		{
+			name:      "overloaded",
+			status:    http.StatusTooManyRequests,
+			body:      `{"error":"Model is overloaded","error_type":"overloaded"}`,
+			errorType: "rate_limit_exceeded",
+			message:   "Model is overloaded",
+			retriable: true,
+		},
+		{
+			name:      "model still loading after the warm-up timeout",
+			status:    http.StatusServiceUnavailable,
+			body:      `{"error":"Model meta-llama/Llama-3.1-8B-Instruct is currently loading","estimated_time":120.5}`,
+			errorType: "model_loading",
+			message:   "Model meta-llama/Llama-3.1-8B-Instruct is currently loading",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseVendorError("huggingface", tt.status, []byte(tt.body))
+			var apiErr *VendorAPIError
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tt.errorType, apiErr.ErrorType)
+			assert.Equal(t, tt.message, apiErr.Message)
+			assert.Equal(t, tt.retriable, apiErr.Retriable)
+		})
+	}
+}
+
+func TestHuggingFaceWarmupTransport(t *testing.T) {
+	loading := func(w http.ResponseWriter, gzipped bool) {
+		body := []byte(`{"error":"Model is currently loading","estimated_time":0.01}`)
+		if gzipped {
+			var compressed bytes.Buffer
+			zw := gzip.NewWriter(&compressed)
+			_, _ = zw.Write(body)
+			_ = zw.Close()
+			body = compressed.Bytes()
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write(body)
+	}
+
+	t.Run("retries until the model is ready", func(t *testing.T) {
+		var bodies []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			if len(bodies) < 3 {
+				loading(w, len(bodies) == 2)
+				return
+			}
+			_, _ = w.Write([]byte(`{"object":"chat.completion"}`))
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: &huggingFaceWarmupTransport{base: http.DefaultTransport, maxWait: time.Minute}}
+		resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"model":"tgi"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []string{`{"model":"tgi"}`, `{"model":"tgi"}`, `{"model":"tgi"}`}, bodies, "the body is replayed on every attempt")
+	})
+
+	t.Run("gives up after the warm-up timeout", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			loading(w, false)
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: &huggingFaceWarmupTransport{base: http.DefaultTransport, maxWait: 0}}
+		resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, 1, attempts)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "estimated_time", "the last response body is still readable")
+	})
+
+	t.Run("other 503s are returned at once", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		client := &http.Client{Transport: &huggingFaceWarmupTransport{base: http.DefaultTransport, maxWait: time.Minute}}
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, 1, attempts)
+	})
+}
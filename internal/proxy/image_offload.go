@@ -0,0 +1,248 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // register GIF decoding for downscaling
+	"image/jpeg"
+	_ "image/png" // register PNG decoding for downscaling
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/media"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// inlineImageLimits are the largest decoded inline images, in bytes, each vendor accepts
+// Vendors not listed have no known limit and are left alone
+var inlineImageLimits = map[string]int{
+	"openai":    20 << 20,
+	"gemini":    20 << 20,
+	"anthropic": 5 << 20,
+	"vertex":    7 << 20,
+	"mistral":   10 << 20,
+	"groq":      4 << 20,
+}
+
+// imageURLVendors are the vendors that fetch http(s) image URLs themselves
+var imageURLVendors = map[string]bool{
+	"openai":    true,
+	"anthropic": true,
+	"vertex":    true,
+	"mistral":   true,
+	"groq":      true,
+}
+
+// downscaleJPEGQuality is the JPEG quality downscaled images are encoded with
+const downscaleJPEGQuality = 85
+
+// maxDownscaleAttempts bounds how many progressively smaller sizes are tried
+const maxDownscaleAttempts = 5
+
+// errImageStillTooLarge is returned when downscaling cannot bring an image under the limit
+var errImageStillTooLarge = errors.New("image is still over the size limit after downscaling")
+
+// offloadOversizedImages reports whether INLINE_IMAGE_OFFLOAD is enabled
+func offloadOversizedImages() bool {
+	return utils.GetEnvBool("INLINE_IMAGE_OFFLOAD", false)
+}
+
+// offloadOversizedImagesForVendor replaces inline images larger than vendor accepts
+// Images are uploaded to media storage and sent as signed URLs to vendors that fetch URLs,
+// and downscaled otherwise or when the upload fails. On failure an image is forwarded
+// unchanged so the vendor still reports the error
+func offloadOversizedImagesForVendor(ctx context.Context, vendor string, body []byte) []byte {
+	limit, ok := inlineImageLimits[vendor]
+	if !ok || !offloadOversizedImages() || !bytes.Contains(body, []byte("data:image/")) {
+		return body
+	}
+
+	var requestData map[string]interface{}
+	if err := codec.Unmarshal(body, &requestData); err != nil {
+		return body
+	}
+	messages, _ := requestData["messages"].([]interface{})
+
+	uploader := media.NewUploader(media.NewSignerFromEnv(), &http.Client{Timeout: 60 * time.Second})
+	modified := false
+	for _, rawMessage := range messages {
+		message, _ := rawMessage.(map[string]interface{})
+		parts, _ := message["content"].([]interface{})
+		for _, rawPart := range parts {
+			part, _ := rawPart.(map[string]interface{})
+			imageURL, _ := part["image_url"].(map[string]interface{})
+			dataURL, _ := imageURL["url"].(string)
+			if !strings.HasPrefix(dataURL, "data:image/") || inlineDataSize(dataURL) <= limit {
+				continue
+			}
+
+			replacement, method, err := replaceOversizedImage(ctx, uploader, vendor, dataURL, limit)
+			if err != nil {
+				logger.Warn(ctx, "Failed to shrink oversized inline image, forwarding unchanged",
+					"vendor", vendor,
+					"image_bytes", inlineDataSize(dataURL),
+					"limit_bytes", limit,
+					"error", err.Error(),
+				)
+				continue
+			}
+			logger.Info(ctx, "Replaced oversized inline image",
+				"vendor", vendor,
+				"image_bytes", inlineDataSize(dataURL),
+				"limit_bytes", limit,
+				"method", method,
+			)
+			imageURL["url"] = replacement
+			modified = true
+		}
+	}
+
+	if !modified {
+		return body
+	}
+	updated, err := codec.Marshal(requestData)
+	if err != nil {
+		return body
+	}
+	return updated
+}
+
+// replaceOversizedImage returns a signed URL or a downscaled data URL for the image,
+// along with which method was used
+func replaceOversizedImage(ctx context.Context, uploader *media.Uploader, vendor, dataURL string, limit int) (string, string, error) {
+	contentType, data, err := decodeDataURL(dataURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	if imageURLVendors[vendor] && uploader.Configured() {
+		signedURL, err := uploadImage(ctx, uploader, data, contentType)
+		if err == nil {
+			return signedURL, "signed_url", nil
+		}
+		logger.Warn(ctx, "Failed to upload oversized inline image, downscaling instead",
+			"vendor", vendor,
+			"error", err.Error(),
+		)
+	}
+
+	downscaled, err := downscaleImage(data, limit)
+	if err != nil {
+		return "", "", err
+	}
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(downscaled), "downscale", nil
+}
+
+// uploadImage stores the image and returns a signed URL vendors can fetch it from
+func uploadImage(ctx context.Context, uploader *media.Uploader, data []byte, contentType string) (string, error) {
+	reference, err := uploader.Upload(ctx, data, contentType)
+	if err != nil {
+		return "", err
+	}
+	return media.NewSignerFromEnv().SignReference(reference)
+}
+
+// inlineDataSize returns the decoded size of a base64 data URL's payload
+func inlineDataSize(dataURL string) int {
+	_, payload, found := strings.Cut(dataURL, ",")
+	if !found {
+		return 0
+	}
+	return base64.StdEncoding.DecodedLen(len(payload))
+}
+
+// decodeDataURL splits a base64 data URL into its content type and decoded bytes
+func decodeDataURL(dataURL string) (string, []byte, error) {
+	header, payload, found := strings.Cut(strings.TrimPrefix(dataURL, "data:"), ",")
+	contentType, isBase64 := strings.CutSuffix(header, ";base64")
+	if !found || !isBase64 {
+		return "", nil, fmt.Errorf("unsupported data URL")
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid base64 image data: %w", err)
+	}
+	return contentType, data, nil
+}
+
+// downscaleImage re-encodes an image as JPEG, shrinking it until it fits within limit bytes
+// Transparent areas are flattened onto white because JPEG has no alpha channel
+func downscaleImage(data []byte, limit int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode image for downscaling: %w", err)
+	}
+
+	bounds := src.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, bounds.Min, draw.Over)
+
+	// Encoded size scales roughly with pixel count, so start at the square root of the ratio
+	scale := math.Min(1, math.Sqrt(float64(limit)/float64(len(data))))
+	for attempt := 0; attempt < maxDownscaleAttempts; attempt++ {
+		width := int(float64(bounds.Dx()) * scale)
+		height := int(float64(bounds.Dy()) * scale)
+		if width < 1 || height < 1 {
+			break
+		}
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resizeRGBA(flat, width, height), &jpeg.Options{Quality: downscaleJPEGQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode downscaled image: %w", err)
+		}
+		if buf.Len() <= limit {
+			return buf.Bytes(), nil
+		}
+		scale *= 0.75
+	}
+	return nil, errImageStillTooLarge
+}
+
+// resizeRGBA resizes src to width x height by averaging the source pixels under each target pixel
+func resizeRGBA(src *image.RGBA, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	if bounds.Dx() == width && bounds.Dy() == height {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * bounds.Dy() / height
+		y1 := max((y+1)*bounds.Dy()/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := x * bounds.Dx() / width
+			x1 := max((x+1)*bounds.Dx()/width, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(src.Pix[offset])
+					g += uint64(src.Pix[offset+1])
+					b += uint64(src.Pix[offset+2])
+					a += uint64(src.Pix[offset+3])
+					n++
+					offset += 4
+				}
+			}
+
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = uint8(r / n)
+			dst.Pix[offset+1] = uint8(g / n)
+			dst.Pix[offset+2] = uint8(b / n)
+			dst.Pix[offset+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
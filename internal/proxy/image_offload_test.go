@@ -0,0 +1,154 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noisyPNG returns a PNG that compresses poorly, so it is large for its dimensions
+func noisyPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{uint8(rng.Intn(256)), uint8(rng.Intn(256)), uint8(rng.Intn(256)), 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// withImageLimit sets a small inline image limit for vendor for the duration of the test
+func withImageLimit(t *testing.T, vendor string, limit int, acceptsURLs bool) {
+	t.Helper()
+	inlineImageLimits[vendor] = limit
+	imageURLVendors[vendor] = acceptsURLs
+	t.Cleanup(func() {
+		delete(inlineImageLimits, vendor)
+		delete(imageURLVendors, vendor)
+	})
+}
+
+func imageRequest(data []byte) []byte {
+	body, _ := json.Marshal(map[string]interface{}{
+		"model": "m",
+		"messages": []interface{}{map[string]interface{}{
+			"role": "user",
+			"content": []interface{}{
+				map[string]interface{}{"type": "text", "text": "What is this?"},
+				map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "data:image/png;base64," + base64.StdEncoding.EncodeToString(data)}},
+			},
+		}},
+	})
+	return body
+}
+
+func imageURLFrom(t *testing.T, body []byte) string {
+	t.Helper()
+	var request struct {
+		Messages []struct {
+			Content []struct {
+				ImageURL struct {
+					URL string `json:"url"`
+				} `json:"image_url"`
+			} `json:"content"`
+		} `json:"messages"`
+	}
+	require.NoError(t, json.Unmarshal(body, &request))
+	return request.Messages[0].Content[1].ImageURL.URL
+}
+
+func TestDownscaleImage(t *testing.T) {
+	data := noisyPNG(t, 400, 300)
+	limit := len(data) / 10
+
+	downscaled, err := downscaleImage(data, limit)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(downscaled), limit)
+
+	img, err := jpeg.Decode(bytes.NewReader(downscaled))
+	require.NoError(t, err)
+	assert.Less(t, img.Bounds().Dx(), 400)
+	assert.InDelta(t, 4.0/3.0, float64(img.Bounds().Dx())/float64(img.Bounds().Dy()), 0.05, "aspect ratio is kept")
+
+	_, err = downscaleImage([]byte("not an image"), limit)
+	assert.Error(t, err)
+}
+
+func TestOffloadOversizedImagesForVendor(t *testing.T) {
+	data := noisyPNG(t, 200, 200)
+	body := imageRequest(data)
+
+	t.Run("disabled by default", func(t *testing.T) {
+		withImageLimit(t, "test-vendor", 1024, false)
+		assert.Equal(t, body, offloadOversizedImagesForVendor(context.Background(), "test-vendor", body))
+	})
+
+	t.Run("images within the limit are untouched", func(t *testing.T) {
+		t.Setenv("INLINE_IMAGE_OFFLOAD", "true")
+		withImageLimit(t, "test-vendor", len(data)*2, false)
+		assert.Equal(t, body, offloadOversizedImagesForVendor(context.Background(), "test-vendor", body))
+	})
+
+	t.Run("downscaled for vendors without URL support", func(t *testing.T) {
+		t.Setenv("INLINE_IMAGE_OFFLOAD", "true")
+		withImageLimit(t, "test-vendor", len(data)/4, false)
+
+		url := imageURLFrom(t, offloadOversizedImagesForVendor(context.Background(), "test-vendor", body))
+		assert.True(t, strings.HasPrefix(url, "data:image/jpeg;base64,"))
+		assert.LessOrEqual(t, inlineDataSize(url), len(data)/4)
+	})
+
+	t.Run("uploaded for vendors that fetch URLs", func(t *testing.T) {
+		var uploaded []byte
+		storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.Equal(t, "image/png", r.Header.Get("Content-Type"))
+			uploaded, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer storage.Close()
+
+		t.Setenv("INLINE_IMAGE_OFFLOAD", "true")
+		t.Setenv("MEDIA_STORAGE_URL", storage.URL)
+		t.Setenv("MEDIA_SIGNING_KEY", "test-signing-key")
+		withImageLimit(t, "test-vendor", len(data)/4, true)
+
+		url := imageURLFrom(t, offloadOversizedImagesForVendor(context.Background(), "test-vendor", body))
+		assert.True(t, strings.HasPrefix(url, storage.URL+"/inline/"))
+		assert.Contains(t, url, "signature=")
+		assert.Equal(t, data, uploaded)
+	})
+
+	t.Run("downscaled when the upload fails", func(t *testing.T) {
+		storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer storage.Close()
+
+		t.Setenv("INLINE_IMAGE_OFFLOAD", "true")
+		t.Setenv("MEDIA_STORAGE_URL", storage.URL)
+		t.Setenv("MEDIA_SIGNING_KEY", "test-signing-key")
+		withImageLimit(t, "test-vendor", len(data)/4, true)
+
+		url := imageURLFrom(t, offloadOversizedImagesForVendor(context.Background(), "test-vendor", body))
+		assert.True(t, strings.HasPrefix(url, "data:image/jpeg;base64,"))
+	})
+}
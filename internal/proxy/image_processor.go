@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -14,16 +15,21 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aashari/go-generative-api-router/internal/admission"
+	"github.com/aashari/go-generative-api-router/internal/files"
 	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/media"
 	"github.com/aashari/go-generative-api-router/internal/utils"
 )
 
 // ImageProcessor handles image URL processing and conversion
 type ImageProcessor struct {
 	httpClient     *http.Client
+	downloader     *utils.Downloader
 	maxSize        int64
 	fileProcessor  *FileProcessor
 	audioProcessor *AudioProcessor
+	mediaSigner    *media.Signer
 }
 
 // NewImageProcessor creates a new image processor with default settings
@@ -32,7 +38,9 @@ func NewImageProcessor() *ImageProcessor {
 		httpClient: &http.Client{
 			Timeout: 120 * time.Second, // Increased timeout for image downloads
 		},
-		maxSize: 20 * 1024 * 1024, // 20MB limit
+		downloader:  utils.NewDownloaderFromEnv(120 * time.Second),
+		maxSize:     mediaMaxBytes(),
+		mediaSigner: media.NewSignerFromEnv(),
 	}
 	// Initialize file processor with all required fields
 	processor.fileProcessor = &FileProcessor{
@@ -41,7 +49,7 @@ func NewImageProcessor() *ImageProcessor {
 		httpClient: &http.Client{
 			Timeout: 120 * time.Second, // Increased timeout for file downloads
 		},
-		maxSize: 20 * 1024 * 1024, // 20MB limit
+		maxSize: processor.maxSize,
 	}
 	// Initialize audio processor
 	processor.audioProcessor = NewAudioProcessor()
@@ -281,14 +289,14 @@ func (p *ImageProcessor) processContentParts(ctx context.Context, parts []Conten
 	itemsToProcess := make(map[int]int) // maps result index to parts index
 	resultIndex := 0
 	for i, part := range parts {
-		if part.Type == "image_url" && part.ImageURL != nil && p.isPublicURL(part.ImageURL.URL) {
+		if part.Type == "image_url" && part.ImageURL != nil && p.isFetchableURL(part.ImageURL.URL) {
 			itemsToProcess[resultIndex] = i
 			resultIndex++
 		} else if part.Type == "file_url" && part.FileURL != nil {
 			// Process all file_url types without pre-validation
 			itemsToProcess[resultIndex] = i
 			resultIndex++
-		} else if part.Type == "audio_url" && part.AudioURL != nil && p.isPublicURL(part.AudioURL.URL) {
+		} else if part.Type == "audio_url" && part.AudioURL != nil && p.isFetchableURL(part.AudioURL.URL) {
 			// Process all audio_url types
 			itemsToProcess[resultIndex] = i
 			resultIndex++
@@ -337,9 +345,14 @@ func (p *ImageProcessor) processContentParts(ctx context.Context, parts []Conten
 			var err error
 
 			if part.Type == "image_url" {
-				// Process image
-				processedURL, imgErr := p.downloadAndConvertImageWithHeaders(ctx, part.ImageURL.URL, part.ImageURL.Headers)
-				err = imgErr
+				// Process image (router-media:// references are resolved to signed storage URLs first)
+				var processedURL string
+				imageURL, imageHeaders, resolveErr := p.mediaSigner.Resolve(part.ImageURL.URL, part.ImageURL.Headers)
+				if resolveErr != nil {
+					err = resolveErr
+				} else {
+					processedURL, err = p.downloadAndConvertImageWithHeaders(ctx, imageURL, imageHeaders)
+				}
 				processedContent = ContentPart{
 					Type: "image_url",
 					ImageURL: &ImageURL{
@@ -349,8 +362,15 @@ func (p *ImageProcessor) processContentParts(ctx context.Context, parts []Conten
 				}
 			} else if part.Type == "file_url" {
 				// Process file using intelligent file processor
-				fileContent, fileErr := p.fileProcessor.ProcessFileURLIntelligent(ctx, part.FileURL)
-				err = fileErr
+				var fileContent ContentPart
+				if fileID, isHandle := files.ParseHandle(part.FileURL.URL); isHandle {
+					// file://{id} handles are read from the files API store
+					fileContent, err = p.fileProcessor.ProcessStoredFile(ctx, fileID)
+				} else if fileURL, fileHeaders, resolveErr := p.mediaSigner.Resolve(part.FileURL.URL, part.FileURL.Headers); resolveErr != nil {
+					err = resolveErr
+				} else {
+					fileContent, err = p.fileProcessor.ProcessFileURLIntelligent(ctx, &FileURL{URL: fileURL, Headers: fileHeaders})
+				}
 				if err == nil {
 					processedContent = fileContent
 				} else {
@@ -359,8 +379,13 @@ func (p *ImageProcessor) processContentParts(ctx context.Context, parts []Conten
 				}
 			} else if part.Type == "audio_url" {
 				// Process audio using modular audio processor
-				audioData, audioErr := p.audioProcessor.ProcessAudioURL(ctx, part.AudioURL.URL, part.AudioURL.Headers)
-				err = audioErr
+				var audioData *AudioData
+				audioURL, audioHeaders, resolveErr := p.mediaSigner.Resolve(part.AudioURL.URL, part.AudioURL.Headers)
+				if resolveErr != nil {
+					err = resolveErr
+				} else {
+					audioData, err = p.audioProcessor.ProcessAudioURL(ctx, audioURL, audioHeaders)
+				}
 				if err == nil {
 					processedContent = ContentPart{
 						Type: "input_audio",
@@ -495,7 +520,11 @@ func (p *ImageProcessor) generateProcessingFailureMessage(err error, itemType st
 	}
 
 	// Determine specific error message based on error type
-	if strings.Contains(errorMsg, "no such host") || strings.Contains(errorMsg, "dial tcp") {
+	if errors.Is(err, media.ErrDirectURLsDisabled) {
+		baseMessage = fmt.Sprintf("Respond naturally that direct %s links are not accepted by this service. Ask them to upload the %s to the shared media storage and reference it instead.", itemType, itemType)
+	} else if errors.Is(err, media.ErrInvalidReference) || errors.Is(err, media.ErrStorageNotConfigured) {
+		baseMessage = fmt.Sprintf("Respond naturally that the referenced %s could not be found in the media storage. Ask them to verify the %s reference or upload it again.", itemType, itemType)
+	} else if strings.Contains(errorMsg, "no such host") || strings.Contains(errorMsg, "dial tcp") {
 		baseMessage = fmt.Sprintf("Respond naturally that you couldn't access the %s due to network connectivity issues. The %s server appears to be unreachable or the domain doesn't exist. Ask the user to verify the URL or provide an alternative %s.", itemType, itemType, itemType)
 	} else if strings.Contains(errorMsg, "status 401") || strings.Contains(errorMsg, "status 403") {
 		baseMessage = fmt.Sprintf("Respond naturally that the %s requires authentication or access permissions that weren't provided. The %s couldn't be accessed due to authorization issues. Suggest they provide proper authentication headers or use a publicly accessible %s.", itemType, itemType, itemType)
@@ -513,7 +542,11 @@ func (p *ImageProcessor) generateProcessingFailureMessage(err error, itemType st
 		}
 		baseMessage = fmt.Sprintf("Respond naturally that the URL doesn't point to a valid %s file. The content isn't an %s format that can be processed. Ask them to provide a direct link to an %s file %s.", itemType, itemType, itemType, formatExamples)
 	} else if strings.Contains(errorMsg, "size exceeds limit") {
-		baseMessage = fmt.Sprintf("Respond naturally that the %s file is too large to process (exceeds 20MB limit). Ask them to provide a smaller %s or compress it before sharing.", itemType, itemType)
+		limit := p.maxSize
+		if itemType == "audio" && p.audioProcessor != nil {
+			limit = p.audioProcessor.maxSize
+		}
+		baseMessage = fmt.Sprintf("Respond naturally that the %s file is too large to process (exceeds %s limit). Ask them to provide a smaller %s or compress it before sharing.", itemType, formatSizeLimit(limit), itemType)
 	} else if strings.Contains(errorMsg, "timeout") || strings.Contains(errorMsg, "deadline exceeded") {
 		baseMessage = fmt.Sprintf("Respond naturally that the %s took too long to download due to slow response from the %s server. Suggest they try again later or provide an alternative %s.", itemType, itemType, itemType)
 	} else if strings.Contains(errorMsg, "markitdown failed") && itemType == "file" {
@@ -549,6 +582,11 @@ func (p *ImageProcessor) isPublicURL(url string) bool {
 	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
 }
 
+// isFetchableURL checks if the URL is public or a router-media:// storage reference
+func (p *ImageProcessor) isFetchableURL(url string) bool {
+	return p.isPublicURL(url) || media.IsReference(url)
+}
+
 // downloadAndConvertImage downloads an image from a URL and converts it to base64 (backward compatibility)
 func (p *ImageProcessor) downloadAndConvertImage(ctx context.Context, imageURL string) (string, error) {
 	return p.downloadAndConvertImageWithHeaders(ctx, imageURL, nil)
@@ -560,10 +598,11 @@ func (p *ImageProcessor) downloadAndConvertImageWithHeaders(ctx context.Context,
 	ctx = logger.WithStage(ctx, "image_download")
 
 	// Use the utility function to download the file
-	imageData, contentType, err := utils.DownloadFile(ctx, imageURL, headers, p.maxSize)
+	imageData, contentType, err := p.downloader.Download(ctx, imageURL, headers, p.maxSize)
 	if err != nil {
 		return "", fmt.Errorf("failed to download image: %w", err)
 	}
+	admission.Grow(ctx, int64(len(imageData)))
 
 	// Check content type
 	if !p.isValidImageType(contentType) {
@@ -836,11 +875,33 @@ func (p *ImageProcessor) downloadAndConvertFileWithHeaders(ctx context.Context,
 	ctx = logger.WithStage(ctx, "file_download")
 
 	// Use the utility function to download the file
-	fileData, originalContentType, err := utils.DownloadFile(ctx, fileURL, headers, p.maxSize)
+	fileData, originalContentType, err := p.downloader.Download(ctx, fileURL, headers, p.maxSize)
 	if err != nil {
 		return "", fmt.Errorf("failed to download file: %w", err)
 	}
+	admission.Grow(ctx, int64(len(fileData)))
+
+	// Detect actual file type for better logging
+	detectedFileType := p.detectDocumentFormat(fileData)
+
+	textContent, err := p.convertFileDataToText(ctx, fileData, fileURL)
+	if err != nil {
+		return "", err
+	}
+
+	logger.Debug(ctx, "File downloaded and converted",
+		"original_url", fileURL,
+		"original_content_type", originalContentType,
+		"detected_file_type", detectedFileType,
+		"size_bytes", len(fileData),
+		"text_length", len(textContent),
+		"content_type_detected", originalContentType != detectedFileType && detectedFileType != "unknown")
+
+	return textContent, nil
+}
 
+// convertFileDataToText converts file content to text using markitdown through a temporary file
+func (p *ImageProcessor) convertFileDataToText(ctx context.Context, fileData []byte, source string) (string, error) {
 	// Create temporary file
 	tempFile, err := os.CreateTemp("/tmp", "file_processor_*")
 	if err != nil {
@@ -856,24 +917,11 @@ func (p *ImageProcessor) downloadAndConvertFileWithHeaders(ctx context.Context,
 	}
 	tempFile.Close()
 
-	// Detect actual file type for better logging
-	detectedFileType := p.detectDocumentFormat(fileData)
-
 	// Convert file to text using markitdown
-	textContent, err := p.convertFileToText(ctx, tempFile.Name(), fileURL)
+	textContent, err := p.convertFileToText(ctx, tempFile.Name(), source)
 	if err != nil {
 		return "", fmt.Errorf("failed to convert file to text: %w", err)
 	}
-
-	logger.Debug(ctx, "File downloaded and converted",
-		"original_url", fileURL,
-		"original_content_type", originalContentType,
-		"detected_file_type", detectedFileType,
-		"size_bytes", len(fileData),
-		"text_length", len(textContent),
-		"temp_file", tempFile.Name(),
-		"content_type_detected", originalContentType != detectedFileType && detectedFileType != "unknown")
-
 	return textContent, nil
 }
 
@@ -0,0 +1,26 @@
+package proxy
+
+// JinaAdapter routes rerank requests to Jina AI, whose rerank API already takes and
+// returns the router's shape
+type JinaAdapter struct {
+	rerankOnlyAdapter
+}
+
+func init() {
+	RegisterVendorAdapter("jina", func() VendorAdapter { return NewJinaAdapter() })
+}
+
+// NewJinaAdapter creates a Jina AI adapter
+func NewJinaAdapter() *JinaAdapter {
+	return &JinaAdapter{}
+}
+
+// TranslateRerankRequest sends the request unchanged
+func (a *JinaAdapter) TranslateRerankRequest(body []byte) ([]byte, error) {
+	return body, nil
+}
+
+// TranslateRerankResponse returns the response unchanged
+func (a *JinaAdapter) TranslateRerankResponse(body []byte) ([]byte, error) {
+	return body, nil
+}
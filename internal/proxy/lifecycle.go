@@ -0,0 +1,34 @@
+package proxy
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/events"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+)
+
+// publishEvent publishes a lifecycle event for the request on the process-wide event bus
+func publishEvent(r *http.Request, event events.Event) {
+	event.RequestID = requestIDFromContext(r)
+	events.Default().Publish(r.Context(), event)
+}
+
+// publishOutcome publishes RequestCompleted or RequestFailed with the final routing decision
+// and hands the decision to the access log
+func publishOutcome(r *http.Request, decision *routingDecision, start time.Time, err error) {
+	event := events.Event{
+		Type:          events.RequestCompleted,
+		OriginalModel: decision.OriginalModel,
+		Vendor:        decision.Vendor,
+		Model:         decision.Model,
+		Duration:      time.Since(start),
+		Decision:      &decision.RoutingDecision,
+	}
+	if err != nil {
+		event.Type = events.RequestFailed
+		event.Error = err.Error()
+	}
+	publishEvent(r, event)
+	monitoring.AccessRecordFromContext(r.Context()).SetDecision(decision.RoutingDecision)
+}
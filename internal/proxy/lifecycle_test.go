@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/events"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyRequest_PublishesLifecycleEvents(t *testing.T) {
+	vendorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1700000000,"model":"gpt-4",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],` +
+			`"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer vendorServer.Close()
+
+	var received []events.Event
+	unsubscribe := events.Default().Subscribe(func(ctx context.Context, event events.Event) {
+		received = append(received, event)
+	})
+	defer unsubscribe()
+
+	creds := []config.Credential{{Platform: "openai", Type: "api-key", Value: "sk-test"}}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4"}}
+	mockSelector := &MockSelector{}
+	mockSelector.On("Select", creds, models).Return(&selector.VendorSelection{Vendor: "openai", Model: "gpt-4", Credential: creds[0]}, nil)
+
+	req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(`{"model":"my-model","messages":[{"role":"user","content":"Hello"}]}`))
+	rr := httptest.NewRecorder()
+	ProxyRequest(rr, req, creds, models, NewAPIClient(map[string]string{"openai": vendorServer.URL}), mockSelector)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var types []events.Type
+	for _, event := range received {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []events.Type{
+		events.RequestReceived, events.VendorSelected, events.RequestValidated, events.VendorResponded, events.RequestCompleted,
+	}, types)
+
+	assert.Equal(t, "my-model", received[0].OriginalModel)
+	assert.Equal(t, http.StatusOK, received[3].StatusCode)
+	completed := received[4]
+	assert.Equal(t, "openai", completed.Vendor)
+	require.NotNil(t, completed.Decision)
+	assert.Equal(t, DecisionOutcomeSuccess, completed.Decision.Outcome)
+	assert.Equal(t, 1, completed.Decision.Attempts)
+	assert.Equal(t, 1, completed.Decision.PromptTokens, "usage is recorded for budgets")
+	assert.Equal(t, 1, completed.Decision.CompletionTokens)
+}
@@ -0,0 +1,159 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// mergeableRoles are the roles whose adjacent messages can be merged without losing meaning
+// Tool messages are never merged because each one answers a distinct tool_call_id
+var mergeableRoles = map[string]bool{
+	"system":    true,
+	"developer": true,
+	"user":      true,
+	"assistant": true,
+}
+
+// mergeMessagesVendors returns the vendors configured via MERGE_SAME_ROLE_MESSAGES_VENDORS
+// (comma-separated, "*" for all) that require consecutive same-role messages to be merged
+func mergeMessagesVendors() []string {
+	return utils.GetEnvStringSlice("MERGE_SAME_ROLE_MESSAGES_VENDORS", nil)
+}
+
+// shouldMergeMessages reports whether same-role message merging is enabled for vendor
+func shouldMergeMessages(vendor string) bool {
+	for _, v := range mergeMessagesVendors() {
+		if v == "*" || strings.EqualFold(v, vendor) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeMessagesForVendor merges consecutive same-role messages when enabled for vendor
+// On failure the body is returned unchanged so normalization never blocks a request
+func normalizeMessagesForVendor(ctx context.Context, vendor string, body []byte) []byte {
+	if !shouldMergeMessages(vendor) {
+		return body
+	}
+
+	merged, count, err := MergeConsecutiveMessages(body)
+	if err != nil {
+		logger.Warn(ctx, "Failed to merge consecutive same-role messages, forwarding unchanged",
+			"vendor", vendor,
+			"error", err.Error(),
+		)
+		return body
+	}
+	if count > 0 {
+		logger.Debug(ctx, "Merged consecutive same-role messages",
+			"vendor", vendor,
+			"merged_messages", count,
+		)
+	}
+	return merged
+}
+
+// MergeConsecutiveMessages merges adjacent messages sharing the same role into one
+// String contents are joined with a blank line; when either side uses content parts,
+// both are converted to parts and concatenated so multimodal content is preserved
+// Returns the new body and the number of messages merged away
+func MergeConsecutiveMessages(body []byte) ([]byte, int, error) {
+	var requestData map[string]interface{}
+	if err := codec.Unmarshal(body, &requestData); err != nil {
+		return nil, 0, fmt.Errorf("invalid request format: %w", err)
+	}
+
+	messages, ok := requestData["messages"].([]interface{})
+	if !ok || len(messages) < 2 {
+		return body, 0, nil
+	}
+
+	result := make([]interface{}, 0, len(messages))
+	mergedCount := 0
+	for _, raw := range messages {
+		message, ok := raw.(map[string]interface{})
+		if !ok {
+			result = append(result, raw)
+			continue
+		}
+
+		if len(result) > 0 {
+			if previous, ok := result[len(result)-1].(map[string]interface{}); ok && canMergeMessages(previous, message) {
+				previous["content"] = mergeContent(previous["content"], message["content"])
+				mergedCount++
+				continue
+			}
+		}
+		result = append(result, message)
+	}
+
+	if mergedCount == 0 {
+		return body, 0, nil
+	}
+
+	requestData["messages"] = result
+	merged, err := codec.Marshal(requestData)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode merged request: %w", err)
+	}
+	return merged, mergedCount, nil
+}
+
+// canMergeMessages reports whether next can be folded into previous
+func canMergeMessages(previous, next map[string]interface{}) bool {
+	role, _ := previous["role"].(string)
+	if !mergeableRoles[role] || next["role"] != role {
+		return false
+	}
+
+	// Messages from different named participants or carrying tool calls must stay separate
+	if previous["name"] != next["name"] {
+		return false
+	}
+	if _, hasToolCalls := previous["tool_calls"]; hasToolCalls {
+		return false
+	}
+	if _, hasToolCalls := next["tool_calls"]; hasToolCalls {
+		return false
+	}
+
+	return isMergeableContent(previous["content"]) && isMergeableContent(next["content"])
+}
+
+// isMergeableContent reports whether content is a string or an array of content parts
+func isMergeableContent(content interface{}) bool {
+	switch content.(type) {
+	case string, []interface{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// mergeContent joins two message contents, preserving content parts when present
+func mergeContent(first, second interface{}) interface{} {
+	firstText, firstIsString := first.(string)
+	secondText, secondIsString := second.(string)
+	if firstIsString && secondIsString {
+		return firstText + "\n\n" + secondText
+	}
+	return append(contentParts(first), contentParts(second)...)
+}
+
+// contentParts converts message content to a list of content parts
+func contentParts(content interface{}) []interface{} {
+	switch c := content.(type) {
+	case string:
+		return []interface{}{map[string]interface{}{"type": "text", "text": c}}
+	case []interface{}:
+		return c
+	default:
+		return nil
+	}
+}
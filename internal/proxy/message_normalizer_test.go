@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeConsecutiveMessages(t *testing.T) {
+	tests := []struct {
+		name          string
+		messages      string
+		expected      string
+		expectedCount int
+	}{
+		{
+			name:          "string contents are joined",
+			messages:      `[{"role":"user","content":"Hello"},{"role":"user","content":"World"}]`,
+			expected:      `[{"role":"user","content":"Hello\n\nWorld"}]`,
+			expectedCount: 1,
+		},
+		{
+			name:          "alternating roles are untouched",
+			messages:      `[{"role":"user","content":"Hi"},{"role":"assistant","content":"Hello"},{"role":"user","content":"Bye"}]`,
+			expected:      `[{"role":"user","content":"Hi"},{"role":"assistant","content":"Hello"},{"role":"user","content":"Bye"}]`,
+			expectedCount: 0,
+		},
+		{
+			name:          "string and image parts are merged into parts",
+			messages:      `[{"role":"user","content":"Describe this"},{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,AAA"}}]}]`,
+			expected:      `[{"role":"user","content":[{"type":"text","text":"Describe this"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AAA"}}]}]`,
+			expectedCount: 1,
+		},
+		{
+			name:          "parts and parts are concatenated in order",
+			messages:      `[{"role":"user","content":[{"type":"text","text":"A"}]},{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://x/a.png"}}]},{"role":"user","content":"B"}]`,
+			expected:      `[{"role":"user","content":[{"type":"text","text":"A"},{"type":"image_url","image_url":{"url":"https://x/a.png"}},{"type":"text","text":"B"}]}]`,
+			expectedCount: 2,
+		},
+		{
+			name:          "tool messages are never merged",
+			messages:      `[{"role":"tool","tool_call_id":"a","content":"1"},{"role":"tool","tool_call_id":"b","content":"2"}]`,
+			expected:      `[{"role":"tool","tool_call_id":"a","content":"1"},{"role":"tool","tool_call_id":"b","content":"2"}]`,
+			expectedCount: 0,
+		},
+		{
+			name:          "assistant tool calls stay separate",
+			messages:      `[{"role":"assistant","content":null,"tool_calls":[{"id":"a"}]},{"role":"assistant","content":"Done"}]`,
+			expected:      `[{"role":"assistant","content":null,"tool_calls":[{"id":"a"}]},{"role":"assistant","content":"Done"}]`,
+			expectedCount: 0,
+		},
+		{
+			name:          "different names stay separate",
+			messages:      `[{"role":"user","name":"alice","content":"Hi"},{"role":"user","name":"bob","content":"Hey"}]`,
+			expected:      `[{"role":"user","name":"alice","content":"Hi"},{"role":"user","name":"bob","content":"Hey"}]`,
+			expectedCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(`{"model":"gpt-4o","messages":` + tt.messages + `}`)
+
+			merged, count, err := MergeConsecutiveMessages(body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCount, count)
+
+			var result struct {
+				Model    string          `json:"model"`
+				Messages json.RawMessage `json:"messages"`
+			}
+			require.NoError(t, json.Unmarshal(merged, &result))
+			assert.Equal(t, "gpt-4o", result.Model)
+			assert.JSONEq(t, tt.expected, string(result.Messages))
+		})
+	}
+}
+
+func TestMergeConsecutiveMessages_InvalidJSON(t *testing.T) {
+	_, _, err := MergeConsecutiveMessages([]byte(`{"messages":`))
+	assert.Error(t, err)
+}
+
+func TestNormalizeMessagesForVendor(t *testing.T) {
+	body := []byte(`{"messages":[{"role":"user","content":"a"},{"role":"user","content":"b"}]}`)
+
+	t.Run("disabled by default", func(t *testing.T) {
+		assert.Equal(t, body, normalizeMessagesForVendor(t.Context(), "gemini", body))
+	})
+
+	t.Run("enabled for listed vendor only", func(t *testing.T) {
+		t.Setenv("MERGE_SAME_ROLE_MESSAGES_VENDORS", "anthropic, Gemini")
+		assert.NotEqual(t, body, normalizeMessagesForVendor(t.Context(), "gemini", body))
+		assert.Equal(t, body, normalizeMessagesForVendor(t.Context(), "openai", body))
+	})
+
+	t.Run("wildcard enables all vendors", func(t *testing.T) {
+		t.Setenv("MERGE_SAME_ROLE_MESSAGES_VENDORS", "*")
+		assert.NotEqual(t, body, normalizeMessagesForVendor(t.Context(), "openai", body))
+	})
+}
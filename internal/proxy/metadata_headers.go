@@ -0,0 +1,86 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Routing metadata headers; each names an X-Router-* response header
+const (
+	// RouterHeaderVendor sends the vendor that served the request in X-Router-Vendor
+	RouterHeaderVendor = "vendor"
+	// RouterHeaderModel sends the vendor's model that served the request in X-Router-Model
+	RouterHeaderModel = "model"
+	// RouterHeaderAttempts sends how many vendor requests were made, including retries and
+	// fallbacks, in X-Router-Attempts
+	RouterHeaderAttempts = "attempts"
+	// RouterHeaderCache sends X-Router-Cache on every response: HIT and MISS from the semantic
+	// cache, BYPASS for requests it did not handle
+	RouterHeaderCache = "cache"
+	// RouterHeaderQueueWait sends how many milliseconds the request waited for admission and
+	// for a slot on its vendor in X-Router-Queue-Wait
+	RouterHeaderQueueWait = "queue_wait"
+	// RouterHeaderFailedAttempts sends the vendor attempts that failed over, as
+	// "vendor/model=reason" entries, in X-Router-Failed-Attempts
+	RouterHeaderFailedAttempts = "failed_attempts"
+)
+
+// cacheBypass is the X-Router-Cache value of responses the semantic cache did not handle
+const cacheBypass = "BYPASS"
+
+// enabledRouterHeaders returns the routing metadata headers to send for a request: those of
+// the client key's ACL policy when it sets any, otherwise ROUTER_HEADERS. None are sent by
+// default, so clients cannot tell which vendor served them unless a deployment or key opts in
+func enabledRouterHeaders(r *http.Request) map[string]bool {
+	names := access.Default().PolicyFor(r).RouterHeaders
+	if names == nil {
+		names = utils.GetEnvStringSlice("ROUTER_HEADERS", nil)
+	}
+	enabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		enabled[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	return enabled
+}
+
+// setRouterHeaders sets the enabled routing metadata headers of a response served by a
+// vendor; it must be called before the headers are sent
+func setRouterHeaders(r *http.Request, header http.Header) {
+	enabled := enabledRouterHeaders(r)
+	if len(enabled) == 0 {
+		return
+	}
+
+	if enabled[RouterHeaderCache] && header.Get(utils.HeaderXRouterCache) == "" {
+		header.Set(utils.HeaderXRouterCache, cacheBypass)
+	}
+	decision := routingDecisionFromContext(r.Context())
+	if decision == nil {
+		return
+	}
+	vendor, model := decision.ServedBy()
+	if enabled[RouterHeaderVendor] {
+		header.Set(utils.HeaderXRouterVendor, vendor)
+	}
+	if enabled[RouterHeaderModel] {
+		header.Set(utils.HeaderXRouterModel, model)
+	}
+	if enabled[RouterHeaderAttempts] {
+		header.Set(utils.HeaderXRouterAttempts, strconv.Itoa(decision.Attempts))
+	}
+	if enabled[RouterHeaderQueueWait] {
+		header.Set(utils.HeaderXRouterQueueWait, strconv.FormatInt(decision.QueueWaitMs, 10))
+	}
+}
+
+// setCacheHeader sets X-Router-Cache to the semantic cache's result when the request's
+// routing metadata headers include it
+func setCacheHeader(r *http.Request, header http.Header, result string) {
+	if enabledRouterHeaders(r)[RouterHeaderCache] {
+		header.Set(utils.HeaderXRouterCache, result)
+	}
+}
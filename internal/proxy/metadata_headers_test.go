@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/cache"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetRouterHeaders(t *testing.T) {
+	access.SetDefault(access.NewACL(access.Policy{}, map[string]access.Policy{
+		"sk-trusted": {RouterHeaders: []string{"vendor", "model", "attempts", "cache", "queue_wait"}},
+		"sk-masked":  {RouterHeaders: []string{}},
+	}))
+	t.Cleanup(func() { access.SetDefault(access.NewACL(access.Policy{}, nil)) })
+
+	allHeaders := map[string]string{
+		utils.HeaderXRouterVendor:    "gemini",
+		utils.HeaderXRouterModel:     "gemini-2.0-flash",
+		utils.HeaderXRouterAttempts:  "2",
+		utils.HeaderXRouterCache:     "BYPASS",
+		utils.HeaderXRouterQueueWait: "120",
+	}
+	tests := []struct {
+		name     string
+		env      string
+		key      string
+		expected map[string]string
+	}{
+		{name: "nothing sent by default", expected: map[string]string{}},
+		{name: "deployment setting", env: "vendor, Attempts", expected: map[string]string{
+			utils.HeaderXRouterVendor:   "gemini",
+			utils.HeaderXRouterAttempts: "2",
+		}},
+		{name: "key policy enables headers", key: "sk-trusted", expected: allHeaders},
+		{name: "key policy keeps the vendor masked", env: "vendor", key: "sk-masked", expected: map[string]string{}},
+		{name: "unknown key uses deployment setting", env: "model", key: "sk-other", expected: map[string]string{
+			utils.HeaderXRouterModel: "gemini-2.0-flash",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ROUTER_HEADERS", tt.env)
+			r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			if tt.key != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			decision := &routingDecision{}
+			decision.Vendor, decision.Model = "openai", "gpt-4o"
+			decision.FallbackVendor, decision.FallbackModel = "gemini", "gemini-2.0-flash"
+			decision.Attempts = 2
+			decision.QueueWaitMs = 120
+			r = r.WithContext(withRoutingDecision(r.Context(), decision))
+
+			header := http.Header{}
+			setRouterHeaders(r, header)
+			for name := range allHeaders {
+				if value, ok := tt.expected[name]; ok {
+					assert.Equal(t, value, header.Get(name), name)
+				} else {
+					assert.Empty(t, header.Get(name), name)
+				}
+			}
+		})
+	}
+}
+
+func TestSetRouterHeaders_KeepsSemanticCacheResult(t *testing.T) {
+	t.Setenv("ROUTER_HEADERS", "cache")
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+	header := http.Header{}
+	header.Set(utils.HeaderXRouterCache, cacheMiss)
+	setRouterHeaders(r, header)
+	assert.Equal(t, cacheMiss, header.Get(utils.HeaderXRouterCache))
+}
+
+func TestRouterHeaders_NotSentWithoutOptIn(t *testing.T) {
+	t.Setenv("ROUTER_HEADERS", "")
+	body := `{"model":"any","messages":[{"role":"user","content":"Write to Dear Alice"}]}`
+	creds := []config.Credential{{Platform: "openai", Value: "sk-1"}, {Platform: "gemini", Value: "gm-1"}}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-2.5-flash"}}
+	send := func(client APIClientInterface, modelSelector selector.Selector) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer sk-masked")
+		rr := httptest.NewRecorder()
+		ProxyRequest(rr, req, creds, models, client, modelSelector)
+		require.Equal(t, http.StatusOK, rr.Code)
+		return rr
+	}
+	assertNoRouterHeaders := func(t *testing.T, header http.Header) {
+		for name := range header {
+			assert.False(t, strings.HasPrefix(name, "X-Router-"), "%s sent without opt-in", name)
+		}
+	}
+
+	t.Run("after a failover", func(t *testing.T) {
+		mockSelector := &MockSelector{}
+		mockSelector.On("Select", creds, models).Return(&selector.VendorSelection{Vendor: "openai", Model: "gpt-4o", Credential: creds[0]}, nil)
+		mockSelector.On("Select", mock.Anything, mock.Anything).Return(&selector.VendorSelection{Vendor: "gemini", Model: "gemini-2.5-flash", Credential: creds[1]}, nil)
+		client := &credentialErrorClient{errs: map[string]error{"sk-1": &VendorValidationError{Vendor: "openai", MissingField: "choices"}}}
+
+		rr := send(client, mockSelector)
+		assert.Equal(t, "gemini/gemini-2.5-flash@gm-1", client.attempts[len(client.attempts)-1], "the request failed over")
+		assertNoRouterHeaders(t, rr.Header())
+	})
+
+	t.Run("after a cache hit", func(t *testing.T) {
+		semanticCache, err := cache.New(cache.Config{})
+		require.NoError(t, err)
+		cache.SetDefault(semanticCache)
+		t.Cleanup(func() { cache.SetDefault(nil) })
+		mockSelector := &MockSelector{}
+		mockSelector.On("Select", creds, models).Return(&selector.VendorSelection{Vendor: "openai", Model: "gpt-4o", Credential: creds[0]}, nil)
+		client := &scriptedClient{responses: []string{finalAnswer}}
+
+		assertNoRouterHeaders(t, send(client, mockSelector).Header())
+		rr := send(client, mockSelector)
+		assert.Len(t, client.bodies, 1, "the second request is served from the cache")
+		assertNoRouterHeaders(t, rr.Header())
+	})
+}
@@ -0,0 +1,233 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+)
+
+// mistralFinishReasons maps Mistral-specific finish reasons to OpenAI finish reasons
+var mistralFinishReasons = map[string]string{
+	"model_length": "length",
+	"error":        "stop",
+}
+
+// mistralModerationCategories maps Mistral moderation categories to OpenAI's; the
+// others (health, financial, law and pii) have no OpenAI equivalent and are kept as-is
+var mistralModerationCategories = map[string]string{
+	"sexual":                         "sexual",
+	"hate_and_discrimination":        "hate",
+	"violence_and_threats":           "violence",
+	"dangerous_and_criminal_content": "illicit",
+	"selfharm":                       "self-harm",
+}
+
+// mistralToolCallIDPattern matches the tool call IDs Mistral accepts
+var mistralToolCallIDPattern = regexp.MustCompile(`^[a-zA-Z0-9]{9}$`)
+
+// MistralAdapter handles the quirks of Mistral's otherwise OpenAI-compatible API
+// Mistral only accepts 9-character alphanumeric tool call IDs, requires tool results
+// to name their function, spells tool_choice "required" as "any", rejects the OpenAI
+// "user" field and reports finish reasons OpenAI clients do not know
+type MistralAdapter struct {
+	openAICompatibleAdapter
+}
+
+func init() {
+	RegisterVendorAdapter("mistral", func() VendorAdapter { return NewMistralAdapter() })
+}
+
+// NewMistralAdapter creates a Mistral adapter
+func NewMistralAdapter() *MistralAdapter {
+	return &MistralAdapter{}
+}
+
+// TranslateRequest rewrites tool call IDs, tool result names and tool_choice for Mistral and
+// drops the user field
+func (a *MistralAdapter) TranslateRequest(body []byte) ([]byte, error) {
+	var request map[string]interface{}
+	if err := codec.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+
+	toolNames := make(map[string]string)
+	messages, _ := request["messages"].([]interface{})
+	for _, item := range messages {
+		message, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		toolCalls, _ := message["tool_calls"].([]interface{})
+		for _, rawCall := range toolCalls {
+			call, ok := rawCall.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			id := mistralToolCallID(call["id"])
+			call["id"] = id
+			if function, ok := call["function"].(map[string]interface{}); ok {
+				if name, ok := function["name"].(string); ok {
+					toolNames[id] = name
+				}
+			}
+		}
+
+		if message["role"] == "tool" {
+			id := mistralToolCallID(message["tool_call_id"])
+			message["tool_call_id"] = id
+			if name, _ := message["name"].(string); name == "" && toolNames[id] != "" {
+				message["name"] = toolNames[id]
+			}
+		}
+	}
+
+	if request["tool_choice"] == "required" {
+		request["tool_choice"] = "any"
+	}
+	delete(request, "user")
+
+	return codec.Marshal(request)
+}
+
+// TranslateResponse maps Mistral-specific finish reasons to their OpenAI equivalents
+func (a *MistralAdapter) TranslateResponse(body []byte) ([]byte, error) {
+	if !hasMistralFinishReason(body) {
+		return body, nil
+	}
+
+	var response map[string]interface{}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	mapMistralFinishReasons(response)
+	return codec.Marshal(response)
+}
+
+// TranslateStream maps Mistral-specific finish reasons in streamed chunks
+func (a *MistralAdapter) TranslateStream(r io.Reader) io.Reader {
+	return newChunkRewriter(r, hasMistralFinishReason, mapMistralFinishReasons)
+}
+
+// TranslateModerationResponse renames Mistral moderation categories to OpenAI's
+func (a *MistralAdapter) TranslateModerationResponse(body []byte) ([]byte, error) {
+	var response map[string]interface{}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	results, _ := response["results"].([]interface{})
+	for _, item := range results {
+		result, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range []string{"categories", "category_scores"} {
+			if values, ok := result[field].(map[string]interface{}); ok {
+				result[field] = mapMistralModerationCategories(values)
+			}
+		}
+	}
+	return codec.Marshal(response)
+}
+
+// mapMistralModerationCategories renames the categories with an OpenAI equivalent
+func mapMistralModerationCategories(values map[string]interface{}) map[string]interface{} {
+	mapped := make(map[string]interface{}, len(values))
+	for category, value := range values {
+		if name, ok := mistralModerationCategories[category]; ok {
+			category = name
+		}
+		mapped[category] = value
+	}
+	return mapped
+}
+
+// hasMistralFinishReason cheaply checks whether a payload may need finish reason mapping
+func hasMistralFinishReason(body []byte) bool {
+	for reason := range mistralFinishReasons {
+		if bytes.Contains(body, []byte(`"`+reason+`"`)) {
+			return true
+		}
+	}
+	return false
+}
+
+// mapMistralFinishReasons rewrites the finish_reason of every choice in a response or chunk
+func mapMistralFinishReasons(response map[string]interface{}) {
+	choices, _ := response["choices"].([]interface{})
+	for _, item := range choices {
+		choice, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if reason, ok := choice["finish_reason"].(string); ok {
+			if mapped, ok := mistralFinishReasons[reason]; ok {
+				choice["finish_reason"] = mapped
+			}
+		}
+	}
+}
+
+// mistralToolCallID converts a tool call ID into the 9-character alphanumeric form Mistral
+// accepts. IDs Mistral issued are kept; others are hashed so an assistant tool call and
+// its tool result always map to the same ID
+func mistralToolCallID(raw interface{}) string {
+	id, _ := raw.(string)
+	if mistralToolCallIDPattern.MatchString(id) {
+		return id
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])[:9]
+}
+
+// ParseError replaces the generic message with the one from Mistral's error body
+// Mistral reports request validation failures as 422 with a list of details, which are
+// treated as non-retriable invalid requests
+func (a *MistralAdapter) ParseError(apiErr *VendorAPIError, responseBody []byte) {
+	if apiErr.StatusCode == http.StatusUnprocessableEntity {
+		apiErr.ErrorType = "invalid_request"
+		apiErr.Retriable = false
+	}
+
+	var body struct {
+		Message interface{} `json:"message"`
+		Detail  interface{} `json:"detail"`
+	}
+	if codec.Unmarshal(responseBody, &body) != nil {
+		return
+	}
+	if message := mistralErrorMessage(body.Message); message != "" {
+		apiErr.Message = message
+	} else if message := mistralErrorMessage(map[string]interface{}{"detail": body.Detail}); message != "" {
+		apiErr.Message = message
+	}
+}
+
+// mistralErrorMessage extracts a readable message from a Mistral error message, which is
+// either a string or an object holding validation details
+func mistralErrorMessage(message interface{}) string {
+	switch m := message.(type) {
+	case string:
+		return m
+	case map[string]interface{}:
+		details, _ := m["detail"].([]interface{})
+		var messages []string
+		for _, item := range details {
+			detail, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if msg, ok := detail["msg"].(string); ok {
+				messages = append(messages, msg)
+			}
+		}
+		return strings.Join(messages, "; ")
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMistralAdapter_TranslateRequest(t *testing.T) {
+	body := `{
+		"model": "mistral-large-latest",
+		"messages": [
+			{"role": "user", "content": "Weather in Paris?"},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_abc123def456", "type": "function", "function": {"name": "get_weather", "arguments": "{}"}},
+				{"id": "D681PevKs", "type": "function", "function": {"name": "get_time", "arguments": "{}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_abc123def456", "content": "sunny"},
+			{"role": "tool", "tool_call_id": "D681PevKs", "name": "get_time", "content": "noon"}
+		],
+		"tool_choice": "required",
+		"user": "user-1234"
+	}`
+
+	translated, err := NewMistralAdapter().TranslateRequest([]byte(body))
+	require.NoError(t, err)
+
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(translated, &request))
+	assert.Equal(t, "any", request["tool_choice"])
+	assert.NotContains(t, request, "user")
+
+	messages := request["messages"].([]interface{})
+	toolCalls := messages[1].(map[string]interface{})["tool_calls"].([]interface{})
+	rewrittenID := toolCalls[0].(map[string]interface{})["id"].(string)
+	assert.Regexp(t, `^[a-zA-Z0-9]{9}$`, rewrittenID)
+	assert.Equal(t, "D681PevKs", toolCalls[1].(map[string]interface{})["id"], "Mistral-issued IDs are kept")
+
+	firstResult := messages[2].(map[string]interface{})
+	assert.Equal(t, rewrittenID, firstResult["tool_call_id"])
+	assert.Equal(t, "get_weather", firstResult["name"])
+	assert.Equal(t, "get_time", messages[3].(map[string]interface{})["name"])
+}
+
+func TestMistralAdapter_TranslateResponse(t *testing.T) {
+	adapter := NewMistralAdapter()
+
+	translated, err := adapter.TranslateResponse([]byte(`{"id":"1","choices":[{"index":0,"message":{"role":"assistant","content":"..."},"finish_reason":"model_length"}]}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(translated), `"finish_reason":"length"`)
+
+	unchanged := `{"id":"1","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`
+	translated, err = adapter.TranslateResponse([]byte(unchanged))
+	require.NoError(t, err)
+	assert.Equal(t, unchanged, string(translated))
+}
+
+func TestMistralAdapter_TranslateStream(t *testing.T) {
+	events := strings.Join([]string{
+		`data: {"id":"1","choices":[{"index":0,"delta":{"content":"Hel"},"finish_reason":null}]}`,
+		"",
+		`data: {"id":"1","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"error"}]}`,
+		"",
+		"data: [DONE]",
+		"",
+	}, "\n")
+
+	output, err := io.ReadAll(NewMistralAdapter().TranslateStream(strings.NewReader(events)))
+	require.NoError(t, err)
+
+	chunks := strings.Split(strings.TrimSpace(string(output)), "\n\n")
+	require.Len(t, chunks, 3)
+	assert.Equal(t, `data: {"id":"1","choices":[{"index":0,"delta":{"content":"Hel"},"finish_reason":null}]}`, chunks[0])
+	assert.Contains(t, chunks[1], `"finish_reason":"stop"`)
+	assert.Equal(t, "data: [DONE]", chunks[2])
+}
+
+func TestMistralAdapter_TranslateModerationResponse(t *testing.T) {
+	translated, err := NewMistralAdapter().TranslateModerationResponse([]byte(`{"id":"m1","model":"mistral-moderation-latest","results":[{
+		"categories":{"hate_and_discrimination":true,"selfharm":false,"pii":true},
+		"category_scores":{"hate_and_discrimination":0.91,"selfharm":0.01,"pii":0.8}}]}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"m1","model":"mistral-moderation-latest","results":[{
+		"categories":{"hate":true,"self-harm":false,"pii":true},
+		"category_scores":{"hate":0.91,"self-harm":0.01,"pii":0.8}}]}`, string(translated))
+}
+
+func TestParseVendorError_Mistral(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		errorType string
+		message   string
+		retriable bool
+	}{
+		{
+			name:      "validation details",
+			status:    http.StatusUnprocessableEntity,
+			body:      `{"object":"error","message":{"detail":[{"type":"missing","loc":["body","messages"],"msg":"Field required"}]},"type":"invalid_request_error"}`,
+			errorType: "invalid_request",
+			message:   "Field required",
+		},
+		{
+			name:      "message order",
+			status:    http.StatusBadRequest,
+			body:      `{"object":"error","message":"Unexpected role 'user' after role 'tool'","type":"invalid_request_message_order","code":"3230"}`,
+			errorType: "invalid_request",
+			message:   "Unexpected role 'user' after role 'tool'",
+		},
+		{
+			name:      "rate limit",
+			status:    http.StatusTooManyRequests,
+			body:      `{"message":"Requests rate limit exceeded"}`,
+			errorType: "rate_limit_exceeded",
+			message:   "Requests rate limit exceeded",
+			retriable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiErr *VendorAPIError
+			require.True(t, errors.As(ParseVendorError("mistral", tt.status, []byte(tt.body)), &apiErr))
+			assert.Equal(t, tt.errorType, apiErr.ErrorType)
+			assert.Equal(t, tt.message, apiErr.Message)
+			assert.Equal(t, tt.retriable, apiErr.Retriable)
+		})
+	}
+}
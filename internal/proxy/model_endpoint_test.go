@@ -0,0 +1,101 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIClient_SetupRequestModelEndpoint(t *testing.T) {
+	client := NewAPIClient(map[string]string{"openai": "https://api.openai.com/v1"})
+	body := []byte(`{"model":"any-model","messages":[{"role":"user","content":"Hi"}]}`)
+
+	tests := []struct {
+		name           string
+		selection      selector.VendorSelection
+		expectedURL    string
+		expectedHeader map[string]string
+		expectedErr    bool
+	}{
+		{
+			name:           "vendor base URL",
+			selection:      selector.VendorSelection{Vendor: "openai", Model: "gpt-4o", Credential: config.Credential{Type: "api-key", Value: "sk-test"}},
+			expectedURL:    "https://api.openai.com/v1/chat/completions",
+			expectedHeader: map[string]string{"Authorization": "Bearer sk-test"},
+		},
+		{
+			name: "model base URL under its own vendor name",
+			selection: selector.VendorSelection{
+				Vendor: "vllm", Model: "Qwen/Qwen2.5-7B-Instruct",
+				Credential: config.Credential{Platform: "vllm", Type: "api-key", Value: "token"},
+				BaseURL:    "http://vllm.internal:8000/v1/",
+			},
+			expectedURL:    "http://vllm.internal:8000/v1/chat/completions",
+			expectedHeader: map[string]string{"Authorization": "Bearer token"},
+		},
+		{
+			name: "custom auth header",
+			selection: selector.VendorSelection{
+				Vendor: "tgi", Model: "tgi",
+				Credential: config.Credential{Platform: "tgi", Type: "api-key", Value: "hf_abc"},
+				BaseURL:    "https://tgi.example.com/v1",
+				AuthHeader: "X-Api-Key: Token {key}",
+			},
+			expectedURL:    "https://tgi.example.com/v1/chat/completions",
+			expectedHeader: map[string]string{"X-Api-Key": "Token hf_abc", "Authorization": ""},
+		},
+		{
+			name: "keyless model sends no credential",
+			selection: selector.VendorSelection{
+				Vendor: "lmstudio", Model: "qwen2.5-7b-instruct",
+				Credential: config.Credential{Platform: "lmstudio", Type: config.CredentialTypeNone},
+				BaseURL:    "http://localhost:1234/v1",
+			},
+			expectedURL:    "http://localhost:1234/v1/chat/completions",
+			expectedHeader: map[string]string{"Authorization": ""},
+		},
+		{
+			name:        "unknown vendor without a base URL",
+			selection:   selector.VendorSelection{Vendor: "vllm", Model: "any"},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+			req, _, err := client.setupRequest(r, &tt.selection, body, "any-model")
+			if tt.expectedErr {
+				assert.ErrorIs(t, err, ErrUnknownVendor)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedURL, req.URL.String())
+			for name, value := range tt.expectedHeader {
+				assert.Equal(t, value, req.Header.Get(name), name)
+			}
+		})
+	}
+}
+
+func TestAPIClient_BaseURLsFollowConfigStore(t *testing.T) {
+	store := config.NewStore(config.NewSnapshot(config.Data{Vendors: map[string]string{"openai": "https://api.openai.com/v1"}}))
+	client := NewAPIClientForStore(store)
+	selection := &selector.VendorSelection{Vendor: "openai", Model: "gpt-4o"}
+
+	baseURL, err := client.baseURLFor(selection)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.openai.com/v1", baseURL)
+
+	store.Swap(config.NewSnapshot(config.Data{Vendors: map[string]string{"openai": "https://openai-proxy.internal/v1"}}))
+	baseURL, err = client.baseURLFor(selection)
+	require.NoError(t, err)
+	assert.Equal(t, "https://openai-proxy.internal/v1", baseURL, "a published snapshot applies to the next request")
+
+	_, err = client.baseURLFor(&selector.VendorSelection{Vendor: "gemini"})
+	assert.ErrorIs(t, err, ErrUnknownVendor)
+}
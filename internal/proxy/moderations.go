@@ -0,0 +1,359 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/events"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/reliability"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+	"github.com/aashari/go-generative-api-router/internal/validator"
+)
+
+// ErrModerationsUnsupported is returned when the selected vendor does not serve moderations
+var ErrModerationsUnsupported = errors.New("vendor does not support moderations")
+
+// moderationCategories are the categories of OpenAI's moderation models, present in every
+// normalized result whether or not the vendor reports them
+var moderationCategories = []string{
+	"harassment",
+	"harassment/threatening",
+	"hate",
+	"hate/threatening",
+	"illicit",
+	"illicit/violent",
+	"self-harm",
+	"self-harm/intent",
+	"self-harm/instructions",
+	"sexual",
+	"sexual/minors",
+	"violence",
+	"violence/graphic",
+}
+
+// ModerationsClientInterface defines the interface for clients sending moderation requests
+type ModerationsClientInterface interface {
+	SendModerationRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error
+}
+
+// ProxyModerationRequest validates a moderation request, routes it to a vendor serving one
+// of the moderation models and forwards the normalized response. When the vendor fails for
+// any reason other than rejecting the input, the request falls back to another vendor
+func ProxyModerationRequest(w http.ResponseWriter, r *http.Request, creds []config.Credential, models []config.VendorModel, apiClient ModerationsClientInterface, modelSelector selector.Selector) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	start := time.Now()
+	ctx := logger.WithComponent(r.Context(), "proxy")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := r.Body.Close(); err != nil {
+		logger.Warn(logger.WithStage(ctx, "request_handling"), "Failed to close request body", "error", err)
+	}
+
+	request, originalModel, err := validator.ValidateModerationRequest(body)
+	if err != nil {
+		logger.Warn(logger.WithStage(ctx, "request_validation"), "Moderation request validation failed", "error", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	publishEvent(r, events.Event{Type: events.RequestReceived, OriginalModel: originalModel})
+
+	// Restrict the pool to what the client key's ACL permits, minus any requested exclusions
+	r, creds, models, ok := restrictEndpointPool(ctx, w, r, body, originalModel, start, creds, models)
+	if !ok {
+		return
+	}
+
+	// Only vendors with a moderation API can take the request
+	models = moderationModels(models)
+	creds = filter.CredentialsForModels(creds, models)
+
+	r, selection, decision, ok := selectEndpointVendor(ctx, w, r, creds, models, modelSelector, originalModel, start)
+	if !ok {
+		return
+	}
+	publishEvent(r, events.Event{Type: events.RequestValidated, OriginalModel: originalModel, Vendor: selection.Vendor, Model: selection.Model})
+
+	ctx = context.WithValue(ctx, "vendor", selection.Vendor)
+	ctx = context.WithValue(ctx, "model", selection.Model)
+	err = sendModeration(ctx, w, r, selection, request, originalModel, apiClient, decision)
+
+	// Fall back to a moderation model of another vendor, without further retries
+	if err != nil && shouldFallBackVendor(err) {
+		remaining := models[:0:0]
+		for _, model := range models {
+			if model.Vendor != selection.Vendor {
+				remaining = append(remaining, model)
+			}
+		}
+		if fallback, selectErr := modelSelector.Select(filter.CredentialsForModels(creds, remaining), remaining); selectErr == nil {
+			logger.Warn(logger.WithStage(ctx, "vendor_fallback"), "Moderation vendor failed, falling back",
+				"original_vendor", selection.Vendor,
+				"fallback_vendor", fallback.Vendor,
+				"fallback_model", fallback.Model,
+				"error", err.Error(),
+			)
+			decision.FallbackVendor = fallback.Vendor
+			decision.FallbackModel = fallback.Model
+			decision.Credential = credentialLabel(fallback.Credential)
+			selection = fallback
+			err = sendModeration(ctx, w, r, selection, request, originalModel, apiClient, decision)
+		}
+	}
+	if err != nil {
+		writeUpstreamError(ctx, w, err, selection.Vendor)
+	}
+	decision.Complete(err)
+	publishOutcome(r, decision, start, err)
+}
+
+// sendModeration sends the request to the selected model, retrying the first attempt and
+// sending fallbacks once
+func sendModeration(ctx context.Context, w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, request map[string]interface{},
+	originalModel string, apiClient ModerationsClientInterface, decision *routingDecision) error {
+	request["model"] = selection.Model
+	modifiedBody, err := codec.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	logger.Info(logger.WithStage(ctx, "RequestProcessing"), "Proxying moderation request",
+		"original_model", originalModel,
+		"vendor", selection.Vendor,
+		"model", selection.Model,
+		"request_body", logger.RequestBody(modifiedBody),
+	)
+
+	if decision.FallbackVendor != "" {
+		decision.Attempts++
+		return apiClient.SendModerationRequest(w, r, selection, modifiedBody, originalModel)
+	}
+	return reliability.NewRetryExecutor(nil).ExecuteWithRetry(ctx, func() error {
+		decision.Attempts++
+		return apiClient.SendModerationRequest(w, r, selection, modifiedBody, originalModel)
+	})
+}
+
+// shouldFallBackVendor reports whether another vendor may succeed where one failed:
+// every failure except the vendor rejecting the input as invalid
+func shouldFallBackVendor(err error) bool {
+	var apiErr *VendorAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorType != "invalid_request"
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// moderationModels returns the models whose vendor serves moderations
+func moderationModels(models []config.VendorModel) []config.VendorModel {
+	var result []config.VendorModel
+	for _, model := range models {
+		if SupportsModerations(model.Vendor) {
+			result = append(result, model)
+		}
+	}
+	return result
+}
+
+// SendModerationRequest sends a moderation request to the vendor API and writes the
+// normalized response back
+func (c *APIClient) SendModerationRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error {
+	adapter := adapterFor(selection.Vendor)
+	provider, ok := adapter.(ModerationsProvider)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrModerationsUnsupported, selection.Vendor)
+	}
+	baseURL, err := c.baseURLFor(selection)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, provider.ModerationsEndpoint(baseURL), bytes.NewReader(modifiedBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	req.Header.Set(utils.HeaderAcceptEncoding, utils.AcceptEncodingGzip)
+	if err := authorizeRequest(req, adapter, selection); err != nil {
+		return err
+	}
+
+	resp, release, err := c.doVendorRequest(r, req, selection, modifiedBody, originalModel)
+	if err != nil {
+		return err
+	}
+	// The request counts against the credential's concurrency cap until its response is handled
+	defer release()
+	defer resp.Body.Close()
+
+	observeRateLimit(r, selection, resp)
+
+	sizes := trackResponseSize(selection.Vendor, &resp.Body, resp.Header.Get(utils.HeaderContentEncoding) == utils.AcceptEncodingGzip)
+	defer sizes.record()
+
+	responseBody, err := c.standardizer.processResponseBody(resp.Body, resp.Header.Get(utils.HeaderContentEncoding), selection.Vendor)
+	if resp.StatusCode >= 400 {
+		if err != nil {
+			return ParseVendorError(selection.Vendor, resp.StatusCode, nil)
+		}
+		vendorErr := ParseVendorError(selection.Vendor, resp.StatusCode, responseBody)
+		logger.Warn(r.Context(), "Vendor API error detected",
+			"vendor", selection.Vendor,
+			"status_code", resp.StatusCode,
+			"retriable", IsRetriableAPIError(vendorErr),
+			"response_body", string(responseBody),
+			"component", "APIClient",
+			"stage", "VendorAPIError",
+		)
+		return vendorErr
+	}
+	if err != nil {
+		logger.Error(r.Context(), "Error processing response body", err,
+			"vendor", selection.Vendor,
+			"component", "APIClient",
+			"stage", "ResponseBodyProcessing",
+		)
+		return err
+	}
+	sizes.uncompressed = int64(len(responseBody))
+
+	translated, err := provider.TranslateModerationResponse(responseBody)
+	if err == nil {
+		translated, err = ProcessModerationResponse(translated, originalModel)
+	}
+	if err != nil {
+		logger.Error(r.Context(), "Error processing moderation response", err,
+			"vendor", selection.Vendor,
+			"response_size_bytes", len(responseBody),
+			"component", "APIClient",
+			"stage", "ResponseProcessing",
+		)
+		return err
+	}
+
+	shouldCompress := c.standardizer.shouldCompress(r)
+	finalResponse := translated
+	if shouldCompress {
+		compressed, err := c.standardizer.compressResponseMandatory(translated)
+		if err != nil {
+			logger.Error(r.Context(), "Error compressing response", err,
+				"vendor", selection.Vendor,
+				"component", "APIClient",
+				"stage", "ResponseCompression",
+			)
+			// Fall back to uncompressed if compression fails
+			shouldCompress = false
+		} else {
+			finalResponse = compressed
+			w.Header().Set(utils.HeaderContentEncoding, utils.AcceptEncodingGzip)
+		}
+	}
+
+	c.standardizer.setCompliantHeaders(w, selection.Vendor, len(finalResponse), shouldCompress)
+	setRouterHeaders(r, w.Header())
+	if _, err := w.Write(finalResponse); err != nil {
+		logger.Error(r.Context(), "Error writing response", err,
+			"vendor", selection.Vendor,
+			"component", "APIClient",
+			"stage", "ResponseWriting",
+		)
+		return err
+	}
+
+	logger.Info(r.Context(), "Moderation response sent to client",
+		"vendor", selection.Vendor,
+		"model", selection.Model,
+		"original_model", originalModel,
+		"original_response_size", len(responseBody),
+		"final_response_size", len(finalResponse),
+		"compressed", shouldCompress,
+		"component", "APIClient",
+		"stage", "FinalResponseSent",
+	)
+	return nil
+}
+
+// ProcessModerationResponse normalizes a moderation response into the OpenAI shape: every
+// result carries each OpenAI category with a boolean and a score, missing ones reported as
+// false and 0, and is flagged when any category is. Vendor-specific categories are kept
+func ProcessModerationResponse(body []byte, originalModel string) ([]byte, error) {
+	var response map[string]interface{}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	items, ok := response["results"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: missing required field 'results'", ErrInvalidResponse)
+	}
+	results := make([]interface{}, 0, len(items))
+	for i, raw := range items {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: result %d is not an object", ErrInvalidResponse, i)
+		}
+		categories, _ := item["categories"].(map[string]interface{})
+		scores, _ := item["category_scores"].(map[string]interface{})
+		if categories == nil {
+			categories = make(map[string]interface{})
+		}
+		if scores == nil {
+			scores = make(map[string]interface{})
+		}
+		for _, category := range moderationCategories {
+			if _, exists := categories[category]; !exists {
+				categories[category] = false
+			}
+			if _, exists := scores[category]; !exists {
+				scores[category] = 0.0
+			}
+		}
+
+		flagged, ok := item["flagged"].(bool)
+		if !ok {
+			for _, value := range categories {
+				if value == true {
+					flagged = true
+					break
+				}
+			}
+		}
+
+		result := map[string]interface{}{
+			"flagged":         flagged,
+			"categories":      categories,
+			"category_scores": scores,
+		}
+		if applied, exists := item["category_applied_input_types"]; exists {
+			result["category_applied_input_types"] = applied
+		}
+		results = append(results, result)
+	}
+
+	id, _ := response["id"].(string)
+	if id == "" {
+		id = "modr-" + utils.GenerateShortID()
+	}
+	return codec.Marshal(map[string]interface{}{
+		"id":      id,
+		"model":   originalModel,
+		"results": results,
+	})
+}
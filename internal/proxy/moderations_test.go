@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mistralModeration = `{"id":"m1","model":"mistral-moderation-latest","results":[{
+	"categories":{"hate_and_discrimination":true,"selfharm":false,"pii":false},
+	"category_scores":{"hate_and_discrimination":0.91,"selfharm":0.01,"pii":0.02}}]}`
+
+func TestProxyModerationRequest(t *testing.T) {
+	tests := []struct {
+		name             string
+		body             string
+		openAIStatus     int
+		expectedStatus   int
+		expectedVendors  []string
+		expectedCategory string
+	}{
+		{
+			name:             "served by the selected vendor",
+			body:             `{"model":"omni-moderation-latest","input":"I hate them"}`,
+			openAIStatus:     http.StatusOK,
+			expectedStatus:   http.StatusOK,
+			expectedVendors:  []string{"openai"},
+			expectedCategory: "harassment",
+		},
+		{
+			name:             "falls back to the alternative vendor",
+			body:             `{"input":"I hate them"}`,
+			openAIStatus:     http.StatusUnauthorized,
+			expectedStatus:   http.StatusOK,
+			expectedVendors:  []string{"openai", "mistral"},
+			expectedCategory: "hate",
+		},
+		{
+			name:            "input rejected by the vendor is not retried elsewhere",
+			body:            `{"input":"I hate them"}`,
+			openAIStatus:    http.StatusBadRequest,
+			expectedStatus:  http.StatusBadGateway,
+			expectedVendors: []string{"openai"},
+		},
+		{
+			name:           "invalid request",
+			body:           `{"input":[]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vendors []string
+			vendorServer := func(vendor string, status int, response string) *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, "/moderations", r.URL.Path)
+					vendors = append(vendors, vendor)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(status)
+					_, _ = w.Write([]byte(response))
+				}))
+			}
+			openAIServer := vendorServer("openai", tt.openAIStatus, `{"id":"modr-1","model":"omni-moderation-latest","results":[{"flagged":true,"categories":{"harassment":true},"category_scores":{"harassment":0.8}}]}`)
+			defer openAIServer.Close()
+			mistralServer := vendorServer("mistral", http.StatusOK, mistralModeration)
+			defer mistralServer.Close()
+
+			creds := []config.Credential{{Platform: "openai", Type: "api-key", Value: "sk-test"}, {Platform: "mistral", Type: "api-key", Value: "mk-test"}}
+			models := []config.VendorModel{
+				{Vendor: "openai", Model: "omni-moderation-latest", Type: config.ModelTypeModeration},
+				{Vendor: "mistral", Model: "mistral-moderation-latest", Type: config.ModelTypeModeration},
+			}
+			mockSelector := &MockSelector{}
+			mockSelector.On("Select", creds, models).Return(&selector.VendorSelection{Vendor: "openai", Model: models[0].Model, Credential: creds[0]}, nil)
+			mockSelector.On("Select", creds[1:], models[1:]).Return(&selector.VendorSelection{Vendor: "mistral", Model: models[1].Model, Credential: creds[1]}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/moderations", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			client := NewAPIClient(map[string]string{"openai": openAIServer.URL, "mistral": mistralServer.URL})
+			ProxyModerationRequest(rr, req, creds, models, client, mockSelector)
+
+			require.Equal(t, tt.expectedStatus, rr.Code, rr.Body.String())
+			assert.Equal(t, tt.expectedVendors, vendors)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Model   string `json:"model"`
+				Results []struct {
+					Flagged        bool               `json:"flagged"`
+					Categories     map[string]bool    `json:"categories"`
+					CategoryScores map[string]float64 `json:"category_scores"`
+				} `json:"results"`
+			}
+			body, _ := io.ReadAll(rr.Body)
+			require.NoError(t, json.Unmarshal(body, &response))
+			require.Len(t, response.Results, 1)
+			result := response.Results[0]
+			assert.True(t, result.Flagged)
+			assert.True(t, result.Categories[tt.expectedCategory])
+			for _, category := range moderationCategories {
+				assert.Contains(t, result.CategoryScores, category, "every OpenAI category is reported")
+			}
+		})
+	}
+}
+
+func TestProcessModerationResponse(t *testing.T) {
+	processed, err := ProcessModerationResponse([]byte(`{"results":[{"categories":{"violence":false},"category_scores":{"violence":0.2}}]}`), "my-moderation")
+	require.NoError(t, err)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(processed, &response))
+	assert.Equal(t, "my-moderation", response["model"])
+	assert.True(t, strings.HasPrefix(response["id"].(string), "modr-"))
+	result := response["results"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, false, result["flagged"])
+	assert.Equal(t, 0.2, result["category_scores"].(map[string]interface{})["violence"])
+	assert.Len(t, result["categories"], len(moderationCategories))
+
+	_, err = ProcessModerationResponse([]byte(`{"id":"x"}`), "m")
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+}
@@ -0,0 +1,371 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// ollamaDoneReasons maps Ollama done reasons to OpenAI finish reasons
+var ollamaDoneReasons = map[string]string{
+	"stop":   "stop",
+	"length": "length",
+}
+
+// ollamaOptions maps OpenAI sampling parameters to Ollama model options
+var ollamaOptions = map[string]string{
+	"temperature":       "temperature",
+	"top_p":             "top_p",
+	"seed":              "seed",
+	"stop":              "stop",
+	"frequency_penalty": "frequency_penalty",
+	"presence_penalty":  "presence_penalty",
+	"max_tokens":        "num_predict",
+}
+
+// OllamaAdapter translates OpenAI chat completions to and from Ollama's native /api/chat
+// Ollama instances run on-prem without API keys, so the Bearer token is only sent when the
+// credential has one, e.g. for an instance behind an authenticating proxy
+type OllamaAdapter struct {
+	clientOnce sync.Once
+	client     *http.Client
+}
+
+func init() {
+	RegisterVendorAdapter("ollama", func() VendorAdapter { return NewOllamaAdapter() })
+}
+
+// NewOllamaAdapter creates an Ollama adapter
+func NewOllamaAdapter() *OllamaAdapter {
+	return &OllamaAdapter{}
+}
+
+// KeptRequestFields passes the sampling parameters and response format through validation,
+// to be sent as Ollama options and format
+func (a *OllamaAdapter) KeptRequestFields() []string {
+	fields := []string{"max_completion_tokens", "response_format"}
+	for openAIName := range ollamaOptions {
+		fields = append(fields, openAIName)
+	}
+	return fields
+}
+
+// Endpoint returns the native chat URL
+func (a *OllamaAdapter) Endpoint(baseURL, model string, streaming bool) string {
+	return baseURL + "/api/chat"
+}
+
+// EmbeddingsEndpoint returns Ollama's OpenAI-compatible embeddings URL
+func (a *OllamaAdapter) EmbeddingsEndpoint(baseURL string) string {
+	return baseURL + "/v1/embeddings"
+}
+
+// Authorize sets the Bearer token when the credential has one
+func (a *OllamaAdapter) Authorize(req *http.Request, credential config.Credential) error {
+	if credential.Value != "" {
+		req.Header.Set(utils.HeaderAuthorization, "Bearer "+credential.Value)
+	}
+	return nil
+}
+
+// HTTPClient returns the client used for Ollama requests
+// Local hosts should answer a connection attempt at once, so OLLAMA_CONNECT_TIMEOUT
+// (seconds, default 5) fails fast on an unreachable instance, while OLLAMA_TIMEOUT
+// (seconds, default 1800) leaves room for loading a model and generating on modest hardware
+func (a *OllamaAdapter) HTTPClient() *http.Client {
+	a.clientOnce.Do(func() {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = (&net.Dialer{
+			Timeout:   utils.GetEnvDuration("OLLAMA_CONNECT_TIMEOUT", 5*time.Second),
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+		a.client = &http.Client{
+			Timeout:   utils.GetEnvDuration("OLLAMA_TIMEOUT", 1800*time.Second),
+			Transport: transport,
+		}
+	})
+	return a.client
+}
+
+// TranslateRequest converts an OpenAI chat completion request into an /api/chat request
+// Content parts are flattened to text plus base64 images, tool call arguments are sent as
+// objects and sampling parameters move into options
+func (a *OllamaAdapter) TranslateRequest(body []byte) ([]byte, error) {
+	var request map[string]interface{}
+	if err := codec.Unmarshal(body, &request); err != nil {
+		return nil, fmt.Errorf("invalid request format: %w", err)
+	}
+
+	messages, _ := request["messages"].([]interface{})
+	converted := make([]interface{}, 0, len(messages))
+	for _, raw := range messages {
+		message, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		role, _ := message["role"].(string)
+		if role == "developer" {
+			role = "system"
+		}
+		ollamaMessage := map[string]interface{}{
+			"role":    role,
+			"content": contentText(message["content"]),
+		}
+		if images := ollamaImages(message["content"]); len(images) > 0 {
+			ollamaMessage["images"] = images
+		}
+		if toolCalls := ollamaToolCalls(message["tool_calls"]); len(toolCalls) > 0 {
+			ollamaMessage["tool_calls"] = toolCalls
+		}
+		converted = append(converted, ollamaMessage)
+	}
+
+	ollamaRequest := map[string]interface{}{
+		"model":    request["model"],
+		"messages": converted,
+		// Ollama streams unless told otherwise
+		"stream": request["stream"] == true,
+	}
+	if tools, ok := request["tools"].([]interface{}); ok && len(tools) > 0 {
+		ollamaRequest["tools"] = tools
+	}
+	if format := ollamaFormat(request["response_format"]); format != nil {
+		ollamaRequest["format"] = format
+	}
+
+	options := make(map[string]interface{})
+	for openAIName, ollamaName := range ollamaOptions {
+		if value, ok := request[openAIName]; ok && value != nil {
+			options[ollamaName] = value
+		}
+	}
+	if maxTokens, ok := request["max_completion_tokens"]; ok && maxTokens != nil {
+		options["num_predict"] = maxTokens
+	}
+	if len(options) > 0 {
+		ollamaRequest["options"] = options
+	}
+
+	return codec.Marshal(ollamaRequest)
+}
+
+// ollamaResponse is a non-streaming /api/chat response, and also the shape of each streamed line
+type ollamaResponse struct {
+	Model     string `json:"model"`
+	CreatedAt string `json:"created_at"`
+	Message   struct {
+		Role      string           `json:"role"`
+		Content   string           `json:"content"`
+		ToolCalls []ollamaToolCall `json:"tool_calls"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	DoneReason      string `json:"done_reason"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+// ollamaToolCall is a function call made by the model
+type ollamaToolCall struct {
+	Function struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	} `json:"function"`
+}
+
+// usage converts Ollama's evaluation counts to OpenAI usage
+func (r *ollamaResponse) usage() map[string]interface{} {
+	return map[string]interface{}{
+		"prompt_tokens":     r.PromptEvalCount,
+		"completion_tokens": r.EvalCount,
+		"total_tokens":      r.PromptEvalCount + r.EvalCount,
+	}
+}
+
+// created returns the response time as a Unix timestamp, defaulting to now
+func (r *ollamaResponse) created() int64 {
+	if createdAt, err := time.Parse(time.RFC3339Nano, r.CreatedAt); err == nil {
+		return createdAt.Unix()
+	}
+	return time.Now().Unix()
+}
+
+// TranslateResponse converts an /api/chat response into an OpenAI chat completion
+func (a *OllamaAdapter) TranslateResponse(body []byte) ([]byte, error) {
+	var response ollamaResponse
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	toolCalls, err := openAIToolCallsFromOllama(response.Message.ToolCalls)
+	if err != nil {
+		return nil, err
+	}
+
+	message := map[string]interface{}{
+		"role":    "assistant",
+		"content": response.Message.Content,
+	}
+	if len(toolCalls) > 0 {
+		message["tool_calls"] = toolCalls
+		if response.Message.Content == "" {
+			message["content"] = nil
+		}
+	}
+
+	return codec.Marshal(map[string]interface{}{
+		"id":      utils.GenerateChatCompletionID(),
+		"object":  "chat.completion",
+		"created": response.created(),
+		"model":   response.Model,
+		"choices": []interface{}{map[string]interface{}{
+			"index":         0,
+			"message":       message,
+			"finish_reason": ollamaFinishReason(response.DoneReason, len(toolCalls) > 0),
+		}},
+		"usage": response.usage(),
+	})
+}
+
+// TranslateStream converts Ollama's newline-delimited stream into OpenAI chunk events
+func (a *OllamaAdapter) TranslateStream(r io.Reader) io.Reader {
+	s := &ollamaStream{}
+	s.chunkReader = newChunkReader(r, utils.GenerateChatCompletionID(), s.translateEvent, func() { s.writeDone() })
+	return s
+}
+
+// ollamaStream produces OpenAI SSE chunks from Ollama's newline-delimited JSON stream; the
+// stream is complete at the response marked done or when the source ends
+type ollamaStream struct {
+	*chunkReader
+	started    bool
+	nextToolID int
+}
+
+// translateEvent converts a single streamed response line
+func (s *ollamaStream) translateEvent(data []byte) {
+	var event ollamaResponse
+	if err := codec.Unmarshal(data, &event); err != nil {
+		return
+	}
+
+	if !s.started {
+		s.started = true
+		s.writeChunk(map[string]interface{}{"role": "assistant", "content": ""}, nil, nil)
+	}
+	if event.Message.Content != "" {
+		s.writeChunk(map[string]interface{}{"content": event.Message.Content}, nil, nil)
+	}
+	if toolCalls, err := openAIToolCallsFromOllama(event.Message.ToolCalls); err == nil {
+		for _, call := range toolCalls {
+			call.(map[string]interface{})["index"] = s.nextToolID
+			s.nextToolID++
+			s.writeChunk(map[string]interface{}{"tool_calls": []interface{}{call}}, nil, nil)
+		}
+	}
+	if event.Done {
+		s.writeChunk(map[string]interface{}{}, ollamaFinishReason(event.DoneReason, s.nextToolID > 0), event.usage())
+		s.writeDone()
+	}
+}
+
+// ollamaFinishReason maps a done reason, reporting "tool_calls" when the model called tools
+func ollamaFinishReason(doneReason string, calledTools bool) string {
+	reason, ok := ollamaDoneReasons[doneReason]
+	if !ok {
+		reason = "stop"
+	}
+	if calledTools && reason == "stop" {
+		return "tool_calls"
+	}
+	return reason
+}
+
+// ollamaImages returns the base64 payloads of data: image URLs in message content
+// Ollama cannot fetch remote URLs; the image processor has already inlined public ones
+func ollamaImages(content interface{}) []interface{} {
+	parts, _ := content.([]interface{})
+	var images []interface{}
+	for _, part := range parts {
+		partMap, _ := part.(map[string]interface{})
+		imageURL, _ := partMap["image_url"].(map[string]interface{})
+		url, _ := imageURL["url"].(string)
+		if _, payload, ok := strings.Cut(url, ";base64,"); ok && strings.HasPrefix(url, "data:") {
+			images = append(images, payload)
+		}
+	}
+	return images
+}
+
+// ollamaToolCalls converts assistant tool_calls into Ollama calls with object arguments
+func ollamaToolCalls(toolCalls interface{}) []interface{} {
+	list, _ := toolCalls.([]interface{})
+	result := make([]interface{}, 0, len(list))
+	for _, call := range list {
+		callMap, ok := call.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		function, _ := callMap["function"].(map[string]interface{})
+		arguments := map[string]interface{}{}
+		if raw, ok := function["arguments"].(string); ok && raw != "" {
+			_ = codec.Unmarshal([]byte(raw), &arguments)
+		}
+		result = append(result, map[string]interface{}{
+			"function": map[string]interface{}{
+				"name":      function["name"],
+				"arguments": arguments,
+			},
+		})
+	}
+	return result
+}
+
+// ollamaFormat converts response_format into Ollama's format: "json" or a JSON schema
+func ollamaFormat(responseFormat interface{}) interface{} {
+	format, _ := responseFormat.(map[string]interface{})
+	switch format["type"] {
+	case "json_object":
+		return "json"
+	case "json_schema":
+		jsonSchema, _ := format["json_schema"].(map[string]interface{})
+		if schema, ok := jsonSchema["schema"]; ok {
+			return schema
+		}
+		return "json"
+	default:
+		return nil
+	}
+}
+
+// openAIToolCallsFromOllama converts Ollama tool calls into OpenAI tool calls with generated IDs
+func openAIToolCallsFromOllama(toolCalls []ollamaToolCall) ([]interface{}, error) {
+	result := make([]interface{}, 0, len(toolCalls))
+	for _, call := range toolCalls {
+		arguments := call.Function.Arguments
+		if arguments == nil {
+			arguments = map[string]interface{}{}
+		}
+		encoded, err := codec.Marshal(arguments)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid tool call arguments: %v", ErrInvalidResponse, err)
+		}
+		result = append(result, map[string]interface{}{
+			"id":   utils.GenerateToolCallID(),
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":      call.Function.Name,
+				"arguments": string(encoded),
+			},
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOllamaAdapter_TranslateRequest(t *testing.T) {
+	body := `{
+		"model": "llama3.2-vision:11b",
+		"messages": [
+			{"role": "developer", "content": "Be brief."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is this?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBORw0KGgo="}}
+			]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":\"cat\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "a cat"}
+		],
+		"temperature": 0.3,
+		"max_completion_tokens": 64,
+		"stop": ["\n\n"],
+		"response_format": {"type": "json_object"}
+	}`
+
+	translated, err := NewOllamaAdapter().TranslateRequest([]byte(body))
+	require.NoError(t, err)
+
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(translated, &request))
+	assert.Equal(t, false, request["stream"], "Ollama streams unless told otherwise")
+	assert.Equal(t, "json", request["format"])
+	assert.Equal(t, map[string]interface{}{"temperature": 0.3, "num_predict": float64(64), "stop": []interface{}{"\n\n"}}, request["options"])
+
+	messages := request["messages"].([]interface{})
+	require.Len(t, messages, 4)
+	assert.Equal(t, map[string]interface{}{"role": "system", "content": "Be brief."}, messages[0])
+	assert.Equal(t, map[string]interface{}{"role": "user", "content": "What is this?", "images": []interface{}{"iVBORw0KGgo="}}, messages[1])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"function": map[string]interface{}{"name": "lookup", "arguments": map[string]interface{}{"q": "cat"}},
+	}}, messages[2].(map[string]interface{})["tool_calls"])
+	assert.Equal(t, "a cat", messages[3].(map[string]interface{})["content"])
+}
+
+func TestOllamaAdapter_OptionsSurviveValidation(t *testing.T) {
+	body := `{"model":"llama3.2","messages":[{"role":"user","content":"Hi"}],"temperature":0.2,"max_completion_tokens":64,"response_format":{"type":"json_object"}}`
+	validated, _, err := validator.ValidateAndModifyRequest([]byte(body), "llama3.2", keptRequestFields("ollama")...)
+	require.NoError(t, err)
+
+	translated, err := NewOllamaAdapter().TranslateRequest(validated)
+	require.NoError(t, err)
+
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(translated, &request))
+	assert.Equal(t, map[string]interface{}{"temperature": 0.2, "num_predict": float64(64)}, request["options"])
+	assert.Equal(t, "json", request["format"])
+}
+
+func TestOllamaAdapter_TranslateResponse(t *testing.T) {
+	body := `{
+		"model": "llama3.1:8b",
+		"created_at": "2026-10-16T09:12:44.123456Z",
+		"message": {"role": "assistant", "content": "", "tool_calls": [{"function": {"name": "lookup", "arguments": {"q": "cat"}}}]},
+		"done": true,
+		"done_reason": "stop",
+		"prompt_eval_count": 26,
+		"eval_count": 12
+	}`
+
+	translated, err := NewOllamaAdapter().TranslateResponse([]byte(body))
+	require.NoError(t, err)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(translated, &response))
+	assert.Equal(t, "chat.completion", response["object"])
+	assert.Equal(t, float64(1792141964), response["created"])
+	assert.Equal(t, map[string]interface{}{"prompt_tokens": float64(26), "completion_tokens": float64(12), "total_tokens": float64(38)}, response["usage"])
+
+	choice := response["choices"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "tool_calls", choice["finish_reason"])
+	message := choice["message"].(map[string]interface{})
+	assert.Nil(t, message["content"])
+	call := message["tool_calls"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, `{"q":"cat"}`, call["function"].(map[string]interface{})["arguments"])
+	assert.NotEmpty(t, call["id"])
+}
+
+func TestOllamaAdapter_TranslateStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"model":"llama3.1:8b","message":{"role":"assistant","content":"Hel"},"done":false}`,
+		`{"model":"llama3.1:8b","message":{"role":"assistant","content":"lo"},"done":false}`,
+		`{"model":"llama3.1:8b","message":{"role":"assistant","content":""},"done":true,"done_reason":"length","prompt_eval_count":5,"eval_count":2}`,
+	}, "\n") + "\n"
+
+	translated, err := io.ReadAll(NewOllamaAdapter().TranslateStream(strings.NewReader(stream)))
+	require.NoError(t, err)
+
+	events := strings.Split(strings.TrimSpace(string(translated)), "\n\n")
+	require.Len(t, events, 5)
+	assert.Contains(t, events[0], `"role":"assistant"`)
+	assert.Contains(t, events[1], `"content":"Hel"`)
+	assert.Contains(t, events[2], `"content":"lo"`)
+	assert.Contains(t, events[3], `"finish_reason":"length"`)
+	assert.Contains(t, events[3], `"total_tokens":7`)
+	assert.Equal(t, "data: [DONE]", events[4])
+}
+
+func TestOllamaAdapter_Authorize(t *testing.T) {
+	adapter := NewOllamaAdapter()
+
+	req := httptest.NewRequest("POST", "http://localhost:11434/api/chat", nil)
+	require.NoError(t, adapter.Authorize(req, config.Credential{Platform: "ollama", Type: config.CredentialTypeNone}))
+	assert.Empty(t, req.Header.Get("Authorization"))
+
+	require.NoError(t, adapter.Authorize(req, config.Credential{Platform: "ollama", Type: "api-key", Value: "proxy-token"}))
+	assert.Equal(t, "Bearer proxy-token", req.Header.Get("Authorization"))
+
+	assert.Equal(t, "http://localhost:11434/api/chat", adapter.Endpoint("http://localhost:11434", "llama3.1:8b", true))
+}
+
+func TestOllamaAdapter_HTTPClient(t *testing.T) {
+	t.Setenv("OLLAMA_TIMEOUT", "90")
+
+	provider, ok := adapterFor("ollama").(HTTPClientProvider)
+	require.True(t, ok)
+	client := NewOllamaAdapter().HTTPClient()
+	assert.Equal(t, 90.0, client.Timeout.Seconds())
+	assert.Same(t, provider.HTTPClient(), provider.HTTPClient(), "the client is created once")
+}
@@ -1,15 +1,20 @@
 package proxy
 
 import (
-	"encoding/json"
+	"unicode/utf8"
 
+	"github.com/aashari/go-generative-api-router/internal/codec"
 	"github.com/aashari/go-generative-api-router/internal/types"
 )
 
+// charsPerToken is the usual rough ratio of characters to tokens for English text. Prompts
+// are sized with it for routing, before the model and so its tokenizer are known
+const charsPerToken = 4
+
 // AnalyzePayload extracts routing-relevant information from the request payload
 func AnalyzePayload(body []byte) (*types.PayloadContext, error) {
 	var requestData map[string]interface{}
-	if err := json.Unmarshal(body, &requestData); err != nil {
+	if err := codec.Unmarshal(body, &requestData); err != nil {
 		return nil, err
 	}
 
@@ -27,6 +32,15 @@ func AnalyzePayload(body []byte) (*types.PayloadContext, error) {
 		context.HasStream = stream
 	}
 
+	// Check for audio output
+	if modalities, ok := requestData["modalities"].([]interface{}); ok {
+		for _, modality := range modalities {
+			if modality == "audio" {
+				context.HasAudio = true
+			}
+		}
+	}
+
 	// Check for tools
 	if tools, ok := requestData["tools"].([]interface{}); ok && len(tools) > 0 {
 		context.HasTools = true
@@ -36,7 +50,7 @@ func AnalyzePayload(body []byte) (*types.PayloadContext, error) {
 	if messages, ok := requestData["messages"].([]interface{}); ok {
 		context.MessagesCount = len(messages)
 
-		// Check for images and videos in message content
+		// Check for images, videos and audio in message content
 		for _, msg := range messages {
 			if msgMap, ok := msg.(map[string]interface{}); ok {
 				// Check if content is an array (for multimodal messages)
@@ -49,6 +63,8 @@ func AnalyzePayload(body []byte) (*types.PayloadContext, error) {
 									context.HasImages = true
 								case "video_url":
 									context.HasVideos = true
+								case "input_audio", "audio_url":
+									context.HasAudio = true
 								}
 							}
 						}
@@ -58,11 +74,49 @@ func AnalyzePayload(body []byte) (*types.PayloadContext, error) {
 		}
 	}
 
-	// TODO: Add token estimation logic here if needed
+	// Estimate the prompt size, for selectors that weigh context windows and cost; vendors
+	// tokenize differently, so a character-based approximation is enough
+	messages, _ := requestData["messages"].([]interface{})
+	context.PromptTokens = estimateMessageTokens(messages)
+	for _, field := range []string{"max_completion_tokens", "max_tokens"} {
+		if limit, ok := requestData[field].(float64); ok && limit > 0 {
+			context.MaxCompletionTokens = int(limit)
+			break
+		}
+	}
 
 	return context, nil
 }
 
+// estimateMessageTokens estimates prompt tokens from the text content of decoded request messages
+func estimateMessageTokens(messages []interface{}) int {
+	chars := 0
+	for _, msg := range messages {
+		msgMap, ok := msg.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch content := msgMap["content"].(type) {
+		case string:
+			chars += utf8.RuneCountInString(content)
+		case []interface{}:
+			for _, part := range content {
+				if partMap, ok := part.(map[string]interface{}); ok {
+					if text, ok := partMap["text"].(string); ok {
+						chars += utf8.RuneCountInString(text)
+					}
+				}
+			}
+		}
+	}
+	return estimateTokens(chars)
+}
+
+// estimateTokens estimates the token count of text from its character count
+func estimateTokens(chars int) int {
+	return (chars + charsPerToken - 1) / charsPerToken
+}
+
 // ShouldExcludeModel determines if a model should be excluded based on payload context
 // This will be used when model configuration is extended with capabilities
 func ShouldExcludeModel(context *types.PayloadContext, modelConfig map[string]interface{}) bool {
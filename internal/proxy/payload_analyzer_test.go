@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzePayload_Capabilities(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		images bool
+		videos bool
+		audio  bool
+	}{
+		{
+			name: "text only",
+			body: `{"messages":[{"role":"user","content":"Hello"}]}`,
+		},
+		{
+			name:   "image part",
+			body:   `{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}]}`,
+			images: true,
+		},
+		{
+			name:   "video part",
+			body:   `{"messages":[{"role":"user","content":[{"type":"video_url","video_url":{"url":"https://example.com/a.mp4"}}]}]}`,
+			videos: true,
+		},
+		{
+			name:  "input audio part",
+			body:  `{"messages":[{"role":"user","content":[{"type":"input_audio","input_audio":{"data":"UklGRg==","format":"wav"}}]}]}`,
+			audio: true,
+		},
+		{
+			name:  "audio output modality",
+			body:  `{"modalities":["text","audio"],"messages":[{"role":"user","content":"Say hi"}]}`,
+			audio: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			context, err := AnalyzePayload([]byte(tt.body))
+			require.NoError(t, err)
+			assert.Equal(t, tt.images, context.HasImages)
+			assert.Equal(t, tt.videos, context.HasVideos)
+			assert.Equal(t, tt.audio, context.HasAudio)
+		})
+	}
+}
+
+func TestProxyRequest_NoCapableModel(t *testing.T) {
+	creds := []config.Credential{{Platform: "openai", Type: "api-key", Value: "sk-test"}}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4", Config: &config.ModelConfig{SupportImage: true, SupportTools: true}}}
+
+	req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(
+		`{"model":"my-model","messages":[{"role":"user","content":[{"type":"input_audio","input_audio":{"data":"UklGRg==","format":"wav"}}]}]}`))
+	rr := httptest.NewRecorder()
+	ProxyRequest(rr, req, creds, models, NewAPIClient(map[string]string{"openai": "http://127.0.0.1:0"}), selector.NewContextAwareSelector())
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "request needs audio")
+}
@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"io"
+
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+)
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// countingReadCloser counts the bytes read through a ReadCloser such as a response body
+type countingReadCloser struct {
+	countingReader
+	closer io.Closer
+}
+
+func (c *countingReadCloser) Close() error {
+	return c.closer.Close()
+}
+
+// responseSizeTracker measures a vendor response body on the wire and after decompression
+type responseSizeTracker struct {
+	vendor       string
+	compressed   bool
+	wire         *countingReadCloser
+	uncompressed int64
+}
+
+// trackResponseSize replaces body with a counting reader and returns the tracker measuring it
+func trackResponseSize(vendor string, body *io.ReadCloser, compressed bool) *responseSizeTracker {
+	wire := &countingReadCloser{countingReader: countingReader{Reader: *body}, closer: *body}
+	*body = wire
+	return &responseSizeTracker{vendor: vendor, compressed: compressed, wire: wire}
+}
+
+// record publishes the measured sizes to the payload size metrics
+func (t *responseSizeTracker) record() {
+	uncompressed := t.uncompressed
+	if !t.compressed {
+		uncompressed = t.wire.n
+	}
+	monitoring.DefaultPayloadSizeMetrics().RecordResponse(t.vendor, t.wire.n, uncompressed, t.compressed)
+}
@@ -3,13 +3,21 @@ package proxy
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"time"
 
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/alias"
 	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/events"
+	"github.com/aashari/go-generative-api-router/internal/files"
 	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
 	"github.com/aashari/go-generative-api-router/internal/reliability"
 	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/tools"
 	"github.com/aashari/go-generative-api-router/internal/utils"
 	"github.com/aashari/go-generative-api-router/internal/validator"
 )
@@ -26,6 +34,8 @@ func ProxyRequest(w http.ResponseWriter, r *http.Request, creds []config.Credent
 		return
 	}
 
+	start := time.Now()
+
 	// Read the request body once and reuse it
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -38,6 +48,12 @@ func ProxyRequest(w http.ResponseWriter, r *http.Request, creds []config.Credent
 		logger.Warn(ctx, "Failed to close request body", "error", err)
 	}
 
+	// Offer the server-side tools to the model and run the ones it calls
+	if modified, clientTools, ok := offerServerTools(body, tools.Default()); ok {
+		body = modified
+		apiClient = &serverToolClient{next: apiClient, registry: tools.Default(), clientTools: clientTools}
+	}
+
 	// Parse payload to extract original model and other context
 	payloadContext, err := AnalyzePayload(body)
 	var originalModel string
@@ -63,18 +79,92 @@ func ProxyRequest(w http.ResponseWriter, r *http.Request, creds []config.Credent
 			"messages_count", payloadContext.MessagesCount)
 	}
 
+	// The end user named by the OpenAI "user" field is logged and recorded hashed, and
+	// counted against the client key's per-user rate limit
+	user, err := requestUser(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if user != "" {
+		r = r.WithContext(logger.WithUser(r.Context(), access.UserHash(user)))
+	}
+	if payloadContext != nil {
+		payloadContext.ConversationKey = conversationKey(r)
+	}
+	publishEvent(r, events.Event{Type: events.RequestReceived, OriginalModel: originalModel})
+	if !enforceRateLimits(w, r, user) {
+		return
+	}
+
+	// Requests naming a model alias are routed within the alias's pool
+	candidateCount := len(models)
+	creds, models, isAlias := alias.Default().Filter(originalModel, creds, models)
+	if isAlias && len(models) == 0 {
+		err := fmt.Errorf("no model of alias %q is available", originalModel)
+		ctx := logger.WithComponent(r.Context(), "proxy")
+		ctx = logger.WithStage(ctx, "model_alias")
+		logger.Warn(ctx, "Alias pool is unavailable", "alias", originalModel)
+		recordSelectionFailure(r, originalModel, payloadContext, candidateCount, start, err)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	// Restrict the pool to what the client key's ACL permits, minus any requested exclusions
+	exclusions, err := parseRoutingExclusions(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r = r.WithContext(withRoutingExclusions(r.Context(), exclusions))
+
+	// A request may give its vendors less time than the configured timeouts allow; the
+	// deadline covers every attempt, retry and failover hop made for it
+	requestTimeout, err := parseRequestTimeout(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	deadlineCtx, cancelDeadline := withRequestDeadline(r.Context(), requestTimeout)
+	defer cancelDeadline()
+	r = r.WithContext(deadlineCtx)
+	creds, models, err = restrictRoutingPool(access.Default().PolicyFor(r), exclusions, creds, models)
+	if err != nil {
+		ctx := logger.WithComponent(r.Context(), "proxy")
+		ctx = logger.WithStage(ctx, "routing_access")
+		logger.Warn(ctx, "Routing pool restriction rejected request", "error", err.Error())
+		recordSelectionFailure(r, originalModel, payloadContext, candidateCount, start, err)
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrExclusionsDenied) || errors.Is(err, ErrNoPermittedRoute) {
+			status = http.StatusForbidden
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	// Serve near-identical templated requests from the semantic cache when SEMANTIC_CACHE_FILE is set
+	apiClient, served := serveFromSemanticCache(w, r, body, apiClient)
+	if served {
+		return
+	}
+
 	// Use context-aware selection if available
 	var selection *selector.VendorSelection
 
 	// Check if the selector supports context-aware selection
-	if contextSelector, ok := modelSelector.(*selector.ContextAwareSelector); ok && payloadContext != nil {
+	if contextSelector, ok := modelSelector.(selector.ContextSelector); ok && payloadContext != nil {
 		// Use context-aware selection
 		selection, err = contextSelector.SelectWithContext(creds, models, payloadContext)
 		if err != nil {
 			ctx := logger.WithComponent(r.Context(), "proxy")
 			ctx = logger.WithStage(ctx, "vendor_selection")
 			logger.Error(ctx, "Context-aware vendor selection failed", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			recordSelectionFailure(r, originalModel, payloadContext, len(models), start, err)
+			status := http.StatusInternalServerError
+			if errors.Is(err, selector.ErrNoCapableModel) {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
 			return
 		}
 		ctx := logger.WithComponent(r.Context(), "proxy")
@@ -85,6 +175,7 @@ func ProxyRequest(w http.ResponseWriter, r *http.Request, creds []config.Credent
 			"context_filters", map[string]bool{
 				"images": payloadContext.HasImages,
 				"videos": payloadContext.HasVideos,
+				"audio":  payloadContext.HasAudio,
 				"tools":  payloadContext.HasTools,
 				"stream": payloadContext.HasStream,
 			})
@@ -95,14 +186,37 @@ func ProxyRequest(w http.ResponseWriter, r *http.Request, creds []config.Credent
 			ctx := logger.WithComponent(r.Context(), "proxy")
 			ctx = logger.WithStage(ctx, "vendor_selection")
 			logger.Error(ctx, "Vendor selection failed", err)
+			recordSelectionFailure(r, originalModel, payloadContext, len(models), start, err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 	}
 
+	// Track the routing decision so it can be queried later via the admin endpoint
+	decision := newRoutingDecision(r, selection, originalModel, payloadContext, models)
+	if seed, ok := requestSeed(body); ok {
+		decision.Seed = &seed
+	}
+	r = r.WithContext(withRoutingDecision(r.Context(), decision))
+	publishEvent(r, events.Event{Type: events.VendorSelected, OriginalModel: originalModel, Vendor: selection.Vendor, Model: selection.Model})
+
+	// The request holds a slot on the vendor it is sent to until it is served, waiting for one
+	// while the vendor or the router is at its concurrency limit; failover moves the slot to
+	// each fallback vendor
+	slot := &vendorSlot{}
+	defer slot.Release()
+	r = r.WithContext(withVendorSlot(r.Context(), slot))
+	if err := slot.moveTo(w, r, selection.Vendor); err != nil {
+		decision.Complete(err)
+		publishOutcome(r, decision, start, err)
+		return
+	}
+
 	// Execute the proxy request with retry logic
 	// Pass the original model we extracted
-	err = executeProxyRequestWithRetry(w, r, selection, body, creds, models, apiClient, modelSelector, originalModel)
+	err = executeProxyRequestWithRetry(w, r, selection, body, creds, models, apiClient, modelSelector, originalModel, decision)
+	decision.Complete(err)
+	publishOutcome(r, decision, start, err)
 	if err != nil {
 		// Error already handled in executeProxyRequestWithRetry
 		return
@@ -111,12 +225,15 @@ func ProxyRequest(w http.ResponseWriter, r *http.Request, creds []config.Credent
 
 // executeProxyRequestWithRetry handles the actual proxy request with comprehensive retry logic
 func executeProxyRequestWithRetry(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, body []byte,
-	creds []config.Credential, models []config.VendorModel, apiClient APIClientInterface, modelSelector selector.Selector, originalModel string) error {
+	creds []config.Credential, models []config.VendorModel, apiClient APIClientInterface, modelSelector selector.Selector, originalModel string, decision *routingDecision) error {
 
 	// Enrich context with vendor information and models
 	ctx := context.WithValue(r.Context(), "vendor", selection.Vendor)
 	ctx = context.WithValue(ctx, "model", selection.Model)
 	ctx = context.WithValue(ctx, "vendor_models", models)
+	// Track what a streaming response has sent, so a vendor failing before any content can
+	// be replaced without the client noticing
+	ctx = withStreamState(ctx)
 	r = r.WithContext(ctx)
 
 	ctx = logger.WithComponent(ctx, "proxy")
@@ -136,9 +253,13 @@ func executeProxyRequestWithRetry(w http.ResponseWriter, r *http.Request, select
 		"stage", "RequestLogging",
 	)
 
-	// Process image URLs if present (convert public URLs to base64)
+	// Process image URLs if present (convert public URLs to base64); file://{id} handles
+	// resolve to the files uploaded with the request's client key
+	timing := monitoring.ServerTimingFromContext(ctx)
+	mediaStarted := time.Now()
 	imageProcessor := NewImageProcessor()
-	processedBody, err := imageProcessor.ProcessRequestBody(ctx, body)
+	processedBody, err := imageProcessor.ProcessRequestBody(files.WithOwner(ctx, files.Owner(access.ClientKey(r))), body)
+	timing.Add(monitoring.TimingMedia, time.Since(mediaStarted))
 	if err != nil {
 		ctx = logger.WithStage(ctx, "image_processing")
 		logger.Error(ctx, "Image processing failed", err)
@@ -156,13 +277,24 @@ func executeProxyRequestWithRetry(w http.ResponseWriter, r *http.Request, select
 	}
 
 	// Validate and modify request
-	modifiedBody, _, err := validator.ValidateAndModifyRequest(processedBody, selection.Model)
+	validationStarted := time.Now()
+	modifiedBody, _, err := validator.ValidateAndModifyRequest(processedBody, selection.Model, keptRequestFields(selection.Vendor)...)
+	timing.Add(monitoring.TimingValidation, time.Since(validationStarted))
 	if err != nil {
 		ctx = logger.WithStage(ctx, "request_validation")
 		logger.Error(ctx, "Request validation failed", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return err
 	}
+	publishEvent(r, events.Event{Type: events.RequestValidated, OriginalModel: originalModel, Vendor: selection.Vendor, Model: selection.Model})
+
+	// Merge consecutive same-role messages for vendors that reject them
+	modifiedBody = normalizeMessagesForVendor(ctx, selection.Vendor, modifiedBody)
+
+	// Shrink inline images the selected vendor would reject as too large
+	mediaStarted = time.Now()
+	modifiedBody = offloadOversizedImagesForVendor(ctx, selection.Vendor, modifiedBody)
+	timing.Add(monitoring.TimingMedia, time.Since(mediaStarted))
 
 	// Use the passed original model (already extracted in ProxyRequest)
 
@@ -180,9 +312,9 @@ func executeProxyRequestWithRetry(w http.ResponseWriter, r *http.Request, select
 		"vendor", selection.Vendor,
 		"model", selection.Model,
 		"total_combinations", len(creds)*len(models),
-		"original_request_body", string(body),
-		"processed_request_body", string(processedBody),
-		"modified_request_body", string(modifiedBody),
+		"original_request_body", logger.RequestBody(body),
+		"processed_request_body", logger.RequestBody(processedBody),
+		"modified_request_body", logger.RequestBody(modifiedBody),
 		"request_headers", r.Header,
 		"selection_details", map[string]any{
 			"vendor":                selection.Vendor,
@@ -205,119 +337,130 @@ func executeProxyRequestWithRetry(w http.ResponseWriter, r *http.Request, select
 		"stage", "RequestProcessing",
 	)
 
-	// Create retry executor with default configuration
-	retryExecutor := reliability.NewRetryExecutor(nil) // Uses default config
+	// Create retry executor with default configuration, retrying only within the client
+	// key's retry budget
+	clientKey := access.ClientKey(r)
+	retryBudget := reliability.DefaultRetryBudget()
+	retryBudget.RecordRequest(clientKey)
+	retryConfig := reliability.DefaultRetryConfig()
+	retryConfig.AllowRetry = func() bool { return retryBudget.AllowRetry(clientKey) }
+	retryExecutor := reliability.NewRetryExecutor(retryConfig)
 
 	// Execute the API request with retry logic
 	err = retryExecutor.ExecuteWithRetry(ctx, func() error {
+		decision.Attempts++
 		return apiClient.SendRequest(w, r, selection, modifiedBody, originalModel)
 	})
 
 	if err != nil {
-		// Check if this is a retriable validation error (vendor fallback)
-		if IsRetriableValidationError(err) {
-			ctx = logger.WithStage(ctx, "vendor_fallback")
-			logger.Warn(ctx, "Vendor validation failed, attempting random fallback",
-				"original_vendor", selection.Vendor,
-				"original_model", selection.Model,
-				"error", err.Error(),
-				"fallback_strategy", "random_selection")
-
-			// Check if we have any credentials and models available for fallback
-			if len(creds) == 0 || len(models) == 0 {
-				logger.Error(ctx, "No credentials or models available for fallback", nil,
-					"total_creds", len(creds),
-					"total_models", len(models))
-				http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
-				return err
-			}
+		// A client that went away or ran out of time gets no further vendor attempts
+		if ctxErr := requestContextError(r.Context()); ctxErr != nil {
+			err = ctxErr
+		}
 
-			// Select a different vendor/model combination for retry
-			var fallbackSelection *selector.VendorSelection
-			var retryErr error
-
-			// Try context-aware selection for retry if available
-			if contextSelector, ok := modelSelector.(*selector.ContextAwareSelector); ok {
-				// Re-parse the payload to get context
-				payloadContext, _ := AnalyzePayload(body)
-				if payloadContext != nil {
-					fallbackSelection, retryErr = contextSelector.SelectWithContext(creds, models, payloadContext)
-				} else {
-					fallbackSelection, retryErr = modelSelector.Select(creds, models)
-				}
-			} else {
-				fallbackSelection, retryErr = modelSelector.Select(creds, models)
-			}
+		// A rate limit, server error or invalid response that outlasted the retries hands the
+		// request over to another vendor model or credential
+		if shouldFailOver(err) {
+			return failOver(ctx, w, r, err, selection, body, processedBody, creds, models, apiClient, modelSelector, originalModel, decision)
+		}
 
-			if retryErr != nil {
-				logger.Error(ctx, "Failed to select fallback vendor/model", retryErr)
-				http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
-				return err
-			}
+		writeUpstreamError(ctx, w, err, selection.Vendor)
+		return err
+	}
 
-			logger.Info(ctx, "Retrying with random fallback selection",
-				"fallback_vendor", fallbackSelection.Vendor,
-				"fallback_model", fallbackSelection.Model,
-				"original_model", originalModel,
-				"original_vendor", selection.Vendor)
-
-			// Create a fresh request for the retry (important for proper context)
-			retryReq := r.Clone(r.Context())
-
-			// Execute retry with the new selection (no further retries to avoid infinite loops)
-			// Note: We don't call executeProxyRequestWithRetry to avoid infinite recursion
-			// Instead, we directly call the API client with the new selection
-			retryCtx := context.WithValue(retryReq.Context(), "vendor", fallbackSelection.Vendor)
-			retryCtx = context.WithValue(retryCtx, "model", fallbackSelection.Model)
-			retryReq = retryReq.WithContext(retryCtx)
-
-			// Validate and modify request for the new vendor
-			fallbackModifiedBody, _, validationErr := validator.ValidateAndModifyRequest(processedBody, fallbackSelection.Model)
-			if validationErr != nil {
-				retryCtx = logger.WithStage(retryCtx, "fallback_validation")
-				logger.Error(retryCtx, "Fallback request validation failed", validationErr)
-				http.Error(w, "Service temporarily unavailable", http.StatusServiceUnavailable)
-				return validationErr
-			}
+	return nil
+}
 
-			// Execute the fallback request directly (no retry to avoid recursion)
-			return apiClient.SendRequest(w, retryReq, fallbackSelection, fallbackModifiedBody, originalModel)
-		}
+// prepareFallbackRequest readies a request for a fallback vendor: a clone of r naming the
+// new vendor and model in its context, and the processed body validated and adapted for them
+func prepareFallbackRequest(r *http.Request, selection *selector.VendorSelection, processedBody []byte) (*http.Request, []byte, error) {
+	// Create a fresh request for the retry (important for proper context)
+	retryReq := r.Clone(r.Context())
+	retryCtx := context.WithValue(retryReq.Context(), "vendor", selection.Vendor)
+	retryCtx = context.WithValue(retryCtx, "model", selection.Model)
+	retryReq = retryReq.WithContext(retryCtx)
+
+	// Validate and modify request for the new vendor
+	timing := monitoring.ServerTimingFromContext(retryCtx)
+	validationStarted := time.Now()
+	modifiedBody, _, err := validator.ValidateAndModifyRequest(processedBody, selection.Model, keptRequestFields(selection.Vendor)...)
+	timing.Add(monitoring.TimingValidation, time.Since(validationStarted))
+	if err != nil {
+		retryCtx = logger.WithStage(retryCtx, "fallback_validation")
+		logger.Error(retryCtx, "Fallback request validation failed", err)
+		return nil, nil, err
+	}
+	modifiedBody = normalizeMessagesForVendor(retryCtx, selection.Vendor, modifiedBody)
+	mediaStarted := time.Now()
+	modifiedBody = offloadOversizedImagesForVendor(retryCtx, selection.Vendor, modifiedBody)
+	timing.Add(monitoring.TimingMedia, time.Since(mediaStarted))
+	return retryReq, modifiedBody, nil
+}
 
-		// Check if this is a retriable API error (quota, rate limits, server errors)
-		if IsRetriableAPIError(err) {
-			isQuotaError := IsQuotaError(err)
-			ctx = logger.WithStage(ctx, "api_error_handling")
-			logger.Error(ctx, "Retriable API error after all retry attempts", err,
-				"vendor", selection.Vendor,
-				"error_type", "retriable_api_error_exhausted",
-				"is_quota", isQuotaError)
-
-			// For quota or rate limit errors, return 429 status
-			if isQuotaError {
-				http.Error(w, "API quota or rate limit exceeded. Please try again later.", http.StatusTooManyRequests)
-			} else {
-				http.Error(w, "Service temporarily unavailable after multiple retries.", http.StatusServiceUnavailable)
-			}
-			return err
+// writeUpstreamError answers a request whose vendor call failed: 429 for quota and rate
+// limits, 503 for other retriable errors that outlasted the retries, 504 when the request's
+// own deadline passed, 502 otherwise. Clients that disconnected get no answer
+func writeUpstreamError(ctx context.Context, w http.ResponseWriter, err error, vendor string) {
+	// Nobody is left to answer once the client went away
+	if errors.Is(err, ErrClientDisconnected) {
+		ctx = logger.WithStage(ctx, "client_disconnected")
+		logger.Info(ctx, "Client disconnected, vendor request aborted",
+			"vendor", vendor)
+		return
+	}
+
+	// Once a stream has started, the client can only be told in the stream itself
+	if streamStarted(ctx) {
+		ctx = logger.WithStage(ctx, "stream_error_handling")
+		logger.Error(ctx, "Streaming response failed after it started", err,
+			"vendor", vendor)
+		if writeErr := writeStreamError(w, "The response stream was interrupted: "+err.Error()); writeErr != nil {
+			logger.Warn(ctx, "Failed to send stream error event", "error", writeErr.Error())
 		}
+		return
+	}
 
-		// Check for specific error types
-		if errors.Is(err, ErrUnknownVendor) {
-			ctx = logger.WithStage(ctx, "configuration_error")
-			logger.Error(ctx, "Unknown vendor configuration error", err,
-				"vendor", selection.Vendor)
-			http.Error(w, "Internal configuration error: Unknown vendor", http.StatusBadRequest)
-			return err
+	// Check if this is a retriable API error (quota, rate limits, server errors)
+	if IsRetriableAPIError(err) {
+		isQuotaError := IsQuotaError(err)
+		ctx = logger.WithStage(ctx, "api_error_handling")
+		logger.Error(ctx, "Retriable API error after all retry attempts", err,
+			"vendor", vendor,
+			"error_type", "retriable_api_error_exhausted",
+			"is_quota", isQuotaError)
+
+		// For quota or rate limit errors, return 429 status
+		if isQuotaError {
+			http.Error(w, "API quota or rate limit exceeded. Please try again later.", http.StatusTooManyRequests)
+		} else {
+			http.Error(w, "Service temporarily unavailable after multiple retries.", http.StatusServiceUnavailable)
 		}
+		return
+	}
 
-		// For other network errors
-		ctx = logger.WithStage(ctx, "communication_error")
-		logger.Error(ctx, "Failed to communicate with upstream service", err,
-			"vendor", selection.Vendor)
-		http.Error(w, "Failed to communicate with upstream service: "+err.Error(), http.StatusBadGateway)
-		return err
+	// The client's X-Request-Timeout ran out before a vendor answered
+	var deadlineErr *RequestDeadlineError
+	if errors.As(err, &deadlineErr) {
+		ctx = logger.WithStage(ctx, "request_deadline")
+		logger.Warn(ctx, "Request deadline exceeded before a vendor answered",
+			"vendor", vendor,
+			"request_timeout", deadlineErr.Limit.String())
+		http.Error(w, "Request timed out: "+deadlineErr.Error(), http.StatusGatewayTimeout)
+		return
 	}
 
-	return nil
+	// Check for specific error types
+	if errors.Is(err, ErrUnknownVendor) {
+		ctx = logger.WithStage(ctx, "configuration_error")
+		logger.Error(ctx, "Unknown vendor configuration error", err,
+			"vendor", vendor)
+		http.Error(w, "Internal configuration error: Unknown vendor", http.StatusBadRequest)
+		return
+	}
+
+	// For other network errors
+	ctx = logger.WithStage(ctx, "communication_error")
+	logger.Error(ctx, "Failed to communicate with upstream service", err,
+		"vendor", vendor)
+	http.Error(w, "Failed to communicate with upstream service: "+err.Error(), http.StatusBadGateway)
 }
@@ -69,6 +69,8 @@ func setupProxyTestData() ([]config.Credential, []config.VendorModel, map[string
 // TestFullProxyPipeline_NonStreaming tests the complete proxy pipeline for non-streaming requests
 func TestFullProxyPipeline_NonStreaming(t *testing.T) {
 	credentials, models, vendors := setupProxyTestData()
+	// The vendor's own error responses are under test here, so nothing fails over
+	t.Setenv("FAILOVER_MAX_HOPS", "0")
 
 	tests := []struct {
 		name           string
@@ -0,0 +1,244 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Reasoning policies; each decides how the chain of thought of reasoning models reaches clients
+const (
+	// ReasoningPolicyField returns reasoning in the message's reasoning_content field
+	ReasoningPolicyField = "field"
+	// ReasoningPolicyStrip drops reasoning from responses
+	ReasoningPolicyStrip = "strip"
+	// ReasoningPolicyExtensions returns reasoning in the message's "extensions" object
+	ReasoningPolicyExtensions = "extensions"
+	// ReasoningPolicyInline returns reasoning in content, wrapped in <think> tags
+	ReasoningPolicyInline = "inline"
+)
+
+// Tags some vendors wrap a chain of thought in when they return it as part of content
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// ValidReasoningPolicy reports whether policy names a reasoning policy
+func ValidReasoningPolicy(policy string) bool {
+	switch policy {
+	case ReasoningPolicyField, ReasoningPolicyStrip, ReasoningPolicyExtensions, ReasoningPolicyInline:
+		return true
+	}
+	return false
+}
+
+// reasoningPolicy returns the reasoning policy for a request: the client key's ACL policy
+// when it sets one, otherwise REASONING_POLICY. Unknown values fall back to the field policy
+func reasoningPolicy(r *http.Request) string {
+	policy := access.Default().PolicyFor(r).Reasoning
+	if policy == "" {
+		policy = utils.GetEnvString("REASONING_POLICY", ReasoningPolicyField)
+	}
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	if !ValidReasoningPolicy(policy) {
+		return ReasoningPolicyField
+	}
+	return policy
+}
+
+// extractInlineReasoning moves a <think> block leading a message's content into
+// reasoning_content, unless the vendor already reported reasoning separately
+func extractInlineReasoning(message map[string]interface{}) {
+	content, ok := message["content"].(string)
+	if !ok {
+		return
+	}
+	trimmed := strings.TrimLeft(content, " \t\r\n")
+	if !strings.HasPrefix(trimmed, thinkOpenTag) {
+		return
+	}
+	reasoning, answer, found := strings.Cut(trimmed[len(thinkOpenTag):], thinkCloseTag)
+	if !found {
+		return
+	}
+	message["content"] = strings.TrimLeft(answer, " \t\r\n")
+	if existing, _ := message["reasoning_content"].(string); existing == "" {
+		if reasoning = strings.TrimSpace(reasoning); reasoning != "" {
+			message["reasoning_content"] = reasoning
+		}
+	}
+}
+
+// applyReasoningPolicy rewrites the reasoning_content of a complete message per policy
+func applyReasoningPolicy(message map[string]interface{}, policy string) {
+	reasoning, _ := message["reasoning_content"].(string)
+	if reasoning == "" {
+		return
+	}
+	switch policy {
+	case ReasoningPolicyStrip:
+		delete(message, "reasoning_content")
+	case ReasoningPolicyExtensions:
+		delete(message, "reasoning_content")
+		addMessageExtension(message, "reasoning", reasoning)
+	case ReasoningPolicyInline:
+		delete(message, "reasoning_content")
+		content, _ := message["content"].(string)
+		message["content"] = thinkOpenTag + reasoning + thinkCloseTag + "\n\n" + content
+	}
+}
+
+// addMessageExtension sets a field of a message's or delta's "extensions" object
+func addMessageExtension(message map[string]interface{}, name string, value interface{}) {
+	extensions, _ := message["extensions"].(map[string]interface{})
+	if extensions == nil {
+		extensions = make(map[string]interface{})
+		message["extensions"] = extensions
+	}
+	extensions[name] = value
+}
+
+// ApplyReasoningPolicy rewrites the reasoning of every choice of a processed chat completion
+// response per policy
+func ApplyReasoningPolicy(body []byte, policy string) ([]byte, error) {
+	if policy == "" || policy == ReasoningPolicyField || !strings.Contains(string(body), `"reasoning_content"`) {
+		return body, nil
+	}
+	var response map[string]interface{}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	choices, _ := response["choices"].([]interface{})
+	for _, choice := range choices {
+		choiceMap, _ := choice.(map[string]interface{})
+		if message, ok := choiceMap["message"].(map[string]interface{}); ok {
+			applyReasoningPolicy(message, policy)
+		}
+	}
+	return codec.Marshal(response)
+}
+
+// reasoningStream tracks the reasoning of one streamed choice across deltas
+type reasoningStream struct {
+	phase int
+	// pending holds content that may be the start of a tag split across deltas
+	pending string
+	// trimAnswer drops the whitespace separating an inline block from the answer
+	trimAnswer bool
+	// inlineOpen is set once an opening <think> tag was sent under the inline policy
+	inlineOpen bool
+}
+
+// Phases of a streamed choice's content
+const (
+	reasoningPhaseStart = iota
+	reasoningPhaseThinking
+	reasoningPhaseAnswer
+)
+
+// processStreamReasoning extracts inline reasoning from a delta's content and applies the
+// reasoning policy to it. finished closes an inline block still open when the choice ends
+func (sp *StreamProcessor) processStreamReasoning(delta map[string]interface{}, choiceIndex int, finished bool) {
+	if sp.reasoning == nil {
+		sp.reasoning = make(map[int]*reasoningStream)
+	}
+	state, ok := sp.reasoning[choiceIndex]
+	if !ok {
+		state = &reasoningStream{}
+		sp.reasoning[choiceIndex] = state
+	}
+
+	if content, ok := delta["content"].(string); ok {
+		answer, reasoning := state.split(content)
+		delta["content"] = answer
+		if existing, _ := delta["reasoning_content"].(string); reasoning != "" {
+			delta["reasoning_content"] = existing + reasoning
+		}
+	}
+
+	reasoning, _ := delta["reasoning_content"].(string)
+	if reasoning == "" {
+		delete(delta, "reasoning_content")
+	}
+	switch sp.ReasoningPolicy {
+	case ReasoningPolicyStrip:
+		delete(delta, "reasoning_content")
+	case ReasoningPolicyExtensions:
+		if reasoning != "" {
+			delete(delta, "reasoning_content")
+			addMessageExtension(delta, "reasoning", reasoning)
+		}
+	case ReasoningPolicyInline:
+		delete(delta, "reasoning_content")
+		content, _ := delta["content"].(string)
+		var inline string
+		if reasoning != "" {
+			if !state.inlineOpen {
+				inline = thinkOpenTag
+				state.inlineOpen = true
+			}
+			inline += reasoning
+		}
+		if state.inlineOpen && (content != "" || finished) {
+			inline += thinkCloseTag + "\n\n"
+			state.inlineOpen = false
+		}
+		if inline != "" {
+			delta["content"] = inline + content
+		}
+	}
+}
+
+// split separates the content of a delta into answer text and reasoning, following a
+// <think> block that opens the choice's content across deltas
+func (s *reasoningStream) split(content string) (string, string) {
+	switch s.phase {
+	case reasoningPhaseStart:
+		text := s.pending + content
+		trimmed := strings.TrimLeft(text, " \t\r\n")
+		switch {
+		case strings.HasPrefix(trimmed, thinkOpenTag):
+			s.pending = ""
+			s.phase = reasoningPhaseThinking
+			return s.split(trimmed[len(thinkOpenTag):])
+		case trimmed == "" || strings.HasPrefix(thinkOpenTag, trimmed):
+			s.pending = text
+			return "", ""
+		}
+		s.pending = ""
+		s.phase = reasoningPhaseAnswer
+		return text, ""
+	case reasoningPhaseThinking:
+		text := s.pending + content
+		if reasoning, answer, found := strings.Cut(text, thinkCloseTag); found {
+			s.pending = ""
+			s.phase = reasoningPhaseAnswer
+			s.trimAnswer = true
+			answer, _ = s.split(answer)
+			return answer, reasoning
+		}
+		held := partialTagSuffix(text, thinkCloseTag)
+		s.pending = text[len(text)-held:]
+		return "", text[:len(text)-held]
+	}
+	if s.trimAnswer {
+		content = strings.TrimLeft(content, " \t\r\n")
+		s.trimAnswer = content == ""
+	}
+	return content, ""
+}
+
+// partialTagSuffix returns the length of the longest suffix of text that starts tag
+func partialTagSuffix(text, tag string) int {
+	for n := min(len(tag)-1, len(text)); n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
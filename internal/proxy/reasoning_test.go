@@ -0,0 +1,178 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReasoningPolicy(t *testing.T) {
+	access.SetDefault(access.NewACL(access.Policy{}, map[string]access.Policy{
+		"sk-strip": {Reasoning: "strip"},
+	}))
+	t.Cleanup(func() { access.SetDefault(access.NewACL(access.Policy{}, nil)) })
+
+	tests := []struct {
+		name     string
+		env      string
+		key      string
+		expected string
+	}{
+		{name: "field by default", expected: ReasoningPolicyField},
+		{name: "deployment setting", env: "Inline", expected: ReasoningPolicyInline},
+		{name: "key policy overrides deployment", env: "inline", key: "sk-strip", expected: ReasoningPolicyStrip},
+		{name: "unknown policy falls back to field", env: "hide", expected: ReasoningPolicyField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("REASONING_POLICY", tt.env)
+			r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+			if tt.key != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			assert.Equal(t, tt.expected, reasoningPolicy(r))
+		})
+	}
+}
+
+func TestApplyReasoningPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   string
+		message  string
+		expected map[string]interface{}
+	}{
+		{
+			name:     "field keeps reasoning_content",
+			policy:   ReasoningPolicyField,
+			message:  `{"role":"assistant","content":"42","reasoning_content":"6 times 7"}`,
+			expected: map[string]interface{}{"role": "assistant", "content": "42", "reasoning_content": "6 times 7"},
+		},
+		{
+			name:     "inline block is moved to reasoning_content",
+			policy:   ReasoningPolicyField,
+			message:  `{"role":"assistant","content":"<think>\n6 times 7\n</think>\n\n42"}`,
+			expected: map[string]interface{}{"role": "assistant", "content": "42", "reasoning_content": "6 times 7"},
+		},
+		{
+			name:     "strip",
+			policy:   ReasoningPolicyStrip,
+			message:  `{"role":"assistant","content":"<think>6 times 7</think>42"}`,
+			expected: map[string]interface{}{"role": "assistant", "content": "42"},
+		},
+		{
+			name:    "extensions",
+			policy:  ReasoningPolicyExtensions,
+			message: `{"role":"assistant","content":"42","reasoning_content":"6 times 7"}`,
+			expected: map[string]interface{}{"role": "assistant", "content": "42",
+				"extensions": map[string]interface{}{"reasoning": "6 times 7"}},
+		},
+		{
+			name:     "inline",
+			policy:   ReasoningPolicyInline,
+			message:  `{"role":"assistant","content":"42","reasoning":"6 times 7"}`,
+			expected: map[string]interface{}{"role": "assistant", "content": "<think>6 times 7</think>\n\n42"},
+		},
+		{
+			name:     "tags after the start of content are not reasoning",
+			policy:   ReasoningPolicyStrip,
+			message:  `{"role":"assistant","content":"Use <think></think> tags"}`,
+			expected: map[string]interface{}{"role": "assistant", "content": "Use <think></think> tags"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(`{"id":"chatcmpl-1","object":"chat.completion","model":"gpt-4o","choices":[{"index":0,"message":` + tt.message + `,"finish_reason":"stop"}]}`)
+			processed, err := ProcessResponse(body, "openai", "", "gpt-4o")
+			require.NoError(t, err)
+			processed, err = ApplyReasoningPolicy(processed, tt.policy)
+			require.NoError(t, err)
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(processed, &response))
+			message := response["choices"].([]interface{})[0].(map[string]interface{})["message"].(map[string]interface{})
+			delete(message, "annotations")
+			delete(message, "refusal")
+			assert.Equal(t, tt.expected, message)
+		})
+	}
+}
+
+func TestStreamProcessor_ReasoningPolicy(t *testing.T) {
+	// A vendor streaming its chain of thought inline, with tags split across deltas
+	inline := []string{
+		`{"role":"assistant","content":"<th"}`,
+		`{"content":"ink>6 times"}`,
+		`{"content":" 7</th"}`,
+		`{"content":"ink>\n\n"}`,
+		`{"content":"42"}`,
+	}
+	// A vendor streaming its chain of thought in a separate field
+	separate := []string{
+		`{"role":"assistant","content":null,"reasoning_content":"6 times"}`,
+		`{"content":null,"reasoning_content":" 7"}`,
+		`{"content":"42"}`,
+	}
+
+	tests := []struct {
+		name          string
+		policy        string
+		deltas        []string
+		wantContent   string
+		wantReasoning string
+		wantExtension string
+	}{
+		{name: "inline block to field", deltas: inline, wantContent: "42", wantReasoning: "6 times 7"},
+		{name: "field kept", policy: ReasoningPolicyField, deltas: separate, wantContent: "42", wantReasoning: "6 times 7"},
+		{name: "strip inline block", policy: ReasoningPolicyStrip, deltas: inline, wantContent: "42"},
+		{name: "strip field", policy: ReasoningPolicyStrip, deltas: separate, wantContent: "42"},
+		{name: "extensions", policy: ReasoningPolicyExtensions, deltas: inline, wantContent: "42", wantExtension: "6 times 7"},
+		{name: "inline", policy: ReasoningPolicyInline, deltas: separate, wantContent: "<think>6 times 7</think>\n\n42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp := NewStreamProcessor("chatcmpl-test", 1700000000, "fp_test", "together", "my-model")
+			sp.ReasoningPolicy = tt.policy
+
+			var content, reasoning, extension strings.Builder
+			for _, delta := range tt.deltas {
+				chunk := sp.ProcessChunk([]byte(`data: {"choices":[{"index":0,"delta":` + delta + `,"finish_reason":null}]}` + "\n"))
+				var data map[string]interface{}
+				require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(string(chunk)), "data: ")), &data))
+				processed := data["choices"].([]interface{})[0].(map[string]interface{})["delta"].(map[string]interface{})
+
+				text, _ := processed["content"].(string)
+				content.WriteString(text)
+				text, _ = processed["reasoning_content"].(string)
+				reasoning.WriteString(text)
+				if extensions, ok := processed["extensions"].(map[string]interface{}); ok {
+					extension.WriteString(extensions["reasoning"].(string))
+				}
+			}
+
+			assert.Equal(t, tt.wantContent, content.String())
+			assert.Equal(t, tt.wantReasoning, reasoning.String())
+			assert.Equal(t, tt.wantExtension, extension.String())
+		})
+	}
+}
+
+func TestStreamProcessor_InlineReasoningClosedAtFinish(t *testing.T) {
+	sp := NewStreamProcessor("chatcmpl-test", 1700000000, "fp_test", "deepseek", "my-model")
+	sp.ReasoningPolicy = ReasoningPolicyInline
+
+	sp.ProcessChunk([]byte(`data: {"choices":[{"index":0,"delta":{"reasoning_content":"hmm"},"finish_reason":null}]}` + "\n"))
+	chunk := sp.ProcessChunk([]byte(`data: {"choices":[{"index":0,"delta":{},"finish_reason":"length"}]}` + "\n"))
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(string(chunk)), "data: ")), &data))
+	delta := data["choices"].([]interface{})[0].(map[string]interface{})["delta"].(map[string]interface{})
+	assert.Equal(t, "</think>\n\n", delta["content"])
+}
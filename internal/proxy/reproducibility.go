@@ -0,0 +1,67 @@
+package proxy
+
+import (
+	"context"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+)
+
+// Reproducibility describes how a seeded request was served, so a client can
+// re-run an identical generation against the same vendor and model
+type Reproducibility struct {
+	Seed              int64  `json:"seed"`
+	Vendor            string `json:"vendor"`
+	Model             string `json:"model"`
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+}
+
+// routingDecisionKey is the context key under which the in-flight routing decision is stored
+type routingDecisionKey struct{}
+
+// withRoutingDecision makes the decision reachable from the API client while the request is proxied
+func withRoutingDecision(ctx context.Context, decision *routingDecision) context.Context {
+	return context.WithValue(ctx, routingDecisionKey{}, decision)
+}
+
+// routingDecisionFromContext returns the in-flight routing decision, if any
+func routingDecisionFromContext(ctx context.Context) *routingDecision {
+	decision, _ := ctx.Value(routingDecisionKey{}).(*routingDecision)
+	return decision
+}
+
+// requestSeed returns the seed of a chat completion request, if the client set one
+func requestSeed(body []byte) (int64, bool) {
+	var request struct {
+		Seed *int64 `json:"seed"`
+	}
+	if err := codec.Unmarshal(body, &request); err != nil || request.Seed == nil {
+		return 0, false
+	}
+	return *request.Seed, true
+}
+
+// newReproducibility returns the reproducibility record for a seeded request, or nil when unseeded
+func newReproducibility(body []byte, vendor, model string) *Reproducibility {
+	seed, ok := requestSeed(body)
+	if !ok {
+		return nil
+	}
+	return &Reproducibility{Seed: seed, Vendor: vendor, Model: model}
+}
+
+// vendorFingerprint returns the system_fingerprint reported by the vendor in a response or chunk
+func vendorFingerprint(data interface{}) string {
+	fields, ok := data.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	fingerprint, _ := fields["system_fingerprint"].(string)
+	return fingerprint
+}
+
+// recordFingerprint stores the vendor fingerprint on the routing decision for the audit log
+func recordFingerprint(ctx context.Context, info *Reproducibility) {
+	if decision := routingDecisionFromContext(ctx); decision != nil && info.SystemFingerprint != "" {
+		decision.SystemFingerprint = info.SystemFingerprint
+	}
+}
@@ -0,0 +1,38 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReproducibility(t *testing.T) {
+	assert.Nil(t, newReproducibility([]byte(`{"model":"gpt-4o","messages":[]}`), "openai", "gpt-4o"))
+
+	info := newReproducibility([]byte(`{"model":"gpt-4o","seed":1234,"messages":[]}`), "openai", "gpt-4o")
+	require.NotNil(t, info)
+	assert.Equal(t, &Reproducibility{Seed: 1234, Vendor: "openai", Model: "gpt-4o"}, info)
+}
+
+func TestStreamProcessor_Reproducibility(t *testing.T) {
+	sp := NewStreamProcessor("chatcmpl-1", 1700000000, "fp_generated", "openai", "any-model")
+	sp.Reproducibility = &Reproducibility{Seed: 7, Vendor: "openai", Model: "gpt-4o"}
+	sp.Extensions = map[string]interface{}{ExtensionReproducibility: sp.Reproducibility}
+
+	chunk := sp.ProcessChunk([]byte(`data: {"system_fingerprint":"fp_vendor","choices":[{"index":0,"delta":{"content":"Hi"}}]}` + "\n"))
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(string(chunk)), "data: ")), &data))
+	assert.Equal(t, "fp_generated", data["system_fingerprint"], "chunks keep the conversation-level fingerprint")
+	assert.Equal(t, map[string]interface{}{"reproducibility": map[string]interface{}{
+		"seed": float64(7), "vendor": "openai", "model": "gpt-4o", "system_fingerprint": "fp_vendor",
+	}}, data["extensions"])
+
+	decision := &routingDecision{}
+	recordFingerprint(withRoutingDecision(context.Background(), decision), sp.Reproducibility)
+	assert.Equal(t, "fp_vendor", decision.SystemFingerprint)
+}
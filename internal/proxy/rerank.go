@@ -0,0 +1,330 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/events"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/reliability"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+	"github.com/aashari/go-generative-api-router/internal/validator"
+)
+
+// ErrRerankUnsupported is returned when the selected vendor does not serve rerank requests
+var ErrRerankUnsupported = errors.New("vendor does not support rerank")
+
+// RerankClientInterface defines the interface for clients sending rerank requests
+type RerankClientInterface interface {
+	SendRerankRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error
+}
+
+// ProxyRerankRequest validates a rerank request, routes it to a vendor serving one of the
+// rerank models and forwards the normalized response. When the vendor fails for any reason
+// other than rejecting the input, the request falls back to another vendor
+func ProxyRerankRequest(w http.ResponseWriter, r *http.Request, creds []config.Credential, models []config.VendorModel, apiClient RerankClientInterface, modelSelector selector.Selector) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	start := time.Now()
+	ctx := logger.WithComponent(r.Context(), "proxy")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := r.Body.Close(); err != nil {
+		logger.Warn(logger.WithStage(ctx, "request_handling"), "Failed to close request body", "error", err)
+	}
+
+	request, originalModel, err := validator.ValidateRerankRequest(body)
+	if err != nil {
+		logger.Warn(logger.WithStage(ctx, "request_validation"), "Rerank request validation failed", "error", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	publishEvent(r, events.Event{Type: events.RequestReceived, OriginalModel: originalModel})
+
+	// Restrict the pool to what the client key's ACL permits, minus any requested exclusions
+	r, creds, models, ok := restrictEndpointPool(ctx, w, r, body, originalModel, start, creds, models)
+	if !ok {
+		return
+	}
+
+	// Only vendors with a rerank API can take the request
+	models = rerankModels(models)
+	creds = filter.CredentialsForModels(creds, models)
+
+	r, selection, decision, ok := selectEndpointVendor(ctx, w, r, creds, models, modelSelector, originalModel, start)
+	if !ok {
+		return
+	}
+	publishEvent(r, events.Event{Type: events.RequestValidated, OriginalModel: originalModel, Vendor: selection.Vendor, Model: selection.Model})
+
+	ctx = context.WithValue(ctx, "vendor", selection.Vendor)
+	ctx = context.WithValue(ctx, "model", selection.Model)
+	err = sendRerank(ctx, w, r, selection, request, originalModel, apiClient, decision)
+
+	// Fall back to a rerank model of another vendor, without further retries
+	if err != nil && shouldFallBackVendor(err) {
+		remaining := models[:0:0]
+		for _, model := range models {
+			if model.Vendor != selection.Vendor {
+				remaining = append(remaining, model)
+			}
+		}
+		if fallback, selectErr := modelSelector.Select(filter.CredentialsForModels(creds, remaining), remaining); selectErr == nil {
+			logger.Warn(logger.WithStage(ctx, "vendor_fallback"), "Rerank vendor failed, falling back",
+				"original_vendor", selection.Vendor,
+				"fallback_vendor", fallback.Vendor,
+				"fallback_model", fallback.Model,
+				"error", err.Error(),
+			)
+			decision.FallbackVendor = fallback.Vendor
+			decision.FallbackModel = fallback.Model
+			decision.Credential = credentialLabel(fallback.Credential)
+			selection = fallback
+			err = sendRerank(ctx, w, r, selection, request, originalModel, apiClient, decision)
+		}
+	}
+	if err != nil {
+		writeUpstreamError(ctx, w, err, selection.Vendor)
+	}
+	decision.Complete(err)
+	publishOutcome(r, decision, start, err)
+}
+
+// sendRerank sends the request to the selected model, retrying the first attempt and
+// sending fallbacks once
+func sendRerank(ctx context.Context, w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, request map[string]interface{},
+	originalModel string, apiClient RerankClientInterface, decision *routingDecision) error {
+	request["model"] = selection.Model
+	modifiedBody, err := codec.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	logger.Info(logger.WithStage(ctx, "RequestProcessing"), "Proxying rerank request",
+		"original_model", originalModel,
+		"vendor", selection.Vendor,
+		"model", selection.Model,
+		"request_body", logger.RequestBody(modifiedBody),
+	)
+
+	if decision.FallbackVendor != "" {
+		decision.Attempts++
+		return apiClient.SendRerankRequest(w, r, selection, modifiedBody, originalModel)
+	}
+	return reliability.NewRetryExecutor(nil).ExecuteWithRetry(ctx, func() error {
+		decision.Attempts++
+		return apiClient.SendRerankRequest(w, r, selection, modifiedBody, originalModel)
+	})
+}
+
+// rerankModels returns the models whose vendor serves rerank requests
+func rerankModels(models []config.VendorModel) []config.VendorModel {
+	var result []config.VendorModel
+	for _, model := range models {
+		if SupportsRerank(model.Vendor) {
+			result = append(result, model)
+		}
+	}
+	return result
+}
+
+// SendRerankRequest sends a rerank request to the vendor API and writes the normalized
+// response back
+func (c *APIClient) SendRerankRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error {
+	adapter := adapterFor(selection.Vendor)
+	provider, ok := adapter.(RerankProvider)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrRerankUnsupported, selection.Vendor)
+	}
+	baseURL, err := c.baseURLFor(selection)
+	if err != nil {
+		return err
+	}
+
+	vendorBody, err := provider.TranslateRerankRequest(modifiedBody)
+	if err != nil {
+		return fmt.Errorf("failed to translate request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, provider.RerankEndpoint(baseURL), bytes.NewReader(vendorBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	req.Header.Set(utils.HeaderAcceptEncoding, utils.AcceptEncodingGzip)
+	if err := authorizeRequest(req, adapter, selection); err != nil {
+		return err
+	}
+
+	resp, release, err := c.doVendorRequest(r, req, selection, vendorBody, originalModel)
+	if err != nil {
+		return err
+	}
+	// The request counts against the credential's concurrency cap until its response is handled
+	defer release()
+	defer resp.Body.Close()
+
+	observeRateLimit(r, selection, resp)
+
+	sizes := trackResponseSize(selection.Vendor, &resp.Body, resp.Header.Get(utils.HeaderContentEncoding) == utils.AcceptEncodingGzip)
+	defer sizes.record()
+
+	responseBody, err := c.standardizer.processResponseBody(resp.Body, resp.Header.Get(utils.HeaderContentEncoding), selection.Vendor)
+	if resp.StatusCode >= 400 {
+		if err != nil {
+			return ParseVendorError(selection.Vendor, resp.StatusCode, nil)
+		}
+		vendorErr := ParseVendorError(selection.Vendor, resp.StatusCode, responseBody)
+		logger.Warn(r.Context(), "Vendor API error detected",
+			"vendor", selection.Vendor,
+			"status_code", resp.StatusCode,
+			"retriable", IsRetriableAPIError(vendorErr),
+			"response_body", string(responseBody),
+			"component", "APIClient",
+			"stage", "VendorAPIError",
+		)
+		return vendorErr
+	}
+	if err != nil {
+		logger.Error(r.Context(), "Error processing response body", err,
+			"vendor", selection.Vendor,
+			"component", "APIClient",
+			"stage", "ResponseBodyProcessing",
+		)
+		return err
+	}
+	sizes.uncompressed = int64(len(responseBody))
+
+	translated, err := provider.TranslateRerankResponse(responseBody)
+	if err == nil {
+		translated, err = ProcessRerankResponse(translated, originalModel, modifiedBody)
+	}
+	if err != nil {
+		logger.Error(r.Context(), "Error processing rerank response", err,
+			"vendor", selection.Vendor,
+			"response_size_bytes", len(responseBody),
+			"component", "APIClient",
+			"stage", "ResponseProcessing",
+		)
+		return err
+	}
+
+	shouldCompress := c.standardizer.shouldCompress(r)
+	finalResponse := translated
+	if shouldCompress {
+		compressed, err := c.standardizer.compressResponseMandatory(translated)
+		if err != nil {
+			logger.Error(r.Context(), "Error compressing response", err,
+				"vendor", selection.Vendor,
+				"component", "APIClient",
+				"stage", "ResponseCompression",
+			)
+			// Fall back to uncompressed if compression fails
+			shouldCompress = false
+		} else {
+			finalResponse = compressed
+			w.Header().Set(utils.HeaderContentEncoding, utils.AcceptEncodingGzip)
+		}
+	}
+
+	c.standardizer.setCompliantHeaders(w, selection.Vendor, len(finalResponse), shouldCompress)
+	setRouterHeaders(r, w.Header())
+	if _, err := w.Write(finalResponse); err != nil {
+		logger.Error(r.Context(), "Error writing response", err,
+			"vendor", selection.Vendor,
+			"component", "APIClient",
+			"stage", "ResponseWriting",
+		)
+		return err
+	}
+
+	logger.Info(r.Context(), "Rerank response sent to client",
+		"vendor", selection.Vendor,
+		"model", selection.Model,
+		"original_model", originalModel,
+		"original_response_size", len(responseBody),
+		"final_response_size", len(finalResponse),
+		"compressed", shouldCompress,
+		"component", "APIClient",
+		"stage", "FinalResponseSent",
+	)
+	return nil
+}
+
+// ProcessRerankResponse normalizes a rerank response: results are sorted by descending
+// relevance_score and cut to top_n, carry the text of their document when the request set
+// return_documents, and the response is labelled with the requested model
+func ProcessRerankResponse(body []byte, originalModel string, requestBody []byte) ([]byte, error) {
+	var request struct {
+		Documents       []string `json:"documents"`
+		TopN            int      `json:"top_n"`
+		ReturnDocuments bool     `json:"return_documents"`
+	}
+	if err := codec.Unmarshal(requestBody, &request); err != nil {
+		return nil, fmt.Errorf("invalid rerank request: %w", err)
+	}
+	var response map[string]interface{}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	items, ok := response["results"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: missing required field 'results'", ErrInvalidResponse)
+	}
+	results := make([]map[string]interface{}, 0, len(items))
+	for i, raw := range items {
+		item, _ := raw.(map[string]interface{})
+		index, indexOK := item["index"].(float64)
+		score, scoreOK := item["relevance_score"].(float64)
+		if !indexOK || !scoreOK || index < 0 || int(index) >= len(request.Documents) {
+			return nil, fmt.Errorf("%w: result %d needs a document index and a relevance_score", ErrInvalidResponse, i)
+		}
+		result := map[string]interface{}{
+			"index":           int(index),
+			"relevance_score": score,
+		}
+		if request.ReturnDocuments {
+			result["document"] = map[string]interface{}{"text": request.Documents[int(index)]}
+		}
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i]["relevance_score"].(float64) > results[j]["relevance_score"].(float64)
+	})
+	if request.TopN > 0 && len(results) > request.TopN {
+		results = results[:request.TopN]
+	}
+
+	id, _ := response["id"].(string)
+	if id == "" {
+		id = "rerank-" + utils.GenerateShortID()
+	}
+	normalized := map[string]interface{}{
+		"id":      id,
+		"object":  "list",
+		"model":   originalModel,
+		"results": results,
+	}
+	if usage, ok := response["usage"].(map[string]interface{}); ok {
+		normalized["usage"] = usage
+	}
+	return codec.Marshal(normalized)
+}
@@ -0,0 +1,141 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyRerankRequest(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		cohereStatus    int
+		expectedStatus  int
+		expectedVendors []string
+		expectedOrder   []int
+	}{
+		{
+			name:            "served by the selected vendor",
+			body:            `{"model":"rerank-v3.5","query":"capital of France","documents":["Berlin","Paris","Rome"],"return_documents":true}`,
+			cohereStatus:    http.StatusOK,
+			expectedStatus:  http.StatusOK,
+			expectedVendors: []string{"cohere"},
+			expectedOrder:   []int{1, 0},
+		},
+		{
+			name:            "falls back to the alternative vendor",
+			body:            `{"query":"capital of France","documents":["Berlin","Paris","Rome"],"top_n":2,"return_documents":true}`,
+			cohereStatus:    http.StatusUnauthorized,
+			expectedStatus:  http.StatusOK,
+			expectedVendors: []string{"cohere", "voyage"},
+			expectedOrder:   []int{1, 2},
+		},
+		{
+			name:            "input rejected by the vendor is not retried elsewhere",
+			body:            `{"query":"capital of France","documents":["Berlin","Paris","Rome"]}`,
+			cohereStatus:    http.StatusBadRequest,
+			expectedStatus:  http.StatusBadGateway,
+			expectedVendors: []string{"cohere"},
+		},
+		{
+			name:           "invalid request",
+			body:           `{"query":"capital of France","documents":[]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vendors []string
+			var voyageRequest map[string]interface{}
+			cohereServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/rerank", r.URL.Path)
+				vendors = append(vendors, "cohere")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.cohereStatus)
+				_, _ = w.Write([]byte(`{"id":"c1","results":[{"index":0,"relevance_score":0.2},{"index":1,"relevance_score":0.9}],"meta":{"billed_units":{"search_units":1}}}`))
+			}))
+			defer cohereServer.Close()
+			voyageServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/rerank", r.URL.Path)
+				vendors = append(vendors, "voyage")
+				body, _ := io.ReadAll(r.Body)
+				require.NoError(t, json.Unmarshal(body, &voyageRequest))
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"object":"list","data":[{"index":2,"relevance_score":0.4},{"index":1,"relevance_score":0.95}],"model":"rerank-2","usage":{"total_tokens":21}}`))
+			}))
+			defer voyageServer.Close()
+
+			creds := []config.Credential{{Platform: "cohere", Type: "api-key", Value: "co-test"}, {Platform: "voyage", Type: "api-key", Value: "pa-test"}}
+			models := []config.VendorModel{
+				{Vendor: "cohere", Model: "rerank-v3.5", Type: config.ModelTypeRerank},
+				{Vendor: "voyage", Model: "rerank-2", Type: config.ModelTypeRerank},
+			}
+			mockSelector := &MockSelector{}
+			mockSelector.On("Select", creds, models).Return(&selector.VendorSelection{Vendor: "cohere", Model: models[0].Model, Credential: creds[0]}, nil)
+			mockSelector.On("Select", creds[1:], models[1:]).Return(&selector.VendorSelection{Vendor: "voyage", Model: models[1].Model, Credential: creds[1]}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/rerank", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			client := NewAPIClient(map[string]string{"cohere": cohereServer.URL, "voyage": voyageServer.URL})
+			ProxyRerankRequest(rr, req, creds, models, client, mockSelector)
+
+			require.Equal(t, tt.expectedStatus, rr.Code, rr.Body.String())
+			assert.Equal(t, tt.expectedVendors, vendors)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			if voyageRequest != nil {
+				assert.Equal(t, 2.0, voyageRequest["top_k"], "top_n is sent to Voyage as top_k")
+			}
+
+			var response struct {
+				Object  string `json:"object"`
+				Results []struct {
+					Index    int `json:"index"`
+					Document struct {
+						Text string `json:"text"`
+					} `json:"document"`
+				} `json:"results"`
+			}
+			body, _ := io.ReadAll(rr.Body)
+			require.NoError(t, json.Unmarshal(body, &response))
+			assert.Equal(t, "list", response.Object)
+			require.Len(t, response.Results, len(tt.expectedOrder))
+			for i, index := range tt.expectedOrder {
+				assert.Equal(t, index, response.Results[i].Index)
+				assert.Equal(t, []string{"Berlin", "Paris", "Rome"}[index], response.Results[i].Document.Text)
+			}
+		})
+	}
+}
+
+func TestProcessRerankResponse(t *testing.T) {
+	request := []byte(`{"query":"q","documents":["a","b","c"],"top_n":2,"return_documents":false}`)
+	processed, err := ProcessRerankResponse([]byte(`{"results":[{"index":2,"relevance_score":0.1},{"index":0,"relevance_score":0.7},{"index":1,"relevance_score":0.3}],"usage":{"total_tokens":9}}`), "my-reranker", request)
+	require.NoError(t, err)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(processed, &response))
+	assert.Equal(t, "my-reranker", response["model"])
+	assert.True(t, strings.HasPrefix(response["id"].(string), "rerank-"))
+	assert.Equal(t, map[string]interface{}{"total_tokens": 9.0}, response["usage"])
+	results := response["results"].([]interface{})
+	require.Len(t, results, 2, "results are cut to top_n")
+	assert.Equal(t, map[string]interface{}{"index": 0.0, "relevance_score": 0.7}, results[0])
+	assert.Equal(t, map[string]interface{}{"index": 1.0, "relevance_score": 0.3}, results[1])
+
+	_, err = ProcessRerankResponse([]byte(`{"id":"x"}`), "m", request)
+	assert.ErrorIs(t, err, ErrInvalidResponse)
+	_, err = ProcessRerankResponse([]byte(`{"results":[{"index":3,"relevance_score":0.5}]}`), "m", request)
+	assert.ErrorIs(t, err, ErrInvalidResponse, "indexes must point at a request document")
+}
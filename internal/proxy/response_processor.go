@@ -4,10 +4,10 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 
+	"github.com/aashari/go-generative-api-router/internal/codec"
 	"github.com/aashari/go-generative-api-router/internal/logger"
 	"github.com/aashari/go-generative-api-router/internal/utils"
 )
@@ -41,7 +41,7 @@ func ProcessResponse(responseBody []byte, vendor string, contentEncoding string,
 	if bytes.HasPrefix(trimmed, []byte("[")) {
 		// Handle array response
 		var arrayResponse []interface{}
-		if err := json.Unmarshal(decompressed, &arrayResponse); err != nil {
+		if err := codec.Unmarshal(decompressed, &arrayResponse); err != nil {
 			ctx = logger.WithComponent(ctx, "response_processor")
 			ctx = logger.WithStage(ctx, "array_parsing")
 			logger.Error(ctx, "Array response parsing failed", err,
@@ -75,12 +75,12 @@ func ProcessResponse(responseBody []byte, vendor string, contentEncoding string,
 					"code":    "empty_array",
 				},
 			}
-			modifiedResponseBody, _ := json.Marshal(errorResponse)
+			modifiedResponseBody, _ := codec.Marshal(errorResponse)
 			return modifiedResponseBody, nil
 		} else if len(arrayResponse) == 1 {
 			// Single element array - unwrap it
 			if firstElem, ok := arrayResponse[0].(map[string]interface{}); ok {
-				decompressed, _ = json.Marshal(firstElem)
+				decompressed, _ = codec.Marshal(firstElem)
 			} else {
 				// First element is not an object - create error response
 				errorResponse := map[string]interface{}{
@@ -91,7 +91,7 @@ func ProcessResponse(responseBody []byte, vendor string, contentEncoding string,
 						"code":    "invalid_array_element",
 					},
 				}
-				modifiedResponseBody, _ := json.Marshal(errorResponse)
+				modifiedResponseBody, _ := codec.Marshal(errorResponse)
 				return modifiedResponseBody, nil
 			}
 		} else {
@@ -115,7 +115,7 @@ func ProcessResponse(responseBody []byte, vendor string, contentEncoding string,
 			}
 
 			if validResponse != nil {
-				decompressed, _ = json.Marshal(validResponse)
+				decompressed, _ = codec.Marshal(validResponse)
 			} else {
 				// No valid response found - create error
 				errorResponse := map[string]interface{}{
@@ -126,7 +126,7 @@ func ProcessResponse(responseBody []byte, vendor string, contentEncoding string,
 						"code":    "no_valid_response",
 					},
 				}
-				modifiedResponseBody, _ := json.Marshal(errorResponse)
+				modifiedResponseBody, _ := codec.Marshal(errorResponse)
 				return modifiedResponseBody, nil
 			}
 		}
@@ -134,7 +134,7 @@ func ProcessResponse(responseBody []byte, vendor string, contentEncoding string,
 
 	// 3. Parse JSON (now handles both objects and processed arrays)
 	var responseData map[string]interface{}
-	if err := json.Unmarshal(decompressed, &responseData); err != nil {
+	if err := codec.Unmarshal(decompressed, &responseData); err != nil {
 		// Log complete unmarshaling error
 		ctx = logger.WithComponent(ctx, "response_processor")
 		ctx = logger.WithStage(ctx, "json_parsing")
@@ -175,7 +175,7 @@ func ProcessResponse(responseBody []byte, vendor string, contentEncoding string,
 	normalizeUsageField(responseData)
 
 	// 8. Marshal back to JSON
-	modifiedResponseBody, err := json.Marshal(responseData)
+	modifiedResponseBody, err := codec.Marshal(responseData)
 	if err != nil {
 		// Log complete marshaling error
 		ctx = logger.WithComponent(ctx, "response_processor")
@@ -471,6 +471,13 @@ func processMessage(message map[string]interface{}, vendor string) {
 		"complete_message", message,
 		"vendor", vendor)
 
+	// Convert vendor citations (e.g. Cohere) into annotations
+	normalizeCitations(message)
+
+	// Report the chain of thought of reasoning models in one place
+	normalizeReasoning(message)
+	extractInlineReasoning(message)
+
 	// Add annotations array if missing
 	if _, ok := message["annotations"]; !ok {
 		message["annotations"] = []interface{}{}
@@ -500,6 +507,58 @@ func processMessage(message map[string]interface{}, vendor string) {
 	}
 }
 
+// normalizeCitations replaces a message's vendor "citations" (objects with start, end,
+// url and title) with OpenAI url_citation annotations, keeping any existing annotations
+func normalizeCitations(message map[string]interface{}) {
+	citations, ok := message["citations"].([]interface{})
+	if !ok {
+		return
+	}
+	delete(message, "citations")
+
+	annotations, _ := message["annotations"].([]interface{})
+	for _, citation := range citations {
+		citationMap, ok := citation.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		url, _ := citationMap["url"].(string)
+		title, _ := citationMap["title"].(string)
+		annotations = append(annotations, map[string]interface{}{
+			"type": "url_citation",
+			"url_citation": map[string]interface{}{
+				"start_index": citationMap["start"],
+				"end_index":   citationMap["end"],
+				"url":         url,
+				"title":       title,
+			},
+		})
+	}
+	message["annotations"] = annotations
+}
+
+// reasoningAliases are other names vendors give a message's chain of thought
+var reasoningAliases = []string{"reasoning", "thinking"}
+
+// normalizeReasoning moves a message's or delta's chain of thought into reasoning_content,
+// the field DeepSeek introduced and other OpenAI-compatible servers adopted. Empty and null
+// values are dropped so responses of non-reasoning models keep their OpenAI shape
+func normalizeReasoning(message map[string]interface{}) {
+	for _, alias := range reasoningAliases {
+		text, ok := message[alias].(string)
+		if !ok {
+			continue
+		}
+		delete(message, alias)
+		if existing, _ := message["reasoning_content"].(string); existing == "" {
+			message["reasoning_content"] = text
+		}
+	}
+	if text, _ := message["reasoning_content"].(string); text == "" {
+		delete(message, "reasoning_content")
+	}
+}
+
 // normalizeUsageField ensures usage field is present with all required subfields
 func normalizeUsageField(responseData map[string]interface{}) {
 	if usage, ok := responseData["usage"].(map[string]interface{}); ok {
@@ -734,3 +734,93 @@ func TestNormalizeUsageField(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeCitations(t *testing.T) {
+	message := map[string]interface{}{
+		"role":    "assistant",
+		"content": "Paris is the capital of France.",
+		"citations": []interface{}{
+			map[string]interface{}{"start": 0, "end": 5, "text": "Paris", "url": "https://example.com/paris", "title": "Paris"},
+			map[string]interface{}{"start": 25, "end": 31, "text": "France"},
+		},
+	}
+
+	processMessage(message, "cohere")
+
+	assert.NotContains(t, message, "citations")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"type":         "url_citation",
+			"url_citation": map[string]interface{}{"start_index": 0, "end_index": 5, "url": "https://example.com/paris", "title": "Paris"},
+		},
+		map[string]interface{}{
+			"type":         "url_citation",
+			"url_citation": map[string]interface{}{"start_index": 25, "end_index": 31, "url": "", "title": ""},
+		},
+	}, message["annotations"])
+}
+
+func TestNormalizeReasoning(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:     "DeepSeek reasoning_content is kept",
+			message:  map[string]interface{}{"content": "42", "reasoning_content": "6 times 7"},
+			expected: map[string]interface{}{"content": "42", "reasoning_content": "6 times 7"},
+		},
+		{
+			name:     "reasoning alias is moved",
+			message:  map[string]interface{}{"content": "42", "reasoning": "6 times 7"},
+			expected: map[string]interface{}{"content": "42", "reasoning_content": "6 times 7"},
+		},
+		{
+			name:     "thinking alias is moved",
+			message:  map[string]interface{}{"content": "42", "thinking": "6 times 7"},
+			expected: map[string]interface{}{"content": "42", "reasoning_content": "6 times 7"},
+		},
+		{
+			name:     "null reasoning is dropped",
+			message:  map[string]interface{}{"content": "42", "reasoning_content": nil},
+			expected: map[string]interface{}{"content": "42"},
+		},
+		{
+			name:     "structured reasoning is left alone",
+			message:  map[string]interface{}{"content": "42", "reasoning": map[string]interface{}{"effort": "low"}},
+			expected: map[string]interface{}{"content": "42", "reasoning": map[string]interface{}{"effort": "low"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalizeReasoning(tt.message)
+			assert.Equal(t, tt.expected, tt.message)
+		})
+	}
+}
+
+func TestProcessResponse_DeepSeekReasoning(t *testing.T) {
+	body := []byte(`{
+		"id": "930c60df",
+		"object": "chat.completion",
+		"created": 1737521977,
+		"model": "deepseek-reasoner",
+		"choices": [{
+			"index": 0,
+			"message": {"role": "assistant", "content": "9.8 is greater.", "reasoning_content": "Compare the tenths: 8 > 1."},
+			"finish_reason": "stop"
+		}],
+		"usage": {"prompt_tokens": 12, "completion_tokens": 40, "total_tokens": 52, "completion_tokens_details": {"reasoning_tokens": 28}}
+	}`)
+
+	processed, err := ProcessResponse(body, "deepseek", "", "any-model")
+	require.NoError(t, err)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(processed, &response))
+	message := response["choices"].([]interface{})[0].(map[string]interface{})["message"].(map[string]interface{})
+	assert.Equal(t, "9.8 is greater.", message["content"])
+	assert.Equal(t, "Compare the tenths: 8 > 1.", message["reasoning_content"])
+}
@@ -0,0 +1,189 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+)
+
+// Request headers that pin or prefer a route for a single request
+const (
+	HeaderForceVendor     = "X-Force-Vendor"
+	HeaderForceModel      = "X-Force-Model"
+	HeaderForceCredential = "X-Force-Credential"
+	HeaderPreferredVendor = "X-Preferred-Vendor"
+	HeaderPreferredModel  = "X-Preferred-Model"
+)
+
+// Errors returned when a request's routing headers cannot be honoured
+var (
+	ErrRoutingHeadersDenied = errors.New("routing headers are not permitted for this client key")
+	ErrNoPinnedRoute        = errors.New("no available route matches the routing headers")
+)
+
+// RoutingPins are the routing headers of a request. Forced fields restrict the pool to
+// matching routes and fail the request when none is available; preferred fields narrow the
+// pool to matching routes only when one is available. Models are given as "model" (any
+// vendor) or "vendor/model", credentials by their configured id
+type RoutingPins struct {
+	Vendor          string
+	Model           string
+	Credential      string
+	PreferredVendor string
+	PreferredModel  string
+}
+
+// ParseRoutingPins reads the routing headers of a request
+func ParseRoutingPins(r *http.Request) RoutingPins {
+	return RoutingPins{
+		Vendor:          strings.TrimSpace(r.Header.Get(HeaderForceVendor)),
+		Model:           strings.TrimSpace(r.Header.Get(HeaderForceModel)),
+		Credential:      strings.TrimSpace(r.Header.Get(HeaderForceCredential)),
+		PreferredVendor: strings.TrimSpace(r.Header.Get(HeaderPreferredVendor)),
+		PreferredModel:  strings.TrimSpace(r.Header.Get(HeaderPreferredModel)),
+	}
+}
+
+// IsEmpty reports whether the request sets no routing header
+func (p RoutingPins) IsEmpty() bool {
+	return p == RoutingPins{}
+}
+
+// forced reports whether the request pins any part of its route
+func (p RoutingPins) forced() bool {
+	return p.Vendor != "" || p.Model != "" || p.Credential != ""
+}
+
+// CheckRoutingPins reports whether a client key's policy lets it send routing headers: the
+// policy must not deny them, and must allow a forced vendor when it lists allowed vendors
+func CheckRoutingPins(policy access.Policy, pins RoutingPins) error {
+	if pins.IsEmpty() {
+		return nil
+	}
+	if policy.DenyRoutingHeaders {
+		return ErrRoutingHeadersDenied
+	}
+	if pins.Vendor != "" && len(policy.AllowedVendors) > 0 {
+		for _, allowed := range policy.AllowedVendors {
+			if strings.EqualFold(allowed, pins.Vendor) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrVendorNotPermitted, pins.Vendor)
+	}
+	return nil
+}
+
+// AuthorizeRoutingPins returns a request's routing headers after checking the client key may
+// send them. Like ?vendor=, pinning bypasses the balancing policies, so every request that
+// forces a route, permitted or not, is written to the audit log
+func AuthorizeRoutingPins(ctx context.Context, r *http.Request) (RoutingPins, error) {
+	pins := ParseRoutingPins(r)
+	if pins.IsEmpty() {
+		return pins, nil
+	}
+	ctx = logger.WithStage(ctx, "RoutingHeaders")
+	err := CheckRoutingPins(access.Default().PolicyFor(r), pins)
+	if err != nil {
+		logger.Warn(ctx, "Routing headers denied",
+			"audit", true,
+			"forced_vendor", pins.Vendor,
+			"forced_model", pins.Model,
+			"forced_credential", pins.Credential,
+			"client_key", access.KeyHint(r),
+			"path", r.URL.Path,
+			"error", err.Error(),
+		)
+		return RoutingPins{}, err
+	}
+	if pins.forced() {
+		logger.Info(ctx, "Forced routing by header",
+			"audit", true,
+			"forced_vendor", pins.Vendor,
+			"forced_model", pins.Model,
+			"forced_credential", pins.Credential,
+			"client_key", access.KeyHint(r),
+			"path", r.URL.Path,
+		)
+	}
+	return pins, nil
+}
+
+// Apply restricts the pool to the forced routes, then narrows it to the preferred ones the
+// client key may use when there are any, dropping credentials left without models
+func (p RoutingPins) Apply(policy access.Policy, creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel, error) {
+	if p.IsEmpty() {
+		return creds, models, nil
+	}
+
+	if p.Credential != "" {
+		pinned := creds[:0:0]
+		for _, cred := range creds {
+			if cred.ID == p.Credential {
+				pinned = append(pinned, cred)
+			}
+		}
+		creds = pinned
+	}
+	if p.Vendor != "" || p.Model != "" {
+		models = keepModels(models, func(model config.VendorModel) bool {
+			return (p.Vendor == "" || strings.EqualFold(model.Vendor, p.Vendor)) &&
+				(p.Model == "" || access.MatchesModel(p.Model, model.Vendor, model.Model))
+		})
+	}
+	creds, models = pairedPool(creds, models)
+	if len(models) == 0 {
+		return nil, nil, ErrNoPinnedRoute
+	}
+
+	if p.PreferredVendor != "" || p.PreferredModel != "" {
+		preferred := keepModels(models, func(model config.VendorModel) bool {
+			return policy.Permits(model.Vendor, model.Model) &&
+				(p.PreferredVendor == "" || strings.EqualFold(model.Vendor, p.PreferredVendor)) &&
+				(p.PreferredModel == "" || access.MatchesModel(p.PreferredModel, model.Vendor, model.Model))
+		})
+		if len(preferred) > 0 {
+			creds, models = pairedPool(creds, preferred)
+		}
+	}
+	return creds, models, nil
+}
+
+// keepModels returns the models for which keep reports true
+func keepModels(models []config.VendorModel, keep func(config.VendorModel) bool) []config.VendorModel {
+	kept := models[:0:0]
+	for _, model := range models {
+		if keep(model) {
+			kept = append(kept, model)
+		}
+	}
+	return kept
+}
+
+// pairedPool drops the credentials without a model and the models without a credential
+func pairedPool(creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel) {
+	credVendors := make(map[string]bool)
+	for _, cred := range creds {
+		credVendors[cred.Platform] = true
+	}
+	models = keepModels(models, func(model config.VendorModel) bool {
+		return credVendors[model.Vendor]
+	})
+	modelVendors := make(map[string]bool)
+	for _, model := range models {
+		modelVendors[model.Vendor] = true
+	}
+	paired := creds[:0:0]
+	for _, cred := range creds {
+		if modelVendors[cred.Platform] {
+			paired = append(paired, cred)
+		}
+	}
+	return paired, models
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutingPinsApply(t *testing.T) {
+	creds := []config.Credential{
+		{ID: "openai-primary", Platform: "openai"},
+		{ID: "openai-batch", Platform: "openai"},
+		{Platform: "gemini"},
+	}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o"},
+		{Vendor: "openai", Model: "gpt-4o-mini"},
+		{Vendor: "gemini", Model: "gemini-2.5-flash"},
+	}
+
+	tests := []struct {
+		name       string
+		pins       RoutingPins
+		policy     access.Policy
+		wantCreds  []string
+		wantModels []string
+		wantErr    error
+	}{
+		{
+			name:       "no headers",
+			wantCreds:  []string{"openai", "openai", "gemini"},
+			wantModels: []string{"gpt-4o", "gpt-4o-mini", "gemini-2.5-flash"},
+		},
+		{
+			name:       "forced vendor",
+			pins:       RoutingPins{Vendor: "Gemini"},
+			wantCreds:  []string{"gemini"},
+			wantModels: []string{"gemini-2.5-flash"},
+		},
+		{
+			name:       "forced vendor/model",
+			pins:       RoutingPins{Model: "openai/gpt-4o-mini"},
+			wantCreds:  []string{"openai", "openai"},
+			wantModels: []string{"gpt-4o-mini"},
+		},
+		{
+			name:       "forced credential",
+			pins:       RoutingPins{Credential: "openai-batch"},
+			wantCreds:  []string{"openai"},
+			wantModels: []string{"gpt-4o", "gpt-4o-mini"},
+		},
+		{
+			name:    "forced model of another vendor",
+			pins:    RoutingPins{Vendor: "gemini", Model: "gpt-4o"},
+			wantErr: ErrNoPinnedRoute,
+		},
+		{
+			name:    "unknown credential",
+			pins:    RoutingPins{Credential: "missing"},
+			wantErr: ErrNoPinnedRoute,
+		},
+		{
+			name:       "preferred vendor",
+			pins:       RoutingPins{PreferredVendor: "gemini"},
+			wantCreds:  []string{"gemini"},
+			wantModels: []string{"gemini-2.5-flash"},
+		},
+		{
+			name:       "unavailable preference is ignored",
+			pins:       RoutingPins{PreferredModel: "claude-3"},
+			wantCreds:  []string{"openai", "openai", "gemini"},
+			wantModels: []string{"gpt-4o", "gpt-4o-mini", "gemini-2.5-flash"},
+		},
+		{
+			name:       "preference the key may not use is ignored",
+			pins:       RoutingPins{PreferredVendor: "gemini"},
+			policy:     access.Policy{AllowedVendors: []string{"openai"}},
+			wantCreds:  []string{"openai", "openai", "gemini"},
+			wantModels: []string{"gpt-4o", "gpt-4o-mini", "gemini-2.5-flash"},
+		},
+		{
+			name:       "preference within a forced vendor",
+			pins:       RoutingPins{Vendor: "openai", PreferredModel: "gpt-4o"},
+			wantCreds:  []string{"openai", "openai"},
+			wantModels: []string{"gpt-4o"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCreds, gotModels, err := tt.pins.Apply(tt.policy, creds, models)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var vendors, names []string
+			for _, cred := range gotCreds {
+				vendors = append(vendors, cred.Platform)
+			}
+			for _, model := range gotModels {
+				names = append(names, model.Model)
+			}
+			assert.Equal(t, tt.wantCreds, vendors)
+			assert.Equal(t, tt.wantModels, names)
+		})
+	}
+}
+
+func TestAuthorizeRoutingPins(t *testing.T) {
+	access.SetDefault(access.NewACL(access.Policy{DenyRoutingHeaders: true}, map[string]access.Policy{
+		"sk-ops": {AllowedVendors: []string{"openai", "gemini"}},
+	}))
+	t.Cleanup(func() { access.SetDefault(access.NewACL(access.Policy{}, nil)) })
+
+	tests := []struct {
+		name     string
+		key      string
+		headers  map[string]string
+		wantPins RoutingPins
+		wantErr  error
+	}{
+		{name: "no headers", key: "sk-other"},
+		{
+			name:     "key granted routing headers",
+			key:      "sk-ops",
+			headers:  map[string]string{HeaderForceModel: "gpt-4o", HeaderPreferredVendor: "openai"},
+			wantPins: RoutingPins{Model: "gpt-4o", PreferredVendor: "openai"},
+		},
+		{
+			name:    "forced vendor outside the key's vendors",
+			key:     "sk-ops",
+			headers: map[string]string{HeaderForceVendor: "groq"},
+			wantErr: ErrVendorNotPermitted,
+		},
+		{
+			name:    "default policy denies routing headers",
+			key:     "sk-other",
+			headers: map[string]string{HeaderPreferredModel: "gpt-4o"},
+			wantErr: ErrRoutingHeadersDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.key)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			pins, err := AuthorizeRoutingPins(r.Context(), r)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPins, pins)
+		})
+	}
+}
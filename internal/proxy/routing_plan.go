@@ -0,0 +1,220 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/alias"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/tools"
+	"github.com/aashari/go-generative-api-router/internal/types"
+	"github.com/aashari/go-generative-api-router/internal/validator"
+)
+
+// Routing policies a RoutingPlan reports on, in the order they are applied
+const (
+	PolicyVendor       = "vendor"       // the ?vendor= query parameter
+	PolicyAlias        = "alias"        // the pool of a model alias named by a chat request
+	PolicyACL          = "acl"          // the client key's access policy
+	PolicyExclusions   = "exclusions"   // router.exclude_vendors and router.exclude_models
+	PolicyCapabilities = "capabilities" // image, video, tools and streaming support of chat models
+	PolicyEndpoint     = "endpoint"     // vendor support for the embeddings, transcriptions, speech, moderations or rerank API
+)
+
+// RoutingRequest is a request to plan routing for
+type RoutingRequest struct {
+	// ModelType is the type of model the endpoint routes to, such as config.ModelTypeChat
+	ModelType string
+	// Vendor is the ?vendor= query parameter
+	Vendor string
+	// Body is the request body; transcription bodies are multipart and are not inspected
+	Body []byte
+	// Policy is the access policy of the client key sending the request
+	Policy access.Policy
+}
+
+// RoutingStep lists the vendor/model pairs one routing policy removed from the pool
+type RoutingStep struct {
+	Policy  string   `json:"policy"`
+	Removed []string `json:"removed"`
+}
+
+// RoutingPlan is the outcome of the routing policies for a request
+type RoutingPlan struct {
+	// Pool is the vendor/model pairs the selector would choose from
+	Pool []string `json:"pool,omitempty"`
+	// Steps are the policies that removed at least one pair, in the order they were applied
+	Steps []RoutingStep `json:"steps,omitempty"`
+	// Status is the HTTP status the request is rejected with, 0 when it can be routed
+	Status int `json:"status,omitempty"`
+	// Error is the rejection message sent to the client
+	Error string `json:"error,omitempty"`
+}
+
+// PlanRouting applies the routing policies that depend only on configuration and the request:
+// the vendor filter, model aliases, the client key's ACL, routing exclusions, model capabilities and vendor
+// support for the endpoint. Runtime state such as canary quarantine, rate limits, budgets and
+// rollout shares is not considered
+func PlanRouting(request RoutingRequest, creds []config.Credential, models []config.VendorModel) *RoutingPlan {
+	plan := &RoutingPlan{}
+	models = filter.ModelsByType(models, request.ModelType)
+	creds = filter.CredentialsForModels(creds, models)
+
+	if request.Vendor != "" {
+		if err := CheckVendorQuery(request.Policy, request.Vendor); err != nil {
+			return plan.reject(http.StatusForbidden, err)
+		}
+		remaining := filter.ModelsByVendor(models, request.Vendor)
+		plan.record(PolicyVendor, models, remaining)
+		creds = filter.CredentialsByVendor(creds, request.Vendor)
+		if len(creds) == 0 {
+			return plan.reject(http.StatusBadRequest, fmt.Errorf("no credentials available for vendor: %s", request.Vendor))
+		}
+		if len(remaining) == 0 {
+			return plan.reject(http.StatusBadRequest, fmt.Errorf("no models available for vendor: %s", request.Vendor))
+		}
+		models = remaining
+	}
+
+	body := request.Body
+	var payloadContext *types.PayloadContext
+	var speechFormat string
+	switch request.ModelType {
+	case config.ModelTypeChat:
+		if modified, _, ok := offerServerTools(body, tools.Default()); ok {
+			body = modified
+		}
+		payloadContext, _ = AnalyzePayload(body)
+		if payloadContext != nil {
+			aliasCreds, pool, isAlias := alias.Default().Filter(payloadContext.OriginalModel, creds, models)
+			if isAlias {
+				plan.record(PolicyAlias, models, pool)
+				if len(pool) == 0 {
+					return plan.reject(http.StatusServiceUnavailable, fmt.Errorf("no model of alias %q is available", payloadContext.OriginalModel))
+				}
+				creds, models = aliasCreds, pool
+			}
+		}
+	case config.ModelTypeEmbedding:
+		if _, _, err := validator.ValidateEmbeddingsRequest(body); err != nil {
+			return plan.reject(http.StatusBadRequest, err)
+		}
+	case config.ModelTypeModeration:
+		if _, _, err := validator.ValidateModerationRequest(body); err != nil {
+			return plan.reject(http.StatusBadRequest, err)
+		}
+	case config.ModelTypeRerank:
+		if _, _, err := validator.ValidateRerankRequest(body); err != nil {
+			return plan.reject(http.StatusBadRequest, err)
+		}
+	case config.ModelTypeSpeech:
+		speechRequest, _, err := validator.ValidateSpeechRequest(body)
+		if err != nil {
+			return plan.reject(http.StatusBadRequest, err)
+		}
+		speechFormat, _ = speechRequest["response_format"].(string)
+	}
+
+	// Transcription requests are multipart and cannot carry routing exclusions
+	var exclusions *routingExclusions
+	if request.ModelType != config.ModelTypeTranscription {
+		var err error
+		if exclusions, err = parseRoutingExclusions(body); err != nil {
+			return plan.reject(http.StatusBadRequest, err)
+		}
+	}
+	if exclusions != nil && request.Policy.DenyExclusions {
+		return plan.reject(http.StatusForbidden, ErrExclusionsDenied)
+	}
+
+	permittedCreds, permitted, err := restrictRoutingPool(request.Policy, nil, creds, models)
+	if err != nil {
+		return plan.reject(http.StatusForbidden, err)
+	}
+	plan.record(PolicyACL, models, permitted)
+	creds, models = permittedCreds, permitted
+
+	if exclusions != nil {
+		remainingCreds, remaining, err := restrictRoutingPool(access.Policy{}, exclusions, creds, models)
+		if err != nil {
+			return plan.reject(http.StatusBadRequest, err)
+		}
+		plan.record(PolicyExclusions, models, remaining)
+		creds, models = remainingCreds, remaining
+	}
+
+	switch request.ModelType {
+	case config.ModelTypeChat:
+		if payloadContext != nil {
+			capable := selector.FilterModelsByCapabilities(models, payloadContext)
+			plan.record(PolicyCapabilities, models, capable)
+			if len(models) > 0 && len(capable) == 0 {
+				return plan.reject(http.StatusBadRequest, selector.NoCapableModelError(payloadContext))
+			}
+			models = capable
+		}
+	case config.ModelTypeEmbedding:
+		models = plan.apply(PolicyEndpoint, models, embeddingsModels(models))
+	case config.ModelTypeTranscription:
+		models = plan.apply(PolicyEndpoint, models, transcriptionModels(models))
+	case config.ModelTypeModeration:
+		models = plan.apply(PolicyEndpoint, models, moderationModels(models))
+	case config.ModelTypeRerank:
+		models = plan.apply(PolicyEndpoint, models, rerankModels(models))
+	case config.ModelTypeSpeech:
+		speech := speechModels(models, "")
+		models = plan.apply(PolicyEndpoint, models, speechModels(models, speechFormat))
+		if len(speech) > 0 && len(models) == 0 {
+			return plan.reject(http.StatusBadRequest, fmt.Errorf("no speech model supports response_format %q", speechFormat))
+		}
+	}
+
+	creds = filter.CredentialsForModels(creds, models)
+	if _, err := selector.NewEvenDistributionSelector().Select(creds, models); err != nil {
+		return plan.reject(http.StatusInternalServerError, err)
+	}
+	plan.Pool = routingPairs(models)
+	return plan
+}
+
+// record adds a step for the pairs a policy removed, if it removed any
+func (p *RoutingPlan) record(policy string, before, after []config.VendorModel) {
+	kept := make(map[string]bool, len(after))
+	for _, pair := range routingPairs(after) {
+		kept[pair] = true
+	}
+	var removed []string
+	for _, pair := range routingPairs(before) {
+		if !kept[pair] {
+			removed = append(removed, pair)
+		}
+	}
+	if len(removed) > 0 {
+		p.Steps = append(p.Steps, RoutingStep{Policy: policy, Removed: removed})
+	}
+}
+
+// apply records a policy's step and returns the models it kept
+func (p *RoutingPlan) apply(policy string, before, after []config.VendorModel) []config.VendorModel {
+	p.record(policy, before, after)
+	return after
+}
+
+// reject marks the request as rejected with the status and error it would receive
+func (p *RoutingPlan) reject(status int, err error) *RoutingPlan {
+	p.Status = status
+	p.Error = err.Error()
+	return p
+}
+
+// routingPairs returns the models as "vendor/model" pairs
+func routingPairs(models []config.VendorModel) []string {
+	pairs := make([]string, 0, len(models))
+	for _, model := range models {
+		pairs = append(pairs, model.Vendor+"/"+model.Model)
+	}
+	return pairs
+}
@@ -0,0 +1,117 @@
+package proxy
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/alias"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanRouting_Alias(t *testing.T) {
+	creds := []config.Credential{{Platform: "openai"}, {Platform: "groq"}}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o"},
+		{Vendor: "groq", Model: "llama-3"},
+	}
+	require.NoError(t, alias.Default().Configure(map[string][]string{"fast": {"groq/llama-3"}}, models))
+	t.Cleanup(func() { require.NoError(t, alias.Default().Configure(nil, nil)) })
+
+	plan := PlanRouting(RoutingRequest{ModelType: config.ModelTypeChat, Body: []byte(`{"model":"fast","messages":[]}`)}, creds, models)
+	assert.Zero(t, plan.Status, plan.Error)
+	assert.Equal(t, []string{"groq/llama-3"}, plan.Pool)
+	assert.Equal(t, []RoutingStep{{Policy: PolicyAlias, Removed: []string{"openai/gpt-4o"}}}, plan.Steps)
+
+	plan = PlanRouting(RoutingRequest{ModelType: config.ModelTypeChat, Vendor: "openai", Body: []byte(`{"model":"fast","messages":[]}`)}, creds, models)
+	assert.Equal(t, http.StatusServiceUnavailable, plan.Status)
+}
+
+func TestPlanRouting(t *testing.T) {
+	creds := []config.Credential{{Platform: "openai"}, {Platform: "groq"}, {Platform: "anthropic"}}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o", Config: &config.ModelConfig{SupportTools: true}},
+		{Vendor: "groq", Model: "llama-3", Config: &config.ModelConfig{}},
+		{Vendor: "openai", Model: "tts-1", Type: config.ModelTypeSpeech},
+		{Vendor: "groq", Model: "playai-tts", Type: config.ModelTypeSpeech},
+		{Vendor: "anthropic", Model: "claude-voice", Type: config.ModelTypeSpeech},
+		{Vendor: "anthropic", Model: "claude-listen", Type: config.ModelTypeTranscription},
+	}
+
+	tests := []struct {
+		name           string
+		request        RoutingRequest
+		expectedPool   []string
+		expectedSteps  []RoutingStep
+		expectedStatus int
+	}{
+		{
+			name:          "tools need a tool-capable model",
+			request:       RoutingRequest{ModelType: config.ModelTypeChat, Body: []byte(`{"messages":[],"tools":[{"type":"function","function":{"name":"f"}}]}`)},
+			expectedPool:  []string{"openai/gpt-4o"},
+			expectedSteps: []RoutingStep{{Policy: PolicyCapabilities, Removed: []string{"groq/llama-3"}}},
+		},
+		{
+			name:           "no model supports audio",
+			request:        RoutingRequest{ModelType: config.ModelTypeChat, Body: []byte(`{"messages":[],"modalities":["audio"]}`)},
+			expectedSteps:  []RoutingStep{{Policy: PolicyCapabilities, Removed: []string{"openai/gpt-4o", "groq/llama-3"}}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "ACL then exclusions",
+			request: RoutingRequest{ModelType: config.ModelTypeChat, Body: []byte(`{"messages":[],"router":{"exclude_models":["gpt-4o"]}}`), Policy: access.Policy{AllowedModels: []string{"gpt-4o", "llama-3"}}},
+			expectedSteps: []RoutingStep{
+				{Policy: PolicyExclusions, Removed: []string{"openai/gpt-4o"}},
+			},
+			expectedPool: []string{"groq/llama-3"},
+		},
+		{
+			name:          "speech format narrows vendors",
+			request:       RoutingRequest{ModelType: config.ModelTypeSpeech, Body: []byte(`{"input":"hi","response_format":"aac"}`)},
+			expectedPool:  []string{"openai/tts-1"},
+			expectedSteps: []RoutingStep{{Policy: PolicyEndpoint, Removed: []string{"groq/playai-tts", "anthropic/claude-voice"}}},
+		},
+		{
+			name:           "vendor without a transcriptions API",
+			request:        RoutingRequest{ModelType: config.ModelTypeTranscription},
+			expectedSteps:  []RoutingStep{{Policy: PolicyEndpoint, Removed: []string{"anthropic/claude-listen"}}},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "unknown vendor",
+			request:        RoutingRequest{ModelType: config.ModelTypeChat, Vendor: "mistral", Body: []byte(`{"messages":[]}`)},
+			expectedSteps:  []RoutingStep{{Policy: PolicyVendor, Removed: []string{"openai/gpt-4o", "groq/llama-3"}}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "vendor query denied to the key",
+			request:        RoutingRequest{ModelType: config.ModelTypeChat, Vendor: "openai", Body: []byte(`{"messages":[]}`), Policy: access.Policy{DenyVendorQuery: true}},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "vendor outside the key's allowed vendors",
+			request:        RoutingRequest{ModelType: config.ModelTypeChat, Vendor: "openai", Body: []byte(`{"messages":[]}`), Policy: access.Policy{AllowedVendors: []string{"groq"}}},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "ACL permits nothing",
+			request:        RoutingRequest{ModelType: config.ModelTypeChat, Body: []byte(`{"messages":[]}`), Policy: access.Policy{AllowedVendors: []string{"gemini"}}},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := PlanRouting(tt.request, creds, models)
+			assert.Equal(t, tt.expectedStatus, plan.Status, plan.Error)
+			assert.Equal(t, tt.expectedSteps, plan.Steps)
+			if tt.expectedStatus == 0 {
+				assert.Equal(t, tt.expectedPool, plan.Pool)
+			} else {
+				assert.Empty(t, plan.Pool)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/cache"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Values of the X-Router-Cache response header
+const (
+	cacheHit  = "HIT"
+	cacheMiss = "MISS"
+)
+
+// serveFromSemanticCache answers the request from the semantic cache when it holds a response
+// On a miss it returns a client that caches the successful response, or apiClient unchanged
+// when caching is disabled or the request is not cacheable
+func serveFromSemanticCache(w http.ResponseWriter, r *http.Request, body []byte, apiClient APIClientInterface) (APIClientInterface, bool) {
+	semanticCache := cache.Default()
+	if semanticCache == nil {
+		return apiClient, false
+	}
+	// Responses are scoped to the client key and the requested vendor
+	key, ok := semanticCache.Key(access.ClientKey(r)+"\n"+r.URL.Query().Get("vendor"), body)
+	if !ok {
+		return apiClient, false
+	}
+
+	response, hit := semanticCache.Get(key)
+	if !hit {
+		return &semanticCacheClient{next: apiClient, cache: semanticCache, key: key}, false
+	}
+
+	ctx := logger.WithComponent(r.Context(), "SemanticCache")
+	logger.Info(ctx, "Serving response from semantic cache",
+		"response_size", len(response),
+	)
+	w.Header().Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	w.Header().Set(utils.HeaderContentLength, strconv.Itoa(len(response)))
+	setCacheHeader(r, w.Header(), cacheHit)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(response); err != nil {
+		logger.Error(ctx, "Failed to write cached response", err)
+	}
+	return apiClient, true
+}
+
+// semanticCacheClient stores the successful response of a cache miss
+type semanticCacheClient struct {
+	next  APIClientInterface
+	cache *cache.Cache
+	key   string
+}
+
+// SendRequest sends the request and caches a 200 response before returning it to the client
+func (c *semanticCacheClient) SendRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error {
+	recorder := newBufferedResponse()
+	if err := c.next.SendRequest(recorder, r, selection, modifiedBody, originalModel); err != nil {
+		return err
+	}
+	if recorder.status == http.StatusOK {
+		if response, err := recorder.decodedBody(); err == nil {
+			c.cache.Put(c.key, response)
+		}
+	}
+	setCacheHeader(r, recorder.header, cacheMiss)
+	return recorder.writeTo(w)
+}
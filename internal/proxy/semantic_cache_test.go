@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/cache"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyRequest_SemanticCache(t *testing.T) {
+	semanticCache, err := cache.New(cache.Config{Patterns: []cache.Pattern{{Name: "name", Pattern: `Dear \w+`, Replacement: "Dear <name>"}}})
+	require.NoError(t, err)
+	cache.SetDefault(semanticCache)
+	t.Cleanup(func() { cache.SetDefault(nil) })
+	t.Setenv("ROUTER_HEADERS", "cache")
+
+	creds := []config.Credential{{Platform: "openai", Type: "api-key", Value: "sk-test"}}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}}
+	mockSelector := &MockSelector{}
+	mockSelector.On("Select", creds, models).Return(&selector.VendorSelection{Vendor: "openai", Model: "gpt-4o", Credential: creds[0]}, nil)
+	client := &scriptedClient{responses: []string{finalAnswer, finalAnswer, finalAnswer}}
+
+	send := func(clientKey, prompt string, stream bool) *httptest.ResponseRecorder {
+		body := `{"model":"gpt-4o","messages":[{"role":"user","content":"` + prompt + `"}]}`
+		if stream {
+			body = strings.Replace(body, `{"model"`, `{"stream":true,"model"`, 1)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+clientKey)
+		rr := httptest.NewRecorder()
+		ProxyRequest(rr, req, creds, models, client, mockSelector)
+		require.Equal(t, http.StatusOK, rr.Code)
+		return rr
+	}
+
+	first := send("sk-a", "Write to Dear Alice", false)
+	assert.Equal(t, "MISS", first.Header().Get("X-Router-Cache"))
+	second := send("sk-a", "Write to Dear Bob", false)
+	assert.Equal(t, "HIT", second.Header().Get("X-Router-Cache"))
+	assert.JSONEq(t, finalAnswer, second.Body.String())
+	assert.Len(t, client.bodies, 1, "the hit is not sent to the vendor")
+
+	send("sk-b", "Write to Dear Bob", false)
+	assert.Len(t, client.bodies, 2, "cached responses are not shared across client keys")
+	send("sk-a", "Write to Dear Bob", true)
+	assert.Len(t, client.bodies, 3, "streaming requests bypass the cache")
+
+	stats := semanticCache.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+}
@@ -0,0 +1,264 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/tools"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// offerServerTools adds the registered server-side tools to a non-streaming chat request
+// Tools the client declares under the same name take precedence and stay the client's
+// Returns the request unchanged, and false, when server-side tools do not apply to it
+func offerServerTools(body []byte, registry *tools.Registry) ([]byte, map[string]bool, bool) {
+	if !registry.Enabled() {
+		return body, nil, false
+	}
+	var request map[string]interface{}
+	if err := codec.Unmarshal(body, &request); err != nil {
+		return body, nil, false
+	}
+	if stream, ok := request["stream"].(bool); ok && stream {
+		return body, nil, false
+	}
+
+	requestTools, _ := request["tools"].([]interface{})
+	clientTools := make(map[string]bool, len(requestTools))
+	for _, tool := range requestTools {
+		if name := toolFunctionName(tool); name != "" {
+			clientTools[name] = true
+		}
+	}
+	for _, definition := range registry.Definitions() {
+		if name := toolFunctionName(definition); !clientTools[name] {
+			requestTools = append(requestTools, definition)
+		}
+	}
+	request["tools"] = requestTools
+
+	modified, err := codec.Marshal(request)
+	if err != nil {
+		return body, nil, false
+	}
+	return modified, clientTools, true
+}
+
+// serverToolClient runs the server-side tools a model calls and re-invokes the model with
+// their results until it answers, so the client only sees the final response
+// It lives for one client request: completed rounds are kept across retries and failovers,
+// so a tool already run is not run again when a later vendor call is retried
+type serverToolClient struct {
+	next        APIClientInterface
+	registry    *tools.Registry
+	clientTools map[string]bool
+
+	// completed holds the assistant and tool messages of the rounds run so far
+	completed        []interface{}
+	round            int
+	promptTokens     int
+	completionTokens int
+}
+
+// SendRequest sends the request and resolves the model's server-side tool calls
+// A response calling any client tool is returned to the client as-is
+func (c *serverToolClient) SendRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error {
+	var request map[string]interface{}
+	if err := codec.Unmarshal(modifiedBody, &request); err != nil {
+		return c.next.SendRequest(w, r, selection, modifiedBody, originalModel)
+	}
+
+	ctx := logger.WithComponent(r.Context(), "ServerTools")
+	body := modifiedBody
+	if c.round > 0 {
+		// A retry or failover resumes after the rounds already completed
+		var err error
+		if body, err = c.resume(request); err != nil {
+			return err
+		}
+	}
+	for {
+		recorder := newBufferedResponse()
+		if err := c.next.SendRequest(recorder, r, selection, body, originalModel); err != nil {
+			return err
+		}
+
+		response, err := recorder.decodedBody()
+		if err != nil || recorder.status != http.StatusOK {
+			return recorder.writeTo(w)
+		}
+		prompt, completion := responseUsage(response)
+		c.promptTokens += prompt
+		c.completionTokens += completion
+
+		message, toolCalls := c.serverToolCalls(response)
+		if len(toolCalls) == 0 || c.round >= c.registry.MaxRounds() {
+			recordUsage(r.Context(), c.promptTokens, c.completionTokens)
+			setCostHeader(r.Context(), recorder.header)
+			return recorder.writeTo(w)
+		}
+
+		c.completed = append(c.completed, message)
+		for _, call := range toolCalls {
+			c.completed = append(c.completed, c.execute(ctx, call, c.round))
+		}
+		c.round++
+		if body, err = c.resume(request); err != nil {
+			return err
+		}
+	}
+}
+
+// resume returns request with the messages of the completed rounds appended to the client's
+// The call to the model is the last one once it has used up the rounds
+func (c *serverToolClient) resume(request map[string]interface{}) ([]byte, error) {
+	messages, _ := request["messages"].([]interface{})
+	resumed := make([]interface{}, 0, len(messages)+len(c.completed))
+	resumed = append(resumed, messages...)
+	resumed = append(resumed, c.completed...)
+
+	next := make(map[string]interface{}, len(request)+1)
+	for key, value := range request {
+		next[key] = value
+	}
+	next["messages"] = resumed
+	if c.round >= c.registry.MaxRounds() {
+		// The last round must produce an answer rather than more tool calls
+		next["tool_choice"] = "none"
+	}
+	return codec.Marshal(next)
+}
+
+// serverToolCalls returns the assistant message of a response and its tool calls when all
+// of them are server-side tools, or no calls when the model answered or called a client tool
+func (c *serverToolClient) serverToolCalls(response []byte) (map[string]interface{}, []map[string]interface{}) {
+	var completion struct {
+		Choices []struct {
+			Message map[string]interface{} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := codec.Unmarshal(response, &completion); err != nil || len(completion.Choices) == 0 {
+		return nil, nil
+	}
+	message := completion.Choices[0].Message
+	rawCalls, _ := message["tool_calls"].([]interface{})
+	if len(rawCalls) == 0 {
+		return nil, nil
+	}
+
+	calls := make([]map[string]interface{}, 0, len(rawCalls))
+	for _, raw := range rawCalls {
+		call, ok := raw.(map[string]interface{})
+		name := toolFunctionName(raw)
+		if !ok || c.clientTools[name] || !c.registry.Has(name) {
+			return nil, nil
+		}
+		calls = append(calls, call)
+	}
+
+	assistant := map[string]interface{}{
+		"role":       "assistant",
+		"content":    message["content"],
+		"tool_calls": rawCalls,
+	}
+	return assistant, calls
+}
+
+// execute runs one tool call and returns the tool message carrying its result
+// Failures are reported to the model as the result so it can recover
+func (c *serverToolClient) execute(ctx context.Context, call map[string]interface{}, round int) map[string]interface{} {
+	name := toolFunctionName(call)
+	function, _ := call["function"].(map[string]interface{})
+	arguments, _ := function["arguments"].(string)
+	id, _ := call["id"].(string)
+
+	start := time.Now()
+	result, err := c.registry.Execute(ctx, name, arguments)
+	duration := time.Since(start)
+	if err != nil {
+		encoded, _ := codec.Marshal(map[string]string{"error": err.Error()})
+		result = string(encoded)
+		logger.Warn(logger.WithStage(ctx, "ServerToolFailed"), "Server-side tool failed",
+			"tool", name,
+			"tool_call_id", id,
+			"round", round+1,
+			"duration_ms", duration.Milliseconds(),
+			"error", err.Error(),
+		)
+	} else {
+		logger.Info(logger.WithStage(ctx, "ServerToolExecuted"), "Server-side tool executed",
+			"tool", name,
+			"tool_call_id", id,
+			"round", round+1,
+			"duration_ms", duration.Milliseconds(),
+			"result_size", len(result),
+		)
+	}
+
+	return map[string]interface{}{
+		"role":         "tool",
+		"tool_call_id": id,
+		"content":      result,
+	}
+}
+
+// toolFunctionName returns the function name of a tool definition or tool call
+func toolFunctionName(tool interface{}) string {
+	fields, _ := tool.(map[string]interface{})
+	function, _ := fields["function"].(map[string]interface{})
+	name, _ := function["name"].(string)
+	return name
+}
+
+// bufferedResponse holds a response written by the API client until it is known to be final
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+// decodedBody returns the response body, decompressed when it was gzipped for the client
+func (b *bufferedResponse) decodedBody() ([]byte, error) {
+	if b.header.Get(utils.HeaderContentEncoding) != utils.AcceptEncodingGzip {
+		return b.body.Bytes(), nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(b.body.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// writeTo sends the buffered response to the client as it was written
+func (b *bufferedResponse) writeTo(w http.ResponseWriter) error {
+	for name, values := range b.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(b.status)
+	_, err := w.Write(b.body.Bytes())
+	return err
+}
@@ -0,0 +1,225 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedClient answers each request with the next scripted response and keeps the bodies it was sent
+type scriptedClient struct {
+	responses []string
+	gzip      bool
+	bodies    []map[string]interface{}
+}
+
+func (c *scriptedClient) SendRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error {
+	var body map[string]interface{}
+	if err := json.Unmarshal(modifiedBody, &body); err != nil {
+		return err
+	}
+	c.bodies = append(c.bodies, body)
+
+	response := []byte(c.responses[len(c.bodies)-1])
+	w.Header().Set("Content-Type", "application/json")
+	if c.gzip {
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		_, _ = zw.Write(response)
+		_ = zw.Close()
+		response = compressed.Bytes()
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+	_, err := w.Write(response)
+	return err
+}
+
+func toolCallResponse(name, arguments string) string {
+	call, _ := json.Marshal(map[string]interface{}{
+		"id":       "call_" + name,
+		"type":     "function",
+		"function": map[string]interface{}{"name": name, "arguments": arguments},
+	})
+	return `{"choices":[{"index":0,"message":{"role":"assistant","content":null,"tool_calls":[` + string(call) + `]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":10,"completion_tokens":5}}`
+}
+
+const finalAnswer = `{"choices":[{"index":0,"message":{"role":"assistant","content":"The answer is 20."},"finish_reason":"stop"}],"usage":{"prompt_tokens":30,"completion_tokens":7}}`
+
+func TestOfferServerTools(t *testing.T) {
+	registry := tools.NewRegistry([]tools.Definition{
+		{Name: "calculator", Type: tools.TypeCalculator},
+		{Name: "get_weather", Type: tools.TypeHTTP, URL: "https://weather.internal"},
+	}, 0)
+
+	body, clientTools, ok := offerServerTools([]byte(`{"messages":[],"tools":[{"type":"function","function":{"name":"get_weather"}}]}`), registry)
+	require.True(t, ok)
+	assert.Equal(t, map[string]bool{"get_weather": true}, clientTools)
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &request))
+	requestTools := request["tools"].([]interface{})
+	require.Len(t, requestTools, 2, "the client's get_weather shadows the server-side one")
+	assert.Equal(t, "get_weather", toolFunctionName(requestTools[0]))
+	assert.Equal(t, "calculator", toolFunctionName(requestTools[1]))
+
+	streaming := []byte(`{"messages":[],"stream":true}`)
+	body, _, ok = offerServerTools(streaming, registry)
+	assert.False(t, ok, "streaming requests are left alone")
+	assert.Equal(t, streaming, body)
+
+	_, _, ok = offerServerTools([]byte(`{"messages":[]}`), tools.NewRegistry(nil, 0))
+	assert.False(t, ok, "no tools registered")
+}
+
+func TestServerToolClient(t *testing.T) {
+	tests := []struct {
+		name              string
+		maxRounds         int
+		clientTools       map[string]bool
+		gzip              bool
+		responses         []string
+		expectedRequests  int
+		expectedResponse  string
+		expectedToolNone  bool
+		expectedToolReply string
+		expectedUsage     [2]int
+	}{
+		{
+			name:              "executes the tool and returns the final answer",
+			responses:         []string{toolCallResponse("calculator", `{"expression":"(2 + 3) * 4"}`), finalAnswer},
+			expectedRequests:  2,
+			expectedResponse:  finalAnswer,
+			expectedToolReply: "20",
+			expectedUsage:     [2]int{40, 12},
+		},
+		{
+			name:              "gzipped responses",
+			gzip:              true,
+			responses:         []string{toolCallResponse("calculator", `{"expression":"1 +"}`), finalAnswer},
+			expectedRequests:  2,
+			expectedResponse:  finalAnswer,
+			expectedToolReply: `{"error":"unexpected end of expression"}`,
+			expectedUsage:     [2]int{40, 12},
+		},
+		{
+			name:             "client tool calls are returned to the client",
+			clientTools:      map[string]bool{"get_weather": true},
+			responses:        []string{toolCallResponse("get_weather", `{"city":"Paris"}`)},
+			expectedRequests: 1,
+			expectedResponse: toolCallResponse("get_weather", `{"city":"Paris"}`),
+		},
+		{
+			name:              "last round forbids further tool calls",
+			maxRounds:         1,
+			responses:         []string{toolCallResponse("calculator", `{"expression":"2"}`), toolCallResponse("calculator", `{"expression":"3"}`)},
+			expectedRequests:  2,
+			expectedResponse:  toolCallResponse("calculator", `{"expression":"3"}`),
+			expectedToolNone:  true,
+			expectedToolReply: "2",
+			expectedUsage:     [2]int{20, 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := tools.NewRegistry([]tools.Definition{{Name: "calculator", Type: tools.TypeCalculator}}, tt.maxRounds)
+			next := &scriptedClient{responses: tt.responses, gzip: tt.gzip}
+			client := &serverToolClient{next: next, registry: registry, clientTools: tt.clientTools}
+
+			decision := &routingDecision{}
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			req = req.WithContext(withRoutingDecision(req.Context(), decision))
+			rr := httptest.NewRecorder()
+			err := client.SendRequest(rr, req, &selector.VendorSelection{Vendor: "openai", Model: "gpt-4o"},
+				[]byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"What is (2 + 3) * 4?"}]}`), "my-model")
+			require.NoError(t, err)
+
+			require.Len(t, next.bodies, tt.expectedRequests)
+			body := rr.Body.Bytes()
+			if tt.gzip {
+				assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+				zr, err := gzip.NewReader(rr.Body)
+				require.NoError(t, err)
+				var decoded bytes.Buffer
+				_, err = decoded.ReadFrom(zr)
+				require.NoError(t, err)
+				body = decoded.Bytes()
+			}
+			assert.JSONEq(t, tt.expectedResponse, string(body))
+
+			if tt.expectedRequests == 1 {
+				return
+			}
+			messages := next.bodies[1]["messages"].([]interface{})
+			require.Len(t, messages, 3)
+			assistant := messages[1].(map[string]interface{})
+			assert.Equal(t, "assistant", assistant["role"])
+			assert.Len(t, assistant["tool_calls"], 1)
+			assert.Equal(t, map[string]interface{}{"role": "tool", "tool_call_id": "call_calculator", "content": tt.expectedToolReply}, messages[2])
+			_, toolNone := next.bodies[1]["tool_choice"]
+			assert.Equal(t, tt.expectedToolNone, toolNone)
+
+			// Usage covers every round, not just the last
+			assert.Equal(t, tt.expectedUsage, [2]int{decision.PromptTokens, decision.CompletionTokens})
+		})
+	}
+}
+
+// flakyClient fails the listed calls to the vendor before they reach the scripted client
+type flakyClient struct {
+	*scriptedClient
+	calls    int
+	failures map[int]bool
+}
+
+func (c *flakyClient) SendRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error {
+	c.calls++
+	if c.failures[c.calls] {
+		return errors.New("upstream unavailable")
+	}
+	return c.scriptedClient.SendRequest(w, r, selection, modifiedBody, originalModel)
+}
+
+func TestServerToolClient_RetryKeepsCompletedRounds(t *testing.T) {
+	var executions int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		executions++
+		_, _ = w.Write([]byte(`{"order":"created"}`))
+	}))
+	defer server.Close()
+
+	registry := tools.NewRegistry([]tools.Definition{{Name: "create_order", Type: tools.TypeHTTP, URL: server.URL}}, 0)
+	next := &flakyClient{
+		scriptedClient: &scriptedClient{responses: []string{toolCallResponse("create_order", `{"item":"book"}`), finalAnswer}},
+		failures:       map[int]bool{2: true},
+	}
+	client := &serverToolClient{next: next, registry: registry}
+
+	decision := &routingDecision{}
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	req = req.WithContext(withRoutingDecision(req.Context(), decision))
+	body := []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"Order a book"}]}`)
+	selection := &selector.VendorSelection{Vendor: "openai", Model: "gpt-4o"}
+
+	// The second round fails, then the request is retried as the retry executor would
+	err := client.SendRequest(httptest.NewRecorder(), req, selection, body, "my-model")
+	require.Error(t, err)
+	rr := httptest.NewRecorder()
+	require.NoError(t, client.SendRequest(rr, req, selection, body, "my-model"))
+
+	assert.Equal(t, 1, executions, "the tool must not run again on retry")
+	assert.JSONEq(t, finalAnswer, rr.Body.String())
+	require.Len(t, next.bodies, 2)
+	messages := next.bodies[1]["messages"].([]interface{})
+	require.Len(t, messages, 3)
+	assert.Equal(t, map[string]interface{}{"role": "tool", "tool_call_id": "call_create_order", "content": `{"order":"created"}`}, messages[2])
+	assert.Equal(t, [2]int{40, 12}, [2]int{decision.PromptTokens, decision.CompletionTokens})
+}
@@ -0,0 +1,287 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/events"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/reliability"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+	"github.com/aashari/go-generative-api-router/internal/validator"
+)
+
+// ErrSpeechUnsupported is returned when the selected vendor does not serve text-to-speech
+var ErrSpeechUnsupported = errors.New("vendor does not support text-to-speech")
+
+// speechContentTypes maps speech formats, OpenAI's and vendors' own, to their content type
+var speechContentTypes = map[string]string{
+	"mp3":  "audio/mpeg",
+	"opus": "audio/ogg",
+	"ogg":  "audio/ogg",
+	"aac":  "audio/aac",
+	"flac": "audio/flac",
+	"wav":  "audio/wav",
+	"pcm":  "audio/pcm",
+	"raw":  "audio/pcm",
+}
+
+// speechChunkSize is how much audio is relayed to the client at a time
+const speechChunkSize = 32 * 1024
+
+// SpeechClientInterface defines the interface for clients sending text-to-speech requests
+type SpeechClientInterface interface {
+	SendSpeechRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error
+}
+
+// ProxySpeechRequest validates a text-to-speech request, routes it to a vendor serving one
+// of the speech models in the requested format and streams the audio back
+func ProxySpeechRequest(w http.ResponseWriter, r *http.Request, creds []config.Credential, models []config.VendorModel, apiClient SpeechClientInterface, modelSelector selector.Selector) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	start := time.Now()
+	ctx := logger.WithComponent(r.Context(), "proxy")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := r.Body.Close(); err != nil {
+		logger.Warn(logger.WithStage(ctx, "request_handling"), "Failed to close request body", "error", err)
+	}
+
+	request, originalModel, err := validator.ValidateSpeechRequest(body)
+	if err != nil {
+		logger.Warn(logger.WithStage(ctx, "request_validation"), "Speech request validation failed", "error", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	publishEvent(r, events.Event{Type: events.RequestReceived, OriginalModel: originalModel})
+
+	// Restrict the pool to what the client key's ACL permits, minus any requested exclusions
+	r, creds, models, ok := restrictEndpointPool(ctx, w, r, body, originalModel, start, creds, models)
+	if !ok {
+		return
+	}
+
+	// Only vendors with a speech API that can produce the requested format can take the request
+	format, _ := request["response_format"].(string)
+	speech := speechModels(models, "")
+	models = speechModels(models, format)
+	if len(speech) > 0 && len(models) == 0 {
+		err := fmt.Errorf("no speech model supports response_format %q", format)
+		recordSelectionFailure(r, originalModel, nil, len(speech), start, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	creds = filter.CredentialsForModels(creds, models)
+
+	r, selection, decision, ok := selectEndpointVendor(ctx, w, r, creds, models, modelSelector, originalModel, start)
+	if !ok {
+		return
+	}
+
+	mapSpeechRequest(request, selection, models)
+	modifiedBody, err := codec.Marshal(request)
+	if err != nil {
+		logger.Error(logger.WithStage(ctx, "request_validation"), "Failed to encode speech request", err)
+		http.Error(w, "Failed to encode request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	publishEvent(r, events.Event{Type: events.RequestValidated, OriginalModel: originalModel, Vendor: selection.Vendor, Model: selection.Model})
+
+	ctx = context.WithValue(ctx, "vendor", selection.Vendor)
+	ctx = context.WithValue(ctx, "model", selection.Model)
+	logger.Info(logger.WithStage(ctx, "RequestProcessing"), "Proxying speech request",
+		"original_model", originalModel,
+		"vendor", selection.Vendor,
+		"model", selection.Model,
+		"request_body", logger.RequestBody(modifiedBody),
+	)
+
+	err = reliability.NewRetryExecutor(nil).ExecuteWithRetry(ctx, func() error {
+		decision.Attempts++
+		return apiClient.SendSpeechRequest(w, r, selection, modifiedBody, originalModel)
+	})
+	if err != nil {
+		writeUpstreamError(ctx, w, err, selection.Vendor)
+	}
+	decision.Complete(err)
+	publishOutcome(r, decision, start, err)
+}
+
+// speechModels returns the models whose vendor serves text-to-speech in the format,
+// or in any format when format is empty
+func speechModels(models []config.VendorModel, format string) []config.VendorModel {
+	var result []config.VendorModel
+	for _, model := range models {
+		provider, ok := adapterFor(model.Vendor).(SpeechProvider)
+		if !ok {
+			continue
+		}
+		if _, ok := provider.SpeechFormat(format); ok || format == "" {
+			result = append(result, model)
+		}
+	}
+	return result
+}
+
+// mapSpeechRequest sets the selected model and translates the voice through the model's
+// voice map and the response format to the vendor's name for it
+func mapSpeechRequest(request map[string]interface{}, selection *selector.VendorSelection, models []config.VendorModel) {
+	request["model"] = selection.Model
+
+	voice, _ := request["voice"].(string)
+	for _, model := range models {
+		if model.Vendor == selection.Vendor && model.Model == selection.Model {
+			if vendorVoice, ok := model.Voices[voice]; ok {
+				request["voice"] = vendorVoice
+			}
+			break
+		}
+	}
+
+	if provider, ok := adapterFor(selection.Vendor).(SpeechProvider); ok {
+		format, _ := request["response_format"].(string)
+		if vendorFormat, ok := provider.SpeechFormat(format); ok {
+			request["response_format"] = vendorFormat
+		}
+	}
+}
+
+// SendSpeechRequest sends a text-to-speech request to the vendor API and streams the
+// audio back as it arrives
+func (c *APIClient) SendSpeechRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error {
+	adapter := adapterFor(selection.Vendor)
+	provider, ok := adapter.(SpeechProvider)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSpeechUnsupported, selection.Vendor)
+	}
+	baseURL, err := c.baseURLFor(selection)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, provider.SpeechEndpoint(baseURL), bytes.NewReader(modifiedBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	if err := authorizeRequest(req, adapter, selection); err != nil {
+		return err
+	}
+
+	resp, release, err := c.doVendorRequest(r, req, selection, modifiedBody, originalModel)
+	if err != nil {
+		return err
+	}
+	// The request counts against the credential's concurrency cap until its response is handled
+	defer release()
+	defer resp.Body.Close()
+
+	observeRateLimit(r, selection, resp)
+
+	sizes := trackResponseSize(selection.Vendor, &resp.Body, false)
+	defer sizes.record()
+
+	if resp.StatusCode >= 400 {
+		responseBody, err := c.standardizer.processResponseBody(resp.Body, resp.Header.Get(utils.HeaderContentEncoding), selection.Vendor)
+		if err != nil {
+			return ParseVendorError(selection.Vendor, resp.StatusCode, nil)
+		}
+		vendorErr := ParseVendorError(selection.Vendor, resp.StatusCode, responseBody)
+		logger.Warn(r.Context(), "Vendor API error detected",
+			"vendor", selection.Vendor,
+			"status_code", resp.StatusCode,
+			"retriable", IsRetriableAPIError(vendorErr),
+			"response_body", string(responseBody),
+			"component", "APIClient",
+			"stage", "VendorAPIError",
+		)
+		return vendorErr
+	}
+
+	c.standardizer.setCompliantHeaders(w, selection.Vendor, 0, false)
+	setRouterHeaders(r, w.Header())
+	w.Header().Set(utils.HeaderContentType, speechContentType(resp.Header.Get(utils.HeaderContentType), modifiedBody))
+	w.Header().Set(utils.HeaderCacheControl, utils.CacheControlNoCache)
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	// Relay the audio as it is synthesized rather than after the whole clip is ready
+	var written int64
+	buffer := make([]byte, speechChunkSize)
+	for {
+		n, readErr := resp.Body.Read(buffer)
+		if n > 0 {
+			if _, err := w.Write(buffer[:n]); err != nil {
+				logger.Warn(r.Context(), "Client disconnected during speech stream",
+					"vendor", selection.Vendor,
+					"bytes_written", written,
+					"error", err.Error(),
+					"component", "APIClient",
+					"stage", "StreamWriting",
+				)
+				return nil
+			}
+			written += int64(n)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			// Headers are already sent, so the audio simply ends early
+			logger.Error(r.Context(), "Error reading speech stream", readErr,
+				"vendor", selection.Vendor,
+				"bytes_written", written,
+				"component", "APIClient",
+				"stage", "StreamReading",
+			)
+			break
+		}
+	}
+
+	logger.Info(r.Context(), "Speech response sent to client",
+		"vendor", selection.Vendor,
+		"model", selection.Model,
+		"original_model", originalModel,
+		"content_type", w.Header().Get(utils.HeaderContentType),
+		"audio_size_bytes", written,
+		"component", "APIClient",
+		"stage", "FinalResponseSent",
+	)
+	return nil
+}
+
+// speechContentType returns the vendor's content type when it describes audio or events,
+// and otherwise the content type of the requested format
+func speechContentType(vendorContentType string, requestBody []byte) string {
+	if strings.HasPrefix(vendorContentType, "audio/") || strings.HasPrefix(vendorContentType, utils.ContentTypeEventStream) {
+		return vendorContentType
+	}
+	var request struct {
+		ResponseFormat string `json:"response_format"`
+	}
+	_ = codec.Unmarshal(requestBody, &request)
+	if contentType, ok := speechContentTypes[request.ResponseFormat]; ok {
+		return contentType
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,143 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxySpeechRequest(t *testing.T) {
+	tests := []struct {
+		name                string
+		body                string
+		vendor              string
+		vendorContentType   string
+		vendorStatus        int
+		expectedStatus      int
+		expectedContentType string
+		expectedVendorBody  map[string]interface{}
+	}{
+		{
+			name:                "voice is mapped and audio relayed",
+			body:                `{"model":"my-tts","input":"Hello","voice":"alloy","speed":1.5}`,
+			vendor:              "openai",
+			vendorContentType:   "audio/mpeg",
+			vendorStatus:        http.StatusOK,
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "audio/mpeg",
+			expectedVendorBody:  map[string]interface{}{"model": "tts-1", "input": "Hello", "voice": "shimmer", "response_format": "mp3", "speed": 1.5},
+		},
+		{
+			name:                "format is mapped to the vendor's",
+			body:                `{"input":"Hello","voice":"Fritz-PlayAI","response_format":"opus"}`,
+			vendor:              "groq",
+			vendorContentType:   "application/octet-stream",
+			vendorStatus:        http.StatusOK,
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "audio/ogg",
+			expectedVendorBody:  map[string]interface{}{"model": "playai-tts", "input": "Hello", "voice": "Fritz-PlayAI", "response_format": "ogg"},
+		},
+		{
+			name:           "format no speech model supports",
+			body:           `{"input":"Hello","response_format":"aac"}`,
+			vendor:         "groq",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid request",
+			body:           `{"voice":"alloy"}`,
+			vendor:         "openai",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:              "vendor error",
+			body:              `{"input":"Hello"}`,
+			vendor:            "openai",
+			vendorContentType: "application/json",
+			vendorStatus:      http.StatusBadRequest,
+			expectedStatus:    http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vendorRequest map[string]interface{}
+			vendorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/audio/speech", r.URL.Path)
+				body, _ := io.ReadAll(r.Body)
+				require.NoError(t, json.Unmarshal(body, &vendorRequest))
+				w.Header().Set("Content-Type", tt.vendorContentType)
+				w.WriteHeader(tt.vendorStatus)
+				if tt.vendorStatus >= 400 {
+					_, _ = w.Write([]byte(`{"error":{"message":"invalid voice","type":"invalid_request_error"}}`))
+					return
+				}
+				_, _ = w.Write([]byte("ID3-audio-bytes"))
+			}))
+			defer vendorServer.Close()
+
+			creds := []config.Credential{{Platform: tt.vendor, Type: "api-key", Value: "sk-test"}}
+			vendorModels := map[string]config.VendorModel{
+				"openai": {Vendor: "openai", Model: "tts-1", Type: config.ModelTypeSpeech, Voices: map[string]string{"alloy": "shimmer"}},
+				"groq":   {Vendor: "groq", Model: "playai-tts", Type: config.ModelTypeSpeech},
+			}
+			models := []config.VendorModel{
+				vendorModels[tt.vendor],
+				{Vendor: "anthropic", Model: "claude-voice", Type: config.ModelTypeSpeech},
+			}
+			mockSelector := &MockSelector{}
+			mockSelector.On("Select", creds, models[:1]).Return(&selector.VendorSelection{Vendor: tt.vendor, Model: models[0].Model, Credential: creds[0]}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/audio/speech", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			ProxySpeechRequest(rr, req, creds, models, NewAPIClient(map[string]string{tt.vendor: vendorServer.URL}), mockSelector)
+
+			require.Equal(t, tt.expectedStatus, rr.Code, rr.Body.String())
+			if tt.expectedStatus == http.StatusBadRequest {
+				assert.Nil(t, vendorRequest, "rejected requests never reach a vendor")
+				return
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tt.expectedVendorBody, vendorRequest)
+			assert.Equal(t, tt.expectedContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, "ID3-audio-bytes", rr.Body.String())
+		})
+	}
+}
+
+func TestSpeechFormats(t *testing.T) {
+	tests := []struct {
+		vendor   string
+		format   string
+		expected string
+		ok       bool
+	}{
+		{vendor: "openai", format: "aac", expected: "aac", ok: true},
+		{vendor: "groq", format: "opus", expected: "ogg", ok: true},
+		{vendor: "groq", format: "pcm", ok: false},
+		{vendor: "together", format: "pcm", expected: "raw", ok: true},
+		{vendor: "together", format: "flac", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vendor+"/"+tt.format, func(t *testing.T) {
+			provider, ok := adapterFor(tt.vendor).(SpeechProvider)
+			require.True(t, ok)
+			format, ok := provider.SpeechFormat(tt.format)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, format)
+		})
+	}
+	assert.False(t, SupportsSpeech("anthropic"))
+	assert.False(t, SupportsSpeech("ollama"))
+}
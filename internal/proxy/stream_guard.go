@@ -0,0 +1,123 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Reasons reported when the router cuts a stream short
+const (
+	StreamCutoffMaxDuration         = "max_duration"
+	StreamCutoffMaxCompletionTokens = "max_completion_tokens"
+)
+
+// StreamLimits are guardrails applied to streaming responses; zero values disable a limit
+type StreamLimits struct {
+	MaxDuration         time.Duration
+	MaxCompletionTokens int
+}
+
+// StreamLimitsFromEnv reads STREAM_MAX_DURATION (seconds) and STREAM_MAX_COMPLETION_TOKENS
+func StreamLimitsFromEnv() StreamLimits {
+	return StreamLimits{
+		MaxDuration:         utils.GetEnvDuration("STREAM_MAX_DURATION", 0),
+		MaxCompletionTokens: utils.GetEnvInt("STREAM_MAX_COMPLETION_TOKENS", 0),
+	}
+}
+
+// Enabled reports whether any limit is configured
+func (l StreamLimits) Enabled() bool {
+	return l.MaxDuration > 0 || l.MaxCompletionTokens > 0
+}
+
+// streamGuard enforces StreamLimits on a single streaming response and,
+// when it cuts the stream, reconciles usage by counting what was streamed so far
+type streamGuard struct {
+	limits       StreamLimits
+	promptTokens int
+	timedOut     atomic.Bool
+	timer        *time.Timer
+}
+
+// newStreamGuard starts enforcing limits on the stream sp processes; upstream is closed when
+// the duration limit is hit so a blocked read returns immediately
+func newStreamGuard(limits StreamLimits, sp *StreamProcessor, requestBody []byte, upstream io.Closer) *streamGuard {
+	guard := &streamGuard{
+		limits:       limits,
+		promptTokens: sp.PromptTokens(requestBody),
+	}
+	if limits.MaxDuration > 0 {
+		guard.timer = time.AfterFunc(limits.MaxDuration, func() {
+			guard.timedOut.Store(true)
+			upstream.Close()
+		})
+	}
+	return guard
+}
+
+// stop releases the duration timer
+func (g *streamGuard) stop() {
+	if g != nil && g.timer != nil {
+		g.timer.Stop()
+	}
+}
+
+// cutoffReason returns why the stream must be cut, or "" if it may continue
+func (g *streamGuard) cutoffReason(sp *StreamProcessor) string {
+	if g == nil {
+		return ""
+	}
+	if g.timedOut.Load() {
+		return StreamCutoffMaxDuration
+	}
+	if g.limits.MaxCompletionTokens > 0 && sp.CompletionTokens() >= g.limits.MaxCompletionTokens {
+		return StreamCutoffMaxCompletionTokens
+	}
+	return ""
+}
+
+// writeCutoff terminates the client stream with a final chunk carrying finish_reason
+// "length" and usage reconciled from the tokens streamed so far, followed by [DONE] and the
+// stream summary, if enabled. The vendor never reports usage for a cut stream; the usage
+// object keeps the OpenAI shape, and the stream summary and routing decision mark it estimated
+func (g *streamGuard) writeCutoff(w http.ResponseWriter, sp *StreamProcessor, flusher http.Flusher) error {
+	completionTokens := sp.CompletionTokens()
+	finalChunk := map[string]interface{}{
+		"id":                 sp.ConversationID,
+		"object":             "chat.completion.chunk",
+		"created":            sp.Timestamp,
+		"model":              sp.OriginalModel,
+		"system_fingerprint": sp.SystemFingerprint,
+		"service_tier":       "default",
+		"choices": []interface{}{
+			map[string]interface{}{
+				"index":         0,
+				"delta":         map[string]interface{}{},
+				"logprobs":      nil,
+				"finish_reason": "length",
+			},
+		},
+		"usage": map[string]interface{}{
+			"prompt_tokens":     g.promptTokens,
+			"completion_tokens": completionTokens,
+			"total_tokens":      g.promptTokens + completionTokens,
+		},
+	}
+
+	data, err := codec.Marshal(finalChunk)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte("data: " + string(data) + "\n\ndata: [DONE]\n\n")); err != nil {
+		return err
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+	return writeStreamSummary(w, sp, flusher)
+}
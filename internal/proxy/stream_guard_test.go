@@ -0,0 +1,184 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lastUsageChunk returns the final data chunk before [DONE]
+func lastUsageChunk(t *testing.T, body string) map[string]interface{} {
+	t.Helper()
+	require.True(t, strings.HasSuffix(body, "data: [DONE]\n\n"))
+
+	events := strings.Split(strings.TrimSpace(body), "\n\n")
+	require.GreaterOrEqual(t, len(events), 2)
+
+	var chunk map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(events[len(events)-2], "data: ")), &chunk))
+	return chunk
+}
+
+func TestStreamGuard_MaxCompletionTokens(t *testing.T) {
+	var upstream strings.Builder
+	for i := 0; i < 10; i++ {
+		upstream.WriteString(`data: {"choices":[{"index":0,"delta":{"content":"abcdefgh"}}]}` + "\n\n")
+	}
+	upstream.WriteString("data: [DONE]\n\n")
+
+	requestBody := []byte(`{"messages":[{"role":"user","content":"12345678"}]}`)
+	sp := NewStreamProcessor("chatcmpl-test", 1700000000, "fp_test", "openai", "gpt-4o")
+	guard := newStreamGuard(StreamLimits{MaxCompletionTokens: 5}, sp, requestBody, io.NopCloser(nil))
+	rec := httptest.NewRecorder()
+
+	client := &APIClient{}
+	err := client.processStreamingResponse(rec, bufio.NewReader(strings.NewReader(upstream.String())), sp, rec, guard)
+	require.NoError(t, err)
+
+	// Each chunk streams 2 tokens, so the guard cuts after the third chunk
+	assert.Equal(t, 3, strings.Count(rec.Body.String(), "abcdefgh"))
+
+	chunk := lastUsageChunk(t, rec.Body.String())
+	assert.Equal(t, "chatcmpl-test", chunk["id"])
+	assert.Equal(t, "gpt-4o", chunk["model"])
+	choice := chunk["choices"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "length", choice["finish_reason"])
+
+	// The prompt counts its message framing as well as its text
+	usage := chunk["usage"].(map[string]interface{})
+	assert.Equal(t, float64(10), usage["prompt_tokens"])
+	assert.Equal(t, float64(6), usage["completion_tokens"])
+	assert.Equal(t, float64(16), usage["total_tokens"])
+	assert.NotContains(t, usage, "estimated", "the usage object keeps the OpenAI shape")
+}
+
+// wordTokenizer counts one token per whitespace-separated word
+type wordTokenizer struct{}
+
+func (wordTokenizer) Name() string             { return "words" }
+func (wordTokenizer) Exact() bool              { return true }
+func (wordTokenizer) Count(text string) int    { return len(strings.Fields(text)) }
+func (wordTokenizer) Encode(text string) []int { return nil }
+
+func TestStreamGuard_CountsWithModelTokenizer(t *testing.T) {
+	upstream := `data: {"choices":[{"index":0,"delta":{"content":"one two three"}}]}` + "\n\n" +
+		`data: {"choices":[{"index":0,"delta":{"content":" four"}}]}` + "\n\n" +
+		"data: [DONE]\n\n"
+
+	sp := NewStreamProcessor("chatcmpl-test", 1700000000, "fp_test", "openai", "gpt-4o")
+	sp.Tokenizer = wordTokenizer{}
+	requestBody := []byte(`{"messages":[{"role":"user","content":"say four words"}]}`)
+	guard := newStreamGuard(StreamLimits{MaxCompletionTokens: 4}, sp, requestBody, io.NopCloser(nil))
+	rec := httptest.NewRecorder()
+
+	client := &APIClient{}
+	require.NoError(t, client.processStreamingResponse(rec, bufio.NewReader(strings.NewReader(upstream)), sp, rec, guard))
+
+	// 3 tokens priming the reply, 3 framing the message, 1 for the role and 3 for the content
+	usage := lastUsageChunk(t, rec.Body.String())["usage"].(map[string]interface{})
+	assert.Equal(t, float64(10), usage["prompt_tokens"])
+	assert.Equal(t, float64(4), usage["completion_tokens"])
+
+	promptTokens, completionTokens, estimated := sp.Usage(requestBody)
+	assert.Equal(t, []int{10, 4}, []int{promptTokens, completionTokens})
+	assert.True(t, estimated, "usage the vendor never reported is estimated")
+}
+
+// countingTokenizer counts one token per word and how many times it was asked to
+type countingTokenizer struct {
+	wordTokenizer
+	calls int
+}
+
+func (c *countingTokenizer) Count(text string) int {
+	c.calls++
+	return c.wordTokenizer.Count(text)
+}
+
+func TestStreamProcessor_Usage(t *testing.T) {
+	tests := []struct {
+		name              string
+		usageChunk        string
+		expectedUsage     [2]int
+		expectedEstimated bool
+		expectedCounted   bool
+	}{
+		{
+			name:          "vendor usage",
+			usageChunk:    `{"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":9}}`,
+			expectedUsage: [2]int{12, 9},
+		},
+		{
+			name:          "vendor reports no completion tokens",
+			usageChunk:    `{"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":0}}`,
+			expectedUsage: [2]int{12, 0},
+		},
+		{
+			name:              "no vendor usage",
+			usageChunk:        `{"choices":[]}`,
+			expectedUsage:     [2]int{10, 4},
+			expectedEstimated: true,
+			expectedCounted:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := `data: {"choices":[{"index":0,"delta":{"content":"one two three"}}]}` + "\n\n" +
+				`data: {"choices":[{"index":0,"delta":{"content":" four"}}]}` + "\n\n" +
+				"data: " + tt.usageChunk + "\n\n" +
+				"data: [DONE]\n\n"
+
+			tok := &countingTokenizer{}
+			sp := NewStreamProcessor("chatcmpl-test", 1700000000, "fp_test", "openai", "gpt-4o")
+			sp.Tokenizer = tok
+			rec := httptest.NewRecorder()
+			client := &APIClient{}
+			require.NoError(t, client.processStreamingResponse(rec, bufio.NewReader(strings.NewReader(upstream)), sp, rec, nil))
+			assert.Zero(t, tok.calls, "nothing is counted while streaming without a guard")
+
+			promptTokens, completionTokens, estimated := sp.Usage([]byte(`{"messages":[{"role":"user","content":"say four words"}]}`))
+			assert.Equal(t, tt.expectedUsage, [2]int{promptTokens, completionTokens})
+			assert.Equal(t, tt.expectedEstimated, estimated)
+			assert.Equal(t, tt.expectedCounted, tok.calls > 0)
+		})
+	}
+}
+
+func TestStreamGuard_MaxDuration(t *testing.T) {
+	reader, writer := io.Pipe()
+	go func() {
+		_, _ = writer.Write([]byte(`data: {"choices":[{"index":0,"delta":{"content":"abcd"}}]}` + "\n\n"))
+		// Stall without closing, as a hung vendor would
+	}()
+
+	sp := NewStreamProcessor("chatcmpl-test", 1700000000, "fp_test", "openai", "gpt-4o")
+	guard := newStreamGuard(StreamLimits{MaxDuration: 50 * time.Millisecond}, sp, nil, reader)
+	defer guard.stop()
+	rec := httptest.NewRecorder()
+
+	client := &APIClient{}
+	err := client.processStreamingResponse(rec, bufio.NewReader(reader), sp, rec, guard)
+	require.NoError(t, err)
+
+	chunk := lastUsageChunk(t, rec.Body.String())
+	usage := chunk["usage"].(map[string]interface{})
+	assert.Equal(t, float64(1), usage["completion_tokens"])
+}
+
+func TestStreamGuard_NoLimitsPassesThrough(t *testing.T) {
+	upstream := `data: {"choices":[{"index":0,"delta":{"content":"hi"}}]}` + "\n\n" + "data: [DONE]\n\n"
+	sp := NewStreamProcessor("chatcmpl-test", 1700000000, "fp_test", "openai", "gpt-4o")
+	rec := httptest.NewRecorder()
+
+	client := &APIClient{}
+	require.NoError(t, client.processStreamingResponse(rec, bufio.NewReader(strings.NewReader(upstream)), sp, rec, nil))
+	assert.NotContains(t, rec.Body.String(), `"finish_reason":"length"`)
+}
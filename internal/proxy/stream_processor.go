@@ -3,11 +3,15 @@ package proxy
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/aashari/go-generative-api-router/internal/codec"
 	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/tokenizer"
 )
 
 // StreamProcessor handles stateful processing of streaming responses
@@ -18,6 +22,35 @@ type StreamProcessor struct {
 	Vendor            string
 	OriginalModel     string
 	isFirstChunk      bool
+	contentSent       bool
+	// completionTokens counts the streamed text up to uncounted, which holds the content,
+	// reasoning and tool call arguments streamed since; they are counted only when asked for
+	completionTokens int
+	uncounted        []string
+	// Usage reported by the vendor, usually in the last chunk, and whether it reported it
+	reportedPromptTokens     int
+	reportedCompletionTokens int
+	promptTokensReported     bool
+	completionTokensReported bool
+	// Tokenizer counts the tokens of the prompt and of what is streamed when the vendor
+	// reports no usage; nil uses the process-wide tokenizer of the vendor
+	Tokenizer tokenizer.Tokenizer
+	// Reproducibility, when set, collects the vendor fingerprint of a seeded request
+	Reproducibility *Reproducibility
+	// Extensions, when set, is attached to every chunk as an "extensions" object
+	Extensions map[string]interface{}
+	// Summary, when set, is sent as a router.summary event after [DONE]
+	Summary *StreamSummary
+	// ReasoningPolicy decides how reasoning reaches the client; empty returns it in reasoning_content
+	ReasoningPolicy string
+	reasoning       map[int]*reasoningStream
+	// OnFirstWrite, when set, runs once the first chunk has been written to the client
+	OnFirstWrite func()
+	wroteChunk   bool
+	// firstContentAt and lastContentAt are when the first and the latest delta carrying
+	// content, reasoning or tool calls arrived
+	firstContentAt time.Time
+	lastContentAt  time.Time
 }
 
 // NewStreamProcessor creates a new stream processor with conversation-level values
@@ -70,7 +103,7 @@ func (sp *StreamProcessor) ProcessChunk(chunk []byte) []byte {
 
 	// Parse the JSON chunk
 	var chunkData map[string]interface{}
-	if err := json.Unmarshal([]byte(jsonData), &chunkData); err != nil {
+	if err := codec.Unmarshal([]byte(jsonData), &chunkData); err != nil {
 		// Log complete unmarshaling error
 		ctx = logger.WithStage(ctx, "json_parsing")
 		logger.Error(ctx, "Stream chunk JSON parsing failed", err,
@@ -96,7 +129,7 @@ func (sp *StreamProcessor) ProcessChunk(chunk []byte) []byte {
 	sp.processChunkData(chunkData)
 
 	// Convert back to JSON
-	modifiedJSON, err := json.Marshal(chunkData)
+	modifiedJSON, err := codec.Marshal(chunkData)
 	if err != nil {
 		// Log complete marshaling error
 		ctx = logger.WithStage(ctx, "marshaling")
@@ -126,8 +159,92 @@ func (sp *StreamProcessor) ProcessChunk(chunk []byte) []byte {
 	return result
 }
 
+// CompletionTokens counts the completion tokens streamed so far across all choices
+// Text is counted when asked for, so streams whose vendor reports usage are never tokenized
+func (sp *StreamProcessor) CompletionTokens() int {
+	for _, text := range sp.uncounted {
+		sp.completionTokens += sp.tokenizer().Count(text)
+	}
+	sp.uncounted = sp.uncounted[:0]
+	return sp.completionTokens
+}
+
+// PromptTokens counts the prompt tokens of the request messages and tools, or returns 0 for
+// a body that is not a chat completion request
+func (sp *StreamProcessor) PromptTokens(requestBody []byte) int {
+	var request tokenizer.CountTokensRequest
+	if err := codec.Unmarshal(requestBody, &request); err != nil {
+		return 0
+	}
+	request.Model = sp.OriginalModel
+	response, err := tokenizer.CountTokens(sp.tokenizer(), request)
+	if err != nil {
+		return 0
+	}
+	return response.InputTokens
+}
+
+// tokenizer returns the tokenizer counting the stream's tokens
+func (sp *StreamProcessor) tokenizer() tokenizer.Tokenizer {
+	if sp.Tokenizer == nil {
+		sp.Tokenizer = tokenizer.Default().ForModel(sp.Vendor, "")
+	}
+	return sp.Tokenizer
+}
+
+// chunkWritten records that a chunk reached the client, running OnFirstWrite for the first one
+func (sp *StreamProcessor) chunkWritten() {
+	if sp.wroteChunk {
+		return
+	}
+	sp.wroteChunk = true
+	if sp.OnFirstWrite != nil {
+		sp.OnFirstWrite()
+	}
+}
+
+// ContentSent reports whether any content, reasoning or tool call has been streamed
+func (sp *StreamProcessor) ContentSent() bool {
+	return sp.contentSent
+}
+
+// Usage returns the prompt and completion tokens of the stream, as reported by the vendor
+// or, when it reported none, counted from the request and the text streamed so far, in
+// which case estimated is true: the vendor's own count may differ
+func (sp *StreamProcessor) Usage(requestBody []byte) (promptTokens, completionTokens int, estimated bool) {
+	promptTokens, completionTokens = sp.reportedPromptTokens, sp.reportedCompletionTokens
+	if !sp.promptTokensReported {
+		promptTokens = sp.PromptTokens(requestBody)
+		estimated = true
+	}
+	if !sp.completionTokensReported {
+		completionTokens = sp.CompletionTokens()
+		estimated = true
+	}
+	return promptTokens, completionTokens, estimated
+}
+
 // processChunkData processes the parsed chunk data
 func (sp *StreamProcessor) processChunkData(chunkData map[string]interface{}) {
+	if usage, ok := chunkData["usage"].(map[string]interface{}); ok {
+		if tokens, ok := usage["prompt_tokens"].(float64); ok {
+			sp.reportedPromptTokens = int(tokens)
+			sp.promptTokensReported = true
+		}
+		if tokens, ok := usage["completion_tokens"].(float64); ok {
+			sp.reportedCompletionTokens = int(tokens)
+			sp.completionTokensReported = true
+		}
+	}
+	if sp.Reproducibility != nil {
+		if fingerprint := vendorFingerprint(chunkData); fingerprint != "" {
+			sp.Reproducibility.SystemFingerprint = fingerprint
+		}
+	}
+	if sp.Extensions != nil {
+		chunkData["extensions"] = sp.Extensions
+	}
+
 	// Set consistent values
 	chunkData["id"] = sp.ConversationID
 	chunkData["created"] = sp.Timestamp
@@ -199,9 +316,17 @@ func (sp *StreamProcessor) processStreamChoices(choices []interface{}) {
 			choiceMap["logprobs"] = nil
 		}
 
+		// Choices of multi-choice streams arrive in separate chunks, told apart by their index
+		index := i
+		if value, ok := choiceMap["index"].(float64); ok {
+			index = int(value)
+		}
+
 		// Process delta or message
 		if delta, ok := choiceMap["delta"].(map[string]interface{}); ok {
 			sp.processStreamDelta(delta, i)
+			finishReason, _ := choiceMap["finish_reason"].(string)
+			sp.processStreamReasoning(delta, index, finishReason != "")
 		} else if message, ok := choiceMap["message"].(map[string]interface{}); ok {
 			sp.processStreamMessage(message, i)
 		} else {
@@ -234,6 +359,15 @@ func (sp *StreamProcessor) processStreamDelta(delta map[string]interface{}, choi
 		"conversation_id", sp.ConversationID,
 		"original_model", sp.OriginalModel)
 
+	// Report the chain of thought of reasoning models in one place
+	normalizeReasoning(delta)
+
+	// Keep streamed text so usage can be counted if the vendor reports none or the stream is cut
+	sp.countCompletionTokens(delta)
+
+	// Convert vendor citations (e.g. Cohere) into annotations
+	normalizeCitations(delta)
+
 	// Add annotations if missing
 	if _, ok := delta["annotations"]; !ok {
 		delta["annotations"] = []interface{}{}
@@ -269,6 +403,77 @@ func (sp *StreamProcessor) processStreamDelta(delta map[string]interface{}, choi
 	}
 }
 
+// countCompletionTokens adds the content, reasoning and tool call arguments of a delta to the
+// text CompletionTokens counts
+func (sp *StreamProcessor) countCompletionTokens(delta map[string]interface{}) {
+	hasContent := false
+	if content, ok := delta["content"].(string); ok && content != "" {
+		sp.uncounted = append(sp.uncounted, content)
+		hasContent = true
+	}
+	if reasoning, ok := delta["reasoning_content"].(string); ok && reasoning != "" {
+		sp.uncounted = append(sp.uncounted, reasoning)
+		hasContent = true
+	}
+	if hasContent || deltaHasToolCalls(delta) {
+		sp.contentSent = true
+		sp.lastContentAt = time.Now()
+		if sp.firstContentAt.IsZero() {
+			sp.firstContentAt = sp.lastContentAt
+		}
+	}
+	if toolCalls, ok := delta["tool_calls"].([]interface{}); ok {
+		for _, toolCall := range toolCalls {
+			toolCallMap, _ := toolCall.(map[string]interface{})
+			function, _ := toolCallMap["function"].(map[string]interface{})
+			if arguments, ok := function["arguments"].(string); ok && arguments != "" {
+				sp.uncounted = append(sp.uncounted, arguments)
+			}
+		}
+	}
+}
+
+// deltaHasToolCalls reports whether a delta carries tool calls
+func deltaHasToolCalls(delta map[string]interface{}) bool {
+	toolCalls, _ := delta["tool_calls"].([]interface{})
+	return len(toolCalls) > 0
+}
+
+// TimeToFirstToken returns how long after started the first content delta arrived, and
+// false when the stream carried none
+func (sp *StreamProcessor) TimeToFirstToken(started time.Time) (time.Duration, bool) {
+	if sp.firstContentAt.IsZero() {
+		return 0, false
+	}
+	return sp.firstContentAt.Sub(started), true
+}
+
+// TokensPerSecond returns the rate completionTokens were streamed at, from the first content
+// delta to the last, and false when the stream was too short to tell
+func (sp *StreamProcessor) TokensPerSecond(completionTokens int) (float64, bool) {
+	elapsed := sp.lastContentAt.Sub(sp.firstContentAt)
+	if sp.firstContentAt.IsZero() || elapsed <= 0 || completionTokens <= 0 {
+		return 0, false
+	}
+	return float64(completionTokens) / elapsed.Seconds(), true
+}
+
+// recordStreamMetrics records the time to first token and the throughput of a finished
+// stream in the Prometheus metrics and on the request's routing decision
+func recordStreamMetrics(ctx context.Context, sp *StreamProcessor, selection *selector.VendorSelection, started time.Time, completionTokens int) {
+	ttft, ok := sp.TimeToFirstToken(started)
+	if !ok {
+		return
+	}
+	metrics := monitoring.DefaultPrometheusMetrics()
+	metrics.ObserveStreamTTFT(selection.Vendor, selection.Model, ttft)
+	tokensPerSecond, ok := sp.TokensPerSecond(completionTokens)
+	if ok {
+		metrics.ObserveStreamThroughput(selection.Vendor, selection.Model, tokensPerSecond)
+	}
+	recordStreamTiming(ctx, ttft, tokensPerSecond)
+}
+
 // processStreamMessage processes message in streaming chunks
 func (sp *StreamProcessor) processStreamMessage(message map[string]interface{}, choiceIndex int) {
 	// Log complete message processing start in stream
@@ -282,6 +487,11 @@ func (sp *StreamProcessor) processStreamMessage(message map[string]interface{},
 		"conversation_id", sp.ConversationID,
 		"original_model", sp.OriginalModel)
 
+	// Report the chain of thought of reasoning models in one place
+	normalizeReasoning(message)
+	extractInlineReasoning(message)
+	applyReasoningPolicy(message, sp.ReasoningPolicy)
+
 	// Add annotations if missing
 	if _, ok := message["annotations"]; !ok {
 		message["annotations"] = []interface{}{}
@@ -338,7 +548,7 @@ func (sp *StreamProcessor) parseStreamChunk(chunk []byte) (map[string]interface{
 	jsonData := chunk[6:] // Skip "data: " prefix
 
 	var chunkData map[string]interface{}
-	if err := json.Unmarshal(jsonData, &chunkData); err != nil {
+	if err := codec.Unmarshal(jsonData, &chunkData); err != nil {
 		ctx := context.Background()
 		ctx = logger.WithComponent(ctx, "stream_processor")
 		ctx = logger.WithStage(ctx, "json_parsing")
@@ -413,7 +623,7 @@ func (sp *StreamProcessor) addUsageForFirstChunk(chunkData map[string]interface{
 // reconstructSSE reconstructs SSE format from processed data
 func (sp *StreamProcessor) reconstructSSE(chunkData map[string]interface{}) []byte {
 	// Marshal the processed data back to JSON
-	modifiedJSON, err := json.Marshal(chunkData)
+	modifiedJSON, err := codec.Marshal(chunkData)
 	if err != nil {
 		ctx := context.Background()
 		ctx = logger.WithComponent(ctx, "stream_processor")
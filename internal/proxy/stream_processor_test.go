@@ -0,0 +1,31 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamProcessor_TimeToFirstTokenAndThroughput(t *testing.T) {
+	sp := NewStreamProcessor("chatcmpl-1", 1700000000, "fp_1", "openai", "my-model")
+	started := time.Now()
+
+	sp.ProcessChunk([]byte(`data: {"choices":[{"index":0,"delta":{"role":"assistant"}}]}`))
+	_, ok := sp.TimeToFirstToken(started)
+	assert.False(t, ok, "a role-only delta carries no content")
+
+	sp.ProcessChunk([]byte(`data: {"choices":[{"index":0,"delta":{"content":"Hello"}}]}`))
+	ttft, ok := sp.TimeToFirstToken(started)
+	require.True(t, ok)
+	assert.GreaterOrEqual(t, ttft, time.Duration(0))
+	_, ok = sp.TokensPerSecond(10)
+	assert.False(t, ok, "one content delta spans no time")
+
+	sp.ProcessChunk([]byte(`data: {"choices":[{"index":0,"delta":{"content":" world"}}]}`))
+	sp.firstContentAt = sp.lastContentAt.Add(-500 * time.Millisecond)
+	tokensPerSecond, ok := sp.TokensPerSecond(50)
+	require.True(t, ok)
+	assert.InDelta(t, 100, tokensPerSecond, 1e-9)
+}
@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/errors"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// StreamInterruptedCode is the error code of the SSE error event ending an interrupted stream
+const StreamInterruptedCode = "stream_interrupted"
+
+// StreamInterruptedError reports a vendor stream that failed or ended before [DONE]
+type StreamInterruptedError struct {
+	Vendor string
+	// ContentSent is whether content had reached the client, which rules out restarting the
+	// stream on the same or another vendor
+	ContentSent bool
+	Err         error
+}
+
+// Error implements the error interface
+func (e *StreamInterruptedError) Error() string {
+	return "vendor " + e.Vendor + " stream interrupted: " + e.Err.Error()
+}
+
+// Unwrap returns the error that interrupted the stream
+func (e *StreamInterruptedError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetriable implements the RetryableError interface: a stream that sent no content yet
+// can be restarted
+func (e *StreamInterruptedError) IsRetriable() bool {
+	return !e.ContentSent
+}
+
+// streamState is what a streaming response has sent its client so far. It is shared by the
+// vendor attempts serving one request, so a stream restarted on another vendor continues the
+// one the client already has: its headers are not sent twice and its chunks keep their id
+type streamState struct {
+	headersSent       bool
+	conversationID    string
+	timestamp         int64
+	systemFingerprint string
+}
+
+// streamStateKey holds the stream state of a request in its context
+type streamStateKey struct{}
+
+// withStreamState returns ctx carrying a fresh stream state
+func withStreamState(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamStateKey{}, &streamState{})
+}
+
+// streamStateFromContext returns the stream state ctx carries, or nil
+func streamStateFromContext(ctx context.Context) *streamState {
+	state, _ := ctx.Value(streamStateKey{}).(*streamState)
+	return state
+}
+
+// streamIdentity returns the id, creation time and system fingerprint of a stream's chunks,
+// generating them for the first vendor attempt and reusing them for restarts
+func streamIdentity(ctx context.Context) (string, int64, string) {
+	state := streamStateFromContext(ctx)
+	if state == nil {
+		return utils.GenerateChatCompletionID(), time.Now().Unix(), utils.GenerateSystemFingerprint()
+	}
+	if state.conversationID == "" {
+		state.conversationID = utils.GenerateChatCompletionID()
+		state.timestamp = time.Now().Unix()
+		state.systemFingerprint = utils.GenerateSystemFingerprint()
+	}
+	return state.conversationID, state.timestamp, state.systemFingerprint
+}
+
+// streamStarted reports whether the response to the request ctx belongs to has sent its
+// stream headers, after which errors can only reach the client as SSE events
+func streamStarted(ctx context.Context) bool {
+	state := streamStateFromContext(ctx)
+	return state != nil && state.headersSent
+}
+
+// isRestartableStreamError reports whether err is a stream that was interrupted before any
+// content reached the client, and so can be replayed on another vendor
+func isRestartableStreamError(err error) bool {
+	var streamErr *StreamInterruptedError
+	return stderrors.As(err, &streamErr) && !streamErr.ContentSent
+}
+
+// writeStreamError ends a started stream with an SSE error event in the OpenAI error format,
+// so clients are told why it stopped instead of seeing it end silently
+func writeStreamError(w http.ResponseWriter, message string) error {
+	data, err := codec.Marshal(errors.ErrorResponse{
+		Error: *errors.NewAPIErrorWithCode(errors.ErrorTypeUnavailable, message, StreamInterruptedCode),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte("data: " + string(data) + "\n\n")); err != nil {
+		return err
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	roleChunk    = `data: {"id":"v-1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"role":"assistant"},"finish_reason":null}]}` + "\n\n"
+	contentChunk = `data: {"id":"v-1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"content":"Hello"},"finish_reason":null}]}` + "\n\n"
+	finishChunk  = `data: {"id":"v-1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}` + "\n\n"
+)
+
+// streamingVendor serves chunks as an SSE stream, ending it without [DONE] unless complete
+func streamingVendor(t *testing.T, complete bool, chunks ...string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, chunk := range chunks {
+			_, _ = w.Write([]byte(chunk))
+			w.(http.Flusher).Flush()
+		}
+		if complete {
+			_, _ = w.Write([]byte("data: [DONE]\n\n"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestStreamInterrupted(t *testing.T) {
+	body := []byte(`{"model":"any","stream":true,"messages":[{"role":"user","content":"Hi"}]}`)
+	creds := []config.Credential{{Platform: "openai", Value: "sk-openai"}, {Platform: "gemini", Value: "gm-1"}}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-2.5-flash"}}
+	primary := &selector.VendorSelection{Vendor: "openai", Model: "gpt-4o", Credential: creds[0]}
+	ids := regexp.MustCompile(`"id":"([^"]+)"`)
+
+	send := func(t *testing.T, client *APIClient) (*httptest.ResponseRecorder, *http.Request, error) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(string(body)))
+		r = r.WithContext(withStreamState(r.Context()))
+		return w, r, client.SendRequest(w, r, primary, body, "any")
+	}
+
+	t.Run("restarts on another vendor before any content", func(t *testing.T) {
+		client := NewAPIClient(map[string]string{
+			"openai": streamingVendor(t, false, roleChunk).URL,
+			"gemini": streamingVendor(t, true, roleChunk, contentChunk, finishChunk).URL,
+		})
+		w, r, err := send(t, client)
+		var streamErr *StreamInterruptedError
+		require.ErrorAs(t, err, &streamErr)
+		assert.False(t, streamErr.ContentSent)
+		assert.True(t, streamErr.IsRetriable())
+		require.True(t, shouldFailOver(err))
+
+		decision := &routingDecision{}
+		err = failOver(r.Context(), w, r, err, primary, body, body, creds, models, client, selector.NewContextAwareSelector(), "any", decision)
+		require.NoError(t, err)
+		assert.Equal(t, "gemini", decision.FallbackVendor)
+		require.Len(t, decision.FailedAttempts, 1)
+		assert.Equal(t, reasonStreamInterrupted, decision.FailedAttempts[0].Reason)
+
+		response := w.Body.String()
+		assert.Contains(t, response, `"content":"Hello"`)
+		assert.True(t, strings.HasSuffix(strings.TrimSpace(response), "data: [DONE]"))
+		assert.NotContains(t, response, StreamInterruptedCode)
+		matches := ids.FindAllStringSubmatch(response, -1)
+		require.Len(t, matches, 4)
+		for _, match := range matches {
+			assert.Equal(t, matches[0][1], match[1], "the restarted stream keeps the chunk id")
+		}
+	})
+
+	t.Run("ends with an error event once content was sent", func(t *testing.T) {
+		client := NewAPIClient(map[string]string{"openai": streamingVendor(t, false, roleChunk, contentChunk).URL})
+		w, r, err := send(t, client)
+		var streamErr *StreamInterruptedError
+		require.ErrorAs(t, err, &streamErr)
+		assert.True(t, streamErr.ContentSent)
+		assert.False(t, streamErr.IsRetriable())
+		assert.False(t, shouldFailOver(err))
+
+		writeUpstreamError(r.Context(), w, err, "openai")
+		assert.Equal(t, http.StatusOK, w.Code)
+		response := w.Body.String()
+		assert.Contains(t, response, `"content":"Hello"`)
+		assert.Contains(t, response, `"code":"stream_interrupted"`)
+		assert.NotContains(t, response, "[DONE]")
+	})
+
+	t.Run("completed streams are not interrupted", func(t *testing.T) {
+		client := NewAPIClient(map[string]string{"openai": streamingVendor(t, true, roleChunk, contentChunk, finishChunk).URL})
+		_, r, err := send(t, client)
+		require.NoError(t, err)
+		assert.True(t, streamStarted(r.Context()))
+		assert.False(t, streamStarted(context.Background()))
+	})
+}
@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/budget"
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+)
+
+// StreamSummaryEvent is the SSE event name of the summary sent after [DONE]
+const StreamSummaryEvent = "router.summary"
+
+// StreamSummary is the telemetry of a streamed completion, sent to the client once the stream ends
+type StreamSummary struct {
+	ID              string             `json:"id"`
+	RequestID       string             `json:"request_id,omitempty"`
+	Vendor          string             `json:"vendor"`
+	Model           string             `json:"model"`
+	OriginalModel   string             `json:"original_model"`
+	LatencyMs       int64              `json:"latency_ms"`
+	VendorLatencyMs int64              `json:"vendor_latency_ms"`
+	Usage           StreamSummaryUsage `json:"usage"`
+	Attempts        int                `json:"attempts"`
+	Retries         int                `json:"retries"`
+	Fallback        bool               `json:"fallback"`
+	// EstimatedCostUSD is omitted when the model has no pricing configured
+	EstimatedCostUSD *float64 `json:"estimated_cost_usd,omitempty"`
+
+	requestBody []byte
+	started     time.Time
+	decision    *routingDecision
+}
+
+// StreamSummaryUsage is the token usage of a streamed completion, as reported by the vendor
+// or estimated when the stream carries none
+type StreamSummaryUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	// Estimated is set when the router counted the tokens because the vendor reported none
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// newStreamSummary prepares the summary of a stream served by selection, or returns nil when
+// the summary extension is not enabled for the request. vendorLatency is how long the vendor
+// took to start responding
+func newStreamSummary(r *http.Request, selection *selector.VendorSelection, conversationID, originalModel string, vendorLatency time.Duration, requestBody []byte) *StreamSummary {
+	if !enabledExtensions(r)[ExtensionSummary] {
+		return nil
+	}
+	summary := &StreamSummary{
+		ID:              conversationID,
+		Vendor:          selection.Vendor,
+		Model:           selection.Model,
+		OriginalModel:   originalModel,
+		VendorLatencyMs: vendorLatency.Milliseconds(),
+		requestBody:     requestBody,
+		started:         requestStarted(r, vendorLatency),
+	}
+	if decision := routingDecisionFromContext(r.Context()); decision != nil {
+		summary.RequestID = decision.RequestID
+		summary.decision = decision
+	}
+	return summary
+}
+
+// requestStarted is when routing of the request began, or when its vendor request was sent
+// if the request carries no routing decision
+func requestStarted(r *http.Request, vendorLatency time.Duration) time.Time {
+	if decision := routingDecisionFromContext(r.Context()); decision != nil && !decision.started.IsZero() {
+		return decision.started
+	}
+	return time.Now().Add(-vendorLatency)
+}
+
+// event completes the summary with the usage streamed by sp and renders it as an SSE event
+func (s *StreamSummary) event(sp *StreamProcessor) ([]byte, error) {
+	s.LatencyMs = time.Since(s.started).Milliseconds()
+	promptTokens, completionTokens, estimated := sp.Usage(s.requestBody)
+	s.Usage = StreamSummaryUsage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+		Estimated:        estimated,
+	}
+	s.Attempts = 1
+	if s.decision != nil && s.decision.Attempts > 0 {
+		s.Attempts = s.decision.Attempts
+		s.Fallback = s.decision.FallbackVendor != ""
+	}
+	s.Retries = s.Attempts - 1
+	if cost, ok := budget.Default().Cost(s.Vendor, s.Model, promptTokens, completionTokens); ok {
+		s.EstimatedCostUSD = &cost
+	}
+
+	data, err := codec.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return []byte("event: " + StreamSummaryEvent + "\ndata: " + string(data) + "\n\n"), nil
+}
+
+// writeStreamSummary sends the summary event of sp, if enabled, once [DONE] has been written
+func writeStreamSummary(w http.ResponseWriter, sp *StreamProcessor, flusher http.Flusher) error {
+	if sp.Summary == nil {
+		return nil
+	}
+	event, err := sp.Summary.event(sp)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(event); err != nil {
+		return err
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamSummary(t *testing.T) {
+	upstream := `data: {"choices":[{"index":0,"delta":{"content":"Hello"}}]}` + "\n\n" +
+		`data: {"choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":3}}` + "\n\n" +
+		"data: [DONE]\n\n"
+	selection := &selector.VendorSelection{Vendor: "openai", Model: "gpt-4o"}
+	requestBody := []byte(`{"messages":[{"role":"user","content":"Hi"}],"stream":true}`)
+
+	tests := []struct {
+		name        string
+		env         string
+		wantSummary bool
+	}{
+		{name: "disabled by default", env: ""},
+		{name: "other extensions only", env: "route,attempts"},
+		{name: "enabled", env: "summary", wantSummary: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("RESPONSE_EXTENSIONS", tt.env)
+			r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+			decision := newRoutingDecision(r, selection, "my-model", nil, nil)
+			decision.Attempts = 2
+			decision.FallbackVendor = "openai"
+			r = r.WithContext(withRoutingDecision(r.Context(), decision))
+
+			sp := NewStreamProcessor("chatcmpl-test", 1700000000, "fp_test", "openai", "my-model")
+			sp.Summary = newStreamSummary(r, selection, "chatcmpl-test", "my-model", 40*time.Millisecond, requestBody)
+			rec := httptest.NewRecorder()
+			require.NoError(t, (&APIClient{}).processStreamingResponse(rec, bufio.NewReader(strings.NewReader(upstream)), sp, rec, nil))
+
+			body := rec.Body.String()
+			if !tt.wantSummary {
+				assert.Nil(t, sp.Summary)
+				assert.True(t, strings.HasSuffix(body, "data: [DONE]\n\n"))
+				assert.NotContains(t, body, StreamSummaryEvent)
+				return
+			}
+
+			_, event, found := strings.Cut(body, "data: [DONE]\n\n")
+			require.True(t, found)
+			require.True(t, strings.HasPrefix(event, "event: router.summary\ndata: "), "the summary follows [DONE]")
+
+			var summary map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(event), "event: router.summary\ndata: ")), &summary))
+			assert.Equal(t, "chatcmpl-test", summary["id"])
+			assert.Equal(t, "openai", summary["vendor"])
+			assert.Equal(t, "gpt-4o", summary["model"])
+			assert.Equal(t, "my-model", summary["original_model"])
+			assert.Equal(t, float64(40), summary["vendor_latency_ms"])
+			assert.GreaterOrEqual(t, summary["latency_ms"], float64(0))
+			assert.Equal(t, map[string]interface{}{"prompt_tokens": float64(12), "completion_tokens": float64(3), "total_tokens": float64(15)}, summary["usage"])
+			assert.Equal(t, float64(2), summary["attempts"])
+			assert.Equal(t, float64(1), summary["retries"])
+			assert.Equal(t, true, summary["fallback"])
+			assert.NotContains(t, summary, "estimated_cost_usd", "unpriced models have no cost estimate")
+		})
+	}
+}
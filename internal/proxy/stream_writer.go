@@ -0,0 +1,223 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Policies applied when a client's output buffer is full
+const (
+	// SlowClientDisconnect ends the stream as soon as the buffer overflows
+	SlowClientDisconnect = "disconnect"
+	// SlowClientPause stops reading from the vendor until the client drains the buffer
+	SlowClientPause = "pause"
+)
+
+// ErrSlowClient is returned when a client reads its stream too slowly to keep up
+var ErrSlowClient = errors.New("client is reading the stream too slowly")
+
+// StreamBackpressure bounds how much of a stream may be waiting on a slow client
+type StreamBackpressure struct {
+	// WriteTimeout is how long a single chunk may take to reach the client; zero disables backpressure
+	WriteTimeout time.Duration
+	// BufferBytes is how many bytes may be queued for the client before Policy applies
+	BufferBytes int
+	// Policy is SlowClientDisconnect or SlowClientPause
+	Policy string
+}
+
+// StreamBackpressureFromEnv reads STREAM_WRITE_TIMEOUT (seconds), STREAM_BUFFER_BYTES and STREAM_SLOW_CLIENT_POLICY
+func StreamBackpressureFromEnv() StreamBackpressure {
+	policy := strings.ToLower(utils.GetEnvString("STREAM_SLOW_CLIENT_POLICY", SlowClientDisconnect))
+	if policy != SlowClientPause {
+		policy = SlowClientDisconnect
+	}
+	return StreamBackpressure{
+		WriteTimeout: utils.GetEnvDuration("STREAM_WRITE_TIMEOUT", 0),
+		BufferBytes:  utils.GetEnvInt("STREAM_BUFFER_BYTES", 256*1024),
+		Policy:       policy,
+	}
+}
+
+// Enabled reports whether streams are written with backpressure
+func (b StreamBackpressure) Enabled() bool {
+	return b.WriteTimeout > 0
+}
+
+// streamWriter decouples reading from the vendor from writing to the client
+// Chunks are queued up to a byte limit and written by a separate goroutine, each
+// under its own write deadline, so a stalled client can neither block upstream
+// reads indefinitely nor make the router buffer without bound
+type streamWriter struct {
+	http.ResponseWriter
+	ctx        context.Context
+	flusher    http.Flusher
+	controller *http.ResponseController
+	limits     StreamBackpressure
+	vendor     string
+	metrics    *monitoring.SlowClientMetrics
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   [][]byte
+	pending int
+	closed  bool
+	err     error
+	done    chan struct{}
+}
+
+// newStreamWriter starts writing queued chunks to w
+func newStreamWriter(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, limits StreamBackpressure, vendor string) *streamWriter {
+	sw := &streamWriter{
+		ResponseWriter: w,
+		ctx:            ctx,
+		flusher:        flusher,
+		controller:     http.NewResponseController(w),
+		limits:         limits,
+		vendor:         vendor,
+		metrics:        monitoring.DefaultSlowClientMetrics(),
+		done:           make(chan struct{}),
+	}
+	sw.cond = sync.NewCond(&sw.mu)
+	go sw.run()
+	return sw
+}
+
+// Write queues a chunk for the client, applying the slow-client policy when the buffer is full
+// A chunk larger than the whole buffer is still accepted once the buffer is empty
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	chunk := append([]byte(nil), p...)
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if sw.err != nil {
+		return 0, sw.err
+	}
+	if sw.overflows(len(chunk)) {
+		if sw.limits.Policy != SlowClientPause {
+			sw.fail(fmt.Errorf("%w: %d bytes waiting to be written", ErrSlowClient, sw.pending), monitoring.SlowClientBufferFull)
+			return 0, sw.err
+		}
+		start := time.Now()
+		for sw.err == nil && sw.overflows(len(chunk)) {
+			sw.cond.Wait()
+		}
+		sw.record(monitoring.SlowClientPaused, time.Since(start))
+		if sw.err != nil {
+			return 0, sw.err
+		}
+	}
+
+	sw.queue = append(sw.queue, chunk)
+	sw.pending += len(chunk)
+	sw.cond.Broadcast()
+	return len(p), nil
+}
+
+// Flush is a no-op; every chunk is flushed as soon as it is written
+func (sw *streamWriter) Flush() {}
+
+// Close waits for queued chunks to be written and returns the first write error
+func (sw *streamWriter) Close() error {
+	sw.mu.Lock()
+	sw.closed = true
+	sw.cond.Broadcast()
+	sw.mu.Unlock()
+
+	<-sw.done
+	// Clear the deadline so it cannot affect a later request on a kept-alive connection
+	_ = sw.controller.SetWriteDeadline(time.Time{})
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	return sw.err
+}
+
+// overflows reports whether queueing size more bytes would exceed the buffer; callers hold mu
+func (sw *streamWriter) overflows(size int) bool {
+	return sw.pending > 0 && sw.pending+size > sw.limits.BufferBytes
+}
+
+// run writes queued chunks until the writer is closed and drained, or a write fails
+func (sw *streamWriter) run() {
+	defer close(sw.done)
+	for {
+		sw.mu.Lock()
+		for len(sw.queue) == 0 && !sw.closed && sw.err == nil {
+			sw.cond.Wait()
+		}
+		if sw.err != nil || len(sw.queue) == 0 {
+			sw.mu.Unlock()
+			return
+		}
+		chunk := sw.queue[0]
+		sw.queue = sw.queue[1:]
+		sw.mu.Unlock()
+
+		err := sw.writeChunk(chunk)
+
+		sw.mu.Lock()
+		sw.pending -= len(chunk)
+		if err != nil && sw.err == nil {
+			event := ""
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				event = monitoring.SlowClientWriteTimeout
+				err = fmt.Errorf("%w: chunk not written within %s", ErrSlowClient, sw.limits.WriteTimeout)
+			}
+			sw.fail(err, event)
+		}
+		sw.cond.Broadcast()
+		sw.mu.Unlock()
+	}
+}
+
+// writeChunk writes and flushes one chunk under the write deadline
+// Writers that cannot set deadlines, such as test recorders, are written without one
+func (sw *streamWriter) writeChunk(chunk []byte) error {
+	err := sw.controller.SetWriteDeadline(time.Now().Add(sw.limits.WriteTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := sw.ResponseWriter.Write(chunk); err != nil {
+		return err
+	}
+	if sw.flusher != nil {
+		sw.flusher.Flush()
+	}
+	return nil
+}
+
+// fail stops the stream with err, recording event when it is a slow-client event; callers hold mu
+func (sw *streamWriter) fail(err error, event string) {
+	sw.err = err
+	sw.cond.Broadcast()
+	if event != "" {
+		sw.record(event, 0)
+	}
+}
+
+// record counts and logs a slow-client event
+func (sw *streamWriter) record(event string, paused time.Duration) {
+	sw.metrics.Record(sw.vendor, event, paused)
+	logger.Warn(sw.ctx, "Slow streaming client",
+		"vendor", sw.vendor,
+		"event", event,
+		"pending_bytes", sw.pending,
+		"buffer_bytes", sw.limits.BufferBytes,
+		"paused_ms", paused.Milliseconds(),
+		"policy", sw.limits.Policy,
+		"component", "APIClient",
+		"stage", "StreamBackpressure",
+	)
+}
@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stalledWriter is a client connection whose writes block until released
+type stalledWriter struct {
+	*httptest.ResponseRecorder
+	release chan struct{}
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+func newStalledWriter() *stalledWriter {
+	return &stalledWriter{ResponseRecorder: httptest.NewRecorder(), release: make(chan struct{})}
+}
+
+func (w *stalledWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.deadline.IsZero() && time.Now().After(w.deadline) {
+		return 0, fmt.Errorf("write tcp: %w", os.ErrDeadlineExceeded)
+	}
+	return w.ResponseRecorder.Write(p)
+}
+
+func (w *stalledWriter) SetWriteDeadline(deadline time.Time) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.deadline = deadline
+	return nil
+}
+
+func newTestStreamWriter(w http.ResponseWriter, limits StreamBackpressure) (*streamWriter, *monitoring.SlowClientMetrics) {
+	sw := newStreamWriter(context.Background(), w, nil, limits, "openai")
+	metrics := monitoring.NewSlowClientMetrics()
+	sw.metrics = metrics
+	return sw, metrics
+}
+
+func TestStreamWriter_WritesInOrder(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	sw, metrics := newTestStreamWriter(recorder, StreamBackpressure{WriteTimeout: time.Second, BufferBytes: 1024})
+
+	for _, chunk := range []string{"data: 1\n\n", "data: 2\n\n", "data: [DONE]\n\n"} {
+		_, err := sw.Write([]byte(chunk))
+		require.NoError(t, err)
+	}
+	require.NoError(t, sw.Close())
+
+	assert.Equal(t, "data: 1\n\ndata: 2\n\ndata: [DONE]\n\n", recorder.Body.String())
+	assert.Empty(t, metrics.Snapshot())
+}
+
+func TestStreamWriter_DisconnectWhenBufferFull(t *testing.T) {
+	client := newStalledWriter()
+	sw, metrics := newTestStreamWriter(client, StreamBackpressure{WriteTimeout: time.Minute, BufferBytes: 16, Policy: SlowClientDisconnect})
+
+	_, err := sw.Write([]byte("data: 0123456\n\n"))
+	require.NoError(t, err)
+	_, err = sw.Write([]byte("data: 0123456\n\n"))
+	assert.ErrorIs(t, err, ErrSlowClient)
+
+	close(client.release)
+	assert.ErrorIs(t, sw.Close(), ErrSlowClient)
+
+	snapshot := metrics.Snapshot()
+	require.Len(t, snapshot, 1)
+	assert.Equal(t, int64(1), snapshot[0].BufferFullDisconnects)
+}
+
+func TestStreamWriter_PauseUntilClientDrains(t *testing.T) {
+	client := newStalledWriter()
+	sw, metrics := newTestStreamWriter(client, StreamBackpressure{WriteTimeout: time.Minute, BufferBytes: 16, Policy: SlowClientPause})
+
+	_, err := sw.Write([]byte("data: first\n\n"))
+	require.NoError(t, err)
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := sw.Write([]byte("data: second\n\n"))
+		written <- err
+	}()
+
+	select {
+	case <-written:
+		t.Fatal("write should wait while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(client.release)
+	require.NoError(t, <-written)
+	require.NoError(t, sw.Close())
+
+	assert.Equal(t, "data: first\n\ndata: second\n\n", client.Body.String())
+	snapshot := metrics.Snapshot()
+	require.Len(t, snapshot, 1)
+	assert.Equal(t, int64(1), snapshot[0].Pauses)
+}
+
+func TestStreamWriter_WriteTimeout(t *testing.T) {
+	client := newStalledWriter()
+	sw, metrics := newTestStreamWriter(client, StreamBackpressure{WriteTimeout: 10 * time.Millisecond, BufferBytes: 1024})
+
+	_, err := sw.Write([]byte("data: late\n\n"))
+	require.NoError(t, err)
+
+	time.Sleep(30 * time.Millisecond)
+	close(client.release)
+
+	assert.ErrorIs(t, sw.Close(), ErrSlowClient)
+	snapshot := metrics.Snapshot()
+	require.Len(t, snapshot, 1)
+	assert.Equal(t, int64(1), snapshot[0].WriteTimeouts)
+}
+
+func TestStreamBackpressureFromEnv(t *testing.T) {
+	t.Setenv("STREAM_WRITE_TIMEOUT", "15")
+	t.Setenv("STREAM_BUFFER_BYTES", "4096")
+	t.Setenv("STREAM_SLOW_CLIENT_POLICY", "Pause")
+
+	limits := StreamBackpressureFromEnv()
+	assert.True(t, limits.Enabled())
+	assert.Equal(t, 15*time.Second, limits.WriteTimeout)
+	assert.Equal(t, 4096, limits.BufferBytes)
+	assert.Equal(t, SlowClientPause, limits.Policy)
+
+	t.Setenv("STREAM_SLOW_CLIENT_POLICY", "drop")
+	assert.Equal(t, SlowClientDisconnect, StreamBackpressureFromEnv().Policy, "unknown policies fall back to disconnect")
+}
@@ -0,0 +1,235 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// HeaderRequestTimeout lets a request shorten the total time its vendor may take, in seconds
+const HeaderRequestTimeout = "X-Request-Timeout"
+
+// Default vendor request timeouts, overridable with CLIENT_CONNECT_TIMEOUT and CLIENT_TIMEOUT
+// The first byte is not bounded by default, only by the total
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultTotalTimeout   = 1200 * time.Second
+)
+
+// Timeout phases, as reported by TimeoutError
+const (
+	TimeoutPhaseConnect   = "connect"
+	TimeoutPhaseFirstByte = "first_byte"
+	TimeoutPhaseTotal     = "total"
+)
+
+// Timeouts bound the phases of a vendor request; a zero phase is unbounded
+type Timeouts struct {
+	// Connect bounds dialing the vendor
+	Connect time.Duration
+	// FirstByte bounds waiting for the response headers once the request is sent
+	FirstByte time.Duration
+	// Total bounds the whole request, including reading or streaming the response
+	Total time.Duration
+}
+
+// TimeoutsFromEnv reads the default vendor request timeouts: CLIENT_CONNECT_TIMEOUT,
+// CLIENT_FIRST_BYTE_TIMEOUT and CLIENT_TIMEOUT, in seconds
+func TimeoutsFromEnv() Timeouts {
+	return Timeouts{
+		Connect:   utils.GetEnvDuration("CLIENT_CONNECT_TIMEOUT", DefaultConnectTimeout),
+		FirstByte: utils.GetEnvDuration("CLIENT_FIRST_BYTE_TIMEOUT", 0),
+		Total:     utils.GetEnvDuration("CLIENT_TIMEOUT", DefaultTotalTimeout),
+	}
+}
+
+// ForModel returns t with the phases the model's timeouts set replacing its own
+func (t Timeouts) ForModel(model *config.VendorModel) Timeouts {
+	if model == nil || model.Timeouts == nil {
+		return t
+	}
+	if seconds := model.Timeouts.ConnectSeconds; seconds > 0 {
+		t.Connect = time.Duration(seconds) * time.Second
+	}
+	if seconds := model.Timeouts.FirstByteSeconds; seconds > 0 {
+		t.FirstByte = time.Duration(seconds) * time.Second
+	}
+	if seconds := model.Timeouts.TotalSeconds; seconds > 0 {
+		t.Total = time.Duration(seconds) * time.Second
+	}
+	return t
+}
+
+// Shorten returns t with its total bounded by limit as well; a zero limit changes nothing
+func (t Timeouts) Shorten(limit time.Duration) Timeouts {
+	if limit > 0 && (t.Total <= 0 || limit < t.Total) {
+		t.Total = limit
+	}
+	return t
+}
+
+// TimeoutError reports a vendor request that ran out of time in one of its phases
+type TimeoutError struct {
+	Phase string
+	Limit time.Duration
+}
+
+// Error implements the error interface
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("vendor %s timeout after %s", strings.ReplaceAll(e.Phase, "_", " "), e.Limit)
+}
+
+// Timeout marks the error as a timeout for net.Error checks
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// parseRequestTimeout reads the X-Request-Timeout header: a positive number of seconds, or
+// zero when the header is absent
+func parseRequestTimeout(r *http.Request) (time.Duration, error) {
+	value := strings.TrimSpace(r.Header.Get(HeaderRequestTimeout))
+	if value == "" {
+		return 0, nil
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("%s must be a positive number of seconds, got %q", HeaderRequestTimeout, value)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// requestTimeoutKey holds the X-Request-Timeout of a request in its context
+type requestTimeoutKey struct{}
+
+// withRequestTimeout returns ctx carrying the request's X-Request-Timeout
+func withRequestTimeout(ctx context.Context, limit time.Duration) context.Context {
+	if limit <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, requestTimeoutKey{}, limit)
+}
+
+// requestTimeoutFromContext returns the X-Request-Timeout carried by ctx, or zero
+func requestTimeoutFromContext(ctx context.Context) time.Duration {
+	limit, _ := ctx.Value(requestTimeoutKey{}).(time.Duration)
+	return limit
+}
+
+// timeoutsKey holds the timeouts of a vendor request in its context
+type timeoutsKey struct{}
+
+// withTimeouts returns ctx carrying the timeouts for vendor requests made with it
+func withTimeouts(ctx context.Context, timeouts Timeouts) context.Context {
+	return context.WithValue(ctx, timeoutsKey{}, timeouts)
+}
+
+// connectTimeoutKey holds the connect timeout of a vendor request for the dialer
+type connectTimeoutKey struct{}
+
+// errFirstByteTimeout cancels a request whose response headers are late
+var errFirstByteTimeout = errors.New("first byte timeout")
+
+// timeoutTransport enforces Timeouts on vendor requests: those carried by the request's
+// context, or its defaults
+type timeoutTransport struct {
+	next     http.RoundTripper
+	defaults Timeouts
+}
+
+// newTimeoutTransport returns a transport enforcing defaults, or the timeouts requests
+// carry in their context
+func newTimeoutTransport(defaults Timeouts) *timeoutTransport {
+	dialer := &net.Dialer{KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		limit, _ := ctx.Value(connectTimeoutKey{}).(time.Duration)
+		if limit <= 0 {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		dialCtx, cancel := context.WithTimeout(ctx, limit)
+		defer cancel()
+		conn, err := dialer.DialContext(dialCtx, network, addr)
+		if err != nil && errors.Is(dialCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return nil, &TimeoutError{Phase: TimeoutPhaseConnect, Limit: limit}
+		}
+		return conn, err
+	}
+	return &timeoutTransport{next: transport, defaults: defaults}
+}
+
+// RoundTrip sends req within its connect, first byte and total timeouts. The total timeout
+// keeps running while the response body is read, until it is closed
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timeouts, ok := req.Context().Value(timeoutsKey{}).(Timeouts)
+	if !ok {
+		timeouts = t.defaults
+	}
+
+	ctx, cancelTotal := req.Context(), context.CancelFunc(func() {})
+	if timeouts.Total > 0 {
+		ctx, cancelTotal = context.WithTimeout(ctx, timeouts.Total)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	ctx = context.WithValue(ctx, connectTimeoutKey{}, timeouts.Connect)
+	release := func() {
+		cancel(nil)
+		cancelTotal()
+	}
+
+	var firstByte *time.Timer
+	if timeouts.FirstByte > 0 {
+		firstByte = time.AfterFunc(timeouts.FirstByte, func() { cancel(errFirstByteTimeout) })
+	}
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if firstByte != nil {
+		firstByte.Stop()
+	}
+	if err != nil {
+		release()
+		var timeoutErr *TimeoutError
+		switch {
+		case errors.As(err, &timeoutErr):
+			return nil, timeoutErr
+		case errors.Is(context.Cause(ctx), errFirstByteTimeout):
+			return nil, &TimeoutError{Phase: TimeoutPhaseFirstByte, Limit: timeouts.FirstByte}
+		case errors.Is(ctx.Err(), context.DeadlineExceeded) && req.Context().Err() == nil:
+			return nil, &TimeoutError{Phase: TimeoutPhaseTotal, Limit: timeouts.Total}
+		}
+		return nil, err
+	}
+	resp.Body = &timeoutBody{ReadCloser: resp.Body, ctx: ctx, release: release, limit: timeouts.Total}
+	return resp, nil
+}
+
+// timeoutBody is a response body read within the total timeout of its request
+type timeoutBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	release func()
+	limit   time.Duration
+}
+
+// Read reads the body, reporting a TimeoutError once the total timeout has passed
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && errors.Is(b.ctx.Err(), context.DeadlineExceeded) {
+		return n, &TimeoutError{Phase: TimeoutPhaseTotal, Limit: b.limit}
+	}
+	return n, err
+}
+
+// Close closes the body and releases the request's timers
+func (b *timeoutBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeouts_ForModelAndShorten(t *testing.T) {
+	defaults := Timeouts{Connect: 10 * time.Second, Total: 1200 * time.Second}
+
+	model := &config.VendorModel{Vendor: "openai", Model: "o1", Timeouts: &config.ModelTimeouts{FirstByteSeconds: 90, TotalSeconds: 1800}}
+	assert.Equal(t, Timeouts{Connect: 10 * time.Second, FirstByte: 90 * time.Second, Total: 1800 * time.Second}, defaults.ForModel(model))
+	assert.Equal(t, defaults, defaults.ForModel(&config.VendorModel{Vendor: "openai", Model: "gpt-4o"}))
+
+	assert.Equal(t, 30*time.Second, defaults.Shorten(30*time.Second).Total)
+	assert.Equal(t, 1200*time.Second, defaults.Shorten(3600*time.Second).Total, "a request cannot lengthen the total")
+	assert.Equal(t, 1200*time.Second, defaults.Shorten(0).Total)
+}
+
+func TestParseRequestTimeout(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"", 0, false},
+		{"30", 30 * time.Second, false},
+		{"2.5", 2500 * time.Millisecond, false},
+		{"0", 0, true},
+		{"-5", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			if tt.value != "" {
+				r.Header.Set(HeaderRequestTimeout, tt.value)
+			}
+			limit, err := parseRequestTimeout(r)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, limit)
+		})
+	}
+}
+
+func TestTimeoutTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-headers" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		if r.URL.Path == "/slow-body" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		_, _ = io.WriteString(w, "done")
+	}))
+	defer server.Close()
+
+	send := func(path string, timeouts Timeouts) (string, error) {
+		client := &http.Client{Transport: newTimeoutTransport(Timeouts{})}
+		req, err := http.NewRequestWithContext(withTimeouts(context.Background(), timeouts), http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	t.Run("within the timeouts", func(t *testing.T) {
+		body, err := send("/slow-body", Timeouts{FirstByte: time.Second, Total: time.Second})
+		require.NoError(t, err)
+		assert.Equal(t, "done", body)
+	})
+
+	t.Run("first byte timeout", func(t *testing.T) {
+		_, err := send("/slow-headers", Timeouts{FirstByte: 50 * time.Millisecond, Total: time.Second})
+		var timeoutErr *TimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		assert.Equal(t, TimeoutPhaseFirstByte, timeoutErr.Phase)
+	})
+
+	t.Run("first byte does not bound the body", func(t *testing.T) {
+		body, err := send("/slow-body", Timeouts{FirstByte: 50 * time.Millisecond, Total: time.Second})
+		require.NoError(t, err)
+		assert.Equal(t, "done", body)
+	})
+
+	t.Run("total timeout while reading the body", func(t *testing.T) {
+		_, err := send("/slow-body", Timeouts{Total: 100 * time.Millisecond})
+		var timeoutErr *TimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		assert.Equal(t, TimeoutPhaseTotal, timeoutErr.Phase)
+	})
+}
+
+func TestAPIClient_TimeoutsFor(t *testing.T) {
+	client := &APIClient{timeouts: Timeouts{Connect: 10 * time.Second, Total: 1200 * time.Second}}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o"},
+		{Vendor: "openai", Model: "o1", Timeouts: &config.ModelTimeouts{TotalSeconds: 1800}},
+	}
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	r = r.WithContext(context.WithValue(r.Context(), "vendor_models", models))
+
+	assert.Equal(t, 1200*time.Second, client.timeoutsFor(r, &selector.VendorSelection{Vendor: "openai", Model: "gpt-4o"}).Total)
+	assert.Equal(t, 1800*time.Second, client.timeoutsFor(r, &selector.VendorSelection{Vendor: "openai", Model: "o1"}).Total)
+
+	r = r.WithContext(withRequestTimeout(r.Context(), 45*time.Second))
+	assert.Equal(t, 45*time.Second, client.timeoutsFor(r, &selector.VendorSelection{Vendor: "openai", Model: "o1"}).Total)
+}
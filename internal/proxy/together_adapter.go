@@ -0,0 +1,153 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+)
+
+// togetherChoiceFields are fields Together adds to choices that OpenAI responses lack
+var togetherChoiceFields = []string{"text", "seed"}
+
+// TogetherAdapter handles the quirks of Together AI's OpenAI-compatible API
+// Model IDs are organization-qualified ("meta-llama/Llama-3.3-70B-Instruct-Turbo") and are
+// sent as configured. Together ignores max_completion_tokens, reports some completions
+// with finish reason "eos", and adds text, seed, token_id and prompt fields OpenAI
+// clients do not expect, which are translated or dropped
+type TogetherAdapter struct {
+	openAICompatibleAdapter
+}
+
+func init() {
+	RegisterVendorAdapter("together", func() VendorAdapter { return NewTogetherAdapter() })
+}
+
+// NewTogetherAdapter creates a Together AI adapter
+func NewTogetherAdapter() *TogetherAdapter {
+	return &TogetherAdapter{}
+}
+
+// KeptRequestFields passes the client's output limit through validation
+func (a *TogetherAdapter) KeptRequestFields() []string {
+	return []string{"max_tokens", "max_completion_tokens"}
+}
+
+// TranslateRequest sends max_completion_tokens as the max_tokens Together understands
+func (a *TogetherAdapter) TranslateRequest(body []byte) ([]byte, error) {
+	if !bytes.Contains(body, []byte(`"max_completion_tokens"`)) {
+		return body, nil
+	}
+
+	var request map[string]interface{}
+	if err := codec.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+	if maxTokens, ok := request["max_completion_tokens"]; ok {
+		if _, set := request["max_tokens"]; !set {
+			request["max_tokens"] = maxTokens
+		}
+		delete(request, "max_completion_tokens")
+	}
+	return codec.Marshal(request)
+}
+
+// TranslateResponse removes Together-specific fields and maps its finish reasons
+func (a *TogetherAdapter) TranslateResponse(body []byte) ([]byte, error) {
+	var response map[string]interface{}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	cleanTogetherPayload(response)
+	return codec.Marshal(response)
+}
+
+// TranslateStream removes Together-specific fields from streamed chunks
+func (a *TogetherAdapter) TranslateStream(r io.Reader) io.Reader {
+	return newChunkRewriter(r, isTogetherChunk, cleanTogetherPayload)
+}
+
+// isTogetherChunk reports whether an SSE payload is a JSON chunk rather than [DONE]
+func isTogetherChunk(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// cleanTogetherPayload rewrites a Together response or chunk into the OpenAI shape
+func cleanTogetherPayload(payload map[string]interface{}) {
+	delete(payload, "prompt")
+	if usage, ok := payload["usage"]; ok && usage == nil {
+		delete(payload, "usage")
+	}
+
+	choices, _ := payload["choices"].([]interface{})
+	for _, item := range choices {
+		choice, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range togetherChoiceFields {
+			delete(choice, field)
+		}
+		if reason, _ := choice["finish_reason"].(string); reason == "eos" {
+			choice["finish_reason"] = "stop"
+		}
+		for _, key := range []string{"delta", "message"} {
+			message, ok := choice[key].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			delete(message, "token_id")
+			// Together sends "tool_calls": null or [] on messages that call no tools
+			if toolCalls, _ := message["tool_calls"].([]interface{}); len(toolCalls) == 0 {
+				delete(message, "tool_calls")
+			}
+		}
+	}
+}
+
+// SpeechFormat maps OpenAI speech formats to Together's, which calls raw PCM "raw"
+func (a *TogetherAdapter) SpeechFormat(format string) (string, bool) {
+	switch format {
+	case "mp3", "wav":
+		return format, true
+	case "pcm":
+		return "raw", true
+	}
+	return "", false
+}
+
+// ParseError replaces the generic message with the one from Together's error body
+// Together returns OpenAI-style error objects, bare error strings or a top-level message
+// depending on the endpoint. A 404 means the model is not available to the key and a 402
+// that the account is out of credit
+func (a *TogetherAdapter) ParseError(apiErr *VendorAPIError, responseBody []byte) {
+	switch apiErr.StatusCode {
+	case http.StatusNotFound:
+		apiErr.ErrorType = "model_not_found"
+		apiErr.Retriable = false
+	case http.StatusPaymentRequired:
+		apiErr.ErrorType = "insufficient_quota"
+		apiErr.Retriable = false
+	}
+
+	var body struct {
+		Error   interface{} `json:"error"`
+		Message string      `json:"message"`
+	}
+	if codec.Unmarshal(responseBody, &body) != nil {
+		return
+	}
+	switch e := body.Error.(type) {
+	case string:
+		apiErr.Message = e
+	case map[string]interface{}:
+		if message, _ := e["message"].(string); message != "" {
+			apiErr.Message = message
+		}
+	default:
+		if body.Message != "" {
+			apiErr.Message = body.Message
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTogetherAdapter_TranslateRequest(t *testing.T) {
+	adapter := NewTogetherAdapter()
+
+	body := `{"model":"meta-llama/Llama-3.3-70B-Instruct-Turbo","messages":[],"max_completion_tokens":128}`
+	translated, err := adapter.TranslateRequest([]byte(body))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"model":"meta-llama/Llama-3.3-70B-Instruct-Turbo","messages":[],"max_tokens":128}`, string(translated))
+
+	unchanged := `{"model":"Qwen/Qwen2.5-72B-Instruct-Turbo","messages":[],"max_tokens":64}`
+	translated, err = adapter.TranslateRequest([]byte(unchanged))
+	require.NoError(t, err)
+	assert.Equal(t, unchanged, string(translated))
+}
+
+func TestTogetherAdapter_MaxTokensSurviveValidation(t *testing.T) {
+	body := `{"model":"meta-llama/Llama-3.3-70B-Instruct-Turbo","messages":[{"role":"user","content":"Hi"}],"max_completion_tokens":64}`
+	validated, _, err := validator.ValidateAndModifyRequest([]byte(body), "meta-llama/Llama-3.3-70B-Instruct-Turbo", keptRequestFields("together")...)
+	require.NoError(t, err)
+
+	translated, err := NewTogetherAdapter().TranslateRequest(validated)
+	require.NoError(t, err)
+
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(translated, &request))
+	assert.Equal(t, float64(64), request["max_tokens"])
+	assert.NotContains(t, request, "max_completion_tokens")
+}
+
+func TestTogetherAdapter_TranslateResponse(t *testing.T) {
+	body := `{
+		"id": "8f3a", "object": "chat.completion", "created": 1700000000,
+		"model": "meta-llama/Llama-3.3-70B-Instruct-Turbo",
+		"prompt": [],
+		"choices": [{
+			"index": 0, "text": "Hi", "seed": 123, "finish_reason": "eos",
+			"message": {"role": "assistant", "content": "Hi", "tool_calls": []}
+		}],
+		"usage": {"prompt_tokens": 3, "completion_tokens": 1, "total_tokens": 4}
+	}`
+
+	translated, err := NewTogetherAdapter().TranslateResponse([]byte(body))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "8f3a", "object": "chat.completion", "created": 1700000000,
+		"model": "meta-llama/Llama-3.3-70B-Instruct-Turbo",
+		"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "Hi"}}],
+		"usage": {"prompt_tokens": 3, "completion_tokens": 1, "total_tokens": 4}
+	}`, string(translated))
+}
+
+func TestTogetherAdapter_TranslateStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"id":"8f3a","object":"chat.completion.chunk","choices":[{"index":0,"text":"Hi","seed":null,"finish_reason":null,"delta":{"token_id":13347,"role":"assistant","content":"Hi","tool_calls":null}}],"usage":null}`,
+		``,
+		`data: {"id":"8f3a","object":"chat.completion.chunk","choices":[{"index":0,"text":"","seed":42,"finish_reason":"eos","delta":{"token_id":2,"content":""}}],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`,
+		``,
+		`data: [DONE]`,
+		``,
+	}, "\n")
+
+	translated, err := io.ReadAll(NewTogetherAdapter().TranslateStream(strings.NewReader(stream)))
+	require.NoError(t, err)
+
+	events := strings.Split(strings.TrimSpace(string(translated)), "\n\n")
+	require.Len(t, events, 3)
+
+	var first, last map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(events[0], "data: ")), &first))
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(events[1], "data: ")), &last))
+	assert.NotContains(t, first, "usage")
+	assert.Equal(t, map[string]interface{}{
+		"index": float64(0), "finish_reason": nil, "delta": map[string]interface{}{"role": "assistant", "content": "Hi"},
+	}, first["choices"].([]interface{})[0])
+	assert.Equal(t, "stop", last["choices"].([]interface{})[0].(map[string]interface{})["finish_reason"])
+	assert.Contains(t, last, "usage")
+	assert.Equal(t, "data: [DONE]", events[2])
+}
+
+func TestParseVendorError_Together(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		body          string
+		expectedType  string
+		expectedMsg   string
+		expectedRetry bool
+	}{
+		{
+			name:         "OpenAI-style error object",
+			statusCode:   http.StatusBadRequest,
+			body:         `{"error":{"message":"Input validation error: inputs tokens + max_new_tokens must be <= 8193","type":"invalid_request_error","param":null,"code":null}}`,
+			expectedType: "invalid_request",
+			expectedMsg:  "Input validation error: inputs tokens + max_new_tokens must be <= 8193",
+		},
+		{
+			name:         "model not available",
+			statusCode:   http.StatusNotFound,
+			body:         `{"error":{"message":"Unable to access model meta-llama/Missing. Please visit https://api.together.ai/models to view the list of supported models.","type":"invalid_request_error","code":"model_not_available"}}`,
+			expectedType: "model_not_found",
+			expectedMsg:  "Unable to access model meta-llama/Missing. Please visit https://api.together.ai/models to view the list of supported models.",
+		},
+		{
+			name:         "bare error string",
+			statusCode:   http.StatusPaymentRequired,
+			body:         `{"error":"Credit limit exceeded"}`,
+			expectedType: "insufficient_quota",
+			expectedMsg:  "Credit limit exceeded",
+		},
+		{
+			name:          "top-level message",
+			statusCode:    http.StatusServiceUnavailable,
+			body:          `{"message":"The server is overloaded or not ready yet."}`,
+			expectedType:  "server_error",
+			expectedMsg:   "The server is overloaded or not ready yet.",
+			expectedRetry: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var apiErr *VendorAPIError
+			require.True(t, errors.As(ParseVendorError("together", tt.statusCode, []byte(tt.body)), &apiErr))
+			assert.Equal(t, tt.expectedType, apiErr.ErrorType)
+			assert.Equal(t, tt.expectedMsg, apiErr.Message)
+			assert.Equal(t, tt.expectedRetry, apiErr.Retriable)
+		})
+	}
+}
@@ -0,0 +1,555 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/events"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/reliability"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// ErrTranscriptionsUnsupported is returned when the selected vendor does not serve transcriptions
+var ErrTranscriptionsUnsupported = errors.New("vendor does not support audio transcriptions")
+
+// maxTranscriptionUpload caps the multipart body: the audio limit plus room for the other fields
+const maxTranscriptionUpload = 26 * 1024 * 1024
+
+// transcriptionResponseFormats are the response formats of the OpenAI transcriptions API
+var transcriptionResponseFormats = map[string]bool{
+	"json":         true,
+	"text":         true,
+	"srt":          true,
+	"verbose_json": true,
+	"vtt":          true,
+}
+
+// audioExtensions maps audio content types to the file extension vendors infer the format from
+var audioExtensions = map[string]string{
+	"audio/mpeg":  ".mp3",
+	"audio/mp3":   ".mp3",
+	"audio/wav":   ".wav",
+	"audio/wave":  ".wav",
+	"audio/x-wav": ".wav",
+	"audio/flac":  ".flac",
+	"audio/ogg":   ".ogg",
+	"audio/mp4":   ".m4a",
+	"audio/webm":  ".webm",
+}
+
+// TranscriptionRequest is a parsed audio transcription request
+type TranscriptionRequest struct {
+	File     []byte
+	Filename string
+	// Fields holds the form fields forwarded to the vendor as-is, such as language,
+	// prompt, temperature and timestamp_granularities[]
+	Fields         map[string][]string
+	ResponseFormat string
+	Stream         bool
+}
+
+// TranscriptionsClientInterface defines the interface for clients sending transcription requests
+type TranscriptionsClientInterface interface {
+	SendTranscriptionRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, request *TranscriptionRequest, originalModel string) error
+}
+
+// ProxyTranscriptionRequest parses a multipart transcription request, routes it to a vendor
+// serving one of the transcription models and forwards the response
+func ProxyTranscriptionRequest(w http.ResponseWriter, r *http.Request, creds []config.Credential, models []config.VendorModel, apiClient TranscriptionsClientInterface, modelSelector selector.Selector) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	start := time.Now()
+	ctx := logger.WithComponent(r.Context(), "proxy")
+
+	request, originalModel, err := ParseTranscriptionRequest(r, NewAudioProcessor())
+	if err != nil {
+		logger.Warn(logger.WithStage(ctx, "request_validation"), "Transcription request validation failed", "error", err.Error())
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	publishEvent(r, events.Event{Type: events.RequestReceived, OriginalModel: originalModel})
+
+	// Restrict the pool to what the client key's ACL permits
+	r, creds, models, ok := restrictEndpointPool(ctx, w, r, nil, originalModel, start, creds, models)
+	if !ok {
+		return
+	}
+
+	// Only vendors with a transcriptions API can take the request
+	models = transcriptionModels(models)
+	creds = filter.CredentialsForModels(creds, models)
+
+	r, selection, decision, ok := selectEndpointVendor(ctx, w, r, creds, models, modelSelector, originalModel, start)
+	if !ok {
+		return
+	}
+	publishEvent(r, events.Event{Type: events.RequestValidated, OriginalModel: originalModel, Vendor: selection.Vendor, Model: selection.Model})
+
+	ctx = context.WithValue(ctx, "vendor", selection.Vendor)
+	ctx = context.WithValue(ctx, "model", selection.Model)
+	logger.Info(logger.WithStage(ctx, "RequestProcessing"), "Proxying transcription request",
+		"original_model", originalModel,
+		"vendor", selection.Vendor,
+		"model", selection.Model,
+		"filename", request.Filename,
+		"audio_size_bytes", len(request.File),
+		"response_format", request.ResponseFormat,
+		"stream", request.Stream,
+	)
+
+	err = reliability.NewRetryExecutor(nil).ExecuteWithRetry(ctx, func() error {
+		decision.Attempts++
+		return apiClient.SendTranscriptionRequest(w, r, selection, request, originalModel)
+	})
+	if err != nil {
+		writeUpstreamError(ctx, w, err, selection.Vendor)
+	}
+	decision.Complete(err)
+	publishOutcome(r, decision, start, err)
+}
+
+// ParseTranscriptionRequest reads a multipart transcription request. The audio comes from
+// the "file" part or, when that is absent, is downloaded from the "file_url" field
+// Returns the request and the original model value, "any-model" when none was given
+func ParseTranscriptionRequest(r *http.Request, audio *AudioProcessor) (*TranscriptionRequest, string, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get(utils.HeaderContentType))
+	if err != nil || mediaType != "multipart/form-data" {
+		return nil, "", fmt.Errorf("invalid request format: content type must be multipart/form-data")
+	}
+
+	r.Body = http.MaxBytesReader(nil, r.Body, maxTranscriptionUpload)
+	if err := r.ParseMultipartForm(maxTranscriptionUpload); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, "", fmt.Errorf("request body exceeds %d bytes: %w", maxTranscriptionUpload, err)
+		}
+		return nil, "", fmt.Errorf("invalid request format: %v", err)
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	request := &TranscriptionRequest{
+		Fields:         make(map[string][]string),
+		ResponseFormat: "json",
+	}
+	for field, values := range r.MultipartForm.Value {
+		if len(values) == 0 {
+			continue
+		}
+		switch field {
+		case "model", "file_url":
+		case "response_format":
+			if !transcriptionResponseFormats[values[0]] {
+				return nil, "", fmt.Errorf("invalid 'response_format' field: must be one of json, text, srt, verbose_json or vtt")
+			}
+			request.ResponseFormat = values[0]
+			request.Fields[field] = values[:1]
+		case "stream":
+			stream, err := strconv.ParseBool(values[0])
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid 'stream' field: must be a boolean")
+			}
+			request.Stream = stream
+			request.Fields[field] = values[:1]
+		case "temperature":
+			temperature, err := strconv.ParseFloat(values[0], 64)
+			if err != nil || temperature < 0 || temperature > 1 {
+				return nil, "", fmt.Errorf("invalid 'temperature' field: must be a number between 0 and 1")
+			}
+			request.Fields[field] = values[:1]
+		default:
+			request.Fields[field] = values
+		}
+	}
+
+	if err := readTranscriptionAudio(r, audio, request); err != nil {
+		return nil, "", err
+	}
+
+	originalModel := r.FormValue("model")
+	if originalModel == "" {
+		originalModel = "any-model" // Default if no model provided
+	}
+	return request, originalModel, nil
+}
+
+// readTranscriptionAudio fills the request's audio from the uploaded file or the file URL
+func readTranscriptionAudio(r *http.Request, audio *AudioProcessor, request *TranscriptionRequest) error {
+	if files := r.MultipartForm.File["file"]; len(files) > 0 {
+		file, err := files[0].Open()
+		if err != nil {
+			return fmt.Errorf("failed to read 'file': %v", err)
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return fmt.Errorf("failed to read 'file': %v", err)
+		}
+		if len(data) == 0 {
+			return fmt.Errorf("invalid 'file' field: must not be empty")
+		}
+		request.File, request.Filename = data, files[0].Filename
+		return nil
+	}
+
+	fileURL := r.FormValue("file_url")
+	if fileURL == "" {
+		return fmt.Errorf("missing 'file' or 'file_url' field in request")
+	}
+	parsed, err := url.Parse(fileURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("invalid 'file_url' field: must be an http or https URL")
+	}
+
+	data, contentType, err := audio.downloadAudio(r.Context(), fileURL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid 'file_url' field: %v", err)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("invalid 'file_url' field: audio is empty")
+	}
+	request.File, request.Filename = data, audioFilename(audio, parsed.Path, contentType, data)
+	return nil
+}
+
+// audioFilename names downloaded audio after its URL path, adding an extension from the
+// content type, or failing that the file's magic numbers, since vendors infer the format from it
+func audioFilename(audio *AudioProcessor, urlPath, contentType string, data []byte) string {
+	name := path.Base(urlPath)
+	if name == "." || name == "/" {
+		name = "audio"
+	}
+	if path.Ext(name) != "" {
+		return name
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if ext, ok := audioExtensions[mediaType]; ok {
+		return name + ext
+	}
+	if detected, ok := audio.detectAudioFormat(data); ok {
+		return name + audioExtensions[detected]
+	}
+	return name
+}
+
+// transcriptionModels returns the models whose vendor serves transcriptions
+func transcriptionModels(models []config.VendorModel) []config.VendorModel {
+	var result []config.VendorModel
+	for _, model := range models {
+		if SupportsTranscriptions(model.Vendor) {
+			result = append(result, model)
+		}
+	}
+	return result
+}
+
+// buildTranscriptionBody encodes the request as the multipart body sent to the vendor
+func buildTranscriptionBody(request *TranscriptionRequest, model string) ([]byte, string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", request.Filename)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(request.File); err != nil {
+		return nil, "", err
+	}
+	if err := writer.WriteField("model", model); err != nil {
+		return nil, "", err
+	}
+
+	fields := make([]string, 0, len(request.Fields))
+	for field := range request.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		for _, value := range request.Fields[field] {
+			if err := writer.WriteField(field, value); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
+
+// SendTranscriptionRequest sends a transcription request to the vendor API and writes the
+// response back, relaying partial transcripts as they arrive when streaming was requested
+func (c *APIClient) SendTranscriptionRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, request *TranscriptionRequest, originalModel string) error {
+	adapter := adapterFor(selection.Vendor)
+	provider, ok := adapter.(TranscriptionsProvider)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrTranscriptionsUnsupported, selection.Vendor)
+	}
+	baseURL, err := c.baseURLFor(selection)
+	if err != nil {
+		return err
+	}
+
+	body, contentType, err := buildTranscriptionBody(request, selection.Model)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, provider.TranscriptionsEndpoint(baseURL), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set(utils.HeaderContentType, contentType)
+	if !request.Stream {
+		// Streams are left to the transport, which decompresses them transparently
+		req.Header.Set(utils.HeaderAcceptEncoding, utils.AcceptEncodingGzip)
+	}
+	if err := authorizeRequest(req, adapter, selection); err != nil {
+		return err
+	}
+
+	resp, release, err := c.doVendorRequest(r, req, selection, body, originalModel)
+	if err != nil {
+		return err
+	}
+	// The request counts against the credential's concurrency cap until its response is handled
+	defer release()
+	defer resp.Body.Close()
+
+	observeRateLimit(r, selection, resp)
+
+	sizes := trackResponseSize(selection.Vendor, &resp.Body, resp.Header.Get(utils.HeaderContentEncoding) == utils.AcceptEncodingGzip)
+	defer sizes.record()
+
+	if resp.StatusCode < 400 && request.Stream && strings.HasPrefix(resp.Header.Get(utils.HeaderContentType), utils.ContentTypeEventStream) {
+		return c.relayTranscriptionStream(w, r, selection, resp)
+	}
+
+	responseBody, err := c.standardizer.processResponseBody(resp.Body, resp.Header.Get(utils.HeaderContentEncoding), selection.Vendor)
+	if resp.StatusCode >= 400 {
+		if err != nil {
+			return ParseVendorError(selection.Vendor, resp.StatusCode, nil)
+		}
+		vendorErr := ParseVendorError(selection.Vendor, resp.StatusCode, responseBody)
+		logger.Warn(r.Context(), "Vendor API error detected",
+			"vendor", selection.Vendor,
+			"status_code", resp.StatusCode,
+			"retriable", IsRetriableAPIError(vendorErr),
+			"response_body", string(responseBody),
+			"component", "APIClient",
+			"stage", "VendorAPIError",
+		)
+		return vendorErr
+	}
+	if err != nil {
+		logger.Error(r.Context(), "Error processing response body", err,
+			"vendor", selection.Vendor,
+			"component", "APIClient",
+			"stage", "ResponseBodyProcessing",
+		)
+		return err
+	}
+	sizes.uncompressed = int64(len(responseBody))
+
+	if request.Stream {
+		// The vendor answered in one piece, so the whole transcript arrives as the final event
+		return c.writeTranscriptionDone(w, r, selection, responseBody, request.ResponseFormat)
+	}
+
+	finalResponse, err := ProcessTranscriptionResponse(responseBody, request.ResponseFormat)
+	if err != nil {
+		logger.Error(r.Context(), "Error processing transcription response", err,
+			"vendor", selection.Vendor,
+			"response_size_bytes", len(responseBody),
+			"component", "APIClient",
+			"stage", "ResponseProcessing",
+		)
+		return err
+	}
+	recordTranscriptionUsage(r.Context(), finalResponse)
+	setCostHeader(r.Context(), w.Header())
+
+	c.standardizer.setCompliantHeaders(w, selection.Vendor, len(finalResponse), false)
+	setRouterHeaders(r, w.Header())
+	if !isJSONTranscriptionFormat(request.ResponseFormat) {
+		w.Header().Set(utils.HeaderContentType, "text/plain; charset=utf-8")
+	}
+	if _, err := w.Write(finalResponse); err != nil {
+		logger.Error(r.Context(), "Error writing response", err,
+			"vendor", selection.Vendor,
+			"component", "APIClient",
+			"stage", "ResponseWriting",
+		)
+		return err
+	}
+
+	logger.Info(r.Context(), "Transcription response sent to client",
+		"vendor", selection.Vendor,
+		"model", selection.Model,
+		"original_model", originalModel,
+		"response_format", request.ResponseFormat,
+		"final_response_size", len(finalResponse),
+		"component", "APIClient",
+		"stage", "FinalResponseSent",
+	)
+	return nil
+}
+
+// relayTranscriptionStream forwards the vendor's transcript events to the client as they arrive
+func (c *APIClient) relayTranscriptionStream(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, resp *http.Response) error {
+	c.setupResponseHeadersWithVendor(w, resp, true, selection.Vendor)
+	setRouterHeaders(r, w.Header())
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:")); ok {
+				recordTranscriptionUsage(r.Context(), bytes.TrimSpace(data))
+			}
+			if _, writeErr := w.Write(line); writeErr != nil {
+				logger.Warn(r.Context(), "Client disconnected during transcription stream",
+					"vendor", selection.Vendor,
+					"error", writeErr.Error(),
+					"component", "APIClient",
+					"stage", "StreamWriting",
+				)
+				return nil
+			}
+			if flusher != nil && len(bytes.TrimSpace(line)) == 0 {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Headers are already sent, so the stream simply ends early
+			logger.Error(r.Context(), "Error reading transcription stream", err,
+				"vendor", selection.Vendor,
+				"component", "APIClient",
+				"stage", "StreamReading",
+			)
+			break
+		}
+	}
+	// Sent as the trailer announced with the headers
+	setCostHeader(r.Context(), w.Header())
+	if flusher != nil {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// writeTranscriptionDone writes a complete transcript as a single transcript.text.done event
+func (c *APIClient) writeTranscriptionDone(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, responseBody []byte, responseFormat string) error {
+	done := map[string]interface{}{"type": "transcript.text.done", "text": string(responseBody)}
+	if isJSONTranscriptionFormat(responseFormat) {
+		var response map[string]interface{}
+		if err := codec.Unmarshal(responseBody, &response); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+		}
+		done["text"], _ = response["text"].(string)
+		if usage, ok := response["usage"]; ok {
+			done["usage"] = usage
+		}
+	}
+	event, err := codec.Marshal(done)
+	if err != nil {
+		return err
+	}
+	recordTranscriptionUsage(r.Context(), event)
+	setCostHeader(r.Context(), w.Header())
+
+	c.standardizer.setCompliantHeaders(w, selection.Vendor, 0, false)
+	setRouterHeaders(r, w.Header())
+	w.Header().Set(utils.HeaderContentType, utils.ContentTypeEventStreamUTF8)
+	w.Header().Set(utils.HeaderCacheControl, utils.CacheControlNoCache)
+	_, err = fmt.Fprintf(w, "data: %s\n\n", event)
+	return err
+}
+
+// ProcessTranscriptionResponse shapes a vendor transcription response for the client: the
+// json format is reduced to the OpenAI fields, verbose_json loses vendor extensions and the
+// text formats are returned untouched
+func ProcessTranscriptionResponse(body []byte, responseFormat string) ([]byte, error) {
+	if !isJSONTranscriptionFormat(responseFormat) {
+		return body, nil
+	}
+
+	var response map[string]interface{}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	text, ok := response["text"].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing required field 'text'", ErrInvalidResponse)
+	}
+
+	if responseFormat == "verbose_json" {
+		for field := range response {
+			if strings.HasPrefix(field, "x_") {
+				delete(response, field)
+			}
+		}
+		return codec.Marshal(response)
+	}
+
+	result := map[string]interface{}{"text": text}
+	for _, field := range []string{"logprobs", "usage"} {
+		if value, exists := response[field]; exists {
+			result[field] = value
+		}
+	}
+	return codec.Marshal(result)
+}
+
+// recordTranscriptionUsage records the token usage a transcription response or event
+// reports; duration-based usage carries no tokens and is ignored
+func recordTranscriptionUsage(ctx context.Context, body []byte) {
+	var response struct {
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return
+	}
+	if response.Usage.InputTokens > 0 || response.Usage.OutputTokens > 0 {
+		recordUsage(ctx, response.Usage.InputTokens, response.Usage.OutputTokens)
+	}
+}
+
+func isJSONTranscriptionFormat(responseFormat string) bool {
+	return responseFormat == "json" || responseFormat == "verbose_json"
+}
@@ -0,0 +1,242 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTranscriptionRequest builds a multipart transcription request with an optional audio file
+func newTranscriptionRequest(t *testing.T, audio []byte, fields map[string]string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if audio != nil {
+		part, err := writer.CreateFormFile("file", "speech.mp3")
+		require.NoError(t, err)
+		_, err = part.Write(audio)
+		require.NoError(t, err)
+	}
+	for field, value := range fields {
+		require.NoError(t, writer.WriteField(field, value))
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/audio/transcriptions", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestParseTranscriptionRequest(t *testing.T) {
+	audioServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/wav")
+		_, _ = w.Write([]byte("RIFF0000WAVEfmt "))
+	}))
+	defer audioServer.Close()
+
+	tests := []struct {
+		name             string
+		audio            []byte
+		fields           map[string]string
+		expectedError    string
+		expectedModel    string
+		expectedFilename string
+		expectedFields   map[string][]string
+	}{
+		{
+			name:             "uploaded file",
+			audio:            []byte("ID3audio"),
+			fields:           map[string]string{"model": "whisper-1", "language": "en", "response_format": "verbose_json", "temperature": "0.2"},
+			expectedModel:    "whisper-1",
+			expectedFilename: "speech.mp3",
+			expectedFields:   map[string][]string{"language": {"en"}, "response_format": {"verbose_json"}, "temperature": {"0.2"}},
+		},
+		{
+			name:             "file URL",
+			fields:           map[string]string{"file_url": audioServer.URL + "/recordings/call"},
+			expectedModel:    "any-model",
+			expectedFilename: "call.wav",
+			expectedFields:   map[string][]string{},
+		},
+		{name: "missing audio", fields: map[string]string{"model": "whisper-1"}, expectedError: "missing 'file' or 'file_url'"},
+		{name: "non-HTTP file URL", fields: map[string]string{"file_url": "file:///etc/passwd"}, expectedError: "must be an http or https URL"},
+		{name: "unknown response format", audio: []byte("a"), fields: map[string]string{"response_format": "xml"}, expectedError: "'response_format'"},
+		{name: "invalid stream", audio: []byte("a"), fields: map[string]string{"stream": "sometimes"}, expectedError: "'stream'"},
+		{name: "temperature out of range", audio: []byte("a"), fields: map[string]string{"temperature": "1.5"}, expectedError: "'temperature'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, originalModel, err := ParseTranscriptionRequest(newTranscriptionRequest(t, tt.audio, tt.fields), NewAudioProcessor())
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedModel, originalModel)
+			assert.Equal(t, tt.expectedFilename, request.Filename)
+			assert.Equal(t, tt.expectedFields, request.Fields)
+			assert.NotEmpty(t, request.File)
+		})
+	}
+
+	t.Run("JSON body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/audio/transcriptions", bytes.NewReader([]byte(`{"file":"x"}`)))
+		req.Header.Set("Content-Type", "application/json")
+		_, _, err := ParseTranscriptionRequest(req, NewAudioProcessor())
+		assert.ErrorContains(t, err, "multipart/form-data")
+	})
+}
+
+func TestProcessTranscriptionResponse(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		responseFormat string
+		expected       string
+		expectedError  string
+	}{
+		{
+			name:           "json drops vendor extras",
+			body:           `{"text":"Hello there.","x_groq":{"id":"req_1"},"usage":{"type":"duration","seconds":3}}`,
+			responseFormat: "json",
+			expected:       `{"text":"Hello there.","usage":{"type":"duration","seconds":3}}`,
+		},
+		{
+			name:           "verbose_json keeps segments",
+			body:           `{"task":"transcribe","language":"english","duration":1.5,"text":"Hi.","segments":[{"id":0,"text":"Hi."}],"x_groq":{"id":"req_1"}}`,
+			responseFormat: "verbose_json",
+			expected:       `{"task":"transcribe","language":"english","duration":1.5,"text":"Hi.","segments":[{"id":0,"text":"Hi."}]}`,
+		},
+		{name: "missing text", body: `{"segments":[]}`, responseFormat: "json", expectedError: "missing required field 'text'"},
+		{name: "invalid JSON", body: `Hello`, responseFormat: "json", expectedError: ErrInvalidResponse.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processed, err := ProcessTranscriptionResponse([]byte(tt.body), tt.responseFormat)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				assert.True(t, errors.Is(err, ErrInvalidResponse))
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(processed))
+		})
+	}
+
+	t.Run("text formats are untouched", func(t *testing.T) {
+		srt := "1\n00:00:00,000 --> 00:00:01,500\nHi.\n"
+		processed, err := ProcessTranscriptionResponse([]byte(srt), "srt")
+		require.NoError(t, err)
+		assert.Equal(t, srt, string(processed))
+	})
+}
+
+func TestProxyTranscriptionRequest(t *testing.T) {
+	tests := []struct {
+		name                string
+		fields              map[string]string
+		vendorContentType   string
+		vendorResponse      string
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "json response",
+			fields:              map[string]string{"model": "my-whisper", "language": "en"},
+			vendorContentType:   "application/json",
+			vendorResponse:      `{"text":"Hello there.","x_groq":{"id":"req_1"}}`,
+			expectedContentType: "application/json",
+			expectedBody:        `{"text":"Hello there."}`,
+		},
+		{
+			name:                "text response",
+			fields:              map[string]string{"response_format": "text"},
+			vendorContentType:   "text/plain",
+			vendorResponse:      "Hello there.\n",
+			expectedContentType: "text/plain",
+			expectedBody:        "Hello there.\n",
+		},
+		{
+			name:                "streamed partial transcripts are relayed",
+			fields:              map[string]string{"stream": "true"},
+			vendorContentType:   "text/event-stream",
+			vendorResponse:      "data: {\"type\":\"transcript.text.delta\",\"delta\":\"Hello\"}\n\ndata: {\"type\":\"transcript.text.done\",\"text\":\"Hello there.\"}\n\n",
+			expectedContentType: "text/event-stream",
+			expectedBody:        "data: {\"type\":\"transcript.text.delta\",\"delta\":\"Hello\"}\n\ndata: {\"type\":\"transcript.text.done\",\"text\":\"Hello there.\"}\n\n",
+		},
+		{
+			name:                "stream from a vendor answering in one piece",
+			fields:              map[string]string{"stream": "true"},
+			vendorContentType:   "application/json",
+			vendorResponse:      `{"text":"Hello there."}`,
+			expectedContentType: "text/event-stream",
+			expectedBody:        "data: {\"text\":\"Hello there.\",\"type\":\"transcript.text.done\"}\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vendorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/audio/transcriptions", r.URL.Path)
+				assert.Equal(t, "Bearer gsk-test", r.Header.Get("Authorization"))
+				require.NoError(t, r.ParseMultipartForm(1<<20))
+				assert.Equal(t, "whisper-large-v3", r.FormValue("model"))
+				for field, value := range tt.fields {
+					if field != "model" {
+						assert.Equal(t, value, r.FormValue(field), field)
+					}
+				}
+				file, header, err := r.FormFile("file")
+				require.NoError(t, err)
+				defer file.Close()
+				assert.Equal(t, "speech.mp3", header.Filename)
+
+				w.Header().Set("Content-Type", tt.vendorContentType)
+				_, _ = w.Write([]byte(tt.vendorResponse))
+			}))
+			defer vendorServer.Close()
+
+			creds := []config.Credential{
+				{Platform: "groq", Type: "api-key", Value: "gsk-test"},
+				{Platform: "anthropic", Type: "api-key", Value: "sk-ant-test"},
+			}
+			models := []config.VendorModel{
+				{Vendor: "groq", Model: "whisper-large-v3", Type: config.ModelTypeTranscription},
+				{Vendor: "anthropic", Model: "claude-whisper", Type: config.ModelTypeTranscription},
+			}
+			mockSelector := &MockSelector{}
+			mockSelector.On("Select", creds[:1], models[:1]).Return(&selector.VendorSelection{Vendor: "groq", Model: "whisper-large-v3", Credential: creds[0]}, nil)
+
+			req := newTranscriptionRequest(t, []byte("ID3audio"), tt.fields)
+			rr := httptest.NewRecorder()
+			ProxyTranscriptionRequest(rr, req, creds, models, NewAPIClient(map[string]string{"groq": vendorServer.URL}), mockSelector)
+
+			require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+			mockSelector.AssertExpectations(t)
+			assert.Contains(t, rr.Header().Get("Content-Type"), tt.expectedContentType)
+			if tt.expectedContentType == "application/json" {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			} else {
+				assert.Equal(t, tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSupportsTranscriptions(t *testing.T) {
+	for _, vendor := range []string{"openai", "groq", "together"} {
+		assert.True(t, SupportsTranscriptions(vendor), vendor)
+	}
+	for _, vendor := range []string{"anthropic", "ollama"} {
+		assert.False(t, SupportsTranscriptions(vendor), vendor)
+	}
+}
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+)
+
+// requestUser returns the end-user identifier a request names in the OpenAI "user" field,
+// or "" when it names none
+func requestUser(body []byte) (string, error) {
+	var request struct {
+		User interface{} `json:"user"`
+	}
+	if err := codec.Unmarshal(body, &request); err != nil || request.User == nil {
+		return "", nil
+	}
+	user, ok := request.User.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid 'user' field: must be a string")
+	}
+	if err := access.ValidateUser(user); err != nil {
+		return "", err
+	}
+	return user, nil
+}
+
+// enforceRateLimits counts a request against its client key's per-key and per-user rate
+// limits, shared by every key of an authenticated client identity, answering 429 with
+// Retry-After and reporting false when either is exhausted
+func enforceRateLimits(w http.ResponseWriter, r *http.Request, user string) bool {
+	err := access.DefaultLimiter().Allow(access.Default().PolicyFor(r), access.QuotaKey(r), user)
+	var rateErr *access.RateLimitError
+	if !errors.As(err, &rateErr) {
+		return true
+	}
+
+	ctx := logger.WithComponent(r.Context(), "proxy")
+	ctx = logger.WithStage(ctx, "rate_limiting")
+	logger.Warn(ctx, "Client rate limit exceeded",
+		"client_key", access.KeyHint(r),
+		"retry_after", rateErr.RetryAfter.String(),
+		"error", err.Error(),
+	)
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(rateErr.RetryAfter.Seconds()))))
+	http.Error(w, err.Error(), http.StatusTooManyRequests)
+	return false
+}
+
+// userHashFromContext returns the hashed end-user identifier of the request, if it named one
+func userHashFromContext(r *http.Request) string {
+	if user, ok := r.Context().Value(logger.UserKey).(string); ok {
+		return user
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/events"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestUser(t *testing.T) {
+	user, err := requestUser([]byte(`{"model":"gpt-4o","user":"user-1234"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1234", user)
+
+	user, err = requestUser([]byte(`{"model":"gpt-4o"}`))
+	require.NoError(t, err)
+	assert.Empty(t, user)
+
+	_, err = requestUser([]byte(`{"user":42}`))
+	assert.Error(t, err)
+	_, err = requestUser([]byte(`{"user":""}`))
+	assert.Error(t, err)
+}
+
+func TestProxyRequest_UserRateLimit(t *testing.T) {
+	vendorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1700000000,"model":"gpt-4",` +
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`))
+	}))
+	defer vendorServer.Close()
+
+	previous := access.Default()
+	access.SetDefault(access.NewACL(access.Policy{}, map[string]access.Policy{
+		"sk-client-saas": {UserRequestsPerMinute: 1},
+	}))
+	t.Cleanup(func() { access.SetDefault(previous) })
+
+	var decisions []string
+	unsubscribe := events.Default().Subscribe(func(ctx context.Context, event events.Event) {
+		if event.Decision != nil {
+			decisions = append(decisions, event.Decision.User)
+		}
+	})
+	defer unsubscribe()
+
+	creds := []config.Credential{{Platform: "openai", Type: "api-key", Value: "sk-test"}}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4"}}
+	mockSelector := &MockSelector{}
+	mockSelector.On("Select", creds, models).Return(&selector.VendorSelection{Vendor: "openai", Model: "gpt-4", Credential: creds[0]}, nil)
+	client := NewAPIClient(map[string]string{"openai": vendorServer.URL})
+
+	send := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader(
+			`{"model":"my-model","user":"`+user+`","messages":[{"role":"user","content":"Hello"}]}`))
+		req.Header.Set("Authorization", "Bearer sk-client-saas")
+		rr := httptest.NewRecorder()
+		ProxyRequest(rr, req, creds, models, client, mockSelector)
+		return rr
+	}
+
+	require.Equal(t, http.StatusOK, send("rate-limit-alice").Code)
+	limited := send("rate-limit-alice")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.NotEmpty(t, limited.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, send("rate-limit-bob").Code, "each user has their own limit")
+
+	assert.Equal(t, []string{access.UserHash("rate-limit-alice"), access.UserHash("rate-limit-bob")}, decisions,
+		"decisions record the hashed user")
+}
@@ -0,0 +1,296 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// VendorAdapter translates between the OpenAI chat completions schema used by clients
+// and a vendor's native API
+type VendorAdapter interface {
+	// Endpoint returns the URL chat requests for model are sent to under the vendor's base URL
+	Endpoint(baseURL, model string, streaming bool) string
+	// Authorize sets the vendor's authentication headers for the credential
+	Authorize(req *http.Request, credential config.Credential) error
+	// TranslateRequest converts an OpenAI chat completion request into the vendor's format
+	TranslateRequest(body []byte) ([]byte, error)
+	// TranslateResponse converts a non-streaming vendor response into an OpenAI chat completion
+	TranslateResponse(body []byte) ([]byte, error)
+	// TranslateStream converts a vendor event stream into OpenAI chat completion chunks
+	TranslateStream(r io.Reader) io.Reader
+}
+
+// RateLimitReporter is implemented by adapters whose vendors report rate limits in
+// response headers, so credentials can be routed around before they return 429s; vendors
+// without one are read as sending OpenAI-style headers
+type RateLimitReporter interface {
+	// RateLimitedUntil returns until when the credential that received the response is
+	// throttled, or the zero time if it has capacity left
+	RateLimitedUntil(statusCode int, header http.Header, now time.Time) time.Time
+}
+
+// HTTPClientProvider is implemented by adapters whose vendors need different connection
+// settings or timeouts from the shared client, such as self-hosted instances
+type HTTPClientProvider interface {
+	HTTPClient() *http.Client
+}
+
+// ErrorParser is implemented by adapters whose vendors report errors in their own shape,
+// to refine the type, message and retriability classified from the HTTP status
+type ErrorParser interface {
+	ParseError(apiErr *VendorAPIError, responseBody []byte)
+}
+
+// EmbeddingsProvider is implemented by adapters whose vendors serve an OpenAI-compatible
+// embeddings API; vendors without one cannot take embedding models
+type EmbeddingsProvider interface {
+	// EmbeddingsEndpoint returns the URL embeddings requests are sent to under the vendor's base URL
+	EmbeddingsEndpoint(baseURL string) string
+}
+
+// RequestFieldKeeper is implemented by adapters that translate chat request fields request
+// validation otherwise drops before the request reaches them, such as max_tokens
+type RequestFieldKeeper interface {
+	// KeptRequestFields names the fields validation passes through for the vendor
+	KeptRequestFields() []string
+}
+
+// keptRequestFields returns the request fields validation passes through for vendor
+func keptRequestFields(vendor string) []string {
+	if keeper, ok := adapterFor(vendor).(RequestFieldKeeper); ok {
+		return keeper.KeptRequestFields()
+	}
+	return nil
+}
+
+// SupportsEmbeddings reports whether vendor serves embeddings requests
+func SupportsEmbeddings(vendor string) bool {
+	_, ok := adapterFor(vendor).(EmbeddingsProvider)
+	return ok
+}
+
+// TranscriptionsProvider is implemented by adapters whose vendors serve an OpenAI-compatible
+// audio transcriptions API; vendors without one cannot take transcription models
+type TranscriptionsProvider interface {
+	// TranscriptionsEndpoint returns the URL transcription requests are sent to under the vendor's base URL
+	TranscriptionsEndpoint(baseURL string) string
+}
+
+// SupportsTranscriptions reports whether vendor serves audio transcription requests
+func SupportsTranscriptions(vendor string) bool {
+	_, ok := adapterFor(vendor).(TranscriptionsProvider)
+	return ok
+}
+
+// SpeechProvider is implemented by adapters whose vendors serve an OpenAI-compatible
+// text-to-speech API; vendors without one cannot take speech models
+type SpeechProvider interface {
+	// SpeechEndpoint returns the URL speech requests are sent to under the vendor's base URL
+	SpeechEndpoint(baseURL string) string
+	// SpeechFormat maps an OpenAI response format to the vendor's, reporting false when
+	// the vendor cannot produce it
+	SpeechFormat(format string) (string, bool)
+}
+
+// SupportsSpeech reports whether vendor serves text-to-speech requests
+func SupportsSpeech(vendor string) bool {
+	_, ok := adapterFor(vendor).(SpeechProvider)
+	return ok
+}
+
+// ModerationsProvider is implemented by adapters whose vendors serve a moderation API;
+// vendors without one cannot take moderation models
+type ModerationsProvider interface {
+	// ModerationsEndpoint returns the URL moderation requests are sent to under the vendor's base URL
+	ModerationsEndpoint(baseURL string) string
+	// TranslateModerationResponse converts a moderation response into OpenAI's categories
+	TranslateModerationResponse(body []byte) ([]byte, error)
+}
+
+// SupportsModerations reports whether vendor serves moderation requests
+func SupportsModerations(vendor string) bool {
+	_, ok := adapterFor(vendor).(ModerationsProvider)
+	return ok
+}
+
+// RerankProvider is implemented by adapters whose vendors serve a rerank API; vendors
+// without one cannot take rerank models
+type RerankProvider interface {
+	// RerankEndpoint returns the URL rerank requests are sent to under the vendor's base URL
+	RerankEndpoint(baseURL string) string
+	// TranslateRerankRequest converts a rerank request (model, query, documents as strings,
+	// top_n and return_documents) into the vendor's format
+	TranslateRerankRequest(body []byte) ([]byte, error)
+	// TranslateRerankResponse converts a rerank response into results with an index and a
+	// relevance_score, and the usage reported by the vendor
+	TranslateRerankResponse(body []byte) ([]byte, error)
+}
+
+// SupportsRerank reports whether vendor serves rerank requests
+func SupportsRerank(vendor string) bool {
+	_, ok := adapterFor(vendor).(RerankProvider)
+	return ok
+}
+
+// VendorAdapterFactory creates a vendor's adapter. It is called on the vendor's first use
+// rather than at registration, which runs from init before the .env file is loaded, so
+// adapters may read their configuration from the environment when created
+type VendorAdapterFactory func() VendorAdapter
+
+// registeredAdapter is a vendor's adapter factory and, once created, its adapter
+type registeredAdapter struct {
+	factory VendorAdapterFactory
+	once    sync.Once
+	adapter VendorAdapter
+}
+
+// Registered vendor adapters; vendors without one are OpenAI-compatible and passed through
+var (
+	vendorAdaptersMu sync.RWMutex
+	vendorAdapters   = make(map[string]*registeredAdapter)
+)
+
+// RegisterVendorAdapter makes the adapter created by factory handle requests for vendor
+// Each adapter file registers its vendor from init; registering a vendor twice panics
+func RegisterVendorAdapter(vendor string, factory VendorAdapterFactory) {
+	vendorAdaptersMu.Lock()
+	defer vendorAdaptersMu.Unlock()
+
+	if factory == nil {
+		panic("proxy: RegisterVendorAdapter factory is nil for " + vendor)
+	}
+	if _, exists := vendorAdapters[vendor]; exists {
+		panic("proxy: RegisterVendorAdapter called twice for " + vendor)
+	}
+	vendorAdapters[vendor] = &registeredAdapter{factory: factory}
+}
+
+// RegisteredVendors returns the vendors with a registered adapter, sorted by name
+func RegisteredVendors() []string {
+	vendorAdaptersMu.RLock()
+	defer vendorAdaptersMu.RUnlock()
+
+	vendors := make([]string, 0, len(vendorAdapters))
+	for vendor := range vendorAdapters {
+		vendors = append(vendors, vendor)
+	}
+	sort.Strings(vendors)
+	return vendors
+}
+
+// adapterFor returns the adapter for vendor, creating it on first use, and defaults to the
+// OpenAI-compatible passthrough
+func adapterFor(vendor string) VendorAdapter {
+	vendorAdaptersMu.RLock()
+	registered, ok := vendorAdapters[vendor]
+	vendorAdaptersMu.RUnlock()
+
+	if !ok {
+		return openAICompatibleAdapter{}
+	}
+	registered.once.Do(func() {
+		registered.adapter = registered.factory()
+	})
+	return registered.adapter
+}
+
+// applyAuthHeader sets a model's own auth header, substituting the credential value for {key}
+// Keyless credentials send no header
+func applyAuthHeader(req *http.Request, template string, credential config.Credential) error {
+	if credential.Type == config.CredentialTypeNone {
+		return nil
+	}
+	name, value, err := config.ParseAuthHeader(template)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(name, strings.ReplaceAll(value, config.AuthHeaderKeyPlaceholder, credential.Value))
+	return nil
+}
+
+// openAICompatibleAdapter forwards requests and responses unchanged
+type openAICompatibleAdapter struct{}
+
+func (openAICompatibleAdapter) Endpoint(baseURL, model string, streaming bool) string {
+	return baseURL + "/chat/completions"
+}
+
+func (openAICompatibleAdapter) EmbeddingsEndpoint(baseURL string) string {
+	return baseURL + "/embeddings"
+}
+
+func (openAICompatibleAdapter) TranscriptionsEndpoint(baseURL string) string {
+	return baseURL + "/audio/transcriptions"
+}
+
+func (openAICompatibleAdapter) SpeechEndpoint(baseURL string) string {
+	return baseURL + "/audio/speech"
+}
+
+func (openAICompatibleAdapter) SpeechFormat(format string) (string, bool) {
+	return format, true
+}
+
+func (openAICompatibleAdapter) ModerationsEndpoint(baseURL string) string {
+	return baseURL + "/moderations"
+}
+
+func (openAICompatibleAdapter) TranslateModerationResponse(body []byte) ([]byte, error) {
+	return body, nil
+}
+
+func (openAICompatibleAdapter) Authorize(req *http.Request, credential config.Credential) error {
+	if credential.Type == config.CredentialTypeNone {
+		return nil
+	}
+	req.Header.Set(utils.HeaderAuthorization, "Bearer "+credential.Value)
+	return nil
+}
+
+func (openAICompatibleAdapter) TranslateRequest(body []byte) ([]byte, error) {
+	return body, nil
+}
+
+func (openAICompatibleAdapter) TranslateResponse(body []byte) ([]byte, error) {
+	return body, nil
+}
+
+func (openAICompatibleAdapter) TranslateStream(r io.Reader) io.Reader {
+	return r
+}
+
+// rerankOnlyAdapter forwards chat requests unchanged for vendors that only serve rerank
+// models; configuration validation keeps their models out of the chat pool
+type rerankOnlyAdapter struct{}
+
+func (rerankOnlyAdapter) Endpoint(baseURL, model string, streaming bool) string {
+	return baseURL + "/chat/completions"
+}
+
+func (rerankOnlyAdapter) Authorize(req *http.Request, credential config.Credential) error {
+	req.Header.Set(utils.HeaderAuthorization, "Bearer "+credential.Value)
+	return nil
+}
+
+func (rerankOnlyAdapter) TranslateRequest(body []byte) ([]byte, error) {
+	return body, nil
+}
+
+func (rerankOnlyAdapter) TranslateResponse(body []byte) ([]byte, error) {
+	return body, nil
+}
+
+func (rerankOnlyAdapter) TranslateStream(r io.Reader) io.Reader {
+	return r
+}
+
+func (rerankOnlyAdapter) RerankEndpoint(baseURL string) string {
+	return baseURL + "/rerank"
+}
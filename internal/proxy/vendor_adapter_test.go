@@ -0,0 +1,52 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisteredVendors(t *testing.T) {
+	assert.Equal(t, []string{"anthropic", "cohere", "deepseek", "groq", "huggingface", "jina", "mistral", "ollama", "together", "vertex", "voyage"}, RegisteredVendors())
+	assert.IsType(t, openAICompatibleAdapter{}, adapterFor("openai"), "unregistered vendors are passed through")
+}
+
+func TestRegisterVendorAdapter(t *testing.T) {
+	assert.PanicsWithValue(t, "proxy: RegisterVendorAdapter called twice for mistral", func() {
+		RegisterVendorAdapter("mistral", func() VendorAdapter { return NewMistralAdapter() })
+	})
+	assert.Panics(t, func() { RegisterVendorAdapter("acme", nil) })
+
+	t.Cleanup(func() {
+		vendorAdaptersMu.Lock()
+		defer vendorAdaptersMu.Unlock()
+		delete(vendorAdapters, "acme")
+	})
+
+	acme := &acmeAdapter{}
+	created := 0
+	RegisterVendorAdapter("acme", func() VendorAdapter {
+		created++
+		return acme
+	})
+	assert.Zero(t, created, "adapters are not created at registration, before the .env file is loaded")
+	assert.Same(t, acme, adapterFor("acme"))
+	assert.Same(t, acme, adapterFor("acme"))
+	assert.Equal(t, 1, created, "adapters are created once, on first use")
+
+	var apiErr *VendorAPIError
+	require.True(t, errors.As(ParseVendorError("acme", http.StatusForbidden, []byte(`{"reason":"region blocked"}`)), &apiErr))
+	assert.Equal(t, "region blocked", apiErr.Message, "registered error parsers refine vendor errors")
+}
+
+// acmeAdapter is a minimal OpenAI-compatible adapter with its own error shape
+type acmeAdapter struct {
+	openAICompatibleAdapter
+}
+
+func (a *acmeAdapter) ParseError(apiErr *VendorAPIError, responseBody []byte) {
+	apiErr.Message = "region blocked"
+}
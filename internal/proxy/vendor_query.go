@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+)
+
+// Errors returned when a client key may not force routing with the ?vendor= query parameter
+var (
+	ErrVendorQueryDenied  = errors.New("the vendor query parameter is not permitted for this client key")
+	ErrVendorNotPermitted = errors.New("vendor is not permitted for this client key")
+)
+
+// CheckVendorQuery reports whether a client key's policy lets it force routing to vendor:
+// the policy must not deny the ?vendor= query parameter, and must allow the vendor when it
+// lists allowed vendors
+func CheckVendorQuery(policy access.Policy, vendor string) error {
+	if policy.DenyVendorQuery {
+		return ErrVendorQueryDenied
+	}
+	if len(policy.AllowedVendors) > 0 {
+		for _, allowed := range policy.AllowedVendors {
+			if strings.EqualFold(allowed, vendor) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrVendorNotPermitted, vendor)
+	}
+	return nil
+}
+
+// AuthorizeVendorQuery returns the vendor a request forces routing to with ?vendor=, or ""
+// when it sets none, after checking the client key may do so. Forced routing bypasses the
+// balancing policies, so every use, permitted or not, is written to the audit log
+func AuthorizeVendorQuery(ctx context.Context, r *http.Request) (string, error) {
+	vendor := r.URL.Query().Get("vendor")
+	if vendor == "" {
+		return "", nil
+	}
+	ctx = logger.WithStage(ctx, "VendorQuery")
+	err := CheckVendorQuery(access.Default().PolicyFor(r), vendor)
+	if err != nil {
+		logger.Warn(ctx, "Forced vendor routing denied",
+			"audit", true,
+			"vendor", vendor,
+			"client_key", access.KeyHint(r),
+			"path", r.URL.Path,
+			"error", err.Error(),
+		)
+		return "", err
+	}
+	logger.Info(ctx, "Forced vendor routing",
+		"audit", true,
+		"vendor", vendor,
+		"client_key", access.KeyHint(r),
+		"path", r.URL.Path,
+	)
+	return vendor, nil
+}
@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckVendorQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  access.Policy
+		vendor  string
+		wantErr error
+	}{
+		{name: "unrestricted key", vendor: "openai"},
+		{name: "allowed vendor", policy: access.Policy{AllowedVendors: []string{"OpenAI"}}, vendor: "openai"},
+		{name: "vendor not allowed", policy: access.Policy{AllowedVendors: []string{"gemini"}}, vendor: "openai", wantErr: ErrVendorNotPermitted},
+		{name: "vendor query denied", policy: access.Policy{DenyVendorQuery: true}, vendor: "openai", wantErr: ErrVendorQueryDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckVendorQuery(tt.policy, tt.vendor)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizeVendorQuery(t *testing.T) {
+	access.SetDefault(access.NewACL(access.Policy{DenyVendorQuery: true}, map[string]access.Policy{
+		"sk-ops": {AllowedVendors: []string{"openai", "gemini"}},
+	}))
+	t.Cleanup(func() { access.SetDefault(access.NewACL(access.Policy{}, nil)) })
+
+	tests := []struct {
+		name       string
+		target     string
+		key        string
+		wantVendor string
+		wantErr    error
+	}{
+		{name: "no vendor query", target: "/v1/chat/completions"},
+		{name: "key granted forced routing", target: "/v1/chat/completions?vendor=gemini", key: "sk-ops", wantVendor: "gemini"},
+		{name: "vendor outside the key's vendors", target: "/v1/chat/completions?vendor=groq", key: "sk-ops", wantErr: ErrVendorNotPermitted},
+		{name: "default policy denies forced routing", target: "/v1/chat/completions?vendor=gemini", key: "sk-other", wantErr: ErrVendorQueryDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tt.target, nil)
+			if tt.key != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			vendor, err := AuthorizeVendorQuery(r.Context(), r)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantVendor, vendor)
+		})
+	}
+}
@@ -0,0 +1,652 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// vertexFinishReasons maps Vertex AI finish reasons to OpenAI finish reasons
+var vertexFinishReasons = map[string]string{
+	"STOP":               "stop",
+	"MAX_TOKENS":         "length",
+	"SAFETY":             "content_filter",
+	"RECITATION":         "content_filter",
+	"BLOCKLIST":          "content_filter",
+	"PROHIBITED_CONTENT": "content_filter",
+	"SPII":               "content_filter",
+	"IMAGE_SAFETY":       "content_filter",
+}
+
+// vertexHarmCategories are the categories VERTEX_SAFETY_THRESHOLD applies to
+var vertexHarmCategories = []string{
+	"HARM_CATEGORY_HATE_SPEECH",
+	"HARM_CATEGORY_DANGEROUS_CONTENT",
+	"HARM_CATEGORY_SEXUALLY_EXPLICIT",
+	"HARM_CATEGORY_HARASSMENT",
+}
+
+// vertexUnsupportedSchemaKeys are JSON Schema keywords Vertex AI rejects in function parameters
+var vertexUnsupportedSchemaKeys = []string{"$schema", "additionalProperties", "strict"}
+
+// VertexAdapter translates OpenAI chat completions to and from Vertex AI's native
+// generateContent API, exposing features the OpenAI-compatible endpoint lacks
+type VertexAdapter struct {
+	settingsOnce sync.Once
+	settings     *vertexSettings
+	tokens       *serviceAccountTokens
+}
+
+// vertexSettings are the environment options applied to every Vertex AI request
+type vertexSettings struct {
+	safetyThreshold string
+	googleSearch    bool
+	codeExecution   bool
+}
+
+func init() {
+	RegisterVendorAdapter("vertex", func() VendorAdapter { return NewVertexAdapter() })
+}
+
+// NewVertexAdapter creates a Vertex AI adapter
+func NewVertexAdapter() *VertexAdapter {
+	return &VertexAdapter{
+		tokens: newServiceAccountTokens(),
+	}
+}
+
+// options returns the adapter's settings, read from the environment on first use since
+// adapters are registered before the .env file is loaded: VERTEX_SAFETY_THRESHOLD (e.g.
+// BLOCK_ONLY_HIGH) is applied to every harm category, VERTEX_GOOGLE_SEARCH_GROUNDING and
+// VERTEX_CODE_EXECUTION enable the built-in tools
+func (a *VertexAdapter) options() *vertexSettings {
+	a.settingsOnce.Do(func() {
+		if a.settings == nil {
+			a.settings = &vertexSettings{
+				safetyThreshold: utils.GetEnvString("VERTEX_SAFETY_THRESHOLD", ""),
+				googleSearch:    utils.GetEnvBool("VERTEX_GOOGLE_SEARCH_GROUNDING", false),
+				codeExecution:   utils.GetEnvBool("VERTEX_CODE_EXECUTION", false),
+			}
+		}
+	})
+	return a.settings
+}
+
+// KeptRequestFields passes the client's output limit through validation, to be sent as
+// maxOutputTokens
+func (a *VertexAdapter) KeptRequestFields() []string {
+	return []string{"max_tokens", "max_completion_tokens"}
+}
+
+// Endpoint returns the model's generateContent URL, or streamGenerateContent with SSE framing
+// The base URL is the publisher path, e.g.
+// https://us-central1-aiplatform.googleapis.com/v1/projects/PROJECT/locations/us-central1/publishers/google
+func (a *VertexAdapter) Endpoint(baseURL, model string, streaming bool) string {
+	if streaming {
+		return baseURL + "/models/" + model + ":streamGenerateContent?alt=sse"
+	}
+	return baseURL + "/models/" + model + ":generateContent"
+}
+
+// Authorize authenticates with an OAuth access token, a service account key exchanged
+// for an access token, or an API key
+func (a *VertexAdapter) Authorize(req *http.Request, credential config.Credential) error {
+	switch credential.Type {
+	case "api-key":
+		req.Header.Del(utils.HeaderAuthorization)
+		req.Header.Set("x-goog-api-key", credential.Value)
+		return nil
+	case "service-account":
+		token, err := a.tokens.Token(req.Context(), credential.Value)
+		if err != nil {
+			return fmt.Errorf("failed to obtain Vertex AI access token: %w", err)
+		}
+		req.Header.Set(utils.HeaderAuthorization, "Bearer "+token)
+		return nil
+	default:
+		req.Header.Set(utils.HeaderAuthorization, "Bearer "+credential.Value)
+		return nil
+	}
+}
+
+// TranslateRequest converts an OpenAI chat completion request into a generateContent request
+// System messages become the system instruction, assistant turns use the "model" role, tool
+// calls and results become functionCall/functionResponse parts, and consecutive same-role
+// turns are merged
+func (a *VertexAdapter) TranslateRequest(body []byte) ([]byte, error) {
+	var request map[string]interface{}
+	if err := codec.Unmarshal(body, &request); err != nil {
+		return nil, fmt.Errorf("invalid request format: %w", err)
+	}
+
+	messages, _ := request["messages"].([]interface{})
+	var systemParts []interface{}
+	contents := make([]map[string]interface{}, 0, len(messages))
+	// functionResponse parts name the function, which OpenAI tool messages only reference by call ID
+	toolNames := make(map[string]string)
+
+	for _, raw := range messages {
+		message, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		role, _ := message["role"].(string)
+		var parts []interface{}
+		switch role {
+		case "system", "developer":
+			if text := contentText(message["content"]); text != "" {
+				systemParts = append(systemParts, map[string]interface{}{"text": text})
+			}
+			continue
+		case "assistant":
+			role = "model"
+			parts = vertexParts(message["content"])
+			parts = append(parts, vertexFunctionCallParts(message["tool_calls"], toolNames)...)
+		case "tool":
+			role = "user"
+			toolCallID, _ := message["tool_call_id"].(string)
+			parts = []interface{}{map[string]interface{}{
+				"functionResponse": map[string]interface{}{
+					"name":     toolNames[toolCallID],
+					"response": vertexFunctionResponse(message["content"]),
+				},
+			}}
+		default:
+			role = "user"
+			parts = vertexParts(message["content"])
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		if last := len(contents) - 1; last >= 0 && contents[last]["role"] == role {
+			contents[last]["parts"] = append(contents[last]["parts"].([]interface{}), parts...)
+			continue
+		}
+		contents = append(contents, map[string]interface{}{"role": role, "parts": parts})
+	}
+
+	translated := map[string]interface{}{
+		"contents": contents,
+	}
+	if len(systemParts) > 0 {
+		translated["systemInstruction"] = map[string]interface{}{"parts": systemParts}
+	}
+
+	generationConfig := map[string]interface{}{}
+	if seed, ok := request["seed"].(float64); ok {
+		generationConfig["seed"] = int64(seed)
+	}
+	// max_completion_tokens, OpenAI's newer name for the limit, wins over max_tokens
+	for _, field := range []string{"max_tokens", "max_completion_tokens"} {
+		if maxTokens, ok := request[field].(float64); ok && maxTokens > 0 {
+			generationConfig["maxOutputTokens"] = int(maxTokens)
+		}
+	}
+	if len(generationConfig) > 0 {
+		translated["generationConfig"] = generationConfig
+	}
+
+	var tools []interface{}
+	if declarations := vertexFunctionDeclarations(request["tools"]); len(declarations) > 0 {
+		tools = append(tools, map[string]interface{}{"functionDeclarations": declarations})
+		if toolConfig := vertexToolConfig(request["tool_choice"]); toolConfig != nil {
+			translated["toolConfig"] = toolConfig
+		}
+	}
+	settings := a.options()
+	if settings.googleSearch {
+		tools = append(tools, map[string]interface{}{"googleSearch": map[string]interface{}{}})
+	}
+	if settings.codeExecution {
+		tools = append(tools, map[string]interface{}{"codeExecution": map[string]interface{}{}})
+	}
+	if len(tools) > 0 {
+		translated["tools"] = tools
+	}
+
+	if settings.safetyThreshold != "" {
+		safetySettings := make([]interface{}, 0, len(vertexHarmCategories))
+		for _, category := range vertexHarmCategories {
+			safetySettings = append(safetySettings, map[string]interface{}{"category": category, "threshold": settings.safetyThreshold})
+		}
+		translated["safetySettings"] = safetySettings
+	}
+
+	return codec.Marshal(translated)
+}
+
+// vertexResponse is a generateContent response, or one event of a streamed response
+type vertexResponse struct {
+	ResponseID   string            `json:"responseId"`
+	ModelVersion string            `json:"modelVersion"`
+	Candidates   []vertexCandidate `json:"candidates"`
+	Usage        struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	Error map[string]interface{} `json:"error"`
+}
+
+type vertexCandidate struct {
+	Index        int    `json:"index"`
+	FinishReason string `json:"finishReason"`
+	Content      struct {
+		Parts []vertexPart `json:"parts"`
+	} `json:"content"`
+}
+
+type vertexPart struct {
+	Text         string `json:"text"`
+	Thought      bool   `json:"thought"`
+	FunctionCall *struct {
+		ID   string      `json:"id"`
+		Name string      `json:"name"`
+		Args interface{} `json:"args"`
+	} `json:"functionCall"`
+	ExecutableCode *struct {
+		Language string `json:"language"`
+		Code     string `json:"code"`
+	} `json:"executableCode"`
+	CodeExecutionResult *struct {
+		Outcome string `json:"outcome"`
+		Output  string `json:"output"`
+	} `json:"codeExecutionResult"`
+}
+
+// usage returns the OpenAI usage object for the response
+func (r *vertexResponse) usage() map[string]interface{} {
+	total := r.Usage.TotalTokenCount
+	if total == 0 {
+		total = r.Usage.PromptTokenCount + r.Usage.CandidatesTokenCount
+	}
+	return map[string]interface{}{
+		"prompt_tokens":     r.Usage.PromptTokenCount,
+		"completion_tokens": r.Usage.CandidatesTokenCount,
+		"total_tokens":      total,
+	}
+}
+
+// TranslateResponse converts a generateContent response into an OpenAI chat completion
+// Code execution parts are rendered as fenced code blocks in the message content
+func (a *VertexAdapter) TranslateResponse(body []byte) ([]byte, error) {
+	var response vertexResponse
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+
+	choices := make([]interface{}, 0, len(response.Candidates))
+	for _, candidate := range response.Candidates {
+		text, toolCalls, err := vertexCandidateContent(candidate.Content.Parts)
+		if err != nil {
+			return nil, err
+		}
+
+		message := map[string]interface{}{
+			"role":    "assistant",
+			"content": text,
+		}
+		if len(toolCalls) > 0 {
+			message["tool_calls"] = toolCalls
+			if text == "" {
+				message["content"] = nil
+			}
+		}
+		choices = append(choices, map[string]interface{}{
+			"index":         candidate.Index,
+			"message":       message,
+			"finish_reason": vertexFinishReason(candidate.FinishReason, len(toolCalls) > 0),
+		})
+	}
+
+	// A blocked prompt has no candidates; report it as a filtered, empty completion
+	if len(choices) == 0 && response.PromptFeedback.BlockReason != "" {
+		choices = append(choices, map[string]interface{}{
+			"index":         0,
+			"message":       map[string]interface{}{"role": "assistant", "content": ""},
+			"finish_reason": "content_filter",
+		})
+	}
+
+	id := response.ResponseID
+	if id == "" {
+		id = utils.GenerateChatCompletionID()
+	}
+	return codec.Marshal(map[string]interface{}{
+		"id":      id,
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   response.ModelVersion,
+		"choices": choices,
+		"usage":   response.usage(),
+	})
+}
+
+// TranslateStream converts streamGenerateContent server-sent events into OpenAI chunk events
+func (a *VertexAdapter) TranslateStream(r io.Reader) io.Reader {
+	s := &vertexStream{}
+	s.chunkReader = newChunkReader(r, utils.GenerateChatCompletionID(), s.translateEvent, s.finish)
+	return s
+}
+
+// vertexStream produces OpenAI SSE chunks from Vertex AI SSE events
+// Vertex reports the finish reason and usage on its last event, so the final chunk is
+// written once the source ends
+type vertexStream struct {
+	*chunkReader
+	started      bool
+	nextToolID   int
+	finishReason string
+	usage        map[string]interface{}
+}
+
+// translateEvent converts a single streamed generateContent response
+func (s *vertexStream) translateEvent(data []byte) {
+	var event vertexResponse
+	if err := codec.Unmarshal(data, &event); err != nil {
+		return
+	}
+	if event.Error != nil {
+		s.writeError(event.Error)
+		return
+	}
+
+	if event.ModelVersion != "" {
+		s.model = event.ModelVersion
+	}
+	if event.Usage.TotalTokenCount > 0 || event.Usage.PromptTokenCount > 0 {
+		s.usage = event.usage()
+	}
+	if event.PromptFeedback.BlockReason != "" {
+		s.finishReason = "content_filter"
+	}
+	if !s.started {
+		s.started = true
+		s.writeChunk(map[string]interface{}{"role": "assistant", "content": ""}, nil, nil)
+	}
+
+	for _, candidate := range event.Candidates {
+		text, toolCalls, err := vertexCandidateContent(candidate.Content.Parts)
+		if err != nil {
+			continue
+		}
+		if text != "" {
+			s.writeChunk(map[string]interface{}{"content": text}, nil, nil)
+		}
+		for _, call := range toolCalls {
+			call.(map[string]interface{})["index"] = s.nextToolID
+			s.nextToolID++
+			s.writeChunk(map[string]interface{}{"tool_calls": []interface{}{call}}, nil, nil)
+		}
+		if candidate.FinishReason != "" {
+			s.finishReason = vertexFinishReason(candidate.FinishReason, s.nextToolID > 0)
+		}
+	}
+}
+
+// finish queues the final chunk with the finish reason and usage, then [DONE]
+func (s *vertexStream) finish() {
+	if s.started {
+		finishReason := s.finishReason
+		if finishReason == "" {
+			finishReason = "stop"
+		}
+		s.writeChunk(map[string]interface{}{}, finishReason, s.usage)
+	}
+	s.writeDone()
+}
+
+// vertexCandidateContent flattens candidate parts into message text and OpenAI tool calls
+// Thought summaries are dropped; executable code and its output become fenced code blocks
+func vertexCandidateContent(parts []vertexPart) (string, []interface{}, error) {
+	var text strings.Builder
+	var toolCalls []interface{}
+	for _, part := range parts {
+		switch {
+		case part.Thought:
+			continue
+		case part.FunctionCall != nil:
+			args := part.FunctionCall.Args
+			if args == nil {
+				args = map[string]interface{}{}
+			}
+			arguments, err := codec.Marshal(args)
+			if err != nil {
+				return "", nil, fmt.Errorf("%w: invalid functionCall args: %v", ErrInvalidResponse, err)
+			}
+			id := part.FunctionCall.ID
+			if id == "" {
+				id = utils.GenerateToolCallID()
+			}
+			toolCalls = append(toolCalls, map[string]interface{}{
+				"id":   id,
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":      part.FunctionCall.Name,
+					"arguments": string(arguments),
+				},
+			})
+		case part.ExecutableCode != nil:
+			language := strings.ToLower(part.ExecutableCode.Language)
+			if language == "language_unspecified" {
+				language = ""
+			}
+			fmt.Fprintf(&text, "\n```%s\n%s\n```\n", language, strings.TrimRight(part.ExecutableCode.Code, "\n"))
+		case part.CodeExecutionResult != nil:
+			fmt.Fprintf(&text, "\n```\n%s\n```\n", strings.TrimRight(part.CodeExecutionResult.Output, "\n"))
+		default:
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String(), toolCalls, nil
+}
+
+// vertexFinishReason maps a finish reason, reporting "tool_calls" when the model called functions
+func vertexFinishReason(finishReason string, calledTools bool) string {
+	reason, ok := vertexFinishReasons[finishReason]
+	if !ok {
+		reason = "stop"
+	}
+	if calledTools && reason == "stop" {
+		return "tool_calls"
+	}
+	return reason
+}
+
+// vertexParts converts OpenAI message content into Vertex AI parts
+func vertexParts(content interface{}) []interface{} {
+	switch c := content.(type) {
+	case string:
+		if c == "" {
+			return nil
+		}
+		return []interface{}{map[string]interface{}{"text": c}}
+	case []interface{}:
+		parts := make([]interface{}, 0, len(c))
+		for _, part := range c {
+			partMap, ok := part.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch partMap["type"] {
+			case "text":
+				if text, ok := partMap["text"].(string); ok && text != "" {
+					parts = append(parts, map[string]interface{}{"text": text})
+				}
+			case "image_url":
+				imageURL, _ := partMap["image_url"].(map[string]interface{})
+				mediaURL, _ := imageURL["url"].(string)
+				if mediaPart := vertexMediaPart(mediaURL); mediaPart != nil {
+					parts = append(parts, mediaPart)
+				}
+			}
+		}
+		return parts
+	default:
+		return nil
+	}
+}
+
+// vertexMediaPart converts a data: URL into inline data and any other URL into a file reference
+func vertexMediaPart(rawURL string) map[string]interface{} {
+	if dataURL, ok := strings.CutPrefix(rawURL, "data:"); ok {
+		mimeType, data, ok := strings.Cut(dataURL, ";base64,")
+		if !ok {
+			return nil
+		}
+		return map[string]interface{}{
+			"inlineData": map[string]interface{}{"mimeType": mimeType, "data": data},
+		}
+	}
+	if rawURL == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"fileData": map[string]interface{}{"mimeType": guessMediaType(rawURL), "fileUri": rawURL},
+	}
+}
+
+// guessMediaType infers an image media type from a URL's extension, defaulting to JPEG
+func guessMediaType(rawURL string) string {
+	if parsed, err := url.Parse(rawURL); err == nil {
+		if mediaType := mime.TypeByExtension(strings.ToLower(path.Ext(parsed.Path))); mediaType != "" {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+			return mediaType
+		}
+	}
+	return "image/jpeg"
+}
+
+// vertexFunctionCallParts converts assistant tool_calls into functionCall parts,
+// remembering each call's function name for the matching tool result
+func vertexFunctionCallParts(toolCalls interface{}, toolNames map[string]string) []interface{} {
+	calls, _ := toolCalls.([]interface{})
+	parts := make([]interface{}, 0, len(calls))
+	for _, call := range calls {
+		callMap, ok := call.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		function, _ := callMap["function"].(map[string]interface{})
+		name, _ := function["name"].(string)
+		if id, ok := callMap["id"].(string); ok {
+			toolNames[id] = name
+		}
+
+		arguments, _ := function["arguments"].(string)
+		var args interface{} = map[string]interface{}{}
+		if arguments != "" {
+			if err := codec.Unmarshal([]byte(arguments), &args); err != nil {
+				args = map[string]interface{}{}
+			}
+		}
+
+		parts = append(parts, map[string]interface{}{
+			"functionCall": map[string]interface{}{"name": name, "args": args},
+		})
+	}
+	return parts
+}
+
+// vertexFunctionResponse wraps tool output as the object Vertex AI expects,
+// passing JSON objects through and wrapping anything else under "content"
+func vertexFunctionResponse(content interface{}) map[string]interface{} {
+	text := contentText(content)
+	var object map[string]interface{}
+	if err := codec.Unmarshal([]byte(text), &object); err == nil && object != nil {
+		return object
+	}
+	return map[string]interface{}{"content": text}
+}
+
+// vertexFunctionDeclarations converts OpenAI function tools into Vertex AI function declarations
+func vertexFunctionDeclarations(tools interface{}) []interface{} {
+	list, _ := tools.([]interface{})
+	declarations := make([]interface{}, 0, len(list))
+	for _, tool := range list {
+		toolMap, ok := tool.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		function, ok := toolMap["function"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		declaration := map[string]interface{}{"name": function["name"]}
+		if description, ok := function["description"].(string); ok && description != "" {
+			declaration["description"] = description
+		}
+		if parameters, ok := function["parameters"].(map[string]interface{}); ok {
+			declaration["parameters"] = sanitizeVertexSchema(parameters)
+		}
+		declarations = append(declarations, declaration)
+	}
+	return declarations
+}
+
+// sanitizeVertexSchema returns a copy of a JSON schema without keywords Vertex AI rejects
+func sanitizeVertexSchema(schema interface{}) interface{} {
+	switch s := schema.(type) {
+	case map[string]interface{}:
+		cleaned := make(map[string]interface{}, len(s))
+		for key, value := range s {
+			cleaned[key] = sanitizeVertexSchema(value)
+		}
+		for _, key := range vertexUnsupportedSchemaKeys {
+			delete(cleaned, key)
+		}
+		return cleaned
+	case []interface{}:
+		cleaned := make([]interface{}, len(s))
+		for i, value := range s {
+			cleaned[i] = sanitizeVertexSchema(value)
+		}
+		return cleaned
+	default:
+		return schema
+	}
+}
+
+// vertexToolConfig converts an OpenAI tool_choice into a Vertex AI function calling config
+func vertexToolConfig(toolChoice interface{}) map[string]interface{} {
+	callingConfig := map[string]interface{}{}
+	switch choice := toolChoice.(type) {
+	case string:
+		switch choice {
+		case "auto":
+			callingConfig["mode"] = "AUTO"
+		case "none":
+			callingConfig["mode"] = "NONE"
+		case "required":
+			callingConfig["mode"] = "ANY"
+		default:
+			return nil
+		}
+	case map[string]interface{}:
+		function, ok := choice["function"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		callingConfig["mode"] = "ANY"
+		callingConfig["allowedFunctionNames"] = []interface{}{function["name"]}
+	default:
+		return nil
+	}
+	return map[string]interface{}{"functionCallingConfig": callingConfig}
+}
@@ -0,0 +1,206 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVertexAdapter_TranslateRequest(t *testing.T) {
+	adapter := &VertexAdapter{settings: &vertexSettings{safetyThreshold: "BLOCK_ONLY_HIGH", googleSearch: true}}
+	body := `{
+		"model": "gemini-2.5-pro",
+		"seed": 7,
+		"max_completion_tokens": 256,
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is this?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBOR"}},
+				{"type": "image_url", "image_url": {"url": "https://example.com/cat.webp?size=large"}}
+			]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":\"png\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "an image"},
+			{"role": "user", "content": "Thanks"}
+		],
+		"tools": [{"type": "function", "function": {"name": "lookup", "parameters": {"type": "object", "additionalProperties": false, "properties": {"q": {"type": "string"}}}}}],
+		"tool_choice": {"type": "function", "function": {"name": "lookup"}}
+	}`
+
+	translated, err := adapter.TranslateRequest([]byte(body))
+	require.NoError(t, err)
+
+	expected := `{
+		"systemInstruction": {"parts": [{"text": "Be brief."}]},
+		"contents": [
+			{"role": "user", "parts": [
+				{"text": "What is this?"},
+				{"inlineData": {"mimeType": "image/png", "data": "iVBOR"}},
+				{"fileData": {"mimeType": "image/webp", "fileUri": "https://example.com/cat.webp?size=large"}}
+			]},
+			{"role": "model", "parts": [
+				{"functionCall": {"name": "lookup", "args": {"q": "png"}}}
+			]},
+			{"role": "user", "parts": [
+				{"functionResponse": {"name": "lookup", "response": {"content": "an image"}}},
+				{"text": "Thanks"}
+			]}
+		],
+		"generationConfig": {"seed": 7, "maxOutputTokens": 256},
+		"tools": [
+			{"functionDeclarations": [{"name": "lookup", "parameters": {"type": "object", "properties": {"q": {"type": "string"}}}}]},
+			{"googleSearch": {}}
+		],
+		"toolConfig": {"functionCallingConfig": {"mode": "ANY", "allowedFunctionNames": ["lookup"]}},
+		"safetySettings": [
+			{"category": "HARM_CATEGORY_HATE_SPEECH", "threshold": "BLOCK_ONLY_HIGH"},
+			{"category": "HARM_CATEGORY_DANGEROUS_CONTENT", "threshold": "BLOCK_ONLY_HIGH"},
+			{"category": "HARM_CATEGORY_SEXUALLY_EXPLICIT", "threshold": "BLOCK_ONLY_HIGH"},
+			{"category": "HARM_CATEGORY_HARASSMENT", "threshold": "BLOCK_ONLY_HIGH"}
+		]
+	}`
+	assert.JSONEq(t, expected, string(translated))
+}
+
+func TestVertexAdapter_SettingsReadOnFirstUse(t *testing.T) {
+	// Adapters are created at package init, before the .env file is loaded
+	adapter := NewVertexAdapter()
+	t.Setenv("VERTEX_SAFETY_THRESHOLD", "BLOCK_NONE")
+	t.Setenv("VERTEX_CODE_EXECUTION", "true")
+
+	translated, err := adapter.TranslateRequest([]byte(`{"model":"gemini-2.5-pro","messages":[{"role":"user","content":"Hi"}]}`))
+	require.NoError(t, err)
+
+	var request map[string]interface{}
+	require.NoError(t, json.Unmarshal(translated, &request))
+	assert.Equal(t, []interface{}{map[string]interface{}{"codeExecution": map[string]interface{}{}}}, request["tools"])
+	assert.Len(t, request["safetySettings"], len(vertexHarmCategories))
+}
+
+func TestVertexAdapter_TranslateResponse(t *testing.T) {
+	adapter := &VertexAdapter{}
+	body := `{
+		"responseId": "resp-1",
+		"modelVersion": "gemini-2.5-pro",
+		"candidates": [{
+			"index": 0,
+			"finishReason": "STOP",
+			"content": {"role": "model", "parts": [
+				{"text": "thinking...", "thought": true},
+				{"text": "Result:"},
+				{"executableCode": {"language": "PYTHON", "code": "print(1 + 1)"}},
+				{"codeExecutionResult": {"outcome": "OUTCOME_OK", "output": "2\n"}},
+				{"functionCall": {"name": "lookup", "args": {"q": "x"}}}
+			]}
+		}],
+		"usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 5, "totalTokenCount": 15}
+	}`
+
+	translated, err := adapter.TranslateResponse([]byte(body))
+	require.NoError(t, err)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(translated, &response))
+	assert.Equal(t, "resp-1", response["id"])
+	assert.Equal(t, map[string]interface{}{"prompt_tokens": float64(10), "completion_tokens": float64(5), "total_tokens": float64(15)}, response["usage"])
+
+	choice := response["choices"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "tool_calls", choice["finish_reason"])
+	message := choice["message"].(map[string]interface{})
+	assert.Equal(t, "Result:\n```python\nprint(1 + 1)\n```\n\n```\n2\n```\n", message["content"])
+	toolCall := message["tool_calls"].([]interface{})[0].(map[string]interface{})
+	assert.JSONEq(t, `{"name":"lookup","arguments":"{\"q\":\"x\"}"}`, mustJSON(t, toolCall["function"]))
+	assert.NotEmpty(t, toolCall["id"])
+
+	assert.NoError(t, NewResponseStandardizer().validateVendorResponse(translated, "vertex"))
+}
+
+func TestVertexAdapter_TranslateResponse_BlockedPrompt(t *testing.T) {
+	translated, err := (&VertexAdapter{}).TranslateResponse([]byte(`{"promptFeedback":{"blockReason":"SAFETY"},"usageMetadata":{"promptTokenCount":4}}`))
+	require.NoError(t, err)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(translated, &response))
+	choice := response["choices"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "content_filter", choice["finish_reason"])
+}
+
+func TestVertexAdapter_TranslateStream(t *testing.T) {
+	events := strings.Join([]string{
+		`data: {"responseId":"resp-1","modelVersion":"gemini-2.5-pro","candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]}}]}`,
+		"",
+		`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"lo"},{"functionCall":{"name":"lookup","args":{"q":"x"}}}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":7,"candidatesTokenCount":3,"totalTokenCount":10}}`,
+		"",
+	}, "\n")
+
+	output, err := io.ReadAll((&VertexAdapter{}).TranslateStream(strings.NewReader(events)))
+	require.NoError(t, err)
+
+	chunks := strings.Split(strings.TrimSpace(string(output)), "\n\n")
+	require.Len(t, chunks, 6)
+	assert.Contains(t, chunks[0], `"role":"assistant"`)
+	assert.Contains(t, chunks[1], `"content":"Hel"`)
+	assert.Contains(t, chunks[2], `"content":"lo"`)
+	assert.Contains(t, chunks[3], `"name":"lookup"`)
+	assert.Contains(t, chunks[3], `"index":0`)
+	assert.Contains(t, chunks[4], `"finish_reason":"tool_calls"`)
+	assert.Contains(t, chunks[4], `"total_tokens":10`)
+	assert.Equal(t, "data: [DONE]", chunks[5])
+}
+
+func TestVertexAdapter_Endpoint(t *testing.T) {
+	adapter := adapterFor("vertex")
+	base := "https://us-central1-aiplatform.googleapis.com/v1/projects/p/locations/us-central1/publishers/google"
+	assert.Equal(t, base+"/models/gemini-2.5-pro:generateContent", adapter.Endpoint(base, "gemini-2.5-pro", false))
+	assert.Equal(t, base+"/models/gemini-2.5-pro:streamGenerateContent?alt=sse", adapter.Endpoint(base, "gemini-2.5-pro", true))
+}
+
+func TestVertexAdapter_AuthorizeServiceAccount(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	encoded, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+
+	var exchanges int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&exchanges, 1)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+		assert.Len(t, strings.Split(r.Form.Get("assertion"), "."), 3)
+		w.Write([]byte(`{"access_token":"ya29.token","expires_in":3600}`))
+	}))
+	defer tokenServer.Close()
+
+	key, err := json.Marshal(map[string]string{
+		"client_email": "router@project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: encoded})),
+		"token_uri":    tokenServer.URL,
+	})
+	require.NoError(t, err)
+
+	adapter := &VertexAdapter{tokens: newServiceAccountTokens()}
+	credential := config.Credential{Platform: "vertex", Type: "service-account", Value: string(key)}
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+		require.NoError(t, adapter.Authorize(req, credential))
+		assert.Equal(t, "Bearer ya29.token", req.Header.Get("Authorization"))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&exchanges), "tokens are cached until they near expiry")
+
+	invalid := config.Credential{Platform: "vertex", Type: "service-account", Value: "not a key"}
+	assert.Error(t, adapter.Authorize(httptest.NewRequest(http.MethodPost, "http://example.com", nil), invalid))
+}
@@ -0,0 +1,211 @@
+package proxy
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+)
+
+const (
+	// vertexScope is the OAuth scope requested for service account access tokens
+	vertexScope = "https://www.googleapis.com/auth/cloud-platform"
+	// googleTokenURL is used when a service account key does not name its token endpoint
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+	// tokenRefreshMargin refreshes cached tokens this long before they expire
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// serviceAccountKey holds the fields of a Google service account JSON key used for token exchange
+type serviceAccountKey struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// cachedToken is an access token and the time it stops being usable
+type cachedToken struct {
+	value  string
+	expiry time.Time
+}
+
+// serviceAccountTokens exchanges service account keys for OAuth access tokens
+// using the JWT bearer grant, caching each token until shortly before it expires
+type serviceAccountTokens struct {
+	mu         sync.Mutex
+	httpClient *http.Client
+	tokens     map[string]cachedToken
+	now        func() time.Time
+}
+
+// newServiceAccountTokens creates a token cache using its own short-timeout HTTP client
+func newServiceAccountTokens() *serviceAccountTokens {
+	return &serviceAccountTokens{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		tokens:     make(map[string]cachedToken),
+		now:        time.Now,
+	}
+}
+
+// Token returns an access token for the raw service account key (JSON or base64-encoded JSON)
+func (t *serviceAccountTokens) Token(ctx context.Context, rawKey string) (string, error) {
+	cacheKey := fmt.Sprintf("%x", sha256.Sum256([]byte(rawKey)))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if token, ok := t.tokens[cacheKey]; ok && t.now().Add(tokenRefreshMargin).Before(token.expiry) {
+		return token.value, nil
+	}
+
+	key, err := parseServiceAccountKey(rawKey)
+	if err != nil {
+		return "", err
+	}
+	token, err := t.exchange(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	t.tokens[cacheKey] = token
+	return token.value, nil
+}
+
+// exchange signs a JWT assertion with the key and trades it for an access token
+func (t *serviceAccountTokens) exchange(ctx context.Context, key *serviceAccountKey) (cachedToken, error) {
+	tokenURL := key.TokenURI
+	if tokenURL == "" {
+		tokenURL = googleTokenURL
+	}
+
+	assertion, err := signServiceAccountJWT(key, tokenURL, t.now())
+	if err != nil {
+		return cachedToken{}, err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return cachedToken{}, fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := codec.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return cachedToken{}, fmt.Errorf("token endpoint returned no access token")
+	}
+	return cachedToken{
+		value:  token.AccessToken,
+		expiry: t.now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// parseServiceAccountKey decodes a service account key given as JSON or base64-encoded JSON
+func parseServiceAccountKey(raw string) (*serviceAccountKey, error) {
+	data := []byte(strings.TrimSpace(raw))
+	if len(data) > 0 && data[0] != '{' {
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("service account key is neither JSON nor base64-encoded JSON")
+		}
+		data = decoded
+	}
+
+	var key serviceAccountKey
+	if err := codec.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("invalid service account key: %w", err)
+	}
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, fmt.Errorf("service account key is missing client_email or private_key")
+	}
+	return &key, nil
+}
+
+// signServiceAccountJWT builds the RS256-signed assertion for the JWT bearer grant
+func signServiceAccountJWT(key *serviceAccountKey, audience string, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return "", fmt.Errorf("service account private_key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return "", fmt.Errorf("invalid service account private_key: %w", err)
+		}
+	}
+	privateKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("service account private_key is not an RSA key")
+	}
+
+	header := map[string]string{"alg": "RS256", "typ": "JWT"}
+	if key.PrivateKeyID != "" {
+		header["kid"] = key.PrivateKeyID
+	}
+	claims := map[string]interface{}{
+		"iss":   key.ClientEmail,
+		"scope": vertexScope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}
+
+	encodedHeader, err := encodeJWTSegment(header)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := encodeJWTSegment(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encodedHeader + "." + encodedClaims
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(nil, privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token assertion: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// encodeJWTSegment JSON-encodes a JWT header or claim set as unpadded base64url
+func encodeJWTSegment(value interface{}) (string, error) {
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode token assertion: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
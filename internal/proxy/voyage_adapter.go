@@ -0,0 +1,46 @@
+package proxy
+
+import (
+	"github.com/aashari/go-generative-api-router/internal/codec"
+)
+
+// VoyageAdapter routes rerank requests to Voyage AI, which names top_n top_k and returns
+// its results in data
+type VoyageAdapter struct {
+	rerankOnlyAdapter
+}
+
+func init() {
+	RegisterVendorAdapter("voyage", func() VendorAdapter { return NewVoyageAdapter() })
+}
+
+// NewVoyageAdapter creates a Voyage AI adapter
+func NewVoyageAdapter() *VoyageAdapter {
+	return &VoyageAdapter{}
+}
+
+// TranslateRerankRequest sends top_n as top_k
+func (a *VoyageAdapter) TranslateRerankRequest(body []byte) ([]byte, error) {
+	var request map[string]interface{}
+	if err := codec.Unmarshal(body, &request); err != nil {
+		return nil, err
+	}
+	if topN, ok := request["top_n"]; ok {
+		request["top_k"] = topN
+		delete(request, "top_n")
+	}
+	return codec.Marshal(request)
+}
+
+// TranslateRerankResponse moves the results out of data
+func (a *VoyageAdapter) TranslateRerankResponse(body []byte) ([]byte, error) {
+	var response map[string]interface{}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	if data, ok := response["data"]; ok {
+		response["results"] = data
+		delete(response, "data")
+	}
+	return codec.Marshal(response)
+}
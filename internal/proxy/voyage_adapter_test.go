@@ -0,0 +1,23 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoyageAdapter_Rerank(t *testing.T) {
+	adapter := NewVoyageAdapter()
+	assert.Equal(t, "https://api.voyageai.com/v1/rerank", adapter.RerankEndpoint("https://api.voyageai.com/v1"))
+
+	translated, err := adapter.TranslateRerankRequest([]byte(`{"model":"rerank-2","query":"q","documents":["a"],"top_n":1,"return_documents":false}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"model":"rerank-2","query":"q","documents":["a"],"top_k":1,"return_documents":false}`, string(translated))
+
+	translated, err = adapter.TranslateRerankResponse([]byte(`{"object":"list","data":[{"index":0,"relevance_score":0.5}],"model":"rerank-2","usage":{"total_tokens":4}}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"object":"list","results":[{"index":0,"relevance_score":0.5}],"model":"rerank-2","usage":{"total_tokens":4}}`, string(translated))
+	assert.True(t, SupportsRerank("voyage"))
+	assert.False(t, SupportsRerank("openai"))
+}
@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// watermarkFormat versions the signed payload, so the fields it covers can change compatibly
+const watermarkFormat = "v1"
+
+// Watermark is signed metadata proving which router build, vendor and model produced a response
+// Signature is the hex HMAC-SHA256, keyed with WATERMARK_KEY, of the other fields joined by
+// newlines after the format version (see signingPayload)
+type Watermark struct {
+	Format        string `json:"format"`
+	RouterVersion string `json:"router_version"`
+	Vendor        string `json:"vendor"`
+	Model         string `json:"model"`
+	ResponseID    string `json:"response_id"`
+	Timestamp     int64  `json:"timestamp"`
+	// ContentSHA256 is the hex SHA-256 of the message content of each choice, in order and
+	// separated by newlines. It is omitted for streams, whose content is not known up front
+	ContentSHA256 string `json:"content_sha256,omitempty"`
+	Signature     string `json:"signature"`
+}
+
+// watermarkKey returns the HMAC key watermarks are signed with, or nil when none is configured
+func watermarkKey() []byte {
+	key := utils.GetEnvString("WATERMARK_KEY", "")
+	if key == "" {
+		return nil
+	}
+	return []byte(key)
+}
+
+// signWatermark stamps w with the router version and vendor/model and signs it with key
+func signWatermark(key []byte, w Watermark, vendor, model string) *Watermark {
+	w.Format = watermarkFormat
+	w.RouterVersion = os.Getenv("VERSION")
+	w.Vendor = vendor
+	w.Model = model
+	w.Signature = hex.EncodeToString(watermarkMAC(key, w))
+	return &w
+}
+
+// VerifyWatermark reports whether w was signed with key and its fields are unchanged
+func VerifyWatermark(key []byte, w Watermark) bool {
+	signature, err := hex.DecodeString(w.Signature)
+	if err != nil || w.Format != watermarkFormat {
+		return false
+	}
+	return hmac.Equal(signature, watermarkMAC(key, w))
+}
+
+// watermarkMAC returns the HMAC-SHA256 of w's signing payload
+func watermarkMAC(key []byte, w Watermark) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(w.signingPayload()))
+	return mac.Sum(nil)
+}
+
+// signingPayload returns the fields covered by the signature, one per line
+func (w Watermark) signingPayload() string {
+	return strings.Join([]string{
+		w.Format,
+		w.RouterVersion,
+		w.Vendor,
+		w.Model,
+		w.ResponseID,
+		strconv.FormatInt(w.Timestamp, 10),
+		w.ContentSHA256,
+	}, "\n")
+}
+
+// responseWatermark returns the unsigned watermark of a processed chat completion body
+func responseWatermark(body []byte) *Watermark {
+	var response struct {
+		ID      string `json:"id"`
+		Created int64  `json:"created"`
+		Choices []struct {
+			Message struct {
+				Content interface{} `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := codec.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+	contents := make([]string, 0, len(response.Choices))
+	for _, choice := range response.Choices {
+		content, _ := choice.Message.Content.(string)
+		contents = append(contents, content)
+	}
+	return &Watermark{
+		ResponseID:    response.ID,
+		Timestamp:     response.Created,
+		ContentSHA256: ContentDigest(contents...),
+	}
+}
+
+// ContentDigest returns the hex SHA-256 a watermark records for the given choice contents
+func ContentDigest(contents ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(contents, "\n")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatermark(t *testing.T) {
+	t.Setenv("VERSION", "1.2.3")
+	key := []byte("watermark-secret")
+
+	unsigned := responseWatermark([]byte(`{"id":"chatcmpl-1","created":1700000000,"choices":[
+		{"message":{"role":"assistant","content":"Hello"}},
+		{"message":{"role":"assistant","content":"Hi"}}]}`))
+	require.NotNil(t, unsigned)
+	assert.Equal(t, ContentDigest("Hello", "Hi"), unsigned.ContentSHA256)
+
+	watermark := signWatermark(key, *unsigned, "openai", "gpt-4o")
+	assert.Equal(t, Watermark{
+		Format:        "v1",
+		RouterVersion: "1.2.3",
+		Vendor:        "openai",
+		Model:         "gpt-4o",
+		ResponseID:    "chatcmpl-1",
+		Timestamp:     1700000000,
+		ContentSHA256: ContentDigest("Hello", "Hi"),
+		Signature:     watermark.Signature,
+	}, *watermark)
+	assert.True(t, VerifyWatermark(key, *watermark))
+	assert.False(t, VerifyWatermark([]byte("other-key"), *watermark))
+
+	tampered := *watermark
+	tampered.Model = "gpt-4o-mini"
+	assert.False(t, VerifyWatermark(key, tampered), "the model is covered by the signature")
+
+	tampered = *watermark
+	tampered.ContentSHA256 = ContentDigest("Goodbye", "Hi")
+	assert.False(t, VerifyWatermark(key, tampered), "the content is covered by the signature")
+
+	assert.Nil(t, responseWatermark([]byte(`not json`)))
+}
+
+func TestResponseExtensions_Watermark(t *testing.T) {
+	t.Setenv("RESPONSE_EXTENSIONS", "watermark")
+	r := httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	unsigned := func() *Watermark { return &Watermark{ResponseID: "chatcmpl-1", Timestamp: 1700000000} }
+
+	assert.Nil(t, responseExtensions(r, "openai", "gpt-4o", nil, unsigned), "no watermark without a key")
+
+	t.Setenv("WATERMARK_KEY", "watermark-secret")
+	extensions := responseExtensions(r, "openai", "gpt-4o", nil, unsigned)
+	require.Contains(t, extensions, ExtensionWatermark)
+	watermark := extensions[ExtensionWatermark].(*Watermark)
+	assert.Equal(t, "gpt-4o", watermark.Model)
+	assert.True(t, VerifyWatermark([]byte("watermark-secret"), *watermark))
+}
@@ -0,0 +1,161 @@
+package quarantine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Default prober settings, overridable with QUARANTINE_PROBE_INTERVAL and QUARANTINE_PROBE_TIMEOUT
+const (
+	DefaultProbeInterval = time.Minute
+	DefaultProbeTimeout  = 30 * time.Second
+)
+
+// Client sends a request to a vendor; the proxy's API client satisfies it and reports the
+// vendor's response to Default(), which is what releases a probed credential
+type Client interface {
+	SendRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, modifiedBody []byte, originalModel string) error
+}
+
+// Prober re-tests quarantined credentials with a minimal chat completion on a chat model of
+// their vendor, so credentials the vendor accepts again return to rotation
+type Prober struct {
+	Config     *config.Store
+	Client     Client
+	Quarantine *Quarantine
+	Interval   time.Duration
+	Timeout    time.Duration
+}
+
+// NewProberFromEnv creates a prober for Default(), or returns nil when QUARANTINE_THRESHOLD
+// is 0, which disables quarantine
+func NewProberFromEnv(store *config.Store, client Client) *Prober {
+	if !Default().Enabled() {
+		return nil
+	}
+	return &Prober{
+		Config:     store,
+		Client:     client,
+		Quarantine: Default(),
+		Interval:   utils.GetEnvDuration("QUARANTINE_PROBE_INTERVAL", DefaultProbeInterval),
+		Timeout:    utils.GetEnvDuration("QUARANTINE_PROBE_TIMEOUT", DefaultProbeTimeout),
+	}
+}
+
+// Start probes every Interval until ctx is cancelled
+func (p *Prober) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.RunOnce(ctx)
+			}
+		}
+	}()
+}
+
+// RunOnce probes every quarantined credential once and returns the quarantine left afterwards
+func (p *Prober) RunOnce(ctx context.Context) []Entry {
+	ctx = logger.WithComponent(ctx, "CredentialQuarantine")
+	ctx = logger.WithStage(ctx, "Probe")
+
+	snapshot := p.Config.Snapshot()
+	creds := snapshot.Credentials()
+	models := filter.ModelsByType(snapshot.Models(), config.ModelTypeChat)
+
+	for _, entry := range p.Quarantine.Entries(creds) {
+		cred := creds[entry.Index]
+		if err := p.probe(ctx, cred, models); err != nil {
+			logger.Info(ctx, "Quarantined credential still failing",
+				"platform", cred.Platform,
+				"index", entry.Index,
+				"error", err.Error(),
+			)
+		}
+	}
+	return p.Quarantine.Entries(creds)
+}
+
+// probe sends a one-token completion with cred to the first chat model of its vendor
+func (p *Prober) probe(ctx context.Context, cred config.Credential, models []config.VendorModel) error {
+	var selection *selector.VendorSelection
+	for _, model := range models {
+		if model.Vendor == cred.Platform {
+			selection = &selector.VendorSelection{
+				Vendor:     model.Vendor,
+				Model:      model.Model,
+				Credential: cred,
+				BaseURL:    model.BaseURL,
+				AuthHeader: model.AuthHeader,
+			}
+			break
+		}
+	}
+	if selection == nil {
+		return fmt.Errorf("no chat model configured for vendor %s", cred.Platform)
+	}
+	p.Quarantine.Probed(cred)
+
+	body, err := codec.Marshal(map[string]interface{}{
+		"model":      selection.Model,
+		"messages":   []map[string]string{{"role": "user", "content": "ping"}},
+		"max_tokens": 1,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(utils.HeaderContentType, "application/json")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Client.SendRequest(discardWriter{header: make(http.Header)}, req, selection, body, selection.Model)
+	}()
+
+	timer := time.NewTimer(p.Timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("no response within %s", p.Timeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// discardWriter drops the response to a probe; only the vendor's status matters, and the
+// API client has reported it by the time SendRequest returns
+type discardWriter struct {
+	header http.Header
+}
+
+func (w discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w discardWriter) Write(p []byte) (int, error) {
+	return io.Discard.Write(p)
+}
+
+func (w discardWriter) WriteHeader(int) {}
@@ -0,0 +1,58 @@
+package quarantine
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient answers with a canned status per credential and reports it to the quarantine,
+// as the API client does
+type fakeClient struct {
+	quarantine *Quarantine
+	statuses   map[string]int
+	probed     []string
+}
+
+func (f *fakeClient) SendRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, body []byte, originalModel string) error {
+	f.probed = append(f.probed, selection.Credential.Value+"@"+selection.Model)
+	status := f.statuses[selection.Credential.Value]
+	f.quarantine.Observe(selection.Credential, status)
+	w.WriteHeader(status)
+	return nil
+}
+
+func TestProber_RunOnce(t *testing.T) {
+	creds := []config.Credential{
+		{Platform: "openai", Value: "sk-healthy"},
+		{Platform: "openai", Value: "sk-revoked"},
+		{Platform: "openai", Value: "sk-fine"},
+	}
+	store := config.NewStore(config.NewSnapshot(config.Data{
+		Credentials: creds,
+		Models:      []config.VendorModel{{Vendor: "openai", Model: "gpt-4o-mini"}, {Vendor: "openai", Model: "gpt-4o"}},
+	}))
+	q := New(1)
+	q.Observe(creds[0], http.StatusTooManyRequests)
+	q.Observe(creds[1], http.StatusUnauthorized)
+
+	client := &fakeClient{quarantine: q, statuses: map[string]int{
+		"sk-healthy": http.StatusOK,
+		"sk-revoked": http.StatusUnauthorized,
+	}}
+	prober := &Prober{Config: store, Client: client, Quarantine: q, Interval: time.Minute, Timeout: time.Second}
+
+	entries := prober.RunOnce(context.Background())
+	assert.Equal(t, []string{"sk-healthy@gpt-4o-mini", "sk-revoked@gpt-4o-mini"}, client.probed, "only quarantined credentials are probed")
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].Index)
+	assert.Equal(t, 2, entries[0].Failures)
+	assert.False(t, entries[0].LastProbe.IsZero())
+	assert.False(t, q.Quarantined(creds[0]), "a probe the vendor accepts releases the credential")
+}
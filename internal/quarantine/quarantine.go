@@ -0,0 +1,219 @@
+// Package quarantine takes credentials a vendor keeps refusing, with 401, 403 or 429
+// responses, out of routing and probes them in the background until the vendor accepts
+// them again
+package quarantine
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// DefaultThreshold is how many refusals in a row quarantine a credential when
+// QUARANTINE_THRESHOLD is unset
+const DefaultThreshold = 3
+
+// Entry is a credential's run of refusals, identified by its position in the configuration
+type Entry struct {
+	Index    int    `json:"index"`
+	Platform string `json:"platform"`
+	Type     string `json:"type"`
+	// Failures is how many refusals in a row the credential received
+	Failures int `json:"consecutive_failures"`
+	// LastStatus is the HTTP status of the latest refusal
+	LastStatus int `json:"last_status"`
+	// Since is when the credential was quarantined, zero while it is below the threshold
+	Since time.Time `json:"quarantined_since,omitempty"`
+	// LastProbe is when the credential was last probed, zero before the first probe
+	LastProbe time.Time `json:"last_probe,omitempty"`
+}
+
+// record is the refusal run of one credential
+type record struct {
+	failures   int
+	lastStatus int
+	since      time.Time
+	lastProbe  time.Time
+}
+
+// Quarantine counts the refusals in a row of each credential and quarantines those that
+// reach the threshold, until a response shows the vendor accepts them again
+type Quarantine struct {
+	mu        sync.RWMutex
+	threshold int
+	records   map[string]*record // SHA-256 hex digest of the credential -> refusal run
+	now       func() time.Time
+}
+
+var (
+	defaultQuarantine     *Quarantine
+	defaultQuarantineOnce sync.Once
+)
+
+// New creates a quarantine for credentials refused threshold times in a row; a
+// non-positive threshold never quarantines
+func New(threshold int) *Quarantine {
+	return &Quarantine{threshold: threshold, records: make(map[string]*record), now: time.Now}
+}
+
+// Default returns the process-wide quarantine, with its threshold set from
+// QUARANTINE_THRESHOLD; 0 disables it
+func Default() *Quarantine {
+	defaultQuarantineOnce.Do(func() {
+		defaultQuarantine = New(utils.GetEnvInt("QUARANTINE_THRESHOLD", DefaultThreshold))
+	})
+	return defaultQuarantine
+}
+
+// Enabled reports whether credentials can be quarantined
+func (q *Quarantine) Enabled() bool {
+	return q.threshold > 0
+}
+
+// Threshold is how many refusals in a row quarantine a credential
+func (q *Quarantine) Threshold() int {
+	return q.threshold
+}
+
+// IsRefusal reports whether a vendor response status refuses the credential: 401, 403 or 429
+func IsRefusal(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden || status == http.StatusTooManyRequests
+}
+
+// Observe records the status of a vendor response to a request sent with cred. A refusal
+// extends the credential's run, quarantining it at the threshold; any other response below
+// 500 shows the vendor accepts the credential, ending the run and any quarantine. Server
+// errors say nothing about the credential and are ignored
+func (q *Quarantine) Observe(cred config.Credential, status int) {
+	if !q.Enabled() || status >= http.StatusInternalServerError {
+		return
+	}
+	key := credentialKey(cred)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	rec, ok := q.records[key]
+	if !IsRefusal(status) {
+		if ok {
+			delete(q.records, key)
+			if !rec.since.IsZero() {
+				logger.Info(context.Background(), "Credential released from quarantine",
+					"platform", cred.Platform,
+					"status", status,
+					"quarantined_for", q.now().Sub(rec.since).Round(time.Second).String(),
+					"component", "CredentialQuarantine",
+					"stage", "Released",
+				)
+			}
+		}
+		return
+	}
+
+	if !ok {
+		rec = &record{}
+		q.records[key] = rec
+	}
+	rec.failures++
+	rec.lastStatus = status
+	if rec.since.IsZero() && rec.failures >= q.threshold {
+		rec.since = q.now()
+		logger.Warn(context.Background(), "Credential quarantined",
+			"platform", cred.Platform,
+			"status", status,
+			"consecutive_failures", rec.failures,
+			"component", "CredentialQuarantine",
+			"stage", "Quarantined",
+		)
+	}
+}
+
+// Quarantined reports whether cred is quarantined
+func (q *Quarantine) Quarantined(cred config.Credential) bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	rec, ok := q.records[credentialKey(cred)]
+	return ok && !rec.since.IsZero()
+}
+
+// Probed records that cred was just probed
+func (q *Quarantine) Probed(cred config.Credential) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if rec, ok := q.records[credentialKey(cred)]; ok {
+		rec.lastProbe = q.now()
+	}
+}
+
+// Entries returns the quarantined credentials among creds, in configuration order
+func (q *Quarantine) Entries(creds []config.Credential) []Entry {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	entries := []Entry{}
+	for i, cred := range creds {
+		rec, ok := q.records[credentialKey(cred)]
+		if !ok || rec.since.IsZero() {
+			continue
+		}
+		entries = append(entries, Entry{
+			Index:      i,
+			Platform:   cred.Platform,
+			Type:       cred.Type,
+			Failures:   rec.failures,
+			LastStatus: rec.lastStatus,
+			Since:      rec.since,
+			LastProbe:  rec.lastProbe,
+		})
+	}
+	return entries
+}
+
+// Filter removes quarantined credentials, and models of vendors left without credentials,
+// from a routing pool. When it would leave nothing to route to, the pool is returned unchanged
+func (q *Quarantine) Filter(creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if len(q.records) == 0 {
+		return creds, models
+	}
+
+	var keptCreds []config.Credential
+	vendors := make(map[string]bool)
+	for _, cred := range creds {
+		if rec, ok := q.records[credentialKey(cred)]; ok && !rec.since.IsZero() {
+			continue
+		}
+		keptCreds = append(keptCreds, cred)
+		vendors[cred.Platform] = true
+	}
+	if len(keptCreds) == len(creds) {
+		return creds, models
+	}
+
+	var keptModels []config.VendorModel
+	for _, model := range models {
+		if vendors[model.Vendor] {
+			keptModels = append(keptModels, model)
+		}
+	}
+
+	if len(keptModels) == 0 || len(keptCreds) == 0 {
+		return creds, models
+	}
+	return keptCreds, keptModels
+}
+
+// credentialKey identifies a credential without keeping its secret value
+func credentialKey(cred config.Credential) string {
+	sum := sha256.Sum256([]byte(cred.Platform + "\x00" + cred.Value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,73 @@
+package quarantine
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuarantine_Observe(t *testing.T) {
+	q := New(3)
+	cred := config.Credential{Platform: "openai", Type: "api-key", Value: "sk-1"}
+	creds := []config.Credential{{Platform: "gemini", Value: "gm-1"}, cred}
+
+	q.Observe(cred, http.StatusUnauthorized)
+	q.Observe(cred, http.StatusForbidden)
+	assert.False(t, q.Quarantined(cred), "below the threshold")
+	q.Observe(cred, http.StatusInternalServerError)
+	q.Observe(cred, http.StatusTooManyRequests)
+	require.True(t, q.Quarantined(cred), "server errors do not break the run")
+
+	entries := q.Entries(creds)
+	require.Len(t, entries, 1)
+	assert.Equal(t, 1, entries[0].Index)
+	assert.Equal(t, "openai", entries[0].Platform)
+	assert.Equal(t, 3, entries[0].Failures)
+	assert.Equal(t, http.StatusTooManyRequests, entries[0].LastStatus)
+	assert.False(t, entries[0].Since.IsZero())
+
+	q.Observe(cred, http.StatusOK)
+	assert.False(t, q.Quarantined(cred))
+	assert.Empty(t, q.Entries(creds))
+
+	q.Observe(cred, http.StatusUnauthorized)
+	q.Observe(cred, http.StatusBadRequest)
+	q.Observe(cred, http.StatusUnauthorized)
+	q.Observe(cred, http.StatusUnauthorized)
+	assert.False(t, q.Quarantined(cred), "an accepted request resets the run")
+
+	disabled := New(0)
+	for i := 0; i < 5; i++ {
+		disabled.Observe(cred, http.StatusUnauthorized)
+	}
+	assert.False(t, disabled.Enabled())
+	assert.False(t, disabled.Quarantined(cred))
+}
+
+func TestQuarantine_Filter(t *testing.T) {
+	creds := []config.Credential{{Platform: "openai", Value: "sk-1"}, {Platform: "openai", Value: "sk-2"}, {Platform: "gemini", Value: "gm-1"}}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-2.5-flash"}}
+	q := New(1)
+
+	keptCreds, keptModels := q.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds)
+	assert.Equal(t, models, keptModels)
+
+	q.Observe(creds[0], http.StatusUnauthorized)
+	keptCreds, keptModels = q.Filter(creds, models)
+	assert.Equal(t, creds[1:], keptCreds)
+	assert.Equal(t, models, keptModels, "openai still has a credential")
+
+	q.Observe(creds[1], http.StatusForbidden)
+	keptCreds, keptModels = q.Filter(creds, models)
+	assert.Equal(t, creds[2:], keptCreds)
+	assert.Equal(t, models[1:], keptModels)
+
+	q.Observe(creds[2], http.StatusTooManyRequests)
+	keptCreds, keptModels = q.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds, "every credential is quarantined: the pool is unchanged")
+	assert.Equal(t, models, keptModels)
+}
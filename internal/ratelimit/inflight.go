@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"sync"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+)
+
+// InFlight counts the vendor requests in flight on each credential, so credentials with a
+// concurrency cap can be avoided while they are at it
+type InFlight struct {
+	mu     sync.Mutex
+	counts map[string]int // SHA-256 hex digest of the credential value -> requests in flight
+}
+
+var (
+	defaultInFlight     *InFlight
+	defaultInFlightOnce sync.Once
+)
+
+// NewInFlight creates a counter with no requests in flight
+func NewInFlight() *InFlight {
+	return &InFlight{counts: make(map[string]int)}
+}
+
+// DefaultInFlight returns the process-wide counter
+func DefaultInFlight() *InFlight {
+	defaultInFlightOnce.Do(func() {
+		defaultInFlight = NewInFlight()
+	})
+	return defaultInFlight
+}
+
+// Acquire counts a request on cred until the returned release is called; release is
+// idempotent
+func (f *InFlight) Acquire(cred config.Credential) (release func()) {
+	key := credentialKey(cred)
+
+	f.mu.Lock()
+	f.counts[key]++
+	f.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if f.counts[key]--; f.counts[key] <= 0 {
+				delete(f.counts, key)
+			}
+		})
+	}
+}
+
+// Count returns the requests in flight on cred
+func (f *InFlight) Count(cred config.Credential) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.counts[credentialKey(cred)]
+}
+
+// Filter removes credentials at their MaxConcurrent cap, and models of vendors left without
+// credentials, from a routing pool. When it would leave nothing to route to, the pool is
+// returned unchanged
+func (f *InFlight) Filter(creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.counts) == 0 {
+		return creds, models
+	}
+
+	var keptCreds []config.Credential
+	vendors := make(map[string]bool)
+	for _, cred := range creds {
+		if cred.MaxConcurrent > 0 && f.counts[credentialKey(cred)] >= cred.MaxConcurrent {
+			continue
+		}
+		keptCreds = append(keptCreds, cred)
+		vendors[cred.Platform] = true
+	}
+	if len(keptCreds) == len(creds) {
+		return creds, models
+	}
+
+	var keptModels []config.VendorModel
+	for _, model := range models {
+		if vendors[model.Vendor] {
+			keptModels = append(keptModels, model)
+		}
+	}
+
+	if len(keptModels) == 0 || len(keptCreds) == 0 {
+		return creds, models
+	}
+	return keptCreds, keptModels
+}
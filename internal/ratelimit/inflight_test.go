@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInFlight_Filter(t *testing.T) {
+	inFlight := NewInFlight()
+	capped := config.Credential{Platform: "openai", Value: "sk-capped", MaxConcurrent: 2}
+	uncapped := config.Credential{Platform: "openai", Value: "sk-uncapped"}
+	gemini := config.Credential{Platform: "gemini", Value: "gm-capped", MaxConcurrent: 1}
+	creds := []config.Credential{capped, uncapped, gemini}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-flash"}}
+
+	releaseFirst := inFlight.Acquire(capped)
+	keptCreds, keptModels := inFlight.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds, "under its cap a credential stays in the pool")
+	assert.Equal(t, models, keptModels)
+
+	releaseSecond := inFlight.Acquire(capped)
+	releaseGemini := inFlight.Acquire(gemini)
+	for i := 0; i < 5; i++ {
+		defer inFlight.Acquire(uncapped)()
+	}
+	assert.Equal(t, 2, inFlight.Count(capped))
+	keptCreds, keptModels = inFlight.Filter(creds, models)
+	assert.Equal(t, []config.Credential{uncapped}, keptCreds)
+	assert.Equal(t, models[:1], keptModels, "a vendor without credentials under their cap leaves the pool")
+
+	releaseSecond()
+	releaseSecond()
+	assert.Equal(t, 1, inFlight.Count(capped), "release is idempotent")
+	keptCreds, _ = inFlight.Filter(creds, models)
+	assert.Equal(t, []config.Credential{capped, uncapped}, keptCreds)
+
+	releaseFirst()
+	keptCreds, keptModels = inFlight.Filter([]config.Credential{gemini}, models[1:])
+	assert.Equal(t, []config.Credential{gemini}, keptCreds, "the pool is never emptied")
+	assert.Equal(t, models[1:], keptModels)
+	releaseGemini()
+	assert.Zero(t, inFlight.Count(gemini))
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// DefaultHeadroom is the share of a rate limit held in reserve: a credential whose remaining
+// requests or tokens fall to this share of the limit is avoided until the limit resets
+const DefaultHeadroom = 0.05
+
+// Limit is one rate limit's state as a vendor last reported it
+type Limit struct {
+	Remaining int `json:"remaining"`
+	// Limit is the size of the limit's window, 0 when the vendor did not report it
+	Limit   int       `json:"limit,omitempty"`
+	ResetAt time.Time `json:"reset_at,omitempty"`
+}
+
+// Quota is the rate-limit state a vendor reported for a credential in a response's
+// x-ratelimit-* headers; limits the vendor did not report are nil
+type Quota struct {
+	Requests   *Limit    `json:"requests,omitempty"`
+	Tokens     *Limit    `json:"tokens,omitempty"`
+	ObservedAt time.Time `json:"observed_at"`
+}
+
+// Headroom returns the share of rate limits held in reserve, from RATELIMIT_HEADROOM
+func Headroom() float64 {
+	headroom := utils.GetEnvFloat64("RATELIMIT_HEADROOM", DefaultHeadroom)
+	if headroom < 0 || headroom >= 1 {
+		return DefaultHeadroom
+	}
+	return headroom
+}
+
+// ParseQuota reads OpenAI-style x-ratelimit-remaining-*, x-ratelimit-limit-* and
+// x-ratelimit-reset-* headers, reporting false when the response carries none
+func ParseQuota(header http.Header, now time.Time) (Quota, bool) {
+	quota := Quota{
+		Requests:   parseLimit(header, "requests", now),
+		Tokens:     parseLimit(header, "tokens", now),
+		ObservedAt: now,
+	}
+	return quota, quota.Requests != nil || quota.Tokens != nil
+}
+
+// LowUntil returns until when a credential with this quota should be avoided: the latest
+// reset of the limits whose remaining count is at or below headroom share of the limit, or
+// exhausted when the limit's size is unknown. It is the zero time when every limit has
+// room, or when a low limit did not say when it resets
+func (q Quota) LowUntil(headroom float64) time.Time {
+	var until time.Time
+	for _, limit := range []*Limit{q.Requests, q.Tokens} {
+		if limit == nil || limit.ResetAt.IsZero() {
+			continue
+		}
+		reserve := int(math.Ceil(headroom * float64(limit.Limit)))
+		if limit.Remaining <= reserve && limit.ResetAt.After(until) {
+			until = limit.ResetAt
+		}
+	}
+	return until
+}
+
+// parseLimit reads one limit's headers; nil when the remaining count is missing
+func parseLimit(header http.Header, name string, now time.Time) *Limit {
+	remaining, err := strconv.Atoi(strings.TrimSpace(header.Get("X-Ratelimit-Remaining-" + name)))
+	if err != nil {
+		return nil
+	}
+	limit := &Limit{Remaining: remaining}
+	if size, err := strconv.Atoi(strings.TrimSpace(header.Get("X-Ratelimit-Limit-" + name))); err == nil {
+		limit.Limit = size
+	}
+	if wait, ok := parseReset(header.Get("X-Ratelimit-Reset-" + name)); ok {
+		limit.ResetAt = now.Add(wait)
+	}
+	return limit
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuota(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	_, ok := ParseQuota(http.Header{}, now)
+	assert.False(t, ok)
+
+	header := http.Header{}
+	header.Set("X-Ratelimit-Limit-Requests", "10000")
+	header.Set("X-Ratelimit-Remaining-Requests", "9999")
+	header.Set("X-Ratelimit-Reset-Requests", "6ms")
+	header.Set("X-Ratelimit-Remaining-Tokens", "1200")
+	quota, ok := ParseQuota(header, now)
+	require.True(t, ok)
+	require.NotNil(t, quota.Requests)
+	assert.Equal(t, Limit{Remaining: 9999, Limit: 10000, ResetAt: now.Add(6 * time.Millisecond)}, *quota.Requests)
+	require.NotNil(t, quota.Tokens)
+	assert.Equal(t, Limit{Remaining: 1200}, *quota.Tokens)
+	assert.Equal(t, now, quota.ObservedAt)
+}
+
+func TestQuota_LowUntil(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(time.Minute)
+
+	tests := []struct {
+		name     string
+		quota    Quota
+		expected time.Time
+	}{
+		{"room left", Quota{Requests: &Limit{Remaining: 60, Limit: 1000, ResetAt: reset}}, time.Time{}},
+		{"within the headroom", Quota{Requests: &Limit{Remaining: 50, Limit: 1000, ResetAt: reset}}, reset},
+		{"unknown limit with room", Quota{Tokens: &Limit{Remaining: 1, ResetAt: reset}}, time.Time{}},
+		{"unknown limit exhausted", Quota{Tokens: &Limit{Remaining: 0, ResetAt: reset}}, reset},
+		{"no reset time", Quota{Tokens: &Limit{Remaining: 0, Limit: 1000}}, time.Time{}},
+		{"latest reset of the low limits", Quota{
+			Requests: &Limit{Remaining: 1, Limit: 100, ResetAt: reset},
+			Tokens:   &Limit{Remaining: 10, Limit: 40000, ResetAt: reset.Add(time.Minute)},
+		}, reset.Add(time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.quota.LowUntil(0.05))
+		})
+	}
+}
+
+func TestThrottledUntil_Headroom(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	header := http.Header{}
+	header.Set("X-Ratelimit-Limit-Tokens", "40000")
+	header.Set("X-Ratelimit-Remaining-Tokens", "1500")
+	header.Set("X-Ratelimit-Reset-Tokens", "12s")
+
+	assert.Equal(t, now.Add(12*time.Second), ThrottledUntil(http.StatusOK, header, now), "1500 of 40000 is within the default 5%")
+
+	t.Setenv("RATELIMIT_HEADROOM", "0.01")
+	assert.True(t, ThrottledUntil(http.StatusOK, header, now).IsZero())
+
+	t.Setenv("RATELIMIT_HEADROOM", "2")
+	assert.Equal(t, DefaultHeadroom, Headroom(), "out-of-range headroom falls back to the default")
+}
+
+func TestTracker_Quota(t *testing.T) {
+	tracker := NewTracker()
+	cred := config.Credential{Platform: "openai", Value: "sk-a"}
+
+	_, ok := tracker.Quota(cred)
+	assert.False(t, ok)
+
+	quota := Quota{Requests: &Limit{Remaining: 10, Limit: 500}}
+	tracker.ObserveQuota(cred, quota)
+	recorded, ok := tracker.Quota(cred)
+	require.True(t, ok)
+	assert.Equal(t, quota, recorded)
+
+	_, ok = tracker.Quota(config.Credential{Platform: "openai", Value: "sk-b"})
+	assert.False(t, ok)
+}
@@ -0,0 +1,294 @@
+// Package ratelimit tracks credentials a vendor has reported as rate limited so
+// requests can be routed away from them before they fail with 429s
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/shared"
+)
+
+// DefaultCooldown is how long a credential is avoided after a 429 that gives no reset time
+const DefaultCooldown = 10 * time.Second
+
+// sharedCooldownsKey is the expiring set of the shared store holding the cooldowns
+const sharedCooldownsKey = "cooldowns"
+
+// Tracker remembers until when each credential is throttled, and the rate-limit quota its
+// vendor last reported for it
+type Tracker struct {
+	mu     sync.RWMutex
+	until  map[string]time.Time // SHA-256 hex digest of the credential value -> throttled until
+	quotas map[string]Quota     // SHA-256 hex digest of the credential value -> last reported quota
+	now    func() time.Time
+	// shared, when set, receives every cooldown observed so other replicas adopt it
+	shared shared.Store
+}
+
+var (
+	defaultTracker     *Tracker
+	defaultTrackerOnce sync.Once
+)
+
+// NewTracker creates a tracker with no throttled credentials
+func NewTracker() *Tracker {
+	return &Tracker{until: make(map[string]time.Time), quotas: make(map[string]Quota), now: time.Now}
+}
+
+// Default returns the process-wide tracker
+func Default() *Tracker {
+	defaultTrackerOnce.Do(func() {
+		defaultTracker = NewTracker()
+	})
+	return defaultTracker
+}
+
+// Observe records until when cred is throttled; a zero time marks it as available
+func (t *Tracker) Observe(cred config.Credential, until time.Time) {
+	key := credentialKey(cred)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if until.IsZero() || !until.After(t.now()) {
+		delete(t.until, key)
+		return
+	}
+	t.until[key] = until
+
+	if t.shared != nil {
+		store := t.shared
+		go func() {
+			if err := store.ExtendUntil(context.Background(), sharedCooldownsKey, key, until); err != nil {
+				logger.Warn(context.Background(), "Failed to share rate-limit cooldown",
+					"error", err.Error(),
+					"component", "RateLimitTracker",
+					"stage", "ShareCooldown",
+				)
+			}
+		}()
+	}
+}
+
+// ObserveQuota records the rate-limit quota a vendor reported for cred
+func (t *Tracker) ObserveQuota(cred config.Credential, quota Quota) {
+	key := credentialKey(cred)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.quotas[key] = quota
+}
+
+// Quota returns the rate-limit quota last reported for cred, and false when none was
+func (t *Tracker) Quota(cred config.Credential) (Quota, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	quota, ok := t.quotas[credentialKey(cred)]
+	return quota, ok
+}
+
+// Throttled reports whether cred is currently throttled
+func (t *Tracker) Throttled(cred config.Credential) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.throttled(credentialKey(cred))
+}
+
+// Until returns until when cred is throttled, or the zero time when it is available
+func (t *Tracker) Until(cred config.Credential) time.Time {
+	key := credentialKey(cred)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if !t.throttled(key) {
+		return time.Time{}
+	}
+	return t.until[key]
+}
+
+// Cooldowns returns until when each still-throttled credential is avoided, keyed by
+// a digest of the credential so the result can be persisted without secrets
+func (t *Tracker) Cooldowns() map[string]time.Time {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	cooldowns := make(map[string]time.Time, len(t.until))
+	for key, until := range t.until {
+		if t.throttled(key) {
+			cooldowns[key] = until
+		}
+	}
+	return cooldowns
+}
+
+// Restore adds cooldowns previously returned by Cooldowns, skipping those already expired
+// and keeping the later time for credentials throttled again since
+func (t *Tracker) Restore(cooldowns map[string]time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	for key, until := range cooldowns {
+		if until.After(now) && until.After(t.until[key]) {
+			t.until[key] = until
+		}
+	}
+}
+
+// Share publishes the cooldowns t observes from now on to store, and adopts those other
+// replicas publish there every interval until ctx is cancelled. Cooldowns only ever
+// extend across replicas: a credential cleared early on one replica stays avoided by the
+// others until its shared cooldown ends
+func (t *Tracker) Share(ctx context.Context, store shared.Store, interval time.Duration) {
+	t.mu.Lock()
+	t.shared = store
+	t.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		// failing keeps an outage of the store to one warning
+		failing := false
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				cooldowns, err := store.Unexpired(ctx, sharedCooldownsKey)
+				if err != nil {
+					if !failing {
+						logger.Warn(ctx, "Failed to read shared rate-limit cooldowns, using local ones",
+							"error", err.Error(),
+							"component", "RateLimitTracker",
+							"stage", "SyncCooldowns",
+						)
+					}
+					failing = true
+					continue
+				}
+				if failing {
+					logger.Info(ctx, "Shared rate-limit cooldowns readable again",
+						"component", "RateLimitTracker",
+						"stage", "SyncCooldowns",
+					)
+					failing = false
+				}
+				t.Restore(cooldowns)
+			}
+		}
+	}()
+}
+
+// Filter removes throttled credentials, and models of vendors left without credentials, from a routing pool
+// When it would leave nothing to route to, the pool is returned unchanged
+func (t *Tracker) Filter(creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if len(t.until) == 0 {
+		return creds, models
+	}
+
+	var keptCreds []config.Credential
+	vendors := make(map[string]bool)
+	for _, cred := range creds {
+		if t.throttled(credentialKey(cred)) {
+			continue
+		}
+		keptCreds = append(keptCreds, cred)
+		vendors[cred.Platform] = true
+	}
+
+	var keptModels []config.VendorModel
+	for _, model := range models {
+		if vendors[model.Vendor] {
+			keptModels = append(keptModels, model)
+		}
+	}
+
+	if len(keptModels) == 0 || len(keptCreds) == 0 {
+		return creds, models
+	}
+	return keptCreds, keptModels
+}
+
+// throttled reports whether the credential with key is throttled; callers hold mu
+func (t *Tracker) throttled(key string) bool {
+	until, ok := t.until[key]
+	return ok && t.now().Before(until)
+}
+
+// ThrottledUntil reads OpenAI-style rate-limit headers and returns until when the credential
+// that received them should be avoided, or the zero time if it has capacity left
+// A 429 uses retry-after, falling back to the reset headers and then DefaultCooldown;
+// otherwise an x-ratelimit-remaining-requests or -tokens down to the Headroom share of its
+// limit (or exhausted, when the limit is not reported) uses its matching reset
+func ThrottledUntil(statusCode int, header http.Header, now time.Time) time.Time {
+	if statusCode == http.StatusTooManyRequests {
+		if wait, ok := parseRetryAfter(header.Get("Retry-After"), now); ok {
+			return now.Add(wait)
+		}
+		if wait := longestReset(header, "requests", "tokens"); wait > 0 {
+			return now.Add(wait)
+		}
+		return now.Add(DefaultCooldown)
+	}
+	quota, _ := ParseQuota(header, now)
+	return quota.LowUntil(Headroom())
+}
+
+// longestReset returns the longest x-ratelimit-reset-* duration among limits
+func longestReset(header http.Header, limits ...string) time.Duration {
+	var longest time.Duration
+	for _, limit := range limits {
+		if wait, ok := parseReset(header.Get("X-Ratelimit-Reset-" + limit)); ok && wait > longest {
+			longest = wait
+		}
+	}
+	return longest
+}
+
+// parseReset parses reset durations such as "7.66s", "2m59.56s" or "120ms"
+func parseReset(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if wait, err := time.ParseDuration(value); err == nil {
+		return wait, true
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	return 0, false
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return at.Sub(now), true
+	}
+	return 0, false
+}
+
+// credentialKey identifies a credential without keeping its secret value
+func credentialKey(cred config.Credential) string {
+	sum := sha256.Sum256([]byte(cred.Platform + "\x00" + cred.Value))
+	return hex.EncodeToString(sum[:])
+}
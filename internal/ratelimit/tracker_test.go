@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottledUntil(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		statusCode int
+		header     map[string]string
+		expected   time.Duration // zero means not throttled
+	}{
+		{"capacity left", 200, map[string]string{
+			"X-Ratelimit-Remaining-Requests": "14399",
+			"X-Ratelimit-Remaining-Tokens":   "5000",
+			"X-Ratelimit-Reset-Tokens":       "7.66s",
+		}, 0},
+		{"requests exhausted", 200, map[string]string{
+			"X-Ratelimit-Remaining-Requests": "0",
+			"X-Ratelimit-Reset-Requests":     "2m59.56s",
+			"X-Ratelimit-Reset-Tokens":       "1s",
+		}, 2*time.Minute + 59560*time.Millisecond},
+		{"tokens exhausted", 200, map[string]string{
+			"X-Ratelimit-Remaining-Tokens": "0",
+			"X-Ratelimit-Reset-Tokens":     "7.66s",
+		}, 7660 * time.Millisecond},
+		{"429 with retry-after", 429, map[string]string{
+			"Retry-After":              "12",
+			"X-Ratelimit-Reset-Tokens": "1s",
+		}, 12 * time.Second},
+		{"429 with retry-after date", 429, map[string]string{
+			"Retry-After": now.Add(30 * time.Second).Format(http.TimeFormat),
+		}, 30 * time.Second},
+		{"429 with reset headers only", 429, map[string]string{
+			"X-Ratelimit-Reset-Requests": "500ms",
+			"X-Ratelimit-Reset-Tokens":   "3s",
+		}, 3 * time.Second},
+		{"429 without headers", 429, nil, DefaultCooldown},
+		{"no headers", 200, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for key, value := range tt.header {
+				header.Set(key, value)
+			}
+			until := ThrottledUntil(tt.statusCode, header, now)
+			if tt.expected == 0 {
+				assert.True(t, until.IsZero())
+				return
+			}
+			assert.Equal(t, now.Add(tt.expected), until)
+		})
+	}
+}
+
+func TestTracker_Filter(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return now }
+
+	groqA := config.Credential{Platform: "groq", Value: "gsk_a"}
+	groqB := config.Credential{Platform: "groq", Value: "gsk_b"}
+	openai := config.Credential{Platform: "openai", Value: "sk-a"}
+	creds := []config.Credential{groqA, groqB, openai}
+	models := []config.VendorModel{{Vendor: "groq", Model: "llama-3.3-70b-versatile"}, {Vendor: "openai", Model: "gpt-4o"}}
+
+	keptCreds, keptModels := tracker.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds, "nothing throttled")
+	assert.Equal(t, models, keptModels)
+
+	tracker.Observe(groqA, now.Add(time.Minute))
+	assert.True(t, tracker.Throttled(groqA))
+	assert.Equal(t, now.Add(time.Minute), tracker.Until(groqA))
+	assert.True(t, tracker.Until(groqB).IsZero())
+	keptCreds, keptModels = tracker.Filter(creds, models)
+	assert.Equal(t, []config.Credential{groqB, openai}, keptCreds)
+	assert.Equal(t, models, keptModels)
+
+	tracker.Observe(groqB, now.Add(time.Minute))
+	keptCreds, keptModels = tracker.Filter(creds, models)
+	assert.Equal(t, []config.Credential{openai}, keptCreds)
+	assert.Equal(t, []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}}, keptModels, "vendors without credentials are dropped")
+
+	tracker.Observe(openai, now.Add(time.Minute))
+	keptCreds, keptModels = tracker.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds, "fails open when everything is throttled")
+	assert.Equal(t, models, keptModels)
+
+	tracker.Observe(groqA, time.Time{})
+	assert.False(t, tracker.Throttled(groqA), "a response with capacity clears the throttle")
+
+	now = now.Add(2 * time.Minute)
+	assert.False(t, tracker.Throttled(groqB), "throttles expire")
+	assert.True(t, tracker.Until(groqB).IsZero())
+}
+
+func TestTracker_Share(t *testing.T) {
+	store := shared.NewMemory()
+	cred := config.Credential{Platform: "openai", Value: "sk-test"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	first, second := NewTracker(), NewTracker()
+	first.Share(ctx, store, 10*time.Millisecond)
+	second.Share(ctx, store, 10*time.Millisecond)
+
+	until := time.Now().Add(time.Minute)
+	first.Observe(cred, until)
+
+	assert.Eventually(t, func() bool { return second.Throttled(cred) }, time.Second, 10*time.Millisecond)
+	assert.WithinDuration(t, until, second.Until(cred), time.Millisecond)
+}
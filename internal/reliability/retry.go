@@ -19,6 +19,7 @@ type RetryConfig struct {
 	BackoffFactor   float64       // Multiplier for exponential backoff
 	JitterEnabled   bool          // Whether to add random jitter to delays
 	RetryableErrors []string      // List of error types that should be retried
+	AllowRetry      func() bool   // Consulted before each retry; false returns the error without retrying
 }
 
 // DefaultRetryConfig returns a sensible default retry configuration
@@ -86,6 +87,17 @@ func (r *RetryExecutor) ExecuteWithRetry(ctx context.Context, operation func() e
 				break
 			}
 
+			// A spent retry budget surfaces the original error instead of retrying
+			if r.config.AllowRetry != nil && !r.config.AllowRetry() {
+				retryCtx := logger.WithComponent(ctx, "RetryExecutor")
+				retryCtx = logger.WithStage(retryCtx, "RetryBudgetExhausted")
+				logger.Warn(retryCtx, "Retry budget exhausted, not retrying",
+					"attempt", attempt,
+					"error", err.Error(),
+				)
+				return err
+			}
+
 			// Calculate delay for next attempt
 			delay := r.calculateBackoff(attempt)
 
@@ -0,0 +1,166 @@
+package reliability
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Default retry budget settings, overridable with RETRY_BUDGET_WINDOW and RETRY_BUDGET_MIN_RETRIES
+const (
+	DefaultRetryBudgetWindow     = time.Minute
+	DefaultRetryBudgetMinRetries = 10
+)
+
+// RetryBudgetStats is a snapshot of a retry budget's settings and of the retries it allowed
+// and denied in the current windows
+type RetryBudgetStats struct {
+	Ratio      float64 `json:"ratio"`
+	Window     string  `json:"window"`
+	MinRetries int     `json:"min_retries"`
+	// Keys is how many client keys sent requests in their current window
+	Keys     int   `json:"keys"`
+	Requests int64 `json:"requests"`
+	Retries  int64 `json:"retries"`
+	// Denied counts retries skipped because the key's budget was spent, since startup
+	Denied int64 `json:"denied"`
+}
+
+// keyBudget counts one client key's requests and retries in its current window
+type keyBudget struct {
+	windowStart time.Time
+	requests    int64
+	retries     int64
+}
+
+// RetryBudget caps the automatic retries made for each client key to a share of its
+// requests in a time window, so a failing vendor does not multiply a client's traffic
+type RetryBudget struct {
+	mu         sync.Mutex
+	ratio      float64
+	window     time.Duration
+	minRetries int
+	keys       map[string]*keyBudget // SHA-256 hex digest of the client key -> its window
+	denied     int64
+	now        func() time.Time
+}
+
+var (
+	defaultRetryBudget     *RetryBudget
+	defaultRetryBudgetOnce sync.Once
+)
+
+// NewRetryBudget creates a budget allowing each client key, per window, minRetries retries
+// plus ratio of its requests; a non-positive ratio disables the budget
+func NewRetryBudget(ratio float64, window time.Duration, minRetries int) *RetryBudget {
+	if window <= 0 {
+		window = DefaultRetryBudgetWindow
+	}
+	return &RetryBudget{
+		ratio:      ratio,
+		window:     window,
+		minRetries: max(minRetries, 0),
+		keys:       make(map[string]*keyBudget),
+		now:        time.Now,
+	}
+}
+
+// DefaultRetryBudget returns the process-wide retry budget, configured by RETRY_BUDGET_RATIO
+// (share of requests that may be retried, e.g. 0.1; unset or 0 disables it),
+// RETRY_BUDGET_WINDOW (seconds) and RETRY_BUDGET_MIN_RETRIES
+func DefaultRetryBudget() *RetryBudget {
+	defaultRetryBudgetOnce.Do(func() {
+		ratio, _ := strconv.ParseFloat(utils.GetEnvString("RETRY_BUDGET_RATIO", "0"), 64)
+		defaultRetryBudget = NewRetryBudget(
+			ratio,
+			utils.GetEnvDuration("RETRY_BUDGET_WINDOW", DefaultRetryBudgetWindow),
+			utils.GetEnvInt("RETRY_BUDGET_MIN_RETRIES", DefaultRetryBudgetMinRetries),
+		)
+	})
+	return defaultRetryBudget
+}
+
+// Enabled reports whether retries are budgeted
+func (b *RetryBudget) Enabled() bool {
+	return b.ratio > 0
+}
+
+// RecordRequest counts a request from clientKey against its budget window
+func (b *RetryBudget) RecordRequest(clientKey string) {
+	if !b.Enabled() {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.budgetFor(clientKey).requests++
+}
+
+// AllowRetry reports whether clientKey may have another request retried, spending one retry
+// of its budget when it may. It always allows retries while the budget is disabled
+func (b *RetryBudget) AllowRetry(clientKey string) bool {
+	if !b.Enabled() {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	budget := b.budgetFor(clientKey)
+	if float64(budget.retries) >= float64(b.minRetries)+b.ratio*float64(budget.requests) {
+		b.denied++
+		return false
+	}
+	budget.retries++
+	return true
+}
+
+// Stats returns a snapshot of the budget across the client keys' current windows
+func (b *RetryBudget) Stats() RetryBudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.prune()
+	stats := RetryBudgetStats{
+		Ratio:      b.ratio,
+		Window:     b.window.String(),
+		MinRetries: b.minRetries,
+		Keys:       len(b.keys),
+		Denied:     b.denied,
+	}
+	for _, budget := range b.keys {
+		stats.Requests += budget.requests
+		stats.Retries += budget.retries
+	}
+	return stats
+}
+
+// budgetFor returns the current window of clientKey, starting a new one once the last ended
+func (b *RetryBudget) budgetFor(clientKey string) *keyBudget {
+	now := b.now()
+	sum := sha256.Sum256([]byte(clientKey))
+	key := hex.EncodeToString(sum[:])
+
+	budget, ok := b.keys[key]
+	if !ok || now.Sub(budget.windowStart) >= b.window {
+		if !ok {
+			b.prune()
+		}
+		budget = &keyBudget{windowStart: now}
+		b.keys[key] = budget
+	}
+	return budget
+}
+
+// prune forgets client keys whose window ended, so keys that stopped sending requests do not
+// accumulate
+func (b *RetryBudget) prune() {
+	now := b.now()
+	for key, budget := range b.keys {
+		if now.Sub(budget.windowStart) >= b.window {
+			delete(b.keys, key)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package reliability
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryBudget(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	b := NewRetryBudget(0.1, time.Minute, 1)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < 20; i++ {
+		b.RecordRequest("key-a")
+	}
+	b.RecordRequest("key-b")
+
+	allowed := 0
+	for b.AllowRetry("key-a") {
+		allowed++
+	}
+	assert.Equal(t, 3, allowed, "one retry plus 10% of 20 requests")
+	assert.True(t, b.AllowRetry("key-b"), "every key has its own budget")
+	assert.True(t, b.AllowRetry("key-b"), "one retry plus 10% of one request")
+	assert.False(t, b.AllowRetry("key-b"))
+
+	stats := b.Stats()
+	assert.Equal(t, 2, stats.Keys)
+	assert.EqualValues(t, 21, stats.Requests)
+	assert.EqualValues(t, 5, stats.Retries)
+	assert.EqualValues(t, 2, stats.Denied)
+
+	now = now.Add(time.Minute)
+	assert.True(t, b.AllowRetry("key-a"), "the budget refills with the next window")
+	assert.Equal(t, 1, b.Stats().Keys, "keys idle for a window are forgotten")
+
+	disabled := NewRetryBudget(0, time.Minute, 0)
+	assert.False(t, disabled.Enabled())
+	assert.True(t, disabled.AllowRetry("key-a"))
+}
+
+// retriableError is an error the retry executor retries
+type retriableError struct{}
+
+func (retriableError) Error() string     { return "server_error" }
+func (retriableError) IsRetriable() bool { return true }
+
+func TestExecuteWithRetry_AllowRetry(t *testing.T) {
+	config := DefaultRetryConfig()
+	config.InitialDelay = time.Millisecond
+	config.JitterEnabled = false
+	retries := 1
+	config.AllowRetry = func() bool {
+		retries--
+		return retries >= 0
+	}
+
+	attempts := 0
+	err := NewRetryExecutor(config).ExecuteWithRetry(context.Background(), func() error {
+		attempts++
+		return retriableError{}
+	})
+	assert.Equal(t, 2, attempts, "the second retry is denied")
+	assert.True(t, errors.Is(err, retriableError{}), "the original error is returned unwrapped")
+	assert.Equal(t, "server_error", err.Error())
+}
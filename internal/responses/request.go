@@ -0,0 +1,335 @@
+// Package responses serves the OpenAI Responses API on top of the chat completions pipeline:
+// requests are translated into chat completion requests and the chat completion, or its
+// stream of chunks, is translated back into a response object or Responses stream events
+package responses
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidRequest is returned for requests that cannot be translated into a chat completion
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Request represents a request to the Responses API
+type Request struct {
+	Model string `json:"model" example:"gpt-4o"`
+	// Input is a string or an array of input items
+	Input              json.RawMessage   `json:"input" swaggertype:"string" example:"Tell me a joke"`
+	Instructions       string            `json:"instructions,omitempty" example:"You are a helpful assistant."`
+	Tools              []Tool            `json:"tools,omitempty"`
+	ToolChoice         json.RawMessage   `json:"tool_choice,omitempty" swaggertype:"string" example:"auto"`
+	ParallelToolCalls  *bool             `json:"parallel_tool_calls,omitempty"`
+	Stream             bool              `json:"stream,omitempty" example:"false"`
+	Temperature        *float64          `json:"temperature,omitempty" example:"0.7"`
+	TopP               *float64          `json:"top_p,omitempty" example:"1"`
+	MaxOutputTokens    *int              `json:"max_output_tokens,omitempty" example:"1024"`
+	Text               *Text             `json:"text,omitempty"`
+	Reasoning          *Reasoning        `json:"reasoning,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	User               string            `json:"user,omitempty" example:"user-123"`
+	PreviousResponseID string            `json:"previous_response_id,omitempty"`
+}
+
+// Tool represents a function the model may call
+type Tool struct {
+	Type        string                 `json:"type" example:"function"`
+	Name        string                 `json:"name" example:"get_weather"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	Strict      *bool                  `json:"strict,omitempty"`
+}
+
+// Text configures the format of the model's text output
+type Text struct {
+	Format *TextFormat `json:"format,omitempty"`
+}
+
+// TextFormat is plain text, a JSON object or JSON matching a schema
+type TextFormat struct {
+	Type        string                 `json:"type" example:"json_schema"`
+	Name        string                 `json:"name,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema,omitempty"`
+	Strict      *bool                  `json:"strict,omitempty"`
+}
+
+// Reasoning configures reasoning models
+type Reasoning struct {
+	Effort string `json:"effort,omitempty" example:"medium"`
+}
+
+// inputItem is one item of the input array: a message, a function call made by the model or
+// the output of a function call
+type inputItem struct {
+	Type      string          `json:"type"`
+	Role      string          `json:"role"`
+	Content   json.RawMessage `json:"content"`
+	CallID    string          `json:"call_id"`
+	Name      string          `json:"name"`
+	Arguments string          `json:"arguments"`
+	Output    json.RawMessage `json:"output"`
+}
+
+// contentPart is one part of a message's content
+type contentPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Refusal  string `json:"refusal"`
+	ImageURL string `json:"image_url"`
+	Detail   string `json:"detail"`
+	FileID   string `json:"file_id"`
+	FileURL  string `json:"file_url"`
+	FileData string `json:"file_data"`
+	Filename string `json:"filename"`
+}
+
+// ChatRequest translates a Responses API request into a chat completion request body
+func ChatRequest(req *Request) (map[string]interface{}, error) {
+	if req.PreviousResponseID != "" {
+		return nil, fmt.Errorf("%w: previous_response_id is not supported; the router does not store responses, send the conversation in input", ErrInvalidRequest)
+	}
+
+	var messages []interface{}
+	if req.Instructions != "" {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": req.Instructions})
+	}
+	inputMessages, err := chatMessages(req.Input)
+	if err != nil {
+		return nil, err
+	}
+	messages = append(messages, inputMessages...)
+
+	payload := map[string]interface{}{
+		"model":    req.Model,
+		"messages": messages,
+	}
+	if req.Stream {
+		payload["stream"] = true
+		payload["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
+	if req.Temperature != nil {
+		payload["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		payload["top_p"] = *req.TopP
+	}
+	if req.MaxOutputTokens != nil {
+		payload["max_tokens"] = *req.MaxOutputTokens
+	}
+	if req.User != "" {
+		payload["user"] = req.User
+	}
+	if req.Reasoning != nil && req.Reasoning.Effort != "" {
+		payload["reasoning_effort"] = req.Reasoning.Effort
+	}
+
+	if len(req.Tools) > 0 {
+		tools := make([]interface{}, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			if tool.Type != "function" {
+				return nil, fmt.Errorf("%w: tools of type %q are not supported, only function tools", ErrInvalidRequest, tool.Type)
+			}
+			function := map[string]interface{}{"name": tool.Name}
+			if tool.Description != "" {
+				function["description"] = tool.Description
+			}
+			if tool.Parameters != nil {
+				function["parameters"] = tool.Parameters
+			}
+			if tool.Strict != nil {
+				function["strict"] = *tool.Strict
+			}
+			tools = append(tools, map[string]interface{}{"type": "function", "function": function})
+		}
+		payload["tools"] = tools
+		if req.ParallelToolCalls != nil {
+			payload["parallel_tool_calls"] = *req.ParallelToolCalls
+		}
+	}
+	if len(req.ToolChoice) > 0 {
+		toolChoice, err := chatToolChoice(req.ToolChoice)
+		if err != nil {
+			return nil, err
+		}
+		payload["tool_choice"] = toolChoice
+	}
+
+	if req.Text != nil && req.Text.Format != nil {
+		switch format := req.Text.Format; format.Type {
+		case "", "text":
+		case "json_object":
+			payload["response_format"] = map[string]interface{}{"type": "json_object"}
+		case "json_schema":
+			schema := map[string]interface{}{"name": format.Name, "schema": format.Schema}
+			if format.Description != "" {
+				schema["description"] = format.Description
+			}
+			if format.Strict != nil {
+				schema["strict"] = *format.Strict
+			}
+			payload["response_format"] = map[string]interface{}{"type": "json_schema", "json_schema": schema}
+		default:
+			return nil, fmt.Errorf("%w: text format %q is not supported", ErrInvalidRequest, format.Type)
+		}
+	}
+	return payload, nil
+}
+
+// chatMessages translates the input of a request into chat messages
+func chatMessages(input json.RawMessage) ([]interface{}, error) {
+	var text string
+	if err := json.Unmarshal(input, &text); err == nil {
+		if text == "" {
+			return nil, fmt.Errorf("%w: input must not be empty", ErrInvalidRequest)
+		}
+		return []interface{}{map[string]interface{}{"role": "user", "content": text}}, nil
+	}
+
+	var items []inputItem
+	if err := json.Unmarshal(input, &items); err != nil || len(items) == 0 {
+		return nil, fmt.Errorf("%w: input must be a string or a non-empty array of input items", ErrInvalidRequest)
+	}
+
+	var messages []interface{}
+	// pendingCalls collects consecutive function calls into one assistant message
+	var pendingCalls []interface{}
+	flushCalls := func() {
+		if len(pendingCalls) > 0 {
+			messages = append(messages, map[string]interface{}{"role": "assistant", "content": nil, "tool_calls": pendingCalls})
+			pendingCalls = nil
+		}
+	}
+	for i, item := range items {
+		switch item.Type {
+		case "", "message":
+			flushCalls()
+			message, err := chatMessage(item)
+			if err != nil {
+				return nil, fmt.Errorf("%w (input[%d])", err, i)
+			}
+			messages = append(messages, message)
+		case "function_call":
+			pendingCalls = append(pendingCalls, map[string]interface{}{
+				"id":       item.CallID,
+				"type":     "function",
+				"function": map[string]interface{}{"name": item.Name, "arguments": item.Arguments},
+			})
+		case "function_call_output":
+			flushCalls()
+			output, err := functionOutput(item.Output)
+			if err != nil {
+				return nil, fmt.Errorf("%w (input[%d])", err, i)
+			}
+			messages = append(messages, map[string]interface{}{"role": "tool", "tool_call_id": item.CallID, "content": output})
+		default:
+			return nil, fmt.Errorf("%w: input items of type %q are not supported (input[%d])", ErrInvalidRequest, item.Type, i)
+		}
+	}
+	flushCalls()
+	return messages, nil
+}
+
+// chatMessage translates a message input item into a chat message
+func chatMessage(item inputItem) (map[string]interface{}, error) {
+	role := item.Role
+	switch role {
+	case "user", "assistant", "system":
+	case "developer":
+		role = "system"
+	default:
+		return nil, fmt.Errorf("%w: message role %q is not supported", ErrInvalidRequest, item.Role)
+	}
+
+	var text string
+	if err := json.Unmarshal(item.Content, &text); err == nil {
+		return map[string]interface{}{"role": role, "content": text}, nil
+	}
+	var parts []contentPart
+	if err := json.Unmarshal(item.Content, &parts); err != nil {
+		return nil, fmt.Errorf("%w: message content must be a string or an array of content parts", ErrInvalidRequest)
+	}
+
+	// Only user messages carry images and files; other roles are sent as plain text
+	if role != "user" {
+		var texts []string
+		for _, part := range parts {
+			switch part.Type {
+			case "input_text", "output_text", "text":
+				texts = append(texts, part.Text)
+			case "refusal":
+				texts = append(texts, part.Refusal)
+			default:
+				return nil, fmt.Errorf("%w: %s messages only support text content, got %q", ErrInvalidRequest, item.Role, part.Type)
+			}
+		}
+		return map[string]interface{}{"role": role, "content": strings.Join(texts, "")}, nil
+	}
+
+	content := make([]interface{}, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case "input_text", "output_text", "text":
+			content = append(content, map[string]interface{}{"type": "text", "text": part.Text})
+		case "input_image":
+			if part.ImageURL == "" {
+				return nil, fmt.Errorf("%w: input_image requires image_url; file_id is not supported", ErrInvalidRequest)
+			}
+			imageURL := map[string]interface{}{"url": part.ImageURL}
+			if part.Detail != "" {
+				imageURL["detail"] = part.Detail
+			}
+			content = append(content, map[string]interface{}{"type": "image_url", "image_url": imageURL})
+		case "input_file":
+			switch {
+			case part.FileURL != "":
+				content = append(content, map[string]interface{}{"type": "file_url", "file_url": map[string]interface{}{"url": part.FileURL}})
+			case part.FileData != "":
+				content = append(content, map[string]interface{}{"type": "file", "file": map[string]interface{}{"filename": part.Filename, "file_data": part.FileData}})
+			default:
+				return nil, fmt.Errorf("%w: input_file requires file_url or file_data; file_id is not supported", ErrInvalidRequest)
+			}
+		default:
+			return nil, fmt.Errorf("%w: content parts of type %q are not supported", ErrInvalidRequest, part.Type)
+		}
+	}
+	return map[string]interface{}{"role": role, "content": content}, nil
+}
+
+// functionOutput returns the output of a function call as text
+func functionOutput(output json.RawMessage) (string, error) {
+	var text string
+	if err := json.Unmarshal(output, &text); err == nil {
+		return text, nil
+	}
+	var parts []contentPart
+	if err := json.Unmarshal(output, &parts); err != nil {
+		return "", fmt.Errorf("%w: function_call_output output must be a string or an array of text parts", ErrInvalidRequest)
+	}
+	var texts []string
+	for _, part := range parts {
+		if part.Type != "input_text" && part.Type != "output_text" && part.Type != "text" {
+			return "", fmt.Errorf("%w: function_call_output only supports text output, got %q", ErrInvalidRequest, part.Type)
+		}
+		texts = append(texts, part.Text)
+	}
+	return strings.Join(texts, ""), nil
+}
+
+// chatToolChoice translates a tool_choice into its chat completion form
+func chatToolChoice(raw json.RawMessage) (interface{}, error) {
+	var mode string
+	if err := json.Unmarshal(raw, &mode); err == nil {
+		return mode, nil
+	}
+	var choice struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &choice); err != nil || choice.Type != "function" || choice.Name == "" {
+		return nil, fmt.Errorf("%w: tool_choice must be auto, none, required or a function", ErrInvalidRequest)
+	}
+	return map[string]interface{}{"type": "function", "function": map[string]interface{}{"name": choice.Name}}, nil
+}
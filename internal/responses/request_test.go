@@ -0,0 +1,110 @@
+package responses
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatRequest(t *testing.T) {
+	body := `{
+		"model": "gpt-4o",
+		"instructions": "Be brief.",
+		"stream": true,
+		"max_output_tokens": 256,
+		"temperature": 0.2,
+		"reasoning": {"effort": "low"},
+		"input": [
+			{"role": "developer", "content": "Answer in French."},
+			{"type": "message", "role": "user", "content": [
+				{"type": "input_text", "text": "What is this?"},
+				{"type": "input_image", "image_url": "data:image/png;base64,iVBOR", "detail": "low"},
+				{"type": "input_file", "file_url": "https://example.com/report.pdf"}
+			]},
+			{"type": "function_call", "call_id": "call_1", "name": "lookup", "arguments": "{\"q\":\"png\"}"},
+			{"type": "function_call", "call_id": "call_2", "name": "lookup", "arguments": "{\"q\":\"pdf\"}"},
+			{"type": "function_call_output", "call_id": "call_1", "output": "an image"},
+			{"type": "function_call_output", "call_id": "call_2", "output": [{"type": "input_text", "text": "a report"}]},
+			{"role": "assistant", "content": [{"type": "output_text", "text": "Une image."}]}
+		],
+		"tools": [{"type": "function", "name": "lookup", "description": "Look up", "parameters": {"type": "object"}, "strict": true}],
+		"tool_choice": {"type": "function", "name": "lookup"},
+		"parallel_tool_calls": false,
+		"text": {"format": {"type": "json_schema", "name": "answer", "schema": {"type": "object"}, "strict": true}}
+	}`
+	var req Request
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+
+	payload, err := ChatRequest(&req)
+	require.NoError(t, err)
+	translated, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	expected := `{
+		"model": "gpt-4o",
+		"stream": true,
+		"stream_options": {"include_usage": true},
+		"max_tokens": 256,
+		"temperature": 0.2,
+		"reasoning_effort": "low",
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "system", "content": "Answer in French."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is this?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBOR", "detail": "low"}},
+				{"type": "file_url", "file_url": {"url": "https://example.com/report.pdf"}}
+			]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":\"png\"}"}},
+				{"id": "call_2", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":\"pdf\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "an image"},
+			{"role": "tool", "tool_call_id": "call_2", "content": "a report"},
+			{"role": "assistant", "content": "Une image."}
+		],
+		"tools": [{"type": "function", "function": {"name": "lookup", "description": "Look up", "parameters": {"type": "object"}, "strict": true}}],
+		"tool_choice": {"type": "function", "function": {"name": "lookup"}},
+		"parallel_tool_calls": false,
+		"response_format": {"type": "json_schema", "json_schema": {"name": "answer", "schema": {"type": "object"}, "strict": true}}
+	}`
+	assert.JSONEq(t, expected, string(translated))
+}
+
+func TestChatRequest_StringInput(t *testing.T) {
+	payload, err := ChatRequest(&Request{Model: "gpt-4o", Input: json.RawMessage(`"Tell me a joke"`)})
+	require.NoError(t, err)
+	translated, err := json.Marshal(payload)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"model": "gpt-4o", "messages": [{"role": "user", "content": "Tell me a joke"}]}`, string(translated))
+}
+
+func TestChatRequest_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"missing input", `{"model": "gpt-4o"}`},
+		{"empty input", `{"model": "gpt-4o", "input": ""}`},
+		{"empty input array", `{"model": "gpt-4o", "input": []}`},
+		{"previous response", `{"model": "gpt-4o", "input": "Hi", "previous_response_id": "resp_1"}`},
+		{"built-in tool", `{"model": "gpt-4o", "input": "Hi", "tools": [{"type": "web_search"}]}`},
+		{"unsupported item", `{"model": "gpt-4o", "input": [{"type": "reasoning", "summary": []}]}`},
+		{"unsupported role", `{"model": "gpt-4o", "input": [{"role": "critic", "content": "Hi"}]}`},
+		{"image by file ID", `{"model": "gpt-4o", "input": [{"role": "user", "content": [{"type": "input_image", "file_id": "file-1"}]}]}`},
+		{"image in assistant message", `{"model": "gpt-4o", "input": [{"role": "assistant", "content": [{"type": "input_image", "image_url": "https://example.com/a.png"}]}]}`},
+		{"invalid tool choice", `{"model": "gpt-4o", "input": "Hi", "tool_choice": {"type": "web_search"}}`},
+		{"unsupported text format", `{"model": "gpt-4o", "input": "Hi", "text": {"format": {"type": "grammar"}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req Request
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &req))
+			_, err := ChatRequest(&req)
+			assert.ErrorIs(t, err, ErrInvalidRequest)
+		})
+	}
+}
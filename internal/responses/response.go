@@ -0,0 +1,277 @@
+package responses
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Response statuses
+const (
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+	StatusIncomplete = "incomplete"
+	StatusFailed     = "failed"
+)
+
+// Response represents a response of the Responses API
+type Response struct {
+	ID                string             `json:"id" example:"resp_abc123"`
+	Object            string             `json:"object" example:"response"`
+	CreatedAt         int64              `json:"created_at" example:"1735689600"`
+	Status            string             `json:"status" example:"completed"`
+	Error             *Error             `json:"error"`
+	IncompleteDetails *IncompleteDetails `json:"incomplete_details"`
+	Instructions      *string            `json:"instructions"`
+	MaxOutputTokens   *int               `json:"max_output_tokens"`
+	Model             string             `json:"model" example:"gpt-4o"`
+	// Output holds MessageItem and FunctionCallItem values
+	Output            []interface{}     `json:"output" swaggertype:"array,object"`
+	ParallelToolCalls bool              `json:"parallel_tool_calls"`
+	Temperature       *float64          `json:"temperature"`
+	TopP              *float64          `json:"top_p"`
+	ToolChoice        interface{}       `json:"tool_choice" swaggertype:"string" example:"auto"`
+	Tools             []Tool            `json:"tools"`
+	Text              Text              `json:"text"`
+	Metadata          map[string]string `json:"metadata"`
+	Usage             *Usage            `json:"usage"`
+	User              string            `json:"user,omitempty"`
+	Store             bool              `json:"store"`
+}
+
+// Error describes why a response failed
+type Error struct {
+	Code    string `json:"code" example:"server_error"`
+	Message string `json:"message"`
+}
+
+// IncompleteDetails describes why a response is incomplete
+type IncompleteDetails struct {
+	Reason string `json:"reason" example:"max_output_tokens"`
+}
+
+// MessageItem is an assistant message in the output of a response
+type MessageItem struct {
+	Type    string       `json:"type" example:"message"`
+	ID      string       `json:"id" example:"msg_abc123"`
+	Status  string       `json:"status" example:"completed"`
+	Role    string       `json:"role" example:"assistant"`
+	Content []OutputText `json:"content"`
+}
+
+// OutputText is the text content of an output message
+type OutputText struct {
+	Type        string        `json:"type" example:"output_text"`
+	Text        string        `json:"text"`
+	Annotations []interface{} `json:"annotations"`
+}
+
+// FunctionCallItem is a function call the model made
+type FunctionCallItem struct {
+	Type      string `json:"type" example:"function_call"`
+	ID        string `json:"id" example:"fc_abc123"`
+	CallID    string `json:"call_id" example:"call_abc123"`
+	Name      string `json:"name" example:"get_weather"`
+	Arguments string `json:"arguments" example:"{\"location\":\"Paris\"}"`
+	Status    string `json:"status" example:"completed"`
+}
+
+// Usage represents token usage of a response
+type Usage struct {
+	InputTokens         int                 `json:"input_tokens" example:"10"`
+	InputTokensDetails  InputTokensDetails  `json:"input_tokens_details"`
+	OutputTokens        int                 `json:"output_tokens" example:"20"`
+	OutputTokensDetails OutputTokensDetails `json:"output_tokens_details"`
+	TotalTokens         int                 `json:"total_tokens" example:"30"`
+}
+
+// InputTokensDetails breaks down the input tokens of a response
+type InputTokensDetails struct {
+	CachedTokens int `json:"cached_tokens" example:"0"`
+}
+
+// OutputTokensDetails breaks down the output tokens of a response
+type OutputTokensDetails struct {
+	ReasoningTokens int `json:"reasoning_tokens" example:"0"`
+}
+
+// chatCompletion is the part of a chat completion translated into a response
+type chatCompletion struct {
+	ID      string `json:"id"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content   *string        `json:"content"`
+			Refusal   *string        `json:"refusal"`
+			ToolCalls []chatToolCall `json:"tool_calls"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+}
+
+// chatToolCall is a tool call of a chat completion or, with Index, of a chunk
+type chatToolCall struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// chatUsage is the token usage of a chat completion
+type chatUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+	CompletionTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+// FromChatCompletion translates a chat completion answering req into a response
+func FromChatCompletion(req *Request, body []byte) (*Response, error) {
+	var completion chatCompletion
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return nil, fmt.Errorf("invalid chat completion: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("invalid chat completion: no choices")
+	}
+
+	if completion.ID == "" {
+		completion.ID = utils.GenerateChatCompletionID()
+	}
+	response := newResponse(req, completion.ID, completion.Created, completion.Model)
+	choice := completion.Choices[0]
+	status := finishStatus(response, choice.FinishReason)
+
+	text := ""
+	if choice.Message.Content != nil {
+		text = *choice.Message.Content
+	} else if choice.Message.Refusal != nil {
+		text = *choice.Message.Refusal
+	}
+	if text != "" {
+		response.Output = append(response.Output, &MessageItem{
+			Type:    "message",
+			ID:      messageID(completion.ID),
+			Status:  status,
+			Role:    "assistant",
+			Content: []OutputText{{Type: "output_text", Text: text, Annotations: []interface{}{}}},
+		})
+	}
+	for _, call := range choice.Message.ToolCalls {
+		response.Output = append(response.Output, &FunctionCallItem{
+			Type:      "function_call",
+			ID:        functionCallID(call.ID),
+			CallID:    call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+			Status:    StatusCompleted,
+		})
+	}
+	response.Usage = usage(completion.Usage)
+	return response, nil
+}
+
+// newResponse returns an in-progress response to req echoing its parameters
+func newResponse(req *Request, chatID string, created int64, model string) *Response {
+	response := &Response{
+		ID:                "resp_" + strings.TrimPrefix(chatID, "chatcmpl-"),
+		Object:            "response",
+		CreatedAt:         created,
+		Status:            StatusInProgress,
+		MaxOutputTokens:   req.MaxOutputTokens,
+		Model:             model,
+		Output:            []interface{}{},
+		ParallelToolCalls: req.ParallelToolCalls == nil || *req.ParallelToolCalls,
+		Temperature:       req.Temperature,
+		TopP:              req.TopP,
+		ToolChoice:        "auto",
+		Tools:             req.Tools,
+		Text:              Text{Format: &TextFormat{Type: "text"}},
+		Metadata:          req.Metadata,
+		User:              req.User,
+	}
+	if response.CreatedAt == 0 {
+		response.CreatedAt = time.Now().Unix()
+	}
+	if response.Model == "" {
+		response.Model = req.Model
+	}
+	if req.Instructions != "" {
+		response.Instructions = &req.Instructions
+	}
+	if len(req.ToolChoice) > 0 {
+		var toolChoice interface{}
+		if err := json.Unmarshal(req.ToolChoice, &toolChoice); err == nil {
+			response.ToolChoice = toolChoice
+		}
+	}
+	if response.Tools == nil {
+		response.Tools = []Tool{}
+	}
+	if req.Text != nil && req.Text.Format != nil {
+		response.Text = *req.Text
+	}
+	if response.Metadata == nil {
+		response.Metadata = map[string]string{}
+	}
+	return response
+}
+
+// finishStatus sets the final status of a response from a chat finish_reason and returns
+// the status of its output items
+func finishStatus(response *Response, finishReason string) string {
+	switch finishReason {
+	case "length":
+		response.Status = StatusIncomplete
+		response.IncompleteDetails = &IncompleteDetails{Reason: "max_output_tokens"}
+	case "content_filter":
+		response.Status = StatusIncomplete
+		response.IncompleteDetails = &IncompleteDetails{Reason: "content_filter"}
+	default:
+		response.Status = StatusCompleted
+	}
+	if response.Status == StatusIncomplete {
+		return StatusIncomplete
+	}
+	return StatusCompleted
+}
+
+// usage translates chat completion usage
+func usage(chat *chatUsage) *Usage {
+	if chat == nil {
+		return nil
+	}
+	total := chat.TotalTokens
+	if total == 0 {
+		total = chat.PromptTokens + chat.CompletionTokens
+	}
+	return &Usage{
+		InputTokens:         chat.PromptTokens,
+		InputTokensDetails:  InputTokensDetails{CachedTokens: chat.PromptTokensDetails.CachedTokens},
+		OutputTokens:        chat.CompletionTokens,
+		OutputTokensDetails: OutputTokensDetails{ReasoningTokens: chat.CompletionTokensDetails.ReasoningTokens},
+		TotalTokens:         total,
+	}
+}
+
+// messageID derives the ID of an output message from the chat completion ID
+func messageID(chatID string) string {
+	return "msg_" + strings.TrimPrefix(chatID, "chatcmpl-")
+}
+
+// functionCallID derives the ID of a function call item from its call ID
+func functionCallID(callID string) string {
+	return "fc_" + strings.TrimPrefix(callID, "call_")
+}
@@ -0,0 +1,271 @@
+package responses
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// stream translates a stream of chat completion chunks into Responses stream events
+type stream struct {
+	w        io.Writer
+	request  *Request
+	response *Response
+	chatID   string
+	sequence int
+	// message is the output message receiving text deltas, created by the first one
+	message      *MessageItem
+	messageIndex int
+	// calls are the function calls being streamed, by the index of their chat tool call
+	calls        map[int]*FunctionCallItem
+	callIndexes  map[int]int
+	finishReason string
+	done         bool
+}
+
+// chatChunk is the part of a chat completion chunk translated into stream events
+type chatChunk struct {
+	ID      string `json:"id"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content   *string        `json:"content"`
+			Refusal   *string        `json:"refusal"`
+			ToolCalls []chatToolCall `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+func newStream(w io.Writer, req *Request) *stream {
+	return &stream{
+		w:           w,
+		request:     req,
+		calls:       make(map[int]*FunctionCallItem),
+		callIndexes: make(map[int]int),
+	}
+}
+
+// data handles the data of one chat completion stream event
+func (s *stream) data(data []byte) error {
+	if s.done {
+		return nil
+	}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("[DONE]")) {
+		return s.complete()
+	}
+
+	var chunk chatChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		// Comments and keep-alives carry no chunk
+		return nil
+	}
+	if err := s.start(chunk.ID, chunk.Created, chunk.Model); err != nil {
+		return err
+	}
+	if chunk.Error != nil {
+		code := chunk.Error.Code
+		if code == "" {
+			code = "server_error"
+		}
+		return s.fail(code, chunk.Error.Message)
+	}
+
+	for _, choice := range chunk.Choices {
+		text := ""
+		if choice.Delta.Content != nil {
+			text = *choice.Delta.Content
+		} else if choice.Delta.Refusal != nil {
+			text = *choice.Delta.Refusal
+		}
+		if text != "" {
+			if err := s.textDelta(text); err != nil {
+				return err
+			}
+		}
+		for _, call := range choice.Delta.ToolCalls {
+			if err := s.toolCallDelta(call); err != nil {
+				return err
+			}
+		}
+		if choice.FinishReason != nil && *choice.FinishReason != "" {
+			s.finishReason = *choice.FinishReason
+		}
+	}
+	if chunk.Usage != nil {
+		s.response.Usage = usage(chunk.Usage)
+	}
+	return nil
+}
+
+// start emits the response.created and response.in_progress events before the first chunk
+func (s *stream) start(chatID string, created int64, model string) error {
+	if s.response != nil {
+		return nil
+	}
+	if chatID == "" {
+		chatID = utils.GenerateChatCompletionID()
+	}
+	s.chatID = chatID
+	s.response = newResponse(s.request, chatID, created, model)
+	if err := s.emit("response.created", map[string]interface{}{"response": s.response}); err != nil {
+		return err
+	}
+	return s.emit("response.in_progress", map[string]interface{}{"response": s.response})
+}
+
+// textDelta streams text into the output message, adding it on the first delta
+func (s *stream) textDelta(text string) error {
+	if s.message == nil {
+		s.message = &MessageItem{
+			Type:    "message",
+			ID:      messageID(s.chatID),
+			Status:  StatusInProgress,
+			Role:    "assistant",
+			Content: []OutputText{},
+		}
+		s.messageIndex = len(s.response.Output)
+		s.response.Output = append(s.response.Output, s.message)
+		if err := s.emit("response.output_item.added", map[string]interface{}{
+			"output_index": s.messageIndex,
+			"item":         s.message,
+		}); err != nil {
+			return err
+		}
+		s.message.Content = append(s.message.Content, OutputText{Type: "output_text", Text: "", Annotations: []interface{}{}})
+		if err := s.emit("response.content_part.added", map[string]interface{}{
+			"item_id":       s.message.ID,
+			"output_index":  s.messageIndex,
+			"content_index": 0,
+			"part":          s.message.Content[0],
+		}); err != nil {
+			return err
+		}
+	}
+	s.message.Content[0].Text += text
+	return s.emit("response.output_text.delta", map[string]interface{}{
+		"item_id":       s.message.ID,
+		"output_index":  s.messageIndex,
+		"content_index": 0,
+		"delta":         text,
+	})
+}
+
+// toolCallDelta streams a function call, adding it on its first delta
+func (s *stream) toolCallDelta(delta chatToolCall) error {
+	call, ok := s.calls[delta.Index]
+	if !ok {
+		call = &FunctionCallItem{
+			Type:   "function_call",
+			ID:     functionCallID(delta.ID),
+			CallID: delta.ID,
+			Name:   delta.Function.Name,
+			Status: StatusInProgress,
+		}
+		s.calls[delta.Index] = call
+		s.callIndexes[delta.Index] = len(s.response.Output)
+		s.response.Output = append(s.response.Output, call)
+		if err := s.emit("response.output_item.added", map[string]interface{}{
+			"output_index": s.callIndexes[delta.Index],
+			"item":         call,
+		}); err != nil {
+			return err
+		}
+	}
+	if delta.Function.Arguments == "" {
+		return nil
+	}
+	call.Arguments += delta.Function.Arguments
+	return s.emit("response.function_call_arguments.delta", map[string]interface{}{
+		"item_id":      call.ID,
+		"output_index": s.callIndexes[delta.Index],
+		"delta":        delta.Function.Arguments,
+	})
+}
+
+// complete closes the output items and emits the final response
+func (s *stream) complete() error {
+	if err := s.start("", 0, ""); err != nil {
+		return err
+	}
+	s.done = true
+	itemStatus := finishStatus(s.response, s.finishReason)
+
+	for index, item := range s.response.Output {
+		switch item := item.(type) {
+		case *MessageItem:
+			item.Status = itemStatus
+			part := item.Content[0]
+			if err := s.emit("response.output_text.done", map[string]interface{}{
+				"item_id":       item.ID,
+				"output_index":  index,
+				"content_index": 0,
+				"text":          part.Text,
+			}); err != nil {
+				return err
+			}
+			if err := s.emit("response.content_part.done", map[string]interface{}{
+				"item_id":       item.ID,
+				"output_index":  index,
+				"content_index": 0,
+				"part":          part,
+			}); err != nil {
+				return err
+			}
+		case *FunctionCallItem:
+			item.Status = StatusCompleted
+			if err := s.emit("response.function_call_arguments.done", map[string]interface{}{
+				"item_id":      item.ID,
+				"output_index": index,
+				"arguments":    item.Arguments,
+			}); err != nil {
+				return err
+			}
+		}
+		if err := s.emit("response.output_item.done", map[string]interface{}{
+			"output_index": index,
+			"item":         item,
+		}); err != nil {
+			return err
+		}
+	}
+
+	event := "response.completed"
+	if s.response.Status == StatusIncomplete {
+		event = "response.incomplete"
+	}
+	return s.emit(event, map[string]interface{}{"response": s.response})
+}
+
+// fail ends the stream with a response.failed event
+func (s *stream) fail(code, message string) error {
+	if err := s.start("", 0, ""); err != nil {
+		return err
+	}
+	s.done = true
+	s.response.Status = StatusFailed
+	s.response.Error = &Error{Code: code, Message: message}
+	return s.emit("response.failed", map[string]interface{}{"response": s.response})
+}
+
+// emit writes one Responses stream event
+func (s *stream) emit(eventType string, fields map[string]interface{}) error {
+	fields["type"] = eventType
+	fields["sequence_number"] = s.sequence
+	s.sequence++
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", eventType, data)
+	return err
+}
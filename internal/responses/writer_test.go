@@ -0,0 +1,221 @@
+package responses
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// streamEvent is one decoded Responses stream event
+type streamEvent struct {
+	name string
+	data map[string]interface{}
+}
+
+func parseEvents(t *testing.T, body string) []streamEvent {
+	t.Helper()
+	var events []streamEvent
+	for _, block := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		lines := strings.SplitN(block, "\n", 2)
+		require.Len(t, lines, 2, block)
+		event := streamEvent{name: strings.TrimPrefix(lines[0], "event: ")}
+		require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &event.data))
+		assert.Equal(t, event.name, event.data["type"])
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestWriter_Completion(t *testing.T) {
+	maxTokens := 64
+	req := &Request{Model: "gpt-4o", Instructions: "Be brief.", MaxOutputTokens: &maxTokens, Metadata: map[string]string{"trace": "1"}}
+	rec := httptest.NewRecorder()
+	writer := NewWriter(rec, req)
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Content-Length", "999")
+	writer.WriteHeader(http.StatusOK)
+	_, err := writer.Write([]byte(`{
+		"id": "chatcmpl-abc",
+		"object": "chat.completion",
+		"created": 1735689600,
+		"model": "gpt-4o",
+		"choices": [{"index": 0, "finish_reason": "tool_calls", "message": {
+			"role": "assistant",
+			"content": "Checking.",
+			"tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{}"}}]
+		}}],
+		"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15, "prompt_tokens_details": {"cached_tokens": 4}}
+	}`))
+	require.NoError(t, err)
+	assert.Empty(t, rec.Body.String(), "the completion is only written once translated")
+	require.NoError(t, writer.Finish())
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, strconv.Itoa(rec.Body.Len()), rec.Header().Get("Content-Length"))
+	expected := `{
+		"id": "resp_abc",
+		"object": "response",
+		"created_at": 1735689600,
+		"status": "completed",
+		"error": null,
+		"incomplete_details": null,
+		"instructions": "Be brief.",
+		"max_output_tokens": 64,
+		"model": "gpt-4o",
+		"output": [
+			{"type": "message", "id": "msg_abc", "status": "completed", "role": "assistant",
+			 "content": [{"type": "output_text", "text": "Checking.", "annotations": []}]},
+			{"type": "function_call", "id": "fc_1", "call_id": "call_1", "name": "lookup", "arguments": "{}", "status": "completed"}
+		],
+		"parallel_tool_calls": true,
+		"temperature": null,
+		"top_p": null,
+		"tool_choice": "auto",
+		"tools": [],
+		"text": {"format": {"type": "text"}},
+		"metadata": {"trace": "1"},
+		"usage": {"input_tokens": 10, "input_tokens_details": {"cached_tokens": 4}, "output_tokens": 5,
+		          "output_tokens_details": {"reasoning_tokens": 0}, "total_tokens": 15},
+		"store": false
+	}`
+	assert.JSONEq(t, expected, rec.Body.String())
+}
+
+func TestWriter_ErrorPassesThrough(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writer := NewWriter(rec, &Request{Model: "gpt-4o"})
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(http.StatusTooManyRequests)
+	_, err := writer.Write([]byte(`{"error":{"message":"slow down"}}`))
+	require.NoError(t, err)
+	require.NoError(t, writer.Finish())
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.JSONEq(t, `{"error":{"message":"slow down"}}`, rec.Body.String())
+}
+
+func TestWriter_UntranslatableCompletion(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writer := NewWriter(rec, &Request{Model: "gpt-4o"})
+	_, err := writer.Write([]byte(`{"choices": []}`))
+	require.NoError(t, err)
+	assert.Error(t, writer.Finish())
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestWriter_Stream(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writer := NewWriter(rec, &Request{Model: "gpt-4o", Stream: true})
+	writer.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	writer.WriteHeader(http.StatusOK)
+
+	chunks := []string{
+		`data: {"id":"chatcmpl-abc","created":1735689600,"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}` + "\n\n",
+		`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"content":"Hel"}}]}` + "\n\n" +
+			`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+		"\n\n",
+		`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"lookup","arguments":""}}]}}]}` + "\n\n",
+		`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"q\":"}}]}}]}` + "\n\n",
+		`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"1}"}}]}}]}` + "\n\n",
+		`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}` + "\n\n",
+		`data: {"id":"chatcmpl-abc","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":4,"total_tokens":7}}` + "\n\n",
+		"data: [DONE]\n\n",
+	}
+	for _, chunk := range chunks {
+		_, err := writer.Write([]byte(chunk))
+		require.NoError(t, err)
+		writer.Flush()
+	}
+	require.NoError(t, writer.Finish())
+	assert.True(t, rec.Flushed)
+
+	events := parseEvents(t, rec.Body.String())
+	var names []string
+	for i, event := range events {
+		names = append(names, event.name)
+		assert.EqualValues(t, i, event.data["sequence_number"])
+	}
+	assert.Equal(t, []string{
+		"response.created",
+		"response.in_progress",
+		"response.output_item.added",
+		"response.content_part.added",
+		"response.output_text.delta",
+		"response.output_text.delta",
+		"response.output_item.added",
+		"response.function_call_arguments.delta",
+		"response.function_call_arguments.delta",
+		"response.output_text.done",
+		"response.content_part.done",
+		"response.output_item.done",
+		"response.function_call_arguments.done",
+		"response.output_item.done",
+		"response.completed",
+	}, names)
+
+	assert.Equal(t, "lo", events[5].data["delta"])
+	assert.Equal(t, "Hello", events[9].data["text"])
+	assert.Equal(t, `{"q":1}`, events[12].data["arguments"])
+
+	completed := events[len(events)-1].data["response"].(map[string]interface{})
+	assert.Equal(t, "resp_abc", completed["id"])
+	assert.Equal(t, "completed", completed["status"])
+	output := completed["output"].([]interface{})
+	require.Len(t, output, 2)
+	assert.Equal(t, "Hello", output[0].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})["text"])
+	assert.Equal(t, "lookup", output[1].(map[string]interface{})["name"])
+	assert.EqualValues(t, 7, completed["usage"].(map[string]interface{})["total_tokens"])
+}
+
+func TestWriter_StreamIncompleteAndCutOff(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks string
+		event  string
+		status string
+	}{
+		{
+			name: "length",
+			chunks: `data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"content":"Hi"}}]}` + "\n\n" +
+				`data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{},"finish_reason":"length"}]}` + "\n\ndata: [DONE]\n\n",
+			event:  "response.incomplete",
+			status: "incomplete",
+		},
+		{
+			name:   "stream ends early",
+			chunks: `data: {"id":"chatcmpl-abc","choices":[{"index":0,"delta":{"content":"Hi"}}]}` + "\n\n",
+			event:  "response.failed",
+			status: "failed",
+		},
+		{
+			name:   "error chunk",
+			chunks: `data: {"error":{"message":"upstream failed"}}` + "\n\n",
+			event:  "response.failed",
+			status: "failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writer := NewWriter(rec, &Request{Model: "gpt-4o", Stream: true})
+			writer.Header().Set("Content-Type", "text/event-stream")
+			_, err := writer.Write([]byte(tt.chunks))
+			require.NoError(t, err)
+			require.NoError(t, writer.Finish())
+
+			events := parseEvents(t, rec.Body.String())
+			last := events[len(events)-1]
+			assert.Equal(t, tt.event, last.name)
+			assert.Equal(t, tt.status, last.data["response"].(map[string]interface{})["status"])
+		})
+	}
+}
@@ -0,0 +1,312 @@
+// Package rollout ramps traffic to newly added models: a model with a rollout config is
+// eligible for a small share of requests at first, promoted step by step while it stays
+// healthy and rolled back when its error rate or latency breaches the configured limits
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+)
+
+// Defaults for rollout settings left at zero
+const (
+	DefaultStepPercent  = 10.0
+	DefaultStepDuration = 5 * time.Minute
+	DefaultMinRequests  = 20
+	DefaultMaxErrorRate = 0.05
+)
+
+// maxLatencySamples bounds the latencies kept per step for the percentile check
+const maxLatencySamples = 1000
+
+// Phase is where a model is in its rollout
+type Phase string
+
+// Rollout phases; a model stays in PhaseRolledBack until its rollout config changes
+const (
+	PhaseRamping    Phase = "ramping"
+	PhaseComplete   Phase = "complete"
+	PhaseRolledBack Phase = "rolled_back"
+)
+
+// State reports a model's rollout progress
+type State struct {
+	Vendor      string    `json:"vendor"`
+	Model       string    `json:"model"`
+	Phase       Phase     `json:"phase"`
+	Percent     float64   `json:"percent"`
+	StepStarted time.Time `json:"step_started"`
+	Requests    int       `json:"step_requests"`
+	Errors      int       `json:"step_errors"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+// trial tracks one model's rollout and the outcomes of its current step
+type trial struct {
+	config    config.RolloutConfig
+	state     State
+	latencies []time.Duration
+}
+
+// Controller ramps and rolls back models with a rollout config
+type Controller struct {
+	mu     sync.Mutex
+	trials map[string]*trial
+	now    func() time.Time
+	rng    *rand.Rand
+}
+
+var (
+	defaultController     *Controller
+	defaultControllerOnce sync.Once
+)
+
+// NewController creates a controller with no models on trial
+func NewController() *Controller {
+	return &Controller{
+		trials: make(map[string]*trial),
+		now:    time.Now,
+		// math/rand is used for traffic sampling, which is not security-critical
+		// #nosec G404
+		rng: rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+// Default returns the process-wide controller
+func Default() *Controller {
+	defaultControllerOnce.Do(func() {
+		defaultController = NewController()
+	})
+	return defaultController
+}
+
+// Filter leaves models on trial out of a routing pool except for their current share of
+// requests, and leaves rolled-back models out entirely. Credentials left without models
+// are removed too. When it would leave nothing to route to, the pool is returned unchanged
+// Trials start the first time a model with a rollout config is seen, and restart when
+// its rollout config changes
+func (c *Controller) Filter(creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	excluded := false
+	var keptModels []config.VendorModel
+	vendors := make(map[string]bool)
+	for _, model := range models {
+		if model.Rollout != nil && !c.admit(model) {
+			excluded = true
+			continue
+		}
+		keptModels = append(keptModels, model)
+		vendors[model.Vendor] = true
+	}
+	if !excluded {
+		return creds, models
+	}
+
+	var keptCreds []config.Credential
+	for _, cred := range creds {
+		if vendors[cred.Platform] {
+			keptCreds = append(keptCreds, cred)
+		}
+	}
+
+	if len(keptModels) == 0 || len(keptCreds) == 0 {
+		return creds, models
+	}
+	return keptCreds, keptModels
+}
+
+// admit decides whether a model on trial is eligible for this request; callers hold mu
+func (c *Controller) admit(model config.VendorModel) bool {
+	t := c.trialFor(model)
+	c.promote(t)
+
+	switch t.state.Phase {
+	case PhaseComplete:
+		return true
+	case PhaseRolledBack:
+		return false
+	default:
+		return c.rng.Float64()*100 < t.state.Percent
+	}
+}
+
+// trialFor returns the model's trial, starting a new one for an unseen or changed config
+func (c *Controller) trialFor(model config.VendorModel) *trial {
+	key := modelKey(model.Vendor, model.Model)
+	if t, ok := c.trials[key]; ok && t.config == *model.Rollout {
+		return t
+	}
+
+	t := &trial{
+		config: *model.Rollout,
+		state: State{
+			Vendor:      model.Vendor,
+			Model:       model.Model,
+			Phase:       PhaseRamping,
+			Percent:     model.Rollout.InitialPercent,
+			StepStarted: c.now(),
+		},
+	}
+	if t.state.Percent >= 100 {
+		t.state.Percent = 100
+		t.state.Phase = PhaseComplete
+	}
+	c.trials[key] = t
+	c.logTransition(t, "Rollout started")
+	return t
+}
+
+// Observe records the outcome of a request served by vendor/model; models not on trial
+// are ignored. failed marks requests that errored, whatever their latency
+func (c *Controller) Observe(vendor, model string, failed bool, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t, ok := c.trials[modelKey(vendor, model)]
+	if !ok || t.state.Phase != PhaseRamping {
+		return
+	}
+
+	t.state.Requests++
+	if failed {
+		t.state.Errors++
+	} else if len(t.latencies) < maxLatencySamples {
+		t.latencies = append(t.latencies, latency)
+	}
+
+	if reason := t.breach(); reason != "" {
+		t.state.Phase = PhaseRolledBack
+		t.state.Percent = 0
+		t.state.Reason = reason
+		c.logTransition(t, "Rollout rolled back")
+		return
+	}
+	c.promote(t)
+}
+
+// promote moves a healthy trial to its next step once the step has lasted long enough
+// and seen enough requests; callers hold mu
+func (c *Controller) promote(t *trial) {
+	if t.state.Phase != PhaseRamping || t.state.Requests < t.minRequests() || c.now().Sub(t.state.StepStarted) < t.stepDuration() {
+		return
+	}
+
+	t.state.Percent += t.stepPercent()
+	t.state.StepStarted = c.now()
+	t.state.Requests, t.state.Errors = 0, 0
+	t.latencies = t.latencies[:0]
+
+	if t.state.Percent >= 100 {
+		t.state.Percent = 100
+		t.state.Phase = PhaseComplete
+		c.logTransition(t, "Rollout completed")
+		return
+	}
+	c.logTransition(t, "Rollout promoted")
+}
+
+// breach returns why the current step breaches the trial's limits, or "" if it does not
+// Steps are only judged once they have seen MinRequests requests
+func (t *trial) breach() string {
+	if t.state.Requests < t.minRequests() {
+		return ""
+	}
+	maxErrorRate := t.config.MaxErrorRate
+	if maxErrorRate == 0 {
+		maxErrorRate = DefaultMaxErrorRate
+	}
+	if rate := float64(t.state.Errors) / float64(t.state.Requests); rate > maxErrorRate {
+		return fmt.Sprintf("error rate %.1f%% above %.1f%%", rate*100, maxErrorRate*100)
+	}
+	if limit := time.Duration(t.config.MaxP95LatencyMs) * time.Millisecond; limit > 0 && len(t.latencies) > 0 {
+		if p95 := percentile(t.latencies, 0.95); p95 > limit {
+			return fmt.Sprintf("p95 latency %dms above %dms", p95.Milliseconds(), limit.Milliseconds())
+		}
+	}
+	return ""
+}
+
+func (t *trial) stepPercent() float64 {
+	if t.config.StepPercent > 0 {
+		return t.config.StepPercent
+	}
+	return DefaultStepPercent
+}
+
+func (t *trial) stepDuration() time.Duration {
+	if t.config.StepSeconds > 0 {
+		return time.Duration(t.config.StepSeconds) * time.Second
+	}
+	return DefaultStepDuration
+}
+
+func (t *trial) minRequests() int {
+	if t.config.MinRequests > 0 {
+		return t.config.MinRequests
+	}
+	return DefaultMinRequests
+}
+
+// States returns the rollout state of every model on trial sorted by vendor and model
+func (c *Controller) States() []State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	states := make([]State, 0, len(c.trials))
+	for _, t := range c.trials {
+		states = append(states, t.state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Vendor != states[j].Vendor {
+			return states[i].Vendor < states[j].Vendor
+		}
+		return states[i].Model < states[j].Model
+	})
+	return states
+}
+
+// logTransition logs a trial entering a new step or phase
+func (c *Controller) logTransition(t *trial, message string) {
+	ctx := logger.WithComponent(context.Background(), "RolloutController")
+	ctx = logger.WithStage(ctx, string(t.state.Phase))
+
+	attrs := []any{
+		"vendor", t.state.Vendor,
+		"model", t.state.Model,
+		"phase", string(t.state.Phase),
+		"percent", t.state.Percent,
+	}
+	if t.state.Phase == PhaseRolledBack {
+		logger.Warn(ctx, message, append(attrs, "reason", t.state.Reason)...)
+		return
+	}
+	logger.Info(ctx, message, attrs...)
+}
+
+// percentile returns the p-th percentile of durations, sorting a copy
+func percentile(durations []time.Duration, p float64) time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	index := int(p*float64(len(sorted))+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	return sorted[index]
+}
+
+// modelKey identifies a vendor/model pair
+func modelKey(vendor, model string) string {
+	return vendor + "/" + model
+}
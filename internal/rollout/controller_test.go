@@ -0,0 +1,145 @@
+package rollout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestController returns a controller whose clock only moves when advance is called
+func newTestController() (*Controller, func(time.Duration)) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	c := NewController()
+	c.now = func() time.Time { return now }
+	return c, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestController_Filter(t *testing.T) {
+	c, _ := newTestController()
+	creds := []config.Credential{{Platform: "openai", Value: "sk-a"}, {Platform: "deepseek", Value: "sk-b"}}
+	trialModel := config.VendorModel{Vendor: "deepseek", Model: "deepseek-chat", Rollout: &config.RolloutConfig{InitialPercent: 25}}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, trialModel}
+
+	admitted := 0
+	for i := 0; i < 4000; i++ {
+		keptCreds, keptModels := c.Filter(creds, models)
+		if len(keptModels) == 2 {
+			admitted++
+			continue
+		}
+		assert.Equal(t, []config.VendorModel{models[0]}, keptModels)
+		assert.Equal(t, creds[:1], keptCreds, "credentials left without models are removed")
+	}
+	assert.InDelta(t, 1000, admitted, 150, "the trial model is eligible for about its share of requests")
+
+	// A pool of only the trial model is never emptied
+	keptCreds, keptModels := c.Filter(creds[1:], models[1:])
+	assert.Equal(t, creds[1:], keptCreds)
+	assert.Equal(t, models[1:], keptModels)
+}
+
+func TestController_RampAndComplete(t *testing.T) {
+	c, advance := newTestController()
+	model := config.VendorModel{Vendor: "deepseek", Model: "deepseek-chat", Rollout: &config.RolloutConfig{
+		InitialPercent: 50, StepPercent: 30, StepSeconds: 60, MinRequests: 2,
+	}}
+	c.Filter(nil, []config.VendorModel{model})
+
+	observe := func(n int) {
+		for i := 0; i < n; i++ {
+			c.Observe("deepseek", "deepseek-chat", false, 100*time.Millisecond)
+		}
+	}
+
+	observe(5)
+	assert.Equal(t, 50.0, c.States()[0].Percent, "a step lasts StepSeconds")
+
+	advance(time.Minute)
+	observe(1)
+	require.Equal(t, 80.0, c.States()[0].Percent)
+	assert.Equal(t, 0, c.States()[0].Requests, "step outcomes reset on promotion")
+
+	advance(time.Minute)
+	observe(1)
+	assert.Equal(t, 80.0, c.States()[0].Percent, "a step needs MinRequests in the step")
+	observe(1)
+	state := c.States()[0]
+	assert.Equal(t, PhaseComplete, state.Phase)
+	assert.Equal(t, 100.0, state.Percent)
+
+	observe(100)
+	assert.Equal(t, PhaseComplete, c.States()[0].Phase, "completed models are no longer judged")
+}
+
+func TestController_Rollback(t *testing.T) {
+	tests := []struct {
+		name     string
+		rollout  config.RolloutConfig
+		failed   []bool
+		latency  time.Duration
+		expected string
+	}{
+		{
+			name:     "error rate",
+			rollout:  config.RolloutConfig{InitialPercent: 10, MinRequests: 4, MaxErrorRate: 0.25},
+			failed:   []bool{false, true, false, true},
+			expected: "error rate 50.0% above 25.0%",
+		},
+		{
+			name:     "default error rate",
+			rollout:  config.RolloutConfig{InitialPercent: 10, MinRequests: 10},
+			failed:   []bool{false, false, false, false, false, false, false, false, false, true},
+			expected: "error rate 10.0% above 5.0%",
+		},
+		{
+			name:     "p95 latency",
+			rollout:  config.RolloutConfig{InitialPercent: 10, MinRequests: 3, MaxP95LatencyMs: 2000},
+			failed:   []bool{false, false, false},
+			latency:  3 * time.Second,
+			expected: "p95 latency 3000ms above 2000ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestController()
+			rollout := tt.rollout
+			model := config.VendorModel{Vendor: "together", Model: "meta-llama/Llama-3.3-70B-Instruct-Turbo", Rollout: &rollout}
+			c.Filter(nil, []config.VendorModel{model})
+
+			for i, failed := range tt.failed {
+				if i < len(tt.failed)-1 {
+					assert.Equal(t, PhaseRamping, c.States()[0].Phase, "steps are judged once they reach MinRequests")
+				}
+				c.Observe(model.Vendor, model.Model, failed, tt.latency)
+			}
+
+			state := c.States()[0]
+			assert.Equal(t, PhaseRolledBack, state.Phase)
+			assert.Equal(t, 0.0, state.Percent)
+			assert.Equal(t, tt.expected, state.Reason)
+
+			// Rolled back models stay out of the pool until their rollout config changes
+			creds := []config.Credential{{Platform: "openai"}, {Platform: "together"}}
+			models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, model}
+			_, kept := c.Filter(creds, models)
+			assert.Equal(t, models[:1], kept)
+
+			changed := rollout
+			changed.InitialPercent = 100
+			models[1].Rollout = &changed
+			_, kept = c.Filter(creds, models)
+			assert.Equal(t, models, kept)
+			assert.Equal(t, PhaseComplete, c.States()[0].Phase, "a trial starting at 100% is complete")
+		})
+	}
+}
+
+func TestController_ObserveIgnoresModelsNotOnTrial(t *testing.T) {
+	c, _ := newTestController()
+	c.Observe("openai", "gpt-4o", true, time.Second)
+	assert.Empty(t, c.States())
+}
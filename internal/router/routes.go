@@ -6,6 +6,7 @@ import (
 	"github.com/aashari/go-generative-api-router/internal/handlers"
 	"github.com/aashari/go-generative-api-router/internal/middleware"
 	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/utils"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
@@ -16,8 +17,54 @@ func SetupRoutes(apiHandlers *handlers.APIHandlers) http.Handler {
 	// Register API handlers
 	mux.HandleFunc("/health", apiHandlers.HealthHandler)
 	mux.HandleFunc("/v1/chat/completions", apiHandlers.ChatCompletionsHandler)
+	mux.HandleFunc("/v1/responses", apiHandlers.ResponsesHandler)
+	mux.HandleFunc("/v1/messages", apiHandlers.MessagesHandler)
+	mux.HandleFunc("/v1/aggregate", apiHandlers.AggregateHandler)
 	mux.HandleFunc("/v1/models", apiHandlers.ModelsHandler)
+	mux.HandleFunc("/v1/models/{id...}", apiHandlers.ModelHandler)
+	mux.HandleFunc("POST /v1/models/{target...}", apiHandlers.GenerateContentHandler)
+	mux.HandleFunc("/v1beta/models/{target...}", apiHandlers.GenerateContentHandler)
 	mux.HandleFunc("/v1/images/text", apiHandlers.ImageToTextHandler)
+	mux.HandleFunc("/v1/embeddings", apiHandlers.EmbeddingsHandler)
+	mux.HandleFunc("/v1/audio/transcriptions", apiHandlers.TranscriptionsHandler)
+	mux.HandleFunc("/v1/audio/speech", apiHandlers.SpeechHandler)
+	mux.HandleFunc("/v1/moderations", apiHandlers.ModerationsHandler)
+	mux.HandleFunc("/v1/rerank", apiHandlers.RerankHandler)
+	mux.HandleFunc("/v1/tokenize", apiHandlers.TokenizeHandler)
+	mux.HandleFunc("/v1/count_tokens", apiHandlers.CountTokensHandler)
+	mux.HandleFunc("/v1/cost_estimate", apiHandlers.CostEstimateHandler)
+	mux.HandleFunc("/v1/files", apiHandlers.FilesHandler)
+	mux.HandleFunc("/v1/files/{id}", apiHandlers.FileHandler)
+	mux.HandleFunc("/v1/files/{id}/content", apiHandlers.FileContentHandler)
+	mux.HandleFunc("/v1/batches", apiHandlers.BatchesHandler)
+	mux.HandleFunc("/v1/batches/{id}", apiHandlers.BatchHandler)
+	mux.HandleFunc("/v1/batches/{id}/cancel", apiHandlers.CancelBatchHandler)
+
+	// Catch-all for unimplemented OpenAI endpoints
+	mux.HandleFunc("/v1/", apiHandlers.UnsupportedEndpointHandler)
+
+	// Register admin handlers
+	mux.HandleFunc("/admin/routing/decisions", apiHandlers.RoutingDecisionsHandler)
+	mux.HandleFunc("/admin/metrics/payload-sizes", apiHandlers.PayloadSizesHandler)
+	mux.HandleFunc("/admin/metrics/response-anomalies", apiHandlers.ResponseAnomaliesHandler)
+	mux.HandleFunc("/admin/metrics/slow-clients", apiHandlers.SlowClientsHandler)
+	mux.HandleFunc("/admin/metrics/load-shedding", apiHandlers.LoadSheddingHandler)
+	mux.HandleFunc("/admin/metrics/latency", apiHandlers.LatencyStatsHandler)
+	mux.HandleFunc("/admin/metrics/semantic-cache", apiHandlers.SemanticCacheHandler)
+	mux.HandleFunc("/admin/maintenance", apiHandlers.MaintenanceHandler)
+	mux.HandleFunc("/admin/rollouts", apiHandlers.RolloutsHandler)
+	mux.HandleFunc("/admin/budgets", apiHandlers.BudgetsHandler)
+	mux.HandleFunc("/admin/support-bundle", apiHandlers.SupportBundleHandler)
+	mux.HandleFunc("/admin/captures", apiHandlers.CapturesHandler)
+	mux.HandleFunc("/admin/captures/{id}", apiHandlers.CaptureHandler)
+	mux.HandleFunc("/admin/captures/{id}/replay", apiHandlers.CaptureReplayHandler)
+	mux.HandleFunc("/admin/dashboard", apiHandlers.DashboardHandler)
+	mux.HandleFunc("/admin/dashboard/data", apiHandlers.DashboardDataHandler)
+
+	// Serve Prometheus metrics here unless METRICS_PORT moves them to their own listener
+	if MetricsPort() == 0 {
+		mux.HandleFunc("/metrics", apiHandlers.MetricsHandler)
+	}
 
 	// Add pprof endpoints for performance profiling
 	monitoring.SetupPprofRoutes(mux)
@@ -31,10 +78,41 @@ func SetupRoutes(apiHandlers *handlers.APIHandlers) http.Handler {
 	))
 
 	// Wrap with middleware stack
-	// Apply CORS first (outermost), then User-Agent filtering, then request correlation
-	handler := middleware.UserAgentFilterMiddleware(mux)
+	// Apply Server-Timing first (outermost, so its total covers every layer), then the access
+	// log (which sees the final status of every response), then slow-request detection (which
+	// holds back each request's log entries until it knows how long it took), then CORS,
+	// then request correlation, then request capture (inside correlation, so exchanges are
+	// keyed by request ID), then API key header translation, then admin and client key
+	// authentication (after translation, so every SDK's key is checked), then User-Agent filtering, then
+	// maintenance mode, then load shedding, then admission control
+	handler := middleware.AdmissionMiddleware(mux)
+	handler = middleware.LoadSheddingMiddleware(handler)
+	handler = middleware.MaintenanceMiddleware(handler)
+	handler = middleware.UserAgentFilterMiddleware(handler)
+	handler = middleware.AuthMiddleware(handler)
+	handler = middleware.AdminAuthMiddleware(handler)
+	handler = middleware.APIKeyHeaderMiddleware(handler)
+	handler = middleware.CaptureMiddleware(handler)
 	handler = middleware.RequestCorrelationMiddleware(handler)
 	handler = middleware.CORSMiddleware(handler)
+	handler = middleware.SlowRequestMiddleware(handler)
+	handler = middleware.AccessLogMiddleware(handler)
+	handler = middleware.ServerTimingMiddleware(handler)
 
+	// Captured requests are replayed through the whole stack
+	apiHandlers.Pipeline = handler
 	return handler
 }
+
+// MetricsPort is the port of the separate metrics listener set by METRICS_PORT, or 0 when
+// metrics are served on the main router
+func MetricsPort() int {
+	return utils.GetEnvPort("METRICS_PORT", 0)
+}
+
+// SetupMetricsRoutes configures the routes of the separate metrics listener
+func SetupMetricsRoutes(apiHandlers *handlers.APIHandlers) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", apiHandlers.MetricsHandler)
+	return mux
+}
@@ -0,0 +1,259 @@
+// Package scenario evaluates declarative routing scenarios against a routing configuration,
+// so changes to models, ACLs and tools can be checked before they are deployed
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/proxy"
+	"gopkg.in/yaml.v3"
+)
+
+// endpointTypes maps scenario endpoints to the type of model they route to
+var endpointTypes = map[string]string{
+	"chat":           config.ModelTypeChat,
+	"embeddings":     config.ModelTypeEmbedding,
+	"transcriptions": config.ModelTypeTranscription,
+	"speech":         config.ModelTypeSpeech,
+	"moderations":    config.ModelTypeModeration,
+	"rerank":         config.ModelTypeRerank,
+}
+
+// File is the on-disk scenario format
+type File struct {
+	Scenarios []Scenario `yaml:"scenarios"`
+}
+
+// Scenario is a synthetic request and the routing outcome expected for it
+type Scenario struct {
+	Name string `yaml:"name"`
+	// Endpoint is chat (the default), embeddings, transcriptions, speech, moderations or rerank
+	Endpoint string `yaml:"endpoint"`
+	// ClientKey is the bearer token the request is sent with
+	ClientKey string `yaml:"client_key"`
+	// Vendor is the ?vendor= query parameter
+	Vendor string `yaml:"vendor"`
+	// Request is the request body
+	Request map[string]interface{} `yaml:"request"`
+	Expect  Expectation            `yaml:"expect"`
+}
+
+// Expectation is the routing outcome a scenario asserts; unset fields are not checked
+// Models are given as "vendor/model"
+type Expectation struct {
+	// Pool is the exact set of models the request may be routed to
+	Pool []string `yaml:"pool"`
+	// Includes are models that must be in the pool
+	Includes []string `yaml:"includes"`
+	// Excludes are models that must not be in the pool
+	Excludes []string `yaml:"excludes"`
+	// Applied is the exact set of policies that removed models from the pool
+	Applied []string `yaml:"applied"`
+	// Rejected expects the request to be rejected
+	Rejected bool `yaml:"rejected"`
+	// Status is the HTTP status the request is rejected with, implying Rejected
+	Status int `yaml:"status"`
+	// Error is a substring of the rejection message, implying Rejected
+	Error string `yaml:"error"`
+}
+
+// Environment is the routing configuration scenarios are evaluated against
+// Every vendor with a model is assumed to have a credential
+type Environment struct {
+	Models []config.VendorModel
+	// ACL defaults to an unrestricted one when nil
+	ACL *access.ACL
+}
+
+// Result is the outcome of one scenario
+type Result struct {
+	Name     string
+	Plan     *proxy.RoutingPlan
+	Failures []string
+}
+
+// Passed reports whether the routing outcome met every expectation
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Load reads and validates a scenario file
+func Load(path string) ([]Scenario, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario file: %w", err)
+	}
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid scenario file: %w", err)
+	}
+	if err := Validate(file.Scenarios); err != nil {
+		return nil, err
+	}
+	return file.Scenarios, nil
+}
+
+// Validate checks that scenarios are named uniquely and use known endpoints and policies
+func Validate(scenarios []Scenario) error {
+	if len(scenarios) == 0 {
+		return fmt.Errorf("scenario file defines no scenarios")
+	}
+	policies := map[string]bool{
+		proxy.PolicyVendor: true, proxy.PolicyACL: true, proxy.PolicyExclusions: true,
+		proxy.PolicyCapabilities: true, proxy.PolicyEndpoint: true,
+	}
+	names := make(map[string]bool, len(scenarios))
+	for i, scenario := range scenarios {
+		if scenario.Name == "" {
+			return fmt.Errorf("scenario %d: name is required", i+1)
+		}
+		if names[scenario.Name] {
+			return fmt.Errorf("scenario %q: name is used more than once", scenario.Name)
+		}
+		names[scenario.Name] = true
+		if _, ok := endpointTypes[scenario.endpoint()]; !ok {
+			return fmt.Errorf("scenario %q: endpoint must be chat, embeddings, transcriptions, speech, moderations or rerank", scenario.Name)
+		}
+		for _, policy := range scenario.Expect.Applied {
+			if !policies[policy] {
+				return fmt.Errorf("scenario %q: unknown policy %q", scenario.Name, policy)
+			}
+		}
+		if scenario.Expect.rejected() && (scenario.Expect.Pool != nil || len(scenario.Expect.Includes) > 0) {
+			return fmt.Errorf("scenario %q: a rejected request has no pool to expect models in", scenario.Name)
+		}
+	}
+	return nil
+}
+
+// Run evaluates each scenario against the environment
+func Run(scenarios []Scenario, env Environment) []Result {
+	acl := env.ACL
+	if acl == nil {
+		acl = access.NewACL(access.Policy{}, nil)
+	}
+	creds := vendorCredentials(env.Models)
+
+	results := make([]Result, 0, len(scenarios))
+	for _, scenario := range scenarios {
+		result := Result{Name: scenario.Name}
+		body, err := json.Marshal(scenario.Request)
+		if err != nil {
+			result.Failures = append(result.Failures, fmt.Sprintf("request cannot be encoded as JSON: %v", err))
+			results = append(results, result)
+			continue
+		}
+		result.Plan = proxy.PlanRouting(proxy.RoutingRequest{
+			ModelType: endpointTypes[scenario.endpoint()],
+			Vendor:    scenario.Vendor,
+			Body:      body,
+			Policy:    acl.Policy(scenario.ClientKey),
+		}, creds, env.Models)
+		result.Failures = scenario.Expect.check(result.Plan)
+		results = append(results, result)
+	}
+	return results
+}
+
+// endpoint returns the scenario's endpoint, defaulting to chat
+func (s Scenario) endpoint() string {
+	if s.Endpoint == "" {
+		return "chat"
+	}
+	return s.Endpoint
+}
+
+// rejected reports whether the expectation is of a rejected request
+func (e Expectation) rejected() bool {
+	return e.Rejected || e.Status != 0 || e.Error != ""
+}
+
+// check returns a description of each expectation the plan does not meet
+func (e Expectation) check(plan *proxy.RoutingPlan) []string {
+	var failures []string
+	if e.rejected() {
+		if plan.Status == 0 {
+			return []string{fmt.Sprintf("expected a rejection, routed to %s", strings.Join(plan.Pool, ", "))}
+		}
+		if e.Status != 0 && e.Status != plan.Status {
+			failures = append(failures, fmt.Sprintf("expected status %d, rejected with %d: %s", e.Status, plan.Status, plan.Error))
+		}
+		if e.Error != "" && !strings.Contains(plan.Error, e.Error) {
+			failures = append(failures, fmt.Sprintf("expected error containing %q, got %q", e.Error, plan.Error))
+		}
+	} else if plan.Status != 0 {
+		return []string{fmt.Sprintf("rejected with status %d: %s", plan.Status, plan.Error)}
+	}
+
+	pool := make(map[string]bool, len(plan.Pool))
+	for _, pair := range plan.Pool {
+		pool[pair] = true
+	}
+	if e.Pool != nil && !sameSet(e.Pool, plan.Pool) {
+		failures = append(failures, fmt.Sprintf("expected pool [%s], got [%s]", strings.Join(sorted(e.Pool), ", "), strings.Join(sorted(plan.Pool), ", ")))
+	}
+	for _, pair := range e.Includes {
+		if !pool[pair] {
+			failures = append(failures, fmt.Sprintf("expected %s in the pool", pair))
+		}
+	}
+	for _, pair := range e.Excludes {
+		if pool[pair] {
+			failures = append(failures, fmt.Sprintf("expected %s not to be in the pool", pair))
+		}
+	}
+
+	if e.Applied != nil {
+		applied := make([]string, 0, len(plan.Steps))
+		for _, step := range plan.Steps {
+			applied = append(applied, step.Policy)
+		}
+		if !sameSet(e.Applied, applied) {
+			failures = append(failures, fmt.Sprintf("expected policies [%s] to apply, got [%s]", strings.Join(sorted(e.Applied), ", "), strings.Join(sorted(applied), ", ")))
+		}
+	}
+	return failures
+}
+
+// vendorCredentials returns a placeholder credential for each vendor with a model
+func vendorCredentials(models []config.VendorModel) []config.Credential {
+	seen := make(map[string]bool)
+	var creds []config.Credential
+	for _, model := range models {
+		if !seen[model.Vendor] {
+			seen[model.Vendor] = true
+			creds = append(creds, config.Credential{Platform: model.Vendor, Type: "api-key", Value: "scenario"})
+		}
+	}
+	return creds
+}
+
+// sameSet reports whether both lists hold the same values, ignoring order and duplicates
+func sameSet(a, b []string) bool {
+	set := make(map[string]bool, len(a))
+	for _, value := range a {
+		set[value] = true
+	}
+	other := make(map[string]bool, len(b))
+	for _, value := range b {
+		if !set[value] {
+			return false
+		}
+		other[value] = true
+	}
+	return len(set) == len(other)
+}
+
+// sorted returns a sorted copy of values
+func sorted(values []string) []string {
+	result := append([]string(nil), values...)
+	sort.Strings(result)
+	return result
+}
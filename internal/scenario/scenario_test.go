@@ -0,0 +1,117 @@
+package scenario
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const scenarioFile = `
+scenarios:
+  - name: images only reach vision models
+    request:
+      messages:
+        - role: user
+          content:
+            - type: image_url
+              image_url: {url: "https://example.com/cat.png"}
+    expect:
+      pool: [openai/gpt-4o]
+      applied: [capabilities]
+  - name: team key is limited to gemini
+    client_key: sk-team
+    request:
+      messages: [{role: user, content: hi}]
+    expect:
+      pool: [gemini/gemini-flash]
+      applied: [acl]
+  - name: team key may not exclude vendors
+    client_key: sk-team
+    request:
+      messages: [{role: user, content: hi}]
+      router: {exclude_vendors: [openai]}
+    expect:
+      status: 403
+      error: not permitted
+  - name: excluding every vendor
+    request:
+      messages: [{role: user, content: hi}]
+      router: {exclude_vendors: [openai, gemini]}
+    expect:
+      status: 400
+  - name: embeddings
+    endpoint: embeddings
+    request: {input: hello}
+    expect:
+      pool: [openai/text-embedding-3-small]
+      applied: []
+  - name: wrong expectation
+    vendor: gemini
+    request:
+      messages: [{role: user, content: hi}]
+    expect:
+      includes: [openai/gpt-4o]
+      applied: [acl]
+`
+
+func TestRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenarios.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(scenarioFile), 0o600))
+	scenarios, err := Load(path)
+	require.NoError(t, err)
+
+	env := Environment{
+		Models: []config.VendorModel{
+			{Vendor: "openai", Model: "gpt-4o", Config: &config.ModelConfig{SupportImage: true}},
+			{Vendor: "gemini", Model: "gemini-flash", Config: &config.ModelConfig{}},
+			{Vendor: "openai", Model: "text-embedding-3-small", Type: config.ModelTypeEmbedding},
+		},
+		ACL: access.NewACL(access.Policy{}, map[string]access.Policy{
+			"sk-team": {AllowedVendors: []string{"gemini"}, DenyExclusions: true},
+		}),
+	}
+	results := Run(scenarios, env)
+	require.Len(t, results, len(scenarios))
+
+	for _, result := range results[:5] {
+		assert.True(t, result.Passed(), "%s: %v", result.Name, result.Failures)
+	}
+	failed := results[5]
+	assert.False(t, failed.Passed())
+	assert.Equal(t, []string{
+		"expected openai/gpt-4o in the pool",
+		"expected policies [acl] to apply, got [vendor]",
+	}, failed.Failures)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		scenarios     []Scenario
+		expectedError string
+	}{
+		{name: "no scenarios", expectedError: "no scenarios"},
+		{name: "missing name", scenarios: []Scenario{{}}, expectedError: "name is required"},
+		{name: "duplicate name", scenarios: []Scenario{{Name: "a"}, {Name: "a"}}, expectedError: "more than once"},
+		{name: "unknown endpoint", scenarios: []Scenario{{Name: "a", Endpoint: "images"}}, expectedError: "endpoint must be"},
+		{name: "unknown policy", scenarios: []Scenario{{Name: "a", Expect: Expectation{Applied: []string{"budget"}}}}, expectedError: `unknown policy "budget"`},
+		{name: "pool of a rejection", scenarios: []Scenario{{Name: "a", Expect: Expectation{Status: 403, Pool: []string{}}}}, expectedError: "no pool"},
+		{name: "valid", scenarios: []Scenario{{Name: "a", Endpoint: "speech", Expect: Expectation{Rejected: true}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.scenarios)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+}
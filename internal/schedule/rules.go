@@ -0,0 +1,137 @@
+// Package schedule applies time-window routing rules: while a rule's window is open, such as
+// nights or weekends, requests are only routed to the vendors and models it names
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	// Embed the time zone database: the runtime image has none, and rules name IANA zones
+	_ "time/tzdata"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+)
+
+// rule is a schedule rule with its window parsed
+type rule struct {
+	config   config.ScheduleRule
+	window   Window
+	location *time.Location
+}
+
+// Rules holds the configured schedule rules; the first rule whose window is open decides
+type Rules struct {
+	mu    sync.RWMutex
+	rules []rule
+	now   func() time.Time
+}
+
+var (
+	defaultRules     *Rules
+	defaultRulesOnce sync.Once
+)
+
+// NewRules creates a rule set with no rules
+func NewRules() *Rules {
+	return &Rules{now: time.Now}
+}
+
+// Default returns the process-wide rule set
+func Default() *Rules {
+	defaultRulesOnce.Do(func() {
+		defaultRules = NewRules()
+	})
+	return defaultRules
+}
+
+// Configure replaces the rules. When any rule is invalid the error names it and the
+// previous rules are kept
+func (r *Rules) Configure(rules []config.ScheduleRule) error {
+	parsed := make([]rule, 0, len(rules))
+	for i, cfg := range rules {
+		name := cfg.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		window, err := ParseWindow(cfg.Window)
+		if err != nil {
+			return fmt.Errorf("schedule %s: %w", name, err)
+		}
+		location := time.UTC
+		if cfg.Timezone != "" {
+			if location, err = time.LoadLocation(cfg.Timezone); err != nil {
+				return fmt.Errorf("schedule %s: unknown timezone %q", name, cfg.Timezone)
+			}
+		}
+		if len(cfg.Vendors) == 0 && len(cfg.Models) == 0 {
+			return fmt.Errorf("schedule %s: names no vendors or models to route to", name)
+		}
+		cfg.Name = name
+		parsed = append(parsed, rule{config: cfg, window: window, location: location})
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = parsed
+	return nil
+}
+
+// Active returns the names of the rules whose windows are open now, in configured order;
+// only the first is applied
+func (r *Rules) Active() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.now()
+	var names []string
+	for _, rule := range r.rules {
+		if rule.window.Contains(now.In(rule.location)) {
+			names = append(names, rule.config.Name)
+		}
+	}
+	return names
+}
+
+// Filter restricts a routing pool to the vendors and models of the first rule whose window
+// is open now, removing credentials left without models. When no rule applies, or the rule
+// would leave nothing to route to, the pool is returned unchanged
+func (r *Rules) Filter(creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := r.now()
+	for _, rule := range r.rules {
+		if !rule.window.Contains(now.In(rule.location)) {
+			continue
+		}
+		var keptModels []config.VendorModel
+		for _, model := range models {
+			if rule.allows(model) {
+				keptModels = append(keptModels, model)
+			}
+		}
+		keptCreds := filter.CredentialsForModels(creds, keptModels)
+		if len(keptModels) == 0 || len(keptCreds) == 0 {
+			return creds, models
+		}
+		return keptCreds, keptModels
+	}
+	return creds, models
+}
+
+// allows reports whether a rule lets requests be routed to model
+func (r rule) allows(model config.VendorModel) bool {
+	for _, vendor := range r.config.Vendors {
+		if strings.EqualFold(vendor, model.Vendor) {
+			return true
+		}
+	}
+	for _, name := range r.config.Models {
+		if name == model.Model || name == model.Vendor+"/"+model.Model {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules_Configure(t *testing.T) {
+	rules := NewRules()
+	valid := config.ScheduleRule{Name: "nights", Window: "00:00-06:00", Vendors: []string{"deepseek"}}
+	require.NoError(t, rules.Configure([]config.ScheduleRule{valid}))
+
+	tests := []struct {
+		name        string
+		rule        config.ScheduleRule
+		expectedErr string
+	}{
+		{"bad window", config.ScheduleRule{Name: "broken", Window: "noon", Vendors: []string{"ollama"}}, "schedule broken: unknown day"},
+		{"bad timezone", config.ScheduleRule{Window: "sat,sun", Timezone: "Mars/Olympus", Vendors: []string{"ollama"}}, "schedule #1: unknown timezone"},
+		{"no targets", config.ScheduleRule{Name: "empty", Window: "sat,sun"}, "names no vendors or models"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rules.Configure([]config.ScheduleRule{tt.rule})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+	assert.Len(t, rules.rules, 1, "invalid rules keep the previous ones")
+}
+
+func TestRules_Filter(t *testing.T) {
+	// 2026-10-16 is a Friday
+	now := time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC)
+	rules := NewRules()
+	rules.now = func() time.Time { return now }
+	require.NoError(t, rules.Configure([]config.ScheduleRule{
+		{Name: "night-batch", Window: "00:00-06:00", Vendors: []string{"deepseek"}},
+		{Name: "weekend-self-hosted", Window: "sat,sun", Timezone: "Asia/Jakarta", Models: []string{"ollama/llama3", "gpt-4o-mini"}},
+		{Name: "unserved", Window: "fri 08:00-09:00", Vendors: []string{"cohere"}},
+	}))
+
+	creds := []config.Credential{
+		{Platform: "openai", Value: "sk-openai"},
+		{Platform: "deepseek", Value: "sk-deepseek"},
+		{Platform: "ollama", Value: "none"},
+	}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o"},
+		{Vendor: "openai", Model: "gpt-4o-mini"},
+		{Vendor: "deepseek", Model: "deepseek-chat"},
+		{Vendor: "ollama", Model: "llama3"},
+	}
+
+	keptCreds, keptModels := rules.Filter(creds, models)
+	assert.Equal(t, []string{"night-batch"}, rules.Active())
+	assert.Equal(t, creds[1:2], keptCreds)
+	assert.Equal(t, models[2:3], keptModels)
+
+	// Friday 18:00 UTC is already Saturday in Jakarta
+	now = time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+	keptCreds, keptModels = rules.Filter(creds, models)
+	assert.Equal(t, []string{"weekend-self-hosted"}, rules.Active())
+	assert.Equal(t, []config.Credential{creds[0], creds[2]}, keptCreds)
+	assert.Equal(t, []config.VendorModel{models[1], models[3]}, keptModels)
+
+	now = time.Date(2026, 10, 16, 8, 30, 0, 0, time.UTC)
+	keptCreds, keptModels = rules.Filter(creds, models)
+	assert.Equal(t, []string{"unserved"}, rules.Active())
+	assert.Equal(t, creds, keptCreds, "a rule that would empty the pool is ignored")
+	assert.Equal(t, models, keptModels)
+
+	now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	keptCreds, keptModels = rules.Filter(creds, models)
+	assert.Empty(t, rules.Active())
+	assert.Equal(t, creds, keptCreds)
+	assert.Equal(t, models, keptModels)
+}
@@ -0,0 +1,133 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// minutesPerDay bounds a window's times, 24:00 being the end of the day
+const minutesPerDay = 24 * 60
+
+// dayNames maps day names, short and long, to their weekday
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// Window is a recurring weekly time window: a set of days and a time range on each of them
+// A range that ends before it starts runs past midnight into the next day
+type Window struct {
+	days   [7]bool
+	start  int // minutes after midnight
+	end    int
+	allDay bool
+}
+
+// ParseWindow parses a window expression: days, a time range, or days followed by a time
+// range. Days are "*" or a comma-separated list of days and day ranges such as "mon-fri"
+// or "sat,sun"; the time range is "HH:MM-HH:MM" in 24-hour time, e.g. "mon-fri 00:00-06:00"
+// or "* 22:00-02:00"
+func ParseWindow(expr string) (Window, error) {
+	var w Window
+	fields := strings.Fields(strings.ToLower(expr))
+	var days, times string
+	switch {
+	case len(fields) == 1 && strings.Contains(fields[0], ":"):
+		days, times = "*", fields[0]
+	case len(fields) == 1:
+		days = fields[0]
+	case len(fields) == 2:
+		days, times = fields[0], fields[1]
+	default:
+		return w, fmt.Errorf("window %q must be days, a time range, or days and a time range", expr)
+	}
+
+	if err := w.parseDays(days); err != nil {
+		return w, err
+	}
+	if times == "" {
+		w.allDay = true
+		return w, nil
+	}
+	startText, endText, ok := strings.Cut(times, "-")
+	if !ok {
+		return w, fmt.Errorf("time range %q must look like HH:MM-HH:MM", times)
+	}
+	var err error
+	if w.start, err = parseClock(startText); err != nil {
+		return w, err
+	}
+	if w.end, err = parseClock(endText); err != nil {
+		return w, err
+	}
+	if w.start == w.end {
+		return w, fmt.Errorf("time range %q is empty", times)
+	}
+	return w, nil
+}
+
+// Contains reports whether t falls inside the window, read in t's location
+func (w Window) Contains(t time.Time) bool {
+	day := t.Weekday()
+	minute := t.Hour()*60 + t.Minute()
+	switch {
+	case w.allDay:
+		return w.days[day]
+	case w.start < w.end:
+		return w.days[day] && minute >= w.start && minute < w.end
+	default:
+		// The range runs past midnight: its evening belongs to day and its early hours to
+		// the day before
+		return (w.days[day] && minute >= w.start) || (w.days[(day+6)%7] && minute < w.end)
+	}
+}
+
+// parseDays sets the window's days from "*" or a list of days and day ranges
+func (w *Window) parseDays(expr string) error {
+	if expr == "*" {
+		for day := range w.days {
+			w.days[day] = true
+		}
+		return nil
+	}
+	for _, part := range strings.Split(expr, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		from, ok := dayNames[first]
+		if !ok {
+			return fmt.Errorf("unknown day %q", first)
+		}
+		to := from
+		if isRange {
+			if to, ok = dayNames[last]; !ok {
+				return fmt.Errorf("unknown day %q", last)
+			}
+		}
+		// Ranges such as fri-mon wrap around the weekend
+		for day := from; ; day = (day + 1) % 7 {
+			w.days[day] = true
+			if day == to {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// parseClock parses HH:MM into minutes after midnight, accepting 24:00 as the end of the day
+func parseClock(text string) (int, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(text, "%d:%d", &hours, &minutes); err != nil || len(text) != 5 {
+		return 0, fmt.Errorf("time %q must look like HH:MM", text)
+	}
+	total := hours*60 + minutes
+	if hours < 0 || minutes < 0 || minutes > 59 || total > minutesPerDay {
+		return 0, fmt.Errorf("time %q is out of range", text)
+	}
+	return total, nil
+}
@@ -0,0 +1,63 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWindow_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"mon-fri 00:00-06:00 extra",
+		"someday",
+		"mon-funday",
+		"mon 0600",
+		"mon 6:00-8:00",
+		"mon 06:00-06:00",
+		"mon 25:00-26:00",
+		"mon 06:75-07:00",
+	} {
+		_, err := ParseWindow(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestWindow_Contains(t *testing.T) {
+	// 2026-10-16 is a Friday
+	at := func(day int, clock string) time.Time {
+		parsed, err := time.Parse("15:04", clock)
+		require.NoError(t, err)
+		return time.Date(2026, 10, 16+day, parsed.Hour(), parsed.Minute(), 0, 0, time.UTC)
+	}
+	friday, saturday, sunday, monday := 0, 1, 2, 3
+
+	tests := []struct {
+		expr     string
+		at       time.Time
+		expected bool
+	}{
+		{"00:00-06:00", at(friday, "05:59"), true},
+		{"00:00-06:00", at(friday, "06:00"), false},
+		{"mon-fri 09:00-17:00", at(friday, "12:00"), true},
+		{"mon-fri 09:00-17:00", at(saturday, "12:00"), false},
+		{"sat,sun", at(saturday, "00:00"), true},
+		{"sat,sun", at(sunday, "23:59"), true},
+		{"sat,sun", at(monday, "00:00"), false},
+		{"fri-mon", at(monday, "10:00"), true},
+		{"Saturday", at(saturday, "10:00"), true},
+		{"* 18:00-24:00", at(friday, "23:59"), true},
+		{"fri 22:00-02:00", at(friday, "23:00"), true},
+		{"fri 22:00-02:00", at(saturday, "01:30"), true},
+		{"fri 22:00-02:00", at(saturday, "23:00"), false},
+		{"fri 22:00-02:00", at(friday, "01:30"), false},
+	}
+
+	for _, tt := range tests {
+		window, err := ParseWindow(tt.expr)
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, tt.expected, window.Contains(tt.at), "%s at %s", tt.expr, tt.at.Format("Mon 15:04"))
+	}
+}
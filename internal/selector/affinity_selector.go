@@ -0,0 +1,141 @@
+package selector
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/shared"
+	"github.com/aashari/go-generative-api-router/internal/types"
+)
+
+// DefaultAffinityTTL is how long a conversation stays pinned to its vendor and model after
+// its last request
+const DefaultAffinityTTL = 30 * time.Minute
+
+// affinityRoute is the vendor and model a conversation is pinned to
+type affinityRoute struct {
+	vendor  string
+	model   string
+	expires time.Time
+}
+
+// AffinitySelector keeps multi-turn conversations on one vendor and model: the first request
+// of a conversation is routed by the wrapped selector, and follow-ups carrying the same
+// ConversationKey go to the same vendor/model for as long as it is still in the pool and
+// the conversation stays active within the TTL. When the pinned model has left the pool,
+// for instance because it failed and is being fallen back from, the conversation is
+// re-pinned to whatever the wrapped selector picks instead. With a Shared store, pins hold
+// across replicas
+type AffinitySelector struct {
+	next ContextSelector
+	// TTL is how long a pin lasts after the conversation's last request
+	TTL time.Duration
+	// Shared, when set, holds the pins too; while it is unavailable each replica uses its own
+	Shared shared.Store
+
+	mu     sync.Mutex
+	routes map[string]*affinityRoute
+	// swept is when expired pins were last dropped
+	swept time.Time
+	now   func() time.Time
+}
+
+// NewAffinitySelector wraps next with conversation affinity; a non-positive ttl uses
+// DefaultAffinityTTL
+func NewAffinitySelector(next ContextSelector, ttl time.Duration) *AffinitySelector {
+	if ttl <= 0 {
+		ttl = DefaultAffinityTTL
+	}
+	return &AffinitySelector{
+		next:   next,
+		TTL:    ttl,
+		routes: make(map[string]*affinityRoute),
+		now:    time.Now,
+	}
+}
+
+// Select routes with the wrapped selector; without a payload context there is no
+// conversation to stick to
+func (s *AffinitySelector) Select(creds []config.Credential, models []config.VendorModel) (*VendorSelection, error) {
+	return s.next.Select(creds, models)
+}
+
+// SelectWithContext routes a request to the vendor and model its conversation is pinned to,
+// or with the wrapped selector, pinning the conversation to its pick
+func (s *AffinitySelector) SelectWithContext(creds []config.Credential, models []config.VendorModel, context *types.PayloadContext) (*VendorSelection, error) {
+	if context == nil || context.ConversationKey == "" {
+		return s.next.SelectWithContext(creds, models, context)
+	}
+	key := context.ConversationKey
+
+	if vendor, model, ok := s.lookup(key); ok {
+		var pinnedModels []config.VendorModel
+		for _, m := range models {
+			if m.Vendor == vendor && m.Model == model {
+				pinnedModels = append(pinnedModels, m)
+			}
+		}
+		pinnedCreds := filter.CredentialsByVendor(creds, vendor)
+		if len(pinnedModels) > 0 && len(pinnedCreds) > 0 {
+			// The wrapped selector still picks among the vendor's credentials and checks the
+			// model supports this turn's capabilities
+			if selection, err := s.next.SelectWithContext(pinnedCreds, pinnedModels, context); err == nil {
+				s.pin(key, selection)
+				selection.Reason = "conversation affinity, " + selection.Reason
+				return selection, nil
+			}
+		}
+	}
+
+	selection, err := s.next.SelectWithContext(creds, models, context)
+	if err != nil {
+		return nil, err
+	}
+	s.pin(key, selection)
+	return selection, nil
+}
+
+// lookup returns the vendor and model of a conversation's unexpired pin
+func (s *AffinitySelector) lookup(key string) (string, string, bool) {
+	if s.Shared != nil {
+		if route, ok, err := s.Shared.Get(context.Background(), "affinity:"+key); err == nil {
+			vendor, model, found := strings.Cut(route, "/")
+			return vendor, model, ok && found
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	route, ok := s.routes[key]
+	if !ok || !s.now().Before(route.expires) {
+		return "", "", false
+	}
+	return route.vendor, route.model, true
+}
+
+// pin (re)starts a conversation's pin to the selection's vendor and model. Once per TTL,
+// expired pins are dropped so ended conversations do not accumulate
+func (s *AffinitySelector) pin(key string, selection *VendorSelection) {
+	if s.Shared != nil {
+		_ = s.Shared.Set(context.Background(), "affinity:"+key, selection.Vendor+"/"+selection.Model, s.TTL)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.swept) >= s.TTL {
+		for other, route := range s.routes {
+			if !now.Before(route.expires) {
+				delete(s.routes, other)
+			}
+		}
+		s.swept = now
+	}
+	s.routes[key] = &affinityRoute{vendor: selection.Vendor, model: selection.Model, expires: now.Add(s.TTL)}
+}
@@ -0,0 +1,130 @@
+package selector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/shared"
+	"github.com/aashari/go-generative-api-router/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAffinitySelector(ttl time.Duration) (*AffinitySelector, func(time.Duration)) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	selector := NewAffinitySelector(NewContextAwareSelector(), ttl)
+	selector.now = func() time.Time { return now }
+	return selector, func(d time.Duration) { now = now.Add(d) }
+}
+
+func affinityTestPool() ([]config.Credential, []config.VendorModel) {
+	credentials := []config.Credential{
+		{Platform: "openai", Type: "api_key", Value: "test-openai-key"},
+		{Platform: "gemini", Type: "api_key", Value: "test-gemini-key"},
+	}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o", Config: &config.ModelConfig{SupportImage: true}},
+		{Vendor: "openai", Model: "gpt-4o-mini", Config: &config.ModelConfig{}},
+		{Vendor: "gemini", Model: "gemini-2.5-flash", Config: &config.ModelConfig{SupportImage: true}},
+		{Vendor: "gemini", Model: "gemini-2.5-pro", Config: &config.ModelConfig{}},
+	}
+	return credentials, models
+}
+
+func TestAffinitySelector_StickyConversations(t *testing.T) {
+	selector, _ := newTestAffinitySelector(time.Minute)
+	creds, models := affinityTestPool()
+
+	conversations := []string{"conv-1", "conv-2", "conv-3", "conv-4"}
+	pinned := make(map[string]string)
+	for _, conversation := range conversations {
+		selection, err := selector.SelectWithContext(creds, models, &types.PayloadContext{ConversationKey: conversation})
+		require.NoError(t, err)
+		pinned[conversation] = selection.Vendor + "/" + selection.Model
+	}
+
+	for i := 0; i < 20; i++ {
+		for _, conversation := range conversations {
+			selection, err := selector.SelectWithContext(creds, models, &types.PayloadContext{ConversationKey: conversation})
+			require.NoError(t, err)
+			assert.Equal(t, pinned[conversation], selection.Vendor+"/"+selection.Model)
+			assert.Equal(t, "conversation affinity, weighted pick among 1 combinations", selection.Reason)
+		}
+	}
+}
+
+func TestAffinitySelector_WithoutConversation(t *testing.T) {
+	selector, _ := newTestAffinitySelector(time.Minute)
+	creds, models := affinityTestPool()
+
+	seen := make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		selection, err := selector.SelectWithContext(creds, models, &types.PayloadContext{})
+		require.NoError(t, err)
+		seen[selection.Model] = true
+	}
+	assert.Len(t, seen, len(models), "requests without a conversation are not pinned")
+	assert.Empty(t, selector.routes)
+}
+
+func TestAffinitySelector_Repin(t *testing.T) {
+	selector, advance := newTestAffinitySelector(time.Minute)
+	creds, models := affinityTestPool()
+	context := &types.PayloadContext{ConversationKey: "conv-1"}
+
+	first, err := selector.SelectWithContext(creds, models, context)
+	require.NoError(t, err)
+
+	// The pinned model leaving the pool, as when it is fallen back from, re-pins the conversation
+	var remaining []config.VendorModel
+	for _, model := range models {
+		if model.Model != first.Model {
+			remaining = append(remaining, model)
+		}
+	}
+	second, err := selector.SelectWithContext(creds, remaining, context)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Model, second.Model)
+	third, err := selector.SelectWithContext(creds, models, context)
+	require.NoError(t, err)
+	assert.Equal(t, second.Model, third.Model, "the conversation stays on its new model")
+
+	// A turn the pinned model cannot serve is routed elsewhere
+	visionContext := &types.PayloadContext{ConversationKey: "conv-vision"}
+	selector.routes["conv-vision"] = &affinityRoute{vendor: "openai", model: "gpt-4o-mini", expires: selector.now().Add(time.Minute)}
+	visionContext.HasImages = true
+	selection, err := selector.SelectWithContext(creds, models, visionContext)
+	require.NoError(t, err)
+	assert.Contains(t, []string{"gpt-4o", "gemini-2.5-flash"}, selection.Model)
+
+	// Pins expire once the conversation has been idle for the TTL, and are swept
+	advance(2 * time.Minute)
+	_, _, ok := selector.lookup("conv-1")
+	assert.False(t, ok)
+	_, err = selector.SelectWithContext(creds, models, &types.PayloadContext{ConversationKey: "conv-2"})
+	require.NoError(t, err)
+	assert.Len(t, selector.routes, 1)
+}
+
+func TestAffinitySelector_Shared(t *testing.T) {
+	store := shared.NewMemory()
+	creds, models := affinityTestPool()
+
+	replicas := make([]*AffinitySelector, 3)
+	for i := range replicas {
+		replicas[i] = NewAffinitySelector(NewContextAwareSelector(), time.Minute)
+		replicas[i].Shared = store
+	}
+
+	selection, err := replicas[0].SelectWithContext(creds, models, &types.PayloadContext{ConversationKey: "conv-1"})
+	require.NoError(t, err)
+	pinned := selection.Vendor + "/" + selection.Model
+
+	// Follow-ups landing on any replica stay on the pin the first one made
+	for i := 0; i < 20; i++ {
+		selection, err := replicas[i%len(replicas)].SelectWithContext(creds, models, &types.PayloadContext{ConversationKey: "conv-1"})
+		require.NoError(t, err)
+		assert.Equal(t, pinned, selection.Vendor+"/"+selection.Model)
+	}
+}
@@ -0,0 +1,130 @@
+package selector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/types"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Model filters a composite selector can chain
+const (
+	// FilterCapabilities keeps the models supporting the request's capabilities
+	FilterCapabilities = "capabilities"
+	// FilterFits keeps the models whose context window holds the request's estimated size
+	FilterFits = "fits"
+	// FilterHealthy drops the models whose rolling error rate reached SELECTOR_MAX_ERROR_RATE
+	FilterHealthy = "healthy"
+	// FilterCheapest keeps the priced models that would serve the request at the lowest cost
+	FilterCheapest = "cheapest"
+)
+
+// DefaultMaxErrorRate is the rolling error rate at which the healthy filter drops a model
+const DefaultMaxErrorRate = 0.5
+
+// ModelFilter narrows the models a request may be routed to
+type ModelFilter func(models []config.VendorModel, context *types.PayloadContext) []config.VendorModel
+
+// CompositeSelector chains model filters in front of another strategy: each filter narrows
+// the pool in turn, and the final strategy picks among the models left. A filter that would
+// leave no model is skipped, so the chain only ever expresses preferences
+type CompositeSelector struct {
+	Filters []ModelFilter
+	Pick    ContextSelector
+}
+
+// NewCompositeSelector chains filters in front of pick
+func NewCompositeSelector(pick ContextSelector, filters ...ModelFilter) *CompositeSelector {
+	return &CompositeSelector{Filters: filters, Pick: pick}
+}
+
+// Select narrows the pool and picks, for a request of unknown content
+func (s *CompositeSelector) Select(creds []config.Credential, models []config.VendorModel) (*VendorSelection, error) {
+	return s.SelectWithContext(creds, models, nil)
+}
+
+// SelectWithContext narrows the pool with each filter in turn and picks among the rest
+func (s *CompositeSelector) SelectWithContext(creds []config.Credential, models []config.VendorModel, context *types.PayloadContext) (*VendorSelection, error) {
+	if len(creds) == 0 {
+		return nil, fmt.Errorf("no credentials available")
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no models available")
+	}
+
+	models = filter.ModelsForCredentials(models, creds)
+	for _, modelFilter := range s.Filters {
+		if narrowed := modelFilter(models, context); len(narrowed) > 0 {
+			models = narrowed
+		}
+	}
+	return s.Pick.SelectWithContext(filter.CredentialsForModels(creds, models), models, context)
+}
+
+// NewModelFilter returns the filter named name
+func NewModelFilter(name string) (ModelFilter, error) {
+	switch name {
+	case FilterCapabilities:
+		return FilterModelsByCapabilities, nil
+	case FilterFits:
+		return func(models []config.VendorModel, context *types.PayloadContext) []config.VendorModel {
+			return fittingModels(models, context, DefaultExpectedCompletionTokens)
+		}, nil
+	case FilterCheapest:
+		return func(models []config.VendorModel, context *types.PayloadContext) []config.VendorModel {
+			return cheapestModels(models, context, DefaultExpectedCompletionTokens)
+		}, nil
+	case FilterHealthy:
+		return HealthyModels(monitoring.DefaultLatencyTracker(), utils.GetEnvFloat64("SELECTOR_MAX_ERROR_RATE", DefaultMaxErrorRate)), nil
+	default:
+		return nil, fmt.Errorf("unknown selector filter %q, expected %q, %q, %q or %q", name, FilterCapabilities, FilterFits, FilterHealthy, FilterCheapest)
+	}
+}
+
+// HealthyModels returns a filter dropping the models whose rolling error rate in tracker has
+// reached maxErrorRate; models without enough recent observations are kept
+func HealthyModels(tracker *monitoring.LatencyTracker, maxErrorRate float64) ModelFilter {
+	return func(models []config.VendorModel, _ *types.PayloadContext) []config.VendorModel {
+		var healthy []config.VendorModel
+		for _, model := range models {
+			stats, ok := tracker.Stats(model.Vendor, model.Model)
+			if ok && stats.Samples >= DefaultMinLatencySamples && stats.ErrorRate >= maxErrorRate {
+				continue
+			}
+			healthy = append(healthy, model)
+		}
+		return healthy
+	}
+}
+
+// newCompositeFromEnv builds the composite strategy: the filters named, in order, by
+// SELECTOR_FILTERS (default "capabilities,fits,healthy"), in front of the strategy named by
+// SELECTOR_COMPOSITE_PICK (default "weighted")
+func newCompositeFromEnv() (ContextSelector, error) {
+	pickName := utils.GetEnvString("SELECTOR_COMPOSITE_PICK", StrategyWeighted)
+	if pickName == StrategyComposite {
+		return nil, fmt.Errorf("SELECTOR_COMPOSITE_PICK cannot be %q", StrategyComposite)
+	}
+	pick, err := NewStrategy(pickName)
+	if err != nil {
+		return nil, fmt.Errorf("SELECTOR_COMPOSITE_PICK: %w", err)
+	}
+
+	var filters []ModelFilter
+	for _, name := range strings.Split(utils.GetEnvString("SELECTOR_FILTERS", "capabilities,fits,healthy"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		modelFilter, err := NewModelFilter(name)
+		if err != nil {
+			return nil, fmt.Errorf("SELECTOR_FILTERS: %w", err)
+		}
+		filters = append(filters, modelFilter)
+	}
+	return NewCompositeSelector(pick, filters...), nil
+}
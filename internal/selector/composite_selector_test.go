@@ -0,0 +1,63 @@
+package selector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompositeSelector(t *testing.T) {
+	tracker := monitoring.NewLatencyTracker(time.Minute)
+	for i := 0; i < 5; i++ {
+		tracker.Observe("openai", "gpt-4o-mini", true, 0)
+		tracker.Observe("gemini", "gemini-flash", false, 200*time.Millisecond)
+	}
+
+	creds := []config.Credential{{Platform: "openai"}, {Platform: "gemini"}, {Platform: "anthropic"}}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o-mini", Pricing: &config.ModelPricing{InputPerMillion: 0.15, OutputPerMillion: 0.6}},
+		{Vendor: "gemini", Model: "gemini-flash", Pricing: &config.ModelPricing{InputPerMillion: 0.3, OutputPerMillion: 2.5}},
+		{Vendor: "anthropic", Model: "claude-sonnet", Pricing: &config.ModelPricing{InputPerMillion: 3, OutputPerMillion: 15}},
+		{Vendor: "mistral", Model: "mistral-small", Pricing: &config.ModelPricing{InputPerMillion: 0.1, OutputPerMillion: 0.3}},
+	}
+	cheapest, err := NewModelFilter(FilterCheapest)
+	require.NoError(t, err)
+	selector := NewCompositeSelector(NewContextAwareSelector(), HealthyModels(tracker, DefaultMaxErrorRate), cheapest)
+
+	for i := 0; i < 20; i++ {
+		selection, err := selector.SelectWithContext(creds, models, &types.PayloadContext{PromptTokens: 1000})
+		require.NoError(t, err)
+		// mistral has no credential and gpt-4o-mini is failing, so gemini-flash is the cheapest left
+		assert.Equal(t, "gemini-flash", selection.Model)
+	}
+
+	// A filter that would leave nothing is skipped
+	onlyFailing := []config.VendorModel{models[0]}
+	selection, err := selector.SelectWithContext(creds, onlyFailing, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o-mini", selection.Model)
+}
+
+func TestCompositeFromEnv(t *testing.T) {
+	t.Setenv("SELECTOR_FILTERS", "fits, cheapest")
+	t.Setenv("SELECTOR_COMPOSITE_PICK", StrategyLatency)
+	strategy, err := NewStrategy(StrategyComposite)
+	require.NoError(t, err)
+	composite := strategy.(*CompositeSelector)
+	assert.Len(t, composite.Filters, 2)
+	assert.IsType(t, &LatencyAwareSelector{}, composite.Pick)
+
+	t.Setenv("SELECTOR_FILTERS", "fits,fastest")
+	_, err = NewStrategy(StrategyComposite)
+	assert.ErrorContains(t, err, `unknown selector filter "fastest"`)
+
+	t.Setenv("SELECTOR_FILTERS", "")
+	t.Setenv("SELECTOR_COMPOSITE_PICK", StrategyComposite)
+	_, err = NewStrategy(StrategyComposite)
+	assert.Error(t, err)
+}
@@ -1,21 +1,28 @@
 package selector
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/aashari/go-generative-api-router/internal/config"
 	"github.com/aashari/go-generative-api-router/internal/types"
 )
 
-// ContextAwareSelector extends EvenDistributionSelector to filter models based on payload context
+// ErrNoCapableModel is returned when no model in the pool supports every capability a
+// request needs; the request cannot be served as sent, so it is a client error
+var ErrNoCapableModel = errors.New("no models available that support the required capabilities")
+
+// ContextAwareSelector extends WeightedSelector to filter models based on payload context
+// Without configured weights it distributes evenly across vendor-credential-model combinations
 type ContextAwareSelector struct {
-	*EvenDistributionSelector
+	*WeightedSelector
 }
 
 // NewContextAwareSelector creates a new context-aware selector
 func NewContextAwareSelector() *ContextAwareSelector {
 	return &ContextAwareSelector{
-		EvenDistributionSelector: NewEvenDistributionSelector(),
+		WeightedSelector: NewWeightedSelector(),
 	}
 }
 
@@ -29,18 +36,50 @@ func (s *ContextAwareSelector) SelectWithContext(creds []config.Credential, mode
 	}
 
 	// Filter models based on payload context
-	filteredModels := filterModelsByCapabilities(models, context)
+	filteredModels := FilterModelsByCapabilities(models, context)
 
 	if len(filteredModels) == 0 {
-		return nil, fmt.Errorf("no models available that support the required capabilities")
+		return nil, NoCapableModelError(context)
 	}
 
 	// Use the parent's Select method with filtered models
-	return s.EvenDistributionSelector.Select(creds, filteredModels)
+	return s.WeightedSelector.Select(creds, filteredModels)
 }
 
-// filterModelsByCapabilities filters models based on their capabilities and the payload context
-func filterModelsByCapabilities(models []config.VendorModel, context *types.PayloadContext) []config.VendorModel {
+// RequiredCapabilities lists the capabilities a request needs, in the order they are checked
+func RequiredCapabilities(context *types.PayloadContext) []string {
+	if context == nil {
+		return nil
+	}
+	var required []string
+	for _, capability := range []struct {
+		name   string
+		needed bool
+	}{
+		{"images", context.HasImages},
+		{"videos", context.HasVideos},
+		{"audio", context.HasAudio},
+		{"tools", context.HasTools},
+		{"streaming", context.HasStream},
+	} {
+		if capability.needed {
+			required = append(required, capability.name)
+		}
+	}
+	return required
+}
+
+// NoCapableModelError wraps ErrNoCapableModel with the capabilities the request needs
+func NoCapableModelError(context *types.PayloadContext) error {
+	required := RequiredCapabilities(context)
+	if len(required) == 0 {
+		return ErrNoCapableModel
+	}
+	return fmt.Errorf("%w: request needs %s", ErrNoCapableModel, strings.Join(required, ", "))
+}
+
+// FilterModelsByCapabilities filters models based on their capabilities and the payload context
+func FilterModelsByCapabilities(models []config.VendorModel, context *types.PayloadContext) []config.VendorModel {
 	if context == nil {
 		// If no context, return all models
 		return models
@@ -76,6 +115,11 @@ func shouldIncludeModel(config *config.ModelConfig, context *types.PayloadContex
 		return false
 	}
 
+	// Check audio support
+	if context.HasAudio && !config.SupportAudio {
+		return false
+	}
+
 	// Check tools support
 	if context.HasTools && !config.SupportTools {
 		return false
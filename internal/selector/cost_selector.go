@@ -0,0 +1,129 @@
+package selector
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/types"
+)
+
+// DefaultExpectedCompletionTokens is the completion size requests that set no limit are
+// priced at
+const DefaultExpectedCompletionTokens = 512
+
+// CostAwareSelector routes each request to the cheapest model able to serve it: models are
+// filtered by the request's capabilities and by whether the estimated prompt and completion
+// fit their context window, then priced from their pricing config at the estimated token
+// counts. Equally cheap models share the traffic by weight; models without pricing are only
+// chosen when no capable model is priced
+type CostAwareSelector struct {
+	*WeightedSelector
+	// ExpectedCompletionTokens prices the completion of requests that set no limit
+	ExpectedCompletionTokens int
+}
+
+// NewCostAwareSelector creates a new cost-aware selector
+func NewCostAwareSelector() *CostAwareSelector {
+	return &CostAwareSelector{
+		WeightedSelector:         NewWeightedSelector(),
+		ExpectedCompletionTokens: DefaultExpectedCompletionTokens,
+	}
+}
+
+// Select picks the cheapest model for a request of unknown size
+func (s *CostAwareSelector) Select(creds []config.Credential, models []config.VendorModel) (*VendorSelection, error) {
+	return s.SelectWithContext(creds, models, nil)
+}
+
+// SelectWithContext picks the cheapest model that supports the request's capabilities and fits
+// its estimated size
+func (s *CostAwareSelector) SelectWithContext(creds []config.Credential, models []config.VendorModel, context *types.PayloadContext) (*VendorSelection, error) {
+	if len(creds) == 0 {
+		return nil, fmt.Errorf("no credentials available")
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no models available")
+	}
+
+	hasCredential := make(map[string]bool)
+	for _, cred := range creds {
+		hasCredential[cred.Platform] = true
+	}
+
+	supported := FilterModelsByCapabilities(models, context)
+	if len(supported) == 0 {
+		return nil, NoCapableModelError(context)
+	}
+
+	var capable []config.VendorModel
+	for _, model := range fittingModels(supported, context, s.ExpectedCompletionTokens) {
+		if hasCredential[model.Vendor] {
+			capable = append(capable, model)
+		}
+	}
+	if len(capable) == 0 {
+		return nil, fmt.Errorf("no models with credentials available that fit the request's context length")
+	}
+
+	cheapest := capable
+	if priced := cheapestModels(capable, context, s.ExpectedCompletionTokens); len(priced) > 0 {
+		cheapest = priced
+	}
+
+	selection, err := s.WeightedSelector.Select(creds, cheapest)
+	if err != nil {
+		return nil, err
+	}
+	selection.Reason = fmt.Sprintf("cheapest of %d capable models, %s", len(capable), selection.Reason)
+	return selection, nil
+}
+
+// estimatedTokens returns the prompt and completion size of a request, pricing requests
+// that set no completion limit at expectedCompletion
+func estimatedTokens(context *types.PayloadContext, expectedCompletion int) (int, int) {
+	if context == nil {
+		return 0, expectedCompletion
+	}
+	completion := expectedCompletion
+	if context.MaxCompletionTokens > 0 {
+		completion = context.MaxCompletionTokens
+	}
+	return context.PromptTokens, completion
+}
+
+// fittingModels returns the models whose context window holds the request's estimated
+// prompt and completion; models with an unknown window are assumed to fit
+func fittingModels(models []config.VendorModel, context *types.PayloadContext, expectedCompletion int) []config.VendorModel {
+	promptTokens, completionTokens := estimatedTokens(context, expectedCompletion)
+	var fitting []config.VendorModel
+	for _, model := range models {
+		if model.Config != nil && model.Config.ContextWindow > 0 && promptTokens+completionTokens > model.Config.ContextWindow {
+			continue
+		}
+		fitting = append(fitting, model)
+	}
+	return fitting
+}
+
+// cheapestModels returns the priced models that would serve the request at the lowest cost,
+// or none when no model is priced
+func cheapestModels(models []config.VendorModel, context *types.PayloadContext, expectedCompletion int) []config.VendorModel {
+	promptTokens, completionTokens := estimatedTokens(context, expectedCompletion)
+	lowest := math.Inf(1)
+	var cheapest []config.VendorModel
+	for _, model := range models {
+		if model.Pricing == nil {
+			continue
+		}
+		cost := float64(promptTokens)*model.Pricing.InputPerMillion + float64(completionTokens)*model.Pricing.OutputPerMillion
+		switch {
+		case cost < lowest:
+			lowest = cost
+			cheapest = []config.VendorModel{model}
+		case cost == lowest:
+			cheapest = append(cheapest, model)
+		}
+	}
+	return cheapest
+}
@@ -0,0 +1,110 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCostAwareSelector_SelectWithContext(t *testing.T) {
+	credentials := []config.Credential{
+		{Platform: "openai", Type: "api_key", Value: "test-openai-key"},
+		{Platform: "gemini", Type: "api_key", Value: "test-gemini-key"},
+	}
+	models := []config.VendorModel{
+		{
+			Vendor:  "openai",
+			Model:   "gpt-4o",
+			Config:  &config.ModelConfig{SupportImage: true, SupportTools: true, SupportStreaming: true, ContextWindow: 128000},
+			Pricing: &config.ModelPricing{InputPerMillion: 2.5, OutputPerMillion: 10},
+		},
+		{
+			Vendor:  "openai",
+			Model:   "gpt-4o-mini",
+			Config:  &config.ModelConfig{SupportImage: true, SupportTools: true, SupportStreaming: true, ContextWindow: 128000},
+			Pricing: &config.ModelPricing{InputPerMillion: 0.15, OutputPerMillion: 0.6},
+		},
+		{
+			Vendor:  "gemini",
+			Model:   "gemini-flash-lite",
+			Config:  &config.ModelConfig{SupportStreaming: true, ContextWindow: 8000},
+			Pricing: &config.ModelPricing{InputPerMillion: 0.075, OutputPerMillion: 0.3},
+		},
+		{
+			Vendor: "gemini",
+			Model:  "gemini-unpriced",
+			Config: &config.ModelConfig{SupportImage: true, SupportTools: true, SupportStreaming: true},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		context  *types.PayloadContext
+		expected string
+	}{
+		{name: "cheapest overall", context: &types.PayloadContext{PromptTokens: 100}, expected: "gemini-flash-lite"},
+		{name: "vision excludes the cheapest", context: &types.PayloadContext{HasImages: true, PromptTokens: 100}, expected: "gpt-4o-mini"},
+		{name: "tools exclude the cheapest", context: &types.PayloadContext{HasTools: true}, expected: "gpt-4o-mini"},
+		{name: "prompt exceeds the cheapest's context window", context: &types.PayloadContext{PromptTokens: 7800}, expected: "gpt-4o-mini"},
+		{name: "completion limit counts against the context window", context: &types.PayloadContext{PromptTokens: 1000, MaxCompletionTokens: 7500}, expected: "gpt-4o-mini"},
+		{name: "unknown size", context: nil, expected: "gemini-flash-lite"},
+	}
+
+	selector := NewCostAwareSelector()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				selection, err := selector.SelectWithContext(credentials, models, tt.context)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, selection.Model)
+			}
+		})
+	}
+}
+
+func TestCostAwareSelector_UnpricedFallback(t *testing.T) {
+	credentials := []config.Credential{
+		{Platform: "openai", Type: "api_key", Value: "test-openai-key"},
+		{Platform: "gemini", Type: "api_key", Value: "test-gemini-key"},
+	}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o", Config: &config.ModelConfig{ContextWindow: 4000}, Pricing: &config.ModelPricing{InputPerMillion: 2.5}},
+		{Vendor: "gemini", Model: "gemini-pro"},
+	}
+	selector := NewCostAwareSelector()
+
+	selection, err := selector.SelectWithContext(credentials, models, &types.PayloadContext{PromptTokens: 100})
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o", selection.Model, "priced models are preferred")
+
+	selection, err = selector.SelectWithContext(credentials, models, &types.PayloadContext{PromptTokens: 5000})
+	require.NoError(t, err)
+	assert.Equal(t, "gemini-pro", selection.Model, "unpriced models serve what no priced model can")
+
+	_, err = selector.SelectWithContext(credentials[:1], models[:1], &types.PayloadContext{PromptTokens: 5000})
+	assert.Error(t, err)
+}
+
+func TestCostAwareSelector_SharesTies(t *testing.T) {
+	credentials := []config.Credential{
+		{Platform: "openai", Type: "api_key", Value: "test-openai-key"},
+		{Platform: "gemini", Type: "api_key", Value: "test-gemini-key"},
+	}
+	pricing := &config.ModelPricing{InputPerMillion: 1, OutputPerMillion: 2}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o-mini", Pricing: pricing},
+		{Vendor: "gemini", Model: "gemini-flash", Pricing: pricing},
+	}
+	selector := NewCostAwareSelector()
+
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		selection, err := selector.Select(credentials, models)
+		require.NoError(t, err)
+		counts[selection.Model]++
+	}
+	assert.InDelta(t, 0.5, float64(counts["gpt-4o-mini"])/1000, 0.1)
+}
@@ -0,0 +1,137 @@
+package selector
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/types"
+)
+
+// Defaults for the latency-aware selector
+const (
+	// DefaultExplorationFloor is the smallest share of traffic each combination keeps, so
+	// that slow or failing backends are still probed and can win traffic back as they recover
+	DefaultExplorationFloor = 0.05
+	// DefaultMinLatencySamples is the decayed observation count below which a model's stats
+	// are too thin or too old to trust
+	DefaultMinLatencySamples = 3.0
+)
+
+// LatencyAwareSelector biases traffic toward currently fast and healthy backends: each
+// vendor-credential-model combination's configured weight is scaled by its model's score,
+// (1 - error rate)² divided by its average latency, from the rolling stats of a
+// LatencyTracker. Models without enough recent observations are scored like the best
+// known model so they get explored, and every combination keeps at least
+// ExplorationFloor of the traffic
+type LatencyAwareSelector struct {
+	rng     *rand.Rand
+	Tracker *monitoring.LatencyTracker
+	// ExplorationFloor is the minimum probability of each combination, capped at an even share
+	ExplorationFloor float64
+	// MinSamples is the decayed observation count a model needs to be scored on its stats
+	MinSamples float64
+}
+
+// NewLatencyAwareSelector creates a latency-aware selector fed from tracker
+func NewLatencyAwareSelector(tracker *monitoring.LatencyTracker) *LatencyAwareSelector {
+	return &LatencyAwareSelector{
+		// math/rand is used for model selection, which is not security-critical
+		// #nosec G404
+		rng:              rand.New(rand.NewSource(rand.Int63())),
+		Tracker:          tracker,
+		ExplorationFloor: DefaultExplorationFloor,
+		MinSamples:       DefaultMinLatencySamples,
+	}
+}
+
+// Select picks a combination, favouring fast and healthy models
+func (s *LatencyAwareSelector) Select(creds []config.Credential, models []config.VendorModel) (*VendorSelection, error) {
+	if len(creds) == 0 {
+		return nil, fmt.Errorf("no credentials available")
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no models available")
+	}
+
+	combinations, weights := weightedCombinations(creds, models)
+	if len(combinations) == 0 {
+		return nil, fmt.Errorf("no valid vendor-credential-model combinations available")
+	}
+
+	scores := make([]float64, len(combinations))
+	known := make([]bool, len(combinations))
+	best := 0.0
+	for i, combination := range combinations {
+		scores[i], known[i] = s.score(combination.Vendor, combination.Model)
+		if known[i] && scores[i] > best {
+			best = scores[i]
+		}
+	}
+	if best == 0 {
+		best = 1
+	}
+
+	total := 0.0
+	for i := range weights {
+		if !known[i] {
+			scores[i] = best
+		}
+		weights[i] *= scores[i]
+		total += weights[i]
+	}
+
+	// Mix the scored distribution with an even floor: each combination gets floor, and the
+	// rest of the probability is shared by score
+	floor := math.Min(math.Max(s.ExplorationFloor, 0), 1/float64(len(combinations)))
+	remainder := 1 - floor*float64(len(combinations))
+	for i := range weights {
+		share := 0.0
+		if total > 0 {
+			share = weights[i] / total
+		} else {
+			share = 1 / float64(len(weights))
+		}
+		weights[i] = floor + remainder*share
+	}
+
+	selection := pickWeighted(s.rng, combinations, weights)
+	selection.Reason = fmt.Sprintf("latency-weighted pick among %d combinations", len(combinations))
+	return selection, nil
+}
+
+// SelectWithContext picks a combination among the models that support the request's
+// capabilities, favouring fast and healthy ones
+func (s *LatencyAwareSelector) SelectWithContext(creds []config.Credential, models []config.VendorModel, context *types.PayloadContext) (*VendorSelection, error) {
+	if len(creds) == 0 {
+		return nil, fmt.Errorf("no credentials available")
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no models available")
+	}
+
+	filteredModels := FilterModelsByCapabilities(models, context)
+	if len(filteredModels) == 0 {
+		return nil, NoCapableModelError(context)
+	}
+	return s.Select(creds, filteredModels)
+}
+
+// score rates vendor/model from its rolling stats, reporting false when there are too few
+// recent observations to go by
+func (s *LatencyAwareSelector) score(vendor, model string) (float64, bool) {
+	if s.Tracker == nil {
+		return 0, false
+	}
+	stats, ok := s.Tracker.Stats(vendor, model)
+	if !ok || stats.Samples < s.MinSamples {
+		return 0, false
+	}
+	// Models whose every recent attempt failed have no latency average; their error rate
+	// already scores them at zero
+	latencySeconds := math.Max(stats.LatencyMs, 1) / 1000
+	healthy := 1 - stats.ErrorRate
+	return healthy * healthy / latencySeconds, true
+}
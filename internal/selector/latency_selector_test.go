@@ -0,0 +1,93 @@
+package selector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func latencyTestPool() ([]config.Credential, []config.VendorModel) {
+	credentials := []config.Credential{
+		{Platform: "openai", Type: "api_key", Value: "test-openai-key"},
+		{Platform: "gemini", Type: "api_key", Value: "test-gemini-key"},
+	}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o"},
+		{Vendor: "gemini", Model: "gemini-2.5-flash"},
+	}
+	return credentials, models
+}
+
+func countLatencySelections(t *testing.T, selector *LatencyAwareSelector, creds []config.Credential, models []config.VendorModel, runs int) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	for i := 0; i < runs; i++ {
+		selection, err := selector.Select(creds, models)
+		require.NoError(t, err)
+		counts[selection.Model]++
+	}
+	return counts
+}
+
+func TestLatencyAwareSelector_PrefersFastBackends(t *testing.T) {
+	tracker := monitoring.NewLatencyTracker(time.Hour)
+	for i := 0; i < 10; i++ {
+		tracker.Observe("openai", "gpt-4o", false, 4*time.Second)
+		tracker.Observe("gemini", "gemini-2.5-flash", false, time.Second)
+	}
+	creds, models := latencyTestPool()
+	selector := NewLatencyAwareSelector(tracker)
+
+	counts := countLatencySelections(t, selector, creds, models, 4000)
+	// Scores 1 and 0.25 split the 90% above the floors 80/20
+	assert.InDelta(t, 0.77, float64(counts["gemini-2.5-flash"])/4000, 0.04)
+}
+
+func TestLatencyAwareSelector_ExplorationFloor(t *testing.T) {
+	tracker := monitoring.NewLatencyTracker(time.Hour)
+	for i := 0; i < 10; i++ {
+		tracker.Observe("openai", "gpt-4o", true, 0)
+		tracker.Observe("gemini", "gemini-2.5-flash", false, time.Second)
+	}
+	creds, models := latencyTestPool()
+	selector := NewLatencyAwareSelector(tracker)
+	selector.ExplorationFloor = 0.1
+
+	counts := countLatencySelections(t, selector, creds, models, 4000)
+	assert.InDelta(t, 0.1, float64(counts["gpt-4o"])/4000, 0.03, "a failing backend keeps the floor share")
+}
+
+func TestLatencyAwareSelector_ExploresUnknownModels(t *testing.T) {
+	tracker := monitoring.NewLatencyTracker(time.Hour)
+	for i := 0; i < 10; i++ {
+		tracker.Observe("openai", "gpt-4o", false, time.Second)
+	}
+	tracker.Observe("gemini", "gemini-2.5-flash", false, time.Minute)
+	creds, models := latencyTestPool()
+	selector := NewLatencyAwareSelector(tracker)
+	selector.ExplorationFloor = 0
+
+	counts := countLatencySelections(t, selector, creds, models, 4000)
+	assert.InDelta(t, 0.5, float64(counts["gemini-2.5-flash"])/4000, 0.05, "too few observations score like the best known model")
+}
+
+func TestLatencyAwareSelector_SelectWithContext(t *testing.T) {
+	creds, models := latencyTestPool()
+	models[0].Config = &config.ModelConfig{SupportImage: true}
+	models[1].Config = &config.ModelConfig{}
+	selector := NewLatencyAwareSelector(monitoring.NewLatencyTracker(time.Hour))
+
+	for i := 0; i < 20; i++ {
+		selection, err := selector.SelectWithContext(creds, models, &types.PayloadContext{HasImages: true})
+		require.NoError(t, err)
+		assert.Equal(t, "gpt-4o", selection.Model)
+	}
+
+	_, err := selector.SelectWithContext(creds, models[1:], &types.PayloadContext{HasImages: true})
+	assert.Error(t, err)
+}
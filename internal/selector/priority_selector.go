@@ -0,0 +1,82 @@
+package selector
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/types"
+)
+
+// PrioritySelector routes along a fallback chain of model pools: models are grouped into
+// tiers by their configured priority, and each request goes to the first tier, in priority
+// order, that has a model with a credential and the capabilities it needs. Within the
+// tier the wrapped selector picks. Without priorities every model is in one tier and the
+// wrapped selector sees the whole pool
+type PrioritySelector struct {
+	next ContextSelector
+}
+
+// NewPrioritySelector wraps next with priority tiers
+func NewPrioritySelector(next ContextSelector) *PrioritySelector {
+	return &PrioritySelector{next: next}
+}
+
+// Select picks from the highest-priority tier with capacity
+func (s *PrioritySelector) Select(creds []config.Credential, models []config.VendorModel) (*VendorSelection, error) {
+	return s.SelectWithContext(creds, models, nil)
+}
+
+// SelectWithContext picks from the highest-priority tier able to serve the request
+func (s *PrioritySelector) SelectWithContext(creds []config.Credential, models []config.VendorModel, context *types.PayloadContext) (*VendorSelection, error) {
+	if len(creds) == 0 {
+		return nil, fmt.Errorf("no credentials available")
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no models available")
+	}
+
+	tiers := PriorityTiers(models)
+	if len(tiers) == 1 {
+		return s.next.SelectWithContext(creds, models, context)
+	}
+
+	var lastErr error
+	for i, tier := range tiers {
+		tierCreds := filter.CredentialsForModels(creds, tier)
+		if len(tierCreds) == 0 {
+			continue
+		}
+		selection, err := s.next.SelectWithContext(tierCreds, tier, context)
+		if err == nil {
+			selection.Reason = fmt.Sprintf("priority tier %d of %d, %s", i+1, len(tiers), selection.Reason)
+			return selection, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no valid vendor-credential-model combinations available")
+	}
+	return nil, lastErr
+}
+
+// PriorityTiers groups models by priority, highest priority (lowest number) first, keeping
+// the configured order within each tier
+func PriorityTiers(models []config.VendorModel) [][]config.VendorModel {
+	byPriority := make(map[int][]config.VendorModel)
+	var priorities []int
+	for _, model := range models {
+		if _, ok := byPriority[model.Priority]; !ok {
+			priorities = append(priorities, model.Priority)
+		}
+		byPriority[model.Priority] = append(byPriority[model.Priority], model)
+	}
+	sort.Ints(priorities)
+
+	tiers := make([][]config.VendorModel, 0, len(priorities))
+	for _, priority := range priorities {
+		tiers = append(tiers, byPriority[priority])
+	}
+	return tiers
+}
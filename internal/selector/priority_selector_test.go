@@ -0,0 +1,59 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityTiers(t *testing.T) {
+	models := []config.VendorModel{
+		{Vendor: "ollama", Model: "llama3", Priority: 9},
+		{Vendor: "openai", Model: "gpt-4o"},
+		{Vendor: "gemini", Model: "gemini-2.5-pro", Priority: 2},
+		{Vendor: "openai", Model: "gpt-4o-mini"},
+	}
+
+	tiers := PriorityTiers(models)
+	require.Len(t, tiers, 3)
+	assert.Equal(t, "gpt-4o", tiers[0][0].Model, "unset priority comes first")
+	assert.Equal(t, "gpt-4o-mini", tiers[0][1].Model)
+	assert.Equal(t, "gemini-2.5-pro", tiers[1][0].Model)
+	assert.Equal(t, "llama3", tiers[2][0].Model)
+
+	assert.Len(t, PriorityTiers(models[1:2]), 1)
+}
+
+func TestPrioritySelector_SelectWithContext(t *testing.T) {
+	credentials := []config.Credential{
+		{Platform: "openai", Type: "api_key", Value: "test-openai-key"},
+		{Platform: "gemini", Type: "api_key", Value: "test-gemini-key"},
+	}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o-mini", Priority: 1, Config: &config.ModelConfig{}},
+		{Vendor: "anthropic", Model: "claude-sonnet-4", Priority: 1},
+		{Vendor: "gemini", Model: "gemini-2.5-flash", Priority: 2, Config: &config.ModelConfig{SupportImage: true}},
+	}
+	selector := NewPrioritySelector(NewContextAwareSelector())
+
+	for i := 0; i < 20; i++ {
+		selection, err := selector.SelectWithContext(credentials, models, &types.PayloadContext{})
+		require.NoError(t, err)
+		assert.Equal(t, "gpt-4o-mini", selection.Model, "the primary tier serves while it can")
+	}
+
+	selection, err := selector.SelectWithContext(credentials, models, &types.PayloadContext{HasImages: true})
+	require.NoError(t, err)
+	assert.Equal(t, "gemini-2.5-flash", selection.Model, "requests the primary tier cannot serve go down the chain")
+	assert.Equal(t, "priority tier 2 of 2, weighted pick among 1 combinations", selection.Reason)
+
+	selection, err = selector.Select(credentials[1:], models)
+	require.NoError(t, err)
+	assert.Equal(t, "gemini-2.5-flash", selection.Model, "tiers without credentials are skipped")
+
+	_, err = selector.SelectWithContext(credentials[:1], models[:1], &types.PayloadContext{HasImages: true})
+	assert.Error(t, err)
+}
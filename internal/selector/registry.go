@@ -0,0 +1,100 @@
+package selector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/monitoring"
+	"github.com/aashari/go-generative-api-router/internal/types"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// StrategyFactory builds the selector of a strategy, reading any settings it has from the
+// environment
+type StrategyFactory func() (ContextSelector, error)
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = make(map[string]StrategyFactory)
+)
+
+func init() {
+	RegisterStrategy(StrategyRandom, func() (ContextSelector, error) {
+		return WithCapabilities(NewRandomSelector()), nil
+	})
+	RegisterStrategy(StrategyEven, func() (ContextSelector, error) {
+		return WithCapabilities(NewEvenDistributionSelector()), nil
+	})
+	RegisterStrategy(StrategyWeighted, func() (ContextSelector, error) {
+		return NewContextAwareSelector(), nil
+	})
+	RegisterStrategy(StrategyCost, func() (ContextSelector, error) {
+		return NewCostAwareSelector(), nil
+	})
+	RegisterStrategy(StrategyLatency, func() (ContextSelector, error) {
+		latencySelector := NewLatencyAwareSelector(monitoring.DefaultLatencyTracker())
+		latencySelector.ExplorationFloor = utils.GetEnvFloat64("LATENCY_EXPLORATION_FLOOR", DefaultExplorationFloor)
+		return latencySelector, nil
+	})
+	RegisterStrategy(StrategyComposite, newCompositeFromEnv)
+}
+
+// RegisterStrategy makes a strategy available to SELECTOR_STRATEGY under name, replacing
+// any strategy already registered with it
+func RegisterStrategy(name string, factory StrategyFactory) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	strategies[name] = factory
+}
+
+// Strategies returns the names of the registered strategies, sorted
+func Strategies() []string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewStrategy builds the selector of the strategy registered under name
+func NewStrategy(name string) (ContextSelector, error) {
+	strategiesMu.RLock()
+	factory, ok := strategies[name]
+	strategiesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown selector strategy %q, expected one of %s", name, strings.Join(Strategies(), ", "))
+	}
+	return factory()
+}
+
+// capabilitySelector adapts a selector that ignores the payload context, so that it only
+// picks among the models supporting the request's capabilities
+type capabilitySelector struct {
+	Selector
+}
+
+// WithCapabilities adapts next to pick only among the models supporting each request's
+// capabilities
+func WithCapabilities(next Selector) ContextSelector {
+	return &capabilitySelector{Selector: next}
+}
+
+// SelectWithContext picks with the wrapped selector among the capable models
+func (s *capabilitySelector) SelectWithContext(creds []config.Credential, models []config.VendorModel, context *types.PayloadContext) (*VendorSelection, error) {
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no models available")
+	}
+	filteredModels := FilterModelsByCapabilities(models, context)
+	if len(filteredModels) == 0 {
+		return nil, NoCapableModelError(context)
+	}
+	return s.Select(filter.CredentialsForModels(creds, filteredModels), filteredModels)
+}
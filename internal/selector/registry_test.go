@@ -0,0 +1,77 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStrategy(t *testing.T) {
+	for _, name := range []string{StrategyRandom, StrategyEven, StrategyWeighted, StrategyCost, StrategyLatency, StrategyComposite} {
+		t.Run(name, func(t *testing.T) {
+			strategy, err := NewStrategy(name)
+			require.NoError(t, err)
+			assert.NotNil(t, strategy)
+		})
+	}
+
+	_, err := NewStrategy("fastest")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected one of composite, cost, even, latency, random, weighted")
+}
+
+func TestRegisterStrategy(t *testing.T) {
+	RegisterStrategy("first", func() (ContextSelector, error) {
+		return WithCapabilities(&firstSelector{}), nil
+	})
+	t.Cleanup(func() {
+		strategiesMu.Lock()
+		defer strategiesMu.Unlock()
+		delete(strategies, "first")
+	})
+	assert.Contains(t, Strategies(), "first")
+
+	t.Setenv("SELECTOR_STRATEGY", "first")
+	t.Setenv("AFFINITY_TTL", "0")
+	selector, err := NewFromEnv()
+	require.NoError(t, err)
+
+	creds := []config.Credential{{Platform: "openai"}, {Platform: "gemini"}}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-flash"}}
+	selection, err := selector.SelectWithContext(creds, models, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o", selection.Model)
+}
+
+func TestWithCapabilities(t *testing.T) {
+	creds := []config.Credential{{Platform: "openai"}, {Platform: "gemini"}}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-3.5-turbo", Config: &config.ModelConfig{SupportTools: true}},
+		{Vendor: "gemini", Model: "gemini-flash", Config: &config.ModelConfig{SupportImage: true}},
+	}
+	selector := WithCapabilities(NewRandomSelector())
+
+	for i := 0; i < 20; i++ {
+		selection, err := selector.SelectWithContext(creds, models, &types.PayloadContext{HasImages: true})
+		require.NoError(t, err)
+		assert.Equal(t, "gemini-flash", selection.Model)
+	}
+
+	_, err := selector.SelectWithContext(creds, models, &types.PayloadContext{HasAudio: true})
+	assert.ErrorIs(t, err, ErrNoCapableModel)
+}
+
+// firstSelector always picks the first model with its vendor's first credential
+type firstSelector struct{}
+
+func (s *firstSelector) Select(creds []config.Credential, models []config.VendorModel) (*VendorSelection, error) {
+	for _, cred := range creds {
+		if cred.Platform == models[0].Vendor {
+			return &VendorSelection{Vendor: cred.Platform, Model: models[0].Model, Credential: cred}, nil
+		}
+	}
+	return nil, assert.AnError
+}
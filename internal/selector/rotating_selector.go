@@ -0,0 +1,155 @@
+package selector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/shared"
+	"github.com/aashari/go-generative-api-router/internal/types"
+)
+
+// Credential rotation modes accepted by CREDENTIAL_ROTATION
+const (
+	RotationRandom     = "random"
+	RotationRoundRobin = "round_robin"
+)
+
+// rotationTTL is how long a shared rotation counter outlives its vendor's last request
+const rotationTTL = 24 * time.Hour
+
+// RotatingSelector rotates through a vendor's credentials in weighted round-robin order:
+// the wrapped selector picks the vendor and model, and the credential is the next one of
+// that vendor in the rotation. Over any stretch of requests each credential gets its
+// weight's share, spread evenly rather than at random; with equal weights the rotation is
+// strict round-robin. With a Shared store, replicas take turns from one counter per vendor
+type RotatingSelector struct {
+	next ContextSelector
+	// Shared, when set, holds the rotation counters; while it is unavailable each replica
+	// rotates on its own
+	Shared shared.Store
+
+	mu sync.Mutex
+	// current holds each credential's smooth weighted round-robin counter, keyed by a
+	// digest of the credential
+	current map[string]float64
+}
+
+// NewRotatingSelector wraps next with credential rotation
+func NewRotatingSelector(next ContextSelector) *RotatingSelector {
+	return &RotatingSelector{next: next, current: make(map[string]float64)}
+}
+
+// Select picks with the wrapped selector and rotates the credential
+func (s *RotatingSelector) Select(creds []config.Credential, models []config.VendorModel) (*VendorSelection, error) {
+	selection, err := s.next.Select(creds, models)
+	if err != nil {
+		return nil, err
+	}
+	return s.rotate(creds, selection), nil
+}
+
+// SelectWithContext picks with the wrapped selector and rotates the credential
+func (s *RotatingSelector) SelectWithContext(creds []config.Credential, models []config.VendorModel, context *types.PayloadContext) (*VendorSelection, error) {
+	selection, err := s.next.SelectWithContext(creds, models, context)
+	if err != nil {
+		return nil, err
+	}
+	return s.rotate(creds, selection), nil
+}
+
+// rotate replaces the selection's credential with the next of its vendor
+func (s *RotatingSelector) rotate(creds []config.Credential, selection *VendorSelection) *VendorSelection {
+	candidates := filter.CredentialsByVendor(creds, selection.Vendor)
+	if len(candidates) < 2 {
+		return selection
+	}
+
+	best := -1
+	if s.Shared != nil {
+		if turn, err := s.Shared.Incr(context.Background(), "rotation:"+selection.Vendor, rotationTTL); err == nil {
+			best = weightedTurn(candidates, turn)
+		}
+	}
+	if best < 0 {
+		best = s.localTurn(candidates)
+	}
+
+	rotated := *selection
+	rotated.Credential = candidates[best]
+	rotated.Reason += ", credential round-robin"
+	return &rotated
+}
+
+// localTurn picks the next candidate using smooth weighted round-robin: every candidate's
+// counter grows by its weight, the largest wins and is set back by the candidates' total
+// weight
+func (s *RotatingSelector) localTurn(candidates []config.Credential) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := 0.0
+	best, bestKey := -1, ""
+	for i, cred := range candidates {
+		weight := effectiveWeight(cred.Weight)
+		total += weight
+		key := rotationKey(cred)
+		s.current[key] += weight
+		if best < 0 || s.current[key] > s.current[bestKey] {
+			best, bestKey = i, key
+		}
+	}
+	s.current[bestKey] -= total
+	return best
+}
+
+// weightedTurn returns the candidate whose turn the nth pick of a shared rotation is. The
+// smooth weighted round-robin sequence of the candidates' weights, rounded to hundredths,
+// is replayed up to n modulo its period, so replicas drawing n from one counter follow a
+// single sequence
+func weightedTurn(candidates []config.Credential, n int64) int {
+	weights := make([]int64, len(candidates))
+	var divisor int64
+	for i, cred := range candidates {
+		weights[i] = int64(math.Max(1, math.Round(effectiveWeight(cred.Weight)*100)))
+		divisor = gcd(divisor, weights[i])
+	}
+	var total int64
+	for i := range weights {
+		weights[i] /= divisor
+		total += weights[i]
+	}
+
+	current := make([]int64, len(weights))
+	best := 0
+	for step := ((n-1)%total + total) % total; step >= 0; step-- {
+		best = 0
+		for i, weight := range weights {
+			current[i] += weight
+			if current[i] > current[best] {
+				best = i
+			}
+		}
+		current[best] -= total
+	}
+	return best
+}
+
+// gcd returns the greatest common divisor of a and b
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// rotationKey identifies a credential in the rotation without holding its secret
+func rotationKey(cred config.Credential) string {
+	sum := sha256.Sum256([]byte(cred.Platform + "\x00" + cred.Value))
+	return hex.EncodeToString(sum[:])
+}
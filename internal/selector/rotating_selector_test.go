@@ -0,0 +1,72 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingSelector_RoundRobin(t *testing.T) {
+	creds := []config.Credential{
+		{Platform: "openai", Value: "sk-1"},
+		{Platform: "openai", Value: "sk-2"},
+		{Platform: "openai", Value: "sk-3"},
+	}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}}
+	selector := NewRotatingSelector(NewContextAwareSelector())
+
+	var order []string
+	for i := 0; i < 6; i++ {
+		selection, err := selector.SelectWithContext(creds, models, nil)
+		require.NoError(t, err)
+		order = append(order, selection.Credential.Value)
+	}
+	assert.Equal(t, []string{"sk-1", "sk-2", "sk-3", "sk-1", "sk-2", "sk-3"}, order)
+}
+
+func TestRotatingSelector_Weighted(t *testing.T) {
+	creds := []config.Credential{
+		{Platform: "openai", Value: "sk-large", Weight: 3},
+		{Platform: "openai", Value: "sk-small"},
+		{Platform: "gemini", Value: "gm-1"},
+	}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}}
+	selector := NewRotatingSelector(NewContextAwareSelector())
+
+	var order []string
+	for i := 0; i < 8; i++ {
+		selection, err := selector.Select(creds, models)
+		require.NoError(t, err)
+		assert.Equal(t, "openai", selection.Credential.Platform, "only the selected vendor's credentials rotate")
+		order = append(order, selection.Credential.Value)
+	}
+	// Smooth weighted round-robin interleaves the small credential instead of bunching the large one
+	assert.Equal(t, []string{"sk-large", "sk-large", "sk-small", "sk-large", "sk-large", "sk-large", "sk-small", "sk-large"}, order)
+}
+
+func TestRotatingSelector_Shared(t *testing.T) {
+	creds := []config.Credential{
+		{Platform: "openai", Value: "sk-large", Weight: 3},
+		{Platform: "openai", Value: "sk-small"},
+	}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}}
+	store := shared.NewMemory()
+
+	replicas := make([]*RotatingSelector, 2)
+	for i := range replicas {
+		replicas[i] = NewRotatingSelector(NewContextAwareSelector())
+		replicas[i].Shared = store
+	}
+
+	var order []string
+	for i := 0; i < 8; i++ {
+		selection, err := replicas[i%len(replicas)].Select(creds, models)
+		require.NoError(t, err)
+		order = append(order, selection.Credential.Value)
+	}
+	// Replicas taking turns follow the same sequence as a single replica rotating alone
+	assert.Equal(t, []string{"sk-large", "sk-large", "sk-small", "sk-large", "sk-large", "sk-large", "sk-small", "sk-large"}, order)
+}
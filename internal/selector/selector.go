@@ -3,8 +3,12 @@ package selector
 import (
 	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/shared"
+	"github.com/aashari/go-generative-api-router/internal/types"
+	"github.com/aashari/go-generative-api-router/internal/utils"
 )
 
 // VendorSelection stores the selected vendor, model and credential
@@ -12,6 +16,12 @@ type VendorSelection struct {
 	Vendor     string
 	Model      string
 	Credential config.Credential
+	// BaseURL and AuthHeader carry the model's own endpoint overrides, if it has any
+	BaseURL    string
+	AuthHeader string
+	// Reason says why this vendor, model and credential were picked, for the routing
+	// decision log, e.g. "weighted pick among 4 combinations"
+	Reason string
 }
 
 // VendorModelCombination represents a specific combination of credential and model
@@ -19,6 +29,8 @@ type VendorModelCombination struct {
 	Vendor     string
 	Model      string
 	Credential config.Credential
+	BaseURL    string
+	AuthHeader string
 }
 
 // RandomSelector is a selector that randomly chooses vendors and models
@@ -67,6 +79,9 @@ func (s *RandomSelector) Select(creds []config.Credential, models []config.Vendo
 		Vendor:     vendor,
 		Model:      model,
 		Credential: selectedCred,
+		BaseURL:    selectedModel.BaseURL,
+		AuthHeader: selectedModel.AuthHeader,
+		Reason:     fmt.Sprintf("random pick among %d credentials and %d %s models", len(creds), len(vendorModels), vendor),
 	}, nil
 }
 
@@ -104,6 +119,8 @@ func (s *EvenDistributionSelector) Select(creds []config.Credential, models []co
 					Vendor:     cred.Platform,
 					Model:      model.Model,
 					Credential: cred,
+					BaseURL:    model.BaseURL,
+					AuthHeader: model.AuthHeader,
 				})
 			}
 		}
@@ -120,6 +137,9 @@ func (s *EvenDistributionSelector) Select(creds []config.Credential, models []co
 		Vendor:     selectedCombination.Vendor,
 		Model:      selectedCombination.Model,
 		Credential: selectedCombination.Credential,
+		BaseURL:    selectedCombination.BaseURL,
+		AuthHeader: selectedCombination.AuthHeader,
+		Reason:     fmt.Sprintf("even pick among %d combinations", len(combinations)),
 	}, nil
 }
 
@@ -127,3 +147,55 @@ func (s *EvenDistributionSelector) Select(creds []config.Credential, models []co
 type Selector interface {
 	Select(creds []config.Credential, models []config.VendorModel) (*VendorSelection, error)
 }
+
+// ContextSelector is implemented by selectors that take the request's payload context into
+// account, such as its capabilities and estimated size
+type ContextSelector interface {
+	Selector
+	SelectWithContext(creds []config.Credential, models []config.VendorModel, context *types.PayloadContext) (*VendorSelection, error)
+}
+
+// Selection strategies
+const (
+	StrategyRandom    = "random"
+	StrategyEven      = "even"
+	StrategyWeighted  = "weighted"
+	StrategyCost      = "cost"
+	StrategyLatency   = "latency"
+	StrategyComposite = "composite"
+)
+
+// NewFromEnv creates the selector for the strategy named by SELECTOR_STRATEGY: "weighted"
+// (the default) spreads traffic by configured weights, "random" picks a credential and then
+// one of its vendor's models at random, "even" spreads traffic evenly across combinations,
+// "cost" prefers the cheapest capable model, "latency" favours currently fast and healthy
+// models, exploring the others with at least LATENCY_EXPLORATION_FLOOR of the traffic each,
+// and "composite" chains the SELECTOR_FILTERS in front of SELECTOR_COMPOSITE_PICK. Strategies
+// added with RegisterStrategy can be named too. Conversations are kept on one vendor and
+// model for AFFINITY_TTL seconds after their last request; 0 turns affinity off. Models with
+// a priority are tried tier by tier, whatever the strategy. Affinity pins and rotation turns
+// are kept in the shared store, when one is set, so replicas agree on them
+func NewFromEnv() (ContextSelector, error) {
+	strategySelector, err := NewStrategy(utils.GetEnvString("SELECTOR_STRATEGY", StrategyWeighted))
+	if err != nil {
+		return nil, fmt.Errorf("SELECTOR_STRATEGY: %w", err)
+	}
+
+	switch rotation := utils.GetEnvString("CREDENTIAL_ROTATION", RotationRandom); rotation {
+	case RotationRandom:
+	case RotationRoundRobin:
+		rotatingSelector := NewRotatingSelector(strategySelector)
+		rotatingSelector.Shared = shared.Default()
+		strategySelector = rotatingSelector
+	default:
+		return nil, fmt.Errorf("unknown CREDENTIAL_ROTATION %q, expected %q or %q", rotation, RotationRandom, RotationRoundRobin)
+	}
+
+	ttl := time.Duration(utils.GetEnvInt("AFFINITY_TTL", int(DefaultAffinityTTL/time.Second))) * time.Second
+	if ttl > 0 {
+		affinitySelector := NewAffinitySelector(strategySelector, ttl)
+		affinitySelector.Shared = shared.Default()
+		strategySelector = affinitySelector
+	}
+	return NewPrioritySelector(strategySelector), nil
+}
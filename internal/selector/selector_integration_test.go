@@ -282,8 +282,8 @@ func TestContextAwareSelector_ContextFiltering(t *testing.T) {
 			}
 
 			// Statistical verification for distribution among available models
-			// Note: ContextAwareSelector uses EvenDistributionSelector under the hood,
-			// which distributes based on credential-model combinations, not just models.
+			// Note: ContextAwareSelector uses WeightedSelector under the hood, which without weights
+			// distributes based on credential-model combinations, not just models.
 			// So we expect uneven distribution across models based on credential counts.
 
 			// Verify all selected models are valid (this is the primary verification)
@@ -351,6 +351,7 @@ func TestSelector_EdgeCases(t *testing.T) {
 	selectors := map[string]Selector{
 		"RandomSelector":           NewRandomSelector(),
 		"EvenDistributionSelector": NewEvenDistributionSelector(),
+		"WeightedSelector":         NewWeightedSelector(),
 	}
 
 	for selectorName, selector := range selectors {
@@ -376,6 +377,16 @@ func TestSelector_EdgeCases(t *testing.T) {
 	}
 }
 
+func TestContextAwareSelector_NoCapableModel(t *testing.T) {
+	credentials, models := setupTestData()
+	selector := NewContextAwareSelector()
+
+	selection, err := selector.SelectWithContext(credentials, models, &types.PayloadContext{HasAudio: true, HasTools: true})
+	assert.Nil(t, selection)
+	require.ErrorIs(t, err, ErrNoCapableModel)
+	assert.Contains(t, err.Error(), "request needs audio, tools")
+}
+
 // Test ContextAwareSelector edge cases
 func TestContextAwareSelector_EdgeCases(t *testing.T) {
 	credentials, models := setupTestData()
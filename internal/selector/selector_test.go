@@ -0,0 +1,37 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	selector, err := NewFromEnv()
+	require.NoError(t, err)
+	require.IsType(t, &PrioritySelector{}, selector)
+	assert.IsType(t, &AffinitySelector{}, selector.(*PrioritySelector).next)
+
+	t.Setenv("AFFINITY_TTL", "0")
+	t.Setenv("SELECTOR_STRATEGY", StrategyCost)
+	selector, err = NewFromEnv()
+	require.NoError(t, err)
+	require.IsType(t, &PrioritySelector{}, selector)
+	assert.IsType(t, &CostAwareSelector{}, selector.(*PrioritySelector).next)
+
+	t.Setenv("CREDENTIAL_ROTATION", RotationRoundRobin)
+	selector, err = NewFromEnv()
+	require.NoError(t, err)
+	require.IsType(t, &RotatingSelector{}, selector.(*PrioritySelector).next)
+	assert.IsType(t, &CostAwareSelector{}, selector.(*PrioritySelector).next.(*RotatingSelector).next)
+
+	t.Setenv("CREDENTIAL_ROTATION", "sticky")
+	_, err = NewFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("CREDENTIAL_ROTATION", RotationRandom)
+	t.Setenv("SELECTOR_STRATEGY", "fastest")
+	_, err = NewFromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+package selector
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+)
+
+// WeightedSelector chooses among vendor-credential-model combinations in proportion to
+// their weight, the product of the model's and the credential's configured weights
+// Unset weights count as 1, so without weights it distributes like EvenDistributionSelector
+type WeightedSelector struct {
+	rng *rand.Rand
+}
+
+// NewWeightedSelector creates a new weighted selector
+func NewWeightedSelector() *WeightedSelector {
+	// math/rand is used for model selection, which is not security-critical.
+	// Using crypto/rand would incur unnecessary performance overhead.
+	return &WeightedSelector{
+		// #nosec G404
+		rng: rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+// Select picks a vendor-credential-model combination with probability proportional to its weight
+func (s *WeightedSelector) Select(creds []config.Credential, models []config.VendorModel) (*VendorSelection, error) {
+	if len(creds) == 0 {
+		return nil, fmt.Errorf("no credentials available")
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no models available")
+	}
+
+	combinations, weights := weightedCombinations(creds, models)
+	if len(combinations) == 0 {
+		return nil, fmt.Errorf("no valid vendor-credential-model combinations available")
+	}
+	return pickWeighted(s.rng, combinations, weights), nil
+}
+
+// weightedCombinations lists the vendor-credential-model combinations of a pool with their
+// configured weights
+func weightedCombinations(creds []config.Credential, models []config.VendorModel) ([]VendorModelCombination, []float64) {
+	var combinations []VendorModelCombination
+	var weights []float64
+	for _, cred := range creds {
+		for _, model := range models {
+			if cred.Platform != model.Vendor {
+				continue
+			}
+			combinations = append(combinations, VendorModelCombination{
+				Vendor:     cred.Platform,
+				Model:      model.Model,
+				Credential: cred,
+				BaseURL:    model.BaseURL,
+				AuthHeader: model.AuthHeader,
+			})
+			weights = append(weights, effectiveWeight(model.Weight)*effectiveWeight(cred.Weight))
+		}
+	}
+	return combinations, weights
+}
+
+// pickWeighted draws one of a non-empty list of combinations with probability proportional
+// to its weight
+func pickWeighted(rng *rand.Rand, combinations []VendorModelCombination, weights []float64) *VendorSelection {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+
+	// Walk the cumulative weights to the randomly drawn point; the last combination takes
+	// any floating-point remainder
+	selected := combinations[len(combinations)-1]
+	point := rng.Float64() * total
+	for i, weight := range weights {
+		if point < weight {
+			selected = combinations[i]
+			break
+		}
+		point -= weight
+	}
+
+	return &VendorSelection{
+		Vendor:     selected.Vendor,
+		Model:      selected.Model,
+		Credential: selected.Credential,
+		BaseURL:    selected.BaseURL,
+		AuthHeader: selected.AuthHeader,
+		Reason:     fmt.Sprintf("weighted pick among %d combinations", len(combinations)),
+	}
+}
+
+// effectiveWeight returns a configured weight, counting unset (zero) weights as 1
+func effectiveWeight(weight float64) float64 {
+	if weight <= 0 {
+		return 1
+	}
+	return weight
+}
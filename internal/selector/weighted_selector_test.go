@@ -0,0 +1,83 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeightedSelector_ModelWeights(t *testing.T) {
+	credentials := []config.Credential{
+		{Platform: "openai", Type: "api_key", Value: "test-openai-key"},
+		{Platform: "gemini", Type: "api_key", Value: "test-gemini-key"},
+	}
+	models := []config.VendorModel{
+		{Vendor: "gemini", Model: "gemini-flash", Weight: 80},
+		{Vendor: "openai", Model: "gpt-4o", Weight: 20},
+	}
+	selector := NewWeightedSelector()
+
+	const iterations = 10000
+	const tolerance = 0.03
+
+	counts := make(map[string]int)
+	for i := 0; i < iterations; i++ {
+		selection, err := selector.Select(credentials, models)
+		require.NoError(t, err)
+		counts[selection.Model]++
+	}
+
+	assert.InDelta(t, 0.8, float64(counts["gemini-flash"])/iterations, tolerance)
+	assert.InDelta(t, 0.2, float64(counts["gpt-4o"])/iterations, tolerance)
+}
+
+func TestWeightedSelector_CredentialWeights(t *testing.T) {
+	credentials := []config.Credential{
+		{Platform: "openai", Type: "api_key", Value: "test-openai-key-1", Weight: 3},
+		{Platform: "openai", Type: "api_key", Value: "test-openai-key-2"},
+	}
+	models := []config.VendorModel{
+		{Vendor: "openai", Model: "gpt-4o"},
+		{Vendor: "openai", Model: "gpt-4o-mini", Weight: 0.5},
+	}
+	selector := NewWeightedSelector()
+
+	const iterations = 10000
+	const tolerance = 0.03
+
+	credentialCounts := make(map[string]int)
+	modelCounts := make(map[string]int)
+	for i := 0; i < iterations; i++ {
+		selection, err := selector.Select(credentials, models)
+		require.NoError(t, err)
+		credentialCounts[selection.Credential.Value]++
+		modelCounts[selection.Model]++
+	}
+
+	// Unset weights count as 1: key 1 takes 3/4 of traffic, gpt-4o-mini 1/3
+	assert.InDelta(t, 0.75, float64(credentialCounts["test-openai-key-1"])/iterations, tolerance)
+	assert.InDelta(t, 1.0/3.0, float64(modelCounts["gpt-4o-mini"])/iterations, tolerance)
+}
+
+func TestWeightedSelector_Unweighted(t *testing.T) {
+	credentials, models := setupTestData()
+	selector := NewWeightedSelector()
+
+	const iterations = 10000
+	const tolerance = 0.03
+
+	counts := make(map[string]int)
+	for i := 0; i < iterations; i++ {
+		selection, err := selector.Select(credentials, models)
+		require.NoError(t, err)
+		counts[selection.Model+"|"+selection.Credential.Value]++
+	}
+
+	// Without weights every one of the 10 combinations is equally likely
+	require.Len(t, counts, 10)
+	for combination, count := range counts {
+		assert.InDelta(t, 0.1, float64(count)/iterations, tolerance, combination)
+	}
+}
@@ -0,0 +1,349 @@
+package shared
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxIdleConns is how many idle connections a Redis store keeps for reuse
+const maxIdleConns = 16
+
+// RedisConfig configures the Redis store
+type RedisConfig struct {
+	// Addr is the server's host:port
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	TLS      bool
+	// Prefix is prepended to every key, so several deployments can share a server
+	Prefix string
+	// Timeout bounds dialing and each command
+	Timeout time.Duration
+}
+
+// ParseRedisURL parses a redis://[[username]:password@]host[:port][/db] URL, or rediss://
+// for TLS
+func ParseRedisURL(rawURL string) (RedisConfig, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return RedisConfig{}, err
+	}
+
+	var config RedisConfig
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		config.TLS = true
+	default:
+		return RedisConfig{}, fmt.Errorf("unsupported scheme %q, expected redis or rediss", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return RedisConfig{}, fmt.Errorf("missing host")
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "6379"
+	}
+	config.Addr = net.JoinHostPort(u.Hostname(), port)
+	if u.User != nil {
+		config.Username = u.User.Username()
+		config.Password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if config.DB, err = strconv.Atoi(db); err != nil || config.DB < 0 {
+			return RedisConfig{}, fmt.Errorf("invalid database %q", db)
+		}
+	}
+	return config, nil
+}
+
+// Redis is a Store kept in a Redis server (6.2 or later), spoken to over RESP with a small
+// pool of connections. Expiring sets are sorted sets scored by expiry in Unix milliseconds
+type Redis struct {
+	config RedisConfig
+	idle   chan *redisConn
+}
+
+// redisConn is a connection with its buffered reader
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// redisError is an error reply from the server
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// NewRedis creates a Redis store; connections are made as commands need them. A
+// non-positive Timeout uses DefaultTimeout
+func NewRedis(config RedisConfig) *Redis {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	return &Redis{config: config, idle: make(chan *redisConn, maxIdleConns)}
+}
+
+// Key returns key with the configured prefix, as the Store methods send it
+func (r *Redis) Key(key string) string {
+	return r.config.Prefix + key
+}
+
+// Ping checks the server answers
+func (r *Redis) Ping(ctx context.Context) error {
+	_, err := r.Do(ctx, []string{"PING"})
+	return err
+}
+
+// Get returns the value of key, and false when it is unset or expired
+func (r *Redis) Get(ctx context.Context, key string) (string, bool, error) {
+	replies, err := r.Do(ctx, []string{"GET", r.config.Prefix + key})
+	if err != nil {
+		return "", false, err
+	}
+	value, ok := replies[0].(string)
+	return value, ok, nil
+}
+
+// Set sets key to value until ttl passes
+func (r *Redis) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	_, err := r.Do(ctx, []string{"SET", r.config.Prefix + key, value, "PX", milliseconds(ttl)})
+	return err
+}
+
+// Incr increments the counter at key, which expires ttl after its last increment, and
+// returns its new value
+func (r *Redis) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	key = r.config.Prefix + key
+	replies, err := r.Do(ctx, []string{"INCR", key}, []string{"PEXPIRE", key, milliseconds(ttl)})
+	if err != nil {
+		return 0, err
+	}
+	count, ok := replies[0].(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected INCR reply %v", replies[0])
+	}
+	return count, nil
+}
+
+// ExtendUntil adds member to the expiring set at key, keeping the later time
+func (r *Redis) ExtendUntil(ctx context.Context, key, member string, until time.Time) error {
+	_, err := r.Do(ctx, []string{"ZADD", r.config.Prefix + key, "GT", strconv.FormatInt(until.UnixMilli(), 10), member})
+	return err
+}
+
+// Unexpired returns the members of the expiring set at key that have not expired yet,
+// dropping the others from it
+func (r *Redis) Unexpired(ctx context.Context, key string) (map[string]time.Time, error) {
+	key = r.config.Prefix + key
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	replies, err := r.Do(ctx,
+		[]string{"ZREMRANGEBYSCORE", key, "-inf", now},
+		[]string{"ZRANGEBYSCORE", key, "(" + now, "+inf", "WITHSCORES"},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	items, _ := replies[1].([]any)
+	members := make(map[string]time.Time, len(items)/2)
+	for i := 0; i+1 < len(items); i += 2 {
+		member, _ := items[i].(string)
+		score, _ := items[i+1].(string)
+		millis, err := strconv.ParseFloat(score, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redis: unexpected score %q", score)
+		}
+		members[member] = time.UnixMilli(int64(millis))
+	}
+	return members, nil
+}
+
+// Do sends commands in one round trip and returns their replies. An error reply to any
+// of them is returned as the error. Keys are sent as given: callers needing commands the
+// Store methods do not cover add the prefix themselves with Key
+func (r *Redis) Do(ctx context.Context, commands ...[]string) ([]any, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	replies, err := r.roundTrip(ctx, conn, commands)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	r.release(conn)
+
+	for _, reply := range replies {
+		if replyErr, ok := reply.(redisError); ok {
+			return nil, replyErr
+		}
+	}
+	return replies, nil
+}
+
+// roundTrip writes commands to conn and reads as many replies, within Timeout
+func (r *Redis) roundTrip(ctx context.Context, conn *redisConn, commands [][]string) ([]any, error) {
+	deadline := time.Now().Add(r.config.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var request strings.Builder
+	for _, command := range commands {
+		fmt.Fprintf(&request, "*%d\r\n", len(command))
+		for _, arg := range command {
+			fmt.Fprintf(&request, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+	if _, err := io.WriteString(conn, request.String()); err != nil {
+		return nil, err
+	}
+
+	replies := make([]any, len(commands))
+	for i := range commands {
+		reply, err := readReply(conn.reader)
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = reply
+	}
+	return replies, nil
+}
+
+// conn returns an idle connection, or dials a new one, authenticating and selecting the
+// database as configured
+func (r *Redis) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-r.idle:
+		return conn, nil
+	default:
+	}
+
+	var (
+		netConn net.Conn
+		err     error
+	)
+	dialer := &net.Dialer{Timeout: r.config.Timeout}
+	if r.config.TLS {
+		netConn, err = (&tls.Dialer{NetDialer: dialer}).DialContext(ctx, "tcp", r.config.Addr)
+	} else {
+		netConn, err = dialer.DialContext(ctx, "tcp", r.config.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	conn := &redisConn{Conn: netConn, reader: bufio.NewReader(netConn)}
+
+	var setup [][]string
+	if r.config.Password != "" {
+		if r.config.Username != "" {
+			setup = append(setup, []string{"AUTH", r.config.Username, r.config.Password})
+		} else {
+			setup = append(setup, []string{"AUTH", r.config.Password})
+		}
+	}
+	if r.config.DB != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(r.config.DB)})
+	}
+	if len(setup) > 0 {
+		replies, err := r.roundTrip(ctx, conn, setup)
+		if err == nil {
+			for _, reply := range replies {
+				if replyErr, ok := reply.(redisError); ok {
+					err = replyErr
+				}
+			}
+		}
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// release returns conn to the idle pool, closing it when the pool is full
+func (r *Redis) release(conn *redisConn) {
+	select {
+	case r.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+// readReply reads one RESP reply: a string, an int64, nil, a redisError or a []any
+func readReply(reader *bufio.Reader) (any, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("redis: malformed reply")
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return redisError(body), nil
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]any, count)
+		for i := range items {
+			if items[i], err = readReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply type %q", kind)
+	}
+}
+
+// milliseconds formats d as a positive count of milliseconds
+func milliseconds(d time.Duration) string {
+	if d < time.Millisecond {
+		d = time.Millisecond
+	}
+	return strconv.FormatInt(d.Milliseconds(), 10)
+}
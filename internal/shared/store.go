@@ -0,0 +1,180 @@
+// Package shared keeps routing state that every router replica must agree on, conversation
+// affinity, credential rotation turns and rate-limit cooldowns, in a store the replicas
+// share. Without one, each replica routes on its own in-memory state
+package shared
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Default shared state settings, overridable with REDIS_TIMEOUT_MS, REDIS_KEY_PREFIX and
+// SHARED_STATE_SYNC_INTERVAL
+const (
+	DefaultTimeout      = 200 * time.Millisecond
+	DefaultKeyPrefix    = "router:"
+	DefaultSyncInterval = time.Second
+)
+
+// Store holds state shared by router replicas. Every method is safe for concurrent use;
+// callers treat errors as the store being unavailable and fall back to local state
+type Store interface {
+	// Ping checks the store is reachable
+	Ping(ctx context.Context) error
+	// Get returns the value of key, and false when it is unset or expired
+	Get(ctx context.Context, key string) (string, bool, error)
+	// Set sets key to value until ttl passes
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// Incr increments the counter at key, which expires ttl after its last increment, and
+	// returns its new value; a missing counter starts from 0
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// ExtendUntil adds member to the expiring set at key until the given time, keeping the
+	// later time when it is already in it
+	ExtendUntil(ctx context.Context, key, member string, until time.Time) error
+	// Unexpired returns the members of the expiring set at key that have not expired yet,
+	// with their expiry
+	Unexpired(ctx context.Context, key string) (map[string]time.Time, error)
+}
+
+var (
+	defaultStore   Store
+	defaultStoreMu sync.RWMutex
+)
+
+// NewFromEnv creates a Redis store for REDIS_URL, or returns nil when it is unset, which
+// keeps routing state per replica
+func NewFromEnv() (Store, error) {
+	rawURL := utils.GetEnvString("REDIS_URL", "")
+	if rawURL == "" {
+		return nil, nil
+	}
+	config, err := ParseRedisURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("REDIS_URL: %w", err)
+	}
+	config.Prefix = utils.GetEnvString("REDIS_KEY_PREFIX", DefaultKeyPrefix)
+	config.Timeout = time.Duration(utils.GetEnvInt("REDIS_TIMEOUT_MS", int(DefaultTimeout/time.Millisecond))) * time.Millisecond
+	return NewRedis(config), nil
+}
+
+// Default returns the process-wide store, or nil when replicas do not share state
+func Default() Store {
+	defaultStoreMu.RLock()
+	defer defaultStoreMu.RUnlock()
+	return defaultStore
+}
+
+// SetDefault replaces the process-wide store
+func SetDefault(store Store) {
+	defaultStoreMu.Lock()
+	defer defaultStoreMu.Unlock()
+	defaultStore = store
+}
+
+// Memory is a Store kept in process memory, for tests and single-replica setups
+type Memory struct {
+	mu       sync.Mutex
+	values   map[string]memoryValue
+	counters map[string]memoryCounter
+	sets     map[string]map[string]time.Time
+	now      func() time.Time
+}
+
+type memoryValue struct {
+	value   string
+	expires time.Time
+}
+
+type memoryCounter struct {
+	count   int64
+	expires time.Time
+}
+
+// NewMemory creates an empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{
+		values:   make(map[string]memoryValue),
+		counters: make(map[string]memoryCounter),
+		sets:     make(map[string]map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Ping always succeeds
+func (m *Memory) Ping(_ context.Context) error {
+	return nil
+}
+
+// Get returns the value of key, and false when it is unset or expired
+func (m *Memory) Get(_ context.Context, key string) (string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	value, ok := m.values[key]
+	if !ok || !m.now().Before(value.expires) {
+		delete(m.values, key)
+		return "", false, nil
+	}
+	return value.value, true, nil
+}
+
+// Set sets key to value until ttl passes
+func (m *Memory) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = memoryValue{value: value, expires: m.now().Add(ttl)}
+	return nil
+}
+
+// Incr increments the counter at key and returns its new value
+func (m *Memory) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	counter := m.counters[key]
+	if !now.Before(counter.expires) {
+		counter.count = 0
+	}
+	counter.count++
+	counter.expires = now.Add(ttl)
+	m.counters[key] = counter
+	return counter.count, nil
+}
+
+// ExtendUntil adds member to the expiring set at key, keeping the later time
+func (m *Memory) ExtendUntil(_ context.Context, key, member string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	set, ok := m.sets[key]
+	if !ok {
+		set = make(map[string]time.Time)
+		m.sets[key] = set
+	}
+	if until.After(set[member]) {
+		set[member] = until
+	}
+	return nil
+}
+
+// Unexpired returns the members of the expiring set at key that have not expired yet
+func (m *Memory) Unexpired(_ context.Context, key string) (map[string]time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	members := make(map[string]time.Time)
+	for member, until := range m.sets[key] {
+		if until.After(now) {
+			members[member] = until
+		} else {
+			delete(m.sets[key], member)
+		}
+	}
+	return members, nil
+}
@@ -0,0 +1,72 @@
+package shared
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	store := NewMemory()
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Set(ctx, "affinity:conv-1", "openai/gpt-4o", time.Minute))
+	value, ok, err := store.Get(ctx, "affinity:conv-1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "openai/gpt-4o", value)
+
+	for want := int64(1); want <= 3; want++ {
+		count, err := store.Incr(ctx, "rotation:openai", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, want, count)
+	}
+
+	require.NoError(t, store.ExtendUntil(ctx, "cooldowns", "key-1", now.Add(30*time.Second)))
+	require.NoError(t, store.ExtendUntil(ctx, "cooldowns", "key-1", now.Add(10*time.Second)))
+	require.NoError(t, store.ExtendUntil(ctx, "cooldowns", "key-2", now.Add(90*time.Second)))
+	members, err := store.Unexpired(ctx, "cooldowns")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Time{"key-1": now.Add(30 * time.Second), "key-2": now.Add(90 * time.Second)}, members)
+
+	now = now.Add(time.Minute)
+	_, ok, err = store.Get(ctx, "affinity:conv-1")
+	require.NoError(t, err)
+	assert.False(t, ok, "values expire with their TTL")
+	count, err := store.Incr(ctx, "rotation:openai", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count, "counters restart once expired")
+	members, err = store.Unexpired(ctx, "cooldowns")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Time{"key-2": now.Add(30 * time.Second)}, members)
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("REDIS_URL", "")
+	store, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, store, "no store keeps state per replica")
+
+	t.Setenv("REDIS_URL", "rediss://:secret@cache.internal:6380/2")
+	t.Setenv("REDIS_KEY_PREFIX", "prod:")
+	t.Setenv("REDIS_TIMEOUT_MS", "50")
+	store, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, RedisConfig{
+		Addr:     "cache.internal:6380",
+		Password: "secret",
+		DB:       2,
+		TLS:      true,
+		Prefix:   "prod:",
+		Timeout:  50 * time.Millisecond,
+	}, store.(*Redis).config)
+
+	t.Setenv("REDIS_URL", "memcached://cache.internal")
+	_, err = NewFromEnv()
+	assert.ErrorContains(t, err, "REDIS_URL")
+}
@@ -0,0 +1,165 @@
+// Package state persists routing health state, rate-limit cooldowns and canary
+// quarantine, to a file so a restart during a vendor outage does not route straight
+// back to the failing upstream. Vendor spend is saved with it so a restart does not
+// reset budgets
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/budget"
+	"github.com/aashari/go-generative-api-router/internal/canary"
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/ratelimit"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Default persistence settings, overridable with STATE_SAVE_INTERVAL and STATE_MAX_AGE
+const (
+	DefaultSaveInterval = 10 * time.Second
+	DefaultMaxAge       = 15 * time.Minute
+)
+
+// Snapshot is the persisted form of the routing health state
+type Snapshot struct {
+	SavedAt   time.Time            `json:"saved_at"`
+	Cooldowns map[string]time.Time `json:"cooldowns,omitempty"`
+	Canary    []canary.Result      `json:"canary,omitempty"`
+	Spend     []budget.Spend       `json:"spend,omitempty"`
+}
+
+// Persister saves the state of Tracker, Canary and Budget to Path every Interval and
+// restores it on startup. Cooldowns decay on their own as they expire; canary results
+// checked more than MaxAge ago are dropped on restore, while spend is kept until its budget
+// window ends. A nil Tracker, Canary or Budget is skipped
+type Persister struct {
+	Path     string
+	Interval time.Duration
+	MaxAge   time.Duration
+	Tracker  *ratelimit.Tracker
+	Canary   *canary.Status
+	Budget   *budget.Limiter
+}
+
+// NewPersisterFromEnv creates a persister for STATE_FILE, or returns nil when it is
+// unset, which keeps routing health state in memory only
+func NewPersisterFromEnv(tracker *ratelimit.Tracker, status *canary.Status, limiter *budget.Limiter) *Persister {
+	path := utils.GetEnvString("STATE_FILE", "")
+	if path == "" {
+		return nil
+	}
+	return &Persister{
+		Path:     path,
+		Interval: utils.GetEnvDuration("STATE_SAVE_INTERVAL", DefaultSaveInterval),
+		MaxAge:   utils.GetEnvDuration("STATE_MAX_AGE", DefaultMaxAge),
+		Tracker:  tracker,
+		Canary:   status,
+		Budget:   limiter,
+	}
+}
+
+// Restore loads the saved state, if any, into Tracker and Canary
+// A missing file is not an error; it is what the first start looks like
+func (p *Persister) Restore() error {
+	data, err := os.ReadFile(p.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := codec.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("failed to parse state file: %w", err)
+	}
+
+	if p.Tracker != nil {
+		p.Tracker.Restore(snapshot.Cooldowns)
+	}
+	if p.Canary != nil {
+		cutoff := time.Now().Add(-p.MaxAge)
+		var fresh []canary.Result
+		for _, result := range snapshot.Canary {
+			if result.CheckedAt.After(cutoff) {
+				fresh = append(fresh, result)
+			}
+		}
+		p.Canary.Restore(fresh)
+	}
+	if p.Budget != nil {
+		p.Budget.Restore(snapshot.Spend)
+	}
+	return nil
+}
+
+// Save writes the current state to Path, replacing the previous file atomically
+func (p *Persister) Save() error {
+	snapshot := Snapshot{SavedAt: time.Now().UTC()}
+	if p.Tracker != nil {
+		snapshot.Cooldowns = p.Tracker.Cooldowns()
+	}
+	if p.Canary != nil {
+		snapshot.Canary = p.Canary.Results()
+	}
+	if p.Budget != nil {
+		snapshot.Spend = p.Budget.Spend()
+	}
+
+	data, err := codec.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.Path), filepath.Base(p.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p.Path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+// Start saves the state every Interval until ctx is cancelled, then saves it once more
+func (p *Persister) Start(ctx context.Context) {
+	ctx = logger.WithComponent(ctx, "StatePersister")
+	ctx = logger.WithStage(ctx, "Save")
+
+	go func() {
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				p.saveLogged(context.WithoutCancel(ctx))
+				return
+			case <-ticker.C:
+				p.saveLogged(ctx)
+			}
+		}
+	}()
+}
+
+// saveLogged saves the state, logging rather than returning a failure
+func (p *Persister) saveLogged(ctx context.Context) {
+	if err := p.Save(); err != nil {
+		logger.Error(ctx, "Failed to save routing state", err, "path", p.Path)
+	}
+}
@@ -0,0 +1,92 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/budget"
+	"github.com/aashari/go-generative-api-router/internal/canary"
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersister_SaveAndRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Now()
+	throttled := config.Credential{Platform: "openai", Type: "api-key", Value: "sk-throttled"}
+
+	tracker := ratelimit.NewTracker()
+	tracker.Observe(throttled, now.Add(time.Minute))
+	status := canary.NewStatus(true)
+	status.Replace([]canary.Result{
+		{Vendor: "openai", Model: "gpt-4o", Healthy: false, Reason: "timeout", CheckedAt: now.Add(-time.Minute)},
+		{Vendor: "openai", Model: "gpt-4o-mini", Healthy: false, Reason: "timeout", CheckedAt: now.Add(-time.Hour)},
+		{Vendor: "gemini", Model: "gemini-2.5-pro", Healthy: true, CheckedAt: now.Add(-time.Minute)},
+	})
+
+	limiter := budget.NewLimiter()
+	limiter.Configure(map[string]config.VendorBudget{"openai": {MonthlyUSD: 100}},
+		[]config.VendorModel{{Vendor: "openai", Model: "gpt-4o", Pricing: &config.ModelPricing{InputPerMillion: 10}}})
+	limiter.Observe("openai", "gpt-4o", 1_000_000, 0)
+
+	saver := &Persister{Path: path, MaxAge: 15 * time.Minute, Tracker: tracker, Canary: status, Budget: limiter}
+	require.NoError(t, saver.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "sk-throttled", "credential values are never written")
+
+	restoredTracker := ratelimit.NewTracker()
+	restoredStatus := canary.NewStatus(true)
+	restoredLimiter := budget.NewLimiter()
+	loader := &Persister{Path: path, MaxAge: 15 * time.Minute, Tracker: restoredTracker, Canary: restoredStatus, Budget: restoredLimiter}
+	require.NoError(t, loader.Restore())
+
+	assert.True(t, restoredTracker.Throttled(throttled))
+	assert.True(t, restoredStatus.Enabled())
+	results := restoredStatus.Results()
+	require.Len(t, results, 2, "results older than MaxAge are dropped")
+	assert.Equal(t, "gemini-2.5-pro", results[0].Model)
+	assert.Equal(t, "gpt-4o", results[1].Model)
+	assert.Equal(t, 1, results[1].ConsecutiveFailures)
+	spend := restoredLimiter.Spend()
+	require.Len(t, spend, 1)
+	assert.Equal(t, 10.0, spend[0].MonthlyUSD)
+
+	// Cooldowns that expired while the process was down are not restored
+	expiredPath := filepath.Join(t.TempDir(), "expired.json")
+	data, err = codec.Marshal(Snapshot{Cooldowns: map[string]time.Time{"digest": now.Add(-time.Second)}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(expiredPath, data, 0o600))
+	expired := &Persister{Path: expiredPath, Tracker: ratelimit.NewTracker()}
+	require.NoError(t, expired.Restore())
+	assert.Empty(t, expired.Tracker.Cooldowns())
+}
+
+func TestPersister_Restore(t *testing.T) {
+	dir := t.TempDir()
+
+	missing := &Persister{Path: filepath.Join(dir, "missing.json"), Tracker: ratelimit.NewTracker()}
+	assert.NoError(t, missing.Restore(), "a missing file is a first start")
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	require.NoError(t, os.WriteFile(corrupt, []byte("{not json"), 0o600))
+	assert.Error(t, (&Persister{Path: corrupt}).Restore())
+}
+
+func TestNewPersisterFromEnv(t *testing.T) {
+	t.Setenv("STATE_FILE", "")
+	assert.Nil(t, NewPersisterFromEnv(nil, nil, nil))
+
+	t.Setenv("STATE_FILE", "/var/lib/router/state.json")
+	t.Setenv("STATE_SAVE_INTERVAL", "30")
+	persister := NewPersisterFromEnv(nil, nil, nil)
+	require.NotNil(t, persister)
+	assert.Equal(t, 30*time.Second, persister.Interval)
+	assert.Equal(t, DefaultMaxAge, persister.MaxAge)
+}
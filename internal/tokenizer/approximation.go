@@ -0,0 +1,74 @@
+package tokenizer
+
+import (
+	"math"
+	"unicode"
+)
+
+// Approximations of tokenizers that are not embedded
+const (
+	ApproximationClaude  = "claude-approximation"
+	ApproximationGemini  = "gemini-approximation"
+	ApproximationGeneric = "generic-approximation"
+)
+
+// charsPerPiece is how many non-space characters of a word, number or punctuation run one token
+// covers on average, per tokenizer. Larger vocabularies cover more
+var charsPerPiece = map[string]float64{
+	EncodingCL100K:       5.5,
+	EncodingO200K:        6,
+	ApproximationClaude:  5,
+	ApproximationGemini:  6,
+	ApproximationGeneric: 5.5,
+}
+
+// approximation estimates token counts from the pieces tiktoken splits text into, for models
+// whose tokenizer is not available. Han, kana and Hangul characters count one token each
+type approximation struct {
+	name          string
+	charsPerToken float64
+}
+
+// newApproximation returns the approximation named name
+func newApproximation(name string) *approximation {
+	return &approximation{name: name, charsPerToken: charsPerPiece[name]}
+}
+
+// Name returns the approximation name; approximated OpenAI encodings keep their encoding name
+func (a *approximation) Name() string {
+	return a.name
+}
+
+// Exact reports that counts are estimates
+func (a *approximation) Exact() bool {
+	return false
+}
+
+// Encode returns nil, since an approximation has no token IDs
+func (a *approximation) Encode(string) []int {
+	return nil
+}
+
+// Count estimates the number of tokens in text
+func (a *approximation) Count(text string) int {
+	count := 0
+	for _, piece := range split(cl100kPattern, text) {
+		chars, ideographs, newline := 0, 0, false
+		for _, r := range piece {
+			switch {
+			case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+				ideographs++
+			case r == '\n':
+				newline = true
+			case !unicode.IsSpace(r):
+				chars++
+			}
+		}
+		count += ideographs + int(math.Ceil(float64(chars)/a.charsPerToken))
+		// Whitespace merges into the following word, except for line breaks
+		if chars == 0 && ideographs == 0 && newline {
+			count++
+		}
+	}
+	return count
+}
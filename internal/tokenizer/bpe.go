@@ -0,0 +1,190 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// whitespace is the Unicode White_Space property, which tiktoken's \s matches; Go's \s is ASCII only
+const whitespace = `\t\n\x0B\f\r\x{85}\p{Z}`
+
+// Pre-tokenization patterns of the tiktoken encodings. Both end in \s+(?!\S), which RE2 cannot
+// express; the lookahead is applied by splitNext instead
+var (
+	cl100kPattern = regexp.MustCompile(`\A(?:` +
+		`(?i:'s|'t|'re|'ve|'m|'ll|'d)` +
+		`|[^\r\n\p{L}\p{N}]?\p{L}+` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + whitespace + `\p{L}\p{N}]+[\r\n]*` +
+		`|[` + whitespace + `]*[\r\n]+` +
+		`|[` + whitespace + `]+)`)
+	o200kPattern = regexp.MustCompile(`\A(?:` +
+		`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + whitespace + `\p{L}\p{N}]+[\r\n/]*` +
+		`|[` + whitespace + `]*[\r\n]+` +
+		`|[` + whitespace + `]+)`)
+)
+
+// split cuts text into the pieces an encoding merges independently
+func split(pattern *regexp.Regexp, text string) []string {
+	var pieces []string
+	for len(text) > 0 {
+		end := splitNext(pattern, text)
+		pieces = append(pieces, text[:end])
+		text = text[end:]
+	}
+	return pieces
+}
+
+// splitNext returns the length of the first piece of text
+func splitNext(pattern *regexp.Regexp, text string) int {
+	loc := pattern.FindStringIndex(text)
+	if loc == nil || loc[1] == 0 {
+		// Unreachable with the patterns above; consume one rune to make progress
+		_, size := utf8.DecodeRuneInString(text)
+		return size
+	}
+	end := loc[1]
+
+	// \s+(?!\S): a run of whitespace followed by a non-space leaves its last character to
+	// prefix the next piece. Runs ending in a newline come from the [\r\n]+ alternative
+	piece := text[:end]
+	if end < len(text) && isSpaceOnly(piece) && piece[len(piece)-1] != '\n' && piece[len(piece)-1] != '\r' {
+		next, _ := utf8.DecodeRuneInString(text[end:])
+		_, lastSize := utf8.DecodeLastRuneInString(piece)
+		if !unicode.IsSpace(next) && lastSize < len(piece) {
+			end -= lastSize
+		}
+	}
+	return end
+}
+
+// isSpaceOnly reports whether text is entirely whitespace
+func isSpaceOnly(text string) bool {
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// BPE is a byte-pair encoding loaded from a tiktoken rank file
+type BPE struct {
+	name    string
+	pattern *regexp.Regexp
+	ranks   map[string]int
+}
+
+// LoadBPE reads a tiktoken rank file, one base64 token and its rank per line
+func LoadBPE(name string, r io.Reader) (*BPE, error) {
+	pattern, ok := encodingPatterns[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s line %d: expected a token and a rank", name, line)
+		}
+		token, err := base64.StdEncoding.DecodeString(string(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid token: %w", name, line, err)
+		}
+		rank, err := strconv.Atoi(string(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: invalid rank: %w", name, line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("%s has no tokens", name)
+	}
+	return &BPE{name: name, pattern: pattern, ranks: ranks}, nil
+}
+
+// encodingPatterns maps the supported encodings to their pre-tokenization patterns
+var encodingPatterns = map[string]*regexp.Regexp{
+	EncodingCL100K: cl100kPattern,
+	EncodingO200K:  o200kPattern,
+}
+
+// Name returns the encoding name
+func (b *BPE) Name() string {
+	return b.name
+}
+
+// Exact reports that counts match the vendor's tokenizer
+func (b *BPE) Exact() bool {
+	return true
+}
+
+// Count returns the number of tokens in text
+func (b *BPE) Count(text string) int {
+	return len(b.Encode(text))
+}
+
+// Encode returns the token IDs of text
+func (b *BPE) Encode(text string) []int {
+	var tokens []int
+	for _, piece := range split(b.pattern, text) {
+		if rank, ok := b.ranks[piece]; ok {
+			tokens = append(tokens, rank)
+			continue
+		}
+		tokens = append(tokens, b.merge(piece)...)
+	}
+	return tokens
+}
+
+// merge encodes a piece by repeatedly merging the adjacent pair with the lowest rank, as tiktoken does
+func (b *BPE) merge(piece string) []int {
+	// bounds[i] is the start of the i-th part; the last entry is the end of the piece
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, bestRank := -1, 0
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]; ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+
+	tokens := make([]int, 0, len(bounds)-1)
+	for i := 0; i+1 < len(bounds); i++ {
+		part := piece[bounds[i]:bounds[i+1]]
+		if rank, ok := b.ranks[part]; ok {
+			tokens = append(tokens, rank)
+			continue
+		}
+		// Every single byte has a rank in a complete encoding; count unknown bytes individually
+		for range len(part) {
+			tokens = append(tokens, -1)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,78 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		text     string
+		expected []string
+	}{
+		{"words keep their leading space", EncodingCL100K, "Hello world", []string{"Hello", " world"}},
+		{"contractions", EncodingCL100K, "I'm here", []string{"I", "'m", " here"}},
+		{"numbers in groups of three", EncodingCL100K, "12345", []string{"123", "45"}},
+		{"punctuation", EncodingCL100K, "Hi!!! ok", []string{"Hi", "!!!", " ok"}},
+		{"newlines", EncodingCL100K, "a\n\nb", []string{"a", "\n\n", "b"}},
+		{"whitespace leaves a space for the next word", EncodingCL100K, "hi   there", []string{"hi", "  ", " there"}},
+		{"trailing whitespace", EncodingCL100K, "hi  ", []string{"hi", "  "}},
+		{"unicode whitespace", EncodingCL100K, "a  b", []string{"a", " ", " b"}},
+		{"o200k splits camel case", EncodingO200K, "HelloWorld", []string{"Hello", "World"}},
+		{"o200k keeps contractions on words", EncodingO200K, "I'm", []string{"I'm"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, split(encodingPatterns[tt.pattern], tt.text))
+		})
+	}
+}
+
+// rankFile renders tokens in tiktoken format, ranked in order
+func rankFile(tokens ...string) string {
+	var b strings.Builder
+	for rank, token := range tokens {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+	}
+	return b.String()
+}
+
+func TestBPE_Encode(t *testing.T) {
+	bpe, err := LoadBPE(EncodingCL100K, strings.NewReader(rankFile("a", "b", "c", " ", "ab", "bc", " ab", "abc")))
+	require.NoError(t, err)
+
+	tests := []struct {
+		text     string
+		expected []int
+	}{
+		{"abc", []int{7}},
+		{"abcb", []int{7, 1}}, // "ab" ranks before "bc", then "ab"+"c" merges
+		{" abc", []int{6, 2}}, // after "ab", " ab" ranks before "abc"
+		{"cab ab", []int{2, 4, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, bpe.Encode(tt.text))
+			assert.Equal(t, len(tt.expected), bpe.Count(tt.text))
+		})
+	}
+}
+
+func TestLoadBPE_Errors(t *testing.T) {
+	_, err := LoadBPE("p50k_base", strings.NewReader(rankFile("a")))
+	assert.ErrorContains(t, err, "unknown encoding")
+
+	_, err = LoadBPE(EncodingCL100K, strings.NewReader("not-base64! 0\n"))
+	assert.ErrorContains(t, err, "line 1: invalid token")
+
+	_, err = LoadBPE(EncodingCL100K, strings.NewReader(""))
+	assert.ErrorContains(t, err, "no tokens")
+}
@@ -0,0 +1,174 @@
+package tokenizer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidRequest is returned for tokenize and count requests that cannot be counted
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Token overheads of the chat format, as documented for OpenAI models
+const (
+	// tokensPerMessage frames every message
+	tokensPerMessage = 3
+	// tokensPerName is added for messages that set a name
+	tokensPerName = 1
+	// tokensReplyPriming primes the assistant reply
+	tokensReplyPriming = 3
+	// tokensPerToolCall frames every tool call of an assistant message
+	tokensPerToolCall = 3
+)
+
+// Image token costs of OpenAI vision models: low detail images cost a flat amount, others are
+// counted as a 1024x1024 image at high detail since their size is not known without downloading them
+const (
+	lowDetailImageTokens  = 85
+	highDetailImageTokens = 765
+)
+
+// TokenizeRequest is the body of /v1/tokenize
+type TokenizeRequest struct {
+	Model string `json:"model" example:"gpt-4o"`
+	Input string `json:"input" example:"Hello, world!"`
+}
+
+// TokenizeResponse is the response of /v1/tokenize
+type TokenizeResponse struct {
+	Object    string `json:"object" example:"tokenize"`
+	Model     string `json:"model" example:"gpt-4o"`
+	Tokenizer string `json:"tokenizer" example:"o200k_base"`
+	// Exact is false when the count is an approximation of the vendor's tokenizer
+	Exact bool `json:"exact" example:"true"`
+	// Tokens are the token IDs, only returned for exact tokenizers
+	Tokens []int `json:"tokens,omitempty"`
+	Count  int   `json:"count" example:"4"`
+}
+
+// CountTokensRequest is the body of /v1/count_tokens
+type CountTokensRequest struct {
+	Model    string                   `json:"model" example:"gpt-4o"`
+	Messages []map[string]interface{} `json:"messages"`
+	Tools    []interface{}            `json:"tools,omitempty"`
+}
+
+// CountTokensResponse is the response of /v1/count_tokens
+type CountTokensResponse struct {
+	Object    string `json:"object" example:"count_tokens"`
+	Model     string `json:"model" example:"gpt-4o"`
+	Tokenizer string `json:"tokenizer" example:"o200k_base"`
+	// Exact is false when the count approximates the vendor's tokenizer or includes images,
+	// audio, files or tool definitions, whose cost is estimated
+	Exact       bool `json:"exact" example:"true"`
+	InputTokens int  `json:"input_tokens" example:"12"`
+}
+
+// Tokenize counts the tokens of a text input
+func Tokenize(tok Tokenizer, req TokenizeRequest) (TokenizeResponse, error) {
+	if req.Model == "" {
+		return TokenizeResponse{}, fmt.Errorf("%w: 'model' is required", ErrInvalidRequest)
+	}
+	response := TokenizeResponse{Object: "tokenize", Model: req.Model, Tokenizer: tok.Name(), Exact: tok.Exact()}
+	if tokens := tok.Encode(req.Input); tokens != nil {
+		response.Tokens = tokens
+		response.Count = len(tokens)
+	} else {
+		response.Count = tok.Count(req.Input)
+	}
+	return response, nil
+}
+
+// CountTokens counts the prompt tokens of a chat completion request
+func CountTokens(tok Tokenizer, req CountTokensRequest) (CountTokensResponse, error) {
+	if req.Model == "" {
+		return CountTokensResponse{}, fmt.Errorf("%w: 'model' is required", ErrInvalidRequest)
+	}
+	if len(req.Messages) == 0 {
+		return CountTokensResponse{}, fmt.Errorf("%w: 'messages' must not be empty", ErrInvalidRequest)
+	}
+
+	exact := tok.Exact()
+	count := tokensReplyPriming
+	for i, message := range req.Messages {
+		role, _ := message["role"].(string)
+		if role == "" {
+			return CountTokensResponse{}, fmt.Errorf("%w: message %d needs a role", ErrInvalidRequest, i)
+		}
+		tokens, estimated, err := countMessage(tok, message)
+		if err != nil {
+			return CountTokensResponse{}, fmt.Errorf("%w: message %d: %v", ErrInvalidRequest, i, err)
+		}
+		count += tokens
+		exact = exact && !estimated
+	}
+	if len(req.Tools) > 0 {
+		// Vendors render tool definitions in their own formats; count their JSON instead
+		definitions, err := json.Marshal(req.Tools)
+		if err != nil {
+			return CountTokensResponse{}, fmt.Errorf("%w: invalid tools: %v", ErrInvalidRequest, err)
+		}
+		count += tok.Count(string(definitions))
+		exact = false
+	}
+
+	return CountTokensResponse{
+		Object:      "count_tokens",
+		Model:       req.Model,
+		Tokenizer:   tok.Name(),
+		Exact:       exact,
+		InputTokens: count,
+	}, nil
+}
+
+// countMessage counts the tokens of one message, reporting whether the count includes estimates
+func countMessage(tok Tokenizer, message map[string]interface{}) (int, bool, error) {
+	role, _ := message["role"].(string)
+	count := tokensPerMessage + tok.Count(role)
+	estimated := false
+
+	switch content := message["content"].(type) {
+	case nil:
+	case string:
+		count += tok.Count(content)
+	case []interface{}:
+		for _, part := range content {
+			partMap, _ := part.(map[string]interface{})
+			switch partMap["type"] {
+			case "text":
+				text, _ := partMap["text"].(string)
+				count += tok.Count(text)
+			case "image_url":
+				image, _ := partMap["image_url"].(map[string]interface{})
+				if image["detail"] == "low" {
+					count += lowDetailImageTokens
+				} else {
+					count += highDetailImageTokens
+				}
+				estimated = true
+			default:
+				// Audio and files are billed by duration and pages, not counted here
+				estimated = true
+			}
+		}
+	default:
+		return 0, false, fmt.Errorf("content must be a string or an array of content parts")
+	}
+
+	if name, ok := message["name"].(string); ok && name != "" {
+		count += tok.Count(name) + tokensPerName
+	}
+	if id, ok := message["tool_call_id"].(string); ok {
+		count += tok.Count(id)
+	}
+	toolCalls, _ := message["tool_calls"].([]interface{})
+	for _, toolCall := range toolCalls {
+		toolCallMap, _ := toolCall.(map[string]interface{})
+		function, _ := toolCallMap["function"].(map[string]interface{})
+		name, _ := function["name"].(string)
+		arguments, _ := function["arguments"].(string)
+		count += tokensPerToolCall + tok.Count(name) + tok.Count(arguments)
+		estimated = true
+	}
+	return count, estimated, nil
+}
@@ -0,0 +1,135 @@
+// Package tokenizer counts the tokens of prompts without calling vendors
+package tokenizer
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Encodings of OpenAI models
+const (
+	EncodingCL100K = "cl100k_base"
+	EncodingO200K  = "o200k_base"
+)
+
+// Tokenizer counts the tokens of text for a model family
+type Tokenizer interface {
+	// Name identifies the encoding or approximation
+	Name() string
+	// Exact reports whether counts match the vendor's own tokenizer
+	Exact() bool
+	// Count returns the number of tokens in text
+	Count(text string) int
+	// Encode returns the token IDs of text, or nil when the tokenizer only approximates
+	Encode(text string) []int
+}
+
+// embedded holds the rank files present in data/ at build time
+//
+//go:embed data
+var embedded embed.FS
+
+// Registry resolves the tokenizer of a model
+type Registry struct {
+	encodings map[string]*BPE
+}
+
+var (
+	defaultRegistry   = &Registry{encodings: make(map[string]*BPE)}
+	defaultRegistryMu sync.RWMutex
+)
+
+// NewRegistry loads the encodings embedded at build time, replaced by those found in dir
+// Encodings with no rank file are approximated
+func NewRegistry(dir string) (*Registry, error) {
+	registry := &Registry{encodings: make(map[string]*BPE)}
+	for name := range encodingPatterns {
+		file := name + ".tiktoken"
+		var data fs.File
+		var err error
+		if dir != "" {
+			data, err = os.Open(filepath.Join(dir, file))
+		}
+		if dir == "" || errors.Is(err, fs.ErrNotExist) {
+			data, err = embedded.Open("data/" + file)
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", file, err)
+		}
+		encoding, err := LoadBPE(name, data)
+		data.Close()
+		if err != nil {
+			return nil, err
+		}
+		registry.encodings[name] = encoding
+	}
+	return registry, nil
+}
+
+// NewRegistryFromEnv loads the rank files of TOKENIZER_DIR, if set, over the embedded ones
+func NewRegistryFromEnv() (*Registry, error) {
+	return NewRegistry(utils.GetEnvString("TOKENIZER_DIR", ""))
+}
+
+// Default returns the process-wide registry, which approximates every model until SetDefault is called
+func Default() *Registry {
+	defaultRegistryMu.RLock()
+	defer defaultRegistryMu.RUnlock()
+	return defaultRegistry
+}
+
+// SetDefault replaces the process-wide registry
+func SetDefault(registry *Registry) {
+	defaultRegistryMu.Lock()
+	defer defaultRegistryMu.Unlock()
+	defaultRegistry = registry
+}
+
+// ForModel returns the tokenizer of a model served by vendor; vendor may be empty when the
+// model is not configured. Models whose encoding is not loaded get an approximation
+func (r *Registry) ForModel(vendor, model string) Tokenizer {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	if encoding := openAIEncoding(name); encoding != "" {
+		if bpe, ok := r.encodings[encoding]; ok {
+			return bpe
+		}
+		return newApproximation(encoding)
+	}
+	switch {
+	case strings.HasPrefix(name, "claude") || vendor == "anthropic":
+		return newApproximation(ApproximationClaude)
+	case strings.HasPrefix(name, "gemini") || strings.HasPrefix(name, "gemma") || vendor == "gemini" || vendor == "vertex":
+		return newApproximation(ApproximationGemini)
+	}
+	return newApproximation(ApproximationGeneric)
+}
+
+// openAIEncoding returns the encoding of an OpenAI model, or "" for other models
+func openAIEncoding(model string) string {
+	for _, prefix := range []string{"gpt-4o", "chatgpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "o1", "o3", "o4", "gpt-oss"} {
+		if strings.HasPrefix(model, prefix) {
+			return EncodingO200K
+		}
+	}
+	for _, prefix := range []string{"gpt-4", "gpt-3.5", "gpt-35", "text-embedding-ada-002", "text-embedding-3"} {
+		if strings.HasPrefix(model, prefix) {
+			return EncodingCL100K
+		}
+	}
+	return ""
+}
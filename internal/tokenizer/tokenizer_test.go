@@ -0,0 +1,148 @@
+package tokenizer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry(t *testing.T) *Registry {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "o200k_base.tiktoken"), []byte(rankFile("H", "i", " ", "Hi", "!")), 0o600))
+	registry, err := NewRegistry(dir)
+	require.NoError(t, err)
+	return registry
+}
+
+func TestRegistry_ForModel(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	tests := []struct {
+		vendor, model string
+		name          string
+		exact         bool
+	}{
+		{"openai", "gpt-4o", EncodingO200K, true},
+		{"", "openai/gpt-5-mini", EncodingO200K, true},
+		{"openai", "gpt-4-turbo", EncodingCL100K, false},
+		{"anthropic", "claude-sonnet-4", ApproximationClaude, false},
+		{"", "claude-3-5-haiku", ApproximationClaude, false},
+		{"vertex", "my-tuned-model", ApproximationGemini, false},
+		{"", "gemini-2.5-pro", ApproximationGemini, false},
+		{"mistral", "mistral-large", ApproximationGeneric, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.vendor+"/"+tt.model, func(t *testing.T) {
+			tok := registry.ForModel(tt.vendor, tt.model)
+			assert.Equal(t, tt.name, tok.Name())
+			assert.Equal(t, tt.exact, tok.Exact())
+		})
+	}
+}
+
+func TestApproximation_Count(t *testing.T) {
+	tok := newApproximation(ApproximationGeneric)
+	assert.Equal(t, 0, tok.Count(""))
+	assert.Equal(t, 2, tok.Count("Hello world"))
+	assert.Greater(t, tok.Count("internationalization"), 1, "long words span several tokens")
+	assert.Equal(t, 4, tok.Count("你好世界"), "ideographs count one token each")
+	assert.Equal(t, 3, tok.Count("a\n\nb"), "line breaks count")
+	assert.Nil(t, tok.Encode("Hello"))
+}
+
+func TestTokenize(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	response, err := Tokenize(registry.ForModel("openai", "gpt-4o"), TokenizeRequest{Model: "gpt-4o", Input: "Hi Hi!"})
+	require.NoError(t, err)
+	assert.Equal(t, TokenizeResponse{Object: "tokenize", Model: "gpt-4o", Tokenizer: EncodingO200K, Exact: true, Tokens: []int{3, 2, 3, 4}, Count: 4}, response)
+
+	response, err = Tokenize(registry.ForModel("anthropic", "claude-sonnet-4"), TokenizeRequest{Model: "claude-sonnet-4", Input: "Hi Hi!"})
+	require.NoError(t, err)
+	assert.False(t, response.Exact)
+	assert.Nil(t, response.Tokens)
+	assert.Equal(t, 3, response.Count)
+
+	_, err = Tokenize(registry.ForModel("", ""), TokenizeRequest{Input: "Hi"})
+	assert.ErrorIs(t, err, ErrInvalidRequest)
+}
+
+func TestCountTokens(t *testing.T) {
+	tok := newTestRegistry(t).ForModel("openai", "gpt-4o")
+
+	tests := []struct {
+		name     string
+		request  CountTokensRequest
+		expected int
+		exact    bool
+		err      string
+	}{
+		{
+			// 3 priming + (3 framing + "user" + "Hi Hi!"); the test encoding has no ranks for "user", so its bytes count one each
+			name: "text message",
+			request: CountTokensRequest{Model: "gpt-4o", Messages: []map[string]interface{}{
+				{"role": "user", "content": "Hi Hi!"},
+			}},
+			expected: 3 + 3 + 4 + 4,
+			exact:    true,
+		},
+		{
+			name: "name adds a token",
+			request: CountTokensRequest{Model: "gpt-4o", Messages: []map[string]interface{}{
+				{"role": "user", "content": "Hi", "name": "Hi"},
+			}},
+			expected: 3 + 3 + 4 + 1 + 1 + 1,
+			exact:    true,
+		},
+		{
+			name: "images are estimated",
+			request: CountTokensRequest{Model: "gpt-4o", Messages: []map[string]interface{}{
+				{"role": "user", "content": []interface{}{
+					map[string]interface{}{"type": "text", "text": "Hi"},
+					map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "https://example.com/a.png", "detail": "low"}},
+				}},
+			}},
+			expected: 3 + 3 + 4 + 1 + 85,
+			exact:    false,
+		},
+		{
+			name:    "messages are required",
+			request: CountTokensRequest{Model: "gpt-4o"},
+			err:     "'messages' must not be empty",
+		},
+		{
+			name: "roles are required",
+			request: CountTokensRequest{Model: "gpt-4o", Messages: []map[string]interface{}{
+				{"content": "Hi"},
+			}},
+			err: "message 0 needs a role",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := CountTokens(tok, tt.request)
+			if tt.err != "" {
+				assert.ErrorIs(t, err, ErrInvalidRequest)
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, response.InputTokens)
+			assert.Equal(t, tt.exact, response.Exact)
+			assert.Equal(t, EncodingO200K, response.Tokenizer)
+		})
+	}
+
+	withTools, err := CountTokens(tok, CountTokensRequest{Model: "gpt-4o",
+		Messages: []map[string]interface{}{{"role": "user", "content": "Hi"}},
+		Tools:    []interface{}{map[string]interface{}{"type": "function"}},
+	})
+	require.NoError(t, err)
+	assert.False(t, withTools.Exact, "tool definitions are estimated")
+	assert.Greater(t, withTools.InputTokens, 3+3+4+1)
+	assert.Equal(t, "count_tokens", withTools.Object)
+}
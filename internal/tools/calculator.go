@@ -0,0 +1,216 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxExpressionLength bounds the expressions the calculator accepts
+const maxExpressionLength = 1024
+
+// Evaluate computes an arithmetic expression of numbers, + - * / % ^ and parentheses
+// ^ is exponentiation and binds tighter than unary minus, so -2^2 is -4
+func Evaluate(expression string) (float64, error) {
+	if strings.TrimSpace(expression) == "" {
+		return 0, fmt.Errorf("'expression' must not be empty")
+	}
+	if len(expression) > maxExpressionLength {
+		return 0, fmt.Errorf("'expression' exceeds %d characters", maxExpressionLength)
+	}
+
+	p := &parser{input: expression}
+	result, err := p.expression()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.input[p.pos], p.pos)
+	}
+	if math.IsInf(result, 0) || math.IsNaN(result) {
+		return 0, fmt.Errorf("result is not a finite number")
+	}
+	return result, nil
+}
+
+// parser is a recursive descent parser over the grammar
+//
+//	expression = term { ("+" | "-") term }
+//	term       = unary { ("*" | "/" | "%") unary }
+//	unary      = ("-" | "+") unary | power
+//	power      = primary [ "^" unary ]
+//	primary    = number | "(" expression ")"
+type parser struct {
+	input string
+	pos   int
+	depth int
+}
+
+func (p *parser) expression() (float64, error) {
+	left, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch p.peek() {
+		case '+', '-':
+			op := p.next()
+			right, err := p.term()
+			if err != nil {
+				return 0, err
+			}
+			if op == '+' {
+				left += right
+			} else {
+				left -= right
+			}
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *parser) term() (float64, error) {
+	left, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch p.peek() {
+		case '*', '/', '%':
+			op := p.next()
+			right, err := p.unary()
+			if err != nil {
+				return 0, err
+			}
+			switch op {
+			case '*':
+				left *= right
+			case '/':
+				if right == 0 {
+					return 0, fmt.Errorf("division by zero")
+				}
+				left /= right
+			default:
+				if right == 0 {
+					return 0, fmt.Errorf("division by zero")
+				}
+				left = math.Mod(left, right)
+			}
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *parser) unary() (float64, error) {
+	switch p.peek() {
+	case '-', '+':
+		op := p.next()
+		value, err := p.nested(p.unary)
+		if op == '-' {
+			value = -value
+		}
+		return value, err
+	}
+	return p.power()
+}
+
+func (p *parser) power() (float64, error) {
+	base, err := p.primary()
+	if err != nil {
+		return 0, err
+	}
+	if p.peek() != '^' {
+		return base, nil
+	}
+	p.next()
+	exponent, err := p.nested(p.unary)
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(base, exponent), nil
+}
+
+func (p *parser) primary() (float64, error) {
+	if p.peek() == '(' {
+		p.next()
+		value, err := p.nested(p.expression)
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, fmt.Errorf("missing closing parenthesis at position %d", p.pos)
+		}
+		p.next()
+		return value, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && (unicode.IsDigit(rune(p.input[p.pos])) || p.input[p.pos] == '.') {
+		p.pos++
+	}
+	// Allow exponent notation such as 1.5e3
+	if p.pos > start && p.pos < len(p.input) && (p.input[p.pos] == 'e' || p.input[p.pos] == 'E') {
+		end := p.pos + 1
+		if end < len(p.input) && (p.input[end] == '+' || p.input[end] == '-') {
+			end++
+		}
+		if end < len(p.input) && unicode.IsDigit(rune(p.input[end])) {
+			p.pos = end
+			for p.pos < len(p.input) && unicode.IsDigit(rune(p.input[p.pos])) {
+				p.pos++
+			}
+		}
+	}
+	if p.pos == start {
+		if p.pos >= len(p.input) {
+			return 0, fmt.Errorf("unexpected end of expression")
+		}
+		return 0, fmt.Errorf("unexpected %q at position %d", p.input[p.pos], p.pos)
+	}
+	value, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", p.input[start:p.pos])
+	}
+	return value, nil
+}
+
+// nested parses a sub-expression, bounding the recursion depth
+func (p *parser) nested(parse func() (float64, error)) (float64, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > 64 {
+		return 0, fmt.Errorf("expression is nested too deeply")
+	}
+	return parse()
+}
+
+// peek returns the next non-space character, or 0 at the end of the input
+func (p *parser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) next() byte {
+	c := p.peek()
+	p.pos++
+	return c
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// formatNumber prints a result without a trailing fraction for whole numbers
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expression    string
+		expected      float64
+		expectedError string
+	}{
+		{expression: "1 + 2 * 3", expected: 7},
+		{expression: "(1 + 2) * 3", expected: 9},
+		{expression: "10 / 4", expected: 2.5},
+		{expression: "10 % 4", expected: 2},
+		{expression: "2 ^ 3 ^ 2", expected: 512},
+		{expression: "-2 ^ 2", expected: -4},
+		{expression: "2 * -3", expected: -6},
+		{expression: "1.5e3 + .5", expected: 1500.5},
+		{expression: "  ((4))  ", expected: 4},
+		{expression: "", expectedError: "must not be empty"},
+		{expression: "1 / 0", expectedError: "division by zero"},
+		{expression: "5 % 0", expectedError: "division by zero"},
+		{expression: "(1 + 2", expectedError: "missing closing parenthesis"},
+		{expression: "1 + ", expectedError: "unexpected end of expression"},
+		{expression: "2 x 3", expectedError: "unexpected 'x' at position 2"},
+		{expression: "1.2.3", expectedError: "invalid number"},
+		{expression: "10 ^ 400", expectedError: "not a finite number"},
+		{expression: strings.Repeat("(", 100) + "1" + strings.Repeat(")", 100), expectedError: "nested too deeply"},
+		{expression: strings.Repeat("1+", 600) + "1", expectedError: "exceeds 1024 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			result, err := Evaluate(tt.expression)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, tt.expected, result, 1e-9)
+		})
+	}
+}
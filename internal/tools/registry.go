@@ -0,0 +1,362 @@
+// Package tools holds the server-side tools the router runs on behalf of models. Tools
+// declared in the tools file are offered to the model alongside the client's own tools;
+// when the model calls one, the router executes it and hands the result back to the
+// model instead of returning the call to the client
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Tool types
+const (
+	// TypeHTTP calls an HTTP API declared in the tools file with the model's arguments
+	TypeHTTP = "http"
+	// TypeWebFetch fetches a web page the model names
+	TypeWebFetch = "web_fetch"
+	// TypeCalculator evaluates an arithmetic expression
+	TypeCalculator = "calculator"
+)
+
+const (
+	// DefaultMaxRounds is how many times a model is re-invoked with tool results per request
+	DefaultMaxRounds = 5
+	// defaultTimeout bounds a single tool execution
+	defaultTimeout = 10 * time.Second
+	// maxResultBytes caps the tool output handed back to the model
+	maxResultBytes = 64 * 1024
+)
+
+// toolNamePattern is the function name format vendors accept
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Definition declares a server-side tool
+type Definition struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON schema of the tool's arguments; built-in tools have a default
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	// URL is the endpoint of an HTTP tool; environment variables in it are expanded
+	URL string `json:"url,omitempty"`
+	// Method is GET, which sends the arguments as query parameters, or POST (default),
+	// which sends them as the JSON body
+	Method string `json:"method,omitempty"`
+	// Headers are added to HTTP tool requests; environment variables in values are expanded
+	Headers map[string]string `json:"headers,omitempty"`
+	// TimeoutSeconds bounds one execution, 10 by default
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+	// AllowedHosts limits a web_fetch tool to these hosts, or their subdomains for
+	// "*.example.com" entries; empty allows any public host
+	AllowedHosts []string `json:"allowed_hosts,omitempty"`
+}
+
+// registryFile is the on-disk tools format
+type registryFile struct {
+	MaxRounds int          `json:"max_rounds,omitempty"`
+	Tools     []Definition `json:"tools"`
+}
+
+// Registry holds the server-side tools by name
+type Registry struct {
+	tools     map[string]Definition
+	maxRounds int
+	// httpClient calls the APIs of http tools, which the operator declared
+	httpClient *http.Client
+	// webTransport carries web_fetch requests to the URLs models name, connecting only to
+	// addresses permitted accepts
+	webTransport *http.Transport
+	permitted    func(net.IP) bool
+}
+
+var (
+	defaultRegistry   = NewRegistry(nil, 0)
+	defaultRegistryMu sync.RWMutex
+)
+
+// NewRegistry creates a registry of already validated tools; maxRounds of zero uses DefaultMaxRounds
+func NewRegistry(definitions []Definition, maxRounds int) *Registry {
+	if maxRounds <= 0 {
+		maxRounds = DefaultMaxRounds
+	}
+	registry := &Registry{
+		tools:      make(map[string]Definition, len(definitions)),
+		maxRounds:  maxRounds,
+		httpClient: &http.Client{},
+		permitted:  publicAddress,
+	}
+	registry.webTransport = newWebFetchTransport(func(ip net.IP) bool { return registry.permitted(ip) })
+	for _, definition := range definitions {
+		registry.tools[definition.Name] = definition
+	}
+	return registry
+}
+
+// LoadRegistry reads the tools file: a "tools" list and an optional "max_rounds"
+func LoadRegistry(path string) (*Registry, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tools file: %w", err)
+	}
+	var file registryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid tools file: %w", err)
+	}
+	if err := Validate(file.Tools); err != nil {
+		return nil, err
+	}
+	return NewRegistry(file.Tools, file.MaxRounds), nil
+}
+
+// LoadRegistryFromEnv loads the tools file named by TOOLS_FILE, or returns an empty registry when unset
+func LoadRegistryFromEnv() (*Registry, error) {
+	path := utils.GetEnvString("TOOLS_FILE", "")
+	if path == "" {
+		return NewRegistry(nil, 0), nil
+	}
+	return LoadRegistry(path)
+}
+
+// Default returns the process-wide registry, empty until SetDefault is called
+func Default() *Registry {
+	defaultRegistryMu.RLock()
+	defer defaultRegistryMu.RUnlock()
+	return defaultRegistry
+}
+
+// SetDefault replaces the process-wide registry
+func SetDefault(registry *Registry) {
+	defaultRegistryMu.Lock()
+	defer defaultRegistryMu.Unlock()
+	defaultRegistry = registry
+}
+
+// Validate checks tool names are valid and unique and each tool has what its type needs
+func Validate(definitions []Definition) error {
+	seen := make(map[string]bool, len(definitions))
+	for i, definition := range definitions {
+		if !toolNamePattern.MatchString(definition.Name) {
+			return fmt.Errorf("tool %d has an invalid name %q: use up to 64 letters, digits, underscores or dashes", i, definition.Name)
+		}
+		if seen[definition.Name] {
+			return fmt.Errorf("tool %s is declared twice", definition.Name)
+		}
+		seen[definition.Name] = true
+
+		switch definition.Type {
+		case TypeWebFetch, TypeCalculator:
+		case TypeHTTP:
+			parsed, err := url.Parse(os.ExpandEnv(definition.URL))
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return fmt.Errorf("tool %s needs an http or https url", definition.Name)
+			}
+			if method := strings.ToUpper(definition.Method); method != "" && method != http.MethodGet && method != http.MethodPost {
+				return fmt.Errorf("tool %s has an invalid method %q, expected GET or POST", definition.Name, definition.Method)
+			}
+		default:
+			return fmt.Errorf("tool %s has an invalid type %q, expected %q, %q or %q", definition.Name, definition.Type, TypeHTTP, TypeWebFetch, TypeCalculator)
+		}
+		if definition.TimeoutSeconds < 0 {
+			return fmt.Errorf("tool %s has a negative timeout", definition.Name)
+		}
+	}
+	return nil
+}
+
+// Enabled reports whether any tools are registered
+func (r *Registry) Enabled() bool {
+	return len(r.tools) > 0
+}
+
+// Has reports whether a tool is registered under the name
+func (r *Registry) Has(name string) bool {
+	_, ok := r.tools[name]
+	return ok
+}
+
+// MaxRounds is how many times a model may be re-invoked with tool results per request
+func (r *Registry) MaxRounds() int {
+	return r.maxRounds
+}
+
+// Definitions returns the registered tools in the OpenAI "tools" format, sorted by name
+func (r *Registry) Definitions() []map[string]interface{} {
+	names := make([]string, 0, len(r.tools))
+	for name := range r.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	definitions := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		tool := r.tools[name]
+		function := map[string]interface{}{
+			"name":       tool.Name,
+			"parameters": tool.parameters(),
+		}
+		if description := tool.description(); description != "" {
+			function["description"] = description
+		}
+		definitions = append(definitions, map[string]interface{}{
+			"type":     "function",
+			"function": function,
+		})
+	}
+	return definitions
+}
+
+// Execute runs a registered tool with the model's JSON arguments and returns its output
+func (r *Registry) Execute(ctx context.Context, name, arguments string) (string, error) {
+	tool, ok := r.tools[name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", name)
+	}
+
+	args := make(map[string]interface{})
+	if strings.TrimSpace(arguments) != "" {
+		if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %v", err)
+		}
+	}
+
+	timeout := defaultTimeout
+	if tool.TimeoutSeconds > 0 {
+		timeout = time.Duration(tool.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch tool.Type {
+	case TypeCalculator:
+		expression, _ := args["expression"].(string)
+		result, err := Evaluate(expression)
+		if err != nil {
+			return "", err
+		}
+		return formatNumber(result), nil
+	case TypeWebFetch:
+		target, _ := args["url"].(string)
+		return r.fetchPage(ctx, tool, target)
+	default:
+		return r.executeHTTP(ctx, tool, args)
+	}
+}
+
+// executeHTTP calls the tool's API with the arguments as query parameters or a JSON body
+func (r *Registry) executeHTTP(ctx context.Context, tool Definition, args map[string]interface{}) (string, error) {
+	endpoint := os.ExpandEnv(tool.URL)
+	var req *http.Request
+	var err error
+	if strings.EqualFold(tool.Method, http.MethodGet) {
+		parsed, parseErr := url.Parse(endpoint)
+		if parseErr != nil {
+			return "", parseErr
+		}
+		query := parsed.Query()
+		for key, value := range args {
+			if text, ok := value.(string); ok {
+				query.Set(key, text)
+				continue
+			}
+			encoded, _ := json.Marshal(value)
+			query.Set(key, string(encoded))
+		}
+		parsed.RawQuery = query.Encode()
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	} else {
+		body, marshalErr := json.Marshal(args)
+		if marshalErr != nil {
+			return "", marshalErr
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err == nil {
+			req.Header.Set(utils.HeaderContentType, utils.ContentTypeJSON)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(utils.HeaderUserAgent, utils.ServiceName)
+	for name, value := range tool.Headers {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+	return r.do(r.httpClient, req)
+}
+
+// do sends a tool request with client and returns the response body, truncated to maxResultBytes
+func (r *Registry) do(client *http.Client, req *http.Request) (string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResultBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(truncate(body))))
+	}
+	return string(truncate(body)), nil
+}
+
+// parameters returns the tool's argument schema, defaulting for built-in tools
+func (d Definition) parameters() map[string]interface{} {
+	if d.Parameters != nil {
+		return d.Parameters
+	}
+	var property, description string
+	switch d.Type {
+	case TypeCalculator:
+		property, description = "expression", "Arithmetic expression using + - * / % ^ and parentheses, e.g. (2 + 3) * 4"
+	case TypeWebFetch:
+		property, description = "url", "The http or https URL to fetch"
+	default:
+		return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			property: map[string]interface{}{"type": "string", "description": description},
+		},
+		"required": []string{property},
+	}
+}
+
+// description returns the tool's description, defaulting for built-in tools
+func (d Definition) description() string {
+	if d.Description != "" {
+		return d.Description
+	}
+	switch d.Type {
+	case TypeCalculator:
+		return "Evaluates an arithmetic expression and returns the result"
+	case TypeWebFetch:
+		return "Fetches a web page and returns its content"
+	}
+	return ""
+}
+
+func truncate(body []byte) []byte {
+	if len(body) > maxResultBytes {
+		return body[:maxResultBytes]
+	}
+	return body
+}
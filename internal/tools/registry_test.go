@@ -0,0 +1,218 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		definitions   []Definition
+		expectedError string
+	}{
+		{
+			name: "valid tools",
+			definitions: []Definition{
+				{Name: "calculator", Type: TypeCalculator},
+				{Name: "fetch_page", Type: TypeWebFetch},
+				{Name: "lookup-order", Type: TypeHTTP, URL: "https://orders.internal/lookup", Method: "get"},
+			},
+		},
+		{name: "invalid name", definitions: []Definition{{Name: "look up", Type: TypeCalculator}}, expectedError: "invalid name"},
+		{name: "duplicate name", definitions: []Definition{{Name: "calc", Type: TypeCalculator}, {Name: "calc", Type: TypeCalculator}}, expectedError: "declared twice"},
+		{name: "unknown type", definitions: []Definition{{Name: "shell", Type: "exec"}}, expectedError: "invalid type"},
+		{name: "HTTP tool without URL", definitions: []Definition{{Name: "lookup", Type: TypeHTTP}}, expectedError: "needs an http or https url"},
+		{name: "HTTP tool with bad method", definitions: []Definition{{Name: "lookup", Type: TypeHTTP, URL: "https://a.example", Method: "DELETE"}}, expectedError: "invalid method"},
+		{name: "negative timeout", definitions: []Definition{{Name: "calc", Type: TypeCalculator, TimeoutSeconds: -1}}, expectedError: "negative timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.definitions)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestLoadRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"max_rounds":3,"tools":[{"name":"calculator","type":"calculator"}]}`), 0o600))
+
+	registry, err := LoadRegistry(path)
+	require.NoError(t, err)
+	assert.True(t, registry.Enabled())
+	assert.True(t, registry.Has("calculator"))
+	assert.Equal(t, 3, registry.MaxRounds())
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"tools":[{"name":"calculator","type":"abacus"}]}`), 0o600))
+	_, err = LoadRegistry(path)
+	assert.ErrorContains(t, err, "invalid type")
+
+	assert.False(t, NewRegistry(nil, 0).Enabled())
+	assert.Equal(t, DefaultMaxRounds, NewRegistry(nil, 0).MaxRounds())
+}
+
+func TestRegistryDefinitions(t *testing.T) {
+	registry := NewRegistry([]Definition{
+		{Name: "lookup_order", Type: TypeHTTP, URL: "https://orders.internal", Description: "Looks up an order",
+			Parameters: map[string]interface{}{"type": "object", "properties": map[string]interface{}{"id": map[string]interface{}{"type": "string"}}}},
+		{Name: "calculator", Type: TypeCalculator},
+	}, 0)
+
+	encoded, err := json.Marshal(registry.Definitions())
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"type":"function","function":{"name":"calculator","description":"Evaluates an arithmetic expression and returns the result",
+			"parameters":{"type":"object","properties":{"expression":{"type":"string","description":"Arithmetic expression using + - * / % ^ and parentheses, e.g. (2 + 3) * 4"}},"required":["expression"]}}},
+		{"type":"function","function":{"name":"lookup_order","description":"Looks up an order",
+			"parameters":{"type":"object","properties":{"id":{"type":"string"}}}}}
+	]`, string(encoded))
+}
+
+func TestRegistryExecute(t *testing.T) {
+	t.Setenv("ORDERS_TOKEN", "secret")
+	var lastRequest *http.Request
+	var lastBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastRequest = r
+		body, _ := io.ReadAll(r.Body)
+		lastBody = string(body)
+		if r.URL.Path == "/missing" {
+			http.Error(w, "no such order", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"shipped"}`))
+	}))
+	defer server.Close()
+
+	registry := NewRegistry([]Definition{
+		{Name: "calculator", Type: TypeCalculator},
+		{Name: "fetch", Type: TypeWebFetch},
+		{Name: "order_post", Type: TypeHTTP, URL: server.URL + "/orders", Headers: map[string]string{"Authorization": "Bearer ${ORDERS_TOKEN}"}},
+		{Name: "order_get", Type: TypeHTTP, URL: server.URL + "/orders?v=2", Method: "GET"},
+		{Name: "order_missing", Type: TypeHTTP, URL: server.URL + "/missing"},
+	}, 0)
+
+	tests := []struct {
+		name          string
+		tool          string
+		arguments     string
+		expected      string
+		expectedError string
+		check         func(t *testing.T)
+	}{
+		{name: "calculator", tool: "calculator", arguments: `{"expression":"(2 + 3) * 4"}`, expected: "20"},
+		{name: "calculator fraction", tool: "calculator", arguments: `{"expression":"1/8"}`, expected: "0.125"},
+		{
+			name: "HTTP POST", tool: "order_post", arguments: `{"id":"A-1","verbose":true}`, expected: `{"status":"shipped"}`,
+			check: func(t *testing.T) {
+				assert.Equal(t, http.MethodPost, lastRequest.Method)
+				assert.Equal(t, "Bearer secret", lastRequest.Header.Get("Authorization"))
+				assert.JSONEq(t, `{"id":"A-1","verbose":true}`, lastBody)
+			},
+		},
+		{
+			name: "HTTP GET", tool: "order_get", arguments: `{"id":"A-1","limit":2}`, expected: `{"status":"shipped"}`,
+			check: func(t *testing.T) {
+				assert.Equal(t, http.MethodGet, lastRequest.Method)
+				assert.Equal(t, "2", lastRequest.URL.Query().Get("v"))
+				assert.Equal(t, "A-1", lastRequest.URL.Query().Get("id"))
+				assert.Equal(t, "2", lastRequest.URL.Query().Get("limit"))
+			},
+		},
+		{name: "web fetch of the loopback address", tool: "fetch", arguments: `{"url":"` + server.URL + `/page"}`, expectedError: "may only reach public addresses"},
+		{name: "web fetch non-HTTP URL", tool: "fetch", arguments: `{"url":"file:///etc/passwd"}`, expectedError: "must be an http or https URL"},
+		{name: "HTTP error", tool: "order_missing", arguments: `{}`, expectedError: "status 404: no such order"},
+		{name: "invalid arguments", tool: "calculator", arguments: `{"expression":`, expectedError: "invalid arguments"},
+		{name: "unknown tool", tool: "shell", arguments: `{}`, expectedError: "unknown tool"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := registry.Execute(context.Background(), tt.tool, tt.arguments)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+			if tt.check != nil {
+				tt.check(t)
+			}
+		})
+	}
+}
+
+func TestRegistryExecute_WebFetchReachesOnlyPublicAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/to-private":
+			http.Redirect(w, r, "http://10.0.0.1/latest/meta-data", http.StatusFound)
+		case "/to-metadata-host":
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusFound)
+		default:
+			_, _ = w.Write([]byte("page"))
+		}
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	definitions := []Definition{
+		{Name: "fetch", Type: TypeWebFetch},
+		{Name: "fetch_docs", Type: TypeWebFetch, AllowedHosts: []string{"docs.example.com", "*.example.org"}},
+	}
+
+	t.Run("loopback rejected", func(t *testing.T) {
+		registry := NewRegistry(definitions, 0)
+		for _, target := range []string{server.URL, "http://localhost:" + port + "/", "http://[::1]:" + port + "/", "http://169.254.169.254/latest/meta-data"} {
+			_, err := registry.Execute(context.Background(), "fetch", `{"url":"`+target+`"}`)
+			assert.ErrorIs(t, err, errBlockedAddress, target)
+		}
+	})
+
+	// Let the test server through, as if it were a public host, to follow its redirects
+	registry := NewRegistry(definitions, 0)
+	registry.permitted = func(ip net.IP) bool { return ip.Equal(net.IPv4(127, 0, 0, 1)) }
+
+	t.Run("public page fetched", func(t *testing.T) {
+		result, err := registry.Execute(context.Background(), "fetch", `{"url":"`+server.URL+`/page"}`)
+		require.NoError(t, err)
+		assert.Equal(t, "page", result)
+	})
+
+	t.Run("redirect to a private address rejected", func(t *testing.T) {
+		for _, path := range []string{"/to-private", "/to-metadata-host"} {
+			_, err := registry.Execute(context.Background(), "fetch", `{"url":"`+server.URL+path+`"}`)
+			assert.ErrorIs(t, err, errBlockedAddress, path)
+		}
+	})
+
+	t.Run("host outside the allow-list rejected", func(t *testing.T) {
+		_, err := registry.Execute(context.Background(), "fetch_docs", `{"url":"`+server.URL+`/page"}`)
+		assert.ErrorContains(t, err, "web_fetch may not reach 127.0.0.1")
+	})
+}
+
+func TestAllowedHost(t *testing.T) {
+	hosts := []string{"docs.example.com", "*.Example.org"}
+	assert.True(t, allowedHost(hosts, "docs.example.com"))
+	assert.True(t, allowedHost(hosts, "api.example.org"))
+	assert.False(t, allowedHost(hosts, "example.org"), "a wildcard only covers subdomains")
+	assert.False(t, allowedHost(hosts, "evil-docs.example.com"))
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// maxWebFetchRedirects is how many redirects a web_fetch request follows, as net/http does
+const maxWebFetchRedirects = 10
+
+// errBlockedAddress is returned for web_fetch requests to addresses the router may not reach
+var errBlockedAddress = errors.New("web_fetch may only reach public addresses")
+
+// publicAddress reports whether ip is a public unicast address: not loopback, private,
+// link-local, unspecified or multicast
+func publicAddress(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// newWebFetchTransport returns the transport of web_fetch requests. Addresses are checked
+// with permitted once resolved, at dial time, so a host cannot resolve to a public address
+// when checked and to an internal one when connected. Proxies from the environment are not
+// used, since they would connect on the router's behalf
+func newWebFetchTransport(permitted func(net.IP) bool) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: defaultTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !permitted(ip) {
+				return fmt.Errorf("%w: %s", errBlockedAddress, host)
+			}
+			return nil
+		},
+	}
+	return &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: defaultTimeout,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+// fetchPage fetches target for a web_fetch tool, following redirects that pass the same
+// checks as target: an http or https URL on one of the tool's allowed hosts, if it lists any,
+// that is not a literal internal address
+func (r *Registry) fetchPage(ctx context.Context, tool Definition, target string) (string, error) {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("'url' must be an http or https URL")
+	}
+	if err := r.checkFetchURL(tool, parsed); err != nil {
+		return "", err
+	}
+
+	client := &http.Client{
+		Transport: r.webTransport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxWebFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxWebFetchRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to a non-http URL")
+			}
+			return r.checkFetchURL(tool, req.URL)
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(utils.HeaderUserAgent, utils.ServiceName)
+	return r.do(client, req)
+}
+
+// checkFetchURL rejects URLs whose host is not on the tool's allow-list, when it has one, or
+// is a literal address the router may not reach. Host names are checked once resolved, when dialing
+func (r *Registry) checkFetchURL(tool Definition, target *url.URL) error {
+	host := strings.ToLower(target.Hostname())
+	if len(tool.AllowedHosts) > 0 && !allowedHost(tool.AllowedHosts, host) {
+		return fmt.Errorf("web_fetch may not reach %s", host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !r.permitted(ip) {
+		return fmt.Errorf("%w: %s", errBlockedAddress, host)
+	}
+	return nil
+}
+
+// allowedHost reports whether host is one of hosts or a subdomain of a "*.example.com" entry
+func allowedHost(hosts []string, host string) bool {
+	for _, allowed := range hosts {
+		allowed = strings.ToLower(allowed)
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
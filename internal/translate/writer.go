@@ -0,0 +1,166 @@
+// Package translate serves chat completions to clients of other APIs: the Responses, Messages
+// and Gemini endpoints route a translated request like a chat completion and write the result
+// through a Writer, which hands it to their format's translators
+package translate
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Translators are the format-specific parts of a Writer
+type Translators struct {
+	// Completion translates a successful chat completion into the JSON body sent to the client
+	Completion func(body []byte) ([]byte, error)
+	// Chunk translates the data of one chat completion stream event
+	Chunk func(data []byte) error
+	// End is called once the chat completion stream is over, to end the client's stream if
+	// the chat completion left it open
+	End func()
+	// Error writes a chat completion error response in the client's format; nil passes
+	// errors through unchanged as they are written
+	Error func(w http.ResponseWriter, status int, body []byte)
+	// StreamContentType, when set, replaces the event stream content type of streams
+	StreamContentType string
+}
+
+// Writer is the http.ResponseWriter given to the chat completions handler: it translates a
+// successful chat completion once Finish is called, and a chat completion stream as the
+// chunks arrive
+type Writer struct {
+	w           http.ResponseWriter
+	translators Translators
+	status      int
+	// streaming is set once a successful event stream starts
+	streaming bool
+	// body buffers a completion or an error, or the incomplete event at the end of the stream so far
+	body bytes.Buffer
+}
+
+// NewWriter returns a writer translating a chat completion into w with translators
+func NewWriter(w http.ResponseWriter, translators Translators) *Writer {
+	return &Writer{w: w, translators: translators}
+}
+
+// Header returns the header map of the underlying writer
+func (t *Writer) Header() http.Header {
+	return t.w.Header()
+}
+
+// WriteHeader records the status of the chat completion; streams, and errors passed
+// through, are sent right away, completions and translated errors once Finish translates them
+func (t *Writer) WriteHeader(status int) {
+	if t.status != 0 {
+		return
+	}
+	t.status = status
+	if t.passesErrors() {
+		t.w.WriteHeader(status)
+		return
+	}
+	if status == http.StatusOK && strings.HasPrefix(t.w.Header().Get(utils.HeaderContentType), utils.ContentTypeEventStream) {
+		t.streaming = true
+		t.w.Header().Del(utils.HeaderContentLength)
+		if t.translators.StreamContentType != "" {
+			t.w.Header().Set(utils.HeaderContentType, t.translators.StreamContentType)
+		}
+		t.w.WriteHeader(status)
+	}
+}
+
+// Write buffers completions and errors and translates stream events
+func (t *Writer) Write(p []byte) (int, error) {
+	if t.status == 0 {
+		t.WriteHeader(http.StatusOK)
+	}
+	if t.passesErrors() {
+		return t.w.Write(p)
+	}
+	t.body.Write(p)
+	if !t.streaming {
+		return len(p), nil
+	}
+
+	// Translate every complete event; the rest waits for the next write
+	for {
+		buffered := t.body.Bytes()
+		end := bytes.Index(buffered, []byte("\n\n"))
+		if end < 0 {
+			break
+		}
+		event := make([]byte, end)
+		copy(event, buffered[:end])
+		t.body.Next(end + 2)
+		if err := t.event(event); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush flushes the underlying writer while streaming
+func (t *Writer) Flush() {
+	if !t.streaming {
+		return
+	}
+	if flusher, ok := t.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// passesErrors reports whether the chat completion failed and its error goes through unchanged
+func (t *Writer) passesErrors() bool {
+	return t.status != http.StatusOK && t.translators.Error == nil
+}
+
+// event translates the data lines of one chat completion stream event
+func (t *Writer) event(event []byte) error {
+	for _, line := range bytes.Split(event, []byte("\n")) {
+		if data, ok := bytes.CutPrefix(bytes.TrimRight(line, "\r"), []byte("data:")); ok {
+			if err := t.translators.Chunk(bytes.TrimSpace(data)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Finish writes the translated completion or error, or ends a stream the chat completion
+// left open. It returns an error, having written nothing, when a successful chat completion
+// cannot be translated
+func (t *Writer) Finish() error {
+	if t.status == 0 {
+		return fmt.Errorf("the chat completion wrote no response")
+	}
+	if t.streaming {
+		if t.body.Len() > 0 {
+			// The client is gone when writing fails; there is nobody left to report to
+			_ = t.event(t.body.Bytes())
+		}
+		t.translators.End()
+		t.Flush()
+		return nil
+	}
+	if t.status != http.StatusOK {
+		if t.translators.Error != nil {
+			t.translators.Error(t.w, t.status, t.body.Bytes())
+		}
+		return nil
+	}
+
+	body, err := t.translators.Completion(t.body.Bytes())
+	if err != nil {
+		return err
+	}
+	t.w.Header().Del(utils.HeaderContentEncoding)
+	t.w.Header().Set(utils.HeaderContentType, utils.ContentTypeJSON)
+	t.w.Header().Set(utils.HeaderContentLength, strconv.Itoa(len(body)))
+	t.w.WriteHeader(http.StatusOK)
+	_, err = t.w.Write(body)
+	return err
+}
@@ -0,0 +1,117 @@
+package translate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingTranslators upper-cases completions and keeps the stream data they are given
+func recordingTranslators(chunks *[]string, ended *bool) Translators {
+	return Translators{
+		Completion: func(body []byte) ([]byte, error) {
+			return []byte(strings.ToUpper(string(body))), nil
+		},
+		Chunk: func(data []byte) error {
+			*chunks = append(*chunks, string(data))
+			return nil
+		},
+		End: func() { *ended = true },
+	}
+}
+
+func TestWriter_Completion(t *testing.T) {
+	var chunks []string
+	var ended bool
+	rec := httptest.NewRecorder()
+	writer := NewWriter(rec, recordingTranslators(&chunks, &ended))
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Content-Encoding", "gzip")
+	_, err := writer.Write([]byte(`{"id":`))
+	require.NoError(t, err)
+	_, err = writer.Write([]byte(`"chatcmpl-1"}`))
+	require.NoError(t, err)
+	assert.Empty(t, rec.Body.String(), "completions wait for Finish")
+
+	require.NoError(t, writer.Finish())
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"ID":"CHATCMPL-1"}`, rec.Body.String())
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "19", rec.Header().Get("Content-Length"))
+	assert.Empty(t, chunks)
+	assert.False(t, ended)
+}
+
+func TestWriter_Stream(t *testing.T) {
+	var chunks []string
+	var ended bool
+	rec := httptest.NewRecorder()
+	translators := recordingTranslators(&chunks, &ended)
+	translators.StreamContentType = "application/json"
+	writer := NewWriter(rec, translators)
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Content-Length", "100")
+	writer.WriteHeader(http.StatusOK)
+	// Events split across writes are translated once complete
+	_, err := writer.Write([]byte("data: {\"n\":1}\n\nda"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"n":1}`}, chunks)
+	_, err = writer.Write([]byte("ta: {\"n\":2}\r\n\r\n: comment\n\ndata: [DONE]"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"n":1}`, `{"n":2}`}, chunks)
+
+	require.NoError(t, writer.Finish())
+	assert.Equal(t, []string{`{"n":1}`, `{"n":2}`, "[DONE]"}, chunks, "the last event is translated without its separator")
+	assert.True(t, ended)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Empty(t, rec.Header().Get("Content-Length"))
+}
+
+func TestWriter_Errors(t *testing.T) {
+	t.Run("passed through without an error translator", func(t *testing.T) {
+		var chunks []string
+		var ended bool
+		rec := httptest.NewRecorder()
+		writer := NewWriter(rec, recordingTranslators(&chunks, &ended))
+
+		writer.WriteHeader(http.StatusTooManyRequests)
+		_, err := writer.Write([]byte(`{"error":{"message":"slow down"}}`))
+		require.NoError(t, err)
+		require.NoError(t, writer.Finish())
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, `{"error":{"message":"slow down"}}`, rec.Body.String())
+	})
+
+	t.Run("translated", func(t *testing.T) {
+		var chunks []string
+		var ended bool
+		rec := httptest.NewRecorder()
+		translators := recordingTranslators(&chunks, &ended)
+		translators.Error = func(w http.ResponseWriter, status int, body []byte) {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("translated: " + string(body)))
+		}
+		writer := NewWriter(rec, translators)
+
+		writer.WriteHeader(http.StatusBadGateway)
+		_, err := writer.Write([]byte("upstream failed"))
+		require.NoError(t, err)
+		assert.Empty(t, rec.Body.String(), "errors wait for Finish")
+		require.NoError(t, writer.Finish())
+		assert.Equal(t, http.StatusBadGateway, rec.Code)
+		assert.Equal(t, "translated: upstream failed", rec.Body.String())
+	})
+
+	t.Run("no response", func(t *testing.T) {
+		var chunks []string
+		var ended bool
+		writer := NewWriter(httptest.NewRecorder(), recordingTranslators(&chunks, &ended))
+		assert.Error(t, writer.Finish())
+	})
+}
@@ -115,6 +115,38 @@ type Model struct {
 	Object  string `json:"object" example:"model"`
 	Created int64  `json:"created" example:"1677610602"`
 	OwnedBy string `json:"owned_by" example:"openai"`
+	// Models lists the models an alias routes to; omitted for configured models
+	Models []string `json:"models,omitempty"`
+}
+
+// ModelDetail is a configured model with what it supports, returned by /v1/models/{id}
+type ModelDetail struct {
+	Model
+	// Type is the API the model is routed from: chat, embedding, transcription, speech, moderation or rerank
+	Type         string            `json:"type" example:"chat"`
+	Capabilities ModelCapabilities `json:"capabilities"`
+	// ContextWindow is the model's context size in tokens, 0 when not configured
+	ContextWindow int           `json:"context_window" example:"128000"`
+	Pricing       *ModelPricing `json:"pricing,omitempty"`
+	// Vendors lists every vendor serving a model with this ID
+	Vendors []string `json:"vendors" example:"openai"`
+}
+
+// ModelCapabilities are the request features a model supports
+type ModelCapabilities struct {
+	Vision    bool `json:"vision" example:"true"`
+	Video     bool `json:"video" example:"false"`
+	Audio     bool `json:"audio" example:"false"`
+	Tools     bool `json:"tools" example:"true"`
+	Streaming bool `json:"streaming" example:"true"`
+	// Declared is false when the model has no capability config and is assumed to support everything
+	Declared bool `json:"declared" example:"true"`
+}
+
+// ModelPricing is a model's price in US dollars per million tokens
+type ModelPricing struct {
+	InputPerMillion  float64 `json:"input_per_million" example:"2.5"`
+	OutputPerMillion float64 `json:"output_per_million" example:"10"`
 }
 
 // ImageToTextRequest represents a request to describe a single image
@@ -128,3 +160,115 @@ type ImageURLWithHeaders struct {
 	URL     string            `json:"url"`
 	Headers map[string]string `json:"headers,omitempty"`
 }
+
+// EmbeddingsRequest represents a request to the embeddings API
+type EmbeddingsRequest struct {
+	// Input is a string, an array of strings, an array of token IDs or an array of token ID arrays
+	Input          interface{} `json:"input" swaggertype:"string" example:"The food was delicious and the waiter was friendly"`
+	Model          string      `json:"model" example:"text-embedding-3-small"`
+	EncodingFormat string      `json:"encoding_format,omitempty" example:"float"`
+	Dimensions     int         `json:"dimensions,omitempty" example:"256"`
+	User           string      `json:"user,omitempty" example:"user-123"`
+}
+
+// EmbeddingsResponse represents a response from the embeddings API
+type EmbeddingsResponse struct {
+	Object string          `json:"object" example:"list"`
+	Data   []Embedding     `json:"data"`
+	Model  string          `json:"model" example:"text-embedding-3-small"`
+	Usage  EmbeddingsUsage `json:"usage"`
+}
+
+// Embedding represents the embedding of one input
+type Embedding struct {
+	Object    string    `json:"object" example:"embedding"`
+	Index     int       `json:"index" example:"0"`
+	Embedding []float64 `json:"embedding"`
+}
+
+// EmbeddingsUsage represents token usage of an embeddings request
+type EmbeddingsUsage struct {
+	PromptTokens int `json:"prompt_tokens" example:"8"`
+	TotalTokens  int `json:"total_tokens" example:"8"`
+}
+
+// TranscriptionResponse represents a json response from the audio transcriptions API
+type TranscriptionResponse struct {
+	Text  string              `json:"text" example:"The quick brown fox jumped over the lazy dog."`
+	Usage *TranscriptionUsage `json:"usage,omitempty"`
+}
+
+// SpeechRequest represents a request to the text-to-speech API
+type SpeechRequest struct {
+	Model          string  `json:"model" example:"tts-1"`
+	Input          string  `json:"input" example:"The quick brown fox jumped over the lazy dog."`
+	Voice          string  `json:"voice,omitempty" example:"alloy"`
+	Instructions   string  `json:"instructions,omitempty" example:"Speak in a cheerful tone."`
+	ResponseFormat string  `json:"response_format,omitempty" example:"mp3" enums:"mp3,opus,aac,flac,wav,pcm"`
+	Speed          float64 `json:"speed,omitempty" example:"1.0"`
+	StreamFormat   string  `json:"stream_format,omitempty" example:"audio" enums:"audio,sse"`
+}
+
+// TranscriptionUsage represents usage of a transcription request, billed in tokens or seconds of audio
+type TranscriptionUsage struct {
+	Type         string  `json:"type" example:"tokens"`
+	InputTokens  int     `json:"input_tokens,omitempty" example:"14"`
+	OutputTokens int     `json:"output_tokens,omitempty" example:"45"`
+	Seconds      float64 `json:"seconds,omitempty" example:"12.5"`
+}
+
+// ModerationRequest represents a request to the moderations API
+type ModerationRequest struct {
+	Model string      `json:"model,omitempty" example:"omni-moderation-latest"`
+	Input interface{} `json:"input" swaggertype:"string" example:"I want to hurt them."`
+}
+
+// ModerationResponse represents a response from the moderations API
+type ModerationResponse struct {
+	ID      string             `json:"id" example:"modr-abc123"`
+	Model   string             `json:"model" example:"omni-moderation-latest"`
+	Results []ModerationResult `json:"results"`
+}
+
+// ModerationResult represents the moderation verdict for one input
+type ModerationResult struct {
+	Flagged        bool               `json:"flagged" example:"true"`
+	Categories     map[string]bool    `json:"categories"`
+	CategoryScores map[string]float64 `json:"category_scores"`
+}
+
+// RerankRequest represents a request to the rerank API
+type RerankRequest struct {
+	Model           string        `json:"model,omitempty" example:"rerank-v3.5"`
+	Query           string        `json:"query" example:"What is the capital of France?"`
+	Documents       []interface{} `json:"documents" swaggertype:"array,string" example:"Paris is the capital of France.,Berlin is the capital of Germany."`
+	TopN            int           `json:"top_n,omitempty" example:"3"`
+	ReturnDocuments bool          `json:"return_documents,omitempty" example:"true"`
+}
+
+// RerankResponse represents a response from the rerank API
+type RerankResponse struct {
+	ID      string         `json:"id" example:"rerank-abc123"`
+	Object  string         `json:"object" example:"list"`
+	Model   string         `json:"model" example:"rerank-v3.5"`
+	Results []RerankResult `json:"results"`
+	Usage   *RerankUsage   `json:"usage,omitempty"`
+}
+
+// RerankResult represents the relevance of one document to the query
+type RerankResult struct {
+	Index          int             `json:"index" example:"0"`
+	RelevanceScore float64         `json:"relevance_score" example:"0.98"`
+	Document       *RerankDocument `json:"document,omitempty"`
+}
+
+// RerankDocument represents a ranked document returned with return_documents
+type RerankDocument struct {
+	Text string `json:"text" example:"Paris is the capital of France."`
+}
+
+// RerankUsage represents the usage reported by the vendor: tokens or Cohere search units
+type RerankUsage struct {
+	TotalTokens int `json:"total_tokens,omitempty" example:"42"`
+	SearchUnits int `json:"search_units,omitempty" example:"1"`
+}
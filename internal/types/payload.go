@@ -8,5 +8,13 @@ type PayloadContext struct {
 	HasTools      bool
 	HasImages     bool
 	HasVideos     bool
+	HasAudio      bool
 	MessagesCount int
+	// PromptTokens is an approximate count of the request's prompt tokens
+	PromptTokens int
+	// MaxCompletionTokens is the completion limit the request sets, 0 when it sets none
+	MaxCompletionTokens int
+	// ConversationKey identifies the conversation the request belongs to for sticky routing,
+	// "" when it names none
+	ConversationKey string
 }
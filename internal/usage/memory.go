@@ -0,0 +1,107 @@
+package usage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Memory is a Store kept in process memory; its totals are per replica and lost on restart
+type Memory struct {
+	mu        sync.Mutex
+	retention time.Duration
+	totals    map[totalsKey]*Totals
+	now       func() time.Time
+}
+
+// totalsKey identifies the totals of a client key on a vendor model during a day
+type totalsKey struct {
+	day       time.Time
+	clientKey string
+	vendor    string
+	model     string
+}
+
+// NewMemory creates an empty store keeping totals for retention; a non-positive retention
+// keeps them for DefaultRetentionDays
+func NewMemory(retention time.Duration) *Memory {
+	if retention <= 0 {
+		retention = DefaultRetentionDays * 24 * time.Hour
+	}
+	return &Memory{retention: retention, totals: make(map[totalsKey]*Totals), now: time.Now}
+}
+
+// Add adds record to the totals of its day
+func (m *Memory) Add(_ context.Context, record Record) error {
+	if record.Time.IsZero() {
+		record.Time = m.now()
+	}
+	key := totalsKey{day: dayOf(record.Time), clientKey: record.ClientKey, vendor: record.Vendor, model: record.Model}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	totals, ok := m.totals[key]
+	if !ok {
+		m.prune()
+		totals = &Totals{Day: key.day, ClientKey: key.clientKey, Vendor: key.vendor, Model: key.model}
+		m.totals[key] = totals
+	}
+	totals.Requests++
+	totals.PromptTokens += int64(record.PromptTokens)
+	totals.CompletionTokens += int64(record.CompletionTokens)
+	totals.TotalTokens += int64(record.PromptTokens + record.CompletionTokens)
+	if record.Estimated {
+		totals.EstimatedRequests++
+	}
+	return nil
+}
+
+// Totals returns the daily totals matching filter, oldest day first
+func (m *Memory) Totals(_ context.Context, filter Filter) ([]Totals, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	days := filter.days(m.now(), m.retention)
+	result := []Totals{}
+	if len(days) == 0 {
+		return result, nil
+	}
+	first, last := days[0], days[len(days)-1]
+	for key, totals := range m.totals {
+		if key.day.Before(first) || key.day.After(last) || !filter.matches(key.clientKey, key.vendor, key.model) {
+			continue
+		}
+		result = append(result, *totals)
+	}
+	sortTotals(result)
+	return result, nil
+}
+
+// prune forgets totals of days past the retention period
+func (m *Memory) prune() {
+	cutoff := dayOf(m.now().Add(-m.retention))
+	for key := range m.totals {
+		if key.day.Before(cutoff) {
+			delete(m.totals, key)
+		}
+	}
+}
+
+// sortTotals orders totals by day, then client key, vendor and model
+func sortTotals(totals []Totals) {
+	sort.Slice(totals, func(i, j int) bool {
+		a, b := totals[i], totals[j]
+		if !a.Day.Equal(b.Day) {
+			return a.Day.Before(b.Day)
+		}
+		if a.ClientKey != b.ClientKey {
+			return a.ClientKey < b.ClientKey
+		}
+		if a.Vendor != b.Vendor {
+			return a.Vendor < b.Vendor
+		}
+		return a.Model < b.Model
+	})
+}
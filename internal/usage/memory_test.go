@@ -0,0 +1,80 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_AddAccumulatesDailyTotals(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	store := NewMemory(30 * 24 * time.Hour)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, store.Add(ctx, Record{ClientKey: "k1", Vendor: "openai", Model: "gpt-4o", PromptTokens: 10, CompletionTokens: 5}))
+	require.NoError(t, store.Add(ctx, Record{ClientKey: "k1", Vendor: "openai", Model: "gpt-4o", PromptTokens: 20, CompletionTokens: 1, Estimated: true, Time: now.Add(-time.Hour)}))
+	require.NoError(t, store.Add(ctx, Record{ClientKey: "k1", Vendor: "openai", Model: "gpt-4o", PromptTokens: 7, Time: now.Add(-24 * time.Hour)}))
+	require.NoError(t, store.Add(ctx, Record{ClientKey: "k2", Vendor: "gemini", Model: "gemini-2.0-flash", PromptTokens: 3, CompletionTokens: 4}))
+
+	totals, err := store.Totals(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, totals, 3)
+
+	assert.Equal(t, time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), totals[0].Day)
+	assert.Equal(t, int64(7), totals[0].PromptTokens)
+
+	assert.Equal(t, Totals{
+		Day: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), ClientKey: "k1", Vendor: "openai", Model: "gpt-4o",
+		Requests: 2, PromptTokens: 30, CompletionTokens: 6, TotalTokens: 36, EstimatedRequests: 1,
+	}, totals[1])
+	assert.Equal(t, "k2", totals[2].ClientKey)
+}
+
+func TestMemory_TotalsFilter(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	store := NewMemory(30 * 24 * time.Hour)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, store.Add(ctx, Record{ClientKey: "k1", Vendor: "openai", Model: "gpt-4o", PromptTokens: 1}))
+	require.NoError(t, store.Add(ctx, Record{ClientKey: "k1", Vendor: "openai", Model: "gpt-4o", PromptTokens: 2, Time: now.Add(-48 * time.Hour)}))
+	require.NoError(t, store.Add(ctx, Record{ClientKey: "k2", Vendor: "openai", Model: "gpt-4o-mini", PromptTokens: 3}))
+
+	totals, err := store.Totals(ctx, Filter{ClientKey: "k1"})
+	require.NoError(t, err)
+	assert.Len(t, totals, 2)
+
+	totals, err = store.Totals(ctx, Filter{Model: "gpt-4o-mini"})
+	require.NoError(t, err)
+	require.Len(t, totals, 1)
+	assert.Equal(t, "k2", totals[0].ClientKey)
+
+	totals, err = store.Totals(ctx, Filter{From: now.Add(-24 * time.Hour)})
+	require.NoError(t, err)
+	assert.Len(t, totals, 2, "days before From are left out")
+
+	totals, err = store.Totals(ctx, Filter{To: now.Add(-24 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, totals, 1, "days after To are left out")
+	assert.Equal(t, int64(2), totals[0].PromptTokens)
+}
+
+func TestMemory_DropsTotalsPastRetention(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	store := NewMemory(2 * 24 * time.Hour)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, store.Add(ctx, Record{Vendor: "openai", Model: "gpt-4o", PromptTokens: 1, Time: now.Add(-5 * 24 * time.Hour)}))
+	require.NoError(t, store.Add(ctx, Record{Vendor: "openai", Model: "gpt-4o", PromptTokens: 2}))
+
+	totals, err := store.Totals(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, totals, 1)
+	assert.Equal(t, int64(2), totals[0].PromptTokens)
+	assert.Len(t, store.totals, 1, "the expired day is pruned when a new one starts")
+}
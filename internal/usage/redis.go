@@ -0,0 +1,139 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// memberSeparator joins the client key, vendor and model of a day's totals into one set
+// member; it cannot appear in any of them
+const memberSeparator = "\x00"
+
+// RedisClient sends raw commands to a Redis server; *shared.Redis satisfies it
+type RedisClient interface {
+	// Key returns key with the configured key prefix
+	Key(key string) string
+	// Do sends commands in one round trip and returns their replies
+	Do(ctx context.Context, commands ...[]string) ([]any, error)
+}
+
+// Redis is a Store kept in a Redis server, so replicas account into the same totals. Each
+// day has a set of the client key, vendor and model combinations seen that day, and a hash
+// of counters per combination; both expire once the retention period has passed
+type Redis struct {
+	client    RedisClient
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewRedis creates a store in the server client speaks to, keeping totals for retention; a
+// non-positive retention keeps them for DefaultRetentionDays
+func NewRedis(client RedisClient, retention time.Duration) *Redis {
+	if retention <= 0 {
+		retention = DefaultRetentionDays * 24 * time.Hour
+	}
+	return &Redis{client: client, retention: retention, now: time.Now}
+}
+
+// Add adds record to the totals of its day
+func (r *Redis) Add(ctx context.Context, record Record) error {
+	if record.Time.IsZero() {
+		record.Time = r.now()
+	}
+	day := dayOf(record.Time)
+	ttl := day.Add(r.retention + 24*time.Hour).Sub(r.now())
+	if ttl <= 0 {
+		return nil
+	}
+	expiry := strconv.FormatInt(ttl.Milliseconds(), 10)
+
+	dayKey := r.dayKey(day)
+	member := strings.Join([]string{record.ClientKey, record.Vendor, record.Model}, memberSeparator)
+	totalsKey := dayKey + ":" + member
+	commands := [][]string{
+		{"SADD", dayKey, member},
+		{"PEXPIRE", dayKey, expiry},
+		{"HINCRBY", totalsKey, "requests", "1"},
+		{"HINCRBY", totalsKey, "prompt_tokens", strconv.Itoa(record.PromptTokens)},
+		{"HINCRBY", totalsKey, "completion_tokens", strconv.Itoa(record.CompletionTokens)},
+	}
+	if record.Estimated {
+		commands = append(commands, []string{"HINCRBY", totalsKey, "estimated_requests", "1"})
+	}
+	commands = append(commands, []string{"PEXPIRE", totalsKey, expiry})
+	_, err := r.client.Do(ctx, commands...)
+	return err
+}
+
+// Totals returns the daily totals matching filter, oldest day first, reading the members of
+// every day in one round trip and their counters in another
+func (r *Redis) Totals(ctx context.Context, filter Filter) ([]Totals, error) {
+	result := []Totals{}
+	days := filter.days(r.now(), r.retention)
+	if len(days) == 0 {
+		return result, nil
+	}
+
+	commands := make([][]string, len(days))
+	for i, day := range days {
+		commands[i] = []string{"SMEMBERS", r.dayKey(day)}
+	}
+	replies, err := r.client.Do(ctx, commands...)
+	if err != nil {
+		return nil, err
+	}
+
+	commands = commands[:0]
+	for i, reply := range replies {
+		members, _ := reply.([]any)
+		for _, item := range members {
+			member, _ := item.(string)
+			parts := strings.Split(member, memberSeparator)
+			if len(parts) != 3 || !filter.matches(parts[0], parts[1], parts[2]) {
+				continue
+			}
+			result = append(result, Totals{Day: days[i], ClientKey: parts[0], Vendor: parts[1], Model: parts[2]})
+			commands = append(commands, []string{"HGETALL", r.dayKey(days[i]) + ":" + member})
+		}
+	}
+	if len(commands) == 0 {
+		return result, nil
+	}
+
+	replies, err = r.client.Do(ctx, commands...)
+	if err != nil {
+		return nil, err
+	}
+	for i, reply := range replies {
+		fields, _ := reply.([]any)
+		for j := 0; j+1 < len(fields); j += 2 {
+			name, _ := fields[j].(string)
+			value, _ := fields[j+1].(string)
+			count, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("usage: unexpected %s counter %q", name, value)
+			}
+			switch name {
+			case "requests":
+				result[i].Requests = count
+			case "prompt_tokens":
+				result[i].PromptTokens = count
+			case "completion_tokens":
+				result[i].CompletionTokens = count
+			case "estimated_requests":
+				result[i].EstimatedRequests = count
+			}
+		}
+		result[i].TotalTokens = result[i].PromptTokens + result[i].CompletionTokens
+	}
+	sortTotals(result)
+	return result, nil
+}
+
+// dayKey is the key of the set of combinations seen on day
+func (r *Redis) dayKey(day time.Time) string {
+	return r.client.Key("usage:" + day.Format(time.DateOnly))
+}
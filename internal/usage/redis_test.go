@@ -0,0 +1,124 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedisClient serves the set and hash commands of the Redis store from memory
+type fakeRedisClient struct {
+	mu      sync.Mutex
+	sets    map[string]map[string]bool
+	hashes  map[string]map[string]int64
+	expires map[string]string
+}
+
+func newFakeRedisClient() *fakeRedisClient {
+	return &fakeRedisClient{
+		sets:    make(map[string]map[string]bool),
+		hashes:  make(map[string]map[string]int64),
+		expires: make(map[string]string),
+	}
+}
+
+func (f *fakeRedisClient) Key(key string) string {
+	return "router:" + key
+}
+
+func (f *fakeRedisClient) Do(_ context.Context, commands ...[]string) ([]any, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	replies := make([]any, len(commands))
+	for i, command := range commands {
+		switch command[0] {
+		case "SADD":
+			if f.sets[command[1]] == nil {
+				f.sets[command[1]] = make(map[string]bool)
+			}
+			f.sets[command[1]][command[2]] = true
+			replies[i] = int64(1)
+		case "HINCRBY":
+			if f.hashes[command[1]] == nil {
+				f.hashes[command[1]] = make(map[string]int64)
+			}
+			n, _ := strconv.ParseInt(command[3], 10, 64)
+			f.hashes[command[1]][command[2]] += n
+			replies[i] = f.hashes[command[1]][command[2]]
+		case "PEXPIRE":
+			f.expires[command[1]] = command[2]
+			replies[i] = int64(1)
+		case "SMEMBERS":
+			var members []string
+			for member := range f.sets[command[1]] {
+				members = append(members, member)
+			}
+			sort.Strings(members)
+			items := []any{}
+			for _, member := range members {
+				items = append(items, member)
+			}
+			replies[i] = items
+		case "HGETALL":
+			items := []any{}
+			for field, value := range f.hashes[command[1]] {
+				items = append(items, field, strconv.FormatInt(value, 10))
+			}
+			replies[i] = items
+		default:
+			return nil, fmt.Errorf("unexpected command %v", command)
+		}
+	}
+	return replies, nil
+}
+
+func TestRedis_AddAndTotals(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	client := newFakeRedisClient()
+	store := NewRedis(client, 30*24*time.Hour)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, store.Add(ctx, Record{ClientKey: "k1", Vendor: "openai", Model: "gpt-4o", PromptTokens: 10, CompletionTokens: 5}))
+	require.NoError(t, store.Add(ctx, Record{ClientKey: "k1", Vendor: "openai", Model: "gpt-4o", PromptTokens: 20, CompletionTokens: 1, Estimated: true}))
+	require.NoError(t, store.Add(ctx, Record{ClientKey: "k2", Vendor: "gemini", Model: "gemini-2.0-flash", PromptTokens: 3, Time: now.Add(-24 * time.Hour)}))
+
+	assert.Contains(t, client.sets, "router:usage:2026-10-17")
+	assert.Equal(t, strconv.FormatInt((30*24*time.Hour+9*time.Hour).Milliseconds(), 10), client.expires["router:usage:2026-10-17"],
+		"a day's totals expire once the retention period has passed its end")
+
+	totals, err := store.Totals(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, totals, 2)
+	assert.Equal(t, Totals{
+		Day: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), ClientKey: "k2", Vendor: "gemini", Model: "gemini-2.0-flash",
+		Requests: 1, PromptTokens: 3, TotalTokens: 3,
+	}, totals[0])
+	assert.Equal(t, Totals{
+		Day: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), ClientKey: "k1", Vendor: "openai", Model: "gpt-4o",
+		Requests: 2, PromptTokens: 30, CompletionTokens: 6, TotalTokens: 36, EstimatedRequests: 1,
+	}, totals[1])
+
+	totals, err = store.Totals(ctx, Filter{Vendor: "openai"})
+	require.NoError(t, err)
+	require.Len(t, totals, 1)
+	assert.Equal(t, "k1", totals[0].ClientKey)
+}
+
+func TestRedis_SkipsRecordsPastRetention(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	client := newFakeRedisClient()
+	store := NewRedis(client, 24*time.Hour)
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Add(context.Background(), Record{Vendor: "openai", Model: "gpt-4o", PromptTokens: 1, Time: now.Add(-3 * 24 * time.Hour)}))
+	assert.Empty(t, client.sets)
+}
@@ -0,0 +1,172 @@
+// Package usage accounts the tokens every request used, per client key, vendor and model
+// and UTC day, in a store kept in memory or shared by replicas through Redis. Quotas,
+// billing and the usage API read their figures from it
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/shared"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// Default accounting settings, overridable with USAGE_RETENTION_DAYS
+const (
+	DefaultRetentionDays = 90
+	// accountTimeout bounds how long a record may take to reach the store
+	accountTimeout = 5 * time.Second
+)
+
+// Record is the usage of one request
+type Record struct {
+	// ClientKey identifies the client's bearer token by its SHA-256 hex digest; empty for
+	// requests without one
+	ClientKey        string
+	Vendor           string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	// Estimated is set when the router counted the tokens because the vendor reported none,
+	// as for streams cut short
+	Estimated bool
+	// Time is when the request completed; zero means now
+	Time time.Time
+}
+
+// Totals is the usage of a client key on a vendor model during a UTC day
+type Totals struct {
+	Day              time.Time `json:"day"`
+	ClientKey        string    `json:"client_key,omitempty"`
+	Vendor           string    `json:"vendor"`
+	Model            string    `json:"model"`
+	Requests         int64     `json:"requests"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	// EstimatedRequests counts the requests whose tokens were counted by the router rather
+	// than reported by the vendor
+	EstimatedRequests int64 `json:"estimated_requests"`
+}
+
+// Filter selects totals; empty fields match everything. From and To are the first and last
+// days included, defaulting to the start of the retention period and today
+type Filter struct {
+	ClientKey string
+	Vendor    string
+	Model     string
+	From      time.Time
+	To        time.Time
+}
+
+// Store accumulates usage records into daily totals. Every method is safe for concurrent use
+type Store interface {
+	// Add adds record to the totals of its day
+	Add(ctx context.Context, record Record) error
+	// Totals returns the daily totals matching filter, oldest day first
+	Totals(ctx context.Context, filter Filter) ([]Totals, error)
+}
+
+var (
+	defaultStore   Store
+	defaultStoreMu sync.RWMutex
+)
+
+// NewStoreFromEnv creates the store selected by USAGE_STORE: memory (the default) or redis,
+// which shares totals between replicas through the server at REDIS_URL. Totals are kept
+// for USAGE_RETENTION_DAYS days
+func NewStoreFromEnv() (Store, error) {
+	retention := time.Duration(utils.GetEnvInt("USAGE_RETENTION_DAYS", DefaultRetentionDays)) * 24 * time.Hour
+
+	switch kind := utils.GetEnvString("USAGE_STORE", "memory"); strings.ToLower(kind) {
+	case "memory":
+		return NewMemory(retention), nil
+	case "redis":
+		store, err := shared.NewFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		if store == nil {
+			return nil, fmt.Errorf("USAGE_STORE=redis requires REDIS_URL")
+		}
+		return NewRedis(store.(*shared.Redis), retention), nil
+	default:
+		return nil, fmt.Errorf("unknown USAGE_STORE %q: must be memory or redis", kind)
+	}
+}
+
+// Default returns the process-wide store, nil until SetDefault is called
+func Default() Store {
+	defaultStoreMu.RLock()
+	defer defaultStoreMu.RUnlock()
+	return defaultStore
+}
+
+// SetDefault replaces the process-wide store
+func SetDefault(store Store) {
+	defaultStoreMu.Lock()
+	defer defaultStoreMu.Unlock()
+	defaultStore = store
+}
+
+// Account adds record to the process-wide store in the background, so a slow store does not
+// hold up the response; failures are logged and the record is lost
+func Account(record Record) {
+	store := Default()
+	if store == nil {
+		return
+	}
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), accountTimeout)
+		defer cancel()
+		if err := store.Add(ctx, record); err != nil {
+			logger.Warn(ctx, "Failed to account token usage",
+				"vendor", record.Vendor,
+				"model", record.Model,
+				"prompt_tokens", record.PromptTokens,
+				"completion_tokens", record.CompletionTokens,
+				"error", err.Error(),
+				"component", "Usage",
+				"stage", "Account",
+			)
+		}
+	}()
+}
+
+// dayOf returns the start of the UTC day of t
+func dayOf(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// days returns the days filter covers, oldest first, within the retention period ending today
+func (f Filter) days(now time.Time, retention time.Duration) []time.Time {
+	today := dayOf(now)
+	first := dayOf(now.Add(-retention))
+	if !f.From.IsZero() && dayOf(f.From).After(first) {
+		first = dayOf(f.From)
+	}
+	last := today
+	if !f.To.IsZero() && dayOf(f.To).Before(last) {
+		last = dayOf(f.To)
+	}
+
+	var days []time.Time
+	for day := first; !day.After(last); day = day.Add(24 * time.Hour) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// matches reports whether totals of clientKey on vendor and model are selected by f
+func (f Filter) matches(clientKey, vendor, model string) bool {
+	return (f.ClientKey == "" || f.ClientKey == clientKey) &&
+		(f.Vendor == "" || f.Vendor == vendor) &&
+		(f.Model == "" || f.Model == model)
+}
@@ -17,6 +17,10 @@ const (
 	HeaderCorrelationID = "X-Correlation-ID"
 	HeaderResponseTime  = "X-Response-Time"
 
+	// Timing Headers
+	HeaderServerTiming      = "Server-Timing"
+	HeaderTimingAllowOrigin = "Timing-Allow-Origin"
+
 	// Client IP Headers (priority order)
 	HeaderXForwardedFor  = "X-Forwarded-For"
 	HeaderXRealIP        = "X-Real-IP"
@@ -31,12 +35,20 @@ const (
 	HeaderXCSRFToken          = "X-CSRF-Token"
 
 	// Service Headers
-	HeaderXPoweredBy      = "X-Powered-By"
-	HeaderXVendorSource   = "X-Vendor-Source"
-	HeaderXAccelBuffering = "X-Accel-Buffering"
+	HeaderXPoweredBy            = "X-Powered-By"
+	HeaderXVendorSource         = "X-Vendor-Source"
+	HeaderXAccelBuffering       = "X-Accel-Buffering"
+	HeaderXRouterCache          = "X-Router-Cache"
+	HeaderXRouterFailedAttempts = "X-Router-Failed-Attempts"
+	HeaderXRouterCost           = "X-Router-Cost"
+	HeaderXRouterVendor         = "X-Router-Vendor"
+	HeaderXRouterModel          = "X-Router-Model"
+	HeaderXRouterAttempts       = "X-Router-Attempts"
+	HeaderXRouterQueueWait      = "X-Router-Queue-Wait"
 
 	// Transfer Headers
 	HeaderTransferEncoding = "Transfer-Encoding"
+	HeaderTrailer          = "Trailer"
 	HeaderVary             = "Vary"
 
 	// CORS Headers
@@ -47,6 +59,8 @@ const (
 
 	// Authorization Headers
 	HeaderAuthorization = "Authorization"
+	HeaderXAPIKey       = "X-Api-Key"
+	HeaderXGoogAPIKey   = "X-Goog-Api-Key"
 )
 
 // Content Type Constants
@@ -80,8 +94,8 @@ const (
 // CORS Values
 const (
 	CORSAllowOriginAll   = "*"
-	CORSAllowMethodsAll  = "POST, GET, OPTIONS, PUT, DELETE"
-	CORSAllowHeadersStd  = "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization"
+	CORSAllowMethodsAll  = "POST, GET, HEAD, OPTIONS, PUT, DELETE"
+	CORSAllowHeadersStd  = "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Api-Key, Anthropic-Version, Anthropic-Beta, X-Goog-Api-Key, X-Goog-Api-Client"
 	CORSExposeHeadersStd = "X-Request-ID, X-Response-Time"
 )
 
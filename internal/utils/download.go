@@ -0,0 +1,318 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Download defaults, used when the DOWNLOAD_* environment variables are unset
+const (
+	DefaultDownloadRetries         = 3
+	DefaultDownloadRetryDelay      = 500 * time.Millisecond
+	DefaultDownloadSegments        = 4
+	DefaultDownloadSegmentMinBytes = 8 << 20
+)
+
+// errRangeNotHonored is returned when a host answers a range request with something other
+// than the requested range
+var errRangeNotHonored = errors.New("host did not honor the byte range")
+
+// Downloader fetches remote media. A download whose connection drops part way through is
+// resumed with an HTTP Range request instead of starting over, and large files on hosts that
+// accept byte ranges are fetched as segments in parallel
+type Downloader struct {
+	client *http.Client
+	// retries is how many times a dropped download, or each segment, is resumed
+	retries    int
+	retryDelay time.Duration
+	// segments is how many ranges are fetched in parallel; 1 disables segmented downloads
+	segments int
+	// segmentMinBytes is the smallest file fetched in segments
+	segmentMinBytes int64
+}
+
+// NewDownloader creates a downloader whose requests each time out after timeout
+func NewDownloader(timeout time.Duration, retries int, retryDelay time.Duration, segments int, segmentMinBytes int64) *Downloader {
+	return &Downloader{
+		client:          &http.Client{Timeout: timeout},
+		retries:         max(retries, 0),
+		retryDelay:      retryDelay,
+		segments:        max(segments, 1),
+		segmentMinBytes: segmentMinBytes,
+	}
+}
+
+// NewDownloaderFromEnv creates a downloader configured by DOWNLOAD_RETRIES,
+// DOWNLOAD_SEGMENTS and DOWNLOAD_SEGMENT_MIN_BYTES
+func NewDownloaderFromEnv(timeout time.Duration) *Downloader {
+	return NewDownloader(
+		timeout,
+		GetEnvInt("DOWNLOAD_RETRIES", DefaultDownloadRetries),
+		DefaultDownloadRetryDelay,
+		GetEnvInt("DOWNLOAD_SEGMENTS", DefaultDownloadSegments),
+		int64(GetEnvInt("DOWNLOAD_SEGMENT_MIN_BYTES", DefaultDownloadSegmentMinBytes)),
+	)
+}
+
+// Download downloads a file with optional headers, rejecting files of maxSize bytes or more
+// A Content-Length over the limit is rejected before the body is read
+func (d *Downloader) Download(ctx context.Context, url string, headers map[string]string, maxSize int64) ([]byte, string, error) {
+	req, err := d.newRequest(ctx, url, headers)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download file: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, "", fmt.Errorf("failed to download file: status %d", resp.StatusCode)
+	}
+	if resp.ContentLength >= maxSize {
+		resp.Body.Close()
+		return nil, "", sizeLimitError(maxSize)
+	}
+	contentType := resp.Header.Get(HeaderContentType)
+
+	// Ranges only line up with the body when the transport did not decompress it
+	source := rangeSource{
+		url:       url,
+		headers:   headers,
+		validator: validator(resp.Header),
+		rangeable: resp.Header.Get("Accept-Ranges") == "bytes" && !resp.Uncompressed,
+	}
+	var data []byte
+	if source.rangeable && d.segments > 1 && resp.ContentLength > 0 && resp.ContentLength >= d.segmentMinBytes {
+		data, err = d.downloadSegments(ctx, source, resp.Body, resp.ContentLength)
+	} else {
+		data, err = d.downloadResuming(ctx, source, resp.Body, maxSize)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return data, contentType, nil
+}
+
+// rangeSource is a file being downloaded, with what is needed to request parts of it again
+type rangeSource struct {
+	url     string
+	headers map[string]string
+	// validator is the ETag or Last-Modified sent in If-Range, so a file that changed
+	// between requests is not stitched together from two versions
+	validator string
+	rangeable bool
+}
+
+// downloadResuming reads body, resuming it from where it dropped while retries remain; if the
+// file changed in the meantime the host sends it whole and the download starts over
+func (d *Downloader) downloadResuming(ctx context.Context, source rangeSource, body io.ReadCloser, maxSize int64) ([]byte, error) {
+	var data bytes.Buffer
+	for attempts := 0; ; attempts++ {
+		if body == nil {
+			if err := d.wait(ctx); err != nil {
+				return nil, fmt.Errorf("failed to read file data: %w", err)
+			}
+			resp, err := d.requestRange(ctx, source, int64(data.Len()), -1)
+			if err != nil {
+				if attempts >= d.retries || ctx.Err() != nil {
+					return nil, fmt.Errorf("failed to read file data: %w", err)
+				}
+				continue
+			}
+			if resp.StatusCode == http.StatusOK {
+				data.Reset()
+			}
+			body = resp.Body
+		}
+
+		_, err := io.Copy(&data, io.LimitReader(body, maxSize-int64(data.Len())))
+		body.Close()
+		body = nil
+		if int64(data.Len()) >= maxSize {
+			return nil, sizeLimitError(maxSize)
+		}
+		if err == nil {
+			return data.Bytes(), nil
+		}
+		if !source.rangeable || attempts >= d.retries || ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to read file data: %w", err)
+		}
+	}
+}
+
+// downloadSegments splits a file of size bytes into ranges fetched in parallel; the first
+// range is read from the response already open
+func (d *Downloader) downloadSegments(ctx context.Context, source rangeSource, body io.ReadCloser, size int64) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	data := make([]byte, size)
+	segmentSize := (size + int64(d.segments) - 1) / int64(d.segments)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for start := int64(0); start < size; start += segmentSize {
+		end := min(start+segmentSize, size)
+		var segmentBody io.ReadCloser
+		if start == 0 {
+			segmentBody = body
+		}
+		wg.Add(1)
+		go func(start int64, segment []byte, body io.ReadCloser) {
+			defer wg.Done()
+			if err := d.downloadSegment(ctx, source, start, segment, body); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(start, data[start:end], segmentBody)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, fmt.Errorf("failed to read file data: %w", firstErr)
+	}
+	return data, nil
+}
+
+// downloadSegment fills segment with the bytes of the file starting at offset, reading body
+// first when given, and resuming the range from where it dropped while retries remain
+func (d *Downloader) downloadSegment(ctx context.Context, source rangeSource, offset int64, segment []byte, body io.ReadCloser) error {
+	filled := 0
+	for attempts := 0; ; attempts++ {
+		if body == nil {
+			if attempts > 0 {
+				if err := d.wait(ctx); err != nil {
+					return err
+				}
+			}
+			resp, err := d.requestRange(ctx, source, offset+int64(filled), offset+int64(len(segment))-1)
+			if err != nil {
+				if attempts >= d.retries || ctx.Err() != nil {
+					return err
+				}
+				continue
+			}
+			if resp.StatusCode != http.StatusPartialContent {
+				// The file changed, so the segments already fetched are of another version
+				resp.Body.Close()
+				return errRangeNotHonored
+			}
+			body = resp.Body
+		}
+
+		n, err := io.ReadFull(body, segment[filled:])
+		body.Close()
+		body = nil
+		filled += n
+		if err == nil {
+			return nil
+		}
+		if attempts >= d.retries || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// requestRange requests the bytes of a file from start to end, or to its end when end is
+// negative. The response is a 206 for that range, or a 200 with the whole file when it
+// changed since the first request
+func (d *Downloader) requestRange(ctx context.Context, source rangeSource, start, end int64) (*http.Response, error) {
+	req, err := d.newRequest(ctx, source.url, source.headers)
+	if err != nil {
+		return nil, err
+	}
+	byteRange := fmt.Sprintf("bytes=%d-", start)
+	if end >= 0 {
+		byteRange += strconv.FormatInt(end, 10)
+	}
+	req.Header.Set("Range", byteRange)
+	req.Header.Set(HeaderAcceptEncoding, "identity")
+	if source.validator != "" {
+		req.Header.Set("If-Range", source.validator)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusPartialContent:
+		if rangeStart(resp.Header.Get("Content-Range")) == start {
+			return resp, nil
+		}
+		resp.Body.Close()
+		return nil, errRangeNotHonored
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to resume download: status %d", resp.StatusCode)
+	}
+}
+
+// newRequest builds a GET request for url with the service User-Agent and custom headers
+func (d *Downloader) newRequest(ctx context.Context, url string, headers map[string]string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	// Set user agent to avoid blocks
+	req.Header.Set(HeaderUserAgent, ServiceName)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+// wait pauses before a retry, returning early when ctx is done
+func (d *Downloader) wait(ctx context.Context) error {
+	timer := time.NewTimer(d.retryDelay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// validator returns the strong ETag, or else the Last-Modified date, identifying the version
+// of a file; weak ETags cannot be used with If-Range
+func validator(header http.Header) string {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
+
+// rangeStart returns the first byte of a "bytes start-end/size" Content-Range, or -1
+func rangeStart(contentRange string) int64 {
+	spec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return -1
+	}
+	first, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return -1
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return start
+}
+
+// sizeLimitError reports a file of maxSize bytes or more
+func sizeLimitError(maxSize int64) error {
+	return fmt.Errorf("file size exceeds limit of %d bytes", maxSize)
+}
@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rangeServer serves content with byte range support; full downloads are cut off after
+// dropAfter bytes, and ranges after dropAfter bytes until dropRanges is used up
+func rangeServer(t *testing.T, content []byte, etag string, dropAfter int, dropRanges int32, ranges *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "video/mp4")
+		if r.Header.Get("Range") == "" {
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			if dropAfter > 0 {
+				w.Write(content[:dropAfter])
+				w.(http.Flusher).Flush()
+				panic(http.ErrAbortHandler)
+			}
+			w.Write(content)
+			return
+		}
+		if atomic.AddInt32(ranges, 1) <= dropRanges {
+			// Start the range, then drop the connection
+			start, _, _ := strings.Cut(strings.TrimPrefix(r.Header.Get("Range"), "bytes="), "-")
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %s-%d/%d", start, len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testContent(size int) []byte {
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	return content
+}
+
+func TestDownloader_ResumesDroppedDownload(t *testing.T) {
+	content := testContent(4096)
+	var ranges int32
+	server := rangeServer(t, content, `"v1"`, 1000, 0, &ranges)
+
+	data, contentType, err := NewDownloader(5*time.Second, 2, time.Millisecond, 1, 0).Download(context.Background(), server.URL, nil, 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.Equal(t, "video/mp4", contentType)
+	assert.EqualValues(t, 1, ranges, "the download resumed once from where it dropped")
+}
+
+func TestDownloader_GivesUpAfterRetries(t *testing.T) {
+	content := testContent(4096)
+	var ranges int32
+	server := rangeServer(t, content, `"v1"`, 1000, 10, &ranges)
+
+	_, _, err := NewDownloader(5*time.Second, 2, time.Millisecond, 1, 0).Download(context.Background(), server.URL, nil, 1<<20)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read file data")
+	assert.EqualValues(t, 2, ranges)
+}
+
+func TestDownloader_NoResumeWithoutRangeSupport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Range"), "hosts without range support are not sent ranges")
+		w.Header().Set("Content-Length", "4096")
+		w.Write(testContent(1000))
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}))
+	defer server.Close()
+
+	_, _, err := NewDownloader(5*time.Second, 2, time.Millisecond, 1, 0).Download(context.Background(), server.URL, nil, 1<<20)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read file data")
+}
+
+func TestDownloader_RestartsWhenFileChanged(t *testing.T) {
+	original, changed := testContent(4096), bytes.Repeat([]byte("new"), 100)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Header().Set("Content-Length", "4096")
+			w.Write(original[:1000])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		assert.Equal(t, `"v1"`, r.Header.Get("If-Range"))
+		// The ETag no longer matches, so the whole new version is sent
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(changed))
+	}))
+	defer server.Close()
+
+	data, _, err := NewDownloader(5*time.Second, 2, time.Millisecond, 1, 0).Download(context.Background(), server.URL, nil, 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, changed, data)
+}
+
+func TestDownloader_Segments(t *testing.T) {
+	content := testContent(10000)
+	var ranges int32
+	// The first range request drops, so one segment is resumed
+	server := rangeServer(t, content, `"v1"`, 0, 1, &ranges)
+
+	data, _, err := NewDownloader(5*time.Second, 2, time.Millisecond, 4, 1000).Download(context.Background(), server.URL, nil, 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.EqualValues(t, 4, ranges, "three segments were requested as ranges, one of them twice")
+}
+
+func TestDownloader_SegmentsNeedMinimumSize(t *testing.T) {
+	content := testContent(10000)
+	var ranges int32
+	server := rangeServer(t, content, `"v1"`, 0, 0, &ranges)
+
+	data, _, err := NewDownloader(5*time.Second, 2, time.Millisecond, 4, 20000).Download(context.Background(), server.URL, nil, 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.Zero(t, ranges)
+}
+
+func TestDownloader_RejectsLargeContentLength(t *testing.T) {
+	var wrote int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1048576")
+		w.WriteHeader(http.StatusOK)
+		atomic.StoreInt32(&wrote, 1)
+	}))
+	defer server.Close()
+
+	_, _, err := NewDownloader(5*time.Second, 2, time.Millisecond, 4, 0).Download(context.Background(), server.URL, nil, 1024)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file size exceeds limit of 1024 bytes")
+}
+
+func TestRangeStart(t *testing.T) {
+	tests := []struct {
+		contentRange string
+		expected     int64
+	}{
+		{"bytes 100-199/1000", 100},
+		{"bytes 0-0/1", 0},
+		{"bytes */1000", -1},
+		{"items 1-2/3", -1},
+		{"", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentRange, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rangeStart(tt.contentRange))
+		})
+	}
+}
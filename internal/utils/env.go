@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -28,6 +29,23 @@ func GetEnvString(key, defaultValue string) string {
 	return defaultValue
 }
 
+// GetEnvStringSlice gets a comma-separated list from environment variable with a default fallback
+// Entries are trimmed and empty entries are dropped
+func GetEnvStringSlice(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 // GetEnvInt gets an integer from environment variable with a default fallback
 func GetEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
@@ -51,6 +51,29 @@ func TestGetEnvString(t *testing.T) {
 	}
 }
 
+func TestGetEnvStringSlice(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		expected []string
+	}{
+		{name: "unset uses default", envValue: "", expected: []string{"default"}},
+		{name: "single value", envValue: "gemini", expected: []string{"gemini"}},
+		{name: "trims and drops empty entries", envValue: " gemini, ,anthropic ,", expected: []string{"gemini", "anthropic"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.envValue != "" {
+				t.Setenv("TEST_STRING_SLICE", tt.envValue)
+			}
+
+			result := GetEnvStringSlice("TEST_STRING_SLICE", []string{"default"})
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
 func TestGetEnvInt(t *testing.T) {
 	tests := []struct {
 		name         string
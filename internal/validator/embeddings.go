@@ -0,0 +1,132 @@
+package validator
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+)
+
+// ValidateEmbeddingsRequest validates an OpenAI-style embeddings request
+// Returns a clean request holding only the fields forwarded to vendors, with the model
+// left for the caller to set, and the original model value from the request
+func ValidateEmbeddingsRequest(body []byte) (map[string]interface{}, string, error) {
+	var requestData map[string]interface{}
+	if err := codec.Unmarshal(body, &requestData); err != nil {
+		return nil, "", fmt.Errorf("invalid request format: %v", err)
+	}
+
+	if err := validateEmbeddingsInput(requestData); err != nil {
+		return nil, "", err
+	}
+
+	if format, exists := requestData["encoding_format"]; exists {
+		if format != "float" && format != "base64" {
+			return nil, "", fmt.Errorf("invalid 'encoding_format' field: must be 'float' or 'base64'")
+		}
+	}
+
+	if dimensions, exists := requestData["dimensions"]; exists {
+		if !isPositiveInteger(dimensions) {
+			return nil, "", fmt.Errorf("invalid 'dimensions' field: must be a positive integer")
+		}
+	}
+
+	if user, exists := requestData["user"]; exists {
+		if _, ok := user.(string); !ok {
+			return nil, "", fmt.Errorf("invalid 'user' field: must be a string")
+		}
+	}
+
+	originalModel, _ := requestData["model"].(string)
+	if originalModel == "" {
+		originalModel = "any-model" // Default if no model provided
+	}
+
+	cleanRequest := map[string]interface{}{
+		"input": requestData["input"],
+	}
+	for _, field := range []string{"encoding_format", "dimensions", "user"} {
+		if value, exists := requestData[field]; exists {
+			cleanRequest[field] = value
+		}
+	}
+
+	return cleanRequest, originalModel, nil
+}
+
+// validateEmbeddingsInput checks 'input' is a string, an array of strings, an array of
+// token IDs or an array of token ID arrays, none of them empty
+func validateEmbeddingsInput(requestData map[string]interface{}) error {
+	input, exists := requestData["input"]
+	if !exists {
+		return fmt.Errorf("missing 'input' field in request")
+	}
+
+	switch value := input.(type) {
+	case string:
+		if value == "" {
+			return fmt.Errorf("invalid 'input' field: must not be empty")
+		}
+		return nil
+	case []interface{}:
+		if len(value) == 0 {
+			return fmt.Errorf("invalid 'input' field: must not be empty")
+		}
+		for i, item := range value {
+			if err := validateEmbeddingsInputItem(item, value[0]); err != nil {
+				return fmt.Errorf("invalid 'input' field at index %d: %v", i, err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid 'input' field: must be a string or an array")
+	}
+}
+
+// validateEmbeddingsInputItem checks one element of an input array has the same kind as
+// the first element: a non-empty string, a token ID or a non-empty array of token IDs
+func validateEmbeddingsInputItem(item, first interface{}) error {
+	switch value := item.(type) {
+	case string:
+		if _, ok := first.(string); !ok {
+			return fmt.Errorf("arrays must not mix strings and tokens")
+		}
+		if value == "" {
+			return fmt.Errorf("must not be an empty string")
+		}
+	case float64:
+		if _, ok := first.(float64); !ok {
+			return fmt.Errorf("arrays must not mix strings and tokens")
+		}
+		if !isToken(value) {
+			return fmt.Errorf("token IDs must be non-negative integers")
+		}
+	case []interface{}:
+		if _, ok := first.([]interface{}); !ok {
+			return fmt.Errorf("arrays must not mix strings and tokens")
+		}
+		if len(value) == 0 {
+			return fmt.Errorf("must not be an empty token array")
+		}
+		for _, token := range value {
+			if id, ok := token.(float64); !ok || !isToken(id) {
+				return fmt.Errorf("token IDs must be non-negative integers")
+			}
+		}
+	default:
+		return fmt.Errorf("must be a string, a token ID or an array of token IDs")
+	}
+	return nil
+}
+
+// isToken reports whether a JSON number is a valid token ID
+func isToken(value float64) bool {
+	return value >= 0 && value == math.Trunc(value)
+}
+
+// isPositiveInteger reports whether a JSON value is an integer above zero
+func isPositiveInteger(value interface{}) bool {
+	number, ok := value.(float64)
+	return ok && number > 0 && number == math.Trunc(number)
+}
@@ -0,0 +1,62 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEmbeddingsRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectedError string
+		expectedModel string
+		expected      map[string]interface{}
+	}{
+		{
+			name:          "string input",
+			body:          `{"model":"text-embedding-3-small","input":"The food was delicious","encoding_format":"float","dimensions":256,"user":"user-1","extra":true}`,
+			expectedModel: "text-embedding-3-small",
+			expected:      map[string]interface{}{"input": "The food was delicious", "encoding_format": "float", "dimensions": float64(256), "user": "user-1"},
+		},
+		{
+			name:          "array of strings without a model",
+			body:          `{"input":["first","second"]}`,
+			expectedModel: "any-model",
+			expected:      map[string]interface{}{"input": []interface{}{"first", "second"}},
+		},
+		{
+			name:          "token arrays",
+			body:          `{"model":"m","input":[[1,2,3],[4]]}`,
+			expectedModel: "m",
+			expected:      map[string]interface{}{"input": []interface{}{[]interface{}{float64(1), float64(2), float64(3)}, []interface{}{float64(4)}}},
+		},
+		{name: "invalid JSON", body: `{`, expectedError: "invalid request format"},
+		{name: "missing input", body: `{"model":"m"}`, expectedError: "missing 'input' field"},
+		{name: "empty string", body: `{"input":""}`, expectedError: "must not be empty"},
+		{name: "empty array", body: `{"input":[]}`, expectedError: "must not be empty"},
+		{name: "object input", body: `{"input":{"text":"hi"}}`, expectedError: "must be a string or an array"},
+		{name: "mixed array", body: `{"input":["text",42]}`, expectedError: "index 1: arrays must not mix strings and tokens"},
+		{name: "empty string in array", body: `{"input":["text",""]}`, expectedError: "index 1: must not be an empty string"},
+		{name: "fractional token", body: `{"input":[1,2.5]}`, expectedError: "token IDs must be non-negative integers"},
+		{name: "empty token array", body: `{"input":[[1],[]]}`, expectedError: "must not be an empty token array"},
+		{name: "unknown encoding format", body: `{"input":"hi","encoding_format":"int8"}`, expectedError: "'encoding_format'"},
+		{name: "zero dimensions", body: `{"input":"hi","dimensions":0}`, expectedError: "'dimensions'"},
+		{name: "non-string user", body: `{"input":"hi","user":7}`, expectedError: "'user'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, originalModel, err := ValidateEmbeddingsRequest([]byte(tt.body))
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedModel, originalModel)
+			assert.Equal(t, tt.expected, request)
+		})
+	}
+}
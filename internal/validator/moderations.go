@@ -0,0 +1,91 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+)
+
+// ValidateModerationRequest validates an OpenAI-style moderation request
+// Returns a clean request holding only the input, with the model left for the caller to
+// set, and the original model value from the request
+func ValidateModerationRequest(body []byte) (map[string]interface{}, string, error) {
+	var requestData map[string]interface{}
+	if err := codec.Unmarshal(body, &requestData); err != nil {
+		return nil, "", fmt.Errorf("invalid request format: %v", err)
+	}
+
+	if err := validateModerationInput(requestData); err != nil {
+		return nil, "", err
+	}
+
+	originalModel, _ := requestData["model"].(string)
+	if originalModel == "" {
+		originalModel = "any-model" // Default if no model provided
+	}
+
+	return map[string]interface{}{"input": requestData["input"]}, originalModel, nil
+}
+
+// validateModerationInput checks 'input' is a non-empty string, an array of non-empty
+// strings or an array of text and image_url content parts
+func validateModerationInput(requestData map[string]interface{}) error {
+	input, exists := requestData["input"]
+	if !exists {
+		return fmt.Errorf("missing 'input' field in request")
+	}
+
+	switch value := input.(type) {
+	case string:
+		if value == "" {
+			return fmt.Errorf("invalid 'input' field: must not be empty")
+		}
+		return nil
+	case []interface{}:
+		if len(value) == 0 {
+			return fmt.Errorf("invalid 'input' field: must not be empty")
+		}
+		for i, item := range value {
+			if err := validateModerationInputItem(item, value[0]); err != nil {
+				return fmt.Errorf("invalid 'input' field at index %d: %v", i, err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid 'input' field: must be a string or an array")
+	}
+}
+
+// validateModerationInputItem checks one element of an input array has the same kind as
+// the first element: a non-empty string or a text or image_url content part
+func validateModerationInputItem(item, first interface{}) error {
+	switch value := item.(type) {
+	case string:
+		if _, ok := first.(string); !ok {
+			return fmt.Errorf("arrays must not mix strings and content parts")
+		}
+		if value == "" {
+			return fmt.Errorf("must not be an empty string")
+		}
+	case map[string]interface{}:
+		if _, ok := first.(map[string]interface{}); !ok {
+			return fmt.Errorf("arrays must not mix strings and content parts")
+		}
+		switch value["type"] {
+		case "text":
+			if text, ok := value["text"].(string); !ok || text == "" {
+				return fmt.Errorf("text parts must have a non-empty 'text'")
+			}
+		case "image_url":
+			imageURL, _ := value["image_url"].(map[string]interface{})
+			if url, ok := imageURL["url"].(string); !ok || url == "" {
+				return fmt.Errorf("image_url parts must have a non-empty 'image_url.url'")
+			}
+		default:
+			return fmt.Errorf("content parts must have type 'text' or 'image_url'")
+		}
+	default:
+		return fmt.Errorf("must be a string or a content part")
+	}
+	return nil
+}
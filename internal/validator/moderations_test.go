@@ -0,0 +1,55 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateModerationRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectedError string
+		expectedModel string
+		expected      map[string]interface{}
+	}{
+		{
+			name:          "string input",
+			body:          `{"model":"omni-moderation-latest","input":"I want to hurt them","extra":true}`,
+			expectedModel: "omni-moderation-latest",
+			expected:      map[string]interface{}{"input": "I want to hurt them"},
+		},
+		{
+			name:          "multimodal input",
+			body:          `{"input":[{"type":"text","text":"hi"},{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}`,
+			expectedModel: "any-model",
+			expected: map[string]interface{}{"input": []interface{}{
+				map[string]interface{}{"type": "text", "text": "hi"},
+				map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "https://example.com/a.png"}},
+			}},
+		},
+		{name: "invalid JSON", body: `{`, expectedError: "invalid request format"},
+		{name: "missing input", body: `{"model":"m"}`, expectedError: "missing 'input' field"},
+		{name: "empty array", body: `{"input":[]}`, expectedError: "must not be empty"},
+		{name: "empty string in array", body: `{"input":["a",""]}`, expectedError: "index 1"},
+		{name: "mixed array", body: `{"input":["a",{"type":"text","text":"b"}]}`, expectedError: "must not mix"},
+		{name: "unknown part type", body: `{"input":[{"type":"audio"}]}`, expectedError: "'text' or 'image_url'"},
+		{name: "image without url", body: `{"input":[{"type":"image_url","image_url":{}}]}`, expectedError: "image_url.url"},
+		{name: "number input", body: `{"input":42}`, expectedError: "must be a string or an array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, originalModel, err := ValidateModerationRequest([]byte(tt.body))
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedModel, originalModel)
+			assert.Equal(t, tt.expected, request)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+)
+
+// ValidateRerankRequest validates a rerank request
+// Returns a clean request holding the query, the documents as strings, top_n when given and
+// return_documents, with the model left for the caller to set, and the original model value
+func ValidateRerankRequest(body []byte) (map[string]interface{}, string, error) {
+	var requestData map[string]interface{}
+	if err := codec.Unmarshal(body, &requestData); err != nil {
+		return nil, "", fmt.Errorf("invalid request format: %v", err)
+	}
+
+	query, ok := requestData["query"].(string)
+	if !ok || query == "" {
+		return nil, "", fmt.Errorf("missing or empty 'query' field in request")
+	}
+	documents, err := rerankDocuments(requestData["documents"])
+	if err != nil {
+		return nil, "", err
+	}
+
+	request := map[string]interface{}{
+		"query":            query,
+		"documents":        documents,
+		"return_documents": false,
+	}
+	if topN, exists := requestData["top_n"]; exists {
+		n, ok := topN.(float64)
+		if !ok || n != float64(int(n)) || n < 1 {
+			return nil, "", fmt.Errorf("invalid 'top_n' field: must be a positive integer")
+		}
+		request["top_n"] = int(n)
+	}
+	if returnDocuments, exists := requestData["return_documents"]; exists {
+		value, ok := returnDocuments.(bool)
+		if !ok {
+			return nil, "", fmt.Errorf("invalid 'return_documents' field: must be a boolean")
+		}
+		request["return_documents"] = value
+	}
+
+	originalModel, _ := requestData["model"].(string)
+	if originalModel == "" {
+		originalModel = "any-model" // Default if no model provided
+	}
+
+	return request, originalModel, nil
+}
+
+// rerankDocuments checks 'documents' is a non-empty array of non-empty strings or of
+// objects with a non-empty 'text', and returns them as strings
+func rerankDocuments(value interface{}) ([]interface{}, error) {
+	if value == nil {
+		return nil, fmt.Errorf("missing 'documents' field in request")
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid 'documents' field: must be an array")
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("invalid 'documents' field: must not be empty")
+	}
+
+	documents := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		var text string
+		switch document := item.(type) {
+		case string:
+			text = document
+		case map[string]interface{}:
+			text, _ = document["text"].(string)
+		default:
+			return nil, fmt.Errorf("invalid 'documents' field at index %d: must be a string or an object with 'text'", i)
+		}
+		if text == "" {
+			return nil, fmt.Errorf("invalid 'documents' field at index %d: must not be empty", i)
+		}
+		documents = append(documents, text)
+	}
+	return documents, nil
+}
@@ -0,0 +1,62 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRerankRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectedError string
+		expectedModel string
+		expected      map[string]interface{}
+	}{
+		{
+			name:          "string documents",
+			body:          `{"model":"rerank-v3.5","query":"capital of France","documents":["Paris","Berlin"],"top_n":1,"extra":true}`,
+			expectedModel: "rerank-v3.5",
+			expected: map[string]interface{}{
+				"query":            "capital of France",
+				"documents":        []interface{}{"Paris", "Berlin"},
+				"top_n":            1,
+				"return_documents": false,
+			},
+		},
+		{
+			name:          "object documents",
+			body:          `{"query":"q","documents":[{"text":"a","title":"ignored"}],"return_documents":true}`,
+			expectedModel: "any-model",
+			expected: map[string]interface{}{
+				"query":            "q",
+				"documents":        []interface{}{"a"},
+				"return_documents": true,
+			},
+		},
+		{name: "invalid JSON", body: `{`, expectedError: "invalid request format"},
+		{name: "missing query", body: `{"documents":["a"]}`, expectedError: "'query'"},
+		{name: "missing documents", body: `{"query":"q"}`, expectedError: "missing 'documents'"},
+		{name: "empty documents", body: `{"query":"q","documents":[]}`, expectedError: "must not be empty"},
+		{name: "empty document", body: `{"query":"q","documents":["a",{"text":""}]}`, expectedError: "index 1"},
+		{name: "number document", body: `{"query":"q","documents":[1]}`, expectedError: "index 0"},
+		{name: "fractional top_n", body: `{"query":"q","documents":["a"],"top_n":1.5}`, expectedError: "'top_n'"},
+		{name: "zero top_n", body: `{"query":"q","documents":["a"],"top_n":0}`, expectedError: "'top_n'"},
+		{name: "string return_documents", body: `{"query":"q","documents":["a"],"return_documents":"yes"}`, expectedError: "'return_documents'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, originalModel, err := ValidateRerankRequest([]byte(tt.body))
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedModel, originalModel)
+			assert.Equal(t, tt.expected, request)
+		})
+	}
+}
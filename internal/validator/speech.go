@@ -0,0 +1,86 @@
+package validator
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+)
+
+// maxSpeechInputLength is the longest text the OpenAI speech API accepts
+const maxSpeechInputLength = 4096
+
+// defaultSpeechVoice is used when a speech request names no voice
+const defaultSpeechVoice = "alloy"
+
+// speechFormats are the audio formats of the OpenAI speech API
+var speechFormats = map[string]bool{"mp3": true, "opus": true, "aac": true, "flac": true, "wav": true, "pcm": true}
+
+// ValidateSpeechRequest validates an OpenAI-style text-to-speech request
+// Returns a clean request holding only the fields forwarded to vendors, with the model
+// left for the caller to set and voice and response_format always present, and the
+// original model value from the request
+func ValidateSpeechRequest(body []byte) (map[string]interface{}, string, error) {
+	var requestData map[string]interface{}
+	if err := codec.Unmarshal(body, &requestData); err != nil {
+		return nil, "", fmt.Errorf("invalid request format: %v", err)
+	}
+
+	input, exists := requestData["input"]
+	if !exists {
+		return nil, "", fmt.Errorf("missing 'input' field in request")
+	}
+	text, ok := input.(string)
+	if !ok || text == "" {
+		return nil, "", fmt.Errorf("invalid 'input' field: must be a non-empty string")
+	}
+	if utf8.RuneCountInString(text) > maxSpeechInputLength {
+		return nil, "", fmt.Errorf("invalid 'input' field: must be at most %d characters", maxSpeechInputLength)
+	}
+
+	cleanRequest := map[string]interface{}{
+		"input":           text,
+		"voice":           defaultSpeechVoice,
+		"response_format": "mp3",
+	}
+
+	for _, field := range []string{"voice", "instructions"} {
+		if value, exists := requestData[field]; exists {
+			text, ok := value.(string)
+			if !ok || text == "" {
+				return nil, "", fmt.Errorf("invalid '%s' field: must be a non-empty string", field)
+			}
+			cleanRequest[field] = text
+		}
+	}
+
+	if format, exists := requestData["response_format"]; exists {
+		name, _ := format.(string)
+		if !speechFormats[name] {
+			return nil, "", fmt.Errorf("invalid 'response_format' field: must be one of mp3, opus, aac, flac, wav or pcm")
+		}
+		cleanRequest["response_format"] = name
+	}
+
+	if speed, exists := requestData["speed"]; exists {
+		value, ok := speed.(float64)
+		if !ok || value < 0.25 || value > 4 {
+			return nil, "", fmt.Errorf("invalid 'speed' field: must be a number between 0.25 and 4.0")
+		}
+		cleanRequest["speed"] = value
+	}
+
+	if streamFormat, exists := requestData["stream_format"]; exists {
+		if streamFormat != "audio" && streamFormat != "sse" {
+			return nil, "", fmt.Errorf("invalid 'stream_format' field: must be 'audio' or 'sse'")
+		}
+		cleanRequest["stream_format"] = streamFormat
+	}
+
+	originalModel, _ := requestData["model"].(string)
+	if originalModel == "" {
+		originalModel = "any-model" // Default if no model provided
+	}
+
+	return cleanRequest, originalModel, nil
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSpeechRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectedError string
+		expectedModel string
+		expected      map[string]interface{}
+	}{
+		{
+			name:          "full request",
+			body:          `{"model":"tts-1","input":"Hello world","voice":"nova","response_format":"opus","speed":1.25,"instructions":"Speak cheerfully","stream_format":"audio","extra":true}`,
+			expectedModel: "tts-1",
+			expected:      map[string]interface{}{"input": "Hello world", "voice": "nova", "response_format": "opus", "speed": 1.25, "instructions": "Speak cheerfully", "stream_format": "audio"},
+		},
+		{
+			name:          "defaults",
+			body:          `{"input":"Hello world"}`,
+			expectedModel: "any-model",
+			expected:      map[string]interface{}{"input": "Hello world", "voice": "alloy", "response_format": "mp3"},
+		},
+		{name: "invalid JSON", body: `{`, expectedError: "invalid request format"},
+		{name: "missing input", body: `{"voice":"nova"}`, expectedError: "missing 'input' field"},
+		{name: "empty input", body: `{"input":""}`, expectedError: "'input'"},
+		{name: "input too long", body: `{"input":"` + strings.Repeat("a", 4097) + `"}`, expectedError: "at most 4096 characters"},
+		{name: "empty voice", body: `{"input":"hi","voice":""}`, expectedError: "'voice'"},
+		{name: "unknown format", body: `{"input":"hi","response_format":"ogg"}`, expectedError: "'response_format'"},
+		{name: "speed too fast", body: `{"input":"hi","speed":5}`, expectedError: "'speed'"},
+		{name: "unknown stream format", body: `{"input":"hi","stream_format":"chunks"}`, expectedError: "'stream_format'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, originalModel, err := ValidateSpeechRequest([]byte(tt.body))
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedModel, originalModel)
+			assert.Equal(t, tt.expected, request)
+		})
+	}
+}
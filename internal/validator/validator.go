@@ -1,15 +1,19 @@
 package validator
 
 import (
-	"encoding/json"
 	"fmt"
+	"math"
+
+	"github.com/aashari/go-generative-api-router/internal/access"
+	"github.com/aashari/go-generative-api-router/internal/codec"
 )
 
 // ValidateAndModifyRequest validates the request and modifies it with the selected model
+// Fields named in keep are passed through for vendor adapters that translate them
 // Returns the modified body and the original model value from the request
-func ValidateAndModifyRequest(body []byte, model string) ([]byte, string, error) {
+func ValidateAndModifyRequest(body []byte, model string, keep ...string) ([]byte, string, error) {
 	var requestData map[string]interface{}
-	if err := json.Unmarshal(body, &requestData); err != nil {
+	if err := codec.Unmarshal(body, &requestData); err != nil {
 		return nil, "", fmt.Errorf("invalid request format: %v", err)
 	}
 
@@ -38,6 +42,16 @@ func ValidateAndModifyRequest(body []byte, model string) ([]byte, string, error)
 		return nil, "", err
 	}
 
+	// Validate seed if present
+	if err := validateSeed(requestData); err != nil {
+		return nil, "", err
+	}
+
+	// Validate user if present
+	if err := validateUser(requestData); err != nil {
+		return nil, "", err
+	}
+
 	// Extract the original model before replacing it
 	originalModel, _ := requestData["model"].(string)
 	if originalModel == "" {
@@ -63,8 +77,27 @@ func ValidateAndModifyRequest(body []byte, model string) ([]byte, string, error)
 		cleanRequest["stream"] = stream
 	}
 
+	// Seed is passed through so vendors that support it can sample deterministically
+	if seed, hasSeed := requestData["seed"]; hasSeed {
+		cleanRequest["seed"] = seed
+	}
+
+	// The end-user identifier is passed through for vendors' abuse monitoring; adapters of
+	// vendors that do not accept it drop or translate it
+	if user, hasUser := requestData["user"]; hasUser {
+		cleanRequest["user"] = user
+	}
+
+	// Pass through the fields the selected vendor's adapter translates, e.g. max_tokens for
+	// vendors that require it
+	for _, field := range keep {
+		if value, ok := requestData[field]; ok {
+			cleanRequest[field] = value
+		}
+	}
+
 	// Re-encode the clean request (without max_tokens, temperature, top_p, etc.)
-	modifiedBody, err := json.Marshal(cleanRequest)
+	modifiedBody, err := codec.Marshal(cleanRequest)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to encode modified request: %v", err)
 	}
@@ -238,3 +271,28 @@ func validateStream(requestData map[string]interface{}) error {
 	}
 	return nil
 }
+
+// validateSeed ensures the 'seed' field, if present, is an integer
+func validateSeed(requestData map[string]interface{}) error {
+	seed, exists := requestData["seed"]
+	if !exists {
+		return nil
+	}
+	if value, ok := seed.(float64); !ok || value != math.Trunc(value) {
+		return fmt.Errorf("invalid 'seed' field: must be an integer")
+	}
+	return nil
+}
+
+// validateUser ensures the 'user' field, if present, is a non-empty string of bounded length
+func validateUser(requestData map[string]interface{}) error {
+	user, exists := requestData["user"]
+	if !exists {
+		return nil
+	}
+	value, ok := user.(string)
+	if !ok {
+		return fmt.Errorf("invalid 'user' field: must be a string")
+	}
+	return access.ValidateUser(value)
+}
@@ -2,6 +2,7 @@ package validator
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -64,6 +65,31 @@ func TestValidateAndModifyRequest(t *testing.T) {
 			expectedModel:  "gpt-4",
 			expectedFields: []string{"model", "messages", "stream"},
 		},
+		{
+			name: "request with seed",
+			input: map[string]interface{}{
+				"model":       "gpt-4",
+				"messages":    []interface{}{map[string]interface{}{"role": "user", "content": "Hello"}},
+				"seed":        42,
+				"temperature": 0.2,
+			},
+			selectedModel:  "gpt-4o",
+			expectError:    false,
+			expectedModel:  "gpt-4",
+			expectedFields: []string{"model", "messages", "seed"},
+		},
+		{
+			name: "request with user",
+			input: map[string]interface{}{
+				"model":    "gpt-4",
+				"messages": []interface{}{map[string]interface{}{"role": "user", "content": "Hello"}},
+				"user":     "user-1234",
+			},
+			selectedModel:  "gpt-4o",
+			expectError:    false,
+			expectedModel:  "gpt-4",
+			expectedFields: []string{"model", "messages", "user"},
+		},
 		{
 			name: "vision request with image_url",
 			input: map[string]interface{}{
@@ -235,6 +261,22 @@ func TestValidateAndModifyRequest(t *testing.T) {
 	}
 }
 
+func TestValidateAndModifyRequest_KeepsFields(t *testing.T) {
+	body := []byte(`{"model":"claude","messages":[{"role":"user","content":"Hi"}],"max_tokens":50,"temperature":0.2}`)
+
+	stripped, _, err := ValidateAndModifyRequest(body, "claude-sonnet-4")
+	require.NoError(t, err)
+	assert.NotContains(t, string(stripped), "max_tokens")
+
+	kept, _, err := ValidateAndModifyRequest(body, "claude-sonnet-4", "max_tokens", "max_completion_tokens")
+	require.NoError(t, err)
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(kept, &result))
+	assert.Equal(t, float64(50), result["max_tokens"])
+	assert.NotContains(t, result, "max_completion_tokens", "absent fields stay absent")
+	assert.NotContains(t, result, "temperature", "fields not named are still dropped")
+}
+
 func TestValidateMessages(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -776,3 +818,88 @@ func TestValidateStream(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSeed(t *testing.T) {
+	tests := []struct {
+		name        string
+		requestData map[string]interface{}
+		expectError bool
+	}{
+		{
+			name:        "valid integer seed",
+			requestData: map[string]interface{}{"seed": float64(42)},
+			expectError: false,
+		},
+		{
+			name:        "no seed field (optional)",
+			requestData: map[string]interface{}{},
+			expectError: false,
+		},
+		{
+			name:        "fractional seed",
+			requestData: map[string]interface{}{"seed": 1.5},
+			expectError: true,
+		},
+		{
+			name:        "string seed",
+			requestData: map[string]interface{}{"seed": "42"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSeed(tt.requestData)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateUser(t *testing.T) {
+	tests := []struct {
+		name        string
+		requestData map[string]interface{}
+		expectError bool
+	}{
+		{
+			name:        "valid user",
+			requestData: map[string]interface{}{"user": "user-1234"},
+			expectError: false,
+		},
+		{
+			name:        "no user field (optional)",
+			requestData: map[string]interface{}{},
+			expectError: false,
+		},
+		{
+			name:        "empty user",
+			requestData: map[string]interface{}{"user": ""},
+			expectError: true,
+		},
+		{
+			name:        "numeric user",
+			requestData: map[string]interface{}{"user": float64(1234)},
+			expectError: true,
+		},
+		{
+			name:        "overlong user",
+			requestData: map[string]interface{}{"user": strings.Repeat("u", 257)},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateUser(tt.requestData)
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
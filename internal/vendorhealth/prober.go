@@ -0,0 +1,216 @@
+package vendorhealth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/codec"
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/filter"
+	"github.com/aashari/go-generative-api-router/internal/logger"
+	"github.com/aashari/go-generative-api-router/internal/proxy"
+	"github.com/aashari/go-generative-api-router/internal/quarantine"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// DefaultTimeout bounds a probe when VENDOR_HEALTH_TIMEOUT is unset
+const DefaultTimeout = 10 * time.Second
+
+// Prober sends every configured vendor a one-token chat completion on its first chat model
+// and publishes whether the vendor answered. Answers refusing the credential, such as 401 or
+// 429, still show the vendor is up; only server errors, timeouts and unreachable vendors fail
+type Prober struct {
+	Config   *config.Store
+	Client   proxy.APIClientInterface
+	Status   *Status
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// NewProberFromEnv creates a prober reporting into Default(), or returns nil when
+// VENDOR_HEALTH_INTERVAL is unset or zero, which keeps vendor health probes disabled
+func NewProberFromEnv(store *config.Store, client proxy.APIClientInterface) *Prober {
+	interval := utils.GetEnvDuration("VENDOR_HEALTH_INTERVAL", 0)
+	if interval <= 0 {
+		return nil
+	}
+	return &Prober{
+		Config:   store,
+		Client:   client,
+		Status:   Default(),
+		Interval: interval,
+		Timeout:  utils.GetEnvDuration("VENDOR_HEALTH_TIMEOUT", DefaultTimeout),
+	}
+}
+
+// Start probes immediately and then every Interval until ctx is cancelled
+func (p *Prober) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+
+		for {
+			p.RunOnce(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce probes every vendor with a chat model once, concurrently, publishes the results
+// and returns them
+func (p *Prober) RunOnce(ctx context.Context) []Result {
+	ctx = logger.WithComponent(ctx, "VendorHealth")
+	ctx = logger.WithStage(ctx, "Probe")
+
+	snapshot := p.Config.Snapshot()
+	creds := snapshot.Credentials()
+
+	var targets []config.VendorModel
+	seen := make(map[string]bool)
+	for _, model := range filter.ModelsByType(snapshot.Models(), config.ModelTypeChat) {
+		if !seen[model.Vendor] {
+			seen[model.Vendor] = true
+			targets = append(targets, model)
+		}
+	}
+
+	results := make([]Result, len(targets))
+	var wg sync.WaitGroup
+	for i, model := range targets {
+		wg.Add(1)
+		go func(i int, model config.VendorModel) {
+			defer wg.Done()
+			results[i] = p.check(ctx, model, creds)
+			if !results[i].Healthy {
+				logger.Warn(ctx, "Vendor health probe failed",
+					"vendor", model.Vendor,
+					"model", model.Model,
+					"reason", results[i].Reason,
+					"latency_ms", results[i].LatencyMs,
+				)
+			}
+		}(i, model)
+	}
+	wg.Wait()
+
+	p.Status.Replace(results)
+	return p.Status.Results()
+}
+
+// check probes a vendor through one of its models, preferring credentials not quarantined
+func (p *Prober) check(ctx context.Context, model config.VendorModel, creds []config.Credential) Result {
+	result := Result{Vendor: model.Vendor, Model: model.Model, CheckedAt: time.Now().UTC()}
+
+	var selection *selector.VendorSelection
+	for _, cred := range creds {
+		if cred.Platform != model.Vendor {
+			continue
+		}
+		if selection == nil || quarantine.Default().Quarantined(selection.Credential) {
+			selection = &selector.VendorSelection{
+				Vendor:     model.Vendor,
+				Model:      model.Model,
+				Credential: cred,
+				BaseURL:    model.BaseURL,
+				AuthHeader: model.AuthHeader,
+			}
+		}
+	}
+	if selection == nil {
+		result.Reason = "no credential configured for vendor"
+		return result
+	}
+
+	latency, err := p.send(ctx, selection)
+	result.LatencyMs = latency.Milliseconds()
+	if err != nil {
+		result.Reason = err.Error()
+		return result
+	}
+	result.Healthy = true
+	return result
+}
+
+// send runs a one-token completion through the API client, giving up after Timeout. It
+// returns an error only when the vendor failed to answer
+func (p *Prober) send(ctx context.Context, selection *selector.VendorSelection) (time.Duration, error) {
+	body, err := codec.Marshal(map[string]interface{}{
+		"model":      selection.Model,
+		"messages":   []map[string]string{{"role": "user", "content": "ping"}},
+		"max_tokens": 1,
+	})
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set(utils.HeaderContentType, "application/json")
+
+	recorder := &statusRecorder{header: make(http.Header), status: http.StatusOK}
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		done <- p.Client.SendRequest(recorder, req, selection, body, selection.Model)
+	}()
+
+	timer := time.NewTimer(p.Timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		latency := time.Since(start)
+		var vendorErr *proxy.VendorAPIError
+		if errors.As(err, &vendorErr) {
+			return latency, vendorFailure(vendorErr.StatusCode)
+		}
+		if err != nil {
+			return latency, err
+		}
+		return latency, vendorFailure(recorder.status)
+	case <-timer.C:
+		return time.Since(start), fmt.Errorf("no response within %s", p.Timeout)
+	case <-ctx.Done():
+		return time.Since(start), ctx.Err()
+	}
+}
+
+// vendorFailure returns an error for response statuses showing the vendor failing: server
+// errors and request timeouts. Other statuses show it up, even when refusing the request
+func vendorFailure(status int) error {
+	if status >= http.StatusInternalServerError || status == http.StatusRequestTimeout {
+		return fmt.Errorf("status %d", status)
+	}
+	return nil
+}
+
+// statusRecorder keeps the status of the response the API client writes for a probe and
+// drops its body
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (r *statusRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	return io.Discard.Write(p)
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+}
@@ -0,0 +1,91 @@
+package vendorhealth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/proxy"
+	"github.com/aashari/go-generative-api-router/internal/selector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient answers probes with a canned error per vendor
+type fakeClient struct {
+	mu     sync.Mutex
+	errs   map[string]error
+	delay  time.Duration
+	probed []string
+}
+
+func (f *fakeClient) SendRequest(w http.ResponseWriter, r *http.Request, selection *selector.VendorSelection, body []byte, originalModel string) error {
+	f.mu.Lock()
+	f.probed = append(f.probed, selection.Vendor+"/"+selection.Model)
+	err := f.errs[selection.Vendor]
+	f.mu.Unlock()
+	time.Sleep(f.delay)
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func TestProber_RunOnce(t *testing.T) {
+	store := config.NewStore(config.NewSnapshot(config.Data{
+		Credentials: []config.Credential{
+			{Platform: "openai", Value: "sk-1"},
+			{Platform: "gemini", Value: "gm-1"},
+			{Platform: "anthropic", Value: "sk-ant"},
+			{Platform: "mistral", Value: "ms-1"},
+		},
+		Models: []config.VendorModel{
+			{Vendor: "openai", Model: "gpt-4o-mini"},
+			{Vendor: "openai", Model: "gpt-4o"},
+			{Vendor: "gemini", Model: "gemini-2.5-flash"},
+			{Vendor: "anthropic", Model: "claude-sonnet-4"},
+			{Vendor: "mistral", Model: "mistral-large"},
+			{Vendor: "groq", Model: "llama-3.3-70b-versatile"},
+		},
+	}))
+	client := &fakeClient{errs: map[string]error{
+		"gemini":    &proxy.VendorAPIError{Vendor: "gemini", StatusCode: http.StatusServiceUnavailable},
+		"anthropic": &proxy.VendorAPIError{Vendor: "anthropic", StatusCode: http.StatusTooManyRequests},
+		"mistral":   fmt.Errorf("failed to send request to vendor: connection refused"),
+	}}
+	prober := &Prober{Config: store, Client: client, Status: NewStatus(1), Timeout: time.Second}
+
+	healthy := make(map[string]bool)
+	reasons := make(map[string]string)
+	for _, result := range prober.RunOnce(context.Background()) {
+		healthy[result.Vendor] = result.Healthy
+		reasons[result.Vendor] = result.Reason
+		assert.Equal(t, !result.Healthy, result.Degraded, result.Vendor)
+	}
+
+	assert.True(t, healthy["openai"])
+	assert.True(t, healthy["anthropic"], "a refused credential still shows the vendor up")
+	assert.False(t, healthy["gemini"])
+	assert.Equal(t, "status 503", reasons["gemini"])
+	assert.Contains(t, reasons["mistral"], "connection refused")
+	assert.Equal(t, "no credential configured for vendor", reasons["groq"])
+	assert.NotContains(t, client.probed, "openai/gpt-4o", "each vendor is probed once")
+}
+
+func TestProber_Timeout(t *testing.T) {
+	store := config.NewStore(config.NewSnapshot(config.Data{
+		Credentials: []config.Credential{{Platform: "openai", Value: "sk-1"}},
+		Models:      []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}},
+	}))
+	prober := &Prober{Config: store, Client: &fakeClient{delay: 200 * time.Millisecond}, Status: NewStatus(1), Timeout: 10 * time.Millisecond}
+
+	results := prober.RunOnce(context.Background())
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Healthy)
+	assert.Equal(t, "no response within 10ms", results[0].Reason)
+}
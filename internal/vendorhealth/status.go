@@ -0,0 +1,144 @@
+// Package vendorhealth periodically sends each configured vendor a lightweight request and
+// tracks which vendors keep failing it, so they can be reported and routed around before
+// user requests hit them
+package vendorhealth
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/aashari/go-generative-api-router/internal/utils"
+)
+
+// DefaultFailureThreshold is how many failed probes in a row degrade a vendor when
+// VENDOR_HEALTH_FAILURE_THRESHOLD is unset
+const DefaultFailureThreshold = 2
+
+// Result is the outcome of the latest probe of one vendor
+type Result struct {
+	Vendor  string `json:"vendor"`
+	Model   string `json:"model,omitempty"`
+	Healthy bool   `json:"healthy"`
+	// Degraded is whether the vendor failed enough probes in a row to be routed around
+	Degraded            bool      `json:"degraded"`
+	Reason              string    `json:"reason,omitempty"`
+	LatencyMs           int64     `json:"latency_ms"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	CheckedAt           time.Time `json:"checked_at"`
+}
+
+// Status holds the latest probe result of every vendor and decides which are degraded
+type Status struct {
+	mu        sync.RWMutex
+	enabled   bool
+	threshold int
+	results   map[string]Result
+}
+
+var (
+	defaultStatus     *Status
+	defaultStatusOnce sync.Once
+)
+
+// NewStatus creates an empty status degrading vendors after threshold failed probes in a row
+func NewStatus(threshold int) *Status {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Status{threshold: threshold, results: make(map[string]Result)}
+}
+
+// Default returns the process-wide vendor health status, with its threshold set from
+// VENDOR_HEALTH_FAILURE_THRESHOLD
+func Default() *Status {
+	defaultStatusOnce.Do(func() {
+		defaultStatus = NewStatus(utils.GetEnvInt("VENDOR_HEALTH_FAILURE_THRESHOLD", DefaultFailureThreshold))
+	})
+	return defaultStatus
+}
+
+// Enabled reports whether a prober has reported results into this status
+func (s *Status) Enabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.enabled
+}
+
+// Replace publishes the results of a complete probe run
+// Consecutive failure counts carry over from the previous run and vendors no longer
+// configured are dropped
+func (s *Status) Replace(results []Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := make(map[string]Result, len(results))
+	for _, result := range results {
+		if result.Healthy {
+			result.ConsecutiveFailures = 0
+		} else {
+			result.ConsecutiveFailures = s.results[result.Vendor].ConsecutiveFailures + 1
+		}
+		result.Degraded = result.ConsecutiveFailures >= s.threshold
+		next[result.Vendor] = result
+	}
+	s.results = next
+	s.enabled = true
+}
+
+// Results returns the latest result of every probed vendor sorted by vendor
+func (s *Status) Results() []Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]Result, 0, len(s.results))
+	for _, result := range s.results {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Vendor < results[j].Vendor })
+	return results
+}
+
+// Degraded returns the results of vendors that are degraded
+func (s *Status) Degraded() []Result {
+	var degraded []Result
+	for _, result := range s.Results() {
+		if result.Degraded {
+			degraded = append(degraded, result)
+		}
+	}
+	return degraded
+}
+
+// Filter removes the models and credentials of degraded vendors from a routing pool
+// When it would leave nothing to route to, the pool is returned unchanged
+func (s *Status) Filter(creds []config.Credential, models []config.VendorModel) ([]config.Credential, []config.VendorModel) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.results) == 0 {
+		return creds, models
+	}
+
+	var keptModels []config.VendorModel
+	for _, model := range models {
+		if !s.results[model.Vendor].Degraded {
+			keptModels = append(keptModels, model)
+		}
+	}
+	if len(keptModels) == len(models) || len(keptModels) == 0 {
+		return creds, models
+	}
+
+	var keptCreds []config.Credential
+	for _, cred := range creds {
+		if !s.results[cred.Platform].Degraded {
+			keptCreds = append(keptCreds, cred)
+		}
+	}
+	if len(keptCreds) == 0 {
+		return creds, models
+	}
+	return keptCreds, keptModels
+}
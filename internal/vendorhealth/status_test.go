@@ -0,0 +1,48 @@
+package vendorhealth
+
+import (
+	"testing"
+
+	"github.com/aashari/go-generative-api-router/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus_Replace(t *testing.T) {
+	s := NewStatus(2)
+	assert.False(t, s.Enabled())
+
+	s.Replace([]Result{{Vendor: "openai", Healthy: true}, {Vendor: "gemini", Reason: "status 503"}})
+	assert.True(t, s.Enabled())
+	assert.Empty(t, s.Degraded(), "one failure is below the threshold")
+
+	s.Replace([]Result{{Vendor: "openai", Healthy: true}, {Vendor: "gemini", Reason: "status 503"}})
+	degraded := s.Degraded()
+	require.Len(t, degraded, 1)
+	assert.Equal(t, "gemini", degraded[0].Vendor)
+	assert.Equal(t, 2, degraded[0].ConsecutiveFailures)
+
+	s.Replace([]Result{{Vendor: "gemini", Healthy: true}})
+	assert.Empty(t, s.Degraded(), "a successful probe clears the vendor")
+	assert.Len(t, s.Results(), 1, "vendors no longer probed are dropped")
+}
+
+func TestStatus_Filter(t *testing.T) {
+	creds := []config.Credential{{Platform: "openai"}, {Platform: "gemini"}}
+	models := []config.VendorModel{{Vendor: "openai", Model: "gpt-4o"}, {Vendor: "gemini", Model: "gemini-2.5-flash"}}
+	s := NewStatus(1)
+
+	keptCreds, keptModels := s.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds)
+	assert.Equal(t, models, keptModels)
+
+	s.Replace([]Result{{Vendor: "openai", Reason: "status 500"}, {Vendor: "gemini", Healthy: true}})
+	keptCreds, keptModels = s.Filter(creds, models)
+	assert.Equal(t, creds[1:], keptCreds)
+	assert.Equal(t, models[1:], keptModels)
+
+	s.Replace([]Result{{Vendor: "openai", Reason: "status 500"}, {Vendor: "gemini", Reason: "status 502"}})
+	keptCreds, keptModels = s.Filter(creds, models)
+	assert.Equal(t, creds, keptCreds, "every vendor is degraded: the pool is unchanged")
+	assert.Equal(t, models, keptModels)
+}
@@ -0,0 +1,289 @@
+// Package client is a Go client for the Generative API Router's OpenAI-compatible API
+// and its router-specific extensions: vendor pinning, routing exclusions, response
+// extensions, capability filters and routing decision lookup
+//
+// It depends only on the standard library so services can import it without pulling
+// in the router's own dependencies
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds non-streaming requests made with the default HTTP client
+const DefaultTimeout = 5 * time.Minute
+
+// Client calls a router deployment. Its fields may be changed before first use
+type Client struct {
+	// BaseURL is the router's address, e.g. "https://genapi.example.com"
+	BaseURL string
+	// APIKey is sent as a bearer token; empty sends no Authorization header
+	APIKey string
+	// HTTPClient makes the requests; streaming requests rely on ctx rather than its Timeout
+	HTTPClient *http.Client
+	// Header is added to every request, e.g. a User-Agent identifying the calling service
+	Header http.Header
+}
+
+// New creates a client for the router at baseURL authenticating with apiKey
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+		Header:     make(http.Header),
+	}
+}
+
+// APIError is an error response from the router
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Type       string `json:"type"`
+	Message    string `json:"message"`
+	Param      string `json:"param,omitempty"`
+	Code       string `json:"code,omitempty"`
+	RequestID  string `json:"-"`
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("router returned status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("router returned status %d (%s): %s", e.StatusCode, e.Type, e.Message)
+}
+
+// CreateChatCompletion sends a non-streaming chat completion request
+func (c *Client) CreateChatCompletion(ctx context.Context, request ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	request.Stream = false
+	resp, err := c.postChatCompletion(ctx, request, c.httpClient())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var completion ChatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return nil, fmt.Errorf("failed to decode chat completion: %w", err)
+	}
+	completion.RequestID = resp.Header.Get("X-Request-ID")
+	return &completion, nil
+}
+
+// CreateChatCompletionStream sends a streaming chat completion request
+// The caller must Close the returned stream
+func (c *Client) CreateChatCompletionStream(ctx context.Context, request ChatCompletionRequest) (*Stream, error) {
+	request.Stream = true
+
+	// A client-wide Timeout would cut long streams short; cancellation comes from ctx instead
+	streamClient := *c.httpClient()
+	streamClient.Timeout = 0
+
+	resp, err := c.postChatCompletion(ctx, request, &streamClient)
+	if err != nil {
+		return nil, err
+	}
+	return newStream(resp), nil
+}
+
+// ListModels lists the models the router can route to, optionally filtered
+func (c *Client) ListModels(ctx context.Context, filter ModelsFilter) (*ModelsResponse, error) {
+	query := url.Values{}
+	if filter.Vendor != "" {
+		query.Set("vendor", filter.Vendor)
+	}
+	if len(filter.Capabilities) > 0 {
+		query.Set("capability", strings.Join(filter.Capabilities, ","))
+	}
+	if filter.SupportsTools != nil {
+		query.Set("supports_tools", strconv.FormatBool(*filter.SupportsTools))
+	}
+	if filter.SupportsStreaming != nil {
+		query.Set("supports_streaming", strconv.FormatBool(*filter.SupportsStreaming))
+	}
+	if filter.MinContext > 0 {
+		query.Set("min_context", strconv.Itoa(filter.MinContext))
+	}
+
+	var models ModelsResponse
+	if err := c.getJSON(ctx, "/v1/models", query, &models); err != nil {
+		return nil, err
+	}
+	return &models, nil
+}
+
+// Health returns the router's health report; a degraded router is not an error
+func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
+	var health HealthResponse
+	if err := c.getJSON(ctx, "/health", nil, &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// RoutingDecisions returns recent routing decisions, e.g. the one for a response's RequestID
+func (c *Client) RoutingDecisions(ctx context.Context, filter DecisionsFilter) (*RoutingDecisionsResponse, error) {
+	query := url.Values{}
+	for name, value := range map[string]string{
+		"request_id": filter.RequestID,
+		"client_key": filter.ClientKey,
+		"vendor":     filter.Vendor,
+		"model":      filter.Model,
+		"outcome":    filter.Outcome,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if !filter.Since.IsZero() {
+		query.Set("since", filter.Since.Format(time.RFC3339))
+	}
+	if filter.Limit > 0 {
+		query.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	var decisions RoutingDecisionsResponse
+	if err := c.getJSON(ctx, "/admin/routing/decisions", query, &decisions); err != nil {
+		return nil, err
+	}
+	return &decisions, nil
+}
+
+// postChatCompletion sends a chat completion request and returns the successful response
+func (c *Client) postChatCompletion(ctx context.Context, request ChatCompletionRequest, httpClient *http.Client) (*http.Response, error) {
+	body, err := encodeChatCompletionRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	if request.Vendor != "" {
+		query.Set("vendor", request.Vendor)
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, "/v1/chat/completions", query, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if request.RequestID != "" {
+		req.Header.Set("X-Request-ID", request.RequestID)
+	}
+	if request.Stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	return c.send(httpClient, req)
+}
+
+// encodeChatCompletionRequest marshals a request, merging in its Extra fields
+func encodeChatCompletionRequest(request ChatCompletionRequest) ([]byte, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode chat completion request: %w", err)
+	}
+	if len(request.Extra) == 0 {
+		return body, nil
+	}
+
+	var merged map[string]interface{}
+	if err := json.Unmarshal(body, &merged); err != nil {
+		return nil, fmt.Errorf("failed to encode chat completion request: %w", err)
+	}
+	for key, value := range request.Extra {
+		if _, ok := merged[key]; !ok {
+			merged[key] = value
+		}
+	}
+	body, err = json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode chat completion request: %w", err)
+	}
+	return body, nil
+}
+
+// getJSON sends a GET request and decodes the successful response into target
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, target interface{}) error {
+	req, err := c.newRequest(ctx, http.MethodGet, path, query, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(c.httpClient(), req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", path, err)
+	}
+	return nil
+}
+
+// newRequest builds a request to path with the client's authentication and headers
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Request, error) {
+	target := strings.TrimRight(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range c.Header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	return req, nil
+}
+
+// send performs req, turning non-2xx responses into an *APIError
+func (c *Client) send(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to router failed: %w", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	return nil, decodeAPIError(resp)
+}
+
+// decodeAPIError reads an OpenAI-style error body, falling back to the raw body text
+func decodeAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	var envelope struct {
+		Error *APIError `json:"error"`
+	}
+	apiErr := &APIError{Message: strings.TrimSpace(string(body))}
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error != nil {
+		apiErr = envelope.Error
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	apiErr.StatusCode = resp.StatusCode
+	apiErr.RequestID = resp.Header.Get("X-Request-ID")
+	return apiErr
+}
+
+// httpClient returns the configured HTTP client or http.DefaultClient
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
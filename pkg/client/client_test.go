@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CreateChatCompletion(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "gemini", r.URL.Query().Get("vendor"))
+		assert.Equal(t, "Bearer sk-router", r.Header.Get("Authorization"))
+		assert.Equal(t, "req-123", r.Header.Get("X-Request-ID"))
+		assert.Equal(t, "billing-service", r.Header.Get("User-Agent"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		w.Header().Set("X-Request-ID", "req-123")
+		w.Write([]byte(`{
+			"id": "chatcmpl-1", "object": "chat.completion", "created": 1760000000, "model": "gpt-4o",
+			"choices": [{"index": 0, "message": {"role": "assistant", "content": "pong"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 5, "completion_tokens": 1, "total_tokens": 6},
+			"extensions": {"route": {"vendor": "gemini", "model": "gemini-2.5-flash"}, "attempts": 2}
+		}`))
+	}))
+	defer server.Close()
+
+	c := New(server.URL+"/", "sk-router")
+	c.Header.Set("User-Agent", "billing-service")
+	seed := int64(7)
+	completion, err := c.CreateChatCompletion(context.Background(), ChatCompletionRequest{
+		Model:     "gpt-4o",
+		Messages:  []Message{{Role: "user", Content: "ping"}},
+		Seed:      &seed,
+		Router:    &RouterOptions{ExcludeVendors: []string{"openai"}},
+		Extra:     map[string]interface{}{"reasoning_effort": "low", "model": "ignored"},
+		Vendor:    "gemini",
+		RequestID: "req-123",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "gpt-4o", received["model"], "Extra never overrides typed fields")
+	assert.Equal(t, "low", received["reasoning_effort"])
+	assert.Equal(t, map[string]interface{}{"exclude_vendors": []interface{}{"openai"}}, received["router"])
+	assert.Equal(t, float64(7), received["seed"])
+	assert.NotContains(t, received, "stream")
+	assert.NotContains(t, received, "vendor")
+
+	assert.Equal(t, "pong", completion.Choices[0].Message.Content)
+	assert.Equal(t, "req-123", completion.RequestID)
+	require.NotNil(t, completion.Extensions)
+	assert.Equal(t, &Route{Vendor: "gemini", Model: "gemini-2.5-flash"}, completion.Extensions.Route)
+	assert.Equal(t, 2, *completion.Extensions.Attempts)
+	assert.Nil(t, completion.Extensions.Reproducibility)
+}
+
+func TestClient_APIError(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected APIError
+	}{
+		{
+			name:     "OpenAI-style error",
+			body:     `{"error": {"type": "invalid_request_error", "message": "routing exclusions leave no vendor or model to route to", "code": "all_routes_excluded"}}`,
+			expected: APIError{StatusCode: 400, Type: "invalid_request_error", Message: "routing exclusions leave no vendor or model to route to", Code: "all_routes_excluded", RequestID: "req-9"},
+		},
+		{
+			name:     "plain text body",
+			body:     "bad gateway\n",
+			expected: APIError{StatusCode: 400, Message: "bad gateway", RequestID: "req-9"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Request-ID", "req-9")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := New(server.URL, "").CreateChatCompletion(context.Background(), ChatCompletionRequest{Model: "gpt-4o"})
+			var apiErr *APIError
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tt.expected, *apiErr)
+		})
+	}
+}
+
+func TestClient_CreateChatCompletionStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, true, request["stream"])
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("X-Request-ID", "req-stream")
+		w.Write([]byte(strings.Join([]string{
+			`: keep-alive`,
+			`data: {"id":"chatcmpl-2","object":"chat.completion.chunk","created":1760000000,"model":"deepseek-reasoner","choices":[{"index":0,"delta":{"role":"assistant","reasoning_content":"Compare"},"finish_reason":null}]}`,
+			``,
+			`data: {"id":"chatcmpl-2","object":"chat.completion.chunk","created":1760000000,"model":"deepseek-reasoner","choices":[{"index":0,"delta":{"content":"9.8"},"finish_reason":null}]}`,
+			``,
+			`data: {"id":"chatcmpl-2","object":"chat.completion.chunk","created":1760000000,"model":"deepseek-reasoner","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"compare","arguments":"{\"a\":"}}]},"finish_reason":null}]}`,
+			``,
+			`data: {"id":"chatcmpl-2","object":"chat.completion.chunk","created":1760000000,"model":"deepseek-reasoner","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"9.8}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":3,"completion_tokens":4,"total_tokens":7}}`,
+			``,
+			`data: [DONE]`,
+			``,
+		}, "\n")))
+	}))
+	defer server.Close()
+
+	stream, err := New(server.URL, "sk-router").CreateChatCompletionStream(context.Background(), ChatCompletionRequest{Model: "deepseek-reasoner"})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	assert.Equal(t, "req-stream", stream.RequestID)
+	first, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "Compare", first.Choices[0].Delta.ReasoningContent)
+	assert.Nil(t, first.Choices[0].FinishReason)
+
+	completion, err := stream.Collect()
+	require.NoError(t, err)
+	message := completion.Choices[0].Message
+	assert.Equal(t, "9.8", message.Content, "Collect assembles the chunks not yet received")
+	assert.Equal(t, []ToolCall{{ID: "call_1", Type: "function", Function: FunctionCall{Name: "compare", Arguments: `{"a":9.8}`}}}, message.ToolCalls)
+	assert.Equal(t, "tool_calls", completion.Choices[0].FinishReason)
+	assert.Equal(t, 7, completion.Usage.TotalTokens)
+	assert.Equal(t, "req-stream", completion.RequestID)
+
+	_, err = stream.Recv()
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "nothing follows [DONE]")
+}
+
+func TestStream_MidStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: {\"error\":{\"type\":\"server_error\",\"message\":\"upstream stream stalled\"}}\n\n"))
+	}))
+	defer server.Close()
+
+	stream, err := New(server.URL, "").CreateChatCompletionStream(context.Background(), ChatCompletionRequest{Model: "gpt-4o"})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "upstream stream stalled", apiErr.Message)
+}
+
+func TestClient_ListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/models", r.URL.Path)
+		assert.Equal(t, "capability=vision%2Ctools&min_context=128000&supports_streaming=true&vendor=openai", r.URL.RawQuery)
+		w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o","object":"model","created":1760000000,"owned_by":"openai"}]}`))
+	}))
+	defer server.Close()
+
+	streaming := true
+	models, err := New(server.URL, "").ListModels(context.Background(), ModelsFilter{
+		Vendor:            "openai",
+		Capabilities:      []string{"vision", "tools"},
+		SupportsStreaming: &streaming,
+		MinContext:        128000,
+	})
+	require.NoError(t, err)
+	require.Len(t, models.Data, 1)
+	assert.Equal(t, "openai", models.Data[0].OwnedBy)
+}
+
+func TestClient_RoutingDecisions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/admin/routing/decisions", r.URL.Path)
+		assert.Equal(t, "limit=1&request_id=req-123", r.URL.RawQuery)
+		w.Write([]byte(`{"object":"list","total":40,"returned":1,"data":[{"request_id":"req-123","timestamp":"2026-10-16T09:12:44Z","original_model":"gpt-4o","vendor":"gemini","model":"gemini-2.5-flash","candidate_count":3,"attempts":1,"outcome":"success"}]}`))
+	}))
+	defer server.Close()
+
+	decisions, err := New(server.URL, "").RoutingDecisions(context.Background(), DecisionsFilter{RequestID: "req-123", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, decisions.Data, 1)
+	assert.Equal(t, "gemini", decisions.Data[0].Vendor)
+	assert.Equal(t, "success", decisions.Data[0].Outcome)
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxEventSize bounds a single server-sent event line
+const maxEventSize = 4 << 20
+
+// Stream reads the chunks of a streaming chat completion
+type Stream struct {
+	// RequestID is the X-Request-ID the router assigned to the request
+	RequestID string
+
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+}
+
+// newStream wraps a successful streaming response
+func newStream(resp *http.Response) *Stream {
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+	return &Stream{
+		RequestID: resp.Header.Get("X-Request-ID"),
+		body:      resp.Body,
+		scanner:   scanner,
+	}
+}
+
+// Recv returns the next chunk, or io.EOF once the router sends [DONE]
+// Errors the router reports mid-stream are returned as an *APIError
+func (s *Stream) Recv() (*ChatCompletionChunk, error) {
+	for s.scanner.Scan() {
+		line := bytes.TrimSpace(s.scanner.Bytes())
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue // blank separators, comments and keep-alives
+		}
+		data := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+		if string(data) == "[DONE]" {
+			return nil, io.EOF
+		}
+
+		var event struct {
+			ChatCompletionChunk
+			Error *APIError `json:"error"`
+		}
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if event.Error != nil {
+			event.Error.RequestID = s.RequestID
+			return nil, event.Error
+		}
+		chunk := event.ChatCompletionChunk
+		return &chunk, nil
+	}
+	if err := s.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+	return nil, io.ErrUnexpectedEOF
+}
+
+// Close releases the stream's connection
+func (s *Stream) Close() error {
+	return s.body.Close()
+}
+
+// Collect reads the rest of the stream and assembles it into a single response
+// with each choice's content, reasoning and tool calls concatenated
+func (s *Stream) Collect() (*ChatCompletionResponse, error) {
+	response := &ChatCompletionResponse{Object: "chat.completion", RequestID: s.RequestID}
+	var content, reasoning []strings.Builder
+
+	for {
+		chunk, err := s.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		response.ID, response.Created, response.Model = chunk.ID, chunk.Created, chunk.Model
+		if chunk.SystemFingerprint != "" {
+			response.SystemFingerprint = chunk.SystemFingerprint
+		}
+		if chunk.Usage != nil {
+			response.Usage = chunk.Usage
+		}
+		if chunk.Extensions != nil {
+			response.Extensions = chunk.Extensions
+		}
+
+		for _, delta := range chunk.Choices {
+			for len(response.Choices) <= delta.Index {
+				response.Choices = append(response.Choices, Choice{Index: len(response.Choices), Message: Message{Role: "assistant"}})
+				content = append(content, strings.Builder{})
+				reasoning = append(reasoning, strings.Builder{})
+			}
+			choice := &response.Choices[delta.Index]
+			if text, ok := delta.Delta.Content.(string); ok {
+				content[delta.Index].WriteString(text)
+			}
+			reasoning[delta.Index].WriteString(delta.Delta.ReasoningContent)
+			choice.Message.ToolCalls = mergeToolCalls(choice.Message.ToolCalls, delta.Delta.ToolCalls)
+			if delta.FinishReason != nil {
+				choice.FinishReason = *delta.FinishReason
+			}
+		}
+	}
+
+	for i := range response.Choices {
+		response.Choices[i].Message.Content = content[i].String()
+		response.Choices[i].Message.ReasoningContent = reasoning[i].String()
+	}
+	return response, nil
+}
+
+// mergeToolCalls appends streamed tool call fragments to the calls assembled so far,
+// matching fragments to calls by index and concatenating their arguments
+func mergeToolCalls(calls []ToolCall, fragments []ToolCall) []ToolCall {
+	for _, fragment := range fragments {
+		index := len(calls)
+		if fragment.Index != nil {
+			index = *fragment.Index
+		}
+		for len(calls) <= index {
+			calls = append(calls, ToolCall{})
+		}
+		call := &calls[index]
+		if fragment.ID != "" {
+			call.ID = fragment.ID
+		}
+		if fragment.Type != "" {
+			call.Type = fragment.Type
+		}
+		if fragment.Function.Name != "" {
+			call.Function.Name = fragment.Function.Name
+		}
+		call.Function.Arguments += fragment.Function.Arguments
+	}
+	for i := range calls {
+		calls[i].Index = nil
+	}
+	return calls
+}
@@ -0,0 +1,216 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ChatCompletionRequest is an OpenAI chat completion request plus the router's extensions
+// Fields not covered here can be sent through Extra, which is merged into the request body
+type ChatCompletionRequest struct {
+	Model          string                 `json:"model"`
+	Messages       []Message              `json:"messages"`
+	Stream         bool                   `json:"stream,omitempty"`
+	MaxTokens      int                    `json:"max_tokens,omitempty"`
+	Temperature    *float64               `json:"temperature,omitempty"`
+	TopP           *float64               `json:"top_p,omitempty"`
+	Stop           []string               `json:"stop,omitempty"`
+	Seed           *int64                 `json:"seed,omitempty"`
+	User           string                 `json:"user,omitempty"`
+	Tools          []Tool                 `json:"tools,omitempty"`
+	ToolChoice     interface{}            `json:"tool_choice,omitempty"`
+	ResponseFormat map[string]interface{} `json:"response_format,omitempty"`
+	Router         *RouterOptions         `json:"router,omitempty"`
+	Extra          map[string]interface{} `json:"-"`
+
+	// Vendor pins the request to one vendor, sent as the vendor query parameter
+	Vendor string `json:"-"`
+	// RequestID is sent as X-Request-ID so the routing decision can be looked up later
+	RequestID string `json:"-"`
+}
+
+// RouterOptions restrict the routing pool of a single request
+// Models are given as "model" (any vendor) or "vendor/model"
+type RouterOptions struct {
+	ExcludeVendors []string `json:"exclude_vendors,omitempty"`
+	ExcludeModels  []string `json:"exclude_models,omitempty"`
+}
+
+// Message is a chat message; Content is a string or an array of content parts
+type Message struct {
+	Role             string      `json:"role"`
+	Content          interface{} `json:"content"`
+	Name             string      `json:"name,omitempty"`
+	ToolCalls        []ToolCall  `json:"tool_calls,omitempty"`
+	ToolCallID       string      `json:"tool_call_id,omitempty"`
+	ReasoningContent string      `json:"reasoning_content,omitempty"`
+}
+
+// Tool is a tool the model may call
+type Tool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition describes a callable function
+type FunctionDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// ToolCall is a call the model made to a tool
+type ToolCall struct {
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall holds the name and JSON-encoded arguments of a tool call
+type FunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// ChatCompletionResponse is a non-streaming chat completion
+type ChatCompletionResponse struct {
+	ID                string      `json:"id"`
+	Object            string      `json:"object"`
+	Created           int64       `json:"created"`
+	Model             string      `json:"model"`
+	SystemFingerprint string      `json:"system_fingerprint,omitempty"`
+	Choices           []Choice    `json:"choices"`
+	Usage             *Usage      `json:"usage,omitempty"`
+	Extensions        *Extensions `json:"extensions,omitempty"`
+
+	// RequestID is the X-Request-ID the router assigned to the request
+	RequestID string `json:"-"`
+}
+
+// Choice is one completion choice
+type Choice struct {
+	Index        int     `json:"index"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+// ChatCompletionChunk is one streamed chunk of a chat completion
+type ChatCompletionChunk struct {
+	ID                string        `json:"id"`
+	Object            string        `json:"object"`
+	Created           int64         `json:"created"`
+	Model             string        `json:"model"`
+	SystemFingerprint string        `json:"system_fingerprint,omitempty"`
+	Choices           []ChunkChoice `json:"choices"`
+	Usage             *Usage        `json:"usage,omitempty"`
+	Extensions        *Extensions   `json:"extensions,omitempty"`
+}
+
+// ChunkChoice is the delta of one choice in a streamed chunk
+type ChunkChoice struct {
+	Index        int     `json:"index"`
+	Delta        Message `json:"delta"`
+	FinishReason *string `json:"finish_reason"`
+}
+
+// Usage reports token counts
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Extensions are the router-specific fields a deployment or client key opted into
+// via RESPONSE_EXTENSIONS or the client ACL; each is nil when not emitted
+type Extensions struct {
+	Route           *Route           `json:"route,omitempty"`
+	Attempts        *int             `json:"attempts,omitempty"`
+	Reproducibility *Reproducibility `json:"reproducibility,omitempty"`
+}
+
+// Route names the vendor and model that served a request
+type Route struct {
+	Vendor string `json:"vendor"`
+	Model  string `json:"model"`
+}
+
+// Reproducibility describes how a seeded request was served
+type Reproducibility struct {
+	Seed              int64  `json:"seed"`
+	Vendor            string `json:"vendor"`
+	Model             string `json:"model"`
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+}
+
+// ModelsFilter narrows down the models listed; zero values do not filter
+type ModelsFilter struct {
+	Vendor            string
+	Capabilities      []string // vision, video, tools or streaming
+	SupportsTools     *bool
+	SupportsStreaming *bool
+	MinContext        int
+}
+
+// ModelsResponse lists the models the router can route to
+type ModelsResponse struct {
+	Object string  `json:"object"`
+	Data   []Model `json:"data"`
+}
+
+// Model is a routable model; OwnedBy is its vendor
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// HealthResponse is the router's health report
+type HealthResponse struct {
+	Status    string                     `json:"status"`
+	Timestamp string                     `json:"timestamp"`
+	Services  map[string]string          `json:"services"`
+	Details   map[string]json.RawMessage `json:"details,omitempty"`
+}
+
+// DecisionsFilter narrows down the routing decisions returned; zero values do not filter
+type DecisionsFilter struct {
+	RequestID string
+	ClientKey string
+	Vendor    string
+	Model     string
+	Outcome   string
+	Since     time.Time
+	Limit     int
+}
+
+// RoutingDecisionsResponse lists recent routing decisions, newest first
+type RoutingDecisionsResponse struct {
+	Object   string            `json:"object"`
+	Total    int               `json:"total"`
+	Returned int               `json:"returned"`
+	Data     []RoutingDecision `json:"data"`
+}
+
+// RoutingDecision records why a request was routed to a vendor/model
+type RoutingDecision struct {
+	RequestID         string          `json:"request_id"`
+	ClientKey         string          `json:"client_key,omitempty"`
+	Timestamp         time.Time       `json:"timestamp"`
+	OriginalModel     string          `json:"original_model"`
+	Vendor            string          `json:"vendor"`
+	Model             string          `json:"model"`
+	VendorFilter      string          `json:"vendor_filter,omitempty"`
+	ExcludedVendors   []string        `json:"excluded_vendors,omitempty"`
+	ExcludedModels    []string        `json:"excluded_models,omitempty"`
+	Filters           map[string]bool `json:"capability_filters,omitempty"`
+	CandidateCount    int             `json:"candidate_count"`
+	Attempts          int             `json:"attempts"`
+	FallbackVendor    string          `json:"fallback_vendor,omitempty"`
+	FallbackModel     string          `json:"fallback_model,omitempty"`
+	Outcome           string          `json:"outcome"`
+	Error             string          `json:"error,omitempty"`
+	Seed              *int64          `json:"seed,omitempty"`
+	SystemFingerprint string          `json:"system_fingerprint,omitempty"`
+}